@@ -0,0 +1,195 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package compress compresses the payloads sent by the flushers, and records the compression ratio and the
+// time cost as the self metrics to guide the tuning of the compression and its level.
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+)
+
+// The supported compressions.
+const (
+	None   = "none"
+	Gzip   = "gzip"
+	Snappy = "snappy"
+	Zstd   = "zstd"
+	LZ4    = "lz4"
+)
+
+// The headers describing the compressed payloads. The lz4 block has no standard content encoding, so it is
+// described by the compress type and the raw size headers like SLS, which are also accepted by service_http_server.
+const (
+	HeaderContentEncoding = "Content-Encoding"
+	HeaderCompressType    = "x-log-compresstype"
+	HeaderBodyRawSize     = "x-log-bodyrawsize"
+)
+
+// Compressor compresses the payloads with the configured compression, which is safe for concurrent use.
+type Compressor struct {
+	compression string
+	level       int
+	zstdEncoder *zstd.Encoder
+
+	rawBytesMetric        pipeline.CounterMetric
+	compressedBytesMetric pipeline.CounterMetric
+	ratioMetric           pipeline.CounterMetric
+	costMetric            pipeline.CounterMetric
+}
+
+// NewCompressor returns the compressor of the compression, or nil if the compression is none or empty.
+// The level is the compression level of gzip (1-9) or zstd (1-22), and 0 means the default level. The
+// zstdDictionaryFile is the zstd dictionary trained by `zstd --train`, which is only used by zstd.
+func NewCompressor(compression string, level int, zstdDictionaryFile string) (*Compressor, error) {
+	c := &Compressor{compression: strings.ToLower(strings.TrimSpace(compression)), level: level}
+	switch c.compression {
+	case "", None:
+		return nil, nil
+	case Gzip:
+		if level == 0 {
+			c.level = gzip.DefaultCompression
+		} else if level < gzip.BestSpeed || level > gzip.BestCompression {
+			return nil, fmt.Errorf("invalid gzip compression level %d", level)
+		}
+	case Zstd:
+		options := []zstd.EOption{zstd.WithEncoderConcurrency(1)}
+		if level != 0 {
+			options = append(options, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+		if zstdDictionaryFile != "" {
+			dict, err := os.ReadFile(zstdDictionaryFile)
+			if err != nil {
+				return nil, err
+			}
+			options = append(options, zstd.WithEncoderDict(dict))
+		}
+		encoder, err := zstd.NewWriter(nil, options...)
+		if err != nil {
+			return nil, fmt.Errorf("invalid zstd options: %v", err)
+		}
+		c.zstdEncoder = encoder
+	case Snappy, LZ4:
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", compression)
+	}
+	return c, nil
+}
+
+// Compression returns the normalized compression.
+func (c *Compressor) Compression() string {
+	return c.compression
+}
+
+// RegisterMetrics registers the self metrics of the compressor, which are the raw and the compressed bytes,
+// the average percentage of the compressed size to the raw size, and the time cost in microseconds.
+func (c *Compressor) RegisterMetrics(context pipeline.Context) {
+	c.rawBytesMetric = helper.NewCounterMetricAndRegister("compress_raw_bytes", context)
+	c.compressedBytesMetric = helper.NewCounterMetricAndRegister("compress_compressed_bytes", context)
+	c.ratioMetric = helper.NewAverageMetricAndRegister("compress_ratio_percent", context)
+	c.costMetric = helper.NewCounterMetricAndRegister("compress_cost_us", context)
+}
+
+// Compress returns the compressed data, and data is not modified.
+func (c *Compressor) Compress(data []byte) ([]byte, error) {
+	start := time.Now()
+	var compressed []byte
+	switch c.compression {
+	case Gzip:
+		var buf bytes.Buffer
+		w, err := gzip.NewWriterLevel(&buf, c.level)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = w.Write(data); err != nil {
+			return nil, err
+		}
+		if err = w.Close(); err != nil {
+			return nil, err
+		}
+		compressed = buf.Bytes()
+	case Snappy:
+		compressed = snappy.Encode(nil, data)
+	case Zstd:
+		compressed = c.zstdEncoder.EncodeAll(data, make([]byte, 0, len(data)/2))
+	case LZ4:
+		compressed = make([]byte, lz4.CompressBlockBound(len(data)))
+		n, err := lz4.CompressBlock(data, compressed, nil)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			// the incompressible data is encoded as the literals only
+			compressed = appendLZ4Literals(compressed[:0], data)
+		} else {
+			compressed = compressed[:n]
+		}
+	default:
+		return data, nil
+	}
+
+	if c.rawBytesMetric != nil {
+		c.rawBytesMetric.Add(int64(len(data)))
+		c.compressedBytesMetric.Add(int64(len(compressed)))
+		if len(data) > 0 {
+			c.ratioMetric.Add(int64(len(compressed) * 100 / len(data)))
+		}
+		c.costMetric.Add(time.Since(start).Microseconds())
+	}
+	return compressed, nil
+}
+
+// Headers returns the headers describing the compressed payload of rawSize bytes.
+func (c *Compressor) Headers(rawSize int) map[string]string {
+	if c.compression == LZ4 {
+		return map[string]string{HeaderCompressType: LZ4, HeaderBodyRawSize: strconv.Itoa(rawSize)}
+	}
+	return map[string]string{HeaderContentEncoding: c.compression}
+}
+
+// Close releases the resources of the compressor.
+func (c *Compressor) Close() error {
+	if c.zstdEncoder != nil {
+		return c.zstdEncoder.Close()
+	}
+	return nil
+}
+
+// appendLZ4Literals appends the lz4 block holding data as the literals of the only sequence.
+func appendLZ4Literals(dst, data []byte) []byte {
+	n := len(data)
+	if n < 15 {
+		dst = append(dst, byte(n<<4))
+	} else {
+		dst = append(dst, 0xF0)
+		for n -= 15; n >= 255; n -= 255 {
+			dst = append(dst, 255)
+		}
+		dst = append(dst, byte(n))
+	}
+	return append(dst, data...)
+}
@@ -0,0 +1,121 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"io"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+var payload = bytes.Repeat([]byte(`{"time":1434055562,"contents":{"content":"hello world"},"tags":{"host.ip":"192.168.0.1"}}`+"\n"), 100)
+
+func TestNewCompressor(t *testing.T) {
+	for _, compression := range []string{"", "none", " None "} {
+		c, err := NewCompressor(compression, 0, "")
+		require.NoError(t, err)
+		assert.Nil(t, c)
+	}
+	for _, args := range []struct {
+		compression string
+		level       int
+		dict        string
+	}{{"brotli", 0, ""}, {Gzip, 10, ""}, {Zstd, 0, "not_existed.dict"}, {Zstd, 0, "compress.go"}} {
+		_, err := NewCompressor(args.compression, args.level, args.dict)
+		assert.Error(t, err, args.compression)
+	}
+}
+
+func TestCompressor_Compress(t *testing.T) {
+	dict, err := os.ReadFile("testdata/zstd.dict")
+	require.NoError(t, err)
+	decompress := map[string]func(data []byte) ([]byte, error){
+		Gzip: func(data []byte) ([]byte, error) {
+			r, err := gzip.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			return io.ReadAll(r)
+		},
+		Snappy: func(data []byte) ([]byte, error) { return snappy.Decode(nil, data) },
+		Zstd: func(data []byte) ([]byte, error) {
+			d, err := zstd.NewReader(nil, zstd.WithDecoderDicts(dict))
+			if err != nil {
+				return nil, err
+			}
+			defer d.Close()
+			return d.DecodeAll(data, nil)
+		},
+		LZ4: func(data []byte) ([]byte, error) {
+			raw := make([]byte, len(payload))
+			n, err := lz4.UncompressBlock(data, raw)
+			return raw[:n], err
+		},
+	}
+
+	for compression, fn := range decompress {
+		c, err := NewCompressor(compression, 0, "testdata/zstd.dict")
+		require.NoError(t, err)
+		c.RegisterMetrics(mock.NewEmptyContext("p", "l", "c"))
+		compressed, err := c.Compress(payload)
+		require.NoError(t, err, compression)
+		assert.Less(t, len(compressed), len(payload), compression)
+		raw, err := fn(compressed)
+		require.NoError(t, err, compression)
+		assert.Equal(t, payload, raw, compression)
+
+		assert.Equal(t, int64(len(payload)), c.rawBytesMetric.Get())
+		assert.Equal(t, int64(len(compressed)), c.compressedBytesMetric.Get())
+		assert.Equal(t, int64(len(compressed)*100/len(payload)), c.ratioMetric.Get())
+		require.NoError(t, c.Close())
+	}
+}
+
+func TestCompressor_CompressIncompressibleLZ4(t *testing.T) {
+	c, err := NewCompressor(LZ4, 0, "")
+	require.NoError(t, err)
+	for _, size := range []int{10, 300} {
+		data := make([]byte, size)
+		_, _ = rand.Read(data)
+		compressed, err := c.Compress(data)
+		require.NoError(t, err)
+		raw := make([]byte, size)
+		n, err := lz4.UncompressBlock(compressed, raw)
+		require.NoError(t, err)
+		assert.Equal(t, data, raw[:n])
+	}
+}
+
+func TestCompressor_Headers(t *testing.T) {
+	c, err := NewCompressor(LZ4, 0, "")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{HeaderCompressType: LZ4, HeaderBodyRawSize: strconv.Itoa(100)}, c.Headers(100))
+	c, err = NewCompressor("ZSTD", 3, "")
+	require.NoError(t, err)
+	assert.Equal(t, Zstd, c.Compression())
+	assert.Equal(t, map[string]string{HeaderContentEncoding: Zstd}, c.Headers(100))
+}
@@ -0,0 +1,88 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package connector links the pipelines in the same agent: flusher_connector pushes the LogGroups of one
+// pipeline into a named bounded queue, which is consumed by service_connector as the input of other pipelines.
+package connector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+// DefaultQueueSize is the capacity of a connector in LogGroups.
+const DefaultQueueSize = 1000
+
+// Connector is a named bounded queue of LogGroups. The connectors are never removed, so the LogGroups in the
+// queue are kept when the configs on either side are reloaded.
+type Connector struct {
+	Name  string
+	queue chan *protocol.LogGroup
+}
+
+var (
+	connectorsLock sync.Mutex
+	connectors     = make(map[string]*Connector)
+)
+
+// Get returns the connector named @name, which is created with the capacity of @queueSize LogGroups if it does
+// not exist yet. The capacity is decided by the side created first.
+func Get(name string, queueSize int) *Connector {
+	connectorsLock.Lock()
+	defer connectorsLock.Unlock()
+	if c, ok := connectors[name]; ok {
+		return c
+	}
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+	c := &Connector{Name: name, queue: make(chan *protocol.LogGroup, queueSize)}
+	connectors[name] = c
+	return c
+}
+
+// Full returns true if the queue has no room for more LogGroups.
+func (c *Connector) Full() bool {
+	return len(c.queue) >= cap(c.queue)
+}
+
+// Len returns the number of the LogGroups waiting to be consumed.
+func (c *Connector) Len() int {
+	return len(c.queue)
+}
+
+// Push adds the LogGroup to the queue, waiting at most @timeout for the room. It returns false on timeout.
+func (c *Connector) Push(logGroup *protocol.LogGroup, timeout time.Duration) bool {
+	select {
+	case c.queue <- logGroup:
+		return true
+	default:
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case c.queue <- logGroup:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// Queue returns the channel the LogGroups are consumed from. The inputs sharing a connector compete for the
+// LogGroups, each of which is consumed only once.
+func (c *Connector) Queue() <-chan *protocol.LogGroup {
+	return c.queue
+}
@@ -153,7 +153,7 @@ func (c *ContainerDiscoverManager) Clean() {
 
 func (c *ContainerDiscoverManager) LogAlarm(err error, msg string) {
 	if err != nil {
-		logger.Warning(context.Background(), "DOCKER_CENTER_ALARM", "message", msg, "error found", err)
+		logger.Warning(context.Background(), util.AlarmDockerCenter, "message", msg, "error found", err)
 	} else {
 		logger.Debug(context.Background(), "message", msg)
 	}
@@ -229,7 +229,7 @@ func (c *ContainerDiscoverManager) Init(initTryTimes int) {
 		}
 		if err != nil {
 			c.enableDockerDiscover = false
-			logger.Errorf(context.Background(), "DOCKER_CENTER_ALARM", "fetch docker containers error in %d times, close docker discover", initTryTimes)
+			logger.Errorf(context.Background(), util.AlarmDockerCenter, "fetch docker containers error in %d times, close docker discover", initTryTimes)
 		}
 	}
 	if c.enableCRIDiscover {
@@ -240,7 +240,7 @@ func (c *ContainerDiscoverManager) Init(initTryTimes int) {
 		}
 		if err != nil {
 			c.enableCRIDiscover = false
-			logger.Errorf(context.Background(), "DOCKER_CENTER_ALARM", "fetch cri containers error in %d times, close cri discover", initTryTimes)
+			logger.Errorf(context.Background(), util.AlarmDockerCenter, "fetch cri containers error in %d times, close cri discover", initTryTimes)
 		}
 	}
 	if c.enableStaticDiscover {
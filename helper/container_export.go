@@ -37,6 +37,10 @@ type ContainerMeta struct {
 	Env             map[string]string
 }
 
+// containerIDRegex matches the container id in the cgroup path, such as `/docker/<id>`,
+// `/system.slice/docker-<id>.scope` and `/kubepods/burstable/pod<uid>/cri-containerd-<id>.scope`.
+var containerIDRegex = regexp.MustCompile(`[0-9a-f]{64}`)
+
 type DockerInfoDetailWithFilteredEnvAndLabel struct {
 	Detail *DockerInfoDetail
 	Env    map[string]string
@@ -100,6 +104,16 @@ func GetContainerMeta(containerID string) *ContainerMeta {
 	return getFunc()
 }
 
+// ContainerIDFromCgroup returns the last container id in the cgroup path, or empty when the path
+// does not belong to a container.
+func ContainerIDFromCgroup(cgroup string) string {
+	ids := containerIDRegex.FindAllString(cgroup, -1)
+	if len(ids) == 0 {
+		return ""
+	}
+	return ids[len(ids)-1]
+}
+
 func ProcessContainerAllInfo(processor func(*DockerInfoDetail)) {
 	getDockerCenterInstance().processAllContainerInfo(processor)
 }
@@ -2,6 +2,7 @@ package helper
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/docker/docker/api/types"
@@ -90,3 +91,22 @@ func mockDockerInfoDetail(containerName string, envList []string) *DockerInfoDet
 	dockerInfo.Config.Env = envList
 	return CreateContainerInfoDetail(dockerInfo, *flags.LogConfigPrefix, false)
 }
+
+func TestContainerIDFromCgroup(t *testing.T) {
+	id := strings.Repeat("0123456789abcdef", 4)
+	tests := []struct {
+		cgroup string
+		want   string
+	}{
+		{"/docker/" + id, id},
+		{"/system.slice/docker-" + id + ".scope", id},
+		{"/kubepods/burstable/pod7a3c9f1e-5b2d-4c8a-9e6f-1d2b3c4d5e6f/cri-containerd-" + id + ".scope", id},
+		{"/user.slice/user-1000.slice/session-1.scope", ""},
+		{"/", ""},
+	}
+	for _, tt := range tests {
+		if got := ContainerIDFromCgroup(tt.cgroup); got != tt.want {
+			t.Errorf("ContainerIDFromCgroup(%s) = %s, want %s", tt.cgroup, got, tt.want)
+		}
+	}
+}
@@ -14,6 +14,19 @@
 
 package helper
 
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	converter "github.com/alibaba/ilogtail/pkg/protocol/converter"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
 type ConvertConfig struct {
 	TagFieldsRename      map[string]string // Rename one or more fields from tags.
 	ProtocolFieldsRename map[string]string // Rename one or more fields, The protocol field options can only be: contents, tags, time
@@ -21,4 +34,177 @@ type ConvertConfig struct {
 	Protocol             string            // Convert protocol
 	Encoding             string            // Convert encoding
 	IgnoreUnExpectedData bool              // IgnoreUnExpectedData will skip on unexpected data if set to true, or will return error and stop processing the whole batch data if set to false
+	JSONSchemaFile       string            // The JSON Schema or OpenAPI document to validate the output records, no validation if empty
+	JSONSchemaPointer    string            // The JSON pointer of the schema in JSONSchemaFile, such as /components/schemas/Log, the whole document if empty
+	RejectFile           string            // The file to append the records violating the schema as JSON lines, the records are dropped with an alarm if empty
+	RejectFileMaxSize    int64             // The max bytes of RejectFile, which is rotated to RejectFile.1 replacing the previous one when exceeded, 100MB by default
+	Template             string            // The Go text/template rendering the records of the template encoding
+	TemplateBatch        bool              // Render the logs of a batch as one record with the template instead of one record per log
+	FieldMapping         map[string]string // Map the CEF or LEEF keys to the log fields for the cef and leef protocols
+	IncludeFields        []string          // The fields serialized, such as content.msg and tag.host.name, a trailing * matches the prefix, all fields if empty
+	ExcludeFields        []string          // The fields not serialized, which takes precedence over IncludeFields
+	FieldsRename         map[string]string // Rename the serialized fields, such as {"content.msg": "message"}
+}
+
+// InitFieldSelection sets the field selection to the converter if configured.
+func (c *ConvertConfig) InitFieldSelection(conv *converter.Converter) error {
+	if len(c.IncludeFields) == 0 && len(c.ExcludeFields) == 0 && len(c.FieldsRename) == 0 {
+		return nil
+	}
+	return conv.SetFieldSelection(&converter.FieldSelection{
+		Include: c.IncludeFields,
+		Exclude: c.ExcludeFields,
+		Rename:  c.FieldsRename,
+	})
+}
+
+// InitFieldMapping sets the FieldMapping to the converter if configured.
+func (c *ConvertConfig) InitFieldMapping(conv *converter.Converter) error {
+	if len(c.FieldMapping) == 0 {
+		return nil
+	}
+	return conv.SetFieldMapping(c.FieldMapping)
+}
+
+// InitTemplate sets the Template to the converter if the template encoding is used.
+func (c *ConvertConfig) InitTemplate(conv *converter.Converter) error {
+	if c.Encoding != converter.EncodingTemplate {
+		return nil
+	}
+	return conv.SetTemplate(c.Template, c.TemplateBatch)
+}
+
+// defaultRejectFileMaxSize is the max bytes of RejectFile before it's rotated.
+const defaultRejectFileMaxSize = 100 * 1024 * 1024
+
+// rejectRecord is a line of the RejectFile.
+type rejectRecord struct {
+	Time   string `json:"time"`
+	Error  string `json:"error"`
+	Record string `json:"record"`
+}
+
+// LoadJSONSchema compiles the JSON Schema or the OpenAPI document in file, the pointer is the JSON pointer of
+// the schema in the document, such as /components/schemas/Log, the whole document if empty.
+func LoadJSONSchema(file, pointer string) (*converter.JSONSchema, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return converter.NewJSONSchema(data, pointer)
+}
+
+// InitJSONSchema makes the converter validate the output records against JSONSchemaFile, the violating records
+// are routed to RejectFile. It returns the closer of RejectFile if opened, which should be closed when the flusher stops.
+func (c *ConvertConfig) InitJSONSchema(ctx context.Context, conv *converter.Converter) (io.Closer, error) {
+	if c.JSONSchemaFile == "" {
+		return nil, nil
+	}
+	schema, err := LoadJSONSchema(c.JSONSchemaFile, c.JSONSchemaPointer)
+	if err != nil {
+		return nil, err
+	}
+
+	var file *rejectFile
+	if c.RejectFile != "" {
+		maxSize := c.RejectFileMaxSize
+		if maxSize <= 0 {
+			maxSize = defaultRejectFileMaxSize
+		}
+		if file, err = openRejectFile(c.RejectFile, maxSize); err != nil {
+			return nil, err
+		}
+	}
+	handler := func(record []byte, err error) {
+		if file == nil {
+			logger.Warning(ctx, util.AlarmConverterSchema, "drop the record violating the json schema, error", err)
+			return
+		}
+		line, _ := json.Marshal(&rejectRecord{Time: time.Now().Format(time.RFC3339), Error: err.Error(), Record: string(record)})
+		line = append(line, '\n')
+		if _, werr := file.Write(line); werr != nil {
+			logger.Warning(ctx, util.AlarmConverterSchema, "write the reject file error", werr, "validation error", err)
+		}
+	}
+	if err = conv.SetJSONSchema(schema, handler); err != nil {
+		if file != nil {
+			_ = file.Close()
+		}
+		return nil, err
+	}
+	if file == nil {
+		return nil, nil
+	}
+	return file, nil
+}
+
+// rejectFile appends the lines to the file, and rotates it to the file with the suffix .1 when the size exceeds
+// maxSize, so that the rejected records take at most twice of maxSize.
+type rejectFile struct {
+	path    string
+	maxSize int64
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func openRejectFile(path string, maxSize int64) (*rejectFile, error) {
+	f := &rejectFile{path: path, maxSize: maxSize}
+	return f, f.open()
+}
+
+func (f *rejectFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+func (f *rejectFile) Write(line []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.size > 0 && f.size+int64(len(line)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(line)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate renames the full file, and the lines are appended to the original one if the rename fails.
+func (f *rejectFile) rotate() error {
+	err := f.file.Close()
+	f.file = nil
+	if err != nil {
+		return err
+	}
+	renameErr := os.Rename(f.path, f.path+".1")
+	if err = f.open(); err != nil {
+		return err
+	}
+	return renameErr
+}
+
+func (f *rejectFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
 }
@@ -0,0 +1,149 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	converter "github.com/alibaba/ilogtail/pkg/protocol/converter"
+)
+
+func TestConvertConfig_InitJSONSchema(t *testing.T) {
+	dir := t.TempDir()
+	config := ConvertConfig{
+		Protocol:          converter.ProtocolCustomSingle,
+		Encoding:          converter.EncodingJSONCompact,
+		JSONSchemaFile:    filepath.Join(dir, "openapi.json"),
+		JSONSchemaPointer: "/components/schemas/Log",
+		RejectFile:        filepath.Join(dir, "reject.log"),
+	}
+	document := `{"components":{"schemas":{"Log":{"type":"object","required":["status"]}}}}`
+	require.NoError(t, os.WriteFile(config.JSONSchemaFile, []byte(document), 0600))
+
+	c, err := converter.NewConverter(config.Protocol, config.Encoding, nil, nil)
+	require.NoError(t, err)
+	closer, err := config.InitJSONSchema(context.Background(), c)
+	require.NoError(t, err)
+	require.NotNil(t, closer)
+
+	stream, err := c.ToByteStream(&protocol.LogGroup{Logs: []*protocol.Log{
+		{Time: 1, Contents: []*protocol.Log_Content{{Key: "status", Value: "200"}}},
+		{Time: 2, Contents: []*protocol.Log_Content{{Key: "message", Value: "no status"}}},
+	}})
+	require.NoError(t, err)
+	assert.Len(t, stream.([][]byte), 1)
+	require.NoError(t, closer.Close())
+
+	data, err := os.ReadFile(config.RejectFile)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 1)
+	var record rejectRecord
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "/: missing properties: 'status'", record.Error)
+	assert.Contains(t, record.Record, `"message":"no status"`)
+
+	config.JSONSchemaFile = ""
+	closer, err = config.InitJSONSchema(context.Background(), c)
+	assert.NoError(t, err)
+	assert.Nil(t, closer)
+
+	c, err = converter.NewConverter(converter.ProtocolCustomSingle, converter.EncodingProtobuf, nil, nil)
+	require.NoError(t, err)
+	config.JSONSchemaFile = filepath.Join(dir, "openapi.json")
+	_, err = config.InitJSONSchema(context.Background(), c)
+	assert.Error(t, err)
+}
+
+func TestRejectFileRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reject.log")
+	require.NoError(t, os.WriteFile(path, []byte("old\n"), 0600))
+	file, err := openRejectFile(path, 8)
+	require.NoError(t, err)
+	// the existing size is counted, and the file is rotated before it exceeds the max size
+	_, err = file.Write([]byte("line1\n"))
+	require.NoError(t, err)
+	_, err = file.Write([]byte("line2\n"))
+	require.NoError(t, err)
+	_, err = file.Write([]byte("line3\n"))
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "line3\n", string(data))
+	data, err = os.ReadFile(path + ".1")
+	require.NoError(t, err)
+	assert.Equal(t, "line2\n", string(data))
+	_, err = file.Write([]byte("line4\n"))
+	assert.Error(t, err)
+}
+
+func TestConvertConfig_InitTemplate(t *testing.T) {
+	config := ConvertConfig{Protocol: converter.ProtocolCustomSingle, Encoding: converter.EncodingTemplate, Template: `{{index .Contents "msg"}}`}
+	c, err := converter.NewConverter(config.Protocol, config.Encoding, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, config.InitTemplate(c))
+	stream, err := c.ToByteStream(&protocol.LogGroup{Logs: []*protocol.Log{{Contents: []*protocol.Log_Content{{Key: "msg", Value: "hello"}}}}})
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("hello")}, stream)
+
+	config.Template = "{{"
+	assert.Error(t, config.InitTemplate(c))
+}
+
+func TestConvertConfig_InitFieldMapping(t *testing.T) {
+	config := ConvertConfig{Protocol: converter.ProtocolCEF, Encoding: converter.EncodingCustom, FieldMapping: map[string]string{"msg": "content", "dvc": ""}}
+	c, err := converter.NewConverter(config.Protocol, config.Encoding, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, config.InitFieldMapping(c))
+	stream, err := c.ToByteStream(&protocol.LogGroup{Logs: []*protocol.Log{{Time: 1, Contents: []*protocol.Log_Content{{Key: "content", Value: "hello"}}}}})
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("CEF:0|iLogtail|iLogtail||||Unknown|msg=hello rt=1000")}, stream)
+
+	c, err = converter.NewConverter(converter.ProtocolCustomSingle, converter.EncodingJSON, nil, nil)
+	require.NoError(t, err)
+	assert.Error(t, config.InitFieldMapping(c))
+}
+
+func TestConvertConfig_InitFieldSelection(t *testing.T) {
+	config := ConvertConfig{
+		Protocol:      converter.ProtocolCustomSingle,
+		Encoding:      converter.EncodingJSON,
+		IncludeFields: []string{"content.*"},
+		ExcludeFields: []string{"content.password"},
+		FieldsRename:  map[string]string{"content.msg": "message"},
+	}
+	c, err := converter.NewConverter(config.Protocol, config.Encoding, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, config.InitFieldSelection(c))
+	stream, err := c.ToByteStream(&protocol.LogGroup{Logs: []*protocol.Log{{Time: 1, Contents: []*protocol.Log_Content{
+		{Key: "msg", Value: "hello"}, {Key: "password", Value: "secret"},
+	}}}})
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(`{"contents":{"message":"hello"},"tags":{},"time":1}`)}, stream)
+
+	config.IncludeFields = []string{"msg"}
+	assert.Error(t, config.InitFieldSelection(c))
+}
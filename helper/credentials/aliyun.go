@@ -0,0 +1,218 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credentials
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	expirationTimeFormat = "2006-01-02T15:04:05Z"
+
+	ecsMetadataTokenHeader    = "X-aliyun-ecs-metadata-token"
+	ecsMetadataTokenTTLHeader = "X-aliyun-ecs-metadata-token-ttl-seconds"
+
+	roleArnEnv         = "ALIBABA_CLOUD_ROLE_ARN"
+	oidcProviderArnEnv = "ALIBABA_CLOUD_OIDC_PROVIDER_ARN"
+	oidcTokenFileEnv   = "ALIBABA_CLOUD_OIDC_TOKEN_FILE" //nolint:gosec
+	defaultSTSEndpoint = "sts.aliyuncs.com"
+)
+
+// ecsMetadataEndpoint is the instance metadata service of ECS, which is replaced in the tests.
+var ecsMetadataEndpoint = "http://100.100.100.200"
+
+func init() {
+	Providers[ProviderECSRAMRole] = newECSRAMRoleProvider
+	Providers[ProviderRRSA] = newRRSAProvider
+}
+
+// stsCredentials is the temporary credentials returned by the ECS metadata service and the STS API.
+type stsCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	AccessKeySecret string
+	SecurityToken   string
+	Expiration      string
+	Code            string
+}
+
+func (c *stsCredentials) toCredential() (*Credential, error) {
+	expiration, err := time.Parse(expirationTimeFormat, c.Expiration)
+	if err != nil {
+		return nil, fmt.Errorf("parse the expiration %q error: %v", c.Expiration, err)
+	}
+	return &Credential{
+		AccessKeyID:     c.AccessKeyID,
+		AccessKeySecret: c.AccessKeySecret,
+		SecurityToken:   c.SecurityToken,
+		Expiration:      expiration,
+	}, nil
+}
+
+type ecsRAMRoleProvider struct {
+	client   *http.Client
+	roleName string
+}
+
+// newECSRAMRoleProvider gets the temporary credentials of the RAM role attached to the ECS instance from the
+// instance metadata service, which are refreshed before the expiration.
+func newECSRAMRoleProvider(config *Config) (Provider, error) {
+	e := &ecsRAMRoleProvider{client: &http.Client{Timeout: 3 * time.Second}, roleName: config.RoleName}
+	p := newCachedProvider(ProviderECSRAMRole, 0, e.fetch)
+	if _, err := p.Retrieve(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (e *ecsRAMRoleProvider) fetch() (*Credential, error) {
+	// the token is required by the hardened mode of the metadata service, and is optional in the normal mode
+	token, _ := e.request(http.MethodPut, "/latest/api/token", map[string]string{ecsMetadataTokenTTLHeader: "60"})
+	headers := map[string]string{}
+	if token != "" {
+		headers[ecsMetadataTokenHeader] = token
+	}
+	roleName := e.roleName
+	if roleName == "" {
+		roles, err := e.request(http.MethodGet, "/latest/meta-data/ram/security-credentials/", headers)
+		if err != nil {
+			return nil, fmt.Errorf("get the ram roles of the instance error: %v", err)
+		}
+		roleName = strings.TrimSpace(strings.Split(strings.TrimSpace(roles), "\n")[0])
+		if roleName == "" {
+			return nil, errors.New("no ram role attached to the instance")
+		}
+	}
+	body, err := e.request(http.MethodGet, "/latest/meta-data/ram/security-credentials/"+roleName, headers)
+	if err != nil {
+		return nil, fmt.Errorf("get the credentials of ram role %s error: %v", roleName, err)
+	}
+	var result stsCredentials
+	if err = json.Unmarshal([]byte(body), &result); err != nil {
+		return nil, fmt.Errorf("parse the credentials of ram role %s error: %v", roleName, err)
+	}
+	if !strings.EqualFold(result.Code, "success") {
+		return nil, fmt.Errorf("get the credentials of ram role %s error, code %s", roleName, result.Code)
+	}
+	return result.toCredential()
+}
+
+func (e *ecsRAMRoleProvider) request(method, path string, headers map[string]string) (string, error) {
+	req, err := http.NewRequest(method, ecsMetadataEndpoint+path, nil)
+	if err != nil {
+		return "", err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return string(body), nil
+}
+
+type rrsaProvider struct {
+	client          *http.Client
+	endpoint        string
+	roleArn         string
+	oidcProviderArn string
+	oidcTokenFile   string
+}
+
+// newRRSAProvider assumes the RAM role with the OIDC token of the service account, which is the RAM Roles for
+// Service Accounts (RRSA) of ACK. The token file is reread on each refreshing since it is rotated by kubelet.
+func newRRSAProvider(config *Config) (Provider, error) {
+	r := &rrsaProvider{
+		client:          &http.Client{Timeout: 10 * time.Second},
+		endpoint:        config.STSEndpoint,
+		roleArn:         valueOrEnv(config.RoleArn, roleArnEnv),
+		oidcProviderArn: valueOrEnv(config.OIDCProviderArn, oidcProviderArnEnv),
+		oidcTokenFile:   valueOrEnv(config.OIDCTokenFile, oidcTokenFileEnv),
+	}
+	if r.roleArn == "" || r.oidcProviderArn == "" || r.oidcTokenFile == "" {
+		return nil, errors.New("RoleArn, OIDCProviderArn and OIDCTokenFile of the rrsa credential provider must be set")
+	}
+	if r.endpoint == "" {
+		r.endpoint = defaultSTSEndpoint
+	}
+	if !strings.HasPrefix(r.endpoint, "http://") && !strings.HasPrefix(r.endpoint, "https://") {
+		r.endpoint = "https://" + r.endpoint
+	}
+	p := newCachedProvider(ProviderRRSA, 0, r.fetch)
+	if _, err := p.Retrieve(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *rrsaProvider) fetch() (*Credential, error) {
+	token, err := os.ReadFile(filepath.Clean(r.oidcTokenFile))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	form := url.Values{}
+	form.Set("Action", "AssumeRoleWithOIDC")
+	form.Set("Format", "JSON")
+	form.Set("Version", "2015-04-01")
+	form.Set("Timestamp", now.UTC().Format(expirationTimeFormat))
+	form.Set("RoleArn", r.roleArn)
+	form.Set("OIDCProviderArn", r.oidcProviderArn)
+	form.Set("OIDCToken", strings.TrimSpace(string(token)))
+	form.Set("RoleSessionName", "ilogtail-"+strconv.FormatInt(now.Unix(), 10))
+	form.Set("DurationSeconds", "3600")
+	resp, err := r.client.PostForm(r.endpoint+"/", form)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Credentials stsCredentials
+		Code        string
+		Message     string
+	}
+	if err = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode the sts response error: %v, status code %d", err, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("assume role %s with oidc error, status code %d, code %s: %s", r.roleArn, resp.StatusCode, result.Code, result.Message)
+	}
+	return result.Credentials.toCredential()
+}
+
+func valueOrEnv(value, env string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(env)
+}
@@ -0,0 +1,237 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	ProviderStatic     = "static"
+	ProviderEnv        = "env"
+	ProviderFile       = "file"
+	ProviderVault      = "vault"
+	ProviderECSRAMRole = "ecs_ram_role"
+	ProviderRRSA       = "rrsa"
+
+	// the expiring credentials are refreshed ahead of the expiration
+	refreshAhead = 5 * time.Minute
+)
+
+// Credential is the secrets requested by the plugins, such as an access key pair with the security token
+// of the temporary credentials, or a username with the password.
+type Credential struct {
+	AccessKeyID     string
+	AccessKeySecret string
+	SecurityToken   string
+	Username        string
+	Password        string
+	// Expiration is the expiration time of the temporary credentials, zero if never expires.
+	Expiration time.Time
+}
+
+// Provider provides the current credential to the plugins, the plugins should retrieve it before each
+// use rather than keeping it, so that the rotated or refreshed secrets take effect.
+type Provider interface {
+	Retrieve() (*Credential, error)
+}
+
+// Config selects the provider of the credential, which keeps the plaintext secrets out of the pipeline configs.
+type Config struct {
+	// Provider is one of static, env, file, vault, ecs_ram_role and rrsa.
+	Provider string
+
+	// the secrets of the static provider, which is only for the test environment
+	AccessKeyID     string
+	AccessKeySecret string
+	SecurityToken   string
+	Username        string
+	Password        string
+
+	// EnvPrefix is the prefix of the environment variables read by the env provider, such as ALIBABA_CLOUD_ACCESS_KEY_ID,
+	// ALIBABA_CLOUD_ACCESS_KEY_SECRET, ALIBABA_CLOUD_SECURITY_TOKEN, ALIBABA_CLOUD_USERNAME and ALIBABA_CLOUD_PASSWORD.
+	// ALIBABA_CLOUD_ by default.
+	EnvPrefix string
+
+	// File is the json file read by the file provider, whose keys are the same as the fields of Credential.
+	File string
+	// ReloadIntervalSec is the interval to reread the file or the vault secret without the lease, 60 by default.
+	ReloadIntervalSec int
+
+	// VaultAddress is the address of HashiCorp Vault, such as https://vault.example.com:8200.
+	VaultAddress string
+	// VaultPath is the path of the secret, such as secret/data/ilogtail for the KV version 2 engine.
+	VaultPath string
+	// VaultTokenFile is the file of the vault token, the VAULT_TOKEN environment variable is used if empty.
+	VaultTokenFile string
+	// VaultNamespace is the namespace of Vault Enterprise. (optional)
+	VaultNamespace string
+
+	// RoleName is the RAM role attached to the ECS instance, the first role is used if empty.
+	RoleName string
+
+	// the RRSA settings of ACK, which are read from ALIBABA_CLOUD_ROLE_ARN, ALIBABA_CLOUD_OIDC_PROVIDER_ARN and
+	// ALIBABA_CLOUD_OIDC_TOKEN_FILE injected by the RRSA webhook if empty
+	RoleArn         string
+	OIDCProviderArn string
+	OIDCTokenFile   string
+	// STSEndpoint is the endpoint to assume the role, sts.aliyuncs.com by default.
+	STSEndpoint string
+}
+
+// ProviderCreator creates the provider with the config.
+type ProviderCreator func(config *Config) (Provider, error)
+
+// Providers are the registered providers, the other providers could be registered in the init functions.
+var Providers = map[string]ProviderCreator{}
+
+// NewProvider creates the provider selected by the config.
+func (c *Config) NewProvider() (Provider, error) {
+	creator, ok := Providers[c.Provider]
+	if !ok {
+		names := make([]string, 0, len(Providers))
+		for name := range Providers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown credential provider %q, which should be one of %v", c.Provider, names)
+	}
+	provider, err := creator(c)
+	if err != nil {
+		return nil, err
+	}
+	return &redactedProvider{Provider: provider}, nil
+}
+
+// Equal returns true if the secrets of the credentials are the same, the expiration is not compared.
+func (c *Credential) Equal(other *Credential) bool {
+	return c.AccessKeyID == other.AccessKeyID && c.AccessKeySecret == other.AccessKeySecret &&
+		c.SecurityToken == other.SecurityToken && c.Username == other.Username && c.Password == other.Password
+}
+
+// Tracker retrieves the credential from the provider, and tells whether it is rotated since the last retrieval,
+// so that the clients retrieving the credential before each use reconnect only when it is rotated.
+type Tracker struct {
+	provider Provider
+	last     *Credential
+}
+
+// NewTracker returns the tracker of the credential provided by @provider.
+func NewTracker(provider Provider) *Tracker {
+	return &Tracker{provider: provider}
+}
+
+// Retrieve returns the credential and true if it differs from the last retrieved one or it is the first retrieval.
+func (t *Tracker) Retrieve() (*Credential, bool, error) {
+	cred, err := t.provider.Retrieve()
+	if err != nil {
+		return nil, false, err
+	}
+	changed := t.last == nil || !cred.Equal(t.last)
+	t.last = cred
+	return cred, changed, nil
+}
+
+// WaitChange retrieves the credential every interval, and returns the new one when it differs from @cred, so that
+// the clients only accepting the static secrets at the initialization are recreated with the rotated or refreshed
+// secrets. It returns nil when @done is closed.
+func WaitChange(provider Provider, cred *Credential, interval time.Duration, done <-chan struct{}) *Credential {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return nil
+		case <-ticker.C:
+		}
+		current, err := provider.Retrieve()
+		if err != nil {
+			logger.Warning(context.Background(), util.AlarmCredential, "retrieve the credential error", err)
+			continue
+		}
+		if !current.Equal(cred) {
+			return current
+		}
+	}
+}
+
+func (c *Config) reloadInterval() time.Duration {
+	if c.ReloadIntervalSec <= 0 {
+		return time.Minute
+	}
+	return time.Duration(c.ReloadIntervalSec) * time.Second
+}
+
+// redactedProvider registers the retrieved secrets, which are masked in the self logs, the alarms and the config dumps.
+type redactedProvider struct {
+	Provider
+}
+
+func (p *redactedProvider) Retrieve() (*Credential, error) {
+	cred, err := p.Provider.Retrieve()
+	if err == nil {
+		util.RegisterSecret(cred.AccessKeySecret, cred.SecurityToken, cred.Password)
+	}
+	return cred, err
+}
+
+// cachedProvider caches the fetched credential until it expires or the refresh interval passes, and keeps
+// the cached one if the refreshing fails before the expiration.
+type cachedProvider struct {
+	name     string
+	fetch    func() (*Credential, error)
+	interval time.Duration
+
+	mu        sync.Mutex
+	cred      *Credential
+	lastFetch time.Time
+}
+
+func newCachedProvider(name string, interval time.Duration, fetch func() (*Credential, error)) *cachedProvider {
+	return &cachedProvider{name: name, fetch: fetch, interval: interval}
+}
+
+func (p *cachedProvider) Retrieve() (*Credential, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	if p.cred != nil && !p.stale(now) {
+		return p.cred, nil
+	}
+	cred, err := p.fetch()
+	if err != nil {
+		if p.cred != nil && (p.cred.Expiration.IsZero() || now.Before(p.cred.Expiration)) {
+			logger.Warning(context.Background(), util.AlarmCredential, "refresh the credential error, use the cached one", err, "provider", p.name)
+			return p.cred, nil
+		}
+		return nil, fmt.Errorf("retrieve the credential from %s error: %w", p.name, err)
+	}
+	p.cred, p.lastFetch = cred, now
+	return cred, nil
+}
+
+func (p *cachedProvider) stale(now time.Time) bool {
+	if !p.cred.Expiration.IsZero() && now.After(p.cred.Expiration.Add(-refreshAhead)) {
+		return true
+	}
+	return p.interval > 0 && now.Sub(p.lastFetch) >= p.interval
+}
@@ -0,0 +1,264 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credentials
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+func TestStaticAndEnvProvider(t *testing.T) {
+	p, err := (&Config{Provider: ProviderStatic, Username: "user", Password: "pass"}).NewProvider()
+	require.NoError(t, err)
+	cred, err := p.Retrieve()
+	require.NoError(t, err)
+	assert.Equal(t, &Credential{Username: "user", Password: "pass"}, cred)
+
+	t.Setenv("TEST_ACCESS_KEY_ID", "id")
+	t.Setenv("TEST_ACCESS_KEY_SECRET", "secret")
+	p, err = (&Config{Provider: ProviderEnv, EnvPrefix: "TEST_"}).NewProvider()
+	require.NoError(t, err)
+	cred, err = p.Retrieve()
+	require.NoError(t, err)
+	assert.Equal(t, &Credential{AccessKeyID: "id", AccessKeySecret: "secret"}, cred)
+
+	p, err = (&Config{Provider: ProviderEnv, EnvPrefix: "NOT_EXIST_"}).NewProvider()
+	require.NoError(t, err)
+	_, err = p.Retrieve()
+	assert.Error(t, err)
+
+	_, err = (&Config{Provider: "unknown"}).NewProvider()
+	assert.ErrorContains(t, err, "unknown credential provider")
+}
+
+func TestRetrievedSecretsRedacted(t *testing.T) {
+	util.ResetSecrets()
+	defer util.ResetSecrets()
+	p, err := (&Config{Provider: ProviderStatic, AccessKeyID: "mock-id", AccessKeySecret: "mock-secret", SecurityToken: "mock-token"}).NewProvider()
+	require.NoError(t, err)
+	cred, err := p.Retrieve()
+	require.NoError(t, err)
+	assert.Equal(t, "mock-secret", cred.AccessKeySecret)
+	assert.Equal(t, "id:mock-id secret:****** token:******", util.RedactSecrets("id:mock-id secret:mock-secret token:mock-token"))
+}
+
+func TestFileProvider(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "credential.json")
+	require.NoError(t, os.WriteFile(file, []byte(`{"AccessKeyID":"id1","AccessKeySecret":"secret1"}`), 0600))
+	p, err := (&Config{Provider: ProviderFile, File: file, ReloadIntervalSec: 1}).NewProvider()
+	require.NoError(t, err)
+	cred, err := p.Retrieve()
+	require.NoError(t, err)
+	assert.Equal(t, "id1", cred.AccessKeyID)
+
+	// the rotated secret is reread after the interval
+	require.NoError(t, os.WriteFile(file, []byte(`{"AccessKeyID":"id2","AccessKeySecret":"secret2"}`), 0600))
+	cred, err = p.Retrieve()
+	require.NoError(t, err)
+	assert.Equal(t, "id1", cred.AccessKeyID)
+	p.(*redactedProvider).Provider.(*cachedProvider).lastFetch = time.Now().Add(-2 * time.Second)
+	cred, err = p.Retrieve()
+	require.NoError(t, err)
+	assert.Equal(t, "id2", cred.AccessKeyID)
+
+	// the cached secret is kept if the file is broken
+	require.NoError(t, os.WriteFile(file, []byte(`broken`), 0600))
+	p.(*redactedProvider).Provider.(*cachedProvider).lastFetch = time.Now().Add(-2 * time.Second)
+	cred, err = p.Retrieve()
+	require.NoError(t, err)
+	assert.Equal(t, "id2", cred.AccessKeyID)
+
+	_, err = (&Config{Provider: ProviderFile, File: filepath.Join(t.TempDir(), "not_exist.json")}).NewProvider()
+	assert.Error(t, err)
+}
+
+func TestCachedProviderExpiration(t *testing.T) {
+	var fetched int
+	var fetchErr error
+	p := newCachedProvider("test", 0, func() (*Credential, error) {
+		fetched++
+		return &Credential{AccessKeyID: "id", Expiration: time.Now().Add(refreshAhead + time.Hour)}, fetchErr
+	})
+	_, err := p.Retrieve()
+	require.NoError(t, err)
+	_, err = p.Retrieve()
+	require.NoError(t, err)
+	assert.Equal(t, 1, fetched)
+
+	// refreshed ahead of the expiration, and the cached one is used if the refreshing fails
+	p.cred.Expiration = time.Now().Add(time.Minute)
+	fetchErr = errors.New("unavailable")
+	cred, err := p.Retrieve()
+	require.NoError(t, err)
+	assert.Equal(t, "id", cred.AccessKeyID)
+	assert.Equal(t, 2, fetched)
+
+	p.cred.Expiration = time.Now().Add(-time.Minute)
+	_, err = p.Retrieve()
+	assert.Error(t, err)
+}
+
+type rotatingProvider struct {
+	mu  sync.Mutex
+	key string
+}
+
+func (p *rotatingProvider) Retrieve() (*Credential, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return &Credential{AccessKeyID: "id", AccessKeySecret: p.key}, nil
+}
+
+func TestWaitChange(t *testing.T) {
+	provider := &rotatingProvider{key: "secret1"}
+	cred, _ := provider.Retrieve()
+
+	done := make(chan struct{})
+	time.AfterFunc(50*time.Millisecond, func() { close(done) })
+	assert.Nil(t, WaitChange(provider, cred, 10*time.Millisecond, done))
+
+	provider.mu.Lock()
+	provider.key = "secret2"
+	provider.mu.Unlock()
+	changed := WaitChange(provider, cred, 10*time.Millisecond, make(chan struct{}))
+	require.NotNil(t, changed)
+	assert.Equal(t, "secret2", changed.AccessKeySecret)
+}
+
+func TestTracker(t *testing.T) {
+	provider := &rotatingProvider{key: "secret1"}
+	tracker := NewTracker(provider)
+	cred, changed, err := tracker.Retrieve()
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "secret1", cred.AccessKeySecret)
+	_, changed, _ = tracker.Retrieve()
+	assert.False(t, changed)
+
+	provider.key = "secret2"
+	cred, changed, _ = tracker.Retrieve()
+	assert.True(t, changed)
+	assert.Equal(t, "secret2", cred.AccessKeySecret)
+}
+
+func TestVaultProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" || r.Header.Get("X-Vault-Namespace") != "ns" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/ilogtail":
+			_, _ = w.Write([]byte(`{"lease_duration":0,"data":{"data":{"Username":"user","Password":"pass"},"metadata":{"version":1}}}`))
+		case "/v1/kv/ilogtail":
+			_, _ = w.Write([]byte(`{"lease_duration":3600,"data":{"AccessKeyID":"id","AccessKeySecret":"secret"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("token\n"), 0600))
+	p, err := (&Config{Provider: ProviderVault, VaultAddress: server.URL, VaultPath: "secret/data/ilogtail", VaultTokenFile: tokenFile, VaultNamespace: "ns"}).NewProvider()
+	require.NoError(t, err)
+	cred, err := p.Retrieve()
+	require.NoError(t, err)
+	assert.Equal(t, &Credential{Username: "user", Password: "pass"}, cred)
+
+	t.Setenv(vaultTokenEnv, "token")
+	p, err = (&Config{Provider: ProviderVault, VaultAddress: server.URL, VaultPath: "/kv/ilogtail", VaultNamespace: "ns"}).NewProvider()
+	require.NoError(t, err)
+	cred, err = p.Retrieve()
+	require.NoError(t, err)
+	assert.Equal(t, "id", cred.AccessKeyID)
+	assert.False(t, cred.Expiration.IsZero())
+
+	_, err = (&Config{Provider: ProviderVault, VaultAddress: server.URL, VaultPath: "kv/ilogtail"}).NewProvider()
+	assert.ErrorContains(t, err, "status code 403")
+}
+
+func TestECSRAMRoleProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+			_, _ = w.Write([]byte("metadata-token"))
+		case r.Header.Get(ecsMetadataTokenHeader) != "metadata-token":
+			w.WriteHeader(http.StatusForbidden)
+		case r.URL.Path == "/latest/meta-data/ram/security-credentials/":
+			_, _ = w.Write([]byte("ilogtail-role\n"))
+		case r.URL.Path == "/latest/meta-data/ram/security-credentials/ilogtail-role":
+			_, _ = w.Write([]byte(`{"AccessKeyId":"STS.id","AccessKeySecret":"secret","Expiration":"2099-01-01T00:00:00Z","SecurityToken":"token","Code":"Success"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer func(endpoint string) {
+		ecsMetadataEndpoint = endpoint
+	}(ecsMetadataEndpoint)
+	ecsMetadataEndpoint = server.URL
+
+	p, err := (&Config{Provider: ProviderECSRAMRole}).NewProvider()
+	require.NoError(t, err)
+	cred, err := p.Retrieve()
+	require.NoError(t, err)
+	assert.Equal(t, &Credential{AccessKeyID: "STS.id", AccessKeySecret: "secret", SecurityToken: "token", Expiration: time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC)}, cred)
+
+	_, err = (&Config{Provider: ProviderECSRAMRole, RoleName: "other-role"}).NewProvider()
+	assert.Error(t, err)
+}
+
+func TestRRSAProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.Form.Get("Action") != "AssumeRoleWithOIDC" || r.Form.Get("OIDCToken") != "oidc-token" ||
+			r.Form.Get("RoleArn") != "acs:ram::1:role/ilogtail" || r.Form.Get("OIDCProviderArn") != "acs:ram::1:oidc-provider/ack" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"Code":"InvalidParameter","Message":"invalid"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"Credentials":{"AccessKeyId":"STS.id","AccessKeySecret":"secret","SecurityToken":"token","Expiration":"2099-01-01T00:00:00Z"}}`))
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("oidc-token"), 0600))
+	t.Setenv(roleArnEnv, "acs:ram::1:role/ilogtail")
+	t.Setenv(oidcProviderArnEnv, "acs:ram::1:oidc-provider/ack")
+	t.Setenv(oidcTokenFileEnv, tokenFile)
+	p, err := (&Config{Provider: ProviderRRSA, STSEndpoint: server.URL}).NewProvider()
+	require.NoError(t, err)
+	cred, err := p.Retrieve()
+	require.NoError(t, err)
+	assert.Equal(t, "STS.id", cred.AccessKeyID)
+	assert.Equal(t, "token", cred.SecurityToken)
+
+	_, err = (&Config{Provider: ProviderRRSA, STSEndpoint: server.URL, RoleArn: "acs:ram::1:role/other"}).NewProvider()
+	assert.ErrorContains(t, err, "InvalidParameter")
+}
@@ -0,0 +1,99 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credentials
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func init() {
+	Providers[ProviderStatic] = newStaticProvider
+	Providers[ProviderEnv] = newEnvProvider
+	Providers[ProviderFile] = newFileProvider
+}
+
+type staticProvider struct {
+	cred *Credential
+}
+
+func newStaticProvider(config *Config) (Provider, error) {
+	return &staticProvider{cred: &Credential{
+		AccessKeyID:     config.AccessKeyID,
+		AccessKeySecret: config.AccessKeySecret,
+		SecurityToken:   config.SecurityToken,
+		Username:        config.Username,
+		Password:        config.Password,
+	}}, nil
+}
+
+func (p *staticProvider) Retrieve() (*Credential, error) {
+	return p.cred, nil
+}
+
+// envProvider reads the environment variables on each retrieving, which is cheap enough to skip caching.
+type envProvider struct {
+	prefix string
+}
+
+func newEnvProvider(config *Config) (Provider, error) {
+	prefix := config.EnvPrefix
+	if prefix == "" {
+		prefix = "ALIBABA_CLOUD_"
+	}
+	return &envProvider{prefix: prefix}, nil
+}
+
+func (p *envProvider) Retrieve() (*Credential, error) {
+	cred := &Credential{
+		AccessKeyID:     os.Getenv(p.prefix + "ACCESS_KEY_ID"),
+		AccessKeySecret: os.Getenv(p.prefix + "ACCESS_KEY_SECRET"),
+		SecurityToken:   os.Getenv(p.prefix + "SECURITY_TOKEN"),
+		Username:        os.Getenv(p.prefix + "USERNAME"),
+		Password:        os.Getenv(p.prefix + "PASSWORD"),
+	}
+	if *cred == (Credential{}) {
+		return nil, fmt.Errorf("no credential in the environment variables with prefix %s", p.prefix)
+	}
+	return cred, nil
+}
+
+// newFileProvider rereads the file every reload interval, so that the secrets rotated by the secret
+// managers, such as the mounted Kubernetes secrets, take effect without restart.
+func newFileProvider(config *Config) (Provider, error) {
+	if config.File == "" {
+		return nil, errors.New("the File of the file credential provider is empty")
+	}
+	file := filepath.Clean(config.File)
+	p := newCachedProvider(ProviderFile, config.reloadInterval(), func() (*Credential, error) {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var cred Credential
+		if err = json.Unmarshal(data, &cred); err != nil {
+			return nil, fmt.Errorf("parse the credential file %s error: %v", file, err)
+		}
+		return &cred, nil
+	})
+	// fail fast on the wrong file
+	if _, err := p.Retrieve(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
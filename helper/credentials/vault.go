@@ -0,0 +1,132 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credentials
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const vaultTokenEnv = "VAULT_TOKEN" //nolint:gosec
+
+func init() {
+	Providers[ProviderVault] = newVaultProvider
+}
+
+// vaultResponse is the response of reading a secret, the Data of the KV version 2 engine wraps the
+// secret in Data.data with the metadata in Data.metadata.
+type vaultResponse struct {
+	LeaseDuration int                    `json:"lease_duration"`
+	Data          map[string]interface{} `json:"data"`
+	Errors        []string               `json:"errors"`
+}
+
+type vaultProvider struct {
+	client    *http.Client
+	url       string
+	tokenFile string
+	namespace string
+}
+
+// newVaultProvider reads the secret from HashiCorp Vault with the token auth, the secret is refreshed
+// before the lease expires, or every reload interval if without the lease.
+func newVaultProvider(config *Config) (Provider, error) {
+	if config.VaultAddress == "" || config.VaultPath == "" {
+		return nil, errors.New("both VaultAddress and VaultPath of the vault credential provider must be set")
+	}
+	v := &vaultProvider{
+		client:    &http.Client{Timeout: 10 * time.Second},
+		url:       strings.TrimSuffix(config.VaultAddress, "/") + "/v1/" + strings.TrimPrefix(config.VaultPath, "/"),
+		tokenFile: config.VaultTokenFile,
+		namespace: config.VaultNamespace,
+	}
+	p := newCachedProvider(ProviderVault, config.reloadInterval(), v.fetch)
+	if _, err := p.Retrieve(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (v *vaultProvider) token() (string, error) {
+	if v.tokenFile == "" {
+		if token := os.Getenv(vaultTokenEnv); token != "" {
+			return token, nil
+		}
+		return "", errors.New("neither VaultTokenFile nor the VAULT_TOKEN environment variable is set")
+	}
+	// the token file could be rotated by the vault agent
+	token, err := os.ReadFile(filepath.Clean(v.tokenFile))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+func (v *vaultProvider) fetch() (*Credential, error) {
+	token, err := v.token()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, v.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if v.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.namespace)
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var result vaultResponse
+	if err = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode the vault response error: %v, status code %d", err, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("read the vault secret error, status code %d: %v", resp.StatusCode, result.Errors)
+	}
+
+	data := result.Data
+	if inner, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok = data["metadata"]; ok {
+			data = inner
+		}
+	}
+	cred := &Credential{
+		AccessKeyID:     vaultString(data, "AccessKeyID"),
+		AccessKeySecret: vaultString(data, "AccessKeySecret"),
+		SecurityToken:   vaultString(data, "SecurityToken"),
+		Username:        vaultString(data, "Username"),
+		Password:        vaultString(data, "Password"),
+	}
+	if result.LeaseDuration > 0 {
+		cred.Expiration = time.Now().Add(time.Duration(result.LeaseDuration) * time.Second)
+	}
+	return cred, nil
+}
+
+func vaultString(data map[string]interface{}, key string) string {
+	s, _ := data[key].(string)
+	return s
+}
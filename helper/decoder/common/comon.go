@@ -15,16 +15,17 @@
 package common
 
 import (
-	"compress/gzip"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
 
-	"github.com/golang/snappy"
 	"github.com/pierrec/lz4"
+
+	"github.com/alibaba/ilogtail/helper/decompress"
 )
 
 const (
@@ -38,32 +39,37 @@ const (
 	ProtocolOTLPTraceV1  = "otlp_tracev1"
 	ProtocolRaw          = "raw"
 	ProtocolPyroscope    = "pyroscope"
+	ProtocolGraphite     = "graphite"
+	ProtocolCEF          = "cef"
+	ProtocolLEEF         = "leef"
+	ProtocolJSON         = "json"
 )
 
 func CollectBody(res http.ResponseWriter, req *http.Request, maxBodySize int64) ([]byte, int, error) {
-	body := req.Body
-
-	// Handle gzip request bodies
-	if req.Header.Get("Content-Encoding") == "gzip" {
-		var err error
-		body, err = gzip.NewReader(req.Body)
+	var bytes []byte
+	var err error
+	// Handle compressed request bodies, the decompressed size is limited by maxBodySize.
+	switch encoding := strings.ToLower(req.Header.Get("Content-Encoding")); encoding {
+	case decompress.Gzip, decompress.Deflate, decompress.Zstd, decompress.Snappy:
+		body, err := decompress.NewReader(req.Body, encoding, maxBodySize)
 		if err != nil {
+			if errors.Is(err, decompress.ErrTooLarge) {
+				return nil, http.StatusRequestEntityTooLarge, err
+			}
 			return nil, http.StatusBadRequest, err
 		}
-		defer body.Close()
-	}
-
-	body = http.MaxBytesReader(res, body, maxBodySize)
-	bytes, err := ioutil.ReadAll(body)
-	if err != nil {
-		return nil, http.StatusRequestEntityTooLarge, err
-	}
-
-	if req.Header.Get("Content-Encoding") == "snappy" {
-		bytes, err = snappy.Decode(nil, bytes)
-		if err != nil {
+		defer body.Close() //nolint:errcheck
+		if bytes, err = ioutil.ReadAll(body); err != nil {
+			if errors.Is(err, decompress.ErrTooLarge) {
+				return nil, http.StatusRequestEntityTooLarge, err
+			}
 			return nil, http.StatusBadRequest, err
 		}
+	default:
+		body := http.MaxBytesReader(res, req.Body, maxBodySize)
+		if bytes, err = ioutil.ReadAll(body); err != nil {
+			return nil, http.StatusRequestEntityTooLarge, err
+		}
 	}
 
 	if req.Header.Get("x-log-compresstype") == "lz4" {
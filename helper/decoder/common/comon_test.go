@@ -0,0 +1,61 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectBody(t *testing.T) {
+	data := []byte(strings.Repeat("a", 1024))
+	var gzipped bytes.Buffer
+	writer := gzip.NewWriter(&gzipped)
+	_, _ = writer.Write(data)
+	_ = writer.Close()
+
+	cases := []struct {
+		encoding   string
+		body       []byte
+		maxSize    int64
+		statusCode int
+	}{
+		{"", data, 1024, http.StatusOK},
+		{"", data, 1023, http.StatusRequestEntityTooLarge},
+		{"gzip", gzipped.Bytes(), 1024, http.StatusOK},
+		{"gzip", gzipped.Bytes(), 1023, http.StatusRequestEntityTooLarge},
+		{"gzip", data, 1024, http.StatusBadRequest},
+		{"snappy", snappy.Encode(nil, data), 1024, http.StatusOK},
+		{"snappy", snappy.Encode(nil, data), 1023, http.StatusRequestEntityTooLarge},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(c.body))
+		req.Header.Set("Content-Encoding", c.encoding)
+		body, statusCode, err := CollectBody(httptest.NewRecorder(), req, c.maxSize)
+		require.Equal(t, c.statusCode, statusCode, c.encoding)
+		if statusCode == http.StatusOK {
+			require.NoError(t, err)
+			assert.Equal(t, data, body)
+		}
+	}
+}
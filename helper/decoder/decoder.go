@@ -21,15 +21,20 @@ import (
 	"time"
 
 	"github.com/alibaba/ilogtail/helper/decoder/common"
+	"github.com/alibaba/ilogtail/helper/decoder/graphite"
 	"github.com/alibaba/ilogtail/helper/decoder/influxdb"
+	jsondecoder "github.com/alibaba/ilogtail/helper/decoder/json"
 	"github.com/alibaba/ilogtail/helper/decoder/opentelemetry"
 	"github.com/alibaba/ilogtail/helper/decoder/prometheus"
 	"github.com/alibaba/ilogtail/helper/decoder/pyroscope"
 	"github.com/alibaba/ilogtail/helper/decoder/raw"
+	"github.com/alibaba/ilogtail/helper/decoder/siem"
 	"github.com/alibaba/ilogtail/helper/decoder/sls"
 	"github.com/alibaba/ilogtail/helper/decoder/statsd"
+	"github.com/alibaba/ilogtail/helper/k8smeta"
 	"github.com/alibaba/ilogtail/pkg/models"
 	"github.com/alibaba/ilogtail/pkg/protocol"
+	converter "github.com/alibaba/ilogtail/pkg/protocol/converter"
 )
 
 // Decoder used to parse buffer to sls logs
@@ -45,6 +50,21 @@ type Decoder interface {
 type Option struct {
 	FieldsExtend      bool
 	DisableUncompress bool
+	FieldMapping      map[string]string
+	// JSONSchema validates the objects of the json format when it's not nil
+	JSONSchema *converter.JSONSchema
+	// ProfileTrimPathPrefixes are the path prefixes stripped from the pprof profiles per application
+	ProfileTrimPathPrefixes map[string][]string
+	// ProfileDiff appends the deltas of the stacks between the consecutive uploads of the pyroscope profiles
+	ProfileDiff bool
+	// ProfileHeapDumpTriggers emit the heap dump trigger events of the pyroscope memory profiles per application
+	ProfileHeapDumpTriggers map[string]*pyroscope.HeapDumpTrigger
+	// ProfileClockSkew corrects the time ranges of the pyroscope profiles ahead of the ingestion time
+	ProfileClockSkew *pyroscope.ClockSkewConfig
+	// ProfileParseTimeoutSec limits the decompression and parsing time of each pyroscope profile upload
+	ProfileParseTimeoutSec int
+	// ProfileK8sMeta adds the kubernetes labels of the pods sending the pyroscope profiles when it's not nil
+	ProfileK8sMeta *k8smeta.Options
 }
 
 var errDecoderNotFound = errors.New("no such decoder")
@@ -76,7 +96,21 @@ func GetDecoderWithOptions(format string, option Option) (Decoder, error) {
 		return &raw.Decoder{DisableUncompress: option.DisableUncompress}, nil
 
 	case common.ProtocolPyroscope:
-		return &pyroscope.Decoder{}, nil
+		d := &pyroscope.Decoder{TrimPathPrefixes: option.ProfileTrimPathPrefixes, Diff: option.ProfileDiff, HeapDumpTriggers: option.ProfileHeapDumpTriggers, ClockSkew: option.ProfileClockSkew, ParseTimeoutSec: option.ProfileParseTimeoutSec}
+		if option.ProfileK8sMeta != nil {
+			manager, err := k8smeta.GetMetaManager(*option.ProfileK8sMeta)
+			if err != nil {
+				return nil, err
+			}
+			d.PodLookup = manager
+		}
+		return d, nil
+	case common.ProtocolGraphite:
+		return &graphite.Decoder{}, nil
+	case common.ProtocolCEF, common.ProtocolLEEF:
+		return &siem.Decoder{Format: strings.TrimSpace(strings.ToLower(format)), FieldMapping: option.FieldMapping}, nil
+	case common.ProtocolJSON:
+		return &jsondecoder.Decoder{Schema: option.JSONSchema}, nil
 	}
 	return nil, errDecoderNotFound
 }
@@ -0,0 +1,181 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphite
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/helper/decoder/common"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	metricNameKey = "__name__"
+	labelsKey     = "__labels__"
+	timeNanoKey   = "__time_nano__"
+	valueKey      = "__value__"
+)
+
+// Decoder parses the graphite plaintext protocol, in which each line is `<path> <value> [<timestamp>]`.
+// The path could carry the tags in the tagged format, e.g. `cpu.load;host=server01;region=cn`.
+// The missing timestamp or the timestamp -1 means the receiving time.
+type Decoder struct {
+}
+
+type point struct {
+	name      string
+	tags      map[string]string
+	value     float64
+	timestamp int64
+}
+
+func (d *Decoder) Decode(data []byte, req *http.Request, tags map[string]string) (logs []*protocol.Log, err error) {
+	points := d.parsePoints(data)
+	logs = make([]*protocol.Log, 0, len(points))
+	for _, p := range points {
+		name := p.name
+		helper.ReplaceInvalidChars(&name)
+		logs = append(logs, &protocol.Log{
+			Time: uint32(p.timestamp / int64(time.Second)),
+			Contents: []*protocol.Log_Content{
+				{Key: metricNameKey, Value: name},
+				{Key: labelsKey, Value: formatLabels(p.tags)},
+				{Key: timeNanoKey, Value: strconv.FormatInt(p.timestamp, 10)},
+				{Key: valueKey, Value: strconv.FormatFloat(p.value, 'g', -1, 64)},
+			},
+		})
+	}
+	return logs, nil
+}
+
+func (d *Decoder) DecodeV2(data []byte, req *http.Request) (groups []*models.PipelineGroupEvents, err error) {
+	points := d.parsePoints(data)
+	group := &models.PipelineGroupEvents{
+		Group:  models.NewGroup(models.NewMetadata(), models.NewTags()),
+		Events: make([]models.PipelineEvent, 0, len(points)),
+	}
+	for _, p := range points {
+		group.Events = append(group.Events, models.NewSingleValueMetric(p.name, models.MetricTypeUntyped, models.NewTagsWithMap(p.tags), p.timestamp, p.value))
+	}
+	return []*models.PipelineGroupEvents{group}, nil
+}
+
+func (d *Decoder) ParseRequest(res http.ResponseWriter, req *http.Request, maxBodySize int64) (data []byte, statusCode int, err error) {
+	return common.CollectBody(res, req, maxBodySize)
+}
+
+// parsePoints skips the invalid lines, as one bad line should not drop the whole batch sent by the carbon clients.
+// The decoder is shared by the connections, so only the first invalid line of the batch is alarmed.
+func (d *Decoder) parsePoints(data []byte) []*point {
+	now := time.Now()
+	alarmed := false
+	lines := bytes.Split(data, []byte("\n"))
+	points := make([]*point, 0, len(lines))
+	for _, line := range lines {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		p, err := parseLine(string(line), now)
+		if err != nil {
+			logger.Debug(context.Background(), "parse graphite error", err)
+			if !alarmed {
+				logger.Error(context.Background(), util.AlarmGraphiteParse, "parse err", err)
+				alarmed = true
+			}
+			continue
+		}
+		points = append(points, p)
+	}
+	return points
+}
+
+// parseLine parses one line of the graphite plaintext protocol.
+func parseLine(line string, now time.Time) (*point, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || len(fields) > 3 {
+		return nil, fmt.Errorf("invalid graphite line: %s", line)
+	}
+	name, tags, err := parsePath(fields[0])
+	if err != nil {
+		return nil, err
+	}
+	value, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value in graphite line: %s", line)
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, fmt.Errorf("non-finite value in graphite line: %s", line)
+	}
+	timestamp := now.UnixNano()
+	if len(fields) == 3 && fields[2] != "-1" {
+		seconds, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil || seconds < 0 {
+			return nil, fmt.Errorf("invalid timestamp in graphite line: %s", line)
+		}
+		timestamp = int64(seconds * float64(time.Second))
+	}
+	return &point{name: name, tags: tags, value: value, timestamp: timestamp}, nil
+}
+
+func parsePath(path string) (name string, tags map[string]string, err error) {
+	parts := strings.Split(path, ";")
+	name = parts[0]
+	if len(name) == 0 {
+		return "", nil, errors.New("empty metric name in graphite line")
+	}
+	tags = make(map[string]string, len(parts)-1)
+	for _, part := range parts[1:] {
+		k, v, ok := strings.Cut(part, "=")
+		if !ok || len(k) == 0 || len(v) == 0 {
+			return "", nil, fmt.Errorf("invalid tag %s in graphite path: %s", part, path)
+		}
+		tags[k] = v
+	}
+	return name, tags, nil
+}
+
+func formatLabels(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var builder strings.Builder
+	for i, k := range keys {
+		if i != 0 {
+			builder.WriteByte('|')
+		}
+		key := k
+		helper.ReplaceInvalidChars(&key)
+		builder.WriteString(key)
+		builder.WriteString("#$#")
+		builder.WriteString(tags[k])
+	}
+	return builder.String()
+}
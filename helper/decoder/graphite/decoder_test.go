@@ -0,0 +1,90 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/models"
+)
+
+var textFormat = `
+servers.host01.cpu.load 0.6 1434055562
+cpu.load;host=server01;region=cn 1.5 1434055562.5
+
+disk.used 10 -1
+memory.free 20
+invalid.line
+invalid.value abc 1434055562
+invalid.tag;host 1 1434055562
+`
+
+func TestParseLine(t *testing.T) {
+	now := time.Unix(1434055600, 0)
+	p, err := parseLine("cpu.load;host=server01;region=cn 1.5 1434055562.5", now)
+	require.NoError(t, err)
+	assert.Equal(t, &point{name: "cpu.load", tags: map[string]string{"host": "server01", "region": "cn"}, value: 1.5, timestamp: 1434055562500000000}, p)
+
+	p, err = parseLine("disk.used 10 -1", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.UnixNano(), p.timestamp)
+
+	for _, line := range []string{"a", "a b c d", ";host=a 1", "a;host 1", "a;=b 1", "a NaN", "a 1 x", "a 1 -2"} {
+		_, err = parseLine(line, now)
+		assert.Error(t, err, line)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	decoder := &Decoder{}
+	logs, err := decoder.Decode([]byte(textFormat), nil, nil)
+	require.NoError(t, err)
+	require.Len(t, logs, 4)
+
+	contents := make(map[string]string)
+	for _, c := range logs[1].Contents {
+		contents[c.Key] = c.Value
+	}
+	assert.Equal(t, map[string]string{
+		metricNameKey: "cpu_load",
+		labelsKey:     "host#$#server01|region#$#cn",
+		timeNanoKey:   "1434055562500000000",
+		valueKey:      "1.5",
+	}, contents)
+	assert.Equal(t, uint32(1434055562), logs[1].Time)
+}
+
+func TestDecodeV2(t *testing.T) {
+	decoder := &Decoder{}
+	groups, err := decoder.DecodeV2([]byte(textFormat), nil)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	require.Len(t, groups[0].Events, 4)
+
+	metric := groups[0].Events[1].(*models.Metric)
+	assert.Equal(t, "cpu.load", metric.GetName())
+	assert.Equal(t, "server01", metric.GetTags().Get("host"))
+	assert.Equal(t, "cn", metric.GetTags().Get("region"))
+	assert.Equal(t, 1.5, metric.GetValue().GetSingleValue())
+	assert.Equal(t, uint64(1434055562500000000), metric.GetTimestamp())
+
+	metric = groups[0].Events[0].(*models.Metric)
+	assert.Equal(t, "servers.host01.cpu.load", metric.GetName())
+	assert.Equal(t, 0, metric.GetTags().Len())
+}
@@ -46,20 +46,19 @@ const (
 
 const tagDB = "__tag__:db"
 
+const (
+	metaDB              = "db"
+	metaRetentionPolicy = "rp"
+	singleValueField    = "value"
+)
+
 // Decoder impl
 type Decoder struct {
 	FieldsExtend bool
 }
 
 func (d *Decoder) Decode(data []byte, req *http.Request, tags map[string]string) (logs []*protocol.Log, decodeErr error) {
-	precision := req.FormValue("precision")
-	var points []models.Point
-	var err error
-	if precision != "" {
-		points, err = models.ParsePointsWithPrecision(data, time.Now().UTC(), precision)
-	} else {
-		points, err = models.ParsePoints(data)
-	}
+	points, err := d.parsePoints(data, req)
 	if err != nil {
 		return nil, err
 	}
@@ -74,8 +73,72 @@ func (d *Decoder) ParseRequest(res http.ResponseWriter, req *http.Request, maxBo
 }
 
 func (d *Decoder) DecodeV2(data []byte, req *http.Request) (groups []*imodels.PipelineGroupEvents, err error) {
-	//TODO: Implement DecodeV2
-	return nil, nil
+	points, err := d.parsePoints(data, req)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := imodels.NewMetadata()
+	if db := req.FormValue("db"); len(db) > 0 {
+		meta.Add(metaDB, db)
+	}
+	if rp := req.FormValue("rp"); len(rp) > 0 {
+		meta.Add(metaRetentionPolicy, rp)
+	}
+	group := &imodels.PipelineGroupEvents{
+		Group:  imodels.NewGroup(meta, imodels.NewTags()),
+		Events: make([]imodels.PipelineEvent, 0, len(points)),
+	}
+	for _, point := range points {
+		if metric := d.parsePointToMetric(point); metric != nil {
+			group.Events = append(group.Events, metric)
+		}
+	}
+	return []*imodels.PipelineGroupEvents{group}, nil
+}
+
+func (d *Decoder) parsePoints(data []byte, req *http.Request) ([]models.Point, error) {
+	if precision := req.FormValue("precision"); precision != "" {
+		return models.ParsePointsWithPrecision(data, time.Now().UTC(), precision)
+	}
+	return models.ParsePoints(data)
+}
+
+// parsePointToMetric converts the point to a metric event. The numeric fields are the values of the metric,
+// and the point with only one numeric field named value is converted to a single value metric.
+// The string and boolean fields are the typed values of the metric.
+func (d *Decoder) parsePointToMetric(point models.Point) *imodels.Metric {
+	fields, err := point.Fields()
+	if err != nil {
+		return nil
+	}
+	tags := imodels.NewTags()
+	for _, tag := range point.Tags() {
+		tags.Add(string(tag.Key), string(tag.Value))
+	}
+
+	multiValues := imodels.NewMetricMultiValue()
+	typedValues := imodels.NewMetricTypedValues()
+	for field, v := range fields {
+		switch v := v.(type) {
+		case float64:
+			multiValues.Add(field, v)
+		case int64:
+			multiValues.Add(field, float64(v))
+		case uint64:
+			multiValues.Add(field, float64(v))
+		case bool:
+			typedValues.Add(field, &imodels.TypedValue{Type: imodels.ValueTypeBoolean, Value: v})
+		case string:
+			typedValues.Add(field, &imodels.TypedValue{Type: imodels.ValueTypeString, Value: v})
+		}
+	}
+
+	var value imodels.MetricValue = multiValues
+	if multiValues.Values.Len() == 1 && multiValues.Values.Contains(singleValueField) {
+		value = &imodels.MetricSingleValue{Value: multiValues.Values.Get(singleValueField)}
+	}
+	return imodels.NewMetric(string(point.Name()), imodels.MetricTypeUntyped, tags, point.UnixNano(), value, typedValues)
 }
 
 func (d *Decoder) parsePointsToLogs(points []models.Point, req *http.Request) []*protocol.Log {
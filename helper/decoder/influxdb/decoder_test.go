@@ -17,11 +17,15 @@ package influxdb
 import (
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/alibaba/ilogtail/pkg/models"
 	"github.com/alibaba/ilogtail/pkg/protocol"
+	converter "github.com/alibaba/ilogtail/pkg/protocol/converter"
 )
 
 var textFormat = `
@@ -180,3 +184,53 @@ func TestMySQL(t *testing.T) {
 		fmt.Printf("%s \n", log.String())
 	}
 }
+
+func TestDecodeV2(t *testing.T) {
+	decoder := &Decoder{}
+	req := httptest.NewRequest(http.MethodPost, "/write?db=telegraf&rp=autogen&precision=s", nil)
+	data := `cpu,host=server01,region=uswest value=1 1434055562
+temperature,machine=unit42 internal=32,external=100i 1434055562
+event,host=server01 msg="logged out",fatal=true 1434055562
+`
+	groups, err := decoder.DecodeV2([]byte(data), req)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, "telegraf", groups[0].Group.GetMetadata().Get("db"))
+	assert.Equal(t, "autogen", groups[0].Group.GetMetadata().Get("rp"))
+	require.Len(t, groups[0].Events, 3)
+
+	cpu := groups[0].Events[0].(*models.Metric)
+	assert.Equal(t, "cpu", cpu.GetName())
+	assert.Equal(t, uint64(1434055562000000000), cpu.GetTimestamp())
+	assert.Equal(t, "server01", cpu.GetTags().Get("host"))
+	assert.True(t, cpu.GetValue().IsSingleValue())
+	assert.Equal(t, 1.0, cpu.GetValue().GetSingleValue())
+
+	temperature := groups[0].Events[1].(*models.Metric)
+	assert.True(t, temperature.GetValue().IsMultiValues())
+	assert.Equal(t, map[string]float64{"internal": 32, "external": 100}, temperature.GetValue().GetMultiValues().Iterator())
+
+	event := groups[0].Events[2].(*models.Metric)
+	assert.Equal(t, 0, event.GetValue().GetMultiValues().Len())
+	assert.Equal(t, &models.TypedValue{Type: models.ValueTypeString, Value: "logged out"}, event.GetTypedValue().Get("msg"))
+	assert.Equal(t, &models.TypedValue{Type: models.ValueTypeBoolean, Value: true}, event.GetTypedValue().Get("fatal"))
+
+	_, err = decoder.DecodeV2([]byte("cpu value="), req)
+	assert.Error(t, err)
+}
+
+func TestDecodeV2AndEncode(t *testing.T) {
+	data := `cpu,host=server01,region=uswest value=1 1434055562000000000
+temperature,machine=unit42,type=assembly external=100,internal=32 1434055562000000035
+event,host=server01 fatal=true,msg="logged out" 1434055562000000000
+`
+	decoder := &Decoder{}
+	groups, err := decoder.DecodeV2([]byte(data), httptest.NewRequest(http.MethodPost, "/write", nil))
+	require.NoError(t, err)
+
+	c, err := converter.NewConverter(converter.ProtocolInfluxdb, converter.EncodingCustom, nil, nil)
+	require.NoError(t, err)
+	stream, _, err := c.ToByteStreamWithSelectedFieldsV2(groups[0], nil)
+	require.NoError(t, err)
+	assert.Equal(t, data, string(stream.([][]byte)[0]))
+}
@@ -0,0 +1,144 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper/decoder/common"
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	converter "github.com/alibaba/ilogtail/pkg/protocol/converter"
+)
+
+// Decoder parses the body of a JSON object, an array of JSON objects, or the newline delimited JSON objects,
+// and each object is decoded as a log. The string values are collected as they are, and the other values
+// are collected as their JSON text.
+type Decoder struct {
+	// Schema validates each object, and the request is rejected if any object does not match, so that the
+	// malformed data sent by the clients is found early rather than in the backend.
+	Schema *converter.JSONSchema
+}
+
+func (d *Decoder) Decode(data []byte, req *http.Request, tags map[string]string) (logs []*protocol.Log, err error) {
+	objects, err := d.parse(data)
+	if err != nil {
+		return nil, err
+	}
+	now := uint32(time.Now().Unix())
+	logs = make([]*protocol.Log, 0, len(objects))
+	for _, object := range objects {
+		log := &protocol.Log{Time: now, Contents: make([]*protocol.Log_Content, 0, len(object)+len(tags))}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			log.Contents = append(log.Contents, &protocol.Log_Content{Key: key, Value: valueString(object[key])})
+		}
+		for key, value := range tags {
+			log.Contents = append(log.Contents, &protocol.Log_Content{Key: key, Value: value})
+		}
+		logs = append(logs, log)
+	}
+	return logs, nil
+}
+
+// DecodeV2 decodes each object as a log whose body is the object.
+func (d *Decoder) DecodeV2(data []byte, req *http.Request) (groups []*models.PipelineGroupEvents, err error) {
+	objects, err := d.parse(data)
+	if err != nil {
+		return nil, err
+	}
+	now := uint64(time.Now().UnixNano())
+	group := &models.PipelineGroupEvents{
+		Group:  models.NewGroup(models.NewMetadata(), models.NewTags()),
+		Events: make([]models.PipelineEvent, 0, len(objects)),
+	}
+	for _, object := range objects {
+		body, err := json.Marshal(object)
+		if err != nil {
+			return nil, err
+		}
+		group.Events = append(group.Events, models.NewLog("", body, "", now, models.NewTags()))
+	}
+	return []*models.PipelineGroupEvents{group}, nil
+}
+
+func (d *Decoder) ParseRequest(res http.ResponseWriter, req *http.Request, maxBodySize int64) (data []byte, statusCode int, err error) {
+	return common.CollectBody(res, req, maxBodySize)
+}
+
+// parse returns the objects of the body, and validates them by the schema.
+func (d *Decoder) parse(data []byte) ([]map[string]json.RawMessage, error) {
+	data = bytes.TrimSpace(data)
+	var values []json.RawMessage
+	if bytes.HasPrefix(data, []byte("[")) {
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil, err
+		}
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		for {
+			var value json.RawMessage
+			if err := decoder.Decode(&value); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return nil, err
+			}
+			values = append(values, value)
+		}
+	}
+	objects := make([]map[string]json.RawMessage, 0, len(values))
+	for i, value := range values {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(value, &object); err != nil {
+			return nil, fmt.Errorf("object %d: %w", i, err)
+		}
+		if object == nil {
+			return nil, fmt.Errorf("object %d is null", i)
+		}
+		if d.Schema != nil {
+			if err := d.Schema.Validate(value); err != nil {
+				return nil, fmt.Errorf("object %d: %w", i, err)
+			}
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+func valueString(value json.RawMessage) string {
+	if value[0] == '"' {
+		var s string
+		if err := json.Unmarshal(value, &s); err == nil {
+			return s
+		}
+	}
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, value); err != nil {
+		return string(value)
+	}
+	return compacted.String()
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package json
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	converter "github.com/alibaba/ilogtail/pkg/protocol/converter"
+)
+
+func contents(log *protocol.Log) map[string]string {
+	m := make(map[string]string, len(log.Contents))
+	for _, content := range log.Contents {
+		m[content.Key] = content.Value
+	}
+	return m
+}
+
+func TestDecode(t *testing.T) {
+	decoder := &Decoder{}
+	for _, body := range []string{
+		`{"msg":"a\nb","status":200,"user":{"id": 1}}` + "\n" + `{"msg":"c","ok":true}`,
+		`[{"msg":"a\nb","status":200,"user":{"id": 1}}, {"msg":"c","ok":true}]`,
+	} {
+		logs, err := decoder.Decode([]byte(body), &http.Request{}, map[string]string{"source": "web"})
+		require.NoError(t, err, body)
+		require.Len(t, logs, 2)
+		assert.Equal(t, map[string]string{"msg": "a\nb", "status": "200", "user": `{"id":1}`, "source": "web"}, contents(logs[0]))
+		assert.Equal(t, map[string]string{"msg": "c", "ok": "true", "source": "web"}, contents(logs[1]))
+	}
+
+	_, err := decoder.Decode([]byte(`{"msg":`), &http.Request{}, nil)
+	assert.Error(t, err)
+	_, err = decoder.Decode([]byte(`"msg"`), &http.Request{}, nil)
+	assert.Error(t, err)
+	_, err = decoder.Decode([]byte(`[null]`), &http.Request{}, nil)
+	assert.Error(t, err)
+}
+
+func TestSchema(t *testing.T) {
+	schema, err := converter.NewJSONSchema([]byte(`{"type":"object","required":["msg"],"properties":{"msg":{"type":"string"},"status":{"type":"number"}}}`), "")
+	require.NoError(t, err)
+	decoder := &Decoder{Schema: schema}
+
+	_, err = decoder.Decode([]byte(`{"msg":"a","status":200}`), &http.Request{}, nil)
+	assert.NoError(t, err)
+	_, err = decoder.Decode([]byte(`{"msg":"a"}{"status":200}`), &http.Request{}, nil)
+	assert.ErrorContains(t, err, "object 1: ")
+	assert.ErrorContains(t, err, "msg")
+	_, err = decoder.Decode([]byte(`[{"msg":"a","status":"200"}]`), &http.Request{}, nil)
+	assert.ErrorContains(t, err, "object 0: ")
+	assert.ErrorContains(t, err, "status")
+}
+
+func TestDecodeV2(t *testing.T) {
+	groups, err := (&Decoder{}).DecodeV2([]byte(`{"msg":"a"} {"msg":"b"}`), &http.Request{})
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	require.Len(t, groups[0].Events, 2)
+	assert.Equal(t, `{"msg":"b"}`, string(groups[0].Events[1].(*models.Log).GetBody()))
+}
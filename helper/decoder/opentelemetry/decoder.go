@@ -349,6 +349,7 @@ func ConvertOtlpTracesToGroupEvents(traces ptrace.Traces) (groupEventsSlice []*m
 					uint64(startTs), uint64(endTs), attrs2Tags(spanAttrs), events, links)
 
 				span.ParentSpanID = otSpan.ParentSpanID().String()
+				span.TraceState = otSpan.TraceState().AsRaw()
 				span.Status = models.StatusCode(otSpan.Status().Code())
 
 				if message := otSpan.Status().Message(); len(message) > 0 {
@@ -388,6 +389,7 @@ func convertSpanLinks(srcLinks ptrace.SpanLinkSlice) []*models.SpanLink {
 			TraceState: srcLink.TraceState().AsRaw(),
 			Tags:       attrs2Tags(srcLink.Attributes()),
 		}
+		addDroppedAttributesCount(spanLink.Tags, srcLink.DroppedAttributesCount())
 		spanLinks = append(spanLinks, &spanLink)
 	}
 	return spanLinks
@@ -403,11 +405,18 @@ func convertSpanEvents(srcEvents ptrace.SpanEventSlice) []*models.SpanEvent {
 			Timestamp: int64(srcEvent.Timestamp()),
 			Tags:      attrs2Tags(srcEvent.Attributes()),
 		}
+		addDroppedAttributesCount(spanEvent.Tags, srcEvent.DroppedAttributesCount())
 		events = append(events, spanEvent)
 	}
 	return events
 }
 
+func addDroppedAttributesCount(tags models.Tags, count uint32) {
+	if count > 0 {
+		tags.Add(otlp.TagKeySpanDroppedAttrsCount, strconv.Itoa(int(count)))
+	}
+}
+
 func attrs2Tags(attributes pcommon.Map) models.Tags {
 	tags := models.NewTags()
 
@@ -469,6 +478,8 @@ func newMetricFromSumDatapoint(datapoint pmetric.NumberDataPoint, aggregationTem
 	}
 	metric.Unit = metricUnit
 	metric.Description = metricDescription
+	metric.Temporality = convertTemporality(aggregationTemporality)
+	metric.Monotonic = isMonotonic == "true"
 	metric.SetObservedTimestamp(uint64(startTimestamp))
 	return metric
 }
@@ -506,9 +517,12 @@ func newMetricFromHistogramDatapoint(datapoint pmetric.HistogramDataPoint, aggre
 	tags.Add(otlp.TagKeyMetricHistogramType, pmetric.MetricTypeHistogram.String())
 
 	// TODO:
-	// handle datapoint's Exemplars, Flags
+	// handle datapoint's Exemplars
 	multivalue := models.NewMetricMultiValue()
 	multivalue.Add(otlp.FieldCount, float64(datapoint.Count()))
+	if datapoint.Flags() != 0 {
+		multivalue.Add(otlp.FieldFlags, float64(datapoint.Flags()))
+	}
 
 	if datapoint.HasSum() {
 		multivalue.Add(otlp.FieldSum, datapoint.Sum())
@@ -526,7 +540,9 @@ func newMetricFromHistogramDatapoint(datapoint pmetric.HistogramDataPoint, aggre
 	// bucketsCounts can be 0, otherwise, #bucketCounts == #bounds + 1.
 	if bucketCounts.Len() == 0 || bucketCounts.Len() != explicitBounds.Len()+1 {
 		metric := models.NewMultiValuesMetric(metricName, models.MetricTypeHistogram, tags, timestamp, multivalue.GetMultiValues())
+		metric.Temporality = convertTemporality(aggregationTemporality)
 		metric.Unit = metricUnit
+		metric.Description = metricDescription
 		metric.SetObservedTimestamp(uint64(startTimestamp))
 		return metric
 	}
@@ -548,6 +564,7 @@ func newMetricFromHistogramDatapoint(datapoint pmetric.HistogramDataPoint, aggre
 	}
 
 	metric := models.NewMultiValuesMetric(metricName, models.MetricTypeHistogram, tags, timestamp, multivalue.GetMultiValues())
+	metric.Temporality = convertTemporality(aggregationTemporality)
 	metric.Unit = metricUnit
 	metric.Description = metricDescription
 	metric.SetObservedTimestamp(uint64(startTimestamp))
@@ -565,9 +582,12 @@ func newMetricFromExponentialHistogramDatapoint(datapoint pmetric.ExponentialHis
 	tags.Add(otlp.TagKeyMetricHistogramType, pmetric.MetricTypeExponentialHistogram.String())
 
 	// TODO:
-	// handle datapoint's Exemplars, Flags
+	// handle datapoint's Exemplars
 	multivalue := models.NewMetricMultiValue()
 	multivalue.Add(otlp.FieldCount, float64(datapoint.Count()))
+	if datapoint.Flags() != 0 {
+		multivalue.Add(otlp.FieldFlags, float64(datapoint.Flags()))
+	}
 
 	if datapoint.HasSum() {
 		multivalue.Add(otlp.FieldSum, datapoint.Sum())
@@ -599,12 +619,23 @@ func newMetricFromExponentialHistogramDatapoint(datapoint pmetric.ExponentialHis
 	multivalue.Add(otlp.FieldZeroCount, float64(datapoint.ZeroCount()))
 
 	metric := models.NewMultiValuesMetric(metricName, models.MetricTypeHistogram, tags, timestamp, multivalue.GetMultiValues())
+	metric.Temporality = convertTemporality(aggregationTemporality)
 	metric.Unit = metricUnit
 	metric.Description = metricDescription
 	metric.SetObservedTimestamp(uint64(startTimestamp))
 	return metric
 }
 
+func convertTemporality(aggregationTemporality pmetric.AggregationTemporality) models.MetricTemporality {
+	switch aggregationTemporality {
+	case pmetric.AggregationTemporalityDelta:
+		return models.MetricTemporalityDelta
+	case pmetric.AggregationTemporalityCumulative:
+		return models.MetricTemporalityCumulative
+	}
+	return models.MetricTemporalityUnspecified
+}
+
 func genExponentialHistogramValues(isPositive bool, base float64, buckets pmetric.ExponentialHistogramDataPointBuckets) map[string]float64 {
 	offset := buckets.Offset()
 	rawbucketCounts := buckets.BucketCounts()
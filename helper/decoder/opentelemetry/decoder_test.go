@@ -19,17 +19,22 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 
 	"github.com/alibaba/ilogtail/helper/decoder/common"
 	"github.com/alibaba/ilogtail/pkg/models"
+	converter "github.com/alibaba/ilogtail/pkg/protocol/converter"
 	"github.com/alibaba/ilogtail/pkg/protocol/otlp"
 )
 
@@ -779,3 +784,74 @@ var tracesOTLPFull = func() ptrace.Traces {
 	sp2.SetName("testSpan2")
 	return td
 }()
+
+// sortAttributes sorts the attributes in the JSON encoded OTLP payload by the keys, since the
+// attributes are stored in maps by the models.
+func sortAttributes(t *testing.T, data []byte) string {
+	var value interface{}
+	assert.NoError(t, json.Unmarshal(data, &value))
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for key, item := range v {
+				if attributes, ok := item.([]interface{}); ok && key == "attributes" {
+					sort.Slice(attributes, func(i, j int) bool {
+						return fmt.Sprint(attributes[i].(map[string]interface{})["key"]) < fmt.Sprint(attributes[j].(map[string]interface{})["key"])
+					})
+				}
+				walk(item)
+			}
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		}
+	}
+	walk(value)
+	sorted, err := json.Marshal(value)
+	assert.NoError(t, err)
+	return string(sorted)
+}
+
+func TestTracesRoundTrip(t *testing.T) {
+	golden, err := os.ReadFile(filepath.Join("testdata", "traces.json"))
+	assert.NoError(t, err)
+	traces, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces(golden)
+	assert.NoError(t, err)
+
+	groups, err := ConvertOtlpTracesToGroupEvents(traces)
+	assert.NoError(t, err)
+	converted := ptrace.NewTraces()
+	for _, group := range groups {
+		rsTraces := converted.ResourceSpans().AppendEmpty()
+		assert.NoError(t, converter.ConvertPipelineGroupEvenstsToOtlpEvents(group, plog.NewResourceLogs(), pmetric.NewResourceMetrics(), rsTraces))
+	}
+
+	expected, err := (&ptrace.JSONMarshaler{}).MarshalTraces(traces)
+	assert.NoError(t, err)
+	actual, err := (&ptrace.JSONMarshaler{}).MarshalTraces(converted)
+	assert.NoError(t, err)
+	assert.JSONEq(t, sortAttributes(t, expected), sortAttributes(t, actual))
+}
+
+func TestExponentialHistogramRoundTrip(t *testing.T) {
+	golden, err := os.ReadFile(filepath.Join("testdata", "exponential_histogram.json"))
+	assert.NoError(t, err)
+	metrics, err := (&pmetric.JSONUnmarshaler{}).UnmarshalMetrics(golden)
+	assert.NoError(t, err)
+
+	groups, err := ConvertOtlpMetricsToGroupEvents(metrics)
+	assert.NoError(t, err)
+	converted := pmetric.NewMetrics()
+	for _, group := range groups {
+		rsMetrics := converted.ResourceMetrics().AppendEmpty()
+		assert.NoError(t, converter.ConvertPipelineGroupEvenstsToOtlpEvents(group, plog.NewResourceLogs(), rsMetrics, ptrace.NewResourceSpans()))
+	}
+
+	expected, err := (&pmetric.JSONMarshaler{}).MarshalMetrics(metrics)
+	assert.NoError(t, err)
+	actual, err := (&pmetric.JSONMarshaler{}).MarshalMetrics(converted)
+	assert.NoError(t, err)
+	assert.JSONEq(t, sortAttributes(t, expected), sortAttributes(t, actual))
+}
@@ -0,0 +1,87 @@
+package pyroscope
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper/profile"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+const (
+	// ClockSkewModeShift shifts the time range of the skewed upload to end at the ingestion time, keeping the duration.
+	ClockSkewModeShift = "shift"
+	// ClockSkewModeClamp clamps the future timestamps of the skewed upload to the ingestion time.
+	ClockSkewModeClamp = "clamp"
+	// ClockSkewModeIngestion shifts the time ranges of all the uploads to end at the ingestion time.
+	ClockSkewModeIngestion = "ingestion"
+
+	defaultMaxClockSkewSec = 60
+
+	labelOriginalFrom  = "_original_from_"
+	labelOriginalUntil = "_original_until_"
+)
+
+// ClockSkewConfig corrects the time ranges of the profiles sent by the agents with the clocks ahead of the ingestion
+// clock, and records the original timestamps as the labels.
+type ClockSkewConfig struct {
+	// Mode is one of shift, clamp and ingestion, default to shift
+	Mode string
+	// MaxSkewSec is the tolerance of the until time of the upload ahead of the ingestion time, default to 60
+	MaxSkewSec int
+}
+
+// clockSkewCorrection is the correction of an upload.
+type clockSkewCorrection struct {
+	mode   string
+	now    time.Time
+	offset time.Duration
+	limit  time.Time
+}
+
+// correct detects the skew of the time range of the upload, and corrects the time range of the meta, the returned
+// correction should be applied to the parsed logs, which is nil if the upload is not skewed.
+func (c *ClockSkewConfig) correct(meta *profile.Meta, now time.Time) *clockSkewCorrection {
+	maxSkew := time.Duration(c.MaxSkewSec) * time.Second
+	if c.MaxSkewSec <= 0 {
+		maxSkew = defaultMaxClockSkewSec * time.Second
+	}
+	mode := c.Mode
+	if mode == "" {
+		mode = ClockSkewModeShift
+	}
+	if mode != ClockSkewModeIngestion && meta.EndTime.Sub(now) <= maxSkew {
+		return nil
+	}
+	meta.Tags[labelOriginalFrom] = strconv.FormatInt(meta.StartTime.Unix(), 10)
+	meta.Tags[labelOriginalUntil] = strconv.FormatInt(meta.EndTime.Unix(), 10)
+	correction := &clockSkewCorrection{mode: mode, now: now, limit: now.Add(maxSkew)}
+	if mode == ClockSkewModeClamp {
+		if meta.StartTime.After(now) {
+			meta.StartTime = now
+		}
+		meta.EndTime = now
+	} else {
+		correction.offset = now.Sub(meta.EndTime)
+		meta.StartTime = meta.StartTime.Add(correction.offset)
+		meta.EndTime = now
+	}
+	return correction
+}
+
+// apply corrects the times of the logs still ahead of the ingestion time, which are taken from the profiles
+// rather than the time range of the upload, such as the time of the pprof profiles.
+func (c *clockSkewCorrection) apply(logs []*protocol.Log) {
+	for _, log := range logs {
+		t := time.Unix(int64(log.Time), 0)
+		if !t.After(c.limit) {
+			continue
+		}
+		if c.mode == ClockSkewModeClamp {
+			t = c.now
+		} else {
+			t = t.Add(c.offset)
+		}
+		log.Time = uint32(t.Unix())
+	}
+}
@@ -0,0 +1,50 @@
+package pyroscope
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/helper/profile"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+func TestClockSkewCorrection(t *testing.T) {
+	now := time.Unix(1680000000, 0)
+	newMeta := func(from, until int64) *profile.Meta {
+		return &profile.Meta{Tags: map[string]string{"__name__": "shop"}, StartTime: time.Unix(from, 0), EndTime: time.Unix(until, 0)}
+	}
+
+	// within the tolerance
+	meta := newMeta(1680000000, 1680000030)
+	require.Nil(t, (&ClockSkewConfig{}).correct(meta, now))
+	require.Equal(t, int64(1680000030), meta.EndTime.Unix())
+	require.NotContains(t, meta.Tags, labelOriginalUntil)
+
+	meta = newMeta(1680003590, 1680003600)
+	c := (&ClockSkewConfig{}).correct(meta, now)
+	require.NotNil(t, c)
+	require.Equal(t, int64(1679999990), meta.StartTime.Unix())
+	require.Equal(t, int64(1680000000), meta.EndTime.Unix())
+	require.Equal(t, "1680003590", meta.Tags[labelOriginalFrom])
+	require.Equal(t, "1680003600", meta.Tags[labelOriginalUntil])
+	logs := []*protocol.Log{{Time: 1679999990}, {Time: 1680003595}}
+	c.apply(logs)
+	require.Equal(t, uint32(1679999990), logs[0].Time)
+	require.Equal(t, uint32(1679999995), logs[1].Time)
+
+	meta = newMeta(1680003590, 1680003600)
+	c = (&ClockSkewConfig{Mode: ClockSkewModeClamp}).correct(meta, now)
+	require.Equal(t, int64(1680000000), meta.StartTime.Unix())
+	require.Equal(t, int64(1680000000), meta.EndTime.Unix())
+	logs = []*protocol.Log{{Time: 1680003595}}
+	c.apply(logs)
+	require.Equal(t, uint32(1680000000), logs[0].Time)
+
+	// the ingestion mode corrects the uploads in the past too
+	meta = newMeta(1679999880, 1679999890)
+	require.NotNil(t, (&ClockSkewConfig{Mode: ClockSkewModeIngestion}).correct(meta, now))
+	require.Equal(t, int64(1679999990), meta.StartTime.Unix())
+	require.Equal(t, int64(1680000000), meta.EndTime.Unix())
+}
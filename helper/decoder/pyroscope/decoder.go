@@ -2,28 +2,66 @@ package pyroscope
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pyroscope-io/pyroscope/pkg/storage/segment"
 	"github.com/pyroscope-io/pyroscope/pkg/util/attime"
 
 	"github.com/alibaba/ilogtail/helper/decoder/common"
+	"github.com/alibaba/ilogtail/helper/k8smeta"
 	"github.com/alibaba/ilogtail/helper/profile"
 	"github.com/alibaba/ilogtail/helper/profile/pyroscope/jfr"
+	"github.com/alibaba/ilogtail/helper/profile/pyroscope/nettrace"
+	"github.com/alibaba/ilogtail/helper/profile/pyroscope/perfetto"
 	"github.com/alibaba/ilogtail/helper/profile/pyroscope/pprof"
 	"github.com/alibaba/ilogtail/helper/profile/pyroscope/raw"
+	"github.com/alibaba/ilogtail/helper/profile/pyroscope/v8"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/models"
 	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
-const AlarmType = "PYROSCOPE_ALARM"
+const AlarmType = util.AlarmPyroscope
+
+// ParseTimeoutAlarmType is the alarm of the uploads aborted by the parse timeout
+const ParseTimeoutAlarmType = util.AlarmProfileParseTimeout
 
 type Decoder struct {
+	// TrimPathPrefixes are the path prefixes stripped from the file names of the pprof profiles, keyed by the
+	// application name, and the prefixes of "*" are applied to all the applications.
+	TrimPathPrefixes map[string][]string
+	// Diff appends the deltas of the stacks between the consecutive uploads of the same segment key
+	Diff bool
+	// HeapDumpTriggers emit the heap dump trigger events when the memory profiles exceed the thresholds, keyed by
+	// the application name, and the trigger of "*" is applied to the applications without their own triggers.
+	HeapDumpTriggers map[string]*HeapDumpTrigger
+	// ClockSkew corrects the time ranges of the uploads ahead of the ingestion time
+	ClockSkew *ClockSkewConfig
+	// ParseTimeoutSec limits the wall-clock time of the decompression and the parsing of an upload, and the
+	// upload exceeding it is aborted with the alarm. Zero means no limit.
+	ParseTimeoutSec int
+	// PodLookup adds the kubernetes labels of the pods sending the uploads when it's not nil
+	PodLookup PodLookup
+
+	initOnce sync.Once
+	differ   *profileDiffer
+	triggers *heapDumpTriggers
+}
+
+// Close releases the kubernetes metadata cache of PodLookup when it's shared by k8smeta.GetMetaManager.
+func (d *Decoder) Close() error {
+	if manager, ok := d.PodLookup.(*k8smeta.MetaManager); ok {
+		k8smeta.ReleaseMetaManager(manager)
+		d.PodLookup = nil
+	}
+	return nil
 }
 
 func (d *Decoder) DecodeV2(data []byte, req *http.Request) (groups []*models.PipelineGroupEvents, err error) {
@@ -36,7 +74,46 @@ func (d *Decoder) Decode(data []byte, req *http.Request, tags map[string]string)
 	if err != nil {
 		return nil, err
 	}
-	return in.Profile.Parse(context.Background(), &in.Metadata, tags)
+	if d.PodLookup != nil {
+		addPodLabels(d.PodLookup, req, in.Metadata.Tags)
+	}
+	var correction *clockSkewCorrection
+	if d.ClockSkew != nil {
+		correction = d.ClockSkew.correct(&in.Metadata, time.Now())
+	}
+	ctx := req.Context()
+	if d.ParseTimeoutSec > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(d.ParseTimeoutSec)*time.Second)
+		defer cancel()
+	}
+	start := time.Now()
+	if logs, err = in.Profile.Parse(ctx, &in.Metadata, tags); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			logger.Warning(ctx, ParseTimeoutAlarmType, "app", in.Metadata.Tags["__name__"], "format", req.URL.Query().Get("format"),
+				"contentType", req.Header.Get("Content-Type"), "size", len(data), "elapsed", time.Since(start), "timeout", d.ParseTimeoutSec)
+		}
+		return nil, err
+	}
+	if correction != nil {
+		correction.apply(logs)
+	}
+	d.initOnce.Do(func() {
+		d.differ = newProfileDiffer()
+		d.triggers = newHeapDumpTriggers()
+	})
+	if d.Diff {
+		d.differ.diff(in.Metadata.Tags, logs)
+	}
+	profile.RecordRecentProfile(in.Metadata.Tags["__name__"], logs)
+	trigger, ok := d.HeapDumpTriggers[in.Metadata.Tags["__name__"]]
+	if !ok {
+		trigger = d.HeapDumpTriggers["*"]
+	}
+	if trigger != nil {
+		logs = append(logs, d.triggers.check(trigger, &in.Metadata, tags, logs)...)
+	}
+	return logs, nil
 }
 
 func (d *Decoder) extractRawInput(data []byte, req *http.Request) (*profile.Input, error) {
@@ -48,13 +125,37 @@ func (d *Decoder) extractRawInput(data []byte, req *http.Request) (*profile.Inpu
 	var category string
 	switch {
 	case ft == profile.FormatPprof:
-		in.Profile = pprof.NewRawProfile(data, "")
+		in.Profile = d.newPprofProfile(data, "", in.Metadata.Tags)
 		category = "pprof"
 	case ft == profile.FormatJFR:
 		in.Profile = jfr.NewRawProfile(data, ct)
 		category = "JFR"
+	case ft == profile.FormatNetTrace:
+		if in.Metadata.SpyName == "unknown" {
+			in.Metadata.SpyName = profile.PyroscopeDotnet
+		}
+		in.Profile = nettrace.NewRawProfile(data)
+		category = "nettrace"
+	case ft == profile.FormatV8CPUProfile:
+		if in.Metadata.SpyName == "unknown" {
+			in.Metadata.SpyName = profile.PyroscopeNodeJs
+		}
+		in.Profile = v8.NewRawProfile(data)
+		category = "cpuprofile"
+	case ft == profile.FormatPerfetto:
+		in.Profile = perfetto.NewRawProfile(data)
+		category = "perfetto"
+	case ft == profile.FormatPySpy, ft == profile.FormatRbSpy:
+		if in.Metadata.SpyName == "unknown" {
+			in.Metadata.SpyName = profile.PyroscopePython
+			if ft == profile.FormatRbSpy {
+				in.Metadata.SpyName = profile.PyroscopeRuby
+			}
+		}
+		in.Profile = raw.NewRawProfile(data, ft)
+		category = string(ft)
 	case strings.Contains(ct, "multipart/form-data"):
-		in.Profile = pprof.NewRawProfile(data, ct)
+		in.Profile = d.newPprofProfile(data, ct, in.Metadata.Tags)
 		category = "pprof"
 	case ft == profile.FormatTrie, ct == "binary/octet-stream+trie":
 		in.Profile = raw.NewRawProfile(data, profile.FormatTrie)
@@ -73,6 +174,15 @@ func (d *Decoder) extractRawInput(data []byte, req *http.Request) (*profile.Inpu
 	return in, nil
 }
 
+func (d *Decoder) newPprofProfile(data []byte, format string, tags map[string]string) *pprof.RawProfile {
+	p := pprof.NewRawProfile(data, format)
+	if len(d.TrimPathPrefixes) > 0 {
+		prefixes := d.TrimPathPrefixes[tags["__name__"]]
+		p.TrimPathPrefixes = append(append([]string(nil), prefixes...), d.TrimPathPrefixes["*"]...)
+	}
+	return p
+}
+
 func (d *Decoder) ParseRequest(res http.ResponseWriter, req *http.Request, maxBodySize int64) (data []byte, statusCode int, err error) {
 	return common.CollectBody(res, req, maxBodySize)
 }
@@ -2,8 +2,11 @@ package pyroscope
 
 import (
 	"bytes"
+	"context"
 	"net/http"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/alibaba/ilogtail/plugins/test"
 
@@ -61,3 +64,44 @@ func TestDecoder_DecodeTire(t *testing.T) {
 	require.Equal(t, test.ReadLogVal(log, "labels"), "{\"__name__\":\"demo\",\"a\":\"b\",\"cluster\":\"sls-mall\"}")
 	require.Equal(t, test.ReadLogVal(log, "val"), "1.00")
 }
+
+func TestDecoder_Diff(t *testing.T) {
+	d := &Decoder{Diff: true}
+	upload := func(values map[string]uint64) map[string]string {
+		trie := transporttrie.New()
+		for k, v := range values {
+			trie.Insert([]byte(k), v)
+		}
+		var buf bytes.Buffer
+		trie.Serialize(&buf)
+		request, err := http.NewRequest("POST", "http://localhost:8080?aggregationType=sum&from=1673495500&name=demo.cpu{a=b}&sampleRate=100&spyName=ebpfspy&units=samples&until=1673495510", &buf)
+		require.NoError(t, err)
+		request.Header.Set("Content-Type", "binary/octet-stream+trie")
+		logs, err := d.Decode(buf.Bytes(), request, map[string]string{})
+		require.NoError(t, err)
+		diffs := make(map[string]string)
+		for _, log := range logs {
+			diffs[test.ReadLogVal(log, "name")] = test.ReadLogVal(log, "diff")
+		}
+		return diffs
+	}
+	require.Equal(t, map[string]string{"baz": "3.00", "qux": "1.00"}, upload(map[string]uint64{"foo;bar;baz": 3, "foo;bar;qux": 1}))
+	require.Equal(t, map[string]string{"baz": "-1.00", "qux": "0.00", "zoo": "4.00"}, upload(map[string]uint64{"foo;bar;baz": 2, "foo;bar;qux": 1, "zoo": 4}))
+}
+
+func TestDecoder_ParseTimeout(t *testing.T) {
+	data, err := os.ReadFile("../../profile/pyroscope/pprof/testdata/cpu.pb.gz")
+	require.NoError(t, err)
+	d := &Decoder{ParseTimeoutSec: 10}
+	request, err := http.NewRequest("POST", "http://localhost:8080?aggregationType=sum&from=1673495500&name=demo.cpu{a=b}&spyName=gospy&format=pprof&until=1673495510", bytes.NewReader(data))
+	require.NoError(t, err)
+	logs, err := d.Decode(data, request, map[string]string{})
+	require.NoError(t, err)
+	require.NotEmpty(t, logs)
+
+	// the deadline of the request is exceeded before the parsing
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	_, err = d.Decode(data, request.WithContext(ctx), map[string]string{})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,90 @@
+package pyroscope
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pyroscope-io/pyroscope/pkg/storage/segment"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+// diffExpiration is the duration after which the previous upload of a segment key is forgotten.
+const diffExpiration = 10 * time.Minute
+
+type stackValues struct {
+	values  map[string]float64
+	updated time.Time
+}
+
+// profileDiffer remembers the values of the stacks of the last upload of every segment key, and computes the deltas
+// of the stacks between the consecutive uploads.
+type profileDiffer struct {
+	mu       sync.Mutex
+	previous map[string]*stackValues
+	nowFunc  func() time.Time
+}
+
+func newProfileDiffer() *profileDiffer {
+	return &profileDiffer{
+		previous: make(map[string]*stackValues),
+		nowFunc:  time.Now,
+	}
+}
+
+// diff appends the "diff" column to the profile logs, which is the value of the stack minus the value of the same
+// stack in the previous upload of the segment key, the stacks absent from the previous upload are diffed against 0,
+// and the stacks absent from the current upload are not emitted.
+func (d *profileDiffer) diff(tags map[string]string, logs []*protocol.Log) {
+	keyTags := make(map[string]string, len(tags))
+	for k, v := range tags {
+		// the original timestamps of the skewed uploads differ between the uploads
+		if k != labelOriginalFrom && k != labelOriginalUntil {
+			keyTags[k] = v
+		}
+	}
+	key := segment.NewKey(keyTags).Normalized()
+	current := make(map[string]float64)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.nowFunc()
+	var previous map[string]float64
+	if p, ok := d.previous[key]; ok {
+		previous = p.values
+	}
+	for _, log := range logs {
+		var stackID, labels, valueType, val string
+		for _, cont := range log.Contents {
+			switch cont.Key {
+			case "stackID":
+				stackID = cont.Value
+			case "labels":
+				labels = cont.Value
+			case "valueTypes":
+				valueType = cont.Value
+			case "val":
+				val = cont.Value
+			}
+		}
+		if stackID == "" || val == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			continue
+		}
+		stackKey := valueType + "|" + labels + "|" + stackID
+		current[stackKey] += v
+		log.Contents = append(log.Contents, &protocol.Log_Content{
+			Key:   "diff",
+			Value: strconv.FormatFloat(v-previous[stackKey], 'f', 2, 64),
+		})
+	}
+	for k, p := range d.previous {
+		if now.Sub(p.updated) > diffExpiration {
+			delete(d.previous, k)
+		}
+	}
+	d.previous[key] = &stackValues{values: current, updated: now}
+}
@@ -0,0 +1,37 @@
+package pyroscope
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/alibaba/ilogtail/helper/k8smeta"
+)
+
+// PodLookup resolves the pod of the client ip, which is implemented by k8smeta.MetaManager.
+type PodLookup interface {
+	PodByIP(ip string) *k8smeta.PodMeta
+}
+
+// addPodLabels adds the namespace, pod, node and workload labels of the pod sending @req to @tags, the labels set by
+// the agents are kept.
+func addPodLabels(lookup PodLookup, req *http.Request, tags map[string]string) {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	pod := lookup.PodByIP(host)
+	if pod == nil {
+		return
+	}
+	for k, v := range map[string]string{
+		"namespace":     pod.Namespace,
+		"pod":           pod.Name,
+		"node":          pod.NodeName,
+		"workload_kind": pod.WorkloadKind,
+		"workload_name": pod.WorkloadName,
+	} {
+		if _, ok := tags[k]; !ok && v != "" {
+			tags[k] = v
+		}
+	}
+}
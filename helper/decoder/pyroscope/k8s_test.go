@@ -0,0 +1,45 @@
+package pyroscope
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/pyroscope-io/pyroscope/pkg/structs/transporttrie"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/helper/k8smeta"
+	"github.com/alibaba/ilogtail/plugins/test"
+)
+
+type mockPodLookup map[string]*k8smeta.PodMeta
+
+func (m mockPodLookup) PodByIP(ip string) *k8smeta.PodMeta {
+	return m[ip]
+}
+
+func TestDecoder_PodLabels(t *testing.T) {
+	d := &Decoder{PodLookup: mockPodLookup{
+		"10.0.0.1": {Namespace: "default", Name: "web-0", NodeName: "node-1", WorkloadKind: "StatefulSet", WorkloadName: "web"},
+	}}
+	upload := func(remoteAddr, name string) string {
+		trie := transporttrie.New()
+		trie.Insert([]byte("foo;bar"), 1)
+		var buf bytes.Buffer
+		trie.Serialize(&buf)
+		request, err := http.NewRequest("POST", "http://localhost:8080?aggregationType=sum&from=1673495500&name="+name+"&spyName=gospy&units=samples&until=1673495510", &buf)
+		require.NoError(t, err)
+		request.Header.Set("Content-Type", "binary/octet-stream+trie")
+		request.RemoteAddr = remoteAddr
+		logs, err := d.Decode(buf.Bytes(), request, map[string]string{})
+		require.NoError(t, err)
+		require.Len(t, logs, 1)
+		return test.ReadLogVal(logs[0], "labels")
+	}
+	require.Equal(t, `{"__name__":"demo","namespace":"default","node":"node-1","pod":"web-0","workload_kind":"StatefulSet","workload_name":"web"}`,
+		upload("10.0.0.1:34567", "demo.cpu"))
+	// the labels of the agents are kept
+	require.Equal(t, `{"__name__":"demo","namespace":"default","node":"node-1","pod":"custom","workload_kind":"StatefulSet","workload_name":"web"}`,
+		upload("10.0.0.1:34567", "demo.cpu{pod=custom}"))
+	require.Equal(t, `{"__name__":"demo"}`, upload("10.0.0.2:34567", "demo.cpu"))
+}
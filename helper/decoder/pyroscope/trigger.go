@@ -0,0 +1,138 @@
+package pyroscope
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper/profile"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	triggerAlarmType          = util.AlarmProfileTrigger
+	defaultTriggerCooldownSec = 600
+	actuatorTimeout           = 5 * time.Second
+)
+
+// HeapDumpTrigger emits the trigger event when the total value of a memory value type of the profile, such as
+// inuse_space or alloc_space, exceeds the threshold, so that the automation could capture the heap dump while the
+// problem is live.
+type HeapDumpTrigger struct {
+	// Thresholds of the value types, such as {"inuse_space": 1073741824}
+	Thresholds map[string]float64
+	// CooldownSec is the minimal interval of the triggers of the same value type, default to 600
+	CooldownSec int
+	// ActuatorURL is requested by POST with the trigger event as the JSON body when triggered if not empty, such as
+	// the local actuator endpoint of the application or a Jolokia agent bridging the JMX
+	ActuatorURL string
+}
+
+type heapDumpTriggers struct {
+	mu            sync.Mutex
+	lastTriggered map[string]time.Time
+	client        *http.Client
+	nowFunc       func() time.Time
+}
+
+func newHeapDumpTriggers() *heapDumpTriggers {
+	return &heapDumpTriggers{
+		lastTriggered: make(map[string]time.Time),
+		client:        &http.Client{Timeout: actuatorTimeout},
+		nowFunc:       time.Now,
+	}
+}
+
+// check returns the trigger events of the value types exceeding the thresholds of the trigger.
+func (h *heapDumpTriggers) check(trigger *HeapDumpTrigger, meta *profile.Meta, tags map[string]string, logs []*protocol.Log) []*protocol.Log {
+	totals := make(map[string]float64)
+	for _, log := range logs {
+		var valueType, val string
+		for _, cont := range log.Contents {
+			switch cont.Key {
+			case "valueTypes":
+				valueType = cont.Value
+			case "val":
+				val = cont.Value
+			}
+		}
+		if _, ok := trigger.Thresholds[valueType]; !ok {
+			continue
+		}
+		if v, err := strconv.ParseFloat(val, 64); err == nil {
+			totals[valueType] += v
+		}
+	}
+	cooldown := time.Duration(trigger.CooldownSec) * time.Second
+	if trigger.CooldownSec <= 0 {
+		cooldown = defaultTriggerCooldownSec * time.Second
+	}
+	app := meta.Tags["__name__"]
+	labels := make(map[string]string, len(meta.Tags)+len(tags))
+	for k, v := range meta.Tags {
+		labels[k] = v
+	}
+	for k, v := range tags {
+		labels[k] = v
+	}
+	b, _ := json.Marshal(labels)
+
+	var events []*protocol.Log
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := h.nowFunc()
+	for valueType, total := range totals {
+		threshold := trigger.Thresholds[valueType]
+		if total <= threshold {
+			continue
+		}
+		key := app + "|" + valueType
+		if last, ok := h.lastTriggered[key]; ok && now.Sub(last) < cooldown {
+			continue
+		}
+		h.lastTriggered[key] = now
+		event := &protocol.Log{Time: uint32(now.Unix())}
+		for _, kv := range [][2]string{
+			{"name", "heap_dump"},
+			{"app", app},
+			{"language", meta.SpyName},
+			{"type", profile.DetectProfileType(valueType).String()},
+			{"dataType", "Trigger"},
+			{"valueTypes", valueType},
+			{"val", strconv.FormatFloat(total, 'f', 2, 64)},
+			{"threshold", strconv.FormatFloat(threshold, 'f', 2, 64)},
+			{"labels", string(b)},
+		} {
+			event.Contents = append(event.Contents, &protocol.Log_Content{Key: kv[0], Value: kv[1]})
+		}
+		events = append(events, event)
+		if trigger.ActuatorURL != "" {
+			go h.callActuator(trigger.ActuatorURL, event)
+		}
+	}
+	return events
+}
+
+func (h *heapDumpTriggers) callActuator(url string, event *protocol.Log) {
+	body := make(map[string]string, len(event.Contents))
+	for _, cont := range event.Contents {
+		body[cont.Key] = cont.Value
+	}
+	b, _ := json.Marshal(body)
+	resp, err := h.client.Post(url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		logger.Warning(context.Background(), triggerAlarmType, "call heap dump actuator error", err, "url", url)
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		logger.Warning(context.Background(), triggerAlarmType, "call heap dump actuator error", fmt.Sprintf("status code %d", resp.StatusCode), "url", url)
+	}
+}
@@ -0,0 +1,68 @@
+package pyroscope
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/helper/profile"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/test"
+)
+
+func memLog(valueType, val string) *protocol.Log {
+	return &protocol.Log{Contents: []*protocol.Log_Content{
+		{Key: "valueTypes", Value: valueType},
+		{Key: "val", Value: val},
+	}}
+}
+
+func TestHeapDumpTrigger(t *testing.T) {
+	called := make(chan map[string]string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		called <- body
+	}))
+	defer server.Close()
+
+	now := time.Unix(1680000000, 0)
+	h := newHeapDumpTriggers()
+	h.nowFunc = func() time.Time { return now }
+	trigger := &HeapDumpTrigger{
+		Thresholds:  map[string]float64{"inuse_space": 1000},
+		CooldownSec: 60,
+		ActuatorURL: server.URL,
+	}
+	meta := &profile.Meta{Tags: map[string]string{"__name__": "shop"}, SpyName: "java"}
+	logs := []*protocol.Log{memLog("inuse_space", "600.00"), memLog("inuse_space", "500.00"), memLog("alloc_space", "5000.00")}
+
+	events := h.check(trigger, meta, map[string]string{"cluster": "c1"}, logs)
+	require.Len(t, events, 1)
+	require.Equal(t, "heap_dump", test.ReadLogVal(events[0], "name"))
+	require.Equal(t, "shop", test.ReadLogVal(events[0], "app"))
+	require.Equal(t, "profile_mem", test.ReadLogVal(events[0], "type"))
+	require.Equal(t, "Trigger", test.ReadLogVal(events[0], "dataType"))
+	require.Equal(t, "1100.00", test.ReadLogVal(events[0], "val"))
+	require.Equal(t, "1000.00", test.ReadLogVal(events[0], "threshold"))
+	require.Equal(t, "{\"__name__\":\"shop\",\"cluster\":\"c1\"}", test.ReadLogVal(events[0], "labels"))
+	select {
+	case body := <-called:
+		require.Equal(t, "inuse_space", body["valueTypes"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("actuator not called")
+	}
+
+	// in the cooldown
+	now = now.Add(30 * time.Second)
+	require.Len(t, h.check(trigger, meta, nil, logs), 0)
+	now = now.Add(31 * time.Second)
+	require.Len(t, h.check(trigger, meta, nil, logs), 1)
+	// below the threshold
+	now = now.Add(time.Hour)
+	require.Len(t, h.check(trigger, meta, nil, logs[:1]), 0)
+}
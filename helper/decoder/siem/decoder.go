@@ -0,0 +1,296 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package siem
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper/decoder/common"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	converter "github.com/alibaba/ilogtail/pkg/protocol/converter"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const tagPrefix = "__tag__:"
+
+// Decoder parses the security events in the Common Event Format (CEF) or the Log Event Extended Format (LEEF),
+// one event per line. The syslog header before `CEF:` or `LEEF:` is skipped. The header fields are parsed into
+// the keys of converter.CEFHeaderKeys or converter.LEEFHeaderKeys, and the extensions into their own keys.
+type Decoder struct {
+	Format string
+	// FieldMapping maps the CEF or LEEF keys to the log fields, the field with the prefix tag. is decoded as a tag.
+	FieldMapping map[string]string
+}
+
+type field struct {
+	key   string
+	value string
+}
+
+// event is a parsed line.
+type event struct {
+	line   []byte
+	fields []field
+}
+
+func (d *Decoder) Decode(data []byte, req *http.Request, tags map[string]string) (logs []*protocol.Log, err error) {
+	now := time.Now()
+	events := d.parseEvents(data)
+	logs = make([]*protocol.Log, 0, len(events))
+	for _, e := range events {
+		logs = append(logs, d.toLog(e.fields, now))
+	}
+	return logs, nil
+}
+
+// DecodeV2 decodes each event as a log whose body is the line and whose tags are the fields, the fields
+// mapped with the prefix tag. are the tags without the prefix.
+func (d *Decoder) DecodeV2(data []byte, req *http.Request) (groups []*models.PipelineGroupEvents, err error) {
+	now := time.Now()
+	events := d.parseEvents(data)
+	group := &models.PipelineGroupEvents{
+		Group:  models.NewGroup(models.NewMetadata(), models.NewTags()),
+		Events: make([]models.PipelineEvent, 0, len(events)),
+	}
+	for _, e := range events {
+		tags := models.NewTags()
+		for _, f := range e.fields {
+			key, _ := d.mapKey(f.key)
+			tags.Add(key, f.value)
+		}
+		timestamp := uint64(eventTime(e.fields, now).UnixNano())
+		group.Events = append(group.Events, models.NewLog("", e.line, "", timestamp, tags))
+	}
+	return []*models.PipelineGroupEvents{group}, nil
+}
+
+func (d *Decoder) ParseRequest(res http.ResponseWriter, req *http.Request, maxBodySize int64) (data []byte, statusCode int, err error) {
+	return common.CollectBody(res, req, maxBodySize)
+}
+
+// parseEvents skips the invalid lines, as one bad line should not drop the whole batch. The decoder is shared
+// by the connections, so only the first invalid line of the batch is alarmed.
+func (d *Decoder) parseEvents(data []byte) []event {
+	alarmed := false
+	lines := bytes.Split(data, []byte("\n"))
+	events := make([]event, 0, len(lines))
+	for _, line := range lines {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var fields []field
+		var err error
+		if d.Format == common.ProtocolLEEF {
+			fields, err = parseLEEF(string(line))
+		} else {
+			fields, err = parseCEF(string(line))
+		}
+		if err != nil {
+			logger.Debug(context.Background(), "parse security event error", err)
+			if !alarmed {
+				logger.Error(context.Background(), util.AlarmSIEMParse, "parse err", err)
+				alarmed = true
+			}
+			continue
+		}
+		events = append(events, event{line: line, fields: fields})
+	}
+	return events
+}
+
+// toLog uses the event time of eventTime, and the fields mapped with the prefix tag. are the tags.
+func (d *Decoder) toLog(fields []field, now time.Time) *protocol.Log {
+	log := &protocol.Log{
+		Time:     uint32(eventTime(fields, now).Unix()),
+		Contents: make([]*protocol.Log_Content, 0, len(fields)),
+	}
+	for _, f := range fields {
+		key, isTag := d.mapKey(f.key)
+		if isTag {
+			key = tagPrefix + key
+		}
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: key, Value: f.value})
+	}
+	return log
+}
+
+// mapKey returns the log field of the key by FieldMapping, and whether it's a tag.
+func (d *Decoder) mapKey(key string) (string, bool) {
+	if mapped, ok := d.FieldMapping[key]; ok && mapped != "" {
+		if strings.HasPrefix(mapped, "tag.") {
+			return mapped[len("tag."):], true
+		}
+		return mapped, false
+	}
+	return key, false
+}
+
+// eventTime returns the event time in milliseconds of rt in CEF or devTime in LEEF if present, or the receiving time.
+func eventTime(fields []field, now time.Time) time.Time {
+	for _, f := range fields {
+		if f.key == "rt" || f.key == "devTime" {
+			if ms, err := strconv.ParseInt(f.value, 10, 64); err == nil && ms > 0 {
+				return time.UnixMilli(ms)
+			}
+		}
+	}
+	return now
+}
+
+// parseCEF parses `CEF:Version|Device Vendor|Device Product|Device Version|Signature ID|Name|Severity|Extension`,
+// in which the pipes and the backslashes are escaped in the header, and the extension is the space separated
+// key=value pairs whose values could contain spaces.
+func parseCEF(line string) ([]field, error) {
+	start := strings.Index(line, "CEF:")
+	if start < 0 {
+		return nil, fmt.Errorf("invalid cef line: %s", line)
+	}
+	rest := line[start+len("CEF:"):]
+	fields := make([]field, 0, len(converter.CEFHeaderKeys)+8)
+	for _, key := range converter.CEFHeaderKeys {
+		value, next, ok := cutCEFHeader(rest)
+		if !ok {
+			return nil, fmt.Errorf("incomplete cef header: %s", line)
+		}
+		fields = append(fields, field{key: key, value: value})
+		rest = next
+	}
+	return append(fields, parseCEFExtension(rest)...), nil
+}
+
+// cutCEFHeader cuts the header field before the first unescaped pipe.
+func cutCEFHeader(s string) (value, rest string, ok bool) {
+	var builder strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s) && (s[i+1] == '|' || s[i+1] == '\\'):
+			builder.WriteByte(s[i+1])
+			i++
+		case c == '|':
+			return builder.String(), s[i+1:], true
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return "", "", false
+}
+
+// parseCEFExtension finds the unescaped equal signs, the key of each is the word before it, and the value
+// spans to the key of the next one. An equal sign without a key before it is kept in the value.
+func parseCEFExtension(ext string) []field {
+	type pair struct{ keyStart, eq int }
+	pairs := make([]pair, 0, 8)
+	valueStart := 0
+	for i := 0; i < len(ext); i++ {
+		switch ext[i] {
+		case '\\':
+			i++
+		case '=':
+			keyStart := strings.LastIndexByte(ext[:i], ' ') + 1
+			if keyStart < valueStart || keyStart == i {
+				continue
+			}
+			pairs = append(pairs, pair{keyStart: keyStart, eq: i})
+			valueStart = i + 1
+		}
+	}
+
+	fields := make([]field, 0, len(pairs))
+	for j, p := range pairs {
+		end := len(ext)
+		if j+1 < len(pairs) {
+			end = pairs[j+1].keyStart
+		}
+		fields = append(fields, field{
+			key:   ext[p.keyStart:p.eq],
+			value: unescapeCEFValue(strings.TrimRight(ext[p.eq+1:end], " ")),
+		})
+	}
+	return fields
+}
+
+var cefValueUnescaper = strings.NewReplacer(`\\`, `\`, `\=`, `=`, `\n`, "\n", `\r`, "\r")
+
+func unescapeCEFValue(s string) string {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s
+	}
+	return cefValueUnescaper.Replace(s)
+}
+
+// parseLEEF parses `LEEF:1.0|Vendor|Product|Version|EventID|Attributes` delimited by tabs, or
+// `LEEF:2.0|Vendor|Product|Version|EventID|Delimiter|Attributes` with the delimiter character such as ^ or its
+// hex code such as x5E, which is tab if empty.
+func parseLEEF(line string) ([]field, error) {
+	start := strings.Index(line, "LEEF:")
+	if start < 0 {
+		return nil, fmt.Errorf("invalid leef line: %s", line)
+	}
+	parts := strings.SplitN(line[start+len("LEEF:"):], "|", len(converter.LEEFHeaderKeys)+1)
+	if len(parts) <= len(converter.LEEFHeaderKeys) {
+		return nil, fmt.Errorf("incomplete leef header: %s", line)
+	}
+	fields := make([]field, 0, len(converter.LEEFHeaderKeys)+8)
+	for i, key := range converter.LEEFHeaderKeys {
+		fields = append(fields, field{key: key, value: parts[i]})
+	}
+	attributes := parts[len(converter.LEEFHeaderKeys)]
+	delimiter := "\t"
+	if strings.HasPrefix(parts[0], "2") {
+		d, rest, ok := strings.Cut(attributes, "|")
+		if !ok {
+			return nil, fmt.Errorf("missing leef delimiter: %s", line)
+		}
+		var err error
+		if delimiter, err = parseLEEFDelimiter(d); err != nil {
+			return nil, err
+		}
+		attributes = rest
+	}
+	for _, attribute := range strings.Split(attributes, delimiter) {
+		key, value, ok := strings.Cut(attribute, "=")
+		if !ok || len(key) == 0 {
+			continue
+		}
+		fields = append(fields, field{key: key, value: value})
+	}
+	return fields, nil
+}
+
+func parseLEEFDelimiter(d string) (string, error) {
+	switch {
+	case d == "":
+		return "\t", nil
+	case len(d) == 1:
+		return d, nil
+	case strings.HasPrefix(d, "0x") || strings.HasPrefix(d, "x"):
+		code, err := strconv.ParseUint(d[strings.IndexByte(d, 'x')+1:], 16, 8)
+		if err != nil || code == 0 {
+			return "", fmt.Errorf("invalid leef delimiter: %s", d)
+		}
+		return string(rune(code)), nil
+	}
+	return "", errors.New("invalid leef delimiter: " + d)
+}
@@ -0,0 +1,115 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package siem
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/helper/decoder/common"
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+func TestParseCEF(t *testing.T) {
+	fields, err := parseCEF(`Sep 19 08:26:10 host CEF:0|Security|threatmanager|1.0|100|detected a \| in message|10|src=10.0.0.1 act=blocked a \= dst=2.1.2.2 msg=line1\nline2 url=http://a?b=c`)
+	require.NoError(t, err)
+	assert.Equal(t, []field{
+		{"cef_version", "0"}, {"device_vendor", "Security"}, {"device_product", "threatmanager"}, {"device_version", "1.0"},
+		{"signature_id", "100"}, {"name", "detected a | in message"}, {"severity", "10"},
+		{"src", "10.0.0.1"}, {"act", "blocked a ="}, {"dst", "2.1.2.2"}, {"msg", "line1\nline2"}, {"url", "http://a?b=c"},
+	}, fields)
+
+	fields, err = parseCEF(`CEF:0|V|P|1|2|N|3|`)
+	require.NoError(t, err)
+	assert.Len(t, fields, 7)
+
+	for _, line := range []string{"not a cef line", "CEF:0|V|P|1|2|N"} {
+		_, err = parseCEF(line)
+		assert.Error(t, err, line)
+	}
+}
+
+func TestParseLEEF(t *testing.T) {
+	fields, err := parseLEEF("LEEF:1.0|Microsoft|MSExchange|4.0 SP1|15345|src=192.0.2.0\tdst=172.50.123.1\tmsg=a=b")
+	require.NoError(t, err)
+	assert.Equal(t, []field{
+		{"leef_version", "1.0"}, {"device_vendor", "Microsoft"}, {"device_product", "MSExchange"}, {"device_version", "4.0 SP1"},
+		{"event_id", "15345"}, {"src", "192.0.2.0"}, {"dst", "172.50.123.1"}, {"msg", "a=b"},
+	}, fields)
+
+	fields, err = parseLEEF("<13>Sep 19 host LEEF:2.0|Lancope|StealthWatch|1.0|41|^|src=10.0.1.8^dst=10.0.0.5")
+	require.NoError(t, err)
+	assert.Equal(t, []field{{"src", "10.0.1.8"}, {"dst", "10.0.0.5"}}, fields[5:])
+
+	fields, err = parseLEEF("LEEF:2.0|V|P|1|41|x7C|src=10.0.1.8|dst=10.0.0.5")
+	require.NoError(t, err)
+	assert.Equal(t, []field{{"src", "10.0.1.8"}, {"dst", "10.0.0.5"}}, fields[5:])
+
+	for _, line := range []string{"not a leef line", "LEEF:1.0|V|P|1", "LEEF:2.0|V|P|1|41|src=a", "LEEF:2.0|V|P|1|41|xZZ|src=a"} {
+		_, err = parseLEEF(line)
+		assert.Error(t, err, line)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	decoder := &Decoder{Format: common.ProtocolCEF, FieldMapping: map[string]string{"src": "client_ip", "dvchost": "tag.host.name"}}
+	logs, err := decoder.Decode([]byte("CEF:0|V|P|1|100|N|5|src=10.0.0.1 dvchost=server01 rt=1434055562000\ninvalid line\n\n"), nil, nil)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, uint32(1434055562), logs[0].Time)
+	assert.Equal(t, &protocol.Log_Content{Key: "client_ip", Value: "10.0.0.1"}, logs[0].Contents[7])
+	assert.Equal(t, &protocol.Log_Content{Key: "__tag__:host.name", Value: "server01"}, logs[0].Contents[8])
+
+	decoder = &Decoder{Format: common.ProtocolLEEF}
+	logs, err = decoder.Decode([]byte("LEEF:1.0|V|P|1|41|src=10.0.1.8\tdevTime=1434055600000"), nil, nil)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, uint32(1434055600), logs[0].Time)
+	assert.Equal(t, "41", logs[0].Contents[4].Value)
+}
+
+func TestDecodeV2(t *testing.T) {
+	decoder := &Decoder{Format: common.ProtocolCEF, FieldMapping: map[string]string{"src": "client_ip", "dvchost": "tag.host.name"}}
+	line := "CEF:0|V|P|1|100|N|5|src=10.0.0.1 dvchost=server01 rt=1434055562000"
+	groups, err := decoder.DecodeV2([]byte(line+"\ninvalid line\n"), nil)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	require.Len(t, groups[0].Events, 1)
+	log := groups[0].Events[0].(*models.Log)
+	assert.Equal(t, line, string(log.GetBody()))
+	assert.Equal(t, uint64(1434055562000000000), log.GetTimestamp())
+	assert.Equal(t, "10.0.0.1", log.GetTags().Get("client_ip"))
+	assert.Equal(t, "server01", log.GetTags().Get("host.name"))
+	assert.Equal(t, "100", log.GetTags().Get("signature_id"))
+}
+
+func TestDecodeConcurrently(t *testing.T) {
+	decoder := &Decoder{Format: common.ProtocolLEEF}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logs, err := decoder.Decode([]byte("invalid line\nLEEF:1.0|V|P|1|41|src=10.0.1.8"), nil, nil)
+			assert.NoError(t, err)
+			assert.Len(t, logs, 1)
+		}()
+	}
+	wg.Wait()
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/models"
 	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
 
 	dogstatsd "github.com/narqo/go-dogstatsd-parser"
 	"github.com/prometheus/common/model"
@@ -80,7 +81,7 @@ func (d *Decoder) Decode(data []byte, req *http.Request, tags map[string]string)
 		if err != nil {
 			logger.Debug(context.Background(), "parse statsd error", err)
 			if time.Since(d.Time).Seconds() > 10 {
-				logger.Error(context.Background(), "STATSD_PARSE_ALARM", "parse err", err)
+				logger.Error(context.Background(), util.AlarmStatsdParse, "parse err", err)
 				d.Time = time.Now()
 			}
 			continue
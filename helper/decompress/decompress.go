@@ -0,0 +1,241 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package decompress decompresses the payloads received by the inputs, and limits the decompressed
+// size to protect the agent from the decompression bombs.
+package decompress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// The supported compressions.
+const (
+	None    = "none"
+	Auto    = "auto"
+	Gzip    = "gzip"
+	Deflate = "deflate"
+	Zstd    = "zstd"
+	Snappy  = "snappy"
+)
+
+// ErrTooLarge is returned when the decompressed size exceeds the limit.
+var ErrTooLarge = errors.New("the decompressed size exceeds the limit")
+
+var (
+	gzipMagic         = []byte{0x1f, 0x8b}
+	zstdMagic         = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	snappyFramedMagic = []byte("\xff\x06\x00\x00sNaPpY")
+)
+
+// Normalize returns the compression in lower case, empty means None, and returns error for the
+// unsupported compressions.
+func Normalize(compression string) (string, error) {
+	compression = strings.ToLower(strings.TrimSpace(compression))
+	switch compression {
+	case "", None, "identity":
+		return None, nil
+	case "zlib":
+		return Deflate, nil
+	case "zst":
+		return Zstd, nil
+	case Auto, Gzip, Deflate, Zstd, Snappy:
+		return compression, nil
+	}
+	return "", fmt.Errorf("unsupported compression %q", compression)
+}
+
+// Detect returns the compression of data by the magic number, or None if unknown. The deflate and
+// snappy block formats have no magic number, so they cannot be detected.
+func Detect(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		return Gzip
+	case bytes.HasPrefix(data, zstdMagic):
+		return Zstd
+	case bytes.HasPrefix(data, snappyFramedMagic):
+		return Snappy
+	}
+	return None
+}
+
+// Decompress decompresses data, the compression is detected when it is Auto. At most maxSize bytes
+// are decompressed if maxSize is positive, otherwise ErrTooLarge is returned.
+func Decompress(data []byte, compression string, maxSize int64) ([]byte, error) {
+	compression, err := Normalize(compression)
+	if err != nil {
+		return nil, err
+	}
+	if compression == Auto {
+		compression = Detect(data)
+	}
+	switch compression {
+	case None:
+		if maxSize > 0 && int64(len(data)) > maxSize {
+			return nil, ErrTooLarge
+		}
+		return data, nil
+	case Snappy:
+		if !bytes.HasPrefix(data, snappyFramedMagic) {
+			return decodeSnappyBlock(data, maxSize)
+		}
+	}
+	reader, err := NewReader(bytes.NewReader(data), compression, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close() //nolint:errcheck
+	return io.ReadAll(reader)
+}
+
+// NewReader returns a reader decompressing r, the compression is detected when it is Auto. The
+// reading fails with ErrTooLarge after maxSize bytes are decompressed if maxSize is positive.
+// The snappy block format is read as a whole because it cannot be decoded by streaming.
+func NewReader(r io.Reader, compression string, maxSize int64) (io.ReadCloser, error) {
+	compression, err := Normalize(compression)
+	if err != nil {
+		return nil, err
+	}
+	if compression == Auto || compression == Snappy {
+		buffered := bufio.NewReader(r)
+		// the error is ignored because the short header is handled as the uncompressed data.
+		header, _ := buffered.Peek(len(snappyFramedMagic))
+		if compression == Auto {
+			compression = Detect(header)
+		} else if !bytes.HasPrefix(header, snappyFramedMagic) {
+			return newSnappyBlockReader(buffered, maxSize)
+		}
+		r = buffered
+	}
+
+	var reader io.Reader
+	closer := func() error { return nil }
+	switch compression {
+	case None:
+		reader = r
+	case Gzip:
+		gzipReader, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		reader, closer = gzipReader, gzipReader.Close
+	case Deflate:
+		zlibReader, err := zlib.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		reader, closer = zlibReader, zlibReader.Close
+	case Zstd:
+		options := []zstd.DOption{zstd.WithDecoderConcurrency(1)}
+		if maxSize > 0 {
+			options = append(options, zstd.WithDecoderMaxMemory(uint64(maxSize)))
+		}
+		decoder, err := zstd.NewReader(r, options...)
+		if err != nil {
+			return nil, err
+		}
+		reader = &zstdReader{decoder: decoder}
+		closer = func() error {
+			decoder.Close()
+			return nil
+		}
+	case Snappy:
+		reader = snappy.NewReader(r)
+	}
+	return &limitedReader{reader: reader, remaining: maxSize, limited: maxSize > 0, closer: closer}, nil
+}
+
+func newSnappyBlockReader(r io.Reader, maxSize int64) (io.ReadCloser, error) {
+	reader := r
+	if maxSize > 0 {
+		// the max encoded length of maxSize bytes, see snappy.MaxEncodedLen.
+		reader = io.LimitReader(r, 32+maxSize+maxSize/6)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := decodeSnappyBlock(data, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(decoded)), nil
+}
+
+func decodeSnappyBlock(data []byte, maxSize int64) ([]byte, error) {
+	size, err := snappy.DecodedLen(data)
+	if err != nil {
+		return nil, err
+	}
+	// check the size in the header before allocating the buffer.
+	if maxSize > 0 && int64(size) > maxSize {
+		return nil, ErrTooLarge
+	}
+	return snappy.Decode(nil, data)
+}
+
+// zstdReader returns ErrTooLarge when the window size of the frame exceeds the limit.
+type zstdReader struct {
+	decoder *zstd.Decoder
+}
+
+func (z *zstdReader) Read(p []byte) (int, error) {
+	n, err := z.decoder.Read(p)
+	if errors.Is(err, zstd.ErrDecoderSizeExceeded) || errors.Is(err, zstd.ErrWindowSizeExceeded) {
+		err = ErrTooLarge
+	}
+	return n, err
+}
+
+type limitedReader struct {
+	reader    io.Reader
+	remaining int64
+	limited   bool
+	closer    func() error
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if !l.limited {
+		return l.reader.Read(p)
+	}
+	if l.remaining <= 0 {
+		// the limit is reached, it is fine only if there is no more data.
+		var b [1]byte
+		n, err := l.reader.Read(b[:])
+		if n > 0 {
+			return 0, ErrTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.reader.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+func (l *limitedReader) Close() error {
+	return l.closer()
+}
@@ -0,0 +1,108 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decompress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func compress(t *testing.T, compression string, data []byte) []byte {
+	var buf bytes.Buffer
+	var writer io.WriteCloser
+	switch compression {
+	case Gzip:
+		writer = gzip.NewWriter(&buf)
+	case Deflate:
+		writer = zlib.NewWriter(&buf)
+	case Zstd:
+		encoder, err := zstd.NewWriter(&buf)
+		require.NoError(t, err)
+		writer = encoder
+	case Snappy:
+		writer = snappy.NewBufferedWriter(&buf)
+	default:
+		return snappy.Encode(nil, data)
+	}
+	_, err := writer.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	data := []byte(strings.Repeat("hello world\n", 1000))
+	for _, compression := range []string{Gzip, Deflate, Zstd, Snappy, "snappy_block"} {
+		compressed := compress(t, compression, data)
+		explicit := compression
+		if compression == "snappy_block" {
+			explicit = Snappy
+		}
+		decompressed, err := Decompress(compressed, explicit, 0)
+		require.NoError(t, err, compression)
+		assert.Equal(t, data, decompressed, compression)
+
+		decompressed, err = Decompress(compressed, explicit, int64(len(data)))
+		require.NoError(t, err, compression)
+		assert.Equal(t, data, decompressed, compression)
+
+		_, err = Decompress(compressed, explicit, int64(len(data)-1))
+		assert.ErrorIs(t, err, ErrTooLarge, compression)
+
+		// the snappy block is decoded as a whole when the reader is created.
+		reader, err := NewReader(bytes.NewReader(compressed), explicit, 100)
+		if err == nil {
+			_, err = io.ReadAll(reader)
+			assert.NoError(t, reader.Close())
+		}
+		assert.ErrorIs(t, err, ErrTooLarge, compression)
+	}
+}
+
+func TestDecompressAuto(t *testing.T) {
+	data := []byte("hello world")
+	for _, compression := range []string{Gzip, Zstd, Snappy} {
+		compressed := compress(t, compression, data)
+		assert.Equal(t, compression, Detect(compressed))
+		decompressed, err := Decompress(compressed, Auto, 0)
+		require.NoError(t, err, compression)
+		assert.Equal(t, data, decompressed, compression)
+	}
+
+	decompressed, err := Decompress(data, Auto, 0)
+	require.NoError(t, err)
+	assert.Equal(t, data, decompressed)
+	reader, err := NewReader(bytes.NewReader([]byte("h")), Auto, 0)
+	require.NoError(t, err)
+	decompressed, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("h"), decompressed)
+
+	_, err = Decompress(data, "", 5)
+	assert.ErrorIs(t, err, ErrTooLarge)
+	_, err = Decompress(data, "lzma", 0)
+	assert.Error(t, err)
+	_, err = Decompress(data, Gzip, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,110 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// ErrDestinationNotAllowed is returned by Ensure when the name doesn't match the allowed pattern, or the max
+// count of the destinations is reached, the flushers should not send the data to the destination.
+var ErrDestinationNotAllowed = errors.New("destination not allowed")
+
+// DestinationLimit limits the destinations created, so that the unexpected tags don't create the destinations
+// without bound.
+type DestinationLimit struct {
+	// MaxCount is the max count of the destinations ensured, no limit if not positive.
+	MaxCount int
+	// AllowPattern is the regex the names must match, all the names are allowed if empty.
+	AllowPattern string
+}
+
+// DestinationCreator creates the destinations resolved dynamically by the flushers, such as the logstores and
+// the topics named by the namespaces, on their first use. The destinations created are remembered, and the
+// creation failed is not retried within the retry interval so that the flushing is not blocked by the backend.
+type DestinationCreator struct {
+	create        func(name string) error
+	retryInterval time.Duration
+	maxCount      int
+	allowed       *regexp.Regexp
+
+	lock     sync.Mutex
+	created  map[string]bool
+	failed   map[string]time.Time
+	creating map[string]bool
+}
+
+// NewDestinationCreator returns a creator calling @create for the destinations unknown, @create should return
+// nil if the destination exists already.
+func NewDestinationCreator(create func(name string) error, retryInterval time.Duration, limit DestinationLimit) (*DestinationCreator, error) {
+	c := &DestinationCreator{
+		create:        create,
+		retryInterval: retryInterval,
+		maxCount:      limit.MaxCount,
+		created:       make(map[string]bool),
+		failed:        make(map[string]time.Time),
+		creating:      make(map[string]bool),
+	}
+	if limit.AllowPattern != "" {
+		reg, err := regexp.Compile(limit.AllowPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AllowPattern %v: %v", limit.AllowPattern, err)
+		}
+		c.allowed = reg
+	}
+	return c, nil
+}
+
+// Ensure creates the destination if it's not created yet, and returns the error of the creation. It returns nil
+// without creating when the last creation failed within the retry interval, or the destination is being created
+// by another goroutine, the data is sent anyway and the errors are reported by the sending. The lock is not held
+// during the creation, so the creations of the different destinations don't wait for each other.
+func (c *DestinationCreator) Ensure(name string) error {
+	if c.allowed != nil && !c.allowed.MatchString(name) {
+		return fmt.Errorf("%w: %s doesn't match the pattern %s", ErrDestinationNotAllowed, name, c.allowed)
+	}
+	c.lock.Lock()
+	if c.created[name] {
+		c.lock.Unlock()
+		return nil
+	}
+	if last, ok := c.failed[name]; (ok && time.Since(last) < c.retryInterval) || c.creating[name] {
+		c.lock.Unlock()
+		return nil
+	}
+	if c.maxCount > 0 && len(c.created)+len(c.creating) >= c.maxCount {
+		c.lock.Unlock()
+		return fmt.Errorf("%w: %s exceeds the max count %d", ErrDestinationNotAllowed, name, c.maxCount)
+	}
+	c.creating[name] = true
+	c.lock.Unlock()
+
+	err := c.create(name)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.creating, name)
+	if err != nil {
+		c.failed[name] = time.Now()
+		return err
+	}
+	delete(c.failed, name)
+	c.created[name] = true
+	return nil
+}
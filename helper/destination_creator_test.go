@@ -0,0 +1,87 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helper
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDestinationCreator(t *testing.T) {
+	calls := map[string]int{}
+	fail := true
+	creator, err := NewDestinationCreator(func(name string) error {
+		calls[name]++
+		if name == "broken" && fail {
+			return errors.New("quota exceeded")
+		}
+		return nil
+	}, 50*time.Millisecond, DestinationLimit{})
+	require.NoError(t, err)
+
+	assert.NoError(t, creator.Ensure("ns-a"))
+	assert.NoError(t, creator.Ensure("ns-a"))
+	assert.Equal(t, 1, calls["ns-a"])
+
+	assert.Error(t, creator.Ensure("broken"))
+	// not retried within the retry interval
+	assert.NoError(t, creator.Ensure("broken"))
+	assert.Equal(t, 1, calls["broken"])
+
+	time.Sleep(60 * time.Millisecond)
+	assert.Error(t, creator.Ensure("broken"))
+	assert.Equal(t, 2, calls["broken"])
+
+	fail = false
+	time.Sleep(60 * time.Millisecond)
+	assert.NoError(t, creator.Ensure("broken"))
+	assert.NoError(t, creator.Ensure("broken"))
+	assert.Equal(t, 3, calls["broken"])
+}
+
+func TestDestinationCreatorLimit(t *testing.T) {
+	_, err := NewDestinationCreator(func(string) error { return nil }, time.Minute, DestinationLimit{AllowPattern: "("})
+	assert.Error(t, err)
+
+	block := make(chan struct{})
+	creator, err := NewDestinationCreator(func(name string) error {
+		if name == "ns-slow" {
+			<-block
+		}
+		return nil
+	}, time.Minute, DestinationLimit{MaxCount: 2, AllowPattern: "^ns-"})
+	require.NoError(t, err)
+	assert.ErrorIs(t, creator.Ensure("kube-system"), ErrDestinationNotAllowed)
+
+	done := make(chan error)
+	go func() { done <- creator.Ensure("ns-slow") }()
+	require.Eventually(t, func() bool {
+		creator.lock.Lock()
+		defer creator.lock.Unlock()
+		return creator.creating["ns-slow"]
+	}, time.Second, time.Millisecond)
+	// the other destinations are not blocked by the slow creation, and the slow one is not created twice
+	assert.NoError(t, creator.Ensure("ns-a"))
+	assert.NoError(t, creator.Ensure("ns-slow"))
+	assert.ErrorIs(t, creator.Ensure("ns-b"), ErrDestinationNotAllowed)
+	close(block)
+	assert.NoError(t, <-done)
+	assert.NoError(t, creator.Ensure("ns-a"))
+	assert.ErrorIs(t, creator.Ensure("ns-b"), ErrDestinationNotAllowed)
+}
@@ -56,6 +56,9 @@ const k8sPodUUIDLabel = "io.kubernetes.pod.uid"
 const k8sInnerLabelPrefix = "io.kubernetes"
 const k8sInnerAnnotationPrefix = "annotation."
 
+// gVisorRuntime is the runtime name of gVisor.
+const gVisorRuntime = "runsc"
+
 const (
 	ContainerStatusRunning = "running"
 	ContainerStatusExited  = "exited"
@@ -616,10 +619,20 @@ func (dc *DockerCenter) CreateInfoDetail(info types.ContainerJSON, envConfigPref
 	if criRuntimeWrapper != nil && info.HostConfig != nil && len(did.DefaultRootPath) == 0 {
 		did.DefaultRootPath = criRuntimeWrapper.lookupContainerRootfsAbsDir(info)
 	}
+	// The rootfs overlay of runsc keeps the writes in the sandbox, only the files in the volumes are visible on the host then.
+	if info.HostConfig != nil && isGVisorRuntime(info.HostConfig.Runtime) && len(did.DefaultRootPath) > 0 {
+		logger.Infof(context.Background(), "container(id: %s, name: %s) runs in gVisor, the files not in the volumes can be collected only when the rootfs overlay of runsc is disabled", info.ID, info.Name)
+	}
 	logger.Debugf(context.Background(), "container(id: %s, name: %s) default root path is %s", info.ID, info.Name, did.DefaultRootPath)
 	return did
 }
 
+// isGVisorRuntime checks the runtime name of docker, the runtime type of containerd and the runtime handler of CRI-O.
+func isGVisorRuntime(runtime string) bool {
+	runtime = strings.ToLower(runtime)
+	return strings.Contains(runtime, gVisorRuntime) || strings.Contains(runtime, "gvisor")
+}
+
 func getDockerCenterInstance() *DockerCenter {
 	onceDocker.Do(func() {
 		logger.Init()
@@ -632,7 +645,7 @@ func getDockerCenterInstance() *DockerCenter {
 			var err error
 			criRuntimeWrapper, err = NewCRIRuntimeWrapper(dockerCenterInstance)
 			if err != nil {
-				logger.Errorf(context.Background(), "DOCKER_CENTER_ALARM", "[CRIRuntime] creare cri-runtime client error: %v", err)
+				logger.Errorf(context.Background(), util.AlarmDockerCenter, "[CRIRuntime] creare cri-runtime client error: %v", err)
 				criRuntimeWrapper = nil
 			} else {
 				logger.Infof(context.Background(), "[CRIRuntime] create cri-runtime client successfully")
@@ -666,7 +679,7 @@ func (dc *DockerCenter) readStaticConfig(forceFlush bool) {
 	defer staticDockerContainerLock.Unlock()
 	containerInfo, removedIDs, changed, err := tryReadStaticContainerInfo()
 	if err != nil {
-		logger.Warning(context.Background(), "READ_STATIC_CONFIG_ALARM", "read static container info error", err)
+		logger.Warning(context.Background(), util.AlarmReadStaticConfig, "read static container info error", err)
 	}
 	if !dc.initStaticContainerInfoSuccess && len(containerInfo) > 0 {
 		dc.initStaticContainerInfoSuccess = true
@@ -707,7 +720,7 @@ func (dc *DockerCenter) setLastError(err error, msg string) {
 	dc.lastErr = err
 	dc.lastErrMu.Unlock()
 	if err != nil {
-		logger.Warning(context.Background(), "DOCKER_CENTER_ALARM", "message", msg, "error found", err)
+		logger.Warning(context.Background(), util.AlarmDockerCenter, "message", msg, "error found", err)
 	} else {
 		logger.Debug(context.Background(), "message", msg)
 	}
@@ -886,7 +899,7 @@ func (dc *DockerCenter) getAllAcceptedInfoV2(
 		if ok {
 			matchList[id] = c
 		} else {
-			logger.Warningf(context.Background(), "DOCKER_MATCH_ALARM", "matched container not in docker center")
+			logger.Warningf(context.Background(), util.AlarmDockerMatch, "matched container not in docker center")
 		}
 	}
 
@@ -1140,7 +1153,7 @@ func dockerCenterRecover() {
 	if err := recover(); err != nil {
 		trace := make([]byte, 2048)
 		runtime.Stack(trace, true)
-		logger.Error(context.Background(), "PLUGIN_RUNTIME_ALARM", "docker center runtime error", err, "stack", string(trace))
+		logger.Error(context.Background(), util.AlarmPluginRuntime, "docker center runtime error", err, "stack", string(trace))
 	}
 }
 
@@ -1169,7 +1182,7 @@ func (dc *DockerCenter) eventListener() {
 			select {
 			case event, ok := <-events:
 				if !ok {
-					logger.Errorf(context.Background(), "DOCKER_EVENT_ALARM", "docker event listener stop")
+					logger.Errorf(context.Background(), util.AlarmDockerEvent, "docker event listener stop")
 					errorCount++
 					breakFlag = true
 					break
@@ -1191,15 +1204,15 @@ func (dc *DockerCenter) eventListener() {
 					select {
 					case dc.eventChan <- event:
 					default:
-						logger.Error(context.Background(), "DOCKER_EVENT_ALARM", "event queue is full, miss event", event)
+						logger.Error(context.Background(), util.AlarmDockerEvent, "event queue is full, miss event", event)
 					}
 				}
 				dc.eventChanLock.Unlock()
 			case err = <-errors:
-				logger.Error(context.Background(), "DOCKER_EVENT_ALARM", "docker event listener error", err)
+				logger.Error(context.Background(), util.AlarmDockerEvent, "docker event listener error", err)
 				breakFlag = true
 			case <-timer.C:
-				logger.Errorf(context.Background(), "DOCKER_EVENT_ALARM", "no docker event in 1 hour. Reset event listener")
+				logger.Errorf(context.Background(), util.AlarmDockerEvent, "no docker event in 1 hour. Reset event listener")
 				breakFlag = true
 			}
 		}
@@ -31,6 +31,7 @@ import (
 	"time"
 
 	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
 
 	containerdcriserver "github.com/containerd/containerd/pkg/cri/server"
 	"github.com/docker/docker/api/types"
@@ -50,6 +51,21 @@ var (
 	dockerShimUnixSocket2 = "/run/dockershim.sock"
 )
 
+// criRuntimeSockets are the well-known endpoints of containerd and CRI-O, the first existing one is used
+// when CONTAINERD_SOCK_PATH is not set.
+var criRuntimeSockets = []string{
+	"/run/containerd/containerd.sock",
+	"/var/run/containerd/containerd.sock",
+	"/var/run/crio/crio.sock",
+	"/run/crio/crio.sock",
+}
+
+// kataSandboxesDir is where kata containers shares the rootfs of containers with the guest vm.
+var kataSandboxesDir = "/run/kata-containers/shared/sandboxes"
+
+// crioRuntimeHandlerAnnotation is the annotation of the runtime handler which CRI-O sets on the spec of the containers.
+const crioRuntimeHandlerAnnotation = "io.kubernetes.cri-o.RuntimeHandler"
+
 var criRuntimeWrapper *CRIRuntimeWrapper
 
 // CRIRuntimeWrapper wrapper for containerd client
@@ -214,7 +230,7 @@ func newRuntimeServiceClient() (cri.RuntimeServiceClient, error) {
 func NewCRIRuntimeWrapper(dockerCenter *DockerCenter) (*CRIRuntimeWrapper, error) {
 	client, err := newRuntimeServiceClient()
 	if err != nil {
-		logger.Errorf(context.Background(), "CONNECT_CRI_RUNTIME_ALARM", "Connect remote cri-runtime failed: %v", err)
+		logger.Errorf(context.Background(), util.AlarmConnectCRIRuntime, "Connect remote cri-runtime failed: %v", err)
 		return nil, err
 	}
 
@@ -254,13 +270,13 @@ func (cw *CRIRuntimeWrapper) createContainerInfo(containerID string) (detail *Do
 			foundInfo = true
 			ci, err = parseContainerInfo(info)
 			if err != nil {
-				logger.Errorf(context.Background(), "CREATE_CONTAINERD_INFO_ALARM", "failed to parse container info, containerId: %s, data: %s, error: %v", containerID, info, err)
+				logger.Errorf(context.Background(), util.AlarmCreateContainerInfo, "failed to parse container info, containerId: %s, data: %s, error: %v", containerID, info, err)
 			}
 		}
 	}
 
 	if !foundInfo {
-		logger.Warningf(context.Background(), "CREATE_CONTAINERD_INFO_ALARM", "can not find container info from CRI::ContainerStatus, containerId: %s", containerID)
+		logger.Warningf(context.Background(), util.AlarmCreateContainerInfo, "can not find container info from CRI::ContainerStatus, containerId: %s", containerID)
 		return nil, "", cri.ContainerState_CONTAINER_UNKNOWN, fmt.Errorf("can not find container info from CRI::ContainerStatus, containerId: %s", containerID)
 	}
 
@@ -343,6 +359,18 @@ func (cw *CRIRuntimeWrapper) createContainerInfo(containerID string) (detail *Do
 	dockerContainer.HostnamePath = hostnamePath
 	dockerContainer.HostsPath = hostsPath
 
+	// Report gVisor containers with the runtime name of docker, so CreateInfoDetail treats them the same way.
+	if isGVisorContainer(ci) {
+		dockerContainer.HostConfig.Runtime = gVisorRuntime
+	}
+
+	// CreateInfoDetail looks up the rootfs, so resolve it with the runtime spec first.
+	var rootPath string
+	if ci.RuntimeSpec != nil && ci.RuntimeSpec.Root != nil {
+		rootPath = ci.RuntimeSpec.Root.Path
+	}
+	cw.resolveContainerRootfs(containerID, ci.SandboxID, rootPath)
+
 	return cw.dockerCenter.CreateInfoDetail(dockerContainer, envConfigPrefix, false), ci.SandboxID, state, nil
 }
 
@@ -435,7 +463,7 @@ func (cw *CRIRuntimeWrapper) loopSyncContainers() {
 			return
 		case <-ticker.C:
 			if err := cw.syncContainers(); err != nil {
-				logger.Errorf(context.Background(), "SYNC_CONTAINERD_ALARM", "syncContainers error: %v", err)
+				logger.Errorf(context.Background(), util.AlarmSyncContainerd, "syncContainers error: %v", err)
 			}
 		}
 	}
@@ -478,7 +506,7 @@ func (cw *CRIRuntimeWrapper) syncContainers() error {
 			continue
 		}
 		if err := cw.fetchOne(id); err != nil {
-			logger.Errorf(context.Background(), "CREATE_CONTAINERD_INFO_ALARM", "failed to createContainerInfo, containerId: %s, error: %v", id, err)
+			logger.Errorf(context.Background(), util.AlarmCreateContainerInfo, "failed to createContainerInfo, containerId: %s, error: %v", id, err)
 		}
 	}
 
@@ -638,9 +666,63 @@ func (cw *CRIRuntimeWrapper) lookupContainerRootfsAbsDir(info types.ContainerJSO
 	return ""
 }
 
+// sandboxRootfsCandidates returns the possible rootfs dirs of a container which are not under the task dirs of containerd.
+// @rootPath is the root path in the runtime spec, which is absolute for CRI-O, e.g. /var/lib/containers/storage/overlay/{LayerID}/merged,
+// and relative to the bundle for containerd. The containers in kata sandboxes are mounted under the shared dir of the sandbox.
+// The shim of gVisor mounts the rootfs into the bundle as runc does, so the containerd task dirs of lookupContainerRootfsAbsDir
+// are used for them.
+func sandboxRootfsCandidates(containerID, sandboxID, rootPath string) []string {
+	var dirs []string
+	if path.IsAbs(rootPath) {
+		dirs = append(dirs, rootPath)
+	}
+	if len(sandboxID) > 0 {
+		dirs = append(dirs,
+			path.Join(kataSandboxesDir, sandboxID, "mounts", containerID, "rootfs"),
+			path.Join(kataSandboxesDir, sandboxID, "shared", containerID, "rootfs"),
+		)
+	}
+	return dirs
+}
+
+// resolveContainerRootfs caches the first existing dir of sandboxRootfsCandidates.
+func (cw *CRIRuntimeWrapper) resolveContainerRootfs(containerID, sandboxID, rootPath string) {
+	if _, ok := cw.lookupRootfsCache(containerID); ok {
+		return
+	}
+	for _, dir := range sandboxRootfsCandidates(containerID, sandboxID, rootPath) {
+		if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+			cw.rootfsLock.Lock()
+			cw.rootfsCache[containerID] = dir
+			cw.rootfsLock.Unlock()
+			return
+		}
+	}
+}
+
+// isGVisorContainer checks the runtime type of containerd, e.g. io.containerd.runsc.v1, and the runtime handler of CRI-O.
+func isGVisorContainer(ci containerdcriserver.ContainerInfo) bool {
+	if isGVisorRuntime(ci.RuntimeType) {
+		return true
+	}
+	return ci.RuntimeSpec != nil && isGVisorRuntime(ci.RuntimeSpec.Annotations[crioRuntimeHandlerAnnotation])
+}
+
+// lookupCRIRuntimeSocket returns the first existing socket of @sockets.
+func lookupCRIRuntimeSocket(sockets []string) string {
+	for _, sock := range sockets {
+		if fi, err := os.Stat(sock); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			return sock
+		}
+	}
+	return ""
+}
+
 func init() {
 	containerdSockPathStr := os.Getenv("CONTAINERD_SOCK_PATH")
 	if len(containerdSockPathStr) > 0 {
 		containerdUnixSocket = containerdSockPathStr
+	} else if sock := lookupCRIRuntimeSocket(criRuntimeSockets); len(sock) > 0 {
+		containerdUnixSocket = sock
 	}
 }
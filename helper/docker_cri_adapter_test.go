@@ -18,6 +18,9 @@
 package helper
 
 import (
+	"net"
+	"os"
+	"path"
 	"testing"
 
 	"github.com/docker/docker/api/types"
@@ -43,3 +46,62 @@ func TestLookupContainerRootfsAbsDir(t *testing.T) {
 	dir := crirt.lookupContainerRootfsAbsDir(container)
 	require.Equal(t, dir, "")
 }
+
+func TestResolveContainerRootfs(t *testing.T) {
+	crirt := &CRIRuntimeWrapper{
+		containers:  make(map[string]*innerContainerInfo),
+		rootfsCache: make(map[string]string),
+	}
+	tmpDir := t.TempDir()
+	defer func(dir string) {
+		kataSandboxesDir = dir
+	}(kataSandboxesDir)
+	kataSandboxesDir = path.Join(tmpDir, "sandboxes")
+
+	// CRI-O
+	crioRootfs := path.Join(tmpDir, "overlay", "layer", "merged")
+	require.NoError(t, os.MkdirAll(crioRootfs, 0750))
+	crirt.resolveContainerRootfs("crio", "sandbox1", crioRootfs)
+	dir, ok := crirt.lookupRootfsCache("crio")
+	require.True(t, ok)
+	require.Equal(t, crioRootfs, dir)
+
+	// kata
+	kataRootfs := path.Join(kataSandboxesDir, "sandbox2", "shared", "kata", "rootfs")
+	require.NoError(t, os.MkdirAll(kataRootfs, 0750))
+	crirt.resolveContainerRootfs("kata", "sandbox2", "rootfs")
+	require.Equal(t, kataRootfs, crirt.lookupContainerRootfsAbsDir(types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{ID: "kata"},
+	}))
+
+	// containerd with relative root path is left to lookupContainerRootfsAbsDir
+	crirt.resolveContainerRootfs("runc", "sandbox3", "rootfs")
+	_, ok = crirt.lookupRootfsCache("runc")
+	require.False(t, ok)
+}
+
+func TestLookupCRIRuntimeSocket(t *testing.T) {
+	tmpDir := t.TempDir()
+	sock := path.Join(tmpDir, "crio.sock")
+	listener, err := net.Listen("unix", sock)
+	require.NoError(t, err)
+	defer listener.Close()
+	require.NoError(t, os.WriteFile(path.Join(tmpDir, "file.sock"), nil, 0600))
+
+	require.Equal(t, sock, lookupCRIRuntimeSocket([]string{path.Join(tmpDir, "none.sock"), path.Join(tmpDir, "file.sock"), sock}))
+	require.Equal(t, "", lookupCRIRuntimeSocket([]string{path.Join(tmpDir, "none.sock")}))
+}
+
+func TestIsGVisorContainer(t *testing.T) {
+	for data, expected := range map[string]bool{
+		`{"runtimeType":"io.containerd.runsc.v1"}`:                                        true,
+		`{"runtimeType":"io.containerd.runc.v2"}`:                                         false,
+		`{"runtimeSpec":{"annotations":{"io.kubernetes.cri-o.RuntimeHandler":"gvisor"}}}`: true,
+		`{"runtimeSpec":{"annotations":{"io.kubernetes.cri-o.RuntimeHandler":"kata"}}}`:   false,
+		`{"runtimeSpec":{}}`: false,
+	} {
+		ci, err := parseContainerInfo(data)
+		require.NoError(t, err)
+		require.Equal(t, expected, isGVisorContainer(ci), data)
+	}
+}
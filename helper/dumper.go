@@ -46,7 +46,7 @@ func (d *Dumper) Init() {
 	d.stop = make(chan struct{})
 	files, err := GetFileListByPrefix(path.Join(util.GetCurrentBinaryPath(), "dump"), d.prefix, true, 0)
 	if err != nil {
-		logger.Warning(context.Background(), "LIST_HISTORY_DUMP_ALARM", "err", err)
+		logger.Warning(context.Background(), util.AlarmListHistoryDump, "err", err)
 	} else {
 		d.dumpDataKeepFiles = files
 	}
@@ -102,7 +102,7 @@ func (d *Dumper) doDumpFile() {
 			if time.Now().Hour() != lastHour {
 				file, cerr := cutFile()
 				if cerr != nil {
-					logger.Error(context.Background(), "DUMP_FILE_ALARM", "cut new file error", err)
+					logger.Error(context.Background(), util.AlarmDumpFile, "cut new file error", err)
 				} else {
 					offset, _ = file.Seek(0, io.SeekEnd)
 					f = file
@@ -0,0 +1,50 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebpf
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+var kernelVersionRegex = regexp.MustCompile(`^(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// KernelVersion is the major, minor and patch version of the kernel.
+type KernelVersion [3]int
+
+// ParseKernelVersion parses the kernel release like "5.10.134-13.an8.x86_64".
+func ParseKernelVersion(release string) (KernelVersion, error) {
+	var v KernelVersion
+	matches := kernelVersionRegex.FindStringSubmatch(release)
+	if matches == nil {
+		return v, fmt.Errorf("invalid kernel release %q", release)
+	}
+	for i := 0; i < 3; i++ {
+		if matches[i+1] != "" {
+			v[i], _ = strconv.Atoi(matches[i+1])
+		}
+	}
+	return v, nil
+}
+
+// AtLeast tells if the version is not lower than major.minor.
+func (v KernelVersion) AtLeast(major, minor int) bool {
+	return v[0] > major || (v[0] == major && v[1] >= minor)
+}
+
+func (v KernelVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+}
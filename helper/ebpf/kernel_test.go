@@ -0,0 +1,43 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebpf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKernelVersion(t *testing.T) {
+	for release, expected := range map[string]KernelVersion{
+		"5.10.134-13.an8.x86_64": {5, 10, 134},
+		"4.19.91":                {4, 19, 91},
+		"6.1-rc1":                {6, 1, 0},
+	} {
+		v, err := ParseKernelVersion(release)
+		require.NoError(t, err)
+		assert.Equal(t, expected, v, release)
+	}
+	_, err := ParseKernelVersion("unknown")
+	assert.Error(t, err)
+
+	v := KernelVersion{4, 16, 0}
+	assert.True(t, v.AtLeast(4, 16))
+	assert.True(t, v.AtLeast(3, 20))
+	assert.False(t, v.AtLeast(4, 18))
+	assert.False(t, v.AtLeast(5, 0))
+	assert.Equal(t, "4.16.0", v.String())
+}
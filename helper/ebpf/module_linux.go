@@ -0,0 +1,283 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package ebpf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	cilium "github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/asm"
+	"github.com/cilium/ebpf/features"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/rlimit"
+	"golang.org/x/sys/unix"
+)
+
+// ExitLabel is the label of the instructions returning 0 appended by Module.Load.
+const ExitLabel = "exit"
+
+// tracefsPath is where the link package finds the trace events to attach, under the debugfs.
+const tracefsPath = "/sys/kernel/debug/tracing"
+
+var removeMemlockOnce sync.Once
+
+// CurrentKernelVersion returns the version of the running kernel.
+func CurrentKernelVersion() (KernelVersion, error) {
+	var uname unix.Utsname
+	if err := unix.Uname(&uname); err != nil {
+		return KernelVersion{}, err
+	}
+	return ParseKernelVersion(unix.ByteSliceToString(uname.Release[:]))
+}
+
+// CheckSupport checks if the kernel is at least @major.@minor and supports the tracepoint programs and
+// the perf event arrays, and the debugfs is mounted.
+func CheckSupport(major, minor int) error {
+	version, err := CurrentKernelVersion()
+	if err != nil {
+		return err
+	}
+	if !version.AtLeast(major, minor) {
+		return fmt.Errorf("%w: kernel %v is lower than %d.%d", ErrNotSupported, version, major, minor)
+	}
+	if err = features.HaveProgType(cilium.TracePoint); err != nil {
+		return fmt.Errorf("%w: %v", ErrNotSupported, err)
+	}
+	if err = features.HaveMapType(cilium.PerfEventArray); err != nil {
+		return fmt.Errorf("%w: %v", ErrNotSupported, err)
+	}
+	if _, err = os.Stat(filepath.Join(tracefsPath, "events")); err != nil {
+		return fmt.Errorf("%w: debugfs is not mounted at /sys/kernel/debug", ErrNotSupported)
+	}
+	return nil
+}
+
+// ReadTraceEventFormat reads the format of the tracepoint @group:@name from the tracefs.
+func ReadTraceEventFormat(group, name string) (*TraceEventFormat, error) {
+	file, err := os.Open(filepath.Join(tracefsPath, "events", group, name, "format"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close() //nolint:gosec
+	return ParseTraceEventFormat(file)
+}
+
+// Module holds the maps, programs and links of a feature, which are released together by Close.
+// The programs are assembled at runtime rather than compiled from C, so the agent needs neither
+// clang nor the kernel headers.
+type Module struct {
+	maps  []*cilium.Map
+	progs []*cilium.Program
+	links []link.Link
+}
+
+// NewModule removes the memlock limit for the maps and returns an empty module. The failure of the
+// removal is ignored, because the maps are charged to the memory cgroup rather than the limit since
+// 5.11, and the map creation fails with its own error otherwise.
+func NewModule() (*Module, error) {
+	removeMemlockOnce.Do(func() {
+		_ = rlimit.RemoveMemlock()
+	})
+	return &Module{}, nil
+}
+
+// NewMap creates a map released with the module.
+func (m *Module) NewMap(spec *cilium.MapSpec) (*cilium.Map, error) {
+	bpfMap, err := cilium.NewMap(spec)
+	if err != nil {
+		return nil, fmt.Errorf("create map %s error: %v", spec.Name, err)
+	}
+	m.maps = append(m.maps, bpfMap)
+	return bpfMap, nil
+}
+
+// Load loads the program of @insns with the exit instructions labeled by ExitLabel appended.
+func (m *Module) Load(name string, progType cilium.ProgramType, insns asm.Instructions) (*cilium.Program, error) {
+	insns = append(insns,
+		asm.Mov.Imm(asm.R0, 0).Sym(ExitLabel),
+		asm.Return(),
+	)
+	prog, err := cilium.NewProgram(&cilium.ProgramSpec{
+		Name:         name,
+		Type:         progType,
+		Instructions: insns,
+		License:      "GPL",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load program %s error: %v", name, err)
+	}
+	m.progs = append(m.progs, prog)
+	return prog, nil
+}
+
+// AttachTracepoint loads and attaches the program of @insns to the tracepoint @group:@name.
+func (m *Module) AttachTracepoint(group, name string, insns asm.Instructions) error {
+	prog, err := m.Load(name, cilium.TracePoint, insns)
+	if err != nil {
+		return err
+	}
+	l, err := link.Tracepoint(group, name, prog)
+	if err != nil {
+		return fmt.Errorf("attach tracepoint %s:%s error: %v", group, name, err)
+	}
+	m.links = append(m.links, l)
+	return nil
+}
+
+// AttachKprobe loads and attaches the program of @insns to the entry of the kernel function @symbol.
+func (m *Module) AttachKprobe(symbol string, insns asm.Instructions) error {
+	prog, err := m.Load(symbol, cilium.Kprobe, insns)
+	if err != nil {
+		return err
+	}
+	l, err := link.Kprobe(symbol, prog)
+	if err != nil {
+		return fmt.Errorf("attach kprobe %s error: %v", symbol, err)
+	}
+	m.links = append(m.links, l)
+	return nil
+}
+
+// Close detaches the programs and releases the maps.
+func (m *Module) Close() error {
+	var errs []error
+	for _, l := range m.links {
+		if err := l.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, prog := range m.progs {
+		if err := prog.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, bpfMap := range m.maps {
+		if err := bpfMap.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	m.links, m.progs, m.maps = nil, nil, nil
+	if len(errs) > 0 {
+		return fmt.Errorf("close ebpf module error: %v", errs)
+	}
+	return nil
+}
+
+// ZeroStack clears @size bytes of the stack from @offset, which should be aligned to 8 bytes, for the
+// verifier rejects passing uninitialized stack to the helpers.
+func ZeroStack(offset int16, size int) asm.Instructions {
+	insns := make(asm.Instructions, 0, size/8)
+	for i := 0; i < size; i += 8 {
+		insns = append(insns, asm.StoreImm(asm.RFP, offset+int16(i), 0, asm.DWord))
+	}
+	return insns
+}
+
+// CopyField copies the tracepoint field at most @size bytes from the context in @ctx to the stack at
+// @offset, R1 is clobbered.
+func CopyField(ctx asm.Register, field TraceEventField, offset int16, size int) asm.Instructions {
+	if field.Size < size {
+		size = field.Size
+	}
+	var insns asm.Instructions
+	for copied := 0; copied < size; {
+		var width asm.Size
+		switch n := size - copied; {
+		case n >= 8:
+			width = asm.DWord
+		case n >= 4:
+			width = asm.Word
+		case n >= 2:
+			width = asm.Half
+		default:
+			width = asm.Byte
+		}
+		insns = append(insns,
+			asm.LoadMem(asm.R1, ctx, field.Offset+int16(copied), width),
+			asm.StoreMem(asm.RFP, offset+int16(copied), asm.R1, width),
+		)
+		copied += width.Sizeof()
+	}
+	return insns
+}
+
+// LookupOrInit looks up the value of the key on the stack at @keyOffset in @bpfMap, inserting a zero
+// value if absent with @valueSize bytes of the stack from @valueOffset. R0 is the pointer to the
+// value afterwards, or it jumps to ExitLabel when the value is evicted at once. R1-R5 are clobbered.
+func LookupOrInit(bpfMap *cilium.Map, keyOffset, valueOffset int16, valueSize int, label string) asm.Instructions {
+	insns := asm.Instructions{
+		asm.LoadMapPtr(asm.R1, bpfMap.FD()),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, int32(keyOffset)),
+		asm.FnMapLookupElem.Call(),
+		asm.JNE.Imm(asm.R0, 0, label),
+	}
+	insns = append(insns, ZeroStack(valueOffset, valueSize)...)
+	return append(insns,
+		asm.LoadMapPtr(asm.R1, bpfMap.FD()),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, int32(keyOffset)),
+		asm.Mov.Reg(asm.R3, asm.RFP),
+		asm.Add.Imm(asm.R3, int32(valueOffset)),
+		asm.Mov.Imm(asm.R4, unix.BPF_NOEXIST),
+		asm.FnMapUpdateElem.Call(),
+		asm.LoadMapPtr(asm.R1, bpfMap.FD()),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, int32(keyOffset)),
+		asm.FnMapLookupElem.Call(),
+		asm.JEq.Imm(asm.R0, 0, ExitLabel),
+		// The label of the next instruction, nop.
+		asm.Mov.Reg(asm.R0, asm.R0).Sym(label),
+	)
+}
+
+// KprobeArg loads the @n-th argument of the probed function from the pt_regs in @regs to @dst.
+func KprobeArg(dst, regs asm.Register, n int) (asm.Instruction, error) {
+	if n < 0 || n >= len(kprobeArgOffsets) {
+		return asm.Instruction{}, errors.New("kprobe argument out of range")
+	}
+	if kprobeArgOffsets[n] < 0 {
+		return asm.Instruction{}, fmt.Errorf("%w: kprobe arguments on this architecture", ErrNotSupported)
+	}
+	return asm.LoadMem(dst, regs, kprobeArgOffsets[n], asm.DWord), nil
+}
+
+// ClampSize jumps to ExitLabel if the signed size in @reg is not positive, and limits it to @max, so
+// the verifier knows the bounds of the size passed to the helpers.
+func ClampSize(reg asm.Register, max int32, label string) asm.Instructions {
+	return asm.Instructions{
+		asm.JSLE.Imm(reg, 0, ExitLabel),
+		asm.JLE.Imm(reg, max, label),
+		asm.Mov.Imm(reg, max),
+		// The label of the next instruction, nop.
+		asm.Mov.Reg(reg, reg).Sym(label),
+	}
+}
+
+// ProbeReadUser returns bpf_probe_read_user to read the user memory since 5.5, or bpf_probe_read
+// reading both the user and kernel memory on the older kernels.
+func ProbeReadUser(version KernelVersion) asm.BuiltinFunc {
+	if version.AtLeast(5, 5) {
+		return asm.FnProbeReadUser
+	}
+	return asm.FnProbeRead
+}
@@ -0,0 +1,121 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebpf
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrNotSupported is returned when eBPF can't be used on the platform or kernel.
+var ErrNotSupported = errors.New("ebpf is not supported")
+
+// TraceEventField is a field of the context passed to the tracepoint programs.
+type TraceEventField struct {
+	Name   string
+	Offset int16
+	Size   int
+	Signed bool
+}
+
+// TraceEventFormat is the layout of a tracepoint context, which is parsed from the format file of the
+// event in tracefs so that the programs generated at runtime adapt to the kernel version.
+type TraceEventFormat struct {
+	Name   string
+	ID     int
+	Fields map[string]TraceEventField
+}
+
+// Field returns the field by name, an array field is named without the brackets.
+func (f *TraceEventFormat) Field(name string) (TraceEventField, bool) {
+	field, ok := f.Fields[name]
+	return field, ok
+}
+
+// ParseTraceEventFormat parses the format file of a trace event, such as
+// /sys/kernel/tracing/events/sock/inet_sock_set_state/format.
+func ParseTraceEventFormat(r io.Reader) (*TraceEventFormat, error) {
+	format := &TraceEventFormat{Fields: make(map[string]TraceEventField)}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "name:"):
+			format.Name = strings.TrimSpace(strings.TrimPrefix(line, "name:"))
+		case strings.HasPrefix(line, "ID:"):
+			id, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "ID:")))
+			if err != nil {
+				return nil, fmt.Errorf("invalid trace event id %q: %v", line, err)
+			}
+			format.ID = id
+		case strings.HasPrefix(line, "field:"):
+			field, err := parseTraceEventField(line)
+			if err != nil {
+				return nil, err
+			}
+			format.Fields[field.Name] = field
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(format.Fields) == 0 {
+		return nil, errors.New("no field in trace event format")
+	}
+	return format, nil
+}
+
+// parseTraceEventField parses a line like "field:__u8 saddr[4];	offset:32;	size:4;	signed:0;".
+func parseTraceEventField(line string) (TraceEventField, error) {
+	var field TraceEventField
+	for _, part := range strings.Split(line, ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), ":")
+		if !found {
+			continue
+		}
+		var err error
+		switch key {
+		case "field":
+			decl := strings.Fields(value)
+			if len(decl) == 0 {
+				return field, fmt.Errorf("invalid trace event field %q", line)
+			}
+			name := decl[len(decl)-1]
+			if i := strings.IndexByte(name, '['); i >= 0 {
+				name = name[:i]
+			}
+			field.Name = strings.TrimPrefix(name, "*")
+		case "offset":
+			var offset int
+			offset, err = strconv.Atoi(value)
+			field.Offset = int16(offset)
+		case "size":
+			field.Size, err = strconv.Atoi(value)
+		case "signed":
+			field.Signed = value == "1"
+		}
+		if err != nil {
+			return field, fmt.Errorf("invalid trace event field %q: %v", line, err)
+		}
+	}
+	if field.Name == "" || field.Size == 0 {
+		return field, fmt.Errorf("invalid trace event field %q", line)
+	}
+	return field, nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebpf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const inetSockSetStateFormat = `name: inet_sock_set_state
+ID: 1399
+format:
+	field:unsigned short common_type;	offset:0;	size:2;	signed:0;
+	field:unsigned char common_flags;	offset:2;	size:1;	signed:0;
+	field:unsigned char common_preempt_count;	offset:3;	size:1;	signed:0;
+	field:int common_pid;	offset:4;	size:4;	signed:1;
+
+	field:const void * skaddr;	offset:8;	size:8;	signed:0;
+	field:int oldstate;	offset:16;	size:4;	signed:1;
+	field:int newstate;	offset:20;	size:4;	signed:1;
+	field:__u16 sport;	offset:24;	size:2;	signed:0;
+	field:__u16 dport;	offset:26;	size:2;	signed:0;
+	field:__u16 family;	offset:28;	size:2;	signed:0;
+	field:__u16 protocol;	offset:30;	size:2;	signed:0;
+	field:__u8 saddr[4];	offset:32;	size:4;	signed:0;
+	field:__u8 daddr[4];	offset:36;	size:4;	signed:0;
+	field:__u8 saddr_v6[16];	offset:40;	size:16;	signed:0;
+	field:__u8 daddr_v6[16];	offset:56;	size:16;	signed:0;
+
+print fmt: "family=%s protocol=%s sport=%hu dport=%hu", REC->family, REC->protocol, REC->sport, REC->dport
+`
+
+func TestParseTraceEventFormat(t *testing.T) {
+	format, err := ParseTraceEventFormat(strings.NewReader(inetSockSetStateFormat))
+	require.NoError(t, err)
+	assert.Equal(t, "inet_sock_set_state", format.Name)
+	assert.Equal(t, 1399, format.ID)
+	assert.Len(t, format.Fields, 15)
+
+	field, ok := format.Field("skaddr")
+	require.True(t, ok, "the pointer should be named without the star")
+	assert.Equal(t, TraceEventField{Name: "skaddr", Offset: 8, Size: 8}, field)
+	field, ok = format.Field("oldstate")
+	require.True(t, ok)
+	assert.True(t, field.Signed)
+	field, ok = format.Field("saddr_v6")
+	require.True(t, ok, "the array should be named without the brackets")
+	assert.Equal(t, int16(40), field.Offset)
+	assert.Equal(t, 16, field.Size)
+	_, ok = format.Field("rtt")
+	assert.False(t, ok)
+
+	_, err = ParseTraceEventFormat(strings.NewReader("name: empty\nID: 1\n"))
+	assert.Error(t, err)
+	_, err = ParseTraceEventFormat(strings.NewReader("field:int a;\toffset:x;\tsize:4;\tsigned:1;"))
+	assert.Error(t, err)
+}
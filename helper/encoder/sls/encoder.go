@@ -0,0 +1,185 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sls
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pierrec/lz4"
+
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	converter "github.com/alibaba/ilogtail/pkg/protocol/converter"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	metricNameKey = "__name__"
+	labelsKey     = "__labels__"
+	timeNanoKey   = "__time_nano__"
+	valueKey      = "__value__"
+	typeKey       = "__type__"
+	fieldNameKey  = "__field__"
+	contentKey    = "content"
+
+	// TagKeyTopic and TagKeySource are the group tags stored in the Topic and Source of the LogGroup
+	// instead of the LogTags.
+	TagKeyTopic  = "__topic__"
+	TagKeySource = "__source__"
+
+	// The headers of the SLS PutLogs API describing the compressed body.
+	HeaderCompressType = "x-log-compresstype"
+	HeaderBodyRawSize  = "x-log-bodyrawsize"
+	CompressTypeLZ4    = "lz4"
+)
+
+var errSpanNotSupported = errors.New("span events could not be encoded in the SLS LogGroup")
+
+// Encoded is a LogGroup serialized in protobuf, which is compressed in lz4 if Compressed is true.
+type Encoded struct {
+	Data       []byte
+	RawSize    int
+	Compressed bool
+}
+
+// ConvertToLogGroup converts the events of the group to the SLS LogGroup.
+// The shard hash of the routing hints is stored in the __shardhash__ LogTag consumed by flusher_sls, unless the
+// group tags have one. The metrics are converted to the same contents as the metric inputs of the v1 pipeline, a multi-value metric is
+// converted to one log per field named name:field. The ByteArray events are stored in the content field, and the
+// logs are converted by converter.ToV1Log, which returns the log wrapped by converter.LogV1Event without copying.
+func ConvertToLogGroup(groupEvents *models.PipelineGroupEvents) (*protocol.LogGroup, error) {
+	logGroup := &protocol.LogGroup{Logs: make([]*protocol.Log, 0, len(groupEvents.Events))}
+	for _, tag := range groupEvents.Group.GetTags().SortTo(nil) {
+		switch tag.Key {
+		case TagKeyTopic:
+			logGroup.Topic = tag.Value
+		case TagKeySource:
+			logGroup.Source = tag.Value
+		default:
+			logGroup.LogTags = append(logGroup.LogTags, &protocol.LogTag{Key: tag.Key, Value: tag.Value})
+		}
+	}
+	if shardHash := groupEvents.GetRoutingHints().ShardHash; shardHash != "" && !groupEvents.Group.GetTags().Contains(util.ShardHashTagKey) {
+		logGroup.LogTags = append(logGroup.LogTags, &protocol.LogTag{Key: util.ShardHashTagKey, Value: shardHash})
+	}
+	for _, event := range groupEvents.Events {
+		switch e := event.(type) {
+		case *models.Metric:
+			logGroup.Logs = appendMetricLogs(logGroup.Logs, e)
+		case models.ByteArray:
+			logGroup.Logs = append(logGroup.Logs, &protocol.Log{
+				Contents: []*protocol.Log_Content{{Key: contentKey, Value: string(e)}},
+			})
+		case *models.Log, *converter.LogV1Event:
+			log, _ := converter.ToV1Log(e)
+			logGroup.Logs = append(logGroup.Logs, log)
+		case *models.Span:
+			return nil, errSpanNotSupported
+		default:
+			return nil, fmt.Errorf("unsupported event type %v", event.GetType())
+		}
+	}
+	return logGroup, nil
+}
+
+// Encode converts the group to the LogGroup and marshals it in protobuf. The data is compressed in lz4 when
+// compress is true, unless it is incompressible, which could be told by Encoded.Compressed.
+func Encode(groupEvents *models.PipelineGroupEvents, compress bool) (*Encoded, error) {
+	logGroup, err := ConvertToLogGroup(groupEvents)
+	if err != nil {
+		return nil, err
+	}
+	data, err := logGroup.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	encoded := &Encoded{Data: data, RawSize: len(data)}
+	if !compress {
+		return encoded, nil
+	}
+	buf := make([]byte, lz4.CompressBlockBound(len(data)))
+	n, err := lz4.CompressBlock(data, buf, nil)
+	if err != nil {
+		return nil, err
+	}
+	// n is 0 when the data is incompressible
+	if n > 0 {
+		encoded.Data = buf[:n]
+		encoded.Compressed = true
+	}
+	return encoded, nil
+}
+
+func appendMetricLogs(logs []*protocol.Log, metric *models.Metric) []*protocol.Log {
+	labels := formatLabels(metric.GetTags())
+	timestamp := metric.GetTimestamp()
+	newLog := func(name, value string, extra ...*protocol.Log_Content) *protocol.Log {
+		contents := []*protocol.Log_Content{
+			{Key: metricNameKey, Value: name},
+			{Key: labelsKey, Value: labels},
+			{Key: timeNanoKey, Value: strconv.FormatUint(timestamp, 10)},
+			{Key: valueKey, Value: value},
+		}
+		return &protocol.Log{Time: uint32(timestamp / 1e9), Contents: append(contents, extra...)}
+	}
+
+	value := metric.GetValue()
+	if value.IsSingleValue() {
+		logs = append(logs, newLog(metric.GetName(), formatFloat(value.GetSingleValue())))
+	} else {
+		for _, field := range value.GetMultiValues().SortTo(nil) {
+			logs = append(logs, newLog(metric.GetName()+":"+field.Key, formatFloat(field.Value)))
+		}
+	}
+	for _, field := range metric.GetTypedValue().SortTo(nil) {
+		valueType, typedValue := formatTypedValue(field.Value)
+		logs = append(logs, newLog(metric.GetName()+":"+field.Key, typedValue,
+			&protocol.Log_Content{Key: typeKey, Value: valueType},
+			&protocol.Log_Content{Key: fieldNameKey, Value: field.Key}))
+	}
+	return logs
+}
+
+func formatLabels(tags models.Tags) string {
+	var builder strings.Builder
+	for i, tag := range tags.SortTo(nil) {
+		if i != 0 {
+			builder.WriteByte('|')
+		}
+		builder.WriteString(tag.Key)
+		builder.WriteString("#$#")
+		builder.WriteString(tag.Value)
+	}
+	return builder.String()
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func formatTypedValue(v *models.TypedValue) (string, string) {
+	switch v.Type {
+	case models.ValueTypeBoolean:
+		if b, ok := v.Value.(bool); ok && b {
+			return "bool", "1"
+		}
+		return "bool", "0"
+	default:
+		return "string", fmt.Sprint(v.Value)
+	}
+}
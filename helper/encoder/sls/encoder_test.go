@@ -0,0 +1,136 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sls
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pierrec/lz4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	converter "github.com/alibaba/ilogtail/pkg/protocol/converter"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+func newGroupEvents(events ...models.PipelineEvent) *models.PipelineGroupEvents {
+	tags := models.NewTagsWithKeyValues(TagKeyTopic, "topic", TagKeySource, "10.0.0.1", "host", "a")
+	return &models.PipelineGroupEvents{Group: models.NewGroup(models.NewMetadata(), tags), Events: events}
+}
+
+func TestConvertToLogGroup(t *testing.T) {
+	multiValues := models.NewMetricMultiValue()
+	multiValues.Add("used", 2)
+	multiValues.Add("free", 1.5)
+	typedValues := models.NewMetricTypedValues()
+	typedValues.Add("up", &models.TypedValue{Type: models.ValueTypeBoolean, Value: true})
+	groupEvents := newGroupEvents(
+		models.NewSingleValueMetric("cpu", models.MetricTypeGauge, models.NewTagsWithKeyValues("z", "1", "a", "2"), 1e18, 0.5),
+		models.NewMetric("mem", models.MetricTypeGauge, models.NewTags(), 2e18, multiValues, typedValues),
+		models.ByteArray("raw line"),
+	)
+
+	logGroup, err := ConvertToLogGroup(groupEvents)
+	require.NoError(t, err)
+	assert.Equal(t, "topic", logGroup.Topic)
+	assert.Equal(t, "10.0.0.1", logGroup.Source)
+	assert.Equal(t, []*protocol.LogTag{{Key: "host", Value: "a"}}, logGroup.LogTags)
+	require.Len(t, logGroup.Logs, 5)
+	assert.Equal(t, uint32(1e9), logGroup.Logs[0].Time)
+	assert.Equal(t, []*protocol.Log_Content{
+		{Key: metricNameKey, Value: "cpu"},
+		{Key: labelsKey, Value: "a#$#2|z#$#1"},
+		{Key: timeNanoKey, Value: "1000000000000000000"},
+		{Key: valueKey, Value: "0.5"},
+	}, logGroup.Logs[0].Contents)
+	assert.Equal(t, "mem:free", logGroup.Logs[1].Contents[0].Value)
+	assert.Equal(t, "1.5", logGroup.Logs[1].Contents[3].Value)
+	assert.Equal(t, "mem:used", logGroup.Logs[2].Contents[0].Value)
+	assert.Equal(t, []*protocol.Log_Content{
+		{Key: metricNameKey, Value: "mem:up"},
+		{Key: labelsKey, Value: ""},
+		{Key: timeNanoKey, Value: "2000000000000000000"},
+		{Key: valueKey, Value: "1"},
+		{Key: typeKey, Value: "bool"},
+		{Key: fieldNameKey, Value: "up"},
+	}, logGroup.Logs[3].Contents)
+	assert.Equal(t, []*protocol.Log_Content{{Key: contentKey, Value: "raw line"}}, logGroup.Logs[4].Contents)
+
+	_, err = ConvertToLogGroup(newGroupEvents(&models.Span{}))
+	assert.Error(t, err)
+}
+
+func TestConvertToLogGroupShardHash(t *testing.T) {
+	groupEvents := newGroupEvents(models.ByteArray("raw line"))
+	groupEvents.Group.RoutingHints = &models.RoutingHints{ShardHash: "abc"}
+	logGroup, err := ConvertToLogGroup(groupEvents)
+	require.NoError(t, err)
+	assert.Equal(t, []*protocol.LogTag{{Key: "host", Value: "a"}, {Key: util.ShardHashTagKey, Value: "abc"}}, logGroup.LogTags)
+
+	// the shard hash of the tags is kept
+	groupEvents.Group.Tags.Add(util.ShardHashTagKey, "def")
+	logGroup, err = ConvertToLogGroup(groupEvents)
+	require.NoError(t, err)
+	assert.Equal(t, []*protocol.LogTag{{Key: util.ShardHashTagKey, Value: "def"}, {Key: "host", Value: "a"}}, logGroup.LogTags)
+}
+
+func TestConvertToLogGroupV1RoundTrip(t *testing.T) {
+	origin := &protocol.LogGroup{
+		Logs:    []*protocol.Log{{Time: 1, Contents: []*protocol.Log_Content{{Key: contentKey, Value: "raw line"}}}},
+		Topic:   "topic",
+		Source:  "10.0.0.1",
+		LogTags: []*protocol.LogTag{{Key: "host", Value: "a"}},
+	}
+	groupEvents := converter.LogGroupToPipelineGroupEvents(origin)
+	groupEvents.Events = append(groupEvents.Events, models.NewLog("", []byte("v2 line"), "", 2e9, nil))
+
+	logGroup, err := ConvertToLogGroup(groupEvents)
+	require.NoError(t, err)
+	assert.Equal(t, "topic", logGroup.Topic)
+	assert.Equal(t, "10.0.0.1", logGroup.Source)
+	assert.Equal(t, origin.LogTags, logGroup.LogTags)
+	require.Len(t, logGroup.Logs, 2)
+	// the wrapped v1 log is not copied
+	assert.Same(t, origin.Logs[0], logGroup.Logs[0])
+	assert.Equal(t, &protocol.Log{Time: 2, Contents: []*protocol.Log_Content{{Key: contentKey, Value: "v2 line"}}}, logGroup.Logs[1])
+}
+
+func TestEncode(t *testing.T) {
+	line := models.ByteArray(strings.Repeat("GET /index.html 200 ", 50))
+	groupEvents := newGroupEvents(line, line)
+
+	encoded, err := Encode(groupEvents, false)
+	require.NoError(t, err)
+	assert.False(t, encoded.Compressed)
+	assert.Equal(t, encoded.RawSize, len(encoded.Data))
+	raw := encoded.Data
+
+	encoded, err = Encode(groupEvents, true)
+	require.NoError(t, err)
+	require.True(t, encoded.Compressed)
+	assert.Less(t, len(encoded.Data), encoded.RawSize)
+	data := make([]byte, encoded.RawSize)
+	n, err := lz4.UncompressBlock(encoded.Data, data)
+	require.NoError(t, err)
+	assert.Equal(t, raw, data[:n])
+
+	logGroup := &protocol.LogGroup{}
+	require.NoError(t, logGroup.Unmarshal(data))
+	require.Len(t, logGroup.Logs, 2)
+	assert.Equal(t, string(line), logGroup.Logs[1].Contents[0].Value)
+}
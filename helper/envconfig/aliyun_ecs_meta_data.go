@@ -31,6 +31,7 @@ import (
 
 	"github.com/alibaba/ilogtail/helper"
 	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 const (
@@ -71,14 +72,14 @@ func getToken() (result []byte, err error) {
 	logger.Debug(context.Background(), "get role list request", aliyunECSRamURL)
 	respList, err = client.Get(aliyunECSRamURL)
 	if err != nil {
-		logger.Warning(context.Background(), "UPDATE_STS_ALARM", "get role list error", err)
+		logger.Warning(context.Background(), util.AlarmUpdateSTS, "get role list error", err)
 		return nil, err
 	}
 	defer respList.Body.Close()
 	var body []byte
 	body, err = ioutil.ReadAll(respList.Body)
 	if err != nil {
-		logger.Warning(context.Background(), "UPDATE_STS_ALARM", "parse role list error", err)
+		logger.Warning(context.Background(), util.AlarmUpdateSTS, "parse role list error", err)
 		return nil, err
 	}
 	logger.Debug(context.Background(), "get role list response", string(body))
@@ -92,13 +93,13 @@ func getToken() (result []byte, err error) {
 	logger.Debug(context.Background(), "get token request", aliyunECSRamURL+role)
 	respGet, err = client.Get(aliyunECSRamURL + role)
 	if err != nil {
-		logger.Warning(context.Background(), "UPDATE_STS_ALARM", "get token error", err, "role", role)
+		logger.Warning(context.Background(), util.AlarmUpdateSTS, "get token error", err, "role", role)
 		return nil, err
 	}
 	defer respGet.Body.Close()
 	body, err = ioutil.ReadAll(respGet.Body)
 	if err != nil {
-		logger.Warning(context.Background(), "UPDATE_STS_ALARM", "parse token error", err, "role", role)
+		logger.Warning(context.Background(), util.AlarmUpdateSTS, "parse token error", err, "role", role)
 		return nil, err
 	}
 	return body, nil
@@ -216,20 +217,20 @@ func UpdateTokenFunction() (accessKeyID, accessKeySecret, securityToken string,
 		var tokenResult SecurityTokenResult
 		err = json.Unmarshal(tokenResultBuffer, &tokenResult)
 		if err != nil {
-			logger.Warning(context.Background(), "UPDATE_STS_ALARM", "unmarshal token error", err, "token", string(tokenResultBuffer))
+			logger.Warning(context.Background(), util.AlarmUpdateSTS, "unmarshal token error", err, "token", string(tokenResultBuffer))
 			continue
 		}
 		if strings.ToLower(tokenResult.Code) != "success" {
 			tokenResult.AccessKeySecret = "xxxxx"
 			tokenResult.SecurityToken = "xxxxx"
-			logger.Warning(context.Background(), "UPDATE_STS_ALARM", "token code not success", err, "result", tokenResult)
+			logger.Warning(context.Background(), util.AlarmUpdateSTS, "token code not success", err, "result", tokenResult)
 			continue
 		}
 		expireTime, err = time.Parse(expirationTimeFormat, tokenResult.Expiration)
 		if err != nil {
 			tokenResult.AccessKeySecret = "xxxxx"
 			tokenResult.SecurityToken = "xxxxx"
-			logger.Warning(context.Background(), "UPDATE_STS_ALARM", "parse time error", err, "result", tokenResult)
+			logger.Warning(context.Background(), util.AlarmUpdateSTS, "parse time error", err, "result", tokenResult)
 			continue
 		}
 		logger.Info(context.Background(), "get security token success, id", tokenResult.AccessKeyID, "expire", tokenResult.Expiration, "last update", tokenResult.LastUpdated)
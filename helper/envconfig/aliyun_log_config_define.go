@@ -27,6 +27,7 @@ import (
 	"github.com/alibaba/ilogtail/helper"
 	"github.com/alibaba/ilogtail/pkg/flags"
 	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 // K8S Name : k8s_logtail_logtail-ds-rq95g_kube-system_32417d70-9085-11e8-851d-00163f008685_0
@@ -207,7 +208,7 @@ func initFileConfig(k8sInfo *helper.K8SInfo, config *AliyunLogConfigSpec, filePa
 	config.LogtailConfig.InputType = "file"
 	logPath, filePattern, err := splitLogPathAndFilePattern(filePath)
 	if err != nil {
-		logger.Error(context.Background(), "INVALID_DOCKER_ENV_CONFIG_ALARM", "invalid file config, you must input full file path", filePath, err)
+		logger.Error(context.Background(), util.AlarmInvalidDockerEnvConfig, "invalid file config, you must input full file path", filePath, err)
 	}
 
 	if !jsonFlag {
@@ -338,7 +339,7 @@ func makeLogConfigSpec(dockerInfo *helper.DockerInfoDetail, envConfigInfo *helpe
 	if configDetail, ok := envConfigInfo.ConfigItemMap["detail"]; ok {
 		totalConfig += configDetail
 		if err := json.Unmarshal([]byte(configDetail), &config.LogtailConfig.LogtailConfig); err != nil {
-			logger.Error(context.Background(), "INVALID_ENV_CONFIG_DETAIL", "unmarshal error", err, "detail", configDetail)
+			logger.Error(context.Background(), util.AlarmInvalidEnvConfigDetail, "unmarshal error", err, "detail", configDetail)
 		}
 		config.LogtailConfig.InputType = configType
 		config.SimpleConfig = false
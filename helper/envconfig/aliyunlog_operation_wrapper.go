@@ -105,7 +105,7 @@ func createAliyunLogOperationWrapper(endpoint, project, accessKeyID, accessKeySe
 		if err == nil {
 			break
 		}
-		logger.Warning(context.Background(), "CREATE_PROJECT_ALARM", "create project error, project", project, "error", err)
+		logger.Warning(context.Background(), util.AlarmCreateProject, "create project error, project", project, "error", err)
 		time.Sleep(time.Second * time.Duration(30))
 	}
 
@@ -115,7 +115,7 @@ func createAliyunLogOperationWrapper(endpoint, project, accessKeyID, accessKeySe
 		if err == nil {
 			break
 		}
-		logger.Warning(context.Background(), "CREATE_MACHINEGROUP_ALARM", "create machine group error, project", project, "error", err)
+		logger.Warning(context.Background(), util.AlarmCreateMachineGroup, "create machine group error, project", project, "error", err)
 		time.Sleep(time.Second * time.Duration(30))
 	}
 	if err != nil {
@@ -199,7 +199,7 @@ func (o *operationWrapper) createProductLogstore(config *AliyunLogConfigSpec, pr
 			customErr := CustomErrorFromPopError(err)
 			k8s_event.GetEventRecorder().SendErrorEventWithAnnotation(k8s_event.GetEventRecorder().GetObject(), GetAnnotationByError(annotations, customErr), k8s_event.CreateProductLogStore, "", fmt.Sprintf("create product log failed, error: %s", err.Error()))
 		}
-		logger.Warning(context.Background(), "CREATE_PRODUCT_ALARM", "create product error, error", err)
+		logger.Warning(context.Background(), util.AlarmCreateProduct, "create product error, error", err)
 		return err
 	} else if k8s_event.GetEventRecorder() != nil {
 		k8s_event.GetEventRecorder().SendNormalEventWithAnnotation(k8s_event.GetEventRecorder().GetObject(), annotations, k8s_event.CreateProductLogStore, "create product log success")
@@ -302,7 +302,7 @@ func (o *operationWrapper) makesureLogstoreExist(config *AliyunLogConfigSpec, pr
 	if err == nil {
 		logger.Info(context.Background(), "create index done, logstore", logstore)
 	} else {
-		logger.Warning(context.Background(), "CREATE_INDEX_ALARM", "create index done, logstore", logstore, "error", err)
+		logger.Warning(context.Background(), util.AlarmCreateIndex, "create index done, logstore", logstore, "error", err)
 	}
 	return nil
 }
@@ -38,7 +38,7 @@ func panicRecover() {
 	if err := recover(); err != nil {
 		trace := make([]byte, 2048)
 		runtime.Stack(trace, true)
-		logger.Error(context.Background(), "PLUGIN_RUNTIME_ALARM", "docker env conifg runtime error", err, "stack", string(trace))
+		logger.Error(context.Background(), util.AlarmPluginRuntime, "docker env conifg runtime error", err, "stack", string(trace))
 	}
 }
 
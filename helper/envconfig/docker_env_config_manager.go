@@ -23,6 +23,7 @@ import (
 
 	"github.com/alibaba/ilogtail/pkg/flags"
 	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
 	pluginmanager "github.com/alibaba/ilogtail/pluginmanager"
 )
 
@@ -74,14 +75,14 @@ func (decm *Manager) loadCheckpoint() {
 		return
 	}
 	if err = json.Unmarshal(val, &decm.AllConfigMap); err != nil {
-		logger.Error(context.Background(), "DOCKER_ENV_CONFIG_CHECKPOINT_ALARM", "load checkpoint error, err", err, "content", string(val))
+		logger.Error(context.Background(), util.AlarmDockerEnvConfigCheckpoint, "load checkpoint error, err", err, "content", string(val))
 	}
 }
 
 func (decm *Manager) saveCheckpoint() {
 	checkpoint, err := json.Marshal(decm.AllConfigMap)
 	if err != nil {
-		logger.Error(context.Background(), "DOCKER_ENV_CONFIG_CHECKPOINT_ALARM", "save checkpoint error, err", err)
+		logger.Error(context.Background(), util.AlarmDockerEnvConfigCheckpoint, "save checkpoint error, err", err)
 	}
 	_ = pluginmanager.CheckPointManager.SaveCheckpoint("docker_env_config", "v1", checkpoint)
 }
@@ -93,7 +94,7 @@ func (decm *Manager) run() {
 	for i := 0; i < 100000000; i++ {
 		decm.operationWrapper, err = createAliyunLogOperationWrapper(*flags.LogServiceEndpoint, *flags.DefaultLogProject, *flags.DefaultAccessKeyID, *flags.DefaultAccessKeySecret, *flags.DefaultSTSToken, decm.shutdown)
 		if err != nil {
-			logger.Error(context.Background(), "DOCKER_ENV_CONFIG_INIT_ALARM", "create log operation wrapper, err", err)
+			logger.Error(context.Background(), util.AlarmDockerEnvConfigInit, "create log operation wrapper, err", err)
 			sleepInterval := i * 5
 			if sleepInterval > 3600 {
 				sleepInterval = 3600
@@ -133,7 +134,7 @@ func (decm *Manager) run() {
 					tryInterval = 900
 				}
 				config.NextTryTime = nowTime + tryInterval
-				logger.Error(context.Background(), "DOCKER_ENV_CONFIG_OPERATION_ALARM", "update config failed, config key", config.Key(), "error", err.Error(), "error count", config.ErrorCount, "next try time", config.NextTryTime)
+				logger.Error(context.Background(), util.AlarmDockerEnvConfigOperation, "update config failed, config key", config.Key(), "error", err.Error(), "error count", config.ErrorCount, "next try time", config.NextTryTime)
 			} else {
 				// when update success, set config.ErrorCount = 0
 				config.ErrorCount = 0
@@ -20,6 +20,7 @@ import (
 
 	"github.com/alibaba/ilogtail/helper"
 	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 const nginxIngressProcessor = `
@@ -62,18 +63,18 @@ func initNginxIngress(_ *helper.K8SInfo, config *AliyunLogConfigSpec, configType
 	}
 	pluginConfig, ok := config.LogtailConfig.LogtailConfig["plugin"]
 	if !ok {
-		logger.Error(context.Background(), "LOGTAIL_CONFIG_ALARM", "invalid nginx ingress config", "no plugin")
+		logger.Error(context.Background(), util.AlarmLogtailConfig, "invalid nginx ingress config", "no plugin")
 		return
 	}
 	pluginConfigDetail, ok := pluginConfig.(map[string]interface{})
 	if !ok {
-		logger.Error(context.Background(), "LOGTAIL_CONFIG_ALARM", "invalid nginx ingress config", "plugin type error")
+		logger.Error(context.Background(), util.AlarmLogtailConfig, "invalid nginx ingress config", "plugin type error")
 		return
 	}
 	nginxIngressProcessorDetail := make(map[string]interface{})
 	err := json.Unmarshal(([]byte)(nginxIngressProcessor), &nginxIngressProcessorDetail)
 	if err != nil {
-		logger.Error(context.Background(), "LOGTAIL_CONFIG_ALARM", "invalid nginx ingress config", "processor type error")
+		logger.Error(context.Background(), util.AlarmLogtailConfig, "invalid nginx ingress config", "processor type error")
 		return
 	}
 	pluginConfigDetail["processors"] = []interface{}{nginxIngressProcessorDetail}
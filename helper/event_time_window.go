@@ -0,0 +1,122 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helper
+
+import "time"
+
+// EventTimeWindows assigns events to the tumbling windows of their event time, and tracks the watermark
+// which is the max event time seen minus the allowed lateness. A window is closed once the watermark passes
+// its end, and the later events of it are dropped, so that delayed or replayed data is aggregated into the
+// windows they belong to instead of the windows they arrive in.
+// The watermark also moves on with the processing time while no events arrive, so that the last windows
+// are closed when the source is idle.
+// The event times later than the processing time plus the max skew are clamped, so that one event from the
+// future could not close all the windows, and the count of the open windows is limited.
+type EventTimeWindows struct {
+	size      int64
+	lateness  int64
+	watermark int64
+	// The processing time when the watermark is updated, zero before the first event.
+	updated    time.Time
+	nowFunc    func() time.Time
+	maxSkew    int64
+	maxWindows int
+	open       map[int64]struct{}
+}
+
+const (
+	defaultEventTimeMaxSkewSec = 300
+	defaultEventTimeMaxWindows = 1000
+)
+
+// NewEventTimeWindows creates the windows of sizeSec seconds allowing the events at most latenessSec seconds late.
+func NewEventTimeWindows(sizeSec, latenessSec int, nowFunc func() time.Time) *EventTimeWindows {
+	if nowFunc == nil {
+		nowFunc = time.Now
+	}
+	return &EventTimeWindows{
+		size:       int64(sizeSec),
+		lateness:   int64(latenessSec),
+		nowFunc:    nowFunc,
+		maxSkew:    defaultEventTimeMaxSkewSec,
+		maxWindows: defaultEventTimeMaxWindows,
+		open:       make(map[int64]struct{}),
+	}
+}
+
+// SetLimits changes the max seconds the event times could be ahead of the processing time, and the max count
+// of the open windows. The non-positive values keep the defaults.
+func (w *EventTimeWindows) SetLimits(maxSkewSec, maxWindows int) {
+	if maxSkewSec > 0 {
+		w.maxSkew = int64(maxSkewSec)
+	}
+	if maxWindows > 0 {
+		w.maxWindows = maxWindows
+	}
+}
+
+// Assign returns the start of the window of the event time in seconds, and false if the window is closed or
+// too many windows are open.
+func (w *EventTimeWindows) Assign(eventTime int64) (int64, bool) {
+	if limit := w.nowFunc().Unix() + w.maxSkew; eventTime > limit {
+		eventTime = limit
+	}
+	start := eventTime - eventTime%w.size
+	watermark, ok := w.Watermark()
+	if ok && start+w.size <= watermark {
+		return start, false
+	}
+	if _, exists := w.open[start]; !exists {
+		for s := range w.open {
+			if ok && s+w.size <= watermark {
+				delete(w.open, s)
+			}
+		}
+		if len(w.open) >= w.maxWindows {
+			return start, false
+		}
+		w.open[start] = struct{}{}
+	}
+	if eventTime-w.lateness > watermark || !ok {
+		watermark = eventTime - w.lateness
+	}
+	w.watermark, w.updated = watermark, w.nowFunc()
+	return start, true
+}
+
+// Watermark returns the current watermark in seconds, and false before the first event.
+func (w *EventTimeWindows) Watermark() (int64, bool) {
+	if w.updated.IsZero() {
+		return 0, false
+	}
+	return w.watermark + int64(w.nowFunc().Sub(w.updated)/time.Second), true
+}
+
+// Closed reports whether the window starting at start is closed by the watermark.
+func (w *EventTimeWindows) Closed(start int64) bool {
+	watermark, ok := w.Watermark()
+	return ok && start+w.size <= watermark
+}
+
+// Size returns the size of the windows in seconds.
+func (w *EventTimeWindows) Size() int64 {
+	return w.size
+}
+
+// Reset forgets the watermark, e.g. when the aggregated data is dropped.
+func (w *EventTimeWindows) Reset() {
+	w.watermark, w.updated = 0, time.Time{}
+	w.open = make(map[int64]struct{})
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventTimeWindows(t *testing.T) {
+	now := time.Unix(1000, 0)
+	w := NewEventTimeWindows(10, 5, func() time.Time { return now })
+	assert.False(t, w.Closed(0))
+
+	start, ok := w.Assign(123)
+	assert.True(t, ok)
+	assert.Equal(t, int64(120), start)
+	watermark, _ := w.Watermark()
+	assert.Equal(t, int64(118), watermark)
+
+	// late but within the lateness
+	start, ok = w.Assign(117)
+	assert.True(t, ok)
+	assert.Equal(t, int64(110), start)
+	assert.False(t, w.Closed(110))
+
+	// the watermark passes the end of window 110
+	_, ok = w.Assign(125)
+	assert.True(t, ok)
+	assert.True(t, w.Closed(110))
+	assert.False(t, w.Closed(120))
+	_, ok = w.Assign(119)
+	assert.False(t, ok)
+
+	// the watermark moves on while idle
+	now = now.Add(10 * time.Second)
+	assert.True(t, w.Closed(120))
+
+	w.Reset()
+	_, ok = w.Assign(100)
+	assert.True(t, ok)
+}
+
+func TestEventTimeWindowsLimits(t *testing.T) {
+	now := time.Unix(1000, 0)
+	w := NewEventTimeWindows(10, 100, func() time.Time { return now })
+	w.SetLimits(20, 2)
+
+	// the event from the future is clamped to now plus the skew
+	start, ok := w.Assign(99999)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1020), start)
+	watermark, _ := w.Watermark()
+	assert.Equal(t, int64(920), watermark)
+	_, ok = w.Assign(1000)
+	assert.True(t, ok, "the window should not be closed by the event from the future")
+
+	// at most 2 windows are open
+	_, ok = w.Assign(990)
+	assert.False(t, ok)
+	_, ok = w.Assign(1005)
+	assert.True(t, ok, "the open window still accepts events")
+}
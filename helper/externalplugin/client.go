@@ -0,0 +1,233 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalplugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+const (
+	defaultTimeoutMs              = 5000
+	defaultHealthCheckIntervalSec = 10
+)
+
+// Options are the common options of the external plugins.
+type Options struct {
+	// The address of the running sidecar, such as "127.0.0.1:7000" or "unix:///var/run/plugin.sock".
+	Address string
+	// The command to launch the sidecar, which prints the handshake line "1|network|address|grpc" to stdout
+	// after listening, see Serve. The sidecars launched by the same command and env are shared by the plugins.
+	// The executable must be one of the allowed commands of the agent, see SetAllowedCommands.
+	Command []string
+	// The extra environment variables of the launched sidecar.
+	Env map[string]string
+	// The name of the plugin served by the sidecar.
+	Plugin string
+	// The config of the plugin, which is passed to the sidecar in json.
+	Config map[string]interface{}
+	// The timeout of the requests, 5000 by default.
+	TimeoutMs int
+	// The interval to check the health of the sidecar, 10 by default. The launched sidecar is relaunched
+	// after 3 continuous failures.
+	HealthCheckIntervalSec int
+}
+
+// Client is an instance of the external plugin in the sidecar.
+type Client struct {
+	opts       Options
+	category   string
+	configName string
+	config     string
+	timeout    time.Duration
+	sidecar    *sidecar
+
+	lock       sync.Mutex
+	instanceID string
+}
+
+// NewClient connects the sidecar of @opts and creates an instance of the plugin of @category.
+func NewClient(opts Options, category string, configName string) (*Client, error) {
+	if opts.Plugin == "" {
+		return nil, errors.New("the plugin name is required")
+	}
+	if (opts.Address == "") == (len(opts.Command) == 0) {
+		return nil, errors.New("either Address or Command is required")
+	}
+	if len(opts.Command) > 0 && !isCommandAllowed(opts.Command[0]) {
+		return nil, fmt.Errorf("command %s of the external plugin is not allowed, see SetAllowedCommands", opts.Command[0])
+	}
+	if opts.TimeoutMs <= 0 {
+		opts.TimeoutMs = defaultTimeoutMs
+	}
+	if opts.HealthCheckIntervalSec <= 0 {
+		opts.HealthCheckIntervalSec = defaultHealthCheckIntervalSec
+	}
+	config := []byte("{}")
+	if opts.Config != nil {
+		var err error
+		if config, err = json.Marshal(opts.Config); err != nil {
+			return nil, err
+		}
+	}
+	c := &Client{
+		opts:       opts,
+		category:   category,
+		configName: configName,
+		config:     string(config),
+		timeout:    time.Duration(opts.TimeoutMs) * time.Millisecond,
+	}
+	var err error
+	if c.sidecar, err = acquireSidecar(&c.opts); err != nil {
+		return nil, err
+	}
+	if _, err = c.init(); err != nil {
+		c.sidecar.release()
+		return nil, err
+	}
+	return c, nil
+}
+
+// init creates the instance in the sidecar, and returns the id.
+func (c *Client) init() (string, error) {
+	client, err := c.client()
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	resp, err := client.Init(ctx, &InitRequest{Plugin: c.opts.Plugin, Category: c.category, ConfigName: c.configName, Config: c.config})
+	if err != nil {
+		return "", fmt.Errorf("init external plugin %s error: %v", c.opts.Plugin, err)
+	}
+	c.lock.Lock()
+	c.instanceID = resp.InstanceId
+	c.lock.Unlock()
+	return resp.InstanceId, nil
+}
+
+func (c *Client) getInstanceID() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.instanceID
+}
+
+// client returns the client of the current connection, which changes when the sidecar is relaunched.
+func (c *Client) client() (ExternalPluginClient, error) {
+	conn := c.sidecar.getConn()
+	if conn == nil {
+		return nil, status.Error(codes.Unavailable, "external plugin is not connected")
+	}
+	return NewExternalPluginClient(conn), nil
+}
+
+// invoke calls @fn with the instance id, and creates the instance again if the sidecar doesn't know it.
+func (c *Client) invoke(fn func(ctx context.Context, client ExternalPluginClient, instanceID string) error) error {
+	call := func(instanceID string) error {
+		client, err := c.client()
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+		defer cancel()
+		return fn(ctx, client, instanceID)
+	}
+	err := call(c.getInstanceID())
+	if status.Code(err) != codes.NotFound {
+		return err
+	}
+	instanceID, err := c.init()
+	if err != nil {
+		return err
+	}
+	return call(instanceID)
+}
+
+// Process processes @logGroup by the processor instance.
+func (c *Client) Process(logGroup *protocol.LogGroup) (*protocol.LogGroup, error) {
+	var resp *ProcessResponse
+	err := c.invoke(func(ctx context.Context, client ExternalPluginClient, instanceID string) (err error) {
+		resp, err = client.Process(ctx, &ProcessRequest{InstanceId: instanceID, LogGroup: logGroup})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.LogGroup == nil {
+		resp.LogGroup = &protocol.LogGroup{}
+	}
+	return resp.LogGroup, nil
+}
+
+// Flush sends @logGroups by the flusher instance.
+func (c *Client) Flush(logGroups []*protocol.LogGroup) error {
+	return c.invoke(func(ctx context.Context, client ExternalPluginClient, instanceID string) error {
+		_, err := client.Flush(ctx, &FlushRequest{InstanceId: instanceID, LogGroups: logGroups})
+		return err
+	})
+}
+
+// Collect receives the data of the input instance until @ctx is done or the stream is broken.
+func (c *Client) Collect(ctx context.Context, fn func(*protocol.LogGroup)) error {
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := client.Collect(ctx, &InstanceRequest{InstanceId: c.getInstanceID()})
+	if err != nil {
+		return err
+	}
+	for {
+		logGroup, err := stream.Recv()
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				if _, initErr := c.init(); initErr != nil {
+					return initErr
+				}
+			}
+			return err
+		}
+		fn(logGroup)
+	}
+}
+
+// Healthy returns whether the sidecar passes the health checks.
+func (c *Client) Healthy() bool {
+	return c.sidecar.isHealthy()
+}
+
+// Close stops the instance and releases the sidecar.
+func (c *Client) Close() error {
+	defer c.sidecar.release()
+	client, err := c.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	_, err = client.Stop(ctx, &InstanceRequest{InstanceId: c.getInstanceID()})
+	return err
+}
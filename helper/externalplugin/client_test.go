@@ -0,0 +1,209 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalplugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+const helperEnv = "EXTERNAL_PLUGIN_TEST_HELPER"
+
+// the test binary serves the test plugins as the sidecar if launched by TestLaunchedSidecar.
+func TestMain(m *testing.M) {
+	if os.Getenv(helperEnv) == "1" {
+		if err := Serve(testFactories); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+type testPlugin struct {
+	Key string
+
+	lock    sync.Mutex
+	flushed []*protocol.LogGroup
+}
+
+func (p *testPlugin) Stop() error {
+	return nil
+}
+
+func (p *testPlugin) Process(logGroup *protocol.LogGroup) (*protocol.LogGroup, error) {
+	for _, log := range logGroup.Logs {
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: p.Key, Value: "processed"})
+	}
+	return logGroup, nil
+}
+
+func (p *testPlugin) Flush(logGroups []*protocol.LogGroup) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if len(logGroups) == 0 {
+		return errors.New("empty")
+	}
+	p.flushed = append(p.flushed, logGroups...)
+	return nil
+}
+
+func (p *testPlugin) Collect(ctx context.Context, emit func(*protocol.LogGroup) error) error {
+	for i := 0; ; i++ {
+		logGroup := &protocol.LogGroup{Logs: []*protocol.Log{{Contents: []*protocol.Log_Content{{Key: p.Key, Value: "collected"}}}}}
+		if err := emit(logGroup); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+var (
+	lastPlugin     *testPlugin
+	testFactories  = map[string]Factory{"test": newTestPlugin}
+	lastPluginLock sync.Mutex
+)
+
+func newTestPlugin(configName string, config string) (Instance, error) {
+	p := &testPlugin{}
+	if err := json.Unmarshal([]byte(config), p); err != nil {
+		return nil, err
+	}
+	lastPluginLock.Lock()
+	lastPlugin = p
+	lastPluginLock.Unlock()
+	return p, nil
+}
+
+func startServer(t *testing.T) (*Server, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer(ServerOption())
+	s := NewServer(testFactories)
+	s.Register(server)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+	return s, listener.Addr().String()
+}
+
+func TestClient(t *testing.T) {
+	s, address := startServer(t)
+	opts := Options{Address: address, Plugin: "test", Config: map[string]interface{}{"Key": "k"}}
+
+	processor, err := NewClient(opts, CategoryProcessor, "c")
+	require.NoError(t, err)
+	logGroup, err := processor.Process(&protocol.LogGroup{Logs: []*protocol.Log{{Time: 1}}})
+	require.NoError(t, err)
+	require.Len(t, logGroup.Logs, 1)
+	assert.Equal(t, "k", logGroup.Logs[0].Contents[0].Key)
+	assert.True(t, processor.Healthy())
+
+	// the instance is created again if the sidecar doesn't know it
+	s.instances.Delete(processor.getInstanceID())
+	_, err = processor.Process(&protocol.LogGroup{})
+	require.NoError(t, err)
+	require.NoError(t, processor.Close())
+
+	flusher, err := NewClient(opts, CategoryFlusher, "c")
+	require.NoError(t, err)
+	require.NoError(t, flusher.Flush([]*protocol.LogGroup{{Topic: "t"}}))
+	lastPluginLock.Lock()
+	assert.Equal(t, "t", lastPlugin.flushed[0].Topic)
+	lastPluginLock.Unlock()
+	assert.ErrorContains(t, flusher.Flush(nil), "empty")
+	require.NoError(t, flusher.Close())
+
+	input, err := NewClient(opts, CategoryInput, "c")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	var collected int
+	err = input.Collect(ctx, func(logGroup *protocol.LogGroup) {
+		assert.Equal(t, "collected", logGroup.Logs[0].Contents[0].Value)
+		if collected++; collected == 3 {
+			cancel()
+		}
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 3, collected)
+	require.NoError(t, input.Close())
+
+	_, err = NewClient(Options{Address: address, Plugin: "unknown"}, CategoryInput, "c")
+	assert.ErrorContains(t, err, "unknown plugin")
+	_, err = NewClient(Options{Address: address, Command: []string{"plugin"}, Plugin: "test"}, CategoryInput, "c")
+	assert.Error(t, err)
+	_, err = NewClient(Options{Command: []string{"/bin/sh", "-c", "echo"}, Plugin: "test"}, CategoryInput, "c")
+	assert.ErrorContains(t, err, "not allowed")
+}
+
+func TestLaunchedSidecar(t *testing.T) {
+	SetAllowedCommands([]string{os.Args[0]})
+	defer SetAllowedCommands(nil)
+	opts := Options{Command: []string{os.Args[0]}, Env: map[string]string{helperEnv: "1"}, Plugin: "test", Config: map[string]interface{}{"Key": "k"}}
+	processor, err := NewClient(opts, CategoryProcessor, "c1")
+	require.NoError(t, err)
+	another, err := NewClient(opts, CategoryProcessor, "c2")
+	require.NoError(t, err)
+	assert.Same(t, processor.sidecar, another.sidecar, "the sidecar should be shared")
+	require.NoError(t, another.Close())
+
+	logGroup, err := processor.Process(&protocol.LogGroup{Logs: []*protocol.Log{{Time: 1}}})
+	require.NoError(t, err)
+	assert.Equal(t, "processed", logGroup.Logs[0].Contents[0].Value)
+	require.NoError(t, processor.sidecar.check())
+
+	// the relaunched sidecar doesn't know the instance, which is created again
+	processor.sidecar.lock.Lock()
+	processor.sidecar.close()
+	require.NoError(t, processor.sidecar.connect())
+	processor.sidecar.lock.Unlock()
+	_, err = processor.Process(&protocol.LogGroup{Logs: []*protocol.Log{{Time: 1}}})
+	require.NoError(t, err)
+
+	// the sidecar is closed when the agent exits, and the plugins closed later don't fail
+	StopAllSidecars()
+	assert.Nil(t, processor.sidecar.getConn())
+	assert.Error(t, processor.Close())
+}
+
+func TestParseHandshake(t *testing.T) {
+	address, err := parseHandshake("1|unix|/tmp/plugin.sock|grpc\n")
+	require.NoError(t, err)
+	assert.Equal(t, "unix:///tmp/plugin.sock", address)
+	address, err = parseHandshake("1|tcp|127.0.0.1:7000|grpc")
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:7000", address)
+	for _, line := range []string{"", "2|tcp|127.0.0.1:7000|grpc", "1|tcp|127.0.0.1:7000|netrpc", "1|udp|127.0.0.1:7000|grpc"} {
+		_, err = parseHandshake(line)
+		assert.Error(t, err, line)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalplugin
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const serviceName = "ilogtail.plugin.v1.ExternalPlugin"
+
+// gogoMessage is a message generated by gogo protobuf, such as the messages of external_plugin.pb.go and
+// the log groups.
+type gogoMessage interface {
+	Marshal() ([]byte, error)
+	Unmarshal(data []byte) error
+}
+
+// codec encodes the gogo messages of the contract with the generated methods, and the messages of the
+// health service by protobuf. It's forced on both sides, so the codec registered globally by other plugins
+// doesn't matter.
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	switch m := v.(type) {
+	case gogoMessage:
+		return m.Marshal()
+	case proto.Message:
+		return proto.Marshal(m)
+	}
+	return nil, fmt.Errorf("unexpected message type %T", v)
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	switch m := v.(type) {
+	case gogoMessage:
+		return m.Unmarshal(data)
+	case proto.Message:
+		return proto.Unmarshal(data, m)
+	}
+	return fmt.Errorf("unexpected message type %T", v)
+}
+
+func (codec) Name() string {
+	return "proto"
+}
@@ -0,0 +1,27 @@
+#!/bin/bash
+# Copyright 2023 iLogtail Authors
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# Generates external_plugin.pb.go in the package of the external plugins by protoc-gen-gogofaster,
+# the log groups are the ones of pkg/protocol.
+PROTO_HOME=$(cd $(dirname "$0"); pwd)
+ROOT_DIR=$(cd "$PROTO_HOME"/../../..; pwd)
+GEN_HOME=$(mktemp -d)
+
+protoc -I="${PROTO_HOME}" \
+  -I="${ROOT_DIR}/pkg/protocol/proto" \
+  --gogofaster_out=plugins=grpc,Msls_logs.proto=github.com/alibaba/ilogtail/pkg/protocol:"${GEN_HOME}" \
+  "${PROTO_HOME}"/external_plugin.proto
+cp "${GEN_HOME}"/github.com/alibaba/ilogtail/helper/externalplugin/external_plugin.pb.go "${PROTO_HOME}"/..
+rm -rf "${GEN_HOME}"
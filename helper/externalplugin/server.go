@@ -0,0 +1,206 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+// Instance is an instance of an external plugin created by the sidecar, which must also implement
+// Input, Processor or Flusher according to its category.
+type Instance interface {
+	Stop() error
+}
+
+// Input is the instance of an external input plugin.
+type Input interface {
+	Instance
+	// Collect emits the data until @ctx is done.
+	Collect(ctx context.Context, emit func(*protocol.LogGroup) error) error
+}
+
+// Processor is the instance of an external processor plugin.
+type Processor interface {
+	Instance
+	Process(logGroup *protocol.LogGroup) (*protocol.LogGroup, error)
+}
+
+// Flusher is the instance of an external flusher plugin.
+type Flusher interface {
+	Instance
+	Flush(logGroups []*protocol.LogGroup) error
+}
+
+// Factory creates an instance of the plugin with the json @config.
+type Factory func(configName string, config string) (Instance, error)
+
+// Server serves the external plugins created by the factories by the plugin name, it's the SDK for
+// the sidecars written in Go.
+type Server struct {
+	factories map[string]Factory
+	lastID    int64
+	instances sync.Map
+	health    *health.Server
+}
+
+// NewServer returns a server of the plugins created by @factories.
+func NewServer(factories map[string]Factory) *Server {
+	s := &Server{factories: factories, health: health.NewServer()}
+	s.health.SetServingStatus(serviceName, healthpb.HealthCheckResponse_SERVING)
+	return s
+}
+
+// Register registers the plugin service and the health service to @server, which must be created with
+// the ServerOption.
+func (s *Server) Register(server *grpc.Server) {
+	RegisterExternalPluginServer(server, s)
+	healthpb.RegisterHealthServer(server, s.health)
+}
+
+// ServerOption is the option to create the grpc server of the plugins.
+func ServerOption() grpc.ServerOption {
+	return grpc.ForceServerCodec(codec{})
+}
+
+// Serve serves the plugins in the sidecar process launched by ilogtail. It listens on a local address,
+// prints the handshake line to stdout, and serves until the listener fails.
+func Serve(factories map[string]Factory) error {
+	if os.Getenv(magicCookieKey) != magicCookieValue {
+		return errors.New("the external plugin should be launched by ilogtail")
+	}
+	var listener net.Listener
+	var err error
+	if runtime.GOOS == "windows" {
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+	} else {
+		var dir string
+		if dir, err = os.MkdirTemp("", "ilogtail-plugin"); err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir) //nolint:errcheck
+		listener, err = net.Listen("unix", filepath.Join(dir, "plugin.sock"))
+	}
+	if err != nil {
+		return err
+	}
+	server := grpc.NewServer(ServerOption())
+	NewServer(factories).Register(server)
+	fmt.Printf("%d|%s|%s|grpc\n", protocolVersion, listener.Addr().Network(), listener.Addr().String())
+	return server.Serve(listener)
+}
+
+// Init creates an instance of the plugin.
+func (s *Server) Init(ctx context.Context, req *InitRequest) (*InitResponse, error) {
+	factory, ok := s.factories[req.Plugin]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown plugin %s", req.Plugin)
+	}
+	instance, err := factory(req.ConfigName, req.Config)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	var implemented bool
+	switch req.Category {
+	case CategoryInput:
+		_, implemented = instance.(Input)
+	case CategoryProcessor:
+		_, implemented = instance.(Processor)
+	case CategoryFlusher:
+		_, implemented = instance.(Flusher)
+	}
+	if !implemented {
+		_ = instance.Stop()
+		return nil, status.Errorf(codes.InvalidArgument, "plugin %s is not a %s", req.Plugin, req.Category)
+	}
+	id := strconv.FormatInt(atomic.AddInt64(&s.lastID, 1), 10)
+	s.instances.Store(id, instance)
+	return &InitResponse{InstanceId: id}, nil
+}
+
+func (s *Server) instance(id string) (Instance, error) {
+	if instance, ok := s.instances.Load(id); ok {
+		return instance.(Instance), nil
+	}
+	return nil, status.Errorf(codes.NotFound, "unknown instance %s", id)
+}
+
+// Collect streams the data of the input instance.
+func (s *Server) Collect(req *InstanceRequest, stream ExternalPlugin_CollectServer) error {
+	instance, err := s.instance(req.InstanceId)
+	if err != nil {
+		return err
+	}
+	input, ok := instance.(Input)
+	if !ok {
+		return status.Errorf(codes.InvalidArgument, "instance %s is not an input", req.InstanceId)
+	}
+	return input.Collect(stream.Context(), stream.Send)
+}
+
+// Process processes the log group by the processor instance.
+func (s *Server) Process(ctx context.Context, req *ProcessRequest) (*ProcessResponse, error) {
+	instance, err := s.instance(req.InstanceId)
+	if err != nil {
+		return nil, err
+	}
+	processor, ok := instance.(Processor)
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "instance %s is not a processor", req.InstanceId)
+	}
+	logGroup, err := processor.Process(req.LogGroup)
+	if err != nil {
+		return nil, err
+	}
+	return &ProcessResponse{LogGroup: logGroup}, nil
+}
+
+// Flush sends the log groups by the flusher instance.
+func (s *Server) Flush(ctx context.Context, req *FlushRequest) (*Empty, error) {
+	instance, err := s.instance(req.InstanceId)
+	if err != nil {
+		return nil, err
+	}
+	flusher, ok := instance.(Flusher)
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "instance %s is not a flusher", req.InstanceId)
+	}
+	return &Empty{}, flusher.Flush(req.LogGroups)
+}
+
+// Stop stops and releases the instance.
+func (s *Server) Stop(ctx context.Context, req *InstanceRequest) (*Empty, error) {
+	instance, ok := s.instances.LoadAndDelete(req.InstanceId)
+	if !ok {
+		return &Empty{}, nil
+	}
+	return &Empty{}, instance.(Instance).Stop()
+}
@@ -0,0 +1,323 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalplugin
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	// the environment variable set for the launched sidecars, so the sidecar could refuse to run by accident.
+	magicCookieKey   = "ILOGTAIL_EXTERNAL_PLUGIN_COOKIE"
+	magicCookieValue = "5f2a8a6b0e9c4d3f"
+	protocolVersion  = 1
+
+	handshakeTimeout       = 10 * time.Second
+	healthFailureThreshold = 3
+)
+
+// The categories of the external plugins.
+const (
+	CategoryInput     = "input"
+	CategoryProcessor = "processor"
+	CategoryFlusher   = "flusher"
+)
+
+var (
+	sidecarsLock sync.Mutex
+	sidecars     = make(map[string]*sidecar)
+)
+
+// sidecar is the connection to a sidecar process, which is launched by the command or is running at the address.
+// The sidecars are shared by the plugin instances with the same command or address.
+type sidecar struct {
+	key            string
+	address        string
+	command        []string
+	env            map[string]string
+	healthInterval time.Duration
+
+	lock    sync.RWMutex
+	conn    *grpc.ClientConn
+	cmd     *exec.Cmd
+	healthy int32
+	refs    int
+	stopCh  chan struct{}
+}
+
+func sidecarKey(opts *Options) string {
+	if opts.Address != "" {
+		return opts.Address
+	}
+	keys := make([]string, 0, len(opts.Env))
+	for k, v := range opts.Env {
+		keys = append(keys, k+"="+v)
+	}
+	sort.Strings(keys)
+	return strings.Join(append(keys, opts.Command...), " ")
+}
+
+// acquireSidecar returns the sidecar of @opts, which is connected or launched on the first acquiring.
+func acquireSidecar(opts *Options) (*sidecar, error) {
+	key := sidecarKey(opts)
+	sidecarsLock.Lock()
+	defer sidecarsLock.Unlock()
+	if s, ok := sidecars[key]; ok {
+		s.refs++
+		return s, nil
+	}
+	s := &sidecar{
+		key:            key,
+		address:        opts.Address,
+		command:        opts.Command,
+		env:            opts.Env,
+		healthInterval: time.Duration(opts.HealthCheckIntervalSec) * time.Second,
+		healthy:        1,
+		refs:           1,
+		stopCh:         make(chan struct{}),
+	}
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	sidecars[key] = s
+	go s.checkHealth()
+	return s, nil
+}
+
+// release closes the connection and stops the launched process when it's not used by any plugin.
+func (s *sidecar) release() {
+	sidecarsLock.Lock()
+	defer sidecarsLock.Unlock()
+	// the sidecar is already stopped by StopAllSidecars
+	if s.refs == 0 {
+		return
+	}
+	if s.refs--; s.refs > 0 {
+		return
+	}
+	delete(sidecars, s.key)
+	close(s.stopCh)
+	s.lock.Lock()
+	s.close()
+	s.lock.Unlock()
+}
+
+// StopAllSidecars closes all the sidecars and kills the launched processes, it's called when the agent exits,
+// so the sidecars are not left running if some plugins are not stopped.
+func StopAllSidecars() {
+	sidecarsLock.Lock()
+	defer sidecarsLock.Unlock()
+	for key, s := range sidecars {
+		delete(sidecars, key)
+		s.refs = 0
+		close(s.stopCh)
+		s.lock.Lock()
+		s.close()
+		s.lock.Unlock()
+	}
+}
+
+func (s *sidecar) getConn() *grpc.ClientConn {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.conn
+}
+
+func (s *sidecar) isHealthy() bool {
+	return atomic.LoadInt32(&s.healthy) == 1
+}
+
+// connect launches the process if it's launched by the command, and dials the address, the caller should
+// hold the lock except for the first connecting.
+func (s *sidecar) connect() error {
+	address := s.address
+	if len(s.command) > 0 {
+		cmd, addr, err := s.launch()
+		if err != nil {
+			return err
+		}
+		s.cmd, address = cmd, addr
+	}
+	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(codec{})))
+	if err != nil {
+		s.close()
+		return fmt.Errorf("dial external plugin %s error: %v", address, err)
+	}
+	s.conn = conn
+	return nil
+}
+
+func (s *sidecar) close() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+	if s.cmd != nil {
+		_ = killProcessGroup(s.cmd)
+		s.cmd = nil
+	}
+}
+
+// launch starts the process and reads the handshake line "version|network|address|grpc" from its stdout,
+// the other outputs of the process are written to the log of ilogtail.
+func (s *sidecar) launch() (*exec.Cmd, string, error) {
+	cmd := exec.Command(s.command[0], s.command[1:]...) //nolint:gosec
+	cmd.Env = append(os.Environ(), magicCookieKey+"="+magicCookieValue)
+	for k, v := range s.env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Stderr = &outputWriter{key: s.key}
+	setProcessGroup(cmd)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, "", err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, "", fmt.Errorf("launch external plugin %s error: %v", s.key, err)
+	}
+	reader := bufio.NewReader(stdout)
+	lineCh := make(chan string, 1)
+	go func() {
+		line, _ := reader.ReadString('\n')
+		lineCh <- line
+		_, _ = io.Copy(&outputWriter{key: s.key}, reader)
+		_ = cmd.Wait()
+	}()
+	var line string
+	select {
+	case line = <-lineCh:
+	case <-time.After(handshakeTimeout):
+	}
+	address, err := parseHandshake(line)
+	if err != nil {
+		_ = killProcessGroup(cmd)
+		return nil, "", fmt.Errorf("handshake with external plugin %s error: %v", s.key, err)
+	}
+	logger.Info(context.Background(), "launch external plugin", s.key, "pid", cmd.Process.Pid, "address", address)
+	return cmd, address, nil
+}
+
+func parseHandshake(line string) (string, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return "", errors.New("no handshake line")
+	}
+	parts := strings.Split(line, "|")
+	if len(parts) != 4 || parts[3] != "grpc" {
+		return "", fmt.Errorf("invalid handshake line %q", line)
+	}
+	if version, err := strconv.Atoi(parts[0]); err != nil || version != protocolVersion {
+		return "", fmt.Errorf("unsupported protocol version %s", parts[0])
+	}
+	switch parts[1] {
+	case "unix":
+		return "unix://" + parts[2], nil
+	case "tcp":
+		return parts[2], nil
+	}
+	return "", fmt.Errorf("unsupported network %s", parts[1])
+}
+
+// checkHealth checks the health service of the sidecar periodically, and relaunches the process after
+// continuous failures if it's launched by the command.
+func (s *sidecar) checkHealth() {
+	ticker := time.NewTicker(s.healthInterval)
+	defer ticker.Stop()
+	failures := 0
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+		}
+		err := s.check()
+		if err == nil {
+			failures = 0
+			atomic.StoreInt32(&s.healthy, 1)
+			continue
+		}
+		if failures++; failures < healthFailureThreshold {
+			continue
+		}
+		atomic.StoreInt32(&s.healthy, 0)
+		logger.Warning(context.Background(), util.AlarmExternalPlugin, "external plugin is unhealthy", s.key, "error", err)
+		if len(s.command) == 0 {
+			continue
+		}
+		s.lock.Lock()
+		select {
+		case <-s.stopCh:
+			s.lock.Unlock()
+			return
+		default:
+		}
+		s.close()
+		if err = s.connect(); err != nil {
+			logger.Warning(context.Background(), util.AlarmExternalPlugin, "relaunch external plugin error", err)
+		} else {
+			failures = 0
+		}
+		s.lock.Unlock()
+	}
+}
+
+func (s *sidecar) check() error {
+	conn := s.getConn()
+	if conn == nil {
+		return errors.New("not connected")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.healthInterval)
+	defer cancel()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: serviceName})
+	if err != nil {
+		return err
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("status %s", resp.Status)
+	}
+	return nil
+}
+
+// outputWriter writes the outputs of the sidecar process to the log of ilogtail.
+type outputWriter struct {
+	key string
+}
+
+func (w *outputWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		logger.Info(context.Background(), "external plugin", w.key, "output", line)
+	}
+	return len(p), nil
+}
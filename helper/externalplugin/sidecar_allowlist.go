@@ -0,0 +1,46 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalplugin
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+var (
+	allowedCommandsLock sync.RWMutex
+	allowedCommands     = make(map[string]bool)
+)
+
+// SetAllowedCommands sets the executables the external plugins could launch, which are set by the agent rather
+// than the configs, so a config could not run an arbitrary command. No command is allowed by default, and the
+// plugins could only connect to the running sidecars by Address.
+func SetAllowedCommands(commands []string) {
+	allowed := make(map[string]bool, len(commands))
+	for _, command := range commands {
+		if command != "" {
+			allowed[filepath.Clean(command)] = true
+		}
+	}
+	allowedCommandsLock.Lock()
+	allowedCommands = allowed
+	allowedCommandsLock.Unlock()
+}
+
+func isCommandAllowed(command string) bool {
+	allowedCommandsLock.RLock()
+	defer allowedCommandsLock.RUnlock()
+	return allowedCommands[filepath.Clean(command)]
+}
@@ -0,0 +1,42 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package externalplugin
+
+import (
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKillProcessGroup(t *testing.T) {
+	// the shell forks the sleep, which is killed with the shell
+	cmd := exec.Command("/bin/sh", "-c", "sleep 60 & wait")
+	setProcessGroup(cmd)
+	require.NoError(t, cmd.Start())
+	pgid := cmd.Process.Pid
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, killProcessGroup(cmd))
+	_ = cmd.Wait()
+	assert.Eventually(t, func() bool {
+		return syscall.Kill(-pgid, 0) != nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -25,7 +25,10 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/status"
+
+	"github.com/alibaba/ilogtail/pkg/tlscommon"
 )
 
 var supportedCompressionType = map[string]interface{}{"gzip": nil, "snappy": nil, "zstd": nil}
@@ -59,6 +62,21 @@ type GrpcClientConfig struct {
 	Retry RetryConfig `json:"Retry"`
 
 	Timeout int `json:"Timeout"`
+
+	// TLS configures the client certificate and the CA, which takes precedence over the https:// endpoint.
+	TLS *tlscommon.TLSConfig `json:"TLS"`
+
+	// KeepAlive sends the http2 pings to keep the connection alive and detect the broken one, disabled if nil.
+	KeepAlive *GrpcKeepAliveConfig `json:"KeepAlive"`
+}
+
+type GrpcKeepAliveConfig struct {
+	// Time is the inactive period after which a ping is sent, at least 10s, no ping is sent if 0.
+	Time time.Duration `json:"Time"`
+	// Timeout is the time waiting for the ack of the ping before the connection is closed, 20s by default.
+	Timeout time.Duration `json:"Timeout"`
+	// PermitWithoutStream sends the pings even if there is no request in flight.
+	PermitWithoutStream bool `json:"PermitWithoutStream"`
 }
 
 type RetryConfig struct {
@@ -81,6 +99,15 @@ func (cfg *GrpcClientConfig) GetDialOptions() ([]grpc.DialOption, error) {
 	if strings.HasPrefix(cfg.Endpoint, "https://") {
 		cred = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
 	}
+	if cfg.TLS != nil {
+		tlsConfig, err := cfg.TLS.LoadTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		if tlsConfig != nil {
+			cred = credentials.NewTLS(tlsConfig)
+		}
+	}
 	opts = append(opts, grpc.WithTransportCredentials(cred))
 
 	if cfg.ReadBufferSize > 0 {
@@ -91,6 +118,14 @@ func (cfg *GrpcClientConfig) GetDialOptions() ([]grpc.DialOption, error) {
 		opts = append(opts, grpc.WithWriteBufferSize(cfg.WriteBufferSize))
 	}
 
+	if cfg.KeepAlive != nil {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                cfg.KeepAlive.Time,
+			Timeout:             cfg.KeepAlive.Timeout,
+			PermitWithoutStream: cfg.KeepAlive.PermitWithoutStream,
+		}))
+	}
+
 	opts = append(opts, grpc.WithTimeout(cfg.GetTimeout()))
 	opts = append(opts, grpc.WithDefaultCallOptions(grpc.WaitForReady(cfg.WaitForReady)))
 
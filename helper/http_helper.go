@@ -0,0 +1,81 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helper
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultDialTimeout   = 30 * time.Second
+	defaultDialKeepAlive = 30 * time.Second
+)
+
+// HTTPConnectionConfig configures the connection pool of the http client owned by a plugin, so that the
+// settings of a plugin, e.g. a flusher shipping data across regions, don't affect the others.
+// The zero values keep the defaults of http.DefaultTransport.
+type HTTPConnectionConfig struct {
+	// The max idle connections to all the hosts, 0 means the default 100.
+	MaxIdleConns int
+	// The max idle connections to each host, 0 means the default of the plugin.
+	MaxIdleConnsPerHost int
+	// The max connections to each host including the ones in use, 0 means no limit.
+	MaxConnsPerHost int
+	// The time an idle connection is kept in the pool, 0 means the default 90s.
+	IdleConnTimeout time.Duration
+	// The interval of the tcp keepalive probes, 0 means the default 30s and negative means disabled.
+	KeepAlive time.Duration
+	// Close the connection after each request instead of reusing it.
+	DisableKeepAlives bool
+}
+
+// NewTransport returns a new transport cloned from http.DefaultTransport with the connection settings,
+// defaultMaxIdleConnsPerHost is used if MaxIdleConnsPerHost is not set. http.DefaultTransport replaced by
+// other implementations, e.g. the mocks in tests, is returned as it is if tlsConfig is nil.
+func (c *HTTPConnectionConfig) NewTransport(tlsConfig *tls.Config, defaultMaxIdleConnsPerHost int) http.RoundTripper {
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	if defaultTransport, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport = defaultTransport.Clone()
+	} else if tlsConfig == nil {
+		return http.DefaultTransport
+	}
+	keepAlive := defaultDialKeepAlive
+	if c.KeepAlive != 0 {
+		keepAlive = c.KeepAlive
+	}
+	transport.DialContext = (&net.Dialer{Timeout: defaultDialTimeout, KeepAlive: keepAlive}).DialContext
+	if c.MaxIdleConns > 0 {
+		transport.MaxIdleConns = c.MaxIdleConns
+	}
+	if c.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = c.MaxIdleConnsPerHost
+	} else if defaultMaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+	if c.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = c.MaxConnsPerHost
+	}
+	if c.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = c.IdleConnTimeout
+	}
+	transport.DisableKeepAlives = c.DisableKeepAlives
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	return transport
+}
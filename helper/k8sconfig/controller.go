@@ -0,0 +1,278 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package k8sconfig watches the PipelineConfig custom resources, applies them as the pipeline configs of
+// the agent and reports the results back to the status of the resources.
+package k8sconfig
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	PhaseApplied = "Applied"
+	PhaseFailed  = "Failed"
+
+	resyncPeriod = time.Minute * 10
+)
+
+// PipelineConfigGVR is the resource of the PipelineConfig CRD, see k8s_templates/ilogtail-pipelineconfig-crd.yaml.
+var PipelineConfigGVR = schema.GroupVersionResource{Group: "ilogtail.alibaba.com", Version: "v1alpha1", Resource: "pipelineconfigs"}
+
+// Config is a pipeline config converted from a PipelineConfig resource.
+type Config struct {
+	Namespace  string
+	Name       string
+	Generation int64
+	JSONStr    string
+}
+
+// Key returns the unique name of the config.
+func (c *Config) Key() string {
+	return c.Namespace + "/" + c.Name
+}
+
+// Applier replaces the running pipeline configs.
+type Applier interface {
+	// Apply replaces all the running configs with @configs, and returns the errors of the configs
+	// failed to load, keyed by Config.Key.
+	Apply(configs []*Config) map[string]error
+}
+
+// Options of the Controller.
+type Options struct {
+	// The namespace to watch, empty means all namespaces.
+	Namespace string
+	// The configs in ClusterNamespace could collect the containers of all namespaces,
+	// while the others are restricted to their own namespaces.
+	ClusterNamespace string
+	// NodeName identifies the agent in the status of PipelineConfig.
+	NodeName string
+	// The changes arriving in DebounceInterval are applied together.
+	DebounceInterval time.Duration
+}
+
+// NodeStatus is the result of applying a PipelineConfig on a node.
+type NodeStatus struct {
+	Phase              string `json:"phase"`
+	Message            string `json:"message,omitempty"`
+	ObservedGeneration int64  `json:"observedGeneration"`
+	LastUpdateTime     string `json:"lastUpdateTime"`
+}
+
+// Controller applies the PipelineConfig resources when they change.
+type Controller struct {
+	opts     Options
+	client   dynamic.Interface
+	informer cache.SharedIndexInformer
+	applier  Applier
+	// applied is the generation of each applied config, to skip the events of status updates.
+	applied      map[string]int64
+	notify       chan struct{}
+	stopCh       chan struct{}
+	stopOnce     sync.Once
+	reportStatus func(namespace, name string, status *NodeStatus) error
+	nowFunc      func() time.Time
+}
+
+// NewController creates a Controller watching PipelineConfig with @client.
+func NewController(client dynamic.Interface, applier Applier, opts Options) *Controller {
+	c := &Controller{
+		opts:    opts,
+		client:  client,
+		applier: applier,
+		applied: make(map[string]int64),
+		notify:  make(chan struct{}, 1),
+		stopCh:  make(chan struct{}),
+		nowFunc: time.Now,
+	}
+	c.reportStatus = c.patchStatus
+	c.informer = dynamicinformer.NewFilteredDynamicInformer(client, PipelineConfigGVR, opts.Namespace, resyncPeriod,
+		cache.Indexers{}, nil).Informer()
+	c.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { c.trigger() },
+		UpdateFunc: func(interface{}, interface{}) { c.trigger() },
+		DeleteFunc: func(interface{}) { c.trigger() },
+	})
+	return c
+}
+
+// Start starts watching and applying in background.
+func (c *Controller) Start() {
+	go c.informer.Run(c.stopCh)
+	go c.run()
+}
+
+// Stop stops the controller, the applied configs keep running.
+func (c *Controller) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stopCh)
+	})
+}
+
+func (c *Controller) trigger() {
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (c *Controller) run() {
+	if !cache.WaitForCacheSync(c.stopCh, c.informer.HasSynced) {
+		return
+	}
+	for {
+		select {
+		case <-c.notify:
+		case <-c.stopCh:
+			return
+		}
+		if c.opts.DebounceInterval > 0 {
+			select {
+			case <-time.After(c.opts.DebounceInterval):
+			case <-c.stopCh:
+				return
+			}
+		}
+		c.sync()
+	}
+}
+
+// sync applies all the PipelineConfig resources if any of them is changed.
+func (c *Controller) sync() {
+	objs := c.informer.GetStore().List()
+	configs := make([]*Config, 0, len(objs))
+	statuses := make(map[string]*NodeStatus, len(objs))
+	changed := false
+	generations := make(map[string]int64, len(objs))
+	for _, obj := range objs {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		key := u.GetNamespace() + "/" + u.GetName()
+		generations[key] = u.GetGeneration()
+		if generation, ok := c.applied[key]; !ok || generation != u.GetGeneration() {
+			changed = true
+		}
+		cfg, err := ParsePipelineConfig(u, c.opts.ClusterNamespace)
+		if err != nil {
+			statuses[key] = c.newStatus(u.GetGeneration(), err)
+			continue
+		}
+		configs = append(configs, cfg)
+	}
+	if len(generations) != len(c.applied) {
+		changed = true
+	}
+	if !changed {
+		return
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Key() < configs[j].Key() })
+
+	logger.Info(context.Background(), "apply pipeline configs, count", len(configs), "invalid", len(statuses))
+	errs := c.applier.Apply(configs)
+	for _, cfg := range configs {
+		statuses[cfg.Key()] = c.newStatus(cfg.Generation, errs[cfg.Key()])
+	}
+	c.applied = generations
+	for _, obj := range objs {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		status := statuses[u.GetNamespace()+"/"+u.GetName()]
+		if status.Phase == PhaseFailed {
+			logger.Warning(context.Background(), util.AlarmPipelineConfig, "apply pipeline config error, namespace", u.GetNamespace(),
+				"name", u.GetName(), "error", status.Message)
+		}
+		if err := c.reportStatus(u.GetNamespace(), u.GetName(), status); err != nil {
+			logger.Warning(context.Background(), util.AlarmPipelineConfig, "report status error, namespace", u.GetNamespace(),
+				"name", u.GetName(), "error", err)
+		}
+	}
+}
+
+func (c *Controller) newStatus(generation int64, err error) *NodeStatus {
+	status := &NodeStatus{
+		Phase:              PhaseApplied,
+		ObservedGeneration: generation,
+		LastUpdateTime:     c.nowFunc().UTC().Format(time.RFC3339),
+	}
+	if err != nil {
+		status.Phase = PhaseFailed
+		status.Message = err.Error()
+	}
+	return status
+}
+
+// patchStatus merges @status into status.nodes[NodeName], so the agents on different nodes don't conflict.
+func (c *Controller) patchStatus(namespace, name string, status *NodeStatus) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"nodes": map[string]interface{}{c.opts.NodeName: status},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	_, err = c.client.Resource(PipelineConfigGVR).Namespace(namespace).Patch(ctx, name, types.MergePatchType, patch,
+		metav1.PatchOptions{}, "status")
+	return err
+}
+
+// ParsePipelineConfig converts @u to Config, which is restricted to the namespace of @u unless it is
+// @clusterNamespace, see restrictToNamespace.
+func ParsePipelineConfig(u *unstructured.Unstructured, clusterNamespace string) (*Config, error) {
+	pipeline, ok, err := unstructured.NestedMap(u.Object, "spec", "config")
+	if err != nil {
+		return nil, fmt.Errorf("invalid spec.config: %v", err)
+	}
+	if !ok || len(pipeline) == 0 {
+		return nil, fmt.Errorf("spec.config is empty")
+	}
+	if u.GetNamespace() != clusterNamespace {
+		if err = restrictToNamespace(pipeline, u.GetNamespace()); err != nil {
+			return nil, err
+		}
+	}
+	bytes, err := json.Marshal(pipeline)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{
+		Namespace:  u.GetNamespace(),
+		Name:       u.GetName(),
+		Generation: u.GetGeneration(),
+		JSONStr:    string(bytes),
+	}, nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sconfig
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+type fakeApplier struct {
+	applied [][]*Config
+	errs    map[string]error
+}
+
+func (a *fakeApplier) Apply(configs []*Config) map[string]error {
+	a.applied = append(a.applied, configs)
+	return a.errs
+}
+
+func newPipelineConfig(namespace, name string, generation int64, config map[string]interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "ilogtail.alibaba.com/v1alpha1",
+		"kind":       "PipelineConfig",
+		"spec":       map[string]interface{}{},
+	}}
+	if config != nil {
+		u.Object["spec"].(map[string]interface{})["config"] = config
+	}
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	u.SetGeneration(generation)
+	return u
+}
+
+func stdoutConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"inputs": []interface{}{
+			map[string]interface{}{"type": "service_docker_stdout", "detail": map[string]interface{}{"K8sNamespaceRegex": ".*"}},
+			map[string]interface{}{"type": "metric_docker_file"},
+		},
+		"processors": []interface{}{
+			map[string]interface{}{"type": "processor_add_fields", "detail": map[string]interface{}{"Fields": map[string]interface{}{"env": "${HOME}"}}},
+		},
+		"flushers": []interface{}{map[string]interface{}{"type": "flusher_sls"}},
+	}
+}
+
+func TestParsePipelineConfig(t *testing.T) {
+	cfg, err := ParsePipelineConfig(newPipelineConfig("app", "stdout", 2, stdoutConfig()), "ilogtail")
+	require.NoError(t, err)
+	assert.Equal(t, "app/stdout", cfg.Key())
+	assert.Equal(t, int64(2), cfg.Generation)
+	assert.JSONEq(t, `{"inputs":[
+		{"type":"service_docker_stdout","detail":{"K8sNamespaceRegex":"^app$"}},
+		{"type":"metric_docker_file","detail":{"K8sNamespaceRegex":"^app$"}}],
+		"processors":[{"type":"processor_add_fields","detail":{"Fields":{"env":"$${HOME}"}}}],
+		"flushers":[{"type":"flusher_sls","detail":{}}]}`, cfg.JSONStr)
+
+	cfg, err = ParsePipelineConfig(newPipelineConfig("ilogtail", "stdout", 1, stdoutConfig()), "ilogtail")
+	require.NoError(t, err)
+	assert.Contains(t, cfg.JSONStr, `"K8sNamespaceRegex":".*"`)
+	assert.Contains(t, cfg.JSONStr, `"env":"${HOME}"`)
+
+	_, err = ParsePipelineConfig(newPipelineConfig("app", "empty", 1, nil), "ilogtail")
+	assert.Error(t, err)
+}
+
+func TestParsePipelineConfigNotAllowed(t *testing.T) {
+	input := map[string]interface{}{"type": "service_docker_stdout"}
+	for name, config := range map[string]map[string]interface{}{
+		"input":     {"inputs": []interface{}{map[string]interface{}{"type": "metric_debug_file"}}},
+		"processor": {"inputs": []interface{}{input}, "processors": []interface{}{map[string]interface{}{"type": "processor_enrich"}}},
+		"flusher":   {"inputs": []interface{}{input}, "flushers": []interface{}{map[string]interface{}{"type": "flusher_stdout"}}},
+		"category":  {"inputs": []interface{}{input}, "flushers": []interface{}{map[string]interface{}{"type": "processor_json"}}},
+		"field": {"inputs": []interface{}{input}, "flushers": []interface{}{map[string]interface{}{"type": "flusher_kafka_v2",
+			"detail": map[string]interface{}{"Authentication": map[string]interface{}{"TLS": map[string]interface{}{"CAFile": "/etc/ca"}}}}}},
+		"circuit breaker": {"inputs": []interface{}{input}, "flushers": []interface{}{map[string]interface{}{"type": "flusher_sls",
+			"circuit_breaker": map[string]interface{}{"DeadLetterDir": "/etc"}}}},
+		"global":  {"inputs": []interface{}{input}, "global": map[string]interface{}{"DefaultLogQueueSize": int64(100000)}},
+		"include": {"inputs": []interface{}{input}, "processors": []interface{}{map[string]interface{}{"$include": "/etc/passwd"}}},
+		"blocks":  {"inputs": []interface{}{input}, "processor_blocks": map[string]interface{}{}},
+	} {
+		_, err := ParsePipelineConfig(newPipelineConfig("app", "bad", 1, config), "ilogtail")
+		assert.Error(t, err, name)
+	}
+
+	config := map[string]interface{}{
+		"inputs": []interface{}{input},
+		"flushers": []interface{}{map[string]interface{}{"type": "flusher_kafka_v2", "detail": map[string]interface{}{
+			"Brokers":        []interface{}{"kafka:9092"},
+			"Authentication": map[string]interface{}{"SASL": map[string]interface{}{"Username": "u", "Password": "p"}},
+		}}},
+		"global": map[string]interface{}{"FlushIntervalMs": int64(1000)},
+	}
+	_, err := ParsePipelineConfig(newPipelineConfig("app", "kafka", 1, config), "ilogtail")
+	assert.NoError(t, err)
+}
+
+func TestControllerSync(t *testing.T) {
+	client, err := dynamic.NewForConfig(&rest.Config{Host: "http://127.0.0.1:1"})
+	require.NoError(t, err)
+	applier := &fakeApplier{errs: map[string]error{"app/bad": errors.New("invalid plugin")}}
+	c := NewController(client, applier, Options{ClusterNamespace: "ilogtail", NodeName: "node-1"})
+	c.nowFunc = func() time.Time { return time.Unix(1660000000, 0) }
+	statuses := make(map[string]*NodeStatus)
+	c.reportStatus = func(namespace, name string, status *NodeStatus) error {
+		statuses[namespace+"/"+name] = status
+		return nil
+	}
+	store := c.informer.GetStore()
+
+	require.NoError(t, store.Add(newPipelineConfig("app", "stdout", 1, stdoutConfig())))
+	require.NoError(t, store.Add(newPipelineConfig("app", "bad", 3, stdoutConfig())))
+	require.NoError(t, store.Add(newPipelineConfig("app", "empty", 1, nil)))
+	c.sync()
+	require.Len(t, applier.applied, 1)
+	require.Len(t, applier.applied[0], 2)
+	assert.Equal(t, "app/bad", applier.applied[0][0].Key())
+	assert.Equal(t, "app/stdout", applier.applied[0][1].Key())
+	assert.Equal(t, &NodeStatus{Phase: PhaseApplied, ObservedGeneration: 1, LastUpdateTime: "2022-08-08T23:06:40Z"}, statuses["app/stdout"])
+	assert.Equal(t, PhaseFailed, statuses["app/bad"].Phase)
+	assert.Equal(t, "invalid plugin", statuses["app/bad"].Message)
+	assert.Equal(t, int64(3), statuses["app/bad"].ObservedGeneration)
+	assert.Equal(t, PhaseFailed, statuses["app/empty"].Phase)
+
+	// the status updates don't change the generation
+	c.sync()
+	require.Len(t, applier.applied, 1)
+
+	require.NoError(t, store.Update(newPipelineConfig("app", "stdout", 2, stdoutConfig())))
+	c.sync()
+	require.Len(t, applier.applied, 2)
+
+	require.NoError(t, store.Delete(newPipelineConfig("app", "bad", 3, nil)))
+	c.sync()
+	require.Len(t, applier.applied, 3)
+	require.Len(t, applier.applied[2], 1)
+	assert.Equal(t, "app/stdout", applier.applied[2][0].Key())
+}
@@ -0,0 +1,226 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sconfig
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	includeKey = "$include"
+	envRef     = "${"
+)
+
+// namespacedInputs are the container inputs which are restricted to the namespace of the PipelineConfig.
+var namespacedInputs = map[string]bool{
+	"service_docker_stdout":     true,
+	"service_docker_stdout_raw": true,
+	"metric_docker_file":        true,
+	"service_jmx":               true,
+}
+
+// namespacedPlugins are the processors, aggregators and flushers allowed in the PipelineConfig out of the cluster
+// namespace, which neither access the files or the services of the host nor send the data to the other tenants.
+// The value is the fields allowed in the detail of the plugin, a field allows all its sub fields and a dotted
+// field allows the sub field only, and nil allows all the fields.
+var namespacedPlugins = map[string][]string{
+	"processor_add_fields":           nil,
+	"processor_anchor":               nil,
+	"processor_appender":             nil,
+	"processor_base64_decoding":      nil,
+	"processor_base64_encoding":      nil,
+	"processor_csv":                  nil,
+	"processor_default":              nil,
+	"processor_desensitize":          nil,
+	"processor_drop":                 nil,
+	"processor_drop_last_key":        nil,
+	"processor_filter_key_regex":     nil,
+	"processor_filter_regex":         nil,
+	"processor_gotime":               nil,
+	"processor_json":                 nil,
+	"processor_md5":                  nil,
+	"processor_packjson":             nil,
+	"processor_pick_key":             nil,
+	"processor_regex":                nil,
+	"processor_rename":               nil,
+	"processor_split_char":           nil,
+	"processor_split_key_value":      nil,
+	"processor_split_log_regex":      nil,
+	"processor_split_log_string":     nil,
+	"processor_split_string":         nil,
+	"processor_strptime":             nil,
+	"processor_strptime_v2":          nil,
+	"aggregator_default":             nil,
+	"aggregator_context":             nil,
+	"aggregator_content_value_group": nil,
+	"aggregator_metadata_group":      nil,
+	"flusher_sls":                    nil,
+	"flusher_kafka_v2": {
+		"Brokers", "Topic", "Version", "Timeout", "PartitionerType", "HashKeys", "HashOnce", "MessageKey", "ClientID",
+		"Metadata", "KeepAlive", "MaxOpenRequests", "MaxMessageBytes", "RequiredACKs", "BrokerTimeout", "Compression",
+		"CompressionLevel", "BulkMaxSize", "BulkFlushFrequency", "MaxRetries", "Backoff", "ChanBufferSize", "Headers",
+		"FieldsRename", "Convert.TagFieldsRename", "Convert.ProtocolFieldsRename", "Convert.Protocol", "Convert.Encoding",
+		"Authentication.PlainText.Username", "Authentication.PlainText.Password", "Authentication.SASL.SaslMechanism",
+		"Authentication.SASL.Username", "Authentication.SASL.Password",
+	},
+}
+
+// namespacedPipelineFields are the top-level fields allowed in the PipelineConfig out of the cluster namespace.
+var namespacedPipelineFields = map[string]bool{
+	"inputs":      true,
+	"processors":  true,
+	"aggregators": true,
+	"flushers":    true,
+	"global":      true,
+}
+
+// namespacedGlobalFields are the global fields allowed in the PipelineConfig out of the cluster namespace.
+var namespacedGlobalFields = []string{"InputIntervalMs", "AggregatIntervalMs", "FlushIntervalMs"}
+
+// namespacedPluginFields are the fields of the plugins allowed in the PipelineConfig out of the cluster namespace.
+var namespacedPluginFields = map[string]bool{
+	"type":   true,
+	"detail": true,
+	"match":  true,
+}
+
+// restrictToNamespace checks that the pipeline only uses the plugins and the fields allowed out of the cluster
+// namespace, overrides K8sNamespaceRegex of the container inputs with @namespace, and escapes the references
+// of the environment variables, so that the config could neither collect the other namespaces nor access
+// the files, the services and the environment of the host.
+func restrictToNamespace(pipeline map[string]interface{}, namespace string) error {
+	for key, value := range pipeline {
+		if !namespacedPipelineFields[key] {
+			return fmt.Errorf("%s is not allowed out of the cluster namespace", key)
+		}
+		if key == "global" {
+			global, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("invalid global")
+			}
+			if err := checkFields(global, namespacedGlobalFields, "global."); err != nil {
+				return err
+			}
+			continue
+		}
+		plugins, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("invalid %s", key)
+		}
+		for _, p := range plugins {
+			plugin, ok := p.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("invalid plugin of %s", key)
+			}
+			if err := restrictPlugin(key, plugin, namespace); err != nil {
+				return err
+			}
+		}
+	}
+	return escapeTemplate(pipeline)
+}
+
+func restrictPlugin(category string, plugin map[string]interface{}, namespace string) error {
+	typeName, _ := plugin["type"].(string)
+	for key := range plugin {
+		if !namespacedPluginFields[key] {
+			return fmt.Errorf("%s of plugin %s is not allowed out of the cluster namespace", key, typeName)
+		}
+	}
+	detail, ok := plugin["detail"].(map[string]interface{})
+	if !ok {
+		if _, hasDetail := plugin["detail"]; hasDetail {
+			return fmt.Errorf("invalid detail of plugin %s", typeName)
+		}
+		detail = make(map[string]interface{})
+		plugin["detail"] = detail
+	}
+	if category == "inputs" {
+		if !namespacedInputs[typeName] {
+			return fmt.Errorf("input %s is not allowed out of the cluster namespace", typeName)
+		}
+		detail["K8sNamespaceRegex"] = "^" + regexp.QuoteMeta(namespace) + "$"
+		return nil
+	}
+	fields, ok := namespacedPlugins[typeName]
+	if !ok || !strings.HasPrefix(typeName, strings.TrimSuffix(category, "s")+"_") {
+		return fmt.Errorf("%s %s is not allowed out of the cluster namespace", strings.TrimSuffix(category, "s"), typeName)
+	}
+	if fields == nil {
+		return nil
+	}
+	return checkFields(detail, fields, typeName+".")
+}
+
+// checkFields returns an error if @m has the fields not in @allowed, @prefix is the path of @m in the error.
+func checkFields(m map[string]interface{}, allowed []string, prefix string) error {
+	for key, value := range m {
+		var subFields []string
+		whole := false
+		for _, field := range allowed {
+			if field == key {
+				whole = true
+				break
+			}
+			if strings.HasPrefix(field, key+".") {
+				subFields = append(subFields, field[len(key)+1:])
+			}
+		}
+		if whole {
+			continue
+		}
+		sub, ok := value.(map[string]interface{})
+		if len(subFields) == 0 || !ok {
+			return fmt.Errorf("%s%s is not allowed out of the cluster namespace", prefix, key)
+		}
+		if err := checkFields(sub, subFields, prefix+key+"."); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeTemplate escapes the references of the environment variables in the string values as the literal ones,
+// and rejects the file includes.
+func escapeTemplate(v interface{}) error {
+	switch val := v.(type) {
+	case []interface{}:
+		for i, item := range val {
+			if s, ok := item.(string); ok {
+				val[i] = strings.ReplaceAll(s, envRef, "$"+envRef)
+				continue
+			}
+			if err := escapeTemplate(item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		for k, item := range val {
+			if k == includeKey {
+				return fmt.Errorf("%s is not allowed out of the cluster namespace", includeKey)
+			}
+			if s, ok := item.(string); ok {
+				val[k] = strings.ReplaceAll(s, envRef, "$"+envRef)
+				continue
+			}
+			if err := escapeTemplate(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
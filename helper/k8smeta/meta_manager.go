@@ -0,0 +1,412 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package k8smeta provides a shared cache of kubernetes metadata, so that inputs and processors could
+// attach pod labels, owner workloads and node info to logs without running their own informers.
+package k8smeta
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	podIPIndex          = "ip"
+	podContainerIDIndex = "containerID"
+	resyncPeriod        = time.Minute * 30
+)
+
+// Options identifies a MetaManager, the managers with the same options are shared.
+type Options struct {
+	// When KubeConfigPath is empty, cluster config would be read.
+	KubeConfigPath string
+	// Only the pods matching LabelSelector are cached.
+	LabelSelector string
+}
+
+// PodMeta is the metadata of a pod and the objects related to it.
+type PodMeta struct {
+	Namespace   string
+	Name        string
+	UID         string
+	IP          string
+	NodeName    string
+	Labels      map[string]string
+	Annotations map[string]string
+	// The top level controller of the pod, e.g. Deployment instead of ReplicaSet.
+	WorkloadKind    string
+	WorkloadName    string
+	Services        []string
+	NodeLabels      map[string]string
+	NamespaceLabels map[string]string
+}
+
+// MetaManager watches pods, services, replicasets, nodes and namespaces, and indexes pods by ip and container id.
+type MetaManager struct {
+	podFactory  informers.SharedInformerFactory
+	factory     informers.SharedInformerFactory
+	podInformer cache.SharedIndexInformer
+	rsLister    appslisters.ReplicaSetLister
+	svcLister   corelisters.ServiceLister
+	nodeLister  corelisters.NodeLister
+	nsLister    corelisters.NamespaceLister
+	synced      []cache.InformerSynced
+	stopCh      chan struct{}
+	startOnce   sync.Once
+	stopOnce    sync.Once
+	services    serviceIndex
+
+	// the options and the users of a manager returned by GetMetaManager.
+	opts Options
+	refs int
+}
+
+// serviceIndex caches the selectors of the services by namespace, and the services of the pods,
+// so a lookup doesn't match the pod with all the services of its namespace.
+type serviceIndex struct {
+	lock sync.Mutex
+	// generation is increased when any service changes, which invalidates the services of the pods.
+	generation uint64
+	selectors  map[string][]serviceSelector
+	pods       map[types.UID]*podServices
+}
+
+type serviceSelector struct {
+	name     string
+	selector labels.Selector
+}
+
+type podServices struct {
+	resourceVersion string
+	generation      uint64
+	services        []string
+}
+
+var (
+	managers    = make(map[Options]*MetaManager)
+	managerLock sync.Mutex
+)
+
+// GetMetaManager returns the started MetaManager of @opts, which is created at the first call.
+// The caches are filled asynchronously, so the lookups may miss until HasSynced returns true.
+// The manager must be released by ReleaseMetaManager when it's no longer used.
+func GetMetaManager(opts Options) (*MetaManager, error) {
+	managerLock.Lock()
+	defer managerLock.Unlock()
+	if m, ok := managers[opts]; ok {
+		m.refs++
+		return m, nil
+	}
+	kubeConfigPath := opts.KubeConfigPath
+	if kubeConfigPath != "" {
+		if _, err := os.Stat(kubeConfigPath); err != nil {
+			path := filepath.Join(os.Getenv("HOME"), ".kube", "config")
+			if _, err := os.Stat(path); err != nil {
+				kubeConfigPath = ""
+			} else {
+				kubeConfigPath = path
+			}
+		}
+	}
+	c, err := clientcmd.BuildConfigFromFlags("", kubeConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("error in reading kube config: %v", err)
+	}
+	client, err := kubernetes.NewForConfig(c)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating kubernetes client: %v", err)
+	}
+	if _, err = labels.Parse(opts.LabelSelector); err != nil {
+		return nil, fmt.Errorf("invalid label selector %v: %v", opts.LabelSelector, err)
+	}
+	m := NewMetaManager(client, opts.LabelSelector)
+	m.opts = opts
+	m.refs = 1
+	m.Start()
+	managers[opts] = m
+	return m, nil
+}
+
+// ReleaseMetaManager releases a manager returned by GetMetaManager, whose informers are stopped
+// when it's released by all the users.
+func ReleaseMetaManager(m *MetaManager) {
+	managerLock.Lock()
+	defer managerLock.Unlock()
+	if m.refs--; m.refs > 0 {
+		return
+	}
+	if managers[m.opts] == m {
+		delete(managers, m.opts)
+	}
+	m.Stop()
+}
+
+// NewMetaManager creates a MetaManager with @client, Start must be called before lookups.
+func NewMetaManager(client kubernetes.Interface, labelSelector string) *MetaManager {
+	m := &MetaManager{
+		podFactory: informers.NewSharedInformerFactoryWithOptions(client, resyncPeriod,
+			informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.LabelSelector = labelSelector
+			})),
+		factory: informers.NewSharedInformerFactory(client, resyncPeriod),
+		stopCh:  make(chan struct{}),
+		services: serviceIndex{
+			selectors: make(map[string][]serviceSelector),
+			pods:      make(map[types.UID]*podServices),
+		},
+	}
+	m.podInformer = m.podFactory.Core().V1().Pods().Informer()
+	_ = m.podInformer.AddIndexers(cache.Indexers{
+		podIPIndex:          podIPIndexFunc,
+		podContainerIDIndex: podContainerIDIndexFunc,
+	})
+	rsInformer := m.factory.Apps().V1().ReplicaSets()
+	svcInformer := m.factory.Core().V1().Services()
+	nodeInformer := m.factory.Core().V1().Nodes()
+	nsInformer := m.factory.Core().V1().Namespaces()
+	m.rsLister = rsInformer.Lister()
+	m.svcLister = svcInformer.Lister()
+	m.nodeLister = nodeInformer.Lister()
+	m.nsLister = nsInformer.Lister()
+	svcInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    m.onServiceChange,
+		UpdateFunc: func(_, obj interface{}) { m.onServiceChange(obj) },
+		DeleteFunc: m.onServiceChange,
+	})
+	m.podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: m.onPodDelete,
+	})
+	m.synced = []cache.InformerSynced{
+		m.podInformer.HasSynced,
+		rsInformer.Informer().HasSynced,
+		svcInformer.Informer().HasSynced,
+		nodeInformer.Informer().HasSynced,
+		nsInformer.Informer().HasSynced,
+	}
+	return m
+}
+
+// Start starts the informers.
+func (m *MetaManager) Start() {
+	m.startOnce.Do(func() {
+		m.podFactory.Start(m.stopCh)
+		m.factory.Start(m.stopCh)
+	})
+}
+
+// HasSynced returns true when all the caches are filled.
+func (m *MetaManager) HasSynced() bool {
+	for _, synced := range m.synced {
+		if !synced() {
+			return false
+		}
+	}
+	return true
+}
+
+// WaitForCacheSync waits until all the caches are filled or @timeout.
+func (m *MetaManager) WaitForCacheSync(timeout time.Duration) bool {
+	stopCh := make(chan struct{})
+	timer := time.AfterFunc(timeout, func() { close(stopCh) })
+	defer timer.Stop()
+	return cache.WaitForCacheSync(stopCh, m.synced...)
+}
+
+// Stop stops the informers of a manager created by NewMetaManager.
+func (m *MetaManager) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stopCh)
+	})
+}
+
+// PodByIP returns the pod of @ip, the running one is preferred when the ip is reused by several pods.
+func (m *MetaManager) PodByIP(ip string) *PodMeta {
+	return m.podByIndex(podIPIndex, ip)
+}
+
+// PodByContainerID returns the pod of @containerID, which could be with or without the runtime prefix like docker://.
+func (m *MetaManager) PodByContainerID(containerID string) *PodMeta {
+	return m.podByIndex(podContainerIDIndex, trimContainerID(containerID))
+}
+
+// PodByName returns the pod of @namespace and @name.
+func (m *MetaManager) PodByName(namespace, name string) *PodMeta {
+	obj, exists, err := m.podInformer.GetIndexer().GetByKey(namespace + "/" + name)
+	if err != nil || !exists {
+		return nil
+	}
+	return m.podMeta(obj.(*api.Pod))
+}
+
+func (m *MetaManager) podByIndex(index, value string) *PodMeta {
+	if value == "" {
+		return nil
+	}
+	objs, err := m.podInformer.GetIndexer().ByIndex(index, value)
+	if err != nil || len(objs) == 0 {
+		return nil
+	}
+	pod := objs[0].(*api.Pod)
+	for _, obj := range objs[1:] {
+		if p := obj.(*api.Pod); p.Status.Phase == api.PodRunning && pod.Status.Phase != api.PodRunning {
+			pod = p
+		}
+	}
+	return m.podMeta(pod)
+}
+
+func (m *MetaManager) podMeta(pod *api.Pod) *PodMeta {
+	meta := &PodMeta{
+		Namespace:   pod.Namespace,
+		Name:        pod.Name,
+		UID:         string(pod.UID),
+		IP:          pod.Status.PodIP,
+		NodeName:    pod.Spec.NodeName,
+		Labels:      pod.Labels,
+		Annotations: pod.Annotations,
+	}
+	meta.WorkloadKind, meta.WorkloadName = m.workload(pod)
+	meta.Services = m.podServices(pod)
+	if node, err := m.nodeLister.Get(pod.Spec.NodeName); err == nil {
+		meta.NodeLabels = node.Labels
+	}
+	if ns, err := m.nsLister.Get(pod.Namespace); err == nil {
+		meta.NamespaceLabels = ns.Labels
+	}
+	return meta
+}
+
+// podServices returns the names of the services selecting @pod, which are cached until the pod or any service changes.
+func (m *MetaManager) podServices(pod *api.Pod) []string {
+	idx := &m.services
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+	if cached, ok := idx.pods[pod.UID]; ok && cached.resourceVersion == pod.ResourceVersion && cached.generation == idx.generation {
+		return cached.services
+	}
+	selectors, ok := idx.selectors[pod.Namespace]
+	if !ok {
+		services, err := m.svcLister.Services(pod.Namespace).List(labels.Everything())
+		if err != nil {
+			return nil
+		}
+		selectors = make([]serviceSelector, 0, len(services))
+		for _, svc := range services {
+			if len(svc.Spec.Selector) > 0 {
+				selectors = append(selectors, serviceSelector{name: svc.Name, selector: labels.SelectorFromSet(svc.Spec.Selector)})
+			}
+		}
+		idx.selectors[pod.Namespace] = selectors
+	}
+	var services []string
+	podLabels := labels.Set(pod.Labels)
+	for _, s := range selectors {
+		if s.selector.Matches(podLabels) {
+			services = append(services, s.name)
+		}
+	}
+	sort.Strings(services)
+	if pod.UID != "" {
+		idx.pods[pod.UID] = &podServices{resourceVersion: pod.ResourceVersion, generation: idx.generation, services: services}
+	}
+	return services
+}
+
+// onServiceChange drops the cached selectors of the namespace of the service, and the cached services of all the pods.
+func (m *MetaManager) onServiceChange(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	namespace, _, _ := cache.SplitMetaNamespaceKey(key)
+	m.services.lock.Lock()
+	delete(m.services.selectors, namespace)
+	m.services.generation++
+	m.services.lock.Unlock()
+}
+
+func (m *MetaManager) onPodDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if pod, ok := obj.(*api.Pod); ok {
+		m.services.lock.Lock()
+		delete(m.services.pods, pod.UID)
+		m.services.lock.Unlock()
+	}
+}
+
+// workload returns the top level controller of @pod, ReplicaSets are resolved to their Deployments.
+func (m *MetaManager) workload(pod *api.Pod) (kind, name string) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return "", ""
+	}
+	if owner.Kind == "ReplicaSet" {
+		if rs, err := m.rsLister.ReplicaSets(pod.Namespace).Get(owner.Name); err == nil {
+			if rsOwner := metav1.GetControllerOf(rs); rsOwner != nil {
+				return rsOwner.Kind, rsOwner.Name
+			}
+		}
+	}
+	return owner.Kind, owner.Name
+}
+
+func podIPIndexFunc(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*api.Pod)
+	// The ip of host network pods is the node ip, which could not identify a pod.
+	if !ok || pod.Spec.HostNetwork || pod.Status.PodIP == "" {
+		return nil, nil
+	}
+	return []string{pod.Status.PodIP}, nil
+}
+
+func podContainerIDIndexFunc(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*api.Pod)
+	if !ok {
+		return nil, nil
+	}
+	var ids []string
+	for _, statuses := range [][]api.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		for _, status := range statuses {
+			if id := trimContainerID(status.ContainerID); id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+func trimContainerID(id string) string {
+	if idx := strings.Index(id, "://"); idx >= 0 {
+		return id[idx+3:]
+	}
+	return id
+}
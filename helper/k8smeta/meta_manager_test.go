@@ -0,0 +1,168 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8smeta
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+func controllerRef(kind, name string) []metav1.OwnerReference {
+	controller := true
+	return []metav1.OwnerReference{{Kind: kind, Name: name, Controller: &controller}}
+}
+
+// newFakeObjects returns a pod owned by deployment nginx, and the objects related to it.
+func newFakeObjects() []runtime.Object {
+	return []runtime.Object{
+		&api.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Labels: map[string]string{"env": "prod"}}},
+		&api.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{"zone": "a"}}},
+		&apps.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nginx-6799fc88d8",
+			OwnerReferences: controllerRef("Deployment", "nginx")}},
+		&api.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nginx-svc"},
+			Spec: api.ServiceSpec{Selector: map[string]string{"app": "nginx"}}},
+		&api.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other-svc"},
+			Spec: api.ServiceSpec{Selector: map[string]string{"app": "other"}}},
+		&api.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nginx-6799fc88d8-abcde", UID: "uid-1",
+				Labels: map[string]string{"app": "nginx"}, OwnerReferences: controllerRef("ReplicaSet", "nginx-6799fc88d8")},
+			Spec: api.PodSpec{NodeName: "node-1"},
+			Status: api.PodStatus{Phase: api.PodRunning, PodIP: "10.0.0.1",
+				ContainerStatuses: []api.ContainerStatus{{ContainerID: "containerd://abc123"}}},
+		},
+		&api.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "node-agent", Labels: map[string]string{"app": "agent"}},
+			Spec:       api.PodSpec{NodeName: "node-1", HostNetwork: true},
+			Status:     api.PodStatus{Phase: api.PodRunning, PodIP: "192.168.0.1"},
+		},
+	}
+}
+
+// newFakeManager returns a MetaManager whose caches are filled with @objs, without starting the informers.
+func newFakeManager(t *testing.T, objs []runtime.Object) *MetaManager {
+	m := NewMetaManager(&kubernetes.Clientset{}, "")
+	for _, obj := range objs {
+		var indexer cache.Indexer
+		switch obj.(type) {
+		case *api.Pod:
+			indexer = m.podInformer.GetIndexer()
+		case *api.Namespace:
+			indexer = m.factory.Core().V1().Namespaces().Informer().GetIndexer()
+		case *api.Node:
+			indexer = m.factory.Core().V1().Nodes().Informer().GetIndexer()
+		case *api.Service:
+			indexer = m.factory.Core().V1().Services().Informer().GetIndexer()
+		case *apps.ReplicaSet:
+			indexer = m.factory.Apps().V1().ReplicaSets().Informer().GetIndexer()
+		}
+		require.NoError(t, indexer.Add(obj))
+	}
+	return m
+}
+
+func TestMetaManager(t *testing.T) {
+	m := newFakeManager(t, newFakeObjects())
+
+	pod := m.PodByIP("10.0.0.1")
+	require.NotNil(t, pod)
+	assert.Equal(t, "nginx-6799fc88d8-abcde", pod.Name)
+	assert.Equal(t, "uid-1", pod.UID)
+	assert.Equal(t, "node-1", pod.NodeName)
+	assert.Equal(t, "Deployment", pod.WorkloadKind)
+	assert.Equal(t, "nginx", pod.WorkloadName)
+	assert.Equal(t, []string{"nginx-svc"}, pod.Services)
+	assert.Equal(t, map[string]string{"zone": "a"}, pod.NodeLabels)
+	assert.Equal(t, map[string]string{"env": "prod"}, pod.NamespaceLabels)
+
+	assert.Equal(t, pod, m.PodByContainerID("abc123"))
+	assert.Equal(t, pod, m.PodByContainerID("docker://abc123"))
+	assert.Equal(t, pod, m.PodByName("default", "nginx-6799fc88d8-abcde"))
+	assert.Nil(t, m.PodByIP("192.168.0.1"))
+	assert.Nil(t, m.PodByIP(""))
+	assert.Nil(t, m.PodByName("default", "unknown"))
+
+	agent := m.PodByName("default", "node-agent")
+	require.NotNil(t, agent)
+	assert.Equal(t, "", agent.WorkloadKind)
+	assert.Empty(t, agent.Services)
+}
+
+func TestWaitForCacheSync(t *testing.T) {
+	m := NewMetaManager(&kubernetes.Clientset{}, "")
+	assert.False(t, m.HasSynced())
+	assert.False(t, m.WaitForCacheSync(time.Millisecond*100))
+}
+
+func TestPodServicesCache(t *testing.T) {
+	m := newFakeManager(t, newFakeObjects())
+	pod := m.PodByIP("10.0.0.1")
+	require.NotNil(t, pod)
+	assert.Equal(t, []string{"nginx-svc"}, pod.Services)
+	require.Contains(t, m.services.pods, types.UID(pod.UID))
+
+	// the cached services are returned until any service changes
+	svcIndexer := m.factory.Core().V1().Services().Informer().GetIndexer()
+	another := &api.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "another-svc"},
+		Spec: api.ServiceSpec{Selector: map[string]string{"app": "nginx"}}}
+	require.NoError(t, svcIndexer.Add(another))
+	assert.Equal(t, []string{"nginx-svc"}, m.PodByIP("10.0.0.1").Services)
+	m.onServiceChange(another)
+	assert.Equal(t, []string{"another-svc", "nginx-svc"}, m.PodByIP("10.0.0.1").Services)
+
+	require.NoError(t, svcIndexer.Delete(another))
+	m.onServiceChange(cache.DeletedFinalStateUnknown{Key: "default/another-svc", Obj: another})
+	assert.Equal(t, []string{"nginx-svc"}, m.PodByIP("10.0.0.1").Services)
+
+	obj, _, err := m.podInformer.GetIndexer().GetByKey("default/nginx-6799fc88d8-abcde")
+	require.NoError(t, err)
+	m.onPodDelete(obj)
+	assert.NotContains(t, m.services.pods, types.UID(pod.UID))
+}
+
+func TestReleaseMetaManager(t *testing.T) {
+	opts := Options{LabelSelector: "app=test"}
+	m := NewMetaManager(&kubernetes.Clientset{}, opts.LabelSelector)
+	m.opts = opts
+	m.refs = 2
+	managerLock.Lock()
+	managers[opts] = m
+	managerLock.Unlock()
+
+	ReleaseMetaManager(m)
+	assert.Contains(t, managers, opts)
+	select {
+	case <-m.stopCh:
+		t.Fatal("the manager is stopped while it's used")
+	default:
+	}
+
+	ReleaseMetaManager(m)
+	assert.NotContains(t, managers, opts)
+	select {
+	case <-m.stopCh:
+	default:
+		t.Fatal("the manager is not stopped after it's released")
+	}
+}
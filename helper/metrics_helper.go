@@ -81,6 +81,15 @@ func (kv *KeyValues) Clone() KeyValues {
 	return newKeyValues
 }
 
+// ToMap returns the key values as a map, the latter value wins when the keys are duplicated.
+func (kv *KeyValues) ToMap() map[string]string {
+	m := make(map[string]string, len(kv.keyValues))
+	for _, label := range kv.keyValues {
+		m[label.Key] = label.Value
+	}
+	return m
+}
+
 func (kv *KeyValues) String() string {
 	var builder strings.Builder
 	kv.labelToStringBuilder(&builder)
@@ -15,7 +15,10 @@
 package helper
 
 import (
+	"fmt"
 	"net"
+	"net/url"
+	"strings"
 )
 
 func GetFreePort() (port int, err error) {
@@ -26,3 +29,25 @@ func GetFreePort() (port int, err error) {
 	defer listener.Close()
 	return listener.Addr().(*net.TCPAddr).Port, nil
 }
+
+// SplitAddress splits the address like tcp://0.0.0.0:2003 into the network and the host with @defaultPort
+// if the port is missing. The address of the unix networks is the path of the socket, such as unix:///tmp/a.sock.
+func SplitAddress(address string, defaultPort string) (network, host string, err error) {
+	parts := strings.SplitN(address, "://", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("missing protocol within address '%s'", address)
+	}
+	switch parts[0] {
+	case "unix", "unixpacket", "unixgram":
+		return parts[0], parts[1], nil
+	}
+	u, err := url.Parse(address)
+	if err != nil {
+		return "", "", err
+	}
+	port := u.Port()
+	if port == "" {
+		port = defaultPort
+	}
+	return u.Scheme, net.JoinHostPort(u.Hostname(), port), nil
+}
@@ -0,0 +1,42 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitAddress(t *testing.T) {
+	network, host, err := SplitAddress("tcp://127.0.0.1", "2003")
+	require.NoError(t, err)
+	assert.Equal(t, "tcp", network)
+	assert.Equal(t, "127.0.0.1:2003", host)
+
+	network, host, err = SplitAddress("udp://:2004", "2003")
+	require.NoError(t, err)
+	assert.Equal(t, "udp", network)
+	assert.Equal(t, ":2004", host)
+
+	network, host, err = SplitAddress("unixgram:///tmp/syslog.sock", "6514")
+	require.NoError(t, err)
+	assert.Equal(t, "unixgram", network)
+	assert.Equal(t, "/tmp/syslog.sock", host)
+
+	_, _, err = SplitAddress("127.0.0.1:2003", "2003")
+	assert.Error(t, err)
+}
@@ -20,6 +20,8 @@ package helper
 import (
 	"context"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/util"
@@ -29,9 +31,28 @@ func ContainerProcessAlive(pid int) bool {
 	procStatPath := GetMountedFilePath(fmt.Sprintf("/proc/%d/stat", pid))
 	exist, err := util.PathExists(procStatPath)
 	if err != nil {
-		logger.Error(context.Background(), "DETECT_CONTAINER_ALARM", "stat container proc path", procStatPath, "error", err)
+		logger.Error(context.Background(), util.AlarmDetectContainer, "stat container proc path", procStatPath, "error", err)
 	} else if !exist {
 		return false
 	}
 	return true
 }
+
+// ProcessContainerID returns the id of the container the process belongs to, or empty when the
+// process does not run in a container.
+func ProcessContainerID(pid int) string {
+	content, err := os.ReadFile(GetMountedFilePath(fmt.Sprintf("/proc/%d/cgroup", pid)))
+	if err != nil {
+		logger.Debugf(context.Background(), "error when reading the cgroup of process %d: %v", pid, err)
+		return ""
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		// hierarchy-ID:controller-list:cgroup-path
+		if parts := strings.SplitN(line, ":", 3); len(parts) == 3 {
+			if id := ContainerIDFromCgroup(parts[2]); id != "" {
+				return id
+			}
+		}
+	}
+	return ""
+}
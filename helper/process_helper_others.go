@@ -20,3 +20,8 @@ package helper
 func ContainerProcessAlive(pid int) bool {
 	return true
 }
+
+// ProcessContainerID returns empty because the containers are only discovered by cgroups on Linux.
+func ProcessContainerID(pid int) string {
+	return ""
+}
@@ -0,0 +1,35 @@
+package profile
+
+import (
+	"context"
+	"io"
+)
+
+// checkInterval is the number of the iterations between the cancellation checks of the long loops of the parsers.
+const checkInterval = 1024
+
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// NewContextReader returns the reader failing with the error of @ctx once it's done, so that the decompression and
+// the decoding reading from it are aborted by the deadline of the upload.
+func NewContextReader(ctx context.Context, r io.Reader) io.Reader {
+	return &contextReader{ctx: ctx, r: r}
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// Canceled reports the error of @ctx every checkInterval iterations, and is cheap enough to be called per iteration.
+func Canceled(ctx context.Context, i int) error {
+	if i%checkInterval != 0 {
+		return nil
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,224 @@
+package profile
+
+import (
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+const (
+	flamegraphWidth       = 1200
+	flamegraphFrameHeight = 16
+	flamegraphMinWidth    = 0.5
+	// maxRecentProfiles is the max count of the apps with the recent profiles, the oldest is evicted when exceeded.
+	maxRecentProfiles = 100
+)
+
+var (
+	recentEnabled  int32
+	recentLock     sync.RWMutex
+	recentProfiles = make(map[string]*RecentProfile)
+)
+
+// RecentProfile is the most recent parsed profile of an app, whose stacks are folded by the value types.
+type RecentProfile struct {
+	App    string
+	Time   time.Time
+	Folded map[string]map[string]uint64
+}
+
+// EnableRecentProfiles makes the decoders keep the most recent parsed profile of every app for debugging.
+func EnableRecentProfiles() {
+	atomic.StoreInt32(&recentEnabled, 1)
+}
+
+// RecordRecentProfile folds the stacks of the parsed profile logs of the app, and replaces the recent profile of it.
+// At most maxRecentProfiles apps are kept. It does nothing unless EnableRecentProfiles is called.
+func RecordRecentProfile(app string, logs []*protocol.Log) {
+	if atomic.LoadInt32(&recentEnabled) == 0 {
+		return
+	}
+	p := &RecentProfile{App: app, Time: time.Now(), Folded: make(map[string]map[string]uint64)}
+	for _, log := range logs {
+		var name, stack, valueType, val string
+		for _, cont := range log.Contents {
+			switch cont.Key {
+			case "name":
+				name = cont.Value
+			case "stack":
+				stack = cont.Value
+			case "valueTypes":
+				valueType = cont.Value
+			case "val":
+				val = cont.Value
+			}
+		}
+		if name == "" || valueType == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			continue
+		}
+		// the stack is from the caller of the leaf to the root
+		var frames []string
+		if stack != "" {
+			frames = strings.Split(stack, "\n")
+		}
+		folded := make([]string, 0, len(frames)+1)
+		for i := len(frames) - 1; i >= 0; i-- {
+			folded = append(folded, strings.ReplaceAll(frames[i], ";", ":"))
+		}
+		folded = append(folded, strings.ReplaceAll(name, ";", ":"))
+		if p.Folded[valueType] == nil {
+			p.Folded[valueType] = make(map[string]uint64)
+		}
+		p.Folded[valueType][strings.Join(folded, ";")] += uint64(v)
+	}
+	if len(p.Folded) == 0 {
+		return
+	}
+	recentLock.Lock()
+	defer recentLock.Unlock()
+	if _, ok := recentProfiles[app]; !ok && len(recentProfiles) >= maxRecentProfiles {
+		var oldest *RecentProfile
+		for _, r := range recentProfiles {
+			if oldest == nil || r.Time.Before(oldest.Time) {
+				oldest = r
+			}
+		}
+		delete(recentProfiles, oldest.App)
+	}
+	recentProfiles[app] = p
+}
+
+// GetRecentProfile returns the most recent parsed profile of the app.
+func GetRecentProfile(app string) (*RecentProfile, bool) {
+	recentLock.RLock()
+	defer recentLock.RUnlock()
+	p, ok := recentProfiles[app]
+	return p, ok
+}
+
+// RecentProfileApps returns the apps with the recent profiles and the value types of them.
+func RecentProfileApps() map[string][]string {
+	recentLock.RLock()
+	defer recentLock.RUnlock()
+	apps := make(map[string][]string, len(recentProfiles))
+	for app, p := range recentProfiles {
+		types := make([]string, 0, len(p.Folded))
+		for t := range p.Folded {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		apps[app] = types
+	}
+	return apps
+}
+
+// WriteFolded writes the stacks in the folded format, which is a stack of the frames joined by ";" from the root
+// to the leaf and the value in each line.
+func WriteFolded(w io.Writer, folded map[string]uint64) error {
+	stacks := make([]string, 0, len(folded))
+	for s := range folded {
+		stacks = append(stacks, s)
+	}
+	sort.Strings(stacks)
+	for _, s := range stacks {
+		if _, err := fmt.Fprintf(w, "%s %d\n", s, folded[s]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type flameNode struct {
+	name     string
+	value    uint64
+	children map[string]*flameNode
+}
+
+func (n *flameNode) child(name string) *flameNode {
+	c, ok := n.children[name]
+	if !ok {
+		c = &flameNode{name: name, children: make(map[string]*flameNode)}
+		n.children[name] = c
+	}
+	return c
+}
+
+// WriteFlamegraphSVG renders the folded stacks as the SVG flamegraph, with the root at the bottom.
+func WriteFlamegraphSVG(w io.Writer, title string, folded map[string]uint64) error {
+	root := &flameNode{name: "all", children: make(map[string]*flameNode)}
+	depth := 0
+	for s, v := range folded {
+		root.value += v
+		n := root
+		frames := strings.Split(s, ";")
+		for _, f := range frames {
+			n = n.child(f)
+			n.value += v
+		}
+		if len(frames) > depth {
+			depth = len(frames)
+		}
+	}
+	height := (depth+1)*flamegraphFrameHeight + 2*flamegraphFrameHeight
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="monospace" font-size="11">`+"\n", flamegraphWidth, height)
+	fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle" font-size="14">%s</text>`+"\n", flamegraphWidth/2, flamegraphFrameHeight, html.EscapeString(title))
+	if root.value > 0 {
+		scale := float64(flamegraphWidth) / float64(root.value)
+		var draw func(n *flameNode, x float64, level int)
+		draw = func(n *flameNode, x float64, level int) {
+			width := float64(n.value) * scale
+			if width < flamegraphMinWidth {
+				return
+			}
+			y := height - (level+1)*flamegraphFrameHeight
+			label := fmt.Sprintf("%s (%d, %.2f%%)", n.name, n.value, float64(n.value)*100/float64(root.value))
+			fmt.Fprintf(&b, `<g><title>%s</title><rect x="%.1f" y="%d" width="%.1f" height="%d" fill="%s" stroke="white" stroke-width="0.5"/>`,
+				html.EscapeString(label), x, y, width, flamegraphFrameHeight-1, flameColor(n.name))
+			// about 7 pixels per character
+			if chars := int(width / 7); chars >= 3 {
+				text := n.name
+				if len(text) > chars {
+					text = text[:chars-2] + ".."
+				}
+				fmt.Fprintf(&b, `<text x="%.1f" y="%d">%s</text>`, x+3, y+flamegraphFrameHeight-4, html.EscapeString(text))
+			}
+			b.WriteString("</g>\n")
+			names := make([]string, 0, len(n.children))
+			for name := range n.children {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				c := n.children[name]
+				draw(c, x, level+1)
+				x += float64(c.value) * scale
+			}
+		}
+		draw(root, 0, 0)
+	}
+	b.WriteString("</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// flameColor returns the stable warm color of the frame.
+func flameColor(name string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	v := h.Sum32()
+	return fmt.Sprintf("rgb(%d,%d,%d)", 205+v%50, 80+(v>>8)%150, (v>>16)%55)
+}
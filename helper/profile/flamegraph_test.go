@@ -0,0 +1,81 @@
+package profile
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+func stackLog(name, stack, valueType, val string) *protocol.Log {
+	return &protocol.Log{Contents: []*protocol.Log_Content{
+		{Key: "name", Value: name},
+		{Key: "stack", Value: stack},
+		{Key: "valueTypes", Value: valueType},
+		{Key: "val", Value: val},
+	}}
+}
+
+func TestRecentProfile(t *testing.T) {
+	logs := []*protocol.Log{
+		stackLog("baz", "bar\nfoo", "cpu", "3.00"),
+		stackLog("qux", "bar\nfoo", "cpu", "1.00"),
+		stackLog("foo", "", "cpu", "2.00"),
+		stackLog("alloc", "foo", "alloc_space", "1024.00"),
+	}
+	RecordRecentProfile("shop", logs)
+	_, ok := GetRecentProfile("shop")
+	require.False(t, ok)
+
+	EnableRecentProfiles()
+	RecordRecentProfile("shop", logs)
+	p, ok := GetRecentProfile("shop")
+	require.True(t, ok)
+	require.Equal(t, map[string][]string{"shop": {"alloc_space", "cpu"}}, RecentProfileApps())
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteFolded(&buf, p.Folded["cpu"]))
+	require.Equal(t, "foo 2\nfoo;bar;baz 3\nfoo;bar;qux 1\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteFlamegraphSVG(&buf, "shop cpu", p.Folded["cpu"]))
+	svg := buf.String()
+	require.True(t, strings.HasPrefix(svg, "<svg "))
+	require.Contains(t, svg, "<title>all (6, 100.00%)</title>")
+	require.Contains(t, svg, "<title>bar (4, 66.67%)</title>")
+	require.Contains(t, svg, "<title>baz (3, 50.00%)</title>")
+}
+
+func TestRecentProfilesLimit(t *testing.T) {
+	EnableRecentProfiles()
+	defer func() {
+		recentLock.Lock()
+		recentProfiles = make(map[string]*RecentProfile)
+		recentLock.Unlock()
+	}()
+	logs := []*protocol.Log{stackLog("foo", "", "cpu", "1.00")}
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < maxRecentProfiles; i++ {
+		app := "app-" + strconv.Itoa(i)
+		RecordRecentProfile(app, logs)
+		recentProfiles[app].Time = base.Add(time.Duration(i) * time.Second)
+	}
+	// the recent profile of an existing app is replaced without eviction
+	RecordRecentProfile("app-0", logs)
+	require.Len(t, RecentProfileApps(), maxRecentProfiles)
+
+	RecordRecentProfile("new", logs)
+	require.Len(t, RecentProfileApps(), maxRecentProfiles)
+	_, ok := GetRecentProfile("new")
+	require.True(t, ok)
+	// app-1 is the oldest after app-0 is recorded again
+	_, ok = GetRecentProfile("app-1")
+	require.False(t, ok)
+	_, ok = GetRecentProfile("app-0")
+	require.True(t, ok)
+}
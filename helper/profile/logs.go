@@ -0,0 +1,63 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profile
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+// ExtractProfileV1 returns the callback converting the stack records to the logs of the v1 pipeline, which are
+// appended to @logs, one log for each value of a record. The tags are added to the labels of the records.
+func ExtractProfileV1(meta *Meta, tags map[string]string, logs *[]*protocol.Log) BatchCallbackFunc {
+	profileID := GetProfileID(meta)
+	return func(records []StackRecord) {
+		if n := len(*logs) + ValueCount(records); n > cap(*logs) {
+			grown := make([]*protocol.Log, len(*logs), n)
+			copy(grown, *logs)
+			*logs = grown
+		}
+		for i := range records {
+			record := &records[i]
+			for k, v := range tags {
+				record.Labels[k] = v
+			}
+			b, _ := json.Marshal(record.Labels)
+			for j, v := range record.Vals {
+				*logs = append(*logs, &protocol.Log{
+					Time: uint32(record.StartTime / 1e9),
+					Contents: []*protocol.Log_Content{
+						{Key: "name", Value: record.Stack.Name},
+						{Key: "stack", Value: strings.Join(record.Stack.Stack, "\n")},
+						{Key: "stackID", Value: strconv.FormatUint(record.ID, 16)},
+						{Key: "language", Value: meta.SpyName},
+						{Key: "type", Value: DetectProfileType(record.Types[j]).String()},
+						{Key: "dataType", Value: "CallStack"},
+						{Key: "durationNs", Value: strconv.FormatInt(record.EndTime-record.StartTime, 10)},
+						{Key: "profileID", Value: profileID},
+						{Key: "labels", Value: string(b)},
+						{Key: "units", Value: record.Units[j]},
+						{Key: "valueTypes", Value: record.Types[j]},
+						{Key: "aggTypes", Value: record.Aggs[j]},
+						{Key: "val", Value: strconv.FormatFloat(float64(v), 'f', 2, 64)},
+					},
+				})
+			}
+		}
+	}
+}
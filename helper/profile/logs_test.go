@@ -0,0 +1,65 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package profile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+func TestExtractProfileV1(t *testing.T) {
+	var logs []*protocol.Log
+	meta := &Meta{SpyName: "dotnet", Tags: map[string]string{"profile_id": "p1"}}
+	cb := ExtractProfileV1(meta, map[string]string{"cluster": "c1"}, &logs)
+	cb([]StackRecord{{
+		ID:        0x1f,
+		Stack:     &Stack{Name: "main", Stack: []string{"main", "start"}},
+		Vals:      []uint64{3, 1024},
+		Types:     []string{"cpu", "alloc_space"},
+		Units:     []string{"nanoseconds", "bytes"},
+		Aggs:      []string{"sum", "sum"},
+		StartTime: 2e9,
+		EndTime:   3e9,
+		Labels:    map[string]string{"thread": "1"},
+	}})
+	idle := []StackRecord{{ID: 1, Stack: &Stack{Name: "idle"}, Vals: []uint64{1}, Types: []string{"cpu"}, Units: []string{"nanoseconds"}, Aggs: []string{"sum"}, Labels: map[string]string{}}}
+	require.Equal(t, 1, ValueCount(idle))
+	cb(idle)
+	require.Len(t, logs, 3)
+
+	contents := make(map[string]string)
+	for _, content := range logs[1].Contents {
+		contents[content.Key] = content.Value
+	}
+	require.Equal(t, uint32(2), logs[1].Time)
+	require.Equal(t, map[string]string{
+		"name":       "main",
+		"stack":      "main\nstart",
+		"stackID":    "1f",
+		"language":   "dotnet",
+		"type":       MemKind.String(),
+		"dataType":   "CallStack",
+		"durationNs": "1000000000",
+		"profileID":  "p1",
+		"labels":     `{"cluster":"c1","thread":"1"}`,
+		"units":      "bytes",
+		"valueTypes": "alloc_space",
+		"aggTypes":   "sum",
+		"val":        "1024.00",
+	}, contents)
+}
@@ -29,6 +29,7 @@ const (
 	MutexKind
 	GoRoutinesKind
 	ExceptionKind
+	IOKind
 	UnknownKind
 )
 
@@ -44,6 +45,8 @@ func (p Kind) String() string {
 		return "profile_goroutines"
 	case ExceptionKind:
 		return "profile_exception"
+	case IOKind:
+		return "profile_io"
 	default:
 		return "profile_unknown"
 	}
@@ -53,6 +56,32 @@ type Format string
 
 type CallbackFunc func(id uint64, stack *Stack, vals []uint64, types, units, aggs []string, startTime, endTime int64, labels map[string]string)
 
+// StackRecord is a stack with the values of its sample types.
+type StackRecord struct {
+	ID        uint64
+	Stack     *Stack
+	Vals      []uint64
+	Types     []string
+	Units     []string
+	Aggs      []string
+	StartTime int64
+	EndTime   int64
+	Labels    map[string]string
+}
+
+// BatchCallbackFunc receives the stacks of a profile in batches, the records slice is reused by the caller and
+// should not be retained after the call.
+type BatchCallbackFunc func(records []StackRecord)
+
+// ValueCount returns the total count of the values of the records, which is the count of the logs converted from them.
+func ValueCount(records []StackRecord) int {
+	var n int
+	for i := range records {
+		n += len(records[i].Vals)
+	}
+	return n
+}
+
 const (
 	FormatPprof      Format = "pprof"
 	FormatJFR        Format = "jfr"
@@ -61,6 +90,16 @@ const (
 	FormatLines      Format = "lines"
 	FormatGroups     Format = "groups"
 	FormatSpeedscope Format = "speedscope"
+	// FormatPySpy is the raw format streamed by py-spy
+	FormatPySpy Format = "pyspy"
+	// FormatRbSpy is the collapsed format streamed by rbspy
+	FormatRbSpy Format = "rbspy"
+	// FormatNetTrace is the nettrace of the EventPipe produced by dotnet-trace
+	FormatNetTrace Format = "nettrace"
+	// FormatV8CPUProfile is the .cpuprofile of the V8 CPU profiler
+	FormatV8CPUProfile Format = "cpuprofile"
+	// FormatPerfetto is the perfetto protobuf trace with the callstack sampling packets
+	FormatPerfetto Format = "perfetto"
 )
 
 type Meta struct {
@@ -104,6 +143,8 @@ func DetectProfileType(valType string) Kind {
 		return GoRoutinesKind
 	case "exception":
 		return ExceptionKind
+	case "socket_read_duration", "socket_write_duration", "file_io_duration":
+		return IOKind
 	default:
 		return UnknownKind
 	}
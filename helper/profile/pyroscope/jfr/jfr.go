@@ -3,11 +3,9 @@ package jfr
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"mime/multipart"
-	"strconv"
 	"strings"
 
 	"github.com/pyroscope-io/pyroscope/pkg/util/form"
@@ -21,7 +19,9 @@ type RawProfile struct {
 	RawData             []byte
 	FormDataContentType string
 
-	logs []*protocol.Log // v1 result
+	logs      []*protocol.Log // v1 result
+	jvmEvents []*jvmEvent
+	records   []profile.StackRecord // reused between the batches
 }
 
 func NewRawProfile(data []byte, format string) *RawProfile {
@@ -36,95 +36,17 @@ func (r *RawProfile) Parse(ctx context.Context, meta *profile.Meta, tags map[str
 	if err != nil {
 		return nil, err
 	}
-	if err := r.ParseJFR(ctx, meta, reader, labels, r.extractProfileV1(meta, tags)); err != nil {
+	if err := r.ParseJFR(ctx, meta, reader, labels, profile.ExtractProfileV1(meta, tags, &r.logs)); err != nil {
 		return nil, err
 	}
+	// the GC and safepoint events of the recording are emitted as the JVM metrics
+	r.logs = append(r.logs, jvmMetrics(r.jvmEvents, meta, tags)...)
+	r.jvmEvents = nil
 	logs = r.logs
 	r.logs = nil
 	return
 }
 
-func (r *RawProfile) extractProfileV1(meta *profile.Meta, tags map[string]string) profile.CallbackFunc {
-	profileID := profile.GetProfileID(meta)
-	return func(id uint64, stack *profile.Stack, vals []uint64, types, units, aggs []string, startTime, endTime int64, labels map[string]string) {
-		var content []*protocol.Log_Content
-		for k, v := range tags {
-			labels[k] = v
-		}
-		b, _ := json.Marshal(labels)
-		content = append(content,
-			&protocol.Log_Content{
-				Key:   "name",
-				Value: stack.Name,
-			},
-			&protocol.Log_Content{
-				Key:   "stack",
-				Value: strings.Join(stack.Stack, "\n"),
-			},
-			&protocol.Log_Content{
-				Key:   "stackID",
-				Value: strconv.FormatUint(id, 16),
-			},
-			&protocol.Log_Content{
-				Key:   "language",
-				Value: meta.SpyName,
-			},
-			&protocol.Log_Content{
-				Key:   "type",
-				Value: profile.DetectProfileType(types[0]).String(),
-			},
-			&protocol.Log_Content{
-				Key:   "dataType",
-				Value: "CallStack",
-			},
-			&protocol.Log_Content{
-				Key:   "durationNs",
-				Value: strconv.FormatInt(endTime-startTime, 10),
-			},
-
-			&protocol.Log_Content{
-				Key:   "profileID",
-				Value: profileID,
-			},
-			&protocol.Log_Content{
-				Key:   "labels",
-				Value: string(b),
-			},
-		)
-		for i, v := range vals {
-			var res []*protocol.Log_Content
-			if i != len(vals)-1 {
-				res = make([]*protocol.Log_Content, len(content))
-				copy(res, content)
-			} else {
-				res = content
-			}
-			res = append(res,
-				&protocol.Log_Content{
-					Key:   "units",
-					Value: units[i],
-				},
-				&protocol.Log_Content{
-					Key:   "valueTypes",
-					Value: types[i],
-				},
-				&protocol.Log_Content{
-					Key:   "aggTypes",
-					Value: aggs[i],
-				},
-				&protocol.Log_Content{
-					Key:   "val",
-					Value: strconv.FormatFloat(float64(v), 'f', 2, 64),
-				},
-			)
-			r.logs = append(r.logs, &protocol.Log{
-				Time:     uint32(startTime / 1e9),
-				Contents: res,
-			})
-		}
-	}
-}
-
 func (r *RawProfile) extractProfileRaw() (io.Reader, *LabelsSnapshot, error) {
 	var reader io.Reader = bytes.NewReader(r.RawData)
 	var err error
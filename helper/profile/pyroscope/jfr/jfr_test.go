@@ -75,7 +75,7 @@ func TestParseJFR(t *testing.T) {
 		Units:           profile.SamplesUnits,
 		AggregationType: profile.SumAggType,
 	}
-	cb := r.extractProfileV1(meta, nil)
+	cb := profile.ExtractProfileV1(meta, nil, &r.logs)
 	r.ParseJFR(context.Background(), meta, reader, &labels, cb)
 	logs := r.logs
 	require.Equal(t, len(logs), 3)
@@ -103,3 +103,17 @@ func readRawFile(fname string) ([]byte, error) {
 	defer f.Close()
 	return ioutil.ReadAll(f)
 }
+
+func TestParseCanceled(t *testing.T) {
+	jfr, err := readGzipFile("./testdata/example.jfr.gz")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rp := RawProfile{RawData: jfr}
+	_, err = rp.Parse(ctx, &profile.Meta{
+		Tags:            map[string]string{"_app_name_": "12"},
+		SpyName:         "javaspy",
+		AggregationType: profile.SumAggType,
+	}, nil)
+	require.ErrorIs(t, err, context.Canceled)
+}
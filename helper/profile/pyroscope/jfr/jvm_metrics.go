@@ -0,0 +1,525 @@
+package jfr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/pyroscope-io/jfr-parser/parser"
+	"github.com/pyroscope-io/jfr-parser/reader"
+
+	"github.com/alibaba/ilogtail/helper/profile"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	eventGarbageCollection = "jdk.GarbageCollection"
+	eventGCPhasePause      = "jdk.GCPhasePause"
+	eventSafepointBegin    = "jdk.SafepointBegin"
+	eventSocketRead        = "jdk.SocketRead"
+	eventSocketWrite       = "jdk.SocketWrite"
+	eventFileRead          = "jdk.FileRead"
+	eventFileWrite         = "jdk.FileWrite"
+
+	metricGCPause      = "jvm_gc_pause_seconds"
+	metricGCCollection = "jvm_gc_collection_seconds"
+	metricSafepoint    = "jvm_safepoint_seconds"
+
+	chunkHeaderSize = 68
+	// the max length of the constant pool reference chain
+	maxDerefDepth = 8
+	// the max nesting depth of the objects, which stops the classes referring to themselves
+	maxDecodeDepth = 32
+)
+
+// pauseBuckets are the upper bounds in seconds of the pause histograms.
+var pauseBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+var jvmEvents = map[string]bool{
+	eventGarbageCollection: true,
+	eventGCPhasePause:      true,
+	eventSafepointBegin:    true,
+	eventSocketRead:        true,
+	eventSocketWrite:       true,
+	eventFileRead:          true,
+	eventFileWrite:         true,
+}
+
+type cpoolRef struct {
+	classID int64
+	index   int64
+}
+
+// jfrObject is an instance of a JFR class, whose values are in the order of the fields of the class.
+// The constant pool references in the values are resolved when got.
+type jfrObject struct {
+	d      *jvmEventDecoder
+	class  *parser.ClassMetadata
+	values []interface{}
+}
+
+// jvmEvent is a GC, safepoint or IO event, with the duration in nanoseconds.
+type jvmEvent struct {
+	name     string
+	duration int64
+	fields   *jfrObject
+}
+
+// jvmEventDecoder decodes the GC, safepoint and IO events of a JFR chunk, which are skipped by the JFR parser.
+// The fields are decoded by the class metadata of the chunk, and the constant pool references are resolved
+// by the constant pools decoded the same way.
+type jvmEventDecoder struct {
+	header  parser.Header
+	classes map[int64]*parser.ClassMetadata
+	pools   map[int64]map[int64]interface{}
+	br      *bytes.Reader
+	rd      reader.Reader
+	// the frames of the stack traces in the constant pool
+	stacks map[cpoolRef][]string
+}
+
+// parseJVMEvents returns the GC, safepoint and IO events of each JFR chunk in data, and is aborted once @ctx is done.
+func parseJVMEvents(ctx context.Context, data []byte) ([][]*jvmEvent, error) {
+	var events [][]*jvmEvent
+	for len(data) > 0 {
+		if err := ctx.Err(); err != nil {
+			return events, err
+		}
+		if len(data) < chunkHeaderSize || !bytes.Equal(data[:4], []byte("FLR\x00")) {
+			return events, errors.New("invalid jfr chunk header")
+		}
+		d := &jvmEventDecoder{
+			classes: make(map[int64]*parser.ClassMetadata),
+			pools:   make(map[int64]map[int64]interface{}),
+			stacks:  make(map[cpoolRef][]string),
+		}
+		if err := d.header.Parse(reader.NewReader(bytes.NewReader(data[8:chunkHeaderSize]), false)); err != nil {
+			return events, err
+		}
+		if d.header.ChunkSize < chunkHeaderSize || d.header.ChunkSize > int64(len(data)) {
+			return events, fmt.Errorf("invalid jfr chunk size %d", d.header.ChunkSize)
+		}
+		chunkEvents, err := d.decodeChunk(ctx, data[:d.header.ChunkSize])
+		events = append(events, chunkEvents)
+		if err != nil {
+			return events, err
+		}
+		data = data[d.header.ChunkSize:]
+	}
+	return events, nil
+}
+
+func (d *jvmEventDecoder) decodeChunk(ctx context.Context, chunk []byte) ([]*jvmEvent, error) {
+	br := bytes.NewReader(chunk)
+	d.br, d.rd = br, reader.NewReader(br, d.header.Features&1 == 1)
+
+	if _, err := br.Seek(d.header.MetadataOffset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := d.rd.VarInt(); err != nil {
+		return nil, err
+	}
+	var metadata parser.MetadataEvent
+	if err := metadata.Parse(d.rd); err != nil {
+		return nil, fmt.Errorf("unable to parse jfr metadata: %w", err)
+	}
+	for i := range metadata.Root.Metadata.Classes {
+		class := &metadata.Root.Metadata.Classes[i]
+		d.classes[class.ID] = class
+	}
+
+	for offset := d.header.ConstantPoolOffset; ; {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if _, err := br.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		delta, err := d.decodeCheckpoint()
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse jfr constant pool: %w", err)
+		}
+		if delta == 0 {
+			break
+		}
+		offset += delta
+	}
+
+	var events []*jvmEvent
+	var iterations int
+	for pointer := int64(chunkHeaderSize); pointer < int64(len(chunk)); {
+		iterations++
+		if err := profile.Canceled(ctx, iterations); err != nil {
+			return events, err
+		}
+		if _, err := br.Seek(pointer, io.SeekStart); err != nil {
+			return events, err
+		}
+		size, err := d.rd.VarInt()
+		if err != nil {
+			return events, err
+		}
+		if size <= 0 {
+			return events, fmt.Errorf("invalid jfr event size %d", size)
+		}
+		kind, err := d.rd.VarLong()
+		if err != nil {
+			return events, err
+		}
+		if class, ok := d.classes[kind]; ok && jvmEvents[class.Name] {
+			obj, err := d.decodeObject(class, 0)
+			if err != nil {
+				return events, fmt.Errorf("unable to parse jfr event %s: %w", class.Name, err)
+			}
+			events = append(events, &jvmEvent{
+				name:     class.Name,
+				duration: d.ticksToDuration(obj.long("duration")),
+				fields:   obj,
+			})
+		}
+		pointer += int64(size)
+	}
+	return events, nil
+}
+
+func (d *jvmEventDecoder) decodeCheckpoint() (int64, error) {
+	if _, err := d.rd.VarInt(); err != nil {
+		return 0, err
+	}
+	if kind, err := d.rd.VarLong(); err != nil || kind != 1 {
+		return 0, fmt.Errorf("unexpected checkpoint event type %d: %v", kind, err)
+	}
+	// start time and duration
+	for i := 0; i < 2; i++ {
+		if _, err := d.rd.VarLong(); err != nil {
+			return 0, err
+		}
+	}
+	delta, err := d.rd.VarLong()
+	if err != nil {
+		return 0, err
+	}
+	if _, err = d.rd.Byte(); err != nil {
+		return 0, err
+	}
+	n, err := d.readSize()
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < n; i++ {
+		classID, err := d.rd.VarLong()
+		if err != nil {
+			return 0, err
+		}
+		pool, ok := d.pools[classID]
+		if !ok {
+			pool = make(map[int64]interface{})
+			d.pools[classID] = pool
+		}
+		m, err := d.readSize()
+		if err != nil {
+			return 0, err
+		}
+		for j := 0; j < m; j++ {
+			index, err := d.rd.VarLong()
+			if err != nil {
+				return 0, err
+			}
+			if pool[index], err = d.decodeValue(classID, 0); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return delta, nil
+}
+
+// readSize reads the length of an array or a string, which is at most the remaining bytes because each element
+// takes at least one byte, so the corrupted lengths don't allocate huge slices.
+func (d *jvmEventDecoder) readSize() (int, error) {
+	n, err := d.rd.VarInt()
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 || int(n) > d.br.Len() {
+		return 0, fmt.Errorf("invalid size %d with %d bytes remaining", n, d.br.Len())
+	}
+	return int(n), nil
+}
+
+func (d *jvmEventDecoder) decodeObject(class *parser.ClassMetadata, depth int) (*jfrObject, error) {
+	if depth > maxDecodeDepth {
+		return nil, fmt.Errorf("class %s is nested deeper than %d", class.Name, maxDecodeDepth)
+	}
+	obj := &jfrObject{d: d, class: class, values: make([]interface{}, len(class.Fields))}
+	for i, f := range class.Fields {
+		var err error
+		switch {
+		case f.ConstantPool:
+			var index int64
+			if index, err = d.rd.VarLong(); err == nil {
+				obj.values[i] = cpoolRef{classID: f.Class, index: index}
+			}
+		case f.Dimension == 1:
+			var n int
+			if n, err = d.readSize(); err != nil {
+				break
+			}
+			values := make([]interface{}, n)
+			for j := range values {
+				if values[j], err = d.decodeValue(f.Class, depth+1); err != nil {
+					break
+				}
+			}
+			obj.values[i] = values
+		default:
+			obj.values[i], err = d.decodeValue(f.Class, depth+1)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+	}
+	return obj, nil
+}
+
+func (d *jvmEventDecoder) decodeValue(classID int64, depth int) (interface{}, error) {
+	class, ok := d.classes[classID]
+	if !ok {
+		return nil, fmt.Errorf("unknown class %d", classID)
+	}
+	switch class.Name {
+	case "boolean":
+		return d.rd.Boolean()
+	case "byte":
+		return d.rd.Byte()
+	case "char":
+		return d.rd.Char()
+	case "short":
+		return d.rd.VarShort()
+	case "int":
+		v, err := d.rd.VarInt()
+		return int64(v), err
+	case "long":
+		return d.rd.VarLong()
+	case "float":
+		return d.rd.Float()
+	case "double":
+		return d.rd.Double()
+	case "java.lang.String":
+		return d.decodeString(classID)
+	}
+	return d.decodeObject(class, depth)
+}
+
+func (d *jvmEventDecoder) decodeString(classID int64) (interface{}, error) {
+	enc, err := d.rd.Byte()
+	if err != nil {
+		return nil, err
+	}
+	switch enc {
+	case 0, 1:
+		return "", nil
+	case 2:
+		index, err := d.rd.VarLong()
+		return cpoolRef{classID: classID, index: index}, err
+	case 3, 5:
+		n, err := d.readSize()
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, n)
+		_, err = io.ReadFull(d.br, b)
+		return string(b), err
+	case 4:
+		n, err := d.readSize()
+		if err != nil {
+			return nil, err
+		}
+		runes := make([]rune, n)
+		for i := range runes {
+			c, err := d.rd.VarShort()
+			if err != nil {
+				return nil, err
+			}
+			runes[i] = rune(uint16(c))
+		}
+		return string(runes), nil
+	}
+	return nil, fmt.Errorf("unsupported string encoding %d", enc)
+}
+
+func (d *jvmEventDecoder) ticksToDuration(ticks int64) int64 {
+	if d.header.TicksPerSecond <= 0 {
+		return ticks
+	}
+	return int64(float64(ticks) * 1e9 / float64(d.header.TicksPerSecond))
+}
+
+func (o *jfrObject) get(name string) interface{} {
+	return o.d.deref(o.raw(name))
+}
+
+func (o *jfrObject) raw(name string) interface{} {
+	for i, f := range o.class.Fields {
+		if f.Name == name {
+			return o.values[i]
+		}
+	}
+	return nil
+}
+
+func (d *jvmEventDecoder) deref(v interface{}) interface{} {
+	for i := 0; i < maxDerefDepth; i++ {
+		ref, ok := v.(cpoolRef)
+		if !ok {
+			return v
+		}
+		v = d.pools[ref.classID][ref.index]
+	}
+	return nil
+}
+
+func (o *jfrObject) long(name string) int64 {
+	v, _ := o.get(name).(int64)
+	return v
+}
+
+func (o *jfrObject) object(name string) *jfrObject {
+	v, _ := o.get(name).(*jfrObject)
+	return v
+}
+
+// stackFrames returns the frames of the stack trace field from the root to the leaf, in the same format
+// as the frames of the samples parsed by the JFR parser.
+func (o *jfrObject) stackFrames(name string) []string {
+	ref, isRef := o.raw(name).(cpoolRef)
+	if isRef {
+		if frames, ok := o.d.stacks[ref]; ok {
+			return frames
+		}
+	}
+	var frames []string
+	if st := o.object(name); st != nil {
+		stackFrames, _ := st.get("frames").([]interface{})
+		for i := len(stackFrames) - 1; i >= 0; i-- {
+			frame, _ := o.d.deref(stackFrames[i]).(*jfrObject)
+			if frame == nil {
+				continue
+			}
+			method := frame.object("method")
+			if method == nil || method.object("type") == nil {
+				continue
+			}
+			typeName, methodName := method.object("type").str("name"), method.str("name")
+			if typeName != "" && methodName != "" {
+				frames = append(frames, mergeJVMGeneratedClasses(typeName)+"."+mergeJVMGeneratedClasses(methodName))
+			}
+		}
+	}
+	if isRef {
+		o.d.stacks[ref] = frames
+	}
+	return frames
+}
+
+// str returns the string field, or the first string field of the object field, such as the name of jdk.types.GCName
+// and the string of jdk.types.Symbol.
+func (o *jfrObject) str(name string) string {
+	switch v := o.get(name).(type) {
+	case string:
+		return v
+	case *jfrObject:
+		for _, fv := range v.values {
+			if s, ok := v.d.deref(fv).(string); ok {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// jvmMetrics aggregates the pause histograms of the GC and safepoint events, the GC pauses are labeled with
+// the name and the cause of the collection of them.
+func jvmMetrics(events []*jvmEvent, meta *profile.Meta, tags map[string]string) []*protocol.Log {
+	type gcInfo struct{ name, cause string }
+	gcs := make(map[int64]gcInfo)
+	for _, e := range events {
+		if e.name == eventGarbageCollection {
+			gcs[e.fields.long("gcId")] = gcInfo{name: e.fields.str("name"), cause: e.fields.str("cause")}
+		}
+	}
+
+	var baseLabels util.Labels
+	for k, v := range meta.Tags {
+		if k == "__name__" {
+			baseLabels = append(baseLabels, util.Label{Name: "app", Value: v})
+		} else if len(k) > 0 && k[0] != '_' {
+			baseLabels = append(baseLabels, util.Label{Name: k, Value: v})
+		}
+	}
+	for k, v := range tags {
+		baseLabels = append(baseLabels, util.Label{Name: k, Value: v})
+	}
+
+	type series struct {
+		name      string
+		labels    util.Labels
+		histogram *util.HistogramData
+	}
+	var ordered []*series
+	index := make(map[string]*series)
+	observe := func(name string, seconds float64, kvs ...string) {
+		key := name
+		for _, kv := range kvs {
+			key += "\x00" + kv
+		}
+		s, ok := index[key]
+		if !ok {
+			s = &series{name: name, histogram: &util.HistogramData{Buckets: make([]util.DefBucket, len(pauseBuckets))}}
+			s.labels = append(s.labels, baseLabels...)
+			for i := 0; i+1 < len(kvs); i += 2 {
+				s.labels = append(s.labels, util.Label{Name: kvs[i], Value: kvs[i+1]})
+			}
+			for i, le := range pauseBuckets {
+				s.histogram.Buckets[i].Le = le
+			}
+			index[key] = s
+			ordered = append(ordered, s)
+		}
+		for i := range s.histogram.Buckets {
+			if seconds <= s.histogram.Buckets[i].Le {
+				s.histogram.Buckets[i].Count++
+			}
+		}
+		s.histogram.Count++
+		s.histogram.Sum += seconds
+	}
+	for _, e := range events {
+		seconds := float64(e.duration) / 1e9
+		switch e.name {
+		case eventGarbageCollection:
+			gc := gcs[e.fields.long("gcId")]
+			observe(metricGCCollection, seconds, "gc", gc.name, "cause", gc.cause)
+		case eventGCPhasePause:
+			gc, ok := gcs[e.fields.long("gcId")]
+			if !ok {
+				gc = gcInfo{name: "unknown", cause: "unknown"}
+			}
+			observe(metricGCPause, seconds, "gc", gc.name, "cause", gc.cause)
+		case eventSafepointBegin:
+			observe(metricSafepoint, seconds)
+		}
+	}
+
+	timeMs := meta.EndTime.UnixNano() / 1e6
+	if meta.EndTime.IsZero() {
+		timeMs = meta.StartTime.UnixNano() / 1e6
+	}
+	var logs []*protocol.Log
+	for _, s := range ordered {
+		logs = append(logs, s.histogram.ToMetricLogs(s.name, timeMs, s.labels)...)
+	}
+	return logs
+}
@@ -0,0 +1,376 @@
+package jfr
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pyroscope-io/jfr-parser/parser"
+	"github.com/pyroscope-io/jfr-parser/reader"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/helper/profile"
+)
+
+// jfrWriter writes the uncompressed JFR chunk, whose ints and longs are fixed-length big-endian.
+type jfrWriter struct {
+	bytes.Buffer
+}
+
+func (w *jfrWriter) int(v int32) {
+	_ = binary.Write(w, binary.BigEndian, v)
+}
+
+func (w *jfrWriter) long(v int64) {
+	_ = binary.Write(w, binary.BigEndian, v)
+}
+
+func (w *jfrWriter) string(s string) {
+	w.WriteByte(3)
+	w.int(int32(len(s)))
+	w.WriteString(s)
+}
+
+type element struct {
+	name     string
+	attrs    [][2]string
+	children []*element
+}
+
+func class(id int, name string, fields ...*element) *element {
+	return &element{name: "class", attrs: [][2]string{{"id", strconv.Itoa(id)}, {"name", name}}, children: fields}
+}
+
+func field(name string, classID int, constantPool bool) *element {
+	attrs := [][2]string{{"name", name}, {"class", strconv.Itoa(classID)}}
+	if constantPool {
+		attrs = append(attrs, [2]string{"constantPool", "true"})
+	}
+	return &element{name: "field", attrs: attrs}
+}
+
+// event writes the event with its size.
+func event(body func(w *jfrWriter)) []byte {
+	var w jfrWriter
+	body(&w)
+	var e jfrWriter
+	e.int(int32(w.Len() + 4))
+	e.Write(w.Bytes())
+	return e.Bytes()
+}
+
+func buildJFRChunk() []byte {
+	const (
+		longID = iota + 1
+		intID
+		stringID
+		gcNameID
+		gcCauseID
+		gcID
+		gcPauseID
+		safepointID
+		booleanID
+		symbolID
+		classID
+		methodID
+		stackFrameID
+		stackTraceID
+		socketReadID
+	)
+	root := &element{name: "root", children: []*element{
+		{name: "metadata", children: []*element{
+			class(longID, "long"),
+			class(intID, "int"),
+			class(stringID, "java.lang.String"),
+			class(gcNameID, "jdk.types.GCName", field("name", stringID, false)),
+			class(gcCauseID, "jdk.types.GCCause", field("cause", stringID, false)),
+			class(gcID, eventGarbageCollection, field("startTime", longID, false), field("duration", longID, false),
+				field("gcId", intID, false), field("name", gcNameID, true), field("cause", gcCauseID, true),
+				field("sumOfPauses", longID, false)),
+			class(gcPauseID, eventGCPhasePause, field("startTime", longID, false), field("duration", longID, false),
+				field("gcId", intID, false), field("name", stringID, false)),
+			class(safepointID, eventSafepointBegin, field("startTime", longID, false), field("duration", longID, false),
+				field("safepointId", longID, false)),
+			class(booleanID, "boolean"),
+			class(symbolID, "jdk.types.Symbol", field("string", stringID, false)),
+			class(classID, "java.lang.Class", field("name", symbolID, true)),
+			class(methodID, "jdk.types.Method", field("type", classID, true), field("name", symbolID, true)),
+			class(stackFrameID, "jdk.types.StackFrame", field("method", methodID, true), field("lineNumber", intID, false)),
+			class(stackTraceID, "jdk.types.StackTrace", field("truncated", booleanID, false),
+				&element{name: "field", attrs: [][2]string{{"name", "frames"}, {"class", strconv.Itoa(stackFrameID)}, {"dimension", "1"}}}),
+			class(socketReadID, eventSocketRead, field("startTime", longID, false), field("duration", longID, false),
+				field("stackTrace", stackTraceID, true), field("host", stringID, false), field("bytesRead", longID, false)),
+		}},
+		{name: "region"},
+	}}
+	var stringTable []string
+	stringIndex := map[string]int32{}
+	var collect func(e *element)
+	intern := func(s string) {
+		if _, ok := stringIndex[s]; !ok {
+			stringIndex[s] = int32(len(stringTable))
+			stringTable = append(stringTable, s)
+		}
+	}
+	collect = func(e *element) {
+		intern(e.name)
+		for _, kv := range e.attrs {
+			intern(kv[0])
+			intern(kv[1])
+		}
+		for _, c := range e.children {
+			collect(c)
+		}
+	}
+	collect(root)
+
+	// 1 tick is 1 microsecond
+	var events []byte
+	gc := func(id int32, duration int64) {
+		events = append(events, event(func(w *jfrWriter) {
+			w.long(gcID)
+			w.long(100)
+			w.long(duration)
+			w.int(id)
+			w.long(1)
+			w.long(int64(id))
+			w.long(duration)
+		})...)
+	}
+	pause := func(id int32, duration int64) {
+		events = append(events, event(func(w *jfrWriter) {
+			w.long(gcPauseID)
+			w.long(100)
+			w.long(duration)
+			w.int(id)
+			w.string("GC Pause")
+		})...)
+	}
+	gc(1, 3000)
+	pause(1, 3000)
+	gc(2, 200000)
+	pause(2, 20000)
+	pause(2, 30000)
+	events = append(events, event(func(w *jfrWriter) {
+		w.long(safepointID)
+		w.long(100)
+		w.long(500)
+		w.long(7)
+	})...)
+	for _, duration := range []int64{2000, 3000} {
+		events = append(events, event(func(w *jfrWriter) {
+			w.long(socketReadID)
+			w.long(100)
+			w.long(duration)
+			w.long(1)
+			w.string("db")
+			w.long(128)
+		})...)
+	}
+
+	metadata := event(func(w *jfrWriter) {
+		w.long(0)
+		w.long(0)
+		w.long(0)
+		w.long(1)
+		w.int(int32(len(stringTable)))
+		for _, s := range stringTable {
+			w.string(s)
+		}
+		var write func(e *element)
+		write = func(e *element) {
+			w.int(stringIndex[e.name])
+			w.int(int32(len(e.attrs)))
+			for _, kv := range e.attrs {
+				w.int(stringIndex[kv[0]])
+				w.int(stringIndex[kv[1]])
+			}
+			w.int(int32(len(e.children)))
+			for _, c := range e.children {
+				write(c)
+			}
+		}
+		write(root)
+	})
+	checkpoint := event(func(w *jfrWriter) {
+		w.long(1)
+		w.long(0)
+		w.long(0)
+		w.long(0)
+		w.WriteByte(0)
+		w.int(6)
+		w.long(gcNameID)
+		w.int(1)
+		w.long(1)
+		w.string("G1New")
+		w.long(gcCauseID)
+		w.int(2)
+		w.long(1)
+		w.string("G1 Evacuation Pause")
+		w.long(2)
+		w.string("System.gc()")
+		w.long(symbolID)
+		w.int(4)
+		for i, s := range []string{"com/shop/Server", "handle", "java/net/SocketInputStream", "read"} {
+			w.long(int64(i + 1))
+			w.string(s)
+		}
+		w.long(classID)
+		w.int(2)
+		w.long(1)
+		w.long(1)
+		w.long(2)
+		w.long(3)
+		w.long(methodID)
+		w.int(2)
+		w.long(1)
+		w.long(1)
+		w.long(2)
+		w.long(2)
+		w.long(2)
+		w.long(4)
+		w.long(stackTraceID)
+		w.int(1)
+		w.long(1)
+		w.WriteByte(0)
+		// the frames from the leaf to the root
+		w.int(2)
+		w.long(2)
+		w.int(10)
+		w.long(1)
+		w.int(20)
+	})
+
+	metadataOffset := int64(chunkHeaderSize + len(events))
+	checkpointOffset := metadataOffset + int64(len(metadata))
+	var w jfrWriter
+	w.WriteString("FLR\x00")
+	w.Write([]byte{0, 2, 0, 0})
+	w.long(checkpointOffset + int64(len(checkpoint)))
+	w.long(checkpointOffset)
+	w.long(metadataOffset)
+	w.long(time.Unix(1680000000, 0).UnixNano())
+	w.long(int64(time.Second))
+	w.long(0)
+	w.long(1000000)
+	w.int(0)
+	w.Write(events)
+	w.Write(metadata)
+	w.Write(checkpoint)
+	return w.Bytes()
+}
+
+func TestJVMMetrics(t *testing.T) {
+	rp := NewRawProfile(buildJFRChunk(), "")
+	logs, err := rp.Parse(context.Background(), &profile.Meta{
+		Tags:            map[string]string{"__name__": "shop", "_sample_rate_": "100"},
+		SpyName:         "javaspy",
+		StartTime:       time.Unix(1680000000, 0),
+		EndTime:         time.Unix(1680000010, 0),
+		Units:           profile.SamplesUnits,
+		AggregationType: profile.SumAggType,
+	}, map[string]string{"cluster": "c1"})
+	require.NoError(t, err)
+
+	values := make(map[string]string)
+	for _, log := range logs {
+		var name, labels, value string
+		for _, cont := range log.Contents {
+			switch cont.Key {
+			case "__name__":
+				name = cont.Value
+			case "__labels__":
+				labels = cont.Value
+			case "__value__":
+				value = cont.Value
+			}
+		}
+		if name == "" {
+			continue
+		}
+		values[name+"{"+labels+"}"] = value
+		require.Equal(t, uint32(1680000010), log.Time)
+	}
+	require.Equal(t, "1", values["jvm_gc_collection_seconds_count{app#$#shop|cause#$#G1 Evacuation Pause|cluster#$#c1|gc#$#G1New}"])
+	require.Equal(t, "1", values["jvm_gc_collection_seconds_count{app#$#shop|cause#$#System.gc()|cluster#$#c1|gc#$#G1New}"])
+	require.Equal(t, "0.2", values["jvm_gc_collection_seconds_sum{app#$#shop|cause#$#System.gc()|cluster#$#c1|gc#$#G1New}"])
+	require.Equal(t, "2", values["jvm_gc_pause_seconds_count{app#$#shop|cause#$#System.gc()|cluster#$#c1|gc#$#G1New}"])
+	require.Equal(t, "0.05", values["jvm_gc_pause_seconds_sum{app#$#shop|cause#$#System.gc()|cluster#$#c1|gc#$#G1New}"])
+	require.Equal(t, "1", values["jvm_gc_pause_seconds_bucket{app#$#shop|cause#$#System.gc()|cluster#$#c1|gc#$#G1New|le#$#0.025}"])
+	require.Equal(t, "1", values["jvm_gc_pause_seconds_bucket{app#$#shop|cause#$#G1 Evacuation Pause|cluster#$#c1|gc#$#G1New|le#$#0.005}"])
+	require.Equal(t, "1", values["jvm_safepoint_seconds_count{app#$#shop|cluster#$#c1}"])
+}
+
+func TestIOProfiles(t *testing.T) {
+	rp := NewRawProfile(buildJFRChunk(), "")
+	logs, err := rp.Parse(context.Background(), &profile.Meta{
+		Tags:            map[string]string{"__name__": "shop"},
+		SpyName:         "javaspy",
+		StartTime:       time.Unix(1680000000, 0),
+		EndTime:         time.Unix(1680000010, 0),
+		Units:           profile.SamplesUnits,
+		AggregationType: profile.SumAggType,
+	}, map[string]string{})
+	require.NoError(t, err)
+
+	var found int
+	for _, log := range logs {
+		contents := make(map[string]string)
+		for _, cont := range log.Contents {
+			contents[cont.Key] = cont.Value
+		}
+		if contents["valueTypes"] != "socket_read_duration" {
+			continue
+		}
+		found++
+		require.Equal(t, "java/net/SocketInputStream.read", contents["name"])
+		require.Equal(t, "com/shop/Server.handle", contents["stack"])
+		require.Equal(t, "profile_io", contents["type"])
+		require.Equal(t, "nanoseconds", contents["units"])
+		require.Equal(t, "5000000.00", contents["val"])
+	}
+	require.Equal(t, 1, found)
+}
+
+func TestDecodeCorruptedObjects(t *testing.T) {
+	newDecoder := func(data []byte, classes ...*parser.ClassMetadata) *jvmEventDecoder {
+		br := bytes.NewReader(data)
+		d := &jvmEventDecoder{classes: make(map[int64]*parser.ClassMetadata), br: br, rd: reader.NewReader(br, false)}
+		for _, c := range classes {
+			d.classes[c.ID] = c
+		}
+		return d
+	}
+	intClass := &parser.ClassMetadata{ID: 1, Name: "int"}
+	stringClass := &parser.ClassMetadata{ID: 2, Name: "java.lang.String"}
+	// the class refers to itself
+	node := &parser.ClassMetadata{ID: 3, Name: "node", Fields: []parser.FieldMetadata{{Name: "next", Class: 3}}}
+	_, err := newDecoder(make([]byte, 64), node).decodeObject(node, 0)
+	require.ErrorContains(t, err, "nested deeper")
+
+	array := &parser.ClassMetadata{ID: 4, Name: "array", Fields: []parser.FieldMetadata{{Name: "values", Class: 1, Dimension: 1}}}
+	var w jfrWriter
+	w.int(-1)
+	_, err = newDecoder(w.Bytes(), intClass, array).decodeObject(array, 0)
+	require.ErrorContains(t, err, "invalid size")
+	w.Reset()
+	w.int(1 << 30)
+	_, err = newDecoder(w.Bytes(), intClass, array).decodeObject(array, 0)
+	require.ErrorContains(t, err, "invalid size")
+
+	for _, enc := range []byte{3, 4} {
+		w.Reset()
+		w.WriteByte(enc)
+		w.int(1 << 30)
+		_, err = newDecoder(w.Bytes(), stringClass).decodeValue(stringClass.ID, 0)
+		require.ErrorContains(t, err, "invalid size")
+	}
+	w.Reset()
+	w.string("ok")
+	v, err := newDecoder(w.Bytes(), stringClass).decodeValue(stringClass.ID, 0)
+	require.NoError(t, err)
+	require.Equal(t, "ok", v)
+}
@@ -1,6 +1,7 @@
 package jfr
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -15,6 +16,7 @@ import (
 
 	"github.com/alibaba/ilogtail/helper/profile"
 	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 const (
@@ -27,26 +29,82 @@ const (
 	sampleTypeOutTLABBytes
 	sampleTypeLockSamples
 	sampleTypeLockDuration
+	sampleTypeSocketReadDuration
+	sampleTypeSocketWriteDuration
+	sampleTypeFileIODuration
 )
 
-func (r *RawProfile) ParseJFR(ctx context.Context, meta *profile.Meta, body io.Reader, jfrLabels *LabelsSnapshot, cb profile.CallbackFunc) (err error) {
+// ioSampleTypes are the sample types of the IO events, whose values are the durations of the events.
+var ioSampleTypes = map[string]int64{
+	eventSocketRead:  sampleTypeSocketReadDuration,
+	eventSocketWrite: sampleTypeSocketWriteDuration,
+	eventFileRead:    sampleTypeFileIODuration,
+	eventFileWrite:   sampleTypeFileIODuration,
+}
+
+func (r *RawProfile) ParseJFR(ctx context.Context, meta *profile.Meta, body io.Reader, jfrLabels *LabelsSnapshot, cb profile.BatchCallbackFunc) (err error) {
 	if meta.SampleRate > 0 {
 		meta.Tags["_sample_rate_"] = strconv.FormatUint(uint64(meta.SampleRate), 10)
 	}
-	chunks, err := parser.ParseWithOptions(body, &parser.ChunkParseOptions{
-		CPoolProcessor: processSymbols,
-	})
+	data, err := io.ReadAll(profile.NewContextReader(ctx, body))
+	if err != nil {
+		return fmt.Errorf("unable to read JFR: %w", err)
+	}
+	chunks, err := parseChunks(ctx, data)
 	if err != nil {
 		return fmt.Errorf("unable to parse JFR format: %w", err)
 	}
-	for _, c := range chunks {
-		r.parseChunk(ctx, meta, c, jfrLabels, cb)
+	// the GC, safepoint and IO events are skipped by the JFR parser
+	jvmChunks, err := parseJVMEvents(ctx, data)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("unable to parse JFR format: %w", ctxErr)
+	}
+	if err != nil {
+		logger.Warning(ctx, util.AlarmJFRJVMEvents, "parse jvm events of jfr error", err)
+	}
+	for i, c := range chunks {
+		var jvmEvents []*jvmEvent
+		if i < len(jvmChunks) {
+			jvmEvents = jvmChunks[i]
+		}
+		if err = r.parseChunk(ctx, meta, c, jfrLabels, jvmEvents, cb); err != nil {
+			return fmt.Errorf("unable to parse JFR chunk: %w", err)
+		}
+		r.jvmEvents = append(r.jvmEvents, jvmEvents...)
 	}
 	return nil
 }
 
+// parseChunks parses the chunks by the JFR parser in another goroutine, so that it returns once @ctx is done even
+// if a large chunk is being parsed. The goroutine exits after the chunk since the following reads fail.
+func parseChunks(ctx context.Context, data []byte) ([]parser.Chunk, error) {
+	type result struct {
+		chunks []parser.Chunk
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		var res result
+		defer func() {
+			if e := recover(); e != nil {
+				res = result{err: fmt.Errorf("jfr parser panicked: %v", e)}
+			}
+			done <- res
+		}()
+		res.chunks, res.err = parser.ParseWithOptions(profile.NewContextReader(ctx, bytes.NewReader(data)), &parser.ChunkParseOptions{
+			CPoolProcessor: processSymbols,
+		})
+	}()
+	select {
+	case res := <-done:
+		return res.chunks, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
 // revive:disable-next-line:cognitive-complexity necessary complexity
-func (r *RawProfile) parseChunk(ctx context.Context, meta *profile.Meta, c parser.Chunk, jfrLabels *LabelsSnapshot, convertCb profile.CallbackFunc) {
+func (r *RawProfile) parseChunk(ctx context.Context, meta *profile.Meta, c parser.Chunk, jfrLabels *LabelsSnapshot, jvmEvents []*jvmEvent, convertCb profile.BatchCallbackFunc) error {
 	stackMap := make(map[uint64]*profile.Stack)
 	valMap := make(map[uint64][]uint64)
 	labelMap := make(map[uint64]map[string]string)
@@ -63,10 +121,15 @@ func (r *RawProfile) parseChunk(ctx context.Context, meta *profile.Meta, c parse
 		}
 	}
 	cache := make(tree.LabelsCache)
+	var iterations int
 	for contextID, events := range groupEventsByContextID(c.Events) {
 		labels := getContextLabels(contextID, jfrLabels)
 		lh := labels.Hash()
 		for _, e := range events {
+			iterations++
+			if err := profile.Canceled(ctx, iterations); err != nil {
+				return err
+			}
 			switch obj := e.(type) {
 			case *parser.ExecutionSample:
 				if fs := frames(obj.StackTrace); fs != nil {
@@ -98,16 +161,34 @@ func (r *RawProfile) parseChunk(ctx context.Context, meta *profile.Meta, c parse
 			}
 		}
 	}
+	// the IO events carry no context, and are attributed to the stacks only
+	for _, e := range jvmEvents {
+		if sampleType, ok := ioSampleTypes[e.name]; ok {
+			if fs := e.fields.stackFrames("stackTrace"); len(fs) > 0 {
+				cache.GetOrCreateTree(sampleType, nil).InsertStackString(fs, uint64(e.duration))
+			}
+		}
+	}
 	for sampleType, entries := range cache {
 		for _, e := range entries {
+			iterations++
+			if err := profile.Canceled(ctx, iterations); err != nil {
+				return err
+			}
 			if i := labelIndex(jfrLabels, e.Labels, segment.ProfileIDLabelName); i != -1 {
 				cutLabels := tree.CutLabel(e.Labels, i)
 				cache.GetOrCreateTree(sampleType, cutLabels).Merge(e.Tree)
 			}
 		}
 	}
+	// the stacks are skipped once the deadline of the upload is exceeded
+	var canceled bool
 	cb := func(n string, labels tree.Labels, t *tree.Tree, u profile.Units) {
 		t.IterateStacks(func(name string, self uint64, stack []string) {
+			iterations++
+			if canceled = canceled || profile.Canceled(ctx, iterations) != nil; canceled {
+				return
+			}
 			id := xxhash.Sum64String(strings.Join(stack, ""))
 			stackMap[id] = &profile.Stack{
 				Name:  profile.FormatPositionAndName(name, profile.FormatType(meta.SpyName)),
@@ -130,14 +211,36 @@ func (r *RawProfile) parseChunk(ctx context.Context, meta *profile.Meta, c parse
 			cb(n, e.Labels, e.Tree, units)
 		}
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
+	records := r.records[:0]
+	if cap(records) < len(stackMap) {
+		records = make([]profile.StackRecord, 0, len(stackMap))
+	}
 	for id, fs := range stackMap {
 		if len(valMap[id]) == 0 || len(typeMap[id]) == 0 || len(unitMap[id]) == 0 || len(aggtypeMap[id]) == 0 || len(labelMap[id]) == 0 {
-			logger.Warning(ctx, "PPROF_PROFILE_ALARM", "stack don't have enough meta or values", fs)
+			logger.Warning(ctx, util.AlarmPProfProfile, "stack don't have enough meta or values", fs)
 			continue
 		}
-		convertCb(id, fs, valMap[id], typeMap[id], unitMap[id], aggtypeMap[id], meta.StartTime.UnixNano(), meta.EndTime.UnixNano(), labelMap[id])
+		records = append(records, profile.StackRecord{
+			ID:        id,
+			Stack:     fs,
+			Vals:      valMap[id],
+			Types:     typeMap[id],
+			Units:     unitMap[id],
+			Aggs:      aggtypeMap[id],
+			StartTime: meta.StartTime.UnixNano(),
+			EndTime:   meta.EndTime.UnixNano(),
+			Labels:    labelMap[id],
+		})
+	}
+	r.records = records
+	if len(records) > 0 {
+		convertCb(records)
 	}
+	return nil
 }
 
 func getName(sampleType int64, event string) string {
@@ -164,6 +267,12 @@ func getName(sampleType int64, event string) string {
 		return "lock_count"
 	case sampleTypeLockDuration:
 		return "lock_duration"
+	case sampleTypeSocketReadDuration:
+		return "socket_read_duration"
+	case sampleTypeSocketWriteDuration:
+		return "socket_write_duration"
+	case sampleTypeFileIODuration:
+		return "file_io_duration"
 	}
 	return "unknown"
 }
@@ -186,6 +295,8 @@ func getUnits(sampleType int64) profile.Units {
 		return profile.LockSamplesUnits
 	case sampleTypeLockDuration:
 		return profile.LockNanosecondsUnits
+	case sampleTypeSocketReadDuration, sampleTypeSocketWriteDuration, sampleTypeFileIODuration:
+		return profile.NanosecondsUnit
 	}
 	return profile.SamplesUnits
 }
@@ -0,0 +1,143 @@
+package nettrace
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/alibaba/ilogtail/helper/profile"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+const (
+	valueTypeCPU        = "cpu"
+	valueTypeWall       = "wall"
+	valueTypeAllocSpace = "alloc_space"
+)
+
+// RawProfile is the nettrace of the EventPipe produced by dotnet-trace, the ThreadSample events of the sample
+// profiler are converted to the cpu (the managed samples) and wall (all the samples) profiles, and the
+// GCAllocationTick events are converted to the alloc_space profile.
+type RawProfile struct {
+	RawData []byte
+
+	logs []*protocol.Log // v1 result
+}
+
+func NewRawProfile(data []byte) *RawProfile {
+	return &RawProfile{
+		RawData: data,
+	}
+}
+
+func (r *RawProfile) Parse(ctx context.Context, meta *profile.Meta, tags map[string]string) (logs []*protocol.Log, err error) {
+	if err = r.parseNettrace(ctx, meta, profile.ExtractProfileV1(meta, tags, &r.logs)); err != nil {
+		return nil, err
+	}
+	logs = r.logs
+	r.logs = nil
+	return
+}
+
+func (r *RawProfile) parseNettrace(ctx context.Context, meta *profile.Meta, cb profile.BatchCallbackFunc) error {
+	p := newTraceParser(ctx, r.RawData)
+	if err := p.parse(); err != nil {
+		return fmt.Errorf("unable to parse nettrace format: %w", err)
+	}
+	type stackValues struct {
+		frames []string
+		values map[string]uint64
+	}
+	stacks := make(map[uint32]*stackValues)
+	for i, s := range p.samples {
+		if err := profile.Canceled(ctx, i); err != nil {
+			return err
+		}
+		sv, ok := stacks[s.stackID]
+		if !ok {
+			frames := p.frames(s.stackID)
+			if len(frames) == 0 {
+				continue
+			}
+			sv = &stackValues{frames: frames, values: make(map[string]uint64)}
+			stacks[s.stackID] = sv
+		}
+		switch {
+		case s.alloc:
+			sv.values[valueTypeAllocSpace] += s.allocated
+		case s.managed:
+			sv.values[valueTypeCPU]++
+			sv.values[valueTypeWall]++
+		default:
+			sv.values[valueTypeWall]++
+		}
+	}
+
+	// the stacks of different ids could be resolved to the same frames
+	merged := make(map[uint64]*profile.StackRecord)
+	var ids []uint64
+	var iterations int
+	for _, sv := range stacks {
+		iterations++
+		if err := profile.Canceled(ctx, iterations); err != nil {
+			return err
+		}
+		id := xxhash.Sum64String(strings.Join(sv.frames, ""))
+		record, ok := merged[id]
+		if !ok {
+			stack := make([]string, 0, len(sv.frames)-1)
+			for i := len(sv.frames) - 2; i >= 0; i-- {
+				stack = append(stack, sv.frames[i])
+			}
+			record = &profile.StackRecord{
+				ID:        id,
+				Stack:     &profile.Stack{Name: sv.frames[len(sv.frames)-1], Stack: stack},
+				StartTime: meta.StartTime.UnixNano(),
+				EndTime:   meta.EndTime.UnixNano(),
+				Labels:    make(map[string]string, len(meta.Tags)),
+			}
+			for k, v := range meta.Tags {
+				record.Labels[k] = v
+			}
+			merged[id] = record
+			ids = append(ids, id)
+		}
+		for _, valueType := range []string{valueTypeCPU, valueTypeWall, valueTypeAllocSpace} {
+			v, ok := sv.values[valueType]
+			if !ok {
+				continue
+			}
+			if i := indexOf(record.Types, valueType); i >= 0 {
+				record.Vals[i] += v
+				continue
+			}
+			record.Vals = append(record.Vals, v)
+			record.Types = append(record.Types, valueType)
+			record.Aggs = append(record.Aggs, string(meta.AggregationType))
+			if valueType == valueTypeAllocSpace {
+				record.Units = append(record.Units, string(profile.BytesUnit))
+			} else {
+				record.Units = append(record.Units, string(profile.SamplesUnits))
+			}
+		}
+	}
+	records := make([]profile.StackRecord, 0, len(ids))
+	for _, id := range ids {
+		records = append(records, *merged[id])
+	}
+	if len(records) > 0 {
+		cb(records)
+	}
+	return nil
+}
+
+func indexOf(strs []string, s string) int {
+	for i, str := range strs {
+		if str == s {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,230 @@
+package nettrace
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/helper/profile"
+	"github.com/alibaba/ilogtail/plugins/test"
+)
+
+type traceWriter struct {
+	bytes.Buffer
+}
+
+func (w *traceWriter) u16(v uint16) {
+	_ = binary.Write(w, binary.LittleEndian, v)
+}
+
+func (w *traceWriter) u32(v uint32) {
+	_ = binary.Write(w, binary.LittleEndian, v)
+}
+
+func (w *traceWriter) u64(v uint64) {
+	_ = binary.Write(w, binary.LittleEndian, v)
+}
+
+func (w *traceWriter) varUint(v uint64) {
+	for v >= 0x80 {
+		w.WriteByte(byte(v) | 0x80)
+		v >>= 7
+	}
+	w.WriteByte(byte(v))
+}
+
+func (w *traceWriter) utf16(s string) {
+	for _, c := range utf16.Encode([]rune(s)) {
+		w.u16(c)
+	}
+	w.u16(0)
+}
+
+func (w *traceWriter) beginObject(name string) {
+	w.WriteByte(tagBeginPrivateObject)
+	w.WriteByte(tagBeginPrivateObject)
+	w.WriteByte(tagNullReference)
+	w.u32(4)
+	w.u32(4)
+	w.u32(uint32(len(name)))
+	w.WriteString(name)
+	w.WriteByte(tagEndObject)
+}
+
+func (w *traceWriter) block(name string, content []byte) {
+	w.beginObject(name)
+	w.u32(uint32(len(content)))
+	for w.Len()%4 != 0 {
+		w.WriteByte(0)
+	}
+	w.Write(content)
+	w.WriteByte(tagEndObject)
+}
+
+// eventBlockHeader writes the header of the event block and the metadata block.
+func eventBlockHeader(flags uint16) *traceWriter {
+	var w traceWriter
+	w.u16(20)
+	w.u16(flags)
+	w.u64(0)
+	w.u64(0)
+	return &w
+}
+
+func metadataPayload(id uint32, provider string, eventID, version uint32) []byte {
+	var w traceWriter
+	w.u32(id)
+	w.utf16(provider)
+	w.u32(eventID)
+	w.utf16("")
+	w.u64(0)
+	w.u32(version)
+	w.u32(0)
+	w.u32(0)
+	return w.Bytes()
+}
+
+func methodPayload(start uint64, size uint32, namespace, name string) []byte {
+	var w traceWriter
+	w.u64(1)
+	w.u64(1)
+	w.u64(start)
+	w.u32(size)
+	w.u32(0)
+	w.u32(0)
+	w.utf16(namespace)
+	w.utf16(name)
+	w.utf16("void ()")
+	w.u16(0)
+	w.u64(0)
+	return w.Bytes()
+}
+
+func buildNettrace() []byte {
+	var w traceWriter
+	w.WriteString(magic)
+	w.u32(uint32(len(serializationHeader)))
+	w.WriteString(serializationHeader)
+
+	w.beginObject("Trace")
+	w.Write(make([]byte, 32))
+	w.u32(8)
+	w.u32(1234)
+	w.u32(4)
+	w.u32(1000)
+	w.WriteByte(tagEndObject)
+
+	// the metadata block with the uncompressed headers
+	metadata := eventBlockHeader(0)
+	for i, payload := range [][]byte{
+		metadataPayload(1, providerSampleProfiler, eventThreadSample, 0),
+		metadataPayload(2, providerRundown, eventMethodDCEndVerbose, 0),
+		metadataPayload(3, providerRuntime, eventGCAllocationTick, 4),
+	} {
+		metadata.u32(uint32(76 + len(payload)))
+		metadata.u32(0)
+		metadata.u32(uint32(i + 1))
+		metadata.u64(1)
+		metadata.u64(1)
+		metadata.u32(0)
+		metadata.u32(0)
+		metadata.u64(100)
+		metadata.Write(make([]byte, 32))
+		metadata.u32(uint32(len(payload)))
+		metadata.Write(payload)
+		for metadata.Len()%4 != 0 {
+			metadata.WriteByte(0)
+		}
+	}
+	w.block("MetadataBlock", metadata.Bytes())
+
+	var stacks traceWriter
+	stacks.u32(1)
+	stacks.u32(2)
+	// Program.Main -> Worker.Run
+	stacks.u32(24)
+	stacks.u64(0x2010)
+	stacks.u64(0x1010)
+	stacks.u64(0x9999)
+	// Program.Main -> Worker.Alloc
+	stacks.u32(16)
+	stacks.u64(0x3010)
+	stacks.u64(0x1010)
+	w.block("StackBlock", stacks.Bytes())
+
+	// the event block with the compressed headers
+	events := eventBlockHeader(compressedHeaders)
+	event := func(flags byte, metadataID, stackID uint64, payload []byte) {
+		events.WriteByte(flags)
+		if flags&flagMetadataID != 0 {
+			events.varUint(metadataID)
+		}
+		if flags&flagStackID != 0 {
+			events.varUint(stackID)
+		}
+		events.varUint(10)
+		if flags&flagDataLength != 0 {
+			events.varUint(uint64(len(payload)))
+		}
+		events.Write(payload)
+	}
+	event(flagMetadataID|flagDataLength, 2, 0, methodPayload(0x1000, 0x100, "App.Program", "Main"))
+	event(flagDataLength, 0, 0, methodPayload(0x2000, 0x100, "App.Worker", "Run"))
+	event(flagMetadataID|flagStackID|flagDataLength, 2, 0, methodPayload(0x3000, 0x100, "App.Worker", "Alloc"))
+	managed := []byte{threadSampleManaged, 0, 0, 0}
+	event(flagMetadataID|flagStackID|flagDataLength, 1, 1, managed)
+	event(0, 0, 0, managed)
+	event(flagDataLength, 0, 0, []byte{1, 0, 0, 0})
+	var alloc traceWriter
+	alloc.u32(102400)
+	alloc.u32(0)
+	alloc.u16(0)
+	alloc.u64(102400)
+	alloc.u64(0x7000)
+	alloc.utf16("System.Byte[]")
+	event(flagMetadataID|flagStackID|flagDataLength, 3, 2, alloc.Bytes())
+	w.block("EventBlock", events.Bytes())
+	w.WriteByte(tagNullReference)
+	return w.Bytes()
+}
+
+func TestParseNettrace(t *testing.T) {
+	r := NewRawProfile(buildNettrace())
+	logs, err := r.Parse(context.Background(), &profile.Meta{
+		Tags:            map[string]string{"__name__": "shop"},
+		SpyName:         profile.PyroscopeDotnet,
+		StartTime:       time.Unix(1680000000, 0),
+		EndTime:         time.Unix(1680000010, 0),
+		Units:           profile.SamplesUnits,
+		AggregationType: profile.SumAggType,
+	}, map[string]string{"cluster": "c1"})
+	require.NoError(t, err)
+	require.Len(t, logs, 3)
+
+	values := make(map[string]string)
+	for _, log := range logs {
+		values[test.ReadLogVal(log, "name")+"|"+test.ReadLogVal(log, "valueTypes")] = test.ReadLogVal(log, "val")
+		require.Equal(t, "App.Program.Main", test.ReadLogVal(log, "stack"))
+		require.Equal(t, "{\"__name__\":\"shop\",\"cluster\":\"c1\"}", test.ReadLogVal(log, "labels"))
+	}
+	require.Equal(t, map[string]string{
+		"App.Worker.Run|cpu":           "2.00",
+		"App.Worker.Run|wall":          "3.00",
+		"App.Worker.Alloc|alloc_space": "102400.00",
+	}, values)
+
+	_, err = NewRawProfile([]byte("Nettrace")).Parse(context.Background(), &profile.Meta{Tags: map[string]string{}}, nil)
+	require.Error(t, err)
+}
+
+func TestParseNettraceCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := NewRawProfile(buildNettrace()).Parse(ctx, &profile.Meta{Tags: map[string]string{}}, nil)
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,530 @@
+package nettrace
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"unicode/utf16"
+
+	"github.com/alibaba/ilogtail/helper/profile"
+)
+
+const (
+	magic               = "Nettrace"
+	serializationHeader = "!FastSerialization.1"
+
+	tagNullReference      = 1
+	tagBeginPrivateObject = 5
+	tagEndObject          = 6
+
+	compressedHeaders = 1
+
+	flagMetadataID               = 1 << 0
+	flagCaptureThreadAndSequence = 1 << 1
+	flagThreadID                 = 1 << 2
+	flagStackID                  = 1 << 3
+	flagActivityID               = 1 << 4
+	flagRelatedActivityID        = 1 << 5
+	flagDataLength               = 1 << 7
+
+	providerSampleProfiler = "Microsoft-DotNETCore-SampleProfiler"
+	providerRuntime        = "Microsoft-Windows-DotNETRuntime"
+	providerRundown        = "Microsoft-Windows-DotNETRuntimeRundown"
+
+	eventThreadSample       = 0
+	eventGCAllocationTick   = 10
+	eventMethodLoadVerbose  = 143
+	eventMethodDCEndVerbose = 144
+
+	threadSampleManaged = 2
+)
+
+var errTruncated = errors.New("truncated nettrace")
+
+type eventMetadata struct {
+	provider string
+	eventID  int32
+	version  int32
+}
+
+type eventHeader struct {
+	metadataID  uint32
+	threadID    uint64
+	stackID     uint32
+	payloadSize uint32
+}
+
+type method struct {
+	start uint64
+	size  uint64
+	name  string
+}
+
+// sample is a CPU sample or an allocation tick event with its stack.
+type sample struct {
+	stackID   uint32
+	managed   bool
+	allocated uint64
+	alloc     bool
+}
+
+// traceParser parses the nettrace format of EventPipe, the layout of which is described in the EventPipeFormat.md
+// of the PerfView repository.
+type traceParser struct {
+	// ctx aborts the parsing once the deadline of the upload is exceeded
+	ctx         context.Context
+	iterations  int
+	data        []byte
+	pos         int
+	pointerSize int
+	metadata    map[uint32]*eventMetadata
+	stacks      map[uint32][]uint64
+	methods     []method
+	samples     []sample
+}
+
+func newTraceParser(ctx context.Context, data []byte) *traceParser {
+	return &traceParser{
+		ctx:         ctx,
+		data:        data,
+		pointerSize: 8,
+		metadata:    make(map[uint32]*eventMetadata),
+		stacks:      make(map[uint32][]uint64),
+	}
+}
+
+func (p *traceParser) parse() error {
+	if len(p.data) < len(magic) || string(p.data[:len(magic)]) != magic {
+		return errors.New("invalid nettrace magic")
+	}
+	p.pos = len(magic)
+	header, err := p.lengthPrefixedString()
+	if err != nil {
+		return err
+	}
+	if header != serializationHeader {
+		return fmt.Errorf("unsupported serialization header %q", header)
+	}
+	for {
+		if err = p.ctx.Err(); err != nil {
+			return err
+		}
+		tag, err := p.byte()
+		if err != nil {
+			return err
+		}
+		if tag == tagNullReference {
+			break
+		}
+		if tag != tagBeginPrivateObject {
+			return fmt.Errorf("unexpected tag %d at %d", tag, p.pos-1)
+		}
+		name, err := p.objectType()
+		if err != nil {
+			return err
+		}
+		switch name {
+		case "Trace":
+			err = p.traceObject()
+		case "EventBlock", "MetadataBlock", "StackBlock", "SPBlock":
+			err = p.block(name)
+		default:
+			err = fmt.Errorf("unsupported object %s", name)
+		}
+		if err != nil {
+			return fmt.Errorf("parse %s error: %w", name, err)
+		}
+		if tag, err = p.byte(); err != nil {
+			return err
+		}
+		if tag != tagEndObject {
+			return fmt.Errorf("unexpected tag %d at the end of %s", tag, name)
+		}
+	}
+	sort.Slice(p.methods, func(i, j int) bool {
+		return p.methods[i].start < p.methods[j].start
+	})
+	return nil
+}
+
+func (p *traceParser) objectType() (string, error) {
+	for _, expected := range []byte{tagBeginPrivateObject, tagNullReference} {
+		if tag, err := p.byte(); err != nil || tag != expected {
+			return "", fmt.Errorf("unexpected object type tag %d: %v", tag, err)
+		}
+	}
+	// version and minimum reader version
+	if _, err := p.bytes(8); err != nil {
+		return "", err
+	}
+	name, err := p.lengthPrefixedString()
+	if err != nil {
+		return "", err
+	}
+	if tag, err := p.byte(); err != nil || tag != tagEndObject {
+		return "", fmt.Errorf("unexpected object type end tag %d: %v", tag, err)
+	}
+	return name, nil
+}
+
+func (p *traceParser) traceObject() error {
+	// SYSTEMTIME, sync time QPC and QPC frequency
+	if _, err := p.bytes(32); err != nil {
+		return err
+	}
+	b, err := p.bytes(16)
+	if err != nil {
+		return err
+	}
+	if size := int(binary.LittleEndian.Uint32(b)); size == 4 || size == 8 {
+		p.pointerSize = size
+	}
+	return nil
+}
+
+func (p *traceParser) block(name string) error {
+	b, err := p.bytes(4)
+	if err != nil {
+		return err
+	}
+	size := int(binary.LittleEndian.Uint32(b))
+	// the content of the block is aligned to 4 bytes
+	if p.pos%4 != 0 {
+		if _, err = p.bytes(4 - p.pos%4); err != nil {
+			return err
+		}
+	}
+	content, err := p.bytes(size)
+	if err != nil {
+		return err
+	}
+	switch name {
+	case "EventBlock", "MetadataBlock":
+		return p.eventBlock(content, name == "MetadataBlock")
+	case "StackBlock":
+		return p.stackBlock(content)
+	}
+	return nil
+}
+
+func (p *traceParser) eventBlock(content []byte, isMetadata bool) error {
+	if len(content) < 4 {
+		return errTruncated
+	}
+	headerSize := int(binary.LittleEndian.Uint16(content))
+	flags := binary.LittleEndian.Uint16(content[2:])
+	if headerSize > len(content) {
+		return errTruncated
+	}
+	r := &blobReader{data: content, pos: headerSize}
+	var h eventHeader
+	for r.pos < len(content) {
+		if err := p.canceled(); err != nil {
+			return err
+		}
+		var err error
+		if flags&compressedHeaders != 0 {
+			err = r.compressedHeader(&h)
+		} else {
+			err = r.header(&h)
+		}
+		if err != nil {
+			return err
+		}
+		payload, err := r.bytes(int(h.payloadSize))
+		if err != nil {
+			return err
+		}
+		if flags&compressedHeaders == 0 {
+			r.align()
+		}
+		if isMetadata {
+			p.addMetadata(payload)
+		} else {
+			p.addEvent(&h, payload)
+		}
+	}
+	return nil
+}
+
+func (p *traceParser) stackBlock(content []byte) error {
+	r := &blobReader{data: content}
+	b, err := r.bytes(8)
+	if err != nil {
+		return err
+	}
+	id := binary.LittleEndian.Uint32(b)
+	count := binary.LittleEndian.Uint32(b[4:])
+	for i := uint32(0); i < count; i++ {
+		if err = p.canceled(); err != nil {
+			return err
+		}
+		if b, err = r.bytes(4); err != nil {
+			return err
+		}
+		if b, err = r.bytes(int(binary.LittleEndian.Uint32(b))); err != nil {
+			return err
+		}
+		ips := make([]uint64, 0, len(b)/p.pointerSize)
+		for j := 0; j+p.pointerSize <= len(b); j += p.pointerSize {
+			ips = append(ips, p.pointer(b[j:]))
+		}
+		p.stacks[id+i] = ips
+	}
+	return nil
+}
+
+func (p *traceParser) addMetadata(payload []byte) {
+	r := &blobReader{data: payload}
+	b, err := r.bytes(4)
+	if err != nil {
+		return
+	}
+	id := binary.LittleEndian.Uint32(b)
+	provider, err := r.utf16String()
+	if err != nil {
+		return
+	}
+	if b, err = r.bytes(4); err != nil {
+		return
+	}
+	m := &eventMetadata{provider: provider, eventID: int32(binary.LittleEndian.Uint32(b))}
+	// event name, keywords, version
+	if _, err = r.utf16String(); err != nil {
+		return
+	}
+	if b, err = r.bytes(12); err == nil {
+		m.version = int32(binary.LittleEndian.Uint32(b[8:]))
+	}
+	p.metadata[id] = m
+}
+
+func (p *traceParser) addEvent(h *eventHeader, payload []byte) {
+	m, ok := p.metadata[h.metadataID]
+	if !ok {
+		return
+	}
+	switch {
+	case m.provider == providerSampleProfiler && m.eventID == eventThreadSample:
+		managed := len(payload) >= 4 && binary.LittleEndian.Uint32(payload) == threadSampleManaged
+		p.samples = append(p.samples, sample{stackID: h.stackID, managed: managed})
+	case m.provider == providerRuntime && m.eventID == eventGCAllocationTick:
+		// AllocationAmount, AllocationKind, ClrInstanceID and AllocationAmount64 since version 2
+		amount := uint64(0)
+		if len(payload) >= 18 && m.version >= 2 {
+			amount = binary.LittleEndian.Uint64(payload[10:])
+		} else if len(payload) >= 4 {
+			amount = uint64(binary.LittleEndian.Uint32(payload))
+		}
+		p.samples = append(p.samples, sample{stackID: h.stackID, alloc: true, allocated: amount})
+	case (m.provider == providerRuntime && m.eventID == eventMethodLoadVerbose) ||
+		(m.provider == providerRundown && m.eventID == eventMethodDCEndVerbose):
+		p.addMethod(payload)
+	}
+}
+
+// addMethod adds the method of the MethodLoadVerbose or MethodDCEndVerbose events, the payload of which is
+// MethodID, ModuleID, MethodStartAddress, MethodSize, MethodToken, MethodFlags, MethodNamespace, MethodName and
+// MethodSignature.
+func (p *traceParser) addMethod(payload []byte) {
+	r := &blobReader{data: payload}
+	b, err := r.bytes(36)
+	if err != nil {
+		return
+	}
+	m := method{start: binary.LittleEndian.Uint64(b[16:]), size: uint64(binary.LittleEndian.Uint32(b[24:]))}
+	namespace, err := r.utf16String()
+	if err != nil {
+		return
+	}
+	name, err := r.utf16String()
+	if err != nil {
+		return
+	}
+	m.name = name
+	if namespace != "" {
+		m.name = namespace + "." + name
+	}
+	p.methods = append(p.methods, m)
+}
+
+// frames resolves the instruction pointers of the stack to the methods from the root to the leaf, the unresolved
+// native frames are skipped.
+func (p *traceParser) frames(stackID uint32) []string {
+	ips := p.stacks[stackID]
+	frames := make([]string, 0, len(ips))
+	for i := len(ips) - 1; i >= 0; i-- {
+		ip := ips[i]
+		j := sort.Search(len(p.methods), func(k int) bool {
+			return p.methods[k].start > ip
+		}) - 1
+		if j >= 0 && ip < p.methods[j].start+p.methods[j].size {
+			frames = append(frames, p.methods[j].name)
+		}
+	}
+	return frames
+}
+
+// canceled checks the cancellation every profile.Canceled iterations of the loops of the events and the stacks.
+func (p *traceParser) canceled() error {
+	p.iterations++
+	return profile.Canceled(p.ctx, p.iterations)
+}
+
+func (p *traceParser) pointer(b []byte) uint64 {
+	if p.pointerSize == 4 {
+		return uint64(binary.LittleEndian.Uint32(b))
+	}
+	return binary.LittleEndian.Uint64(b)
+}
+
+func (p *traceParser) byte() (byte, error) {
+	b, err := p.bytes(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (p *traceParser) bytes(n int) ([]byte, error) {
+	if n < 0 || p.pos+n > len(p.data) {
+		return nil, errTruncated
+	}
+	b := p.data[p.pos : p.pos+n]
+	p.pos += n
+	return b, nil
+}
+
+func (p *traceParser) lengthPrefixedString() (string, error) {
+	b, err := p.bytes(4)
+	if err != nil {
+		return "", err
+	}
+	if b, err = p.bytes(int(binary.LittleEndian.Uint32(b))); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// blobReader reads the content of a block.
+type blobReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *blobReader) bytes(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.data) {
+		return nil, errTruncated
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *blobReader) align() {
+	if r.pos%4 != 0 {
+		r.pos += 4 - r.pos%4
+	}
+}
+
+func (r *blobReader) varUint() (uint64, error) {
+	var v uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		b, err := r.bytes(1)
+		if err != nil {
+			return 0, err
+		}
+		v |= uint64(b[0]&0x7f) << shift
+		if b[0]&0x80 == 0 {
+			return v, nil
+		}
+	}
+	return 0, errors.New("invalid varuint")
+}
+
+// header reads the uncompressed event header: EventSize, MetadataId, SequenceNumber, ThreadId, CaptureThreadId,
+// ProcessorNumber, StackId, TimeStamp, ActivityId, RelatedActivityId and PayloadSize.
+func (r *blobReader) header(h *eventHeader) error {
+	b, err := r.bytes(80)
+	if err != nil {
+		return err
+	}
+	// the high bit is the sorted flag
+	h.metadataID = binary.LittleEndian.Uint32(b[4:]) & 0x7fffffff
+	h.threadID = binary.LittleEndian.Uint64(b[12:])
+	h.stackID = binary.LittleEndian.Uint32(b[32:])
+	h.payloadSize = binary.LittleEndian.Uint32(b[76:])
+	return nil
+}
+
+// compressedHeader reads the compressed event header, the absent fields of which are the same as the previous event
+// of the block.
+func (r *blobReader) compressedHeader(h *eventHeader) error {
+	b, err := r.bytes(1)
+	if err != nil {
+		return err
+	}
+	flags := b[0]
+	var v uint64
+	if flags&flagMetadataID != 0 {
+		if v, err = r.varUint(); err != nil {
+			return err
+		}
+		h.metadataID = uint32(v)
+	}
+	if flags&flagCaptureThreadAndSequence != 0 {
+		// sequence number delta, capture thread id and processor number
+		for i := 0; i < 3; i++ {
+			if _, err = r.varUint(); err != nil {
+				return err
+			}
+		}
+	}
+	if flags&flagThreadID != 0 {
+		if h.threadID, err = r.varUint(); err != nil {
+			return err
+		}
+	}
+	if flags&flagStackID != 0 {
+		if v, err = r.varUint(); err != nil {
+			return err
+		}
+		h.stackID = uint32(v)
+	}
+	// timestamp delta
+	if _, err = r.varUint(); err != nil {
+		return err
+	}
+	for _, f := range []byte{flagActivityID, flagRelatedActivityID} {
+		if flags&f != 0 {
+			if _, err = r.bytes(16); err != nil {
+				return err
+			}
+		}
+	}
+	if flags&flagDataLength != 0 {
+		if v, err = r.varUint(); err != nil {
+			return err
+		}
+		h.payloadSize = uint32(v)
+	}
+	return nil
+}
+
+// utf16String reads the null terminated UTF-16LE string.
+func (r *blobReader) utf16String() (string, error) {
+	var chars []uint16
+	for {
+		b, err := r.bytes(2)
+		if err != nil {
+			return "", err
+		}
+		c := binary.LittleEndian.Uint16(b)
+		if c == 0 {
+			return string(utf16.Decode(chars)), nil
+		}
+		chars = append(chars, c)
+	}
+}
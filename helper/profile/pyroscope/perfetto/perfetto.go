@@ -0,0 +1,92 @@
+package perfetto
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/alibaba/ilogtail/helper/profile"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+const valueTypeCPU = "cpu"
+
+// RawProfile is the perfetto protobuf trace, the callstack sampling packets of which, such as the ones recorded by
+// the traced_perf of Android, are converted to the cpu profile in samples. The process and the thread of the samples
+// are kept as the labels.
+type RawProfile struct {
+	RawData []byte
+
+	logs []*protocol.Log // v1 result
+}
+
+func NewRawProfile(data []byte) *RawProfile {
+	return &RawProfile{
+		RawData: data,
+	}
+}
+
+func (r *RawProfile) Parse(ctx context.Context, meta *profile.Meta, tags map[string]string) (logs []*protocol.Log, err error) {
+	if err = r.parseTrace(ctx, meta, profile.ExtractProfileV1(meta, tags, &r.logs)); err != nil {
+		return nil, err
+	}
+	logs = r.logs
+	r.logs = nil
+	return
+}
+
+func (r *RawProfile) parseTrace(ctx context.Context, meta *profile.Meta, cb profile.BatchCallbackFunc) error {
+	p := newTraceParser()
+	if err := p.parse(ctx, r.RawData); err != nil {
+		return fmt.Errorf("unable to parse perfetto trace: %w", err)
+	}
+	records := make([]profile.StackRecord, 0, len(p.samples))
+	merged := make(map[uint64]int)
+	for i, s := range p.samples {
+		if err := profile.Canceled(ctx, i); err != nil {
+			return err
+		}
+		// the frames of the record are from the leaf to the root
+		frames := make([]string, len(s.frames))
+		for i, f := range s.frames {
+			frames[len(frames)-1-i] = f
+		}
+		labels := make(map[string]string, len(meta.Tags)+4)
+		for k, v := range meta.Tags {
+			labels[k] = v
+		}
+		labels["pid"] = strconv.FormatUint(s.pid, 10)
+		labels["tid"] = strconv.FormatUint(s.tid, 10)
+		if name, ok := p.processNames[s.pid]; ok {
+			labels["process_name"] = name
+		}
+		if name, ok := p.threadNames[s.tid]; ok {
+			labels["thread_name"] = name
+		}
+		id := xxhash.Sum64String(strings.Join(frames, ""))
+		key := id ^ xxhash.Sum64String(labels["pid"]+"/"+labels["tid"])
+		if i, ok := merged[key]; ok {
+			records[i].Vals[0]++
+			continue
+		}
+		merged[key] = len(records)
+		records = append(records, profile.StackRecord{
+			ID:        id,
+			Stack:     &profile.Stack{Name: frames[0], Stack: frames[1:]},
+			Vals:      []uint64{1},
+			Types:     []string{valueTypeCPU},
+			Units:     []string{string(profile.SamplesUnits)},
+			Aggs:      []string{string(meta.AggregationType)},
+			StartTime: meta.StartTime.UnixNano(),
+			EndTime:   meta.EndTime.UnixNano(),
+			Labels:    labels,
+		})
+	}
+	if len(records) > 0 {
+		cb(records)
+	}
+	return nil
+}
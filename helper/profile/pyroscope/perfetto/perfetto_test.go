@@ -0,0 +1,109 @@
+package perfetto
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/alibaba/ilogtail/helper/profile"
+	"github.com/alibaba/ilogtail/plugins/test"
+)
+
+type message []byte
+
+func (m message) varint(num protowire.Number, v uint64) message {
+	m = protowire.AppendTag(m, num, protowire.VarintType)
+	return protowire.AppendVarint(m, v)
+}
+
+func (m message) bytes(num protowire.Number, v []byte) message {
+	m = protowire.AppendTag(m, num, protowire.BytesType)
+	return protowire.AppendBytes(m, v)
+}
+
+func (m message) string(num protowire.Number, v string) message {
+	return m.bytes(num, []byte(v))
+}
+
+func internedString(iid uint64, str string) []byte {
+	return message(nil).varint(fieldIID, iid).string(fieldInternedStringStr, str)
+}
+
+func buildTrace() []byte {
+	var trace message
+	packet := func(p message) {
+		trace = trace.bytes(fieldTracePacket, p)
+	}
+	processTree := message(nil).
+		bytes(fieldProcessTreeProcesses, message(nil).varint(fieldProcessPid, 100).string(fieldProcessCmdline, "com.example.app").string(fieldProcessCmdline, "--flag")).
+		bytes(fieldProcessTreeThreads, message(nil).varint(fieldThreadTid, 101).string(fieldThreadName, "RenderThread").varint(fieldThreadTgid, 100))
+	packet(message(nil).bytes(fieldPacketProcessTree, processTree))
+
+	var packed []byte
+	packed = protowire.AppendVarint(packed, 1)
+	packed = protowire.AppendVarint(packed, 2)
+	interned := message(nil).
+		bytes(fieldInternedFunctionNames, internedString(1, "main")).
+		bytes(fieldInternedFunctionNames, internedString(2, "draw")).
+		bytes(fieldInternedMappingPaths, internedString(1, "system")).
+		bytes(fieldInternedMappingPaths, internedString(2, "libhwui.so")).
+		bytes(fieldInternedMappings, message(nil).varint(fieldIID, 1).varint(fieldMappingPathIDs, 1).varint(fieldMappingPathIDs, 2)).
+		bytes(fieldInternedFrames, message(nil).varint(fieldIID, 1).varint(fieldFrameFunctionName, 1)).
+		bytes(fieldInternedFrames, message(nil).varint(fieldIID, 2).varint(fieldFrameFunctionName, 2)).
+		bytes(fieldInternedFrames, message(nil).varint(fieldIID, 3).varint(fieldFrameMappingID, 1).varint(fieldFrameRelPC, 0x1f0)).
+		bytes(fieldInternedCallstacks, message(nil).varint(fieldIID, 1).bytes(fieldCallstackFrameIDs, packed)).
+		bytes(fieldInternedCallstacks, message(nil).varint(fieldIID, 2).varint(fieldCallstackFrameIDs, 1).varint(fieldCallstackFrameIDs, 3))
+	packet(message(nil).varint(fieldPacketSequenceID, 1).varint(fieldPacketSequenceFlags, seqIncrementalStateCleared).bytes(fieldPacketInternedData, interned))
+
+	sample := func(tid, callstack uint64) {
+		packet(message(nil).varint(8, 1000).varint(fieldPacketSequenceID, 1).
+			bytes(fieldPacketPerfSample, message(nil).varint(1, 0).varint(fieldPerfSamplePid, 100).varint(fieldPerfSampleTid, tid).varint(fieldPerfSampleCallstackIID, callstack)))
+	}
+	sample(101, 1)
+	sample(101, 1)
+	sample(101, 2)
+	sample(102, 1)
+	// the samples of the other sequence don't see the interned data
+	packet(message(nil).varint(fieldPacketSequenceID, 2).
+		bytes(fieldPacketPerfSample, message(nil).varint(fieldPerfSamplePid, 100).varint(fieldPerfSampleTid, 101).varint(fieldPerfSampleCallstackIID, 1)))
+	return trace
+}
+
+func TestParseTrace(t *testing.T) {
+	r := NewRawProfile(buildTrace())
+	logs, err := r.Parse(context.Background(), &profile.Meta{
+		Tags:            map[string]string{"__name__": "app"},
+		SpyName:         "unknown",
+		StartTime:       time.Unix(1680000000, 0),
+		EndTime:         time.Unix(1680000010, 0),
+		AggregationType: profile.SumAggType,
+	}, map[string]string{"cluster": "c1"})
+	require.NoError(t, err)
+	values := make(map[string]string)
+	for _, log := range logs {
+		require.Equal(t, "cpu", test.ReadLogVal(log, "valueTypes"))
+		require.Equal(t, "samples", test.ReadLogVal(log, "units"))
+		require.Equal(t, "profile_cpu", test.ReadLogVal(log, "type"))
+		values[test.ReadLogVal(log, "name")+"|"+test.ReadLogVal(log, "stack")+"|"+test.ReadLogVal(log, "labels")] = test.ReadLogVal(log, "val")
+	}
+	require.Equal(t, map[string]string{
+		`draw|main|{"__name__":"app","cluster":"c1","pid":"100","process_name":"com.example.app","thread_name":"RenderThread","tid":"101"}`:             "2.00",
+		`libhwui.so+0x1f0|main|{"__name__":"app","cluster":"c1","pid":"100","process_name":"com.example.app","thread_name":"RenderThread","tid":"101"}`: "1.00",
+		`draw|main|{"__name__":"app","cluster":"c1","pid":"100","process_name":"com.example.app","tid":"102"}`:                                          "1.00",
+	}, values)
+}
+
+func TestParseInvalidTrace(t *testing.T) {
+	_, err := NewRawProfile([]byte{0x0a, 0xff}).Parse(context.Background(), &profile.Meta{Tags: map[string]string{}}, nil)
+	require.Error(t, err)
+}
+
+func TestParseTraceCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := NewRawProfile(buildTrace()).Parse(ctx, &profile.Meta{Tags: map[string]string{}}, nil)
+	require.ErrorIs(t, err, context.Canceled)
+}
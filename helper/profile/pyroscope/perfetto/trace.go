@@ -0,0 +1,353 @@
+package perfetto
+
+import (
+	"context"
+	"path"
+	"strconv"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/alibaba/ilogtail/helper/profile"
+)
+
+// the field numbers of the perfetto trace protos
+const (
+	fieldTracePacket = 1
+
+	fieldPacketProcessTree     = 2
+	fieldPacketSequenceID      = 10
+	fieldPacketInternedData    = 12
+	fieldPacketSequenceFlags   = 13
+	fieldPacketPerfSample      = 66
+	seqIncrementalStateCleared = 1
+
+	fieldProcessTreeProcesses = 1
+	fieldProcessTreeThreads   = 2
+	fieldProcessPid           = 1
+	fieldProcessCmdline       = 3
+	fieldThreadTid            = 1
+	fieldThreadName           = 2
+	fieldThreadTgid           = 5
+
+	fieldInternedFunctionNames = 5
+	fieldInternedFrames        = 6
+	fieldInternedCallstacks    = 7
+	fieldInternedMappingPaths  = 17
+	fieldInternedMappings      = 19
+
+	fieldIID               = 1
+	fieldInternedStringStr = 2
+	fieldFrameFunctionName = 2
+	fieldFrameMappingID    = 3
+	fieldFrameRelPC        = 4
+	fieldCallstackFrameIDs = 2
+	fieldMappingPathIDs    = 7
+
+	fieldPerfSamplePid          = 2
+	fieldPerfSampleTid          = 3
+	fieldPerfSampleCallstackIID = 4
+)
+
+type frame struct {
+	functionNameID uint64
+	mappingID      uint64
+	relPC          uint64
+}
+
+// internedState is the interned data of a packet sequence, which is cleared by the packets with the
+// SEQ_INCREMENTAL_STATE_CLEARED flag.
+type internedState struct {
+	functionNames map[uint64]string
+	mappingPaths  map[uint64]string
+	mappings      map[uint64][]uint64
+	frames        map[uint64]*frame
+	callstacks    map[uint64][]uint64
+}
+
+func newInternedState() *internedState {
+	return &internedState{
+		functionNames: make(map[uint64]string),
+		mappingPaths:  make(map[uint64]string),
+		mappings:      make(map[uint64][]uint64),
+		frames:        make(map[uint64]*frame),
+		callstacks:    make(map[uint64][]uint64),
+	}
+}
+
+type perfSample struct {
+	pid    uint64
+	tid    uint64
+	frames []string
+}
+
+// traceParser parses the perf samples of the perfetto trace, and resolves the callstacks of them by the interned
+// data of the packet sequences.
+type traceParser struct {
+	sequences    map[uint64]*internedState
+	processNames map[uint64]string
+	threadNames  map[uint64]string
+	samples      []*perfSample
+}
+
+func newTraceParser() *traceParser {
+	return &traceParser{
+		sequences:    make(map[uint64]*internedState),
+		processNames: make(map[uint64]string),
+		threadNames:  make(map[uint64]string),
+	}
+}
+
+// parse parses the packets of the trace, and is aborted once @ctx is done.
+func (p *traceParser) parse(ctx context.Context, data []byte) error {
+	var packets int
+	return consumeFields(data, func(num protowire.Number, _ uint64, value []byte) error {
+		packets++
+		if err := profile.Canceled(ctx, packets); err != nil {
+			return err
+		}
+		if num == fieldTracePacket {
+			return p.packet(value)
+		}
+		return nil
+	})
+}
+
+func (p *traceParser) packet(data []byte) error {
+	var sequenceID, flags uint64
+	var interned, processTree, sample []byte
+	err := consumeFields(data, func(num protowire.Number, v uint64, value []byte) error {
+		switch num {
+		case fieldPacketSequenceID:
+			sequenceID = v
+		case fieldPacketSequenceFlags:
+			flags = v
+		case fieldPacketInternedData:
+			interned = value
+		case fieldPacketProcessTree:
+			processTree = value
+		case fieldPacketPerfSample:
+			sample = value
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	state, ok := p.sequences[sequenceID]
+	if !ok || flags&seqIncrementalStateCleared != 0 {
+		state = newInternedState()
+		p.sequences[sequenceID] = state
+	}
+	if interned != nil {
+		if err = state.intern(interned); err != nil {
+			return err
+		}
+	}
+	if processTree != nil {
+		if err = p.processTree(processTree); err != nil {
+			return err
+		}
+	}
+	if sample != nil {
+		return p.perfSample(state, sample)
+	}
+	return nil
+}
+
+func (s *internedState) intern(data []byte) error {
+	return consumeFields(data, func(num protowire.Number, _ uint64, value []byte) error {
+		var iid uint64
+		switch num {
+		case fieldInternedFunctionNames, fieldInternedMappingPaths:
+			var str string
+			err := consumeFields(value, func(num protowire.Number, v uint64, value []byte) error {
+				switch num {
+				case fieldIID:
+					iid = v
+				case fieldInternedStringStr:
+					str = string(value)
+				}
+				return nil
+			})
+			if num == fieldInternedFunctionNames {
+				s.functionNames[iid] = str
+			} else {
+				s.mappingPaths[iid] = str
+			}
+			return err
+		case fieldInternedFrames:
+			f := &frame{}
+			err := consumeFields(value, func(num protowire.Number, v uint64, _ []byte) error {
+				switch num {
+				case fieldIID:
+					iid = v
+				case fieldFrameFunctionName:
+					f.functionNameID = v
+				case fieldFrameMappingID:
+					f.mappingID = v
+				case fieldFrameRelPC:
+					f.relPC = v
+				}
+				return nil
+			})
+			s.frames[iid] = f
+			return err
+		case fieldInternedCallstacks, fieldInternedMappings:
+			var ids []uint64
+			repeated := uint64(fieldCallstackFrameIDs)
+			if num == fieldInternedMappings {
+				repeated = fieldMappingPathIDs
+			}
+			err := consumeFields(value, func(n protowire.Number, v uint64, value []byte) error {
+				switch {
+				case n == fieldIID:
+					iid = v
+				case uint64(n) == repeated && value != nil:
+					// packed
+					for len(value) > 0 {
+						id, m := protowire.ConsumeVarint(value)
+						if m < 0 {
+							return protowire.ParseError(m)
+						}
+						ids = append(ids, id)
+						value = value[m:]
+					}
+				case uint64(n) == repeated:
+					ids = append(ids, v)
+				}
+				return nil
+			})
+			if num == fieldInternedCallstacks {
+				s.callstacks[iid] = ids
+			} else {
+				s.mappings[iid] = ids
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+func (p *traceParser) processTree(data []byte) error {
+	return consumeFields(data, func(num protowire.Number, _ uint64, value []byte) error {
+		switch num {
+		case fieldProcessTreeProcesses:
+			var pid uint64
+			var cmdline string
+			err := consumeFields(value, func(num protowire.Number, v uint64, value []byte) error {
+				switch num {
+				case fieldProcessPid:
+					pid = v
+				case fieldProcessCmdline:
+					if cmdline == "" {
+						cmdline = string(value)
+					}
+				}
+				return nil
+			})
+			if cmdline != "" {
+				p.processNames[pid] = cmdline
+			}
+			return err
+		case fieldProcessTreeThreads:
+			var tid uint64
+			var name string
+			err := consumeFields(value, func(num protowire.Number, v uint64, value []byte) error {
+				switch num {
+				case fieldThreadTid:
+					tid = v
+				case fieldThreadName:
+					name = string(value)
+				}
+				return nil
+			})
+			if name != "" {
+				p.threadNames[tid] = name
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+func (p *traceParser) perfSample(state *internedState, data []byte) error {
+	s := &perfSample{}
+	var callstack uint64
+	err := consumeFields(data, func(num protowire.Number, v uint64, _ []byte) error {
+		switch num {
+		case fieldPerfSamplePid:
+			s.pid = v
+		case fieldPerfSampleTid:
+			s.tid = v
+		case fieldPerfSampleCallstackIID:
+			callstack = v
+		}
+		return nil
+	})
+	if err != nil || callstack == 0 {
+		return err
+	}
+	// the frames of the callstack are from the root to the leaf
+	for _, id := range state.callstacks[callstack] {
+		if f, ok := state.frames[id]; ok {
+			s.frames = append(s.frames, state.frameName(f))
+		}
+	}
+	if len(s.frames) > 0 {
+		p.samples = append(p.samples, s)
+	}
+	return nil
+}
+
+// frameName returns the function name of the frame, or the mapping and the relative pc of the unsymbolized frame.
+func (s *internedState) frameName(f *frame) string {
+	if name := s.functionNames[f.functionNameID]; name != "" {
+		return name
+	}
+	mapping := "unknown"
+	if ids := s.mappings[f.mappingID]; len(ids) > 0 {
+		var p string
+		for _, id := range ids {
+			p += "/" + s.mappingPaths[id]
+		}
+		mapping = path.Base(p)
+	}
+	return mapping + "+0x" + strconv.FormatUint(f.relPC, 16)
+}
+
+// consumeFields calls @fn with the varint or length-delimited fields of @data, and skips the others.
+func consumeFields(data []byte, fn func(num protowire.Number, v uint64, value []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			if err := fn(num, v, nil); err != nil {
+				return err
+			}
+			data = data[n:]
+		case protowire.BytesType:
+			value, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			if err := fn(num, 0, value); err != nil {
+				return err
+			}
+			data = data[n:]
+		default:
+			if n = protowire.ConsumeFieldValue(num, typ, data); n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+		}
+	}
+	return nil
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/alibaba/ilogtail/helper/profile"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 const (
@@ -70,8 +71,11 @@ type RawProfile struct {
 	FormDataContentType string
 	profile             []byte
 	sampleTypeConfig    map[string]*tree.SampleTypeConfig
+	// TrimPathPrefixes are stripped from the source file names and the mapping file names of the profile
+	TrimPathPrefixes []string
 
-	logs []*protocol.Log // v1 result
+	logs    []*protocol.Log       // v1 result
+	records []profile.StackRecord // reused between the batches
 }
 
 func NewRawProfile(data []byte, format string) *RawProfile {
@@ -82,7 +86,7 @@ func NewRawProfile(data []byte, format string) *RawProfile {
 }
 
 func (r *RawProfile) Parse(ctx context.Context, meta *profile.Meta, tags map[string]string) (logs []*protocol.Log, err error) {
-	cb := r.extractProfileV1(meta, tags)
+	cb := profile.ExtractProfileV1(meta, tags, &r.logs)
 	if err = r.doParse(ctx, meta, cb); err != nil {
 		return nil, err
 	}
@@ -91,7 +95,7 @@ func (r *RawProfile) Parse(ctx context.Context, meta *profile.Meta, tags map[str
 	return
 }
 
-func (r *RawProfile) doParse(ctx context.Context, meta *profile.Meta, cb profile.CallbackFunc) error {
+func (r *RawProfile) doParse(ctx context.Context, meta *profile.Meta, cb profile.BatchCallbackFunc) error {
 	if err := r.extractProfileRaw(); err != nil {
 		return fmt.Errorf("cannot extract profile: %w", err)
 	}
@@ -102,7 +106,7 @@ func (r *RawProfile) doParse(ctx context.Context, meta *profile.Meta, cb profile
 	if meta.SampleRate > 0 {
 		meta.Tags["_sample_rate_"] = strconv.FormatUint(uint64(meta.SampleRate), 10)
 	}
-	return pprof.DecodePool(bytes.NewReader(r.profile), func(tf *tree.Profile) error {
+	return pprof.DecodePool(profile.NewContextReader(ctx, bytes.NewReader(r.profile)), func(tf *tree.Profile) error {
 		if logger.DebugFlag() {
 			var keys []string
 			for k := range r.sampleTypeConfig {
@@ -126,7 +130,7 @@ func (r *RawProfile) doParse(ctx context.Context, meta *profile.Meta, cb profile
 	})
 }
 
-func (r *RawProfile) extractLogs(ctx context.Context, tp *tree.Profile, p Parser, meta *profile.Meta, cb profile.CallbackFunc) error {
+func (r *RawProfile) extractLogs(ctx context.Context, tp *tree.Profile, p Parser, meta *profile.Meta, cb profile.BatchCallbackFunc) error {
 
 	stackMap := make(map[uint64]*profile.Stack)
 	valMap := make(map[uint64][]uint64)
@@ -138,8 +142,25 @@ func (r *RawProfile) extractLogs(ctx context.Context, tp *tree.Profile, p Parser
 	if len(tp.SampleType) > 0 {
 		meta.Units = profile.Units(tp.StringTable[tp.SampleType[0].Type])
 	}
+	sanitizeSymbols(tp, r.TrimPathPrefixes)
+	appLabels := meta.Tags
+	if bl := binaryLabels(tp); len(bl) > 0 {
+		appLabels = make(map[string]string, len(meta.Tags)+len(bl))
+		for k, v := range meta.Tags {
+			appLabels[k] = v
+		}
+		for k, v := range bl {
+			appLabels[k] = v
+		}
+	}
 
-	err := p.iterate(tp, func(vt *tree.ValueType, tl tree.Labels, t *tree.Tree) (keep bool, err error) {
+	// the stacks are skipped once the deadline of the upload is exceeded
+	var iterations int
+	var canceled bool
+	err := p.iterate(ctx, tp, func(vt *tree.ValueType, tl tree.Labels, t *tree.Tree) (keep bool, err error) {
+		if err = ctx.Err(); err != nil {
+			return false, err
+		}
 		if len(tp.StringTable) <= int(vt.Type) || len(tp.StringTable) <= int(vt.Unit) {
 			return true, errors.New("invalid type or unit")
 		}
@@ -147,7 +168,8 @@ func (r *RawProfile) extractLogs(ctx context.Context, tp *tree.Profile, p Parser
 		sunit := tp.StringTable[vt.Unit]
 
 		t.IterateStacks(func(name string, self uint64, stack []string) {
-			if name == "" {
+			iterations++
+			if canceled = canceled || profile.Canceled(ctx, iterations) != nil; canceled || name == "" {
 				return
 			}
 			id := xxhash.Sum64String(strings.Join(stack, ""))
@@ -159,106 +181,46 @@ func (r *RawProfile) extractLogs(ctx context.Context, tp *tree.Profile, p Parser
 			typeMap[id] = append(typeMap[id], p.getDisplayName(stype))
 			unitMap[id] = append(unitMap[id], sunit)
 			valMap[id] = append(valMap[id], self)
-			labelMap[id] = buildKey(meta.Tags, tl, tp.StringTable).Labels()
+			labelMap[id] = buildKey(appLabels, tl, tp.StringTable).Labels()
 		})
 		return true, nil
 	})
+	if err == nil {
+		err = ctx.Err()
+	}
 	if err != nil {
 		return fmt.Errorf("iterate profile tree error: %w", err)
 	}
+	startTime, endTime := meta.StartTime.UnixNano(), meta.EndTime.UnixNano()
+	if tp.GetTimeNanos() != 0 {
+		startTime, endTime = tp.GetTimeNanos(), tp.GetTimeNanos()+tp.GetDurationNanos()
+	}
+	records := r.records[:0]
+	if cap(records) < len(stackMap) {
+		records = make([]profile.StackRecord, 0, len(stackMap))
+	}
 	for id, fs := range stackMap {
 		if len(valMap[id]) == 0 || len(typeMap[id]) == 0 || len(unitMap[id]) == 0 || len(aggtypeMap[id]) == 0 {
-			logger.Warning(ctx, "PPROF_PROFILE_ALARM", "stack don't have enough meta or values", fs)
+			logger.Warning(ctx, util.AlarmPProfProfile, "stack don't have enough meta or values", fs)
 			continue
 		}
-		if tp.GetTimeNanos() != 0 {
-			cb(id, fs, valMap[id], typeMap[id], unitMap[id], aggtypeMap[id], tp.GetTimeNanos(), tp.GetTimeNanos()+tp.GetDurationNanos(), labelMap[id])
-		} else {
-			cb(id, fs, valMap[id], typeMap[id], unitMap[id], aggtypeMap[id], meta.StartTime.UnixNano(), meta.EndTime.UnixNano(), labelMap[id])
-		}
+		records = append(records, profile.StackRecord{
+			ID:        id,
+			Stack:     fs,
+			Vals:      valMap[id],
+			Types:     typeMap[id],
+			Units:     unitMap[id],
+			Aggs:      aggtypeMap[id],
+			StartTime: startTime,
+			EndTime:   endTime,
+			Labels:    labelMap[id],
+		})
 	}
-	return nil
-}
-
-func (r *RawProfile) extractProfileV1(meta *profile.Meta, tags map[string]string) profile.CallbackFunc {
-	profileIDStr := profile.GetProfileID(meta)
-	return func(id uint64, stack *profile.Stack, vals []uint64, types, units, aggs []string, startTime, endTime int64, labels map[string]string) {
-		for k, v := range tags {
-			labels[k] = v
-		}
-		b, _ := json.Marshal(labels)
-		var content []*protocol.Log_Content
-		content = append(content,
-			&protocol.Log_Content{
-				Key:   "name",
-				Value: stack.Name,
-			},
-			&protocol.Log_Content{
-				Key:   "stack",
-				Value: strings.Join(stack.Stack, "\n"),
-			},
-			&protocol.Log_Content{
-				Key:   "stackID",
-				Value: strconv.FormatUint(id, 16),
-			},
-			&protocol.Log_Content{
-				Key:   "language",
-				Value: meta.SpyName,
-			},
-			&protocol.Log_Content{
-				Key:   "type",
-				Value: profile.DetectProfileType(types[0]).String(),
-			},
-			&protocol.Log_Content{
-				Key:   "dataType",
-				Value: "CallStack",
-			},
-			&protocol.Log_Content{
-				Key:   "durationNs",
-				Value: strconv.FormatInt(endTime-startTime, 10),
-			},
-			&protocol.Log_Content{
-				Key:   "profileID",
-				Value: profileIDStr,
-			},
-			&protocol.Log_Content{
-				Key:   "labels",
-				Value: string(b),
-			},
-		)
-		for i, v := range vals {
-			var res []*protocol.Log_Content
-			if i != len(vals)-1 {
-				res = make([]*protocol.Log_Content, len(content))
-				copy(res, content)
-			} else {
-				res = content
-			}
-			res = append(res,
-				&protocol.Log_Content{
-					Key:   "units",
-					Value: units[i],
-				},
-				&protocol.Log_Content{
-					Key:   "valueTypes",
-					Value: types[i],
-				},
-				&protocol.Log_Content{
-					Key:   "aggTypes",
-					Value: aggs[i],
-				},
-				&protocol.Log_Content{
-					Key:   "val",
-					Value: strconv.FormatFloat(float64(v), 'f', 2, 64),
-				},
-			)
-
-			r.logs = append(r.logs, &protocol.Log{
-				Time:     uint32(startTime / 1e9),
-				Contents: res,
-			})
-		}
+	r.records = records
+	if len(records) > 0 {
+		cb(records)
 	}
+	return nil
 }
 
 func buildKey(appLabels map[string]string, labels tree.Labels, table []string) *segment.Key {
@@ -70,7 +70,7 @@ func TestRawProfile_Parse(t *testing.T) {
 		Units:           profile.NanosecondsUnit,
 		AggregationType: profile.SumAggType,
 	}
-	cb := r.extractProfileV1(meta, map[string]string{"cluster": "cluster2"})
+	cb := profile.ExtractProfileV1(meta, map[string]string{"cluster": "cluster2"}, &r.logs)
 	err = r.extractLogs(context.Background(), te, p, meta, cb)
 	require.NoError(t, err)
 	logs := r.logs
@@ -90,3 +90,17 @@ func TestRawProfile_Parse(t *testing.T) {
 	require.Equal(t, test.ReadLogVal(log, "labels"), "{\"_app_name_\":\"12\",\"cluster\":\"cluster2\"}")
 	require.Equal(t, test.ReadLogVal(log, "val"), "25.00")
 }
+
+func TestRawProfile_ParseCanceled(t *testing.T) {
+	data, err := os.ReadFile("testdata/cpu.pb.gz")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := NewRawProfile(data, "")
+	_, err = r.Parse(ctx, &profile.Meta{
+		Tags:            map[string]string{"_app_name_": "12"},
+		SpyName:         "go",
+		AggregationType: profile.SumAggType,
+	}, nil)
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -1,6 +1,7 @@
 package pprof
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/pyroscope-io/pyroscope/pkg/storage/metadata"
@@ -60,9 +61,11 @@ func (p *Parser) getAggregationType(name string, defaultval string) string {
 	}
 }
 
-func (p *Parser) iterate(x *tree.Profile, fn func(vt *tree.ValueType, l tree.Labels, t *tree.Tree) (keep bool, err error)) error {
+func (p *Parser) iterate(ctx context.Context, x *tree.Profile, fn func(vt *tree.ValueType, l tree.Labels, t *tree.Tree) (keep bool, err error)) error {
 	c := make(tree.LabelsCache)
-	p.readTrees(x, c, tree.NewFinder(x))
+	if err := p.readTrees(ctx, x, c, tree.NewFinder(x)); err != nil {
+		return err
+	}
 	for sampleType, entries := range c {
 		if t, ok := x.ResolveSampleType(sampleType); ok {
 			for h, e := range entries {
@@ -79,8 +82,8 @@ func (p *Parser) iterate(x *tree.Profile, fn func(vt *tree.ValueType, l tree.Lab
 	return nil
 }
 
-// readTrees generates trees from the profile populating c.
-func (p *Parser) readTrees(x *tree.Profile, c tree.LabelsCache, f tree.Finder) {
+// readTrees generates trees from the profile populating c, and is aborted once @ctx is done.
+func (p *Parser) readTrees(ctx context.Context, x *tree.Profile, c tree.LabelsCache, f tree.Finder) error {
 	// SampleType value indexes.
 	indexes := make([]int, 0, len(x.SampleType))
 	// Corresponding type IDs used as the main cache keys.
@@ -92,10 +95,13 @@ func (p *Parser) readTrees(x *tree.Profile, c tree.LabelsCache, f tree.Finder) {
 		}
 	}
 	if len(indexes) == 0 {
-		return
+		return nil
 	}
 	stack := make([][]byte, 0, 16)
-	for _, s := range x.Sample {
+	for n, s := range x.Sample {
+		if err := profile.Canceled(ctx, n); err != nil {
+			return err
+		}
 		for i := len(s.LocationId) - 1; i >= 0; i-- {
 			// Resolve stack.
 			loc, ok := f.FindLocation(s.LocationId[i])
@@ -137,6 +143,7 @@ func (p *Parser) readTrees(x *tree.Profile, c tree.LabelsCache, f tree.Finder) {
 		}
 		stack = stack[:0]
 	}
+	return nil
 }
 
 func labelIndex(p *tree.Profile, labels tree.Labels, key string) int {
@@ -0,0 +1,64 @@
+package pprof
+
+import (
+	"path"
+	"strings"
+
+	"github.com/pyroscope-io/pyroscope/pkg/storage/tree"
+)
+
+const (
+	labelBinaryName = "binary_name"
+	labelBuildID    = "build_id"
+)
+
+// sanitizeSymbols replaces the invalid UTF-8 sequences of the function names, and strips the first matched prefix
+// of the trimPrefixes from the source file names and the mapping file names, such as the GOPATH or the build root.
+func sanitizeSymbols(tp *tree.Profile, trimPrefixes []string) {
+	sanitized := make(map[int64]bool)
+	sanitize := func(index int64, isPath bool) {
+		if index <= 0 || int(index) >= len(tp.StringTable) || sanitized[index] {
+			return
+		}
+		sanitized[index] = true
+		s := strings.ToValidUTF8(tp.StringTable[index], "\uFFFD")
+		if isPath {
+			for _, prefix := range trimPrefixes {
+				if prefix != "" && strings.HasPrefix(s, prefix) {
+					s = strings.TrimPrefix(s[len(prefix):], "/")
+					break
+				}
+			}
+		}
+		tp.StringTable[index] = s
+	}
+	for _, fn := range tp.Function {
+		sanitize(fn.Filename, true)
+		sanitize(fn.Name, false)
+		sanitize(fn.SystemName, false)
+	}
+	for _, m := range tp.Mapping {
+		sanitize(m.Filename, true)
+		sanitize(m.BuildId, false)
+	}
+}
+
+// binaryLabels returns the labels of the main binary, which is the first mapping of the real file.
+func binaryLabels(tp *tree.Profile) map[string]string {
+	for _, m := range tp.Mapping {
+		if m.Filename <= 0 || int(m.Filename) >= len(tp.StringTable) || int(m.BuildId) >= len(tp.StringTable) {
+			continue
+		}
+		filename := tp.StringTable[m.Filename]
+		// virtual abstractions, such as [vdso] and [vsyscall]
+		if filename == "" || strings.HasPrefix(filename, "[") {
+			continue
+		}
+		labels := map[string]string{labelBinaryName: path.Base(filename)}
+		if m.BuildId > 0 && tp.StringTable[m.BuildId] != "" {
+			labels[labelBuildID] = tp.StringTable[m.BuildId]
+		}
+		return labels
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package pprof
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pyroscope-io/pyroscope/pkg/storage/tree"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/helper/profile"
+	"github.com/alibaba/ilogtail/plugins/test"
+)
+
+func TestSanitizeSymbols(t *testing.T) {
+	te, err := readPprofFixture("testdata/cpu.pb.gz")
+	require.NoError(t, err)
+	te.StringTable = append(te.StringTable, "/usr/local/bin/shop", "abc123", "[vdso]")
+	n := int64(len(te.StringTable))
+	te.Mapping = append([]*tree.Mapping{{Id: 100, Filename: n - 1}}, te.Mapping...)
+	te.Mapping = append(te.Mapping, &tree.Mapping{Id: 101, Filename: n - 3, BuildId: n - 2})
+
+	p := Parser{
+		stackFrameFormatter: Formatter{},
+		sampleTypesFilter:   filterKnownSamples(DefaultSampleTypeMapping),
+		sampleTypes:         DefaultSampleTypeMapping,
+	}
+	r := &RawProfile{TrimPathPrefixes: []string{"/opt/homebrew/Cellar/go/1.16.1/libexec"}}
+	meta := &profile.Meta{
+		Tags:            map[string]string{"_app_name_": "12"},
+		SpyName:         "go",
+		StartTime:       time.Now(),
+		EndTime:         time.Now(),
+		Units:           profile.NanosecondsUnit,
+		AggregationType: profile.SumAggType,
+	}
+	err = r.extractLogs(context.Background(), te, p, meta, profile.ExtractProfileV1(meta, map[string]string{}, &r.logs))
+	require.NoError(t, err)
+	picks := test.PickLogs(r.logs, "name", "runtime.kevent src/runtime/sys_darwin.go")
+	require.Equal(t, 1, len(picks))
+	require.True(t, strings.HasPrefix(test.ReadLogVal(picks[0], "stack"), "runtime.netpoll src/runtime/netpoll_kqueue.go\n"))
+	require.Equal(t, "{\"_app_name_\":\"12\",\"binary_name\":\"shop\",\"build_id\":\"abc123\"}", test.ReadLogVal(picks[0], "labels"))
+	require.Equal(t, map[string]string{"_app_name_": "12"}, meta.Tags)
+}
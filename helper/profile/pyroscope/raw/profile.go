@@ -16,6 +16,9 @@ import (
 	"github.com/alibaba/ilogtail/pkg/protocol"
 )
 
+// maxSpyLineSize is the max size of a sample line of the py-spy or rbspy formats, whose stacks could be deep.
+const maxSpyLineSize = 1024 * 1024
+
 type Profile struct {
 	RawData []byte
 	Format  profile.Format
@@ -31,15 +34,23 @@ func NewRawProfile(data []byte, format profile.Format) *Profile {
 }
 
 func (p *Profile) Parse(ctx context.Context, meta *profile.Meta, tags map[string]string) (logs []*protocol.Log, err error) {
+	if p.Format == profile.FormatPySpy || p.Format == profile.FormatRbSpy {
+		if err := p.parseSpy(ctx, meta, tags); err != nil {
+			return nil, err
+		}
+		return p.logs, nil
+	}
 	cb := p.extractProfileV1(meta, tags)
-	if err := p.doParse(cb); err != nil {
+	if err := p.doParse(ctx, cb); err != nil {
 		return nil, err
 	}
 	return p.logs, nil
 }
 
-func (p *Profile) doParse(cb func([]byte, int)) error {
-	r := bytes.NewReader(p.RawData)
+// doParse parses the trie or the collapsed lines, which are read by the reader failing once @ctx is done, so the
+// loops of them are aborted by the deadline of the upload.
+func (p *Profile) doParse(ctx context.Context, cb func([]byte, int)) error {
+	r := profile.NewContextReader(ctx, bytes.NewReader(p.RawData))
 	switch p.Format {
 	case profile.FormatTrie:
 		err := transporttrie.IterateRaw(r, make([]byte, 0, 256), cb)
@@ -65,10 +76,55 @@ func (p *Profile) doParse(cb func([]byte, int)) error {
 			}
 			cb(stacktrace, i)
 		}
+		return scanner.Err()
 	}
 	return nil
 }
 
+// parseSpy parses the lines of the py-spy or rbspy streaming formats, whose frames are formatted already.
+func (p *Profile) parseSpy(ctx context.Context, meta *profile.Meta, tags map[string]string) error {
+	profileID := profile.GetProfileID(meta)
+	for k, v := range tags {
+		meta.Tags[k] = v
+	}
+	baseLabels, _ := json.Marshal(meta.Tags)
+	labelsCache := make(map[string]string)
+	scanner := bufio.NewScanner(profile.NewContextReader(ctx, bytes.NewReader(p.RawData)))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSpyLineSize)
+	for scanner.Scan() {
+		sample, ok, err := parseSpyLine(scanner.Bytes(), p.Format)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		labels := string(baseLabels)
+		if len(sample.labels) > 0 {
+			key := sample.labels[labelPid] + "|" + sample.labels[labelThreadID] + "|" + sample.labels[labelThreadName]
+			if labels, ok = labelsCache[key]; !ok {
+				merged := make(map[string]string, len(meta.Tags)+len(sample.labels))
+				for k, v := range meta.Tags {
+					merged[k] = v
+				}
+				for k, v := range sample.labels {
+					merged[k] = v
+				}
+				b, _ := json.Marshal(merged)
+				labels = string(b)
+				labelsCache[key] = labels
+			}
+		}
+		stack := make([]string, 0, len(sample.frames)-1)
+		for i := len(sample.frames) - 2; i >= 0; i-- {
+			stack = append(stack, sample.frames[i])
+		}
+		stackID := strconv.FormatUint(xxhash.Sum64String(labels+strings.Join(sample.frames, ";")), 16)
+		p.appendLog(meta, profileID, labels, sample.frames[len(sample.frames)-1], stack, stackID, sample.value)
+	}
+	return scanner.Err()
+}
+
 func (p *Profile) extractProfileV1(meta *profile.Meta, tags map[string]string) func([]byte, int) {
 	profileID := profile.GetProfileID(meta)
 	for k, v := range tags {
@@ -78,69 +134,73 @@ func (p *Profile) extractProfileV1(meta *profile.Meta, tags map[string]string) f
 	return func(k []byte, v int) {
 		name, stack := p.extractNameAndStacks(k, meta.SpyName)
 		stackID := strconv.FormatUint(xxhash.Sum64(k), 16)
-		var content []*protocol.Log_Content
-		content = append(content,
-			&protocol.Log_Content{
-				Key:   "name",
-				Value: name,
-			},
-			&protocol.Log_Content{
-				Key:   "stack",
-				Value: strings.Join(stack, "\n"),
-			},
-			&protocol.Log_Content{
-				Key:   "stackID",
-				Value: stackID,
-			},
-			&protocol.Log_Content{
-				Key:   "language",
-				Value: meta.SpyName,
-			},
-			&protocol.Log_Content{
-				Key:   "type",
-				Value: profile.DetectProfileType(meta.Units.DetectValueType()).String(),
-			},
-			&protocol.Log_Content{
-				Key:   "units",
-				Value: string(meta.Units),
-			},
-			&protocol.Log_Content{
-				Key:   "valueTypes",
-				Value: meta.Units.DetectValueType(),
-			},
-			&protocol.Log_Content{
-				Key:   "aggTypes",
-				Value: string(meta.AggregationType),
-			},
-			&protocol.Log_Content{
-				Key:   "dataType",
-				Value: "CallStack",
-			},
-			&protocol.Log_Content{
-				Key:   "durationNs",
-				Value: strconv.FormatInt(meta.EndTime.Sub(meta.StartTime).Nanoseconds(), 10),
-			},
-			&protocol.Log_Content{
-				Key:   "profileID",
-				Value: profileID,
-			},
-			&protocol.Log_Content{
-				Key:   "labels",
-				Value: string(labels),
-			},
-			&protocol.Log_Content{
-				Key:   "val",
-				Value: strconv.FormatFloat(float64(v), 'f', 2, 64),
-			},
-		)
-
-		log := &protocol.Log{
-			Time:     uint32(meta.StartTime.Unix()),
-			Contents: content,
-		}
-		p.logs = append(p.logs, log)
+		p.appendLog(meta, profileID, string(labels), name, stack, stackID, v)
 	}
+}
+
+func (p *Profile) appendLog(meta *profile.Meta, profileID, labels, name string, stack []string, stackID string, v int) {
 
+	var content []*protocol.Log_Content
+	content = append(content,
+		&protocol.Log_Content{
+			Key:   "name",
+			Value: name,
+		},
+		&protocol.Log_Content{
+			Key:   "stack",
+			Value: strings.Join(stack, "\n"),
+		},
+		&protocol.Log_Content{
+			Key:   "stackID",
+			Value: stackID,
+		},
+		&protocol.Log_Content{
+			Key:   "language",
+			Value: meta.SpyName,
+		},
+		&protocol.Log_Content{
+			Key:   "type",
+			Value: profile.DetectProfileType(meta.Units.DetectValueType()).String(),
+		},
+		&protocol.Log_Content{
+			Key:   "units",
+			Value: string(meta.Units),
+		},
+		&protocol.Log_Content{
+			Key:   "valueTypes",
+			Value: meta.Units.DetectValueType(),
+		},
+		&protocol.Log_Content{
+			Key:   "aggTypes",
+			Value: string(meta.AggregationType),
+		},
+		&protocol.Log_Content{
+			Key:   "dataType",
+			Value: "CallStack",
+		},
+		&protocol.Log_Content{
+			Key:   "durationNs",
+			Value: strconv.FormatInt(meta.EndTime.Sub(meta.StartTime).Nanoseconds(), 10),
+		},
+		&protocol.Log_Content{
+			Key:   "profileID",
+			Value: profileID,
+		},
+		&protocol.Log_Content{
+			Key:   "labels",
+			Value: labels,
+		},
+		&protocol.Log_Content{
+			Key:   "val",
+			Value: strconv.FormatFloat(float64(v), 'f', 2, 64),
+		},
+	)
+
+	log := &protocol.Log{
+		Time:     uint32(meta.StartTime.Unix()),
+		Contents: content,
+	}
+	p.logs = append(p.logs, log)
 }
 
 func (p *Profile) extractNameAndStacks(k []byte, spyName string) (name string, stack []string) {
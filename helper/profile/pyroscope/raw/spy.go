@@ -0,0 +1,106 @@
+package raw
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/alibaba/ilogtail/helper/profile"
+)
+
+const (
+	labelPid        = "pid"
+	labelThreadID   = "thread_id"
+	labelThreadName = "thread_name"
+)
+
+// spySample is a sample line of the py-spy raw format or the rbspy collapsed format, whose frames are from the root
+// to the leaf and formatted as "function file:line".
+type spySample struct {
+	frames []string
+	labels map[string]string
+	value  int
+}
+
+// parseSpyLine parses the line of the streaming formats of the samplers, which is the frames joined by ";" and
+// the count of the samples, such as
+//
+//	py-spy: process 1234:"python app.py";thread (0x7F2B4C5E6700);<module> (app.py:10);work (lib/util.py:20) 7
+//	rbspy:  <main> - app.rb:3;block in work - lib/util.rb:20 7
+//
+// The process and thread frames of py-spy are converted to the labels.
+func parseSpyLine(line []byte, format profile.Format) (*spySample, bool, error) {
+	line = bytes.TrimSpace(line)
+	index := bytes.LastIndexByte(line, ' ')
+	if index == -1 {
+		return nil, false, nil
+	}
+	value, err := strconv.Atoi(string(line[index+1:]))
+	if err != nil {
+		return nil, false, err
+	}
+	sample := &spySample{value: value}
+	for _, frame := range strings.Split(string(line[:index]), ";") {
+		if frame == "" {
+			continue
+		}
+		if format == profile.FormatPySpy {
+			if parsePySpyFrameLabel(frame, sample) {
+				continue
+			}
+			frame = formatPySpyFrame(frame)
+		} else {
+			frame = formatRbSpyFrame(frame)
+		}
+		sample.frames = append(sample.frames, frame)
+	}
+	return sample, len(sample.frames) > 0, nil
+}
+
+// parsePySpyFrameLabel converts the process frame `process 1234:"cmdline"` and the thread frame
+// `thread (0x7F2B4C5E6700): "MainThread"` of py-spy to the labels.
+func parsePySpyFrameLabel(frame string, sample *spySample) bool {
+	setLabel := func(k, v string) {
+		if sample.labels == nil {
+			sample.labels = make(map[string]string)
+		}
+		sample.labels[k] = v
+	}
+	switch {
+	case strings.HasPrefix(frame, "process "):
+		pid := strings.TrimPrefix(frame, "process ")
+		if i := strings.IndexByte(pid, ':'); i != -1 {
+			pid = pid[:i]
+		}
+		setLabel(labelPid, pid)
+		return true
+	case strings.HasPrefix(frame, "thread ("):
+		rest := strings.TrimPrefix(frame, "thread (")
+		if i := strings.IndexByte(rest, ')'); i != -1 {
+			setLabel(labelThreadID, rest[:i])
+			if name := strings.Trim(strings.TrimPrefix(rest[i+1:], ":"), ` "`); name != "" {
+				setLabel(labelThreadName, name)
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// formatPySpyFrame converts the frame "function (file:line)" of py-spy to "function file:line".
+func formatPySpyFrame(frame string) string {
+	i := strings.LastIndex(frame, " (")
+	if i == -1 || !strings.HasSuffix(frame, ")") {
+		return frame
+	}
+	return frame[:i] + " " + frame[i+2:len(frame)-1]
+}
+
+// formatRbSpyFrame converts the frame "function - file:line" of rbspy to "function file:line".
+func formatRbSpyFrame(frame string) string {
+	i := strings.LastIndex(frame, " - ")
+	if i == -1 {
+		return frame
+	}
+	return frame[:i] + " " + frame[i+3:]
+}
@@ -0,0 +1,66 @@
+package raw
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/helper/profile"
+	"github.com/alibaba/ilogtail/plugins/test"
+)
+
+func TestParsePySpy(t *testing.T) {
+	data := []byte(`process 1234:"python app.py";thread (0x7F2B4C5E6700): "MainThread";<module> (app.py:10);work (lib/util.py:20) 7
+process 1234:"python app.py";thread (0x7F2B4C5E6700): "MainThread";<module> (app.py:10) 3
+
+`)
+	p := NewRawProfile(data, profile.FormatPySpy)
+	logs, err := p.Parse(context.Background(), &profile.Meta{
+		Tags:            map[string]string{"__name__": "app"},
+		SpyName:         profile.PyroscopePython,
+		StartTime:       time.Unix(1680000000, 0),
+		EndTime:         time.Unix(1680000010, 0),
+		Units:           profile.SamplesUnits,
+		AggregationType: profile.SumAggType,
+	}, map[string]string{"cluster": "c1"})
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+	require.Equal(t, "work lib/util.py:20", test.ReadLogVal(logs[0], "name"))
+	require.Equal(t, "<module> app.py:10", test.ReadLogVal(logs[0], "stack"))
+	require.Equal(t, "7.00", test.ReadLogVal(logs[0], "val"))
+	require.Equal(t, "profile_cpu", test.ReadLogVal(logs[0], "type"))
+	require.Equal(t, `{"__name__":"app","cluster":"c1","pid":"1234","thread_id":"0x7F2B4C5E6700","thread_name":"MainThread"}`, test.ReadLogVal(logs[0], "labels"))
+	require.Equal(t, "<module> app.py:10", test.ReadLogVal(logs[1], "name"))
+	require.Equal(t, "", test.ReadLogVal(logs[1], "stack"))
+	require.NotEqual(t, test.ReadLogVal(logs[0], "stackID"), test.ReadLogVal(logs[1], "stackID"))
+}
+
+func TestParseRbSpy(t *testing.T) {
+	data := []byte("<main> - app.rb:3;block in work - lib/util.rb:20 5\n")
+	p := NewRawProfile(data, profile.FormatRbSpy)
+	logs, err := p.Parse(context.Background(), &profile.Meta{
+		Tags:            map[string]string{"__name__": "app"},
+		SpyName:         profile.PyroscopeRuby,
+		Units:           profile.SamplesUnits,
+		AggregationType: profile.SumAggType,
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, "block in work lib/util.rb:20", test.ReadLogVal(logs[0], "name"))
+	require.Equal(t, "<main> app.rb:3", test.ReadLogVal(logs[0], "stack"))
+	require.Equal(t, `{"__name__":"app"}`, test.ReadLogVal(logs[0], "labels"))
+
+	_, err = NewRawProfile([]byte("<main> - app.rb:3 x\n"), profile.FormatRbSpy).Parse(context.Background(), &profile.Meta{Tags: map[string]string{}}, nil)
+	require.Error(t, err)
+}
+
+func TestParseCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := NewRawProfile([]byte("foo;bar 1\n"), profile.FormatGroups).Parse(ctx, &profile.Meta{Tags: map[string]string{}}, nil)
+	require.ErrorIs(t, err, context.Canceled)
+	_, err = NewRawProfile([]byte("<main> - app.rb:3 1\n"), profile.FormatRbSpy).Parse(ctx, &profile.Meta{Tags: map[string]string{}}, nil)
+	require.ErrorIs(t, err, context.Canceled)
+}
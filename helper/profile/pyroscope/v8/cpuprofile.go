@@ -0,0 +1,196 @@
+package v8
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/alibaba/ilogtail/helper/profile"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+const valueTypeCPU = "cpu"
+
+type callFrame struct {
+	FunctionName string `json:"functionName"`
+	URL          string `json:"url"`
+	LineNumber   int    `json:"lineNumber"`
+}
+
+type node struct {
+	ID        int       `json:"id"`
+	CallFrame callFrame `json:"callFrame"`
+	HitCount  int64     `json:"hitCount"`
+	Children  []int     `json:"children"`
+	Parent    int       `json:"parent"`
+}
+
+// cpuProfile is the CPU profile of the V8 profiler, which is returned by the Profiler.stop method of the inspector
+// protocol, and saved as the .cpuprofile file by Chrome DevTools and Node.js.
+type cpuProfile struct {
+	Nodes      []*node `json:"nodes"`
+	StartTime  int64   `json:"startTime"`
+	EndTime    int64   `json:"endTime"`
+	Samples    []int   `json:"samples"`
+	TimeDeltas []int64 `json:"timeDeltas"`
+}
+
+// RawProfile is the V8 CPU profile, the samples of which are converted to the cpu profile in nanoseconds by the time
+// deltas between the samples, or in samples by the hit counts of the nodes if the samples are absent.
+type RawProfile struct {
+	RawData []byte
+
+	logs []*protocol.Log // v1 result
+}
+
+func NewRawProfile(data []byte) *RawProfile {
+	return &RawProfile{
+		RawData: data,
+	}
+}
+
+func (r *RawProfile) Parse(ctx context.Context, meta *profile.Meta, tags map[string]string) (logs []*protocol.Log, err error) {
+	if err = r.parseCPUProfile(ctx, meta, profile.ExtractProfileV1(meta, tags, &r.logs)); err != nil {
+		return nil, err
+	}
+	logs = r.logs
+	r.logs = nil
+	return
+}
+
+func (r *RawProfile) parseCPUProfile(ctx context.Context, meta *profile.Meta, cb profile.BatchCallbackFunc) error {
+	var p cpuProfile
+	if err := json.NewDecoder(profile.NewContextReader(ctx, bytes.NewReader(r.RawData))).Decode(&p); err != nil {
+		return fmt.Errorf("unable to parse V8 CPU profile: %w", err)
+	}
+	if len(p.Nodes) == 0 {
+		return fmt.Errorf("unable to parse V8 CPU profile: no nodes")
+	}
+	nodes := make(map[int]*node, len(p.Nodes))
+	parents := make(map[int]int, len(p.Nodes))
+	for i, n := range p.Nodes {
+		if err := profile.Canceled(ctx, i); err != nil {
+			return err
+		}
+		nodes[n.ID] = n
+		if n.Parent != 0 {
+			parents[n.ID] = n.Parent
+		}
+	}
+	for i, n := range p.Nodes {
+		if err := profile.Canceled(ctx, i); err != nil {
+			return err
+		}
+		for _, c := range n.Children {
+			parents[c] = n.ID
+		}
+	}
+
+	values := make(map[int]uint64)
+	units := profile.NanosecondsUnit
+	if len(p.Samples) > 0 {
+		// the time delta of a sample is the time since the previous sample, so the duration of a sample is the time
+		// delta of the next one, and the last sample lasts until the end of the profile
+		timestamp := p.StartTime
+		for i, id := range p.Samples {
+			if err := profile.Canceled(ctx, i); err != nil {
+				return err
+			}
+			if i < len(p.TimeDeltas) {
+				timestamp += p.TimeDeltas[i]
+			}
+			var duration int64
+			switch {
+			case i+1 < len(p.TimeDeltas):
+				duration = p.TimeDeltas[i+1]
+			case p.EndTime > timestamp:
+				duration = p.EndTime - timestamp
+			}
+			if duration > 0 {
+				values[id] += uint64(duration) * 1000
+			}
+		}
+	} else {
+		units = profile.SamplesUnits
+		for i, n := range p.Nodes {
+			if err := profile.Canceled(ctx, i); err != nil {
+				return err
+			}
+			if n.HitCount > 0 {
+				values[n.ID] += uint64(n.HitCount)
+			}
+		}
+	}
+
+	records := make([]profile.StackRecord, 0, len(values))
+	merged := make(map[uint64]int)
+	for _, n := range p.Nodes {
+		// the frames of each node are walked up to the root, so the check is per node
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		v, ok := values[n.ID]
+		if !ok || v == 0 {
+			continue
+		}
+		var frames []string
+		for id, depth := n.ID, 0; depth < len(p.Nodes); depth++ {
+			cur, ok := nodes[id]
+			if !ok {
+				break
+			}
+			if cur.CallFrame.FunctionName != "(root)" {
+				frames = append(frames, formatFrame(&cur.CallFrame))
+			}
+			if id, ok = parents[id]; !ok {
+				break
+			}
+		}
+		if len(frames) == 0 {
+			continue
+		}
+		// the frames are from the leaf to the root
+		id := xxhash.Sum64String(strings.Join(frames, ""))
+		if i, ok := merged[id]; ok {
+			records[i].Vals[0] += v
+			continue
+		}
+		merged[id] = len(records)
+		labels := make(map[string]string, len(meta.Tags))
+		for k, v := range meta.Tags {
+			labels[k] = v
+		}
+		records = append(records, profile.StackRecord{
+			ID:        id,
+			Stack:     &profile.Stack{Name: frames[0], Stack: frames[1:]},
+			Vals:      []uint64{v},
+			Types:     []string{valueTypeCPU},
+			Units:     []string{string(units)},
+			Aggs:      []string{string(meta.AggregationType)},
+			StartTime: meta.StartTime.UnixNano(),
+			EndTime:   meta.EndTime.UnixNano(),
+			Labels:    labels,
+		})
+	}
+	if len(records) > 0 {
+		cb(records)
+	}
+	return nil
+}
+
+// formatFrame formats the call frame as "function url:line", the line number of which is 1-based.
+func formatFrame(f *callFrame) string {
+	name := f.FunctionName
+	if name == "" {
+		name = "(anonymous)"
+	}
+	if f.URL == "" {
+		return name
+	}
+	return name + " " + f.URL + ":" + strconv.Itoa(f.LineNumber+1)
+}
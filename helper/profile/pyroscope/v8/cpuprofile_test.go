@@ -0,0 +1,76 @@
+package v8
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/helper/profile"
+	"github.com/alibaba/ilogtail/plugins/test"
+)
+
+const cpuProfileJSON = `{
+  "nodes": [
+    {"id": 1, "callFrame": {"functionName": "(root)", "scriptId": "0", "url": "", "lineNumber": -1, "columnNumber": -1}, "hitCount": 0, "children": [2, 4]},
+    {"id": 2, "callFrame": {"functionName": "handle", "scriptId": "1", "url": "file:///app/server.js", "lineNumber": 9, "columnNumber": 2}, "hitCount": 1, "children": [3]},
+    {"id": 3, "callFrame": {"functionName": "", "scriptId": "1", "url": "file:///app/server.js", "lineNumber": 19, "columnNumber": 4}, "hitCount": 2},
+    {"id": 4, "callFrame": {"functionName": "(garbage collector)", "scriptId": "0", "url": "", "lineNumber": -1, "columnNumber": -1}, "hitCount": 1}
+  ],
+  "startTime": 1000,
+  "endTime": 5000,
+  "samples": [3, 2, 3, 4],
+  "timeDeltas": [100, 1000, 500, 1000]
+}`
+
+func parse(t *testing.T, data string) map[string]string {
+	r := NewRawProfile([]byte(data))
+	logs, err := r.Parse(context.Background(), &profile.Meta{
+		Tags:            map[string]string{"__name__": "web"},
+		SpyName:         profile.PyroscopeNodeJs,
+		StartTime:       time.Unix(1680000000, 0),
+		EndTime:         time.Unix(1680000010, 0),
+		AggregationType: profile.SumAggType,
+	}, map[string]string{"cluster": "c1"})
+	require.NoError(t, err)
+	values := make(map[string]string)
+	for _, log := range logs {
+		require.Equal(t, "cpu", test.ReadLogVal(log, "valueTypes"))
+		require.Equal(t, "profile_cpu", test.ReadLogVal(log, "type"))
+		require.Equal(t, "{\"__name__\":\"web\",\"cluster\":\"c1\"}", test.ReadLogVal(log, "labels"))
+		values[test.ReadLogVal(log, "name")+"|"+test.ReadLogVal(log, "stack")+"|"+test.ReadLogVal(log, "units")] = test.ReadLogVal(log, "val")
+	}
+	return values
+}
+
+func TestParseCPUProfile(t *testing.T) {
+	// the samples at 1100, 2100, 2600 and 3600 last until the next ones or the end
+	require.Equal(t, map[string]string{
+		"(anonymous) file:///app/server.js:20|handle file:///app/server.js:10|nanoseconds": "2000000.00",
+		"handle file:///app/server.js:10||nanoseconds":                                     "500000.00",
+		"(garbage collector)||nanoseconds":                                                 "1400000.00",
+	}, parse(t, cpuProfileJSON))
+
+	// the hit counts without the samples
+	require.Equal(t, map[string]string{
+		"(anonymous) file:///app/server.js:20|handle file:///app/server.js:10|samples": "2.00",
+		"handle file:///app/server.js:10||samples":                                     "1.00",
+		"(garbage collector)||samples":                                                 "1.00",
+	}, parse(t, `{"nodes": [
+    {"id": 1, "callFrame": {"functionName": "(root)", "url": "", "lineNumber": -1}, "children": [2, 4]},
+    {"id": 2, "callFrame": {"functionName": "handle", "url": "file:///app/server.js", "lineNumber": 9}, "hitCount": 1, "children": [3]},
+    {"id": 3, "callFrame": {"functionName": "", "url": "file:///app/server.js", "lineNumber": 19}, "hitCount": 2},
+    {"id": 4, "callFrame": {"functionName": "(garbage collector)", "url": "", "lineNumber": -1}, "hitCount": 1}
+  ]}`))
+
+	_, err := NewRawProfile([]byte(`{"nodes": []}`)).Parse(context.Background(), &profile.Meta{Tags: map[string]string{}}, nil)
+	require.Error(t, err)
+}
+
+func TestParseCPUProfileCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := NewRawProfile([]byte(cpuProfileJSON)).Parse(ctx, &profile.Meta{Tags: map[string]string{}}, nil)
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,188 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helper
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// The framings of the records in a stream.
+const (
+	// FramingDelimiter separates the records by a delimiter or a regex separator.
+	FramingDelimiter = "delimiter"
+	// FramingOctetCounting prefixes each record with its length in ASCII decimal and a space, see RFC 6587.
+	FramingOctetCounting = "octet_counting"
+	// FramingUint32Length prefixes each record with its length in 4 bytes big-endian.
+	FramingUint32Length = "uint32_length"
+	// FramingVarintLength prefixes each record with its length in varint, such as the delimited protobuf messages.
+	FramingVarintLength = "varint_length"
+)
+
+// maxOctetCountingDigits is the max digits of the length of the octet counting framing.
+const maxOctetCountingDigits = 10
+
+var ErrInvalidFrame = errors.New("invalid frame header")
+
+// ErrFrameTooLarge is returned when a record is larger than the MaxFrameSize.
+var ErrFrameTooLarge = errors.New("frame too large")
+
+// RecordSplitterConfig is the config to split a stream into records.
+type RecordSplitterConfig struct {
+	Framing        string // ["", delimiter, octet_counting, uint32_length, varint_length], empty means delimiter.
+	Delimiter      string // The delimiter of the delimiter framing, "\n" by default and the trailing '\r' is dropped.
+	DelimiterRegex string // The regex separator of the delimiter framing, takes precedence over Delimiter.
+	// MaxFrameSize is the max size of a record, 0 means unlimited. The length prefix larger than it is rejected
+	// before the record is read, so a broken or malicious header cannot make the reader buffer a huge record.
+	MaxFrameSize int
+}
+
+// RecordSplitter splits a stream into records, its Split method is a bufio.SplitFunc.
+type RecordSplitter struct {
+	framing   string
+	delimiter []byte
+	regex     *regexp.Regexp
+	trimCR    bool
+	maxSize   int
+}
+
+// NewRecordSplitter creates a RecordSplitter with the config.
+func NewRecordSplitter(config RecordSplitterConfig) (*RecordSplitter, error) {
+	if config.MaxFrameSize < 0 {
+		return nil, fmt.Errorf("invalid max frame size %d", config.MaxFrameSize)
+	}
+	s := &RecordSplitter{framing: config.Framing, maxSize: config.MaxFrameSize}
+	switch config.Framing {
+	case "", FramingDelimiter:
+		s.framing = FramingDelimiter
+		switch {
+		case config.DelimiterRegex != "":
+			reg, err := regexp.Compile(config.DelimiterRegex)
+			if err != nil {
+				return nil, fmt.Errorf("invalid delimiter regex %q: %v", config.DelimiterRegex, err)
+			}
+			if reg.MatchString("") {
+				return nil, fmt.Errorf("delimiter regex %q matches the empty string", config.DelimiterRegex)
+			}
+			s.regex = reg
+		case config.Delimiter != "":
+			s.delimiter = []byte(config.Delimiter)
+		default:
+			s.delimiter = []byte{'\n'}
+			s.trimCR = true
+		}
+	case FramingOctetCounting, FramingUint32Length, FramingVarintLength:
+	default:
+		return nil, fmt.Errorf("unsupported framing %q", config.Framing)
+	}
+	return s, nil
+}
+
+// Split returns the next record of data, it waits for more data when the record is incomplete and
+// returns the rest of data as the last record at EOF. ErrFrameTooLarge is returned for the record
+// larger than the MaxFrameSize.
+func (s *RecordSplitter) Split(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	switch s.framing {
+	case FramingOctetCounting:
+		return s.splitOctetCounting(data, atEOF)
+	case FramingUint32Length:
+		if len(data) < 4 {
+			return s.needMore(atEOF)
+		}
+		return s.splitFrame(data, 4, uint64(binary.BigEndian.Uint32(data)), atEOF)
+	case FramingVarintLength:
+		length, n := binary.Uvarint(data)
+		if n < 0 {
+			return 0, nil, ErrInvalidFrame
+		}
+		if n == 0 {
+			return s.needMore(atEOF)
+		}
+		return s.splitFrame(data, n, length, atEOF)
+	}
+	if s.regex != nil {
+		// a match reaching the end of data may go on matching the coming data, so wait for more.
+		if loc := s.regex.FindIndex(data); loc != nil && (loc[1] < len(data) || atEOF) {
+			return s.record(loc[1], data[:loc[0]])
+		}
+	} else if i := bytes.Index(data, s.delimiter); i >= 0 {
+		return s.record(i+len(s.delimiter), s.trim(data[:i]))
+	}
+	if atEOF {
+		return s.record(len(data), s.trim(data))
+	}
+	if s.maxSize > 0 && len(data) > s.maxSize {
+		return 0, nil, ErrFrameTooLarge
+	}
+	return 0, nil, nil
+}
+
+func (s *RecordSplitter) record(advance int, record []byte) (int, []byte, error) {
+	if s.maxSize > 0 && len(record) > s.maxSize {
+		return 0, nil, ErrFrameTooLarge
+	}
+	return advance, record, nil
+}
+
+func (s *RecordSplitter) splitOctetCounting(data []byte, atEOF bool) (int, []byte, error) {
+	sp := bytes.IndexByte(data, ' ')
+	if sp < 0 {
+		if len(data) > maxOctetCountingDigits {
+			return 0, nil, ErrInvalidFrame
+		}
+		return s.needMore(atEOF)
+	}
+	if sp == 0 || sp > maxOctetCountingDigits {
+		return 0, nil, ErrInvalidFrame
+	}
+	length, err := strconv.ParseUint(string(data[:sp]), 10, 64)
+	if err != nil {
+		return 0, nil, ErrInvalidFrame
+	}
+	return s.splitFrame(data, sp+1, length, atEOF)
+}
+
+func (s *RecordSplitter) splitFrame(data []byte, headerSize int, length uint64, atEOF bool) (int, []byte, error) {
+	if s.maxSize > 0 && length > uint64(s.maxSize) {
+		return 0, nil, ErrFrameTooLarge
+	}
+	if length > uint64(len(data)-headerSize) {
+		return s.needMore(atEOF)
+	}
+	end := headerSize + int(length)
+	return end, data[headerSize:end], nil
+}
+
+func (s *RecordSplitter) needMore(atEOF bool) (int, []byte, error) {
+	if atEOF {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	return 0, nil, nil
+}
+
+func (s *RecordSplitter) trim(record []byte) []byte {
+	if s.trimCR && len(record) > 0 && record[len(record)-1] == '\r' {
+		return record[:len(record)-1]
+	}
+	return record
+}
@@ -0,0 +1,129 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helper
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scanRecords(t *testing.T, config RecordSplitterConfig, data []byte) ([]string, error) {
+	splitter, err := NewRecordSplitter(config)
+	require.NoError(t, err)
+	// read one byte a time to check the incomplete records are waited for.
+	scanner := bufio.NewScanner(&oneByteReader{data: data})
+	scanner.Split(splitter.Split)
+	var records []string
+	for scanner.Scan() {
+		records = append(records, scanner.Text())
+	}
+	return records, scanner.Err()
+}
+
+type oneByteReader struct {
+	data []byte
+}
+
+func (r *oneByteReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	p[0] = r.data[0]
+	r.data = r.data[1:]
+	return 1, nil
+}
+
+func TestRecordSplitterDelimiter(t *testing.T) {
+	records, err := scanRecords(t, RecordSplitterConfig{}, []byte("a\r\nb\n\nc"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "", "c"}, records)
+
+	records, err = scanRecords(t, RecordSplitterConfig{Delimiter: "\x1e\n"}, []byte("a\nb\x1e\nc\r\x1e\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a\nb", "c\r"}, records)
+
+	records, err = scanRecords(t, RecordSplitterConfig{DelimiterRegex: `\n-{3,}\n`}, []byte("a\n---\nb\nc\n-----\nd"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b\nc", "d"}, records)
+}
+
+func TestRecordSplitterLengthPrefixed(t *testing.T) {
+	records, err := scanRecords(t, RecordSplitterConfig{Framing: FramingOctetCounting}, []byte("5 a\nb c3 def0 "))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a\nb c", "def", ""}, records)
+
+	var stream bytes.Buffer
+	for _, record := range []string{"hello", strings.Repeat("x", 300)} {
+		_ = binary.Write(&stream, binary.BigEndian, uint32(len(record)))
+		stream.WriteString(record)
+	}
+	records, err = scanRecords(t, RecordSplitterConfig{Framing: FramingUint32Length}, stream.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hello", strings.Repeat("x", 300)}, records)
+
+	stream.Reset()
+	for _, record := range []string{"\x08\x96\x01", strings.Repeat("y", 200)} {
+		header := make([]byte, binary.MaxVarintLen64)
+		stream.Write(header[:binary.PutUvarint(header, uint64(len(record)))])
+		stream.WriteString(record)
+	}
+	records, err = scanRecords(t, RecordSplitterConfig{Framing: FramingVarintLength}, stream.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"\x08\x96\x01", strings.Repeat("y", 200)}, records)
+}
+
+func TestRecordSplitterErrors(t *testing.T) {
+	_, err := scanRecords(t, RecordSplitterConfig{Framing: FramingOctetCounting}, []byte("3 abc<13> x"))
+	assert.ErrorIs(t, err, ErrInvalidFrame)
+
+	records, err := scanRecords(t, RecordSplitterConfig{Framing: FramingUint32Length}, []byte{0, 0, 0, 4, 'a', 'b'})
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Empty(t, records)
+
+	_, err = NewRecordSplitter(RecordSplitterConfig{DelimiterRegex: `\n*`})
+	assert.Error(t, err)
+	_, err = NewRecordSplitter(RecordSplitterConfig{Framing: "unknown"})
+	assert.Error(t, err)
+}
+
+func TestRecordSplitterMaxFrameSize(t *testing.T) {
+	// the huge length is rejected before the record is read
+	records, err := scanRecords(t, RecordSplitterConfig{Framing: FramingUint32Length, MaxFrameSize: 4},
+		[]byte{0, 0, 0, 2, 'a', 'b', 0xff, 0xff, 0xff, 0xff, 'c'})
+	assert.ErrorIs(t, err, ErrFrameTooLarge)
+	assert.Equal(t, []string{"ab"}, records)
+
+	records, err = scanRecords(t, RecordSplitterConfig{Framing: FramingOctetCounting, MaxFrameSize: 4}, []byte("3 abc9999 x"))
+	assert.ErrorIs(t, err, ErrFrameTooLarge)
+	assert.Equal(t, []string{"abc"}, records)
+
+	records, err = scanRecords(t, RecordSplitterConfig{MaxFrameSize: 4}, []byte("abcd\nabcdef\n"))
+	assert.ErrorIs(t, err, ErrFrameTooLarge)
+	assert.Equal(t, []string{"abcd"}, records)
+
+	records, err = scanRecords(t, RecordSplitterConfig{DelimiterRegex: `\n+`, MaxFrameSize: 4}, []byte("ab\n\nabcdefgh"))
+	assert.ErrorIs(t, err, ErrFrameTooLarge)
+	assert.Equal(t, []string{"ab"}, records)
+
+	_, err = NewRecordSplitter(RecordSplitterConfig{MaxFrameSize: -1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,117 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helper
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxRedisBulkLen limits the size of a bulk string reply, e.g. the output of INFO, to avoid allocating by a broken reply.
+const maxRedisBulkLen = 64 << 20
+
+// ErrRedisProtocol is returned for a malformed RESP reply.
+var ErrRedisProtocol = errors.New("redis protocol error")
+
+// RedisError is the error reply of the server.
+type RedisError string
+
+func (e RedisError) Error() string {
+	return string(e)
+}
+
+// WriteRedisCommand writes the command in the RESP array of bulk strings.
+func WriteRedisCommand(w *bufio.Writer, args ...string) error {
+	_, _ = fmt.Fprintf(w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		_, _ = fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return w.Flush()
+}
+
+// ReadRedisReply reads a RESP reply, which is a string for the simple and the bulk strings, an int64 for the integers,
+// a []interface{} for the arrays, nil for the null replies, or a RedisError for the error replies.
+func ReadRedisReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, ErrRedisProtocol
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return RedisError(line[1:]), nil
+	case ':':
+		n, err := strconv.ParseInt(line[1:], 10, 64)
+		if err != nil {
+			return nil, ErrRedisProtocol
+		}
+		return n, nil
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n > maxRedisBulkLen {
+			return nil, ErrRedisProtocol
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err = io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, ErrRedisProtocol
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		array := make([]interface{}, 0, n)
+		for i := 0; i < n; i++ {
+			item, err := ReadRedisReply(r)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, item)
+		}
+		return array, nil
+	}
+	return nil, ErrRedisProtocol
+}
+
+// RedisDo sends the command and reads the reply, the error reply is returned as the error.
+func RedisDo(rw *bufio.ReadWriter, args ...string) (interface{}, error) {
+	if err := WriteRedisCommand(rw.Writer, args...); err != nil {
+		return nil, err
+	}
+	reply, err := ReadRedisReply(rw.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if e, ok := reply.(RedisError); ok {
+		return nil, e
+	}
+	return reply, nil
+}
@@ -0,0 +1,46 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helper
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisDo(t *testing.T) {
+	var out bytes.Buffer
+	in := strings.NewReader("*3\r\n$4\r\nname\r\n$-1\r\n:7\r\n-ERR unknown command\r\n+OK\r\n")
+	rw := bufio.NewReadWriter(bufio.NewReader(in), bufio.NewWriter(&out))
+
+	reply, err := RedisDo(rw, "HGETALL", "user:1")
+	require.NoError(t, err)
+	assert.Equal(t, "*2\r\n$7\r\nHGETALL\r\n$6\r\nuser:1\r\n", out.String())
+	assert.Equal(t, []interface{}{"name", nil, int64(7)}, reply)
+
+	_, err = RedisDo(rw, "FOO")
+	assert.Equal(t, RedisError("ERR unknown command"), err)
+
+	reply, err = RedisDo(rw, "PING")
+	require.NoError(t, err)
+	assert.Equal(t, "OK", reply)
+
+	_, err = ReadRedisReply(bufio.NewReader(strings.NewReader("?\r\n")))
+	assert.Equal(t, ErrRedisProtocol, err)
+}
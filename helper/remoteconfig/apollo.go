@@ -0,0 +1,151 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remoteconfig
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultApolloNamespace = "application"
+	defaultApolloCluster   = "default"
+)
+
+func init() {
+	Sources[ProviderApollo] = newApolloSource
+}
+
+// apolloSource reads the namespace of the Apollo application and watches it by the long polling notifications,
+// each key of the namespace is a pipeline config.
+type apolloSource struct {
+	config    *SourceConfig
+	client    *http.Client
+	namespace string
+	cluster   string
+
+	notificationID int64
+	last           *Snapshot
+}
+
+type apolloConfigs struct {
+	Configurations map[string]string `json:"configurations"`
+	ReleaseKey     string            `json:"releaseKey"`
+}
+
+type apolloNotification struct {
+	NamespaceName  string `json:"namespaceName"`
+	NotificationID int64  `json:"notificationId"`
+}
+
+func newApolloSource(config *SourceConfig, client *http.Client) (Source, error) {
+	if config.AppID == "" {
+		return nil, fmt.Errorf("the AppID of apollo is empty")
+	}
+	s := &apolloSource{config: config, client: client, namespace: config.Namespace, cluster: config.Cluster, notificationID: -1}
+	if s.namespace == "" {
+		s.namespace = defaultApolloNamespace
+	}
+	if s.cluster == "" {
+		s.cluster = defaultApolloCluster
+	}
+	return s, nil
+}
+
+func (s *apolloSource) Name() string {
+	return ProviderApollo
+}
+
+func (s *apolloSource) Watch(ctx context.Context, version string) (*Snapshot, error) {
+	if version != "" && s.last != nil && s.last.Version == version {
+		changed, err := s.notifications(ctx)
+		if err != nil || !changed {
+			return s.last, err
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	path := fmt.Sprintf("/configs/%s/%s/%s", url.PathEscape(s.config.AppID), url.PathEscape(s.cluster), url.PathEscape(s.namespace))
+	body, err := s.get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	var configs apolloConfigs
+	if err = json.Unmarshal(body, &configs); err != nil {
+		return nil, fmt.Errorf("invalid apollo configs: %v", err)
+	}
+	snapshot := &Snapshot{Version: configs.ReleaseKey, Configs: make(map[string]string), FetchTime: time.Now()}
+	for name, value := range configs.Configurations {
+		if value != "" {
+			snapshot.Configs[name] = value
+		}
+	}
+	s.last = snapshot
+	return snapshot, nil
+}
+
+// notifications waits the namespace to be released, and returns false if nothing is released in the timeout.
+func (s *apolloSource) notifications(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, longPollingTimeout+requestTimeout)
+	defer cancel()
+	notifications, err := json.Marshal([]apolloNotification{{NamespaceName: s.namespace, NotificationID: s.notificationID}})
+	if err != nil {
+		return false, err
+	}
+	query := url.Values{"appId": {s.config.AppID}, "cluster": {s.cluster}, "notifications": {string(notifications)}}
+	body, err := s.get(ctx, "/notifications/v2?"+query.Encode(), http.StatusNotModified)
+	if err != nil || body == nil {
+		return false, err
+	}
+	var result []apolloNotification
+	if err = json.Unmarshal(body, &result); err != nil {
+		return false, fmt.Errorf("invalid apollo notifications: %v", err)
+	}
+	for _, n := range result {
+		if n.NamespaceName == s.namespace {
+			s.notificationID = n.NotificationID
+		}
+	}
+	return true, nil
+}
+
+func (s *apolloSource) get(ctx context.Context, pathWithQuery string, ignored ...int) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.config.Address+pathWithQuery, nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.config.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)
+		req.Header.Set("Authorization", "Apollo "+s.config.AppID+":"+apolloSignature(timestamp, pathWithQuery, s.config.Secret))
+		req.Header.Set("Timestamp", timestamp)
+	}
+	body, _, err := doRequest(s.client, req, ignored...)
+	return body, err
+}
+
+// apolloSignature signs the request by the access key secret of the Apollo application.
+func apolloSignature(timestamp, pathWithQuery, secret string) string {
+	mac := hmac.New(sha1.New, []byte(secret))
+	_, _ = mac.Write([]byte(timestamp + "\n" + pathWithQuery))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
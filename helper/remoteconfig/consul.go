@@ -0,0 +1,89 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remoteconfig
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+func init() {
+	Sources[ProviderConsul] = newConsulSource
+}
+
+// consulSource reads the keys with the prefix by the blocking queries of the Consul KV API.
+type consulSource struct {
+	config *SourceConfig
+	client *http.Client
+}
+
+type consulKV struct {
+	Key   string
+	Value []byte // base64 encoded in json
+}
+
+func newConsulSource(config *SourceConfig, client *http.Client) (Source, error) {
+	return &consulSource{config: config, client: client}, nil
+}
+
+func (s *consulSource) Name() string {
+	return ProviderConsul
+}
+
+func (s *consulSource) Watch(ctx context.Context, version string) (*Snapshot, error) {
+	query := url.Values{}
+	query.Set("recurse", "true")
+	if version != "" {
+		query.Set("index", version)
+		query.Set("wait", fmt.Sprintf("%ds", int(longPollingTimeout.Seconds())))
+	}
+	ctx, cancel := context.WithTimeout(ctx, longPollingTimeout+requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.config.Address+"/v1/kv/"+strings.TrimPrefix(s.config.Prefix, "/")+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.config.Token != "" {
+		req.Header.Set("X-Consul-Token", s.config.Token)
+	}
+	// 404 means no keys with the prefix
+	body, resp, err := doRequest(s.client, req, http.StatusNotFound)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{Version: resp.Header.Get("X-Consul-Index"), Configs: make(map[string]string), FetchTime: time.Now()}
+	if body == nil {
+		return snapshot, nil
+	}
+	var kvs []consulKV
+	if err = json.Unmarshal(body, &kvs); err != nil {
+		return nil, fmt.Errorf("invalid consul response: %v", err)
+	}
+	prefix := strings.TrimPrefix(s.config.Prefix, "/")
+	for _, kv := range kvs {
+		name := strings.TrimPrefix(kv.Key, prefix)
+		// skip the folders
+		if name == "" || strings.HasSuffix(name, "/") || len(kv.Value) == 0 {
+			continue
+		}
+		snapshot.Configs[name] = string(kv.Value)
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,317 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package remoteconfig watches the pipeline configs stored in the external config systems, such as Apollo,
+// Nacos, Consul and etcd, and applies them to the agent, with the applied versions kept for pinning and
+// rolling back.
+package remoteconfig
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	defaultHistorySize = 10
+	retryInterval      = time.Second * 10
+)
+
+// Config is a pipeline config read from the remote source.
+type Config struct {
+	Source  string
+	Name    string
+	JSONStr string
+}
+
+// Key returns the unique name of the config.
+func (c *Config) Key() string {
+	return c.Source + "/" + c.Name
+}
+
+// Snapshot is the pipeline configs of a source at a version, keyed by the config name.
+type Snapshot struct {
+	Version   string
+	Configs   map[string]string
+	FetchTime time.Time
+}
+
+func (s *Snapshot) sameConfigs(o *Snapshot) bool {
+	if len(s.Configs) != len(o.Configs) {
+		return false
+	}
+	for name, jsonStr := range s.Configs {
+		if v, ok := o.Configs[name]; !ok || v != jsonStr {
+			return false
+		}
+	}
+	return true
+}
+
+// Source reads the pipeline configs from an external config system.
+type Source interface {
+	// Name identifies the source in the config keys.
+	Name() string
+	// Watch returns the snapshot once it differs from @version, which is returned immediately if @version is
+	// empty. The unchanged snapshot could be returned when the long polling times out.
+	Watch(ctx context.Context, version string) (*Snapshot, error)
+}
+
+// Applier replaces the running remote configs.
+type Applier interface {
+	// Apply replaces all the running remote configs with @configs, and returns the errors of the configs
+	// failed to load, keyed by Config.Key.
+	Apply(configs []*Config) map[string]error
+}
+
+// Status is the state of the Controller.
+type Status struct {
+	Source         string    `json:"source"`
+	AppliedVersion string    `json:"applied_version"`
+	LatestVersion  string    `json:"latest_version"`
+	Pinned         bool      `json:"pinned"`
+	History        []string  `json:"history"`
+	LastError      string    `json:"last_error,omitempty"`
+	LastErrorTime  time.Time `json:"last_error_time,omitempty"`
+}
+
+// Controller applies the snapshots of the source when they change. A snapshot failed to apply is rolled back
+// to the last applied one, and the pinned controller keeps the applied snapshot until it's unpinned.
+type Controller struct {
+	source      Source
+	applier     Applier
+	historySize int
+
+	mu            sync.Mutex
+	applied       *Snapshot
+	latest        *Snapshot
+	history       []*Snapshot // the applied snapshots, the latest is the last
+	pinned        bool
+	failedHash    string // the content hash of the snapshot failed to apply, which is skipped until it changes
+	lastError     string
+	lastErrorTime time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewController creates a Controller applying the configs of @source with @applier.
+func NewController(source Source, applier Applier) *Controller {
+	return &Controller{
+		source:      source,
+		applier:     applier,
+		historySize: defaultHistorySize,
+		done:        make(chan struct{}),
+	}
+}
+
+// Start starts watching and applying in background.
+func (c *Controller) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	go c.run(ctx)
+}
+
+// Stop stops the controller, the applied configs keep running.
+func (c *Controller) Stop() {
+	if c.cancel == nil {
+		return
+	}
+	c.cancel()
+	<-c.done
+}
+
+func (c *Controller) run(ctx context.Context) {
+	defer close(c.done)
+	version := ""
+	for {
+		snapshot, err := c.source.Watch(ctx, version)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.recordError(fmt.Errorf("watch %s error: %v", c.source.Name(), err))
+			select {
+			case <-time.After(retryInterval):
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+		version = snapshot.Version
+		c.update(snapshot)
+	}
+}
+
+// update applies @snapshot unless it's pinned or the configs are unchanged.
+func (c *Controller) update(snapshot *Snapshot) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.latest = snapshot
+	if c.pinned {
+		return
+	}
+	if c.applied != nil && c.applied.sameConfigs(snapshot) {
+		return
+	}
+	hash := contentVersion(snapshot.Configs)
+	if hash == c.failedHash {
+		return
+	}
+	if err := c.apply(snapshot); err != nil {
+		c.failedHash = hash
+		c.recordErrorLocked(err)
+		c.rollback()
+		return
+	}
+	c.failedHash = ""
+}
+
+// apply applies @snapshot and records it in the history if all the configs are loaded.
+func (c *Controller) apply(snapshot *Snapshot) error {
+	names := make([]string, 0, len(snapshot.Configs))
+	for name := range snapshot.Configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	configs := make([]*Config, 0, len(names))
+	for _, name := range names {
+		configs = append(configs, &Config{Source: c.source.Name(), Name: name, JSONStr: snapshot.Configs[name]})
+	}
+	logger.Info(context.Background(), "apply remote configs, source", c.source.Name(), "version", snapshot.Version, "count", len(configs))
+	errs := c.applier.Apply(configs)
+	if len(errs) != 0 {
+		keys := make([]string, 0, len(errs))
+		for key := range errs {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return fmt.Errorf("apply version %s of %s error, %s: %v", snapshot.Version, c.source.Name(), keys[0], errs[keys[0]])
+	}
+	c.applied = snapshot
+	for i, s := range c.history {
+		if s == snapshot {
+			c.history = append(c.history[:i], c.history[i+1:]...)
+			break
+		}
+	}
+	c.history = append(c.history, snapshot)
+	if len(c.history) > c.historySize {
+		c.history = c.history[len(c.history)-c.historySize:]
+	}
+	return nil
+}
+
+// rollback reapplies the last applied snapshot after a snapshot failed to apply.
+func (c *Controller) rollback() {
+	if c.applied == nil {
+		return
+	}
+	logger.Info(context.Background(), "roll back remote configs, source", c.source.Name(), "version", c.applied.Version)
+	if err := c.apply(c.applied); err != nil {
+		c.recordErrorLocked(fmt.Errorf("roll back error: %v", err))
+	}
+}
+
+// Pin keeps the applied snapshot, the changes of the source are not applied until Unpin.
+func (c *Controller) Pin() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pinned = true
+}
+
+// Unpin applies the latest snapshot of the source and follows the changes again.
+func (c *Controller) Unpin() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pinned = false
+	if c.latest == nil || (c.applied != nil && c.applied.sameConfigs(c.latest)) {
+		return nil
+	}
+	if err := c.apply(c.latest); err != nil {
+		c.recordErrorLocked(err)
+		c.rollback()
+		return err
+	}
+	return nil
+}
+
+// Rollback applies the snapshot of @version in the history and pins it, the snapshot before the applied one
+// is used if @version is empty.
+func (c *Controller) Rollback(version string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var target *Snapshot
+	if version == "" {
+		if len(c.history) >= 2 {
+			target = c.history[len(c.history)-2]
+		}
+	} else {
+		for _, s := range c.history {
+			if s.Version == version {
+				target = s
+			}
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("version %q is not in the history", version)
+	}
+	if err := c.apply(target); err != nil {
+		c.recordErrorLocked(err)
+		c.rollback()
+		return err
+	}
+	c.pinned = true
+	return nil
+}
+
+// Status returns the state of the controller.
+func (c *Controller) Status() *Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status := &Status{
+		Source:        c.source.Name(),
+		Pinned:        c.pinned,
+		History:       make([]string, 0, len(c.history)),
+		LastError:     c.lastError,
+		LastErrorTime: c.lastErrorTime,
+	}
+	if c.applied != nil {
+		status.AppliedVersion = c.applied.Version
+	}
+	if c.latest != nil {
+		status.LatestVersion = c.latest.Version
+	}
+	for _, s := range c.history {
+		status.History = append(status.History, s.Version)
+	}
+	return status
+}
+
+func (c *Controller) recordError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recordErrorLocked(err)
+}
+
+func (c *Controller) recordErrorLocked(err error) {
+	c.lastError = err.Error()
+	c.lastErrorTime = time.Now()
+	logger.Warning(context.Background(), util.AlarmRemoteConfig, "source", c.source.Name(), "error", err)
+}
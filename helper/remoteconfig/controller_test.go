@@ -0,0 +1,122 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remoteconfig
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeApplier struct {
+	applied [][]*Config
+	// the config keys failed to load
+	failed map[string]bool
+}
+
+func (a *fakeApplier) Apply(configs []*Config) map[string]error {
+	a.applied = append(a.applied, configs)
+	errs := make(map[string]error)
+	for _, cfg := range configs {
+		if a.failed[cfg.Key()] {
+			errs[cfg.Key()] = errors.New("invalid config")
+		}
+	}
+	return errs
+}
+
+func (a *fakeApplier) last() map[string]string {
+	configs := make(map[string]string)
+	for _, cfg := range a.applied[len(a.applied)-1] {
+		configs[cfg.Name] = cfg.JSONStr
+	}
+	return configs
+}
+
+type fakeSource struct {
+	snapshots chan *Snapshot
+}
+
+func (s *fakeSource) Name() string {
+	return "fake"
+}
+
+func (s *fakeSource) Watch(ctx context.Context, version string) (*Snapshot, error) {
+	select {
+	case snapshot := <-s.snapshots:
+		return snapshot, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func newSnapshot(version string, configs map[string]string) *Snapshot {
+	return &Snapshot{Version: version, Configs: configs, FetchTime: time.Now()}
+}
+
+func TestControllerApplyAndRollback(t *testing.T) {
+	applier := &fakeApplier{failed: map[string]bool{"fake/bad": true}}
+	c := NewController(&fakeSource{}, applier)
+
+	c.update(newSnapshot("1", map[string]string{"a": "a1"}))
+	c.update(newSnapshot("2", map[string]string{"a": "a1"}))
+	require.Len(t, applier.applied, 1, "the unchanged configs should not be applied again")
+	c.update(newSnapshot("3", map[string]string{"a": "a3", "b": "b3"}))
+	assert.Equal(t, map[string]string{"a": "a3", "b": "b3"}, applier.last())
+	assert.Equal(t, "fake/a", applier.applied[1][0].Key())
+
+	// the failed version is rolled back
+	c.update(newSnapshot("4", map[string]string{"a": "a4", "bad": "{"}))
+	assert.Equal(t, map[string]string{"a": "a3", "b": "b3"}, applier.last())
+	status := c.Status()
+	assert.Equal(t, "3", status.AppliedVersion)
+	assert.Equal(t, "4", status.LatestVersion)
+	assert.Equal(t, []string{"1", "3"}, status.History)
+	assert.Contains(t, status.LastError, "apply version 4 of fake error, fake/bad: invalid config")
+	applies := len(applier.applied)
+	c.update(newSnapshot("4.1", map[string]string{"a": "a4", "bad": "{"}))
+	assert.Len(t, applier.applied, applies, "the failed configs should not be applied again until they change")
+
+	// roll back to the previous version and pin it
+	require.NoError(t, c.Rollback(""))
+	assert.Equal(t, map[string]string{"a": "a1"}, applier.last())
+	c.update(newSnapshot("5", map[string]string{"a": "a5"}))
+	assert.Equal(t, map[string]string{"a": "a1"}, applier.last(), "the pinned configs should not change")
+	status = c.Status()
+	assert.True(t, status.Pinned)
+	assert.Equal(t, "1", status.AppliedVersion)
+	assert.Equal(t, []string{"3", "1"}, status.History)
+	assert.Error(t, c.Rollback("4"), "the failed version is not in the history")
+
+	require.NoError(t, c.Unpin())
+	assert.Equal(t, map[string]string{"a": "a5"}, applier.last())
+	assert.False(t, c.Status().Pinned)
+}
+
+func TestControllerRun(t *testing.T) {
+	source := &fakeSource{snapshots: make(chan *Snapshot)}
+	applier := &fakeApplier{}
+	c := NewController(source, applier)
+	c.Start()
+	source.snapshots <- newSnapshot("1", map[string]string{"a": "a1"})
+	source.snapshots <- newSnapshot("2", map[string]string{"a": "a2"})
+	c.Stop()
+	require.Len(t, applier.applied, 2)
+	assert.Equal(t, map[string]string{"a": "a2"}, applier.last())
+}
@@ -0,0 +1,160 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remoteconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+func init() {
+	Sources[ProviderEtcd] = newEtcdSource
+}
+
+// etcdSource polls the keys with the prefix by the json gateway of the etcd v3 API.
+type etcdSource struct {
+	config   *SourceConfig
+	client   *http.Client
+	interval time.Duration
+	// token is reused by the polls until it's rejected, since the authentication is expensive for etcd.
+	token string
+}
+
+type etcdRangeResponse struct {
+	Kvs []struct {
+		Key   []byte `json:"key"`
+		Value []byte `json:"value"`
+	} `json:"kvs"`
+}
+
+func newEtcdSource(config *SourceConfig, client *http.Client) (Source, error) {
+	if config.Prefix == "" {
+		return nil, fmt.Errorf("the prefix of etcd is empty")
+	}
+	return &etcdSource{config: config, client: client, interval: config.pollInterval()}, nil
+}
+
+func (s *etcdSource) Name() string {
+	return ProviderEtcd
+}
+
+// Watch polls the keys until they change, the revision of the cluster changes on the writes of any key, so
+// the version is the content of the keys.
+func (s *etcdSource) Watch(ctx context.Context, version string) (*Snapshot, error) {
+	for {
+		snapshot, err := s.fetch(ctx)
+		if err != nil || snapshot.Version != version {
+			return snapshot, err
+		}
+		if !sleepContext(ctx, s.interval) {
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (s *etcdSource) fetch(ctx context.Context) (*Snapshot, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	body := map[string]string{
+		"key":       base64.StdEncoding.EncodeToString([]byte(s.config.Prefix)),
+		"range_end": base64.StdEncoding.EncodeToString(prefixRangeEnd([]byte(s.config.Prefix))),
+	}
+	var resp etcdRangeResponse
+	for retried := false; ; retried = true {
+		if s.config.Username != "" && s.token == "" {
+			if err := s.authenticate(ctx); err != nil {
+				return nil, err
+			}
+		}
+		status, err := s.post(ctx, "/v3/kv/range", body, s.token, &resp)
+		if err == nil {
+			break
+		}
+		if status != http.StatusUnauthorized || s.config.Username == "" || retried {
+			return nil, err
+		}
+		// the token expired
+		s.token = ""
+	}
+	snapshot := &Snapshot{Configs: make(map[string]string), FetchTime: time.Now()}
+	for _, kv := range resp.Kvs {
+		name := strings.TrimPrefix(string(kv.Key), s.config.Prefix)
+		if name == "" || len(kv.Value) == 0 {
+			continue
+		}
+		snapshot.Configs[name] = string(kv.Value)
+	}
+	snapshot.Version = contentVersion(snapshot.Configs)
+	return snapshot, nil
+}
+
+func (s *etcdSource) authenticate(ctx context.Context) error {
+	var auth struct {
+		Token string `json:"token"`
+	}
+	if _, err := s.post(ctx, "/v3/auth/authenticate", map[string]string{"name": s.config.Username, "password": s.config.Password}, "", &auth); err != nil {
+		return err
+	}
+	s.token = auth.Token
+	return nil
+}
+
+// post returns the status code of the response besides the error, which is 0 if no response is received.
+func (s *etcdSource) post(ctx context.Context, path string, body interface{}, token string, result interface{}) (int, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.Address+path, bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	respBody, resp, err := doRequest(s.client, req)
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	if err != nil {
+		return status, err
+	}
+	if err = json.Unmarshal(respBody, result); err != nil {
+		return status, fmt.Errorf("invalid etcd response of %s: %v", path, err)
+	}
+	return status, nil
+}
+
+// prefixRangeEnd returns the end of the range of the keys with @prefix, which is the prefix with the last
+// byte less than 0xff increased.
+func prefixRangeEnd(prefix []byte) []byte {
+	end := append([]byte(nil), prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// all the keys
+	return []byte{0}
+}
@@ -0,0 +1,165 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remoteconfig
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultNacosGroup = "DEFAULT_GROUP"
+
+func init() {
+	Sources[ProviderNacos] = newNacosSource
+}
+
+// nacosSource reads the DataIDs and watches them by the long polling listener of the Nacos open API.
+type nacosSource struct {
+	config *SourceConfig
+	client *http.Client
+	group  string
+	// the last snapshot, whose md5 of each DataID is sent to the listener
+	last *Snapshot
+}
+
+func newNacosSource(config *SourceConfig, client *http.Client) (Source, error) {
+	if len(config.DataIDs) == 0 {
+		return nil, fmt.Errorf("the DataIDs of nacos are empty")
+	}
+	group := config.Group
+	if group == "" {
+		group = defaultNacosGroup
+	}
+	return &nacosSource{config: config, client: client, group: group}, nil
+}
+
+func (s *nacosSource) Name() string {
+	return ProviderNacos
+}
+
+func (s *nacosSource) Watch(ctx context.Context, version string) (*Snapshot, error) {
+	token, err := s.login(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if version != "" && s.last != nil && s.last.Version == version {
+		changed, err := s.listen(ctx, token)
+		if err != nil || !changed {
+			return s.last, err
+		}
+	}
+	snapshot := &Snapshot{Configs: make(map[string]string), FetchTime: time.Now()}
+	for _, dataID := range s.config.DataIDs {
+		content, err := s.get(ctx, token, dataID)
+		if err != nil {
+			return nil, err
+		}
+		if content != "" {
+			snapshot.Configs[dataID] = content
+		}
+	}
+	snapshot.Version = contentVersion(snapshot.Configs)
+	s.last = snapshot
+	return snapshot, nil
+}
+
+// login returns the access token if the username is set.
+func (s *nacosSource) login(ctx context.Context) (string, error) {
+	if s.config.Username == "" {
+		return "", nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	form := url.Values{"username": {s.config.Username}, "password": {s.config.Password}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.Address+"/nacos/v1/auth/login", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	body, _, err := doRequest(s.client, req)
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		AccessToken string `json:"accessToken"`
+	}
+	if err = json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("invalid nacos login response: %v", err)
+	}
+	return result.AccessToken, nil
+}
+
+// get returns the content of @dataID, which is empty if it does not exist.
+func (s *nacosSource) get(ctx context.Context, token, dataID string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	query := url.Values{"dataId": {dataID}, "group": {s.group}}
+	if s.config.Namespace != "" {
+		query.Set("tenant", s.config.Namespace)
+	}
+	if token != "" {
+		query.Set("accessToken", token)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.config.Address+"/nacos/v1/cs/configs?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	body, _, err := doRequest(s.client, req, http.StatusNotFound)
+	return string(body), err
+}
+
+// listen waits the DataIDs to change by the long polling listener, and returns false if they're unchanged
+// in the timeout.
+func (s *nacosSource) listen(ctx context.Context, token string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, longPollingTimeout+requestTimeout)
+	defer cancel()
+	// each config is dataID^2group^2md5[^2tenant]^1
+	var b strings.Builder
+	for _, dataID := range s.config.DataIDs {
+		content, ok := s.last.Configs[dataID]
+		md5sum := ""
+		if ok {
+			md5sum = fmt.Sprintf("%x", md5.Sum([]byte(content))) //nolint:gosec
+		}
+		b.WriteString(dataID + "\x02" + s.group + "\x02" + md5sum)
+		if s.config.Namespace != "" {
+			b.WriteString("\x02" + s.config.Namespace)
+		}
+		b.WriteString("\x01")
+	}
+	form := url.Values{"Listening-Configs": {b.String()}}
+	path := s.config.Address + "/nacos/v1/cs/configs/listener"
+	if token != "" {
+		path += "?accessToken=" + url.QueryEscape(token)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Long-Pulling-Timeout", fmt.Sprintf("%d", longPollingTimeout.Milliseconds()))
+	body, _, err := doRequest(s.client, req)
+	if err != nil {
+		return false, err
+	}
+	// the changed configs are returned, nothing if timeout
+	return strings.TrimSpace(string(body)) != "", nil
+}
@@ -0,0 +1,168 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remoteconfig
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alibaba/ilogtail/pkg/tlscommon"
+)
+
+const (
+	ProviderApollo = "apollo"
+	ProviderNacos  = "nacos"
+	ProviderConsul = "consul"
+	ProviderEtcd   = "etcd"
+
+	defaultPollInterval = time.Second * 30
+	// the timeout of the long polling requests, which is shorter than the server side limits
+	longPollingTimeout = time.Second * 30
+	// the extra time to wait the long polling response
+	requestTimeout = time.Second * 10
+)
+
+// SourceConfig selects the remote source of the pipeline configs, which is read from the file of the
+// remote-config flag.
+type SourceConfig struct {
+	// Provider is one of apollo, nacos, consul and etcd.
+	Provider string
+	// Address is the address of the config system, such as http://127.0.0.1:8500.
+	Address string
+	// TLS connects the config system with TLS.
+	TLS *tlscommon.TLSConfig
+	// PollIntervalSec is the interval to read the etcd keys, 30 by default.
+	PollIntervalSec int
+
+	// Prefix is the key prefix of the configs in Consul and etcd, the key without the prefix is the config name.
+	Prefix string
+	// Token is the ACL token of Consul.
+	Token string
+	// Username and Password are the user of Nacos or etcd.
+	Username string
+	Password string
+
+	// Namespace is the tenant of Nacos, or the namespace of Apollo which is application by default.
+	Namespace string
+	// Group is the group of the Nacos DataIDs, DEFAULT_GROUP by default.
+	Group string
+	// DataIDs are the Nacos configs, each of which is a pipeline config named by the DataID.
+	DataIDs []string
+
+	// AppID and Cluster are the Apollo application, the cluster is default by default. Each key of the Apollo
+	// namespace is a pipeline config named by the key.
+	AppID   string
+	Cluster string
+	// Secret is the access key secret of the Apollo application. (optional)
+	Secret string
+}
+
+// SourceCreator creates the source with the config.
+type SourceCreator func(config *SourceConfig, client *http.Client) (Source, error)
+
+// Sources are the registered providers of the remote configs.
+var Sources = map[string]SourceCreator{}
+
+// NewSource creates the source selected by the config.
+func (c *SourceConfig) NewSource() (Source, error) {
+	creator, ok := Sources[c.Provider]
+	if !ok {
+		names := make([]string, 0, len(Sources))
+		for name := range Sources {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown remote config provider %q, which should be one of %v", c.Provider, names)
+	}
+	if c.Address == "" {
+		return nil, fmt.Errorf("the address of %s is empty", c.Provider)
+	}
+	c.Address = strings.TrimSuffix(c.Address, "/")
+	transport := &http.Transport{}
+	if t, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport = t.Clone()
+	}
+	if c.TLS != nil {
+		tlsConfig, err := c.TLS.LoadTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	return creator(c, &http.Client{Transport: transport})
+}
+
+func (c *SourceConfig) pollInterval() time.Duration {
+	if c.PollIntervalSec <= 0 {
+		return defaultPollInterval
+	}
+	return time.Duration(c.PollIntervalSec) * time.Second
+}
+
+// doRequest sends @req and returns the body if the status is 200, the body is nil if the status is in @ignored.
+func doRequest(client *http.Client, req *http.Request, ignored ...int) ([]byte, *http.Response, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	for _, code := range ignored {
+		if resp.StatusCode == code {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			return nil, resp, nil
+		}
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp, fmt.Errorf("%s %s status %d: %s", req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, resp, nil
+}
+
+// contentVersion is the version of the sources without one, which is the md5 of the configs.
+func contentVersion(configs map[string]string) string {
+	names := make([]string, 0, len(configs))
+	for name := range configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := md5.New() //nolint:gosec
+	for _, name := range names {
+		_, _ = io.WriteString(h, name)
+		_, _ = h.Write([]byte{0})
+		_, _ = io.WriteString(h, configs[name])
+		_, _ = h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// sleepContext waits @d, and returns false if @ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
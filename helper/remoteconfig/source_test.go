@@ -0,0 +1,182 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remoteconfig
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSource(t *testing.T, config *SourceConfig, handler http.HandlerFunc) Source {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	config.Address = server.URL + "/"
+	source, err := config.NewSource()
+	require.NoError(t, err)
+	return source
+}
+
+func TestNewSource(t *testing.T) {
+	_, err := (&SourceConfig{Provider: "zookeeper", Address: "http://127.0.0.1"}).NewSource()
+	assert.ErrorContains(t, err, "unknown remote config provider")
+	_, err = (&SourceConfig{Provider: ProviderConsul}).NewSource()
+	assert.ErrorContains(t, err, "address")
+	_, err = (&SourceConfig{Provider: ProviderNacos, Address: "http://127.0.0.1"}).NewSource()
+	assert.ErrorContains(t, err, "DataIDs")
+}
+
+func TestConsulSource(t *testing.T) {
+	index := "10"
+	source := newTestSource(t, &SourceConfig{Provider: ProviderConsul, Prefix: "ilogtail/", Token: "acl"}, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/kv/ilogtail/", r.URL.Path)
+		assert.Equal(t, "acl", r.Header.Get("X-Consul-Token"))
+		if r.URL.Query().Get("index") == "10" {
+			assert.Equal(t, "30s", r.URL.Query().Get("wait"))
+			index = "11"
+		}
+		w.Header().Set("X-Consul-Index", index)
+		_ = json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"Key": "ilogtail/", "Value": nil},
+			{"Key": "ilogtail/nginx", "Value": []byte(`{"inputs":[]}`)},
+			{"Key": "ilogtail/app/", "Value": nil},
+		})
+	})
+	snapshot, err := source.Watch(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, "10", snapshot.Version)
+	assert.Equal(t, map[string]string{"nginx": `{"inputs":[]}`}, snapshot.Configs)
+	snapshot, err = source.Watch(context.Background(), "10")
+	require.NoError(t, err)
+	assert.Equal(t, "11", snapshot.Version)
+}
+
+func TestEtcdSource(t *testing.T) {
+	value := `{"inputs":[]}`
+	auths, expired := 0, false
+	source := newTestSource(t, &SourceConfig{Provider: ProviderEtcd, Prefix: "/ilogtail/", Username: "root", Password: "pass", PollIntervalSec: 1},
+		func(w http.ResponseWriter, r *http.Request) {
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			switch r.URL.Path {
+			case "/v3/auth/authenticate":
+				assert.Equal(t, map[string]string{"name": "root", "password": "pass"}, body)
+				auths++
+				_, _ = w.Write([]byte(`{"token":"mock-token"}`))
+			case "/v3/kv/range":
+				assert.Equal(t, "mock-token", r.Header.Get("Authorization"))
+				if expired {
+					expired = false
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("/ilogtail/")), body["key"])
+				assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("/ilogtail0")), body["range_end"])
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{
+					"header": map[string]string{"revision": "5"},
+					"kvs":    []map[string][]byte{{"key": []byte("/ilogtail/nginx"), "value": []byte(value)}},
+				})
+				value = `{"inputs":[{"type":"metric_mock"}]}`
+			}
+		})
+	snapshot, err := source.Watch(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"nginx": `{"inputs":[]}`}, snapshot.Configs)
+	next, err := source.Watch(context.Background(), snapshot.Version)
+	require.NoError(t, err)
+	assert.NotEqual(t, snapshot.Version, next.Version)
+	assert.Equal(t, map[string]string{"nginx": `{"inputs":[{"type":"metric_mock"}]}`}, next.Configs)
+	assert.Equal(t, 1, auths, "the token should be reused")
+
+	expired = true
+	_, err = source.Watch(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, auths, "the expired token should be renewed")
+
+	assert.Equal(t, []byte{0}, prefixRangeEnd([]byte{0xff}))
+	assert.Equal(t, []byte("b"), prefixRangeEnd([]byte{'a', 0xff}))
+}
+
+func TestNacosSource(t *testing.T) {
+	listened := 0
+	source := newTestSource(t, &SourceConfig{Provider: ProviderNacos, Namespace: "dev", DataIDs: []string{"nginx", "missing"}},
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/nacos/v1/cs/configs":
+				assert.Equal(t, "DEFAULT_GROUP", r.URL.Query().Get("group"))
+				assert.Equal(t, "dev", r.URL.Query().Get("tenant"))
+				if r.URL.Query().Get("dataId") == "missing" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				_, _ = w.Write([]byte(`{"inputs":[]}`))
+			case "/nacos/v1/cs/configs/listener":
+				assert.Equal(t, "30000", r.Header.Get("Long-Pulling-Timeout"))
+				md5sum := fmt.Sprintf("%x", md5.Sum([]byte(`{"inputs":[]}`))) //nolint:gosec
+				assert.Equal(t, "nginx\x02DEFAULT_GROUP\x02"+md5sum+"\x02dev\x01missing\x02DEFAULT_GROUP\x02\x02dev\x01", r.FormValue("Listening-Configs"))
+				listened++
+				if listened > 1 {
+					_, _ = w.Write([]byte("nginx%02DEFAULT_GROUP%02dev%01\n"))
+				}
+			}
+		})
+	snapshot, err := source.Watch(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"nginx": `{"inputs":[]}`}, snapshot.Configs)
+	// timeout without changes
+	same, err := source.Watch(context.Background(), snapshot.Version)
+	require.NoError(t, err)
+	assert.Same(t, snapshot, same)
+	changed, err := source.Watch(context.Background(), snapshot.Version)
+	require.NoError(t, err)
+	assert.NotSame(t, snapshot, changed)
+	assert.Equal(t, 2, listened)
+}
+
+func TestApolloSource(t *testing.T) {
+	releaseKey := "r1"
+	source := newTestSource(t, &SourceConfig{Provider: ProviderApollo, AppID: "ilogtail", Secret: "mock-secret"}, func(w http.ResponseWriter, r *http.Request) {
+		timestamp := r.Header.Get("Timestamp")
+		assert.Equal(t, "Apollo ilogtail:"+apolloSignature(timestamp, r.URL.RequestURI(), "mock-secret"), r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/configs/ilogtail/default/application":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"configurations": map[string]string{"nginx": `{"inputs":[]}`, "empty": ""},
+				"releaseKey":     releaseKey,
+			})
+		case "/notifications/v2":
+			assert.Equal(t, "ilogtail", r.URL.Query().Get("appId"))
+			assert.JSONEq(t, `[{"namespaceName":"application","notificationId":-1}]`, r.URL.Query().Get("notifications"))
+			releaseKey = "r2"
+			_, _ = w.Write([]byte(`[{"namespaceName":"application","notificationId":7}]`))
+		}
+	})
+	snapshot, err := source.Watch(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, "r1", snapshot.Version)
+	assert.Equal(t, map[string]string{"nginx": `{"inputs":[]}`}, snapshot.Configs)
+	snapshot, err = source.Watch(context.Background(), "r1")
+	require.NoError(t, err)
+	assert.Equal(t, "r2", snapshot.Version)
+	assert.Equal(t, int64(7), source.(*apolloSource).notificationID)
+}
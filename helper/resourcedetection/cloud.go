@@ -0,0 +1,161 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcedetection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// The endpoints of the metadata services, which are replaced in tests.
+var (
+	ecsMetadataEndpoint   = "http://100.100.100.200"
+	ec2MetadataEndpoint   = "http://169.254.169.254"
+	gceMetadataEndpoint   = "http://metadata.google.internal"
+	azureMetadataEndpoint = "http://169.254.169.254"
+)
+
+// detectECS detects the Alibaba Cloud ECS instance. The token of the hardened mode is requested first, and the
+// metadata is requested without it if the token is unavailable, i.e. in the normal mode.
+func detectECS(ctx context.Context, client *metadataClient) (map[string]string, error) {
+	headers := map[string]string{}
+	token, err := client.get(ctx, http.MethodPut, ecsMetadataEndpoint+"/latest/api/token",
+		map[string]string{"X-aliyun-ecs-metadata-token-ttl-seconds": "60"})
+	if err == nil {
+		headers["X-aliyun-ecs-metadata-token"] = token
+	} else if ctx.Err() != nil {
+		return nil, err
+	}
+	attributes, err := client.getAll(ctx, ecsMetadataEndpoint+"/latest/meta-data/", map[string]string{
+		AttrHostID:                "instance-id",
+		AttrCloudRegion:           "region-id",
+		AttrCloudAvailabilityZone: "zone-id",
+		AttrHostType:              "instance/instance-type",
+		AttrCloudAccountID:        "owner-account-id",
+	}, headers)
+	if err != nil {
+		return nil, err
+	}
+	attributes[AttrCloudProvider] = "alibaba_cloud"
+	attributes[AttrCloudPlatform] = "alibaba_cloud_ecs"
+	return attributes, nil
+}
+
+// detectEC2 detects the AWS EC2 instance from the instance identity document, by the token of IMDSv2 if it's
+// available.
+func detectEC2(ctx context.Context, client *metadataClient) (map[string]string, error) {
+	headers := map[string]string{}
+	token, err := client.get(ctx, http.MethodPut, ec2MetadataEndpoint+"/latest/api/token",
+		map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": "60"})
+	if err == nil {
+		headers["X-aws-ec2-metadata-token"] = token
+	} else if ctx.Err() != nil {
+		return nil, err
+	}
+	body, err := client.get(ctx, http.MethodGet, ec2MetadataEndpoint+"/latest/dynamic/instance-identity/document", headers)
+	if err != nil {
+		return nil, err
+	}
+	var document struct {
+		AccountID        string `json:"accountId"`
+		Region           string `json:"region"`
+		AvailabilityZone string `json:"availabilityZone"`
+		InstanceID       string `json:"instanceId"`
+		InstanceType     string `json:"instanceType"`
+		ImageID          string `json:"imageId"`
+	}
+	if err = json.Unmarshal([]byte(body), &document); err != nil {
+		return nil, fmt.Errorf("invalid instance identity document of ec2: %v", err)
+	}
+	if document.InstanceID == "" {
+		return nil, fmt.Errorf("no instance id in the instance identity document of ec2")
+	}
+	return map[string]string{
+		AttrCloudProvider:         "aws",
+		AttrCloudPlatform:         "aws_ec2",
+		AttrCloudAccountID:        document.AccountID,
+		AttrCloudRegion:           document.Region,
+		AttrCloudAvailabilityZone: document.AvailabilityZone,
+		AttrHostID:                document.InstanceID,
+		AttrHostType:              document.InstanceType,
+		AttrHostImageID:           document.ImageID,
+	}, nil
+}
+
+// detectGCE detects the Google Compute Engine instance. The zone and the machine type are returned in the full
+// resource names, e.g. projects/123/zones/us-central1-a, and the region is the zone without the last part.
+func detectGCE(ctx context.Context, client *metadataClient) (map[string]string, error) {
+	attributes, err := client.getAll(ctx, gceMetadataEndpoint+"/computeMetadata/v1/", map[string]string{
+		AttrHostID:                "instance/id",
+		AttrHostName:              "instance/name",
+		AttrCloudAvailabilityZone: "instance/zone",
+		AttrHostType:              "instance/machine-type",
+		AttrCloudAccountID:        "project/project-id",
+	}, map[string]string{"Metadata-Flavor": "Google"})
+	if err != nil {
+		return nil, err
+	}
+	zone := lastSegment(attributes[AttrCloudAvailabilityZone])
+	attributes[AttrCloudAvailabilityZone] = zone
+	if i := strings.LastIndexByte(zone, '-'); i > 0 {
+		attributes[AttrCloudRegion] = zone[:i]
+	}
+	attributes[AttrHostType] = lastSegment(attributes[AttrHostType])
+	attributes[AttrCloudProvider] = "gcp"
+	attributes[AttrCloudPlatform] = "gcp_compute_engine"
+	return attributes, nil
+}
+
+// detectAzure detects the Azure virtual machine from the compute metadata of the instance.
+func detectAzure(ctx context.Context, client *metadataClient) (map[string]string, error) {
+	body, err := client.get(ctx, http.MethodGet, azureMetadataEndpoint+"/metadata/instance/compute?api-version=2021-02-01&format=json",
+		map[string]string{"Metadata": "true"})
+	if err != nil {
+		return nil, err
+	}
+	var compute struct {
+		Location          string `json:"location"`
+		Name              string `json:"name"`
+		VMID              string `json:"vmId"`
+		VMSize            string `json:"vmSize"`
+		SubscriptionID    string `json:"subscriptionId"`
+		ResourceGroupName string `json:"resourceGroupName"`
+		Zone              string `json:"zone"`
+	}
+	if err = json.Unmarshal([]byte(body), &compute); err != nil {
+		return nil, fmt.Errorf("invalid compute metadata of azure: %v", err)
+	}
+	if compute.VMID == "" {
+		return nil, fmt.Errorf("no vm id in the compute metadata of azure")
+	}
+	return map[string]string{
+		AttrCloudProvider:          "azure",
+		AttrCloudPlatform:          "azure_vm",
+		AttrCloudRegion:            compute.Location,
+		AttrCloudAvailabilityZone:  compute.Zone,
+		AttrCloudAccountID:         compute.SubscriptionID,
+		AttrHostID:                 compute.VMID,
+		AttrHostName:               compute.Name,
+		AttrHostType:               compute.VMSize,
+		"azure.resourcegroup.name": compute.ResourceGroupName,
+	}, nil
+}
+
+func lastSegment(name string) string {
+	return name[strings.LastIndexByte(name, '/')+1:]
+}
@@ -0,0 +1,151 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package resourcedetection detects the cloud resource the agent runs on from the instance metadata services,
+// like the resource detectors of OpenTelemetry. The attributes use the OpenTelemetry semantic conventions,
+// e.g. cloud.provider, cloud.region and host.id.
+package resourcedetection
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The attribute keys of the OpenTelemetry semantic conventions.
+const (
+	AttrCloudProvider         = "cloud.provider"
+	AttrCloudPlatform         = "cloud.platform"
+	AttrCloudRegion           = "cloud.region"
+	AttrCloudAvailabilityZone = "cloud.availability_zone"
+	AttrCloudAccountID        = "cloud.account.id"
+	AttrHostID                = "host.id"
+	AttrHostName              = "host.name"
+	AttrHostType              = "host.type"
+	AttrHostImageID           = "host.image.id"
+)
+
+// The names of the detectors.
+const (
+	DetectorECS   = "ecs"
+	DetectorEC2   = "ec2"
+	DetectorGCE   = "gce"
+	DetectorAzure = "azure"
+)
+
+// DefaultTimeout is the max time of a detector.
+const DefaultTimeout = 2 * time.Second
+
+// metadataHTTPClient requests the link-local metadata services directly, the proxies of the environment, e.g.
+// HTTP_PROXY, can't reach them.
+var metadataHTTPClient = &http.Client{Transport: &http.Transport{Proxy: nil}}
+
+// detectFunc queries the metadata service by the client, and returns the attributes of the resource.
+type detectFunc func(ctx context.Context, client *metadataClient) (map[string]string, error)
+
+// Detectors are the detectors by the name.
+var Detectors = map[string]detectFunc{
+	DetectorECS:   detectECS,
+	DetectorEC2:   detectEC2,
+	DetectorGCE:   detectGCE,
+	DetectorAzure: detectAzure,
+}
+
+// Detect runs the detectors concurrently, each of which times out after @timeout, and merges the attributes of
+// the detectors succeeded. The detectors listed first take precedence when they return the same attribute.
+// The errors of the failed detectors are returned by the name, which are expected when the agent isn't running
+// on the clouds of them.
+func Detect(names []string, timeout time.Duration) (map[string]string, map[string]error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	results := make([]map[string]string, len(names))
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		detect, ok := Detectors[name]
+		if !ok {
+			errs[i] = fmt.Errorf("unknown resource detector %s", name)
+			continue
+		}
+		wg.Add(1)
+		go func(i int, detect detectFunc) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			results[i], errs[i] = detect(ctx, &metadataClient{client: metadataHTTPClient})
+		}(i, detect)
+	}
+	wg.Wait()
+
+	attributes := make(map[string]string)
+	failures := make(map[string]error)
+	for i := len(names) - 1; i >= 0; i-- {
+		if errs[i] != nil {
+			failures[names[i]] = errs[i]
+			continue
+		}
+		for k, v := range results[i] {
+			if v != "" {
+				attributes[k] = v
+			}
+		}
+	}
+	return attributes, failures
+}
+
+type metadataClient struct {
+	client *http.Client
+}
+
+// get requests the metadata service, and returns the body of the response in 200.
+func (c *metadataClient) get(ctx context.Context, method string, url string, headers map[string]string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return "", err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("request %s error, unexpected status code %d", url, resp.StatusCode)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// getAll requests the paths under @endpoint, and returns the bodies by the attribute keys.
+func (c *metadataClient) getAll(ctx context.Context, endpoint string, paths map[string]string, headers map[string]string) (map[string]string, error) {
+	attributes := make(map[string]string, len(paths))
+	for key, path := range paths {
+		value, err := c.get(ctx, http.MethodGet, endpoint+path, headers)
+		if err != nil {
+			return nil, err
+		}
+		attributes[key] = value
+	}
+	return attributes, nil
+}
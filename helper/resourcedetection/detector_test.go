@@ -0,0 +1,166 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcedetection
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetadataServer(t *testing.T, header string, value string, responses map[string]string) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if header != "" && r.Header.Get(header) != value {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		path := r.URL.Path
+		if r.URL.RawQuery != "" {
+			path += "?" + r.URL.RawQuery
+		}
+		body, ok := responses[r.Method+" "+path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestDetectECS(t *testing.T) {
+	ecsMetadataEndpoint = newMetadataServer(t, "", "", map[string]string{
+		"PUT /latest/api/token":                        "token",
+		"GET /latest/meta-data/instance-id":            "i-bp1",
+		"GET /latest/meta-data/region-id":              "cn-hangzhou",
+		"GET /latest/meta-data/zone-id":                "cn-hangzhou-i",
+		"GET /latest/meta-data/instance/instance-type": "ecs.g6.large",
+		"GET /latest/meta-data/owner-account-id":       "1234",
+	})
+	tags, failures := Detect([]string{DetectorECS}, time.Second)
+	assert.Empty(t, failures)
+	assert.Equal(t, map[string]string{
+		AttrCloudProvider:         "alibaba_cloud",
+		AttrCloudPlatform:         "alibaba_cloud_ecs",
+		AttrHostID:                "i-bp1",
+		AttrCloudRegion:           "cn-hangzhou",
+		AttrCloudAvailabilityZone: "cn-hangzhou-i",
+		AttrHostType:              "ecs.g6.large",
+		AttrCloudAccountID:        "1234",
+	}, tags)
+}
+
+func TestDetectEC2(t *testing.T) {
+	ec2MetadataEndpoint = newMetadataServer(t, "", "", map[string]string{
+		"PUT /latest/api/token": "token",
+		"GET /latest/dynamic/instance-identity/document": `{"accountId": "5678", "region": "us-west-2",
+			"availabilityZone": "us-west-2b", "instanceId": "i-0a1", "instanceType": "t3.micro", "imageId": "ami-1"}`,
+	})
+	tags, failures := Detect([]string{DetectorEC2}, time.Second)
+	assert.Empty(t, failures)
+	assert.Equal(t, map[string]string{
+		AttrCloudProvider:         "aws",
+		AttrCloudPlatform:         "aws_ec2",
+		AttrCloudAccountID:        "5678",
+		AttrCloudRegion:           "us-west-2",
+		AttrCloudAvailabilityZone: "us-west-2b",
+		AttrHostID:                "i-0a1",
+		AttrHostType:              "t3.micro",
+		AttrHostImageID:           "ami-1",
+	}, tags)
+}
+
+func TestDetectGCE(t *testing.T) {
+	gceMetadataEndpoint = newMetadataServer(t, "Metadata-Flavor", "Google", map[string]string{
+		"GET /computeMetadata/v1/instance/id":           "42",
+		"GET /computeMetadata/v1/instance/name":         "vm-1",
+		"GET /computeMetadata/v1/instance/zone":         "projects/9/zones/us-central1-a",
+		"GET /computeMetadata/v1/instance/machine-type": "projects/9/machineTypes/e2-medium",
+		"GET /computeMetadata/v1/project/project-id":    "my-project",
+	})
+	tags, failures := Detect([]string{DetectorGCE}, time.Second)
+	assert.Empty(t, failures)
+	assert.Equal(t, map[string]string{
+		AttrCloudProvider:         "gcp",
+		AttrCloudPlatform:         "gcp_compute_engine",
+		AttrCloudAccountID:        "my-project",
+		AttrCloudRegion:           "us-central1",
+		AttrCloudAvailabilityZone: "us-central1-a",
+		AttrHostID:                "42",
+		AttrHostName:              "vm-1",
+		AttrHostType:              "e2-medium",
+	}, tags)
+}
+
+func TestDetectAzure(t *testing.T) {
+	azureMetadataEndpoint = newMetadataServer(t, "Metadata", "true", map[string]string{
+		"GET /metadata/instance/compute?api-version=2021-02-01&format=json": `{"location": "westeurope", "name": "vm-2",
+			"vmId": "uuid", "vmSize": "Standard_D2s_v3", "subscriptionId": "sub", "resourceGroupName": "rg", "zone": "1"}`,
+	})
+	tags, failures := Detect([]string{DetectorAzure}, time.Second)
+	assert.Empty(t, failures)
+	assert.Equal(t, map[string]string{
+		AttrCloudProvider:          "azure",
+		AttrCloudPlatform:          "azure_vm",
+		AttrCloudRegion:            "westeurope",
+		AttrCloudAvailabilityZone:  "1",
+		AttrCloudAccountID:         "sub",
+		AttrHostID:                 "uuid",
+		AttrHostName:               "vm-2",
+		AttrHostType:               "Standard_D2s_v3",
+		"azure.resourcegroup.name": "rg",
+	}, tags)
+}
+
+func TestDetectFailures(t *testing.T) {
+	// the metadata service without the token in the normal mode
+	ecsMetadataEndpoint = newMetadataServer(t, "", "", map[string]string{
+		"GET /latest/meta-data/instance-id":            "i-bp1",
+		"GET /latest/meta-data/region-id":              "cn-hangzhou",
+		"GET /latest/meta-data/zone-id":                "cn-hangzhou-i",
+		"GET /latest/meta-data/instance/instance-type": "ecs.g6.large",
+		"GET /latest/meta-data/owner-account-id":       "1234",
+	})
+	ec2MetadataEndpoint = newMetadataServer(t, "", "", map[string]string{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer slow.Close()
+	gceMetadataEndpoint = slow.URL
+
+	start := time.Now()
+	tags, failures := Detect([]string{DetectorECS, DetectorEC2, DetectorGCE, "unknown"}, 200*time.Millisecond)
+	assert.Less(t, time.Since(start), 2*time.Second)
+	require.Len(t, failures, 3)
+	assert.Contains(t, failures, DetectorEC2)
+	assert.Contains(t, failures, DetectorGCE)
+	assert.Contains(t, failures, "unknown")
+	assert.Equal(t, "alibaba_cloud", tags[AttrCloudProvider])
+	assert.Equal(t, "i-bp1", tags[AttrHostID])
+}
+
+func TestMetadataClientWithoutProxy(t *testing.T) {
+	transport, ok := metadataHTTPClient.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.Nil(t, transport.Proxy, "the metadata services should not be requested through the proxies")
+}
@@ -0,0 +1,138 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAndNext(t *testing.T) {
+	from := time.Date(2023, 2, 27, 10, 17, 42, 500, time.UTC) // Monday
+	for _, c := range []struct {
+		spec string
+		next string
+	}{
+		{"*/5 * * * *", "2023-02-27T10:20:00Z"},
+		{"30 */5 * * * *", "2023-02-27T10:20:30Z"},
+		{"* * * * * *", "2023-02-27T10:17:43Z"},
+		{"0 9-17/4 * * MON-FRI", "2023-02-27T13:00:00Z"},
+		{"0 0 * * sun", "2023-03-05T00:00:00Z"},
+		{"0 0 * * 7", "2023-03-05T00:00:00Z"},
+		{"15,45 10 * * *", "2023-02-27T10:45:00Z"},
+		{"0 0 29 2 *", "2024-02-29T00:00:00Z"},
+		// either the day of month or the day of week matches when both are restricted
+		{"0 0 1 * 3", "2023-03-01T00:00:00Z"},
+		{"0 0 15 * 2", "2023-02-28T00:00:00Z"},
+		{"20/30 * * * *", "2023-02-27T10:20:00Z"},
+		{"@hourly", "2023-02-27T11:00:00Z"},
+		{"@monthly", "2023-03-01T00:00:00Z"},
+		{"@yearly", "2024-01-01T00:00:00Z"},
+		{"@every 90s", "2023-02-27T10:19:12.0000005Z"},
+	} {
+		schedule, err := Parse(c.spec, time.UTC)
+		require.NoError(t, err, c.spec)
+		assert.Equal(t, c.next, schedule.Next(from).Format(time.RFC3339Nano), c.spec)
+	}
+
+	schedule, err := Parse("0 0 30 2 *", time.UTC)
+	require.NoError(t, err)
+	assert.True(t, schedule.Next(from).IsZero())
+
+	shanghai := time.FixedZone("CST", 8*3600)
+	schedule, err = Parse("0 8 * * *", shanghai)
+	require.NoError(t, err)
+	assert.Equal(t, "2023-02-28T00:00:00Z", schedule.Next(from).UTC().Format(time.RFC3339))
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"", "* * * *", "* * * * * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8",
+		"*/0 * * * *", "5-1 * * * *", "a * * * *", "@every 10ms", "@every x", "@weekday",
+	} {
+		_, err := Parse(spec, time.UTC)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestJobSkipsOverlapped(t *testing.T) {
+	schedule, err := Parse("@every 1s", nil)
+	require.NoError(t, err)
+	job := NewJob(schedule, 0)
+	release := make(chan struct{})
+	var runs int32
+	task := func() {
+		atomic.AddInt32(&runs, 1)
+		<-release
+	}
+	assert.True(t, job.trigger(task))
+	assert.False(t, job.trigger(task))
+	assert.Equal(t, int64(1), job.Skipped())
+	close(release)
+	job.wg.Wait()
+	assert.True(t, job.trigger(task))
+	job.wg.Wait()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&runs))
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		job.Run(task, stop)
+		close(done)
+	}()
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		assert.Fail(t, "the job should return after stop")
+	}
+}
+
+func TestConfigNewJob(t *testing.T) {
+	var config *Config
+	job, err := config.NewJob(time.Minute)
+	require.NoError(t, err)
+	now := time.Now()
+	assert.Equal(t, now.Add(time.Minute), job.Schedule.Next(now))
+	_, err = config.NewJob(0)
+	assert.Error(t, err)
+
+	config = &Config{Cron: "*/5 * * * *", JitterMs: 100, Timezone: "UTC"}
+	job, err = config.NewJob(time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 100*time.Millisecond, job.Jitter)
+	assert.Equal(t, time.Date(2023, 1, 1, 0, 5, 0, 0, time.UTC), job.Schedule.Next(time.Date(2023, 1, 1, 0, 1, 0, 0, time.UTC)))
+
+	config.Timezone = "Mars/Olympus"
+	_, err = config.NewJob(time.Minute)
+	assert.Error(t, err)
+}
+
+func TestJobRunAtStart(t *testing.T) {
+	job, err := (&Config{}).NewJob(time.Hour)
+	require.NoError(t, err)
+	job.RunAtStart = true
+	stop := make(chan struct{})
+	var runs int32
+	job.Run(func() {
+		atomic.AddInt32(&runs, 1)
+		close(stop)
+	}, stop)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&runs))
+}
@@ -0,0 +1,130 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Job runs a task at the activations of the schedule, each delayed by a random jitter in [0, Jitter), so that the
+// agents polling the same target are not synchronized. The task runs in its own goroutine, and the activations
+// are skipped while the last run is still running, so a slow task never piles up.
+type Job struct {
+	Schedule Schedule
+	Jitter   time.Duration
+	// OnSkip is called when an activation is skipped, which is optional.
+	OnSkip func()
+	// RunAtStart runs the task once at the start of Run without the jitter, before the first activation.
+	RunAtStart bool
+
+	running int32
+	skipped int64
+	wg      sync.WaitGroup
+}
+
+// NewJob returns the job running by @schedule with @jitter.
+func NewJob(schedule Schedule, jitter time.Duration) *Job {
+	return &Job{Schedule: schedule, Jitter: jitter}
+}
+
+// Run calls @task at the activations until @stop is closed or receives, and then waits for the running task.
+// It returns immediately if the schedule has no more activations.
+func (j *Job) Run(task func(), stop <-chan struct{}) {
+	defer j.wg.Wait()
+	if j.RunAtStart {
+		j.trigger(task)
+	}
+	for {
+		now := time.Now()
+		next := j.Schedule.Next(now)
+		if next.IsZero() {
+			return
+		}
+		delay := next.Sub(now)
+		if j.Jitter > 0 {
+			delay += time.Duration(rand.Int63n(int64(j.Jitter))) //nolint:gosec
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		j.trigger(task)
+	}
+}
+
+// trigger starts the task unless the last run is still running.
+func (j *Job) trigger(task func()) bool {
+	if !atomic.CompareAndSwapInt32(&j.running, 0, 1) {
+		atomic.AddInt64(&j.skipped, 1)
+		if j.OnSkip != nil {
+			j.OnSkip()
+		}
+		return false
+	}
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+		defer atomic.StoreInt32(&j.running, 0)
+		task()
+	}()
+	return true
+}
+
+// Skipped returns the count of the activations skipped because the task was still running.
+func (j *Job) Skipped() int64 {
+	return atomic.LoadInt64(&j.skipped)
+}
+
+// Config is the schedule of a polling input, e.g. {"Cron": "*/5 * * * *", "JitterMs": 10000}, see Parse for the
+// formats of Cron.
+type Config struct {
+	Cron     string
+	JitterMs int
+	// The time zone of Cron, e.g. "Asia/Shanghai", the local time zone by default.
+	Timezone string
+}
+
+// NewJob returns the job by the config, which runs every @interval when the config is nil or Cron is empty.
+func (c *Config) NewJob(interval time.Duration) (*Job, error) {
+	if c == nil || c.Cron == "" {
+		if interval <= 0 {
+			return nil, fmt.Errorf("the interval must be positive without the cron of schedule")
+		}
+		var jitter time.Duration
+		if c != nil {
+			jitter = time.Duration(c.JitterMs) * time.Millisecond
+		}
+		return NewJob(&everySchedule{interval: interval}, jitter), nil
+	}
+	var loc *time.Location
+	if c.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(c.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone of schedule: %v", err)
+		}
+	}
+	schedule, err := Parse(c.Cron, loc)
+	if err != nil {
+		return nil, err
+	}
+	return NewJob(schedule, time.Duration(c.JitterMs)*time.Millisecond), nil
+}
@@ -117,6 +117,16 @@ func (s *AvgMetric) GetAvg() float64 {
 	return avg
 }
 
+// Peek returns the average like GetAvg without resetting it.
+func (s *AvgMetric) Peek() float64 {
+	mu.Lock()
+	defer mu.Unlock()
+	if s.count > 0 {
+		return float64(s.value) / float64(s.count)
+	}
+	return s.prevAvg
+}
+
 func (s *AvgMetric) Name() string {
 	return s.name
 }
@@ -0,0 +1,48 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helper
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/knz/strtime"
+)
+
+// Strptime parses @value by the C strptime @format, the unix timestamp of %s is truncated to the seconds.
+// strptime returns the time in UTC, whose wall clock is taken as the time in @location if it is not nil.
+func Strptime(value, format string, location *time.Location) (time.Time, error) {
+	if format == "%s" && len(value) > 10 {
+		value = value[:10]
+	}
+	t, err := strtime.Strptime(value, format)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if t.IsZero() {
+		return time.Time{}, fmt.Errorf("zero time parsed from %q by %q", value, format)
+	}
+	if location != nil {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), location)
+	}
+	return t, nil
+}
+
+// StrptimeHasZone checks if the times parsed by the strptime @format are in the zone of the value, which are
+// the unix timestamps of %s or the times with the offsets of %z.
+func StrptimeHasZone(format string) bool {
+	return strings.Contains(format, "%s") || strings.Contains(format, "%z")
+}
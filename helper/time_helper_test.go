@@ -0,0 +1,50 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrptime(t *testing.T) {
+	location := time.FixedZone("", 8*60*60)
+	parsed, err := Strptime("2023-01-02 03:04:05", "%Y-%m-%d %H:%M:%S", location)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 1, 2, 3, 4, 5, 0, location).Unix(), parsed.Unix())
+
+	parsed, err = Strptime("2023-01-02 03:04:05", "%Y-%m-%d %H:%M:%S", nil)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC).Unix(), parsed.Unix())
+
+	parsed, err = Strptime("1672628645123", "%s", nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1672628645), parsed.Unix())
+
+	// the short timestamp is not truncated
+	parsed, err = Strptime("1", "%s", nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), parsed.Unix())
+
+	_, err = Strptime("invalid", "%Y-%m-%d", nil)
+	assert.Error(t, err)
+
+	assert.True(t, StrptimeHasZone("%s"))
+	assert.True(t, StrptimeHasZone("%Y-%m-%d %H:%M:%S %z"))
+	assert.False(t, StrptimeHasZone("%Y-%m-%d %H:%M:%S"))
+}
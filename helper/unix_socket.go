@@ -0,0 +1,176 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UnixSocketConfig sets the file permissions of the unix domain sockets listened by the service inputs, so
+// that only the local applications of the user or the group could send the data, without exposing a tcp
+// port to the network.
+type UnixSocketConfig struct {
+	// Mode is the octal file mode of the socket, such as 0660, which is decided by the umask when empty.
+	Mode string
+	// Owner is the user name or id of the socket, unchanged when empty.
+	Owner string
+	// Group is the group name or id of the socket, unchanged when empty.
+	Group string
+
+	mode os.FileMode
+}
+
+// Init validates the mode of the socket.
+func (c *UnixSocketConfig) Init() error {
+	if c == nil || c.Mode == "" {
+		return nil
+	}
+	mode, err := strconv.ParseUint(c.Mode, 8, 32)
+	if err != nil || mode > 0o777 {
+		return fmt.Errorf("invalid mode %q of the unix socket", c.Mode)
+	}
+	c.mode = os.FileMode(mode)
+	return nil
+}
+
+// IsUnixNetwork returns true if the network is unix, unixgram or unixpacket.
+func IsUnixNetwork(network string) bool {
+	return strings.HasPrefix(network, "unix")
+}
+
+// ParseListenAddress returns the network and the address to listen, the address is one of
+// unix:///path/to/socket, unixgram:///path/to/socket, tcp://host:port, udp://host:port,
+// http(s)://host:port/path of which the path is ignored, or host:port of the default network.
+func ParseListenAddress(address, defaultNetwork string) (network, addr string, err error) {
+	scheme, rest, ok := strings.Cut(address, "://")
+	if !ok {
+		return defaultNetwork, address, nil
+	}
+	switch scheme {
+	case "unix", "unixgram", "unixpacket":
+		if rest == "" {
+			return "", "", fmt.Errorf("empty socket path of address %s", address)
+		}
+		return scheme, rest, nil
+	case "http", "https":
+		scheme = "tcp"
+	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6":
+	default:
+		return "", "", fmt.Errorf("unknown protocol %q of address %s", scheme, address)
+	}
+	u, err := url.Parse(address)
+	if err != nil {
+		return "", "", err
+	}
+	return scheme, u.Host, nil
+}
+
+// Listen listens on the stream address, see ParseListenAddress for the format of the address. The stale
+// socket file left by the last process is removed, and the permissions of socket are set by the config.
+func Listen(address, defaultNetwork string, socket *UnixSocketConfig) (net.Listener, error) {
+	network, addr, err := ParseListenAddress(address, defaultNetwork)
+	if err != nil {
+		return nil, err
+	}
+	if !IsUnixNetwork(network) {
+		return net.Listen(network, addr)
+	}
+	if err = removeStaleSocket(network, addr); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	if err = socket.apply(addr); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// ListenPacket listens on the packet address like Listen. The socket file of unixgram is not removed
+// when the connection is closed.
+func ListenPacket(address, defaultNetwork string, socket *UnixSocketConfig) (net.PacketConn, error) {
+	network, addr, err := ParseListenAddress(address, defaultNetwork)
+	if err != nil {
+		return nil, err
+	}
+	if !IsUnixNetwork(network) {
+		return net.ListenPacket(network, addr)
+	}
+	if err = removeStaleSocket(network, addr); err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenPacket(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	if err = socket.apply(addr); err != nil {
+		_ = conn.Close()
+		_ = os.Remove(addr)
+		return nil, err
+	}
+	return conn, nil
+}
+
+// removeStaleSocket removes the socket file nobody listens on. The other files are never removed in case
+// of a wrong path.
+func removeStaleSocket(network, path string) error {
+	stat, err := os.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if stat.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+	conn, err := net.DialTimeout(network, path, time.Second)
+	if err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("%s is listened by another process", path)
+	}
+	if err = os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (c *UnixSocketConfig) apply(path string) error {
+	if c == nil {
+		return nil
+	}
+	if c.Mode != "" {
+		if err := c.Init(); err != nil {
+			return err
+		}
+		if err := os.Chmod(path, c.mode); err != nil {
+			return err
+		}
+	}
+	if c.Owner != "" || c.Group != "" {
+		return chownSocket(path, c.Owner, c.Group)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package helper
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseListenAddress(t *testing.T) {
+	cases := []struct {
+		address string
+		network string
+		addr    string
+	}{
+		{"unix:///var/run/ilogtail.sock", "unix", "/var/run/ilogtail.sock"},
+		{"unixgram:///dev/log", "unixgram", "/dev/log"},
+		{"http://0.0.0.0:4318/v1/logs", "tcp", "0.0.0.0:4318"},
+		{"tcp://127.0.0.1:514", "tcp", "127.0.0.1:514"},
+		{"udp://:8125", "udp", ":8125"},
+		{"0.0.0.0:4317", "tcp", "0.0.0.0:4317"},
+	}
+	for _, c := range cases {
+		network, addr, err := ParseListenAddress(c.address, "tcp")
+		require.NoError(t, err, c.address)
+		assert.Equal(t, c.network, network, c.address)
+		assert.Equal(t, c.addr, addr, c.address)
+	}
+	_, _, err := ParseListenAddress("unix://", "tcp")
+	assert.Error(t, err)
+	_, _, err = ParseListenAddress("ftp://127.0.0.1:21", "tcp")
+	assert.Error(t, err)
+}
+
+func TestListenUnixSocket(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "input.sock")
+	socket := &UnixSocketConfig{Mode: "0600", Owner: strconv.Itoa(os.Getuid()), Group: strconv.Itoa(os.Getgid())}
+	listener, err := Listen("unix://"+path, "tcp", socket)
+	require.NoError(t, err)
+	stat, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), stat.Mode().Perm())
+
+	// the socket listened by another listener is kept
+	_, err = Listen("unix://"+path, "tcp", nil)
+	assert.Error(t, err)
+	require.NoError(t, listener.Close())
+
+	// the stale socket file is removed
+	conn, err := ListenPacket("unixgram://"+path, "udp", nil)
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+	conn, err = ListenPacket("unixgram://"+path, "udp", &UnixSocketConfig{Mode: "0660"})
+	require.NoError(t, err)
+	client, err := net.Dial("unixgram", path)
+	require.NoError(t, err)
+	_, err = client.Write([]byte("hello"))
+	require.NoError(t, err)
+	buf := make([]byte, 16)
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(buf[:n]))
+	_ = client.Close()
+	_ = conn.Close()
+
+	// the regular file is never removed
+	file := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, nil, 0o600))
+	_, err = Listen("unix://"+file, "tcp", nil)
+	assert.Error(t, err)
+
+	assert.Error(t, (&UnixSocketConfig{Mode: "0999"}).Init())
+	assert.Error(t, (&UnixSocketConfig{Mode: "1777"}).Init())
+}
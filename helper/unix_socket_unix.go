@@ -0,0 +1,57 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package helper
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+)
+
+// chownSocket changes the owner and the group of the socket, which are the names or the ids.
+func chownSocket(path, owner, group string) error {
+	uid, gid := -1, -1
+	if owner != "" {
+		id, err := strconv.Atoi(owner)
+		if err != nil {
+			u, lookupErr := user.Lookup(owner)
+			if lookupErr != nil {
+				return fmt.Errorf("lookup owner %s of the unix socket error: %v", owner, lookupErr)
+			}
+			if id, err = strconv.Atoi(u.Uid); err != nil {
+				return err
+			}
+		}
+		uid = id
+	}
+	if group != "" {
+		id, err := strconv.Atoi(group)
+		if err != nil {
+			g, lookupErr := user.LookupGroup(group)
+			if lookupErr != nil {
+				return fmt.Errorf("lookup group %s of the unix socket error: %v", group, lookupErr)
+			}
+			if id, err = strconv.Atoi(g.Gid); err != nil {
+				return err
+			}
+		}
+		gid = id
+	}
+	return os.Lchown(path, uid, gid)
+}
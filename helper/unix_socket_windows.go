@@ -0,0 +1,25 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package helper
+
+import "errors"
+
+// chownSocket is not supported because the access of the sockets is controlled by the ACLs on windows.
+func chownSocket(path, owner, group string) error {
+	return errors.New("owner and group of the unix socket are not supported on windows")
+}
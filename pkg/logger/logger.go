@@ -152,9 +152,9 @@ func Debugf(ctx context.Context, format string, params ...interface{}) {
 	}
 	ltCtx, ok := ctx.Value(pkg.LogTailMeta).(*pkg.LogtailContextMeta)
 	if ok {
-		logtailLogger.Debugf(ltCtx.LoggerHeader()+format, params...)
+		logtailLogger.Debug(ltCtx.LoggerHeader(), formatLog(format, params))
 	} else {
-		logtailLogger.Debugf(format, params...)
+		logtailLogger.Debug(formatLog(format, params))
 	}
 }
 
@@ -170,9 +170,9 @@ func Info(ctx context.Context, kvPairs ...interface{}) {
 func Infof(ctx context.Context, format string, params ...interface{}) {
 	ltCtx, ok := ctx.Value(pkg.LogTailMeta).(*pkg.LogtailContextMeta)
 	if ok {
-		logtailLogger.Infof(ltCtx.LoggerHeader()+format, params...)
+		logtailLogger.Info(ltCtx.LoggerHeader(), formatLog(format, params))
 	} else {
-		logtailLogger.Infof(format, params...)
+		logtailLogger.Info(formatLog(format, params))
 	}
 }
 
@@ -201,7 +201,7 @@ func Warningf(ctx context.Context, alarmType string, format string, params ...in
 		format += "\tlogstore:%v\tconfig:%v"
 		params = append(params, ltCtx.GetLogStore(), ltCtx.GetConfigName())
 	}
-	msg := fmt.Sprintf(format, params...)
+	msg := formatLog(format, params)
 	if ok {
 		_ = logtailLogger.Warn(ltCtx.LoggerHeader(), "AlarmType:", alarmType, "\t", msg)
 		if remoteFlag {
@@ -240,7 +240,7 @@ func Errorf(ctx context.Context, alarmType string, format string, params ...inte
 		format += "\tlogstore:%v\tconfig:%v"
 		params = append(params, ltCtx.GetLogStore(), ltCtx.GetConfigName())
 	}
-	msg := fmt.Sprintf(format, params...)
+	msg := formatLog(format, params)
 	if ok {
 		_ = logtailLogger.Error(ltCtx.LoggerHeader(), "AlarmType:", alarmType, "\t", msg)
 		if remoteFlag {
@@ -296,7 +296,12 @@ func generateLog(kvPairs ...interface{}) string {
 	if len(kvPairs)&0x01 != 0 {
 		logString += fmt.Sprintf("%v:\t", kvPairs[len(kvPairs)-1])
 	}
-	return logString
+	return util.RedactSecrets(logString)
+}
+
+// formatLog formats the log with the registered secrets masked.
+func formatLog(format string, params []interface{}) string {
+	return util.RedactSecrets(fmt.Sprintf(format, params...))
 }
 
 func generateDefaultConfig() string {
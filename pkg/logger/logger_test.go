@@ -594,3 +594,19 @@ func TestOffRemote(t *testing.T) {
 	assert.Equal(t, 0, len(util.GlobalAlarm.AlarmMap))
 	delete(util.GlobalAlarm.AlarmMap, "ALARM_TYPE")
 }
+
+func TestRedactSecrets(t *testing.T) {
+	mu.Lock()
+	defer mu.Unlock()
+	defer util.ResetSecrets()
+	clean()
+	initNormalLogger()
+	util.RegisterSecret("mock-password")
+	Info(context.Background(), "password", "mock-password")
+	Warningf(ctx, "TEST_ALARM", "connect with %s failed", "mock-password")
+	time.Sleep(time.Millisecond)
+	Flush()
+	assert.Regexp(t, `password:\*{6}`, readLog(0))
+	assert.Regexp(t, `connect with \*{6} failed`, readLog(1))
+	assert.NotContains(t, readLog(1), "mock-password")
+}
@@ -99,14 +99,14 @@ func (kv *keyValuesImpl[TValue]) values() (map[string]TValue, bool) {
 
 func (kv *keyValuesImpl[TValue]) Add(key string, value TValue) {
 	if values, ok := kv.values(); ok {
-		values[key] = value
+		values[InternKey(key)] = value
 	}
 }
 
 func (kv *keyValuesImpl[TValue]) AddAll(items map[string]TValue) {
 	if values, ok := kv.values(); ok {
 		for key, value := range items {
-			values[key] = value
+			values[InternKey(key)] = value
 		}
 	}
 }
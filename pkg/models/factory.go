@@ -28,7 +28,7 @@ func NewTagsWithKeyValues(keyValues ...string) Tags {
 	}
 	tags := make(map[string]string)
 	for i := 0; i < len(keyValues); i += 2 {
-		tags[keyValues[i]] = keyValues[i+1]
+		tags[InternKey(keyValues[i])] = keyValues[i+1]
 	}
 	return &keyValuesImpl[string]{
 		keyValues: tags,
@@ -53,7 +53,7 @@ func NewMetadataWithKeyValues(keyValues ...string) Metadata {
 	}
 	tags := make(map[string]string)
 	for i := 0; i < len(keyValues); i += 2 {
-		tags[keyValues[i]] = keyValues[i+1]
+		tags[InternKey(keyValues[i])] = keyValues[i+1]
 	}
 	return &keyValuesImpl[string]{
 		keyValues: tags,
@@ -147,6 +147,16 @@ func NewSpan(name, traceID, spanID string, kind SpanKind, startTime, endTime uin
 	}
 }
 
+func NewLog(name string, body []byte, level string, timestamp uint64, tags Tags) *Log {
+	return &Log{
+		Name:      name,
+		Body:      body,
+		Severity:  NormalizeSeverity(level),
+		Timestamp: timestamp,
+		Tags:      tags,
+	}
+}
+
 func NewByteArray(bytes []byte) ByteArray {
 	return ByteArray(bytes)
 }
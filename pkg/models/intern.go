@@ -0,0 +1,47 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// MaxInternedKeys bounds the interning pool, the keys beyond it are not interned, so that the pool doesn't grow
+// forever with the high cardinality keys.
+const MaxInternedKeys = 16384
+
+var (
+	internPool  sync.Map
+	internCount int64
+)
+
+// InternKey returns the interned string equal to @key, so that the identical keys of the events, such as host and
+// level, share the same backing storage instead of the copies sliced from the buffers of every decoded event.
+func InternKey(key string) string {
+	if v, ok := internPool.Load(key); ok {
+		return v.(string)
+	}
+	if atomic.LoadInt64(&internCount) >= MaxInternedKeys {
+		return key
+	}
+	// copy the key, which may be sliced from a large buffer retained by the pool otherwise
+	k := string([]byte(key))
+	v, loaded := internPool.LoadOrStore(k, k)
+	if !loaded {
+		atomic.AddInt64(&internCount, 1)
+	}
+	return v.(string)
+}
@@ -0,0 +1,91 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func stringData(s string) uintptr {
+	return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+}
+
+func TestInternKey(t *testing.T) {
+	a := string([]byte("namespace_for_intern_test"))
+	b := string([]byte("namespace_for_intern_test"))
+	assert.NotEqual(t, stringData(a), stringData(b))
+	assert.Equal(t, stringData(InternKey(a)), stringData(InternKey(b)))
+
+	tags := NewTags()
+	tags.Add(b, "default")
+	for key := range tags.Iterator() {
+		assert.Equal(t, stringData(InternKey(a)), stringData(key))
+	}
+}
+
+var keyBuffer = []byte("host namespace level")
+
+// decodeTags builds the tags with the keys converted from the decoded buffers like the decoders, the keys of which
+// are the new strings per event without the interning.
+func decodeTags(intern bool) Tags {
+	if intern {
+		tags := NewTags()
+		for _, key := range [][]byte{keyBuffer[:4], keyBuffer[5:14], keyBuffer[15:]} {
+			tags.Add(string(key), "value")
+		}
+		return tags
+	}
+	values := make(map[string]string)
+	for _, key := range [][]byte{keyBuffer[:4], keyBuffer[5:14], keyBuffer[15:]} {
+		values[string(key)] = "value"
+	}
+	return NewTagsWithMap(values)
+}
+
+func benchmarkTagsMemory(b *testing.B, intern bool) {
+	events := make([]Tags, b.N)
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	for i := 0; i < b.N; i++ {
+		events[i] = decodeTags(intern)
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/float64(b.N), "retainedB/event")
+	runtime.KeepAlive(events)
+}
+
+func BenchmarkTagsMemory(b *testing.B) {
+	b.Run("raw", func(b *testing.B) {
+		benchmarkTagsMemory(b, false)
+	})
+	b.Run("interned", func(b *testing.B) {
+		benchmarkTagsMemory(b, true)
+	})
+}
+
+func BenchmarkInternKey(b *testing.B) {
+	key := string([]byte("namespace"))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = InternKey(key)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+// A Log represents a log record, the body of which is kept as is.
+type Log struct {
+	Name              string
+	Body              []byte
+	Severity          Severity
+	TraceID           string
+	SpanID            string
+	Timestamp         uint64
+	ObservedTimestamp uint64
+
+	Tags Tags
+}
+
+func (m *Log) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Log) SetName(name string) {
+	if m != nil {
+		m.Name = name
+	}
+}
+
+func (m *Log) GetTags() Tags {
+	if m != nil && m.Tags != nil {
+		return m.Tags
+	}
+	return noopStringValues
+}
+
+func (m *Log) GetType() EventType {
+	return EventTypeLogging
+}
+
+func (m *Log) GetTimestamp() uint64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *Log) GetObservedTimestamp() uint64 {
+	if m != nil {
+		return m.ObservedTimestamp
+	}
+	return 0
+}
+
+func (m *Log) SetObservedTimestamp(timestamp uint64) {
+	if m != nil {
+		m.ObservedTimestamp = timestamp
+	}
+}
+
+func (m *Log) GetBody() []byte {
+	if m != nil {
+		return m.Body
+	}
+	return nil
+}
+
+func (m *Log) GetSeverity() Severity {
+	if m != nil {
+		return m.Severity
+	}
+	return SeverityUnspecified
+}
+
+// SetLevel sets the severity normalized from the level of any common spelling.
+func (m *Log) SetLevel(level string) {
+	if m != nil {
+		m.Severity = NormalizeSeverity(level)
+	}
+}
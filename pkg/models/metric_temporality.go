@@ -0,0 +1,111 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import "strings"
+
+type cumulativePoint struct {
+	timestamp uint64
+	value     float64
+	values    map[string]float64
+}
+
+// CumulativeToDelta converts the cumulative metrics to the delta ones by the previous points of the same series,
+// which are identified by the names and the tags. It's not safe for concurrent use.
+type CumulativeToDelta struct {
+	series map[string]*cumulativePoint
+	buf    []KeyValue[string]
+	sb     strings.Builder
+}
+
+func NewCumulativeToDelta() *CumulativeToDelta {
+	return &CumulativeToDelta{
+		series: make(map[string]*cumulativePoint),
+	}
+}
+
+// Convert converts the values of the cumulative @metric to the deltas since the previous point in place. It returns
+// false when @metric is the first point of its series or older than the previous one, which has no delta and should
+// be dropped. The metrics of the other temporalities are kept as is.
+// The series of the monotonic metric is regarded as reset when the value decreases, and the delta is the value itself.
+func (c *CumulativeToDelta) Convert(metric *Metric) bool {
+	if metric.GetTemporality() != MetricTemporalityCumulative {
+		return true
+	}
+	key := c.seriesKey(metric)
+	prev, ok := c.series[key]
+	cur := &cumulativePoint{timestamp: metric.GetTimestamp()}
+	value := metric.GetValue()
+	switch {
+	case value.IsSingleValue():
+		cur.value = value.GetSingleValue()
+	case value.IsMultiValues():
+		cur.values = make(map[string]float64, value.GetMultiValues().Len())
+		for k, v := range value.GetMultiValues().Iterator() {
+			cur.values[k] = v
+		}
+	default:
+		return true
+	}
+	if ok && cur.timestamp <= prev.timestamp {
+		return false
+	}
+	c.series[key] = cur
+	if !ok {
+		return false
+	}
+
+	reset := metric.IsMonotonic() && cur.value < prev.value
+	for k, v := range cur.values {
+		if metric.IsMonotonic() && v < prev.values[k] {
+			reset = true
+		}
+	}
+	if !reset {
+		if value.IsSingleValue() {
+			metric.Value = &MetricSingleValue{Value: cur.value - prev.value}
+		} else {
+			values := NewMetricMultiValue()
+			for k, v := range cur.values {
+				values.Add(k, v-prev.values[k])
+			}
+			metric.Value = values
+		}
+	}
+	metric.Temporality = MetricTemporalityDelta
+	return true
+}
+
+// Expire drops the states of the series without the points since @timestamp.
+func (c *CumulativeToDelta) Expire(timestamp uint64) {
+	for key, point := range c.series {
+		if point.timestamp < timestamp {
+			delete(c.series, key)
+		}
+	}
+}
+
+func (c *CumulativeToDelta) seriesKey(metric *Metric) string {
+	c.sb.Reset()
+	c.sb.WriteString(metric.GetName())
+	c.buf = metric.GetTags().SortTo(c.buf[:0])
+	for _, kv := range c.buf {
+		c.sb.WriteByte('|')
+		c.sb.WriteString(kv.Key)
+		c.sb.WriteByte('=')
+		c.sb.WriteString(kv.Value)
+	}
+	return c.sb.String()
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCumulativeCounter(tags Tags, timestamp int64, value float64) *Metric {
+	metric := NewSingleValueMetric("requests", MetricTypeCounter, tags, timestamp, value)
+	metric.Temporality = MetricTemporalityCumulative
+	metric.Monotonic = true
+	return metric
+}
+
+func TestCumulativeToDelta(t *testing.T) {
+	c := NewCumulativeToDelta()
+	tags := NewTagsWithKeyValues("host", "a")
+
+	assert.False(t, c.Convert(newCumulativeCounter(tags, 1, 10)))
+	metric := newCumulativeCounter(tags, 2, 15)
+	assert.True(t, c.Convert(metric))
+	assert.Equal(t, 5.0, metric.GetValue().GetSingleValue())
+	assert.Equal(t, MetricTemporalityDelta, metric.GetTemporality())
+
+	// the series of the other tags has its own state
+	assert.False(t, c.Convert(newCumulativeCounter(NewTagsWithKeyValues("host", "b"), 2, 100)))
+	// the stale point is dropped
+	assert.False(t, c.Convert(newCumulativeCounter(tags, 2, 20)))
+
+	// the counter is reset
+	metric = newCumulativeCounter(tags, 3, 4)
+	assert.True(t, c.Convert(metric))
+	assert.Equal(t, 4.0, metric.GetValue().GetSingleValue())
+
+	// the non monotonic sum could decrease
+	gauge := newCumulativeCounter(tags, 4, 1)
+	gauge.Monotonic = false
+	assert.True(t, c.Convert(gauge))
+	assert.Equal(t, -3.0, gauge.GetValue().GetSingleValue())
+
+	// the delta metrics are kept
+	delta := NewSingleValueMetric("requests", MetricTypeCounter, tags, 5, 7)
+	delta.Temporality = MetricTemporalityDelta
+	assert.True(t, c.Convert(delta))
+	assert.Equal(t, 7.0, delta.GetValue().GetSingleValue())
+
+	c.Expire(5)
+	assert.False(t, c.Convert(newCumulativeCounter(tags, 6, 10)))
+}
+
+func TestCumulativeToDeltaMultiValues(t *testing.T) {
+	c := NewCumulativeToDelta()
+	newHistogram := func(timestamp int64, count, sum float64) *Metric {
+		metric := NewMultiValuesMetric("latency", MetricTypeHistogram, NewTags(), timestamp, NewMetricMultiValueWithMap(map[string]float64{"count": count, "sum": sum}).Values)
+		metric.Temporality = MetricTemporalityCumulative
+		metric.Monotonic = true
+		return metric
+	}
+	assert.False(t, c.Convert(newHistogram(1, 10, 100)))
+	metric := newHistogram(2, 12, 130)
+	assert.True(t, c.Convert(metric))
+	assert.Equal(t, map[string]float64{"count": 2, "sum": 30}, metric.GetValue().GetMultiValues().Iterator())
+}
@@ -30,6 +30,30 @@ const (
 	MetricTypeRateCounter // In bytetsd, ratecounter is an extension of the counter type, which contains a rate value within a period
 )
 
+// MetricTemporality is the aggregation temporality of the counter and histogram values, which are the changes since
+// the previous points of the series when delta, or the totals since the series started when cumulative.
+type MetricTemporality int
+
+const (
+	MetricTemporalityUnspecified MetricTemporality = iota
+	MetricTemporalityDelta
+	MetricTemporalityCumulative
+)
+
+var (
+	MetricTemporalityTexts = map[MetricTemporality]string{
+		MetricTemporalityUnspecified: "Unspecified",
+		MetricTemporalityDelta:       "Delta",
+		MetricTemporalityCumulative:  "Cumulative",
+	}
+
+	MetricTemporalityValues = map[string]MetricTemporality{
+		"Unspecified": MetricTemporalityUnspecified,
+		"Delta":       MetricTemporalityDelta,
+		"Cumulative":  MetricTemporalityCumulative,
+	}
+)
+
 var (
 	MetricTypeTexts = map[MetricType]string{
 		MetricTypeCounter:     "Counter",
@@ -161,6 +185,11 @@ type Metric struct {
 	MetricType MetricType
 	Value      MetricValue
 	TypedValue MetricTypedValues
+
+	// Temporality and Monotonic describe the counter and histogram values, the monotonic values never decrease
+	// unless the series is reset.
+	Temporality MetricTemporality
+	Monotonic   bool
 }
 
 func (m *Metric) GetName() string {
@@ -241,3 +270,17 @@ func (m *Metric) GetTypedValue() MetricTypedValues {
 	}
 	return noopTypedValues
 }
+
+func (m *Metric) GetTemporality() MetricTemporality {
+	if m != nil {
+		return m.Temporality
+	}
+	return MetricTemporalityUnspecified
+}
+
+func (m *Metric) IsMonotonic() bool {
+	if m != nil {
+		return m.Monotonic
+	}
+	return false
+}
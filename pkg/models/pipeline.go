@@ -14,6 +14,8 @@
 
 package models
 
+var noopRoutingHints = &RoutingHints{}
+
 type PipelineEvent interface {
 	GetName() string
 
@@ -30,9 +32,23 @@ type PipelineEvent interface {
 	SetObservedTimestamp(uint64)
 }
 
+// RoutingHints are the destinations of a group suggested by the aggregators, which are consumed by the flushers
+// supporting them instead of the conventional tags like __shardhash__.
+type RoutingHints struct {
+	// ShardHash is the hash key of the SLS shard.
+	ShardHash string
+	// Stream is the labels of the Loki stream.
+	Stream map[string]string
+}
+
 type GroupInfo struct {
 	Metadata Metadata
 	Tags     Tags
+
+	// PartitionKey is the key of the partition the group is sent to, such as the Kafka message key. It's kept with
+	// the group info, so that it's passed through the collectors along with the group.
+	PartitionKey string
+	RoutingHints *RoutingHints
 }
 
 func (g *GroupInfo) GetMetadata() Metadata {
@@ -49,7 +65,35 @@ func (g *GroupInfo) GetTags() Tags {
 	return noopStringValues
 }
 
+func (g *GroupInfo) GetPartitionKey() string {
+	if g != nil {
+		return g.PartitionKey
+	}
+	return ""
+}
+
+func (g *GroupInfo) GetRoutingHints() *RoutingHints {
+	if g != nil && g.RoutingHints != nil {
+		return g.RoutingHints
+	}
+	return noopRoutingHints
+}
+
 type PipelineGroupEvents struct {
 	Group  *GroupInfo
 	Events []PipelineEvent
 }
+
+func (p *PipelineGroupEvents) GetPartitionKey() string {
+	if p != nil {
+		return p.Group.GetPartitionKey()
+	}
+	return ""
+}
+
+func (p *PipelineGroupEvents) GetRoutingHints() *RoutingHints {
+	if p != nil {
+		return p.Group.GetRoutingHints()
+	}
+	return noopRoutingHints
+}
@@ -0,0 +1,121 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Severity is the canonical level of the log events.
+type Severity int
+
+const (
+	SeverityUnspecified Severity = iota
+	SeverityTrace
+	SeverityDebug
+	SeverityInfo
+	SeverityWarn
+	SeverityError
+	SeverityFatal
+)
+
+var (
+	SeverityTexts = map[Severity]string{
+		SeverityUnspecified: "",
+		SeverityTrace:       "TRACE",
+		SeverityDebug:       "DEBUG",
+		SeverityInfo:        "INFO",
+		SeverityWarn:        "WARN",
+		SeverityError:       "ERROR",
+		SeverityFatal:       "FATAL",
+	}
+
+	// severitySpellings are the common spellings of the levels in lower case, including the ones of syslog, log4j,
+	// java.util.logging, glog and the single letter abbreviations.
+	severitySpellings = map[string]Severity{
+		"trace":         SeverityTrace,
+		"trc":           SeverityTrace,
+		"t":             SeverityTrace,
+		"finest":        SeverityTrace,
+		"finer":         SeverityTrace,
+		"verbose":       SeverityTrace,
+		"v":             SeverityTrace,
+		"debug":         SeverityDebug,
+		"dbg":           SeverityDebug,
+		"d":             SeverityDebug,
+		"fine":          SeverityDebug,
+		"config":        SeverityDebug,
+		"info":          SeverityInfo,
+		"inf":           SeverityInfo,
+		"i":             SeverityInfo,
+		"information":   SeverityInfo,
+		"informational": SeverityInfo,
+		"notice":        SeverityInfo,
+		"warn":          SeverityWarn,
+		"warning":       SeverityWarn,
+		"wrn":           SeverityWarn,
+		"w":             SeverityWarn,
+		"error":         SeverityError,
+		"err":           SeverityError,
+		"e":             SeverityError,
+		"severe":        SeverityError,
+		"fatal":         SeverityFatal,
+		"ftl":           SeverityFatal,
+		"f":             SeverityFatal,
+		"critical":      SeverityFatal,
+		"crit":          SeverityFatal,
+		"c":             SeverityFatal,
+		"alert":         SeverityFatal,
+		"emerg":         SeverityFatal,
+		"emergency":     SeverityFatal,
+		"panic":         SeverityFatal,
+	}
+)
+
+func (s Severity) String() string {
+	return SeverityTexts[s]
+}
+
+// NormalizeSeverity maps the common spellings of @level case-insensitively to the canonical severity, such as WARN,
+// warning and W to SeverityWarn. The numeric levels are of the scale of Python logging, where 10, 20, 30, 40 and 50
+// are debug, info, warning, error and critical, and the ones below 10 are trace. The unknown levels are
+// SeverityUnspecified.
+func NormalizeSeverity(level string) Severity {
+	level = strings.TrimSpace(level)
+	if s, ok := severitySpellings[level]; ok {
+		return s
+	}
+	if s, ok := severitySpellings[strings.ToLower(level)]; ok {
+		return s
+	}
+	n, err := strconv.Atoi(level)
+	if err != nil || n <= 0 {
+		return SeverityUnspecified
+	}
+	switch {
+	case n >= 50:
+		return SeverityFatal
+	case n >= 40:
+		return SeverityError
+	case n >= 30:
+		return SeverityWarn
+	case n >= 20:
+		return SeverityInfo
+	case n >= 10:
+		return SeverityDebug
+	}
+	return SeverityTrace
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeSeverity(t *testing.T) {
+	cases := map[string]Severity{
+		"WARN":     SeverityWarn,
+		"warning":  SeverityWarn,
+		"W":        SeverityWarn,
+		"30":       SeverityWarn,
+		" Info ":   SeverityInfo,
+		"notice":   SeverityInfo,
+		"DEBUG":    SeverityDebug,
+		"10":       SeverityDebug,
+		"5":        SeverityTrace,
+		"finest":   SeverityTrace,
+		"ERR":      SeverityError,
+		"severe":   SeverityError,
+		"45":       SeverityError,
+		"CRITICAL": SeverityFatal,
+		"50":       SeverityFatal,
+		"emerg":    SeverityFatal,
+		"":         SeverityUnspecified,
+		"0":        SeverityUnspecified,
+		"unknown":  SeverityUnspecified,
+	}
+	for level, severity := range cases {
+		assert.Equal(t, severity, NormalizeSeverity(level), level)
+	}
+	assert.Equal(t, "WARN", SeverityWarn.String())
+}
+
+func TestLogSeverity(t *testing.T) {
+	log := NewLog("app", []byte("disk is full"), "warning", 1680000000, NewTags())
+	assert.Equal(t, EventTypeLogging, log.GetType())
+	assert.Equal(t, SeverityWarn, log.GetSeverity())
+	log.SetLevel("E")
+	assert.Equal(t, SeverityError, log.GetSeverity())
+
+	var nilLog *Log
+	assert.Equal(t, SeverityUnspecified, nilLog.GetSeverity())
+	assert.NotNil(t, nilLog.GetTags())
+}
@@ -14,6 +14,8 @@
 
 package models
 
+import "time"
+
 type SpanKind int
 
 const (
@@ -224,3 +226,18 @@ func (m *Span) GetEvents() []*SpanEvent {
 	}
 	return noopSpanEvents
 }
+
+// GetDuration returns the duration between the start and end times in nanoseconds, and zero if the span isn't ended.
+func (m *Span) GetDuration() time.Duration {
+	if m != nil && m.EndTime > m.StartTime {
+		return time.Duration(m.EndTime - m.StartTime)
+	}
+	return 0
+}
+
+// SetDuration sets the end time to the start time plus @duration.
+func (m *Span) SetDuration(duration time.Duration) {
+	if m != nil && duration >= 0 {
+		m.EndTime = m.StartTime + uint64(duration)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import "time"
+
+// Clock is the source of the span times.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SystemClock is the clock of time.Now, the readings of which carry the monotonic clock, so that the durations of the
+// spans are not affected by the wall clock adjustments.
+var SystemClock Clock = systemClock{}
+
+// SpanBuilder builds a span timed by a clock, the end time and the event timestamps are the start time plus the
+// elapsed time measured by the clock.
+type SpanBuilder struct {
+	span  *Span
+	clock Clock
+	start time.Time
+}
+
+// StartSpan starts a span at the current time of @clock, and SystemClock is used when @clock is nil.
+func StartSpan(clock Clock, name, traceID, spanID string, kind SpanKind) *SpanBuilder {
+	if clock == nil {
+		clock = SystemClock
+	}
+	start := clock.Now()
+	return &SpanBuilder{
+		span:  NewSpan(name, traceID, spanID, kind, uint64(start.UnixNano()), 0, NewTags(), nil, nil),
+		clock: clock,
+		start: start,
+	}
+}
+
+func (b *SpanBuilder) SetParentSpanID(parentSpanID string) *SpanBuilder {
+	b.span.ParentSpanID = parentSpanID
+	return b
+}
+
+func (b *SpanBuilder) SetTraceState(traceState string) *SpanBuilder {
+	b.span.TraceState = traceState
+	return b
+}
+
+func (b *SpanBuilder) SetTag(key, value string) *SpanBuilder {
+	b.span.Tags.Add(key, value)
+	return b
+}
+
+func (b *SpanBuilder) SetStatus(status StatusCode) *SpanBuilder {
+	b.span.Status = status
+	return b
+}
+
+func (b *SpanBuilder) AddLink(link *SpanLink) *SpanBuilder {
+	b.span.Links = append(b.span.Links, link)
+	return b
+}
+
+// AddEvent adds an event at the current time of the clock.
+func (b *SpanBuilder) AddEvent(name string, tags Tags) *SpanBuilder {
+	b.span.Events = append(b.span.Events, &SpanEvent{
+		Timestamp: int64(b.span.StartTime) + int64(b.elapsed()),
+		Name:      name,
+		Tags:      tags,
+	})
+	return b
+}
+
+// End ends the span at the current time of the clock and returns it.
+func (b *SpanBuilder) End() *Span {
+	b.span.SetDuration(b.elapsed())
+	return b.span
+}
+
+// EndWithDuration ends the span @duration after the start time and returns it.
+func (b *SpanBuilder) EndWithDuration(duration time.Duration) *Span {
+	b.span.SetDuration(duration)
+	return b.span
+}
+
+func (b *SpanBuilder) elapsed() time.Duration {
+	if d := b.clock.Now().Sub(b.start); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// NewSpanWithDuration creates the span starting at @startTime and lasting @duration, which is the common case of
+// the foreign trace formats recording the durations instead of the end times.
+func NewSpanWithDuration(name, traceID, spanID string, kind SpanKind, startTime time.Time, duration time.Duration, tags Tags) *Span {
+	if tags == nil {
+		tags = NewTags()
+	}
+	span := NewSpan(name, traceID, spanID, kind, uint64(startTime.UnixNano()), 0, tags, nil, nil)
+	span.SetDuration(duration)
+	return span
+}
@@ -0,0 +1,65 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockClock struct {
+	now time.Time
+}
+
+func (c *mockClock) Now() time.Time {
+	return c.now
+}
+
+func TestSpanBuilder(t *testing.T) {
+	clock := &mockClock{now: time.Unix(1680000000, 0)}
+	b := StartSpan(clock, "GET /", "trace", "span", SpanKindServer).SetParentSpanID("parent").SetTag("http.method", "GET")
+	clock.now = clock.now.Add(time.Millisecond)
+	b.AddEvent("received", nil)
+	clock.now = clock.now.Add(2 * time.Millisecond)
+	span := b.SetStatus(StatusCodeOK).End()
+
+	assert.Equal(t, uint64(1680000000*int64(time.Second)), span.GetStartTime())
+	assert.Equal(t, 3*time.Millisecond, span.GetDuration())
+	assert.Equal(t, span.GetStartTime()+uint64(3*time.Millisecond), span.GetEndTime())
+	assert.Equal(t, "parent", span.GetParentSpanID())
+	assert.Equal(t, "GET", span.GetTags().Get("http.method"))
+	assert.Equal(t, StatusCodeOK, span.GetStatus())
+	assert.Equal(t, int64(span.GetStartTime())+int64(time.Millisecond), span.GetEvents()[0].Timestamp)
+
+	// the clock going backwards doesn't result in the negative durations
+	clock.now = time.Unix(1670000000, 0)
+	assert.Equal(t, time.Duration(0), StartSpan(clock, "a", "trace", "span", SpanKindInternal).EndWithDuration(-time.Second).GetDuration())
+	b = StartSpan(clock, "a", "trace", "span", SpanKindInternal)
+	clock.now = clock.now.Add(-time.Second)
+	assert.Equal(t, time.Duration(0), b.End().GetDuration())
+}
+
+func TestSpanWithDuration(t *testing.T) {
+	span := NewSpanWithDuration("a", "trace", "span", SpanKindClient, time.Unix(0, 100), 50*time.Microsecond, nil)
+	assert.Equal(t, uint64(100), span.GetStartTime())
+	assert.Equal(t, uint64(100+50000), span.GetEndTime())
+	assert.Equal(t, 50*time.Microsecond, span.GetDuration())
+	assert.NotNil(t, span.GetTags())
+
+	var nilSpan *Span
+	assert.Equal(t, time.Duration(0), nilSpan.GetDuration())
+}
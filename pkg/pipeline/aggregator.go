@@ -45,6 +45,12 @@ type AggregatorV1 interface {
 	Flush() []*protocol.LogGroup
 }
 
+// StoppableAggregator is implemented by the aggregators caching the data across the flushes, such as the open
+// sessions. Stop is called before the last Flush when the config stops, so the cached data is emitted then.
+type StoppableAggregator interface {
+	Stop() error
+}
+
 // AggregatorV2
 // Apply, Push, and Reset can not be called concurrently, so locking is not
 // required when implementing an Aggregator plugin.
@@ -38,3 +38,16 @@ type ProcessorV2 interface {
 	Processor
 	Process(in *models.PipelineGroupEvents, context PipelineContext)
 }
+
+// StoppableProcessor is implemented by the processors holding the resources, such as connections and goroutines,
+// which are released by Stop after the processors of the config stop.
+type StoppableProcessor interface {
+	Stop() error
+}
+
+// TickProcessor is implemented by the processors emitting the logs without the input logs, such as the metrics
+// aggregated on an interval. Tick is called about every second by the goroutine calling ProcessLogs, and the logs
+// returned are passed to the following processors.
+type TickProcessor interface {
+	Tick() []*protocol.Log
+}
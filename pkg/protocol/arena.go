@@ -0,0 +1,148 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"sync"
+	"unsafe"
+)
+
+const (
+	// ArenaContentChunkSize is the number of Log_Content carved from one chunk.
+	ArenaContentChunkSize = 256
+	// ArenaByteChunkSize is the size of one byte chunk used for small strings.
+	ArenaByteChunkSize = 32 * 1024
+	// arenaMaxSmallString is the largest string copied into a byte chunk,
+	// larger ones are allocated on the heap to avoid wasting chunk space.
+	arenaMaxSmallString = ArenaByteChunkSize / 8
+)
+
+var contentChunkPool = sync.Pool{
+	New: func() interface{} {
+		chunk := make([]Log_Content, ArenaContentChunkSize)
+		return &chunk
+	},
+}
+
+var pointerChunkPool = sync.Pool{
+	New: func() interface{} {
+		chunk := make([]*Log_Content, ArenaContentChunkSize)
+		return &chunk
+	},
+}
+
+// LogArena is a chunked allocator for Log_Content and small strings used while
+// constructing the logs of one batch. The Log_Content allocated from the arena
+// shares the lifecycle of the batch: the owner must call Release only after the
+// batch has been acknowledged by all flushers, after which any Log_Content
+// returned by the arena must not be referenced anymore. The strings are copied
+// into fresh chunks that are never reused, so they stay valid after Release and
+// may be kept by the processors, e.g. as map keys.
+//
+// A LogArena is not safe for concurrent use. A nil *LogArena is valid and falls
+// back to regular heap allocation, so callers can make the arena optional.
+type LogArena struct {
+	contents    []*[]Log_Content
+	contentsOff int
+	pointers    []*[]*Log_Content
+	pointersOff int
+	bytes       []byte
+}
+
+// NewLogArena returns an empty arena, chunks are taken from shared pools lazily.
+func NewLogArena() *LogArena {
+	return &LogArena{}
+}
+
+// NewContent returns a Log_Content with the given key and value.
+func (a *LogArena) NewContent(key, value string) *Log_Content {
+	if a == nil {
+		return &Log_Content{Key: key, Value: value}
+	}
+	if len(a.contents) == 0 || a.contentsOff == ArenaContentChunkSize {
+		a.contents = append(a.contents, contentChunkPool.Get().(*[]Log_Content))
+		a.contentsOff = 0
+	}
+	cont := &(*a.contents[len(a.contents)-1])[a.contentsOff]
+	a.contentsOff++
+	cont.Key = key
+	cont.Value = value
+	return cont
+}
+
+// NewContents returns an empty slice with capacity n for Log.Contents.
+func (a *LogArena) NewContents(n int) []*Log_Content {
+	if a == nil || n > ArenaContentChunkSize {
+		return make([]*Log_Content, 0, n)
+	}
+	if len(a.pointers) == 0 || a.pointersOff+n > ArenaContentChunkSize {
+		a.pointers = append(a.pointers, pointerChunkPool.Get().(*[]*Log_Content))
+		a.pointersOff = 0
+	}
+	chunk := *a.pointers[len(a.pointers)-1]
+	contents := chunk[a.pointersOff : a.pointersOff : a.pointersOff+n]
+	a.pointersOff += n
+	return contents
+}
+
+// String returns a string holding a copy of b. Small strings are packed into
+// byte chunks instead of being allocated one by one, a chunk is freed by the GC
+// once none of its strings is referenced.
+func (a *LogArena) String(b []byte) string {
+	if a == nil || len(b) > arenaMaxSmallString {
+		return string(b)
+	}
+	if len(b) == 0 {
+		return ""
+	}
+	if len(b) > cap(a.bytes)-len(a.bytes) {
+		a.bytes = make([]byte, 0, ArenaByteChunkSize)
+	}
+	off := len(a.bytes)
+	a.bytes = append(a.bytes, b...)
+	dst := a.bytes[off:len(a.bytes):len(a.bytes)]
+	return *(*string)(unsafe.Pointer(&dst))
+}
+
+// Release returns all chunks to the shared pools and resets the arena for reuse.
+func (a *LogArena) Release() {
+	if a == nil {
+		return
+	}
+	for i, chunk := range a.contents {
+		used := ArenaContentChunkSize
+		if i == len(a.contents)-1 {
+			used = a.contentsOff
+		}
+		// drop references so that the pooled chunk doesn't pin the values
+		s := (*chunk)[:used]
+		for j := range s {
+			s[j].Reset()
+		}
+		contentChunkPool.Put(chunk)
+	}
+	for _, chunk := range a.pointers {
+		s := *chunk
+		for j := range s {
+			s[j] = nil
+		}
+		pointerChunkPool.Put(chunk)
+	}
+	a.contents = a.contents[:0]
+	a.pointers = a.pointers[:0]
+	// the strings of the chunk may still be referenced, never write it again
+	a.bytes = nil
+	a.contentsOff, a.pointersOff = 0, 0
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogArena(t *testing.T) {
+	arena := NewLogArena()
+	var logs []*Log
+	for i := 0; i < ArenaContentChunkSize; i++ {
+		log := &Log{Contents: arena.NewContents(3)}
+		for j := 0; j < 3; j++ {
+			key := arena.String([]byte("key" + strconv.Itoa(j)))
+			log.Contents = append(log.Contents, arena.NewContent(key, strconv.Itoa(i)))
+		}
+		logs = append(logs, log)
+	}
+	for i, log := range logs {
+		assert.Len(t, log.Contents, 3)
+		for j, cont := range log.Contents {
+			assert.Equal(t, "key"+strconv.Itoa(j), cont.Key)
+			assert.Equal(t, strconv.Itoa(i), cont.Value)
+		}
+	}
+	assert.Len(t, arena.contents, 3)
+	assert.Equal(t, ArenaByteChunkSize, cap(arena.bytes))
+
+	// appending beyond the requested capacity must not overwrite the next log
+	logs[0].Contents = append(logs[0].Contents, arena.NewContent("extra", "v"))
+	assert.Equal(t, "key0", logs[1].Contents[0].Key)
+
+	large := strings.Repeat("x", arenaMaxSmallString+1)
+	assert.Equal(t, large, arena.String([]byte(large)))
+	assert.Equal(t, "", arena.String(nil))
+
+	kept := arena.String([]byte("kept"))
+	arena.Release()
+	assert.Empty(t, arena.contents)
+	assert.Empty(t, arena.pointers)
+	assert.Empty(t, arena.bytes)
+	assert.Equal(t, "k", arena.NewContent("k", "v").Key)
+	// the strings copied before Release are not overwritten
+	assert.Equal(t, "over", arena.String([]byte("over")))
+	assert.Equal(t, "kept", kept)
+}
+
+func TestLogArena_Nil(t *testing.T) {
+	var arena *LogArena
+	cont := arena.NewContent("k", "v")
+	assert.Equal(t, "v", cont.Value)
+	assert.Equal(t, 4, cap(arena.NewContents(4)))
+	assert.Equal(t, "s", arena.String([]byte("s")))
+	arena.Release()
+}
+
+func BenchmarkLogArena(b *testing.B) {
+	build := func(arena *LogArena) {
+		for i := 0; i < 512; i++ {
+			contents := arena.NewContents(8)
+			for j := 0; j < 8; j++ {
+				contents = append(contents, arena.NewContent("key", arena.String([]byte("value"))))
+			}
+		}
+	}
+	b.Run("heap", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			build(nil)
+		}
+	})
+	b.Run("arena", func(b *testing.B) {
+		b.ReportAllocs()
+		arena := NewLogArena()
+		for i := 0; i < b.N; i++ {
+			build(arena)
+			arena.Release()
+		}
+	})
+}
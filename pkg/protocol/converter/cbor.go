@@ -0,0 +1,70 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+// The major types of CBOR(https://www.rfc-editor.org/rfc/rfc8949.html#section-3.1) used by the converter.
+const (
+	cborMajorUint   = 0
+	cborMajorString = 3
+	cborMajorMap    = 5
+
+	cborAdditionalUint8  = 24
+	cborAdditionalUint16 = 25
+	cborAdditionalUint32 = 26
+	cborAdditionalUint64 = 27
+)
+
+func serializeCBOR(log *SingleLog) ([]byte, error) {
+	b := make([]byte, 0, 256)
+	b = appendCBORHead(b, cborMajorMap, numProtocolKeys)
+	b = appendCBORString(b, log.TimeKey)
+	b = appendCBORHead(b, cborMajorUint, uint64(log.Time))
+	b = appendCBORString(b, log.ContentsKey)
+	b = appendCBORStringMap(b, log.Contents)
+	b = appendCBORString(b, log.TagsKey)
+	b = appendCBORStringMap(b, log.Tags)
+	return b, nil
+}
+
+// appendCBORHead appends the initial byte and the following argument of a data item in the shortest form.
+func appendCBORHead(b []byte, major byte, v uint64) []byte {
+	switch {
+	case v < cborAdditionalUint8:
+		return append(b, major<<5|byte(v))
+	case v <= 0xff:
+		return append(b, major<<5|cborAdditionalUint8, byte(v))
+	case v <= 0xffff:
+		return appendBigEndian(append(b, major<<5|cborAdditionalUint16), 2, v)
+	case v <= 0xffffffff:
+		return appendBigEndian(append(b, major<<5|cborAdditionalUint32), 4, v)
+	default:
+		return appendBigEndian(append(b, major<<5|cborAdditionalUint64), 8, v)
+	}
+}
+
+func appendCBORString(b []byte, s string) []byte {
+	b = appendCBORHead(b, cborMajorString, uint64(len(s)))
+	return append(b, s...)
+}
+
+// appendCBORStringMap appends the map with the keys sorted, so that the output is stable.
+func appendCBORStringMap(b []byte, m map[string]string) []byte {
+	b = appendCBORHead(b, cborMajorMap, uint64(len(m)))
+	for _, k := range sortedKeys(m) {
+		b = appendCBORString(b, k)
+		b = appendCBORString(b, m[k])
+	}
+	return b
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"text/template"
 
 	"github.com/alibaba/ilogtail/pkg/flags"
 	"github.com/alibaba/ilogtail/pkg/models"
@@ -29,13 +30,21 @@ const (
 	ProtocolOtlpV1       = "otlp_v1"
 	ProtocolInfluxdb     = "influxdb"
 	ProtocolRaw          = "raw"
+	ProtocolGraphite     = "graphite"
+	ProtocolCEF          = "cef"
+	ProtocolLEEF         = "leef"
 )
 
 const (
-	EncodingNone     = "none"
-	EncodingJSON     = "json"
-	EncodingProtobuf = "protobuf"
-	EncodingCustom   = "custom"
+	EncodingNone        = "none"
+	EncodingJSON        = "json"
+	EncodingJSONCompact = "json_compact"
+	EncodingProtobuf    = "protobuf"
+	EncodingMsgpack     = "msgpack"
+	EncodingCBOR        = "cbor"
+	EncodingRaw         = "raw"
+	EncodingCustom      = "custom"
+	EncodingTemplate    = "template"
 )
 
 const (
@@ -44,6 +53,10 @@ const (
 	targetTagPrefix     = "tag."
 
 	targetGroupMetadataPrefix = "metadata."
+	targetRoutingPrefix       = "routing."
+	targetRoutingStreamPrefix = "routing.stream."
+	targetPartitionKey        = "routing.partition_key"
+	targetShardHash           = "routing.shard_hash"
 )
 
 const (
@@ -96,8 +109,13 @@ var specialTagConversionMap = map[string]string{
 
 var supportedEncodingMap = map[string]map[string]bool{
 	ProtocolCustomSingle: {
-		EncodingJSON:     true,
-		EncodingProtobuf: false,
+		EncodingJSON:        true,
+		EncodingJSONCompact: true,
+		EncodingProtobuf:    true,
+		EncodingMsgpack:     true,
+		EncodingCBOR:        true,
+		EncodingRaw:         true,
+		EncodingTemplate:    true,
 	},
 	ProtocolOtlpV1: {
 		EncodingNone: true,
@@ -108,6 +126,15 @@ var supportedEncodingMap = map[string]map[string]bool{
 	ProtocolRaw: {
 		EncodingCustom: true,
 	},
+	ProtocolGraphite: {
+		EncodingCustom: true,
+	},
+	ProtocolCEF: {
+		EncodingCustom: true,
+	},
+	ProtocolLEEF: {
+		EncodingCustom: true,
+	},
 }
 
 type Converter struct {
@@ -117,6 +144,16 @@ type Converter struct {
 	IgnoreUnExpectedData bool
 	TagKeyRenameMap      map[string]string
 	ProtocolKeyRenameMap map[string]string
+	// Schema and RejectHandler are set by SetJSONSchema to validate the output records.
+	Schema        *JSONSchema
+	RejectHandler RejectHandler
+	// Template and TemplateBatch are set by SetTemplate for the template encoding.
+	Template      *template.Template
+	TemplateBatch bool
+	// FieldMapping maps the CEF or LEEF keys to the log fields for the cef and leef protocols, set by SetFieldMapping.
+	FieldMapping map[string]string
+	// FieldSelection projects and renames the fields of the serialized logs, set by SetFieldSelection.
+	FieldSelection *FieldSelection
 }
 
 func NewConverterWithSep(protocol, encoding, sep string, ignoreUnExpectedData bool, tagKeyRenameMap, protocolKeyRenameMap map[string]string) (*Converter, error) {
@@ -135,7 +172,10 @@ func NewConverter(protocol, encoding string, tagKeyRenameMap, protocolKeyRenameM
 		return nil, fmt.Errorf("unsupported protocol: %s", protocol)
 	}
 	if supported, existed := enc[encoding]; !existed || !supported {
-		return nil, fmt.Errorf("unsupported encoding: %s for protocol %s", encoding, protocol)
+		// serializers registered by other modules are also supported by the custom_single protocol
+		if _, registered := GetSerializer(encoding); protocol != ProtocolCustomSingle || !registered {
+			return nil, fmt.Errorf("unsupported encoding: %s for protocol %s", encoding, protocol)
+		}
 	}
 	return &Converter{
 		Protocol:             protocol,
@@ -169,9 +209,18 @@ func (c *Converter) ToByteStream(logGroup *protocol.LogGroup) (stream interface{
 func (c *Converter) ToByteStreamWithSelectedFields(logGroup *protocol.LogGroup, targetFields []string) (stream interface{}, values []map[string]string, err error) {
 	switch c.Protocol {
 	case ProtocolCustomSingle:
-		return c.ConvertToSingleProtocolStream(logGroup, targetFields)
+		stream, values, err := c.ConvertToSingleProtocolStream(logGroup, targetFields)
+		if err != nil || c.Schema == nil {
+			return stream, values, err
+		}
+		stream, values = c.validateStream(stream, values)
+		return stream, values, nil
 	case ProtocolInfluxdb:
 		return c.ConvertToInfluxdbProtocolStream(logGroup, targetFields)
+	case ProtocolGraphite:
+		return c.ConvertToGraphiteProtocolStream(logGroup, targetFields)
+	case ProtocolCEF, ProtocolLEEF:
+		return c.ConvertToSIEMProtocolStream(logGroup, targetFields)
 	default:
 		return nil, nil, fmt.Errorf("unsupported protocol: %s", c.Protocol)
 	}
@@ -180,9 +229,16 @@ func (c *Converter) ToByteStreamWithSelectedFields(logGroup *protocol.LogGroup,
 func (c *Converter) ToByteStreamWithSelectedFieldsV2(groupEvents *models.PipelineGroupEvents, targetFields []string) (stream interface{}, values []map[string]string, err error) {
 	switch c.Protocol {
 	case ProtocolRaw:
-		return c.ConvertToRawStream(groupEvents, targetFields)
+		stream, values, err := c.ConvertToRawStream(groupEvents, targetFields)
+		if err != nil || c.Schema == nil {
+			return stream, values, err
+		}
+		stream, values = c.validateStream(stream, values)
+		return stream, values, nil
 	case ProtocolInfluxdb:
 		return c.ConvertToInfluxdbProtocolStreamV2(groupEvents, targetFields)
+	case ProtocolGraphite:
+		return c.ConvertToGraphiteProtocolStreamV2(groupEvents, targetFields)
 	default:
 		return nil, nil, fmt.Errorf("unsupported protocol: %s", c.Protocol)
 	}
@@ -70,6 +70,19 @@ func getByteStream(groupEvents *models.PipelineGroupEvents, targetValues map[str
 	return byteGroup, valueGroup, nil
 }
 
+// findRoutingField returns the partition key, the shard hash or a stream label of the routing hints of the group.
+func findRoutingField(field string, group *models.GroupInfo) string {
+	switch {
+	case field == targetPartitionKey:
+		return group.GetPartitionKey()
+	case field == targetShardHash:
+		return group.GetRoutingHints().ShardHash
+	case strings.HasPrefix(field, targetRoutingStreamPrefix):
+		return group.GetRoutingHints().Stream[field[len(targetRoutingStreamPrefix):]]
+	}
+	return ""
+}
+
 func findTargetFieldsInGroup(targetFields []string, group *models.GroupInfo) map[string]string {
 	if len(targetFields) == 0 {
 		return nil
@@ -85,6 +98,8 @@ func findTargetFieldsInGroup(targetFields []string, group *models.GroupInfo) map
 		} else if strings.HasPrefix(field, targetTagPrefix) {
 			tagName = field[len(targetTagPrefix):]
 			tagValue = group.GetTags().Get(tagName)
+		} else if strings.HasPrefix(field, targetRoutingPrefix) {
+			tagValue = findRoutingField(field, group)
 		}
 		targetKVs[field] = tagValue
 	}
@@ -209,6 +209,23 @@ func Test_findTargetFieldsInGroup(t *testing.T) {
 				group:        models.NewGroup(nil, models.NewTagsWithMap(map[string]string{"noTagKey": "tagValue"})),
 			}, want: map[string]string{"tag.tagKey": ""},
 		},
+		{
+			name: "find routing fields",
+			args: args{
+				targetFields: []string{"routing.partition_key", "routing.shard_hash", "routing.stream.app", "routing.stream.none"},
+				group: &models.GroupInfo{
+					PartitionKey: "db1",
+					RoutingHints: &models.RoutingHints{ShardHash: "abc", Stream: map[string]string{"app": "web"}},
+				},
+			}, want: map[string]string{"routing.partition_key": "db1", "routing.shard_hash": "abc", "routing.stream.app": "web", "routing.stream.none": ""},
+		},
+		{
+			name: "can not find routing fields",
+			args: args{
+				targetFields: []string{"routing.partition_key", "routing.shard_hash", "routing.stream.app"},
+				group:        models.NewGroup(nil, nil),
+			}, want: map[string]string{"routing.partition_key": "", "routing.shard_hash": "", "routing.stream.app": ""},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -15,7 +15,6 @@
 package protocol
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/alibaba/ilogtail/pkg/protocol"
@@ -29,7 +28,21 @@ const (
 )
 
 func (c *Converter) ConvertToSingleProtocolLogs(logGroup *protocol.LogGroup, targetFields []string) ([]map[string]interface{}, []map[string]string, error) {
-	convertedLogs, desiredValues := make([]map[string]interface{}, len(logGroup.Logs)), make([]map[string]string, len(logGroup.Logs))
+	singleLogs, desiredValues, err := c.ConvertToSingleLogs(logGroup, targetFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	convertedLogs := make([]map[string]interface{}, len(singleLogs))
+	for i, log := range singleLogs {
+		convertedLogs[i] = log.ToMap()
+	}
+	return convertedLogs, desiredValues, nil
+}
+
+// ConvertToSingleLogs converts the logGroup into the canonical logs shared by all the serializers.
+func (c *Converter) ConvertToSingleLogs(logGroup *protocol.LogGroup, targetFields []string) ([]*SingleLog, []map[string]string, error) {
+	singleLogs, desiredValues := make([]*SingleLog, len(logGroup.Logs)), make([]map[string]string, len(logGroup.Logs))
+	timeKey, contentsKey, tagsKey := c.protocolKey(protocolKeyTime), c.protocolKey(protocolKeyContent), c.protocolKey(protocolKeyTag)
 	for i, log := range logGroup.Logs {
 		contents, tags := convertLogToMap(log, logGroup.LogTags, logGroup.Source, logGroup.Topic, c.TagKeyRenameMap)
 
@@ -38,46 +51,57 @@ func (c *Converter) ConvertToSingleProtocolLogs(logGroup *protocol.LogGroup, tar
 			return nil, nil, err
 		}
 		desiredValues[i] = desiredValue
-
-		customSingleLog := make(map[string]interface{}, numProtocolKeys)
-		if newKey, ok := c.ProtocolKeyRenameMap[protocolKeyTime]; ok {
-			customSingleLog[newKey] = log.Time
-		} else {
-			customSingleLog[protocolKeyTime] = log.Time
-		}
-		if newKey, ok := c.ProtocolKeyRenameMap[protocolKeyContent]; ok {
-			customSingleLog[newKey] = contents
-		} else {
-			customSingleLog[protocolKeyContent] = contents
+		if c.FieldSelection != nil {
+			contents, tags = c.FieldSelection.apply(contents, tags)
 		}
-		if newKey, ok := c.ProtocolKeyRenameMap[protocolKeyTag]; ok {
-			customSingleLog[newKey] = tags
-		} else {
-			customSingleLog[protocolKeyTag] = tags
+
+		singleLogs[i] = &SingleLog{
+			Time:        log.Time,
+			Contents:    contents,
+			Tags:        tags,
+			TimeKey:     timeKey,
+			ContentsKey: contentsKey,
+			TagsKey:     tagsKey,
 		}
-		convertedLogs[i] = customSingleLog
 	}
-	return convertedLogs, desiredValues, nil
+	return singleLogs, desiredValues, nil
 }
 
 func (c *Converter) ConvertToSingleProtocolStream(logGroup *protocol.LogGroup, targetFields []string) ([][]byte, []map[string]string, error) {
-	convertedLogs, desiredValues, err := c.ConvertToSingleProtocolLogs(logGroup, targetFields)
+	if c.Encoding == EncodingTemplate {
+		return c.convertToTemplateStream(logGroup, targetFields)
+	}
+	serializer, ok := GetSerializer(c.Encoding)
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported encoding format: %s", c.Encoding)
+	}
+	singleLogs, desiredValues, err := c.ConvertToSingleLogs(logGroup, targetFields)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	marshaledLogs := make([][]byte, len(logGroup.Logs))
-	for i, log := range convertedLogs {
-		switch c.Encoding {
-		case EncodingJSON:
-			b, err := json.Marshal(log)
-			if err != nil {
-				return nil, nil, fmt.Errorf("unable to marshal log: %v", log)
-			}
-			marshaledLogs[i] = b
-		default:
-			return nil, nil, fmt.Errorf("unsupported encoding format: %s", c.Encoding)
+	if appender, ok := getBatchAppender(c.Encoding); ok {
+		marshaledLogs, err := encodeBatch(singleLogs, appender, serializer)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to marshal log with encoding %s: %v", c.Encoding, err)
+		}
+		return marshaledLogs, desiredValues, nil
+	}
+
+	marshaledLogs := make([][]byte, len(singleLogs))
+	for i, log := range singleLogs {
+		b, err := serializer(log)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to marshal log with encoding %s: %v", c.Encoding, err)
 		}
+		marshaledLogs[i] = b
 	}
 	return marshaledLogs, desiredValues, nil
 }
+
+func (c *Converter) protocolKey(key string) string {
+	if newKey, ok := c.ProtocolKeyRenameMap[key]; ok {
+		return newKey
+	}
+	return key
+}
@@ -0,0 +1,110 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldSelection projects and renames the fields of the logs when they are serialized, so that the flushers of a
+// pipeline could send different schemas of the same logs without the processors duplicating the logs. The fields
+// are the content keys with the prefix content., or the tag keys after the tag conversion with the prefix tag.,
+// such as content.msg and tag.host.name. A field ending with * matches the fields with the prefix, such as tag.k8s.*.
+type FieldSelection struct {
+	// Include is the fields serialized, all the fields are serialized if empty.
+	Include []string
+	// Exclude is the fields not serialized, which takes precedence over Include.
+	Exclude []string
+	// Rename maps the serialized fields to the new keys, such as {"content.msg": "message"}.
+	Rename map[string]string
+}
+
+// SetFieldSelection sets the field selection of the protocols serializing the logs field by field.
+// The fields used by the flusher, such as the partition keys of kafka, are read before the selection.
+func (c *Converter) SetFieldSelection(selection *FieldSelection) error {
+	switch c.Protocol {
+	case ProtocolCustomSingle, ProtocolCEF, ProtocolLEEF:
+	default:
+		return fmt.Errorf("field selection is not supported by protocol %s", c.Protocol)
+	}
+	for _, fields := range [][]string{selection.Include, selection.Exclude} {
+		for _, field := range fields {
+			if err := validateSelectedField(field); err != nil {
+				return err
+			}
+		}
+	}
+	for field, key := range selection.Rename {
+		if err := validateSelectedField(field); err != nil {
+			return err
+		}
+		if strings.HasSuffix(field, "*") {
+			return fmt.Errorf("wildcard field %s cannot be renamed", field)
+		}
+		if key == "" {
+			return fmt.Errorf("empty new key of field %s", field)
+		}
+	}
+	c.FieldSelection = selection
+	return nil
+}
+
+func validateSelectedField(field string) error {
+	if !strings.HasPrefix(field, targetContentPrefix) && !strings.HasPrefix(field, targetTagPrefix) {
+		return fmt.Errorf("field %s must start with %s or %s", field, targetContentPrefix, targetTagPrefix)
+	}
+	return nil
+}
+
+// apply returns the selected contents and tags of a log.
+func (s *FieldSelection) apply(contents, tags map[string]string) (map[string]string, map[string]string) {
+	return s.project(contents, targetContentPrefix), s.project(tags, targetTagPrefix)
+}
+
+func (s *FieldSelection) project(fields map[string]string, prefix string) map[string]string {
+	projected := make(map[string]string, len(fields))
+	for key, value := range fields {
+		field := prefix + key
+		if !s.selected(field) {
+			continue
+		}
+		if newKey, ok := s.Rename[field]; ok {
+			key = newKey
+		}
+		projected[key] = value
+	}
+	return projected
+}
+
+func (s *FieldSelection) selected(field string) bool {
+	if len(s.Include) > 0 && !matchField(s.Include, field) {
+		return false
+	}
+	return !matchField(s.Exclude, field)
+}
+
+func matchField(patterns []string, field string) bool {
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "*") {
+			if strings.HasPrefix(field, strings.TrimSuffix(pattern, "*")) {
+				return true
+			}
+		} else if pattern == field {
+			return true
+		}
+	}
+	return false
+}
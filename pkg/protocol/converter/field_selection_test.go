@@ -0,0 +1,67 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/flags"
+)
+
+func TestConverter_FieldSelection(t *testing.T) {
+	*flags.K8sFlag = false
+	c, err := NewConverter(ProtocolCustomSingle, EncodingJSON, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, c.SetFieldSelection(&FieldSelection{
+		Include: []string{"content.*", "tag.host.*"},
+		Exclude: []string{"content.user"},
+		Rename:  map[string]string{"content.msg": "message", "tag.host.name": "hostname"},
+	}))
+	stream, values, err := c.ToByteStreamWithSelectedFields(mockTemplateLogGroup(), []string{"content.user"})
+	require.NoError(t, err)
+	// the selected fields of the flusher are read before the selection
+	assert.Equal(t, []map[string]string{{"content.user": "alice"}, {}}, values)
+
+	logs := stream.([][]byte)
+	require.Equal(t, 2, len(logs))
+	var log struct {
+		Contents map[string]string
+		Tags     map[string]string
+	}
+	require.NoError(t, json.Unmarshal(logs[0], &log))
+	assert.Equal(t, map[string]string{"message": "login failed | user=alice\nretry"}, log.Contents)
+	assert.Equal(t, map[string]string{"hostname": "host-1", "host.ip": "172.10.0.56"}, log.Tags)
+}
+
+func TestConverter_FieldSelectionInvalid(t *testing.T) {
+	c, err := NewConverter(ProtocolCustomSingle, EncodingJSON, nil, nil)
+	require.NoError(t, err)
+	for _, selection := range []*FieldSelection{
+		{Include: []string{"msg"}},
+		{Exclude: []string{"contents.msg"}},
+		{Rename: map[string]string{"content.*": "all"}},
+		{Rename: map[string]string{"content.msg": ""}},
+	} {
+		assert.Error(t, c.SetFieldSelection(selection))
+	}
+
+	c, err = NewConverter(ProtocolInfluxdb, EncodingCustom, nil, nil)
+	require.NoError(t, err)
+	assert.Error(t, c.SetFieldSelection(&FieldSelection{Include: []string{"content.msg"}}))
+}
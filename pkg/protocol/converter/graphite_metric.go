@@ -0,0 +1,168 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+// ConvertToGraphiteProtocolStream converts @logGroup to []byte in the graphite plaintext protocol, the labels are
+// appended to the path in the tagged format. The field other than value is appended to the metric name as the last node.
+func (c *Converter) ConvertToGraphiteProtocolStream(logGroup *protocol.LogGroup, targetFields []string) (stream [][]byte, values []map[string]string, err error) {
+	buf := *GetPooledByteBuf()
+	reader := newMetricReader()
+	defer reader.recycle()
+
+	for _, log := range logGroup.Logs {
+		if err = reader.set(log); err != nil {
+			return nil, nil, err
+		}
+		metricName, fieldName := reader.readNames()
+		if fieldName != "value" {
+			metricName += "." + fieldName
+		}
+		labels, err := reader.readSortedLabels()
+		if err != nil {
+			return nil, nil, err
+		}
+		v, err := reader.readValue()
+		if err != nil {
+			return nil, nil, err
+		}
+		value, ok := graphiteValue(v)
+		if !ok {
+			if c.IgnoreUnExpectedData {
+				continue
+			}
+			return nil, nil, fmt.Errorf("unsupported graphite value: %v", v)
+		}
+		timestamp, err := reader.readTimestamp()
+		if err != nil {
+			return nil, nil, err
+		}
+		seconds := int64(log.Time)
+		if !timestamp.IsZero() {
+			seconds = timestamp.Unix()
+		}
+
+		buf = appendGraphitePath(buf, metricName)
+		for _, label := range labels {
+			buf = appendGraphiteTag(buf, label.key, label.value)
+		}
+		buf = appendGraphiteValue(buf, value, seconds)
+	}
+
+	// we are batching logs in LogGroup, so only support find tags in the logGroup.LogTags
+	var desiredValues map[string]string
+	if len(targetFields) > 0 {
+		desiredValues = findTargetValuesInLogTags(targetFields, logGroup.LogTags)
+	}
+	return [][]byte{buf}, []map[string]string{desiredValues}, nil
+}
+
+// ConvertToGraphiteProtocolStreamV2 converts the metric events to []byte in the graphite plaintext protocol,
+// each of the multi values is converted to a line with the key appended to the metric name. The typed values are
+// ignored, as graphite only supports the numeric values.
+func (c *Converter) ConvertToGraphiteProtocolStreamV2(groupEvents *models.PipelineGroupEvents, targetFields []string) (stream [][]byte, values []map[string]string, err error) {
+	buf := *GetPooledByteBuf()
+	for _, event := range groupEvents.Events {
+		metric, ok := event.(*models.Metric)
+		if !ok {
+			if c.IgnoreUnExpectedData {
+				logger.Warningf(context.Background(), util.AlarmConvert, "unsupported event type[%T] for converter with graphite protocol", event)
+				continue
+			}
+			return nil, nil, fmt.Errorf("unsupported event type: %v", event.GetType())
+		}
+		tags := metric.GetTags().SortTo(nil)
+		seconds := int64(metric.GetTimestamp() / 1e9)
+		appendLine := func(name string, value float64) {
+			if math.IsNaN(value) || math.IsInf(value, 0) {
+				return
+			}
+			buf = appendGraphitePath(buf, name)
+			for _, tag := range tags {
+				buf = appendGraphiteTag(buf, tag.Key, tag.Value)
+			}
+			buf = appendGraphiteValue(buf, value, seconds)
+		}
+
+		v := metric.GetValue()
+		if v.IsSingleValue() {
+			appendLine(metric.GetName(), v.GetSingleValue())
+		} else if v.IsMultiValues() {
+			for _, kv := range v.GetMultiValues().SortTo(nil) {
+				appendLine(metric.GetName()+"."+kv.Key, kv.Value)
+			}
+		}
+	}
+
+	// we are batching events in groupEvents, so only support find tags in the groupEvents.Group
+	var desiredValues map[string]string
+	if len(targetFields) > 0 {
+		desiredValues = findTargetFieldsInGroup(targetFields, groupEvents.Group)
+	}
+	return [][]byte{buf}, []map[string]string{desiredValues}, nil
+}
+
+func graphiteValue(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, !math.IsNaN(v) && !math.IsInf(v, 0)
+	case int64:
+		return float64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+// graphiteReplacer replaces the characters which are the separators of the graphite plaintext protocol.
+var graphiteReplacer = strings.NewReplacer(" ", "_", "\t", "_", "\n", "_", ";", "_", "=", "_")
+
+func appendGraphitePath(buf []byte, name string) []byte {
+	return append(buf, graphiteReplacer.Replace(name)...)
+}
+
+func appendGraphiteTag(buf []byte, key, value string) []byte {
+	if len(key) == 0 || len(value) == 0 {
+		return buf
+	}
+	buf = append(buf, ';')
+	buf = append(buf, graphiteReplacer.Replace(key)...)
+	buf = append(buf, '=')
+	return append(buf, graphiteReplacer.Replace(value)...)
+}
+
+func appendGraphiteValue(buf []byte, value float64, seconds int64) []byte {
+	buf = append(buf, ' ')
+	buf = strconv.AppendFloat(buf, value, 'g', -1, 64)
+	buf = append(buf, ' ')
+	buf = strconv.AppendInt(buf, seconds, 10)
+	return append(buf, '\n')
+}
@@ -0,0 +1,90 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+func TestConverter_ConvertToGraphiteProtocolStream(t *testing.T) {
+	c, err := NewConverter(ProtocolGraphite, EncodingCustom, nil, nil)
+	require.NoError(t, err)
+
+	logGroup := &protocol.LogGroup{
+		Logs: []*protocol.Log{
+			{
+				Time: 1434055562,
+				Contents: []*protocol.Log_Content{
+					{Key: metricNameKey, Value: "cpu_load"},
+					{Key: metricLabelsKey, Value: "region#$#cn|host#$#server 01"},
+					{Key: metricValueKey, Value: "0.6"},
+				},
+			},
+			{
+				Time: 1434055562,
+				Contents: []*protocol.Log_Content{
+					{Key: metricNameKey, Value: "disk:used"},
+					{Key: metricFieldKey, Value: "used"},
+					{Key: metricValueKey, Value: "10"},
+					{Key: metricValueTypeKey, Value: valueTypeInt},
+					{Key: metricTimeNanoKey, Value: "1434055600000000000"},
+				},
+			},
+		},
+		LogTags: []*protocol.LogTag{{Key: "__tag__:db", Value: "test"}},
+	}
+	stream, values, err := c.ToByteStreamWithSelectedFields(logGroup, []string{"tag.db"})
+	require.NoError(t, err)
+	assert.Equal(t, "cpu_load;host=server_01;region=cn 0.6 1434055562\ndisk.used 10 1434055600\n", string(stream.([][]byte)[0]))
+	assert.Equal(t, []map[string]string{{"tag.db": "test"}}, values)
+
+	logGroup.Logs[0].Contents[2].Value = "x"
+	logGroup.Logs[0].Contents = append(logGroup.Logs[0].Contents, &protocol.Log_Content{Key: metricValueTypeKey, Value: valueTypeString})
+	_, _, err = c.ToByteStreamWithSelectedFields(logGroup, nil)
+	assert.Error(t, err)
+	c.IgnoreUnExpectedData = true
+	stream, _, err = c.ToByteStreamWithSelectedFields(logGroup, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "disk.used 10 1434055600\n", string(stream.([][]byte)[0]))
+}
+
+func TestConverter_ConvertToGraphiteProtocolStreamV2(t *testing.T) {
+	c, err := NewConverter(ProtocolGraphite, EncodingCustom, nil, nil)
+	require.NoError(t, err)
+
+	groupEvents := &models.PipelineGroupEvents{
+		Group: models.NewGroup(models.NewMetadataWithMap(map[string]string{"db": "test"}), nil),
+		Events: []models.PipelineEvent{
+			models.NewSingleValueMetric("cpu.load", models.MetricTypeGauge, models.NewTagsWithKeyValues("region", "cn", "host", "server01"), 1434055562000000000, 0.6),
+			models.NewMultiValuesMetric("memory", models.MetricTypeGauge, models.NewTags(), 1434055562000000000,
+				models.NewMetricMultiValueWithMap(map[string]float64{"used": 2, "free": 1, "nan": math.NaN()}).Values),
+		},
+	}
+	stream, values, err := c.ToByteStreamWithSelectedFieldsV2(groupEvents, []string{"metadata.db"})
+	require.NoError(t, err)
+	assert.Equal(t, "cpu.load;host=server01;region=cn 0.6 1434055562\nmemory.free 1 1434055562\nmemory.used 2 1434055562\n", string(stream.([][]byte)[0]))
+	assert.Equal(t, []map[string]string{{"metadata.db": "test"}}, values)
+
+	groupEvents.Events = append(groupEvents.Events, models.NewByteArray([]byte("test")))
+	_, _, err = c.ToByteStreamWithSelectedFieldsV2(groupEvents, nil)
+	assert.Error(t, err)
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/models"
 	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 // ConvertToInfluxdbProtocolStream converts @logGroup to []byte in the influxdb line protocol,
@@ -97,34 +98,29 @@ func (c *Converter) ConvertToInfluxdbProtocolStreamV2(groupEvents *models.Pipeli
 		metric, ok := event.(*models.Metric)
 		if !ok {
 			if c.IgnoreUnExpectedData {
-				logger.Warningf(context.Background(), "CONVERT_ALARM", "unsupported event type[%T] for converter with influxdb protocol", event)
+				logger.Warningf(context.Background(), util.AlarmConvert, "unsupported event type[%T] for converter with influxdb protocol", event)
 				continue
 			}
 			return nil, nil, fmt.Errorf("unsupported event type: %v", event.GetType())
 		}
-		encoder.StartLine(metric.GetName())
-		for _, v := range metric.GetTags().SortTo(nil) {
-			encoder.AddTag(v.Key, v.Value)
+		fields, err := influxdbFields(metric)
+		if err != nil {
+			return nil, nil, err
 		}
-
-		v := metric.GetValue()
-		if v.IsSingleValue() {
-			vv, _ := lineprotocol.FloatValue(v.GetSingleValue())
-			encoder.AddField("value", vv)
-		} else if v.IsMultiValues() {
-			for name, value := range v.GetMultiValues().Iterator() {
-				vv, _ := lineprotocol.FloatValue(value)
-				encoder.AddField(name, vv)
+		if len(fields) == 0 {
+			if c.IgnoreUnExpectedData {
+				logger.Warningf(context.Background(), util.AlarmConvert, "metric[%s] without any valid field for converter with influxdb protocol", metric.GetName())
+				continue
 			}
+			return nil, nil, fmt.Errorf("metric %s has no valid field", metric.GetName())
 		}
 
-		fields := metric.GetTypedValue()
-		for name, value := range fields.Iterator() {
-			vv, ok := lineprotocol.NewValue(value.Value)
-			if !ok {
-				return nil, nil, fmt.Errorf("unsupported typed value:%+v", value)
-			}
-			encoder.AddField(name, vv)
+		encoder.StartLine(metric.GetName())
+		for _, v := range metric.GetTags().SortTo(nil) {
+			encoder.AddTag(v.Key, v.Value)
+		}
+		for _, field := range fields {
+			encoder.AddField(field.key, field.value)
 		}
 
 		t := int64(metric.GetTimestamp())
@@ -143,6 +139,37 @@ func (c *Converter) ConvertToInfluxdbProtocolStreamV2(groupEvents *models.Pipeli
 	return [][]byte{encoder.Bytes()}, []map[string]string{desiredValues}, nil
 }
 
+type influxdbField struct {
+	key   string
+	value lineprotocol.Value
+}
+
+// influxdbFields returns the fields of the metric in the order of the numeric values and then the typed values,
+// each sorted by the key. The non-finite numeric values are skipped, as they could not be represented in the line protocol.
+func influxdbFields(metric *models.Metric) ([]influxdbField, error) {
+	var fields []influxdbField
+	v := metric.GetValue()
+	if v.IsSingleValue() {
+		if vv, ok := lineprotocol.FloatValue(v.GetSingleValue()); ok {
+			fields = append(fields, influxdbField{key: "value", value: vv})
+		}
+	} else if v.IsMultiValues() {
+		for _, kv := range v.GetMultiValues().SortTo(nil) {
+			if vv, ok := lineprotocol.FloatValue(kv.Value); ok {
+				fields = append(fields, influxdbField{key: kv.Key, value: vv})
+			}
+		}
+	}
+	for _, kv := range metric.GetTypedValue().SortTo(nil) {
+		vv, ok := lineprotocol.NewValue(kv.Value.Value)
+		if !ok {
+			return nil, fmt.Errorf("unsupported typed value:%+v", kv.Value)
+		}
+		fields = append(fields, influxdbField{key: kv.Key, value: vv})
+	}
+	return fields, nil
+}
+
 func findTargetValuesInLogTags(targetFields []string, logTags []*protocol.LogTag) map[string]string {
 	if len(targetFields) == 0 {
 		return nil
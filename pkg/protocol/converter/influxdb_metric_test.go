@@ -16,6 +16,7 @@ package protocol
 
 import (
 	"fmt"
+	"math"
 	"testing"
 	"time"
 
@@ -139,6 +140,26 @@ func TestConverter_ConvertToInfluxdbProtocolStreamV2(t *testing.T) {
 				wantStream: [][]byte{[]byte(fmt.Sprintf("cpu1,k1=v1,k2=v2 f1=1 %d\ncpu2,k3=v3,k4=v4 value=1,f1=\"f1v\" %d\ncpu3,k3=v3,k4=v4 f2=true %d\n",
 					date.Add(1).UnixNano(), date.Add(2).UnixNano(), date.Add(2).UnixNano()))},
 			},
+			{
+				name: "contains multi fields value metric with non-finite values",
+				groupEvents: &models.PipelineGroupEvents{
+					Events: []models.PipelineEvent{
+						models.NewMetric("cpu1", models.MetricTypeGauge, models.NewTags(), date.UnixNano(),
+							models.NewMetricMultiValueWithMap(map[string]float64{"f3": 3, "f1": 1, "f2": math.NaN(), "f4": math.Inf(1)}),
+							models.NewMetricTypedValueWithMap(map[string]*models.TypedValue{"s2": {Type: models.ValueTypeString, Value: "b"}, "s1": {Type: models.ValueTypeString, Value: "a"}})),
+					},
+				},
+				wantStream: [][]byte{[]byte(fmt.Sprintf("cpu1 f1=1,f3=3,s1=\"a\",s2=\"b\" %d\n", date.UnixNano()))},
+			},
+			{
+				name: "contains metric without valid field",
+				groupEvents: &models.PipelineGroupEvents{
+					Events: []models.PipelineEvent{
+						models.NewMetric("cpu1", models.MetricTypeGauge, models.NewTags(), date.UnixNano(), &models.MetricSingleValue{Value: math.NaN()}, nil),
+					},
+				},
+				wantErr: true,
+			},
 		}
 
 		converter, err := NewConverter("influxdb", "custom", nil, nil)
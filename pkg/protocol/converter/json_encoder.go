@@ -0,0 +1,249 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+const jsonHex = "0123456789abcdef"
+
+// jsonSafeSet holds the ASCII characters which needn't be escaped, with the HTML escaping.
+var jsonSafeSet = func() (set [utf8.RuneSelf]bool) {
+	for c := 0x20; c < utf8.RuneSelf; c++ {
+		set[c] = c != '"' && c != '\\' && c != '<' && c != '>' && c != '&'
+	}
+	return
+}()
+
+// batchAppender appends the encoded log to b, it returns false if the log could not be encoded
+// the same way as the serializer, then the serializer is used for the log instead.
+type batchAppender func(b []byte, log *SingleLog) ([]byte, bool)
+
+// batchAppenders are the builtin encodings which append all the logs of a batch into one shared buffer,
+// the output is byte-for-byte identical to the serializer of the same encoding.
+// They are removed once another serializer is registered with the same encoding.
+var batchAppenders = map[string]batchAppender{
+	EncodingJSON:        appendSingleLogJSON,
+	EncodingJSONCompact: appendSingleLogJSONCompact,
+}
+
+func getBatchAppender(encoding string) (batchAppender, bool) {
+	serializerMutex.RLock()
+	defer serializerMutex.RUnlock()
+	appender, ok := batchAppenders[encoding]
+	return appender, ok
+}
+
+// encodeBatch encodes the logs into one buffer and returns the slice of each log, so that a batch costs
+// only a few allocations no matter how many logs it has.
+func encodeBatch(logs []*SingleLog, appender batchAppender, serializer Serializer) ([][]byte, error) {
+	var buf []byte
+	ends := make([]int, len(logs))
+	var fallbacks map[int][]byte
+	for i, log := range logs {
+		if i == 1 {
+			// the logs of a batch are usually alike, so the buffer is sized by the first one
+			grown := make([]byte, len(buf), len(buf)*len(logs)*5/4+1024)
+			copy(grown, buf)
+			buf = grown
+		}
+		var ok bool
+		start := len(buf)
+		if buf, ok = appender(buf, log); !ok {
+			buf = buf[:start]
+			b, err := serializer(log)
+			if err != nil {
+				return nil, err
+			}
+			if fallbacks == nil {
+				fallbacks = make(map[int][]byte)
+			}
+			fallbacks[i] = b
+		}
+		ends[i] = len(buf)
+	}
+	encoded := make([][]byte, len(logs))
+	start := 0
+	for i, end := range ends {
+		if b, ok := fallbacks[i]; ok {
+			encoded[i] = b
+		} else {
+			encoded[i] = buf[start:end:end]
+		}
+		start = end
+	}
+	return encoded, nil
+}
+
+// appendSingleLogJSON is the same as json.Marshal(log.ToMap()).
+func appendSingleLogJSON(b []byte, log *SingleLog) ([]byte, bool) {
+	keys := [numProtocolKeys]string{log.TimeKey, log.ContentsKey, log.TagsKey}
+	if keys[0] == keys[1] || keys[0] == keys[2] || keys[1] == keys[2] {
+		return b, false
+	}
+	sort.Strings(keys[:])
+	b = append(b, '{')
+	for i, key := range keys {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendJSONString(b, key)
+		b = append(b, ':')
+		switch key {
+		case log.TimeKey:
+			b = strconv.AppendUint(b, uint64(log.Time), 10)
+		case log.ContentsKey:
+			b = appendJSONStringMap(b, log.Contents)
+		default:
+			b = appendJSONStringMap(b, log.Tags)
+		}
+	}
+	return append(b, '}'), true
+}
+
+// appendSingleLogJSONCompact is the same as serializeJSONCompact.
+func appendSingleLogJSONCompact(b []byte, log *SingleLog) ([]byte, bool) {
+	if _, ok := log.Contents[compactTimeKey]; ok {
+		return b, false
+	}
+	fields := make([]jsonField, 0, len(log.Contents)+len(log.Tags))
+	for k, v := range log.Contents {
+		fields = append(fields, jsonField{key: k, value: v})
+	}
+	for k, v := range log.Tags {
+		// the tag would overwrite the content with the same flattened key
+		if _, ok := log.Contents[tagPrefix+k]; ok {
+			return b, false
+		}
+		fields = append(fields, jsonField{key: tagPrefix + k, value: v})
+	}
+	fields = append(fields, jsonField{key: compactTimeKey, isTime: true})
+	sort.Slice(fields, func(i, j int) bool { return fields[i].key < fields[j].key })
+
+	b = append(b, '{')
+	for i, f := range fields {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendJSONString(b, f.key)
+		b = append(b, ':')
+		if f.isTime {
+			b = strconv.AppendUint(b, uint64(log.Time), 10)
+		} else {
+			b = appendJSONString(b, f.value)
+		}
+	}
+	return append(b, '}'), true
+}
+
+type jsonField struct {
+	key    string
+	value  string
+	isTime bool
+}
+
+// appendJSONStringMap appends the map with the keys sorted, which is the order of encoding/json.
+func appendJSONStringMap(b []byte, m map[string]string) []byte {
+	if m == nil {
+		return append(b, "null"...)
+	}
+	// sort the keys of small maps on the stack, which are the most common
+	var stackKeys [16]string
+	keys := stackKeys[:0]
+	if len(m) > len(stackKeys) {
+		keys = make([]string, 0, len(m))
+	}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	insertionSortStrings(keys)
+	b = append(b, '{')
+	for i, k := range keys {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendJSONString(b, k)
+		b = append(b, ':')
+		b = appendJSONString(b, m[k])
+	}
+	return append(b, '}')
+}
+
+// appendJSONString appends the quoted string escaped the same way as encoding/json, including the HTML escaping.
+func appendJSONString(b []byte, s string) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if jsonSafeSet[c] {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\b':
+				b = append(b, '\\', 'b')
+			case '\f':
+				b = append(b, '\\', 'f')
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', jsonHex[c>>4], jsonHex[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', jsonHex[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
+
+func insertionSortStrings(s []string) {
+	if len(s) > 16 {
+		sort.Strings(s)
+		return
+	}
+	for i := 1; i < len(s); i++ {
+		for j := i; j > 0 && s[j] < s[j-1]; j-- {
+			s[j], s[j-1] = s[j-1], s[j]
+		}
+	}
+}
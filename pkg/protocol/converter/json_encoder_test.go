@@ -0,0 +1,123 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/flags"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+func TestAppendJSONString(t *testing.T) {
+	for _, s := range []string{
+		"", "plain", `quote " and \ backslash`, "<html> & </html>", "\n\r\t\x00\x1f\x7f",
+		"中文", "invalid \xff\xfe utf8", "line para ", "emoji 😀",
+	} {
+		expected, err := json.Marshal(s)
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), string(appendJSONString(nil, s)), s)
+	}
+}
+
+func TestEncodeBatch_SameAsSerializer(t *testing.T) {
+	logs := []*SingleLog{
+		{
+			Time:     1662434209,
+			Contents: map[string]string{"content": "a <b> \"c\"", "z": "\xff", "__tag__:zzz": "not a tag"},
+			Tags:     map[string]string{"host.ip": "172.10.0.56", "a": "1"},
+		},
+		{Time: 1, Contents: map[string]string{}, Tags: nil},
+		// the keys renamed to the same key, or the flattened keys overwriting each other
+		{Time: 2, Contents: map[string]string{"__tag__:a": "content"}, Tags: map[string]string{"a": "tag"}},
+		{Time: 3, Contents: map[string]string{"__time__": "content"}},
+	}
+	for i, log := range logs {
+		log.TimeKey, log.ContentsKey, log.TagsKey = protocolKeyTime, protocolKeyContent, protocolKeyTag
+		if i == 2 {
+			log.TimeKey, log.ContentsKey, log.TagsKey = "@timestamp", "@timestamp", "attributes"
+		}
+	}
+	for encoding, serializer := range map[string]Serializer{EncodingJSON: serializeJSON, EncodingJSONCompact: serializeJSONCompact} {
+		encoded, err := encodeBatch(logs, batchAppenders[encoding], serializer)
+		require.NoError(t, err)
+		require.Len(t, encoded, len(logs))
+		for i, log := range logs {
+			expected, err := serializer(log)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), string(encoded[i]), "encoding %s log %d", encoding, i)
+		}
+		// appending to one log must not overwrite the next one
+		_ = append(encoded[0], '!')
+		assert.NotEqual(t, byte('!'), encoded[1][0])
+	}
+}
+
+func mockBatchLogGroup(n int) *protocol.LogGroup {
+	logGroup := &protocol.LogGroup{
+		Topic:   "file",
+		Source:  "172.10.0.56",
+		LogTags: []*protocol.LogTag{{Key: "__hostname__", Value: "host-1"}, {Key: "__pack_id__", Value: "ABC-1"}},
+	}
+	now := uint32(time.Now().Unix())
+	for i := 0; i < n; i++ {
+		logGroup.Logs = append(logGroup.Logs, &protocol.Log{
+			Time: now,
+			Contents: []*protocol.Log_Content{
+				{Key: "method", Value: "PUT"},
+				{Key: "status", Value: "200"},
+				{Key: "latency", Value: strconv.Itoa(i)},
+				{Key: "url", Value: "/index.html?user=" + strconv.Itoa(i) + "&from=<home>"},
+				{Key: "user_agent", Value: "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko)"},
+				{Key: "content", Value: "10.0.0.1 - - \"PUT /index.html HTTP/1.1\" 200 512"},
+				{Key: "__tag__:__path__", Value: "/var/log/access.log"},
+			},
+		})
+	}
+	return logGroup
+}
+
+// BenchmarkJSONBatch compares the batch encoder with encoding/json on a batch of 50k logs.
+func BenchmarkJSONBatch(b *testing.B) {
+	*flags.K8sFlag = false
+	const batchSize = 50000
+	logGroup := mockBatchLogGroup(batchSize)
+	c, err := NewConverter(ProtocolCustomSingle, EncodingJSON, nil, nil)
+	require.NoError(b, err)
+
+	b.Run("encoding/json", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			singleLogs, _, _ := c.ConvertToSingleLogs(logGroup, nil)
+			for _, log := range singleLogs {
+				_, _ = json.Marshal(log.ToMap())
+			}
+		}
+		b.ReportMetric(float64(batchSize*b.N)/b.Elapsed().Seconds(), "events/s")
+	})
+	b.Run("batch", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _, _ = c.ConvertToSingleProtocolStream(logGroup, nil)
+		}
+		b.ReportMetric(float64(batchSize*b.N)/b.Elapsed().Seconds(), "events/s")
+	})
+}
@@ -0,0 +1,165 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// schemaURL is the url of the schema document added to the compiler, which resolves the local references.
+const schemaURL = "schema.json"
+
+// JSONSchema validates the JSON documents against a JSON Schema of draft 4 to draft 2020-12, which is the latest
+// draft by default and could be declared by $schema, or the schema object of an OpenAPI 3.0 document. The formats
+// are asserted, and only the local references like #/definitions/log or #/components/schemas/Log are supported.
+type JSONSchema struct {
+	schema *jsonschema.Schema
+}
+
+// RejectHandler handles the output record violating the JSON Schema of the converter. The record may be recycled
+// after the handler returns, so it must be copied if retained.
+type RejectHandler func(record []byte, err error)
+
+// NewJSONSchema compiles the JSON Schema in data. The pointer is the JSON pointer of the schema within the document,
+// such as /components/schemas/Log in an OpenAPI document, and the empty pointer means the whole document.
+func NewJSONSchema(data []byte, pointer string) (*JSONSchema, error) {
+	document, err := decodeJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid json schema: %v", err)
+	}
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat = true
+	compiler.LoadURL = func(url string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("unsupported $ref %s, only the local references are supported", url)
+	}
+	if root, ok := document.(map[string]interface{}); ok && root["openapi"] != nil {
+		// the schema object of OpenAPI 3.0 is based on draft 4, e.g. the boolean exclusiveMinimum
+		compiler.Draft = jsonschema.Draft4
+		document = convertNullable(document)
+		if data, err = json.Marshal(document); err != nil {
+			return nil, err
+		}
+	}
+	if err = compiler.AddResource(schemaURL, bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("invalid json schema: %v", err)
+	}
+	schema, err := compiler.Compile(schemaURL + "#" + pointer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid json schema: %v", err)
+	}
+	return &JSONSchema{schema: schema}, nil
+}
+
+// Validate returns the first violation of the JSON document in data, or nil if it is valid.
+func (s *JSONSchema) Validate(data []byte) error {
+	value, err := decodeJSON(data)
+	if err != nil {
+		return fmt.Errorf("invalid json: %v", err)
+	}
+	err = s.schema.Validate(value)
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+	// the only cause is the keyword violated, such as the missing property of required, and the causes of
+	// the composition keywords like anyOf are described by the keyword itself
+	for len(validationErr.Causes) == 1 {
+		validationErr = validationErr.Causes[0]
+	}
+	location := validationErr.InstanceLocation
+	if location == "" {
+		location = "/"
+	}
+	return fmt.Errorf("%s: %s", location, validationErr.Message)
+}
+
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected data after the json value")
+	}
+	return value, nil
+}
+
+// convertNullable converts the nullable keyword of OpenAPI 3.0, which is not a keyword of JSON Schema. The null type
+// is added to the type, or the schema without type is wrapped by anyOf with the null type.
+func convertNullable(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		for i := range v {
+			v[i] = convertNullable(v[i])
+		}
+	case map[string]interface{}:
+		for key := range v {
+			v[key] = convertNullable(v[key])
+		}
+		if nullable, _ := v["nullable"].(bool); nullable {
+			delete(v, "nullable")
+			if typ, ok := v["type"].(string); ok {
+				v["type"] = []interface{}{typ, "null"}
+				return v
+			}
+			return map[string]interface{}{"anyOf": []interface{}{map[string]interface{}{"type": "null"}, v}}
+		}
+	}
+	return value
+}
+
+// SetJSONSchema makes the converter validate each output record against the schema, the violating records are
+// removed from the output and passed to the handler. It is supported by the json encodings of the custom_single
+// protocol, and the raw protocol without the separator, whose records are the JSON ByteArray events.
+func (c *Converter) SetJSONSchema(schema *JSONSchema, handler RejectHandler) error {
+	switch {
+	case c.Protocol == ProtocolCustomSingle && (c.Encoding == EncodingJSON || c.Encoding == EncodingJSONCompact):
+	case c.Protocol == ProtocolRaw && c.Separator == "":
+	default:
+		return fmt.Errorf("json schema is not supported by protocol %s with encoding %s", c.Protocol, c.Encoding)
+	}
+	c.Schema = schema
+	c.RejectHandler = handler
+	return nil
+}
+
+func (c *Converter) validateStream(stream [][]byte, values []map[string]string) ([][]byte, []map[string]string) {
+	n := 0
+	for i, record := range stream {
+		if err := c.Schema.Validate(record); err != nil {
+			if c.RejectHandler != nil {
+				c.RejectHandler(record, err)
+			}
+			continue
+		}
+		stream[n] = record
+		if i < len(values) {
+			values[n] = values[i]
+		}
+		n++
+	}
+	if len(values) > n {
+		values = values[:n]
+	}
+	return stream[:n], values
+}
@@ -0,0 +1,152 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/flags"
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+const testLogSchema = `{
+	"type": "object",
+	"required": ["time", "contents"],
+	"properties": {
+		"time": {"type": "integer", "minimum": 0},
+		"contents": {
+			"type": "object",
+			"required": ["method", "status"],
+			"properties": {
+				"method": {"enum": ["GET", "POST"]},
+				"status": {"type": "string", "pattern": "^[1-5][0-9]{2}$"},
+				"ip": {"type": "string", "format": "ipv4"}
+			},
+			"additionalProperties": false
+		},
+		"tags": {"$ref": "#/definitions/stringMap"}
+	},
+	"definitions": {
+		"stringMap": {"type": "object", "additionalProperties": {"type": "string"}}
+	}
+}`
+
+func TestJSONSchema_Validate(t *testing.T) {
+	schema, err := NewJSONSchema([]byte(testLogSchema), "")
+	require.NoError(t, err)
+
+	cases := []struct {
+		record string
+		err    string
+	}{
+		{`{"time":1,"contents":{"method":"GET","status":"200","ip":"10.0.0.1"},"tags":{"a":"b"}}`, ""},
+		{`{"time":1.0,"contents":{"method":"POST","status":"404"}}`, ""},
+		{`{"contents":{"method":"GET","status":"200"}}`, "/: missing properties: 'time'"},
+		{`{"time":-1,"contents":{"method":"GET","status":"200"}}`, "/time: must be >= 0 but found -1"},
+		{`{"time":1.5,"contents":{"method":"GET","status":"200"}}`, "/time: expected integer, but got number"},
+		{`{"time":1,"contents":{"method":"PUT","status":"200"}}`, `/contents/method: value must be one of "GET", "POST"`},
+		{`{"time":1,"contents":{"method":"GET","status":"600"}}`, "/contents/status: does not match pattern '^[1-5][0-9]{2}$'"},
+		{`{"time":1,"contents":{"method":"GET","status":"200","ip":"::1"}}`, "/contents/ip: '::1' is not valid 'ipv4'"},
+		{`{"time":1,"contents":{"method":"GET","status":"200","x":"1"}}`, "/contents: additionalProperties 'x' not allowed"},
+		{`{"time":1,"contents":{"method":"GET","status":"200"},"tags":{"a":1}}`, "/tags/a: expected string, but got number"},
+		{`{"time":1`, "invalid json: unexpected EOF"},
+	}
+	for _, c := range cases {
+		err := schema.Validate([]byte(c.record))
+		if c.err == "" {
+			assert.NoError(t, err, c.record)
+		} else if assert.Error(t, err, c.record) {
+			assert.Equal(t, c.err, err.Error())
+		}
+	}
+}
+
+func TestJSONSchema_OpenAPI(t *testing.T) {
+	document := `{
+		"openapi": "3.0.0",
+		"components": {"schemas": {
+			"Event": {
+				"type": "object",
+				"required": ["name"],
+				"properties": {
+					"name": {"type": "string", "minLength": 1, "maxLength": 8},
+					"parent": {"allOf": [{"$ref": "#/components/schemas/Event"}], "nullable": true},
+					"latency": {"type": "number", "minimum": 0, "exclusiveMinimum": true},
+					"kind": {"oneOf": [{"type": "string"}, {"type": "integer"}]},
+					"time": {"type": "string", "format": "date-time"}
+				}
+			}
+		}}
+	}`
+	schema, err := NewJSONSchema([]byte(document), "/components/schemas/Event")
+	require.NoError(t, err)
+
+	assert.NoError(t, schema.Validate([]byte(`{"name":"a","parent":{"name":"b","parent":null},"latency":0.1,"kind":1,"time":"2023-01-02T15:04:05Z"}`)))
+	assert.EqualError(t, schema.Validate([]byte(`{"name":""}`)), "/name: length must be >= 1, but got 0")
+	assert.EqualError(t, schema.Validate([]byte(`{"name":"a","parent":{}}`)), "/parent: anyOf failed")
+	assert.EqualError(t, schema.Validate([]byte(`{"name":"a","latency":0}`)), "/latency: must be > 0 but found 0")
+	assert.EqualError(t, schema.Validate([]byte(`{"name":"a","kind":true}`)), "/kind: oneOf failed")
+	assert.EqualError(t, schema.Validate([]byte(`{"name":"a","time":"yesterday"}`)), "/time: 'yesterday' is not valid 'date-time'")
+
+	_, err = NewJSONSchema([]byte(document), "/components/schemas/Missing")
+	assert.Error(t, err)
+	_, err = NewJSONSchema([]byte(`{"$ref": "http://example.com/schema.json"}`), "")
+	assert.Error(t, err)
+	_, err = NewJSONSchema([]byte(`{"pattern": "("}`), "")
+	assert.Error(t, err)
+}
+
+func TestConverter_SetJSONSchema(t *testing.T) {
+	*flags.K8sFlag = false
+	schema, err := NewJSONSchema([]byte(`{"properties": {"contents": {"required": ["status"]}}}`), "")
+	require.NoError(t, err)
+
+	c, err := NewConverter(ProtocolCustomSingle, EncodingJSON, nil, nil)
+	require.NoError(t, err)
+	var rejected []string
+	require.NoError(t, c.SetJSONSchema(schema, func(record []byte, err error) {
+		rejected = append(rejected, err.Error())
+	}))
+	logGroup := &protocol.LogGroup{Logs: []*protocol.Log{
+		{Time: 1, Contents: []*protocol.Log_Content{{Key: "status", Value: "200"}}},
+		{Time: 2, Contents: []*protocol.Log_Content{{Key: "message", Value: "no status"}}},
+		{Time: 3, Contents: []*protocol.Log_Content{{Key: "status", Value: "500"}}},
+	}}
+	stream, values, err := c.ToByteStreamWithSelectedFields(logGroup, []string{"content.status"})
+	require.NoError(t, err)
+	require.Len(t, stream.([][]byte), 2)
+	assert.Contains(t, string(stream.([][]byte)[1]), `"500"`)
+	assert.Equal(t, []map[string]string{{"content.status": "200"}, {"content.status": "500"}}, values)
+	assert.Equal(t, []string{"/contents: missing properties: 'status'"}, rejected)
+
+	raw, err := NewConverter(ProtocolRaw, EncodingCustom, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, raw.SetJSONSchema(schema, nil))
+	groupEvents := &models.PipelineGroupEvents{
+		Group:  models.NewGroup(models.NewMetadata(), models.NewTags()),
+		Events: []models.PipelineEvent{models.ByteArray(`{"contents":{"status":"200"}}`), models.ByteArray(`not json`)},
+	}
+	stream, _, err = raw.ToByteStreamWithSelectedFieldsV2(groupEvents, nil)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(`{"contents":{"status":"200"}}`)}, stream)
+
+	influxdb, err := NewConverter(ProtocolInfluxdb, EncodingCustom, nil, nil)
+	require.NoError(t, err)
+	assert.Error(t, influxdb.SetJSONSchema(schema, nil))
+}
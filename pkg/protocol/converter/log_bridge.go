@@ -0,0 +1,242 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"sort"
+
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+const (
+	v1BodyKey   = "content"
+	v1LevelKey  = "level"
+	v1TopicKey  = "__topic__"
+	v1SourceKey = "__source__"
+)
+
+// LogV1Event wraps a v1 protocol.Log as a v2 log event without copying it. The content field is the body of
+// the event and the other fields are the tags, which are read and written in the contents of the wrapped log
+// directly, so that converting the event back by ToV1Log costs nothing. The body and the severity are only
+// materialized when they are read.
+type LogV1Event struct {
+	Log *protocol.Log
+
+	name              string
+	observedTimestamp uint64
+	tags              *logContentsTags
+	body              []byte
+	bodyLoaded        bool
+}
+
+// NewLogV1Event returns the v2 log event backed by the v1 log.
+func NewLogV1Event(log *protocol.Log) *LogV1Event {
+	return &LogV1Event{Log: log}
+}
+
+func (e *LogV1Event) GetName() string {
+	return e.name
+}
+
+func (e *LogV1Event) SetName(name string) {
+	e.name = name
+}
+
+func (e *LogV1Event) GetTags() models.Tags {
+	if e.tags == nil {
+		e.tags = &logContentsTags{event: e}
+	}
+	return e.tags
+}
+
+func (e *LogV1Event) GetType() models.EventType {
+	return models.EventTypeLogging
+}
+
+func (e *LogV1Event) GetTimestamp() uint64 {
+	return uint64(e.Log.GetTime()) * 1e9
+}
+
+func (e *LogV1Event) GetObservedTimestamp() uint64 {
+	return e.observedTimestamp
+}
+
+func (e *LogV1Event) SetObservedTimestamp(timestamp uint64) {
+	e.observedTimestamp = timestamp
+}
+
+// GetBody returns the content field of the log, which is copied only on the first call.
+func (e *LogV1Event) GetBody() []byte {
+	if !e.bodyLoaded {
+		if i := findContent(e.Log, v1BodyKey); i >= 0 {
+			e.body = []byte(e.Log.Contents[i].Value)
+		}
+		e.bodyLoaded = true
+	}
+	return e.body
+}
+
+// GetSeverity returns the severity normalized from the level field of the log.
+func (e *LogV1Event) GetSeverity() models.Severity {
+	if i := findContent(e.Log, v1LevelKey); i >= 0 {
+		return models.NormalizeSeverity(e.Log.Contents[i].Value)
+	}
+	return models.SeverityUnspecified
+}
+
+// ToV1Log returns the v1 log of the log events. The log wrapped by LogV1Event is returned as is, and the
+// *models.Log is converted with its tags sorted, followed by the content and the level fields.
+// It returns false for the other events.
+func ToV1Log(event models.PipelineEvent) (*protocol.Log, bool) {
+	switch e := event.(type) {
+	case *LogV1Event:
+		return e.Log, true
+	case *models.Log:
+		tags := e.GetTags()
+		log := &protocol.Log{
+			Time:     uint32(e.GetTimestamp() / 1e9),
+			Contents: make([]*protocol.Log_Content, 0, tags.Len()+2),
+		}
+		for _, tag := range tags.SortTo(nil) {
+			log.Contents = append(log.Contents, &protocol.Log_Content{Key: tag.Key, Value: tag.Value})
+		}
+		if body := e.GetBody(); len(body) > 0 {
+			log.Contents = append(log.Contents, &protocol.Log_Content{Key: v1BodyKey, Value: string(body)})
+		}
+		if severity := e.GetSeverity(); severity != models.SeverityUnspecified && !tags.Contains(v1LevelKey) {
+			log.Contents = append(log.Contents, &protocol.Log_Content{Key: v1LevelKey, Value: severity.String()})
+		}
+		return log, true
+	default:
+		return nil, false
+	}
+}
+
+// LogGroupToPipelineGroupEvents wraps the logs of the v1 LogGroup as LogV1Event. The LogTags are the group tags,
+// with the Topic and the Source stored in the __topic__ and __source__ tags.
+func LogGroupToPipelineGroupEvents(logGroup *protocol.LogGroup) *models.PipelineGroupEvents {
+	tags := models.NewTags()
+	for _, tag := range logGroup.LogTags {
+		tags.Add(tag.Key, tag.Value)
+	}
+	if logGroup.Topic != "" {
+		tags.Add(v1TopicKey, logGroup.Topic)
+	}
+	if logGroup.Source != "" {
+		tags.Add(v1SourceKey, logGroup.Source)
+	}
+	events := make([]models.PipelineEvent, len(logGroup.Logs))
+	for i, log := range logGroup.Logs {
+		events[i] = NewLogV1Event(log)
+	}
+	return &models.PipelineGroupEvents{
+		Group:  models.NewGroup(models.NewMetadata(), tags),
+		Events: events,
+	}
+}
+
+func findContent(log *protocol.Log, key string) int {
+	for i, cont := range log.GetContents() {
+		if cont.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// logContentsTags is the tags view of the contents of the v1 log except the content field. The contents of
+// a log are few, so the lookups scan the contents instead of building an index.
+type logContentsTags struct {
+	event *LogV1Event
+}
+
+func (t *logContentsTags) Add(key string, value string) {
+	log := t.event.Log
+	if key == v1BodyKey {
+		t.event.bodyLoaded = false
+	}
+	if i := findContent(log, key); i >= 0 {
+		log.Contents[i].Value = value
+		return
+	}
+	log.Contents = append(log.Contents, &protocol.Log_Content{Key: key, Value: value})
+}
+
+func (t *logContentsTags) AddAll(items map[string]string) {
+	for k, v := range items {
+		t.Add(k, v)
+	}
+}
+
+func (t *logContentsTags) Get(key string) string {
+	if key == v1BodyKey {
+		return ""
+	}
+	if i := findContent(t.event.Log, key); i >= 0 {
+		return t.event.Log.Contents[i].Value
+	}
+	return ""
+}
+
+func (t *logContentsTags) Contains(key string) bool {
+	return key != v1BodyKey && findContent(t.event.Log, key) >= 0
+}
+
+func (t *logContentsTags) Delete(key string) {
+	if key == v1BodyKey {
+		return
+	}
+	log := t.event.Log
+	if i := findContent(log, key); i >= 0 {
+		log.Contents = append(log.Contents[:i], log.Contents[i+1:]...)
+	}
+}
+
+func (t *logContentsTags) Merge(other models.KeyValues[string]) {
+	t.AddAll(other.Iterator())
+}
+
+// Iterator materializes the tags into a new map, the writes to which are not applied to the log.
+func (t *logContentsTags) Iterator() map[string]string {
+	items := make(map[string]string, len(t.event.Log.GetContents()))
+	for _, cont := range t.event.Log.GetContents() {
+		if cont.Key != v1BodyKey {
+			items[cont.Key] = cont.Value
+		}
+	}
+	return items
+}
+
+func (t *logContentsTags) SortTo(buf []models.KeyValue[string]) []models.KeyValue[string] {
+	buf = buf[:0]
+	for _, cont := range t.event.Log.GetContents() {
+		if cont.Key != v1BodyKey {
+			buf = append(buf, models.KeyValue[string]{Key: cont.Key, Value: cont.Value})
+		}
+	}
+	sort.Sort(models.KeyValueSlice[string](buf))
+	return buf
+}
+
+func (t *logContentsTags) Len() int {
+	n := 0
+	for _, cont := range t.event.Log.GetContents() {
+		if cont.Key != v1BodyKey {
+			n++
+		}
+	}
+	return n
+}
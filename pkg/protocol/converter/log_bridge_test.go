@@ -0,0 +1,137 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+func mockBridgeLog() *protocol.Log {
+	return &protocol.Log{
+		Time: 1662434209,
+		Contents: []*protocol.Log_Content{
+			{Key: "content", Value: "PUT /index.html 200"},
+			{Key: "level", Value: "warning"},
+			{Key: "method", Value: "PUT"},
+		},
+	}
+}
+
+func TestLogV1Event(t *testing.T) {
+	log := mockBridgeLog()
+	event := NewLogV1Event(log)
+	assert.Equal(t, models.EventTypeLogging, event.GetType())
+	assert.Equal(t, uint64(1662434209)*1e9, event.GetTimestamp())
+	assert.Equal(t, []byte("PUT /index.html 200"), event.GetBody())
+	assert.Equal(t, models.SeverityWarn, event.GetSeverity())
+
+	tags := event.GetTags()
+	assert.Equal(t, 2, tags.Len())
+	assert.False(t, tags.Contains("content"))
+	assert.Equal(t, "PUT", tags.Get("method"))
+	assert.Equal(t, map[string]string{"level": "warning", "method": "PUT"}, tags.Iterator())
+	assert.Equal(t, []models.KeyValue[string]{{Key: "level", Value: "warning"}, {Key: "method", Value: "PUT"}}, tags.SortTo(nil))
+
+	// the writes are applied to the wrapped log
+	tags.Add("method", "GET")
+	tags.Add("status", "200")
+	tags.Delete("level")
+	tags.Add("content", "GET /index.html 200")
+	assert.Equal(t, []*protocol.Log_Content{
+		{Key: "content", Value: "GET /index.html 200"},
+		{Key: "method", Value: "GET"},
+		{Key: "status", Value: "200"},
+	}, log.Contents)
+	assert.Equal(t, []byte("GET /index.html 200"), event.GetBody())
+	assert.Equal(t, models.SeverityUnspecified, event.GetSeverity())
+
+	v1Log, ok := ToV1Log(event)
+	require.True(t, ok)
+	assert.Same(t, log, v1Log)
+}
+
+func TestToV1Log(t *testing.T) {
+	log := models.NewLog("access", []byte("PUT /index.html 200"), "warning", 1662434209000000000,
+		models.NewTagsWithKeyValues("method", "PUT", "host", "h1"))
+	v1Log, ok := ToV1Log(log)
+	require.True(t, ok)
+	assert.Equal(t, &protocol.Log{
+		Time: 1662434209,
+		Contents: []*protocol.Log_Content{
+			{Key: "host", Value: "h1"},
+			{Key: "method", Value: "PUT"},
+			{Key: "content", Value: "PUT /index.html 200"},
+			{Key: "level", Value: "WARN"},
+		},
+	}, v1Log)
+
+	_, ok = ToV1Log(models.NewByteArray([]byte("raw")))
+	assert.False(t, ok)
+}
+
+func TestLogGroupToPipelineGroupEvents(t *testing.T) {
+	logGroup := &protocol.LogGroup{
+		Logs:    []*protocol.Log{mockBridgeLog()},
+		Topic:   "file",
+		Source:  "172.10.0.56",
+		LogTags: []*protocol.LogTag{{Key: "__hostname__", Value: "host-1"}},
+	}
+	groupEvents := LogGroupToPipelineGroupEvents(logGroup)
+	assert.Equal(t, map[string]string{"__hostname__": "host-1", "__topic__": "file", "__source__": "172.10.0.56"},
+		groupEvents.Group.GetTags().Iterator())
+	require.Len(t, groupEvents.Events, 1)
+	v1Log, ok := ToV1Log(groupEvents.Events[0])
+	require.True(t, ok)
+	assert.Same(t, logGroup.Logs[0], v1Log)
+}
+
+// BenchmarkLogBridge compares wrapping the v1 logs with copying them into the v2 logs and back.
+func BenchmarkLogBridge(b *testing.B) {
+	logs := make([]*protocol.Log, 1000)
+	for i := range logs {
+		logs[i] = mockBridgeLog()
+	}
+	b.Run("copy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, log := range logs {
+				tags := models.NewTags()
+				var body []byte
+				for _, cont := range log.Contents {
+					if cont.Key == v1BodyKey {
+						body = []byte(cont.Value)
+					} else {
+						tags.Add(cont.Key, cont.Value)
+					}
+				}
+				_, _ = ToV1Log(models.NewLog("", body, "", uint64(log.Time)*1e9, tags))
+			}
+		}
+	})
+	b.Run("wrap", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, log := range logs {
+				_, _ = ToV1Log(NewLogV1Event(log))
+			}
+		}
+	})
+}
@@ -0,0 +1,95 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+// The subset of the msgpack spec(https://github.com/msgpack/msgpack/blob/master/spec.md) used by the converter.
+const (
+	msgpackFixMapPrefix = 0x80
+	msgpackFixStrPrefix = 0xa0
+	msgpackStr8         = 0xd9
+	msgpackStr16        = 0xda
+	msgpackStr32        = 0xdb
+	msgpackMap16        = 0xde
+	msgpackMap32        = 0xdf
+	msgpackUint8        = 0xcc
+	msgpackUint16       = 0xcd
+	msgpackUint32       = 0xce
+	msgpackUint64       = 0xcf
+)
+
+func appendMsgpackMapHeader(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, msgpackFixMapPrefix|byte(n))
+	case n <= 0xffff:
+		b = append(b, msgpackMap16)
+		return appendBigEndian(b, 2, uint64(n))
+	default:
+		b = append(b, msgpackMap32)
+		return appendBigEndian(b, 4, uint64(n))
+	}
+}
+
+func appendMsgpackString(b []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n < 32:
+		b = append(b, msgpackFixStrPrefix|byte(n))
+	case n <= 0xff:
+		b = append(b, msgpackStr8, byte(n))
+	case n <= 0xffff:
+		b = append(b, msgpackStr16)
+		b = appendBigEndian(b, 2, uint64(n))
+	default:
+		b = append(b, msgpackStr32)
+		b = appendBigEndian(b, 4, uint64(n))
+	}
+	return append(b, s...)
+}
+
+func appendMsgpackUint(b []byte, v uint64) []byte {
+	switch {
+	case v < 0x80:
+		return append(b, byte(v))
+	case v <= 0xff:
+		return append(b, msgpackUint8, byte(v))
+	case v <= 0xffff:
+		b = append(b, msgpackUint16)
+		return appendBigEndian(b, 2, v)
+	case v <= 0xffffffff:
+		b = append(b, msgpackUint32)
+		return appendBigEndian(b, 4, v)
+	default:
+		b = append(b, msgpackUint64)
+		return appendBigEndian(b, 8, v)
+	}
+}
+
+// appendMsgpackStringMap appends the map with the keys sorted, so that the output is stable.
+func appendMsgpackStringMap(b []byte, m map[string]string) []byte {
+	b = appendMsgpackMapHeader(b, len(m))
+	for _, k := range sortedKeys(m) {
+		b = appendMsgpackString(b, k)
+		b = appendMsgpackString(b, m[k])
+	}
+	return b
+}
+
+func appendBigEndian(b []byte, size int, v uint64) []byte {
+	for i := size - 1; i >= 0; i-- {
+		b = append(b, byte(v>>(8*i)))
+	}
+	return b
+}
@@ -147,6 +147,7 @@ func ConvertPipelineGroupEvenstsToOtlpEvents(ps *models.PipelineGroupEvents, rsL
 				setScope(scopeLog, groupTags)
 				hasLogs = true
 			}
+			err = ConvertPipelineEventToOtlpLog(v, scopeLog)
 		case models.EventTypeMetric:
 			if !hasMetrics {
 				setAttributes(rsMetrics.Resource().Attributes(), meta)
@@ -184,6 +185,39 @@ func ConvertPipelineGroupEvenstsToOtlpEvents(ps *models.PipelineGroupEvents, rsL
 	return err
 }
 
+var otlpSeverityNumbers = map[models.Severity]plog.SeverityNumber{
+	models.SeverityTrace: plog.SeverityNumberTrace,
+	models.SeverityDebug: plog.SeverityNumberDebug,
+	models.SeverityInfo:  plog.SeverityNumberInfo,
+	models.SeverityWarn:  plog.SeverityNumberWarn,
+	models.SeverityError: plog.SeverityNumberError,
+	models.SeverityFatal: plog.SeverityNumberFatal,
+}
+
+func ConvertPipelineEventToOtlpLog(event models.PipelineEvent, scopeLog plog.ScopeLogs) error {
+	logEvent, ok := event.(*models.Log)
+	if !ok {
+		return fmt.Errorf("pipeline_event:%s is not a log: %v", event.GetName(), event.GetType())
+	}
+
+	record := scopeLog.LogRecords().AppendEmpty()
+	record.SetTimestamp(pcommon.Timestamp(logEvent.Timestamp))
+	record.SetObservedTimestamp(pcommon.Timestamp(logEvent.ObservedTimestamp))
+	record.Body().SetStr(string(logEvent.Body))
+	if severity := logEvent.GetSeverity(); severity != models.SeverityUnspecified {
+		record.SetSeverityNumber(otlpSeverityNumbers[severity])
+		record.SetSeverityText(severity.String())
+	}
+	if traceID, err := convertTraceID(logEvent.TraceID); err == nil {
+		record.SetTraceID(traceID)
+	}
+	if spanID, err := convertSpanID(logEvent.SpanID); err == nil {
+		record.SetSpanID(spanID)
+	}
+	setAttributes(record.Attributes(), logEvent.GetTags())
+	return nil
+}
+
 // metric event -> datapoint
 func ConvertPipelineEventToOtlpMetric(event models.PipelineEvent, scopeMetric pmetric.ScopeMetrics) (err error) {
 	if event.GetType() != models.EventTypeMetric {
@@ -209,19 +243,13 @@ func ConvertPipelineEventToOtlpMetric(event models.PipelineEvent, scopeMetric pm
 	case models.MetricTypeCounter:
 		sum := m.SetEmptySum()
 		sum, err = appgendNumberDatapoint(sum, metricEvent)
-		sum.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+		sum.SetAggregationTemporality(aggregationTemporality(metricEvent, pmetric.AggregationTemporalityDelta))
+		sum.SetIsMonotonic(isMonotonic(metricEvent))
 	case models.MetricTypeRateCounter:
 		sum := m.SetEmptySum()
 		sum, err = appgendNumberDatapoint(sum, metricEvent)
-		at := metricEvent.Tags.Get(otlp.TagKeyMetricAggregationTemporality)
-		if at == pmetric.AggregationTemporalityDelta.String() {
-			sum.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
-		} else if at == pmetric.AggregationTemporalityCumulative.String() {
-			sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
-		}
-		if metricEvent.Tags.Get(otlp.TagKeyMetricIsMonotonic) == "true" {
-			sum.SetIsMonotonic(true)
-		}
+		sum.SetAggregationTemporality(aggregationTemporality(metricEvent, pmetric.AggregationTemporalityUnspecified))
+		sum.SetIsMonotonic(isMonotonic(metricEvent))
 	case models.MetricTypeMeter:
 		// otlp does not support metric.
 	case models.MetricTypeSummary:
@@ -231,21 +259,39 @@ func ConvertPipelineEventToOtlpMetric(event models.PipelineEvent, scopeMetric pm
 		if metricEvent.Tags.Get(otlp.TagKeyMetricHistogramType) == pmetric.MetricTypeExponentialHistogram.String() {
 			exponentialHistogram := m.SetEmptyExponentialHistogram()
 			exponentialHistogram = appendExponentialHistogramDatapoint(exponentialHistogram, metricEvent)
-			at := metricEvent.Tags.Get(otlp.TagKeyMetricAggregationTemporality)
-			if at == pmetric.AggregationTemporalityDelta.String() {
-				exponentialHistogram.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
-			} else if at == pmetric.AggregationTemporalityCumulative.String() {
-				exponentialHistogram.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
-			}
+			exponentialHistogram.SetAggregationTemporality(aggregationTemporality(metricEvent, pmetric.AggregationTemporalityUnspecified))
 		} else {
 			histogram := m.SetEmptyHistogram()
 			appendHistogramDatapoint(histogram, metricEvent)
+			histogram.SetAggregationTemporality(aggregationTemporality(metricEvent, pmetric.AggregationTemporalityUnspecified))
 		}
 	}
 
 	return err
 }
 
+// aggregationTemporality returns the temporality of the metric event, or the one in its tags for the events without
+// it, or @defaultTemporality.
+func aggregationTemporality(metricEvent *models.Metric, defaultTemporality pmetric.AggregationTemporality) pmetric.AggregationTemporality {
+	switch metricEvent.GetTemporality() {
+	case models.MetricTemporalityDelta:
+		return pmetric.AggregationTemporalityDelta
+	case models.MetricTemporalityCumulative:
+		return pmetric.AggregationTemporalityCumulative
+	}
+	switch metricEvent.Tags.Get(otlp.TagKeyMetricAggregationTemporality) {
+	case pmetric.AggregationTemporalityDelta.String():
+		return pmetric.AggregationTemporalityDelta
+	case pmetric.AggregationTemporalityCumulative.String():
+		return pmetric.AggregationTemporalityCumulative
+	}
+	return defaultTemporality
+}
+
+func isMonotonic(metricEvent *models.Metric) bool {
+	return metricEvent.IsMonotonic() || metricEvent.Tags.Get(otlp.TagKeyMetricIsMonotonic) == "true"
+}
+
 func ConvertPipelineEventToOtlpSpan(event models.PipelineEvent, scopeTrace ptrace.ScopeSpans) error {
 	if event.GetType() != models.EventTypeSpan {
 		return fmt.Errorf("pipeline_event:%s is not a span: %v", event.GetName(), event.GetType())
@@ -272,6 +318,7 @@ func ConvertPipelineEventToOtlpSpan(event models.PipelineEvent, scopeTrace ptrac
 		span.SetParentSpanID(parentSpanID)
 	}
 
+	span.TraceState().FromRaw(spanEvent.TraceState)
 	span.SetStartTimestamp(pcommon.Timestamp(spanEvent.StartTime))
 	span.SetEndTimestamp(pcommon.Timestamp(spanEvent.EndTime))
 
@@ -282,6 +329,7 @@ func ConvertPipelineEventToOtlpSpan(event models.PipelineEvent, scopeTrace ptrac
 		otSpanEvent.SetName(v.Name)
 		otSpanEvent.SetTimestamp(pcommon.Timestamp(v.Timestamp))
 		setAttributes(otSpanEvent.Attributes(), v.Tags)
+		setDroppedAttributesCount(otSpanEvent, v.Tags)
 	}
 
 	for _, v := range spanEvent.Links {
@@ -295,6 +343,7 @@ func ConvertPipelineEventToOtlpSpan(event models.PipelineEvent, scopeTrace ptrac
 		}
 		otSpanLink.TraceState().FromRaw(v.TraceState)
 		setAttributes(otSpanLink.Attributes(), v.Tags)
+		setDroppedAttributesCount(otSpanLink, v.Tags)
 	}
 
 	span.Status().SetCode(ptrace.StatusCode(spanEvent.Status))
@@ -307,7 +356,7 @@ func ConvertPipelineEventToOtlpSpan(event models.PipelineEvent, scopeTrace ptrac
 	}
 
 	if droppedEventsCount, err := strconv.Atoi(spanEvent.Tags.Get(otlp.TagKeySpanDroppedEventsCount)); err == nil {
-		span.SetDroppedAttributesCount(uint32(droppedEventsCount))
+		span.SetDroppedEventsCount(uint32(droppedEventsCount))
 	}
 
 	if droppedLinksCount, err := strconv.Atoi(spanEvent.Tags.Get(otlp.TagKeySpanDroppedLinksCount)); err == nil {
@@ -317,6 +366,14 @@ func ConvertPipelineEventToOtlpSpan(event models.PipelineEvent, scopeTrace ptrac
 	return nil
 }
 
+func setDroppedAttributesCount[T interface {
+	SetDroppedAttributesCount(v uint32)
+}](t T, tags models.Tags) {
+	if droppedAttributesCount, err := strconv.Atoi(tags.Get(otlp.TagKeySpanDroppedAttrsCount)); err == nil {
+		t.SetDroppedAttributesCount(uint32(droppedAttributesCount))
+	}
+}
+
 func setScope[T interface {
 	Scope() pcommon.InstrumentationScope
 }](t T, groupTags models.Tags) {
@@ -377,6 +434,10 @@ func appendHistogramDatapoint(histogram pmetric.Histogram, metricEvent *models.M
 		datapoint.SetSum(multiValues.Get(otlp.FieldSum))
 	}
 
+	if multiValues.Contains(otlp.FieldFlags) {
+		datapoint.SetFlags(pmetric.DataPointFlags(multiValues.Get(otlp.FieldFlags)))
+	}
+
 	bucketBounds, bucketCounts := otlp.ComputeBuckets(multiValues, true)
 
 	if len(bucketCounts) >= 1 {
@@ -405,8 +466,13 @@ func appendExponentialHistogramDatapoint(histogram pmetric.ExponentialHistogram,
 		datapoint.SetSum(multiValues.Get(otlp.FieldSum))
 	}
 
+	if multiValues.Contains(otlp.FieldFlags) {
+		datapoint.SetFlags(pmetric.DataPointFlags(multiValues.Get(otlp.FieldFlags)))
+	}
+
 	scale := int32(multiValues.Get(otlp.FieldScale))
 	datapoint.SetScale(scale)
+	datapoint.SetZeroCount(uint64(multiValues.Get(otlp.FieldZeroCount)))
 
 	postiveOffset := int32(multiValues.Get(otlp.FieldPositiveOffset))
 	datapoint.Positive().SetOffset(postiveOffset)
@@ -222,3 +222,40 @@ func TestConvertPipelineGroupEventsToOtlpTraces(t *testing.T) {
 		})
 	})
 }
+
+func TestConvertPipelineGroupEventsToOtlpLogs(t *testing.T) {
+	convey.Convey("When constructing converter with supported encoding", t, func() {
+		c, err := NewConverter(ProtocolOtlpV1, EncodingNone, nil, nil)
+		convey.So(err, convey.ShouldBeNil)
+
+		convey.Convey("When the log events are of several levels", func() {
+			pipelineGroupEvent := &models.PipelineGroupEvents{
+				Group: &models.GroupInfo{
+					Metadata: models.NewMetadata(),
+					Tags:     models.NewTags(),
+				},
+			}
+			levels := []string{"warning", "E", "unknown"}
+			for i, level := range levels {
+				event := models.NewLog("log_"+strconv.Itoa(i), []byte("test log content"), level, 1662434209, models.NewTagsWithKeyValues("method", "GET"))
+				pipelineGroupEvent.Events = append(pipelineGroupEvent.Events, event)
+			}
+
+			logs, _, _, err := c.ConvertPipelineGroupEventsToOTLPEventsV1(pipelineGroupEvent)
+			convey.Convey("Then the converted logs should have the normalized severities", func() {
+				convey.So(err, convey.ShouldBeNil)
+				convey.So(1, convey.ShouldEqual, logs.ScopeLogs().Len())
+				records := logs.ScopeLogs().At(0).LogRecords()
+				convey.So(3, convey.ShouldEqual, records.Len())
+				convey.So(plog.SeverityNumberWarn, convey.ShouldEqual, records.At(0).SeverityNumber())
+				convey.So("WARN", convey.ShouldEqual, records.At(0).SeverityText())
+				convey.So(plog.SeverityNumberError, convey.ShouldEqual, records.At(1).SeverityNumber())
+				convey.So(plog.SeverityNumberUnspecified, convey.ShouldEqual, records.At(2).SeverityNumber())
+				convey.So("test log content", convey.ShouldEqual, records.At(0).Body().AsString())
+				method, ok := records.At(0).Attributes().Get("method")
+				convey.So(ok, convey.ShouldBeTrue)
+				convey.So("GET", convey.ShouldEqual, method.AsString())
+			})
+		})
+	})
+}
@@ -0,0 +1,152 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+const (
+	compactTimeKey = "__time__"
+	defaultRawKey  = "content"
+)
+
+var errNoRawContent = errors.New("no raw content found in log, the raw encoding requires the content field or exactly one field")
+
+// SingleLog is the canonical form of a log in the custom_single protocol. All the serializers
+// consume it, so the tag naming and the protocol key renaming are the same whatever the encoding is.
+type SingleLog struct {
+	Time     uint32
+	Contents map[string]string
+	Tags     map[string]string
+
+	// TimeKey, ContentsKey and TagsKey are the protocol keys after applying the ProtocolKeyRenameMap.
+	TimeKey     string
+	ContentsKey string
+	TagsKey     string
+}
+
+// Serializer marshals a single log into one byte stream.
+type Serializer func(log *SingleLog) ([]byte, error)
+
+var (
+	serializerMutex sync.RWMutex
+	serializers     = map[string]Serializer{
+		EncodingJSON:        serializeJSON,
+		EncodingJSONCompact: serializeJSONCompact,
+		EncodingProtobuf:    serializeProtobuf,
+		EncodingMsgpack:     serializeMsgpack,
+		EncodingCBOR:        serializeCBOR,
+		EncodingRaw:         serializeRaw,
+	}
+)
+
+// RegisterSerializer registers a serializer for the custom_single protocol, so that every flusher
+// using the converter could select it by the encoding name.
+func RegisterSerializer(encoding string, serializer Serializer) {
+	serializerMutex.Lock()
+	defer serializerMutex.Unlock()
+	serializers[encoding] = serializer
+	delete(batchAppenders, encoding)
+}
+
+// GetSerializer returns the serializer registered with the encoding name.
+func GetSerializer(encoding string) (Serializer, bool) {
+	serializerMutex.RLock()
+	defer serializerMutex.RUnlock()
+	serializer, ok := serializers[encoding]
+	return serializer, ok
+}
+
+// ToMap returns the nested map of the log, which is the output of ConvertToSingleProtocolLogs.
+func (l *SingleLog) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		l.TimeKey:     l.Time,
+		l.ContentsKey: l.Contents,
+		l.TagsKey:     l.Tags,
+	}
+}
+
+func serializeJSON(log *SingleLog) ([]byte, error) {
+	return json.Marshal(log.ToMap())
+}
+
+// serializeJSONCompact flattens the log into one level, with the tags prefixed by __tag__: and the time
+// stored in __time__, which is the same naming as SLS.
+func serializeJSONCompact(log *SingleLog) ([]byte, error) {
+	flattened := make(map[string]interface{}, len(log.Contents)+len(log.Tags)+1)
+	for k, v := range log.Contents {
+		flattened[k] = v
+	}
+	for k, v := range log.Tags {
+		flattened[tagPrefix+k] = v
+	}
+	flattened[compactTimeKey] = log.Time
+	return json.Marshal(flattened)
+}
+
+// serializeProtobuf marshals the log as a sls_logs.Log, with the tags stored as the contents prefixed by __tag__:.
+func serializeProtobuf(log *SingleLog) ([]byte, error) {
+	pbLog := &protocol.Log{
+		Time:     log.Time,
+		Contents: make([]*protocol.Log_Content, 0, len(log.Contents)+len(log.Tags)),
+	}
+	for _, k := range sortedKeys(log.Contents) {
+		pbLog.Contents = append(pbLog.Contents, &protocol.Log_Content{Key: k, Value: log.Contents[k]})
+	}
+	for _, k := range sortedKeys(log.Tags) {
+		pbLog.Contents = append(pbLog.Contents, &protocol.Log_Content{Key: tagPrefix + k, Value: log.Tags[k]})
+	}
+	return pbLog.Marshal()
+}
+
+func serializeMsgpack(log *SingleLog) ([]byte, error) {
+	b := make([]byte, 0, 256)
+	b = appendMsgpackMapHeader(b, numProtocolKeys)
+	b = appendMsgpackString(b, log.TimeKey)
+	b = appendMsgpackUint(b, uint64(log.Time))
+	b = appendMsgpackString(b, log.ContentsKey)
+	b = appendMsgpackStringMap(b, log.Contents)
+	b = appendMsgpackString(b, log.TagsKey)
+	b = appendMsgpackStringMap(b, log.Tags)
+	return b, nil
+}
+
+// serializeRaw outputs the original text of the log, which is the content field, or the only field if there is no content field.
+func serializeRaw(log *SingleLog) ([]byte, error) {
+	if v, ok := log.Contents[defaultRawKey]; ok {
+		return []byte(v), nil
+	}
+	if len(log.Contents) == 1 {
+		for _, v := range log.Contents {
+			return []byte(v), nil
+		}
+	}
+	return nil, errNoRawContent
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
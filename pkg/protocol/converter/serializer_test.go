@@ -0,0 +1,191 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/flags"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+func mockSerializerLogGroup() *protocol.LogGroup {
+	return &protocol.LogGroup{
+		Logs: []*protocol.Log{
+			{
+				Time: 1662434209,
+				Contents: []*protocol.Log_Content{
+					{Key: "content", Value: "PUT /index.html 200"},
+					{Key: "__tag__:__path__", Value: "/var/log/access.log"},
+				},
+			},
+		},
+		Topic:   "file",
+		Source:  "172.10.0.56",
+		LogTags: []*protocol.LogTag{{Key: "__hostname__", Value: "host-1"}},
+	}
+}
+
+func TestSerializer_JSON(t *testing.T) {
+	*flags.K8sFlag = false
+	c, err := NewConverter(ProtocolCustomSingle, EncodingJSON, nil, map[string]string{protocolKeyTime: "timestamp"})
+	require.NoError(t, err)
+	stream, _, err := c.ConvertToSingleProtocolStream(mockSerializerLogGroup(), nil)
+	require.NoError(t, err)
+	require.Len(t, stream, 1)
+	assert.JSONEq(t, `{"timestamp":1662434209,"contents":{"content":"PUT /index.html 200"},`+
+		`"tags":{"log.file.path":"/var/log/access.log","host.name":"host-1","host.ip":"172.10.0.56","log.topic":"file"}}`, string(stream[0]))
+}
+
+func TestSerializer_JSONCompact(t *testing.T) {
+	*flags.K8sFlag = false
+	c, err := NewConverter(ProtocolCustomSingle, EncodingJSONCompact, nil, nil)
+	require.NoError(t, err)
+	stream, _, err := c.ConvertToSingleProtocolStream(mockSerializerLogGroup(), nil)
+	require.NoError(t, err)
+	require.Len(t, stream, 1)
+	assert.JSONEq(t, `{"__time__":1662434209,"content":"PUT /index.html 200","__tag__:log.file.path":"/var/log/access.log",`+
+		`"__tag__:host.name":"host-1","__tag__:host.ip":"172.10.0.56","__tag__:log.topic":"file"}`, string(stream[0]))
+}
+
+func TestSerializer_Protobuf(t *testing.T) {
+	*flags.K8sFlag = false
+	c, err := NewConverter(ProtocolCustomSingle, EncodingProtobuf, nil, nil)
+	require.NoError(t, err)
+	stream, _, err := c.ConvertToSingleProtocolStream(mockSerializerLogGroup(), nil)
+	require.NoError(t, err)
+	require.Len(t, stream, 1)
+
+	log := &protocol.Log{}
+	require.NoError(t, log.Unmarshal(stream[0]))
+	assert.Equal(t, uint32(1662434209), log.Time)
+	assert.Equal(t, []*protocol.Log_Content{
+		{Key: "content", Value: "PUT /index.html 200"},
+		{Key: "__tag__:host.ip", Value: "172.10.0.56"},
+		{Key: "__tag__:host.name", Value: "host-1"},
+		{Key: "__tag__:log.file.path", Value: "/var/log/access.log"},
+		{Key: "__tag__:log.topic", Value: "file"},
+	}, log.Contents)
+}
+
+func TestSerializer_Msgpack(t *testing.T) {
+	log := &SingleLog{
+		Time:        1662434209,
+		Contents:    map[string]string{"a": "b"},
+		Tags:        map[string]string{},
+		TimeKey:     protocolKeyTime,
+		ContentsKey: protocolKeyContent,
+		TagsKey:     protocolKeyTag,
+	}
+	b, err := serializeMsgpack(log)
+	require.NoError(t, err)
+	expected := []byte{0x83,
+		0xa4, 't', 'i', 'm', 'e', 0xce, 0x63, 0x16, 0xbb, 0xa1,
+		0xa8, 'c', 'o', 'n', 't', 'e', 'n', 't', 's', 0x81, 0xa1, 'a', 0xa1, 'b',
+		0xa4, 't', 'a', 'g', 's', 0x80,
+	}
+	assert.Equal(t, expected, b)
+}
+
+func TestSerializer_CBOR(t *testing.T) {
+	log := &SingleLog{
+		Time:        1662434209,
+		Contents:    map[string]string{"a": "b"},
+		Tags:        map[string]string{},
+		TimeKey:     protocolKeyTime,
+		ContentsKey: protocolKeyContent,
+		TagsKey:     protocolKeyTag,
+	}
+	b, err := serializeCBOR(log)
+	require.NoError(t, err)
+	expected := []byte{0xa3,
+		0x64, 't', 'i', 'm', 'e', 0x1a, 0x63, 0x16, 0xbb, 0xa1,
+		0x68, 'c', 'o', 'n', 't', 'e', 'n', 't', 's', 0xa1, 0x61, 'a', 0x61, 'b',
+		0x64, 't', 'a', 'g', 's', 0xa0,
+	}
+	assert.Equal(t, expected, b)
+}
+
+func TestSerializer_BinaryLength(t *testing.T) {
+	for _, n := range []int{0, 23, 24, 31, 32, 255, 256, 65535, 65536} {
+		s := strings.Repeat("x", n)
+		var msgpackHead, cborHead []byte
+		switch {
+		case n < 24:
+			msgpackHead, cborHead = []byte{0xa0 | byte(n)}, []byte{0x60 | byte(n)}
+		case n < 32:
+			msgpackHead, cborHead = []byte{0xa0 | byte(n)}, []byte{0x78, byte(n)}
+		case n <= 0xff:
+			msgpackHead, cborHead = []byte{0xd9, byte(n)}, []byte{0x78, byte(n)}
+		case n <= 0xffff:
+			msgpackHead, cborHead = []byte{0xda, byte(n >> 8), byte(n)}, []byte{0x79, byte(n >> 8), byte(n)}
+		default:
+			msgpackHead, cborHead = []byte{0xdb, 0, byte(n >> 16), byte(n >> 8), byte(n)}, []byte{0x7a, 0, byte(n >> 16), byte(n >> 8), byte(n)}
+		}
+		assert.Equal(t, append(msgpackHead, s...), appendMsgpackString(nil, s), "msgpack string length %d", n)
+		assert.Equal(t, append(cborHead, s...), appendCBORString(nil, s), "cbor string length %d", n)
+	}
+}
+
+func TestSerializer_BinarySmallerThanJSON(t *testing.T) {
+	*flags.K8sFlag = false
+	logGroup := mockSerializerLogGroup()
+	sizes := make(map[string]int)
+	for _, encoding := range []string{EncodingJSON, EncodingMsgpack, EncodingCBOR} {
+		c, err := NewConverter(ProtocolCustomSingle, encoding, nil, nil)
+		require.NoError(t, err)
+		stream, _, err := c.ConvertToSingleProtocolStream(logGroup, nil)
+		require.NoError(t, err)
+		sizes[encoding] = len(stream[0])
+	}
+	assert.Less(t, sizes[EncodingMsgpack], sizes[EncodingJSON])
+	assert.Less(t, sizes[EncodingCBOR], sizes[EncodingJSON])
+}
+
+func TestSerializer_Raw(t *testing.T) {
+	c, err := NewConverter(ProtocolCustomSingle, EncodingRaw, nil, nil)
+	require.NoError(t, err)
+	stream, _, err := c.ConvertToSingleProtocolStream(mockSerializerLogGroup(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("PUT /index.html 200")}, stream)
+
+	_, err = serializeRaw(&SingleLog{Contents: map[string]string{"a": "1", "b": "2"}})
+	assert.Error(t, err)
+	b, err := serializeRaw(&SingleLog{Contents: map[string]string{"message": "hello"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+}
+
+func TestRegisterSerializer(t *testing.T) {
+	_, err := NewConverter(ProtocolCustomSingle, "test_keys", nil, nil)
+	assert.Error(t, err)
+
+	RegisterSerializer("test_keys", func(log *SingleLog) ([]byte, error) {
+		return json.Marshal(sortedKeys(log.Contents))
+	})
+	c, err := NewConverter(ProtocolCustomSingle, "test_keys", nil, nil)
+	require.NoError(t, err)
+	stream, _, err := c.ConvertToSingleProtocolStream(mockSerializerLogGroup(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, `["content"]`, string(stream[0]))
+
+	_, err = NewConverter(ProtocolInfluxdb, "test_keys", nil, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,236 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+// CEFHeaderKeys are the keys of the CEF header fields in the logs, in the order of the header.
+var CEFHeaderKeys = []string{"cef_version", "device_vendor", "device_product", "device_version", "signature_id", "name", "severity"}
+
+// LEEFHeaderKeys are the keys of the LEEF header fields in the logs, in the order of the header.
+var LEEFHeaderKeys = []string{"leef_version", "device_vendor", "device_product", "device_version", "event_id"}
+
+var (
+	cefHeaderReplacer  = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ")
+	cefValueReplacer   = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+	leefHeaderReplacer = strings.NewReplacer("|", " ", "\r", " ", "\n", " ")
+	leefValueReplacer  = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+)
+
+// defaultFieldMappings are the field mapping tables used if not overridden by SetFieldMapping,
+// which map the CEF or LEEF keys to the log fields.
+var defaultFieldMappings = map[string]map[string]string{
+	ProtocolCEF: {
+		"dvc":     targetTagPrefix + tagHostIP,
+		"dvchost": targetTagPrefix + tagHostname,
+	},
+	ProtocolLEEF: {
+		"identSrc":      targetTagPrefix + tagHostIP,
+		"identHostName": targetTagPrefix + tagHostname,
+	},
+}
+
+// siemTimeKeys are the keys of the event time in milliseconds, which are added if not mapped from the logs.
+var siemTimeKeys = map[string]string{
+	ProtocolCEF:  "rt",
+	ProtocolLEEF: "devTime",
+}
+
+type siemField struct {
+	key   string
+	value string
+}
+
+// SetFieldMapping sets the field mapping table of the cef and leef protocols, which maps the CEF or LEEF keys,
+// including the header keys such as name and severity, to the log fields. The log field is a content key, or a
+// tag key with the prefix tag., such as {"src": "client_ip", "dvchost": "tag.host.name"}. The mapping overrides
+// the default one with the same key, and the key mapped to an empty field is disabled.
+func (c *Converter) SetFieldMapping(mapping map[string]string) error {
+	defaults, ok := defaultFieldMappings[c.Protocol]
+	if !ok {
+		return fmt.Errorf("field mapping is not supported by protocol %s", c.Protocol)
+	}
+	merged := make(map[string]string, len(defaults)+len(mapping))
+	for k, v := range defaults {
+		merged[k] = v
+	}
+	for k, v := range mapping {
+		merged[k] = v
+	}
+	c.FieldMapping = merged
+	return nil
+}
+
+// ConvertToSIEMProtocolStream converts each log of @logGroup to a CEF or LEEF record. The header fields and the
+// mapped extension keys are read from the log fields in the field mapping table, the other contents are appended
+// as the extensions with their own keys, and the unmapped tags are dropped.
+func (c *Converter) ConvertToSIEMProtocolStream(logGroup *protocol.LogGroup, targetFields []string) ([][]byte, []map[string]string, error) {
+	mapping := c.FieldMapping
+	if mapping == nil {
+		mapping = defaultFieldMappings[c.Protocol]
+	}
+	headerKeys := CEFHeaderKeys
+	if c.Protocol == ProtocolLEEF {
+		headerKeys = LEEFHeaderKeys
+	}
+
+	singleLogs, desiredValues, err := c.ConvertToSingleLogs(logGroup, targetFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	records := make([][]byte, len(singleLogs))
+	for i, log := range singleLogs {
+		consumed := make(map[string]bool, len(mapping)+len(headerKeys))
+		lookup := func(key string) (string, bool) {
+			field, ok := mapping[key]
+			if !ok {
+				field = key
+			} else if field == "" {
+				return "", false
+			}
+			if strings.HasPrefix(field, targetTagPrefix) {
+				value, ok := log.Tags[field[len(targetTagPrefix):]]
+				return value, ok
+			}
+			value, ok := log.Contents[field]
+			if ok {
+				consumed[field] = true
+			}
+			return value, ok
+		}
+
+		headers := make([]string, len(headerKeys))
+		isHeader := make(map[string]bool, len(headerKeys))
+		for j, key := range headerKeys {
+			headers[j], _ = lookup(key)
+			isHeader[key] = true
+		}
+		fields := make([]siemField, 0, len(log.Contents)+len(mapping))
+		for key := range mapping {
+			if isHeader[key] {
+				continue
+			}
+			if value, ok := lookup(key); ok {
+				fields = append(fields, siemField{key: key, value: value})
+			}
+		}
+		for key, value := range log.Contents {
+			if consumed[key] {
+				continue
+			}
+			if _, ok := mapping[key]; ok {
+				// the key is taken by the mapped field
+				continue
+			}
+			fields = append(fields, siemField{key: siemKey(key), value: value})
+		}
+		sort.Slice(fields, func(a, b int) bool { return fields[a].key < fields[b].key })
+		if timeKey := siemTimeKeys[c.Protocol]; !hasSIEMField(fields, timeKey) {
+			fields = append(fields, siemField{key: timeKey, value: strconv.FormatInt(int64(log.Time)*1000, 10)})
+		}
+
+		if c.Protocol == ProtocolLEEF {
+			records[i] = appendLEEF(nil, headers, fields)
+		} else {
+			records[i] = appendCEF(nil, headers, fields)
+		}
+	}
+	return records, desiredValues, nil
+}
+
+func appendCEF(buf []byte, headers []string, fields []siemField) []byte {
+	buf = append(buf, "CEF:"...)
+	buf = append(buf, defaultString(headers[0], "0")...)
+	for j, header := range headers[1:] {
+		switch j + 1 {
+		case 1, 2:
+			header = defaultString(header, "iLogtail")
+		case 6:
+			header = defaultString(header, "Unknown")
+		}
+		buf = append(buf, '|')
+		buf = append(buf, cefHeaderReplacer.Replace(header)...)
+	}
+	buf = append(buf, '|')
+	for j, field := range fields {
+		if j != 0 {
+			buf = append(buf, ' ')
+		}
+		buf = append(buf, field.key...)
+		buf = append(buf, '=')
+		buf = append(buf, cefValueReplacer.Replace(field.value)...)
+	}
+	return buf
+}
+
+// appendLEEF appends the LEEF record delimited by tabs, the delimiter field is added in LEEF 2.0.
+func appendLEEF(buf []byte, headers []string, fields []siemField) []byte {
+	version := defaultString(headers[0], "1.0")
+	buf = append(buf, "LEEF:"...)
+	buf = append(buf, leefHeaderReplacer.Replace(version)...)
+	for j, header := range headers[1:] {
+		if j < 2 {
+			header = defaultString(header, "iLogtail")
+		}
+		buf = append(buf, '|')
+		buf = append(buf, leefHeaderReplacer.Replace(header)...)
+	}
+	if strings.HasPrefix(version, "2") {
+		buf = append(buf, "|x09"...)
+	}
+	buf = append(buf, '|')
+	for j, field := range fields {
+		if j != 0 {
+			buf = append(buf, '\t')
+		}
+		buf = append(buf, field.key...)
+		buf = append(buf, '=')
+		buf = append(buf, leefValueReplacer.Replace(field.value)...)
+	}
+	return buf
+}
+
+// siemKey replaces the characters not allowed in the CEF and LEEF keys with underscores.
+func siemKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '.' || r == '-' {
+			return r
+		}
+		return '_'
+	}, key)
+}
+
+func hasSIEMField(fields []siemField, key string) bool {
+	for _, field := range fields {
+		if field.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func defaultString(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,85 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+func siemLogGroup() *protocol.LogGroup {
+	return &protocol.LogGroup{
+		Logs: []*protocol.Log{
+			{
+				Time: 1434055562,
+				Contents: []*protocol.Log_Content{
+					{Key: "signature_id", Value: "100"},
+					{Key: "name", Value: "Login|Failed"},
+					{Key: "severity", Value: "7"},
+					{Key: "client_ip", Value: "10.0.0.1"},
+					{Key: "msg", Value: "user=admin\nretry"},
+					{Key: "__tag__:__hostname__", Value: "server01"},
+				},
+			},
+		},
+		Source:  "192.168.0.1",
+		LogTags: []*protocol.LogTag{{Key: "env", Value: "prod"}},
+	}
+}
+
+func TestConverter_ConvertToSIEMProtocolStreamCEF(t *testing.T) {
+	c, err := NewConverter(ProtocolCEF, EncodingCustom, nil, nil)
+	require.NoError(t, err)
+
+	stream, values, err := c.ToByteStreamWithSelectedFields(siemLogGroup(), []string{"content.client_ip"})
+	require.NoError(t, err)
+	assert.Equal(t, `CEF:0|iLogtail|iLogtail||100|Login\|Failed|7|client_ip=10.0.0.1 dvc=192.168.0.1 dvchost=server01 msg=user\=admin\nretry rt=1434055562000`,
+		string(stream.([][]byte)[0]))
+	assert.Equal(t, []map[string]string{{"content.client_ip": "10.0.0.1"}}, values)
+
+	require.NoError(t, c.SetFieldMapping(map[string]string{"src": "client_ip", "device_vendor": "tag.env", "dvc": ""}))
+	stream, _, err = c.ToByteStreamWithSelectedFields(siemLogGroup(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, `CEF:0|prod|iLogtail||100|Login\|Failed|7|dvchost=server01 msg=user\=admin\nretry src=10.0.0.1 rt=1434055562000`,
+		string(stream.([][]byte)[0]))
+}
+
+func TestConverter_ConvertToSIEMProtocolStreamLEEF(t *testing.T) {
+	c, err := NewConverter(ProtocolLEEF, EncodingCustom, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, c.SetFieldMapping(map[string]string{"event_id": "signature_id", "src": "client_ip", "devTime": "time"}))
+
+	logGroup := siemLogGroup()
+	stream, _, err := c.ToByteStreamWithSelectedFields(logGroup, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "LEEF:1.0|iLogtail|iLogtail||100|identHostName=server01\tidentSrc=192.168.0.1\tmsg=user=admin retry\tname=Login|Failed\tseverity=7\tsrc=10.0.0.1\tdevTime=1434055562000",
+		string(stream.([][]byte)[0]))
+
+	logGroup.Logs[0].Contents = append(logGroup.Logs[0].Contents, &protocol.Log_Content{Key: "leef_version", Value: "2.0"}, &protocol.Log_Content{Key: "time", Value: "1434055600000"})
+	stream, _, err = c.ToByteStreamWithSelectedFields(logGroup, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "LEEF:2.0|iLogtail|iLogtail||100|x09|devTime=1434055600000\tidentHostName=server01\tidentSrc=192.168.0.1\tmsg=user=admin retry\tname=Login|Failed\tseverity=7\tsrc=10.0.0.1",
+		string(stream.([][]byte)[0]))
+}
+
+func TestConverter_SetFieldMapping(t *testing.T) {
+	c, err := NewConverter(ProtocolCustomSingle, EncodingJSON, nil, nil)
+	require.NoError(t, err)
+	assert.Error(t, c.SetFieldMapping(map[string]string{"src": "client_ip"}))
+}
@@ -0,0 +1,103 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+// templateFuncs are the functions available in the templates besides the builtin ones of text/template.
+var templateFuncs = template.FuncMap{
+	// cefHeader escapes the header fields of CEF, such as the vendor and the name.
+	"cefHeader": func(s string) string { return cefHeaderReplacer.Replace(s) },
+	// cef escapes the extension values of CEF.
+	"cef": func(s string) string { return cefValueReplacer.Replace(s) },
+	// leef replaces the tabs and the line breaks in the attribute values of LEEF, whose default delimiter is tab.
+	"leef": func(s string) string { return leefValueReplacer.Replace(s) },
+	// csv quotes the field as RFC 4180 if it contains the comma, the quote or the line breaks.
+	"csv": func(s string) string {
+		if !strings.ContainsAny(s, ",\"\r\n") {
+			return s
+		}
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	},
+	// json encodes the value in JSON.
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	// formatTime formats the unix seconds in UTC with the Go layout, such as {{.Time | formatTime "Jan 02 15:04:05"}}.
+	"formatTime": func(layout string, seconds uint32) string {
+		return time.Unix(int64(seconds), 0).UTC().Format(layout)
+	},
+	// default returns def if the value is empty, such as {{index .Contents "user" | default "-"}}.
+	"default": func(def, s string) string { return defaultString(s, def) },
+}
+
+// SetTemplate parses the Go text/template rendering the records of the template encoding.
+// The template is executed with each *SingleLog as one record, or with the []*SingleLog of the whole log group as
+// one record if batch is true. The missing keys of Contents and Tags are rendered as empty strings.
+func (c *Converter) SetTemplate(text string, batch bool) error {
+	if c.Encoding != EncodingTemplate {
+		return fmt.Errorf("template is not supported by encoding %s", c.Encoding)
+	}
+	t, err := template.New("converter").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid template: %v", err)
+	}
+	c.Template = t
+	c.TemplateBatch = batch
+	return nil
+}
+
+func (c *Converter) convertToTemplateStream(logGroup *protocol.LogGroup, targetFields []string) ([][]byte, []map[string]string, error) {
+	if c.Template == nil {
+		return nil, nil, fmt.Errorf("template is not set for encoding %s", EncodingTemplate)
+	}
+	singleLogs, desiredValues, err := c.ConvertToSingleLogs(logGroup, targetFields)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if c.TemplateBatch {
+		var buf bytes.Buffer
+		if err = c.Template.Execute(&buf, singleLogs); err != nil {
+			return nil, nil, fmt.Errorf("unable to render logs with template: %v", err)
+		}
+		// we are batching logs in LogGroup, so only support find tags in the logGroup.LogTags
+		var values map[string]string
+		if len(targetFields) > 0 {
+			values = findTargetValuesInLogTags(targetFields, logGroup.LogTags)
+		}
+		return [][]byte{buf.Bytes()}, []map[string]string{values}, nil
+	}
+
+	renderedLogs := make([][]byte, len(singleLogs))
+	for i, log := range singleLogs {
+		var buf bytes.Buffer
+		if err = c.Template.Execute(&buf, log); err != nil {
+			return nil, nil, fmt.Errorf("unable to render log with template: %v", err)
+		}
+		renderedLogs[i] = buf.Bytes()
+	}
+	return renderedLogs, desiredValues, nil
+}
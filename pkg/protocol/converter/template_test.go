@@ -0,0 +1,86 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/flags"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+func mockTemplateLogGroup() *protocol.LogGroup {
+	return &protocol.LogGroup{
+		Logs: []*protocol.Log{
+			{Time: 1662434209, Contents: []*protocol.Log_Content{
+				{Key: "user", Value: "alice"},
+				{Key: "msg", Value: "login failed | user=alice\nretry"},
+			}},
+			{Time: 1662434210, Contents: []*protocol.Log_Content{
+				{Key: "msg", Value: `say "hi", bob`},
+			}},
+		},
+		Source:  "172.10.0.56",
+		LogTags: []*protocol.LogTag{{Key: "__hostname__", Value: "host-1"}, {Key: "__tag__:env", Value: "prod"}},
+	}
+}
+
+func TestConverter_TemplateCEF(t *testing.T) {
+	*flags.K8sFlag = false
+	c, err := NewConverter(ProtocolCustomSingle, EncodingTemplate, nil, nil)
+	require.NoError(t, err)
+	_, _, err = c.ToByteStreamWithSelectedFields(mockTemplateLogGroup(), nil)
+	assert.Error(t, err)
+
+	require.NoError(t, c.SetTemplate(`CEF:0|iLogtail|{{index .Tags "host.name" | cefHeader}}|1.0|100|{{index .Contents "msg" | cefHeader}}|5|`+
+		`rt={{.Time | formatTime "Jan 02 2006 15:04:05"}} suser={{index .Contents "user" | default "-" | cef}} msg={{index .Contents "msg" | cef}}`, false))
+	stream, values, err := c.ToByteStreamWithSelectedFields(mockTemplateLogGroup(), []string{"content.user"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{
+		[]byte(`CEF:0|iLogtail|host-1|1.0|100|login failed \| user=alice retry|5|rt=Sep 06 2022 03:16:49 suser=alice msg=login failed | user\=alice\nretry`),
+		[]byte(`CEF:0|iLogtail|host-1|1.0|100|say "hi", bob|5|rt=Sep 06 2022 03:16:50 suser=- msg=say "hi", bob`),
+	}, stream)
+	assert.Equal(t, []map[string]string{{"content.user": "alice"}, {}}, values)
+}
+
+func TestConverter_TemplateBatch(t *testing.T) {
+	*flags.K8sFlag = false
+	c, err := NewConverter(ProtocolCustomSingle, EncodingTemplate, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, c.SetTemplate(`{{range .}}{{.Time}},{{index .Contents "user" | csv}},{{index .Contents "msg" | csv}}`+"\n{{end}}", true))
+	stream, values, err := c.ToByteStreamWithSelectedFields(mockTemplateLogGroup(), []string{"tag.env"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("1662434209,alice,\"login failed | user=alice\nretry\"\n1662434210,,\"say \"\"hi\"\", bob\"\n")}, stream)
+	assert.Equal(t, []map[string]string{{"tag.env": "prod"}}, values)
+}
+
+func TestConverter_TemplateFuncs(t *testing.T) {
+	c, err := NewConverter(ProtocolCustomSingle, EncodingTemplate, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, c.SetTemplate("LEEF:2.0|iLogtail|agent|1.0|100|\tmsg={{index .Contents \"msg\" | leef}}\tcontents={{json .Contents}}", false))
+	stream, _, err := c.ToByteStreamWithSelectedFields(&protocol.LogGroup{Logs: []*protocol.Log{
+		{Time: 1, Contents: []*protocol.Log_Content{{Key: "msg", Value: "a\tb"}}},
+	}}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "LEEF:2.0|iLogtail|agent|1.0|100|\tmsg=a b\tcontents={\"msg\":\"a\\tb\"}", string(stream.([][]byte)[0]))
+
+	assert.Error(t, c.SetTemplate("{{.Time", false))
+	json, err := NewConverter(ProtocolCustomSingle, EncodingJSON, nil, nil)
+	require.NoError(t, err)
+	assert.Error(t, json.SetTemplate("{{.Time}}", false))
+}
@@ -38,4 +38,5 @@ const (
 	FieldPositiveOffset = "positive.offset"
 	FieldNegativeOffset = "negative.offset"
 	FieldZeroCount      = "zero.count"
+	FieldFlags          = "flags"
 )
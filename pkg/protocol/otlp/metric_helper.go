@@ -103,7 +103,8 @@ func IsInternalTag(tagname string) bool {
 		tagname == TagKeySpanDroppedEventsCount ||
 		tagname == TagKeySpanDroppedLinksCount ||
 		tagname == TagKeySpanStatusMessage ||
-		tagname == TagKeyMetricIsMonotonic
+		tagname == TagKeyMetricIsMonotonic ||
+		tagname == TagKeyMetricHistogramType
 }
 
 func IsInternalField(fieldname string) bool {
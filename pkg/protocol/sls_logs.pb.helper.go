@@ -20,6 +20,26 @@ func CloneLog(log *Log) *Log {
 	return cloneLog
 }
 
+// CloneLogGroup deep copies the LogGroup, e.g. the LogGroups passed to a flusher are shared with the other
+// flushers of the pipeline, which should not be modified.
+func CloneLogGroup(logGroup *LogGroup) *LogGroup {
+	newGroup := &LogGroup{
+		Category:    logGroup.Category,
+		Topic:       logGroup.Topic,
+		Source:      logGroup.Source,
+		MachineUUID: logGroup.MachineUUID,
+		Logs:        make([]*Log, len(logGroup.Logs)),
+		LogTags:     make([]*LogTag, len(logGroup.LogTags)),
+	}
+	for i, log := range logGroup.Logs {
+		newGroup.Logs[i] = CloneLog(log)
+	}
+	for i, tag := range logGroup.LogTags {
+		newGroup.LogTags[i] = &LogTag{Key: tag.Key, Value: tag.Value}
+	}
+	return newGroup
+}
+
 type Codec struct{}
 
 func (Codec) Marshal(v interface{}) ([]byte, error) {
@@ -41,3 +61,10 @@ func (Codec) Unmarshal(data []byte, v interface{}) error {
 func (Codec) Name() string {
 	return "proto"
 }
+
+// MarshalToSizedBuffer marshals the log group to the end of dAtA, which is called by the code embedding the
+// log groups generated by newer gogo protobuf, such as helper/externalplugin.
+func (m *LogGroup) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalTo(dAtA[len(dAtA)-size:])
+}
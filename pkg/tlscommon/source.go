@@ -0,0 +1,168 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlscommon
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+// certSource provides the current certificate and verifies the peers with the current trusted roots,
+// so that the rotated certificates take effect without rebuilding the connections.
+type certSource interface {
+	// certificate returns the current certificate, which is nil if not configured.
+	certificate() *tls.Certificate
+	// verify verifies the peer certificates, the serverName is empty when verifying the clients.
+	verify(certs []*x509.Certificate, serverName string, usage x509.ExtKeyUsage) error
+	close() error
+}
+
+// fileSource loads the certificate and the CA from the files, and reloads them if the files are modified.
+type fileSource struct {
+	caFile, certFile, keyFile string
+	interval                  time.Duration
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	roots     *x509.CertPool
+	modTimes  [3]time.Time
+	lastCheck time.Time
+}
+
+// newFileSource loads the files, and checks the modification of them at most once per interval if the
+// interval is positive.
+func newFileSource(caFile, certFile, keyFile string, interval time.Duration) (*fileSource, error) {
+	s := &fileSource{caFile: caFile, certFile: certFile, keyFile: keyFile, interval: interval}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	s.lastCheck = time.Now()
+	return s, nil
+}
+
+func (s *fileSource) load() error {
+	var modTimes [3]time.Time
+	for i, file := range []string{s.caFile, s.certFile, s.keyFile} {
+		if file == "" {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		modTimes[i] = info.ModTime()
+	}
+
+	var roots *x509.CertPool
+	if s.caFile != "" {
+		var err error
+		if roots, err = (TLSConfig{}).loadCert(s.caFile); err != nil {
+			return fmt.Errorf("failed to load CA CertPool: %w", err)
+		}
+	}
+	var cert *tls.Certificate
+	if s.certFile != "" {
+		c, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+		if err != nil {
+			return fmt.Errorf("could not load TLS key/certificate from %s:%s: %s", s.keyFile, s.certFile, err)
+		}
+		cert = &c
+	}
+
+	s.mu.Lock()
+	s.cert, s.roots, s.modTimes = cert, roots, modTimes
+	s.mu.Unlock()
+	return nil
+}
+
+// reloadIfModified reloads the files if any of them is modified since the last loading, and keeps the loaded
+// ones if the reloading fails, such as the certificate is rotated but the key is not yet.
+func (s *fileSource) reloadIfModified() {
+	if s.interval <= 0 {
+		return
+	}
+	s.mu.Lock()
+	if time.Since(s.lastCheck) < s.interval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastCheck = time.Now()
+	modTimes := s.modTimes
+	s.mu.Unlock()
+
+	for i, file := range []string{s.caFile, s.certFile, s.keyFile} {
+		if file == "" {
+			continue
+		}
+		if info, err := os.Stat(file); err == nil && !info.ModTime().Equal(modTimes[i]) {
+			if err = s.load(); err != nil {
+				logger.Warning(context.Background(), util.AlarmTLSReload, "reload the tls files error", err)
+			}
+			return
+		}
+	}
+}
+
+func (s *fileSource) certificate() *tls.Certificate {
+	s.reloadIfModified()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cert
+}
+
+func (s *fileSource) rootCAs() *x509.CertPool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.roots
+}
+
+// verify verifies the chain with the CA, or the system roots if the CA is not configured.
+func (s *fileSource) verify(certs []*x509.Certificate, serverName string, usage x509.ExtKeyUsage) error {
+	s.reloadIfModified()
+	s.mu.RLock()
+	roots := s.roots
+	s.mu.RUnlock()
+	return verifyChain(certs, roots, serverName, usage)
+}
+
+func (s *fileSource) close() error {
+	return nil
+}
+
+func verifyChain(certs []*x509.Certificate, roots *x509.CertPool, serverName string, usage x509.ExtKeyUsage) error {
+	if len(certs) == 0 {
+		return errors.New("no peer certificate")
+	}
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		DNSName:       serverName,
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{usage},
+	}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(opts)
+	return err
+}
@@ -0,0 +1,299 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlscommon
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	spiffeFetchX509SVIDMethod = "/SpiffeWorkloadAPI/FetchX509SVID"
+	// spiffeHeaderKey is the security header required by the Workload API.
+	spiffeHeaderKey = "workload.spiffe.io"
+
+	spiffeMaxBackoff = 30 * time.Second
+)
+
+// spiffeSource watches the X.509 SVID and the trust bundle from the SPIFFE Workload API, the peers are
+// verified by the bundle and their SPIFFE IDs instead of the host names.
+type spiffeSource struct {
+	address    string
+	allowedIDs map[string]bool
+	conn       *grpc.ClientConn
+	cancel     context.CancelFunc
+	done       chan struct{}
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	roots   *x509.CertPool
+	lastErr error
+}
+
+// newSPIFFESource connects to the Workload API, such as unix:///run/spire/sockets/agent.sock or
+// tcp://127.0.0.1:8081, and waits for the first X.509 SVID within the timeout.
+func newSPIFFESource(address string, allowedIDs []string, timeout time.Duration) (*spiffeSource, error) {
+	target := address
+	if strings.HasPrefix(target, "tcp://") {
+		target = strings.TrimPrefix(target, "tcp://")
+	} else if !strings.HasPrefix(target, "unix:") {
+		return nil, fmt.Errorf("unsupported spiffe workload api address %q, which should be unix:///path or tcp://host:port", address)
+	}
+	conn, err := grpc.Dial(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &spiffeSource{
+		address:    address,
+		allowedIDs: make(map[string]bool, len(allowedIDs)),
+		conn:       conn,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+	for _, id := range allowedIDs {
+		s.allowedIDs[id] = true
+	}
+	updated := make(chan struct{})
+	go s.run(ctx, updated)
+	select {
+	case <-updated:
+		return s, nil
+	case <-time.After(timeout):
+		err = s.lastError()
+		_ = s.close()
+		return nil, fmt.Errorf("no x509 svid fetched from the spiffe workload api %s in %v, last error: %v", address, timeout, err)
+	}
+}
+
+// run watches the Workload API until closed, and rewatches with backoff if the stream is broken.
+func (s *spiffeSource) run(ctx context.Context, updated chan struct{}) {
+	defer close(s.done)
+	var once sync.Once
+	notify := func() { once.Do(func() { close(updated) }) }
+	backoff := time.Second
+	for {
+		err := s.watch(ctx, notify)
+		if ctx.Err() != nil {
+			return
+		}
+		s.mu.Lock()
+		s.lastErr = err
+		s.mu.Unlock()
+		logger.Warning(context.Background(), util.AlarmSPIFFE, "watch the x509 svid from the spiffe workload api error", err, "address", s.address)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > spiffeMaxBackoff {
+			backoff = spiffeMaxBackoff
+		}
+	}
+}
+
+func (s *spiffeSource) watch(ctx context.Context, notify func()) error {
+	ctx = metadata.AppendToOutgoingContext(ctx, spiffeHeaderKey, "true")
+	stream, err := s.conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, spiffeFetchX509SVIDMethod, grpc.ForceCodec(rawCodec{}))
+	if err != nil {
+		return err
+	}
+	// the X509SVIDRequest has no field, so it is encoded as empty
+	if err = stream.SendMsg(&[]byte{}); err != nil {
+		return err
+	}
+	if err = stream.CloseSend(); err != nil {
+		return err
+	}
+	for {
+		var resp []byte
+		if err = stream.RecvMsg(&resp); err != nil {
+			return err
+		}
+		cert, roots, err := parseX509SVIDResponse(resp)
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.cert, s.roots = cert, roots
+		s.mu.Unlock()
+		notify()
+	}
+}
+
+func (s *spiffeSource) lastError() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastErr
+}
+
+func (s *spiffeSource) certificate() *tls.Certificate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cert
+}
+
+// verify verifies the chain with the trust bundle, and the SPIFFE ID in the URI SAN if the allowed IDs are set.
+func (s *spiffeSource) verify(certs []*x509.Certificate, serverName string, usage x509.ExtKeyUsage) error {
+	s.mu.RLock()
+	roots := s.roots
+	s.mu.RUnlock()
+	if err := verifyChain(certs, roots, "", x509.ExtKeyUsageAny); err != nil {
+		return err
+	}
+	id, err := spiffeID(certs[0])
+	if err != nil {
+		return err
+	}
+	if len(s.allowedIDs) > 0 && !s.allowedIDs[id] {
+		return fmt.Errorf("spiffe id %s is not allowed", id)
+	}
+	return nil
+}
+
+func (s *spiffeSource) close() error {
+	s.cancel()
+	err := s.conn.Close()
+	<-s.done
+	return err
+}
+
+func spiffeID(cert *x509.Certificate) (string, error) {
+	for _, uri := range cert.URIs {
+		if uri.Scheme == "spiffe" {
+			return uri.String(), nil
+		}
+	}
+	return "", errors.New("no spiffe id in the peer certificate")
+}
+
+// parseX509SVIDResponse parses the first X509SVID of the X509SVIDResponse, which is the default identity.
+// The fields of X509SVIDResponse are 1: repeated X509SVID svids, 2: repeated bytes crl, 3: map federated_bundles.
+func parseX509SVIDResponse(data []byte) (*tls.Certificate, *x509.CertPool, error) {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, nil, protowire.ParseError(n)
+		}
+		data = data[n:]
+		if num == 1 && typ == protowire.BytesType {
+			svid, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return nil, nil, protowire.ParseError(n)
+			}
+			return parseX509SVID(svid)
+		}
+		if n = protowire.ConsumeFieldValue(num, typ, data); n < 0 {
+			return nil, nil, protowire.ParseError(n)
+		}
+		data = data[n:]
+	}
+	return nil, nil, errors.New("no x509 svid in the workload api response")
+}
+
+// parseX509SVID parses the X509SVID, whose fields are 1: string spiffe_id, 2: bytes x509_svid of the ASN.1 DER
+// certificate chain, 3: bytes x509_svid_key of the PKCS#8 DER private key, 4: bytes bundle of the ASN.1 DER CA certificates.
+func parseX509SVID(data []byte) (*tls.Certificate, *x509.CertPool, error) {
+	var certsDER, keyDER, bundleDER []byte
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, nil, protowire.ParseError(n)
+		}
+		data = data[n:]
+		if typ == protowire.BytesType && num >= 2 && num <= 4 {
+			value, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return nil, nil, protowire.ParseError(n)
+			}
+			switch num {
+			case 2:
+				certsDER = value
+			case 3:
+				keyDER = value
+			case 4:
+				bundleDER = value
+			}
+			data = data[n:]
+			continue
+		}
+		if n = protowire.ConsumeFieldValue(num, typ, data); n < 0 {
+			return nil, nil, protowire.ParseError(n)
+		}
+		data = data[n:]
+	}
+
+	certs, err := x509.ParseCertificates(certsDER)
+	if err != nil || len(certs) == 0 {
+		return nil, nil, fmt.Errorf("invalid x509 svid certificates: %v", err)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(keyDER)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid x509 svid key: %v", err)
+	}
+	bundle, err := x509.ParseCertificates(bundleDER)
+	if err != nil || len(bundle) == 0 {
+		return nil, nil, fmt.Errorf("invalid x509 svid bundle: %v", err)
+	}
+	cert := &tls.Certificate{PrivateKey: key, Leaf: certs[0]}
+	for _, c := range certs {
+		cert.Certificate = append(cert.Certificate, c.Raw)
+	}
+	roots := x509.NewCertPool()
+	for _, c := range bundle {
+		roots.AddCert(c)
+	}
+	return cert, roots, nil
+}
+
+// rawCodec passes the protobuf messages encoded by protowire through gRPC, which avoids the generated code
+// of the Workload API.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected message type %T", v)
+	}
+	return *b, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 // The defaults should be a safe configuration
@@ -29,6 +30,9 @@ const defaultMinTLSVersion = tls.VersionTLS12
 // Uses the default MaxVersion from "crypto/tls"
 const defaultMaxTLSVersion = 0
 
+// The timeout to wait for the first X.509 SVID from the SPIFFE Workload API
+const spiffeFetchTimeout = 10 * time.Second
+
 // TLSConfig is the interface used to configure a tcp client or server from a `Config`
 type TLSConfig struct {
 	// Enable TLS
@@ -47,12 +51,30 @@ type TLSConfig struct {
 	// MaxVersion sets the maximum TLS version that is acceptable.
 	// If not set, refer to crypto/tls for defaults. (optional)
 	MaxVersion string
+	// ClientAuth requires the clients to present certificates verified by the CA, which only works for a server. (optional)
+	ClientAuth bool
+	// ReloadIntervalSec is the interval to check the modification of the cert and key files, and the CA file of a server,
+	// which are reloaded without restart if modified. If not set, the files are loaded only once. (optional)
+	ReloadIntervalSec int
+	// SpiffeEndpointSocket is the address of the SPIFFE Workload API, such as unix:///run/spire/sockets/agent.sock.
+	// If set, the certificate and the trust bundle are fetched from it instead of the files. (optional)
+	SpiffeEndpointSocket string
+	// SpiffeAllowedIDs are the SPIFFE IDs of the peers allowed to connect.
+	// If not set, any peer verified by the trust bundle is allowed. (optional)
+	SpiffeAllowedIDs []string
+
+	source certSource
 }
 
+// LoadTLSConfig returns the tls config for a client, whose certificate is reloaded when modified if ReloadIntervalSec
+// is set, or fetched from the SPIFFE Workload API with the trust bundle if SpiffeEndpointSocket is set.
 func (c *TLSConfig) LoadTLSConfig() (*tls.Config, error) {
 	if !c.Enabled {
 		return nil, nil
 	}
+	if c.ReloadIntervalSec > 0 || c.SpiffeEndpointSocket != "" {
+		return c.loadDynamicTLSConfig()
+	}
 	var err error
 	var certPool *x509.CertPool
 	if c.CAFile != "" {
@@ -89,6 +111,122 @@ func (c *TLSConfig) LoadTLSConfig() (*tls.Config, error) {
 	}, nil
 }
 
+func (c *TLSConfig) loadDynamicTLSConfig() (*tls.Config, error) {
+	minVersion, maxVersion, err := c.versions()
+	if err != nil {
+		return nil, err
+	}
+	source, err := c.loadSource()
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			if cert := source.certificate(); cert != nil {
+				return cert, nil
+			}
+			return &tls.Certificate{}, nil
+		},
+		InsecureSkipVerify: c.InsecureSkipVerify, //nolint:gosec
+		MinVersion:         minVersion,
+		MaxVersion:         maxVersion,
+	}
+	if fs, ok := source.(*fileSource); ok {
+		// the server name is only known by the default verification, so the CA of a client is loaded once
+		config.RootCAs = fs.rootCAs()
+	} else if !c.InsecureSkipVerify {
+		// the SVIDs are verified by the SPIFFE IDs instead of the server names
+		config.InsecureSkipVerify = true
+		config.VerifyConnection = func(cs tls.ConnectionState) error {
+			return source.verify(cs.PeerCertificates, cs.ServerName, x509.ExtKeyUsageServerAuth)
+		}
+	}
+	return config, nil
+}
+
+// LoadServerTLSConfig returns the tls config for a server, which requires and verifies the client
+// certificates if ClientAuth is set.
+func (c *TLSConfig) LoadServerTLSConfig() (*tls.Config, error) {
+	if !c.Enabled {
+		return nil, nil
+	}
+	minVersion, maxVersion, err := c.versions()
+	if err != nil {
+		return nil, err
+	}
+	if c.SpiffeEndpointSocket == "" {
+		if c.CertFile == "" || c.KeyFile == "" {
+			return nil, errors.New("both certificate and key must be supplied for a TLS server")
+		}
+		if c.ClientAuth && c.CAFile == "" {
+			return nil, errors.New("the CA must be supplied to verify the client certificates")
+		}
+	}
+	source, err := c.loadSource()
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return source.certificate(), nil
+		},
+		MinVersion: minVersion,
+		MaxVersion: maxVersion,
+	}
+	if c.ClientAuth {
+		// the client certificates are verified by VerifyConnection with the current CA
+		config.ClientAuth = tls.RequireAnyClientCert
+		config.VerifyConnection = func(cs tls.ConnectionState) error {
+			return source.verify(cs.PeerCertificates, "", x509.ExtKeyUsageClientAuth)
+		}
+	}
+	return config, nil
+}
+
+// Close stops watching the SPIFFE Workload API if started.
+func (c *TLSConfig) Close() error {
+	if c == nil || c.source == nil {
+		return nil
+	}
+	err := c.source.close()
+	c.source = nil
+	return err
+}
+
+// loadSource returns the loaded source, so that the configs loaded from the same TLSConfig share it.
+func (c *TLSConfig) loadSource() (certSource, error) {
+	if c.source != nil {
+		return c.source, nil
+	}
+	if c.SpiffeEndpointSocket != "" {
+		source, err := newSPIFFESource(c.SpiffeEndpointSocket, c.SpiffeAllowedIDs, spiffeFetchTimeout)
+		if err != nil {
+			return nil, err
+		}
+		c.source = source
+		return source, nil
+	}
+	if (c.CertFile == "" && c.KeyFile != "") || (c.CertFile != "" && c.KeyFile == "") {
+		return nil, errors.New("for auth via TLS, either both certificate and key must be supplied, or neither")
+	}
+	source, err := newFileSource(c.CAFile, c.CertFile, c.KeyFile, time.Duration(c.ReloadIntervalSec)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	c.source = source
+	return source, nil
+}
+
+func (c *TLSConfig) versions() (minVersion, maxVersion uint16, err error) {
+	if minVersion, err = convertVersion(c.MinVersion, defaultMinTLSVersion); err != nil {
+		return 0, 0, fmt.Errorf("invalid TLS min_version: %w", err)
+	}
+	if maxVersion, err = convertVersion(c.MaxVersion, defaultMaxTLSVersion); err != nil {
+		return 0, 0, fmt.Errorf("invalid TLS max_version: %w", err)
+	}
+	return minVersion, maxVersion, nil
+}
+
 func (c TLSConfig) loadCert(caPath string) (*x509.CertPool, error) {
 	caPEM, err := os.ReadFile(filepath.Clean(caPath))
 	if err != nil {
@@ -0,0 +1,287 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlscommon
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCert(t *testing.T, cn string, parent *testCert, uri string) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{cn},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if uri != "" {
+		u, err := url.Parse(uri)
+		require.NoError(t, err)
+		template.URIs = []*url.URL{u}
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) write(t *testing.T, certFile, keyFile string) {
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0600))
+	if keyFile == "" {
+		return
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(c.key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+}
+
+func handshake(t *testing.T, clientConfig, serverConfig *tls.Config) (clientErr, serverErr error) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+	done := make(chan error, 1)
+	go func() {
+		server := tls.Server(serverConn, serverConfig)
+		err := server.Handshake()
+		if err != nil {
+			serverConn.Close()
+		}
+		done <- err
+	}()
+	client := tls.Client(clientConn, clientConfig)
+	clientErr = client.Handshake()
+	if clientErr != nil {
+		clientConn.Close()
+	} else {
+		// the client certificate is verified after the client handshake in TLS 1.3, so reads the alert
+		go func() {
+			_, _ = client.Read(make([]byte, 1))
+		}()
+	}
+	return clientErr, <-done
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "ca", nil, "")
+	ca.write(t, filepath.Join(dir, "ca.pem"), "")
+	newTestCert(t, "localhost", ca, "").write(t, filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key"))
+	newTestCert(t, "client", ca, "").write(t, filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key"))
+	other := newTestCert(t, "other", nil, "")
+	newTestCert(t, "client", other, "").write(t, filepath.Join(dir, "other.pem"), filepath.Join(dir, "other.key"))
+
+	server := &TLSConfig{
+		Enabled:    true,
+		CAFile:     filepath.Join(dir, "ca.pem"),
+		CertFile:   filepath.Join(dir, "server.pem"),
+		KeyFile:    filepath.Join(dir, "server.key"),
+		ClientAuth: true,
+	}
+	serverConfig, err := server.LoadServerTLSConfig()
+	require.NoError(t, err)
+
+	client := &TLSConfig{
+		Enabled:           true,
+		CAFile:            filepath.Join(dir, "ca.pem"),
+		CertFile:          filepath.Join(dir, "client.pem"),
+		KeyFile:           filepath.Join(dir, "client.key"),
+		ReloadIntervalSec: 1,
+	}
+	clientConfig, err := client.LoadTLSConfig()
+	require.NoError(t, err)
+	clientConfig.ServerName = "localhost"
+	clientErr, serverErr := handshake(t, clientConfig, serverConfig)
+	assert.NoError(t, clientErr)
+	assert.NoError(t, serverErr)
+
+	// the server name is verified
+	clientConfig.ServerName = "example.com"
+	clientErr, _ = handshake(t, clientConfig, serverConfig)
+	assert.Error(t, clientErr)
+
+	// the client certificate signed by another CA is rejected
+	untrusted := &TLSConfig{
+		Enabled:  true,
+		CAFile:   filepath.Join(dir, "ca.pem"),
+		CertFile: filepath.Join(dir, "other.pem"),
+		KeyFile:  filepath.Join(dir, "other.key"),
+	}
+	untrustedConfig, err := untrusted.LoadTLSConfig()
+	require.NoError(t, err)
+	untrustedConfig.ServerName = "localhost"
+	_, serverErr = handshake(t, untrustedConfig, serverConfig)
+	assert.Error(t, serverErr)
+
+	server.ClientAuth = true
+	server.CAFile = ""
+	server.source = nil
+	_, err = server.LoadServerTLSConfig()
+	assert.Error(t, err)
+}
+
+func TestReloadCertificate(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "ca", nil, "")
+	ca.write(t, filepath.Join(dir, "ca.pem"), "")
+	first := newTestCert(t, "localhost", ca, "")
+	first.write(t, filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key"))
+
+	server := &TLSConfig{
+		Enabled:           true,
+		CertFile:          filepath.Join(dir, "server.pem"),
+		KeyFile:           filepath.Join(dir, "server.key"),
+		ReloadIntervalSec: 1,
+	}
+	serverConfig, err := server.LoadServerTLSConfig()
+	require.NoError(t, err)
+	cert, err := serverConfig.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, first.cert.Raw, cert.Certificate[0])
+
+	// the rotated certificate takes effect after the interval
+	second := newTestCert(t, "localhost", ca, "")
+	second.write(t, filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key"))
+	modTime := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "server.pem"), modTime, modTime))
+	server.source.(*fileSource).lastCheck = time.Now().Add(-2 * time.Second)
+	cert, err = serverConfig.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, second.cert.Raw, cert.Certificate[0])
+
+	// the loaded certificate is kept if the new one is broken
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "server.pem"), []byte("broken"), 0600))
+	modTime = modTime.Add(time.Second)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "server.pem"), modTime, modTime))
+	server.source.(*fileSource).lastCheck = time.Now().Add(-2 * time.Second)
+	cert, err = serverConfig.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, second.cert.Raw, cert.Certificate[0])
+}
+
+func encodeX509SVIDResponse(t *testing.T, svid, ca *testCert) []byte {
+	key, err := x509.MarshalPKCS8PrivateKey(svid.key)
+	require.NoError(t, err)
+	var msg []byte
+	msg = protowire.AppendTag(msg, 1, protowire.BytesType)
+	msg = protowire.AppendString(msg, svid.cert.URIs[0].String())
+	msg = protowire.AppendTag(msg, 2, protowire.BytesType)
+	msg = protowire.AppendBytes(msg, svid.cert.Raw)
+	msg = protowire.AppendTag(msg, 3, protowire.BytesType)
+	msg = protowire.AppendBytes(msg, key)
+	msg = protowire.AppendTag(msg, 4, protowire.BytesType)
+	msg = protowire.AppendBytes(msg, ca.cert.Raw)
+	var resp []byte
+	resp = protowire.AppendTag(resp, 1, protowire.BytesType)
+	return protowire.AppendBytes(resp, msg)
+}
+
+// startWorkloadAPI starts a fake SPIFFE Workload API serving the X.509 SVID.
+func startWorkloadAPI(t *testing.T, svid, ca *testCert) string {
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	resp := encodeX509SVIDResponse(t, svid, ca)
+	server := grpc.NewServer(grpc.ForceServerCodec(rawCodec{}), grpc.UnknownServiceHandler(func(srv interface{}, stream grpc.ServerStream) error {
+		var req []byte
+		if err := stream.RecvMsg(&req); err != nil {
+			return err
+		}
+		if err := stream.SendMsg(&resp); err != nil {
+			return err
+		}
+		<-stream.Context().Done()
+		return nil
+	}))
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+	return "unix://" + socket
+}
+
+func TestSPIFFE(t *testing.T) {
+	ca := newTestCert(t, "ca", nil, "")
+	serverSVID := newTestCert(t, "server", ca, "spiffe://example.org/server")
+	clientSVID := newTestCert(t, "client", ca, "spiffe://example.org/client")
+
+	serverAddress := startWorkloadAPI(t, serverSVID, ca)
+	server := &TLSConfig{
+		Enabled:              true,
+		ClientAuth:           true,
+		SpiffeEndpointSocket: serverAddress,
+		SpiffeAllowedIDs:     []string{"spiffe://example.org/client"},
+	}
+	serverConfig, err := server.LoadServerTLSConfig()
+	require.NoError(t, err)
+	defer server.Close()
+
+	clientAddress := startWorkloadAPI(t, clientSVID, ca)
+	client := &TLSConfig{
+		Enabled:              true,
+		SpiffeEndpointSocket: clientAddress,
+		SpiffeAllowedIDs:     []string{"spiffe://example.org/server"},
+	}
+	clientConfig, err := client.LoadTLSConfig()
+	require.NoError(t, err)
+	defer client.Close()
+	clientErr, serverErr := handshake(t, clientConfig, serverConfig)
+	assert.NoError(t, clientErr)
+	assert.NoError(t, serverErr)
+
+	// the peer with an unexpected SPIFFE ID is rejected
+	client.source.(*spiffeSource).allowedIDs = map[string]bool{"spiffe://example.org/other": true}
+	clientErr, _ = handshake(t, clientConfig, serverConfig)
+	assert.ErrorContains(t, clientErr, "spiffe id spiffe://example.org/server is not allowed")
+
+	_, err = (&TLSConfig{Enabled: true, SpiffeEndpointSocket: "/run/spire/sockets/agent.sock"}).LoadTLSConfig()
+	assert.Error(t, err)
+}
@@ -40,6 +40,17 @@ func DeleteAlarm(key string) {
 	delete(RegisterAlarms, key)
 }
 
+// GetRegisterAlarms returns a copy of the registered alarms.
+func GetRegisterAlarms() []*Alarm {
+	regMu.Lock()
+	defer regMu.Unlock()
+	alarms := make([]*Alarm, 0, len(RegisterAlarms))
+	for _, alarm := range RegisterAlarms {
+		alarms = append(alarms, alarm)
+	}
+	return alarms
+}
+
 func RegisterAlarmsSerializeToPb(logGroup *protocol.LogGroup) {
 	regMu.Lock()
 	defer regMu.Unlock()
@@ -51,12 +62,32 @@ func RegisterAlarmsSerializeToPb(logGroup *protocol.LogGroup) {
 type AlarmItem struct {
 	Message string
 	Count   int
+	// Total is the count since the alarm is initialized, which is not cleared after serialize.
+	Total int64
+}
+
+// maxRecentAlarms is the count of the recent records kept by an Alarm.
+const maxRecentAlarms = 100
+
+// AlarmRecord is the recent alarms with the same type and message, the alarms happened within the
+// dedup interval of the type after the last one are merged into the record.
+type AlarmRecord struct {
+	AlarmDefinition
+	Project    string    `json:"project"`
+	Logstore   string    `json:"logstore"`
+	ConfigName string    `json:"config_name,omitempty"`
+	Message    string    `json:"message"`
+	Count      int64     `json:"count"`
+	FirstTime  time.Time `json:"first_time"`
+	LastTime   time.Time `json:"last_time"`
 }
 
 type Alarm struct {
 	AlarmMap map[string]*AlarmItem
 	Project  string
 	Logstore string
+	// recent is the latest records ordered by the first time.
+	recent []*AlarmRecord
 }
 
 func (p *Alarm) Init(project, logstore string) {
@@ -79,6 +110,7 @@ func (p *Alarm) Record(alarmType, message string) {
 	if len(alarmType) == 0 {
 		return
 	}
+	message = RedactSecrets(message)
 	mu.Lock()
 	alarmItem, existFlag := p.AlarmMap[alarmType]
 	if !existFlag {
@@ -87,9 +119,58 @@ func (p *Alarm) Record(alarmType, message string) {
 	}
 	alarmItem.Message = message
 	alarmItem.Count++
+	alarmItem.Total++
+	p.recordRecent(alarmType, message, time.Now())
 	mu.Unlock()
 }
 
+func (p *Alarm) recordRecent(alarmType, message string, now time.Time) {
+	def := GetAlarmDefinition(alarmType)
+	for _, record := range p.recent {
+		if record.Type == alarmType && record.Message == message && now.Sub(record.LastTime) < def.DedupInterval {
+			record.Count++
+			record.LastTime = now
+			return
+		}
+	}
+	if len(p.recent) >= maxRecentAlarms {
+		p.recent = append(p.recent[:0], p.recent[1:]...)
+	}
+	p.recent = append(p.recent, &AlarmRecord{
+		AlarmDefinition: def,
+		Project:         p.Project,
+		Logstore:        p.Logstore,
+		Message:         message,
+		Count:           1,
+		FirstTime:       now,
+		LastTime:        now,
+	})
+}
+
+// Recent returns the copies of the recent records which last happened after @since.
+func (p *Alarm) Recent(since time.Time) []AlarmRecord {
+	mu.Lock()
+	defer mu.Unlock()
+	records := make([]AlarmRecord, 0, len(p.recent))
+	for _, record := range p.recent {
+		if record.LastTime.After(since) {
+			records = append(records, *record)
+		}
+	}
+	return records
+}
+
+// Totals returns the total count of each alarm type.
+func (p *Alarm) Totals() map[string]int64 {
+	mu.Lock()
+	defer mu.Unlock()
+	totals := make(map[string]int64, len(p.AlarmMap))
+	for alarmType, item := range p.AlarmMap {
+		totals[alarmType] = item.Total
+	}
+	return totals
+}
+
 func (p *Alarm) SerializeToPb(logGroup *protocol.LogGroup) {
 	nowTime := (uint32)(time.Now().Unix())
 	mu.Lock()
@@ -101,6 +182,10 @@ func (p *Alarm) SerializeToPb(logGroup *protocol.LogGroup) {
 		log.Contents = append(log.Contents, &protocol.Log_Content{Key: "project_name", Value: p.Project})
 		log.Contents = append(log.Contents, &protocol.Log_Content{Key: "category", Value: p.Logstore})
 		log.Contents = append(log.Contents, &protocol.Log_Content{Key: "alarm_type", Value: alarmType})
+		def := GetAlarmDefinition(alarmType)
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: "alarm_code", Value: strconv.Itoa(def.Code)})
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: "alarm_severity", Value: def.Severity.String()})
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: "alarm_component", Value: def.Component})
 		log.Contents = append(log.Contents, &protocol.Log_Content{Key: "alarm_count", Value: strconv.Itoa(item.Count)})
 		log.Contents = append(log.Contents, &protocol.Log_Content{Key: "alarm_message", Value: item.Message})
 		log.Contents = append(log.Contents, &protocol.Log_Content{Key: "ip", Value: GetIPAddress()})
@@ -0,0 +1,243 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AlarmSeverity is the severity level of an alarm type.
+type AlarmSeverity int
+
+const (
+	AlarmSeverityInfo AlarmSeverity = iota
+	AlarmSeverityWarning
+	AlarmSeverityError
+	AlarmSeverityCritical
+)
+
+var alarmSeverityNames = []string{"info", "warning", "error", "critical"}
+
+func (s AlarmSeverity) String() string {
+	if s < 0 || int(s) >= len(alarmSeverityNames) {
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+	return alarmSeverityNames[s]
+}
+
+func (s AlarmSeverity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *AlarmSeverity) UnmarshalText(text []byte) error {
+	v, err := ParseAlarmSeverity(string(text))
+	if err == nil {
+		*s = v
+	}
+	return err
+}
+
+// ParseAlarmSeverity parses the name of a severity case-insensitively.
+func ParseAlarmSeverity(name string) (AlarmSeverity, error) {
+	for i, n := range alarmSeverityNames {
+		if strings.EqualFold(n, name) {
+			return AlarmSeverity(i), nil
+		}
+	}
+	return AlarmSeverityInfo, fmt.Errorf("unknown alarm severity %q", name)
+}
+
+// The components of the alarm types, the codes of a component are in the same range.
+const (
+	AlarmComponentCore       = "core"       // 1xxx
+	AlarmComponentCheckpoint = "checkpoint" // 2xxx
+	AlarmComponentPipeline   = "pipeline"   // 3xxx
+	AlarmComponentInput      = "input"      // 4xxx
+	AlarmComponentProcessor  = "processor"  // 5xxx
+	AlarmComponentFlusher    = "flusher"    // 6xxx
+	AlarmComponentContainer  = "container"  // 7xxx
+	AlarmComponentTelemetry  = "telemetry"  // 8xxx
+	AlarmComponentUnknown    = "unknown"
+)
+
+// AlarmCodeUnknown is the code of the alarm types without definitions.
+const AlarmCodeUnknown = 0
+
+// defaultAlarmDedupInterval is the window to merge the alarms with the same type and message into one record.
+const defaultAlarmDedupInterval = time.Minute
+
+// The alarm types of the agent core, the alarm types of the plugins are in alarm_types.go. A new alarm type
+// should be added with its definition there rather than passing a string literal to the logger.
+const (
+	AlarmPlugin              = "PLUGIN_ALARM"
+	AlarmLoadPlugin          = "LOAD_PLUGIN_ALARM"
+	AlarmStartPlugin         = "START_PLUGIN_ALARM"
+	AlarmPluginRun           = "PLUGIN_RUN_ALARM"
+	AlarmPluginRuntime       = "PLUGIN_RUNTIME_ALARM"
+	AlarmInvalidProcessor    = "INVALID_PROCESSOR_TYPE"
+	AlarmInvalidAggregator   = "INVALID_AGGREGATOR_TYPE"
+	AlarmLoadConfig          = "LOAD_CONFIG_ALARM"
+	AlarmConfigLoad          = "CONFIG_LOAD_ALARM"
+	AlarmConfigStopTimeout   = "CONFIG_STOP_TIMEOUT_ALARM"
+	AlarmPipelineConfig      = "PIPELINE_CONFIG_ALARM"
+	AlarmDefaultFlusher      = "DEFAULT_FLUSHER_ALARM"
+	AlarmInitHTTPServer      = "INIT_HTTP_SERVER_ALARM"
+	AlarmWrongProtobuf       = "WRONG_PROTOBUF_ALARM"
+	AlarmHotStandby          = "HOT_STANDBY_ALARM"
+	AlarmCheckpoint          = "CHECKPOINT_ALARM"
+	AlarmCheckpointInit      = "CHECKPOINT_INIT_ALARM"
+	AlarmCheckpointGet       = "CHECKPOINT_GET_ALARM"
+	AlarmCheckpointSave      = "CHECKPOINT_SAVE_ALARM"
+	AlarmCheckpointInvalid   = "CHECKPOINT_INVALID_ALARM"
+	AlarmFlushData           = "FLUSH_DATA_ALARM"
+	AlarmDropData            = "DROP_DATA_ALARM"
+	AlarmStopFlusher         = "STOP_FLUSHER_ALARM"
+	AlarmAggregatorInit      = "AGGREGATOR_INIT_ERROR"
+	AlarmAggregatorAdd       = "AGGREGATOR_ADD_ALARM"
+	AlarmSlowPlugin          = "SLOW_PLUGIN_ALARM"
+	AlarmPipelineAudit       = "PIPELINE_AUDIT_ALARM"
+	AlarmInputCollect        = "INPUT_COLLECT_ALARM"
+	AlarmProcessorInit       = "PROCESSOR_INIT_ALARM"
+	AlarmInvalidRegex        = "INVALID_REGEX_ALARM"
+	AlarmRegexUnmatched      = "REGEX_UNMATCHED_ALARM"
+	AlarmFlusherInit         = "FLUSHER_INIT_ALARM"
+	AlarmFlusherFlush        = "FLUSHER_FLUSH_ALARM"
+	AlarmFlusherStop         = "FLUSHER_STOP_ALARM"
+	AlarmDockerCenter        = "DOCKER_CENTER_ALARM"
+	AlarmDockerEvent         = "DOCKER_EVENT_ALARM"
+	AlarmKubernetesMeta      = "KUBERNETES_META_ALARM"
+	AlarmConnectCRIRuntime   = "CONNECT_CRI_RUNTIME_ALARM"
+	AlarmSelfMetricsExport   = "SELF_METRICS_EXPORT_ALARM"
+	AlarmPProfProfile        = "PPROF_PROFILE_ALARM"
+	AlarmUpdateSTS           = "UPDATE_STS_ALARM"
+	AlarmStatFile            = "STAT_FILE_ALARM"
+	AlarmEBPF                = "EBPF_ALARM"
+	AlarmObjectStorage       = "OBJECT_STORAGE_ALARM"
+	AlarmCloudLogs           = "CLOUD_LOGS_ALARM"
+	AlarmFileReplay          = "FILE_REPLAY_ALARM"
+	AlarmCreateContainerInfo = "CREATE_CONTAINERD_INFO_ALARM"
+)
+
+// AlarmDefinition describes an alarm type.
+type AlarmDefinition struct {
+	Type      string        `json:"type"`
+	Code      int           `json:"code"`
+	Severity  AlarmSeverity `json:"severity"`
+	Component string        `json:"component"`
+	// Hint is the suggestion to resolve the problem.
+	Hint string `json:"hint,omitempty"`
+	// DedupInterval is the window to merge the same alarms into one record, defaultAlarmDedupInterval if zero.
+	DedupInterval time.Duration `json:"-"`
+}
+
+var (
+	alarmDefinitionsMu sync.RWMutex
+	alarmDefinitions   = make(map[string]AlarmDefinition)
+)
+
+// RegisterAlarmDefinition registers or replaces the definition of an alarm type.
+func RegisterAlarmDefinition(def AlarmDefinition) {
+	if def.DedupInterval == 0 {
+		def.DedupInterval = defaultAlarmDedupInterval
+	}
+	alarmDefinitionsMu.Lock()
+	defer alarmDefinitionsMu.Unlock()
+	alarmDefinitions[def.Type] = def
+}
+
+// GetAlarmDefinition returns the definition of @alarmType, the types without definitions are unknown warnings.
+func GetAlarmDefinition(alarmType string) AlarmDefinition {
+	alarmDefinitionsMu.RLock()
+	def, ok := alarmDefinitions[alarmType]
+	alarmDefinitionsMu.RUnlock()
+	if ok {
+		return def
+	}
+	return AlarmDefinition{
+		Type:          alarmType,
+		Code:          AlarmCodeUnknown,
+		Severity:      AlarmSeverityWarning,
+		Component:     AlarmComponentUnknown,
+		DedupInterval: defaultAlarmDedupInterval,
+	}
+}
+
+// GetAlarmDefinitions returns all the registered definitions.
+func GetAlarmDefinitions() []AlarmDefinition {
+	alarmDefinitionsMu.RLock()
+	defer alarmDefinitionsMu.RUnlock()
+	defs := make([]AlarmDefinition, 0, len(alarmDefinitions))
+	for _, def := range alarmDefinitions {
+		defs = append(defs, def)
+	}
+	return defs
+}
+
+func init() {
+	for _, def := range []AlarmDefinition{
+		{Type: AlarmPlugin, Code: 1001, Severity: AlarmSeverityError, Component: AlarmComponentCore, Hint: "check the agent log around the alarm for the failed plugin operation"},
+		{Type: AlarmLoadPlugin, Code: 1002, Severity: AlarmSeverityError, Component: AlarmComponentCore, Hint: "check the plugin types and details in the config"},
+		{Type: AlarmStartPlugin, Code: 1003, Severity: AlarmSeverityError, Component: AlarmComponentCore, Hint: "check the plugin config and the resources it depends on"},
+		{Type: AlarmPluginRun, Code: 1004, Severity: AlarmSeverityError, Component: AlarmComponentCore, Hint: "the plugin failed while running, check its config and the agent log"},
+		{Type: AlarmPluginRuntime, Code: 1005, Severity: AlarmSeverityCritical, Component: AlarmComponentCore, Hint: "a plugin panicked, report the stack in the agent log to the plugin maintainers"},
+		{Type: AlarmInvalidProcessor, Code: 1006, Severity: AlarmSeverityError, Component: AlarmComponentCore, Hint: "the processor type is wrong or the agent version is too old"},
+		{Type: AlarmInvalidAggregator, Code: 1007, Severity: AlarmSeverityError, Component: AlarmComponentCore, Hint: "the aggregator type is wrong or the agent version is too old"},
+		{Type: AlarmLoadConfig, Code: 1008, Severity: AlarmSeverityError, Component: AlarmComponentCore, Hint: "check the syntax of the config"},
+		{Type: AlarmConfigLoad, Code: 1009, Severity: AlarmSeverityError, Component: AlarmComponentCore, Hint: "check the syntax of the config"},
+		{Type: AlarmConfigStopTimeout, Code: 1010, Severity: AlarmSeverityWarning, Component: AlarmComponentCore, Hint: "some plugins of the config are slow to stop, the data may be duplicated after restart"},
+		{Type: AlarmPipelineConfig, Code: 1011, Severity: AlarmSeverityError, Component: AlarmComponentCore, Hint: "check the PipelineConfig resource and its status"},
+		{Type: AlarmDefaultFlusher, Code: 1012, Severity: AlarmSeverityWarning, Component: AlarmComponentCore, Hint: "add flushers to the config explicitly"},
+		{Type: AlarmInitHTTPServer, Code: 1013, Severity: AlarmSeverityError, Component: AlarmComponentCore, Hint: "the http server address may be in use"},
+		{Type: AlarmWrongProtobuf, Code: 1014, Severity: AlarmSeverityError, Component: AlarmComponentCore, Hint: "the data passed from the C++ part is broken"},
+		{Type: AlarmHotStandby, Code: 1015, Severity: AlarmSeverityWarning, Component: AlarmComponentCore, Hint: "the service inputs failed over between the active and standby agents, check the lock and the peer agent"},
+		{Type: AlarmCheckpoint, Code: 2001, Severity: AlarmSeverityError, Component: AlarmComponentCheckpoint, Hint: "check the permission and the free space of the checkpoint directory"},
+		{Type: AlarmCheckpointInit, Code: 2002, Severity: AlarmSeverityCritical, Component: AlarmComponentCheckpoint, Hint: "check the permission of the checkpoint directory"},
+		{Type: AlarmCheckpointGet, Code: 2003, Severity: AlarmSeverityWarning, Component: AlarmComponentCheckpoint, Hint: "the checkpoint is lost, the data may be collected again"},
+		{Type: AlarmCheckpointSave, Code: 2004, Severity: AlarmSeverityError, Component: AlarmComponentCheckpoint, Hint: "check the free space of the checkpoint directory"},
+		{Type: AlarmCheckpointInvalid, Code: 2005, Severity: AlarmSeverityWarning, Component: AlarmComponentCheckpoint, Hint: "the checkpoint is broken and ignored"},
+		{Type: AlarmFlushData, Code: 3001, Severity: AlarmSeverityError, Component: AlarmComponentPipeline, Hint: "check the network and the credentials of the flusher"},
+		{Type: AlarmDropData, Code: 3002, Severity: AlarmSeverityCritical, Component: AlarmComponentPipeline, Hint: "data is dropped, check the flusher availability and the queue sizes"},
+		{Type: AlarmStopFlusher, Code: 3003, Severity: AlarmSeverityWarning, Component: AlarmComponentPipeline, Hint: "the flusher failed to stop, the data in it may be lost"},
+		{Type: AlarmAggregatorInit, Code: 3004, Severity: AlarmSeverityError, Component: AlarmComponentPipeline, Hint: "check the aggregator config"},
+		{Type: AlarmAggregatorAdd, Code: 3005, Severity: AlarmSeverityWarning, Component: AlarmComponentPipeline, Hint: "the aggregator is blocked, the flusher may be too slow"},
+		{Type: AlarmSlowPlugin, Code: 3006, Severity: AlarmSeverityWarning, Component: AlarmComponentPipeline, Hint: "check the slowest plugins with the /slowplugins endpoint"},
+		{Type: AlarmPipelineAudit, Code: 3007, Severity: AlarmSeverityError, Component: AlarmComponentPipeline, Hint: "events failed to be flushed or were dropped when stopping, check the reconciliation with the /audit endpoint"},
+		{Type: AlarmInputCollect, Code: 4001, Severity: AlarmSeverityWarning, Component: AlarmComponentInput, Hint: "the metric input failed to collect, check its target"},
+		{Type: AlarmStatFile, Code: 4002, Severity: AlarmSeverityWarning, Component: AlarmComponentInput, Hint: "check the existence and the permission of the file"},
+		{Type: AlarmEBPF, Code: 4003, Severity: AlarmSeverityError, Component: AlarmComponentInput, Hint: "check the kernel version, the tracefs mount and the privileges of the agent, such as CAP_BPF or CAP_SYS_ADMIN"},
+		{Type: AlarmObjectStorage, Code: 4004, Severity: AlarmSeverityWarning, Component: AlarmComponentInput, Hint: "check the endpoint, the credentials and the permission to list and read the objects"},
+		{Type: AlarmCloudLogs, Code: 4005, Severity: AlarmSeverityWarning, Component: AlarmComponentInput, Hint: "check the endpoint, the credentials and the permission to read the cloud logs, or lower the request rate if throttled"},
+		{Type: AlarmFileReplay, Code: 4006, Severity: AlarmSeverityWarning, Component: AlarmComponentInput, Hint: "check the permission of the archived files, and the TimeRegex and the TimeFormat if the timestamps cannot be parsed"},
+		{Type: AlarmProcessorInit, Code: 5001, Severity: AlarmSeverityError, Component: AlarmComponentProcessor, Hint: "check the processor config"},
+		{Type: AlarmInvalidRegex, Code: 5002, Severity: AlarmSeverityError, Component: AlarmComponentProcessor, Hint: "fix the regex syntax in the processor config"},
+		{Type: AlarmRegexUnmatched, Code: 5003, Severity: AlarmSeverityInfo, Component: AlarmComponentProcessor, Hint: "the log does not match the regex, check the regex or the log format"},
+		{Type: AlarmFlusherInit, Code: 6001, Severity: AlarmSeverityError, Component: AlarmComponentFlusher, Hint: "check the flusher config and the connectivity of its endpoint"},
+		{Type: AlarmFlusherFlush, Code: 6002, Severity: AlarmSeverityError, Component: AlarmComponentFlusher, Hint: "check the network and the credentials of the flusher"},
+		{Type: AlarmFlusherStop, Code: 6003, Severity: AlarmSeverityWarning, Component: AlarmComponentFlusher, Hint: "the flusher failed to stop, the data in it may be lost"},
+		{Type: AlarmUpdateSTS, Code: 6004, Severity: AlarmSeverityError, Component: AlarmComponentFlusher, Hint: "check the credential provider of the flusher"},
+		{Type: AlarmDockerCenter, Code: 7001, Severity: AlarmSeverityError, Component: AlarmComponentContainer, Hint: "check the permission of the container runtime socket"},
+		{Type: AlarmDockerEvent, Code: 7002, Severity: AlarmSeverityWarning, Component: AlarmComponentContainer, Hint: "the container events are interrupted, the container list may be stale"},
+		{Type: AlarmKubernetesMeta, Code: 7003, Severity: AlarmSeverityWarning, Component: AlarmComponentContainer, Hint: "check the permission to access the kubernetes apiserver"},
+		{Type: AlarmConnectCRIRuntime, Code: 7004, Severity: AlarmSeverityError, Component: AlarmComponentContainer, Hint: "check the CRI socket is mounted into the agent"},
+		{Type: AlarmCreateContainerInfo, Code: 7005, Severity: AlarmSeverityWarning, Component: AlarmComponentContainer, Hint: "the container info is incomplete, check the CRI runtime"},
+		{Type: AlarmSelfMetricsExport, Code: 8001, Severity: AlarmSeverityWarning, Component: AlarmComponentTelemetry, Hint: "check the otlp endpoint of the self metrics"},
+		{Type: AlarmPProfProfile, Code: 8002, Severity: AlarmSeverityWarning, Component: AlarmComponentTelemetry, Hint: "the profile data is incomplete and partially ignored"},
+	} {
+		RegisterAlarmDefinition(def)
+	}
+}
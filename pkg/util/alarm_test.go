@@ -0,0 +1,117 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+func TestAlarmDefinition(t *testing.T) {
+	def := GetAlarmDefinition(AlarmFlusherFlush)
+	assert.Equal(t, 6002, def.Code)
+	assert.Equal(t, AlarmSeverityError, def.Severity)
+	assert.Equal(t, AlarmComponentFlusher, def.Component)
+	assert.NotEmpty(t, def.Hint)
+
+	def = GetAlarmDefinition("MY_PLUGIN_ALARM")
+	assert.Equal(t, AlarmCodeUnknown, def.Code)
+	assert.Equal(t, AlarmSeverityWarning, def.Severity)
+	assert.Equal(t, AlarmComponentUnknown, def.Component)
+
+	RegisterAlarmDefinition(AlarmDefinition{Type: "MY_PLUGIN_ALARM", Code: 9001, Severity: AlarmSeverityCritical, Component: AlarmComponentInput})
+	defer func() {
+		alarmDefinitionsMu.Lock()
+		delete(alarmDefinitions, "MY_PLUGIN_ALARM")
+		alarmDefinitionsMu.Unlock()
+	}()
+	def = GetAlarmDefinition("MY_PLUGIN_ALARM")
+	assert.Equal(t, 9001, def.Code)
+	assert.Equal(t, defaultAlarmDedupInterval, def.DedupInterval)
+
+	bytes, err := json.Marshal(def)
+	require.NoError(t, err)
+	assert.Contains(t, string(bytes), `"severity":"critical"`)
+	severity, err := ParseAlarmSeverity("Error")
+	require.NoError(t, err)
+	assert.Equal(t, AlarmSeverityError, severity)
+	_, err = ParseAlarmSeverity("fatal")
+	assert.Error(t, err)
+}
+
+func TestAlarmDefinitionCodes(t *testing.T) {
+	ranges := map[string]int{
+		AlarmComponentCore:       1,
+		AlarmComponentCheckpoint: 2,
+		AlarmComponentPipeline:   3,
+		AlarmComponentInput:      4,
+		AlarmComponentProcessor:  5,
+		AlarmComponentFlusher:    6,
+		AlarmComponentContainer:  7,
+		AlarmComponentTelemetry:  8,
+	}
+	codes := make(map[int]string)
+	for _, def := range GetAlarmDefinitions() {
+		if other, ok := codes[def.Code]; ok {
+			t.Errorf("alarm types %s and %s have the same code %d", other, def.Type, def.Code)
+		}
+		codes[def.Code] = def.Type
+		assert.Equal(t, ranges[def.Component], def.Code/1000, def.Type)
+	}
+}
+
+func TestAlarmRecent(t *testing.T) {
+	alarm := new(Alarm)
+	alarm.Init("p", "l")
+	alarm.Record(AlarmFlushData, "timeout")
+	alarm.Record(AlarmFlushData, "timeout")
+	alarm.Record(AlarmFlushData, "refused")
+	alarm.Record("MY_OTHER_ALARM", "x")
+
+	records := alarm.Recent(time.Time{})
+	require.Len(t, records, 3)
+	assert.Equal(t, int64(2), records[0].Count, "the same alarms should be merged")
+	assert.Equal(t, "timeout", records[0].Message)
+	assert.Equal(t, 3001, records[0].Code)
+	assert.Equal(t, "p", records[0].Project)
+	assert.Equal(t, AlarmComponentUnknown, records[2].Component)
+	assert.Empty(t, alarm.Recent(time.Now()))
+
+	// the merged record is out of the dedup window
+	alarm.recent[0].LastTime = time.Now().Add(-2 * defaultAlarmDedupInterval)
+	alarm.Record(AlarmFlushData, "timeout")
+	assert.Len(t, alarm.Recent(time.Time{}), 4)
+
+	for i := 0; i < maxRecentAlarms; i++ {
+		alarm.Record(AlarmFlushData, string(rune('a'+i%26))+string(rune('a'+i/26)))
+	}
+	assert.Len(t, alarm.Recent(time.Time{}), maxRecentAlarms)
+
+	logGroup := &protocol.LogGroup{}
+	alarm.SerializeToPb(logGroup)
+	contents := make(map[string]string)
+	for _, cont := range logGroup.Logs[0].Contents {
+		contents[cont.Key] = cont.Value
+	}
+	assert.NotEmpty(t, contents["alarm_code"])
+	assert.NotEmpty(t, contents["alarm_severity"])
+	assert.Len(t, alarm.Recent(time.Time{}), maxRecentAlarms, "serializing should not clear the recent records")
+}
@@ -0,0 +1,435 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+// The alarm types of the plugins and the helpers, ordered by the components.
+const (
+	// core
+	AlarmCredential      = "CREDENTIAL_ALARM"
+	AlarmDumpFile        = "DUMP_FILE_ALARM"
+	AlarmExternalPlugin  = "EXTERNAL_PLUGIN_ALARM"
+	AlarmInit            = "INIT_ALARM"
+	AlarmListHistoryDump = "LIST_HISTORY_DUMP_ALARM"
+	AlarmRemoteConfig    = "REMOTE_CONFIG_ALARM"
+	AlarmSPIFFE          = "SPIFFE_ALARM"
+	AlarmTLSReload       = "TLS_RELOAD_ALARM"
+
+	// checkpoint
+	AlarmDockerEnvConfigCheckpoint    = "DOCKER_ENV_CONFIG_CHECKPOINT_ALARM"
+	AlarmInitCheckpoint               = "INIT_CHECKPOINT_ALARM"
+	AlarmMSSQLCheckpoint              = "MSSQL_CHECKPOINT_ALARM"
+	AlarmMySQLCheckpoint              = "MYSQL_CHECKPOINT_ALARM"
+	AlarmPgxCheckpoint                = "PGX_CHECKPOINT_ALARM"
+	AlarmSaveCheckpoint               = "SAVE_CHECKPOINT_ALARM"
+	AlarmSkyWalkingLoadCheckpointFail = "SKYWALKING_LOAD_CHECKPOINT_FAIL"
+	AlarmSkyWalkingSaveCheckpointFail = "SKYWALKING_SAVE_CHECKPOINT_FAIL"
+
+	// pipeline
+	AlarmAggGroup                     = "AGG_GROUP_ALARM"
+	AlarmAggregateOversize            = "AGGREGATE_OVERSIZE_ALARM"
+	AlarmAggShardHashNotFoundKey      = "AGG_SHARDHASH_NOT_FOUND_KEY"
+	AlarmCorrelation                  = "CORRELATION_ALARM"
+	AlarmNoMatchRouter                = "NO_MATCH_ROUTER_ALARM"
+	AlarmRuntime                      = "RUNTIME_ALARM"
+	AlarmSkyWalkingTopicNotRecognized = "SKYWALKING_TOPIC_NOT_RECOGNIZED"
+	AlarmTopK                         = "TOPK_ALARM"
+
+	// input
+	AlarmCanalInvalid                   = "CANAL_INVALID_ALARM"
+	AlarmCanalRuntime                   = "CANAL_RUNTIME_ALARM"
+	AlarmCanalStart                     = "CANAL_START_ALARM"
+	AlarmCompileRegexp                  = "COMPILE_REGEXP_ALARM"
+	AlarmDecodeBodyFail                 = "DECODE_BODY_FAIL_ALARM"
+	AlarmDockerClient                   = "DOCKER_CLIENT_ALARM"
+	AlarmDockerFileMapping              = "DOCKER_FILE_MAPPING_ALARM"
+	AlarmDockerFileMatch                = "DOCKER_FILE_MATCH_ALARM"
+	AlarmDockerRegexCompile             = "DOCKER_REGEX_COMPILE_ALARM"
+	AlarmDockerStdoutStart              = "DOCKER_STDOUT_START_ALARM"
+	AlarmDockerStdoutStat               = "DOCKER_STDOUT_STAT_ALARM"
+	AlarmDockerStdoutStop               = "DOCKER_STDOUT_STOP_ALARM"
+	AlarmErrorJMXPort                   = "ERROR_JMX_PORT"
+	AlarmExportReqFail                  = "EXPORT_REQ_FAIL_ALARM"
+	AlarmFailedCollectHostMetadata      = "FAILED_COLLECT_HOST_METADATA"
+	AlarmFailToInitPing                 = "FAIL_TO_INIT_PING"
+	AlarmFailToRunHTTPing               = "FAIL_TO_RUN_HTTPING"
+	AlarmFailToRunPing                  = "FAIL_TO_RUN_PING"
+	AlarmFileNrPattern                  = "FILENR_PATTERN_ALARM"
+	AlarmGetSigmaEnvError               = "GET_SIGMA_ENV_ERROR"
+	AlarmGPUDCGMCollect                 = "GPU_DCGM_COLLECT_ALARM"
+	AlarmGPUNVMLCollect                 = "GPU_NVML_COLLECT_ALARM"
+	AlarmGPUNVMLDeviceCount             = "GPU_NVML_DEVICE_COUNT_ALARM"
+	AlarmGPUNVMLDeviceIndex             = "GPU_NVML_DEVICE_INDEX_ALARM"
+	AlarmGPUNVMLInit                    = "GPU_NVML_INIT_ALARM"
+	AlarmGPUProcessCollect              = "GPU_PROCESS_COLLECT_ALARM"
+	AlarmGraphiteParse                  = "GRAPHITE_PARSE_ALARM"
+	AlarmHTTPAuth                       = "HTTP_AUTH_ALARM"
+	AlarmHTTPCollect                    = "HTTP_COLLECT_ALARM"
+	AlarmHTTPInit                       = "HTTP_INIT_ALARM"
+	AlarmHTTPLoadAddress                = "HTTP_LOAD_ADDRESS_ALARM"
+	AlarmHTTPParse                      = "HTTP_PARSE_ALARM"
+	AlarmInputCanal                     = "INPUT_CANAL_ALARM"
+	AlarmInputKafka                     = "INPUT_KAFKA_ALARM"
+	AlarmInputSNMPConnectionError       = "INPUT_SNMP_CONNECTION_ERROR"
+	AlarmInputSNMPTimeout               = "INPUT_SNMP_TIMEOUT_ALARM"
+	AlarmInventoryCollect               = "INVENTORY_COLLECT_ALARM"
+	AlarmJFRJVMEvents                   = "JFR_JVM_EVENTS_ALARM"
+	AlarmJMXFetch                       = "JMXFETCH_ALARM"
+	AlarmJMXFetchRuntime                = "JMXFETCH_RUNTIME_ALARM"
+	AlarmJolokiaCollect                 = "JOLOKIA_COLLECT_ALARM"
+	AlarmJolokiaRead                    = "JOLOKIA_READ_ALARM"
+	AlarmJournalRead                    = "JOURNAL_READ_ALARM"
+	AlarmJournalSeek                    = "JOURNAL_SEEK_ALARM"
+	AlarmKubernetesLabelSelectorError   = "KUBERNETES_LABEL_SELECTOR_ERROR"
+	AlarmKubernetesMetaCollectError     = "KUBERNETES_META_COLLECT_ERROR"
+	AlarmKubernetesMetaFetchInterval    = "KUBERNETES_META_FETCH_INTERVAL_ALARM"
+	AlarmLumberConnection               = "LUMBER_CONNECTION_ALARM"
+	AlarmLumberListen                   = "LUMBER_LISTEN_ALARM"
+	AlarmMarshalRESPFail                = "MARSHAL_RESP_FAIL_ALARM"
+	AlarmMQTTConnect                    = "MQTT_CONNECT_ALARM"
+	AlarmMQTTConnectionLost             = "MQTT_CONNECTION_LOST_ALARM"
+	AlarmMQTTSubscribe                  = "MQTT_SUBSCRIBE_ALARM"
+	AlarmMSSQLInit                      = "MSSQL_INIT_ALARM"
+	AlarmMSSQLParse                     = "MSSQL_PARSE_ALARM"
+	AlarmMSSQLQuery                     = "MSSQL_QUERY_ALARM"
+	AlarmMSSQLTimeout                   = "MSSQL_TIMEOUT_ALARM"
+	AlarmMultiHTTPServer                = "MULTI_HTTP_SERVER_ALARM"
+	AlarmMySQLInit                      = "MYSQL_INIT_ALARM"
+	AlarmMySQLParse                     = "MYSQL_PARSE_ALARM"
+	AlarmMySQLQuery                     = "MYSQL_QUERY_ALARM"
+	AlarmMySQLTimeout                   = "MYSQL_TIMEOUT_ALARM"
+	AlarmNginxStatusCollect             = "NGINX_STATUS_COLLECT_ALARM"
+	AlarmNginxStatusInit                = "NGINX_STATUS_INIT_ALARM"
+	AlarmOpen1Mounts                    = "OPEN_1MOUNTS_ALARM"
+	AlarmOpenFileNr                     = "OPEN_FILENR_ALARM"
+	AlarmOpenProcFS                     = "OPEN_PROCFS_ALARM"
+	AlarmParseDockerLine                = "PARSE_DOCKER_LINE_ALARM"
+	AlarmPath                           = "PATH_ALARM"
+	AlarmPgxInit                        = "PGX_INIT_ALARM"
+	AlarmPgxParse                       = "PGX_PARSE_ALARM"
+	AlarmPgxQuery                       = "PGX_QUERY_ALARM"
+	AlarmPgxTimeout                     = "PGX_TIMEOUT_ALARM"
+	AlarmProcessLabelTooLong            = "PROCESS_LABEL_TOO_LONG_ALARM"
+	AlarmProcessList                    = "PROCESS_LIST_ALARM"
+	AlarmProfileParseTimeout            = "PROFILE_PARSE_TIMEOUT_ALARM"
+	AlarmProfileTrigger                 = "PROFILE_TRIGGER_ALARM"
+	AlarmPyroscope                      = "PYROSCOPE_ALARM"
+	AlarmRead1Mounts                    = "READ_1MOUNTS_ALARM"
+	AlarmReadBodyFail                   = "READ_BODY_FAIL_ALARM"
+	AlarmReadFile                       = "READ_FILE_ALARM"
+	AlarmReadFileNr                     = "READ_FILENR_ALARM"
+	AlarmReadProc                       = "READ_PROC_ALARM"
+	AlarmReadProcTCP                    = "READ_PTOCTCP_ALARM"
+	AlarmReadProcTCP6                   = "READ_PTOCTCP6_ALARM"
+	AlarmRedisCollect                   = "REDIS_COLLECT_ALARM"
+	AlarmRedisParseAddress              = "REDIS_PARSE_ADDRESS_ALARM"
+	AlarmRegexCompile                   = "REGEX_COMPILE_ALARM"
+	AlarmServiceGraphiteDecode          = "SERVICE_GRAPHITE_DECODE_ALARM"
+	AlarmServiceGraphiteInit            = "SERVICE_GRAPHITE_INIT_ALARM"
+	AlarmServiceGraphitePacket          = "SERVICE_GRAPHITE_PACKET_ALARM"
+	AlarmServiceGraphiteStream          = "SERVICE_GRAPHITE_STREAM_ALARM"
+	AlarmServiceOTLPInit                = "SERVICE_OTLP_INIT_ALARM"
+	AlarmServiceSyslogClose             = "SERVICE_SYSLOG_CLOSE_ALARM"
+	AlarmServiceSyslogInit              = "SERVICE_SYSLOG_INIT_ALARM"
+	AlarmServiceSyslogPacket            = "SERVICE_SYSLOG_PACKET_ALARM"
+	AlarmServiceSyslogParse             = "SERVICE_SYSLOG_PARSE_ALARM"
+	AlarmServiceSyslogStream            = "SERVICE_SYSLOG_STREAM_ALARM"
+	AlarmServiceTelegrafOverwriteConfig = "SERVICE_TELEGRAF_OVERWRITE_CONFIG_ALARM"
+	AlarmServiceTelegrafRemoveConfig    = "SERVICE_TELEGRAF_REMOVE_CONFIG_ALARM"
+	AlarmServiceTelegrafRuntime         = "SERVICE_TELEGRAF_RUNTIME_ALARM"
+	AlarmSIEMParse                      = "SIEM_PARSE_ALARM"
+	AlarmSkyWalkingCollectTraceError    = "SKYWALKING_COLLECT_TRACE_ERROR"
+	AlarmSkyWalkingMeterGRPCError       = "SKYWALKING_METER_GRPC_ERROR"
+	AlarmSkyWalkingResourceNotReady     = "SKYWALKING_RESOURCE_NOT_READY"
+	AlarmSkyWalkingToOTTraceErr         = "SKYWALKING_TO_OT_TRACE_ERR"
+	AlarmSmartCollect                   = "SMART_COLLECT_ALARM"
+	AlarmStatsdParse                    = "STATSD_PARSE_ALARM"
+	AlarmTelegraf                       = "TELEGRAF_ALARM"
+	AlarmUDPServer                      = "UDP_SERVER_ALARM"
+	AlarmWinEventLogAPI                 = "WINEVENTLOG_API_ALARM"
+	AlarmWinEventLogMain                = "WINEVENTLOG_MAIN_ALARM"
+	AlarmWinEventLogUtil                = "WINEVENTLOG_UTIL_ALARM"
+
+	// processor
+	AlarmAlertProcessor              = "ALERT_PROCESSOR_ALARM"
+	AlarmAnchorFind                  = "ANCHOR_FIND_ALARM"
+	AlarmAnchorJSON                  = "ANCHOR_JSON_ALARM"
+	AlarmBase64D                     = "BASE64_D_ALARM"
+	AlarmBase64DFind                 = "BASE64_D_FIND_ALARM"
+	AlarmBase64EFind                 = "BASE64_E_FIND_ALARM"
+	AlarmConditionInit               = "CONDITION_INIT_ALARM"
+	AlarmDecodeFind                  = "DECODE_FIND_ALARM"
+	AlarmDecodeLog                   = "DECODE_LOG_ALARM"
+	AlarmEnrich                      = "ENRICH_ALARM"
+	AlarmFilterInit                  = "FILTER_INIT_ALARM"
+	AlarmGeoIP                       = "GEOIP_ALARM"
+	AlarmGoTimeFind                  = "GOTIME_FIND_ALARM"
+	AlarmGoTimeParse                 = "GOTIME_PARSE_ALARM"
+	AlarmGrokFind                    = "GROK_FIND_ALARM"
+	AlarmK8SMeta                     = "K8S_META_ALARM"
+	AlarmKvSplitter                  = "KV_SPLITTER_ALARM"
+	AlarmLogRegexFind                = "LOG_REGEX_FIND_ALARM"
+	AlarmLogToMetric                 = "LOG_TO_METRIC_ALARM"
+	AlarmLogToSpan                   = "LOG_TO_SPAN_ALARM"
+	AlarmMD5Find                     = "MD5_FIND_ALARM"
+	AlarmPackJSON                    = "PACK_JSON_ALARM"
+	AlarmProcessorAccessLog          = "PROCESSOR_ACCESS_LOG_ALARM"
+	AlarmProcessorAccessLogFind      = "PROCESSOR_ACCESS_LOG_FIND_ALARM"
+	AlarmProcessorBinaryDecode       = "PROCESSOR_BINARY_DECODE_ALARM"
+	AlarmProcessorBinaryDecodeFind   = "PROCESSOR_BINARY_DECODE_FIND_ALARM"
+	AlarmProcessorEncrypt            = "PROCESSOR_ENCRYPT_ALARM"
+	AlarmProcessorJSONFind           = "PROCESSOR_JSON_FIND_ALARM"
+	AlarmProcessorJSONParser         = "PROCESSOR_JSON_PARSER_ALARM"
+	AlarmProcessorSLSEncode          = "PROCESSOR_SLS_ENCODE_ALARM"
+	AlarmProcessorSplitLogStringFind = "PROCESSOR_SPLIT_LOG_STRING_FIND_ALARM"
+	AlarmRegexFind                   = "REGEX_FIND_ALARM"
+	AlarmRenameFind                  = "RENAME_FIND_ALARM"
+	AlarmSchema                      = "SCHEMA_ALARM"
+	AlarmSplitFind                   = "SPLIT_FIND_ALARM"
+	AlarmSplitLog                    = "SPLIT_LOG_ALARM"
+	AlarmStarlarkProcess             = "STARLARK_PROCESS_ALARM"
+	AlarmStrptimeParse               = "STRPTIME_PARSE_ALARM"
+	AlarmWasmProcess                 = "WASM_PROCESS_ALARM"
+
+	// flusher
+	AlarmCircuitBreaker          = "CIRCUIT_BREAKER_ALARM"
+	AlarmConnectorFlush          = "CONNECTOR_FLUSH_ALARM"
+	AlarmConvert                 = "CONVERT_ALARM"
+	AlarmConverterSchema         = "CONVERTER_SCHEMA_ALARM"
+	AlarmFlusherReady            = "FLUSHER_READY_ALARM"
+	AlarmGRPCFlush               = "GRPC_FLUSH_ALARM"
+	AlarmGRPCFlusher             = "GRPC_FLUSHER_ALARM"
+	AlarmInvalidKafkaPartitioner = "INVALID_KAFKA_PARTITIONER"
+	AlarmSinkGroup               = "SINK_GROUP_ALARM"
+
+	// container
+	AlarmCreateIndex              = "CREATE_INDEX_ALARM"
+	AlarmCreateMachineGroup       = "CREATE_MACHINEGROUP_ALARM"
+	AlarmCreateProduct            = "CREATE_PRODUCT_ALARM"
+	AlarmCreateProject            = "CREATE_PROJECT_ALARM"
+	AlarmDetectContainer          = "DETECT_CONTAINER_ALARM"
+	AlarmDockerEnvConfigInit      = "DOCKER_ENV_CONFIG_INIT_ALARM"
+	AlarmDockerEnvConfigOperation = "DOCKER_ENV_CONFIG_OPERATION_ALARM"
+	AlarmDockerMatch              = "DOCKER_MATCH_ALARM"
+	AlarmInvalidDockerEnvConfig   = "INVALID_DOCKER_ENV_CONFIG_ALARM"
+	AlarmInvalidEnvConfigDetail   = "INVALID_ENV_CONFIG_DETAIL"
+	AlarmLogtailConfig            = "LOGTAIL_CONFIG_ALARM"
+	AlarmReadStaticConfig         = "READ_STATIC_CONFIG_ALARM"
+	AlarmSyncContainerd           = "SYNC_CONTAINERD_ALARM"
+)
+
+func init() {
+	for _, def := range []AlarmDefinition{
+		{Type: AlarmCredential, Code: 1016, Severity: AlarmSeverityWarning, Component: AlarmComponentCore},
+		{Type: AlarmDumpFile, Code: 1017, Severity: AlarmSeverityError, Component: AlarmComponentCore},
+		{Type: AlarmExternalPlugin, Code: 1018, Severity: AlarmSeverityError, Component: AlarmComponentCore},
+		{Type: AlarmInit, Code: 1019, Severity: AlarmSeverityError, Component: AlarmComponentCore},
+		{Type: AlarmListHistoryDump, Code: 1020, Severity: AlarmSeverityWarning, Component: AlarmComponentCore},
+		{Type: AlarmRemoteConfig, Code: 1021, Severity: AlarmSeverityWarning, Component: AlarmComponentCore},
+		{Type: AlarmSPIFFE, Code: 1022, Severity: AlarmSeverityWarning, Component: AlarmComponentCore},
+		{Type: AlarmTLSReload, Code: 1023, Severity: AlarmSeverityWarning, Component: AlarmComponentCore},
+		{Type: AlarmDockerEnvConfigCheckpoint, Code: 2006, Severity: AlarmSeverityError, Component: AlarmComponentCheckpoint},
+		{Type: AlarmInitCheckpoint, Code: 2007, Severity: AlarmSeverityError, Component: AlarmComponentCheckpoint},
+		{Type: AlarmMySQLCheckpoint, Code: 2008, Severity: AlarmSeverityError, Component: AlarmComponentCheckpoint},
+		{Type: AlarmSaveCheckpoint, Code: 2009, Severity: AlarmSeverityError, Component: AlarmComponentCheckpoint},
+		{Type: AlarmSkyWalkingLoadCheckpointFail, Code: 2010, Severity: AlarmSeverityError, Component: AlarmComponentCheckpoint},
+		{Type: AlarmSkyWalkingSaveCheckpointFail, Code: 2011, Severity: AlarmSeverityError, Component: AlarmComponentCheckpoint},
+		{Type: AlarmMSSQLCheckpoint, Code: 2012, Severity: AlarmSeverityError, Component: AlarmComponentCheckpoint},
+		{Type: AlarmPgxCheckpoint, Code: 2013, Severity: AlarmSeverityError, Component: AlarmComponentCheckpoint},
+		{Type: AlarmAggGroup, Code: 3008, Severity: AlarmSeverityError, Component: AlarmComponentPipeline},
+		{Type: AlarmAggShardHashNotFoundKey, Code: 3009, Severity: AlarmSeverityWarning, Component: AlarmComponentPipeline},
+		{Type: AlarmAggregateOversize, Code: 3010, Severity: AlarmSeverityError, Component: AlarmComponentPipeline},
+		{Type: AlarmCorrelation, Code: 3011, Severity: AlarmSeverityWarning, Component: AlarmComponentPipeline},
+		{Type: AlarmNoMatchRouter, Code: 3012, Severity: AlarmSeverityWarning, Component: AlarmComponentPipeline},
+		{Type: AlarmRuntime, Code: 3013, Severity: AlarmSeverityError, Component: AlarmComponentPipeline},
+		{Type: AlarmSkyWalkingTopicNotRecognized, Code: 3014, Severity: AlarmSeverityWarning, Component: AlarmComponentPipeline},
+		{Type: AlarmTopK, Code: 3015, Severity: AlarmSeverityWarning, Component: AlarmComponentPipeline},
+		{Type: AlarmCanalInvalid, Code: 4007, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmCanalRuntime, Code: 4008, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmCanalStart, Code: 4009, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmCompileRegexp, Code: 4010, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmDecodeBodyFail, Code: 4011, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmDockerClient, Code: 4012, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmDockerFileMapping, Code: 4013, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmDockerFileMatch, Code: 4014, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmDockerRegexCompile, Code: 4015, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmDockerStdoutStart, Code: 4016, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmDockerStdoutStat, Code: 4017, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmDockerStdoutStop, Code: 4018, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmErrorJMXPort, Code: 4019, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmExportReqFail, Code: 4020, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmFailToInitPing, Code: 4021, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmFailToRunHTTPing, Code: 4022, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmFailToRunPing, Code: 4023, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmFailedCollectHostMetadata, Code: 4024, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmFileNrPattern, Code: 4025, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmGPUDCGMCollect, Code: 4026, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmGPUNVMLCollect, Code: 4027, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmGPUNVMLDeviceCount, Code: 4028, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmGPUNVMLDeviceIndex, Code: 4029, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmGPUNVMLInit, Code: 4030, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmGPUProcessCollect, Code: 4031, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmGetSigmaEnvError, Code: 4032, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmGraphiteParse, Code: 4033, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmHTTPAuth, Code: 4034, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmHTTPCollect, Code: 4035, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmHTTPInit, Code: 4036, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmHTTPLoadAddress, Code: 4037, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmHTTPParse, Code: 4038, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmInputCanal, Code: 4039, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmInputKafka, Code: 4040, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmInputSNMPConnectionError, Code: 4041, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmInputSNMPTimeout, Code: 4042, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmInventoryCollect, Code: 4043, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmJFRJVMEvents, Code: 4044, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmJMXFetch, Code: 4045, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmJMXFetchRuntime, Code: 4046, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmJolokiaCollect, Code: 4047, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmJolokiaRead, Code: 4048, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmJournalRead, Code: 4049, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmJournalSeek, Code: 4050, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmKubernetesLabelSelectorError, Code: 4051, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmKubernetesMetaCollectError, Code: 4052, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmKubernetesMetaFetchInterval, Code: 4053, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmLumberConnection, Code: 4054, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmLumberListen, Code: 4055, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmMQTTConnect, Code: 4056, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmMQTTConnectionLost, Code: 4057, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmMQTTSubscribe, Code: 4058, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmMarshalRESPFail, Code: 4059, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmMultiHTTPServer, Code: 4060, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmMySQLInit, Code: 4061, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmMySQLParse, Code: 4062, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmMySQLQuery, Code: 4063, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmMySQLTimeout, Code: 4064, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmNginxStatusCollect, Code: 4065, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmNginxStatusInit, Code: 4066, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmOpen1Mounts, Code: 4067, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmOpenFileNr, Code: 4068, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmOpenProcFS, Code: 4069, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmParseDockerLine, Code: 4070, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmPath, Code: 4071, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmProcessLabelTooLong, Code: 4072, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmProcessList, Code: 4073, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmRead1Mounts, Code: 4074, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmReadBodyFail, Code: 4075, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmReadFile, Code: 4076, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmReadFileNr, Code: 4077, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmReadProc, Code: 4078, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmReadProcTCP, Code: 4079, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmReadProcTCP6, Code: 4080, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmRedisCollect, Code: 4081, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmRedisParseAddress, Code: 4082, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmRegexCompile, Code: 4083, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmSIEMParse, Code: 4084, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmServiceGraphiteDecode, Code: 4085, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmServiceGraphiteInit, Code: 4086, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmServiceGraphitePacket, Code: 4087, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmServiceGraphiteStream, Code: 4088, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmServiceOTLPInit, Code: 4089, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmServiceSyslogClose, Code: 4090, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmServiceSyslogInit, Code: 4091, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmServiceSyslogPacket, Code: 4092, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmServiceSyslogParse, Code: 4093, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmServiceSyslogStream, Code: 4094, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmServiceTelegrafOverwriteConfig, Code: 4095, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmServiceTelegrafRemoveConfig, Code: 4096, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmServiceTelegrafRuntime, Code: 4097, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmSkyWalkingCollectTraceError, Code: 4098, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmSkyWalkingMeterGRPCError, Code: 4099, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmSkyWalkingResourceNotReady, Code: 4100, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmSkyWalkingToOTTraceErr, Code: 4101, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmSmartCollect, Code: 4102, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmStatsdParse, Code: 4103, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmUDPServer, Code: 4104, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmWinEventLogAPI, Code: 4105, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmWinEventLogMain, Code: 4106, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmWinEventLogUtil, Code: 4107, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmMSSQLInit, Code: 4108, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmMSSQLParse, Code: 4109, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmMSSQLQuery, Code: 4110, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmMSSQLTimeout, Code: 4111, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmPgxInit, Code: 4112, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmPgxParse, Code: 4113, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmPgxQuery, Code: 4114, Severity: AlarmSeverityError, Component: AlarmComponentInput},
+		{Type: AlarmPgxTimeout, Code: 4115, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmProfileParseTimeout, Code: 4116, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmProfileTrigger, Code: 4117, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmPyroscope, Code: 4118, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmTelegraf, Code: 4119, Severity: AlarmSeverityWarning, Component: AlarmComponentInput},
+		{Type: AlarmAlertProcessor, Code: 5004, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmAnchorFind, Code: 5005, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmAnchorJSON, Code: 5006, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmBase64D, Code: 5007, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmBase64DFind, Code: 5008, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmBase64EFind, Code: 5009, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmConditionInit, Code: 5010, Severity: AlarmSeverityError, Component: AlarmComponentProcessor},
+		{Type: AlarmDecodeFind, Code: 5011, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmDecodeLog, Code: 5012, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmEnrich, Code: 5013, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmFilterInit, Code: 5014, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmGeoIP, Code: 5015, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmGoTimeFind, Code: 5016, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmGoTimeParse, Code: 5017, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmGrokFind, Code: 5018, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmK8SMeta, Code: 5019, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmKvSplitter, Code: 5020, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmLogRegexFind, Code: 5021, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmLogToMetric, Code: 5022, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmLogToSpan, Code: 5023, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmMD5Find, Code: 5024, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmPackJSON, Code: 5025, Severity: AlarmSeverityError, Component: AlarmComponentProcessor},
+		{Type: AlarmProcessorAccessLog, Code: 5026, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmProcessorAccessLogFind, Code: 5027, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmProcessorBinaryDecode, Code: 5028, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmProcessorBinaryDecodeFind, Code: 5029, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmProcessorJSONFind, Code: 5030, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmProcessorJSONParser, Code: 5031, Severity: AlarmSeverityError, Component: AlarmComponentProcessor},
+		{Type: AlarmProcessorSLSEncode, Code: 5032, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmProcessorSplitLogStringFind, Code: 5033, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmRegexFind, Code: 5034, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmRenameFind, Code: 5035, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmSchema, Code: 5036, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmSplitFind, Code: 5037, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmSplitLog, Code: 5038, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmStarlarkProcess, Code: 5039, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmStrptimeParse, Code: 5040, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmWasmProcess, Code: 5041, Severity: AlarmSeverityError, Component: AlarmComponentProcessor},
+		{Type: AlarmProcessorEncrypt, Code: 5042, Severity: AlarmSeverityWarning, Component: AlarmComponentProcessor},
+		{Type: AlarmCircuitBreaker, Code: 6005, Severity: AlarmSeverityWarning, Component: AlarmComponentFlusher},
+		{Type: AlarmConnectorFlush, Code: 6006, Severity: AlarmSeverityWarning, Component: AlarmComponentFlusher},
+		{Type: AlarmConvert, Code: 6007, Severity: AlarmSeverityWarning, Component: AlarmComponentFlusher},
+		{Type: AlarmConverterSchema, Code: 6008, Severity: AlarmSeverityWarning, Component: AlarmComponentFlusher},
+		{Type: AlarmFlusherReady, Code: 6009, Severity: AlarmSeverityWarning, Component: AlarmComponentFlusher},
+		{Type: AlarmGRPCFlush, Code: 6010, Severity: AlarmSeverityError, Component: AlarmComponentFlusher},
+		{Type: AlarmGRPCFlusher, Code: 6011, Severity: AlarmSeverityError, Component: AlarmComponentFlusher},
+		{Type: AlarmInvalidKafkaPartitioner, Code: 6012, Severity: AlarmSeverityError, Component: AlarmComponentFlusher},
+		{Type: AlarmSinkGroup, Code: 6013, Severity: AlarmSeverityWarning, Component: AlarmComponentFlusher},
+		{Type: AlarmCreateIndex, Code: 7006, Severity: AlarmSeverityWarning, Component: AlarmComponentContainer},
+		{Type: AlarmCreateMachineGroup, Code: 7007, Severity: AlarmSeverityWarning, Component: AlarmComponentContainer},
+		{Type: AlarmCreateProduct, Code: 7008, Severity: AlarmSeverityWarning, Component: AlarmComponentContainer},
+		{Type: AlarmCreateProject, Code: 7009, Severity: AlarmSeverityWarning, Component: AlarmComponentContainer},
+		{Type: AlarmDetectContainer, Code: 7010, Severity: AlarmSeverityError, Component: AlarmComponentContainer},
+		{Type: AlarmDockerEnvConfigInit, Code: 7011, Severity: AlarmSeverityError, Component: AlarmComponentContainer},
+		{Type: AlarmDockerEnvConfigOperation, Code: 7012, Severity: AlarmSeverityError, Component: AlarmComponentContainer},
+		{Type: AlarmDockerMatch, Code: 7013, Severity: AlarmSeverityWarning, Component: AlarmComponentContainer},
+		{Type: AlarmInvalidDockerEnvConfig, Code: 7014, Severity: AlarmSeverityError, Component: AlarmComponentContainer},
+		{Type: AlarmInvalidEnvConfigDetail, Code: 7015, Severity: AlarmSeverityError, Component: AlarmComponentContainer},
+		{Type: AlarmLogtailConfig, Code: 7016, Severity: AlarmSeverityError, Component: AlarmComponentContainer},
+		{Type: AlarmReadStaticConfig, Code: 7017, Severity: AlarmSeverityWarning, Component: AlarmComponentContainer},
+		{Type: AlarmSyncContainerd, Code: 7018, Severity: AlarmSeverityError, Component: AlarmComponentContainer},
+	} {
+		RegisterAlarmDefinition(def)
+	}
+}
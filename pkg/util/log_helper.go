@@ -22,30 +22,24 @@ import (
 )
 
 func CreateLog(t time.Time, configTag map[string]string, logTags map[string]string, fields map[string]string) (*protocol.Log, error) {
+	return CreateLogWithArena(nil, t, configTag, logTags, fields)
+}
+
+// CreateLogWithArena is like CreateLog but allocates the contents from arena, which
+// may be nil. The returned log must not be used after the arena is released.
+func CreateLogWithArena(arena *protocol.LogArena, t time.Time, configTag map[string]string, logTags map[string]string, fields map[string]string) (*protocol.Log, error) {
 	var slsLog protocol.Log
-	slsLog.Contents = make([]*protocol.Log_Content, 0, len(configTag)+len(logTags)+len(fields))
+	slsLog.Contents = arena.NewContents(len(configTag) + len(logTags) + len(fields))
 	for key, val := range configTag {
-		cont := &protocol.Log_Content{
-			Key:   key,
-			Value: val,
-		}
-		slsLog.Contents = append(slsLog.Contents, cont)
+		slsLog.Contents = append(slsLog.Contents, arena.NewContent(key, val))
 	}
 
 	for key, val := range logTags {
-		cont := &protocol.Log_Content{
-			Key:   key,
-			Value: val,
-		}
-		slsLog.Contents = append(slsLog.Contents, cont)
+		slsLog.Contents = append(slsLog.Contents, arena.NewContent(key, val))
 	}
 
 	for key, val := range fields {
-		cont := &protocol.Log_Content{
-			Key:   key,
-			Value: val,
-		}
-		slsLog.Contents = append(slsLog.Contents, cont)
+		slsLog.Contents = append(slsLog.Contents, arena.NewContent(key, val))
 	}
 
 	slsLog.Time = uint32(t.Unix())
@@ -53,35 +47,29 @@ func CreateLog(t time.Time, configTag map[string]string, logTags map[string]stri
 }
 
 func CreateLogByArray(t time.Time, configTag map[string]string, logTags map[string]string, columns []string, values []string) (*protocol.Log, error) {
+	return CreateLogByArrayWithArena(nil, t, configTag, logTags, columns, values)
+}
+
+// CreateLogByArrayWithArena is like CreateLogByArray but allocates the contents from arena,
+// which may be nil. The returned log must not be used after the arena is released.
+func CreateLogByArrayWithArena(arena *protocol.LogArena, t time.Time, configTag map[string]string, logTags map[string]string, columns []string, values []string) (*protocol.Log, error) {
+	if len(columns) != len(values) {
+		return nil, fmt.Errorf("columns and values not equal")
+	}
+
 	var slsLog protocol.Log
-	slsLog.Contents = make([]*protocol.Log_Content, 0, len(configTag)+len(logTags)+len(columns))
+	slsLog.Contents = arena.NewContents(len(configTag) + len(logTags) + len(columns))
 
 	for key, val := range configTag {
-		cont := &protocol.Log_Content{
-			Key:   key,
-			Value: val,
-		}
-		slsLog.Contents = append(slsLog.Contents, cont)
+		slsLog.Contents = append(slsLog.Contents, arena.NewContent(key, val))
 	}
 
 	for key, val := range logTags {
-		cont := &protocol.Log_Content{
-			Key:   key,
-			Value: val,
-		}
-		slsLog.Contents = append(slsLog.Contents, cont)
-	}
-
-	if len(columns) != len(values) {
-		return nil, fmt.Errorf("columns and values not equal")
+		slsLog.Contents = append(slsLog.Contents, arena.NewContent(key, val))
 	}
 
 	for index := range columns {
-		cont := &protocol.Log_Content{
-			Key:   columns[index],
-			Value: values[index],
-		}
-		slsLog.Contents = append(slsLog.Contents, cont)
+		slsLog.Contents = append(slsLog.Contents, arena.NewContent(columns[index], values[index]))
 	}
 
 	slsLog.Time = uint32(t.Unix())
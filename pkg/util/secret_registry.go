@@ -0,0 +1,99 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// SecretMask replaces the registered secrets in the self logs, the alarms and the config dumps.
+	SecretMask = "******"
+	// the shorter values are not masked, which would damage the logs more than protecting anything
+	minSecretLength = 4
+	// the oldest secrets are evicted when the rotated secrets exceed the limit
+	maxSecrets = 1024
+)
+
+var secretRegistry = &secrets{index: make(map[string]struct{})}
+
+// secrets keeps the registered secrets in the registration order, and the replacer built from them.
+type secrets struct {
+	mu       sync.Mutex
+	values   []string
+	index    map[string]struct{}
+	replacer atomic.Value // *strings.Replacer
+}
+
+// RegisterSecret registers the secret values, such as the ones retrieved from the credential providers,
+// which are masked by RedactSecrets later.
+func RegisterSecret(values ...string) {
+	secretRegistry.register(values...)
+}
+
+// RedactSecrets masks the registered secrets in @s.
+func RedactSecrets(s string) string {
+	r, _ := secretRegistry.replacer.Load().(*strings.Replacer)
+	if r == nil {
+		return s
+	}
+	return r.Replace(s)
+}
+
+// ResetSecrets clears the registered secrets, which is only used in the tests.
+func ResetSecrets() {
+	secretRegistry.mu.Lock()
+	defer secretRegistry.mu.Unlock()
+	secretRegistry.values = nil
+	secretRegistry.index = make(map[string]struct{})
+	secretRegistry.replacer.Store((*strings.Replacer)(nil))
+}
+
+func (s *secrets) register(values ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := false
+	for _, v := range values {
+		if len(v) < minSecretLength {
+			continue
+		}
+		if _, ok := s.index[v]; ok {
+			continue
+		}
+		s.values = append(s.values, v)
+		s.index[v] = struct{}{}
+		changed = true
+	}
+	if !changed {
+		return
+	}
+	if over := len(s.values) - maxSecrets; over > 0 {
+		for _, v := range s.values[:over] {
+			delete(s.index, v)
+		}
+		s.values = append([]string(nil), s.values[over:]...)
+	}
+	// the longer secrets are replaced first, so that a secret containing another one is masked entirely
+	sorted := append([]string(nil), s.values...)
+	sort.SliceStable(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	pairs := make([]string, 0, len(sorted)*2)
+	for _, v := range sorted {
+		pairs = append(pairs, v, SecretMask)
+	}
+	s.replacer.Store(strings.NewReplacer(pairs...))
+}
@@ -48,6 +48,16 @@ const (
 	PackIDTagKey    = "__pack_id__"
 )
 
+// The contents of the alert logs emitted by processor_alert, the logs with AlertNameKey are sent by flusher_alert.
+const (
+	AlertNameKey      = "__alert_name__"
+	AlertSeverityKey  = "__alert_severity__"
+	AlertMessageKey   = "__alert_message__"
+	AlertCountKey     = "__alert_count__"
+	AlertThresholdKey = "__alert_threshold__"
+	AlertWindowKey    = "__alert_window_sec__"
+)
+
 var (
 	ErrCommandTimeout = errors.New("command time out")
 	ErrNotImplemented = errors.New("not implemented yet")
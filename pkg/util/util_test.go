@@ -15,6 +15,7 @@
 package util
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -31,3 +32,16 @@ func Test(t *testing.T) {
 	assert.Equal(t, "xx", GuessRegionByEndpoint("", "xx"))
 	assert.Equal(t, "xx", GuessRegionByEndpoint("http://", "xx"))
 }
+
+func TestRedactSecrets(t *testing.T) {
+	defer ResetSecrets()
+	assert.Equal(t, "password:abcdefg", RedactSecrets("password:abcdefg"))
+	RegisterSecret("abcdefg", "abc", "", "abcdefgh")
+	assert.Equal(t, "password:******\tsecret:******\tkey:abc", RedactSecrets("password:abcdefg\tsecret:abcdefgh\tkey:abc"))
+
+	for i := 0; i < maxSecrets; i++ {
+		RegisterSecret(fmt.Sprintf("rotated-%d", i))
+	}
+	assert.Equal(t, "abcdefg", RedactSecrets("abcdefg"))
+	assert.Equal(t, "******", RedactSecrets(fmt.Sprintf("rotated-%d", maxSecrets-1)))
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"sync"
+	"time"
 
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/util"
@@ -46,6 +47,24 @@ var (
 	Doc              = flag.Bool("doc", false, "generate plugin docs")
 	DocPath          = flag.String("docpath", "./docs/en/plugins", "generate plugin docs")
 	HTTPLoadFlag     = flag.Bool("http-load", false, "export http endpoint for load plugin config.")
+	CRDController    = flag.Bool("crd-controller", false, "watch the PipelineConfig resources of kubernetes and apply them as plugin configs.")
+	CRDNamespace     = flag.String("crd-namespace", "", "the namespace of the PipelineConfig resources to watch, empty means all namespaces.")
+	ClusterNamespace = flag.String("crd-cluster-namespace", "ilogtail", "the PipelineConfig resources in this namespace could collect the containers of all namespaces.")
+	KubeConfigPath   = flag.String("kubeconfig", "", "the kube config to connect the apiserver, empty means the in-cluster config.")
+	RemoteConfig     = flag.String("remote-config", "", "the json file of the remote config source, such as apollo, nacos, consul and etcd, whose configs are watched and applied, empty means disabled.")
+	Validate         = flag.Bool("validate", false, "validate the plugin configs without running them, and exit with non-zero code if any is invalid.")
+	ValidateSamples  = flag.String("validate-samples", "", "the json file of sample logs to pass through the processors in validate mode.")
+	Benchmark        = flag.String("benchmark", "", "replay the events in this file through the plugin configs, print the profiles of the plugins and exit.")
+	BenchmarkRate    = flag.Int("benchmark-rate", 0, "the target events per second in benchmark mode, 0 means as fast as possible.")
+	BenchmarkLoops   = flag.Int("benchmark-loops", 1, "the times to replay the events in benchmark mode.")
+	BenchmarkTime    = flag.Duration("benchmark-duration", 0, "replay the events repeatedly for this duration in benchmark mode, overrides benchmark-loops.")
+	SelfMetricsFlag  = flag.Bool("self-metrics", false, "export http endpoint /metrics for the self telemetry metrics in prometheus format.")
+	SelfMetricsOTLP  = flag.String("self-metrics-otlp-endpoint", "", "the otlp grpc endpoint to push the self telemetry metrics, empty means disabled.")
+	SelfMetricsTime  = flag.Duration("self-metrics-otlp-interval", 30*time.Second, "the interval to push the self telemetry metrics to the otlp endpoint.")
+	PipelineTapFlag  = flag.Bool("tap", false, "export http endpoint /tap to sample the events passing a stage of the pipelines.")
+	ProfileDebug     = flag.Bool("profile-debug", false, "export http endpoint /flamegraph to render the most recent parsed profiles of the apps.")
+	ShutdownDeadline = flag.Int("shutdown-deadline", 30, "the seconds to drain and flush the data in flight when exiting, 0 means stopping the configs one by one without the deadline.")
+	ExternalCommands = flag.String("external-plugin-commands", "", "the comma separated executables the external plugins are allowed to launch as sidecars, empty means none.")
 )
 
 var (
@@ -99,7 +118,7 @@ func GetFlusherConfiguration() (flusherCategory string, flusherOptions map[strin
 			m := make(map[string]interface{})
 			err := json.Unmarshal(cfg, &m)
 			if err != nil {
-				logger.Error(context.Background(), "DEFAULT_FLUSHER_ALARM", "err", err)
+				logger.Error(context.Background(), util.AlarmDefaultFlusher, "err", err)
 				return "", nil, false
 			}
 			c, ok := m["type"].(string)
@@ -133,4 +152,14 @@ func OverrideByEnv() {
 	_ = util.InitFromEnvBool("LOGTAIL_AUTO_PROF", AutoProfile, *AutoProfile)
 	_ = util.InitFromEnvBool("LOGTAIL_FORCE_COLLECT_SELF_TELEMETRY", ForceSelfCollect, *ForceSelfCollect)
 	_ = util.InitFromEnvBool("LOGTAIL_HTTP_LOAD_CONFIG", HTTPLoadFlag, *HTTPLoadFlag)
+	_ = util.InitFromEnvBool("LOGTAIL_SELF_METRICS", SelfMetricsFlag, *SelfMetricsFlag)
+	_ = util.InitFromEnvString("LOGTAIL_SELF_METRICS_OTLP_ENDPOINT", SelfMetricsOTLP, *SelfMetricsOTLP)
+	_ = util.InitFromEnvBool("LOGTAIL_PIPELINE_TAP", PipelineTapFlag, *PipelineTapFlag)
+	_ = util.InitFromEnvBool("LOGTAIL_PROFILE_DEBUG", ProfileDebug, *ProfileDebug)
+	_ = util.InitFromEnvInt("LOGTAIL_SHUTDOWN_DEADLINE", ShutdownDeadline, *ShutdownDeadline)
+	_ = util.InitFromEnvBool("LOGTAIL_CRD_CONTROLLER", CRDController, *CRDController)
+	_ = util.InitFromEnvString("LOGTAIL_CRD_NAMESPACE", CRDNamespace, *CRDNamespace)
+	_ = util.InitFromEnvString("LOGTAIL_CRD_CLUSTER_NAMESPACE", ClusterNamespace, *ClusterNamespace)
+	_ = util.InitFromEnvString("LOGTAIL_REMOTE_CONFIG", RemoteConfig, *RemoteConfig)
+	_ = util.InitFromEnvString("LOGTAIL_EXTERNAL_PLUGIN_COMMANDS", ExternalCommands, *ExternalCommands)
 }
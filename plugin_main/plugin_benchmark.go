@@ -0,0 +1,63 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/alibaba/ilogtail/plugin_main/flags"
+	"github.com/alibaba/ilogtail/pluginmanager"
+)
+
+// benchmarkMain replays the corpus of the benchmark flag through each plugin config, and prints the reports
+// in JSON, so that the configs could be compared. It returns the exit code of the benchmark mode.
+func benchmarkMain() int {
+	globalCfg, pluginCfgs, err := flags.LoadConfig()
+	if err != nil {
+		fmt.Println("load config error:", err)
+		return 1
+	}
+	if pluginmanager.LoadGlobalConfig(globalCfg) != 0 {
+		fmt.Println("invalid global config")
+		return 1
+	}
+	corpus, err := pluginmanager.LoadBenchmarkCorpus(*flags.Benchmark)
+	if err != nil {
+		fmt.Println("read corpus error:", err)
+		return 1
+	}
+	defaultFlusher, _ := flags.GetFlusherConfiguration()
+	opts := pluginmanager.BenchmarkOptions{
+		Rate:           *flags.BenchmarkRate,
+		Loops:          *flags.BenchmarkLoops,
+		Duration:       *flags.BenchmarkTime,
+		DefaultFlusher: defaultFlusher,
+	}
+	reports := make([]*pluginmanager.BenchmarkReport, 0, len(pluginCfgs))
+	for i, cfg := range pluginCfgs {
+		report, err := pluginmanager.Benchmark(cfg, corpus, opts)
+		if err != nil {
+			fmt.Printf("benchmark config %d error: %v\n", i, err)
+			return 1
+		}
+		reports = append(reports, report)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(reports)
+	return 0
+}
@@ -0,0 +1,153 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/alibaba/ilogtail/helper/k8sconfig"
+	"github.com/alibaba/ilogtail/helper/remoteconfig"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
+	"github.com/alibaba/ilogtail/plugin_main/flags"
+	"github.com/alibaba/ilogtail/pluginmanager"
+)
+
+// appliedConfig is a config loaded by pipelineConfigApplier.
+type appliedConfig struct {
+	project     string
+	logstore    string
+	logstoreKey int64
+	jsonStr     string
+}
+
+// pipelineConfigApplier applies the PipelineConfig resources and the remote configs, only the changed configs are
+// reloaded and the others, including the static configs, keep running.
+type pipelineConfigApplier struct {
+	crdConfigs    []*k8sconfig.Config
+	remoteConfigs []*remoteconfig.Config
+	// applied are the configs loaded successfully, keyed by the config names.
+	applied         map[string]appliedConfig
+	nextLogstoreKey int64
+}
+
+func newPipelineConfigApplier() *pipelineConfigApplier {
+	return &pipelineConfigApplier{
+		applied:         make(map[string]appliedConfig),
+		nextLogstoreKey: 124,
+	}
+}
+
+func (a *pipelineConfigApplier) Apply(configs []*k8sconfig.Config) map[string]error {
+	controlLock.Lock()
+	defer controlLock.Unlock()
+	a.crdConfigs = configs
+	return a.reload()
+}
+
+// reload stops the removed and the changed configs, then loads and starts the added and the changed configs,
+// and the changed config failed to load is restored with the previous content. It returns the errors of the
+// PipelineConfig resources and the remote configs.
+func (a *pipelineConfigApplier) reload() map[string]error {
+	expected := make(map[string]appliedConfig)
+	keys := make(map[string]string)
+	for _, cfg := range a.crdConfigs {
+		expected[cfg.Key()] = appliedConfig{project: cfg.Namespace, logstore: cfg.Name, jsonStr: cfg.JSONStr}
+		keys[cfg.Key()] = cfg.Key()
+	}
+	for _, cfg := range a.remoteConfigs {
+		expected[remoteConfigPrefix+cfg.Key()] = appliedConfig{project: cfg.Source, logstore: cfg.Name, jsonStr: cfg.JSONStr}
+		keys[remoteConfigPrefix+cfg.Key()] = cfg.Key()
+	}
+
+	var changed []string
+	previous := make(map[string]appliedConfig)
+	for name, cfg := range a.applied {
+		newCfg, ok := expected[name]
+		if ok && newCfg.project == cfg.project && newCfg.logstore == cfg.logstore && newCfg.jsonStr == cfg.jsonStr {
+			continue
+		}
+		pluginmanager.StopLogstoreConfig(name, !ok)
+		delete(a.applied, name)
+		if ok {
+			newCfg.logstoreKey = cfg.logstoreKey
+			expected[name] = newCfg
+			previous[name] = cfg
+			changed = append(changed, name)
+		}
+	}
+
+	errs := make(map[string]error)
+	for name, cfg := range expected {
+		if _, ok := a.applied[name]; ok {
+			continue
+		}
+		if cfg.logstoreKey == 0 {
+			cfg.logstoreKey = a.nextLogstoreKey
+			a.nextLogstoreKey++
+		}
+		if err := pluginmanager.LoadLogstoreConfig(cfg.project, cfg.logstore, name, cfg.logstoreKey, cfg.jsonStr); err != nil {
+			errs[keys[name]] = err
+			// the config stopped for the change keeps running with the previous content
+			old, ok := previous[name]
+			if !ok {
+				continue
+			}
+			if err = pluginmanager.LoadLogstoreConfig(old.project, old.logstore, name, old.logstoreKey, old.jsonStr); err != nil {
+				logger.Error(context.Background(), util.AlarmPipelineConfig, "restore the previous config error", err, "config", name)
+				continue
+			}
+			cfg = old
+		}
+		pluginmanager.StartLogstoreConfig(name)
+		a.applied[name] = cfg
+	}
+	if len(changed) > 0 || len(errs) > 0 {
+		logger.Info(context.Background(), "pipeline configs reloaded, changed", changed, "errors", len(errs))
+	}
+	return errs
+}
+
+// startCRDController watches the PipelineConfig resources when the crd-controller flag is set.
+func startCRDController(applier *pipelineConfigApplier) *k8sconfig.Controller {
+	if !*flags.CRDController {
+		return nil
+	}
+	cfg, err := clientcmd.BuildConfigFromFlags("", *flags.KubeConfigPath)
+	if err != nil {
+		logger.Error(context.Background(), util.AlarmPipelineConfig, "read kube config error", err)
+		return nil
+	}
+	client, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		logger.Error(context.Background(), util.AlarmPipelineConfig, "create kubernetes client error", err)
+		return nil
+	}
+	nodeName := util.GetHostName()
+	_ = util.InitFromEnvString("_node_name_", &nodeName, nodeName)
+	controller := k8sconfig.NewController(client, applier, k8sconfig.Options{
+		Namespace:        *flags.CRDNamespace,
+		ClusterNamespace: *flags.ClusterNamespace,
+		NodeName:         nodeName,
+		DebounceInterval: time.Second * 3,
+	})
+	controller.Start()
+	logger.Info(context.Background(), "pipeline config controller started, namespace", *flags.CRDNamespace, "node", nodeName)
+	return controller
+}
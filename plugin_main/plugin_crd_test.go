@@ -0,0 +1,65 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux || windows
+// +build linux windows
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/alibaba/ilogtail/helper/k8sconfig"
+	"github.com/alibaba/ilogtail/pluginmanager"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipelineConfigApplierReloadChanged(t *testing.T) {
+	applier := newPipelineConfigApplier()
+	defer applier.Apply(nil)
+	configA := &k8sconfig.Config{Namespace: "ns", Name: "a", JSONStr: fmt.Sprintf(configTemplateJSONStr, 0)}
+	configB := &k8sconfig.Config{Namespace: "ns", Name: "b", JSONStr: fmt.Sprintf(configTemplateJSONStr, 0)}
+	require.Empty(t, applier.Apply([]*k8sconfig.Config{configA, configB}))
+	runningA := pluginmanager.LogtailConfig[configA.Key()]
+	runningB := pluginmanager.LogtailConfig[configB.Key()]
+	require.NotNil(t, runningA)
+	require.NotNil(t, runningB)
+
+	// only the changed config is reloaded
+	changedB := &k8sconfig.Config{Namespace: "ns", Name: "b", JSONStr: fmt.Sprintf(configTemplateJSONStr, 1)}
+	require.Empty(t, applier.Apply([]*k8sconfig.Config{configA, changedB}))
+	require.Same(t, runningA, pluginmanager.LogtailConfig[configA.Key()])
+	require.NotSame(t, runningB, pluginmanager.LogtailConfig[changedB.Key()])
+	require.Equal(t, runningB.LogstoreKey, pluginmanager.LogtailConfig[changedB.Key()].LogstoreKey)
+
+	// the removed config is stopped, and the invalid config is reported
+	invalidC := &k8sconfig.Config{Namespace: "ns", Name: "c", JSONStr: "{"}
+	errs := applier.Apply([]*k8sconfig.Config{configA, invalidC})
+	require.Len(t, errs, 1)
+	require.Error(t, errs[invalidC.Key()])
+	require.Same(t, runningA, pluginmanager.LogtailConfig[configA.Key()])
+	require.NotContains(t, pluginmanager.LogtailConfig, changedB.Key())
+	require.NotContains(t, pluginmanager.LogtailConfig, invalidC.Key())
+	require.NotContains(t, pluginmanager.LastLogtailConfig, changedB.Key())
+
+	// the changed config failed to load keeps running with the previous content
+	invalidA := &k8sconfig.Config{Namespace: "ns", Name: "a", JSONStr: "{"}
+	errs = applier.Apply([]*k8sconfig.Config{invalidA})
+	require.Len(t, errs, 1)
+	require.Error(t, errs[invalidA.Key()])
+	require.Contains(t, pluginmanager.LogtailConfig, configA.Key())
+	require.Equal(t, runningA.LogstoreKey, pluginmanager.LogtailConfig[configA.Key()].LogstoreKey)
+}
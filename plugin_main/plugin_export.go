@@ -18,11 +18,13 @@ import (
 	"context"
 	"encoding/json"
 	"runtime/debug"
+	"strings"
 	"sync"
 	"time"
 	"unsafe"
 
 	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/helper/externalplugin"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/util"
 	"github.com/alibaba/ilogtail/plugin_main/flags"
@@ -79,7 +81,7 @@ func LoadGlobalConfig(jsonStr string) int {
 func LoadConfig(project string, logstore string, configName string, logstoreKey int64, jsonStr string) int {
 	logger.Debug(context.Background(), "load config", configName, logstoreKey, "\n"+jsonStr)
 	if started {
-		logger.Error(context.Background(), "CONFIG_LOAD_ALARM", "cannot load config before hold on the running configs")
+		logger.Error(context.Background(), util.AlarmConfigLoad, "cannot load config before hold on the running configs")
 		return 1
 	}
 	err := pluginmanager.LoadLogstoreConfig(util.StringDeepCopy(project),
@@ -87,7 +89,7 @@ func LoadConfig(project string, logstore string, configName string, logstoreKey
 		// Make deep copy if you want to save it in Go in the future.
 		logstoreKey, jsonStr)
 	if err != nil {
-		logger.Error(context.Background(), "CONFIG_LOAD_ALARM", "load config error, project",
+		logger.Error(context.Background(), util.AlarmConfigLoad, "load config error, project",
 			project, "logstore", logstore, "config", configName, "error", err)
 		return 1
 	}
@@ -137,12 +139,13 @@ func HoldOn(exitFlag int) {
 	if started {
 		err := pluginmanager.HoldOn(exitFlag != 0)
 		if err != nil {
-			logger.Error(context.Background(), "PLUGIN_ALARM", "hold on error", err)
+			logger.Error(context.Background(), util.AlarmPlugin, "hold on error", err)
 		}
 	}
 	started = false
 	logger.Info(context.Background(), "Hold on", "success")
 	if exitFlag != 0 {
+		externalplugin.StopAllSidecars()
 		logger.Info(context.Background(), "logger", "close and recover")
 		logger.Close()
 	}
@@ -154,7 +157,7 @@ func Resume() {
 	if !started {
 		err := pluginmanager.Resume()
 		if err != nil {
-			logger.Error(context.Background(), "PLUGIN_ALARM", "resume error", err)
+			logger.Error(context.Background(), util.AlarmPlugin, "resume error", err)
 		}
 	}
 	started = true
@@ -228,14 +231,18 @@ func initPluginBase(cfgStr string) int {
 	initOnce.Do(func() {
 		logger.Init()
 		flags.OverrideByEnv()
+		if *flags.ExternalCommands != "" {
+			externalplugin.SetAllowedCommands(strings.Split(*flags.ExternalCommands, ","))
+		}
 		InitHTTPServer()
 		setGCPercentForSlowStart()
 		logger.Info(context.Background(), "init plugin base, version", pluginmanager.BaseVersion)
 		LoadGlobalConfig(cfgStr)
 		if err := pluginmanager.Init(); err != nil {
-			logger.Error(context.Background(), "PLUGIN_ALARM", "init plugin error", err)
+			logger.Error(context.Background(), util.AlarmPlugin, "init plugin error", err)
 			rst = 1
 		}
+		startSelfMetricsExporter()
 	})
 	return rst
 }
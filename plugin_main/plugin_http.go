@@ -16,6 +16,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -25,12 +26,16 @@ import (
 	"runtime/debug"
 	"runtime/pprof"
 	"runtime/trace"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/alibaba/ilogtail/helper/profile"
 	"github.com/alibaba/ilogtail/pkg"
 	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
 	"github.com/alibaba/ilogtail/plugin_main/flags"
+	"github.com/alibaba/ilogtail/pluginmanager"
 )
 
 var (
@@ -99,7 +104,7 @@ func HandleLoadConfig(w http.ResponseWriter, r *http.Request) {
 	defer controlLock.Unlock()
 	bytes, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		logger.Error(context.Background(), "LOAD_CONFIG_ALARM", "stage", "read", "err", err)
+		logger.Error(context.Background(), util.AlarmLoadConfig, "stage", "read", "err", err)
 		w.WriteHeader(500)
 		_, _ = w.Write([]byte("read body error"))
 		return
@@ -107,7 +112,7 @@ func HandleLoadConfig(w http.ResponseWriter, r *http.Request) {
 	logger.Infof(context.Background(), "%s", string(bytes))
 	loadConfigs, err := pkg.DeserializeLoadedConfig(bytes)
 	if err != nil {
-		logger.Error(context.Background(), "LOAD_CONFIG_ALARM", "stage", "parse", "err", err)
+		logger.Error(context.Background(), util.AlarmLoadConfig, "stage", "parse", "err", err)
 		w.WriteHeader(500)
 		_, _ = w.Write([]byte("parse body error"))
 		return
@@ -119,6 +124,143 @@ func HandleLoadConfig(w http.ResponseWriter, r *http.Request) {
 	Resume()
 }
 
+// HandleDumpConfigs returns the running configs in JSON with the secrets redacted, only the config of the
+// config parameter is returned if it is set.
+func HandleDumpConfigs(w http.ResponseWriter, r *http.Request) {
+	controlLock.Lock()
+	defer controlLock.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(pluginmanager.DumpConfigs(r.URL.Query().Get("config")))
+}
+
+// validateRequest is the body of /validate.
+type validateRequest struct {
+	Config  json.RawMessage     `json:"config"`
+	Samples []map[string]string `json:"samples"`
+}
+
+// HandleValidateConfig creates the plugins of the config in the body without starting them, and returns
+// the validation result in JSON. The status is 200 if the config is valid, otherwise 400.
+func HandleValidateConfig(w http.ResponseWriter, r *http.Request) {
+	bytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(500)
+		_, _ = w.Write([]byte("read body error"))
+		return
+	}
+	var req validateRequest
+	if err = json.Unmarshal(bytes, &req); err != nil || len(req.Config) == 0 {
+		w.WriteHeader(400)
+		_, _ = w.Write([]byte("parse body error"))
+		return
+	}
+	result := pluginmanager.ValidateConfig(string(req.Config), req.Samples)
+	w.Header().Set("Content-Type", "application/json")
+	if !result.Valid {
+		w.WriteHeader(400)
+	}
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+// HandleSlowPlugins returns the traces of the slowest plugins in JSON, the count is limited by the top
+// parameter (10 by default). The traces are only recorded when plugin tracing is enabled.
+func HandleSlowPlugins(w http.ResponseWriter, r *http.Request) {
+	top := 10
+	if v := r.URL.Query().Get("top"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			w.WriteHeader(400)
+			_, _ = w.Write([]byte("invalid top parameter"))
+			return
+		}
+		top = n
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(pluginmanager.SlowestPlugins(top))
+}
+
+// HandleAudit returns the reconciliation of the event counters of the audited configs in JSON, the configs are
+// audited when GlobalConfig.Audit is set or ALIYUN_LOGTAIL_ENABLE_PIPELINE_AUDIT is true.
+func HandleAudit(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(pluginmanager.AuditReports())
+}
+
+const (
+	defaultTapCount   = 10
+	maxTapCount       = 1000
+	defaultTapTimeout = 10 * time.Second
+	maxTapTimeout     = time.Minute
+)
+
+// HandleTap samples the events passing a stage of a running config and returns them in JSON. The
+// parameters are config, stage (input, flush or processor:N), count (10 by default) and timeout (10s by
+// default), it returns the sampled events when the count is reached or the timeout expires.
+func HandleTap(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	count := defaultTapCount
+	timeout := defaultTapTimeout
+	var err error
+	if v := query.Get("count"); v != "" {
+		if count, err = strconv.Atoi(v); err != nil || count > maxTapCount {
+			w.WriteHeader(400)
+			_, _ = w.Write([]byte("invalid count parameter"))
+			return
+		}
+	}
+	if v := query.Get("timeout"); v != "" {
+		if timeout, err = time.ParseDuration(v); err != nil || timeout <= 0 || timeout > maxTapTimeout {
+			w.WriteHeader(400)
+			_, _ = w.Write([]byte("invalid timeout parameter"))
+			return
+		}
+	}
+	events, err := pluginmanager.TapPipeline(query.Get("config"), query.Get("stage"), count, timeout)
+	if err != nil {
+		w.WriteHeader(400)
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(events)
+}
+
+// HandleFlamegraph renders the most recent parsed profile of the app as the folded stacks or the SVG flamegraph,
+// and lists the apps with the recent profiles without the app parameter.
+func HandleFlamegraph(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	app := query.Get("app")
+	if app == "" {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(profile.RecentProfileApps())
+		return
+	}
+	p, ok := profile.GetRecentProfile(app)
+	if !ok {
+		http.Error(w, "no recent profile of app "+app, http.StatusNotFound)
+		return
+	}
+	valueType := query.Get("type")
+	if valueType == "" {
+		valueType = "cpu"
+	}
+	folded, ok := p.Folded[valueType]
+	if !ok {
+		http.Error(w, "no "+valueType+" stacks in the recent profile of app "+app, http.StatusNotFound)
+		return
+	}
+	switch query.Get("format") {
+	case "", "folded":
+		w.Header().Set("Content-Type", "text/plain")
+		_ = profile.WriteFolded(w, folded)
+	case "svg":
+		w.Header().Set("Content-Type", "image/svg+xml")
+		_ = profile.WriteFlamegraphSVG(w, app+" "+valueType+" "+p.Time.Format(time.RFC3339), folded)
+	default:
+		http.Error(w, "invalid format parameter", http.StatusBadRequest)
+	}
+}
+
 // HandleHoldOn hold on the ilogtail process.
 func HandleHoldOn(w http.ResponseWriter, r *http.Request) {
 	controlLock.Lock()
@@ -179,6 +321,24 @@ func InitHTTPServer() {
 		if *flags.HTTPLoadFlag {
 			handlers["/loadconfig"] = &handler{handlerFunc: HandleLoadConfig, description: "load new logtail plugin configuration"}
 			handlers["/holdon"] = &handler{handlerFunc: HandleHoldOn, description: "hold on logtail plugin process"}
+			handlers["/validate"] = &handler{handlerFunc: HandleValidateConfig, description: "validate plugin configuration without loading it"}
+			handlers["/configs"] = &handler{handlerFunc: HandleDumpConfigs, description: "dump the running plugin configurations with the secrets redacted"}
+		}
+		if *flags.RemoteConfig != "" {
+			handlers["/remoteconfig"] = &handler{handlerFunc: HandleRemoteConfig, description: "show, pin, unpin or roll back the remote configs"}
+		}
+		if *flags.PipelineTapFlag {
+			handlers["/tap"] = &handler{handlerFunc: HandleTap, description: "sample the events passing a stage of a pipeline"}
+		}
+		if *flags.ProfileDebug {
+			profile.EnableRecentProfiles()
+			handlers["/flamegraph"] = &handler{handlerFunc: HandleFlamegraph, description: "render the most recent parsed profile of an app as folded stacks or svg flamegraph"}
+		}
+		if *flags.SelfMetricsFlag {
+			handlers["/metrics"] = &handler{handlerFunc: HandleSelfMetrics, description: "export self telemetry metrics in prometheus format"}
+			handlers["/alarms"] = &handler{handlerFunc: HandleRecentAlarms, description: "list the recent alarms"}
+			handlers["/alarms/definitions"] = &handler{handlerFunc: HandleAlarmDefinitions, description: "list the definitions of the alarm types"}
+			handlers["/audit"] = &handler{handlerFunc: HandleAudit, description: "reconcile the event counters of the audited configs"}
 		}
 		if *flags.HTTPProfFlag {
 			handlers["/mem"] = &handler{handlerFunc: HandleMem, description: "dump mem info"}
@@ -187,6 +347,7 @@ func InitHTTPServer() {
 			handlers["/cpu180"] = &handler{handlerFunc: HandleCPU180, description: "dump cpu info, 180 seconds"}
 			handlers["/forcegc"] = &handler{handlerFunc: HandleForceGC, description: "force gc"}
 			handlers["/trace"] = &handler{handlerFunc: HandleTrace, description: "dump trace info"}
+			handlers["/slowplugins"] = &handler{handlerFunc: HandleSlowPlugins, description: "list the slowest processors and aggregators"}
 			runtime.SetBlockProfileRate(1)
 			if *flags.AutoProfile {
 				go DumpCPUInfo(100)
@@ -210,7 +371,7 @@ func InitHTTPServer() {
 				logger.Info(context.Background(), "#####################################")
 				logger.Info(context.Background(), "start http server for logtail plugin profile or control")
 				logger.Info(context.Background(), "#####################################")
-				logger.Error(context.Background(), "INIT_HTTP_SERVER_ALARM", "err", http.ListenAndServe(*flags.HTTPAddr, mux))
+				logger.Error(context.Background(), util.AlarmInitHTTPServer, "err", http.ListenAndServe(*flags.HTTPAddr, mux))
 			}()
 		}
 	})
@@ -18,6 +18,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"os"
 	"runtime"
 
 	_ "github.com/alibaba/ilogtail/helper/envconfig"
@@ -39,6 +40,16 @@ func main() {
 		generatePluginDoc()
 		return
 	}
+	if *flags.Validate {
+		code := validateMain()
+		logger.Flush()
+		os.Exit(code)
+	}
+	if *flags.Benchmark != "" {
+		code := benchmarkMain()
+		logger.Flush()
+		os.Exit(code)
+	}
 	cpu := runtime.NumCPU()
 	procs := runtime.GOMAXPROCS(0)
 	fmt.Println("cpu num:", cpu, " GOMAXPROCS:", procs)
@@ -60,11 +71,18 @@ func main() {
 		l := fmt.Sprintf("PluginLogstore_%d", i)
 		c := fmt.Sprintf("1.0#PluginProject_%d##Config%d", i, i)
 		if LoadConfig(p, l, c, 123, cfg) != 0 {
-			logger.Warningf(context.Background(), "START_PLUGIN_ALARM", "%s_%s_%s start fail, config is %s", p, l, c, cfg)
+			logger.Warningf(context.Background(), util.AlarmStartPlugin, "%s_%s_%s start fail, config is %s", p, l, c, cfg)
 			return
 		}
 	}
 	Resume()
+	applier := newPipelineConfigApplier()
+	if controller := startCRDController(applier); controller != nil {
+		defer controller.Stop()
+	}
+	if controller := startRemoteConfigController(applier); controller != nil {
+		defer controller.Stop()
+	}
 	// handle the first shutdown signal gracefully
 	<-signals.SetupSignalHandler()
 	logger.Info(context.Background(), "########################## exit process begin ##########################")
@@ -0,0 +1,107 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sync/atomic"
+
+	"github.com/alibaba/ilogtail/helper/remoteconfig"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
+	"github.com/alibaba/ilogtail/plugin_main/flags"
+)
+
+// remoteConfigPrefix is the prefix of the names of the remote configs, to avoid conflicting with the others.
+const remoteConfigPrefix = "remote#"
+
+// remoteController is the *remoteconfig.Controller started by the remote-config flag.
+var remoteController atomic.Value
+
+// remoteConfigApplier applies the remote configs with the static configs and the PipelineConfig resources.
+type remoteConfigApplier struct {
+	*pipelineConfigApplier
+}
+
+func (a remoteConfigApplier) Apply(configs []*remoteconfig.Config) map[string]error {
+	controlLock.Lock()
+	defer controlLock.Unlock()
+	a.remoteConfigs = configs
+	return a.reload()
+}
+
+// startRemoteConfigController watches the remote config source when the remote-config flag is set.
+func startRemoteConfigController(applier *pipelineConfigApplier) *remoteconfig.Controller {
+	if *flags.RemoteConfig == "" {
+		return nil
+	}
+	data, err := os.ReadFile(*flags.RemoteConfig)
+	if err != nil {
+		logger.Error(context.Background(), util.AlarmPipelineConfig, "read remote config source error", err)
+		return nil
+	}
+	sourceConfig := &remoteconfig.SourceConfig{}
+	if err = json.Unmarshal(data, sourceConfig); err != nil {
+		logger.Error(context.Background(), util.AlarmPipelineConfig, "parse remote config source error", err)
+		return nil
+	}
+	source, err := sourceConfig.NewSource()
+	if err != nil {
+		logger.Error(context.Background(), util.AlarmPipelineConfig, "create remote config source error", err)
+		return nil
+	}
+	controller := remoteconfig.NewController(source, remoteConfigApplier{applier})
+	controller.Start()
+	remoteController.Store(controller)
+	logger.Info(context.Background(), "remote config controller started, provider", sourceConfig.Provider, "address", sourceConfig.Address)
+	return controller
+}
+
+// HandleRemoteConfig returns the status of the remote config controller in JSON, and pins, unpins or rolls back
+// the remote configs by the POST requests with the action parameter, the version parameter of rollback is the
+// version to roll back to, which is the previous one if empty.
+func HandleRemoteConfig(w http.ResponseWriter, r *http.Request) {
+	controller, _ := remoteController.Load().(*remoteconfig.Controller)
+	if controller == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("remote config is not enabled"))
+		return
+	}
+	if r.Method == http.MethodPost {
+		var err error
+		switch action := r.URL.Query().Get("action"); action {
+		case "pin":
+			controller.Pin()
+		case "unpin":
+			err = controller.Unpin()
+		case "rollback":
+			err = controller.Rollback(r.URL.Query().Get("version"))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("invalid action parameter"))
+			return
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(err.Error()))
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(controller.Status())
+}
@@ -0,0 +1,86 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
+	"github.com/alibaba/ilogtail/plugin_main/flags"
+	"github.com/alibaba/ilogtail/pluginmanager"
+)
+
+// HandleSelfMetrics exports the self telemetry metrics in the Prometheus text format.
+func HandleSelfMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := pluginmanager.WritePrometheusSelfMetrics(w, pluginmanager.CollectSelfMetrics()); err != nil {
+		logger.Warning(context.Background(), util.AlarmSelfMetricsExport, "write self metrics error", err)
+	}
+}
+
+// HandleRecentAlarms returns the recent alarm records in JSON. The optional parameters are config to filter
+// the records of a config, severity to filter the records not less severe than it, and since (like 10m)
+// to filter the records happened in the duration.
+func HandleRecentAlarms(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	minSeverity := util.AlarmSeverityInfo
+	since := time.Time{}
+	if v := query.Get("severity"); v != "" {
+		var err error
+		if minSeverity, err = util.ParseAlarmSeverity(v); err != nil {
+			w.WriteHeader(400)
+			_, _ = w.Write([]byte(err.Error()))
+			return
+		}
+	}
+	if v := query.Get("since"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			w.WriteHeader(400)
+			_, _ = w.Write([]byte("invalid since parameter"))
+			return
+		}
+		since = time.Now().Add(-d)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(pluginmanager.RecentAlarms(query.Get("config"), minSeverity, since))
+}
+
+// HandleAlarmDefinitions returns the definitions of the alarm types in JSON.
+func HandleAlarmDefinitions(w http.ResponseWriter, r *http.Request) {
+	defs := util.GetAlarmDefinitions()
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Code < defs[j].Code })
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(defs)
+}
+
+// startSelfMetricsExporter starts pushing the self telemetry metrics when the OTLP endpoint is configured.
+func startSelfMetricsExporter() {
+	if *flags.SelfMetricsOTLP == "" {
+		return
+	}
+	exporter, err := pluginmanager.NewSelfMetricsOTLPExporter(*flags.SelfMetricsOTLP, nil, *flags.SelfMetricsTime)
+	if err != nil {
+		logger.Error(context.Background(), util.AlarmSelfMetricsExport, "create self metrics otlp exporter error", err)
+		return
+	}
+	exporter.Start()
+	logger.Info(context.Background(), "start pushing self metrics to otlp endpoint", *flags.SelfMetricsOTLP)
+}
@@ -0,0 +1,71 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/alibaba/ilogtail/plugin_main/flags"
+	"github.com/alibaba/ilogtail/pluginmanager"
+)
+
+// validateMain validates the plugin configs of the flags, and returns the exit code of the validate mode.
+func validateMain() int {
+	globalCfg, pluginCfgs, err := flags.LoadConfig()
+	if err != nil {
+		fmt.Println("load config error:", err)
+		return 1
+	}
+	if !validateConfigs(os.Stdout, globalCfg, pluginCfgs, *flags.ValidateSamples) {
+		return 1
+	}
+	return 0
+}
+
+// validateConfigs validates @pluginCfgs with the sample logs in @samplesPath, and writes the results in JSON to @w.
+// @return false if any config is invalid.
+func validateConfigs(w io.Writer, globalCfg string, pluginCfgs []string, samplesPath string) bool {
+	var samples []map[string]string
+	if samplesPath != "" {
+		bytes, err := ioutil.ReadFile(samplesPath)
+		if err != nil {
+			fmt.Fprintln(w, "read samples error:", err)
+			return false
+		}
+		if err = json.Unmarshal(bytes, &samples); err != nil {
+			fmt.Fprintln(w, "parse samples error:", err)
+			return false
+		}
+	}
+	if pluginmanager.LoadGlobalConfig(globalCfg) != 0 {
+		fmt.Fprintln(w, "invalid global config")
+		return false
+	}
+	valid := true
+	results := make([]*pluginmanager.ValidationResult, 0, len(pluginCfgs))
+	for _, cfg := range pluginCfgs {
+		result := pluginmanager.ValidateConfig(cfg, samples)
+		valid = valid && result.Valid
+		results = append(results, result)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(results)
+	return valid
+}
@@ -18,6 +18,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/protocol"
 	"github.com/alibaba/ilogtail/pkg/util"
@@ -35,6 +36,7 @@ type AggregatorWrapper struct {
 	Config        *LogstoreConfig
 	LogGroupsChan chan *protocol.LogGroup
 	Interval      time.Duration
+	Tracer        *pluginTracer
 }
 
 // Add inserts @loggroup to LogGroupsChan if @loggroup is not empty.
@@ -75,7 +77,14 @@ func (p *AggregatorWrapper) Run(control *pipeline.AsyncControl) {
 	defer panicRecover(p.Aggregator.Description())
 	for {
 		exitFlag := util.RandomSleep(p.Interval, 0.1, control.CancelToken())
+		if stoppable, ok := p.Aggregator.(pipeline.StoppableAggregator); ok && exitFlag {
+			if err := stoppable.Stop(); err != nil {
+				logger.Warning(p.Config.Context.GetRuntimeContext(), util.AlarmPlugin, "stop aggregator error", err)
+			}
+		}
+		span := p.Tracer.begin()
 		logGroups := p.Aggregator.Flush()
+		p.Tracer.end(span, len(logGroups))
 		for _, logGroup := range logGroups {
 			if len(logGroup.Logs) == 0 {
 				continue
@@ -0,0 +1,378 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+const defaultBenchmarkBatchSize = 100
+
+// BenchmarkOptions controls how the corpus is replayed.
+type BenchmarkOptions struct {
+	// Rate is the target events per second, 0 means as fast as possible.
+	Rate int
+	// Loops is the times to replay the corpus, ignored when Duration is set. Default is 1.
+	Loops int
+	// Duration replays the corpus repeatedly until it is elapsed.
+	Duration time.Duration
+	// BatchSize is the count of events passed to processors at a time. Default is 100.
+	BatchSize int
+	// DefaultFlusher is the type of the flusher used by the configs without flushers, which names the profile
+	// of the flusher. It's passed in by the caller, since the flags of the default flusher belong to plugin_main.
+	DefaultFlusher string
+}
+
+// PluginBenchmark is the profile of a plugin. The latencies are measured per batch, and the allocations
+// include those of the other goroutines in the process, so they are only accurate in a quiet process.
+type PluginBenchmark struct {
+	Plugin          string        `json:"plugin"`
+	Category        string        `json:"category"`
+	InputEvents     int64         `json:"input_events"`
+	OutputEvents    int64         `json:"output_events"`
+	Calls           int64         `json:"calls"`
+	Errors          int64         `json:"errors"`
+	EventsPerSecond float64       `json:"events_per_second"`
+	LatencyAvg      time.Duration `json:"latency_avg_ns"`
+	LatencyP50      time.Duration `json:"latency_p50_ns"`
+	LatencyP90      time.Duration `json:"latency_p90_ns"`
+	LatencyP99      time.Duration `json:"latency_p99_ns"`
+	LatencyMax      time.Duration `json:"latency_max_ns"`
+	AllocBytes      uint64        `json:"alloc_bytes"`
+	Allocs          uint64        `json:"allocs"`
+	BytesPerEvent   float64       `json:"bytes_per_event"`
+
+	total     time.Duration
+	latencies []time.Duration
+}
+
+// BenchmarkReport is the result of Benchmark.
+type BenchmarkReport struct {
+	Events          int64              `json:"events"`
+	OutputEvents    int64              `json:"output_events"`
+	Elapsed         time.Duration      `json:"elapsed_ns"`
+	EventsPerSecond float64            `json:"events_per_second"`
+	Plugins         []*PluginBenchmark `json:"plugins"`
+}
+
+// LoadBenchmarkCorpus reads the events recorded in @path, one event per line. A line of JSON object is
+// an event with the fields of the object, and other lines are events with the line as content.
+func LoadBenchmarkCorpus(path string) ([]map[string]string, error) {
+	file, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close() //nolint:errcheck
+	var corpus []map[string]string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var fields map[string]string
+		if line[0] != '{' || json.Unmarshal(line, &fields) != nil {
+			fields = map[string]string{"content": string(line)}
+		}
+		corpus = append(corpus, fields)
+	}
+	return corpus, scanner.Err()
+}
+
+// Benchmark replays @corpus through the processors, aggregators and flushers of @jsonStr in the calling
+// goroutine, and profiles each plugin. The inputs of the config are not started, and the flushers send
+// the data as usual, so replace them with flusher_checker to profile the processors only.
+func Benchmark(jsonStr string, corpus []map[string]string, opts BenchmarkOptions) (*BenchmarkReport, error) {
+	if len(corpus) == 0 {
+		return nil, errors.New("empty corpus")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBenchmarkBatchSize
+	}
+	if opts.Loops <= 0 {
+		opts.Loops = 1
+	}
+	var plugins map[string]interface{}
+	if err := json.Unmarshal([]byte(jsonStr), &plugins); err != nil {
+		return nil, err
+	}
+	lc, err := createDryRunConfig("benchmark", jsonStr, dryRunBenchmark)
+	if err != nil {
+		return nil, err
+	}
+	defer stopDryRunConfig(lc)
+	runner, ok := lc.PluginRunner.(*pluginv1Runner)
+	if !ok {
+		return nil, errors.New("benchmark is only supported by the configs of version v1")
+	}
+	b := newBenchmarker(lc, runner, plugins, opts.DefaultFlusher)
+
+	report := &BenchmarkReport{}
+	start := time.Now()
+	for loop := 0; opts.Duration > 0 || loop < opts.Loops; loop++ {
+		for i := 0; i < len(corpus); i += opts.BatchSize {
+			end := i + opts.BatchSize
+			if end > len(corpus) {
+				end = len(corpus)
+			}
+			nowTime := uint32(time.Now().Unix())
+			logs := make([]*protocol.Log, 0, end-i)
+			for _, fields := range corpus[i:end] {
+				logs = append(logs, newSampleLog(fields, nowTime))
+			}
+			report.Events += int64(len(logs))
+			report.OutputEvents += b.replay(logs)
+
+			if opts.Rate > 0 {
+				expected := time.Duration(float64(report.Events) / float64(opts.Rate) * float64(time.Second))
+				if wait := expected - time.Since(start); wait > 0 {
+					time.Sleep(wait)
+				}
+			}
+			if opts.Duration > 0 && time.Since(start) >= opts.Duration {
+				break
+			}
+		}
+		if opts.Duration > 0 && time.Since(start) >= opts.Duration {
+			break
+		}
+	}
+	report.OutputEvents += b.flush()
+	report.Elapsed = time.Since(start)
+	report.EventsPerSecond = float64(report.Events) / report.Elapsed.Seconds()
+	report.Plugins = b.profiles()
+	return report, nil
+}
+
+type benchmarker struct {
+	lc          *LogstoreConfig
+	runner      *pluginv1Runner
+	processors  []*PluginBenchmark
+	aggregators []*PluginBenchmark
+	flushers    []*PluginBenchmark
+}
+
+func newBenchmarker(lc *LogstoreConfig, runner *pluginv1Runner, plugins map[string]interface{}, defaultFlusher string) *benchmarker {
+	b := &benchmarker{lc: lc, runner: runner}
+	names := registeredPluginTypes(plugins, "processors", func(t string) bool { return pipeline.Processors[t] != nil })
+	for i := range runner.ProcessorPlugins {
+		b.processors = append(b.processors, &PluginBenchmark{Plugin: pluginNameAt(names, i, "processor"), Category: "processor"})
+	}
+	names = registeredPluginTypes(plugins, "aggregators", func(t string) bool { return pipeline.Aggregators[t] != nil })
+	if len(names) == 0 {
+		names = []string{"aggregator_default"}
+	}
+	for i := range runner.AggregatorPlugins {
+		b.aggregators = append(b.aggregators, &PluginBenchmark{Plugin: pluginNameAt(names, i, "aggregator"), Category: "aggregator"})
+	}
+	names = registeredPluginTypes(plugins, "flushers", func(t string) bool { return pipeline.Flushers[t] != nil })
+	if len(names) == 0 && defaultFlusher != "" {
+		names = []string{defaultFlusher}
+	}
+	for i := range runner.FlusherPlugins {
+		b.flushers = append(b.flushers, &PluginBenchmark{Plugin: pluginNameAt(names, i, "flusher"), Category: "flusher"})
+	}
+	return b
+}
+
+// registeredPluginTypes returns the types of the plugins in @section in order, the unregistered ones
+// are skipped like loading the config.
+func registeredPluginTypes(plugins map[string]interface{}, section string, registered func(string) bool) []string {
+	var types []string
+	list, _ := plugins[section].([]interface{})
+	for _, item := range list {
+		plugin, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		typeName, _ := plugin["type"].(string)
+		if pluginType := getPluginType(typeName); registered(pluginType) {
+			types = append(types, pluginType)
+		}
+	}
+	return types
+}
+
+func pluginNameAt(names []string, i int, category string) string {
+	if i < len(names) {
+		return names[i]
+	}
+	return fmt.Sprintf("%s#%d", category, i)
+}
+
+// measure calls @f and records the latency and the allocations of it in @pb.
+func measure(pb *PluginBenchmark, inputEvents int, f func() int) {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	begin := time.Now()
+	output := f()
+	latency := time.Since(begin)
+	runtime.ReadMemStats(&after)
+	pb.Calls++
+	pb.InputEvents += int64(inputEvents)
+	pb.OutputEvents += int64(output)
+	pb.total += latency
+	pb.latencies = append(pb.latencies, latency)
+	pb.AllocBytes += after.TotalAlloc - before.TotalAlloc
+	pb.Allocs += after.Mallocs - before.Mallocs
+}
+
+// replay passes @logs through the plugins like processLog and runFlusherInternal, and returns the count
+// of the events flushed.
+func (b *benchmarker) replay(logs []*protocol.Log) int64 {
+	for i, processor := range b.runner.ProcessorPlugins {
+		if len(logs) == 0 {
+			break
+		}
+		input := logs
+		measure(b.processors[i], len(input), func() int {
+			logs = processor.Processor.ProcessLogs(input)
+			return len(logs)
+		})
+	}
+	var logGroups []*protocol.LogGroup
+	for i, aggregator := range b.runner.AggregatorPlugins {
+		measure(b.aggregators[i], len(logs), func() int {
+			groups := b.aggregate(aggregator, b.aggregators[i], logs)
+			logGroups = append(logGroups, groups...)
+			return countLogs(groups)
+		})
+	}
+	return b.flushLogGroups(logGroups)
+}
+
+// aggregate adds @logs to @aggregator, and returns the log groups it produces.
+func (b *benchmarker) aggregate(aggregator *AggregatorWrapper, pb *PluginBenchmark, logs []*protocol.Log) []*protocol.LogGroup {
+	var groups []*protocol.LogGroup
+	for _, log := range logs {
+		if len(log.Contents) == 0 {
+			continue
+		}
+		if err := aggregator.Aggregator.Add(log, nil); err != nil {
+			// the queue is full, drain it and retry.
+			groups = append(groups, b.drain()...)
+			if err = aggregator.Aggregator.Add(log, nil); err != nil {
+				pb.Errors++
+			}
+		}
+	}
+	groups = append(groups, aggregator.Aggregator.Flush()...)
+	return append(groups, b.drain()...)
+}
+
+// drain takes the log groups pushed to the queue by aggregators.
+func (b *benchmarker) drain() []*protocol.LogGroup {
+	var groups []*protocol.LogGroup
+	for {
+		select {
+		case group := <-b.runner.LogGroupsChan:
+			groups = append(groups, group)
+		default:
+			return groups
+		}
+	}
+}
+
+// flush flushes the events remained in aggregators.
+func (b *benchmarker) flush() int64 {
+	var logGroups []*protocol.LogGroup
+	for i, aggregator := range b.runner.AggregatorPlugins {
+		measure(b.aggregators[i], 0, func() int {
+			groups := append(aggregator.Aggregator.Flush(), b.drain()...)
+			logGroups = append(logGroups, groups...)
+			return countLogs(groups)
+		})
+	}
+	return b.flushLogGroups(logGroups)
+}
+
+func (b *benchmarker) flushLogGroups(logGroups []*protocol.LogGroup) int64 {
+	nonEmpty := logGroups[:0]
+	for _, group := range logGroups {
+		if len(group.Logs) > 0 {
+			nonEmpty = append(nonEmpty, group)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return 0
+	}
+	count := countLogs(nonEmpty)
+	for i, flusher := range b.runner.FlusherPlugins {
+		measure(b.flushers[i], count, func() int {
+			if err := flusher.Flusher.Flush(b.lc.ProjectName, b.lc.LogstoreName, b.lc.ConfigName, nonEmpty); err != nil {
+				b.flushers[i].Errors++
+				return 0
+			}
+			return count
+		})
+	}
+	return int64(count)
+}
+
+func countLogs(groups []*protocol.LogGroup) int {
+	count := 0
+	for _, group := range groups {
+		count += len(group.Logs)
+	}
+	return count
+}
+
+func (b *benchmarker) profiles() []*PluginBenchmark {
+	var all []*PluginBenchmark
+	all = append(all, b.processors...)
+	all = append(all, b.aggregators...)
+	all = append(all, b.flushers...)
+	for _, pb := range all {
+		if pb.Calls == 0 {
+			continue
+		}
+		pb.LatencyAvg = pb.total / time.Duration(pb.Calls)
+		if pb.total > 0 {
+			pb.EventsPerSecond = float64(pb.InputEvents) / pb.total.Seconds()
+		}
+		if pb.InputEvents > 0 {
+			pb.BytesPerEvent = float64(pb.AllocBytes) / float64(pb.InputEvents)
+		}
+		sort.Slice(pb.latencies, func(i, j int) bool { return pb.latencies[i] < pb.latencies[j] })
+		pb.LatencyP50 = percentile(pb.latencies, 0.5)
+		pb.LatencyP90 = percentile(pb.latencies, 0.9)
+		pb.LatencyP99 = percentile(pb.latencies, 0.99)
+		pb.LatencyMax = pb.latencies[len(pb.latencies)-1]
+	}
+	return all
+}
+
+// percentile returns the @p percentile of the sorted @latencies.
+func percentile(latencies []time.Duration, p float64) time.Duration {
+	idx := int(float64(len(latencies))*p+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(latencies) {
+		idx = len(latencies) - 1
+	}
+	return latencies[idx]
+}
@@ -0,0 +1,83 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux || windows
+// +build linux windows
+
+package pluginmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var benchmarkConfig = `{
+	"inputs": [{"type": "service_mock"}],
+	"processors": [
+		{"type": "processor_unknown"},
+		{"type": "processor_regex", "detail": {"SourceKey": "content", "Regex": "(\\w+) (\\d+)", "Keys": ["name", "age"]}}
+	],
+	"flushers": [{"type": "flusher_checker"}]
+}`
+
+func TestLoadBenchmarkCorpus(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "corpus")
+	require.NoError(t, os.WriteFile(path, []byte("tom 18\n\n{\"content\":\"jerry 3\",\"level\":\"info\"}\n{broken\n"), 0600))
+	corpus, err := LoadBenchmarkCorpus(path)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]string{
+		{"content": "tom 18"},
+		{"content": "jerry 3", "level": "info"},
+		{"content": "{broken"},
+	}, corpus)
+
+	_, err = LoadBenchmarkCorpus(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func TestBenchmark(t *testing.T) {
+	corpus := []map[string]string{{"content": "tom 18"}, {"content": "jerry 3"}, {"content": "spike 5"}, {"content": "x"}, {"content": "tyke 1"}}
+	report, err := Benchmark(benchmarkConfig, corpus, BenchmarkOptions{Loops: 2, BatchSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), report.Events)
+	assert.Equal(t, int64(10), report.OutputEvents)
+	assert.Empty(t, LogtailConfig, "the benchmarked config should not be loaded")
+
+	require.Len(t, report.Plugins, 3)
+	processor := report.Plugins[0]
+	assert.Equal(t, "processor_regex", processor.Plugin)
+	assert.Equal(t, "processor", processor.Category)
+	assert.Equal(t, int64(6), processor.Calls)
+	assert.Equal(t, int64(10), processor.InputEvents)
+	assert.Equal(t, int64(10), processor.OutputEvents)
+	assert.True(t, processor.LatencyP50 <= processor.LatencyP99 && processor.LatencyP99 <= processor.LatencyMax)
+	assert.Greater(t, processor.AllocBytes, uint64(0))
+	assert.Equal(t, "aggregator_default", report.Plugins[1].Plugin)
+	assert.Equal(t, "flusher_checker", report.Plugins[2].Plugin)
+	assert.Equal(t, int64(10), report.Plugins[2].OutputEvents)
+
+	start := time.Now()
+	report, err = Benchmark(benchmarkConfig, corpus, BenchmarkOptions{Rate: 50, BatchSize: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), report.Events)
+	assert.GreaterOrEqual(t, time.Since(start), time.Millisecond*90)
+
+	_, err = Benchmark(benchmarkConfig, nil, BenchmarkOptions{})
+	assert.Error(t, err)
+}
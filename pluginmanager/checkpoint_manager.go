@@ -34,6 +34,9 @@ var CheckPointCleanInterval = flag.Int("CheckPointCleanInterval", 600, "checkpoi
 var MaxCleanItemPerInterval = flag.Int("MaxCleanItemPerInterval", 1000, "max clean items per interval")
 
 type checkPointManager struct {
+	// lock protects db from being closed while the checkpoints are written, e.g. by the configs failed
+	// to stop before the deadline of Shutdown.
+	lock      sync.RWMutex
 	db        *leveldb.DB
 	shutdown  chan struct{}
 	waitgroup sync.WaitGroup
@@ -45,28 +48,34 @@ var CheckPointManager checkPointManager
 var ErrCheckPointNotInit = errors.New("checkpoint db not init")
 
 func (p *checkPointManager) SaveCheckpoint(configName, key string, value []byte) error {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
 	if p.db == nil {
 		return ErrCheckPointNotInit
 	}
 	err := p.db.Put([]byte(configName+"^"+key), value, nil)
 	if err != nil {
-		logger.Error(context.Background(), "CHECKPOINT_SAVE_ALARM", "save checkpoint error, key", key, "error", err)
+		logger.Error(context.Background(), util.AlarmCheckpointSave, "save checkpoint error, key", key, "error", err)
 	}
 	return err
 }
 
 func (p *checkPointManager) GetCheckpoint(configName, key string) ([]byte, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
 	if p.db == nil {
 		return nil, ErrCheckPointNotInit
 	}
 	val, err := p.db.Get([]byte(configName+"^"+key), nil)
 	if err != nil && err != leveldb.ErrNotFound {
-		logger.Error(context.Background(), "CHECKPOINT_GET_ALARM", "get checkpoint error, key", key, "error", err)
+		logger.Error(context.Background(), util.AlarmCheckpointGet, "get checkpoint error, key", key, "error", err)
 	}
 	return val, err
 }
 
 func (p *checkPointManager) DeleteCheckpoint(configName, key string) error {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
 	if p.db == nil {
 		return ErrCheckPointNotInit
 	}
@@ -87,9 +96,11 @@ func (p *checkPointManager) Init() error {
 		dbPath = util.GetCurrentBinaryPath() + *CheckPointFile
 	}
 
+	p.lock.Lock()
+	defer p.lock.Unlock()
 	p.db, err = leveldb.OpenFile(dbPath, nil)
 	if err != nil {
-		logger.Warning(context.Background(), "CHECKPOINT_ALARM", "open checkpoint error", err, "try recover db file", dbPath)
+		logger.Warning(context.Background(), util.AlarmCheckpoint, "open checkpoint error", err, "try recover db file", dbPath)
 		p.db, err = leveldb.RecoverFile(dbPath, nil)
 	}
 
@@ -103,22 +114,44 @@ func (p *checkPointManager) Init() error {
 
 func (p *checkPointManager) HoldOn() {
 	logger.Info(context.Background(), "checkpoint", "HoldOn")
-	if p.db == nil {
+	if !p.opened() {
 		return
 	}
 	p.shutdown <- struct{}{}
 	p.waitgroup.Wait()
 }
 
+// Close persists the checkpoints by closing the db, Init opens it again. The checkpoints written after
+// Close fail with ErrCheckPointNotInit.
+func (p *checkPointManager) Close() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.db == nil || !p.initFlag {
+		return
+	}
+	if err := p.db.Close(); err != nil {
+		logger.Error(context.Background(), util.AlarmCheckpoint, "close checkpoint error", err)
+	}
+	p.db = nil
+	p.initFlag = false
+	logger.Info(context.Background(), "checkpoint", "Close")
+}
+
 func (p *checkPointManager) Resume() {
 	logger.Info(context.Background(), "checkpoint", "Resume")
-	if p.db == nil {
+	if !p.opened() {
 		return
 	}
 	p.waitgroup.Add(1)
 	go p.run()
 }
 
+func (p *checkPointManager) opened() bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.db != nil
+}
+
 func (p *checkPointManager) run() {
 	for {
 		if util.RandomSleep(time.Second*time.Duration(*CheckPointCleanInterval), 0.1, p.shutdown) {
@@ -134,7 +167,7 @@ func (p *checkPointManager) keyMatch(key []byte) bool {
 	keyStr := string(key)
 	index := strings.IndexByte(keyStr, '^')
 	if index <= 0 {
-		logger.Error(context.Background(), "CHECKPOINT_ALARM", "key format not match, key", keyStr)
+		logger.Error(context.Background(), util.AlarmCheckpoint, "key format not match, key", keyStr)
 		return false
 	}
 	_, existFlag := LogtailConfig[keyStr[0:index]]
@@ -148,6 +181,8 @@ func (p *checkPointManager) keyMatch(key []byte) bool {
 }
 
 func (p *checkPointManager) check() {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
 	if p.db == nil {
 		return
 	}
@@ -166,7 +201,7 @@ func (p *checkPointManager) check() {
 	iter.Release()
 	err := iter.Error()
 	if err != nil {
-		logger.Warning(context.Background(), "CHECKPOINT_ALARM", "iterate checkpoint error", err)
+		logger.Warning(context.Background(), util.AlarmCheckpoint, "iterate checkpoint error", err)
 	}
 	for _, key := range cleanItems {
 		_ = p.db.Delete([]byte(key), nil)
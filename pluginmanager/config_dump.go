@@ -0,0 +1,103 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+// the suffixes of the config keys whose values are masked in the dumps, such as SASLPassword,
+// AccessKeySecret, SecurityToken and Authorization, matched case-insensitively.
+var sensitiveKeySuffixes = []string{"password", "secret", "token", "authorization"}
+
+// the suffixes of the config keys whose nested values are all masked in the dumps, such as the Headers
+// of the http flushers, matched case-insensitively.
+var sensitiveMapKeySuffixes = []string{"headers"}
+
+// ConfigDump is the running config with the secrets redacted.
+type ConfigDump struct {
+	ProjectName  string          `json:"project"`
+	LogstoreName string          `json:"logstore"`
+	ConfigName   string          `json:"config_name"`
+	Detail       json.RawMessage `json:"detail"`
+}
+
+// DumpConfigs returns the running configs ordered by the config name, only @configName is returned if it
+// is not empty. The values of the sensitive keys and the secrets registered by the credential providers
+// are masked, so that the dumps could be attached to the debug bundles.
+func DumpConfigs(configName string) []ConfigDump {
+	dumps := make([]ConfigDump, 0)
+	for name, config := range runningConfigs() {
+		if configName != "" && name != configName {
+			continue
+		}
+		dumps = append(dumps, ConfigDump{
+			ProjectName:  config.ProjectName,
+			LogstoreName: config.LogstoreName,
+			ConfigName:   config.ConfigName,
+			Detail:       redactConfigDetail(config.configDetail),
+		})
+	}
+	sort.Slice(dumps, func(i, j int) bool { return dumps[i].ConfigName < dumps[j].ConfigName })
+	return dumps
+}
+
+// redactConfigDetail masks the secrets in the config json, the invalid json is dumped as a string.
+func redactConfigDetail(detail string) json.RawMessage {
+	var v interface{}
+	if err := json.Unmarshal([]byte(detail), &v); err != nil {
+		v = detail
+	}
+	bytes, _ := json.Marshal(redactValue("", v, false))
+	return bytes
+}
+
+// redactValue masks the strings of the sensitive keys, or all the strings if @masked.
+func redactValue(key string, v interface{}, masked bool) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		masked = masked || hasKeySuffix(key, sensitiveMapKeySuffixes)
+		for k, item := range val {
+			val[k] = redactValue(k, item, masked)
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = redactValue(key, item, masked)
+		}
+		return val
+	case string:
+		if val != "" && (masked || hasKeySuffix(key, sensitiveKeySuffixes)) {
+			return util.SecretMask
+		}
+		return util.RedactSecrets(val)
+	default:
+		return v
+	}
+}
+
+func hasKeySuffix(key string, suffixes []string) bool {
+	key = strings.ToLower(key)
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+func TestRedactConfigDetail(t *testing.T) {
+	defer util.ResetSecrets()
+	util.RegisterSecret("mock-vault-secret")
+	detail := `{"inputs":[{"Type":"service_object_storage","AccessKeyID":"id","AccessKeySecret":"secret","Credentials":{"Provider":"file","File":"/etc/ak.json"}}],` +
+		`"flushers":[{"Type":"flusher_kafka","SASLUsername":"user","SASLPassword":"pass","Headers":{"X-Auth":"Bearer mock-vault-secret","X-Api-Key":"key"},"Brokers":["a:9092"],"Topic":"logs-mock-vault-secret","Timeout":3},` +
+		`{"Type":"flusher_http","Authorization":"Basic dXNlcjpwYXNz"}]}`
+	assert.JSONEq(t, `{"inputs":[{"Type":"service_object_storage","AccessKeyID":"id","AccessKeySecret":"******","Credentials":{"Provider":"file","File":"/etc/ak.json"}}],`+
+		`"flushers":[{"Type":"flusher_kafka","SASLUsername":"user","SASLPassword":"******","Headers":{"X-Auth":"******","X-Api-Key":"******"},"Brokers":["a:9092"],"Topic":"logs-******","Timeout":3},`+
+		`{"Type":"flusher_http","Authorization":"******"}]}`,
+		string(redactConfigDetail(detail)))
+	assert.Equal(t, `"invalid ******"`, string(redactConfigDetail("invalid mock-vault-secret")))
+}
@@ -0,0 +1,247 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"crypto/md5" //nolint:gosec
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+// enableDiffReload pauses all the configs instead of stopping them when reloading, like AlwaysOnline.
+// So the unchanged configs keep running, and the unchanged service inputs of the changed configs
+// are taken over by the new configs without closing their listeners.
+// The service inputs are only taken over between the v1 pipelines of the same project and logstore,
+// the other plugins of the changed configs are restarted, and they reload their checkpoints by the config name.
+var enableDiffReload = false
+
+const (
+	pluginHashKey    = "hash"
+	pluginMetricsKey = "metrics"
+	reusedServiceKey = "reused_service"
+)
+
+// reusedServices are the running service inputs detached from the old config, which are waiting to be
+// taken over by the new config with the same name. Their logs are sent to handover meanwhile, which are
+// buffered until the new config is created, and then forwarded to it.
+type reusedServices struct {
+	wrappers map[string][]*ServiceWrapper
+	taken    []*ServiceWrapper
+	handover chan *pipeline.LogWithContext
+	runner   chan PluginRunner
+	done     chan struct{}
+	attached bool
+	context  pipeline.Context
+}
+
+// serviceMetrics are the self metrics registered by a service input when it's initialized.
+type serviceMetrics struct {
+	counters  map[string]pipeline.CounterMetric
+	strings   map[string]pipeline.StringMetric
+	latencies map[string]pipeline.LatencyMetric
+}
+
+// initService initializes @service, and records the self metrics registered by it.
+func initService(service pipeline.ServiceInput, ctx pipeline.Context) (*serviceMetrics, error) {
+	contextImp, ok := ctx.(*ContextImp)
+	if !ok {
+		_, err := service.Init(ctx)
+		return nil, err
+	}
+	before := contextImp.copyMetrics()
+	if _, err := service.Init(ctx); err != nil {
+		return nil, err
+	}
+	metrics := contextImp.copyMetrics()
+	for name, metric := range before.counters {
+		if metrics.counters[name] == metric {
+			delete(metrics.counters, name)
+		}
+	}
+	for name, metric := range before.strings {
+		if metrics.strings[name] == metric {
+			delete(metrics.strings, name)
+		}
+	}
+	for name, metric := range before.latencies {
+		if metrics.latencies[name] == metric {
+			delete(metrics.latencies, name)
+		}
+	}
+	return metrics, nil
+}
+
+// register registers the metrics to the context of the config taking over the service.
+func (m *serviceMetrics) register(ctx pipeline.Context) {
+	if m == nil {
+		return
+	}
+	for _, metric := range m.counters {
+		ctx.RegisterCounterMetric(metric)
+	}
+	for _, metric := range m.strings {
+		ctx.RegisterStringMetric(metric)
+	}
+	for _, metric := range m.latencies {
+		ctx.RegisterLatencyMetric(metric)
+	}
+}
+
+// serviceHash identifies the config of a service input.
+func serviceHash(typeName string, detail interface{}) string {
+	bytes, _ := json.Marshal(detail)
+	return fmt.Sprintf("%x", md5.Sum(append([]byte(typeName+"#"), bytes...))) //nolint:gosec
+}
+
+// serviceHashes counts the hashes of the service inputs in @plugins.
+func serviceHashes(plugins map[string]interface{}) map[string]int {
+	hashes := make(map[string]int)
+	inputs, _ := plugins["inputs"].([]interface{})
+	for _, inputInterface := range inputs {
+		input, ok := inputInterface.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if typeName, ok := input["type"].(string); ok && strings.HasPrefix(typeName, "service_") {
+			hashes[serviceHash(getPluginType(typeName), input["detail"])]++
+		}
+	}
+	return hashes
+}
+
+// detachServices removes the services with the same hashes as @hashes from @old, and redirects their logs
+// to the handover queue. Only the services between v1 runners of the same project and logstore could be
+// taken over, because the services keep the context they are initialized with. The services of hot standby
+// configs are never taken over.
+func detachServices(old PluginRunner, newRunner PluginRunner, hashes map[string]int) *reusedServices {
+	oldRunner, ok := old.(*pluginv1Runner)
+	if !ok || oldRunner.LogstoreConfig.hotStandby != nil {
+		return nil
+	}
+	runner, ok := newRunner.(*pluginv1Runner)
+	if !ok || runner.LogstoreConfig.ProjectName != oldRunner.LogstoreConfig.ProjectName ||
+		runner.LogstoreConfig.LogstoreName != oldRunner.LogstoreConfig.LogstoreName {
+		return nil
+	}
+	r := &reusedServices{
+		wrappers: make(map[string][]*ServiceWrapper),
+		handover: make(chan *pipeline.LogWithContext, cap(oldRunner.LogsChan)),
+		runner:   make(chan PluginRunner, 1),
+		done:     make(chan struct{}),
+		context:  oldRunner.LogstoreConfig.Context,
+	}
+	remained := oldRunner.ServicePlugins[:0]
+	for _, service := range oldRunner.ServicePlugins {
+		if service.hash != "" && hashes[service.hash] > 0 {
+			hashes[service.hash]--
+			service.setLogsChan(r.handover)
+			r.wrappers[service.hash] = append(r.wrappers[service.hash], service)
+			continue
+		}
+		remained = append(remained, service)
+	}
+	oldRunner.ServicePlugins = remained
+	if len(r.wrappers) == 0 {
+		return nil
+	}
+	go r.forward()
+	return r
+}
+
+// forward keeps receiving the logs of the detached services, so they never block the reloading. The logs are
+// buffered until the new config is attached, and the logs buffered are dropped if the new config is not created.
+func (r *reusedServices) forward() {
+	var runner PluginRunner
+	var pending []*pipeline.LogWithContext
+	for {
+		select {
+		case log := <-r.handover:
+			if runner != nil {
+				runner.ReceiveRawLog(log)
+			} else {
+				pending = append(pending, log)
+			}
+		case runner = <-r.runner:
+			for _, log := range pending {
+				runner.ReceiveRawLog(log)
+			}
+			pending = nil
+		case <-r.done:
+			for {
+				select {
+				case log := <-r.handover:
+					if runner != nil {
+						runner.ReceiveRawLog(log)
+					}
+				default:
+					if len(pending) > 0 {
+						logger.Warning(r.context.GetRuntimeContext(), util.AlarmDropData, "drop the logs of the services not taken over", len(pending))
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// take returns a detached service with @hash, or nil if none.
+func (r *reusedServices) take(hash string) *ServiceWrapper {
+	if r == nil || len(r.wrappers[hash]) == 0 {
+		return nil
+	}
+	service := r.wrappers[hash][0]
+	r.wrappers[hash] = r.wrappers[hash][1:]
+	r.taken = append(r.taken, service)
+	return service
+}
+
+// attach forwards the buffered logs to @runner, which has redirected the taken services to itself.
+// The logs are forwarded in background because the runner is not started yet.
+func (r *reusedServices) attach(runner PluginRunner) {
+	if r == nil {
+		return
+	}
+	r.attached = true
+	r.runner <- runner
+}
+
+// release stops the services not taken over, and all the services when the new config is not created.
+// The logs sent to handover by the services stopped are still forwarded to the new config.
+func (r *reusedServices) release() {
+	if r == nil {
+		return
+	}
+	var services []*ServiceWrapper
+	if !r.attached {
+		services = append(services, r.taken...)
+	}
+	for _, wrappers := range r.wrappers {
+		services = append(services, wrappers...)
+	}
+	for _, service := range services {
+		logger.Info(service.Config.Context.GetRuntimeContext(), "stop the service not taken over", service.Input.Description())
+		_ = service.Stop()
+	}
+	close(r.done)
+}
+
+func init() {
+	_ = util.InitFromEnvBool("ALIYUN_LOGTAIL_ENABLE_DIFF_RELOAD", &enableDiffReload, false)
+}
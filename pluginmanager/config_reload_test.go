@@ -0,0 +1,156 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux || windows
+// +build linux windows
+
+package pluginmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/flusher/checker"
+)
+
+const reloadConfigName = "diff_reload"
+
+var reloadServiceConfig = `{
+	"inputs": [{"type": "service_mock", "detail": {"LogsPerSecond": 100, "Fields": {"content": "hello"}}}],
+	"flushers": [{"type": "flusher_checker"}]
+}`
+
+var reloadProcessorConfig = `{
+	"inputs": [{"type": "service_mock", "detail": {"LogsPerSecond": 100, "Fields": {"content": "hello"}}}],
+	"processors": [{"type": "processor_regex", "detail": {"SourceKey": "content", "Regex": "(.*)", "Keys": ["msg"]}}],
+	"flushers": [{"type": "flusher_checker"}]
+}`
+
+func TestServiceHashes(t *testing.T) {
+	hashes := serviceHashes(map[string]interface{}{
+		"inputs": []interface{}{
+			map[string]interface{}{"type": "service_mock", "detail": map[string]interface{}{"LogsPerSecond": 1}},
+			map[string]interface{}{"type": "service_mock#1", "detail": map[string]interface{}{"LogsPerSecond": 1}},
+			map[string]interface{}{"type": "service_mock", "detail": map[string]interface{}{"LogsPerSecond": 2}},
+			map[string]interface{}{"type": "metric_mock", "detail": map[string]interface{}{"LogsPerSecond": 1}},
+		},
+	})
+	assert.Equal(t, map[string]int{
+		serviceHash("service_mock", map[string]interface{}{"LogsPerSecond": 1}): 2,
+		serviceHash("service_mock", map[string]interface{}{"LogsPerSecond": 2}): 1,
+	}, hashes)
+}
+
+type metricService struct {
+	counter pipeline.CounterMetric
+}
+
+func (s *metricService) Init(ctx pipeline.Context) (int, error) {
+	s.counter = helper.NewCounterMetricAndRegister("reload_service_count", ctx)
+	return 0, nil
+}
+
+func (s *metricService) Description() string {
+	return "service registering a counter"
+}
+
+func (s *metricService) Stop() error {
+	return nil
+}
+
+func TestInitServiceMetrics(t *testing.T) {
+	ctx := &ContextImp{}
+	ctx.InitContext(reloadConfigName, reloadConfigName, reloadConfigName)
+	helper.NewCounterMetricAndRegister("reload_processor_count", ctx)
+	service := &metricService{}
+	metrics, err := initService(service, ctx)
+	require.NoError(t, err)
+	require.Len(t, metrics.counters, 1)
+	assert.Same(t, service.counter, metrics.counters["reload_service_count"])
+
+	newCtx := &ContextImp{}
+	newCtx.InitContext(reloadConfigName, reloadConfigName, reloadConfigName)
+	metrics.register(newCtx)
+	assert.Same(t, service.counter, newCtx.CounterMetrics["reload_service_count"])
+	assert.NotContains(t, newCtx.CounterMetrics, "reload_processor_count")
+}
+
+func TestReusedServicesForward(t *testing.T) {
+	ctx := &ContextImp{}
+	ctx.InitContext(reloadConfigName, reloadConfigName, reloadConfigName)
+	r := &reusedServices{
+		wrappers: make(map[string][]*ServiceWrapper),
+		handover: make(chan *pipeline.LogWithContext, 1),
+		runner:   make(chan PluginRunner, 1),
+		done:     make(chan struct{}),
+		context:  ctx,
+	}
+	go r.forward()
+	// the logs are buffered before the new config is attached, so the handover never blocks the services
+	for i := 0; i < 10; i++ {
+		r.handover <- &pipeline.LogWithContext{Log: &protocol.Log{}}
+	}
+	runner := &pluginv1Runner{LogsChan: make(chan *pipeline.LogWithContext, 20)}
+	r.attach(runner)
+	r.handover <- &pipeline.LogWithContext{Log: &protocol.Log{}}
+	r.release()
+	assert.Eventually(t, func() bool { return len(runner.LogsChan) == 11 }, time.Second*5, time.Millisecond*10)
+}
+
+func TestDiffReload(t *testing.T) {
+	defer func(enabled bool) {
+		enableDiffReload = enabled
+		LogtailConfig = make(map[string]*LogstoreConfig)
+	}(enableDiffReload)
+	enableDiffReload = true
+
+	require.NoError(t, LoadMockConfig(reloadConfigName, reloadConfigName, reloadConfigName, reloadServiceConfig))
+	require.NoError(t, Resume())
+	config := LogtailConfig[reloadConfigName]
+	require.NotNil(t, config)
+	service := config.PluginRunner.(*pluginv1Runner).ServicePlugins[0]
+
+	// the unchanged config keeps running
+	require.NoError(t, HoldOn(false))
+	require.NoError(t, LoadMockConfig(reloadConfigName, reloadConfigName, reloadConfigName, reloadServiceConfig))
+	require.NoError(t, Resume())
+	assert.Same(t, config, LogtailConfig[reloadConfigName])
+
+	// the service of the changed config is taken over
+	require.NoError(t, HoldOn(false))
+	require.NoError(t, LoadMockConfig(reloadConfigName, reloadConfigName, reloadConfigName, reloadProcessorConfig))
+	require.NoError(t, Resume())
+	newConfig := LogtailConfig[reloadConfigName]
+	require.NotNil(t, newConfig)
+	assert.NotSame(t, config, newConfig)
+	newRunner := newConfig.PluginRunner.(*pluginv1Runner)
+	require.Len(t, newRunner.ServicePlugins, 1)
+	assert.Same(t, service, newRunner.ServicePlugins[0])
+	assert.Same(t, newConfig, service.Config)
+	assert.Empty(t, config.PluginRunner.(*pluginv1Runner).ServicePlugins)
+
+	flusher, ok := GetConfigFluhsers(newRunner)[0].(*checker.FlusherChecker)
+	require.True(t, ok)
+	assert.Eventually(t, func() bool { return flusher.GetLogCount() > 0 }, time.Second*10, time.Millisecond*100)
+
+	// stop the config rather than caching it, and leave the checkpoint manager held on like the other tests
+	enableDiffReload = false
+	require.NoError(t, HoldOn(false))
+}
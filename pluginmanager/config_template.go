@@ -0,0 +1,298 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// includeKey is the only key of the object replaced by the json content of the file, the array content
+	// is spliced into the parent array.
+	includeKey = "$include"
+	// processorBlocksKey is the top-level field of the named processor lists, which are referenced by
+	// the {"block": "name"} items of the processors.
+	processorBlocksKey = "processor_blocks"
+	blockRefKey        = "block"
+
+	maxTemplateDepth = 16
+)
+
+// envRefRegex matches ${NAME} or ${NAME:-default}, and $${ is the escape of a literal ${. Only the variables
+// with the prefixes in ConfigEnvPrefixes are substituted, the other references are kept as is.
+var envRefRegex = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// expandConfigTemplate substitutes the environment variables in the string values, replaces the file
+// includes and expands the processor blocks of the config. The config is returned as is if it uses none
+// of them.
+func expandConfigTemplate(jsonStr string) (string, error) {
+	if !strings.Contains(jsonStr, "${") && !strings.Contains(jsonStr, includeKey) && !strings.Contains(jsonStr, processorBlocksKey) {
+		return jsonStr, nil
+	}
+	root, err := decodeJSON([]byte(jsonStr))
+	if err != nil {
+		return "", err
+	}
+	e := &templateExpander{baseDir: includeBaseDir(), envPrefixes: LogtailGlobalConfig.ConfigEnvPrefixes}
+	if root, err = e.resolve(root, e.baseDir); err != nil {
+		return "", err
+	}
+	if plugins, ok := root.(map[string]interface{}); ok {
+		if err = expandProcessorBlocks(plugins); err != nil {
+			return "", err
+		}
+	}
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err = encoder.Encode(root); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// includeBaseDir is the directory of the relative include paths, the included files must be in it.
+func includeBaseDir() string {
+	dir := LogtailGlobalConfig.ConfigIncludeDir
+	if dir == "" {
+		dir = LogtailGlobalConfig.LogtailSysConfDir
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return filepath.Clean(dir)
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved
+	}
+	return abs
+}
+
+func decodeJSON(data []byte) (interface{}, error) {
+	var v interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// keep the numbers as is, such as the int64 values beyond the precision of float64
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+type templateExpander struct {
+	baseDir     string
+	envPrefixes []string
+	// the files being included, to detect the include cycles
+	including []string
+}
+
+// resolve substitutes the environment variables and replaces the includes in @v, the relative include
+// paths are relative to @dir, which is the directory of the including file.
+func (e *templateExpander) resolve(v interface{}, dir string) (interface{}, error) {
+	switch val := v.(type) {
+	case string:
+		return e.expandEnv(val), nil
+	case []interface{}:
+		list := make([]interface{}, 0, len(val))
+		for _, item := range val {
+			if path, ok := includePath(item); ok {
+				content, err := e.include(path, dir)
+				if err != nil {
+					return nil, err
+				}
+				if items, ok := content.([]interface{}); ok {
+					list = append(list, items...)
+					continue
+				}
+				list = append(list, content)
+				continue
+			}
+			item, err := e.resolve(item, dir)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, nil
+	case map[string]interface{}:
+		if path, ok := includePath(val); ok {
+			return e.include(path, dir)
+		}
+		for k, item := range val {
+			item, err := e.resolve(item, dir)
+			if err != nil {
+				return nil, err
+			}
+			val[k] = item
+		}
+		return val, nil
+	default:
+		return v, nil
+	}
+}
+
+func (e *templateExpander) include(path string, dir string) (interface{}, error) {
+	path = e.expandEnv(path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	path = filepath.Clean(path)
+	if !e.inBaseDir(path) {
+		return nil, fmt.Errorf("include %s is out of the include dir %s", path, e.baseDir)
+	}
+	// check the target of the symlinks too, otherwise a symlink in the include dir could point to any file
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil, fmt.Errorf("read include %s error: %v", path, err)
+	}
+	if !e.inBaseDir(resolved) {
+		return nil, fmt.Errorf("include %s links to %s out of the include dir %s", path, resolved, e.baseDir)
+	}
+	path = resolved
+	for i, p := range e.including {
+		if p == path {
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(append(e.including[i:], path), " -> "))
+		}
+	}
+	if len(e.including) >= maxTemplateDepth {
+		return nil, fmt.Errorf("includes are nested more than %d levels: %s", maxTemplateDepth, path)
+	}
+	data, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("read include %s error: %v", path, err)
+	}
+	content, err := decodeJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse include %s error: %v", path, err)
+	}
+	e.including = append(e.including, path)
+	defer func() { e.including = e.including[:len(e.including)-1] }()
+	return e.resolve(content, filepath.Dir(path))
+}
+
+func (e *templateExpander) inBaseDir(path string) bool {
+	rel, err := filepath.Rel(e.baseDir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// includePath returns the path if @v is an include object.
+func includePath(v interface{}) (string, bool) {
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return "", false
+	}
+	path, ok := m[includeKey].(string)
+	return path, ok
+}
+
+// expandEnv substitutes ${NAME} with the environment variable, or the default value after :- if it is
+// not set. The references to the variables out of the allowed prefixes and the unset variables without
+// the default value are kept as is, such as the named groups in the replacements of the regex processors.
+func (e *templateExpander) expandEnv(s string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	return envRefRegex.ReplaceAllStringFunc(s, func(ref string) string {
+		if ref == "$${" {
+			return "${"
+		}
+		match := envRefRegex.FindStringSubmatch(ref)
+		if !e.envAllowed(match[1]) {
+			return ref
+		}
+		if value, ok := os.LookupEnv(match[1]); ok {
+			return value
+		}
+		if strings.Contains(ref, ":-") {
+			return match[2]
+		}
+		return ref
+	})
+}
+
+// envAllowed checks if the environment variable @name could be substituted, none could if no prefix is allowed.
+func (e *templateExpander) envAllowed(name string) bool {
+	for _, prefix := range e.envPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandProcessorBlocks replaces the {"block": "name"} items of the processors with the processors of
+// the named blocks, which could reference the other blocks.
+func expandProcessorBlocks(plugins map[string]interface{}) error {
+	blocksValue, hasBlocks := plugins[processorBlocksKey]
+	delete(plugins, processorBlocksKey)
+	blocks, ok := blocksValue.(map[string]interface{})
+	if hasBlocks && !ok {
+		return fmt.Errorf("%s should be an object of the processor lists", processorBlocksKey)
+	}
+	processors, ok := plugins["processors"].([]interface{})
+	if !ok {
+		return nil
+	}
+	expanded, err := expandBlockRefs(processors, blocks, nil)
+	if err != nil {
+		return err
+	}
+	plugins["processors"] = expanded
+	return nil
+}
+
+func expandBlockRefs(processors []interface{}, blocks map[string]interface{}, expanding []string) ([]interface{}, error) {
+	list := make([]interface{}, 0, len(processors))
+	for _, item := range processors {
+		name, ok := blockRef(item)
+		if !ok {
+			list = append(list, item)
+			continue
+		}
+		for i, n := range expanding {
+			if n == name {
+				return nil, fmt.Errorf("processor block cycle: %s", strings.Join(append(expanding[i:], name), " -> "))
+			}
+		}
+		if len(expanding) >= maxTemplateDepth {
+			return nil, fmt.Errorf("processor blocks are nested more than %d levels: %s", maxTemplateDepth, name)
+		}
+		block, ok := blocks[name].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("processor block %s is not defined or not a list", name)
+		}
+		items, err := expandBlockRefs(block, blocks, append(expanding, name))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, items...)
+	}
+	return list, nil
+}
+
+// blockRef returns the block name if @v is a block reference.
+func blockRef(v interface{}) (string, bool) {
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return "", false
+	}
+	name, ok := m[blockRefKey].(string)
+	return name, ok
+}
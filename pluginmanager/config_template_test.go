@@ -0,0 +1,107 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandConfigTemplate(t *testing.T) {
+	dir := t.TempDir()
+	LogtailGlobalConfig.ConfigIncludeDir = dir
+	LogtailGlobalConfig.ConfigEnvPrefixes = []string{"TEST_"}
+	defer func() {
+		LogtailGlobalConfig.ConfigIncludeDir = ""
+		LogtailGlobalConfig.ConfigEnvPrefixes = nil
+	}()
+	t.Setenv("TEST_LOG_PATH", "/var/log/app")
+	t.Setenv("OTHER_SECRET", "s3cr3t")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "blocks"), 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "blocks", "common.json"),
+		[]byte(`{"parse":[{"type":"processor_regex","detail":{"Regex":"(\\S+) (.*)"}},{"block":"drop"}],"drop":{"$include":"drop.json"}}`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "blocks", "drop.json"), []byte(`[{"type":"processor_drop","detail":{"DropKeys":["${TEST_DROP_KEY:-level}"]}}]`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "flushers.json"), []byte(`[{"type":"flusher_stdout"},{"type":"flusher_checker"}]`), 0600))
+
+	expanded, err := expandConfigTemplate(`{
+		"inputs":[{"type":"file_log","detail":{"LogPath":"${TEST_LOG_PATH}","MaxDepth":1,"Replace":"${name}$${TEST_LOG_PATH}","Secret":"${OTHER_SECRET}"}}],
+		"processor_blocks":{"$include":"blocks/common.json"},
+		"processors":[{"type":"processor_default"},{"block":"parse"}],
+		"flushers":[{"$include":"flushers.json"}]
+	}`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"inputs":[{"type":"file_log","detail":{"LogPath":"/var/log/app","MaxDepth":1,"Replace":"${name}${TEST_LOG_PATH}","Secret":"${OTHER_SECRET}"}}],
+		"processors":[{"type":"processor_default"},{"type":"processor_regex","detail":{"Regex":"(\\S+) (.*)"}},{"type":"processor_drop","detail":{"DropKeys":["level"]}}],
+		"flushers":[{"type":"flusher_stdout"},{"type":"flusher_checker"}]
+	}`, expanded)
+
+	plain := `{"inputs":[{"type":"metric_mock"}],"flushers":[{"type":"flusher_stdout"}]}`
+	expanded, err = expandConfigTemplate(plain)
+	require.NoError(t, err)
+	assert.Equal(t, plain, expanded)
+
+	// no variable is substituted without the allowed prefixes
+	LogtailGlobalConfig.ConfigEnvPrefixes = nil
+	expanded, err = expandConfigTemplate(`{"inputs":[{"type":"file_log","detail":{"LogPath":"${TEST_LOG_PATH}","Replace":"$${name}"}}]}`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"inputs":[{"type":"file_log","detail":{"LogPath":"${TEST_LOG_PATH}","Replace":"${name}"}}]}`, expanded)
+}
+
+func TestExpandConfigTemplateError(t *testing.T) {
+	dir := t.TempDir()
+	LogtailGlobalConfig.ConfigIncludeDir = dir
+	defer func() { LogtailGlobalConfig.ConfigIncludeDir = "" }()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"$include":"b.json"}`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.json"), []byte(`{"$include":"a.json"}`), 0600))
+
+	_, err := expandConfigTemplate(`{"processor_blocks":{"$include":"a.json"}}`)
+	assert.ErrorContains(t, err, "include cycle: "+filepath.Join(dir, "a.json")+" -> "+filepath.Join(dir, "b.json")+" -> "+filepath.Join(dir, "a.json"))
+
+	_, err = expandConfigTemplate(`{"processors":[{"$include":"../outside.json"}]}`)
+	assert.ErrorContains(t, err, "out of the include dir")
+
+	outside := filepath.Join(t.TempDir(), "outside.json")
+	require.NoError(t, os.WriteFile(outside, []byte(`[]`), 0600))
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "link.json")))
+	_, err = expandConfigTemplate(`{"processors":[{"$include":"link.json"}]}`)
+	assert.ErrorContains(t, err, "out of the include dir")
+
+	_, err = expandConfigTemplate(`{"processor_blocks":{"a":[{"block":"b"}],"b":[{"block":"a"}]},"processors":[{"block":"a"}]}`)
+	assert.ErrorContains(t, err, "processor block cycle: a -> b -> a")
+
+	_, err = expandConfigTemplate(`{"processor_blocks":{},"processors":[{"block":"unknown"}]}`)
+	assert.ErrorContains(t, err, "processor block unknown is not defined")
+}
+
+func TestConfigDetailNotExpanded(t *testing.T) {
+	LogtailGlobalConfig.ConfigEnvPrefixes = []string{"TEST_"}
+	defer func() { LogtailGlobalConfig.ConfigEnvPrefixes = nil }()
+	t.Setenv("TEST_SECRET", "s3cr3t")
+	config := `{"inputs":[{"type":"metric_mock","detail":{"Tags":{"token":"${TEST_SECRET}"}}}],"flushers":[{"type":"flusher_checker"}]}`
+	lc, err := createLogstoreConfig("p", "l", "template_detail", 1, config)
+	require.NoError(t, err)
+	assert.Equal(t, config, lc.configDetail)
+
+	// the hash follows the expanded config, so the config is reloaded when the variable changes
+	t.Setenv("TEST_SECRET", "changed")
+	changed, err := createLogstoreConfig("p", "l", "template_detail", 1, config)
+	require.NoError(t, err)
+	assert.NotEqual(t, lc.configDetailHash, changed.configDetailHash)
+}
@@ -0,0 +1,256 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+// dryRunMode is how the plugins of a config are initialized when the config is created but never loaded.
+type dryRunMode int
+
+const (
+	dryRunNone dryRunMode = iota
+	// dryRunValidate only decodes the inputs and the flushers without calling their Init, since many of them
+	// connect the backends or open the files in Init. The processors are initialized to run the samples.
+	dryRunValidate
+	// dryRunBenchmark decodes the inputs only, the processors, aggregators and flushers are initialized to be
+	// profiled.
+	dryRunBenchmark
+)
+
+// dryRunConfigSeq makes the names of the configs created by ValidateConfig and Benchmark unique,
+// so that they never hit the cached configs of the running ones.
+var dryRunConfigSeq int64
+
+// ValidationIssue is an error or a warning found in validation.
+type ValidationIssue struct {
+	// Plugin is the type of the plugin, or empty for the issues of the whole config.
+	Plugin  string `json:"plugin,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationResult is the result of ValidateConfig.
+type ValidationResult struct {
+	Valid    bool              `json:"valid"`
+	Errors   []ValidationIssue `json:"errors,omitempty"`
+	Warnings []ValidationIssue `json:"warnings,omitempty"`
+	// Outputs are the contents of the sample logs after processors.
+	Outputs []map[string]string `json:"outputs,omitempty"`
+}
+
+func (r *ValidationResult) addError(plugin string, err error) {
+	r.Errors = append(r.Errors, ValidationIssue{Plugin: plugin, Message: err.Error()})
+}
+
+func (r *ValidationResult) addWarning(plugin string, format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, ValidationIssue{Plugin: plugin, Message: fmt.Sprintf(format, args...)})
+}
+
+// ValidateConfig creates the plugins of @jsonStr and passes @samples through the processors. Only the processors
+// are initialized, the inputs and the flushers are validated by decoding their details, see dryRunValidate.
+func ValidateConfig(jsonStr string, samples []map[string]string) *ValidationResult {
+	result := &ValidationResult{}
+	// the original config is created in dry run, which expands the template again
+	expanded, err := expandConfigTemplate(jsonStr)
+	if err != nil {
+		result.addError("", fmt.Errorf("invalid template: %v", err))
+		return result
+	}
+	var plugins map[string]interface{}
+	if err = json.Unmarshal([]byte(expanded), &plugins); err != nil {
+		result.addError("", fmt.Errorf("invalid json: %v", err))
+		return result
+	}
+	checkPluginDetails(plugins, result)
+	if len(result.Errors) > 0 {
+		return result
+	}
+
+	lc, err := createDryRunConfig("validate", jsonStr, dryRunValidate)
+	if err != nil {
+		result.addError("", err)
+		return result
+	}
+	defer stopDryRunConfig(lc)
+	result.Valid = true
+	if len(samples) == 0 {
+		return result
+	}
+	runner, ok := lc.PluginRunner.(*pluginv1Runner)
+	if !ok {
+		result.addWarning("", "samples are only supported by the configs of version v1")
+		return result
+	}
+	logs := make([]*protocol.Log, 0, len(samples))
+	nowTime := uint32(time.Now().Unix())
+	for _, sample := range samples {
+		logs = append(logs, newSampleLog(sample, nowTime))
+	}
+	for _, processor := range runner.ProcessorPlugins {
+		logs = processor.Processor.ProcessLogs(logs)
+	}
+	for _, log := range logs {
+		output := make(map[string]string, len(log.Contents))
+		for _, cont := range log.Contents {
+			output[cont.Key] = cont.Value
+		}
+		result.Outputs = append(result.Outputs, output)
+	}
+	if len(logs) == 0 {
+		result.addWarning("", "all the samples are dropped by processors")
+	}
+	return result
+}
+
+// checkPluginDetails reports the unknown plugins as errors, and the unknown fields of plugins as warnings.
+// The plugins of the branches are checked in the same way.
+func checkPluginDetails(plugins map[string]interface{}, result *ValidationResult) {
+	_, hasFlushers := plugins["flushers"]
+	branches, hasBranches := plugins[pluginBranchesKey].([]interface{})
+	if !hasFlushers && !hasBranches {
+		result.addWarning("", "no flushers, the default flusher is used")
+	}
+	for _, item := range branches {
+		if branch, ok := item.(map[string]interface{}); ok {
+			checkPluginDetails(branch, result)
+		}
+	}
+	for _, section := range []string{"inputs", "processors", "aggregators", "flushers"} {
+		list, _ := plugins[section].([]interface{})
+		for _, item := range list {
+			plugin, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			typeName, _ := plugin["type"].(string)
+			pluginType := getPluginType(typeName)
+			instance := newPluginInstance(section, pluginType)
+			if instance == nil {
+				result.addError(typeName, fmt.Errorf("unknown plugin type in %s", section))
+				continue
+			}
+			detail, ok := plugin["detail"]
+			if !ok || detail == nil {
+				continue
+			}
+			bytesDetail, _ := json.Marshal(detail)
+			decoder := json.NewDecoder(bytes.NewReader(bytesDetail))
+			decoder.DisallowUnknownFields()
+			if err := decoder.Decode(instance); err != nil {
+				if strings.Contains(err.Error(), "unknown field") {
+					result.addWarning(typeName, "%v", err)
+				} else {
+					result.addError(typeName, fmt.Errorf("invalid detail: %v", err))
+				}
+			}
+		}
+	}
+}
+
+func newPluginInstance(section, pluginType string) interface{} {
+	switch section {
+	case "inputs":
+		if creator, ok := pipeline.MetricInputs[pluginType]; ok && strings.HasPrefix(pluginType, "metric_") {
+			return creator()
+		}
+		if creator, ok := pipeline.ServiceInputs[pluginType]; ok && strings.HasPrefix(pluginType, "service_") {
+			return creator()
+		}
+	case "processors":
+		if creator, ok := pipeline.Processors[pluginType]; ok {
+			return creator()
+		}
+	case "aggregators":
+		if creator, ok := pipeline.Aggregators[pluginType]; ok {
+			return creator()
+		}
+	case "flushers":
+		if creator, ok := pipeline.Flushers[pluginType]; ok {
+			return creator()
+		}
+	}
+	return nil
+}
+
+// createDryRunConfig creates a config which is not loaded, the plugins of it are initialized as @mode but
+// never started.
+func createDryRunConfig(prefix, jsonStr string, mode dryRunMode) (*LogstoreConfig, error) {
+	configName := fmt.Sprintf("__%s__#%d", prefix, atomic.AddInt64(&dryRunConfigSeq, 1))
+	return newLogstoreConfig(prefix+"_project", prefix+"_logstore", configName, 0, jsonStr, mode, nil)
+}
+
+// newSampleLog creates a log with the contents of @fields sorted by key.
+func newSampleLog(fields map[string]string, nowTime uint32) *protocol.Log {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	log := &protocol.Log{Time: nowTime, Contents: make([]*protocol.Log_Content, 0, len(keys))}
+	for _, key := range keys {
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: key, Value: fields[key]})
+	}
+	return log
+}
+
+// stopDryRunConfig releases the resources allocated by the Init of the processors, aggregators and flushers.
+// The inputs of the dry run configs are never initialized.
+func stopDryRunConfig(lc *LogstoreConfig) {
+	defer panicRecover(lc.ConfigName)
+	var processors []interface{}
+	var aggregators []interface{}
+	switch r := lc.PluginRunner.(type) {
+	case *pluginv1Runner:
+		for _, processor := range r.ProcessorPlugins {
+			processors = append(processors, processor.Processor)
+		}
+		for _, aggregator := range r.AggregatorPlugins {
+			aggregators = append(aggregators, aggregator.Aggregator)
+		}
+	case *pluginv2Runner:
+		for _, processor := range r.ProcessorPlugins {
+			processors = append(processors, processor)
+		}
+		for _, aggregator := range r.AggregatorPlugins {
+			aggregators = append(aggregators, aggregator)
+		}
+	}
+	for _, processor := range processors {
+		if stoppable, ok := processor.(pipeline.StoppableProcessor); ok {
+			_ = stoppable.Stop()
+		}
+	}
+	for _, aggregator := range aggregators {
+		if stoppable, ok := aggregator.(pipeline.StoppableAggregator); ok {
+			_ = stoppable.Stop()
+		}
+	}
+	for _, flusher := range GetConfigFluhsers(lc.PluginRunner) {
+		_ = flusher.Stop()
+	}
+	for _, branch := range configBranches(lc) {
+		stopDryRunConfig(branch.Config)
+	}
+}
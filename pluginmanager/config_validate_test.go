@@ -0,0 +1,115 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux || windows
+// +build linux windows
+
+package pluginmanager
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+// probeFlusher counts the calls of Init and Stop.
+type probeFlusher struct{}
+
+var probeInits, probeStops int32
+
+func (*probeFlusher) Init(pipeline.Context) error {
+	atomic.AddInt32(&probeInits, 1)
+	return nil
+}
+
+func (*probeFlusher) Description() string {
+	return "probe flusher"
+}
+
+func (*probeFlusher) IsReady(string, string, int64) bool {
+	return true
+}
+
+func (*probeFlusher) SetUrgent(bool) {
+}
+
+func (*probeFlusher) Stop() error {
+	atomic.AddInt32(&probeStops, 1)
+	return nil
+}
+
+func (*probeFlusher) Flush(string, string, string, []*protocol.LogGroup) error {
+	return nil
+}
+
+func init() {
+	pipeline.Flushers["flusher_probe"] = func() pipeline.Flusher {
+		return &probeFlusher{}
+	}
+}
+
+func TestDryRunInit(t *testing.T) {
+	atomic.StoreInt32(&probeInits, 0)
+	atomic.StoreInt32(&probeStops, 0)
+	result := ValidateConfig(`{"inputs": [{"type": "service_mock"}], "flushers": [{"type": "flusher_probe"}]}`, nil)
+	assert.True(t, result.Valid)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&probeInits), "the flushers should not be initialized in validation")
+
+	_, err := Benchmark(`{"flushers": [{"type": "flusher_probe"}]}`, []map[string]string{{"content": "a"}}, BenchmarkOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&probeInits))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&probeStops), "the flushers should be stopped after the benchmark")
+}
+
+func TestValidateConfig(t *testing.T) {
+	result := ValidateConfig(`{
+		"inputs": [{"type": "service_mock", "detail": {"Fields": {"content": "a"}}}],
+		"processors": [{"type": "processor_regex", "detail": {"SourceKey": "content", "Regex": "(\\w+) (\\d+)", "Keys": ["name", "age"]}}],
+		"flushers": [{"type": "flusher_checker"}]
+	}`, []map[string]string{{"content": "tom 18"}})
+	assert.True(t, result.Valid)
+	assert.Empty(t, result.Errors)
+	assert.Empty(t, result.Warnings)
+	assert.Equal(t, []map[string]string{{"name": "tom", "age": "18"}}, result.Outputs)
+	assert.Empty(t, LogtailConfig, "the validated config should not be loaded")
+
+	result = ValidateConfig(`{
+		"inputs": [{"type": "service_mock", "detail": {"Field": {"content": "a"}}}],
+		"processors": [{"type": "processor_unknown"}]
+	}`, nil)
+	assert.False(t, result.Valid)
+	assert.Equal(t, []ValidationIssue{{Plugin: "processor_unknown", Message: "unknown plugin type in processors"}}, result.Errors)
+	if assert.Len(t, result.Warnings, 2) {
+		assert.Equal(t, "no flushers, the default flusher is used", result.Warnings[0].Message)
+		assert.Equal(t, "service_mock", result.Warnings[1].Plugin)
+		assert.Contains(t, result.Warnings[1].Message, `unknown field "Field"`)
+	}
+
+	result = ValidateConfig(`{
+		"inputs": [{"type": "service_mock"}],
+		"processors": [{"type": "processor_regex", "detail": {"Regex": "("}}],
+		"flushers": [{"type": "flusher_checker"}]
+	}`, nil)
+	assert.False(t, result.Valid)
+	assert.Len(t, result.Errors, 1)
+
+	result = ValidateConfig(`{"inputs": [`, nil)
+	assert.False(t, result.Valid)
+	assert.Contains(t, result.Errors[0].Message, "invalid json")
+}
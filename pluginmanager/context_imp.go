@@ -18,7 +18,9 @@ import (
 	"context"
 	"encoding/json"
 	"sync"
+	"time"
 
+	"github.com/alibaba/ilogtail/helper"
 	"github.com/alibaba/ilogtail/pkg"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
@@ -34,6 +36,8 @@ type ContextImp struct {
 	common      *pkg.LogtailContextMeta
 	pluginNames string
 	ctx         context.Context
+	// counterTotals accumulates the counters cleared by MetricSerializeToPB for the self metrics exporter.
+	counterTotals map[string]int64
 }
 
 var contextMutex sync.Mutex
@@ -92,6 +96,27 @@ func (p *ContextImp) RegisterLatencyMetric(metric pipeline.LatencyMetric) {
 	p.LatencyMetrics[metric.Name()] = metric
 }
 
+// copyMetrics copies the registered metrics.
+func (p *ContextImp) copyMetrics() *serviceMetrics {
+	contextMutex.Lock()
+	defer contextMutex.Unlock()
+	metrics := &serviceMetrics{
+		counters:  make(map[string]pipeline.CounterMetric, len(p.CounterMetrics)),
+		strings:   make(map[string]pipeline.StringMetric, len(p.StringMetrics)),
+		latencies: make(map[string]pipeline.LatencyMetric, len(p.LatencyMetrics)),
+	}
+	for name, metric := range p.CounterMetrics {
+		metrics.counters[name] = metric
+	}
+	for name, metric := range p.StringMetrics {
+		metrics.strings[name] = metric
+	}
+	for name, metric := range p.LatencyMetrics {
+		metrics.latencies[name] = metric
+	}
+	return metrics
+}
+
 func (p *ContextImp) MetricSerializeToPB(log *protocol.Log) {
 	if log == nil {
 		return
@@ -105,6 +130,12 @@ func (p *ContextImp) MetricSerializeToPB(log *protocol.Log) {
 	defer contextMutex.Unlock()
 	if p.CounterMetrics != nil {
 		for _, value := range p.CounterMetrics {
+			if _, ok := value.(*helper.AvgMetric); !ok {
+				if p.counterTotals == nil {
+					p.counterTotals = make(map[string]int64)
+				}
+				p.counterTotals[value.Name()] += value.Get()
+			}
 			value.Serialize(log)
 			value.Clear(0)
 		}
@@ -123,6 +154,23 @@ func (p *ContextImp) MetricSerializeToPB(log *protocol.Log) {
 	}
 }
 
+// collectSelfMetrics passes the cumulative values of counters, and the current values of averages and
+// latencies to @fn, without clearing them.
+func (p *ContextImp) collectSelfMetrics(fn func(name string, value float64, kind selfMetricKind)) {
+	contextMutex.Lock()
+	defer contextMutex.Unlock()
+	for name, value := range p.CounterMetrics {
+		if avg, ok := value.(*helper.AvgMetric); ok {
+			fn(name, avg.Peek(), selfMetricGauge)
+			continue
+		}
+		fn(name, float64(p.counterTotals[name]+value.Get()), selfMetricCounter)
+	}
+	for name, value := range p.LatencyMetrics {
+		fn(name, float64(value.Get())/float64(time.Second), selfMetricLatency)
+	}
+}
+
 func (p *ContextImp) SaveCheckPoint(key string, value []byte) error {
 	logger.Debug(p.ctx, "save checkpoint, key", key, "value", string(value))
 	return CheckPointManager.SaveCheckpoint(p.GetConfigName(), key, value)
@@ -149,7 +197,7 @@ func (p *ContextImp) GetCheckPointObject(key string, obj interface{}) (exist boo
 	}
 	err := json.Unmarshal(val, obj)
 	if err != nil {
-		logger.Error(p.ctx, "CHECKPOINT_INVALID_ALARM", "invalid checkpoint, key", key, "val", util.CutString(string(val), 1024), "error", err)
+		logger.Error(p.ctx, util.AlarmCheckpointInvalid, "invalid checkpoint, key", key, "val", util.CutString(string(val), 1024), "error", err)
 		return false
 	}
 	return true
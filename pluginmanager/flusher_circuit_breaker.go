@@ -0,0 +1,330 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	pluginCircuitBreakerKey = "circuit_breaker"
+
+	defaultCircuitFailureThreshold = 5
+	defaultCircuitOpenTimeoutMs    = 30000
+	defaultCircuitNotReadyTimeout  = 60000
+	defaultDeadLetterMaxBytes      = 1024 * 1024 * 1024
+)
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// CircuitBreakerConfig is configured by the optional "circuit_breaker" field of flushers, e.g.
+//
+//	{"type": "flusher_kafka_v2", "circuit_breaker": {"Fallback": {"type": "flusher_http", "detail": {...}}}, "detail": {...}}
+//
+// The circuit opens after FailureThreshold consecutive failures of Flush, or when the flusher is not ready
+// longer than NotReadyTimeoutMs. The open circuit is always ready, so that the other flushers of the config
+// are not blocked, and the data are shed to the Fallback flusher, or the files in DeadLetterDir, or dropped.
+// After OpenTimeoutMs the circuit is half open, the next data are sent to the flusher as the probe,
+// and the circuit is closed if the probe succeeds, otherwise open again.
+// The data failed to be flushed are also shed, so they may be delivered more than once.
+type CircuitBreakerConfig struct {
+	// The consecutive failures to open the circuit, 5 by default.
+	FailureThreshold int
+	// The flusher not ready longer than it is regarded as a failure and opens the circuit, 60000 by default,
+	// and negative means never.
+	NotReadyTimeoutMs int
+	// The time the circuit keeps open before probing the flusher, 30000 by default.
+	OpenTimeoutMs int
+	// The flusher receiving the shed data, which has the type and detail like the flushers of the config.
+	Fallback map[string]interface{}
+	// The directory of the files the shed data are written to in json lines, which is used without the Fallback.
+	DeadLetterDir string
+	// The max bytes of the dead letter file, the shed data are dropped when exceeded, 1GiB by default.
+	DeadLetterMaxBytes int64
+
+	fallback pipeline.FlusherV1
+}
+
+// addCircuitBreaker parses the circuit breaker config, inits the fallback flusher, and puts it into
+// the config passed to PluginRunner.AddPlugin.
+func addCircuitBreaker(config map[string]interface{}, logstoreConfig *LogstoreConfig, breakerInterface interface{}) error {
+	if breakerInterface == nil {
+		return nil
+	}
+	breaker := &CircuitBreakerConfig{}
+	if err := applyPluginConfig(breaker, breakerInterface); err != nil {
+		return fmt.Errorf("invalid circuit breaker config: %v", err)
+	}
+	if breaker.FailureThreshold <= 0 {
+		breaker.FailureThreshold = defaultCircuitFailureThreshold
+	}
+	if breaker.OpenTimeoutMs <= 0 {
+		breaker.OpenTimeoutMs = defaultCircuitOpenTimeoutMs
+	}
+	if breaker.NotReadyTimeoutMs == 0 {
+		breaker.NotReadyTimeoutMs = defaultCircuitNotReadyTimeout
+	}
+	if breaker.DeadLetterMaxBytes <= 0 {
+		breaker.DeadLetterMaxBytes = defaultDeadLetterMaxBytes
+	}
+	if breaker.Fallback != nil {
+		typeName, _ := breaker.Fallback["type"].(string)
+		creator, ok := pipeline.Flushers[getPluginType(typeName)]
+		if !ok || creator == nil {
+			return fmt.Errorf("can't find the fallback flusher %s of circuit breaker", typeName)
+		}
+		fallback := creator()
+		if err := applyPluginConfig(fallback, breaker.Fallback["detail"]); err != nil {
+			return fmt.Errorf("invalid fallback flusher config of circuit breaker: %v", err)
+		}
+		if err := fallback.Init(logstoreConfig.Context); err != nil {
+			return err
+		}
+		if breaker.fallback, ok = fallback.(pipeline.FlusherV1); !ok {
+			return fmt.Errorf("the fallback flusher %s of circuit breaker is not supported in v1 pipeline", typeName)
+		}
+	} else if breaker.DeadLetterDir != "" {
+		if err := os.MkdirAll(breaker.DeadLetterDir, 0750); err != nil {
+			return err
+		}
+	}
+	config[pluginCircuitBreakerKey] = breaker
+	return nil
+}
+
+// circuitBreakerFlusher protects the pipeline from the flusher whose sink is down. It's called by the
+// flusher goroutine of the runner only, so no lock is required.
+type circuitBreakerFlusher struct {
+	pipeline.FlusherV1
+	config     *CircuitBreakerConfig
+	context    pipeline.Context
+	name       string
+	deadLetter string
+
+	state         circuitState
+	failures      int
+	openedAt      time.Time
+	notReadySince time.Time
+	now           func() time.Time
+
+	deadLetterFile  *os.File
+	deadLetterBytes int64
+
+	stateMetric      pipeline.StringMetric
+	openMetric       pipeline.CounterMetric
+	shedMetric       pipeline.CounterMetric
+	droppedMetric    pipeline.CounterMetric
+	deadLetterMetric pipeline.CounterMetric
+}
+
+// newCircuitBreakerFlusher wraps the flusher, name identifies the flusher in the config, e.g. flusher_kafka_v2_0.
+func newCircuitBreakerFlusher(flusher pipeline.FlusherV1, config *CircuitBreakerConfig, context pipeline.Context, name string) *circuitBreakerFlusher {
+	f := &circuitBreakerFlusher{
+		FlusherV1: flusher,
+		config:    config,
+		context:   context,
+		name:      name,
+		now:       time.Now,
+	}
+	if config.fallback == nil && config.DeadLetterDir != "" {
+		f.deadLetter = filepath.Join(config.DeadLetterDir,
+			unsafeFileNameChars.ReplaceAllString(context.GetConfigName()+"_"+name, "_")+".jsonl")
+	}
+	f.stateMetric = helper.NewStringMetricAndRegister(name+"_circuit_state", context)
+	f.openMetric = helper.NewCounterMetricAndRegister(name+"_circuit_open_count", context)
+	f.shedMetric = helper.NewCounterMetricAndRegister(name+"_circuit_shed_log_groups", context)
+	f.droppedMetric = helper.NewCounterMetricAndRegister(name+"_circuit_dropped_log_groups", context)
+	f.deadLetterMetric = helper.NewCounterMetricAndRegister(name+"_circuit_dead_letter_bytes", context)
+	f.stateMetric.Set(circuitClosed.String())
+	return f
+}
+
+func (f *circuitBreakerFlusher) IsReady(projectName string, logstoreName string, logstoreKey int64) bool {
+	if f.state == circuitOpen && f.now().Sub(f.openedAt) >= time.Duration(f.config.OpenTimeoutMs)*time.Millisecond {
+		f.setState(circuitHalfOpen)
+	}
+	if f.state == circuitOpen {
+		return f.shedReady(projectName, logstoreName, logstoreKey)
+	}
+	if f.FlusherV1.IsReady(projectName, logstoreName, logstoreKey) {
+		f.notReadySince = time.Time{}
+		return true
+	}
+	if f.state == circuitHalfOpen {
+		f.trip("the flusher is not ready when probing")
+		return f.shedReady(projectName, logstoreName, logstoreKey)
+	}
+	if f.config.NotReadyTimeoutMs <= 0 {
+		return false
+	}
+	if f.notReadySince.IsZero() {
+		f.notReadySince = f.now()
+		return false
+	}
+	if f.now().Sub(f.notReadySince) < time.Duration(f.config.NotReadyTimeoutMs)*time.Millisecond {
+		return false
+	}
+	f.trip("the flusher is not ready for a long time")
+	return f.shedReady(projectName, logstoreName, logstoreKey)
+}
+
+func (f *circuitBreakerFlusher) Flush(projectName string, logstoreName string, configName string, logGroupList []*protocol.LogGroup) error {
+	if f.state == circuitOpen {
+		return f.shed(projectName, logstoreName, configName, logGroupList)
+	}
+	err := f.FlusherV1.Flush(projectName, logstoreName, configName, logGroupList)
+	if err == nil {
+		if f.state == circuitHalfOpen {
+			logger.Info(f.context.GetRuntimeContext(), "circuit breaker of flusher is closed", f.name)
+			f.setState(circuitClosed)
+		}
+		f.failures = 0
+		return nil
+	}
+	f.failures++
+	if f.state == circuitHalfOpen || f.failures >= f.config.FailureThreshold {
+		f.trip(err.Error())
+	}
+	if f.config.fallback == nil && f.deadLetter == "" {
+		return err
+	}
+	return f.shed(projectName, logstoreName, configName, logGroupList)
+}
+
+func (f *circuitBreakerFlusher) SetUrgent(flag bool) {
+	f.FlusherV1.SetUrgent(flag)
+	if f.config.fallback != nil {
+		f.config.fallback.SetUrgent(flag)
+	}
+}
+
+func (f *circuitBreakerFlusher) Stop() error {
+	err := f.FlusherV1.Stop()
+	if f.config.fallback != nil {
+		if fallbackErr := f.config.fallback.Stop(); err == nil {
+			err = fallbackErr
+		}
+	}
+	if f.deadLetterFile != nil {
+		if closeErr := f.deadLetterFile.Close(); err == nil {
+			err = closeErr
+		}
+		f.deadLetterFile = nil
+	}
+	return err
+}
+
+func (f *circuitBreakerFlusher) trip(reason string) {
+	logger.Warning(f.context.GetRuntimeContext(), util.AlarmCircuitBreaker, "circuit breaker of flusher is open", f.name,
+		"reason", reason, "open timeout ms", f.config.OpenTimeoutMs)
+	f.setState(circuitOpen)
+	f.openedAt = f.now()
+	f.failures = 0
+	f.notReadySince = time.Time{}
+	f.openMetric.Add(1)
+}
+
+func (f *circuitBreakerFlusher) setState(state circuitState) {
+	f.state = state
+	f.stateMetric.Set(state.String())
+}
+
+// shedReady returns whether the shed data could be accepted, only the fallback flusher may be not ready.
+func (f *circuitBreakerFlusher) shedReady(projectName string, logstoreName string, logstoreKey int64) bool {
+	if f.config.fallback != nil {
+		return f.config.fallback.IsReady(projectName, logstoreName, logstoreKey)
+	}
+	return true
+}
+
+func (f *circuitBreakerFlusher) shed(projectName string, logstoreName string, configName string, logGroupList []*protocol.LogGroup) error {
+	f.shedMetric.Add(int64(len(logGroupList)))
+	if f.config.fallback != nil {
+		return f.config.fallback.Flush(projectName, logstoreName, configName, logGroupList)
+	}
+	if f.deadLetter == "" {
+		// neither Fallback nor DeadLetterDir is set, the data are dropped while the circuit is open
+		f.droppedMetric.Add(int64(len(logGroupList)))
+		logger.Warning(f.context.GetRuntimeContext(), util.AlarmCircuitBreaker, "circuit breaker of flusher is open, data dropped", f.name,
+			"log groups", len(logGroupList))
+		return nil
+	}
+	return f.writeDeadLetter(logGroupList)
+}
+
+// writeDeadLetter appends the LogGroups to the dead letter file in json lines.
+func (f *circuitBreakerFlusher) writeDeadLetter(logGroupList []*protocol.LogGroup) error {
+	if f.deadLetterFile == nil {
+		file, err := os.OpenFile(f.deadLetter, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			f.droppedMetric.Add(int64(len(logGroupList)))
+			return err
+		}
+		if stat, err := file.Stat(); err == nil {
+			f.deadLetterBytes = stat.Size()
+		}
+		f.deadLetterFile = file
+	}
+	writer := bufio.NewWriter(f.deadLetterFile)
+	for i, logGroup := range logGroupList {
+		line, err := json.Marshal(logGroup)
+		if err != nil {
+			f.droppedMetric.Add(1)
+			continue
+		}
+		if f.deadLetterBytes+int64(len(line))+1 > f.config.DeadLetterMaxBytes {
+			f.droppedMetric.Add(int64(len(logGroupList) - i))
+			logger.Warning(f.context.GetRuntimeContext(), util.AlarmCircuitBreaker, "dead letter file is full, data dropped", f.deadLetter,
+				"max bytes", f.config.DeadLetterMaxBytes)
+			break
+		}
+		_, _ = writer.Write(line)
+		_ = writer.WriteByte('\n')
+		f.deadLetterBytes += int64(len(line)) + 1
+		f.deadLetterMetric.Add(int64(len(line)) + 1)
+	}
+	return writer.Flush()
+}
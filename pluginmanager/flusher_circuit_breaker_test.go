@@ -0,0 +1,144 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/flusher/checker"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+// brokenFlusher fails to flush when broken, and is not ready when unready.
+type brokenFlusher struct {
+	checker.FlusherChecker
+	broken  bool
+	unready bool
+}
+
+func (f *brokenFlusher) IsReady(projectName string, logstoreName string, logstoreKey int64) bool {
+	return !f.unready
+}
+
+func (f *brokenFlusher) Flush(projectName string, logstoreName string, configName string, logGroupList []*protocol.LogGroup) error {
+	if f.broken {
+		return errors.New("sink is down")
+	}
+	return f.FlusherChecker.Flush(projectName, logstoreName, configName, logGroupList)
+}
+
+func newCircuitLogGroups(value string) []*protocol.LogGroup {
+	return []*protocol.LogGroup{{Logs: []*protocol.Log{{Contents: []*protocol.Log_Content{{Key: "content", Value: value}}}}}}
+}
+
+func TestCircuitBreakerFallback(t *testing.T) {
+	ctx := mock.NewEmptyContext("p", "l", "c")
+	config := map[string]interface{}{}
+	require.NoError(t, addCircuitBreaker(config, &LogstoreConfig{Context: ctx}, map[string]interface{}{
+		"FailureThreshold": 2,
+		"OpenTimeoutMs":    1000,
+		"Fallback":         map[string]interface{}{"type": "flusher_checker"},
+	}))
+	breaker := config[pluginCircuitBreakerKey].(*CircuitBreakerConfig)
+	assert.Equal(t, defaultCircuitNotReadyTimeout, breaker.NotReadyTimeoutMs)
+	fallback := breaker.fallback.(*checker.FlusherChecker)
+	primary := &brokenFlusher{}
+	require.NoError(t, primary.Init(ctx))
+	flusher := newCircuitBreakerFlusher(primary, breaker, ctx, "flusher_mock_0")
+	now := time.Now()
+	flusher.now = func() time.Time { return now }
+
+	require.True(t, flusher.IsReady("p", "l", 0))
+	require.NoError(t, flusher.Flush("p", "l", "c", newCircuitLogGroups("a")))
+	assert.Equal(t, 1, primary.GetLogCount())
+
+	// the failed data are shed to the fallback, and the circuit opens after 2 consecutive failures
+	primary.broken = true
+	require.NoError(t, flusher.Flush("p", "l", "c", newCircuitLogGroups("b")))
+	assert.Equal(t, circuitClosed, flusher.state)
+	require.NoError(t, flusher.Flush("p", "l", "c", newCircuitLogGroups("c")))
+	assert.Equal(t, circuitOpen, flusher.state)
+	assert.Equal(t, 2, fallback.GetLogCount())
+
+	// the open circuit is ready even if the flusher is not
+	primary.unready = true
+	require.True(t, flusher.IsReady("p", "l", 0))
+	require.NoError(t, flusher.Flush("p", "l", "c", newCircuitLogGroups("d")))
+	assert.Equal(t, 3, fallback.GetLogCount())
+
+	// the probe fails when the flusher is still unready
+	now = now.Add(time.Second)
+	require.True(t, flusher.IsReady("p", "l", 0))
+	assert.Equal(t, circuitOpen, flusher.state)
+
+	// the probe succeeds and closes the circuit
+	primary.unready, primary.broken = false, false
+	now = now.Add(time.Second)
+	require.True(t, flusher.IsReady("p", "l", 0))
+	assert.Equal(t, circuitHalfOpen, flusher.state)
+	require.NoError(t, flusher.Flush("p", "l", "c", newCircuitLogGroups("e")))
+	assert.Equal(t, circuitClosed, flusher.state)
+	assert.Equal(t, 2, primary.GetLogCount())
+	require.NoError(t, flusher.Stop())
+}
+
+func TestCircuitBreakerDeadLetter(t *testing.T) {
+	ctx := mock.NewEmptyContext("p", "l", "c/1")
+	dir := t.TempDir()
+	config := map[string]interface{}{}
+	require.NoError(t, addCircuitBreaker(config, &LogstoreConfig{Context: ctx}, map[string]interface{}{
+		"NotReadyTimeoutMs":  100,
+		"DeadLetterDir":      dir,
+		"DeadLetterMaxBytes": 250,
+	}))
+	primary := &brokenFlusher{unready: true}
+	require.NoError(t, primary.Init(ctx))
+	flusher := newCircuitBreakerFlusher(primary, config[pluginCircuitBreakerKey].(*CircuitBreakerConfig), ctx, "flusher_mock_0")
+	now := time.Now()
+	flusher.now = func() time.Time { return now }
+
+	// the circuit opens when the flusher is not ready longer than the timeout
+	assert.False(t, flusher.IsReady("p", "l", 0))
+	now = now.Add(100 * time.Millisecond)
+	assert.True(t, flusher.IsReady("p", "l", 0))
+	assert.Equal(t, circuitOpen, flusher.state)
+	require.NoError(t, flusher.Flush("p", "l", "c", append(newCircuitLogGroups("a"), newCircuitLogGroups("b")...)))
+	require.NoError(t, flusher.Flush("p", "l", "c", newCircuitLogGroups("c")))
+	require.NoError(t, flusher.Stop())
+
+	content, err := os.ReadFile(filepath.Join(dir, "c_1_flusher_mock_0.jsonl"))
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2, "the data exceeding the max bytes should be dropped")
+	assert.Contains(t, lines[0], `"Value":"a"`)
+	assert.Contains(t, lines[1], `"Value":"b"`)
+}
+
+func TestCircuitBreakerInvalidConfig(t *testing.T) {
+	ctx := mock.NewEmptyContext("p", "l", "c")
+	assert.Error(t, addCircuitBreaker(map[string]interface{}{}, &LogstoreConfig{Context: ctx}, map[string]interface{}{
+		"Fallback": map[string]interface{}{"type": "flusher_not_exist"},
+	}))
+	assert.Error(t, addCircuitBreaker(map[string]interface{}{}, &LogstoreConfig{Context: ctx}, "invalid"))
+}
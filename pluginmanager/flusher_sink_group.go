@@ -0,0 +1,335 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	pluginSinkKey = "sink"
+
+	sinkModePrimary   = "primary"
+	sinkModeSecondary = "secondary"
+	sinkModeMirror    = "mirror"
+	sinkModeShadow    = "shadow"
+
+	defaultFailbackIntervalSec = 30
+	defaultShadowQueueSize     = 16
+)
+
+// SinkConfig is configured by the optional "sink" field of flushers, which works across the flushers of a config:
+//
+//   - primary and secondary: the flushers with the same Group are a failover group, the data are flushed to the
+//     first ready member, the primaries first and then the secondaries in the order of the config. A member failing
+//     to flush is skipped for FailbackIntervalSec unless all the members fail, and the data are flushed to the next one.
+//   - mirror: the flusher only receives Percentage of the LogGroups, e.g. to validate a new storage with part of the data.
+//   - shadow: like mirror, but the data are flushed asynchronously by a queue of QueueSize batches, and dropped
+//     when the queue is full or the flusher is not ready. The errors are ignored, so that the other flushers are
+//     never affected.
+//
+// For example, migrating from kafka to pulsar with the shadow traffic:
+//
+//	{"type": "flusher_kafka_v2", "sink": {"Group": "mq", "Mode": "primary"}, "detail": {...}}
+//	{"type": "flusher_kafka", "sink": {"Group": "mq", "Mode": "secondary"}, "detail": {...}}
+//	{"type": "flusher_pulsar", "sink": {"Mode": "shadow", "Percentage": 10}, "detail": {...}}
+type SinkConfig struct {
+	// The name of the failover group, required by the primary and secondary mode.
+	Group string
+	// The mode of the flusher, which could be primary, secondary, mirror or shadow.
+	Mode string
+	// The percentage of the LogGroups flushed in the mirror and shadow mode, 100 by default.
+	Percentage float64
+	// The time a failed member is skipped in the failover group, 30 by default. All the members of a group
+	// must have the same interval.
+	FailbackIntervalSec int
+	// The max count of the batches of LogGroups queued for the shadow flusher, 16 by default.
+	QueueSize int
+}
+
+// addSink parses the sink config and puts it into the config passed to PluginRunner.AddPlugin.
+func addSink(config map[string]interface{}, sinkInterface interface{}) error {
+	if sinkInterface == nil {
+		return nil
+	}
+	sink := &SinkConfig{}
+	if err := applyPluginConfig(sink, sinkInterface); err != nil {
+		return fmt.Errorf("invalid sink config: %v", err)
+	}
+	sink.Mode = strings.ToLower(sink.Mode)
+	switch sink.Mode {
+	case sinkModePrimary, sinkModeSecondary:
+		if sink.Group == "" {
+			return fmt.Errorf("must specify Group of the %s sink", sink.Mode)
+		}
+		if sink.FailbackIntervalSec <= 0 {
+			sink.FailbackIntervalSec = defaultFailbackIntervalSec
+		}
+	case sinkModeMirror, sinkModeShadow:
+		if sink.Percentage == 0 {
+			sink.Percentage = 100
+		}
+		if sink.Percentage < 0 || sink.Percentage > 100 {
+			return fmt.Errorf("invalid Percentage %v of the %s sink", sink.Percentage, sink.Mode)
+		}
+		if sink.Mode == sinkModeShadow && sink.QueueSize <= 0 {
+			sink.QueueSize = defaultShadowQueueSize
+		}
+	default:
+		return fmt.Errorf("invalid sink mode %q", sink.Mode)
+	}
+	config[pluginSinkKey] = sink
+	return nil
+}
+
+// shadowBatch is the LogGroups queued for the shadow flusher.
+type shadowBatch struct {
+	projectName  string
+	logstoreName string
+	configName   string
+	logGroupList []*protocol.LogGroup
+}
+
+// mirroredFlusher flushes the percentage of the LogGroups to the flusher. The LogGroups are sampled
+// evenly by the accumulated credit, so the result is deterministic.
+type mirroredFlusher struct {
+	pipeline.FlusherV1
+	context    pipeline.Context
+	name       string
+	percentage float64
+	shadow     bool
+	credit     float64
+	// the queue of the shadow flusher, which is consumed by its own goroutine
+	queue chan *shadowBatch
+	wg    sync.WaitGroup
+
+	droppedMetric pipeline.CounterMetric
+	errorMetric   pipeline.CounterMetric
+}
+
+func newMirroredFlusher(flusher pipeline.FlusherV1, sink *SinkConfig, context pipeline.Context, name string) *mirroredFlusher {
+	f := &mirroredFlusher{
+		FlusherV1:  flusher,
+		context:    context,
+		name:       name,
+		percentage: sink.Percentage,
+		shadow:     sink.Mode == sinkModeShadow,
+	}
+	if f.shadow {
+		f.droppedMetric = helper.NewCounterMetricAndRegister(name+"_shadow_dropped_log_groups", context)
+		f.errorMetric = helper.NewCounterMetricAndRegister(name+"_shadow_errors", context)
+		f.queue = make(chan *shadowBatch, sink.QueueSize)
+		f.wg.Add(1)
+		go f.runShadow()
+	}
+	return f
+}
+
+// IsReady of the shadow flusher is always true, and the data are dropped if it's not ready.
+func (f *mirroredFlusher) IsReady(projectName string, logstoreName string, logstoreKey int64) bool {
+	return f.shadow || f.FlusherV1.IsReady(projectName, logstoreName, logstoreKey)
+}
+
+func (f *mirroredFlusher) Flush(projectName string, logstoreName string, configName string, logGroupList []*protocol.LogGroup) error {
+	logGroupList = f.sample(logGroupList)
+	if len(logGroupList) == 0 {
+		return nil
+	}
+	if !f.shadow {
+		return f.FlusherV1.Flush(projectName, logstoreName, configName, logGroupList)
+	}
+	// the LogGroups are shared with the other flushers flushing them at the same time, so the copies are queued
+	batch := &shadowBatch{projectName: projectName, logstoreName: logstoreName, configName: configName,
+		logGroupList: make([]*protocol.LogGroup, len(logGroupList))}
+	for i, logGroup := range logGroupList {
+		batch.logGroupList[i] = protocol.CloneLogGroup(logGroup)
+	}
+	select {
+	case f.queue <- batch:
+	default:
+		f.droppedMetric.Add(int64(len(logGroupList)))
+	}
+	return nil
+}
+
+// runShadow flushes the queued batches until the queue is closed by Stop.
+func (f *mirroredFlusher) runShadow() {
+	defer f.wg.Done()
+	for batch := range f.queue {
+		if !f.FlusherV1.IsReady(batch.projectName, batch.logstoreName, 0) {
+			f.droppedMetric.Add(int64(len(batch.logGroupList)))
+			continue
+		}
+		if err := f.FlusherV1.Flush(batch.projectName, batch.logstoreName, batch.configName, batch.logGroupList); err != nil {
+			f.errorMetric.Add(1)
+			logger.Debug(f.context.GetRuntimeContext(), "shadow flusher error is ignored", f.name, "error", err)
+		}
+	}
+}
+
+// Stop flushes the queued batches of the shadow flusher before stopping it.
+func (f *mirroredFlusher) Stop() error {
+	if f.shadow {
+		close(f.queue)
+		f.wg.Wait()
+	}
+	return f.FlusherV1.Stop()
+}
+
+func (f *mirroredFlusher) sample(logGroupList []*protocol.LogGroup) []*protocol.LogGroup {
+	if f.percentage >= 100 {
+		return logGroupList
+	}
+	var result []*protocol.LogGroup
+	for _, logGroup := range logGroupList {
+		f.credit += f.percentage / 100
+		if f.credit >= 1 {
+			f.credit--
+			result = append(result, logGroup)
+		}
+	}
+	return result
+}
+
+type sinkGroupMember struct {
+	flusher  pipeline.FlusherV1
+	name     string
+	failedAt time.Time
+}
+
+// sinkGroupFlusher is the failover group of the flushers, which is added to the runner as one flusher.
+// It's called by the flusher goroutine of the runner only, so no lock is required.
+type sinkGroupFlusher struct {
+	context          pipeline.Context
+	name             string
+	failbackInterval time.Duration
+	primaries        []*sinkGroupMember
+	secondaries      []*sinkGroupMember
+	active           *sinkGroupMember
+	now              func() time.Time
+
+	failoverMetric pipeline.CounterMetric
+	activeMetric   pipeline.StringMetric
+}
+
+func newSinkGroupFlusher(context pipeline.Context, name string, failbackIntervalSec int) *sinkGroupFlusher {
+	g := &sinkGroupFlusher{
+		context:          context,
+		name:             name,
+		failbackInterval: time.Duration(failbackIntervalSec) * time.Second,
+		now:              time.Now,
+	}
+	g.failoverMetric = helper.NewCounterMetricAndRegister("sink_group_"+name+"_failover_count", context)
+	g.activeMetric = helper.NewStringMetricAndRegister("sink_group_"+name+"_active", context)
+	return g
+}
+
+// add adds the flusher to the group, name identifies the flusher in the config, e.g. flusher_kafka_v2_0.
+func (g *sinkGroupFlusher) add(flusher pipeline.FlusherV1, name string, mode string) {
+	member := &sinkGroupMember{flusher: flusher, name: name}
+	if mode == sinkModePrimary {
+		g.primaries = append(g.primaries, member)
+	} else {
+		g.secondaries = append(g.secondaries, member)
+	}
+}
+
+// candidates returns the members in order, the ones failed recently are moved to the end.
+func (g *sinkGroupFlusher) candidates() []*sinkGroupMember {
+	members := make([]*sinkGroupMember, 0, len(g.primaries)+len(g.secondaries))
+	var failed []*sinkGroupMember
+	now := g.now()
+	for _, list := range [][]*sinkGroupMember{g.primaries, g.secondaries} {
+		for _, member := range list {
+			if !member.failedAt.IsZero() && now.Sub(member.failedAt) < g.failbackInterval {
+				failed = append(failed, member)
+			} else {
+				members = append(members, member)
+			}
+		}
+	}
+	return append(members, failed...)
+}
+
+func (g *sinkGroupFlusher) Init(pipeline.Context) error {
+	return nil
+}
+
+func (g *sinkGroupFlusher) Description() string {
+	return "sink group " + g.name
+}
+
+func (g *sinkGroupFlusher) IsReady(projectName string, logstoreName string, logstoreKey int64) bool {
+	for _, member := range g.candidates() {
+		if member.flusher.IsReady(projectName, logstoreName, logstoreKey) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *sinkGroupFlusher) Flush(projectName string, logstoreName string, configName string, logGroupList []*protocol.LogGroup) error {
+	var err error
+	for _, member := range g.candidates() {
+		if !member.flusher.IsReady(projectName, logstoreName, 0) {
+			continue
+		}
+		if err = member.flusher.Flush(projectName, logstoreName, configName, logGroupList); err != nil {
+			member.failedAt = g.now()
+			logger.Warning(g.context.GetRuntimeContext(), util.AlarmSinkGroup, "flusher of sink group fails", g.name,
+				"flusher", member.name, "error", err)
+			continue
+		}
+		member.failedAt = time.Time{}
+		if g.active != member {
+			if g.active != nil {
+				g.failoverMetric.Add(1)
+				logger.Info(g.context.GetRuntimeContext(), "sink group switches to flusher", member.name, "group", g.name)
+			}
+			g.active = member
+			g.activeMetric.Set(member.name)
+		}
+		return nil
+	}
+	if err == nil {
+		err = fmt.Errorf("no flusher of sink group %s is ready", g.name)
+	}
+	return err
+}
+
+func (g *sinkGroupFlusher) SetUrgent(flag bool) {
+	for _, member := range g.candidates() {
+		member.flusher.SetUrgent(flag)
+	}
+}
+
+func (g *sinkGroupFlusher) Stop() error {
+	var err error
+	for _, member := range g.candidates() {
+		if stopErr := member.flusher.Stop(); stopErr != nil && err == nil {
+			err = stopErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,156 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+func TestAddSink(t *testing.T) {
+	config := map[string]interface{}{}
+	require.NoError(t, addSink(config, map[string]interface{}{"Mode": "Shadow"}))
+	assert.Equal(t, &SinkConfig{Mode: sinkModeShadow, Percentage: 100, QueueSize: defaultShadowQueueSize}, config[pluginSinkKey])
+	require.NoError(t, addSink(config, map[string]interface{}{"Group": "g", "Mode": "primary"}))
+	assert.Equal(t, &SinkConfig{Group: "g", Mode: sinkModePrimary, FailbackIntervalSec: defaultFailbackIntervalSec}, config[pluginSinkKey])
+
+	assert.Error(t, addSink(config, map[string]interface{}{"Mode": "secondary"}))
+	assert.Error(t, addSink(config, map[string]interface{}{"Mode": "mirror", "Percentage": 120}))
+	assert.Error(t, addSink(config, map[string]interface{}{"Mode": "unknown"}))
+}
+
+func TestMirroredFlusher(t *testing.T) {
+	ctx := mock.NewEmptyContext("p", "l", "c")
+	mirror := &brokenFlusher{}
+	require.NoError(t, mirror.Init(ctx))
+	flusher := newMirroredFlusher(mirror, &SinkConfig{Mode: sinkModeMirror, Percentage: 25}, ctx, "flusher_mock_0")
+	for i := 0; i < 8; i++ {
+		require.NoError(t, flusher.Flush("p", "l", "c", newCircuitLogGroups("a")))
+	}
+	assert.Equal(t, 2, mirror.GetLogCount())
+	mirror.unready = true
+	assert.False(t, flusher.IsReady("p", "l", 0))
+
+	// the shadow flusher never blocks or fails the pipeline, and the queued data are flushed when stopped
+	shadow := newMirroredFlusher(mirror, &SinkConfig{Mode: sinkModeShadow, Percentage: 100, QueueSize: 4}, ctx, "flusher_mock_1")
+	assert.True(t, shadow.IsReady("p", "l", 0))
+	require.NoError(t, shadow.Flush("p", "l", "c", newCircuitLogGroups("a")))
+	require.NoError(t, shadow.Stop())
+	assert.Equal(t, int64(1), shadow.droppedMetric.Get())
+	mirror.unready, mirror.broken = false, true
+	shadow = newMirroredFlusher(mirror, &SinkConfig{Mode: sinkModeShadow, Percentage: 100, QueueSize: 4}, ctx, "flusher_mock_2")
+	require.NoError(t, shadow.Flush("p", "l", "c", newCircuitLogGroups("a")))
+	require.NoError(t, shadow.Stop())
+	assert.Equal(t, int64(1), shadow.errorMetric.Get())
+	mirror.broken = false
+	shadow = newMirroredFlusher(mirror, &SinkConfig{Mode: sinkModeShadow, Percentage: 100, QueueSize: 4}, ctx, "flusher_mock_3")
+	require.NoError(t, shadow.Flush("p", "l", "c", newCircuitLogGroups("a")))
+	require.NoError(t, shadow.Stop())
+	assert.Equal(t, 3, mirror.GetLogCount())
+}
+
+// blockedFlusher blocks in Flush until released.
+type blockedFlusher struct {
+	brokenFlusher
+	release chan struct{}
+}
+
+func (f *blockedFlusher) Flush(projectName string, logstoreName string, configName string, logGroupList []*protocol.LogGroup) error {
+	<-f.release
+	return f.brokenFlusher.Flush(projectName, logstoreName, configName, logGroupList)
+}
+
+func TestShadowFlusherQueueFull(t *testing.T) {
+	ctx := mock.NewEmptyContext("p", "l", "c")
+	blocked := &blockedFlusher{release: make(chan struct{})}
+	require.NoError(t, blocked.Init(ctx))
+	shadow := newMirroredFlusher(blocked, &SinkConfig{Mode: sinkModeShadow, Percentage: 100, QueueSize: 1}, ctx, "flusher_mock_0")
+	for i := 0; i < 3; i++ {
+		require.NoError(t, shadow.Flush("p", "l", "c", newCircuitLogGroups("a")))
+	}
+	// at most one batch is being flushed and one is queued, so the others are dropped without blocking
+	assert.GreaterOrEqual(t, shadow.droppedMetric.Get(), int64(1))
+	close(blocked.release)
+	require.NoError(t, shadow.Stop())
+	assert.Equal(t, int64(3), int64(blocked.GetLogCount())+shadow.droppedMetric.Get())
+}
+
+func TestSinkGroupFailover(t *testing.T) {
+	ctx := mock.NewEmptyContext("p", "l", "c")
+	primary, secondary := &brokenFlusher{}, &brokenFlusher{}
+	require.NoError(t, primary.Init(ctx))
+	require.NoError(t, secondary.Init(ctx))
+	group := newSinkGroupFlusher(ctx, "g", 10)
+	group.add(secondary, "flusher_mock_0", sinkModeSecondary)
+	group.add(primary, "flusher_mock_1", sinkModePrimary)
+	now := time.Now()
+	group.now = func() time.Time { return now }
+
+	require.NoError(t, group.Flush("p", "l", "c", newCircuitLogGroups("a")))
+	assert.Equal(t, 1, primary.GetLogCount(), "the primary should be used first")
+
+	// the data are flushed to the secondary when the primary fails, and the primary is skipped for a while
+	primary.broken = true
+	require.NoError(t, group.Flush("p", "l", "c", newCircuitLogGroups("b")))
+	primary.broken = false
+	require.NoError(t, group.Flush("p", "l", "c", newCircuitLogGroups("c")))
+	assert.Equal(t, 2, secondary.GetLogCount())
+	assert.Equal(t, "flusher_mock_0", group.active.name)
+
+	// fail back to the primary after the interval
+	now = now.Add(10 * time.Second)
+	require.NoError(t, group.Flush("p", "l", "c", newCircuitLogGroups("d")))
+	assert.Equal(t, 2, primary.GetLogCount())
+
+	// the unready members are skipped, and the group fails when all the members fail
+	primary.unready = true
+	assert.True(t, group.IsReady("p", "l", 0))
+	secondary.broken = true
+	assert.Error(t, group.Flush("p", "l", "c", newCircuitLogGroups("e")))
+	secondary.unready = true
+	assert.False(t, group.IsReady("p", "l", 0))
+	require.NoError(t, group.Stop())
+}
+
+func TestSinkGroupConfig(t *testing.T) {
+	config := `{"inputs":[{"type":"metric_mock"}],"flushers":[` +
+		`{"type":"flusher_checker","sink":{"Group":"g","Mode":"secondary"}},` +
+		`{"type":"flusher_checker","sink":{"Group":"g","Mode":"primary"}},` +
+		`{"type":"flusher_checker","sink":{"Mode":"shadow","Percentage":10}}]}`
+	lc, err := createLogstoreConfig("p", "l", "sink", 1, config)
+	require.NoError(t, err)
+	runner := lc.PluginRunner.(*pluginv1Runner)
+	require.Len(t, runner.FlusherPlugins, 2)
+	group := runner.FlusherPlugins[0].Flusher.(*sinkGroupFlusher)
+	require.Len(t, group.primaries, 1)
+	assert.Equal(t, "flusher_checker_1", group.primaries[0].name)
+	assert.Equal(t, "flusher_checker_0", group.secondaries[0].name)
+	assert.IsType(t, &mirroredFlusher{}, runner.FlusherPlugins[1].Flusher)
+
+	_, err = createLogstoreConfig("p", "l", "sink", 1, `{"inputs":[{"type":"metric_mock"}],"flushers":[{"type":"flusher_checker","sink":{"Mode":"primary"}}]}`)
+	assert.Error(t, err)
+
+	// all the members of a group must have the same failback interval
+	_, err = createLogstoreConfig("p", "l", "sink", 1, `{"inputs":[{"type":"metric_mock"}],"flushers":[`+
+		`{"type":"flusher_checker","sink":{"Group":"g","Mode":"primary","FailbackIntervalSec":10}},`+
+		`{"type":"flusher_checker","sink":{"Group":"g","Mode":"secondary"}}]}`)
+	assert.Error(t, err)
+}
@@ -26,4 +26,5 @@ type FlusherWrapper struct {
 	Config        *LogstoreConfig
 	LogGroupsChan chan *protocol.LogGroup
 	Interval      time.Duration
+	Audit         *AuditPluginCount
 }
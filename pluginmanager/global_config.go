@@ -33,11 +33,51 @@ type GlobalConfig struct {
 	Tags                     map[string]string
 	// Directory to store logtail data, such as checkpoint, etc.
 	LogtailSysConfDir string
+	// Directory of the files included by the plugin configs, the relative include paths are relative to it
+	// and the files out of it could not be included. LogtailSysConfDir if empty.
+	ConfigIncludeDir string
+	// Prefixes of the environment variables which could be referenced by ${NAME} in the plugin configs,
+	// the references are kept as is if empty. Only the global config of logtail takes effect.
+	ConfigEnvPrefixes []string
 	// Network identification from logtail.
 	HostIP       string
 	Hostname     string
 	AlwaysOnline bool
 	DelayStopSec int
+	// The number of goroutines running processors, logs are processed in order only when it is 1.
+	// Each goroutine has its own instances of the processors, so the states of the processors are kept per goroutine.
+	ProcessorConcurrency int
+	// Logs with the same value of OrderedKey are processed in order by the same goroutine when ProcessorConcurrency
+	// is greater than 1. The value is looked up in the context first, e.g. "source" is the file of file logs,
+	// and then in the contents of log.
+	OrderedKey string
+	// The service inputs run only on the agent holding the lock when it's set, see HotStandbyConfig.
+	HotStandby *HotStandbyConfig
+	// The tenant owning the config, whose data is tagged with __tenant__ and whose self metrics are labeled
+	// with tenant, and the quota of the tenant applies to the config. It's derived from the project of the config
+	// by TenantProjects, the value set by the config itself is ignored.
+	Tenant string
+	// The tenants of the configs by the projects, which are decided by the source delivering the configs, so
+	// that a config could neither claim another tenant nor escape from the quota of its tenant.
+	// Only the global config of the agent takes effect.
+	TenantProjects map[string]string
+	// The quotas of the tenants by the name, "*" is for the tenants without their own quotas and the configs
+	// without tenant. Only the global config of the agent takes effect.
+	TenantQuotas map[string]*TenantQuota
+	// The priority class of the config, "high", "normal" or "low", "normal" by default. The data of the lower
+	// priority configs are shed first when the agent is under pressure, see PrioritySheddingConfig.
+	Priority string
+	// Enables shedding the data by the priorities of the configs. Only the global config of the agent takes effect.
+	PriorityShedding *PrioritySheddingConfig
+	// Detects the cloud resource of the agent and tags the data with it, see ResourceDetectionConfig.
+	// Only the global config of the agent takes effect.
+	ResourceDetection *ResourceDetectionConfig
+	// Counts the events passing each stage of the config and reports the reconciliation every
+	// AuditReportIntervalSec seconds, see pipelineAuditor.
+	Audit                  bool
+	AuditReportIntervalSec int
+	// Decides which timestamp of the logs wins after the processors, see TimestampPolicyConfig.
+	TimestampPolicy *TimestampPolicyConfig
 }
 
 // LogtailGlobalConfig is the singleton instance of GlobalConfig.
@@ -53,7 +93,7 @@ func LoadGlobalConfig(jsonStr string) int {
 		logger.Info(context.Background(), "load global config", jsonStr)
 		if len(jsonStr) >= 2 { // For invalid JSON, use default value and return 0
 			if err := json.Unmarshal([]byte(jsonStr), &LogtailGlobalConfig); err != nil {
-				logger.Error(context.Background(), "LOAD_PLUGIN_ALARM", "load global config error", err)
+				logger.Error(context.Background(), util.AlarmLoadPlugin, "load global config error", err)
 				rst = 1
 			} else {
 				// Update when both of them are not empty.
@@ -77,6 +117,8 @@ func newGlobalConfig() (cfg GlobalConfig) {
 		DefaultLogGroupQueueSize: 4,
 		LogtailSysConfDir:        ".",
 		DelayStopSec:             300,
+		ProcessorConcurrency:     1,
+		AuditReportIntervalSec:   300,
 	}
 	return
 }
@@ -0,0 +1,253 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	hotStandbyLease = "lease"
+	hotStandbyFile  = "file"
+)
+
+// HotStandbyConfig makes the service inputs of a config run only on the agent holding the lock, so that
+// two agents could run the inputs that can't be scaled horizontally as active and standby, such as a
+// syslog server behind a VIP or a single partition puller.
+type HotStandbyConfig struct {
+	// Lock is "lease" to elect by a Kubernetes Lease, or "file" to elect by a file lock on the shared storage.
+	Lock string
+	// Name is the name of the lease or the path of the lock file, the config name by default.
+	// The relative path is under LogtailSysConfDir.
+	Name string
+	// Namespace of the lease, the namespace of the agent pod by default.
+	Namespace string
+	// KubeConfigPath is the path of the kubeconfig, the in-cluster config is used when it's empty.
+	KubeConfigPath string
+	// Identity of the agent in the election, the hostname by default.
+	Identity         string
+	LeaseDurationSec int
+	RenewDeadlineSec int
+	RetryPeriodSec   int
+}
+
+// standbyElector campaigns for the leadership repeatedly.
+type standbyElector interface {
+	// run blocks until @ctx is done, @onStarted is called when becoming the leader with a context
+	// canceled when the leadership is lost, and @onStopped is called after losing the leadership.
+	run(ctx context.Context, onStarted func(context.Context), onStopped func())
+}
+
+// hotStandby starts the service inputs of a config when the agent becomes the leader, and stops them
+// when the leadership is lost. The services are started again when the leadership is regained, so
+// they should support restarting after Stop.
+type hotStandby struct {
+	config  *LogstoreConfig
+	elector standbyElector
+
+	lock    sync.Mutex
+	leading bool
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+func newHotStandby(lc *LogstoreConfig, cfg HotStandbyConfig) (*hotStandby, error) {
+	if cfg.Name == "" {
+		cfg.Name = lc.ConfigName
+	}
+	if cfg.Identity == "" {
+		cfg.Identity = util.GetHostName()
+	}
+	if cfg.LeaseDurationSec <= 0 {
+		cfg.LeaseDurationSec = 15
+	}
+	if cfg.RenewDeadlineSec <= 0 {
+		cfg.RenewDeadlineSec = 10
+	}
+	if cfg.RetryPeriodSec <= 0 {
+		cfg.RetryPeriodSec = 2
+	}
+	h := &hotStandby{config: lc}
+	var err error
+	switch cfg.Lock {
+	case hotStandbyLease:
+		h.elector, err = newLeaseElector(&cfg)
+	case hotStandbyFile:
+		path := cfg.Name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(lc.GlobalConfig.LogtailSysConfDir, path)
+		}
+		h.elector = &fileElector{path: path, retryPeriod: time.Duration(cfg.RetryPeriodSec) * time.Second}
+	default:
+		err = fmt.Errorf("unknown hot standby lock %q, should be %q or %q", cfg.Lock, hotStandbyLease, hotStandbyFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// run campaigns in background, calling @start when becoming the leader and @stop when losing it.
+func (h *hotStandby) run(start, stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+	h.done = make(chan struct{})
+	logger.Info(h.config.Context.GetRuntimeContext(), "hot standby", "campaign")
+	go func() {
+		defer close(h.done)
+		defer panicRecover("hot standby")
+		h.elector.run(ctx, func(leadingCtx context.Context) {
+			h.lock.Lock()
+			defer h.lock.Unlock()
+			// The callback may be delayed until the leadership is lost.
+			if h.leading || leadingCtx.Err() != nil {
+				return
+			}
+			h.leading = true
+			logger.Warning(h.config.Context.GetRuntimeContext(), util.AlarmHotStandby, "become the leader, start the service inputs")
+			start()
+		}, func() {
+			h.lock.Lock()
+			defer h.lock.Unlock()
+			if !h.leading {
+				return
+			}
+			h.leading = false
+			if ctx.Err() == nil {
+				logger.Warning(h.config.Context.GetRuntimeContext(), util.AlarmHotStandby, "lose the leadership, stop the service inputs")
+			}
+			stop()
+		})
+	}()
+}
+
+// stop quits the campaign, the service inputs are stopped if leading.
+func (h *hotStandby) stop() {
+	if h == nil || h.cancel == nil {
+		return
+	}
+	h.cancel()
+	<-h.done
+	h.cancel = nil
+}
+
+// isLeader tells if the service inputs are running.
+func (h *hotStandby) isLeader() bool {
+	if h == nil {
+		return true
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.leading
+}
+
+type leaseElector struct {
+	config leaderelection.LeaderElectionConfig
+}
+
+func newLeaseElector(cfg *HotStandbyConfig) (*leaseElector, error) {
+	c, err := clientcmd.BuildConfigFromFlags("", cfg.KubeConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("error in reading kube config: %v", err)
+	}
+	client, err := kubernetes.NewForConfig(c)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating kubernetes client: %v", err)
+	}
+	namespace := cfg.Namespace
+	if namespace == "" {
+		namespace = podNamespace()
+	}
+	lock, err := resourcelock.New(resourcelock.LeasesResourceLock, namespace, cfg.Name, client.CoreV1(), client.CoordinationV1(),
+		resourcelock.ResourceLockConfig{Identity: cfg.Identity})
+	if err != nil {
+		return nil, err
+	}
+	e := &leaseElector{config: leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   time.Duration(cfg.LeaseDurationSec) * time.Second,
+		RenewDeadline:   time.Duration(cfg.RenewDeadlineSec) * time.Second,
+		RetryPeriod:     time.Duration(cfg.RetryPeriodSec) * time.Second,
+		ReleaseOnCancel: true,
+		Name:            cfg.Name,
+	}}
+	// validate the durations
+	e.config.Callbacks = leaderelection.LeaderCallbacks{OnStartedLeading: func(context.Context) {}, OnStoppedLeading: func() {}}
+	if _, err = leaderelection.NewLeaderElector(e.config); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *leaseElector) run(ctx context.Context, onStarted func(context.Context), onStopped func()) {
+	config := e.config
+	config.Callbacks = leaderelection.LeaderCallbacks{OnStartedLeading: onStarted, OnStoppedLeading: onStopped}
+	for ctx.Err() == nil {
+		le, err := leaderelection.NewLeaderElector(config)
+		if err != nil {
+			logger.Error(ctx, util.AlarmHotStandby, "create leader elector error", err)
+			return
+		}
+		le.Run(ctx)
+	}
+}
+
+// podNamespace returns the namespace of the agent pod from the service account, or "default".
+func podNamespace() string {
+	if ns, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace"); err == nil && len(ns) > 0 {
+		return strings.TrimSpace(string(ns))
+	}
+	return "default"
+}
+
+// fileElector elects by an exclusive lock of a file, which is held until the campaign quits or the
+// process exits, so the standby takes over immediately after the active agent crashes.
+type fileElector struct {
+	path        string
+	retryPeriod time.Duration
+}
+
+func (e *fileElector) run(ctx context.Context, onStarted func(context.Context), onStopped func()) {
+	for {
+		unlock, err := tryLockFile(e.path)
+		if err == nil {
+			leadingCtx, cancel := context.WithCancel(ctx)
+			onStarted(leadingCtx)
+			<-ctx.Done()
+			cancel()
+			onStopped()
+			unlock()
+			return
+		}
+		logger.Debug(ctx, "lock file", e.path, "error", err)
+		if util.RandomSleep(e.retryPeriod, 0.1, ctx.Done()) {
+			return
+		}
+	}
+}
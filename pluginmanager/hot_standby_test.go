@@ -0,0 +1,127 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux || windows
+// +build linux windows
+
+package pluginmanager
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/plugins/flusher/checker"
+)
+
+// switchElector changes the leadership by the values sent to it.
+type switchElector chan bool
+
+func (e switchElector) run(ctx context.Context, onStarted func(context.Context), onStopped func()) {
+	var cancel context.CancelFunc
+	for {
+		select {
+		case leading := <-e:
+			if leading && cancel == nil {
+				var leadingCtx context.Context
+				leadingCtx, cancel = context.WithCancel(ctx)
+				onStarted(leadingCtx)
+			} else if !leading && cancel != nil {
+				cancel()
+				cancel = nil
+				onStopped()
+			}
+		case <-ctx.Done():
+			if cancel != nil {
+				cancel()
+				onStopped()
+			}
+			return
+		}
+	}
+}
+
+func TestHotStandbySwitch(t *testing.T) {
+	require.NoError(t, LoadMockConfig("p", "l", "hot_standby_switch"))
+	lc := LogtailConfig["hot_standby_switch"]
+	delete(LogtailConfig, "hot_standby_switch")
+	elector := make(switchElector)
+	h := &hotStandby{config: lc, elector: elector}
+	starts, stops := 0, 0
+	h.run(func() { starts++ }, func() { stops++ })
+
+	// The repeated values are ignored, which wait for the previous changes to finish.
+	assert.False(t, h.isLeader())
+	elector <- true
+	elector <- true
+	assert.True(t, h.isLeader())
+	elector <- false
+	elector <- false
+	assert.False(t, h.isLeader())
+	elector <- true
+	elector <- true
+	h.stop()
+	assert.False(t, h.isLeader())
+	assert.Equal(t, 2, starts, "the services should be started again when the leadership is regained")
+	assert.Equal(t, 2, stops, "the services should be stopped when quitting the campaign as the leader")
+	h.stop()
+}
+
+func TestHotStandbyFileLock(t *testing.T) {
+	lockFile := filepath.Join(t.TempDir(), "standby.lock")
+	configTemplate := `{
+		"global": {
+			"HotStandby": {"Lock": "file", "Name": %q, "RetryPeriodSec": 1}
+		},
+		"inputs": [{"type": "service_mock", "detail": {"LogsPerSecond": 10, "Fields": {"content": "a"}}}],
+		"flushers": [{"type": "flusher_checker"}]
+	}`
+	for _, name := range []string{"standby_a", "standby_b"} {
+		require.NoError(t, LoadMockConfig("p", "l", name, fmt.Sprintf(configTemplate, lockFile)))
+	}
+	configs := []*LogstoreConfig{LogtailConfig["standby_a"], LogtailConfig["standby_b"]}
+	delete(LogtailConfig, "standby_a")
+	delete(LogtailConfig, "standby_b")
+	configs[0].Start()
+	time.Sleep(time.Millisecond * 500)
+	configs[1].Start()
+	time.Sleep(time.Millisecond * 1500)
+
+	active, standby := configs[0], configs[1]
+	require.True(t, active.hotStandby.isLeader())
+	require.False(t, standby.hotStandby.isLeader())
+	standbyChecker := GetConfigFluhsers(standby.PluginRunner)[0].(*checker.FlusherChecker)
+	assert.Zero(t, standbyChecker.GetLogCount(), "the standby should not collect")
+
+	require.NoError(t, active.Stop(true))
+	assert.Greater(t, GetConfigFluhsers(active.PluginRunner)[0].(*checker.FlusherChecker).GetLogCount(), 0)
+	time.Sleep(time.Millisecond * 2500)
+	assert.True(t, standby.hotStandby.isLeader(), "the standby should take over")
+	require.NoError(t, standby.Stop(true))
+	assert.Greater(t, standbyChecker.GetLogCount(), 0)
+}
+
+func TestHotStandbyInvalidLock(t *testing.T) {
+	err := LoadMockConfig("p", "l", "standby_invalid", `{
+		"global": {"HotStandby": {"Lock": "zookeeper"}},
+		"inputs": [{"type": "service_mock", "detail": {}}],
+		"flushers": [{"type": "flusher_checker"}]
+	}`)
+	assert.Error(t, err)
+}
@@ -23,6 +23,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/alibaba/ilogtail/helper"
 	"github.com/alibaba/ilogtail/pkg/logger"
@@ -102,6 +103,7 @@ type LogstoreConfig struct {
 	// private fields
 	alreadyStarted   bool // if this flag is true, do not start it when config Resume
 	configDetailHash string
+	configDetail     string // the raw json of the config, which is dumped with the secrets redacted
 	// processShutdown  chan struct{}
 	// flushShutdown    chan struct{}
 	pauseChan  chan struct{}
@@ -109,6 +111,24 @@ type LogstoreConfig struct {
 	// processWaitSema  sync.WaitGroup
 	// flushWaitSema    sync.WaitGroup
 	pauseOrResumeWg sync.WaitGroup
+	// the services detached from the old config, only valid when creating.
+	reusedServices *reusedServices
+	// the tracers of the processors and aggregators, only when plugin tracing is enabled.
+	pluginTracers []*pluginTracer
+	// the deadline to flush out the data when stopping, only set by Shutdown.
+	flushOutDeadline time.Time
+	// the count of the data dropped when flushing out.
+	droppedOnStop int64
+	// the leader election of the service inputs, only when GlobalConfig.HotStandby is set.
+	hotStandby *hotStandby
+	// the shedding state of the config, which is nil if the data of the config are never shed.
+	priority *configPriority
+	// the event accounting of the config, which is nil if auditing is disabled.
+	auditor *pipelineAuditor
+	// the timestamp policy of the config, which is nil if GlobalConfig.TimestampPolicy is not set.
+	timestampPolicy *timestampPolicy
+	// the config is created by ValidateConfig or Benchmark if it's not dryRunNone, see dryRunMode.
+	dryRun dryRunMode
 
 	LabelSet map[string]struct{}
 	EnvSet   map[string]struct{}
@@ -148,6 +168,8 @@ func (lc *LogstoreConfig) Start() {
 	lc.resumeChan = make(chan struct{}, 1)
 
 	lc.PluginRunner.Run()
+	trackHighPriority(lc, true)
+	lc.auditor.start()
 
 	logger.Info(lc.Context.GetRuntimeContext(), "config start", "success")
 }
@@ -164,10 +186,12 @@ func (lc *LogstoreConfig) Start() {
 // 7. Stop flusher plugins.
 func (lc *LogstoreConfig) Stop(exitFlag bool) error {
 	logger.Info(lc.Context.GetRuntimeContext(), "config stop", "begin", "exit", exitFlag)
+	trackHighPriority(lc, false)
 	if err := lc.PluginRunner.Stop(exitFlag); err != nil {
 		return err
 	}
 	logger.Info(lc.Context.GetRuntimeContext(), "Plugin Runner stop", "done")
+	lc.auditor.stop()
 	close(lc.pauseChan)
 	close(lc.resumeChan)
 	logger.Info(lc.Context.GetRuntimeContext(), "config stop", "success")
@@ -267,7 +291,7 @@ func (lc *LogstoreConfig) ProcessLog(logByte []byte, packID string, topic string
 	log := &protocol.Log{}
 	err := log.Unmarshal(logByte)
 	if err != nil {
-		logger.Error(lc.Context.GetRuntimeContext(), "WRONG_PROTOBUF_ALARM",
+		logger.Error(lc.Context.GetRuntimeContext(), util.AlarmWrongProtobuf,
 			"cannot process logs passed by core, err", err)
 		return -1
 	}
@@ -309,7 +333,17 @@ func hasDockerStdoutInput(plugins map[string]interface{}) bool {
 var enableAlwaysOnlineForStdout = true
 
 func createLogstoreConfig(project string, logstore string, configName string, logstoreKey int64, jsonStr string) (*LogstoreConfig, error) {
-	var err error
+	return newLogstoreConfig(project, logstore, configName, logstoreKey, jsonStr, dryRunNone, nil)
+}
+
+// newLogstoreConfig creates the config, @parent is the config of the branch if it's a branch, see pipelineBranch.
+func newLogstoreConfig(project string, logstore string, configName string, logstoreKey int64, jsonStr string, dryRun dryRunMode, parent *LogstoreConfig) (*LogstoreConfig, error) {
+	// Only the loaded copy is expanded, the config detail keeps the references of the environment variables
+	// and the files, so the secrets substituted from them are not dumped.
+	expanded, err := expandConfigTemplate(jsonStr)
+	if err != nil {
+		return nil, fmt.Errorf("expand config template error: %v", err)
+	}
 	contextImp := &ContextImp{}
 	contextImp.InitContext(project, logstore, configName)
 	logstoreC := &LogstoreConfig{
@@ -318,7 +352,9 @@ func createLogstoreConfig(project string, logstore string, configName string, lo
 		ConfigName:       configName,
 		LogstoreKey:      logstoreKey,
 		Context:          contextImp,
-		configDetailHash: fmt.Sprintf("%x", md5.Sum([]byte(jsonStr))), //nolint:gosec
+		configDetailHash: fmt.Sprintf("%x", md5.Sum([]byte(expanded))), //nolint:gosec
+		configDetail:     jsonStr,
+		dryRun:           dryRun,
 	}
 
 	// Check if the config has been disabled (keep disabled if config detail is unchanged).
@@ -337,7 +373,7 @@ func createLogstoreConfig(project string, logstore string, configName string, lo
 	}
 
 	var plugins = make(map[string]interface{})
-	if err = json.Unmarshal([]byte(jsonStr), &plugins); err != nil {
+	if err = json.Unmarshal([]byte(expanded), &plugins); err != nil {
 		return nil, err
 	}
 
@@ -356,13 +392,19 @@ func createLogstoreConfig(project string, logstore string, configName string, lo
 			logger.Info(contextImp.GetRuntimeContext(), "config is same after reload, use it again", GetFlushStoreLen(logstoreC.PluginRunner))
 			return logstoreC, nil
 		}
+		// resume the old config before detaching its services, otherwise a service blocked on sending to the
+		// paused pipeline holds the lock of its channel, and the redirecting waits for it forever
 		oldConfig.resume()
+		logstoreC.reusedServices = detachServices(oldConfig.PluginRunner, logstoreC.PluginRunner, serviceHashes(plugins))
+		defer logstoreC.reusedServices.release()
 		_ = oldConfig.Stop(false)
 		logstoreC.PluginRunner.Merge(oldConfig.PluginRunner)
 		logger.Info(contextImp.GetRuntimeContext(), "config is changed after reload", "stop and create a new one")
 	} else if lastConfig, hasLastConfig := LastLogtailConfig[configName]; hasLastConfig {
 		// Move unsent LogGroups from last config to new config.
 		logstoreC.PluginRunner.Merge(lastConfig.PluginRunner)
+	} else if lastBranch, hasLastBranch := lastBranchConfig(configName); hasLastBranch {
+		logstoreC.PluginRunner.Merge(lastBranch.PluginRunner)
 	}
 
 	enableAlwaysOnline := enableAlwaysOnlineForStdout && hasDockerStdoutInput(plugins)
@@ -415,10 +457,19 @@ func createLogstoreConfig(project string, logstore string, configName string, lo
 	}
 
 	logstoreC.GlobalConfig = &LogtailGlobalConfig
-	// If plugins config has "global" field, then override the logstoreC.GlobalConfig
-	if pluginConfigInterface, flag := plugins["global"]; flag || enableAlwaysOnline {
+	if parent != nil {
+		// the branches share the global config of the parent, so the queues and the processors of the branch
+		// are created by the parent's settings.
+		logstoreC.GlobalConfig = parent.GlobalConfig
+	} else if pluginConfigInterface, flag := plugins["global"]; flag || enableAlwaysOnline {
+		// If plugins config has "global" field, then override the logstoreC.GlobalConfig
 		pluginConfig := &GlobalConfig{}
 		*pluginConfig = LogtailGlobalConfig
+		// the quotas of the agent must not be changed by the config, see tenantQuota.
+		pluginConfig.TenantQuotas = nil
+		pluginConfig.TenantProjects = nil
+		pluginConfig.PriorityShedding = nil
+		pluginConfig.ResourceDetection = nil
 		if flag {
 			configJSONStr, err := json.Marshal(pluginConfigInterface) //nolint:govet
 			if err != nil {
@@ -435,15 +486,43 @@ func createLogstoreConfig(project string, logstore string, configName string, lo
 		logstoreC.GlobalConfig = pluginConfig
 		logger.Debug(contextImp.GetRuntimeContext(), "load plugin config", *logstoreC.GlobalConfig)
 	}
+	// the tenant is derived from the project rather than claimed by the config, see configTenant.
+	if tenant := configTenant(project); tenant != logstoreC.GlobalConfig.Tenant {
+		if logstoreC.GlobalConfig == &LogtailGlobalConfig {
+			pluginConfig := LogtailGlobalConfig
+			logstoreC.GlobalConfig = &pluginConfig
+		}
+		logstoreC.GlobalConfig.Tenant = tenant
+	}
+	// the dry run configs are never loaded, so they are not counted by the tenant quota and not audited.
+	if dryRun == dryRunNone {
+		if err = checkTenantQuota(logstoreC.GlobalConfig.Tenant, configName); err != nil {
+			return nil, err
+		}
+		logstoreC.auditor = newPipelineAuditor(logstoreC)
+	}
+	if logstoreC.priority, err = newConfigPriority(logstoreC.GlobalConfig.Priority, logstoreC.Context); err != nil {
+		return nil, err
+	}
+	if logstoreC.timestampPolicy, err = newTimestampPolicy(logstoreC.GlobalConfig.TimestampPolicy, logstoreC); err != nil {
+		return nil, err
+	}
+	// the hot standby of the parent pauses and resumes the inputs, which the branches don't have.
+	if logstoreC.GlobalConfig.HotStandby != nil && dryRun == dryRunNone && parent == nil {
+		if logstoreC.hotStandby, err = newHotStandby(logstoreC, *logstoreC.GlobalConfig.HotStandby); err != nil {
+			return nil, err
+		}
+	}
 
 	logQueueSize := logstoreC.GlobalConfig.DefaultLogQueueSize
 	// Because the transferred data of the file MixProcessMode is quite large, we have to limit queue size to control memory usage here.
-	if checkMixProcessMode(plugins) == file {
+	if parent == nil && checkMixProcessMode(plugins) == file {
 		logger.Infof(contextImp.GetRuntimeContext(), "no inputs in config %v, maybe file input, limit queue size", configName)
 		logQueueSize = 10
 	}
 
 	logGroupSize := logstoreC.GlobalConfig.DefaultLogGroupQueueSize
+	logQueueSize, logGroupSize = tenantQueueSizes(logstoreC.GlobalConfig.Tenant, logQueueSize, logGroupSize)
 
 	if err = logstoreC.PluginRunner.Init(logQueueSize, logGroupSize); err != nil {
 		return nil, err
@@ -461,7 +540,7 @@ func createLogstoreConfig(project string, logstore string, configName string, lo
 						if typeName, ok := input["type"]; ok {
 							if typeNameStr, ok := typeName.(string); ok {
 								if strings.HasPrefix(typeNameStr, "metric_") {
-									err = loadMetric(getPluginType(typeNameStr), logstoreC, input["detail"])
+									err = loadMetric(getPluginType(typeNameStr), logstoreC, input["detail"], input[pluginScheduleKey])
 								} else if strings.HasPrefix(typeNameStr, "service_") {
 									err = loadService(getPluginType(typeNameStr), logstoreC, input["detail"])
 								}
@@ -491,7 +570,7 @@ func createLogstoreConfig(project string, logstore string, configName string, lo
 						if typeName, ok := processor["type"]; ok {
 							if typeNameStr, ok := typeName.(string); ok {
 								logger.Debug(contextImp.GetRuntimeContext(), "add processor", typeNameStr)
-								err = loadProcessor(getPluginType(typeNameStr), i, logstoreC, processor["detail"])
+								err = loadProcessor(getPluginType(typeNameStr), i, logstoreC, processor["detail"], processor[pluginMatchKey])
 								if err != nil {
 									return nil, err
 								}
@@ -543,7 +622,9 @@ func createLogstoreConfig(project string, logstore string, configName string, lo
 						if typeName, ok := flusher["type"]; ok {
 							if typeNameStr, ok := typeName.(string); ok {
 								logger.Debug(contextImp.GetRuntimeContext(), "add flusher", typeNameStr)
-								err = loadFlusher(getPluginType(typeNameStr), logstoreC, flusher["detail"])
+								flusherType := getPluginType(typeNameStr)
+								match := defaultFlusherMatch(flushers, flusherType, flusher[pluginMatchKey])
+								err = loadFlusher(flusherType, logstoreC, flusher["detail"], match, flusher[pluginCircuitBreakerKey], flusher[pluginSinkKey])
 								if err != nil {
 									return nil, err
 								}
@@ -560,6 +641,17 @@ func createLogstoreConfig(project string, logstore string, configName string, lo
 			continue
 		}
 
+		if pluginType == pluginBranchesKey {
+			runner, ok := logstoreC.PluginRunner.(*pluginv1Runner)
+			if !ok {
+				return nil, fmt.Errorf("branches are only supported by the %s pipeline", v1)
+			}
+			if runner.branches, err = loadBranches(logstoreC, pluginConfig); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
 		if pluginType != "global" && pluginType != "version" && pluginType != mixProcessModeFlag {
 			return nil, fmt.Errorf("error plugin name \"%s\"", pluginType)
 		}
@@ -568,6 +660,8 @@ func createLogstoreConfig(project string, logstore string, configName string, lo
 	if err := logstoreC.PluginRunner.Initialized(); err != nil {
 		return nil, err
 	}
+	logstoreC.reusedServices.attach(logstoreC.PluginRunner)
+	logstoreC.reusedServices = nil
 	return logstoreC, nil
 }
 
@@ -600,7 +694,9 @@ func initPluginRunner(lc *LogstoreConfig) (PluginRunner, error) {
 func LoadLogstoreConfig(project string, logstore string, configName string, logstoreKey int64, jsonStr string) error {
 	if len(jsonStr) == 0 {
 		logger.Info(context.Background(), "delete config", configName, "logstore", logstore)
+		LogtailConfigLock.Lock()
 		delete(LogtailConfig, configName)
+		LogtailConfigLock.Unlock()
 		return nil
 	}
 	logger.Info(context.Background(), "load config", configName, "logstore", logstore)
@@ -608,10 +704,24 @@ func LoadLogstoreConfig(project string, logstore string, configName string, logs
 	if err != nil {
 		return err
 	}
+	LogtailConfigLock.Lock()
 	LogtailConfig[configName] = logstoreC
+	LogtailConfigLock.Unlock()
 	return nil
 }
 
+// runningConfigs returns a copy of LogtailConfig, so that the configs could be iterated out of the goroutine
+// loading the configs.
+func runningConfigs() map[string]*LogstoreConfig {
+	LogtailConfigLock.RLock()
+	defer LogtailConfigLock.RUnlock()
+	configs := make(map[string]*LogstoreConfig, len(LogtailConfig))
+	for name, config := range LogtailConfig {
+		configs[name] = config
+	}
+	return configs
+}
+
 func loadBuiltinConfig(name string, project string, logstore string,
 	configName string, cfgStr string) (*LogstoreConfig, error) {
 	logger.Infof(context.Background(), "load built-in config %v, config name: %v, logstore: %v", name, configName, logstore)
@@ -622,7 +732,7 @@ func loadBuiltinConfig(name string, project string, logstore string,
 // @pluginType: the type of metric plugin.
 // @logstoreConfig: where to store the created metric plugin object.
 // It returns any error encountered.
-func loadMetric(pluginType string, logstoreConfig *LogstoreConfig, configInterface interface{}) (err error) {
+func loadMetric(pluginType string, logstoreConfig *LogstoreConfig, configInterface interface{}, scheduleInterface interface{}) (err error) {
 	creator, existFlag := pipeline.MetricInputs[pluginType]
 	if !existFlag || creator == nil {
 		return fmt.Errorf("can't find plugin %s", pluginType)
@@ -631,6 +741,9 @@ func loadMetric(pluginType string, logstoreConfig *LogstoreConfig, configInterfa
 	if err = applyPluginConfig(metric, configInterface); err != nil {
 		return nil
 	}
+	if logstoreConfig.dryRun != dryRunNone {
+		return nil
+	}
 	interval, err := metric.Init(logstoreConfig.Context)
 	if err != nil {
 		return err
@@ -647,7 +760,11 @@ func loadMetric(pluginType string, logstoreConfig *LogstoreConfig, configInterfa
 			}
 		}
 	}
-	return logstoreConfig.PluginRunner.AddPlugin(pluginType, pluginMetricInput, metric, map[string]interface{}{"interval": interval})
+	config := map[string]interface{}{"interval": interval}
+	if err = addSchedule(config, scheduleInterface); err != nil {
+		return err
+	}
+	return logstoreConfig.PluginRunner.AddPlugin(pluginType, pluginMetricInput, metric, config)
 }
 
 // loadService creates a service plugin object and append to logstoreConfig.ServicePlugins.
@@ -659,20 +776,34 @@ func loadService(pluginType string, logstoreConfig *LogstoreConfig, configInterf
 	if !existFlag || creator == nil {
 		return fmt.Errorf("can't find plugin %s", pluginType)
 	}
+	if logstoreConfig.dryRun != dryRunNone {
+		return applyPluginConfig(creator(), configInterface)
+	}
+	hash := serviceHash(pluginType, configInterface)
+	// The services of hot standby configs are started by the leader election, so they are never taken over.
+	if logstoreConfig.hotStandby == nil {
+		if reused := logstoreConfig.reusedServices.take(hash); reused != nil {
+			logger.Info(logstoreConfig.Context.GetRuntimeContext(), "take over the running service", pluginType)
+			return logstoreConfig.PluginRunner.AddPlugin(pluginType, pluginServiceInput, reused.Input,
+				map[string]interface{}{pluginHashKey: hash, reusedServiceKey: reused})
+		}
+	}
 	service := creator()
 	if err = applyPluginConfig(service, configInterface); err != nil {
 		return nil
 	}
-	if _, err = service.Init(logstoreConfig.Context); err != nil {
+	metrics, err := initService(service, logstoreConfig.Context)
+	if err != nil {
 		return err
 	}
-	return logstoreConfig.PluginRunner.AddPlugin(pluginType, pluginServiceInput, service, map[string]interface{}{})
+	return logstoreConfig.PluginRunner.AddPlugin(pluginType, pluginServiceInput, service,
+		map[string]interface{}{pluginHashKey: hash, pluginMetricsKey: metrics})
 }
 
-func loadProcessor(pluginType string, priority int, logstoreConfig *LogstoreConfig, configInterface interface{}) (err error) {
+func loadProcessor(pluginType string, priority int, logstoreConfig *LogstoreConfig, configInterface, matchInterface interface{}) (err error) {
 	creator, existFlag := pipeline.Processors[pluginType]
 	if !existFlag || creator == nil {
-		logger.Error(logstoreConfig.Context.GetRuntimeContext(), "INVALID_PROCESSOR_TYPE", "invalid processor type, maybe type is wrong or logtail version is too old", pluginType)
+		logger.Error(logstoreConfig.Context.GetRuntimeContext(), util.AlarmInvalidProcessor, "invalid processor type, maybe type is wrong or logtail version is too old", pluginType)
 		return nil
 	}
 	processor := creator()
@@ -682,13 +813,66 @@ func loadProcessor(pluginType string, priority int, logstoreConfig *LogstoreConf
 	if err = processor.Init(logstoreConfig.Context); err != nil {
 		return err
 	}
-	return logstoreConfig.PluginRunner.AddPlugin(pluginType, pluginProcessor, processor, map[string]interface{}{"priority": priority})
+	config := map[string]interface{}{"priority": priority}
+	if err = addPluginMatch(config, matchInterface); err != nil {
+		return err
+	}
+	if err = logstoreConfig.PluginRunner.AddPlugin(pluginType, pluginProcessor, processor, config); err != nil {
+		return err
+	}
+	match, _ := config[pluginMatchKey].(*PluginMatch)
+	return loadLaneProcessors(pluginType, creator, logstoreConfig, configInterface, match)
+}
+
+// statefulProcessors are the processors whose states cover all the logs of the config, e.g. the series of
+// processor_log_to_metric, the alert windows of processor_alert and the cache of processor_enrich.
+var statefulProcessors = map[string]bool{
+	"processor_alert":         true,
+	"processor_log_to_metric": true,
+	"processor_enrich":        true,
+}
+
+// loadLaneProcessors creates an instance of the processor for each of the other processor lanes when
+// ProcessorConcurrency is greater than 1, so that the states of the processors are not shared by the lanes.
+// The stateful processors and the TickProcessors are not duplicated, all the lanes share the only instance
+// in turn, see sharedProcessor.
+func loadLaneProcessors(pluginType string, creator pipeline.ProcessorCreator, logstoreConfig *LogstoreConfig, configInterface interface{}, match *PluginMatch) error {
+	runner, ok := logstoreConfig.PluginRunner.(*pluginv1Runner)
+	concurrency := logstoreConfig.GlobalConfig.ProcessorConcurrency
+	if !ok || concurrency <= 1 || len(runner.ProcessorPlugins) == 0 {
+		return nil
+	}
+	wrapper := runner.ProcessorPlugins[len(runner.ProcessorPlugins)-1]
+	if statefulProcessors[pluginType] || isTickProcessor(wrapper.Processor) {
+		wrapper.Processor = &sharedProcessor{ProcessorV1: wrapper.Processor}
+		wrapper.shared = true
+		return nil
+	}
+	processors := make([]pipeline.ProcessorV1, 0, concurrency-1)
+	for lane := 1; lane < concurrency; lane++ {
+		processor, ok := creator().(pipeline.ProcessorV1)
+		if !ok {
+			return nil
+		}
+		if err := applyPluginConfig(processor, configInterface); err != nil {
+			return err
+		}
+		if err := processor.Init(logstoreConfig.Context); err != nil {
+			return err
+		}
+		if match != nil {
+			processor = &matchedProcessor{ProcessorV1: processor, match: match, config: logstoreConfig}
+		}
+		processors = append(processors, processor)
+	}
+	wrapper.laneProcessors = processors
+	return nil
 }
 
 func loadAggregator(pluginType string, logstoreConfig *LogstoreConfig, configInterface interface{}) (err error) {
 	creator, existFlag := pipeline.Aggregators[pluginType]
 	if !existFlag || creator == nil {
-		logger.Error(logstoreConfig.Context.GetRuntimeContext(), "INVALID_AGGREGATOR_TYPE", "invalid aggregator type, maybe type is wrong or logtail version is too old", pluginType)
+		logger.Error(logstoreConfig.Context.GetRuntimeContext(), util.AlarmInvalidAggregator, "invalid aggregator type, maybe type is wrong or logtail version is too old", pluginType)
 		return nil
 	}
 	aggregator := creator()
@@ -698,7 +882,7 @@ func loadAggregator(pluginType string, logstoreConfig *LogstoreConfig, configInt
 	return logstoreConfig.PluginRunner.AddPlugin(pluginType, pluginAggregator, aggregator, map[string]interface{}{})
 }
 
-func loadFlusher(pluginType string, logstoreConfig *LogstoreConfig, configInterface interface{}) (err error) {
+func loadFlusher(pluginType string, logstoreConfig *LogstoreConfig, configInterface, matchInterface, breakerInterface, sinkInterface interface{}) (err error) {
 	creator, existFlag := pipeline.Flushers[pluginType]
 	if !existFlag || creator == nil {
 		return fmt.Errorf("can't find plugin %s", pluginType)
@@ -707,10 +891,23 @@ func loadFlusher(pluginType string, logstoreConfig *LogstoreConfig, configInterf
 	if err = applyPluginConfig(flusher, configInterface); err != nil {
 		return nil
 	}
+	if logstoreConfig.dryRun == dryRunValidate {
+		return nil
+	}
 	if err = flusher.Init(logstoreConfig.Context); err != nil {
 		return err
 	}
-	return logstoreConfig.PluginRunner.AddPlugin(pluginType, pluginFlusher, flusher, map[string]interface{}{})
+	config := map[string]interface{}{}
+	if err = addPluginMatch(config, matchInterface); err != nil {
+		return err
+	}
+	if err = addCircuitBreaker(config, logstoreConfig, breakerInterface); err != nil {
+		return err
+	}
+	if err = addSink(config, sinkInterface); err != nil {
+		return err
+	}
+	return logstoreConfig.PluginRunner.AddPlugin(pluginType, pluginFlusher, flusher, config)
 }
 
 func applyPluginConfig(plugin interface{}, pluginConfig interface{}) error {
@@ -0,0 +1,49 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"fmt"
+
+	"github.com/alibaba/ilogtail/helper/scheduler"
+)
+
+const pluginScheduleKey = "schedule"
+
+// ScheduleConfig is configured by the optional "schedule" field of metric inputs, e.g.
+//
+//	{"type": "metric_http", "schedule": {"Cron": "*/5 * * * *", "JitterMs": 10000}, "detail": {...}}
+//
+// The input collects at the activations of the cron expression instead of every IntervalMs, each delayed by
+// a random jitter in [0, JitterMs). The collection runs in its own goroutine, and the activations are skipped
+// while the last collection is still running. See scheduler.Parse for the formats of Cron.
+type ScheduleConfig = scheduler.Config
+
+// addSchedule parses the schedule config, and puts the job into the config passed to PluginRunner.AddPlugin.
+func addSchedule(config map[string]interface{}, scheduleInterface interface{}) error {
+	if scheduleInterface == nil {
+		return nil
+	}
+	scheduleConfig := &ScheduleConfig{}
+	if err := applyPluginConfig(scheduleConfig, scheduleInterface); err != nil {
+		return fmt.Errorf("invalid schedule config: %v", err)
+	}
+	job, err := scheduleConfig.NewJob(0)
+	if err != nil {
+		return err
+	}
+	config[pluginScheduleKey] = job
+	return nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/plugins/flusher/checker"
+	_ "github.com/alibaba/ilogtail/plugins/input/mock"
+)
+
+func TestMetricSchedule(t *testing.T) {
+	config := `{"inputs": [{"type": "metric_mock", "schedule": {"Cron": "* * * * * *", "JitterMs": 100, "Timezone": "UTC"},
+		"detail": {"IntervalMs": 3600000}}], "flushers": [{"type": "flusher_checker"}]}`
+	lc, err := createLogstoreConfig("p", "l", "schedule_test", 0, config)
+	require.NoError(t, err)
+	runner := lc.PluginRunner.(*pluginv1Runner)
+	require.Len(t, runner.MetricPlugins, 1)
+	require.NotNil(t, runner.MetricPlugins[0].Job)
+	assert.Equal(t, 100*time.Millisecond, runner.MetricPlugins[0].Job.Jitter)
+
+	// the input collects every second by the schedule instead of every hour
+	lc.Start()
+	flusher := GetConfigFluhsers(lc.PluginRunner)[0].(*checker.FlusherChecker)
+	require.Eventually(t, func() bool { return flusher.GetLogCount() > 0 }, 5*time.Second, 100*time.Millisecond)
+	require.NoError(t, lc.Stop(true))
+
+	for _, schedule := range []string{
+		`{"Cron": "* * *"}`,
+		`{"Cron": "* * * * *", "Timezone": "Mars/Olympus"}`,
+		`"* * * * *"`,
+	} {
+		config = `{"inputs": [{"type": "metric_mock", "schedule": ` + schedule + `}], "flushers": [{"type": "flusher_checker"}]}`
+		_, err = createLogstoreConfig("p", "l", "schedule_invalid", 0, config)
+		assert.Error(t, err, schedule)
+	}
+}
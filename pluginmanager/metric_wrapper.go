@@ -15,6 +15,7 @@
 package pluginmanager
 
 import (
+	"github.com/alibaba/ilogtail/helper/scheduler"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/protocol"
@@ -28,6 +29,8 @@ type MetricWrapper struct {
 	Config   *LogstoreConfig
 	Tags     map[string]string
 	Interval time.Duration
+	// The input collects by the schedule instead of the interval when it's set, see ScheduleConfig.
+	Job *scheduler.Job
 
 	LogsChan      chan *pipeline.LogWithContext
 	LatencyMetric pipeline.LatencyMetric
@@ -36,13 +39,17 @@ type MetricWrapper struct {
 func (p *MetricWrapper) Run(control *pipeline.AsyncControl) {
 	logger.Info(p.Config.Context.GetRuntimeContext(), "start run metric ", p.Input.Description())
 	defer panicRecover(p.Input.Description())
+	if p.Job != nil {
+		p.runJob(control)
+		return
+	}
 	for {
 		exitFlag := util.RandomSleep(p.Interval, 0.1, control.CancelToken())
 		p.LatencyMetric.Begin()
 		err := p.Input.Collect(p)
 		p.LatencyMetric.End()
 		if err != nil {
-			logger.Error(p.Config.Context.GetRuntimeContext(), "INPUT_COLLECT_ALARM", "error", err)
+			logger.Error(p.Config.Context.GetRuntimeContext(), util.AlarmInputCollect, "error", err)
 		}
 		if exitFlag {
 			return
@@ -50,6 +57,24 @@ func (p *MetricWrapper) Run(control *pipeline.AsyncControl) {
 	}
 }
 
+// runJob collects by the schedule until the config is stopped. Unlike the interval mode, it doesn't collect
+// once more when stopping.
+func (p *MetricWrapper) runJob(control *pipeline.AsyncControl) {
+	p.Job.OnSkip = func() {
+		logger.Warning(p.Config.Context.GetRuntimeContext(), util.AlarmInputCollect, "skip the scheduled collection",
+			"the last collection is still running", "input", p.Input.Description())
+	}
+	p.Job.Run(func() {
+		defer panicRecover(p.Input.Description())
+		p.LatencyMetric.Begin()
+		err := p.Input.Collect(p)
+		p.LatencyMetric.End()
+		if err != nil {
+			logger.Error(p.Config.Context.GetRuntimeContext(), util.AlarmInputCollect, "error", err)
+		}
+	}, control.CancelToken())
+}
+
 func (p *MetricWrapper) AddData(tags map[string]string, fields map[string]string, t ...time.Time) {
 	p.AddDataWithContext(tags, fields, nil, t...)
 }
@@ -0,0 +1,437 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+// enablePipelineAudit audits all the configs loaded afterwards, a config could also enable it by GlobalConfig.Audit.
+var enablePipelineAudit = false
+
+const (
+	// auditCheckpointKey is the checkpoint key of the persisted counters of a config.
+	auditCheckpointKey = "__pipeline_audit__"
+	// defaultAuditReportInterval is used when GlobalConfig.AuditReportIntervalSec is not positive.
+	defaultAuditReportInterval = 5 * time.Minute
+)
+
+// AuditPluginCount is the events counted at a processor or a flusher of a config.
+type AuditPluginCount struct {
+	Plugin string `json:"plugin"`
+	// In and Out are the events passed to and returned by a processor. Dropped and Added are the decrease and
+	// the increase of the events of each call, so a processor both dropping and splitting events is counted
+	// by the net change of each call.
+	In      int64 `json:"in,omitempty"`
+	Out     int64 `json:"out,omitempty"`
+	Dropped int64 `json:"dropped,omitempty"`
+	Added   int64 `json:"added,omitempty"`
+	// Exported and Failed are the events exported by a flusher successfully and with an error.
+	Exported int64 `json:"exported,omitempty"`
+	Failed   int64 `json:"failed,omitempty"`
+}
+
+// process counts a call of a processor. The methods of a nil count do nothing, so the plugins could be called in
+// the same way when auditing is disabled.
+func (c *AuditPluginCount) process(in, out int) {
+	if c == nil {
+		return
+	}
+	atomic.AddInt64(&c.In, int64(in))
+	atomic.AddInt64(&c.Out, int64(out))
+	if out < in {
+		atomic.AddInt64(&c.Dropped, int64(in-out))
+	} else if out > in {
+		atomic.AddInt64(&c.Added, int64(out-in))
+	}
+}
+
+// export counts the events exported by a flusher.
+func (c *AuditPluginCount) export(events int, err error) {
+	if c == nil {
+		return
+	}
+	if err != nil {
+		atomic.AddInt64(&c.Failed, int64(events))
+	} else {
+		atomic.AddInt64(&c.Exported, int64(events))
+	}
+}
+
+func (c *AuditPluginCount) snapshot() AuditPluginCount {
+	return AuditPluginCount{
+		Plugin:   c.Plugin,
+		In:       atomic.LoadInt64(&c.In),
+		Out:      atomic.LoadInt64(&c.Out),
+		Dropped:  atomic.LoadInt64(&c.Dropped),
+		Added:    atomic.LoadInt64(&c.Added),
+		Exported: atomic.LoadInt64(&c.Exported),
+		Failed:   atomic.LoadInt64(&c.Failed),
+	}
+}
+
+func (c *AuditPluginCount) add(other AuditPluginCount) {
+	atomic.AddInt64(&c.In, other.In)
+	atomic.AddInt64(&c.Out, other.Out)
+	atomic.AddInt64(&c.Dropped, other.Dropped)
+	atomic.AddInt64(&c.Added, other.Added)
+	atomic.AddInt64(&c.Exported, other.Exported)
+	atomic.AddInt64(&c.Failed, other.Failed)
+}
+
+// AuditCounters are the events passing the stages of a config, which are persisted in the checkpoints and
+// accumulated across the restarts and the updates of the config since Since.
+type AuditCounters struct {
+	Since time.Time `json:"since"`
+	// Read is the events received from the inputs.
+	Read       int64              `json:"read"`
+	Processors []AuditPluginCount `json:"processors"`
+	// Shed is the events dropped by the priority shedding, see configPriority.
+	Shed int64 `json:"shed"`
+	// Forwarded is the events passed to the branches, which are audited by the branches themselves.
+	Forwarded int64 `json:"forwarded"`
+	// Empty is the logs without any content, which are skipped before the aggregators.
+	Empty int64 `json:"empty"`
+	// TimestampDropped is the logs dropped by the timestamp policy, see TimestampPolicyConfig.
+	TimestampDropped int64 `json:"timestamp_dropped"`
+	// Flushed is the events passed to the flushers, each flusher receives all of them.
+	Flushed  int64              `json:"flushed"`
+	Flushers []AuditPluginCount `json:"flushers"`
+	// DroppedOnStop is the events dropped because the flushers were not ready before the deadline of stopping.
+	DroppedOnStop int64 `json:"dropped_on_stop"`
+}
+
+// AuditReport is the reconciliation of the counters of a config.
+type AuditReport struct {
+	ConfigName string    `json:"config_name"`
+	Time       time.Time `json:"time"`
+	AuditCounters
+	// Pending is the events read or added by the processors but not yet dropped, shed, forwarded, skipped or flushed,
+	// i.e. the events in the queues and the aggregators, which should go back to 0 when the inputs are idle.
+	Pending int64 `json:"pending"`
+	// Lost is the events failed to be exported by any flusher or dropped when stopping.
+	Lost int64 `json:"lost"`
+	// Balanced is true when no event is lost and the counters add up.
+	Balanced bool `json:"balanced"`
+}
+
+// pipelineAuditor counts the events passing the stages of a config, persists the counters and reports the
+// reconciliation periodically. The methods of a nil auditor do nothing.
+type pipelineAuditor struct {
+	// the counters updated atomically are the first fields to be 64-bit aligned on 32-bit platforms.
+	read             int64
+	shed             int64
+	forwarded        int64
+	empty            int64
+	timestampDropped int64
+	flushed          int64
+	droppedOnStop    int64
+
+	config   *LogstoreConfig
+	interval time.Duration
+
+	mu         sync.Mutex
+	since      time.Time
+	processors []*AuditPluginCount
+	flushers   []*AuditPluginCount
+	// restored is true after the persisted counters are added, which is retried until the checkpoints are ready.
+	restored bool
+	lastLost int64
+	stopCh   chan struct{}
+	wg       sync.WaitGroup
+}
+
+// newPipelineAuditor returns nil when auditing is disabled for @config.
+func newPipelineAuditor(config *LogstoreConfig) *pipelineAuditor {
+	if config == nil || config.GlobalConfig == nil || (!enablePipelineAudit && !config.GlobalConfig.Audit) {
+		return nil
+	}
+	interval := time.Duration(config.GlobalConfig.AuditReportIntervalSec) * time.Second
+	if interval <= 0 {
+		interval = defaultAuditReportInterval
+	}
+	return &pipelineAuditor{config: config, interval: interval, since: time.Now()}
+}
+
+// addProcessor returns the count of the next processor in the running order.
+func (a *pipelineAuditor) addProcessor(plugin string) *AuditPluginCount {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	c := &AuditPluginCount{Plugin: plugin}
+	a.processors = append(a.processors, c)
+	return c
+}
+
+// addFlusher returns the count of the next flusher.
+func (a *pipelineAuditor) addFlusher(plugin string) *AuditPluginCount {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	c := &AuditPluginCount{Plugin: plugin}
+	a.flushers = append(a.flushers, c)
+	return c
+}
+
+func (a *pipelineAuditor) countRead(events int) {
+	if a != nil {
+		atomic.AddInt64(&a.read, int64(events))
+	}
+}
+
+func (a *pipelineAuditor) countShed(events int) {
+	if a != nil {
+		atomic.AddInt64(&a.shed, int64(events))
+	}
+}
+
+func (a *pipelineAuditor) countForwarded(events int) {
+	if a != nil {
+		atomic.AddInt64(&a.forwarded, int64(events))
+	}
+}
+
+func (a *pipelineAuditor) countEmptyLogs(logs []*protocol.Log) {
+	if a == nil {
+		return
+	}
+	for _, log := range logs {
+		if len(log.Contents) == 0 {
+			atomic.AddInt64(&a.empty, 1)
+		}
+	}
+}
+
+func (a *pipelineAuditor) countTimestampDropped(events int) {
+	if a != nil {
+		atomic.AddInt64(&a.timestampDropped, int64(events))
+	}
+}
+
+func (a *pipelineAuditor) countFlushed(events int) {
+	if a != nil {
+		atomic.AddInt64(&a.flushed, int64(events))
+	}
+}
+
+func (a *pipelineAuditor) countDroppedOnStop(events int) {
+	if a != nil {
+		atomic.AddInt64(&a.droppedOnStop, int64(events))
+	}
+}
+
+func (a *pipelineAuditor) counters() AuditCounters {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	counters := AuditCounters{
+		Since:            a.since,
+		Read:             atomic.LoadInt64(&a.read),
+		Shed:             atomic.LoadInt64(&a.shed),
+		Forwarded:        atomic.LoadInt64(&a.forwarded),
+		Empty:            atomic.LoadInt64(&a.empty),
+		TimestampDropped: atomic.LoadInt64(&a.timestampDropped),
+		Flushed:          atomic.LoadInt64(&a.flushed),
+		DroppedOnStop:    atomic.LoadInt64(&a.droppedOnStop),
+		Processors:       make([]AuditPluginCount, len(a.processors)),
+		Flushers:         make([]AuditPluginCount, len(a.flushers)),
+	}
+	for i, c := range a.processors {
+		counters.Processors[i] = c.snapshot()
+	}
+	for i, c := range a.flushers {
+		counters.Flushers[i] = c.snapshot()
+	}
+	return counters
+}
+
+// restore adds the counters persisted by the previous instances of the config. The counts of the plugins are
+// restored only if the plugin at the same position is not changed.
+func (a *pipelineAuditor) restore() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.restored {
+		return
+	}
+	data, err := CheckPointManager.GetCheckpoint(a.config.ConfigName, auditCheckpointKey)
+	if err == ErrCheckPointNotInit {
+		return
+	}
+	a.restored = true
+	if len(data) == 0 {
+		return
+	}
+	var saved AuditCounters
+	if err = json.Unmarshal(data, &saved); err != nil {
+		logger.Warning(a.config.Context.GetRuntimeContext(), util.AlarmCheckpointInvalid, "invalid audit checkpoint", err)
+		return
+	}
+	if !saved.Since.IsZero() && saved.Since.Before(a.since) {
+		a.since = saved.Since
+	}
+	atomic.AddInt64(&a.read, saved.Read)
+	atomic.AddInt64(&a.shed, saved.Shed)
+	atomic.AddInt64(&a.forwarded, saved.Forwarded)
+	atomic.AddInt64(&a.empty, saved.Empty)
+	atomic.AddInt64(&a.timestampDropped, saved.TimestampDropped)
+	atomic.AddInt64(&a.flushed, saved.Flushed)
+	atomic.AddInt64(&a.droppedOnStop, saved.DroppedOnStop)
+	for i, c := range saved.Processors {
+		if i < len(a.processors) && a.processors[i].Plugin == c.Plugin {
+			a.processors[i].add(c)
+		}
+	}
+	for i, c := range saved.Flushers {
+		if i < len(a.flushers) && a.flushers[i].Plugin == c.Plugin {
+			a.flushers[i].add(c)
+		}
+	}
+}
+
+func (a *pipelineAuditor) persist(counters AuditCounters) {
+	data, err := json.Marshal(counters)
+	if err == nil {
+		_ = CheckPointManager.SaveCheckpoint(a.config.ConfigName, auditCheckpointKey, data)
+	}
+}
+
+// report reconciles the counters, persists them and logs the report, and alarms if more events are lost.
+func (a *pipelineAuditor) report() AuditReport {
+	a.restore()
+	r := newAuditReport(a.config.ConfigName, a.counters())
+	if a.restored {
+		a.persist(r.AuditCounters)
+	}
+	data, _ := json.Marshal(r)
+	logger.Info(a.config.Context.GetRuntimeContext(), "pipeline audit", string(data))
+	a.mu.Lock()
+	lost := r.Lost - a.lastLost
+	a.lastLost = r.Lost
+	a.mu.Unlock()
+	if lost > 0 {
+		logger.Error(a.config.Context.GetRuntimeContext(), util.AlarmPipelineAudit, "events lost", lost, "total lost", r.Lost,
+			"failed to flush", r.Lost-r.DroppedOnStop, "dropped on stop", r.DroppedOnStop)
+	}
+	return r
+}
+
+func newAuditReport(configName string, counters AuditCounters) AuditReport {
+	r := AuditReport{ConfigName: configName, Time: time.Now(), AuditCounters: counters}
+	r.Pending = r.Read - r.Shed - r.Forwarded - r.Empty - r.TimestampDropped - r.Flushed - r.DroppedOnStop
+	for _, c := range r.Processors {
+		r.Pending += c.Added - c.Dropped
+	}
+	r.Lost = r.DroppedOnStop
+	for _, c := range r.Flushers {
+		r.Lost += c.Failed
+	}
+	r.Balanced = r.Lost == 0 && r.Pending >= 0
+	return r
+}
+
+// start restores the persisted counters and reports periodically until stop.
+func (a *pipelineAuditor) start() {
+	if a == nil {
+		return
+	}
+	a.restore()
+	a.stopCh = make(chan struct{})
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		defer panicRecover(a.config.ConfigName)
+		ticker := time.NewTicker(a.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-a.stopCh:
+				return
+			case <-ticker.C:
+				a.report()
+			}
+		}
+	}()
+}
+
+// stop reports and persists the counters for the last time, it's called after the pipeline is stopped.
+func (a *pipelineAuditor) stop() {
+	if a == nil || a.stopCh == nil {
+		return
+	}
+	close(a.stopCh)
+	a.wg.Wait()
+	a.stopCh = nil
+	a.report()
+}
+
+// AuditReports returns the reconciliation of the audited configs, which are ordered by the names.
+func AuditReports() []AuditReport {
+	reports := make([]AuditReport, 0)
+	for _, config := range runningConfigs() {
+		if config.auditor != nil {
+			reports = append(reports, newAuditReport(config.ConfigName, config.auditor.counters()))
+		}
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].ConfigName < reports[j].ConfigName })
+	return reports
+}
+
+// countLogGroups returns the count of the logs in the LogGroups.
+func countLogGroups(logGroups []*protocol.LogGroup) int {
+	n := 0
+	for _, logGroup := range logGroups {
+		n += len(logGroup.Logs)
+	}
+	return n
+}
+
+// countGroupEvents returns the count of the events in the groups.
+func countGroupEvents(groups []*models.PipelineGroupEvents) int {
+	n := 0
+	for _, group := range groups {
+		n += len(group.Events)
+	}
+	return n
+}
+
+// countFlushData returns the count of the events in the LogGroups or the groups.
+func countFlushData[T FlushData](data []*T) int {
+	n := 0
+	for _, d := range data {
+		switch v := any(d).(type) {
+		case *protocol.LogGroup:
+			n += len(v.Logs)
+		case *models.PipelineGroupEvents:
+			n += len(v.Events)
+		}
+	}
+	return n
+}
+
+func init() {
+	_ = util.InitFromEnvBool("ALIYUN_LOGTAIL_ENABLE_PIPELINE_AUDIT", &enablePipelineAudit, false)
+}
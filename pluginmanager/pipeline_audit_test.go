@@ -0,0 +1,139 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux || windows
+// +build linux windows
+
+package pluginmanager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+// dropProcessor drops the logs whose first content is "drop".
+type dropProcessor struct{}
+
+func (dropProcessor) Init(pipeline.Context) error { return nil }
+
+func (dropProcessor) Description() string { return "" }
+
+func (dropProcessor) ProcessLogs(logs []*protocol.Log) []*protocol.Log {
+	kept := logs[:0]
+	for _, log := range logs {
+		if len(log.Contents) == 0 || log.Contents[0].Value != "drop" {
+			kept = append(kept, log)
+		}
+	}
+	return kept
+}
+
+// newAuditTestConfig returns an audited config, whose persisted counters are deleted after the test.
+func newAuditTestConfig(t *testing.T, name string) *LogstoreConfig {
+	t.Cleanup(func() {
+		_ = CheckPointManager.DeleteCheckpoint(name, auditCheckpointKey)
+	})
+	ctx := mock.NewEmptyContext("p", "l", name)
+	lc := &LogstoreConfig{ConfigName: name, Context: ctx, GlobalConfig: &GlobalConfig{Audit: true, AggregatIntervalMs: 1000}}
+	lc.Statistics.Init(ctx)
+	lc.auditor = newPipelineAuditor(lc)
+	require.NotNil(t, lc.auditor)
+	return lc
+}
+
+func TestPipelineAudit(t *testing.T) {
+	lc := newAuditTestConfig(t, "audit_pipeline")
+	runner := &pluginv1Runner{LogstoreConfig: lc, FlushOutStore: NewFlushOutStore[protocol.LogGroup]()}
+	require.NoError(t, runner.Init(10, 10))
+	require.NoError(t, runner.addProcessor("processor_drop", dropProcessor{}, 0))
+	require.NoError(t, runner.addAggregator("aggregator_record", &recordAggregator{seqs: make(map[string][]int)}))
+	flusher := &brokenFlusher{broken: true}
+	require.NoError(t, flusher.Init(lc.Context))
+	require.NoError(t, runner.addFlusher("flusher_broken_0", flusher))
+
+	for _, value := range []string{"1", "drop", "2"} {
+		runner.processLog(&pipeline.LogWithContext{
+			Log:     &protocol.Log{Contents: []*protocol.Log_Content{{Key: "seq", Value: value}}},
+			Context: map[string]interface{}{"source": "s"},
+		}, 0)
+	}
+	runner.processLog(&pipeline.LogWithContext{Log: &protocol.Log{}}, 0)
+	LogtailConfig[lc.ConfigName] = lc
+	defer delete(LogtailConfig, lc.ConfigName)
+	reports := AuditReports()
+	require.Len(t, reports, 1)
+	assert.Equal(t, int64(4), reports[0].Read)
+	assert.Equal(t, int64(1), reports[0].Empty)
+	assert.Equal(t, []AuditPluginCount{{Plugin: "processor_drop", In: 4, Out: 3, Dropped: 1}}, reports[0].Processors)
+	// the logs in the aggregator are pending
+	assert.Equal(t, int64(2), reports[0].Pending)
+	assert.True(t, reports[0].Balanced)
+
+	runner.LogGroupsChan <- &protocol.LogGroup{Logs: []*protocol.Log{
+		{Contents: []*protocol.Log_Content{{Key: "seq", Value: "1"}}},
+		{Contents: []*protocol.Log_Content{{Key: "seq", Value: "2"}}},
+	}}
+	runner.runFlusher()
+	runner.FlushControl.WaitCancel()
+
+	report := lc.auditor.report()
+	assert.Equal(t, int64(2), report.Flushed)
+	assert.Equal(t, []AuditPluginCount{{Plugin: "flusher_broken_0", Failed: 2}}, report.Flushers)
+	assert.Equal(t, int64(0), report.Pending)
+	assert.Equal(t, int64(2), report.Lost)
+	assert.False(t, report.Balanced)
+}
+
+func TestPipelineAuditPersistence(t *testing.T) {
+	require.NoError(t, CheckPointManager.Init())
+	lc := newAuditTestConfig(t, "audit_persistence")
+	lc.auditor.addProcessor("processor_drop").process(3, 2)
+	lc.auditor.addFlusher("flusher_stdout_0").export(2, nil)
+	lc.auditor.countRead(3)
+	lc.auditor.countFlushed(2)
+	lc.auditor.start()
+	lc.auditor.stop()
+
+	// the counters are accumulated by the next instance of the config, except the changed plugins
+	next := newAuditTestConfig(t, "audit_persistence")
+	next.auditor.addProcessor("processor_drop").process(1, 1)
+	next.auditor.addFlusher("flusher_kafka_0")
+	next.auditor.countRead(1)
+	next.auditor.start()
+	defer next.auditor.stop()
+	counters := next.auditor.counters()
+	assert.Equal(t, int64(4), counters.Read)
+	assert.Equal(t, int64(2), counters.Flushed)
+	assert.Equal(t, AuditPluginCount{Plugin: "processor_drop", In: 4, Out: 3, Dropped: 1}, counters.Processors[0])
+	assert.Equal(t, AuditPluginCount{Plugin: "flusher_kafka_0"}, counters.Flushers[0])
+	assert.True(t, lc.auditor.since.Equal(counters.Since))
+}
+
+func TestPipelineAuditDisabled(t *testing.T) {
+	lc := &LogstoreConfig{ConfigName: "c", GlobalConfig: &GlobalConfig{}}
+	auditor := newPipelineAuditor(lc)
+	assert.Nil(t, auditor)
+	// a nil auditor and its nil counts could be called directly
+	auditor.countRead(1)
+	auditor.addProcessor("processor_drop").process(1, 0)
+	auditor.addFlusher("flusher_stdout").export(1, nil)
+	auditor.start()
+	auditor.stop()
+}
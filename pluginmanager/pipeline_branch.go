@@ -0,0 +1,206 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	pluginBranchesKey = "branches"
+	branchNameKey     = "name"
+)
+
+// allowedBranchKeys are the fields of a branch, the inputs and the global config are shared with the parent.
+var allowedBranchKeys = map[string]bool{
+	branchNameKey:     true,
+	"processors":      true,
+	"aggregators":     true,
+	"flushers":        true,
+	pluginBranchesKey: true,
+}
+
+// The optional "branches" field turns the linear pipeline of a v1 config into a graph, e.g.
+//
+//	{
+//	    "inputs": [...],
+//	    "processors": [...],
+//	    "branches": [
+//	        {"name": "kafka", "processors": [...], "flushers": [{"type": "flusher_kafka_v2", ...}]},
+//	        {"name": "sls", "flushers": [{"type": "flusher_sls", ...}]}
+//	    ]
+//	}
+//
+// The logs from all the inputs are processed by the processors of the config once, and then each branch
+// receives a copy of the processed logs, which are processed by its own processors, aggregators and flushers.
+// Branches could have their own branches, so the same source could go to multiple destinations without
+// being collected and processed repeatedly by several configs.
+//
+// Each branch runs as a child config named <config>/<branch>, which has its own queues and self metrics, and shares
+// the global config of the parent.
+// A config with branches must not have its own aggregators and flushers.
+type pipelineBranch struct {
+	Name   string
+	Config *LogstoreConfig
+
+	// the input queue of the branch, the logs are dropped rather than blocking the parent and the other
+	// branches when it's full.
+	logsChan      chan *pipeline.LogWithContext
+	droppedMetric pipeline.CounterMetric
+}
+
+// loadBranches creates the child configs of the branches of @lc.
+func loadBranches(lc *LogstoreConfig, branchesInterface interface{}) ([]*pipelineBranch, error) {
+	branchConfigs, ok := branchesInterface.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid branches type, not json array")
+	}
+	branches := make([]*pipelineBranch, 0, len(branchConfigs))
+	names := make(map[string]bool, len(branchConfigs))
+	for _, branchInterface := range branchConfigs {
+		branchConfig, ok := branchInterface.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid branch type, not json object")
+		}
+		name, _ := branchConfig[branchNameKey].(string)
+		if name == "" {
+			return nil, fmt.Errorf("must specify the name of the branch")
+		}
+		if names[name] {
+			return nil, fmt.Errorf("duplicated branch name %s", name)
+		}
+		names[name] = true
+
+		detail := make(map[string]interface{}, len(branchConfig))
+		for key, value := range branchConfig {
+			if !allowedBranchKeys[key] {
+				return nil, fmt.Errorf("invalid field %s of branch %s", key, name)
+			}
+			if key != branchNameKey {
+				detail[key] = value
+			}
+		}
+		jsonStr, err := json.Marshal(detail)
+		if err != nil {
+			return nil, err
+		}
+		config, err := newLogstoreConfig(lc.ProjectName, lc.LogstoreName, lc.ConfigName+"/"+name, lc.LogstoreKey, string(jsonStr), lc.dryRun, lc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid branch %s: %v", name, err)
+		}
+		runner, ok := config.PluginRunner.(*pluginv1Runner)
+		if !ok {
+			return nil, fmt.Errorf("branch %s is not a %s pipeline", name, v1)
+		}
+		branches = append(branches, &pipelineBranch{
+			Name:          name,
+			Config:        config,
+			logsChan:      runner.LogsChan,
+			droppedMetric: helper.NewCounterMetricAndRegister("branch_drop_log", config.Context),
+		})
+	}
+	logger.Info(lc.Context.GetRuntimeContext(), "load branches", len(branches))
+	return branches, nil
+}
+
+// forwardToBranches passes the processed logs to the branches, the last branch takes the logs and the others
+// receive the copies, because the processors of the branches may modify the logs. A slow branch doesn't block
+// the others, the logs are dropped and counted when its queue is full.
+func forwardToBranches(branches []*pipelineBranch, logs []*protocol.Log, context map[string]interface{}) {
+	for i, branch := range branches {
+		last := i == len(branches)-1
+		dropped := 0
+		for _, log := range logs {
+			logCtx := &pipeline.LogWithContext{Log: log, Context: context}
+			if !last {
+				logCtx = &pipeline.LogWithContext{Log: protocol.CloneLog(log), Context: copyContext(context)}
+			}
+			select {
+			case branch.logsChan <- logCtx:
+			default:
+				dropped++
+			}
+		}
+		if dropped > 0 {
+			branch.droppedMetric.Add(int64(dropped))
+			logger.Warning(branch.Config.Context.GetRuntimeContext(), util.AlarmDropData, "the queue of the branch is full, drop logs", dropped)
+		}
+	}
+}
+
+func copyContext(context map[string]interface{}) map[string]interface{} {
+	if context == nil {
+		return nil
+	}
+	newContext := make(map[string]interface{}, len(context))
+	for k, v := range context {
+		newContext[k] = v
+	}
+	return newContext
+}
+
+// startBranches starts the branches before the parent, so that they are ready to receive the logs.
+func startBranches(branches []*pipelineBranch) {
+	for _, branch := range branches {
+		branch.Config.Start()
+	}
+}
+
+// stopBranches stops the branches after the parent, so that the logs processed by the parent are not lost.
+func stopBranches(lc *LogstoreConfig, branches []*pipelineBranch, exit bool) error {
+	for _, branch := range branches {
+		if err := branch.Config.Stop(exit); err != nil {
+			return fmt.Errorf("stop branch %s of config %s error: %v", branch.Name, lc.ConfigName, err)
+		}
+	}
+	return nil
+}
+
+// lastBranchConfig finds the branch config named @configName among the branches of the last configs,
+// the unsent LogGroups of which are moved to the new branch with the same name.
+func lastBranchConfig(configName string) (*LogstoreConfig, bool) {
+	var find func(branches []*pipelineBranch) (*LogstoreConfig, bool)
+	find = func(branches []*pipelineBranch) (*LogstoreConfig, bool) {
+		for _, branch := range branches {
+			if branch.Config.ConfigName == configName {
+				return branch.Config, true
+			}
+			if config, ok := find(configBranches(branch.Config)); ok {
+				return config, true
+			}
+		}
+		return nil, false
+	}
+	for _, config := range LastLogtailConfig {
+		if found, ok := find(configBranches(config)); ok {
+			return found, true
+		}
+	}
+	return nil, false
+}
+
+func configBranches(lc *LogstoreConfig) []*pipelineBranch {
+	if runner, ok := lc.PluginRunner.(*pluginv1Runner); ok {
+		return runner.branches
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/flusher/checker"
+)
+
+const branchTestConfig = `{
+	"processors": [{"type": "processor_regex", "detail": {"SourceKey": "content", "Regex": "(.*)", "Keys": ["shared"], "KeepSource": true}}],
+	"branches": [
+		{
+			"name": "a",
+			"processors": [{"type": "processor_regex", "detail": {"SourceKey": "content", "Regex": "(.*)", "Keys": ["branch"], "KeepSource": true}}],
+			"flushers": [{"type": "flusher_checker"}]
+		},
+		{
+			"name": "b",
+			"branches": [
+				{"name": "c", "flushers": [{"type": "flusher_checker"}]},
+				{"name": "d", "flushers": [{"type": "flusher_checker"}]}
+			]
+		}
+	]
+}`
+
+func branchChecker(t *testing.T, branches []*pipelineBranch, name string) *checker.FlusherChecker {
+	for _, branch := range branches {
+		if branch.Name == name {
+			flushers := GetConfigFluhsers(branch.Config.PluginRunner)
+			require.Len(t, flushers, 1)
+			return flushers[0].(*checker.FlusherChecker)
+		}
+	}
+	require.Failf(t, "branch not found", name)
+	return nil
+}
+
+func TestPipelineBranches(t *testing.T) {
+	lc, err := createLogstoreConfig("p", "l", "branch_test", 0, branchTestConfig)
+	require.NoError(t, err)
+	branches := configBranches(lc)
+	require.Len(t, branches, 2)
+	assert.Equal(t, "branch_test/a", branches[0].Config.ConfigName)
+	assert.Same(t, lc.GlobalConfig, branches[1].Config.GlobalConfig)
+	subBranches := configBranches(branches[1].Config)
+	require.Len(t, subBranches, 2)
+	assert.Equal(t, "branch_test/b/c", subBranches[0].Config.ConfigName)
+	assert.Same(t, lc.GlobalConfig, subBranches[0].Config.GlobalConfig)
+	assert.Empty(t, GetConfigFluhsers(lc.PluginRunner), "the config with branches has no flushers of its own")
+
+	lc.Start()
+	for i := 0; i < 5; i++ {
+		lc.PluginRunner.ReceiveRawLog(&pipeline.LogWithContext{Log: newMatchTestLog("content", "a")})
+	}
+	require.NoError(t, lc.Stop(true))
+
+	checkerA := branchChecker(t, branches, "a")
+	assert.Equal(t, 5, checkerA.GetLogCount())
+	require.NoError(t, checkerA.CheckKeyValue("shared", "a"))
+	require.NoError(t, checkerA.CheckKeyValue("branch", "a"))
+	for _, name := range []string{"c", "d"} {
+		checkerSub := branchChecker(t, subBranches, name)
+		assert.Equal(t, 5, checkerSub.GetLogCount())
+		require.NoError(t, checkerSub.CheckKeyValue("shared", "a"))
+		// the logs of the other branches are copies, which are not modified by the processors of branch a
+		assert.Error(t, checkerSub.CheckKeyValue("branch", "a"))
+	}
+}
+
+func TestPipelineBranchQueues(t *testing.T) {
+	config := `{
+		"global": {"DefaultLogQueueSize": 50, "DefaultLogGroupQueueSize": 7},
+		"branches": [{"name": "a", "branches": [{"name": "b", "flushers": [{"type": "flusher_checker"}]}]}]
+	}`
+	lc, err := createLogstoreConfig("p", "l", "branch_queue", 0, config)
+	require.NoError(t, err)
+	// the queues of the branches without inputs are sized by the global config of the parent
+	branch := configBranches(lc)[0]
+	assert.Equal(t, 50, cap(branch.logsChan))
+	subBranch := configBranches(branch.Config)[0]
+	assert.Equal(t, 50, cap(subBranch.logsChan))
+	assert.Equal(t, 7, cap(subBranch.Config.PluginRunner.(*pluginv1Runner).LogGroupsChan))
+}
+
+func TestForwardToFullBranch(t *testing.T) {
+	newBranch := func(name string, size int) *pipelineBranch {
+		ctx := &ContextImp{}
+		ctx.InitContext("p", "l", "branch_full/"+name)
+		return &pipelineBranch{
+			Name:          name,
+			Config:        &LogstoreConfig{ConfigName: "branch_full/" + name, Context: ctx},
+			logsChan:      make(chan *pipeline.LogWithContext, size),
+			droppedMetric: helper.NewCounterMetricAndRegister("branch_drop_log", ctx),
+		}
+	}
+	full, free := newBranch("full", 1), newBranch("free", 10)
+	logs := []*protocol.Log{newMatchTestLog("content", "a"), newMatchTestLog("content", "b"), newMatchTestLog("content", "c")}
+	// the full branch doesn't block the others
+	forwardToBranches([]*pipelineBranch{full, free}, logs, nil)
+	assert.Len(t, full.logsChan, 1)
+	assert.Equal(t, int64(2), full.droppedMetric.Get())
+	assert.Len(t, free.logsChan, 3)
+	assert.Equal(t, int64(0), free.droppedMetric.Get())
+}
+
+func TestPipelineBranchesInvalid(t *testing.T) {
+	for _, config := range []string{
+		`{"branches": {"name": "a"}}`,
+		`{"branches": [{"flushers": [{"type": "flusher_checker"}]}]}`,
+		`{"branches": [{"name": "a"}, {"name": "a"}]}`,
+		`{"branches": [{"name": "a", "inputs": [{"type": "service_mock"}]}]}`,
+		`{"branches": [{"name": "a"}], "flushers": [{"type": "flusher_checker"}]}`,
+		`{"version": "v2", "branches": [{"name": "a"}]}`,
+	} {
+		_, err := createLogstoreConfig("p", "l", "branch_invalid", 0, config)
+		assert.Error(t, err, config)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	priorityLow    = "low"
+	priorityNormal = "normal"
+	priorityHigh   = "high"
+
+	defaultPriorityCheckIntervalMs = 1000
+)
+
+// priorityRanks are the ranks of the priority classes, the configs with the ranks not greater than the shed level
+// are shed.
+var priorityRanks = map[string]int32{
+	priorityLow:    1,
+	priorityNormal: 2,
+	priorityHigh:   3,
+}
+
+// PrioritySheddingConfig sheds the data of the low priority configs first when the agent is under pressure,
+// so that the high priority configs, such as the audit logs and the metrics, keep running. Only the global
+// config of the agent takes effect, and the zero values disable the corresponding conditions.
+//
+// The pressure is checked every IntervalMs:
+//   - the data of the low priority configs are shed when the heap in use exceeds LowWatermarkMB, or the flush
+//     queue of any high priority config is fuller than FlushQueuePercent, which means its backend is slow;
+//   - the data of the normal priority configs are also shed when the heap in use exceeds HighWatermarkMB.
+//
+// The shed data are dropped before being processed and before being flushed, including the LogGroups waiting for
+// the flushers not ready.
+type PrioritySheddingConfig struct {
+	LowWatermarkMB    int
+	HighWatermarkMB   int
+	FlushQueuePercent int
+	IntervalMs        int
+}
+
+// configPriority is the shedding state of a config which could be shed, i.e. the low and the normal priority
+// configs when shedding is enabled.
+type configPriority struct {
+	rank               int32
+	shedLogMetric      pipeline.CounterMetric
+	shedLogGroupMetric pipeline.CounterMetric
+}
+
+var (
+	// prioritySheddingLevel is the max rank of the configs being shed, 0 means nothing is shed.
+	prioritySheddingLevel int32
+	prioritySheddingOnce  sync.Once
+
+	highPriorityConfigsLock sync.Mutex
+	highPriorityConfigs     = make(map[*LogstoreConfig]struct{})
+)
+
+// newConfigPriority validates @priority and returns the shedding state of the config, which is nil if the config
+// is never shed.
+func newConfigPriority(priority string, context pipeline.Context) (*configPriority, error) {
+	if priority == "" {
+		priority = priorityNormal
+	}
+	rank, ok := priorityRanks[priority]
+	if !ok {
+		return nil, fmt.Errorf("invalid priority %s, must be one of low, normal and high", priority)
+	}
+	if LogtailGlobalConfig.PriorityShedding == nil || priority == priorityHigh {
+		return nil, nil
+	}
+	return &configPriority{
+		rank:               rank,
+		shedLogMetric:      helper.NewCounterMetricAndRegister("priority_shed_log", context),
+		shedLogGroupMetric: helper.NewCounterMetricAndRegister("priority_shed_loggroup", context),
+	}, nil
+}
+
+// shouldShed returns true if the data of the config should be dropped now.
+func (p *configPriority) shouldShed() bool {
+	return p != nil && p.rank <= atomic.LoadInt32(&prioritySheddingLevel)
+}
+
+// shedLogGroups drops the LogGroups of the v1 pipeline.
+func (p *configPriority) shedLogGroups(logGroups []*protocol.LogGroup) {
+	logs := 0
+	for _, logGroup := range logGroups {
+		logs += len(logGroup.Logs)
+	}
+	p.shedLogMetric.Add(int64(logs))
+	p.shedLogGroupMetric.Add(int64(len(logGroups)))
+}
+
+// shedGroupEvents drops the group events of the v2 pipeline.
+func (p *configPriority) shedGroupEvents(groupEvents []*models.PipelineGroupEvents) {
+	events := 0
+	for _, groupEvent := range groupEvents {
+		events += len(groupEvent.Events)
+	}
+	p.shedLogMetric.Add(int64(events))
+	p.shedLogGroupMetric.Add(int64(len(groupEvents)))
+}
+
+// startPriorityShedding starts checking the pressure of the agent if shedding is enabled.
+func startPriorityShedding() {
+	config := LogtailGlobalConfig.PriorityShedding
+	if config == nil {
+		return
+	}
+	prioritySheddingOnce.Do(func() {
+		interval := config.IntervalMs
+		if interval <= 0 {
+			interval = defaultPriorityCheckIntervalMs
+		}
+		go func() {
+			memStat := runtime.MemStats{}
+			for {
+				time.Sleep(time.Duration(interval) * time.Millisecond)
+				runtime.ReadMemStats(&memStat)
+				updatePrioritySheddingLevel(config, memStat.HeapInuse>>20, highPriorityBacklogged(config))
+			}
+		}()
+	})
+}
+
+// updatePrioritySheddingLevel decides the shedding level by the heap in use in MB and whether the flush queues of
+// the high priority configs are backlogged.
+func updatePrioritySheddingLevel(config *PrioritySheddingConfig, heapInuseMB uint64, backlogged bool) int32 {
+	level := int32(0)
+	switch {
+	case config.HighWatermarkMB > 0 && heapInuseMB >= uint64(config.HighWatermarkMB):
+		level = priorityRanks[priorityNormal]
+	case config.LowWatermarkMB > 0 && heapInuseMB >= uint64(config.LowWatermarkMB), backlogged:
+		level = priorityRanks[priorityLow]
+	}
+	if old := atomic.SwapInt32(&prioritySheddingLevel, level); old != level {
+		if level > old {
+			logger.Warning(context.Background(), util.AlarmDropData, "priority shedding level rises", level,
+				"heap inuse MB", heapInuseMB, "high priority backlogged", backlogged)
+		} else {
+			logger.Info(context.Background(), "priority shedding level falls", level)
+		}
+	}
+	return level
+}
+
+// trackHighPriority keeps the running high priority configs, the flush queues of which are checked for the backlog.
+func trackHighPriority(lc *LogstoreConfig, running bool) {
+	if LogtailGlobalConfig.PriorityShedding == nil || lc.GlobalConfig == nil || lc.GlobalConfig.Priority != priorityHigh {
+		return
+	}
+	highPriorityConfigsLock.Lock()
+	defer highPriorityConfigsLock.Unlock()
+	if running {
+		highPriorityConfigs[lc] = struct{}{}
+	} else {
+		delete(highPriorityConfigs, lc)
+	}
+}
+
+// highPriorityBacklogged returns true if the flush queue of any high priority config is fuller than FlushQueuePercent.
+func highPriorityBacklogged(config *PrioritySheddingConfig) bool {
+	if config.FlushQueuePercent <= 0 {
+		return false
+	}
+	highPriorityConfigsLock.Lock()
+	defer highPriorityConfigsLock.Unlock()
+	for lc := range highPriorityConfigs {
+		_, _, logGroupsLen, logGroupsCap := queueLengths(lc.PluginRunner)
+		if logGroupsCap > 0 && logGroupsLen*100 >= logGroupsCap*config.FlushQueuePercent {
+			return true
+		}
+	}
+	return false
+}
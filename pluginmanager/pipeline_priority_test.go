@@ -0,0 +1,93 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/flusher/checker"
+)
+
+func priorityTestConfig(priority string) string {
+	return `{"global": {"Priority": "` + priority + `"}, "flushers": [{"type": "flusher_checker"}]}`
+}
+
+func TestPriorityShedding(t *testing.T) {
+	shedding := &PrioritySheddingConfig{LowWatermarkMB: 100, HighWatermarkMB: 200, FlushQueuePercent: 50}
+	defer func(config *PrioritySheddingConfig) {
+		LogtailGlobalConfig.PriorityShedding = config
+		updatePrioritySheddingLevel(&PrioritySheddingConfig{}, 0, false)
+	}(LogtailGlobalConfig.PriorityShedding)
+	LogtailGlobalConfig.PriorityShedding = shedding
+
+	configs := make(map[string]*LogstoreConfig)
+	for _, priority := range []string{priorityLow, priorityNormal, priorityHigh} {
+		lc, err := createLogstoreConfig("p", "l", "priority_"+priority, 0, priorityTestConfig(priority))
+		require.NoError(t, err)
+		configs[priority] = lc
+	}
+	_, err := createLogstoreConfig("p", "l", "priority_invalid", 0, priorityTestConfig("urgent"))
+	assert.Error(t, err)
+	assert.Nil(t, configs[priorityHigh].priority, "the high priority configs are never shed")
+
+	assert.Equal(t, int32(0), updatePrioritySheddingLevel(shedding, 50, false))
+	assert.Equal(t, int32(1), updatePrioritySheddingLevel(shedding, 150, false))
+	assert.True(t, configs[priorityLow].priority.shouldShed())
+	assert.False(t, configs[priorityNormal].priority.shouldShed())
+	assert.Equal(t, int32(2), updatePrioritySheddingLevel(shedding, 250, false))
+	assert.True(t, configs[priorityNormal].priority.shouldShed())
+	assert.Equal(t, int32(1), updatePrioritySheddingLevel(shedding, 50, true))
+
+	// the logs of the low priority config are shed, while the others continue
+	for _, lc := range configs {
+		lc.Start()
+		for i := 0; i < 5; i++ {
+			lc.PluginRunner.ReceiveRawLog(&pipeline.LogWithContext{Log: newMatchTestLog("content", "a")})
+		}
+		require.NoError(t, lc.Stop(true))
+	}
+	low := GetConfigFluhsers(configs[priorityLow].PluginRunner)[0].(*checker.FlusherChecker)
+	assert.Equal(t, 0, low.GetLogCount())
+	assert.Equal(t, int64(5), configs[priorityLow].priority.shedLogMetric.Get())
+	for _, priority := range []string{priorityNormal, priorityHigh} {
+		flusher := GetConfigFluhsers(configs[priority].PluginRunner)[0].(*checker.FlusherChecker)
+		assert.Equal(t, 5, flusher.GetLogCount(), priority)
+	}
+}
+
+func TestHighPriorityBacklogged(t *testing.T) {
+	shedding := &PrioritySheddingConfig{FlushQueuePercent: 50}
+	defer func(config *PrioritySheddingConfig) {
+		LogtailGlobalConfig.PriorityShedding = config
+	}(LogtailGlobalConfig.PriorityShedding)
+	LogtailGlobalConfig.PriorityShedding = shedding
+
+	lc, err := createLogstoreConfig("p", "l", "priority_backlog", 0, priorityTestConfig(priorityHigh))
+	require.NoError(t, err)
+	trackHighPriority(lc, true)
+	defer trackHighPriority(lc, false)
+
+	runner := lc.PluginRunner.(*pluginv1Runner)
+	assert.False(t, highPriorityBacklogged(shedding))
+	for len(runner.LogGroupsChan)*2 < cap(runner.LogGroupsChan) {
+		runner.LogGroupsChan <- &protocol.LogGroup{}
+	}
+	assert.True(t, highPriorityBacklogged(shedding))
+}
@@ -0,0 +1,238 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+// tapStage is the position in a pipeline to sample the events, which is tapStageInput, tapStageFlush
+// or the index of a processor in the running order, meaning the output of the processor.
+type tapStage int
+
+const (
+	tapStageInput tapStage = -1
+	tapStageFlush tapStage = -2
+
+	tapStageInputName     = "input"
+	tapStageFlushName     = "flush"
+	tapStageProcessorName = "processor"
+)
+
+// pipelineTap samples the events passing a stage of a config.
+type pipelineTap struct {
+	configName string
+	stage      tapStage
+	events     []map[string]interface{}
+	remain     int
+	done       chan struct{}
+}
+
+var (
+	tapMu sync.Mutex
+	taps  []*pipelineTap
+	// activeTaps is checked before locking tapMu, so that the pipelines are not slowed down without taps.
+	activeTaps int32
+)
+
+// parseTapStage parses "input", "flush" or "processor:N" for the output of the N-th (0-based) processor.
+func parseTapStage(s string) (tapStage, error) {
+	switch s {
+	case tapStageInputName:
+		return tapStageInput, nil
+	case tapStageFlushName:
+		return tapStageFlush, nil
+	}
+	if index := strings.TrimPrefix(s, tapStageProcessorName+":"); index != s {
+		if i, err := strconv.Atoi(index); err == nil && i >= 0 {
+			return tapStage(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid stage %q, should be %s, %s or %s:N", s, tapStageInputName, tapStageFlushName, tapStageProcessorName)
+}
+
+func processorCount(config *LogstoreConfig) int {
+	switch r := config.PluginRunner.(type) {
+	case *pluginv1Runner:
+		return len(r.ProcessorPlugins)
+	case *pluginv2Runner:
+		return len(r.ProcessorPlugins)
+	}
+	return 0
+}
+
+// TapPipeline samples at most @count events passing @stage of the running config named @configName.
+// It returns when enough events are sampled or @timeout expires, so the result may have fewer events.
+func TapPipeline(configName, stage string, count int, timeout time.Duration) ([]map[string]interface{}, error) {
+	s, err := parseTapStage(stage)
+	if err != nil {
+		return nil, err
+	}
+	if count <= 0 {
+		return nil, fmt.Errorf("invalid count %d", count)
+	}
+	LogtailConfigLock.RLock()
+	config, ok := LogtailConfig[configName]
+	LogtailConfigLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("config %s is not running", configName)
+	}
+	if n := processorCount(config); s >= 0 && int(s) >= n {
+		return nil, fmt.Errorf("config %s has %d processors, stage %s is out of range", configName, n, stage)
+	}
+
+	t := &pipelineTap{configName: configName, stage: s, remain: count, done: make(chan struct{})}
+	tapMu.Lock()
+	taps = append(taps, t)
+	atomic.AddInt32(&activeTaps, 1)
+	tapMu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-t.done:
+	case <-timer.C:
+	}
+
+	tapMu.Lock()
+	defer tapMu.Unlock()
+	for i, other := range taps {
+		if other == t {
+			taps = append(taps[:i], taps[i+1:]...)
+			atomic.AddInt32(&activeTaps, -1)
+			break
+		}
+	}
+	if t.events == nil {
+		t.events = make([]map[string]interface{}, 0)
+	}
+	return t.events, nil
+}
+
+// tapEvents passes @n events to the taps of @stage in @config, and @convert is only called for the sampled ones.
+func tapEvents(config *LogstoreConfig, stage tapStage, n int, convert func(i int) map[string]interface{}) {
+	if atomic.LoadInt32(&activeTaps) == 0 || n == 0 {
+		return
+	}
+	tapMu.Lock()
+	defer tapMu.Unlock()
+	for _, t := range taps {
+		if t.configName != config.ConfigName || t.stage != stage || t.remain == 0 {
+			continue
+		}
+		for i := 0; i < n && t.remain > 0; i++ {
+			t.events = append(t.events, convert(i))
+			t.remain--
+		}
+		if t.remain == 0 {
+			close(t.done)
+		}
+	}
+}
+
+func tapLogs(config *LogstoreConfig, stage tapStage, logs []*protocol.Log) {
+	tapEvents(config, stage, len(logs), func(i int) map[string]interface{} {
+		return tapLogEvent(logs[i], nil)
+	})
+}
+
+func tapLogGroups(config *LogstoreConfig, stage tapStage, logGroups []*protocol.LogGroup) {
+	if atomic.LoadInt32(&activeTaps) == 0 {
+		return
+	}
+	for _, logGroup := range logGroups {
+		tapEvents(config, stage, len(logGroup.Logs), func(i int) map[string]interface{} {
+			return tapLogEvent(logGroup.Logs[i], logGroup.LogTags)
+		})
+	}
+}
+
+func tapLogEvent(log *protocol.Log, tags []*protocol.LogTag) map[string]interface{} {
+	contents := make(map[string]string, len(log.Contents))
+	for _, cont := range log.Contents {
+		contents[cont.Key] = cont.Value
+	}
+	event := map[string]interface{}{"time": log.Time, "contents": contents}
+	if len(tags) > 0 {
+		tagMap := make(map[string]string, len(tags))
+		for _, tag := range tags {
+			tagMap[tag.Key] = tag.Value
+		}
+		event["tags"] = tagMap
+	}
+	return event
+}
+
+func tapGroupEvents(config *LogstoreConfig, stage tapStage, groups []*models.PipelineGroupEvents) {
+	if atomic.LoadInt32(&activeTaps) == 0 {
+		return
+	}
+	for _, group := range groups {
+		tapEvents(config, stage, len(group.Events), func(i int) map[string]interface{} {
+			return tapPipelineEvent(group.Events[i])
+		})
+	}
+}
+
+// tapPipelineEvent copies the event, since the events are modified by the following plugins after being sampled.
+func tapPipelineEvent(e models.PipelineEvent) map[string]interface{} {
+	event := map[string]interface{}{
+		"name":      e.GetName(),
+		"type":      e.GetType(),
+		"timestamp": e.GetTimestamp(),
+		"tags":      copyKeyValues[string](e.GetTags()),
+	}
+	switch e := e.(type) {
+	case *models.Log:
+		event["content"] = string(e.GetBody())
+		if severity := e.GetSeverity(); severity != models.SeverityUnspecified {
+			event["severity"] = severity
+		}
+	case *models.Metric:
+		if value := e.GetValue(); value.IsSingleValue() {
+			event["value"] = value.GetSingleValue()
+		} else if value.IsMultiValues() {
+			event["values"] = copyKeyValues[float64](value.GetMultiValues())
+		}
+		if typedValues := e.GetTypedValue(); typedValues != nil && typedValues.Len() > 0 {
+			values := make(map[string]interface{}, typedValues.Len())
+			for k, v := range typedValues.Iterator() {
+				if v != nil {
+					values[k] = v.Value
+				}
+			}
+			event["typedValues"] = values
+		}
+	case models.ByteArray:
+		event["content"] = string(e)
+	}
+	return event
+}
+
+func copyKeyValues[T string | float64](kv models.KeyValues[T]) map[string]T {
+	result := make(map[string]T, kv.Len())
+	for k, v := range kv.Iterator() {
+		result[k] = v
+	}
+	return result
+}
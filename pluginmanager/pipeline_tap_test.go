@@ -0,0 +1,114 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux || windows
+// +build linux windows
+
+package pluginmanager
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+func TestTapPipeline(t *testing.T) {
+	contextImp := &ContextImp{}
+	contextImp.InitContext("p", "l", "tap")
+	lc := &LogstoreConfig{ConfigName: "tap", Context: contextImp, GlobalConfig: &GlobalConfig{AggregatIntervalMs: 1000}}
+	lc.Statistics.Init(contextImp)
+	runner := &pluginv1Runner{LogstoreConfig: lc, FlushOutStore: NewFlushOutStore[protocol.LogGroup]()}
+	require.NoError(t, runner.Init(10, 10))
+	require.NoError(t, runner.addProcessor("processor_mark", markProcessor{}, 0))
+	require.NoError(t, runner.addAggregator("aggregator_record", &recordAggregator{seqs: make(map[string][]int)}))
+	lc.PluginRunner = runner
+	LogtailConfig["tap"] = lc
+	defer delete(LogtailConfig, "tap")
+
+	type result struct {
+		events []map[string]interface{}
+		err    error
+	}
+	tap := func(stage string, count int) chan result {
+		ch := make(chan result, 1)
+		before := atomic.LoadInt32(&activeTaps)
+		go func() {
+			events, err := TapPipeline("tap", stage, count, time.Second)
+			ch <- result{events, err}
+		}()
+		for atomic.LoadInt32(&activeTaps) == before {
+			time.Sleep(time.Millisecond)
+		}
+		return ch
+	}
+	inputCh, processorCh := tap("input", 2), tap("processor:0", 2)
+	for i := 0; i < 3; i++ {
+		runner.processLog(&pipeline.LogWithContext{
+			Log:     &protocol.Log{Time: 1, Contents: []*protocol.Log_Content{{Key: "seq", Value: strconv.Itoa(i)}}},
+			Context: map[string]interface{}{"source": "s"},
+		}, 0)
+	}
+	input := <-inputCh
+	require.NoError(t, input.err)
+	assert.Equal(t, []map[string]interface{}{
+		{"time": uint32(1), "contents": map[string]string{"seq": "0"}},
+		{"time": uint32(1), "contents": map[string]string{"seq": "1"}},
+	}, input.events, "the input events should not be changed by the processors")
+	processed := <-processorCh
+	require.NoError(t, processed.err)
+	require.Len(t, processed.events, 2)
+	assert.Equal(t, map[string]string{"seq": "1", "processed": "true"}, processed.events[1]["contents"])
+
+	flushCh := tap("flush", 5)
+	tapLogGroups(lc, tapStageFlush, []*protocol.LogGroup{{
+		Logs:    []*protocol.Log{{Contents: []*protocol.Log_Content{{Key: "seq", Value: "9"}}}},
+		LogTags: []*protocol.LogTag{{Key: "host", Value: "h"}},
+	}})
+	flushed := <-flushCh
+	require.NoError(t, flushed.err)
+	require.Len(t, flushed.events, 1, "the tap should return the sampled events after the timeout")
+	assert.Equal(t, map[string]string{"host": "h"}, flushed.events[0]["tags"])
+	assert.Equal(t, int32(0), atomic.LoadInt32(&activeTaps))
+
+	_, err := TapPipeline("tap", "processor:1", 1, time.Second)
+	assert.Error(t, err)
+	_, err = TapPipeline("tap", "aggregator", 1, time.Second)
+	assert.Error(t, err)
+	_, err = TapPipeline("missing", "input", 1, time.Second)
+	assert.Error(t, err)
+}
+
+func TestTapPipelineEvent(t *testing.T) {
+	log := models.NewLog("l", []byte("hello"), "", 1, models.NewTagsWithKeyValues("host", "h"))
+	event := tapPipelineEvent(log)
+	log.GetTags().Add("host", "changed")
+	assert.Equal(t, "hello", event["content"])
+	assert.Equal(t, map[string]string{"host": "h"}, event["tags"], "the tags should be copied")
+
+	values := models.NewMetricMultiValue()
+	values.Add("a", 1)
+	metric := models.NewMultiValuesMetric("m", models.MetricTypeGauge, models.NewTags(), 1, values.Values)
+	event = tapPipelineEvent(metric)
+	values.Add("a", 2)
+	assert.Equal(t, map[string]float64{"a": 1}, event["values"])
+	assert.Equal(t, 3.5, tapPipelineEvent(models.NewSingleValueMetric("s", models.MetricTypeGauge, models.NewTags(), 1, 3.5))["value"])
+}
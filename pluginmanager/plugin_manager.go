@@ -18,6 +18,7 @@ import (
 	"github.com/alibaba/ilogtail/helper"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/util"
 	"github.com/alibaba/ilogtail/plugin_main/flags"
 
 	"context"
@@ -29,6 +30,10 @@ import (
 
 // Following variables are exported so that tests of main package can reference them.
 var LogtailConfig map[string]*LogstoreConfig
+
+// LogtailConfigLock guards the writes of LogtailConfig and the reads out of the goroutine loading the configs,
+// e.g. the self telemetry and the debug handlers.
+var LogtailConfigLock sync.RWMutex
 var LastLogtailConfig map[string]*LogstoreConfig
 var ContainerConfig *LogstoreConfig
 
@@ -107,7 +112,7 @@ func panicRecover(pluginName string) {
 	if err := recover(); err != nil {
 		trace := make([]byte, 2048)
 		runtime.Stack(trace, true)
-		logger.Error(context.Background(), "PLUGIN_RUNTIME_ALARM", "plugin", pluginName, "panicked", err, "stack", string(trace))
+		logger.Error(context.Background(), util.AlarmPluginRuntime, "plugin", pluginName, "panicked", err, "stack", string(trace))
 	}
 }
 
@@ -120,33 +125,47 @@ func Init() (err error) {
 	}
 	if StatisticsConfig, err = loadBuiltinConfig("statistics", "sls-admin", "logtail_plugin_profile",
 		"shennong_log_profile", statisticsConfigJSON); err != nil {
-		logger.Error(context.Background(), "LOAD_PLUGIN_ALARM", "load statistics config fail", err)
+		logger.Error(context.Background(), util.AlarmLoadPlugin, "load statistics config fail", err)
 		return
 	}
 	if AlarmConfig, err = loadBuiltinConfig("alarm", "sls-admin", "logtail_alarm",
 		"logtail_alarm", alarmConfigJSON); err != nil {
-		logger.Error(context.Background(), "LOAD_PLUGIN_ALARM", "load alarm config fail", err)
+		logger.Error(context.Background(), util.AlarmLoadPlugin, "load alarm config fail", err)
 		return
 	}
 	if ContainerConfig, err = loadBuiltinConfig("container", "sls-admin", "logtail_containers", "logtail_containers", containerConfigJSON); err != nil {
-		logger.Error(context.Background(), "LOAD_PLUGIN_ALARM", "load container config fail", err)
+		logger.Error(context.Background(), util.AlarmLoadPlugin, "load container config fail", err)
 		return
 	}
 	logger.Info(context.Background(), "loadBuiltinConfig container")
 	TimerFetchFuction()
+	startPriorityShedding()
+	detectResource()
 	return
 }
 
 // timeoutStop wrappers LogstoreConfig.Stop with timeout (5s by default).
 // @return true if Stop returns before timeout, otherwise false.
 func timeoutStop(config *LogstoreConfig, flag bool) bool {
-	if !flag && config.GlobalConfig.AlwaysOnline {
+	if !flag && (config.GlobalConfig.AlwaysOnline || enableDiffReload) {
 		config.pause()
 		GetAlwaysOnlineManager().AddCachedConfig(config, time.Duration(config.GlobalConfig.DelayStopSec)*time.Second)
 		logger.Info(config.Context.GetRuntimeContext(), "Pause config and add into always online manager", "done")
 		return true
 	}
 
+	done := stopInBackground(config, flag)
+	select {
+	case <-done:
+		return true
+	case <-time.After(30 * time.Second):
+		return false
+	}
+}
+
+// stopInBackground calls LogstoreConfig.Stop in a goroutine, the returned channel is closed when
+// Stop returns.
+func stopInBackground(config *LogstoreConfig, flag bool) chan int {
 	done := make(chan int)
 	go func() {
 		logger.Info(config.Context.GetRuntimeContext(), "Stop config in goroutine", "begin")
@@ -164,30 +183,43 @@ func timeoutStop(config *LogstoreConfig, flag bool) bool {
 		DisabledLogtailConfigLock.Unlock()
 		logger.Info(config.Context.GetRuntimeContext(), "Valid but slow stop config, enable it again", config.ConfigName)
 	}()
-	select {
-	case <-done:
-		return true
-	case <-time.After(30 * time.Second):
-		return false
-	}
+	return done
 }
 
 // HoldOn stops all config instance and checkpoint manager so that it is ready
 // to load new configs or quit.
-// For user-defined config, timeoutStop is used to avoid hanging.
+// For user-defined config, timeoutStop is used to avoid hanging. When quitting with
+// a positive shutdown deadline, the configs are stopped by Shutdown instead.
 func HoldOn(exitFlag bool) error {
 	defer panicRecover("Run plugin")
 
+	if exitFlag && *flags.ShutdownDeadline > 0 {
+		Shutdown(time.Duration(*flags.ShutdownDeadline) * time.Second)
+		return nil
+	}
 	for _, logstoreConfig := range LogtailConfig {
 		if hasStopped := timeoutStop(logstoreConfig, exitFlag); !hasStopped {
 			// TODO: This alarm can not be sent to server in current alarm design.
-			logger.Error(logstoreConfig.Context.GetRuntimeContext(), "CONFIG_STOP_TIMEOUT_ALARM",
+			logger.Error(logstoreConfig.Context.GetRuntimeContext(), util.AlarmConfigStopTimeout,
 				"timeout when stop config, goroutine might leak")
 			DisabledLogtailConfigLock.Lock()
 			DisabledLogtailConfig[logstoreConfig.ConfigName] = logstoreConfig
 			DisabledLogtailConfigLock.Unlock()
 		}
 	}
+	stopBuiltinConfigs(exitFlag)
+	// clear all config
+	LogtailConfigLock.Lock()
+	LastLogtailConfig = LogtailConfig
+	LogtailConfig = make(map[string]*LogstoreConfig)
+	LogtailConfigLock.Unlock()
+	CheckPointManager.HoldOn()
+	return nil
+}
+
+// stopBuiltinConfigs stops the statistics, alarm and container configs, collecting once before
+// stopping if ForceSelfCollect is set.
+func stopBuiltinConfigs(exitFlag bool) {
 	if StatisticsConfig != nil {
 		if *flags.ForceSelfCollect {
 			logger.Info(context.Background(), "force collect the static metrics")
@@ -215,11 +247,6 @@ func HoldOn(exitFlag bool) error {
 		}
 		_ = ContainerConfig.Stop(exitFlag)
 	}
-	// clear all config
-	LastLogtailConfig = LogtailConfig
-	LogtailConfig = make(map[string]*LogstoreConfig)
-	CheckPointManager.HoldOn()
-	return nil
 }
 
 // Resume starts all configs.
@@ -254,7 +281,7 @@ func Resume() error {
 
 	err := CheckPointManager.Init()
 	if err != nil {
-		logger.Error(context.Background(), "CHECKPOINT_INIT_ALARM", "init checkpoint manager error", err)
+		logger.Error(context.Background(), util.AlarmCheckpointInit, "init checkpoint manager error", err)
 	}
 	CheckPointManager.Resume()
 	// clear last logtail config
@@ -262,6 +289,47 @@ func Resume() error {
 	return nil
 }
 
+// StopLogstoreConfig stops the running config @configName and removes it from LogtailConfig, the other configs keep running.
+// The unsent data of the config is moved to the config loaded later with the same name, unless @removed is set.
+func StopLogstoreConfig(configName string, removed bool) {
+	defer panicRecover("Run plugin")
+	LogtailConfigLock.Lock()
+	logstoreConfig, ok := LogtailConfig[configName]
+	delete(LogtailConfig, configName)
+	LogtailConfigLock.Unlock()
+	if !ok {
+		return
+	}
+	// The removed configs are not paused for AlwaysOnline, the same as the deleted ones in Resume.
+	if hasStopped := timeoutStop(logstoreConfig, removed); !hasStopped {
+		logger.Error(logstoreConfig.Context.GetRuntimeContext(), util.AlarmConfigStopTimeout,
+			"timeout when stop config, goroutine might leak")
+		DisabledLogtailConfigLock.Lock()
+		DisabledLogtailConfig[configName] = logstoreConfig
+		DisabledLogtailConfigLock.Unlock()
+	}
+	if !removed {
+		LastLogtailConfig[configName] = logstoreConfig
+	}
+}
+
+// StartLogstoreConfig starts the config @configName loaded by LoadLogstoreConfig after the others are resumed.
+func StartLogstoreConfig(configName string) {
+	defer panicRecover("Run plugin")
+	delete(LastLogtailConfig, configName)
+	LogtailConfigLock.RLock()
+	logstoreConfig, ok := LogtailConfig[configName]
+	LogtailConfigLock.RUnlock()
+	if !ok {
+		return
+	}
+	if logstoreConfig.alreadyStarted {
+		logstoreConfig.resume()
+		return
+	}
+	logstoreConfig.Start()
+}
+
 func init() {
 	go func() {
 		for {
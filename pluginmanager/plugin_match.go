@@ -0,0 +1,263 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	pluginMatchKey   = "match"
+	alertFlusherType = "flusher_alert"
+)
+
+// PluginMatch is the predicate configured by the optional "match" field of processors and flushers,
+// which routes logs to different processor and flusher chains in one config, e.g.
+//
+//	{"type": "flusher_kafka_v2", "match": {"Conditions": {"level": "^error$"}}, "detail": {...}}
+//
+// A log matches when the value of each key in Conditions matches the regex, and the key could be
+// a content of the log or a tag of the LogGroup. Processors run before the logs are grouped, so they match
+// the tags added to the LogGroups of the config when flushed, e.g. __hostname__, the env tags and the global tags.
+// Not inverts the result, which works as the else branch.
+// Processors only process the matched logs and pass through the others, and flushers only receive the matched logs.
+type PluginMatch struct {
+	Conditions map[string]string
+	Not        bool
+
+	regs map[string]*regexp.Regexp
+}
+
+// newPluginMatch returns nil if config is nil, which means all logs are matched.
+func newPluginMatch(config interface{}) (*PluginMatch, error) {
+	if config == nil {
+		return nil, nil
+	}
+	match := &PluginMatch{}
+	if err := applyPluginConfig(match, config); err != nil {
+		return nil, fmt.Errorf("invalid match config: %v", err)
+	}
+	if len(match.Conditions) == 0 {
+		return nil, fmt.Errorf("must specify Conditions of match")
+	}
+	match.regs = make(map[string]*regexp.Regexp, len(match.Conditions))
+	for key, pattern := range match.Conditions {
+		reg, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid match condition %v of key %v: %v", pattern, key, err)
+		}
+		match.regs[key] = reg
+	}
+	return match, nil
+}
+
+// addPluginMatch parses the match config and puts it into the config passed to PluginRunner.AddPlugin.
+func addPluginMatch(config map[string]interface{}, matchInterface interface{}) error {
+	match, err := newPluginMatch(matchInterface)
+	if err != nil {
+		return err
+	}
+	if match != nil {
+		config[pluginMatchKey] = match
+	}
+	return nil
+}
+
+// defaultFlusherMatch returns the match of the flusher without an explicit one. The alert logs emitted by
+// processor_alert are only sent by flusher_alert when it's configured, so the other flushers skip them.
+func defaultFlusherMatch(flushers []interface{}, pluginType string, matchInterface interface{}) interface{} {
+	if matchInterface != nil || pluginType == alertFlusherType {
+		return matchInterface
+	}
+	for _, flusherInterface := range flushers {
+		if flusher, ok := flusherInterface.(map[string]interface{}); ok {
+			if typeName, ok := flusher["type"].(string); ok && getPluginType(typeName) == alertFlusherType {
+				return map[string]interface{}{
+					"Conditions": map[string]interface{}{util.AlertNameKey: ""},
+					"Not":        true,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// MatchLog checks the contents of log first, and then the tags of the LogGroup.
+func (m *PluginMatch) MatchLog(log *protocol.Log, tags []*protocol.LogTag) bool {
+	if m == nil {
+		return true
+	}
+	matched := 0
+	for _, cont := range log.Contents {
+		if reg, ok := m.regs[cont.Key]; ok {
+			if !reg.MatchString(cont.Value) {
+				return m.Not
+			}
+			matched++
+		}
+	}
+	if matched < len(m.regs) {
+		for _, tag := range tags {
+			if reg, ok := m.regs[tag.Key]; ok {
+				if !reg.MatchString(tag.Value) {
+					return m.Not
+				}
+				matched++
+			}
+		}
+	}
+	return (matched >= len(m.regs)) != m.Not
+}
+
+// SplitLogs splits logs into the matched ones and the others, the orders are kept in each part.
+func (m *PluginMatch) SplitLogs(logs []*protocol.Log) (matched, unmatched []*protocol.Log) {
+	if m == nil {
+		return logs, nil
+	}
+	for _, log := range logs {
+		if m.MatchLog(log, nil) {
+			matched = append(matched, log)
+		} else {
+			unmatched = append(unmatched, log)
+		}
+	}
+	return matched, unmatched
+}
+
+// FilterLogGroups returns new LogGroups only containing the matched logs, the empty ones are removed.
+// The original LogGroups are not modified because they are shared by all flushers.
+func (m *PluginMatch) FilterLogGroups(logGroups []*protocol.LogGroup) []*protocol.LogGroup {
+	if m == nil {
+		return logGroups
+	}
+	result := make([]*protocol.LogGroup, 0, len(logGroups))
+	for _, logGroup := range logGroups {
+		var logs []*protocol.Log
+		for _, log := range logGroup.Logs {
+			if m.MatchLog(log, logGroup.LogTags) {
+				logs = append(logs, log)
+			}
+		}
+		if len(logs) == 0 {
+			continue
+		}
+		result = append(result, &protocol.LogGroup{
+			Logs:        logs,
+			Category:    logGroup.Category,
+			Topic:       logGroup.Topic,
+			Source:      logGroup.Source,
+			MachineUUID: logGroup.MachineUUID,
+			LogTags:     logGroup.LogTags,
+		})
+	}
+	return result
+}
+
+// matchedProcessor only passes the matched logs to the processor, the others skip it.
+type matchedProcessor struct {
+	pipeline.ProcessorV1
+	match *PluginMatch
+	// config provides the tags of the LogGroups to match, no tag is matched when nil.
+	config *LogstoreConfig
+}
+
+// groupTags returns the tags added to the LogGroups of @config when flushed.
+func groupTags(config *LogstoreConfig) []*protocol.LogTag {
+	if config == nil || config.GlobalConfig == nil {
+		return nil
+	}
+	additionalTags := loadAdditionalTags(config.GlobalConfig).Iterator()
+	tags := make([]*protocol.LogTag, 0, len(additionalTags))
+	for key, value := range additionalTags {
+		tags = append(tags, &protocol.LogTag{Key: key, Value: value})
+	}
+	return tags
+}
+
+// ProcessLogs keeps the unmatched logs at their original positions among the processed logs. The processed
+// logs follow the positions of the matched logs they are returned for, and the new ones are put before
+// the next returned matched log, or at the end.
+func (p *matchedProcessor) ProcessLogs(logArray []*protocol.Log) []*protocol.Log {
+	if p.match == nil {
+		return p.ProcessorV1.ProcessLogs(logArray)
+	}
+	tags := groupTags(p.config)
+	matched := make([]*protocol.Log, 0, len(logArray))
+	isMatched := make([]bool, len(logArray))
+	for i, log := range logArray {
+		if isMatched[i] = p.match.MatchLog(log, tags); isMatched[i] {
+			matched = append(matched, log)
+		}
+	}
+	if len(matched) == 0 {
+		return logArray
+	}
+	if len(matched) == len(logArray) {
+		return p.ProcessorV1.ProcessLogs(logArray)
+	}
+	processed := p.ProcessorV1.ProcessLogs(matched)
+	positions := make(map[*protocol.Log]int, len(processed))
+	for i, log := range processed {
+		positions[log] = i
+	}
+	result := make([]*protocol.Log, 0, len(logArray)-len(matched)+len(processed))
+	next := 0
+	for i, log := range logArray {
+		if !isMatched[i] {
+			result = append(result, log)
+			continue
+		}
+		if pos, ok := positions[log]; ok && pos >= next {
+			result = append(result, processed[next:pos+1]...)
+			next = pos + 1
+		}
+	}
+	return append(result, processed[next:]...)
+}
+
+// Stop stops the processor if it's a StoppableProcessor, which is hidden by the wrapper.
+func (p *matchedProcessor) Stop() error {
+	if stoppable, ok := p.ProcessorV1.(pipeline.StoppableProcessor); ok {
+		return stoppable.Stop()
+	}
+	return nil
+}
+
+// Tick passes the logs emitted by the processor without the input logs, which are not matched.
+func (p *matchedProcessor) Tick() []*protocol.Log {
+	if ticker, ok := p.ProcessorV1.(pipeline.TickProcessor); ok {
+		return ticker.Tick()
+	}
+	return nil
+}
+
+// matchedFlusher only passes the matched logs to the flusher.
+type matchedFlusher struct {
+	pipeline.FlusherV1
+	match *PluginMatch
+}
+
+func (f *matchedFlusher) Flush(projectName string, logstoreName string, configName string, logGroupList []*protocol.LogGroup) error {
+	logGroupList = f.match.FilterLogGroups(logGroupList)
+	if len(logGroupList) == 0 {
+		return nil
+	}
+	return f.FlusherV1.Flush(projectName, logstoreName, configName, logGroupList)
+}
@@ -0,0 +1,183 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+	"github.com/alibaba/ilogtail/plugins/flusher/checker"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+type markProcessor struct{}
+
+func (markProcessor) Init(pipeline.Context) error { return nil }
+
+func (markProcessor) Description() string { return "" }
+
+func (markProcessor) ProcessLogs(logArray []*protocol.Log) []*protocol.Log {
+	for _, log := range logArray {
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: "processed", Value: "true"})
+	}
+	return logArray
+}
+
+// matchDropProcessor drops the logs with the drop key and appends a new log.
+type matchDropProcessor struct {
+	stopped bool
+}
+
+func (*matchDropProcessor) Init(pipeline.Context) error { return nil }
+
+func (*matchDropProcessor) Description() string { return "" }
+
+func (*matchDropProcessor) ProcessLogs(logArray []*protocol.Log) []*protocol.Log {
+	result := logArray[:0]
+	for _, log := range logArray {
+		if len(log.Contents) < 3 {
+			result = append(result, log)
+		}
+	}
+	return append(result, newMatchTestLog("level", "error", "n", "new"))
+}
+
+func (p *matchDropProcessor) Stop() error {
+	p.stopped = true
+	return nil
+}
+
+func newMatchTestLog(kvs ...string) *protocol.Log {
+	log := &protocol.Log{}
+	for i := 0; i+1 < len(kvs); i += 2 {
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: kvs[i], Value: kvs[i+1]})
+	}
+	return log
+}
+
+func TestNewPluginMatch(t *testing.T) {
+	match, err := newPluginMatch(nil)
+	require.NoError(t, err)
+	assert.Nil(t, match)
+	_, err = newPluginMatch(map[string]interface{}{})
+	assert.Error(t, err)
+	_, err = newPluginMatch(map[string]interface{}{"Conditions": map[string]interface{}{"level": "("}})
+	assert.Error(t, err)
+	_, err = newPluginMatch("level")
+	assert.Error(t, err)
+}
+
+func TestPluginMatchLog(t *testing.T) {
+	match, err := newPluginMatch(map[string]interface{}{
+		"Conditions": map[string]interface{}{"level": "^error$", "__tag__:env": "prod"},
+	})
+	require.NoError(t, err)
+	tags := []*protocol.LogTag{{Key: "__tag__:env", Value: "prod"}}
+	assert.True(t, match.MatchLog(newMatchTestLog("level", "error"), tags))
+	assert.True(t, match.MatchLog(newMatchTestLog("level", "error", "__tag__:env", "production"), nil))
+	assert.False(t, match.MatchLog(newMatchTestLog("level", "info"), tags))
+	assert.False(t, match.MatchLog(newMatchTestLog("level", "error"), nil))
+	assert.False(t, match.MatchLog(newMatchTestLog("msg", "error"), tags))
+
+	match.Not = true
+	assert.False(t, match.MatchLog(newMatchTestLog("level", "error"), tags))
+	assert.True(t, match.MatchLog(newMatchTestLog("level", "info"), tags))
+	assert.True(t, match.MatchLog(newMatchTestLog("msg", "error"), tags))
+
+	var all *PluginMatch
+	assert.True(t, all.MatchLog(newMatchTestLog("msg", "error"), nil))
+}
+
+func TestMatchedProcessor(t *testing.T) {
+	match, err := newPluginMatch(map[string]interface{}{"Conditions": map[string]interface{}{"level": "error"}})
+	require.NoError(t, err)
+	processor := &matchedProcessor{ProcessorV1: markProcessor{}, match: match}
+	logs := processor.ProcessLogs([]*protocol.Log{newMatchTestLog("level", "info"), newMatchTestLog("level", "error")})
+	require.Len(t, logs, 2)
+	assert.Len(t, logs[0].Contents, 1)
+	assert.Len(t, logs[1].Contents, 2)
+	assert.Equal(t, "processed", logs[1].Contents[1].Key)
+
+	// the order is kept when the processor drops and adds logs
+	dropped := newMatchTestLog("level", "error", "n", "dropped", "drop", "true")
+	logs = (&matchedProcessor{ProcessorV1: &matchDropProcessor{}, match: match}).ProcessLogs([]*protocol.Log{
+		newMatchTestLog("level", "info", "n", "1"),
+		newMatchTestLog("level", "error", "n", "2"),
+		newMatchTestLog("level", "info", "n", "3"),
+		dropped,
+		newMatchTestLog("level", "info", "n", "4"),
+		newMatchTestLog("level", "error", "n", "5"),
+	})
+	var order []string
+	for _, log := range logs {
+		order = append(order, log.Contents[1].Value)
+	}
+	assert.Equal(t, []string{"1", "2", "3", "4", "5", "new"}, order)
+
+	stoppable := &matchDropProcessor{}
+	require.NoError(t, (&matchedProcessor{ProcessorV1: stoppable, match: match}).Stop())
+	assert.True(t, stoppable.stopped)
+	require.NoError(t, (&matchedProcessor{ProcessorV1: markProcessor{}, match: match}).Stop())
+
+	// the tags of the LogGroups of the config are matched
+	match, err = newPluginMatch(map[string]interface{}{"Conditions": map[string]interface{}{"env": "^prod$"}})
+	require.NoError(t, err)
+	globalConfig := newGlobalConfig()
+	globalConfig.Tags = map[string]string{"env": "prod"}
+	processor = &matchedProcessor{ProcessorV1: markProcessor{}, match: match, config: &LogstoreConfig{GlobalConfig: &globalConfig}}
+	logs = processor.ProcessLogs([]*protocol.Log{newMatchTestLog("level", "info")})
+	require.Len(t, logs, 1)
+	assert.Len(t, logs[0].Contents, 2)
+	globalConfig.Tags["env"] = "test"
+	logs = processor.ProcessLogs([]*protocol.Log{newMatchTestLog("level", "info")})
+	require.Len(t, logs, 1)
+	assert.Len(t, logs[0].Contents, 1)
+}
+
+func TestMatchedFlusher(t *testing.T) {
+	match, err := newPluginMatch(map[string]interface{}{"Conditions": map[string]interface{}{"level": "error"}, "Not": true})
+	require.NoError(t, err)
+	flusherChecker := &checker.FlusherChecker{}
+	require.NoError(t, flusherChecker.Init(mock.NewEmptyContext("p", "l", "c")))
+	flusher := &matchedFlusher{FlusherV1: flusherChecker, match: match}
+	logGroups := []*protocol.LogGroup{
+		{Topic: "a", Logs: []*protocol.Log{newMatchTestLog("level", "info"), newMatchTestLog("level", "error")}},
+		{Topic: "b", Logs: []*protocol.Log{newMatchTestLog("level", "error")}},
+	}
+	require.NoError(t, flusher.Flush("p", "l", "c", logGroups))
+	assert.Equal(t, 1, flusherChecker.GetLogCount())
+	require.NoError(t, flusherChecker.CheckKeyValue("level", "info"))
+	// the shared LogGroups are not modified
+	assert.Len(t, logGroups[0].Logs, 2)
+}
+
+func TestDefaultFlusherMatch(t *testing.T) {
+	explicit := map[string]interface{}{"Conditions": map[string]interface{}{"level": "error"}}
+	withoutAlert := []interface{}{map[string]interface{}{"type": "flusher_sls"}}
+	assert.Nil(t, defaultFlusherMatch(withoutAlert, "flusher_sls", nil))
+
+	flushers := append(withoutAlert, map[string]interface{}{"type": "flusher_alert#1"})
+	assert.Nil(t, defaultFlusherMatch(flushers, alertFlusherType, nil))
+	assert.Equal(t, explicit, defaultFlusherMatch(flushers, "flusher_sls", explicit))
+	match, err := newPluginMatch(defaultFlusherMatch(flushers, "flusher_sls", nil))
+	require.NoError(t, err)
+	assert.False(t, match.MatchLog(newMatchTestLog(util.AlertNameKey, "too_many_errors"), nil))
+	assert.True(t, match.MatchLog(newMatchTestLog("level", "error"), nil))
+}
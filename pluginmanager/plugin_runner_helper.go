@@ -15,19 +15,26 @@
 package pluginmanager
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/helper/scheduler"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/models"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/util"
 )
 
+var errFlushOutTimeout = errors.New("flush out timeout")
+
 type timerRunner struct {
-	interval      time.Duration
+	interval time.Duration
+	// the task runs by the schedule instead of the interval when it's set.
+	job           *scheduler.Job
 	context       pipeline.Context
 	latencyMetric pipeline.LatencyMetric
 	state         interface{}
@@ -36,13 +43,17 @@ type timerRunner struct {
 func (p *timerRunner) Run(task func(state interface{}) error, cc *pipeline.AsyncControl) {
 	logger.Info(p.context.GetRuntimeContext(), "task run", "start", "interval", p.interval, "state", fmt.Sprintf("%T", p.state))
 	defer panicRecover(fmt.Sprint(p.state))
+	if p.job != nil {
+		p.runJob(task, cc)
+		return
+	}
 	for {
 		exitFlag := util.RandomSleep(p.interval, 0.1, cc.CancelToken())
 		if p.latencyMetric != nil {
 			p.latencyMetric.Begin()
 		}
 		if err := task(p.state); err != nil {
-			logger.Error(p.context.GetRuntimeContext(), "PLUGIN_RUN_ALARM", "task run", "error", err, "plugin", "state", fmt.Sprintf("%T", p.state))
+			logger.Error(p.context.GetRuntimeContext(), util.AlarmPluginRun, "task run", "error", err, "plugin", "state", fmt.Sprintf("%T", p.state))
 		}
 		if p.latencyMetric != nil {
 			p.latencyMetric.End()
@@ -54,11 +65,44 @@ func (p *timerRunner) Run(task func(state interface{}) error, cc *pipeline.Async
 	}
 }
 
-func flushOutStore[T FlushData, F pipeline.Flusher](lc *LogstoreConfig, store *FlushOutStore[T], flushers []F, flushFunc func(*LogstoreConfig, F, *FlushOutStore[T]) error) bool {
-	for _, flusher := range flushers {
+// runJob runs the task by the schedule until the runner is canceled.
+func (p *timerRunner) runJob(task func(state interface{}) error, cc *pipeline.AsyncControl) {
+	p.job.OnSkip = func() {
+		logger.Warning(p.context.GetRuntimeContext(), util.AlarmPluginRun, "task run", "skipped", "reason", "the last run is still running",
+			"state", fmt.Sprintf("%T", p.state))
+	}
+	p.job.Run(func() {
+		defer panicRecover(fmt.Sprint(p.state))
+		if p.latencyMetric != nil {
+			p.latencyMetric.Begin()
+		}
+		if err := task(p.state); err != nil {
+			logger.Error(p.context.GetRuntimeContext(), util.AlarmPluginRun, "task run", "error", err, "plugin", "state", fmt.Sprintf("%T", p.state))
+		}
+		if p.latencyMetric != nil {
+			p.latencyMetric.End()
+		}
+	}, cc.CancelToken())
+	logger.Info(p.context.GetRuntimeContext(), "task run", "exit", "state", fmt.Sprintf("%T", p.state))
+}
+
+// flushOutStore flushes the data left when stopping, @audits are the audit counts of @flushers, which may be nil.
+func flushOutStore[T FlushData, F pipeline.Flusher](lc *LogstoreConfig, store *FlushOutStore[T], flushers []F, audits []*AuditPluginCount, flushFunc func(*LogstoreConfig, F, *FlushOutStore[T]) error) bool {
+	events := countFlushData(store.Get())
+	for i, flusher := range flushers {
 		for waitCount := 0; !flusher.IsReady(lc.ProjectName, lc.LogstoreName, lc.LogstoreKey); waitCount++ {
-			if waitCount > maxFlushOutTime*100 {
-				logger.Error(lc.Context.GetRuntimeContext(), "DROP_DATA_ALARM", "flush out data timeout, drop data", store.Len())
+			if lc.flushOutTimeout(waitCount) {
+				logger.Error(lc.Context.GetRuntimeContext(), util.AlarmDropData, "flush out data timeout, drop data", store.Len())
+				atomic.AddInt64(&lc.droppedOnStop, int64(store.Len()))
+				if i == 0 {
+					// no flusher has got the data
+					lc.auditor.countDroppedOnStop(events)
+				} else if i < len(audits) {
+					// the data has been flushed by the former flushers, so it fails on this and the latter ones
+					for _, audit := range audits[i:] {
+						audit.export(events, errFlushOutTimeout)
+					}
+				}
 				return false
 			}
 			lc.Statistics.FlushReadyMetric.Add(0)
@@ -66,9 +110,15 @@ func flushOutStore[T FlushData, F pipeline.Flusher](lc *LogstoreConfig, store *F
 		}
 		lc.Statistics.FlushReadyMetric.Add(1)
 		lc.Statistics.FlushLatencyMetric.Begin()
+		if i == 0 {
+			lc.auditor.countFlushed(events)
+		}
 		err := flushFunc(lc, flusher, store)
+		if i < len(audits) {
+			audits[i].export(events, err)
+		}
 		if err != nil {
-			logger.Error(lc.Context.GetRuntimeContext(), "FLUSH_DATA_ALARM", "flush data error", lc.ProjectName, lc.LogstoreName, err)
+			logger.Error(lc.Context.GetRuntimeContext(), util.AlarmFlushData, "flush data error", lc.ProjectName, lc.LogstoreName, err)
 		}
 		lc.Statistics.FlushLatencyMetric.End()
 	}
@@ -81,9 +131,15 @@ func loadAdditionalTags(globalConfig *GlobalConfig) models.Tags {
 	for i := 0; i < len(helper.EnvTags); i += 2 {
 		tags.Add(helper.EnvTags[i], helper.EnvTags[i+1])
 	}
+	for key, value := range resourceTags() {
+		tags.Add(key, value)
+	}
 	for key, value := range globalConfig.Tags {
 		tags.Add(key, value)
 	}
+	if globalConfig.Tenant != "" {
+		tags.Add(tenantTagKey, globalConfig.Tenant)
+	}
 	return tags
 }
 
@@ -16,11 +16,14 @@ package pluginmanager
 
 import (
 	"context"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
 
 	"github.com/stretchr/testify/suite"
 )
@@ -71,3 +74,218 @@ func (s *pluginRunnerTestSuite) TestTimerRunner() {
 	cc.WaitCancel()
 	s.Equal(2, len(ch))
 }
+
+type recordAggregator struct {
+	lock sync.Mutex
+	seqs map[string][]int
+}
+
+func (*recordAggregator) Init(pipeline.Context, pipeline.LogGroupQueue) (int, error) { return 0, nil }
+
+func (*recordAggregator) Description() string { return "" }
+
+func (*recordAggregator) Reset() {}
+
+func (*recordAggregator) Flush() []*protocol.LogGroup { return nil }
+
+func (a *recordAggregator) Add(log *protocol.Log, ctx map[string]interface{}) error {
+	seq, _ := strconv.Atoi(log.Contents[0].Value)
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	source := ctx["source"].(string)
+	a.seqs[source] = append(a.seqs[source], seq)
+	return nil
+}
+
+func (s *pluginRunnerTestSuite) TestProcessorLanes() {
+	lc := &LogstoreConfig{
+		ConfigName:   "c",
+		Context:      s.Context,
+		GlobalConfig: &GlobalConfig{ProcessorConcurrency: 4, OrderedKey: "source"},
+	}
+	lc.Statistics.Init(s.Context)
+	runner := &pluginv1Runner{LogstoreConfig: lc, FlushOutStore: NewFlushOutStore[protocol.LogGroup]()}
+	s.NoError(runner.Init(100, 10))
+	agg := &recordAggregator{seqs: make(map[string][]int)}
+	runner.AggregatorPlugins = append(runner.AggregatorPlugins, &AggregatorWrapper{Aggregator: agg})
+	runner.runProcessor()
+
+	sources := []string{"a", "b", "c", "d", "e"}
+	for i := 0; i < 1000; i++ {
+		runner.ReceiveRawLog(&pipeline.LogWithContext{
+			Log:     &protocol.Log{Contents: []*protocol.Log_Content{{Key: "seq", Value: strconv.Itoa(i)}}},
+			Context: map[string]interface{}{"source": sources[i%len(sources)]},
+		})
+	}
+	runner.ProcessControl.WaitCancel()
+
+	s.Len(agg.seqs, len(sources))
+	for _, seqs := range agg.seqs {
+		s.Len(seqs, 1000/len(sources))
+		for i := 1; i < len(seqs); i++ {
+			s.Less(seqs[i-1], seqs[i])
+		}
+	}
+}
+
+// countProcessor counts the logs processed by the instance without locking, which is not concurrency-safe.
+type countProcessor struct {
+	count int
+}
+
+func (*countProcessor) Init(pipeline.Context) error { return nil }
+
+func (*countProcessor) Description() string { return "" }
+
+func (p *countProcessor) ProcessLogs(logs []*protocol.Log) []*protocol.Log {
+	p.count += len(logs)
+	return logs
+}
+
+func (s *pluginRunnerTestSuite) TestProcessorLaneInstances() {
+	var instances []*countProcessor
+	pipeline.Processors["processor_count_test"] = func() pipeline.Processor {
+		p := &countProcessor{}
+		instances = append(instances, p)
+		return p
+	}
+	defer delete(pipeline.Processors, "processor_count_test")
+
+	lc := &LogstoreConfig{
+		ConfigName:   "c",
+		Context:      s.Context,
+		GlobalConfig: &GlobalConfig{ProcessorConcurrency: 4, OrderedKey: "source"},
+	}
+	lc.Statistics.Init(s.Context)
+	runner := &pluginv1Runner{LogstoreConfig: lc, FlushOutStore: NewFlushOutStore[protocol.LogGroup]()}
+	lc.PluginRunner = runner
+	s.NoError(runner.Init(100, 10))
+	s.NoError(loadProcessor("processor_count_test", 0, lc, nil, nil))
+	s.Len(instances, 4)
+	s.Len(runner.ProcessorPlugins[0].laneProcessors, 3)
+	runner.AggregatorPlugins = append(runner.AggregatorPlugins, &AggregatorWrapper{Aggregator: &recordAggregator{seqs: make(map[string][]int)}})
+	runner.runProcessor()
+
+	sources := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	for i := 0; i < 1000; i++ {
+		runner.ReceiveRawLog(&pipeline.LogWithContext{
+			Log:     &protocol.Log{Contents: []*protocol.Log_Content{{Key: "seq", Value: strconv.Itoa(i)}}},
+			Context: map[string]interface{}{"source": sources[i%len(sources)]},
+		})
+	}
+	runner.ProcessControl.WaitCancel()
+
+	// each lane processes the logs by its own instance
+	total := 0
+	for _, instance := range instances {
+		total += instance.count
+	}
+	s.Equal(1000, total)
+}
+
+// tickProcessor emits a log on each tick.
+type tickProcessor struct {
+	countProcessor
+}
+
+func (*tickProcessor) Tick() []*protocol.Log {
+	return []*protocol.Log{{Contents: []*protocol.Log_Content{{Key: "tick", Value: "1"}}}}
+}
+
+func (s *pluginRunnerTestSuite) TestTickProcessor() {
+	defer func(interval time.Duration) { processorTickInterval = interval }(processorTickInterval)
+	processorTickInterval = 10 * time.Millisecond
+	lc := &LogstoreConfig{ConfigName: "c", Context: s.Context, GlobalConfig: &GlobalConfig{}}
+	lc.Statistics.Init(s.Context)
+	runner := &pluginv1Runner{LogstoreConfig: lc, FlushOutStore: NewFlushOutStore[protocol.LogGroup]()}
+	s.NoError(runner.Init(100, 10))
+	ticker, counter := &tickProcessor{}, &countProcessor{}
+	s.NoError(runner.addProcessor("processor_tick", ticker, 0))
+	s.NoError(runner.addProcessor("processor_count", counter, 1))
+	runner.runProcessor()
+	time.Sleep(100 * time.Millisecond)
+	runner.ProcessControl.WaitCancel()
+
+	// the logs emitted without input are passed to the processors after the ticking one
+	s.Greater(counter.count, 0)
+	s.Equal(0, ticker.count)
+}
+
+func (s *pluginRunnerTestSuite) TestProcessorLaneSharedInstance() {
+	var instances []*tickProcessor
+	pipeline.Processors["processor_tick_test"] = func() pipeline.Processor {
+		p := &tickProcessor{}
+		instances = append(instances, p)
+		return p
+	}
+	defer delete(pipeline.Processors, "processor_tick_test")
+
+	lc := &LogstoreConfig{ConfigName: "c", Context: s.Context, GlobalConfig: &GlobalConfig{ProcessorConcurrency: 4}}
+	lc.Statistics.Init(s.Context)
+	runner := &pluginv1Runner{LogstoreConfig: lc, FlushOutStore: NewFlushOutStore[protocol.LogGroup]()}
+	lc.PluginRunner = runner
+	s.NoError(runner.Init(100, 10))
+	s.NoError(loadProcessor("processor_tick_test", 0, lc, nil, nil))
+	// the TickProcessor is not duplicated for the lanes
+	s.Len(instances, 1)
+	s.True(runner.ProcessorPlugins[0].shared)
+	s.Empty(runner.ProcessorPlugins[0].laneProcessors)
+	runner.AggregatorPlugins = append(runner.AggregatorPlugins, &AggregatorWrapper{Aggregator: &recordAggregator{seqs: make(map[string][]int)}})
+	runner.runProcessor()
+	for i := 0; i < 1000; i++ {
+		runner.ReceiveRawLog(&pipeline.LogWithContext{Log: &protocol.Log{}})
+	}
+	runner.ProcessControl.WaitCancel()
+	s.Equal(1000, instances[0].count)
+}
+
+func (s *pluginRunnerTestSuite) TestTickProcessorOnStop() {
+	defer func(interval time.Duration) { processorTickInterval = interval }(processorTickInterval)
+	processorTickInterval = time.Hour
+	lc := &LogstoreConfig{ConfigName: "c", Context: s.Context, GlobalConfig: &GlobalConfig{}}
+	lc.Statistics.Init(s.Context)
+	runner := &pluginv1Runner{LogstoreConfig: lc, FlushOutStore: NewFlushOutStore[protocol.LogGroup]()}
+	s.NoError(runner.Init(100, 10))
+	counter := &countProcessor{}
+	s.NoError(runner.addProcessor("processor_tick", &tickProcessor{}, 0))
+	s.NoError(runner.addProcessor("processor_count", counter, 1))
+	runner.runProcessor()
+	runner.ProcessControl.WaitCancel()
+
+	// the TickProcessors are flushed once on stop
+	s.Equal(1, counter.count)
+}
+
+func (s *pluginRunnerTestSuite) TestNewProcessorTicker() {
+	lc := &LogstoreConfig{ConfigName: "c", Context: s.Context, GlobalConfig: &GlobalConfig{}}
+	runner := &pluginv1Runner{LogstoreConfig: lc, FlushOutStore: NewFlushOutStore[protocol.LogGroup]()}
+	s.NoError(runner.Init(100, 10))
+	s.NoError(runner.addProcessor("processor_count", &matchedProcessor{ProcessorV1: &countProcessor{}}, 0))
+	tick, stop := runner.newProcessorTicker()
+	s.Nil(tick)
+	stop()
+
+	s.NoError(runner.addProcessor("processor_tick", &matchedProcessor{ProcessorV1: &tickProcessor{}}, 1))
+	tick, stop = runner.newProcessorTicker()
+	s.NotNil(tick)
+	stop()
+}
+
+func (s *pluginRunnerTestSuite) TestLaneIndex() {
+	logCtx := &pipeline.LogWithContext{
+		Log:     &protocol.Log{Contents: []*protocol.Log_Content{{Key: "user", Value: "alice"}}},
+		Context: map[string]interface{}{"source": "file-1"},
+	}
+	_, ok := laneIndex(logCtx, "", 4)
+	s.False(ok)
+	_, ok = laneIndex(logCtx, "topic", 4)
+	s.False(ok)
+	idx, ok := laneIndex(logCtx, "source", 4)
+	s.True(ok)
+	s.True(idx >= 0 && idx < 4)
+	idx2, ok := laneIndex(&pipeline.LogWithContext{Log: &protocol.Log{}, Context: map[string]interface{}{"source": "file-1"}}, "source", 4)
+	s.True(ok)
+	s.Equal(idx, idx2)
+	_, ok = laneIndex(logCtx, "user", 4)
+	s.True(ok)
+}
@@ -15,9 +15,12 @@
 package pluginmanager
 
 import (
+	"fmt"
+	"hash/fnv"
 	"time"
 
 	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/helper/scheduler"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/protocol"
@@ -25,19 +28,30 @@ import (
 	"github.com/alibaba/ilogtail/plugin_main/flags"
 )
 
+// processorTickInterval is the interval calling the TickProcessors.
+var processorTickInterval = time.Second
+
 type pluginv1Runner struct {
 	// pipeline v1 fields
 	LogsChan      chan *pipeline.LogWithContext
 	LogGroupsChan chan *protocol.LogGroup
 
-	MetricPlugins     []*MetricWrapper
-	ServicePlugins    []*ServiceWrapper
+	MetricPlugins  []*MetricWrapper
+	ServicePlugins []*ServiceWrapper
+	// the services taken over from the old config, which are running already.
+	runningServices   map[*ServiceWrapper]struct{}
 	ProcessorPlugins  []*ProcessorWrapper
 	AggregatorPlugins []*AggregatorWrapper
 	FlusherPlugins    []*FlusherWrapper
+	// the failover groups of the flushers by the name, each of which is one of FlusherPlugins.
+	sinkGroups map[string]*sinkGroupFlusher
+	// the index of the next flusher in the config, which names the flusher in the self metrics.
+	flusherIndex int
 
 	FlushOutStore  *FlushOutStore[protocol.LogGroup]
 	LogstoreConfig *LogstoreConfig
+	// the downstream pipelines receiving the processed logs, see pipelineBranch.
+	branches []*pipelineBranch
 
 	InputControl     *pipeline.AsyncControl
 	ProcessControl   *pipeline.AsyncControl
@@ -55,6 +69,7 @@ func (p *pluginv1Runner) Init(inputQueueSize int, flushQueueSize int) error {
 	p.ProcessorPlugins = make([]*ProcessorWrapper, 0)
 	p.AggregatorPlugins = make([]*AggregatorWrapper, 0)
 	p.FlusherPlugins = make([]*FlusherWrapper, 0)
+	p.sinkGroups = make(map[string]*sinkGroupFlusher)
 	p.LogsChan = make(chan *pipeline.LogWithContext, inputQueueSize)
 	p.LogGroupsChan = make(chan *protocol.LogGroup, helper.Max(flushQueueSize, p.FlushOutStore.Len()))
 	p.FlushOutStore.Write(p.LogGroupsChan)
@@ -62,6 +77,12 @@ func (p *pluginv1Runner) Init(inputQueueSize int, flushQueueSize int) error {
 }
 
 func (p *pluginv1Runner) Initialized() error {
+	if len(p.branches) > 0 {
+		if len(p.AggregatorPlugins) > 0 || len(p.FlusherPlugins) > 0 {
+			return fmt.Errorf("aggregators and flushers must be configured in the branches")
+		}
+		return nil
+	}
 	if len(p.AggregatorPlugins) == 0 {
 		logger.Debug(p.LogstoreConfig.Context.GetRuntimeContext(), "add default aggregator")
 		if err := loadAggregator("aggregator_default", p.LogstoreConfig, nil); err != nil {
@@ -71,7 +92,7 @@ func (p *pluginv1Runner) Initialized() error {
 	if len(p.FlusherPlugins) == 0 {
 		logger.Debug(p.LogstoreConfig.Context.GetRuntimeContext(), "add default flusher")
 		category, options := flags.GetFlusherConfiguration()
-		if err := loadFlusher(category, p.LogstoreConfig, options); err != nil {
+		if err := loadFlusher(category, p.LogstoreConfig, options, nil, nil, nil); err != nil {
 			return err
 		}
 	}
@@ -82,23 +103,32 @@ func (p *pluginv1Runner) AddPlugin(pluginName string, category pluginCategory, p
 	switch category {
 	case pluginMetricInput:
 		if metric, ok := plugin.(pipeline.MetricInputV1); ok {
-			return p.addMetricInput(metric, config["interval"].(int))
+			job, _ := config[pluginScheduleKey].(*scheduler.Job)
+			return p.addMetricInput(metric, config["interval"].(int), job)
 		}
 	case pluginServiceInput:
+		if reused, ok := config[reusedServiceKey].(*ServiceWrapper); ok {
+			return p.takeOverServiceInput(reused)
+		}
 		if service, ok := plugin.(pipeline.ServiceInputV1); ok {
-			return p.addServiceInput(service)
+			hash, _ := config[pluginHashKey].(string)
+			metrics, _ := config[pluginMetricsKey].(*serviceMetrics)
+			return p.addServiceInput(service, hash, metrics)
 		}
 	case pluginProcessor:
 		if processor, ok := plugin.(pipeline.ProcessorV1); ok {
-			return p.addProcessor(processor, config["priority"].(int))
+			if match, ok := config[pluginMatchKey].(*PluginMatch); ok {
+				processor = &matchedProcessor{ProcessorV1: processor, match: match, config: p.LogstoreConfig}
+			}
+			return p.addProcessor(pluginName, processor, config["priority"].(int))
 		}
 	case pluginAggregator:
 		if aggregator, ok := plugin.(pipeline.AggregatorV1); ok {
-			return p.addAggregator(aggregator)
+			return p.addAggregator(pluginName, aggregator)
 		}
 	case pluginFlusher:
 		if flusher, ok := plugin.(pipeline.FlusherV1); ok {
-			return p.addFlusher(flusher)
+			return p.addFlusherWithOptions(pluginName, flusher, config)
 		}
 	default:
 		return pluginCategoryUndefinedError(category)
@@ -107,6 +137,7 @@ func (p *pluginv1Runner) AddPlugin(pluginName string, category pluginCategory, p
 }
 
 func (p *pluginv1Runner) Run() {
+	startBranches(p.branches)
 	p.runFlusher()
 	p.runAggregator()
 	p.runProcessor()
@@ -121,60 +152,115 @@ func (p *pluginv1Runner) RunPlugins(category pluginCategory, control *pipeline.A
 	}
 }
 
-func (p *pluginv1Runner) addMetricInput(input pipeline.MetricInputV1, interval int) error {
+func (p *pluginv1Runner) addMetricInput(input pipeline.MetricInputV1, interval int, job *scheduler.Job) error {
 	var wrapper MetricWrapper
 	wrapper.Config = p.LogstoreConfig
 	wrapper.Input = input
 	wrapper.Interval = time.Duration(interval) * time.Millisecond
+	wrapper.Job = job
 	wrapper.LogsChan = p.LogsChan
 	wrapper.LatencyMetric = p.LogstoreConfig.Statistics.CollecLatencytMetric
 	p.MetricPlugins = append(p.MetricPlugins, &wrapper)
 	return nil
 }
 
-func (p *pluginv1Runner) addServiceInput(input pipeline.ServiceInputV1) error {
+func (p *pluginv1Runner) addServiceInput(input pipeline.ServiceInputV1, hash string, metrics *serviceMetrics) error {
 	var wrapper ServiceWrapper
 	wrapper.Config = p.LogstoreConfig
 	wrapper.Input = input
 	wrapper.LogsChan = p.LogsChan
+	wrapper.hash = hash
+	wrapper.metrics = metrics
 	p.ServicePlugins = append(p.ServicePlugins, &wrapper)
 	return nil
 }
 
-func (p *pluginv1Runner) addProcessor(processor pipeline.ProcessorV1, priority int) error {
+// takeOverServiceInput adds the running service of the old config, which is not started again.
+// Its self metrics are moved to the new config, and the checkpoints are kept because the config name is unchanged.
+func (p *pluginv1Runner) takeOverServiceInput(wrapper *ServiceWrapper) error {
+	wrapper.setLogsChan(p.LogsChan)
+	wrapper.Config = p.LogstoreConfig
+	wrapper.metrics.register(p.LogstoreConfig.Context)
+	if p.runningServices == nil {
+		p.runningServices = make(map[*ServiceWrapper]struct{})
+	}
+	p.runningServices[wrapper] = struct{}{}
+	p.ServicePlugins = append(p.ServicePlugins, wrapper)
+	return nil
+}
+
+func (p *pluginv1Runner) addProcessor(pluginName string, processor pipeline.ProcessorV1, priority int) error {
 	var wrapper ProcessorWrapper
 	wrapper.Config = p.LogstoreConfig
 	wrapper.Processor = processor
 	wrapper.LogsChan = p.LogsChan
 	wrapper.Priority = priority
+	wrapper.Tracer = newPluginTracer(p.LogstoreConfig, pluginName, "processor")
+	wrapper.Audit = p.LogstoreConfig.auditor.addProcessor(pluginName)
 	p.ProcessorPlugins = append(p.ProcessorPlugins, &wrapper)
 	return nil
 }
 
-func (p *pluginv1Runner) addAggregator(aggregator pipeline.AggregatorV1) error {
+func (p *pluginv1Runner) addAggregator(pluginName string, aggregator pipeline.AggregatorV1) error {
 	var wrapper AggregatorWrapper
 	wrapper.Config = p.LogstoreConfig
 	wrapper.Aggregator = aggregator
 	wrapper.LogGroupsChan = p.LogGroupsChan
 	interval, err := aggregator.Init(p.LogstoreConfig.Context, &wrapper)
 	if err != nil {
-		logger.Error(p.LogstoreConfig.Context.GetRuntimeContext(), "AGGREGATOR_INIT_ERROR", "Aggregator failed to initialize", aggregator.Description(), "error", err)
+		logger.Error(p.LogstoreConfig.Context.GetRuntimeContext(), util.AlarmAggregatorInit, "Aggregator failed to initialize", aggregator.Description(), "error", err)
 		return err
 	}
 	if interval == 0 {
 		interval = p.LogstoreConfig.GlobalConfig.AggregatIntervalMs
 	}
 	wrapper.Interval = time.Millisecond * time.Duration(interval)
+	wrapper.Tracer = newPluginTracer(p.LogstoreConfig, pluginName, "aggregator")
 	p.AggregatorPlugins = append(p.AggregatorPlugins, &wrapper)
 	return nil
 }
 
-func (p *pluginv1Runner) addFlusher(flusher pipeline.FlusherV1) error {
+// addFlusherWithOptions wraps the flusher by the circuit breaker, the match and the sink options in order.
+func (p *pluginv1Runner) addFlusherWithOptions(pluginName string, flusher pipeline.FlusherV1, config map[string]interface{}) error {
+	name := fmt.Sprintf("%s_%d", pluginName, p.flusherIndex)
+	p.flusherIndex++
+	if breaker, ok := config[pluginCircuitBreakerKey].(*CircuitBreakerConfig); ok {
+		flusher = newCircuitBreakerFlusher(flusher, breaker, p.LogstoreConfig.Context, name)
+	}
+	// the matched logs are filtered before the circuit breaker, so that only they are shed
+	if match, ok := config[pluginMatchKey].(*PluginMatch); ok {
+		flusher = &matchedFlusher{FlusherV1: flusher, match: match}
+	}
+	sink, ok := config[pluginSinkKey].(*SinkConfig)
+	if !ok {
+		return p.addFlusher(name, flusher)
+	}
+	if sink.Mode == sinkModeMirror || sink.Mode == sinkModeShadow {
+		return p.addFlusher(name, newMirroredFlusher(flusher, sink, p.LogstoreConfig.Context, name))
+	}
+	group, ok := p.sinkGroups[sink.Group]
+	if ok && group.failbackInterval != time.Duration(sink.FailbackIntervalSec)*time.Second {
+		return fmt.Errorf("the FailbackIntervalSec %d of %s differs from the other members of the sink group %s",
+			sink.FailbackIntervalSec, name, sink.Group)
+	}
+	if !ok {
+		group = newSinkGroupFlusher(p.LogstoreConfig.Context, sink.Group, sink.FailbackIntervalSec)
+		p.sinkGroups[sink.Group] = group
+		if err := p.addFlusher(sink.Group, group); err != nil {
+			return err
+		}
+	}
+	group.add(flusher, name, sink.Mode)
+	return nil
+}
+
+func (p *pluginv1Runner) addFlusher(name string, flusher pipeline.FlusherV1) error {
 	var wrapper FlusherWrapper
 	wrapper.Config = p.LogstoreConfig
 	wrapper.Flusher = flusher
 	wrapper.LogGroupsChan = p.LogGroupsChan
 	wrapper.Interval = time.Millisecond * time.Duration(p.LogstoreConfig.GlobalConfig.FlushIntervalMs)
+	wrapper.Audit = p.LogstoreConfig.auditor.addFlusher(name)
 	p.FlusherPlugins = append(p.FlusherPlugins, &wrapper)
 	return nil
 }
@@ -182,7 +268,22 @@ func (p *pluginv1Runner) addFlusher(flusher pipeline.FlusherV1) error {
 func (p *pluginv1Runner) runInput() {
 	p.InputControl.Reset()
 	p.runMetricInput(p.InputControl)
+	if p.LogstoreConfig.hotStandby != nil {
+		p.LogstoreConfig.hotStandby.run(func() {
+			for _, service := range p.ServicePlugins {
+				service.Run(p.InputControl)
+			}
+		}, func() {
+			for _, service := range p.ServicePlugins {
+				_ = service.Stop()
+			}
+		})
+		return
+	}
 	for _, service := range p.ServicePlugins {
+		if _, running := p.runningServices[service]; running {
+			continue
+		}
 		s := service
 		p.InputControl.Run(s.Run)
 	}
@@ -197,6 +298,10 @@ func (p *pluginv1Runner) runMetricInput(async *pipeline.AsyncControl) {
 
 func (p *pluginv1Runner) runProcessor() {
 	p.ProcessControl.Reset()
+	if p.LogstoreConfig.GlobalConfig.ProcessorConcurrency > 1 {
+		p.runProcessorLanes(p.LogstoreConfig.GlobalConfig.ProcessorConcurrency)
+		return
+	}
 	p.ProcessControl.Run(p.runProcessorInternal)
 }
 
@@ -213,47 +318,212 @@ func (p *pluginv1Runner) runProcessor() {
 // It returns when processShutdown is closed.
 func (p *pluginv1Runner) runProcessorInternal(cc *pipeline.AsyncControl) {
 	defer panicRecover(p.LogstoreConfig.ConfigName)
-	var logCtx *pipeline.LogWithContext
+	tick, stopTick := p.newProcessorTicker()
+	defer stopTick()
 	for {
 		select {
 		case <-cc.CancelToken():
 			if len(p.LogsChan) == 0 {
-				return
-			}
-		case logCtx = <-p.LogsChan:
-			logs := []*protocol.Log{logCtx.Log}
-			p.LogstoreConfig.Statistics.RawLogMetric.Add(int64(len(logs)))
-			for _, processor := range p.ProcessorPlugins {
-				logs = processor.Processor.ProcessLogs(logs)
-				if len(logs) == 0 {
-					break
+				// flush the states of the TickProcessors, which are lost after the config is stopped
+				if tick != nil {
+					p.tickProcessors(0)
 				}
+				return
 			}
-			nowTime := (uint32)(time.Now().Unix())
-
-			if len(logs) > 0 {
-				p.LogstoreConfig.Statistics.SplitLogMetric.Add(int64(len(logs)))
-				for _, aggregator := range p.AggregatorPlugins {
-					for _, l := range logs {
-						if len(l.Contents) == 0 {
-							continue
-						}
-						if l.Time == uint32(0) {
-							l.Time = nowTime
-						}
-						for tryCount := 1; true; tryCount++ {
-							err := aggregator.Aggregator.Add(l, logCtx.Context)
-							if err == nil {
-								break
-							}
-							// wait until shutdown is active
-							if tryCount%100 == 0 {
-								logger.Warning(p.LogstoreConfig.Context.GetRuntimeContext(), "AGGREGATOR_ADD_ALARM", "error", err)
-							}
-							time.Sleep(time.Millisecond * 10)
+		case logCtx := <-p.LogsChan:
+			p.processLog(logCtx, 0)
+		case <-tick:
+			p.tickProcessors(0)
+		}
+	}
+}
+
+// newProcessorTicker returns the channel ticking the TickProcessors, which is nil if there is none.
+func (p *pluginv1Runner) newProcessorTicker() (<-chan time.Time, func()) {
+	for _, processor := range p.ProcessorPlugins {
+		if isTickProcessor(processor.Processor) {
+			ticker := time.NewTicker(processorTickInterval)
+			return ticker.C, ticker.Stop
+		}
+	}
+	return nil, func() {}
+}
+
+// runProcessorLanes runs processors in @concurrency lanes, each lane is a goroutine with its own channel
+// and its own instances of the processors. Logs are dispatched to lanes by the hash of the value of OrderedKey,
+// so that the logs with the same value are processed in order by the same instances. Logs are dispatched
+// round-robin when OrderedKey is not set or not found.
+func (p *pluginv1Runner) runProcessorLanes(concurrency int) {
+	lanes := make([]chan *pipeline.LogWithContext, concurrency)
+	for i := range lanes {
+		lane := make(chan *pipeline.LogWithContext, cap(p.LogsChan)/concurrency+1)
+		lanes[i] = lane
+		index := i
+		p.ProcessControl.Run(func(cc *pipeline.AsyncControl) {
+			defer panicRecover(p.LogstoreConfig.ConfigName)
+			tick, stopTick := p.newProcessorTicker()
+			defer stopTick()
+			for {
+				select {
+				case logCtx, ok := <-lane:
+					if !ok {
+						if tick != nil {
+							p.tickProcessors(index)
 						}
+						return
 					}
+					p.processLog(logCtx, index)
+				case <-tick:
+					p.tickProcessors(index)
+				}
+			}
+		})
+	}
+	p.ProcessControl.Run(func(cc *pipeline.AsyncControl) {
+		defer panicRecover(p.LogstoreConfig.ConfigName)
+		// Lanes exit after the logs in them are processed.
+		defer func() {
+			for _, lane := range lanes {
+				close(lane)
+			}
+		}()
+		next := 0
+		for {
+			select {
+			case <-cc.CancelToken():
+				if len(p.LogsChan) == 0 {
+					return
+				}
+			case logCtx := <-p.LogsChan:
+				idx, ok := laneIndex(logCtx, p.LogstoreConfig.GlobalConfig.OrderedKey, concurrency)
+				if !ok {
+					idx = next
+					next = (next + 1) % concurrency
 				}
+				lanes[idx] <- logCtx
+			}
+		}
+	})
+}
+
+// isTickProcessor checks the processor wrapped by matchedProcessor or sharedProcessor, which implement Tick
+// for all processors.
+func isTickProcessor(processor pipeline.ProcessorV1) bool {
+	for {
+		switch wrapper := processor.(type) {
+		case *matchedProcessor:
+			processor = wrapper.ProcessorV1
+		case *sharedProcessor:
+			processor = wrapper.ProcessorV1
+		default:
+			_, ok := processor.(pipeline.TickProcessor)
+			return ok
+		}
+	}
+}
+
+// laneIndex returns the lane of @logCtx by the hash of the value of @key in its context or contents.
+func laneIndex(logCtx *pipeline.LogWithContext, key string, concurrency int) (int, bool) {
+	if key == "" {
+		return 0, false
+	}
+	value, ok := logCtx.Context[key].(string)
+	if !ok {
+		for _, cont := range logCtx.Log.Contents {
+			if cont.Key == key {
+				value, ok = cont.Value, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return 0, false
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(value))
+	return int(h.Sum32() % uint32(concurrency)), true
+}
+
+// processLog passes the log through the processors of @lane, and adds the results to aggregators.
+func (p *pluginv1Runner) processLog(logCtx *pipeline.LogWithContext, lane int) {
+	p.LogstoreConfig.auditor.countRead(1)
+	if p.LogstoreConfig.priority.shouldShed() {
+		p.LogstoreConfig.priority.shedLogMetric.Add(1)
+		p.LogstoreConfig.auditor.countShed(1)
+		return
+	}
+	logs := []*protocol.Log{logCtx.Log}
+	p.LogstoreConfig.Statistics.RawLogMetric.Add(int64(len(logs)))
+	tapLogs(p.LogstoreConfig, tapStageInput, logs)
+	p.processLogs(logs, logCtx.Context, 0, lane)
+}
+
+// tickProcessors passes the logs emitted by the TickProcessors of @lane to the processors after them.
+func (p *pluginv1Runner) tickProcessors(lane int) {
+	for i, processor := range p.ProcessorPlugins {
+		if processor.shared && lane > 0 {
+			continue
+		}
+		ticker, ok := processor.instance(lane).(pipeline.TickProcessor)
+		if !ok {
+			continue
+		}
+		if logs := ticker.Tick(); len(logs) > 0 {
+			processor.Audit.process(0, len(logs))
+			tapLogs(p.LogstoreConfig, tapStage(i), logs)
+			p.processLogs(logs, nil, i+1, lane)
+		}
+	}
+}
+
+// processLogs passes the logs through the processors of @lane from the processor at @start, and adds the results
+// to aggregators.
+func (p *pluginv1Runner) processLogs(logs []*protocol.Log, context map[string]interface{}, start, lane int) {
+	for i := start; i < len(p.ProcessorPlugins); i++ {
+		processor := p.ProcessorPlugins[i]
+		instance := processor.instance(lane)
+		span := processor.Tracer.begin()
+		inputCount := len(logs)
+		logs = instance.ProcessLogs(logs)
+		processor.Tracer.end(span, inputCount)
+		processor.Audit.process(inputCount, len(logs))
+		tapLogs(p.LogstoreConfig, tapStage(i), logs)
+		if len(logs) == 0 {
+			break
+		}
+	}
+	if len(logs) == 0 {
+		return
+	}
+	p.LogstoreConfig.Statistics.SplitLogMetric.Add(int64(len(logs)))
+	if len(p.branches) > 0 {
+		p.LogstoreConfig.auditor.countForwarded(len(logs))
+		forwardToBranches(p.branches, logs, context)
+		return
+	}
+	p.LogstoreConfig.auditor.countEmptyLogs(logs)
+	logs = p.LogstoreConfig.timestampPolicy.applyLogs(logs)
+	nowTime := (uint32)(time.Now().Unix())
+	for _, aggregator := range p.AggregatorPlugins {
+		for _, l := range logs {
+			if len(l.Contents) == 0 {
+				continue
+			}
+			if l.Time == uint32(0) {
+				l.Time = nowTime
+			}
+			for tryCount := 1; true; tryCount++ {
+				span := aggregator.Tracer.begin()
+				err := aggregator.Aggregator.Add(l, context)
+				aggregator.Tracer.end(span, 1)
+				if err == nil {
+					break
+				}
+				// wait until shutdown is active
+				if tryCount%100 == 0 {
+					logger.Warning(p.LogstoreConfig.Context.GetRuntimeContext(), util.AlarmAggregatorAdd, "error", err)
+				}
+				time.Sleep(time.Millisecond * 10)
 			}
 		}
 	}
@@ -302,6 +572,11 @@ func (p *pluginv1Runner) runFlusherInternal(cc *pipeline.AsyncControl) {
 			for i := 1; i < listLen; i++ {
 				logGroups[i] = <-p.LogGroupsChan
 			}
+			if p.LogstoreConfig.priority.shouldShed() {
+				p.LogstoreConfig.priority.shedLogGroups(logGroups)
+				p.LogstoreConfig.auditor.countShed(countLogGroups(logGroups))
+				continue
+			}
 			p.LogstoreConfig.Statistics.FlushLogGroupMetric.Add(int64(len(logGroups)))
 
 			// Add tags for each non-empty LogGroup, includes: default hostname tag,
@@ -317,6 +592,8 @@ func (p *pluginv1Runner) runFlusherInternal(cc *pipeline.AsyncControl) {
 				}
 			}
 
+			tapLogGroups(p.LogstoreConfig, tapStageFlush, logGroups)
+
 			// Flush LogGroups to all flushers.
 			// Note: multiple flushers is unrecommended, because all flushers will
 			//   be blocked if one of them is unready.
@@ -330,20 +607,29 @@ func (p *pluginv1Runner) runFlusherInternal(cc *pipeline.AsyncControl) {
 					}
 				}
 				if allReady {
+					events := countLogGroups(logGroups)
+					p.LogstoreConfig.auditor.countFlushed(events)
 					for _, flusher := range p.FlusherPlugins {
 						p.LogstoreConfig.Statistics.FlushReadyMetric.Add(1)
 						p.LogstoreConfig.Statistics.FlushLatencyMetric.Begin()
 						err := flusher.Flusher.Flush(p.LogstoreConfig.ProjectName,
 							p.LogstoreConfig.LogstoreName, p.LogstoreConfig.ConfigName, logGroups)
 						p.LogstoreConfig.Statistics.FlushLatencyMetric.End()
+						flusher.Audit.export(events, err)
 						if err != nil {
-							logger.Error(p.LogstoreConfig.Context.GetRuntimeContext(), "FLUSH_DATA_ALARM", "flush data error",
+							logger.Error(p.LogstoreConfig.Context.GetRuntimeContext(), util.AlarmFlushData, "flush data error",
 								p.LogstoreConfig.ProjectName, p.LogstoreConfig.LogstoreName, err)
 						}
 					}
 					break
 				}
 				if !p.LogstoreConfig.FlushOutFlag {
+					// the flushers are not ready, drop the LogGroups instead of waiting when the config is being shed
+					if p.LogstoreConfig.priority.shouldShed() {
+						p.LogstoreConfig.priority.shedLogGroups(logGroups)
+						p.LogstoreConfig.auditor.countShed(countLogGroups(logGroups))
+						break
+					}
 					time.Sleep(time.Duration(10) * time.Millisecond)
 					continue
 				}
@@ -361,13 +647,24 @@ func (p *pluginv1Runner) Stop(exit bool) error {
 	for _, flusher := range p.FlusherPlugins {
 		flusher.Flusher.SetUrgent(exit)
 	}
-	for _, service := range p.ServicePlugins {
-		_ = service.Stop()
+	if p.LogstoreConfig.hotStandby != nil {
+		p.LogstoreConfig.hotStandby.stop()
+	} else {
+		for _, service := range p.ServicePlugins {
+			_ = service.Stop()
+		}
 	}
 	p.InputControl.WaitCancel()
 	logger.Info(p.LogstoreConfig.Context.GetRuntimeContext(), "metric plugins stop", "done", "service plugins stop", "done")
 
 	p.ProcessControl.WaitCancel()
+	for _, processor := range p.ProcessorPlugins {
+		for _, instance := range append([]pipeline.ProcessorV1{processor.Processor}, processor.laneProcessors...) {
+			if stoppable, ok := instance.(pipeline.StoppableProcessor); ok {
+				_ = stoppable.Stop()
+			}
+		}
+	}
 	logger.Info(p.LogstoreConfig.Context.GetRuntimeContext(), "processor plugins stop", "done")
 
 	p.AggregateControl.WaitCancel()
@@ -378,25 +675,27 @@ func (p *pluginv1Runner) Stop(exit bool) error {
 
 	if exit && p.FlushOutStore.Len() > 0 {
 		flushers := make([]pipeline.FlusherV1, len(p.FlusherPlugins))
+		audits := make([]*AuditPluginCount, len(p.FlusherPlugins))
 		for idx, flusher := range p.FlusherPlugins {
 			flushers[idx] = flusher.Flusher
+			audits[idx] = flusher.Audit
 		}
 		logger.Info(p.LogstoreConfig.Context.GetRuntimeContext(), "flushout loggroups, count", p.FlushOutStore.Len())
-		rst := flushOutStore(p.LogstoreConfig, p.FlushOutStore, flushers, func(lc *LogstoreConfig, sf pipeline.FlusherV1, store *FlushOutStore[protocol.LogGroup]) error {
+		rst := flushOutStore(p.LogstoreConfig, p.FlushOutStore, flushers, audits, func(lc *LogstoreConfig, sf pipeline.FlusherV1, store *FlushOutStore[protocol.LogGroup]) error {
 			return sf.Flush(lc.Context.GetProject(), lc.Context.GetLogstore(), lc.Context.GetConfigName(), store.Get())
 		})
 		logger.Info(p.LogstoreConfig.Context.GetRuntimeContext(), "flushout loggroups, result", rst)
 	}
 	for idx, flusher := range p.FlusherPlugins {
 		if err := flusher.Flusher.Stop(); err != nil {
-			logger.Warningf(p.LogstoreConfig.Context.GetRuntimeContext(), "STOP_FLUSHER_ALARM",
+			logger.Warningf(p.LogstoreConfig.Context.GetRuntimeContext(), util.AlarmStopFlusher,
 				"Failed to stop %vth flusher (description: %v): %v",
 				idx, flusher.Flusher.Description(), err)
 		}
 	}
 	logger.Info(p.LogstoreConfig.Context.GetRuntimeContext(), "flusher plugins stop", "done")
 
-	return nil
+	return stopBranches(p.LogstoreConfig, p.branches, exit)
 }
 
 func (p *pluginv1Runner) ReceiveRawLog(log *pipeline.LogWithContext) {
@@ -15,11 +15,15 @@
 package pluginmanager
 
 import (
+	"fmt"
+	"sync"
 	"time"
 
+	"github.com/alibaba/ilogtail/helper/scheduler"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/models"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 type pluginv2Runner struct {
@@ -39,6 +43,12 @@ type pluginv2Runner struct {
 	AggregatorPlugins []pipeline.AggregatorV2
 	FlusherPlugins    []pipeline.FlusherV2
 	TimerRunner       []*timerRunner
+	// the tracers of ProcessorPlugins and AggregatorPlugins by index.
+	ProcessorTracers  []*pluginTracer
+	AggregatorTracers []*pluginTracer
+	// the audit counts of ProcessorPlugins and FlusherPlugins by index.
+	ProcessorAudits []*AuditPluginCount
+	FlusherAudits   []*AuditPluginCount
 
 	FlushOutStore  *FlushOutStore[models.PipelineGroupEvents]
 	LogstoreConfig *LogstoreConfig
@@ -74,10 +84,20 @@ func (p *pluginv2Runner) Initialized() error {
 }
 
 func (p *pluginv2Runner) AddPlugin(pluginName string, category pluginCategory, plugin interface{}, config map[string]interface{}) error {
+	if _, ok := config[pluginMatchKey]; ok {
+		return fmt.Errorf("match of plugin %v is not supported in v2 pipeline", pluginName)
+	}
+	if _, ok := config[pluginCircuitBreakerKey]; ok {
+		return fmt.Errorf("circuit breaker of plugin %v is not supported in v2 pipeline", pluginName)
+	}
+	if _, ok := config[pluginSinkKey]; ok {
+		return fmt.Errorf("sink of plugin %v is not supported in v2 pipeline", pluginName)
+	}
 	switch category {
 	case pluginMetricInput:
 		if metric, ok := plugin.(pipeline.MetricInputV2); ok {
-			return p.addMetricInput(metric, config["interval"].(int))
+			job, _ := config[pluginScheduleKey].(*scheduler.Job)
+			return p.addMetricInput(metric, config["interval"].(int), job)
 		}
 	case pluginServiceInput:
 		if service, ok := plugin.(pipeline.ServiceInputV2); ok {
@@ -85,15 +105,15 @@ func (p *pluginv2Runner) AddPlugin(pluginName string, category pluginCategory, p
 		}
 	case pluginProcessor:
 		if processor, ok := plugin.(pipeline.ProcessorV2); ok {
-			return p.addProcessor(processor, config["priority"].(int))
+			return p.addProcessor(pluginName, processor, config["priority"].(int))
 		}
 	case pluginAggregator:
 		if aggregator, ok := plugin.(pipeline.AggregatorV2); ok {
-			return p.addAggregator(aggregator)
+			return p.addAggregator(pluginName, aggregator)
 		}
 	case pluginFlusher:
 		if flusher, ok := plugin.(pipeline.FlusherV2); ok {
-			return p.addFlusher(flusher)
+			return p.addFlusher(pluginName, flusher)
 		}
 	default:
 		return pluginCategoryUndefinedError(category)
@@ -116,11 +136,12 @@ func (p *pluginv2Runner) RunPlugins(category pluginCategory, control *pipeline.A
 	}
 }
 
-func (p *pluginv2Runner) addMetricInput(input pipeline.MetricInputV2, interval int) error {
+func (p *pluginv2Runner) addMetricInput(input pipeline.MetricInputV2, interval int, job *scheduler.Job) error {
 	p.MetricPlugins = append(p.MetricPlugins, input)
 	p.TimerRunner = append(p.TimerRunner, &timerRunner{
 		state:         input,
 		interval:      time.Duration(interval) * time.Millisecond,
+		job:           job,
 		context:       p.LogstoreConfig.Context,
 		latencyMetric: p.LogstoreConfig.Statistics.CollecLatencytMetric,
 	})
@@ -132,16 +153,19 @@ func (p *pluginv2Runner) addServiceInput(input pipeline.ServiceInputV2) error {
 	return nil
 }
 
-func (p *pluginv2Runner) addProcessor(processor pipeline.ProcessorV2, _ int) error {
+func (p *pluginv2Runner) addProcessor(pluginName string, processor pipeline.ProcessorV2, _ int) error {
 	p.ProcessorPlugins = append(p.ProcessorPlugins, processor)
+	p.ProcessorTracers = append(p.ProcessorTracers, newPluginTracer(p.LogstoreConfig, pluginName, "processor"))
+	p.ProcessorAudits = append(p.ProcessorAudits, p.LogstoreConfig.auditor.addProcessor(pluginName))
 	return nil
 }
 
-func (p *pluginv2Runner) addAggregator(aggregator pipeline.AggregatorV2) error {
+func (p *pluginv2Runner) addAggregator(pluginName string, aggregator pipeline.AggregatorV2) error {
 	p.AggregatorPlugins = append(p.AggregatorPlugins, aggregator)
+	p.AggregatorTracers = append(p.AggregatorTracers, newPluginTracer(p.LogstoreConfig, pluginName, "aggregator"))
 	interval, err := aggregator.Init(p.LogstoreConfig.Context, &AggregatorWrapper{})
 	if err != nil {
-		logger.Error(p.LogstoreConfig.Context.GetRuntimeContext(), "AGGREGATOR_INIT_ERROR", "Aggregator failed to initialize", aggregator.Description(), "error", err)
+		logger.Error(p.LogstoreConfig.Context.GetRuntimeContext(), util.AlarmAggregatorInit, "Aggregator failed to initialize", aggregator.Description(), "error", err)
 		return err
 	}
 	if interval == 0 {
@@ -156,27 +180,51 @@ func (p *pluginv2Runner) addAggregator(aggregator pipeline.AggregatorV2) error {
 	return nil
 }
 
-func (p *pluginv2Runner) addFlusher(flusher pipeline.FlusherV2) error {
+func (p *pluginv2Runner) addFlusher(pluginName string, flusher pipeline.FlusherV2) error {
 	p.FlusherPlugins = append(p.FlusherPlugins, flusher)
+	p.FlusherAudits = append(p.FlusherAudits, p.LogstoreConfig.auditor.addFlusher(pluginName))
 	return nil
 }
 
 func (p *pluginv2Runner) runInput() {
 	p.InputControl.Reset()
 	p.runMetricInput(p.InputControl)
+	if p.LogstoreConfig.hotStandby != nil {
+		// the services are stopped and waited before they are started again by the next leadership
+		var services sync.WaitGroup
+		p.LogstoreConfig.hotStandby.run(func() {
+			for _, input := range p.ServicePlugins {
+				services.Add(1)
+				go func(input pipeline.ServiceInputV2) {
+					defer services.Done()
+					p.runServiceInput(input)
+				}(input)
+			}
+		}, func() {
+			for _, input := range p.ServicePlugins {
+				_ = input.Stop()
+			}
+			services.Wait()
+		})
+		return
+	}
 	for _, input := range p.ServicePlugins {
 		service := input
 		p.InputControl.Run(func(c *pipeline.AsyncControl) {
-			logger.Info(p.LogstoreConfig.Context.GetRuntimeContext(), "start run service", service)
-			defer panicRecover(service.Description())
-			if err := service.StartService(p.InputPipeContext); err != nil {
-				logger.Error(p.LogstoreConfig.Context.GetRuntimeContext(), "PLUGIN_ALARM", "start service error, err", err)
-			}
-			logger.Info(p.LogstoreConfig.Context.GetRuntimeContext(), "service done", service.Description())
+			p.runServiceInput(service)
 		})
 	}
 }
 
+func (p *pluginv2Runner) runServiceInput(service pipeline.ServiceInputV2) {
+	logger.Info(p.LogstoreConfig.Context.GetRuntimeContext(), "start run service", service)
+	defer panicRecover(service.Description())
+	if err := service.StartService(p.InputPipeContext); err != nil {
+		logger.Error(p.LogstoreConfig.Context.GetRuntimeContext(), util.AlarmPlugin, "start service error, err", err)
+	}
+	logger.Info(p.LogstoreConfig.Context.GetRuntimeContext(), "service done", service.Description())
+}
+
 func (p *pluginv2Runner) runMetricInput(control *pipeline.AsyncControl) {
 	for _, t := range p.TimerRunner {
 		if plugin, ok := t.state.(pipeline.MetricInputV2); ok {
@@ -208,12 +256,19 @@ func (p *pluginv2Runner) runProcessorInternal(cc *pipeline.AsyncControl) {
 			}
 		case group := <-pipeChan:
 			p.LogstoreConfig.Statistics.RawLogMetric.Add(int64(len(group.Events)))
+			p.LogstoreConfig.auditor.countRead(len(group.Events))
 			pipeEvents := []*models.PipelineGroupEvents{group}
-			for _, processor := range p.ProcessorPlugins {
+			tapGroupEvents(p.LogstoreConfig, tapStageInput, pipeEvents)
+			for i, processor := range p.ProcessorPlugins {
+				inputCount := countGroupEvents(pipeEvents)
 				for _, in := range pipeEvents {
+					span := p.ProcessorTracers[i].begin()
 					processor.Process(in, pipeContext)
+					p.ProcessorTracers[i].end(span, len(in.Events))
 				}
 				pipeEvents = pipeContext.Collector().ToArray()
+				p.ProcessorAudits[i].process(inputCount, countGroupEvents(pipeEvents))
+				tapGroupEvents(p.LogstoreConfig, tapStage(i), pipeEvents)
 				if len(pipeEvents) == 0 {
 					break
 				}
@@ -221,20 +276,23 @@ func (p *pluginv2Runner) runProcessorInternal(cc *pipeline.AsyncControl) {
 			if len(pipeEvents) == 0 {
 				break
 			}
-			for _, aggregator := range p.AggregatorPlugins {
+			p.LogstoreConfig.timestampPolicy.applyGroupEvents(pipeEvents)
+			for i, aggregator := range p.AggregatorPlugins {
 				for _, pipeEvent := range pipeEvents {
 					if len(pipeEvent.Events) == 0 {
 						continue
 					}
 					p.LogstoreConfig.Statistics.SplitLogMetric.Add(int64(len(pipeEvent.Events)))
 					for tryCount := 1; true; tryCount++ {
+						span := p.AggregatorTracers[i].begin()
 						err := aggregator.Record(pipeEvent, p.AggregatePipeContext)
+						p.AggregatorTracers[i].end(span, len(pipeEvent.Events))
 						if err == nil {
 							break
 						}
 						// wait until shutdown is active
 						if tryCount%100 == 0 {
-							logger.Warning(p.LogstoreConfig.Context.GetRuntimeContext(), "AGGREGATOR_ADD_ALARM", "error", err)
+							logger.Warning(p.LogstoreConfig.Context.GetRuntimeContext(), util.AlarmAggregatorAdd, "error", err)
 						}
 						time.Sleep(time.Millisecond * 10)
 					}
@@ -294,6 +352,11 @@ func (p *pluginv2Runner) runFlusherInternal(cc *pipeline.AsyncControl) {
 			for i := 1; i < dataSize; i++ {
 				data[i] = <-pipeChan
 			}
+			if p.LogstoreConfig.priority.shouldShed() {
+				p.LogstoreConfig.priority.shedGroupEvents(data)
+				p.LogstoreConfig.auditor.countShed(countGroupEvents(data))
+				continue
+			}
 			p.LogstoreConfig.Statistics.FlushLogGroupMetric.Add(int64(len(data)))
 
 			// Add tags for each non-empty LogGroup, includes: default hostname tag,
@@ -305,6 +368,7 @@ func (p *pluginv2Runner) runFlusherInternal(cc *pipeline.AsyncControl) {
 				p.LogstoreConfig.Statistics.FlushLogMetric.Add(int64(len(item.Events)))
 				item.Group.GetTags().Merge(loadAdditionalTags(p.LogstoreConfig.GlobalConfig))
 			}
+			tapGroupEvents(p.LogstoreConfig, tapStageFlush, data)
 
 			// Flush LogGroups to all flushers.
 			// Note: multiple flushers is unrecommended, because all flushers will
@@ -319,19 +383,28 @@ func (p *pluginv2Runner) runFlusherInternal(cc *pipeline.AsyncControl) {
 					}
 				}
 				if allReady {
-					for _, flusher := range p.FlusherPlugins {
+					events := countGroupEvents(data)
+					p.LogstoreConfig.auditor.countFlushed(events)
+					for i, flusher := range p.FlusherPlugins {
 						p.LogstoreConfig.Statistics.FlushReadyMetric.Add(1)
 						p.LogstoreConfig.Statistics.FlushLatencyMetric.Begin()
 						err := flusher.Export(data, p.FlushPipeContext)
 						p.LogstoreConfig.Statistics.FlushLatencyMetric.End()
+						p.FlusherAudits[i].export(events, err)
 						if err != nil {
-							logger.Error(p.LogstoreConfig.Context.GetRuntimeContext(), "FLUSH_DATA_ALARM", "flush data error",
+							logger.Error(p.LogstoreConfig.Context.GetRuntimeContext(), util.AlarmFlushData, "flush data error",
 								p.LogstoreConfig.ProjectName, p.LogstoreConfig.LogstoreName, err)
 						}
 					}
 					break
 				}
 				if !p.LogstoreConfig.FlushOutFlag {
+					// the flushers are not ready, drop the data instead of waiting when the config is being shed
+					if p.LogstoreConfig.priority.shouldShed() {
+						p.LogstoreConfig.priority.shedGroupEvents(data)
+						p.LogstoreConfig.auditor.countShed(countGroupEvents(data))
+						break
+					}
 					time.Sleep(time.Duration(10) * time.Millisecond)
 					continue
 				}
@@ -349,13 +422,22 @@ func (p *pluginv2Runner) Stop(exit bool) error {
 	for _, flusher := range p.FlusherPlugins {
 		flusher.SetUrgent(exit)
 	}
-	for _, serviceInput := range p.ServicePlugins {
-		_ = serviceInput.Stop()
+	if p.LogstoreConfig.hotStandby != nil {
+		p.LogstoreConfig.hotStandby.stop()
+	} else {
+		for _, serviceInput := range p.ServicePlugins {
+			_ = serviceInput.Stop()
+		}
 	}
 	p.InputControl.WaitCancel()
 	logger.Info(p.LogstoreConfig.Context.GetRuntimeContext(), "metric plugins stop", "done", "service plugins stop", "done")
 
 	p.ProcessControl.WaitCancel()
+	for _, processor := range p.ProcessorPlugins {
+		if stoppable, ok := processor.(pipeline.StoppableProcessor); ok {
+			_ = stoppable.Stop()
+		}
+	}
 	logger.Info(p.LogstoreConfig.Context.GetRuntimeContext(), "processor plugins stop", "done")
 
 	p.AggregateControl.WaitCancel()
@@ -366,14 +448,14 @@ func (p *pluginv2Runner) Stop(exit bool) error {
 
 	if exit && p.FlushOutStore.Len() > 0 {
 		logger.Info(p.LogstoreConfig.Context.GetRuntimeContext(), "Flushout group events, count", p.FlushOutStore.Len())
-		rst := flushOutStore(p.LogstoreConfig, p.FlushOutStore, p.FlusherPlugins, func(lc *LogstoreConfig, pf pipeline.FlusherV2, store *FlushOutStore[models.PipelineGroupEvents]) error {
+		rst := flushOutStore(p.LogstoreConfig, p.FlushOutStore, p.FlusherPlugins, p.FlusherAudits, func(lc *LogstoreConfig, pf pipeline.FlusherV2, store *FlushOutStore[models.PipelineGroupEvents]) error {
 			return pf.Export(store.Get(), p.FlushPipeContext)
 		})
 		logger.Info(p.LogstoreConfig.Context.GetRuntimeContext(), "Flushout group events, result", rst)
 	}
 	for idx, flusher := range p.FlusherPlugins {
 		if err := flusher.Stop(); err != nil {
-			logger.Warningf(p.LogstoreConfig.Context.GetRuntimeContext(), "STOP_FLUSHER_ALARM",
+			logger.Warningf(p.LogstoreConfig.Context.GetRuntimeContext(), util.AlarmStopFlusher,
 				"Failed to stop %vth flusher (description: %v): %v",
 				idx, flusher.Description(), err)
 		}
@@ -0,0 +1,203 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"runtime/metrics"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+// enablePluginTracing measures each call of the processors and aggregators of the configs loaded afterwards.
+var enablePluginTracing = false
+
+// slowPluginThreshold is the latency of a single call beyond which the plugin is reported as slow.
+var slowPluginThreshold = time.Second
+
+const (
+	// pluginTraceWindow is the count of the latest calls used to calculate the moving percentiles.
+	pluginTraceWindow = 1024
+	// slowPluginAlarmInterval limits the alarms of a slow plugin.
+	slowPluginAlarmInterval = time.Minute
+
+	allocBytesMetric   = "/gc/heap/allocs:bytes"
+	allocObjectsMetric = "/gc/heap/allocs:objects"
+)
+
+// PluginTrace is the execution statistics of a plugin in a config.
+type PluginTrace struct {
+	ConfigName string `json:"config_name"`
+	Plugin     string `json:"plugin"`
+	Category   string `json:"category"`
+	Calls      int64  `json:"calls"`
+	Events     int64  `json:"events"`
+	SlowCalls  int64  `json:"slow_calls"`
+	// The latencies are calculated from the latest calls.
+	LatencyAvg time.Duration `json:"latency_avg_ns"`
+	LatencyP50 time.Duration `json:"latency_p50_ns"`
+	LatencyP90 time.Duration `json:"latency_p90_ns"`
+	LatencyP99 time.Duration `json:"latency_p99_ns"`
+	LatencyMax time.Duration `json:"latency_max_ns"`
+	// The allocations of the whole process during the calls, which are approximate because the runtime flushes
+	// the counters lazily and other goroutines allocate concurrently.
+	AllocBytes   uint64 `json:"alloc_bytes"`
+	AllocObjects uint64 `json:"alloc_objects"`
+}
+
+// pluginTracer records the calls of a plugin. The methods of a nil tracer do nothing, so the plugins
+// could be called in the same way when tracing is disabled.
+type pluginTracer struct {
+	config   *LogstoreConfig
+	plugin   string
+	category string
+
+	mu           sync.Mutex
+	calls        int64
+	events       int64
+	slowCalls    int64
+	allocBytes   uint64
+	allocObjects uint64
+	window       [pluginTraceWindow]time.Duration
+	lastAlarm    time.Time
+}
+
+// pluginSpan is a call being traced.
+type pluginSpan struct {
+	begin   time.Time
+	samples [2]metrics.Sample
+}
+
+// newPluginTracer returns nil when tracing is disabled, otherwise registers the tracer in @config.
+func newPluginTracer(config *LogstoreConfig, plugin, category string) *pluginTracer {
+	if !enablePluginTracing || config == nil {
+		return nil
+	}
+	t := &pluginTracer{config: config, plugin: plugin, category: category}
+	config.pluginTracers = append(config.pluginTracers, t)
+	return t
+}
+
+func readAllocs(samples *[2]metrics.Sample) {
+	samples[0].Name, samples[1].Name = allocBytesMetric, allocObjectsMetric
+	metrics.Read(samples[:])
+}
+
+func (t *pluginTracer) begin() (span pluginSpan) {
+	if t == nil {
+		return
+	}
+	readAllocs(&span.samples)
+	span.begin = time.Now()
+	return
+}
+
+// end records the call started by @span which handles @events events.
+func (t *pluginTracer) end(span pluginSpan, events int) {
+	if t == nil {
+		return
+	}
+	latency := time.Since(span.begin)
+	var after [2]metrics.Sample
+	readAllocs(&after)
+
+	t.mu.Lock()
+	t.window[t.calls%pluginTraceWindow] = latency
+	t.calls++
+	t.events += int64(events)
+	if after[0].Value.Kind() == metrics.KindUint64 {
+		t.allocBytes += after[0].Value.Uint64() - span.samples[0].Value.Uint64()
+		t.allocObjects += after[1].Value.Uint64() - span.samples[1].Value.Uint64()
+	}
+	alarm := false
+	if latency >= slowPluginThreshold {
+		t.slowCalls++
+		if now := time.Now(); now.Sub(t.lastAlarm) >= slowPluginAlarmInterval {
+			t.lastAlarm, alarm = now, true
+		}
+	}
+	t.mu.Unlock()
+
+	if alarm {
+		logger.Warning(t.config.Context.GetRuntimeContext(), util.AlarmSlowPlugin, "plugin", t.plugin, "category", t.category,
+			"latency", latency, "events", events, "threshold", slowPluginThreshold)
+	}
+}
+
+func (t *pluginTracer) trace() PluginTrace {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	trace := PluginTrace{
+		ConfigName:   t.config.ConfigName,
+		Plugin:       t.plugin,
+		Category:     t.category,
+		Calls:        t.calls,
+		Events:       t.events,
+		SlowCalls:    t.slowCalls,
+		AllocBytes:   t.allocBytes,
+		AllocObjects: t.allocObjects,
+	}
+	n := int(t.calls)
+	if n > pluginTraceWindow {
+		n = pluginTraceWindow
+	}
+	if n == 0 {
+		return trace
+	}
+	latencies := make([]time.Duration, n)
+	copy(latencies, t.window[:n])
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+	trace.LatencyAvg = total / time.Duration(n)
+	trace.LatencyP50 = percentile(latencies, 0.5)
+	trace.LatencyP90 = percentile(latencies, 0.9)
+	trace.LatencyP99 = percentile(latencies, 0.99)
+	trace.LatencyMax = latencies[n-1]
+	return trace
+}
+
+// SlowestPlugins returns the traces of the plugins in the running configs ordered by the p99 latency,
+// at most @top ones if @top is positive.
+func SlowestPlugins(top int) []PluginTrace {
+	traces := make([]PluginTrace, 0)
+	for _, config := range runningConfigs() {
+		for _, tracer := range config.pluginTracers {
+			traces = append(traces, tracer.trace())
+		}
+	}
+	sort.SliceStable(traces, func(i, j int) bool {
+		if traces[i].LatencyP99 != traces[j].LatencyP99 {
+			return traces[i].LatencyP99 > traces[j].LatencyP99
+		}
+		return traces[i].LatencyAvg > traces[j].LatencyAvg
+	})
+	if top > 0 && len(traces) > top {
+		traces = traces[:top]
+	}
+	return traces
+}
+
+func init() {
+	_ = util.InitFromEnvBool("ALIYUN_LOGTAIL_ENABLE_PLUGIN_TRACING", &enablePluginTracing, false)
+	thresholdMs := int(slowPluginThreshold / time.Millisecond)
+	_ = util.InitFromEnvInt("ALIYUN_LOGTAIL_SLOW_PLUGIN_THRESHOLD_MS", &thresholdMs, thresholdMs)
+	slowPluginThreshold = time.Duration(thresholdMs) * time.Millisecond
+}
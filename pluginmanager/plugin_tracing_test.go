@@ -0,0 +1,98 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux || windows
+// +build linux windows
+
+package pluginmanager
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+// sleepProcessor sleeps for the milliseconds in the first content of the logs.
+type sleepProcessor struct{}
+
+func (*sleepProcessor) Init(pipeline.Context) error { return nil }
+
+func (*sleepProcessor) Description() string { return "" }
+
+func (*sleepProcessor) ProcessLogs(logs []*protocol.Log) []*protocol.Log {
+	for _, log := range logs {
+		ms, _ := strconv.Atoi(log.Contents[0].Value)
+		time.Sleep(time.Duration(ms) * time.Millisecond)
+	}
+	return logs
+}
+
+func TestPluginTracing(t *testing.T) {
+	enablePluginTracing, slowPluginThreshold = true, 20*time.Millisecond
+	defer func() {
+		enablePluginTracing, slowPluginThreshold = false, time.Second
+	}()
+
+	contextImp := &ContextImp{}
+	contextImp.InitContext("p", "l", "c")
+	lc := &LogstoreConfig{ConfigName: "c", Context: contextImp, GlobalConfig: &GlobalConfig{AggregatIntervalMs: 1000}}
+	lc.Statistics.Init(contextImp)
+	runner := &pluginv1Runner{LogstoreConfig: lc, FlushOutStore: NewFlushOutStore[protocol.LogGroup]()}
+	require.NoError(t, runner.Init(10, 10))
+	require.NoError(t, runner.addProcessor("processor_sleep", &sleepProcessor{}, 0))
+	require.NoError(t, runner.addAggregator("aggregator_record", &recordAggregator{seqs: make(map[string][]int)}))
+	require.Len(t, lc.pluginTracers, 2)
+
+	for _, ms := range []string{"0", "30", "0", "0"} {
+		runner.processLog(&pipeline.LogWithContext{
+			Log:     &protocol.Log{Contents: []*protocol.Log_Content{{Key: "sleep", Value: ms}}},
+			Context: map[string]interface{}{"source": "s"},
+		}, 0)
+	}
+
+	LogtailConfig["c"] = lc
+	defer delete(LogtailConfig, "c")
+	traces := SlowestPlugins(1)
+	require.Len(t, traces, 1)
+	processor := traces[0]
+	assert.Equal(t, "processor_sleep", processor.Plugin)
+	assert.Equal(t, "processor", processor.Category)
+	assert.Equal(t, "c", processor.ConfigName)
+	assert.Equal(t, int64(4), processor.Calls)
+	assert.Equal(t, int64(4), processor.Events)
+	assert.Equal(t, int64(1), processor.SlowCalls)
+	assert.GreaterOrEqual(t, processor.LatencyMax, 30*time.Millisecond)
+	assert.Equal(t, processor.LatencyMax, processor.LatencyP99)
+	assert.Less(t, processor.LatencyP50, 20*time.Millisecond)
+
+	traces = SlowestPlugins(0)
+	require.Len(t, traces, 2)
+	assert.Equal(t, "aggregator_record", traces[1].Plugin)
+	assert.Equal(t, int64(4), traces[1].Calls)
+}
+
+func TestPluginTracingDisabled(t *testing.T) {
+	lc := &LogstoreConfig{ConfigName: "c"}
+	tracer := newPluginTracer(lc, "processor_sleep", "processor")
+	assert.Nil(t, tracer)
+	assert.Empty(t, lc.pluginTracers)
+	// a nil tracer could be called directly
+	tracer.end(tracer.begin(), 1)
+}
@@ -15,7 +15,10 @@
 package pluginmanager
 
 import (
+	"sync"
+
 	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
 )
 
 type ProcessorWrapper struct {
@@ -23,6 +26,52 @@ type ProcessorWrapper struct {
 	Config    *LogstoreConfig
 	LogsChan  chan *pipeline.LogWithContext
 	Priority  int
+	Tracer    *pluginTracer
+	Audit     *AuditPluginCount
+	// the instances of Processor for the processor lanes other than the first one, see runProcessorLanes.
+	laneProcessors []pipeline.ProcessorV1
+	// shared means Processor is a sharedProcessor used by all the processor lanes, which is ticked by the first one.
+	shared bool
+}
+
+// sharedProcessor runs the processor for the processor lanes one by one, so that its states cover the logs of
+// all the lanes.
+type sharedProcessor struct {
+	pipeline.ProcessorV1
+	lock sync.Mutex
+}
+
+func (p *sharedProcessor) ProcessLogs(logArray []*protocol.Log) []*protocol.Log {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.ProcessorV1.ProcessLogs(logArray)
+}
+
+// Stop stops the processor if it's a StoppableProcessor, which is hidden by the wrapper.
+func (p *sharedProcessor) Stop() error {
+	if stoppable, ok := p.ProcessorV1.(pipeline.StoppableProcessor); ok {
+		return stoppable.Stop()
+	}
+	return nil
+}
+
+// Tick calls the processor if it's a TickProcessor, which is hidden by the wrapper.
+func (p *sharedProcessor) Tick() []*protocol.Log {
+	ticker, ok := p.ProcessorV1.(pipeline.TickProcessor)
+	if !ok {
+		return nil
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return ticker.Tick()
+}
+
+// instance returns the instance of Processor for the processor lane.
+func (c *ProcessorWrapper) instance(lane int) pipeline.ProcessorV1 {
+	if lane > 0 && lane <= len(c.laneProcessors) {
+		return c.laneProcessors[lane-1]
+	}
+	return c.Processor
 }
 
 type ProcessorWrapperArray []*ProcessorWrapper
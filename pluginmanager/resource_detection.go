@@ -0,0 +1,104 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper/resourcedetection"
+	"github.com/alibaba/ilogtail/pkg/logger"
+)
+
+// ResourceDetectionConfig detects the cloud resource the agent runs on from the instance metadata services, and
+// adds the attributes to the tags of all the log groups, e.g.
+//
+//	{"ResourceDetection": {"Detectors": ["ecs", "ec2"], "TimeoutMs": 2000}}
+//
+// The detectors are "ecs", "ec2", "gce" and "azure", and the attributes follow the OpenTelemetry semantic
+// conventions: cloud.provider, cloud.platform, cloud.region, cloud.availability_zone, cloud.account.id, host.id
+// and host.type. The detection runs when the agent starts and every RefreshIntervalSec, and the result is cached,
+// the detectors failed are ignored and the cached result is kept if all of them fail. The detectors listed first
+// take precedence, and the Tags of the configs take precedence over them.
+type ResourceDetectionConfig struct {
+	Detectors []string
+	// The timeout of each detector, 2000 by default.
+	TimeoutMs int
+	// The interval to detect again, 3600 by default, and negative disables the refresh.
+	RefreshIntervalSec int
+}
+
+const defaultResourceRefreshIntervalSec = 3600
+
+var (
+	resourceTagsLock sync.RWMutex
+	detectedTags     map[string]string
+	refreshOnce      sync.Once
+)
+
+// detectResource detects the resource if it's enabled in the global config of the agent, and refreshes it in
+// background.
+func detectResource() {
+	config := LogtailGlobalConfig.ResourceDetection
+	if config == nil || len(config.Detectors) == 0 {
+		return
+	}
+	refreshResource(config)
+	interval := config.RefreshIntervalSec
+	if interval == 0 {
+		interval = defaultResourceRefreshIntervalSec
+	}
+	if interval < 0 {
+		return
+	}
+	refreshOnce.Do(func() {
+		go func() {
+			defer panicRecover("resource detection")
+			ticker := time.NewTicker(time.Duration(interval) * time.Second)
+			defer ticker.Stop()
+			for range ticker.C {
+				if config := LogtailGlobalConfig.ResourceDetection; config != nil && len(config.Detectors) > 0 {
+					refreshResource(config)
+				}
+			}
+		}()
+	})
+}
+
+// refreshResource runs the detectors, and caches the attributes unless none of the detectors succeeded.
+func refreshResource(config *ResourceDetectionConfig) {
+	tags, failures := resourcedetection.Detect(config.Detectors, time.Duration(config.TimeoutMs)*time.Millisecond)
+	for name, err := range failures {
+		logger.Info(context.Background(), "resource detector failed", name, "error", err)
+	}
+	if len(tags) == 0 {
+		return
+	}
+	resourceTagsLock.Lock()
+	defer resourceTagsLock.Unlock()
+	if !reflect.DeepEqual(tags, detectedTags) {
+		logger.Info(context.Background(), "resource detected", tags)
+		detectedTags = tags
+	}
+}
+
+// resourceTags returns the cached attributes of the detected resource.
+func resourceTags() map[string]string {
+	resourceTagsLock.RLock()
+	defer resourceTagsLock.RUnlock()
+	return detectedTags
+}
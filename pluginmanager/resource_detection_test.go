@@ -0,0 +1,52 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceTags(t *testing.T) {
+	resourceTagsLock.Lock()
+	detectedTags = map[string]string{"cloud.region": "cn-hangzhou", "host.id": "i-bp1"}
+	resourceTagsLock.Unlock()
+	defer func() {
+		resourceTagsLock.Lock()
+		detectedTags = nil
+		resourceTagsLock.Unlock()
+	}()
+
+	tags := loadAdditionalTags(&GlobalConfig{Tags: map[string]string{"host.id": "custom"}})
+	assert.Equal(t, "cn-hangzhou", tags.Get("cloud.region"))
+	// the tags of the config take precedence over the detected ones
+	assert.Equal(t, "custom", tags.Get("host.id"))
+}
+
+func TestRefreshResourceKeepTags(t *testing.T) {
+	resourceTagsLock.Lock()
+	detectedTags = map[string]string{"cloud.region": "cn-hangzhou"}
+	resourceTagsLock.Unlock()
+	defer func() {
+		resourceTagsLock.Lock()
+		detectedTags = nil
+		resourceTagsLock.Unlock()
+	}()
+
+	// the detected tags are kept if all the detectors fail
+	refreshResource(&ResourceDetectionConfig{Detectors: []string{"unknown"}})
+	assert.Equal(t, map[string]string{"cloud.region": "cn-hangzhou"}, resourceTags())
+}
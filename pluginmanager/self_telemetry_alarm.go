@@ -15,6 +15,9 @@
 package pluginmanager
 
 import (
+	"sort"
+	"time"
+
 	"github.com/alibaba/ilogtail/pkg"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
@@ -37,7 +40,7 @@ func (r *InputAlarm) Description() string {
 
 func (r *InputAlarm) Collect(collector pipeline.Collector) error {
 	loggroup := &protocol.LogGroup{}
-	for _, config := range LogtailConfig {
+	for _, config := range runningConfigs() {
 		alarm := config.Context.GetRuntimeContext().Value(pkg.LogTailMeta).(*pkg.LogtailContextMeta).GetAlarm()
 		if alarm != nil {
 			alarm.SerializeToPb(loggroup)
@@ -54,6 +57,40 @@ func (r *InputAlarm) Collect(collector pipeline.Collector) error {
 	return nil
 }
 
+// RecentAlarms returns the recent alarm records which last happened after @since and are not less severe
+// than @minSeverity, ordered by the last time descending. The records of the running configs are attached
+// with the config name, and only the records of @configName are returned if it is not empty.
+func RecentAlarms(configName string, minSeverity util.AlarmSeverity, since time.Time) []util.AlarmRecord {
+	records := make([]util.AlarmRecord, 0)
+	appendRecords := func(alarm *util.Alarm, name string) {
+		if alarm == nil {
+			return
+		}
+		for _, record := range alarm.Recent(since) {
+			if record.Severity >= minSeverity {
+				record.ConfigName = name
+				records = append(records, record)
+			}
+		}
+	}
+	for name, config := range runningConfigs() {
+		if configName != "" && name != configName {
+			continue
+		}
+		if meta, ok := config.Context.GetRuntimeContext().Value(pkg.LogTailMeta).(*pkg.LogtailContextMeta); ok {
+			appendRecords(meta.GetAlarm(), name)
+		}
+	}
+	if configName == "" {
+		appendRecords(util.GlobalAlarm, "")
+		for _, alarm := range util.GetRegisterAlarms() {
+			appendRecords(alarm, "")
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].LastTime.After(records[j].LastTime) })
+	return records
+}
+
 func init() {
 	pipeline.MetricInputs["metric_alarm"] = func() pipeline.MetricInput {
 		return &InputAlarm{}
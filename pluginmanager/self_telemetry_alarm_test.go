@@ -0,0 +1,54 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux || windows
+// +build linux windows
+
+package pluginmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+func TestRecentAlarms(t *testing.T) {
+	contextImp := &ContextImp{}
+	contextImp.InitContext("p", "l", "alarm")
+	LogtailConfig["alarm"] = &LogstoreConfig{ConfigName: "alarm", Context: contextImp}
+	defer delete(LogtailConfig, "alarm")
+	start := time.Now()
+
+	contextImp.common.RecordAlarm(util.AlarmRegexUnmatched, "unmatch")
+	contextImp.common.RecordAlarm(util.AlarmFlushData, "flush error")
+	time.Sleep(time.Millisecond)
+	util.GlobalAlarm.Record(util.AlarmDropData, "drop data")
+
+	records := RecentAlarms("alarm", util.AlarmSeverityInfo, start)
+	require.Len(t, records, 2)
+	for _, record := range records {
+		assert.Equal(t, "alarm", record.ConfigName)
+	}
+
+	records = RecentAlarms("", util.AlarmSeverityError, start)
+	require.Len(t, records, 2)
+	assert.Equal(t, util.AlarmDropData, records[0].Type, "the latest record should be the first")
+	assert.Equal(t, "", records[0].ConfigName)
+	assert.Equal(t, util.AlarmFlushData, records[1].Type)
+	assert.Empty(t, RecentAlarms("", util.AlarmSeverityInfo, time.Now()))
+}
@@ -0,0 +1,334 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const selfMetricPrefix = "ilogtail_"
+
+type selfMetricKind int
+
+const (
+	selfMetricCounter selfMetricKind = iota
+	selfMetricGauge
+	// selfMetricLatency is a gauge of the average latency in seconds since the last statistics.
+	selfMetricLatency
+)
+
+// SelfMetric is a sample of the self telemetry metrics of the agent.
+type SelfMetric struct {
+	Name    string
+	Labels  map[string]string
+	Value   float64
+	Counter bool
+}
+
+// CollectSelfMetrics returns the self telemetry metrics of the running configs, including the metrics
+// registered by plugins, the depths of the pipeline queues and the alarm counts. The counters are cumulative,
+// unlike the statistics shipped to SLS.
+func CollectSelfMetrics() []SelfMetric {
+	var metrics []SelfMetric
+	running := runningConfigs()
+	configs := make([]*LogstoreConfig, 0, len(running)+3)
+	for _, config := range running {
+		configs = append(configs, config)
+	}
+	for _, config := range []*LogstoreConfig{StatisticsConfig, AlarmConfig, ContainerConfig} {
+		if config != nil {
+			configs = append(configs, config)
+		}
+	}
+	for _, config := range configs {
+		labels := map[string]string{
+			"project":     config.ProjectName,
+			"logstore":    config.LogstoreName,
+			"config_name": config.ConfigName,
+		}
+		if config.GlobalConfig != nil && config.GlobalConfig.Tenant != "" {
+			labels["tenant"] = config.GlobalConfig.Tenant
+		}
+		if contextImp, ok := config.Context.(*ContextImp); ok {
+			contextImp.collectSelfMetrics(func(name string, value float64, kind selfMetricKind) {
+				metrics = append(metrics, newSelfMetric(name, labels, value, kind))
+			})
+			if contextImp.common != nil {
+				metrics = appendAlarmMetrics(metrics, contextImp.common.GetAlarm(), labels)
+			}
+		}
+		metrics = appendQueueMetrics(metrics, config.PluginRunner, labels)
+		if config.hotStandby != nil {
+			leader := 0.0
+			if config.hotStandby.isLeader() {
+				leader = 1
+			}
+			metrics = append(metrics, newSelfMetric("hot_standby_leader", labels, leader, selfMetricGauge))
+		}
+	}
+	metrics = appendTenantMetrics(metrics)
+	if LogtailGlobalConfig.PriorityShedding != nil {
+		metrics = append(metrics, newSelfMetric("priority_shedding_level", map[string]string{},
+			float64(atomic.LoadInt32(&prioritySheddingLevel)), selfMetricGauge))
+	}
+	metrics = appendAlarmMetrics(metrics, util.GlobalAlarm, map[string]string{})
+	for _, alarm := range util.GetRegisterAlarms() {
+		metrics = appendAlarmMetrics(metrics, alarm, map[string]string{"project": alarm.Project, "logstore": alarm.Logstore})
+	}
+	return metrics
+}
+
+func newSelfMetric(name string, labels map[string]string, value float64, kind selfMetricKind) SelfMetric {
+	name = selfMetricPrefix + sanitizeMetricName(name)
+	switch kind {
+	case selfMetricCounter:
+		name += "_total"
+	case selfMetricLatency:
+		name += "_seconds"
+	}
+	return SelfMetric{Name: name, Labels: labels, Value: value, Counter: kind == selfMetricCounter}
+}
+
+func appendQueueMetrics(metrics []SelfMetric, runner PluginRunner, labels map[string]string) []SelfMetric {
+	logsLen, logsCap, logGroupsLen, logGroupsCap := queueLengths(runner)
+	if logsCap == 0 && logGroupsCap == 0 {
+		return metrics
+	}
+	return append(metrics,
+		newSelfMetric("input_queue_length", labels, float64(logsLen), selfMetricGauge),
+		newSelfMetric("input_queue_capacity", labels, float64(logsCap), selfMetricGauge),
+		newSelfMetric("flush_queue_length", labels, float64(logGroupsLen), selfMetricGauge),
+		newSelfMetric("flush_queue_capacity", labels, float64(logGroupsCap), selfMetricGauge),
+	)
+}
+
+// queueLengths returns the lengths and capacities of the input queue and the flush queue of @runner.
+func queueLengths(runner PluginRunner) (logsLen, logsCap, logGroupsLen, logGroupsCap int) {
+	switch r := runner.(type) {
+	case *pluginv1Runner:
+		logsLen, logsCap = len(r.LogsChan), cap(r.LogsChan)
+		logGroupsLen, logGroupsCap = len(r.LogGroupsChan), cap(r.LogGroupsChan)
+	case *pluginv2Runner:
+		if r.InputPipeContext == nil || r.AggregatePipeContext == nil {
+			return
+		}
+		logs, logGroups := r.InputPipeContext.Collector().Observe(), r.AggregatePipeContext.Collector().Observe()
+		logsLen, logsCap = len(logs), cap(logs)
+		logGroupsLen, logGroupsCap = len(logGroups), cap(logGroups)
+	}
+	return
+}
+
+// appendTenantMetrics appends the count of the running configs and the count of the configs rejected by the quota
+// of each tenant.
+func appendTenantMetrics(metrics []SelfMetric) []SelfMetric {
+	configs := make(map[string]int)
+	for _, config := range runningConfigs() {
+		if config.GlobalConfig != nil && config.GlobalConfig.Tenant != "" {
+			configs[config.GlobalConfig.Tenant]++
+		}
+	}
+	for tenant, count := range configs {
+		metrics = append(metrics, newSelfMetric("tenant_configs", map[string]string{"tenant": tenant}, float64(count), selfMetricGauge))
+	}
+	for tenant, count := range tenantRejectedCounts() {
+		metrics = append(metrics, newSelfMetric("tenant_quota_rejected", map[string]string{"tenant": tenant}, float64(count), selfMetricCounter))
+	}
+	return metrics
+}
+
+func appendAlarmMetrics(metrics []SelfMetric, alarm *util.Alarm, labels map[string]string) []SelfMetric {
+	if alarm == nil {
+		return metrics
+	}
+	for alarmType, total := range alarm.Totals() {
+		alarmLabels := make(map[string]string, len(labels)+2)
+		for k, v := range labels {
+			alarmLabels[k] = v
+		}
+		alarmLabels["alarm_type"] = alarmType
+		alarmLabels["severity"] = util.GetAlarmDefinition(alarmType).Severity.String()
+		metrics = append(metrics, newSelfMetric("alarm", alarmLabels, float64(total), selfMetricCounter))
+	}
+	return metrics
+}
+
+func sanitizeMetricName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// WritePrometheusSelfMetrics writes @metrics in the Prometheus text exposition format.
+func WritePrometheusSelfMetrics(w io.Writer, metrics []SelfMetric) error {
+	sort.SliceStable(metrics, func(i, j int) bool { return metrics[i].Name < metrics[j].Name })
+	var sb strings.Builder
+	for i, metric := range metrics {
+		if i == 0 || metrics[i-1].Name != metric.Name {
+			metricType := "gauge"
+			if metric.Counter {
+				metricType = "counter"
+			}
+			fmt.Fprintf(&sb, "# TYPE %s %s\n", metric.Name, metricType)
+		}
+		sb.WriteString(metric.Name)
+		if len(metric.Labels) > 0 {
+			keys := make([]string, 0, len(metric.Labels))
+			for k := range metric.Labels {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			sb.WriteByte('{')
+			for j, k := range keys {
+				if j > 0 {
+					sb.WriteByte(',')
+				}
+				fmt.Fprintf(&sb, "%s=\"%s\"", k, escapeLabelValue(metric.Labels[k]))
+			}
+			sb.WriteByte('}')
+		}
+		sb.WriteByte(' ')
+		sb.WriteString(strconv.FormatFloat(metric.Value, 'g', -1, 64))
+		sb.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueReplacer.Replace(v)
+}
+
+// SelfMetricsOTLPExporter pushes the self telemetry metrics to an OTLP gRPC endpoint periodically.
+type SelfMetricsOTLPExporter struct {
+	config    helper.GrpcClientConfig
+	interval  time.Duration
+	startTime time.Time
+	conn      *grpc.ClientConn
+	client    pmetricotlp.GRPCClient
+	shutdown  chan struct{}
+}
+
+// NewSelfMetricsOTLPExporter creates an exporter pushing to @endpoint every @interval.
+func NewSelfMetricsOTLPExporter(endpoint string, headers map[string]string, interval time.Duration) (*SelfMetricsOTLPExporter, error) {
+	e := &SelfMetricsOTLPExporter{
+		config:    helper.GrpcClientConfig{Endpoint: endpoint, Headers: headers},
+		interval:  interval,
+		startTime: time.Now(),
+		shutdown:  make(chan struct{}),
+	}
+	opts, err := e.config.GetDialOptions()
+	if err != nil {
+		return nil, err
+	}
+	if e.conn, err = grpc.Dial(e.config.GetEndpoint(), opts...); err != nil {
+		return nil, err
+	}
+	e.client = pmetricotlp.NewGRPCClient(e.conn)
+	return e, nil
+}
+
+// Start pushes the metrics in background until Stop.
+func (e *SelfMetricsOTLPExporter) Start() {
+	go func() {
+		for !util.RandomSleep(e.interval, 0.1, e.shutdown) {
+			if err := e.Export(CollectSelfMetrics()); err != nil {
+				logger.Warning(context.Background(), util.AlarmSelfMetricsExport, "export self metrics to otlp endpoint error", err)
+			}
+		}
+	}()
+}
+
+// Stop stops pushing and closes the connection.
+func (e *SelfMetricsOTLPExporter) Stop() {
+	close(e.shutdown)
+	_ = e.conn.Close()
+}
+
+// Export pushes @metrics once.
+func (e *SelfMetricsOTLPExporter) Export(metrics []SelfMetric) error {
+	ctx, cancel := context.WithTimeout(context.Background(), e.config.GetTimeout())
+	defer cancel()
+	if len(e.config.Headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(e.config.Headers))
+	}
+	_, err := e.client.Export(ctx, pmetricotlp.NewExportRequestFromMetrics(newOTLPSelfMetrics(metrics, e.startTime)))
+	return err
+}
+
+// newOTLPSelfMetrics converts @metrics to OTLP, the counters are cumulative sums starting from @startTime.
+func newOTLPSelfMetrics(metrics []SelfMetric, startTime time.Time) pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "ilogtail")
+	rm.Resource().Attributes().PutStr("host.name", util.GetHostName())
+	rm.Resource().Attributes().PutStr("host.ip", util.GetIPAddress())
+	sm := rm.ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName("ilogtail")
+	sm.Scope().SetVersion(BaseVersion)
+
+	now := pcommon.NewTimestampFromTime(time.Now())
+	start := pcommon.NewTimestampFromTime(startTime)
+	byName := make(map[string]pmetric.Metric)
+	for _, metric := range metrics {
+		m, ok := byName[metric.Name]
+		if !ok {
+			m = sm.Metrics().AppendEmpty()
+			m.SetName(metric.Name)
+			if metric.Counter {
+				m.SetEmptySum().SetIsMonotonic(true)
+				m.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+			} else {
+				m.SetEmptyGauge()
+			}
+			byName[metric.Name] = m
+		}
+		var dp pmetric.NumberDataPoint
+		if metric.Counter {
+			dp = m.Sum().DataPoints().AppendEmpty()
+			dp.SetStartTimestamp(start)
+		} else {
+			dp = m.Gauge().DataPoints().AppendEmpty()
+		}
+		dp.SetTimestamp(now)
+		dp.SetDoubleValue(metric.Value)
+		for k, v := range metric.Labels {
+			dp.Attributes().PutStr(k, v)
+		}
+	}
+	return md
+}
@@ -0,0 +1,91 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux || windows
+// +build linux windows
+
+package pluginmanager
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+func TestCollectSelfMetrics(t *testing.T) {
+	contextImp := &ContextImp{}
+	contextImp.InitContext("p", "l", "c")
+	counter := helper.NewCounterMetric("proc_in_records")
+	avg := helper.NewAverageMetric("avg_size")
+	latency := helper.NewLatencyMetric("flush_latency")
+	contextImp.RegisterCounterMetric(counter)
+	contextImp.RegisterCounterMetric(avg)
+	contextImp.RegisterLatencyMetric(latency)
+
+	counter.Add(3)
+	avg.Add(4)
+	contextImp.MetricSerializeToPB(&protocol.Log{})
+	counter.Add(2)
+	avg.Add(6)
+	latency.Begin()
+	latency.End()
+
+	values := make(map[string]float64)
+	kinds := make(map[string]selfMetricKind)
+	contextImp.collectSelfMetrics(func(name string, value float64, kind selfMetricKind) {
+		values[name], kinds[name] = value, kind
+	})
+	assert.Equal(t, float64(5), values["proc_in_records"], "the counter should be cumulative across statistics")
+	assert.Equal(t, selfMetricCounter, kinds["proc_in_records"])
+	assert.Equal(t, float64(6), values["avg_size"])
+	assert.Equal(t, selfMetricGauge, kinds["avg_size"])
+	assert.Equal(t, selfMetricLatency, kinds["flush_latency"])
+	assert.Less(t, values["flush_latency"], time.Second.Seconds())
+	// collecting should not clear the statistics shipped to SLS
+	assert.Equal(t, int64(2), counter.Get())
+}
+
+func TestWritePrometheusSelfMetrics(t *testing.T) {
+	labels := map[string]string{"project": "p", "config_name": "a\"b"}
+	metrics := []SelfMetric{
+		newSelfMetric("proc.in-records", labels, 5, selfMetricCounter),
+		newSelfMetric("flush_latency", labels, 0.25, selfMetricLatency),
+		newSelfMetric("input_queue_length", map[string]string{}, 2, selfMetricGauge),
+	}
+	var sb strings.Builder
+	require.NoError(t, WritePrometheusSelfMetrics(&sb, metrics))
+	assert.Equal(t, `# TYPE ilogtail_flush_latency_seconds gauge
+ilogtail_flush_latency_seconds{config_name="a\"b",project="p"} 0.25
+# TYPE ilogtail_input_queue_length gauge
+ilogtail_input_queue_length 2
+# TYPE ilogtail_proc_in_records_total counter
+ilogtail_proc_in_records_total{config_name="a\"b",project="p"} 5
+`, sb.String())
+
+	md := newOTLPSelfMetrics(metrics, time.Now())
+	sm := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	require.Equal(t, 3, sm.Len())
+	for i := 0; i < sm.Len(); i++ {
+		if sm.At(i).Name() == "ilogtail_proc_in_records_total" {
+			assert.True(t, sm.At(i).Sum().IsMonotonic())
+			assert.Equal(t, float64(5), sm.At(i).Sum().DataPoints().At(0).DoubleValue())
+		}
+	}
+}
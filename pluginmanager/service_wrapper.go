@@ -20,6 +20,7 @@ import (
 	"github.com/alibaba/ilogtail/pkg/protocol"
 	"github.com/alibaba/ilogtail/pkg/util"
 
+	"sync"
 	"time"
 )
 
@@ -30,6 +31,11 @@ type ServiceWrapper struct {
 	Interval time.Duration
 
 	LogsChan chan *pipeline.LogWithContext
+	// The running service is taken over by the new config when its hash is unchanged after reloading.
+	hash     string
+	chanLock sync.RWMutex
+	// the self metrics registered by the service when initialized, which are moved to the new config with it.
+	metrics *serviceMetrics
 }
 
 func (p *ServiceWrapper) Run(cc *pipeline.AsyncControl) {
@@ -39,7 +45,7 @@ func (p *ServiceWrapper) Run(cc *pipeline.AsyncControl) {
 		defer panicRecover(p.Input.Description())
 		err := p.Input.Start(p)
 		if err != nil {
-			logger.Error(p.Config.Context.GetRuntimeContext(), "PLUGIN_ALARM", "start service error, err", err)
+			logger.Error(p.Config.Context.GetRuntimeContext(), util.AlarmPlugin, "start service error, err", err)
 		}
 		logger.Info(p.Config.Context.GetRuntimeContext(), "service done", p.Input.Description())
 	}()
@@ -49,11 +55,25 @@ func (p *ServiceWrapper) Run(cc *pipeline.AsyncControl) {
 func (p *ServiceWrapper) Stop() error {
 	err := p.Input.Stop()
 	if err != nil {
-		logger.Error(p.Config.Context.GetRuntimeContext(), "PLUGIN_ALARM", "stop service error, err", err)
+		logger.Error(p.Config.Context.GetRuntimeContext(), util.AlarmPlugin, "stop service error, err", err)
 	}
 	return err
 }
 
+// setLogsChan redirects the logs of the running service to @logsChan.
+// It returns after the logs being sent to the previous channel are sent.
+func (p *ServiceWrapper) setLogsChan(logsChan chan *pipeline.LogWithContext) {
+	p.chanLock.Lock()
+	p.LogsChan = logsChan
+	p.chanLock.Unlock()
+}
+
+func (p *ServiceWrapper) sendLog(log *pipeline.LogWithContext) {
+	p.chanLock.RLock()
+	p.LogsChan <- log
+	p.chanLock.RUnlock()
+}
+
 func (p *ServiceWrapper) AddData(tags map[string]string, fields map[string]string, t ...time.Time) {
 	p.AddDataWithContext(tags, fields, nil, t...)
 }
@@ -77,7 +97,7 @@ func (p *ServiceWrapper) AddDataWithContext(tags map[string]string, fields map[s
 		logTime = t[0]
 	}
 	slsLog, _ := util.CreateLog(logTime, p.Tags, tags, fields)
-	p.LogsChan <- &pipeline.LogWithContext{Log: slsLog, Context: ctx}
+	p.sendLog(&pipeline.LogWithContext{Log: slsLog, Context: ctx})
 }
 
 func (p *ServiceWrapper) AddDataArrayWithContext(tags map[string]string,
@@ -92,9 +112,9 @@ func (p *ServiceWrapper) AddDataArrayWithContext(tags map[string]string,
 		logTime = t[0]
 	}
 	slsLog, _ := util.CreateLogByArray(logTime, p.Tags, tags, columns, values)
-	p.LogsChan <- &pipeline.LogWithContext{Log: slsLog, Context: ctx}
+	p.sendLog(&pipeline.LogWithContext{Log: slsLog, Context: ctx})
 }
 
 func (p *ServiceWrapper) AddRawLogWithContext(log *protocol.Log, ctx map[string]interface{}) {
-	p.LogsChan <- &pipeline.LogWithContext{Log: log, Context: ctx}
+	p.sendLog(&pipeline.LogWithContext{Log: log, Context: ctx})
 }
@@ -0,0 +1,133 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+// ShutdownReport summarizes a Shutdown, the dropped counts are the data in flight lost when exiting.
+type ShutdownReport struct {
+	Deadline time.Duration
+	Elapsed  time.Duration
+	Configs  int
+	// TimeoutConfigs are the configs failed to stop before the deadline.
+	TimeoutConfigs []string
+	// DroppedLogs is the count of the logs left in the input queues of the timeout configs.
+	DroppedLogs int
+	// DroppedGroups is the count of the log groups (or group events of v2 pipelines) not flushed.
+	DroppedGroups int
+}
+
+// Shutdown stops all configs for exiting within @deadline. Unlike HoldOn, the configs are stopped
+// concurrently so that all inputs stop at once, and each of them drains its queues, flushes the
+// aggregators and waits for the flushers to be ready until the shared deadline rather than a fixed
+// time. Then the builtin configs are stopped and the checkpoints are persisted. The configs failed
+// to stop in time are abandoned, their data in flight is counted as dropped and the checkpoints they
+// write after the checkpoint db is closed are rejected. The builtin configs share the same deadline,
+// but their data is not counted in the report.
+func Shutdown(deadline time.Duration) *ShutdownReport {
+	defer panicRecover("Shutdown plugin")
+	start := time.Now()
+	end := start.Add(deadline)
+	configs := runningConfigs()
+	report := &ShutdownReport{Deadline: deadline, Configs: len(configs)}
+	logger.Info(context.Background(), "shutdown", "begin", "configs", len(configs), "deadline", deadline)
+
+	dones := make(map[*LogstoreConfig]chan int, len(configs))
+	for _, logstoreConfig := range configs {
+		logstoreConfig.flushOutDeadline = end
+		dones[logstoreConfig] = stopInBackground(logstoreConfig, true)
+	}
+	timer := time.NewTimer(time.Until(end))
+	defer timer.Stop()
+	expired := false
+	for logstoreConfig, done := range dones {
+		stopped := false
+		if expired {
+			select {
+			case <-done:
+				stopped = true
+			default:
+			}
+		} else {
+			select {
+			case <-done:
+				stopped = true
+			case <-timer.C:
+				expired = true
+			}
+		}
+		if stopped {
+			report.DroppedGroups += int(atomic.LoadInt64(&logstoreConfig.droppedOnStop))
+			continue
+		}
+		logsLen, _, groupsLen, _ := queueLengths(logstoreConfig.PluginRunner)
+		groupsLen += GetFlushStoreLen(logstoreConfig.PluginRunner)
+		report.TimeoutConfigs = append(report.TimeoutConfigs, logstoreConfig.ConfigName)
+		report.DroppedLogs += logsLen
+		report.DroppedGroups += groupsLen
+		logger.Error(logstoreConfig.Context.GetRuntimeContext(), util.AlarmConfigStopTimeout,
+			"timeout when shutdown config, drop logs", logsLen, "drop groups", groupsLen)
+		DisabledLogtailConfigLock.Lock()
+		DisabledLogtailConfig[logstoreConfig.ConfigName] = logstoreConfig
+		DisabledLogtailConfigLock.Unlock()
+	}
+	sort.Strings(report.TimeoutConfigs)
+
+	builtinConfigs := []*LogstoreConfig{StatisticsConfig, AlarmConfig, ContainerConfig}
+	for _, builtinConfig := range builtinConfigs {
+		if builtinConfig != nil {
+			builtinConfig.flushOutDeadline = end
+		}
+	}
+	stopBuiltinConfigs(true)
+	// The builtin configs are reused after Resume.
+	for _, builtinConfig := range builtinConfigs {
+		if builtinConfig != nil {
+			builtinConfig.flushOutDeadline = time.Time{}
+		}
+	}
+	LogtailConfigLock.Lock()
+	LastLogtailConfig = LogtailConfig
+	LogtailConfig = make(map[string]*LogstoreConfig)
+	LogtailConfigLock.Unlock()
+	CheckPointManager.HoldOn()
+	CheckPointManager.Close()
+
+	report.Elapsed = time.Since(start)
+	if report.DroppedLogs > 0 || report.DroppedGroups > 0 {
+		logger.Error(context.Background(), util.AlarmDropData, "shutdown drop data, logs", report.DroppedLogs,
+			"groups", report.DroppedGroups, "timeout configs", report.TimeoutConfigs, "elapsed", report.Elapsed)
+	} else {
+		logger.Info(context.Background(), "shutdown", "done", "elapsed", report.Elapsed)
+	}
+	return report
+}
+
+// flushOutTimeout checks if it's time to give up flushing out the data after @waitCount rounds of
+// waiting, either the Shutdown deadline is exceeded or maxFlushOutTime elapsed without deadline.
+func (lc *LogstoreConfig) flushOutTimeout(waitCount int) bool {
+	if !lc.flushOutDeadline.IsZero() {
+		return time.Now().After(lc.flushOutDeadline)
+	}
+	return waitCount > maxFlushOutTime*100
+}
@@ -0,0 +1,70 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux || windows
+// +build linux windows
+
+package pluginmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/flusher/checker"
+)
+
+func TestShutdown(t *testing.T) {
+	require.NoError(t, Init())
+	require.NoError(t, LoadMockConfig())
+	require.NoError(t, Resume())
+	time.Sleep(time.Millisecond * 1500)
+	config := LogtailConfig["test_config"]
+	c, ok := GetConfigFluhsers(config.PluginRunner)[1].(*checker.FlusherChecker)
+	require.True(t, ok)
+
+	report := Shutdown(2 * time.Second)
+	assert.Equal(t, 1, report.Configs)
+	assert.Empty(t, report.TimeoutConfigs)
+	assert.Zero(t, report.DroppedLogs)
+	assert.Zero(t, report.DroppedGroups)
+	assert.Less(t, report.Elapsed, 3*time.Second, "the builtin configs should not wait longer than the deadline")
+	assert.Greater(t, c.GetLogCount(), 0)
+	assert.Empty(t, LogtailConfig)
+	assert.False(t, CheckPointManager.initFlag, "the checkpoints should be persisted")
+	assert.Equal(t, ErrCheckPointNotInit, CheckPointManager.SaveCheckpoint("test_config", "key", nil), "the checkpoints should not be written after closed")
+	require.NoError(t, CheckPointManager.Init())
+}
+
+func TestFlushOutDeadline(t *testing.T) {
+	require.NoError(t, LoadMockConfig("p", "l", "flush_out_deadline"))
+	lc := LogtailConfig["flush_out_deadline"]
+	delete(LogtailConfig, "flush_out_deadline")
+	store := NewFlushOutStore[protocol.LogGroup]()
+	store.Add(&protocol.LogGroup{}, &protocol.LogGroup{})
+	flusher := &checker.FlusherChecker{Block: true}
+
+	lc.flushOutDeadline = time.Now().Add(100 * time.Millisecond)
+	begin := time.Now()
+	ok := flushOutStore(lc, store, []pipeline.FlusherV1{flusher}, nil, func(*LogstoreConfig, pipeline.FlusherV1, *FlushOutStore[protocol.LogGroup]) error {
+		return nil
+	})
+	assert.False(t, ok)
+	assert.Less(t, time.Since(begin), time.Duration(maxFlushOutTime)*time.Second, "the deadline should take the place of maxFlushOutTime")
+	assert.Equal(t, int64(2), lc.droppedOnStop)
+}
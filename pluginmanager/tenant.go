@@ -0,0 +1,105 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"fmt"
+	"sync"
+)
+
+// tenantTagKey is the tag added to the data of the configs belonging to a tenant.
+const tenantTagKey = "__tenant__"
+
+// TenantQuota limits the resources of the configs belonging to a tenant, zero means unlimited.
+type TenantQuota struct {
+	// The max count of the configs of the tenant, the configs beyond it fail to load.
+	MaxConfigs int
+	// The max sizes of the input queue and the flush queue of each config of the tenant, which override the larger
+	// DefaultLogQueueSize and DefaultLogGroupQueueSize of the configs, so the queue budget of the tenant is
+	// MaxConfigs times them.
+	LogQueueSize      int
+	LogGroupQueueSize int
+}
+
+var (
+	tenantRejectedLock sync.Mutex
+	// the count of the configs failed to load for exceeding the quota, by the tenant.
+	tenantRejected = make(map[string]int64)
+)
+
+// configTenant returns the tenant of the configs of @project by the global config of the agent, or empty if the
+// project doesn't belong to any tenant.
+func configTenant(project string) string {
+	return LogtailGlobalConfig.TenantProjects[project]
+}
+
+// tenantQuota returns the quota of @tenant, the quotas are only read from the global config of the agent
+// so that the configs could not raise their own quotas. The configs without tenant share the quota of "*".
+func tenantQuota(tenant string) *TenantQuota {
+	if quota, ok := LogtailGlobalConfig.TenantQuotas[tenant]; ok {
+		return quota
+	}
+	return LogtailGlobalConfig.TenantQuotas["*"]
+}
+
+// checkTenantQuota checks whether the config @configName of @tenant could be loaded, the config with the same
+// name being replaced is not counted. The configs without tenant are counted together.
+func checkTenantQuota(tenant string, configName string) error {
+	quota := tenantQuota(tenant)
+	if quota == nil || quota.MaxConfigs <= 0 {
+		return nil
+	}
+	count := 0
+	for name, config := range runningConfigs() {
+		if name != configName && config.GlobalConfig != nil && config.GlobalConfig.Tenant == tenant {
+			count++
+		}
+	}
+	if count < quota.MaxConfigs {
+		return nil
+	}
+	tenantRejectedLock.Lock()
+	tenantRejected[tenant]++
+	tenantRejectedLock.Unlock()
+	if tenant == "" {
+		return fmt.Errorf("the configs without tenant exceed the quota of %d configs", quota.MaxConfigs)
+	}
+	return fmt.Errorf("tenant %s exceeds the quota of %d configs", tenant, quota.MaxConfigs)
+}
+
+// tenantQueueSizes caps the queue sizes of the config of @tenant by the quota.
+func tenantQueueSizes(tenant string, logQueueSize, logGroupSize int) (int, int) {
+	quota := tenantQuota(tenant)
+	if quota == nil {
+		return logQueueSize, logGroupSize
+	}
+	if quota.LogQueueSize > 0 && logQueueSize > quota.LogQueueSize {
+		logQueueSize = quota.LogQueueSize
+	}
+	if quota.LogGroupQueueSize > 0 && logGroupSize > quota.LogGroupQueueSize {
+		logGroupSize = quota.LogGroupQueueSize
+	}
+	return logQueueSize, logGroupSize
+}
+
+func tenantRejectedCounts() map[string]int64 {
+	tenantRejectedLock.Lock()
+	defer tenantRejectedLock.Unlock()
+	counts := make(map[string]int64, len(tenantRejected))
+	for tenant, count := range tenantRejected {
+		counts[tenant] = count
+	}
+	return counts
+}
@@ -0,0 +1,73 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/alibaba/ilogtail/plugins/input/mock"
+)
+
+func TestTenantQuota(t *testing.T) {
+	defer func(projects map[string]string, quotas map[string]*TenantQuota, configs map[string]*LogstoreConfig) {
+		LogtailGlobalConfig.TenantProjects, LogtailGlobalConfig.TenantQuotas, LogtailConfig = projects, quotas, configs
+	}(LogtailGlobalConfig.TenantProjects, LogtailGlobalConfig.TenantQuotas, LogtailConfig)
+	LogtailConfig = make(map[string]*LogstoreConfig)
+	LogtailGlobalConfig.TenantProjects = map[string]string{"project-a": "team-a", "project-b": "team-b"}
+	LogtailGlobalConfig.TenantQuotas = map[string]*TenantQuota{
+		"team-a": {MaxConfigs: 1, LogQueueSize: 10, LogGroupQueueSize: 2},
+		"*":      {MaxConfigs: 2},
+	}
+	config := `{"global":{"DefaultLogQueueSize":100,"TenantQuotas":{"team-a":{"MaxConfigs":5}}},` +
+		`"inputs":[{"type":"metric_mock"}],"flushers":[{"type":"flusher_checker"}]}`
+	require.NoError(t, LoadLogstoreConfig("project-a", "l", "a1", 1, config))
+	lc := LogtailConfig["a1"]
+	assert.Equal(t, "team-a", lc.GlobalConfig.Tenant)
+	assert.Equal(t, 10, cap(lc.PluginRunner.(*pluginv1Runner).LogsChan), "the config should not raise its own quota")
+	assert.Equal(t, "team-a", loadAdditionalTags(lc.GlobalConfig).Get(tenantTagKey))
+
+	// replacing the config with the same name is not limited
+	require.NoError(t, LoadLogstoreConfig("project-a", "l", "a1", 1, config))
+	assert.ErrorContains(t, LoadLogstoreConfig("project-a", "l", "a2", 1, config), "tenant team-a exceeds the quota of 1 configs")
+
+	// the config could not claim another tenant to escape from the quota
+	config = `{"global":{"Tenant":"team-c","TenantProjects":{"project-a":"team-c"}},"inputs":[{"type":"metric_mock"}],"flushers":[{"type":"flusher_checker"}]}`
+	assert.ErrorContains(t, LoadLogstoreConfig("project-a", "l", "a3", 1, config), "tenant team-a exceeds the quota of 1 configs")
+
+	// the other tenants use the default quota
+	config = `{"inputs":[{"type":"metric_mock"}],"flushers":[{"type":"flusher_checker"}]}`
+	require.NoError(t, LoadLogstoreConfig("project-b", "l", "b1", 1, config))
+	require.NoError(t, LoadLogstoreConfig("project-b", "l", "b2", 1, config))
+	assert.Error(t, LoadLogstoreConfig("project-b", "l", "b3", 1, config))
+
+	// the configs without tenant share the default quota
+	require.NoError(t, LoadLogstoreConfig("p", "l", "c1", 1, config))
+	assert.Equal(t, "", LogtailConfig["c1"].GlobalConfig.Tenant)
+	config = `{"global":{"Tenant":"team-c"},"inputs":[{"type":"metric_mock"}],"flushers":[{"type":"flusher_checker"}]}`
+	require.NoError(t, LoadLogstoreConfig("p", "l", "c2", 1, config))
+	assert.Equal(t, "", LogtailConfig["c2"].GlobalConfig.Tenant)
+	assert.ErrorContains(t, LoadLogstoreConfig("p", "l", "c3", 1, config), "the configs without tenant exceed the quota of 2 configs")
+
+	values := make(map[string]float64)
+	for _, metric := range appendTenantMetrics(nil) {
+		values[metric.Name+"/"+metric.Labels["tenant"]] = metric.Value
+	}
+	assert.Equal(t, float64(1), values["ilogtail_tenant_configs/team-a"])
+	assert.Equal(t, float64(2), values["ilogtail_tenant_configs/team-b"])
+	assert.GreaterOrEqual(t, values["ilogtail_tenant_quota_rejected_total/team-a"], float64(2))
+}
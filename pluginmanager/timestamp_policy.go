@@ -0,0 +1,258 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pluginmanager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	timestampSourceField   = "field"
+	timestampSourceEvent   = "event"
+	timestampSourceReceive = "receive"
+
+	outOfRangeFallback = "fallback"
+	outOfRangeClamp    = "clamp"
+	outOfRangeDrop     = "drop"
+)
+
+// TimestampPolicyConfig decides which timestamp of the logs wins in a config, rather than each input and processor
+// deciding it in its own way. The policy is applied after the processors, and the logs without any valid timestamp
+// get the receive time.
+type TimestampPolicyConfig struct {
+	// Sources are the candidates of the timestamp in the order of precedence, the first valid one wins. A source is
+	// one of "field" parsing Field with Format, "event" the time set by the input or the processors, such as the
+	// time of the file logs, and "receive" the time the log is processed by the config. ["event"] by default.
+	Sources []string
+	// Field is the content key of the "field" source, or the tag key of the v2 logs.
+	Field string
+	// Format is the strptime format of Field, such as %Y-%m-%d %H:%M:%S, or %s for the unix seconds.
+	Format string
+	// UTCOffset is the UTC offset in seconds of the timestamps of Field without the time zone, such as 28800 for UTC+8.
+	// The local time zone is used if nil.
+	UTCOffset *int
+	// MaxPastSec and MaxFutureSec are the valid range of the timestamps around the receive time, 0 means no limit.
+	MaxPastSec   int
+	MaxFutureSec int
+	// OutOfRange is how the timestamps out of the range are handled, "fallback" to the next source by default,
+	// "clamp" to the bound of the range, or "drop" the log.
+	OutOfRange string
+}
+
+// timestampPolicy applies the TimestampPolicyConfig of a config. The methods of a nil policy do nothing.
+type timestampPolicy struct {
+	TimestampPolicyConfig
+	config   *LogstoreConfig
+	location *time.Location
+
+	fallbackMetric   pipeline.CounterMetric
+	outOfRangeMetric pipeline.CounterMetric
+	dropMetric       pipeline.CounterMetric
+}
+
+// newTimestampPolicy validates @cfg and returns nil if it's not set.
+func newTimestampPolicy(cfg *TimestampPolicyConfig, config *LogstoreConfig) (*timestampPolicy, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	p := &timestampPolicy{TimestampPolicyConfig: *cfg, config: config, location: time.Local}
+	if len(p.Sources) == 0 {
+		p.Sources = []string{timestampSourceEvent}
+	}
+	for _, source := range p.Sources {
+		switch source {
+		case timestampSourceField:
+			if p.Field == "" || p.Format == "" {
+				return nil, fmt.Errorf("Field and Format of the timestamp policy must be set with the field source")
+			}
+		case timestampSourceEvent, timestampSourceReceive:
+		default:
+			return nil, fmt.Errorf("invalid timestamp source %s, must be one of field, event and receive", source)
+		}
+	}
+	if p.UTCOffset != nil {
+		if *p.UTCOffset < -12*60*60 || *p.UTCOffset > 14*60*60 {
+			return nil, fmt.Errorf("UTCOffset %v of the timestamp policy is out of range (from -12 to +14)", *p.UTCOffset)
+		}
+		p.location = time.FixedZone("SpecifiedTimezone", *p.UTCOffset)
+	}
+	if helper.StrptimeHasZone(p.Format) {
+		p.location = nil
+	}
+	if p.MaxPastSec < 0 || p.MaxFutureSec < 0 {
+		return nil, fmt.Errorf("MaxPastSec and MaxFutureSec of the timestamp policy must not be negative")
+	}
+	switch p.OutOfRange {
+	case "":
+		p.OutOfRange = outOfRangeFallback
+	case outOfRangeFallback, outOfRangeClamp, outOfRangeDrop:
+	default:
+		return nil, fmt.Errorf("invalid OutOfRange %s of the timestamp policy, must be one of fallback, clamp and drop", p.OutOfRange)
+	}
+	p.fallbackMetric = helper.NewCounterMetricAndRegister("timestamp_fallback_log", config.Context)
+	p.outOfRangeMetric = helper.NewCounterMetricAndRegister("timestamp_out_of_range_log", config.Context)
+	p.dropMetric = helper.NewCounterMetricAndRegister("timestamp_drop_log", config.Context)
+	return p, nil
+}
+
+// resolve returns the winning timestamp of a log, or false if the log should be dropped. @event is zero if the
+// log has no time, and @field is the value of Field.
+func (p *timestampPolicy) resolve(event time.Time, field string, hasField bool, now time.Time) (time.Time, bool) {
+	for i, source := range p.Sources {
+		var t time.Time
+		switch source {
+		case timestampSourceField:
+			if hasField {
+				t, _ = p.parseField(field)
+			}
+		case timestampSourceEvent:
+			t = event
+		case timestampSourceReceive:
+			t = now
+		}
+		if t.IsZero() {
+			continue
+		}
+		if clamped, ok := p.inRange(t, now); !ok {
+			p.outOfRangeMetric.Add(1)
+			switch p.OutOfRange {
+			case outOfRangeDrop:
+				p.dropMetric.Add(1)
+				return time.Time{}, false
+			case outOfRangeFallback:
+				continue
+			}
+			t = clamped
+		} else if i > 0 {
+			p.fallbackMetric.Add(1)
+		}
+		return t, true
+	}
+	p.fallbackMetric.Add(1)
+	return now, true
+}
+
+// inRange returns the timestamp clamped to the range, and false if it's out of the range.
+func (p *timestampPolicy) inRange(t, now time.Time) (time.Time, bool) {
+	if p.MaxPastSec > 0 {
+		if min := now.Add(-time.Duration(p.MaxPastSec) * time.Second); t.Before(min) {
+			return min, false
+		}
+	}
+	if p.MaxFutureSec > 0 {
+		if max := now.Add(time.Duration(p.MaxFutureSec) * time.Second); t.After(max) {
+			return max, false
+		}
+	}
+	return t, true
+}
+
+// parseField parses the timestamp by the strptime format like processor_strptime.
+func (p *timestampPolicy) parseField(value string) (time.Time, error) {
+	return helper.Strptime(value, p.Format, p.location)
+}
+
+// applyLogs sets the timestamps of the v1 logs and returns the logs not dropped. The logs without any content are
+// kept as they are, which are skipped before the aggregators.
+func (p *timestampPolicy) applyLogs(logs []*protocol.Log) []*protocol.Log {
+	if p == nil {
+		return logs
+	}
+	now := time.Now()
+	kept := logs[:0]
+	for _, log := range logs {
+		if len(log.Contents) == 0 {
+			kept = append(kept, log)
+			continue
+		}
+		var event time.Time
+		if log.Time != 0 {
+			event = time.Unix(int64(log.Time), 0)
+		}
+		var field string
+		var hasField bool
+		if p.Field != "" {
+			for _, content := range log.Contents {
+				if content.Key == p.Field {
+					field, hasField = content.Value, true
+					break
+				}
+			}
+		}
+		t, ok := p.resolve(event, field, hasField, now)
+		if !ok {
+			continue
+		}
+		log.Time = uint32(t.Unix())
+		kept = append(kept, log)
+	}
+	p.warnDropped(len(logs) - len(kept))
+	return kept
+}
+
+// applyGroupEvents sets the timestamps of the v2 logs and removes the logs dropped, the other events are kept as
+// they are.
+func (p *timestampPolicy) applyGroupEvents(groups []*models.PipelineGroupEvents) {
+	if p == nil {
+		return
+	}
+	now := time.Now()
+	dropped := 0
+	for _, group := range groups {
+		kept := group.Events[:0]
+		for _, event := range group.Events {
+			log, ok := event.(*models.Log)
+			if !ok {
+				kept = append(kept, event)
+				continue
+			}
+			var eventTime time.Time
+			if log.Timestamp != 0 {
+				eventTime = time.Unix(0, int64(log.Timestamp))
+			}
+			var field string
+			var hasField bool
+			if p.Field != "" && log.GetTags().Contains(p.Field) {
+				field, hasField = log.GetTags().Get(p.Field), true
+			}
+			t, ok := p.resolve(eventTime, field, hasField, now)
+			if !ok {
+				dropped++
+				continue
+			}
+			log.Timestamp = uint64(t.UnixNano())
+			kept = append(kept, event)
+		}
+		group.Events = kept
+	}
+	p.warnDropped(dropped)
+}
+
+func (p *timestampPolicy) warnDropped(dropped int) {
+	if dropped == 0 {
+		return
+	}
+	p.config.auditor.countTimestampDropped(dropped)
+	logger.Warning(p.config.Context.GetRuntimeContext(), util.AlarmDropData, "drop the logs with the timestamps out of range, count", dropped,
+		"max past sec", p.MaxPastSec, "max future sec", p.MaxFutureSec)
+}
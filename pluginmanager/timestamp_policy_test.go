@@ -0,0 +1,120 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux || windows
+// +build linux windows
+
+package pluginmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+func newTimestampPolicyTestLog(t uint32, value string) *protocol.Log {
+	log := &protocol.Log{Time: t, Contents: []*protocol.Log_Content{{Key: "content", Value: "hello"}}}
+	if value != "" {
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: "ts", Value: value})
+	}
+	return log
+}
+
+func TestTimestampPolicy(t *testing.T) {
+	lc := newAuditTestConfig(t, "timestamp_policy")
+	utc := 0
+	cfg := &TimestampPolicyConfig{
+		Sources:      []string{"field", "event", "receive"},
+		Field:        "ts",
+		Format:       "%Y-%m-%d %H:%M:%S",
+		UTCOffset:    &utc,
+		MaxPastSec:   3600,
+		MaxFutureSec: 60,
+	}
+	policy, err := newTimestampPolicy(cfg, lc)
+	require.NoError(t, err)
+	lc.timestampPolicy = policy
+
+	now := time.Now().UTC()
+	event := uint32(now.Add(-time.Minute).Unix())
+	logs := policy.applyLogs([]*protocol.Log{
+		// the parsed field wins
+		newTimestampPolicyTestLog(event, now.Add(-time.Second*10).Format("2006-01-02 15:04:05")),
+		// the unparsable field falls back to the event time
+		newTimestampPolicyTestLog(event, "yesterday"),
+		// the field out of range falls back to the event time
+		newTimestampPolicyTestLog(event, now.Add(-time.Hour*2).Format("2006-01-02 15:04:05")),
+		// the receive time without the field and the event time
+		newTimestampPolicyTestLog(0, ""),
+		{},
+	})
+	require.Equal(t, 5, len(logs))
+	assert.Equal(t, uint32(now.Add(-time.Second*10).Unix()), logs[0].Time)
+	assert.Equal(t, event, logs[1].Time)
+	assert.Equal(t, event, logs[2].Time)
+	assert.InDelta(t, now.Unix(), int64(logs[3].Time), 2)
+	assert.Equal(t, uint32(0), logs[4].Time)
+
+	cfg.OutOfRange = "clamp"
+	policy, err = newTimestampPolicy(cfg, lc)
+	require.NoError(t, err)
+	logs = policy.applyLogs([]*protocol.Log{newTimestampPolicyTestLog(event, now.Add(time.Hour).Format("2006-01-02 15:04:05"))})
+	require.Equal(t, 1, len(logs))
+	assert.InDelta(t, now.Add(time.Minute).Unix(), int64(logs[0].Time), 2)
+
+	cfg.OutOfRange = "drop"
+	policy, err = newTimestampPolicy(cfg, lc)
+	require.NoError(t, err)
+	logs = policy.applyLogs([]*protocol.Log{
+		newTimestampPolicyTestLog(event, now.Add(time.Hour).Format("2006-01-02 15:04:05")),
+		newTimestampPolicyTestLog(event, ""),
+	})
+	require.Equal(t, 1, len(logs))
+	assert.Equal(t, event, logs[0].Time)
+	assert.Equal(t, int64(1), lc.auditor.counters().TimestampDropped)
+
+	group := &models.PipelineGroupEvents{Events: []models.PipelineEvent{
+		&models.Log{Timestamp: uint64(now.Add(-time.Hour * 2).UnixNano()), Tags: models.NewTags()},
+		&models.Log{Timestamp: uint64(now.UnixNano()), Tags: models.NewTagsWithKeyValues("ts", now.Add(-time.Second).Format("2006-01-02 15:04:05"))},
+		models.NewSingleValueMetric("cpu", models.MetricTypeGauge, models.NewTags(), 0, 1),
+	}}
+	policy.applyGroupEvents([]*models.PipelineGroupEvents{group})
+	require.Equal(t, 2, len(group.Events))
+	assert.Equal(t, uint64(now.Add(-time.Second).Unix()), group.Events[0].GetTimestamp()/uint64(time.Second))
+}
+
+func TestTimestampPolicyInvalid(t *testing.T) {
+	lc := newAuditTestConfig(t, "timestamp_policy_invalid")
+	offset := 15 * 3600
+	for _, cfg := range []*TimestampPolicyConfig{
+		{Sources: []string{"file"}},
+		{Sources: []string{"field"}},
+		{Sources: []string{"field"}, Field: "ts", Format: "%s", UTCOffset: &offset},
+		{MaxPastSec: -1},
+		{OutOfRange: "keep"},
+	} {
+		_, err := newTimestampPolicy(cfg, lc)
+		assert.Error(t, err)
+	}
+	policy, err := newTimestampPolicy(nil, lc)
+	assert.NoError(t, err)
+	assert.Nil(t, policy)
+	logs := []*protocol.Log{{}}
+	assert.Equal(t, logs, policy.applyLogs(logs))
+}
@@ -0,0 +1,250 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package correlation
+
+import (
+	"container/list"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const pluginName = "aggregator_correlation"
+
+const (
+	fieldModeFirst = "first"
+	fieldModeLast  = "last"
+
+	defaultMaxSessions = 10000
+)
+
+type session struct {
+	key       string
+	count     int64
+	firstTime uint32
+	lastTime  uint32
+	fields    map[string]string
+	lastSeen  time.Time
+	ended     bool
+}
+
+// AggregatorCorrelation correlates the logs with the same value of CorrelationKey, e.g. request_id,
+// and emits one merged log for each session, which contains the count, the first and last time of the logs,
+// and the values of Fields. A session is closed when no log arrives in TimeoutMs, or when a log matches all
+// the EndConditions. The logs without CorrelationKey are emitted as they are.
+type AggregatorCorrelation struct {
+	CorrelationKey string
+	TimeoutMs      int
+	// The fields copied to the merged log, the first or the last non-empty value is kept according to FieldMode.
+	Fields    []string
+	FieldMode string
+	// Map of key to regex, the session is closed when a log matches all of them.
+	EndConditions map[string]string
+	CountKey      string
+	FirstTimeKey  string
+	LastTimeKey   string
+	// PartialKey is added with the value true to the merged logs of the sessions closed before they end or time out,
+	// which are the oldest sessions exceeding MaxSessions, and the open sessions when the config stops.
+	PartialKey string
+	// The oldest session is closed when the open sessions exceed MaxSessions, 10000 by default.
+	MaxSessions int
+
+	context     pipeline.Context
+	lock        sync.Mutex
+	sessions    map[string]*list.Element
+	order       *list.List
+	closed      []*protocol.Log
+	endRegs     map[string]*regexp.Regexp
+	fieldKeys   map[string]struct{}
+	nowFunc     func() time.Time
+	timeout     time.Duration
+	hasEndConds bool
+}
+
+// Init method would be trigger before working.
+func (a *AggregatorCorrelation) Init(context pipeline.Context, que pipeline.LogGroupQueue) (int, error) {
+	a.context = context
+	if a.CorrelationKey == "" {
+		return 0, fmt.Errorf("must specify CorrelationKey for plugin %v", pluginName)
+	}
+	if a.TimeoutMs <= 0 {
+		return 0, fmt.Errorf("TimeoutMs must be positive for plugin %v", pluginName)
+	}
+	if a.MaxSessions <= 0 {
+		a.MaxSessions = defaultMaxSessions
+	}
+	if a.FieldMode != fieldModeFirst && a.FieldMode != fieldModeLast {
+		return 0, fmt.Errorf("invalid field mode %v, you can only use \"first\" or \"last\"", a.FieldMode)
+	}
+	a.endRegs = make(map[string]*regexp.Regexp, len(a.EndConditions))
+	for key, pattern := range a.EndConditions {
+		reg, err := regexp.Compile(pattern)
+		if err != nil {
+			return 0, fmt.Errorf("invalid end condition %v of key %v for plugin %v: %v", pattern, key, pluginName, err)
+		}
+		a.endRegs[key] = reg
+	}
+	a.hasEndConds = len(a.endRegs) > 0
+	a.fieldKeys = make(map[string]struct{}, len(a.Fields))
+	for _, key := range a.Fields {
+		a.fieldKeys[key] = struct{}{}
+	}
+	a.sessions = make(map[string]*list.Element)
+	a.order = list.New()
+	a.timeout = time.Duration(a.TimeoutMs) * time.Millisecond
+	if a.nowFunc == nil {
+		a.nowFunc = time.Now
+	}
+	return 0, nil
+}
+
+// Description returns a one-sentence description on the Aggregator
+func (*AggregatorCorrelation) Description() string {
+	return "correlation aggregator for logtail, which merges the logs with the same correlation key"
+}
+
+// Add adds @log to the session of its correlation key.
+func (a *AggregatorCorrelation) Add(log *protocol.Log, ctx map[string]interface{}) error {
+	key, ok := "", false
+	matched := 0
+	for _, cont := range log.Contents {
+		if cont.Key == a.CorrelationKey {
+			key, ok = cont.Value, true
+		}
+		if reg, exist := a.endRegs[cont.Key]; exist && reg.MatchString(cont.Value) {
+			matched++
+		}
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if !ok {
+		a.closed = append(a.closed, log)
+		return nil
+	}
+	now := a.nowFunc()
+	var s *session
+	if elem, exist := a.sessions[key]; exist {
+		s = elem.Value.(*session)
+		a.order.MoveToBack(elem)
+	} else {
+		if len(a.sessions) >= a.MaxSessions {
+			logger.Warning(a.context.GetRuntimeContext(), util.AlarmCorrelation, "too many sessions, close the oldest one, max", a.MaxSessions)
+			a.closeSession(a.order.Front(), true)
+		}
+		s = &session{key: key, firstTime: log.Time, fields: make(map[string]string, len(a.Fields))}
+		a.sessions[key] = a.order.PushBack(s)
+	}
+	s.count++
+	s.lastSeen = now
+	if log.Time < s.firstTime {
+		s.firstTime = log.Time
+	}
+	if log.Time > s.lastTime {
+		s.lastTime = log.Time
+	}
+	for _, cont := range log.Contents {
+		if _, exist := a.fieldKeys[cont.Key]; !exist || cont.Value == "" {
+			continue
+		}
+		if _, exist := s.fields[cont.Key]; !exist || a.FieldMode == fieldModeLast {
+			s.fields[cont.Key] = cont.Value
+		}
+	}
+	if a.hasEndConds && matched == len(a.endRegs) {
+		s.ended = true
+	}
+	return nil
+}
+
+// Flush emits the closed sessions and the logs without correlation key.
+func (a *AggregatorCorrelation) Flush() []*protocol.LogGroup {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	now := a.nowFunc()
+	for elem := a.order.Front(); elem != nil; {
+		next := elem.Next()
+		s := elem.Value.(*session)
+		if s.ended || now.Sub(s.lastSeen) >= a.timeout {
+			a.closeSession(elem, false)
+		}
+		elem = next
+	}
+	if len(a.closed) == 0 {
+		return nil
+	}
+	logGroup := &protocol.LogGroup{Logs: a.closed}
+	a.closed = nil
+	return []*protocol.LogGroup{logGroup}
+}
+
+// Reset closes all the open sessions as partial, which are emitted by the next Flush.
+func (a *AggregatorCorrelation) Reset() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	for a.order.Len() > 0 {
+		s := a.order.Front().Value.(*session)
+		a.closeSession(a.order.Front(), !s.ended)
+	}
+}
+
+// Stop closes all the open sessions as partial when the config stops, which are emitted by the last Flush.
+func (a *AggregatorCorrelation) Stop() error {
+	a.Reset()
+	return nil
+}
+
+// closeSession removes the session from the open sessions, and converts it to the merged log.
+func (a *AggregatorCorrelation) closeSession(elem *list.Element, partial bool) {
+	s := a.order.Remove(elem).(*session)
+	delete(a.sessions, s.key)
+	log := &protocol.Log{Time: s.firstTime}
+	log.Contents = append(log.Contents,
+		&protocol.Log_Content{Key: a.CorrelationKey, Value: s.key},
+		&protocol.Log_Content{Key: a.CountKey, Value: strconv.FormatInt(s.count, 10)},
+		&protocol.Log_Content{Key: a.FirstTimeKey, Value: strconv.FormatUint(uint64(s.firstTime), 10)},
+		&protocol.Log_Content{Key: a.LastTimeKey, Value: strconv.FormatUint(uint64(s.lastTime), 10)},
+	)
+	for _, key := range a.Fields {
+		if value, ok := s.fields[key]; ok {
+			log.Contents = append(log.Contents, &protocol.Log_Content{Key: key, Value: value})
+		}
+	}
+	if partial {
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: a.PartialKey, Value: "true"})
+	}
+	a.closed = append(a.closed, log)
+}
+
+func init() {
+	pipeline.Aggregators[pluginName] = func() pipeline.Aggregator {
+		return &AggregatorCorrelation{
+			TimeoutMs:    30000,
+			FieldMode:    fieldModeFirst,
+			CountKey:     "__count__",
+			FirstTimeKey: "__first_time__",
+			LastTimeKey:  "__last_time__",
+			PartialKey:   "__partial__",
+			MaxSessions:  defaultMaxSessions,
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package correlation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+func newLog(t uint32, kvs ...string) *protocol.Log {
+	log := &protocol.Log{Time: t}
+	for i := 0; i+1 < len(kvs); i += 2 {
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: kvs[i], Value: kvs[i+1]})
+	}
+	return log
+}
+
+func contents(log *protocol.Log) map[string]string {
+	m := make(map[string]string, len(log.Contents))
+	for _, cont := range log.Contents {
+		m[cont.Key] = cont.Value
+	}
+	return m
+}
+
+func flushLogs(a *AggregatorCorrelation) []*protocol.Log {
+	var logs []*protocol.Log
+	for _, logGroup := range a.Flush() {
+		logs = append(logs, logGroup.Logs...)
+	}
+	return logs
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newAggregator(clock *fakeClock, modify func(a *AggregatorCorrelation)) (*AggregatorCorrelation, error) {
+	a := &AggregatorCorrelation{
+		CorrelationKey: "request_id",
+		TimeoutMs:      30000,
+		FieldMode:      fieldModeFirst,
+		CountKey:       "__count__",
+		FirstTimeKey:   "__first_time__",
+		LastTimeKey:    "__last_time__",
+		PartialKey:     "__partial__",
+		MaxSessions:    10000,
+		nowFunc:        clock.Now,
+	}
+	modify(a)
+	_, err := a.Init(mock.NewEmptyContext("p", "l", "c"), nil)
+	return a, err
+}
+
+func TestInvalidConfig(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1660000000, 0)}
+	_, err := newAggregator(clock, func(a *AggregatorCorrelation) { a.CorrelationKey = "" })
+	assert.Error(t, err)
+	_, err = newAggregator(clock, func(a *AggregatorCorrelation) { a.FieldMode = "any" })
+	assert.Error(t, err)
+	_, err = newAggregator(clock, func(a *AggregatorCorrelation) { a.EndConditions = map[string]string{"a": "("} })
+	assert.Error(t, err)
+}
+
+func TestTimeout(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1660000000, 0)}
+	agg, err := newAggregator(clock, func(a *AggregatorCorrelation) { a.Fields = []string{"user", "status"} })
+	require.NoError(t, err)
+
+	require.NoError(t, agg.Add(newLog(1660000001, "request_id", "r1", "user", "alice"), nil))
+	require.NoError(t, agg.Add(newLog(1660000000, "request_id", "r2"), nil))
+	require.NoError(t, agg.Add(newLog(1660000003, "request_id", "r1", "user", "bob", "status", "200"), nil))
+	require.NoError(t, agg.Add(newLog(1660000003, "msg", "no id"), nil))
+
+	logs := flushLogs(agg)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "no id", contents(logs[0])["msg"])
+
+	clock.now = clock.now.Add(20 * time.Second)
+	require.NoError(t, agg.Add(newLog(1660000020, "request_id", "r2"), nil))
+	clock.now = clock.now.Add(10 * time.Second)
+	logs = flushLogs(agg)
+	require.Len(t, logs, 1)
+	assert.Equal(t, uint32(1660000001), logs[0].Time)
+	assert.Equal(t, map[string]string{
+		"request_id":     "r1",
+		"__count__":      "2",
+		"__first_time__": "1660000001",
+		"__last_time__":  "1660000003",
+		"user":           "alice",
+		"status":         "200",
+	}, contents(logs[0]))
+
+	clock.now = clock.now.Add(20 * time.Second)
+	logs = flushLogs(agg)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "r2", contents(logs[0])["request_id"])
+	assert.Equal(t, "1660000020", contents(logs[0])["__last_time__"])
+	assert.Empty(t, agg.Flush())
+}
+
+func TestEndConditionsAndMaxSessions(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1660000000, 0)}
+	agg, err := newAggregator(clock, func(a *AggregatorCorrelation) {
+		a.EndConditions = map[string]string{"event": "^finish$"}
+		a.Fields = []string{"user"}
+		a.FieldMode = fieldModeLast
+		a.MaxSessions = 2
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, agg.Add(newLog(1660000000, "request_id", "r1", "event", "start", "user", "alice"), nil))
+	require.NoError(t, agg.Add(newLog(1660000001, "request_id", "r1", "event", "finish", "user", "bob"), nil))
+	require.NoError(t, agg.Add(newLog(1660000001, "request_id", "r2"), nil))
+	logs := flushLogs(agg)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "r1", contents(logs[0])["request_id"])
+	assert.Equal(t, "bob", contents(logs[0])["user"])
+
+	// r2 is the oldest session when r4 arrives
+	require.NoError(t, agg.Add(newLog(1660000002, "request_id", "r3"), nil))
+	require.NoError(t, agg.Add(newLog(1660000003, "request_id", "r4"), nil))
+	logs = flushLogs(agg)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "r2", contents(logs[0])["request_id"])
+	assert.Equal(t, "true", contents(logs[0])["__partial__"])
+}
+
+func TestPartialSessions(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1660000000, 0)}
+	agg, err := newAggregator(clock, func(a *AggregatorCorrelation) { a.MaxSessions = 0 })
+	require.NoError(t, err)
+	assert.Equal(t, defaultMaxSessions, agg.MaxSessions)
+
+	require.NoError(t, agg.Add(newLog(1660000000, "request_id", "r1"), nil))
+	require.NoError(t, agg.Add(newLog(1660000001, "request_id", "r2"), nil))
+	assert.Empty(t, agg.Flush())
+	agg.Reset()
+	logs := flushLogs(agg)
+	require.Len(t, logs, 2)
+	assert.Equal(t, "r1", contents(logs[0])["request_id"])
+	assert.Equal(t, "true", contents(logs[0])["__partial__"])
+
+	// the open sessions are emitted by the last flush when the config stops
+	require.NoError(t, agg.Add(newLog(1660000002, "request_id", "r3"), nil))
+	require.NoError(t, agg.Stop())
+	logs = flushLogs(agg)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "true", contents(logs[0])["__partial__"])
+}
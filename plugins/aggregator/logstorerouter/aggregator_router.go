@@ -105,7 +105,7 @@ func (p *AggregatorRouter) route(log *protocol.Log, value string) error {
 		return p.defaultAgg.Add(log, nil)
 	}
 	if p.NoMatchError {
-		logger.Warning(p.context.GetRuntimeContext(), "NO_MATCH_ROUTER_ALARM", "no match router", "drop this log")
+		logger.Warning(p.context.GetRuntimeContext(), util.AlarmNoMatchRouter, "no match router", "drop this log")
 	}
 	return nil
 }
@@ -130,7 +130,7 @@ func (p *AggregatorRouter) Add(log *protocol.Log, ctx map[string]interface{}) er
 		return p.defaultAgg.Add(log, ctx)
 	}
 	if p.NoMatchError {
-		logger.Warning(p.context.GetRuntimeContext(), "NO_MATCH_ROUTER_ALARM", "no match router", "drop this log")
+		logger.Warning(p.context.GetRuntimeContext(), util.AlarmNoMatchRouter, "no match router", "drop this log")
 	}
 	return nil
 }
@@ -15,6 +15,8 @@
 package metadatagroup
 
 import (
+	"crypto/md5" //nolint:gosec
+	"encoding/hex"
 	"fmt"
 	"strings"
 	"sync"
@@ -22,6 +24,7 @@ import (
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/models"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 const (
@@ -67,7 +70,7 @@ func (g *metadataGroup) collectByLengthChecker(group *models.PipelineGroupEvents
 		inputSize := len(group.Events)
 		availableSize := g.maxEventsLength - g.nowEventsLength
 		if availableSize < 0 {
-			logger.Error(g.context.GetRuntimeContext(), "RUNTIME_ALARM", "availableSize is negative")
+			logger.Error(g.context.GetRuntimeContext(), util.AlarmRuntime, "availableSize is negative")
 			_ = g.GetResultWithoutLock(ctx)
 			continue
 		}
@@ -93,7 +96,7 @@ func (g *metadataGroup) collectByLengthAndBytesChecker(group *models.PipelineGro
 		availableBytesSize := g.maxEventsByteLength - g.nowEventsByteLength
 		availableLenSize := g.maxEventsLength - g.nowEventsLength
 		if availableLenSize < 0 || availableBytesSize < 0 {
-			logger.Error(g.context.GetRuntimeContext(), "RUNTIME_ALARM", "availableSize or availableLength is negative")
+			logger.Error(g.context.GetRuntimeContext(), util.AlarmRuntime, "availableSize or availableLength is negative")
 			_ = g.GetResultWithoutLock(ctx)
 			continue
 		}
@@ -118,7 +121,7 @@ func (g *metadataGroup) collectByLengthAndBytesChecker(group *models.PipelineGro
 				num = 1
 				oversize = true
 			} else {
-				logger.Errorf(g.context.GetRuntimeContext(), "AGGREGATE_OVERSIZE_ALARM", "event[%s] size [%d] is over the limit size %d, the event would be dropped",
+				logger.Errorf(g.context.GetRuntimeContext(), util.AlarmAggregateOversize, "event[%s] size [%d] is over the limit size %d, the event would be dropped",
 					group.Events[0].GetName(),
 					len(group.Events[0].(models.ByteArray)),
 					g.maxEventsByteLength)
@@ -213,11 +216,11 @@ func (g *AggregatorMetadataGroup) GetResult(ctx pipeline.PipelineContext) error
 }
 
 func (g *AggregatorMetadataGroup) getOrCreateMetadataGroup(event *models.PipelineGroupEvents) *metadataGroup {
-	aggKey, metadata := g.buildGroupKey(event)
+	aggKey, metadataKeyValues := g.buildGroupKey(event)
 	group, ok := g.groupAgg.Load(aggKey)
 	if !ok {
 		newGroup := &metadataGroup{
-			group:               &models.GroupInfo{Metadata: metadata},
+			group:               newGroupInfo(aggKey, metadataKeyValues),
 			maxEventsLength:     g.GroupMaxEventLength,
 			maxEventsByteLength: g.GroupMaxByteLength,
 			dropOversizeEvent:   g.DropOversizeEvent,
@@ -229,7 +232,7 @@ func (g *AggregatorMetadataGroup) getOrCreateMetadataGroup(event *models.Pipelin
 	return group.(*metadataGroup)
 }
 
-func (g *AggregatorMetadataGroup) buildGroupKey(event *models.PipelineGroupEvents) (string, models.Metadata) {
+func (g *AggregatorMetadataGroup) buildGroupKey(event *models.PipelineGroupEvents) (string, map[string]string) {
 	metadataValues := strings.Builder{}
 	metadataKeyValues := map[string]string{}
 	for index, key := range g.GroupMetadataKeys {
@@ -240,7 +243,22 @@ func (g *AggregatorMetadataGroup) buildGroupKey(event *models.PipelineGroupEvent
 		}
 		metadataValues.WriteString(value)
 	}
-	return metadataValues.String(), models.NewMetadataWithMap(metadataKeyValues)
+	return metadataValues.String(), metadataKeyValues
+}
+
+// newGroupInfo returns the group info routing the groups of the same metadata values to the same Kafka partition,
+// SLS shard and Loki stream, whose labels are the metadata.
+func newGroupInfo(aggKey string, metadataKeyValues map[string]string) *models.GroupInfo {
+	stream := make(map[string]string, len(metadataKeyValues))
+	for k, v := range metadataKeyValues {
+		stream[k] = v
+	}
+	shardHash := md5.Sum([]byte(aggKey)) //nolint:gosec
+	return &models.GroupInfo{
+		Metadata:     models.NewMetadataWithMap(metadataKeyValues),
+		PartitionKey: aggKey,
+		RoutingHints: &models.RoutingHints{ShardHash: hex.EncodeToString(shardHash[:]), Stream: stream},
+	}
 }
 
 func NewAggregatorMetadataGroup() *AggregatorMetadataGroup {
@@ -292,6 +292,26 @@ func TestMetadataGroupGroup_Record_Tag(t *testing.T) {
 	require.Equal(t, "metaval", array[0].Group.Metadata.Get("meta"))
 }
 
+func TestMetadataGroupGroup_Record_Routing(t *testing.T) {
+	p := new(AggregatorMetadataGroup)
+	p.GroupMaxEventLength = 5
+	p.GroupMetadataKeys = []string{"meta", "db"}
+	events := constructEvents(12, map[string]string{
+		"meta": "metaval",
+		"db":   "db1",
+	}, map[string]string{})
+	ctx := pipeline.NewObservePipelineConext(100)
+	p.Init(mock.NewEmptyContext("a", "b", "c"), nil)
+	require.NoError(t, p.Record(events, ctx))
+	array := ctx.Collector().ToArray()
+	require.Equal(t, 2, len(array))
+	for _, group := range array {
+		require.Equal(t, "metaval_db1", group.GetPartitionKey())
+		require.Equal(t, "e8415d43a736f39821522654473387f6", group.GetRoutingHints().ShardHash)
+		require.Equal(t, map[string]string{"meta": "metaval", "db": "db1"}, group.GetRoutingHints().Stream)
+	}
+}
+
 func TestMetadataGroupGroup_Record_Timer(t *testing.T) {
 	p := new(AggregatorMetadataGroup)
 	p.GroupMaxEventLength = 500
@@ -0,0 +1,303 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topk
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const pluginName = "aggregator_topk"
+
+const (
+	sortByCount = "count"
+	sortBySum   = "sum"
+)
+
+const defaultEventTimeWindowMs = 60000
+
+type groupStats struct {
+	labels util.Labels
+	count  int64
+	sum    float64
+	digest *tDigest
+}
+
+// AggregatorTopK groups logs by GroupKeys in each window, and emits the summary metrics of the top K groups
+// sorted by count or sum of ValueKey, e.g. the request count and latency quantiles of the top 10 urls.
+// The metrics are named as MetricName_count, MetricName_sum and MetricName with the quantile label,
+// and the values of GroupKeys are used as labels. The quantiles are estimated by t-digest.
+// In EventTime mode, logs are grouped into the windows of their time, and the windows are emitted with
+// the time of their end once the watermark passes it, so that delayed or replayed logs are
+// counted in the windows they belong to.
+type AggregatorTopK struct {
+	GroupKeys []string
+	// The numeric content to compute sum and quantiles, only count is computed when empty.
+	ValueKey    string
+	SortBy      string
+	TopK        int
+	Quantiles   []float64
+	Compression float64
+	MetricName  string
+	// The window in milliseconds, the default flush interval is used when 0.
+	WindowMs int
+	// Logs of the new groups are dropped when the groups in the window exceed MaxGroups, no limit when not positive.
+	MaxGroups int
+	// Group logs by the windows of their time instead of the arrival time, WindowMs is 60000 when 0.
+	EventTime bool
+	// How late in seconds the logs could be in EventTime mode, the later logs are dropped.
+	AllowedLatenessSec int
+
+	context     pipeline.Context
+	lock        sync.Mutex
+	groups      map[string]*groupStats
+	windows     *helper.EventTimeWindows
+	eventGroups map[int64]map[string]*groupStats
+	lateLogs    int
+	quantileStr []string
+	nowFunc     func() time.Time
+}
+
+// Init method would be trigger before working.
+func (a *AggregatorTopK) Init(context pipeline.Context, que pipeline.LogGroupQueue) (int, error) {
+	a.context = context
+	if len(a.GroupKeys) == 0 {
+		return 0, fmt.Errorf("must specify GroupKeys for plugin %v", pluginName)
+	}
+	if a.MetricName == "" {
+		return 0, fmt.Errorf("must specify MetricName for plugin %v", pluginName)
+	}
+	switch a.SortBy {
+	case sortByCount:
+	case sortBySum:
+		if a.ValueKey == "" {
+			return 0, fmt.Errorf("must specify ValueKey to sort by sum for plugin %v", pluginName)
+		}
+	default:
+		return 0, fmt.Errorf("invalid sort by %v, you can only use \"count\" or \"sum\"", a.SortBy)
+	}
+	if a.TopK <= 0 {
+		return 0, fmt.Errorf("TopK must be positive for plugin %v", pluginName)
+	}
+	if a.Compression <= 0 {
+		return 0, fmt.Errorf("Compression must be positive for plugin %v", pluginName)
+	}
+	a.quantileStr = make([]string, len(a.Quantiles))
+	for i, q := range a.Quantiles {
+		if q < 0 || q > 1 {
+			return 0, fmt.Errorf("invalid quantile %v for plugin %v", q, pluginName)
+		}
+		a.quantileStr[i] = strconv.FormatFloat(q, 'g', -1, 64)
+	}
+	a.groups = make(map[string]*groupStats)
+	if a.nowFunc == nil {
+		a.nowFunc = time.Now
+	}
+	if a.EventTime {
+		if a.WindowMs == 0 {
+			a.WindowMs = defaultEventTimeWindowMs
+		}
+		if a.WindowMs < 1000 || a.AllowedLatenessSec < 0 {
+			return 0, fmt.Errorf("WindowMs must be at least 1000 and AllowedLatenessSec must not be negative in event time mode for plugin %v", pluginName)
+		}
+		a.windows = helper.NewEventTimeWindows(a.WindowMs/1000, a.AllowedLatenessSec, a.nowFunc)
+		a.eventGroups = make(map[int64]map[string]*groupStats)
+	}
+	return a.WindowMs, nil
+}
+
+// Description returns a one-sentence description on the Aggregator
+func (*AggregatorTopK) Description() string {
+	return "topk aggregator for logtail, which emits the summary metrics of the top K groups"
+}
+
+// Add adds @log to the group of it.
+func (a *AggregatorTopK) Add(log *protocol.Log, ctx map[string]interface{}) error {
+	values := make([]string, len(a.GroupKeys))
+	var value float64
+	hasValue := false
+	for _, cont := range log.Contents {
+		for i, key := range a.GroupKeys {
+			if cont.Key == key {
+				values[i] = cont.Value
+			}
+		}
+		if a.ValueKey != "" && cont.Key == a.ValueKey {
+			v, err := strconv.ParseFloat(cont.Value, 64)
+			if err != nil {
+				logger.Warning(a.context.GetRuntimeContext(), util.AlarmTopK, "invalid value", cont.Value, "key", a.ValueKey)
+				return nil
+			}
+			value, hasValue = v, true
+		}
+	}
+	if a.ValueKey != "" && !hasValue {
+		return nil
+	}
+	groupKey := strings.Join(values, "\x00")
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	groups := a.groups
+	if a.windows != nil {
+		start, ok := a.windows.Assign(int64(log.Time))
+		if !ok {
+			a.lateLogs++
+			return nil
+		}
+		if groups = a.eventGroups[start]; groups == nil {
+			groups = make(map[string]*groupStats)
+			a.eventGroups[start] = groups
+		}
+	}
+	group, ok := groups[groupKey]
+	if !ok {
+		if a.MaxGroups > 0 && len(groups) >= a.MaxGroups {
+			logger.Warning(a.context.GetRuntimeContext(), util.AlarmTopK, "too many groups, drop log of group", groupKey, "max", a.MaxGroups)
+			return nil
+		}
+		group = &groupStats{labels: make(util.Labels, len(a.GroupKeys))}
+		for i, key := range a.GroupKeys {
+			group.labels[i] = util.Label{Name: key, Value: values[i]}
+		}
+		sort.Sort(group.labels)
+		if a.ValueKey != "" && len(a.Quantiles) > 0 {
+			group.digest = newTDigest(a.Compression)
+		}
+		groups[groupKey] = group
+	}
+	group.count++
+	if hasValue {
+		group.sum += value
+		if group.digest != nil {
+			group.digest.Add(value)
+		}
+	}
+	return nil
+}
+
+// Flush emits the metrics of the top K groups in the window, and starts a new window.
+// In EventTime mode, the metrics of the closed windows are emitted.
+func (a *AggregatorTopK) Flush() []*protocol.LogGroup {
+	if a.windows != nil {
+		return a.flushEventTime()
+	}
+	a.lock.Lock()
+	groups := a.groups
+	a.groups = make(map[string]*groupStats)
+	a.lock.Unlock()
+	logs := a.topKMetrics(groups, a.nowFunc().UnixNano()/int64(time.Millisecond))
+	if len(logs) == 0 {
+		return nil
+	}
+	return []*protocol.LogGroup{{Logs: logs}}
+}
+
+func (a *AggregatorTopK) flushEventTime() []*protocol.LogGroup {
+	a.lock.Lock()
+	var starts []int64
+	for start := range a.eventGroups {
+		if a.windows.Closed(start) {
+			starts = append(starts, start)
+		}
+	}
+	closed := make(map[int64]map[string]*groupStats, len(starts))
+	for _, start := range starts {
+		closed[start] = a.eventGroups[start]
+		delete(a.eventGroups, start)
+	}
+	lateLogs := a.lateLogs
+	a.lateLogs = 0
+	a.lock.Unlock()
+
+	if lateLogs > 0 {
+		logger.Warning(a.context.GetRuntimeContext(), util.AlarmTopK, "drop logs later than the watermark, count", lateLogs)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+	logGroup := &protocol.LogGroup{}
+	for _, start := range starts {
+		logGroup.Logs = append(logGroup.Logs, a.topKMetrics(closed[start], (start+a.windows.Size())*1000)...)
+	}
+	if len(logGroup.Logs) == 0 {
+		return nil
+	}
+	return []*protocol.LogGroup{logGroup}
+}
+
+func (a *AggregatorTopK) topKMetrics(groupMap map[string]*groupStats, timeMs int64) []*protocol.Log {
+	groups := make([]*groupStats, 0, len(groupMap))
+	for _, group := range groupMap {
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if a.SortBy == sortBySum {
+			return groups[i].sum > groups[j].sum
+		}
+		return groups[i].count > groups[j].count
+	})
+	if len(groups) > a.TopK {
+		groups = groups[:a.TopK]
+	}
+	var logs []*protocol.Log
+	for _, group := range groups {
+		logs = append(logs, util.NewMetricLog(a.MetricName+"_count", timeMs, strconv.FormatInt(group.count, 10), group.labels))
+		if a.ValueKey == "" {
+			continue
+		}
+		logs = append(logs, util.NewMetricLog(a.MetricName+"_sum", timeMs, strconv.FormatFloat(group.sum, 'g', -1, 64), group.labels))
+		for i, q := range a.Quantiles {
+			labels := make(util.Labels, len(group.labels), len(group.labels)+1)
+			copy(labels, group.labels)
+			labels = append(labels, util.Label{Name: "quantile", Value: a.quantileStr[i]})
+			sort.Sort(labels)
+			logs = append(logs, util.NewMetricLog(a.MetricName, timeMs, strconv.FormatFloat(group.digest.Quantile(q), 'g', -1, 64), labels))
+		}
+	}
+	return logs
+}
+
+// Reset drops the groups in the current window.
+func (a *AggregatorTopK) Reset() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.groups = make(map[string]*groupStats)
+	if a.windows != nil {
+		a.windows.Reset()
+		a.eventGroups = make(map[int64]map[string]*groupStats)
+	}
+}
+
+func init() {
+	pipeline.Aggregators[pluginName] = func() pipeline.Aggregator {
+		return &AggregatorTopK{
+			SortBy:      sortByCount,
+			TopK:        10,
+			Quantiles:   []float64{0.5, 0.9, 0.99},
+			Compression: 100,
+			MetricName:  "topk",
+			MaxGroups:   10000,
+		}
+	}
+}
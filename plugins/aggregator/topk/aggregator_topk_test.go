@@ -0,0 +1,217 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topk
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+func newLog(kvs ...string) *protocol.Log {
+	log := &protocol.Log{}
+	for i := 0; i+1 < len(kvs); i += 2 {
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: kvs[i], Value: kvs[i+1]})
+	}
+	return log
+}
+
+// metrics returns the values of metric logs indexed by name and labels.
+func metrics(logGroups []*protocol.LogGroup) map[string]string {
+	m := make(map[string]string)
+	for _, logGroup := range logGroups {
+		for _, log := range logGroup.Logs {
+			var name, labels, value string
+			for _, cont := range log.Contents {
+				switch cont.Key {
+				case "__name__":
+					name = cont.Value
+				case "__labels__":
+					labels = cont.Value
+				case "__value__":
+					value = cont.Value
+				}
+			}
+			m[name+"{"+labels+"}"] = value
+		}
+	}
+	return m
+}
+
+func newAggregator() *AggregatorTopK {
+	return &AggregatorTopK{
+		SortBy:      sortByCount,
+		TopK:        10,
+		Quantiles:   []float64{0.5, 0.9, 0.99},
+		Compression: 100,
+		MetricName:  "topk",
+		MaxGroups:   10000,
+		nowFunc:     func() time.Time { return time.Unix(1660000000, 0) },
+	}
+}
+
+func TestTDigest(t *testing.T) {
+	digest := newTDigest(100)
+	assert.True(t, math.IsNaN(digest.Quantile(0.5)))
+	r := rand.New(rand.NewSource(1)) //nolint:gosec
+	for i := 0; i < 100000; i++ {
+		digest.Add(r.Float64() * 1000)
+	}
+	assert.Less(t, len(digest.centroids), 1000)
+	assert.InDelta(t, 500, digest.Quantile(0.5), 10)
+	assert.InDelta(t, 990, digest.Quantile(0.99), 2)
+	assert.InDelta(t, 1, digest.Quantile(0.001), 1)
+
+	digest = newTDigest(100)
+	digest.Add(42)
+	assert.Equal(t, float64(42), digest.Quantile(0.5))
+}
+
+func TestInvalidConfig(t *testing.T) {
+	ctx := mock.NewEmptyContext("p", "l", "c")
+	agg := newAggregator()
+	_, err := agg.Init(ctx, nil)
+	assert.Error(t, err)
+
+	agg = newAggregator()
+	agg.GroupKeys = []string{"url"}
+	agg.SortBy = sortBySum
+	_, err = agg.Init(ctx, nil)
+	assert.Error(t, err)
+
+	agg = newAggregator()
+	agg.GroupKeys = []string{"url"}
+	agg.Quantiles = []float64{1.5}
+	_, err = agg.Init(ctx, nil)
+	assert.Error(t, err)
+}
+
+func TestTopK(t *testing.T) {
+	agg := newAggregator()
+	agg.GroupKeys = []string{"url", "method"}
+	agg.ValueKey = "latency"
+	agg.TopK = 2
+	agg.WindowMs = 10000
+	interval, err := agg.Init(mock.NewEmptyContext("p", "l", "c"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 10000, interval)
+
+	for i := 1; i <= 100; i++ {
+		require.NoError(t, agg.Add(newLog("url", "/a", "method", "GET", "latency", strconv.Itoa(i)), nil))
+	}
+	for i := 0; i < 50; i++ {
+		require.NoError(t, agg.Add(newLog("url", "/b", "method", "GET", "latency", "1000"), nil))
+	}
+	require.NoError(t, agg.Add(newLog("url", "/c", "method", "GET", "latency", "1"), nil))
+	require.NoError(t, agg.Add(newLog("url", "/a", "method", "GET", "latency", "invalid"), nil))
+
+	m := metrics(agg.Flush())
+	assert.Len(t, m, 10)
+	assert.Equal(t, "100", m["topk_count{method#$#GET|url#$#/a}"])
+	assert.Equal(t, "5050", m["topk_sum{method#$#GET|url#$#/a}"])
+	assert.Equal(t, "50", m["topk_count{method#$#GET|url#$#/b}"])
+	assert.Equal(t, "1000", m["topk{method#$#GET|quantile#$#0.5|url#$#/b}"])
+	median, err := strconv.ParseFloat(m["topk{method#$#GET|quantile#$#0.5|url#$#/a}"], 64)
+	require.NoError(t, err)
+	assert.InDelta(t, 50.5, median, 1)
+	assert.NotContains(t, m, "topk_count{method#$#GET|url#$#/c}")
+
+	// a new window is started after flush
+	assert.Empty(t, agg.Flush())
+
+	// sort by sum
+	agg.SortBy = sortBySum
+	agg.TopK = 1
+	for i := 0; i < 10; i++ {
+		require.NoError(t, agg.Add(newLog("url", "/a", "latency", "1"), nil))
+	}
+	require.NoError(t, agg.Add(newLog("url", "/b", "latency", "100"), nil))
+	m = metrics(agg.Flush())
+	assert.Equal(t, "1", m["topk_count{method#$#|url#$#/b}"])
+}
+
+func TestMaxGroups(t *testing.T) {
+	agg := newAggregator()
+	agg.GroupKeys = []string{"url"}
+	agg.MaxGroups = 2
+	_, err := agg.Init(mock.NewEmptyContext("p", "l", "c"), nil)
+	require.NoError(t, err)
+	for _, url := range []string{"/a", "/b", "/c", "/a"} {
+		require.NoError(t, agg.Add(newLog("url", url), nil))
+	}
+	assert.Equal(t, map[string]string{
+		"topk_count{url#$#/a}": "2",
+		"topk_count{url#$#/b}": "1",
+	}, metrics(agg.Flush()))
+
+	// no limit when not positive
+	agg.MaxGroups = 0
+	for _, url := range []string{"/a", "/b", "/c"} {
+		require.NoError(t, agg.Add(newLog("url", url), nil))
+	}
+	assert.Len(t, metrics(agg.Flush()), 3)
+}
+
+func TestEventTime(t *testing.T) {
+	now := time.Unix(1660000100, 0)
+	agg := newAggregator()
+	agg.GroupKeys = []string{"url"}
+	agg.EventTime = true
+	agg.AllowedLatenessSec = 30
+	agg.nowFunc = func() time.Time { return now }
+	interval, err := agg.Init(mock.NewEmptyContext("p", "l", "c"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, defaultEventTimeWindowMs, interval)
+
+	// the start of a window
+	const base = 1660000020
+	add := func(url string, offset uint32) {
+		log := newLog("url", url)
+		log.Time = base + offset
+		require.NoError(t, agg.Add(log, nil))
+	}
+	// replayed logs of two windows arrive at once
+	add("/a", 0)
+	add("/a", 10)
+	add("/b", 70)
+	assert.Empty(t, agg.Flush())
+	// the watermark passes the end of the first window
+	add("/a", 100)
+	logGroups := agg.Flush()
+	require.Len(t, logGroups, 1)
+	require.Len(t, logGroups[0].Logs, 1)
+	assert.Equal(t, uint32(base+60), logGroups[0].Logs[0].Time)
+	assert.Equal(t, map[string]string{"topk_count{url#$#/a}": "2"}, metrics(logGroups))
+
+	// a delayed log within the lateness
+	add("/b", 75)
+	// a log later than the watermark is dropped
+	add("/a", 50)
+
+	// the watermark moves on while idle
+	now = now.Add(time.Minute)
+	logGroups = agg.Flush()
+	require.Len(t, logGroups, 1)
+	assert.Equal(t, map[string]string{"topk_count{url#$#/a}": "1", "topk_count{url#$#/b}": "2"}, metrics(logGroups))
+	assert.Equal(t, uint32(base+120), logGroups[0].Logs[0].Time)
+}
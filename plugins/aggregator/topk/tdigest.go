@@ -0,0 +1,117 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topk
+
+import (
+	"math"
+	"sort"
+)
+
+type centroid struct {
+	mean   float64
+	weight float64
+}
+
+// tDigest is a merging t-digest, which estimates quantiles of a stream in bounded memory.
+// The values are buffered and merged into centroids when the buffer is full, and the size of
+// each centroid is bounded by 4*n*q*(1-q)/compression, so the quantiles near 0 and 1 are more accurate.
+type tDigest struct {
+	compression float64
+	centroids   []centroid
+	buffer      []centroid
+	count       float64
+	min         float64
+	max         float64
+}
+
+func newTDigest(compression float64) *tDigest {
+	return &tDigest{
+		compression: compression,
+		buffer:      make([]centroid, 0, int(compression)*5),
+		min:         math.Inf(1),
+		max:         math.Inf(-1),
+	}
+}
+
+func (t *tDigest) Add(value float64) {
+	if math.IsNaN(value) {
+		return
+	}
+	t.buffer = append(t.buffer, centroid{mean: value, weight: 1})
+	t.count++
+	t.min = math.Min(t.min, value)
+	t.max = math.Max(t.max, value)
+	if len(t.buffer) == cap(t.buffer) {
+		t.compress()
+	}
+}
+
+func (t *tDigest) compress() {
+	if len(t.buffer) == 0 {
+		return
+	}
+	all := append(t.buffer, t.centroids...)
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].mean < all[j].mean
+	})
+	merged := make([]centroid, 0, len(t.centroids)+1)
+	cur := all[0]
+	weightSoFar := 0.0
+	for _, c := range all[1:] {
+		q := (weightSoFar + (cur.weight+c.weight)/2) / t.count
+		if cur.weight+c.weight <= 4*t.count*q*(1-q)/t.compression {
+			cur.mean += (c.mean - cur.mean) * c.weight / (cur.weight + c.weight)
+			cur.weight += c.weight
+			continue
+		}
+		merged = append(merged, cur)
+		weightSoFar += cur.weight
+		cur = c
+	}
+	t.centroids = append(merged, cur)
+	t.buffer = t.buffer[:0]
+}
+
+// Quantile returns the estimated value at quantile q, which is interpolated between the centers of centroids.
+func (t *tDigest) Quantile(q float64) float64 {
+	t.compress()
+	switch {
+	case len(t.centroids) == 0:
+		return math.NaN()
+	case q <= 0:
+		return t.min
+	case q >= 1:
+		return t.max
+	}
+	target := q * t.count
+	prevCenter, prevMean := 0.0, t.min
+	weightSoFar := 0.0
+	for _, c := range t.centroids {
+		center := weightSoFar + c.weight/2
+		if target < center {
+			return interpolate(prevCenter, prevMean, center, c.mean, target)
+		}
+		prevCenter, prevMean = center, c.mean
+		weightSoFar += c.weight
+	}
+	return interpolate(prevCenter, prevMean, t.count, t.max, target)
+}
+
+func interpolate(x0, y0, x1, y1, x float64) float64 {
+	if x1 <= x0 {
+		return y1
+	}
+	return y0 + (y1-y0)*(x-x0)/(x1-x0)
+}
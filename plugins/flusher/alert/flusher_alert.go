@@ -0,0 +1,334 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alert
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	receiverWebhook  = "webhook"
+	receiverDingTalk = "dingtalk"
+	receiverSlack    = "slack"
+
+	defaultTimeout    = 10 * time.Second
+	defaultMaxRetries = 3
+	defaultQueueSize  = 100
+	retryDelay        = time.Second
+)
+
+var errStopped = fmt.Errorf("flusher_alert is stopped")
+
+// alertKeys are the contents of the alert logs other than the labels.
+var alertKeys = map[string]bool{
+	util.AlertNameKey:      true,
+	util.AlertSeverityKey:  true,
+	util.AlertMessageKey:   true,
+	util.AlertCountKey:     true,
+	util.AlertThresholdKey: true,
+	util.AlertWindowKey:    true,
+}
+
+// FlusherAlert sends the alert logs emitted by processor_alert to a webhook, a DingTalk robot or a Slack
+// incoming webhook, the other logs are ignored. The alerts are sent directly from the agent, so that they
+// are received even if the backend of the logs is unreachable. Flush only queues the alerts, which are sent
+// with the retries by the goroutine of the flusher, so a slow receiver never blocks the pipeline.
+type FlusherAlert struct {
+	// Receiver is the type of URL, which is webhook, dingtalk or slack, webhook by default.
+	Receiver string
+	URL      string
+	Headers  map[string]string
+	// Secret signs the requests of the DingTalk robots with the signature security setting.
+	Secret string
+	// Timeout of each request, 10s by default.
+	Timeout time.Duration
+	// MaxRetries of each alert when the request fails, 3 by default.
+	MaxRetries int
+	// QueueSize is the max alerts waiting to be sent, the new alerts are dropped when full, 100 by default.
+	QueueSize int
+	// Connection configures the connection pool of the flusher.
+	Connection helper.HTTPConnectionConfig
+
+	context pipeline.Context
+	client  *http.Client
+	nowFunc func() time.Time
+	queue   chan *pendingAlert
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// pendingAlert is the encoded alert waiting to be sent.
+type pendingAlert struct {
+	name string
+	body []byte
+}
+
+// webhookAlert is the body of the webhook receiver.
+type webhookAlert struct {
+	Name      string            `json:"name"`
+	Severity  string            `json:"severity"`
+	Message   string            `json:"message,omitempty"`
+	Count     int               `json:"count"`
+	Threshold int               `json:"threshold"`
+	WindowSec int               `json:"window_sec"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Time      int64             `json:"time"`
+}
+
+func (f *FlusherAlert) Init(context pipeline.Context) error {
+	f.context = context
+	if f.URL == "" {
+		err := fmt.Errorf("URL of flusher_alert is empty")
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "init alert flusher fail, error", err)
+		return err
+	}
+	switch f.Receiver {
+	case "":
+		f.Receiver = receiverWebhook
+	case receiverWebhook, receiverDingTalk, receiverSlack:
+	default:
+		err := fmt.Errorf("invalid Receiver %s of flusher_alert, must be one of webhook, dingtalk and slack", f.Receiver)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "init alert flusher fail, error", err)
+		return err
+	}
+	if f.Timeout <= 0 {
+		f.Timeout = defaultTimeout
+	}
+	if f.MaxRetries < 0 {
+		f.MaxRetries = 0
+	}
+	if f.QueueSize <= 0 {
+		f.QueueSize = defaultQueueSize
+	}
+	f.client = &http.Client{Timeout: f.Timeout, Transport: f.Connection.NewTransport(nil, 0)}
+	if f.nowFunc == nil {
+		f.nowFunc = time.Now
+	}
+	f.queue = make(chan *pendingAlert, f.QueueSize)
+	f.stop = make(chan struct{})
+	f.wg.Add(1)
+	go f.run()
+	return nil
+}
+
+func (f *FlusherAlert) Description() string {
+	return "alert flusher for logtail, which sends the alerts to webhook, dingtalk or slack"
+}
+
+func (f *FlusherAlert) Flush(projectName string, logstoreName string, configName string, logGroupList []*protocol.LogGroup) error {
+	for _, logGroup := range logGroupList {
+		for _, log := range logGroup.Logs {
+			alert, ok := parseAlert(log)
+			if !ok {
+				continue
+			}
+			body, err := f.encode(alert)
+			if err != nil {
+				logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "encode alert fail, alert", alert.Name, "error", err)
+				continue
+			}
+			select {
+			case f.queue <- &pendingAlert{name: alert.Name, body: body}:
+			default:
+				logger.Warning(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "the alert queue is full, drop alert", alert.Name, "queue size", f.QueueSize)
+			}
+		}
+	}
+	return nil
+}
+
+// run sends the queued alerts until the flusher is stopped.
+func (f *FlusherAlert) run() {
+	defer f.wg.Done()
+	for {
+		select {
+		case <-f.stop:
+			if dropped := len(f.queue); dropped > 0 {
+				logger.Warning(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "drop the alerts not sent at stop, count", dropped)
+			}
+			return
+		case alert := <-f.queue:
+			if err := f.sendWithRetry(alert.body); err != nil && err != errStopped {
+				logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "send alert fail, alert", alert.name, "error", err)
+			}
+		}
+	}
+}
+
+// parseAlert returns the alert of the log, or false if the log is not an alert.
+func parseAlert(log *protocol.Log) (*webhookAlert, bool) {
+	alert := &webhookAlert{Time: int64(log.Time)}
+	for _, cont := range log.Contents {
+		switch cont.Key {
+		case util.AlertNameKey:
+			alert.Name = cont.Value
+		case util.AlertSeverityKey:
+			alert.Severity = cont.Value
+		case util.AlertMessageKey:
+			alert.Message = cont.Value
+		case util.AlertCountKey:
+			alert.Count, _ = strconv.Atoi(cont.Value)
+		case util.AlertThresholdKey:
+			alert.Threshold, _ = strconv.Atoi(cont.Value)
+		case util.AlertWindowKey:
+			alert.WindowSec, _ = strconv.Atoi(cont.Value)
+		default:
+			if !alertKeys[cont.Key] && cont.Key != "__time__" {
+				if alert.Labels == nil {
+					alert.Labels = make(map[string]string)
+				}
+				alert.Labels[cont.Key] = cont.Value
+			}
+		}
+	}
+	return alert, alert.Name != ""
+}
+
+// text renders the alert for the chat receivers.
+func (a *webhookAlert) text() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[%s] %s", a.Severity, a.Name)
+	if a.Message != "" {
+		fmt.Fprintf(&sb, ": %s", a.Message)
+	}
+	fmt.Fprintf(&sb, "\n%d logs in %ds exceed the threshold %d", a.Count, a.WindowSec, a.Threshold)
+	keys := make([]string, 0, len(a.Labels))
+	for k := range a.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&sb, "\n%s: %s", k, a.Labels[k])
+	}
+	return sb.String()
+}
+
+func (f *FlusherAlert) encode(alert *webhookAlert) ([]byte, error) {
+	switch f.Receiver {
+	case receiverDingTalk:
+		return json.Marshal(map[string]interface{}{
+			"msgtype": "text",
+			"text":    map[string]string{"content": alert.text()},
+		})
+	case receiverSlack:
+		return json.Marshal(map[string]string{"text": alert.text()})
+	}
+	return json.Marshal(alert)
+}
+
+func (f *FlusherAlert) sendWithRetry(body []byte) error {
+	var err error
+	for i := 0; i <= f.MaxRetries; i++ {
+		if i > 0 && util.Sleep(retryDelay, f.stop) {
+			return errStopped
+		}
+		if err = f.send(body); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (f *FlusherAlert) send(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, f.requestURL(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range f.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d, response %s", resp.StatusCode, respBody)
+	}
+	if f.Receiver == receiverDingTalk {
+		// the dingtalk robots respond 200 with the error code
+		var result struct {
+			ErrCode int    `json:"errcode"`
+			ErrMsg  string `json:"errmsg"`
+		}
+		if err = json.Unmarshal(respBody, &result); err == nil && result.ErrCode != 0 {
+			return fmt.Errorf("dingtalk error %d: %s", result.ErrCode, result.ErrMsg)
+		}
+	}
+	return nil
+}
+
+// requestURL adds the signature to the URL of the DingTalk robots.
+func (f *FlusherAlert) requestURL() string {
+	if f.Receiver != receiverDingTalk || f.Secret == "" {
+		return f.URL
+	}
+	timestamp := strconv.FormatInt(f.nowFunc().UnixNano()/int64(time.Millisecond), 10)
+	mac := hmac.New(sha256.New, []byte(f.Secret))
+	_, _ = mac.Write([]byte(timestamp + "\n" + f.Secret))
+	sign := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	sep := "?"
+	if strings.Contains(f.URL, "?") {
+		sep = "&"
+	}
+	return f.URL + sep + "timestamp=" + timestamp + "&sign=" + url.QueryEscape(sign)
+}
+
+func (*FlusherAlert) SetUrgent(flag bool) {
+}
+
+// IsReady is always ready after Init, the alerts are dropped when the queue is full.
+func (f *FlusherAlert) IsReady(projectName string, logstoreName string, logstoreKey int64) bool {
+	return f.client != nil
+}
+
+// Stop stops sending the alerts, and waits for the sending one.
+func (f *FlusherAlert) Stop() error {
+	close(f.stop)
+	f.wg.Wait()
+	f.client.CloseIdleConnections()
+	return nil
+}
+
+func init() {
+	pipeline.Flushers["flusher_alert"] = func() pipeline.Flusher {
+		return &FlusherAlert{
+			Timeout:    defaultTimeout,
+			MaxRetries: defaultMaxRetries,
+			QueueSize:  defaultQueueSize,
+		}
+	}
+}
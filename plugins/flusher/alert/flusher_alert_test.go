@@ -0,0 +1,177 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alert
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+type request struct {
+	query string
+	body  map[string]interface{}
+}
+
+func newServer(t *testing.T, response string, failures int) (*httptest.Server, func() []request) {
+	var lock sync.Mutex
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		body := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(data, &body))
+		requests = append(requests, request{query: r.URL.RawQuery, body: body})
+		_, _ = w.Write([]byte(response))
+	}))
+	return server, func() []request {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]request(nil), requests...)
+	}
+}
+
+// flushAndWait flushes the log groups and waits for the expected requests sent by the flusher.
+func flushAndWait(t *testing.T, f *FlusherAlert, requests func() []request, expected int) []request {
+	require.NoError(t, f.Flush("p", "l", "c", newLogGroup()))
+	require.Eventually(t, func() bool { return len(requests()) == expected }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, f.Stop())
+	return requests()
+}
+
+func newLogGroup() []*protocol.LogGroup {
+	return []*protocol.LogGroup{{Logs: []*protocol.Log{
+		{Time: 1700000000, Contents: []*protocol.Log_Content{{Key: "content", Value: "not an alert"}}},
+		{Time: 1700000000, Contents: []*protocol.Log_Content{
+			{Key: util.AlertNameKey, Value: "too_many_errors"},
+			{Key: util.AlertSeverityKey, Value: "critical"},
+			{Key: util.AlertCountKey, Value: "120"},
+			{Key: util.AlertThresholdKey, Value: "100"},
+			{Key: util.AlertWindowKey, Value: "60"},
+			{Key: "app", Value: "x"},
+		}},
+	}}}
+}
+
+func TestFlusherAlertWebhook(t *testing.T) {
+	server, requests := newServer(t, "", 1)
+	defer server.Close()
+	f := &FlusherAlert{URL: server.URL, MaxRetries: 1}
+	require.NoError(t, f.Init(mock.NewEmptyContext("p", "l", "c")))
+	body := flushAndWait(t, f, requests, 1)[0].body
+	assert.Equal(t, "too_many_errors", body["name"])
+	assert.Equal(t, "critical", body["severity"])
+	assert.Equal(t, float64(120), body["count"])
+	assert.Equal(t, float64(100), body["threshold"])
+	assert.Equal(t, float64(60), body["window_sec"])
+	assert.Equal(t, map[string]interface{}{"app": "x"}, body["labels"])
+	assert.Equal(t, float64(1700000000), body["time"])
+}
+
+func TestFlusherAlertDingTalk(t *testing.T) {
+	server, requests := newServer(t, `{"errcode":0,"errmsg":"ok"}`, 0)
+	defer server.Close()
+	now := time.Unix(1700000000, 0)
+	f := &FlusherAlert{Receiver: "dingtalk", URL: server.URL + "/robot/send?access_token=t", Secret: "SEC", nowFunc: func() time.Time { return now }}
+	require.NoError(t, f.Init(mock.NewEmptyContext("p", "l", "c")))
+	sent := flushAndWait(t, f, requests, 1)
+	mac := hmac.New(sha256.New, []byte("SEC"))
+	_, _ = mac.Write([]byte("1700000000000\nSEC"))
+	sign := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	req := httptest.NewRequest(http.MethodPost, "/?"+sent[0].query, nil)
+	assert.Equal(t, "t", req.URL.Query().Get("access_token"))
+	assert.Equal(t, "1700000000000", req.URL.Query().Get("timestamp"))
+	assert.Equal(t, sign, req.URL.Query().Get("sign"))
+	assert.Equal(t, "text", sent[0].body["msgtype"])
+	content := sent[0].body["text"].(map[string]interface{})["content"]
+	assert.Equal(t, "[critical] too_many_errors\n120 logs in 60s exceed the threshold 100\napp: x", content)
+
+	failed := &FlusherAlert{Receiver: "dingtalk", MaxRetries: 0}
+	errServer, _ := newServer(t, `{"errcode":310000,"errmsg":"sign not match"}`, 0)
+	defer errServer.Close()
+	failed.URL = errServer.URL
+	require.NoError(t, failed.Init(mock.NewEmptyContext("p", "l", "c")))
+	assert.Error(t, failed.send([]byte("{}")))
+	require.NoError(t, failed.Stop())
+}
+
+func TestFlusherAlertSlack(t *testing.T) {
+	server, requests := newServer(t, "ok", 0)
+	defer server.Close()
+	f := &FlusherAlert{Receiver: "slack", URL: server.URL}
+	require.NoError(t, f.Init(mock.NewEmptyContext("p", "l", "c")))
+	sent := flushAndWait(t, f, requests, 1)
+	assert.Contains(t, sent[0].body["text"], "[critical] too_many_errors")
+
+	assert.Error(t, (&FlusherAlert{}).Init(mock.NewEmptyContext("p", "l", "c")))
+	assert.Error(t, (&FlusherAlert{URL: server.URL, Receiver: "email"}).Init(mock.NewEmptyContext("p", "l", "c")))
+}
+
+func TestFlusherAlertQueueFull(t *testing.T) {
+	block := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	}))
+	defer server.Close()
+	f := &FlusherAlert{URL: server.URL, QueueSize: 1, MaxRetries: 5}
+	require.NoError(t, f.Init(mock.NewEmptyContext("p", "l", "c")))
+	defer func() {
+		close(block)
+		require.NoError(t, f.Stop())
+	}()
+	// the receiver never responds, but the flushes are not blocked and the overflowed alerts are dropped
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		require.NoError(t, f.Flush("p", "l", "c", newLogGroup()))
+	}
+	assert.Less(t, time.Since(start), time.Second)
+	assert.LessOrEqual(t, len(f.queue), 1)
+	assert.True(t, f.IsReady("p", "l", 0))
+}
+
+func TestFlusherAlertConnection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	f := &FlusherAlert{URL: server.URL, Connection: helper.HTTPConnectionConfig{MaxConnsPerHost: 2}}
+	require.NoError(t, f.Init(mock.NewEmptyContext("p", "l", "c")))
+	defer func() {
+		require.NoError(t, f.Stop())
+	}()
+	transport, ok := f.client.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.Equal(t, 2, transport.MaxConnsPerHost)
+	assert.NotSame(t, http.DefaultTransport, transport, "the flusher should own the transport")
+}
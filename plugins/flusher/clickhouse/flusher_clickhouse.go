@@ -27,6 +27,7 @@ import (
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/protocol"
 	converter "github.com/alibaba/ilogtail/pkg/protocol/converter"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 type FlusherClickHouse struct {
@@ -123,7 +124,7 @@ func (f *FlusherClickHouse) Init(context pipeline.Context) error {
 	f.context = context
 	// Validate config of flusher
 	if err := f.Validate(); err != nil {
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init clickhouse flusher fail, error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "init clickhouse flusher fail, error", err)
 		return err
 	}
 	// Set default value while not set
@@ -136,18 +137,18 @@ func (f *FlusherClickHouse) Init(context pipeline.Context) error {
 	// Init converter
 	convert, err := f.getConverter()
 	if err != nil {
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init clickhouse flusher converter fail, error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "init clickhouse flusher converter fail, error", err)
 		return err
 	}
 	f.converter = convert
 	conn, err := newConn(f)
 	if err != nil {
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init clickhouse flusher error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "init clickhouse flusher error", err)
 		return err
 	}
 	f.conn = conn
 	if err = createNullBufferTable(f); err != nil {
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init clickhouse flusher error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "init clickhouse flusher error", err)
 		return err
 	}
 	return nil
@@ -160,12 +161,12 @@ func (f *FlusherClickHouse) Description() string {
 func (f *FlusherClickHouse) Validate() error {
 	if f.Addresses == nil || len(f.Addresses) == 0 {
 		var err = fmt.Errorf("clickhouse addrs is nil")
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init clickhouse flusher error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "init clickhouse flusher error", err)
 		return err
 	}
 	if f.Table == "" {
 		var err = fmt.Errorf("clickhouse table is nil")
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init clickhouse flusher error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "init clickhouse flusher error", err)
 		return err
 	}
 	return nil
@@ -188,13 +189,13 @@ func (f *FlusherClickHouse) BufferFlush(projectName string, logstoreName string,
 		// Merge topicKeys and HashKeys,Only one convert after merge
 		serializedLogs, err := f.converter.ToByteStream(logGroup)
 		if err != nil {
-			logger.Error(f.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "flush clickhouse convert log fail, error", err)
+			logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "flush clickhouse convert log fail, error", err)
 		}
 		for _, log := range serializedLogs.([][]byte) {
 			sql := fmt.Sprintf("INSERT INTO %s.ilogtail_%s_buffer (_timestamp, _log) VALUES (%d, '%s')", f.Authentication.PlainText.Database, f.Table, time.Now().Unix(), string(log))
 			err = f.conn.AsyncInsert(ctx, sql, false)
 			if err != nil {
-				logger.Error(f.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "flush clickhouse AsyncInsert fail, error", err)
+				logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "flush clickhouse AsyncInsert fail, error", err)
 			}
 		}
 		logger.Debug(f.context.GetRuntimeContext(), "ClickHouse success send events: messageID")
@@ -209,6 +210,7 @@ func (f *FlusherClickHouse) IsReady(projectName string, logstoreName string, log
 }
 
 func (f *FlusherClickHouse) Stop() error {
+	_ = f.Authentication.TLS.Close()
 	return f.conn.Close()
 }
 
@@ -223,7 +225,7 @@ func init() {
 func newConn(f *FlusherClickHouse) (driver.Conn, error) {
 	compression, err := compressionMethod(f.Compression)
 	if err != nil {
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init clickhouse flusher error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "init clickhouse flusher error", err)
 		return nil, err
 	}
 	opt := &clickhouse.Options{
@@ -247,20 +249,20 @@ func newConn(f *FlusherClickHouse) (driver.Conn, error) {
 	}
 	if err = f.Authentication.ConfigureAuthentication(opt); err != nil {
 		err = fmt.Errorf("configure authenticationfailed, err: %w", err)
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init clickhouse flusher error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "init clickhouse flusher error", err)
 		return nil, err
 	}
 	conn, err := clickhouse.Open(opt)
 	if err != nil {
 		err = fmt.Errorf("sql open failed, err: %w", err)
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init clickhouse flusher error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "init clickhouse flusher error", err)
 		return nil, err
 	}
 	if err = conn.Ping(context.Background()); err != nil {
 		if exception, ok := err.(*clickhouse.Exception); ok {
-			logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "code", exception.Code, "msg", exception.Message, "trace", exception.StackTrace)
+			logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "code", exception.Code, "msg", exception.Message, "trace", exception.StackTrace)
 		} else {
-			logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "error", err)
+			logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "error", err)
 		}
 		return nil, err
 	}
@@ -276,7 +278,7 @@ func createNullBufferTable(f *FlusherClickHouse) error {
 	}
 	sqlNull := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (`_timestamp` Int64,`_log` String) ENGINE = Null", sqlNullTableName)
 	if err := f.conn.Exec(context.Background(), sqlNull); err != nil {
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "sql", sqlNull, "error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "sql", sqlNull, "error", err)
 		return err
 	}
 	sqlBuffer := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s AS `%s`.`ilogtail_%s` ENGINE = Buffer(%s, ilogtail_%s, %d, %d, %d, %d, %d, %d, %d)",
@@ -286,7 +288,7 @@ func createNullBufferTable(f *FlusherClickHouse) error {
 		f.BufferNumLayers, f.BufferMinTime, f.BufferMaxTime, f.BufferMinRows, f.BufferMaxRows, f.BufferMinBytes, f.BufferMaxBytes,
 	)
 	if err := f.conn.Exec(context.Background(), sqlBuffer); err != nil {
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "sql", sqlBuffer, "error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "sql", sqlBuffer, "error", err)
 		return err
 	}
 	return nil
@@ -0,0 +1,94 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connector
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper/connector"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const defaultPushTimeoutMs = 5000
+
+// FlusherConnector passes the LogGroups of the pipeline to the service_connector inputs of other pipelines
+// with the same Name. It is not ready when the connector is full, so the pipeline is blocked until the
+// consuming pipelines catch up.
+type FlusherConnector struct {
+	// Name of the connector, which must be the same as the Name of service_connector.
+	Name string
+	// QueueSize is the capacity of the connector in LogGroups, it only takes effect on the side created first.
+	QueueSize int
+	// PushTimeoutMs is the max time to wait for the room of the connector, after which the flush fails.
+	PushTimeoutMs int
+
+	context   pipeline.Context
+	connector *connector.Connector
+}
+
+func (f *FlusherConnector) Init(context pipeline.Context) error {
+	f.context = context
+	if f.Name == "" {
+		return fmt.Errorf("must specify the Name of the connector")
+	}
+	if f.PushTimeoutMs <= 0 {
+		f.PushTimeoutMs = defaultPushTimeoutMs
+	}
+	f.connector = connector.Get(f.Name, f.QueueSize)
+	return nil
+}
+
+func (f *FlusherConnector) Description() string {
+	return "connector flusher for passing the logs to other pipelines in the same agent"
+}
+
+func (f *FlusherConnector) IsReady(projectName string, logstoreName string, logstoreKey int64) bool {
+	return !f.connector.Full()
+}
+
+func (f *FlusherConnector) Flush(projectName string, logstoreName string, configName string, logGroupList []*protocol.LogGroup) error {
+	timeout := time.Duration(f.PushTimeoutMs) * time.Millisecond
+	for _, logGroup := range logGroupList {
+		if len(logGroup.Logs) == 0 {
+			continue
+		}
+		if !f.connector.Push(protocol.CloneLogGroup(logGroup), timeout) {
+			err := fmt.Errorf("connector %s is full after %v", f.Name, timeout)
+			logger.Warning(f.context.GetRuntimeContext(), util.AlarmConnectorFlush, "push error", err, "logs", len(logGroup.Logs))
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *FlusherConnector) SetUrgent(flag bool) {
+}
+
+func (f *FlusherConnector) Stop() error {
+	return nil
+}
+
+func init() {
+	pipeline.Flushers["flusher_connector"] = func() pipeline.Flusher {
+		return &FlusherConnector{
+			QueueSize:     connector.DefaultQueueSize,
+			PushTimeoutMs: defaultPushTimeoutMs,
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"github.com/alibaba/ilogtail/helper/externalplugin"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+// FlusherExternal sends the logs by the flusher plugin implemented by a sidecar, see externalplugin.Options.
+type FlusherExternal struct {
+	externalplugin.Options
+
+	context pipeline.Context
+	client  *externalplugin.Client
+}
+
+func (f *FlusherExternal) Init(context pipeline.Context) error {
+	f.context = context
+	var err error
+	if f.client, err = externalplugin.NewClient(f.Options, externalplugin.CategoryFlusher, context.GetConfigName()); err != nil {
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "init external flusher error", err)
+		return err
+	}
+	return nil
+}
+
+func (f *FlusherExternal) Description() string {
+	return "flusher plugin to send the logs by the external plugin of the sidecar"
+}
+
+func (f *FlusherExternal) Flush(projectName string, logstoreName string, configName string, logGroupList []*protocol.LogGroup) error {
+	if err := f.client.Flush(logGroupList); err != nil {
+		logger.Warning(f.context.GetRuntimeContext(), util.AlarmExternalPlugin, "flush by external plugin error", err, "logstore", logstoreName)
+		return err
+	}
+	return nil
+}
+
+// IsReady returns false when the sidecar fails the health checks, so the data is kept in the queue.
+func (f *FlusherExternal) IsReady(projectName string, logstoreName string, logstoreKey int64) bool {
+	return f.client.Healthy()
+}
+
+func (f *FlusherExternal) SetUrgent(flag bool) {
+}
+
+func (f *FlusherExternal) Stop() error {
+	return f.client.Close()
+}
+
+func init() {
+	pipeline.Flushers["flusher_external"] = func() pipeline.Flusher {
+		return &FlusherExternal{}
+	}
+}
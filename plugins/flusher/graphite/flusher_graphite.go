@@ -0,0 +1,212 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphite
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	converter "github.com/alibaba/ilogtail/pkg/protocol/converter"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	defaultGraphitePort = "2003"
+	defaultTimeout      = 5 * time.Second
+	// defaultMaxPacketSize keeps the UDP packets under the common MTU.
+	defaultMaxPacketSize = 1400
+)
+
+// FlusherGraphite forwards the metrics in the graphite plaintext protocol to carbon or any compatible receiver
+// over TCP or UDP, which could replace the forwarding side of carbon-relay.
+type FlusherGraphite struct {
+	Address              string        // Address of the receiver, eg. tcp://127.0.0.1:2003 or udp://127.0.0.1:2003.
+	Timeout              time.Duration // Timeout of dialing and writing.
+	MaxPacketSize        int           // The max size of a UDP packet, the lines are split into several packets if exceeded.
+	IgnoreUnExpectedData bool          // Skip the metrics whose value could not be sent in graphite instead of dropping the batch.
+
+	context   pipeline.Context
+	converter *converter.Converter
+	scheme    string
+	host      string
+	conn      net.Conn
+	mu        sync.Mutex
+}
+
+func (f *FlusherGraphite) Description() string {
+	return "graphite flusher for logtail"
+}
+
+func (f *FlusherGraphite) Init(context pipeline.Context) error {
+	f.context = context
+	var err error
+	if f.scheme, f.host, err = helper.SplitAddress(f.Address, defaultGraphitePort); err != nil {
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "graphite flusher parse address fail, error", err)
+		return err
+	}
+	switch f.scheme {
+	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6":
+	default:
+		return fmt.Errorf("unknown protocol '%s' in '%s'", f.scheme, f.Address)
+	}
+	if f.Timeout <= 0 {
+		f.Timeout = defaultTimeout
+	}
+	if f.MaxPacketSize <= 0 {
+		f.MaxPacketSize = defaultMaxPacketSize
+	}
+	if f.converter, err = converter.NewConverterWithSep(converter.ProtocolGraphite, converter.EncodingCustom, "", f.IgnoreUnExpectedData, nil, nil); err != nil {
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "graphite flusher init converter fail, error", err)
+		return err
+	}
+	return nil
+}
+
+func (f *FlusherGraphite) Flush(projectName string, logstoreName string, configName string, logGroupList []*protocol.LogGroup) error {
+	for _, logGroup := range logGroupList {
+		stream, _, err := f.converter.ToByteStreamWithSelectedFields(logGroup, nil)
+		if err != nil {
+			logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "graphite flusher converter log fail, error", err)
+			continue
+		}
+		if err = f.write(stream.([][]byte)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *FlusherGraphite) Export(groupEventsArray []*models.PipelineGroupEvents, ctx pipeline.PipelineContext) error {
+	for _, groupEvents := range groupEventsArray {
+		stream, _, err := f.converter.ToByteStreamWithSelectedFieldsV2(groupEvents, nil)
+		if err != nil {
+			logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "graphite flusher converter log fail, error", err)
+			continue
+		}
+		if err = f.write(stream.([][]byte)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *FlusherGraphite) SetUrgent(flag bool) {
+}
+
+func (f *FlusherGraphite) IsReady(projectName string, logstoreName string, logstoreKey int64) bool {
+	return f.converter != nil
+}
+
+func (f *FlusherGraphite) Stop() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.conn != nil {
+		err := f.conn.Close()
+		f.conn = nil
+		return err
+	}
+	return nil
+}
+
+// write sends the lines, the connection is re-established once if the write fails, since the receiver
+// may have closed an idle connection.
+func (f *FlusherGraphite) write(stream [][]byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range stream {
+		chunks := f.split(stream[i])
+		sent, err := f.send(chunks)
+		if err != nil {
+			// only the chunks not written yet are resent, so that the receiver doesn't get duplicated lines
+			f.closeConn()
+			_, err = f.send(chunks[sent:])
+		}
+		converter.PutPooledByteBuf(&stream[i])
+		if err != nil {
+			f.closeConn()
+			logger.Warning(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "graphite flusher write fail, error", err, "address", f.Address)
+			return err
+		}
+	}
+	return nil
+}
+
+// send writes the chunks and returns the count of the chunks written.
+func (f *FlusherGraphite) send(chunks [][]byte) (int, error) {
+	if f.conn == nil {
+		conn, err := net.DialTimeout(f.scheme, f.host, f.Timeout)
+		if err != nil {
+			return 0, err
+		}
+		f.conn = conn
+	}
+	for i, chunk := range chunks {
+		_ = f.conn.SetWriteDeadline(time.Now().Add(f.Timeout))
+		if _, err := f.conn.Write(chunk); err != nil {
+			return i, err
+		}
+	}
+	return len(chunks), nil
+}
+
+// split splits the data at the line boundaries for UDP, so that no line is broken across packets.
+func (f *FlusherGraphite) split(data []byte) [][]byte {
+	if !strings.HasPrefix(f.scheme, "udp") || len(data) <= f.MaxPacketSize {
+		return [][]byte{data}
+	}
+	var chunks [][]byte
+	for len(data) > f.MaxPacketSize {
+		end := bytes.LastIndexByte(data[:f.MaxPacketSize], '\n')
+		if end < 0 {
+			// a single line longer than the packet size is sent as it is
+			if end = bytes.IndexByte(data, '\n'); end < 0 {
+				break
+			}
+		}
+		chunks = append(chunks, data[:end+1])
+		data = data[end+1:]
+	}
+	if len(data) > 0 {
+		chunks = append(chunks, data)
+	}
+	return chunks
+}
+
+func (f *FlusherGraphite) closeConn() {
+	if f.conn != nil {
+		_ = f.conn.Close()
+		f.conn = nil
+	}
+}
+
+func init() {
+	pipeline.Flushers["flusher_graphite"] = func() pipeline.Flusher {
+		return &FlusherGraphite{
+			Address:              "tcp://127.0.0.1:" + defaultGraphitePort,
+			Timeout:              defaultTimeout,
+			MaxPacketSize:        defaultMaxPacketSize,
+			IgnoreUnExpectedData: true,
+		}
+	}
+}
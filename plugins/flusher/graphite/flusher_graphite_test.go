@@ -0,0 +1,73 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphite
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+func TestFlusherGraphite_Export(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	lines := make(chan string, 10)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	f := pipeline.Flushers["flusher_graphite"]().(*FlusherGraphite)
+	f.Address = "tcp://" + l.Addr().String()
+	require.NoError(t, f.Init(mock.NewEmptyContext("p", "l", "c")))
+
+	tags := models.NewTagsWithKeyValues("host", "a")
+	metric := models.NewSingleValueMetric("cpu.load", models.MetricTypeGauge, tags, int64(1434055562*time.Second), 1.5)
+	groupEvents := &models.PipelineGroupEvents{Group: models.NewGroup(models.NewMetadata(), models.NewTags()), Events: []models.PipelineEvent{metric}}
+	require.NoError(t, f.Export([]*models.PipelineGroupEvents{groupEvents}, nil))
+
+	select {
+	case line := <-lines:
+		assert.Equal(t, "cpu.load;host=a 1.5 1434055562", line)
+	case <-time.After(time.Second * 3):
+		t.Fatal("no line received")
+	}
+	require.NoError(t, f.Stop())
+}
+
+func TestFlusherGraphite_Split(t *testing.T) {
+	f := &FlusherGraphite{scheme: "udp", MaxPacketSize: 10}
+	assert.Equal(t, [][]byte{[]byte("a 1 1\n"), []byte("b 2 2\n"), []byte("c 3 3\n")}, f.split([]byte("a 1 1\nb 2 2\nc 3 3\n")))
+	assert.Equal(t, [][]byte{[]byte("long.metric 1 1\n"), []byte("b 2 2\n")}, f.split([]byte("long.metric 1 1\nb 2 2\n")))
+
+	f.scheme = "tcp"
+	assert.Len(t, f.split([]byte("a 1 1\nb 2 2\nc 3 3\n")), 1)
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/tlscommon"
 	"github.com/alibaba/ilogtail/pkg/util"
 )
 
@@ -36,6 +37,8 @@ type Flusher struct {
 	KeyFile            string // The client.key path.
 	CAFile             string // The ca.pem path.
 	InsecureSkipVerify bool   // Controls whether a client verifies the server's certificate chain and host name.
+	// TLS supports mutual TLS with the reloaded certificates or SPIFFE, which takes precedence over the fields above.
+	TLS *tlscommon.TLSConfig
 
 	dialOptions []grpc.DialOption
 	dialSuccess bool
@@ -48,10 +51,17 @@ func (f *Flusher) Init(ctx pipeline.Context) error {
 	encoding.RegisterCodec(new(protocol.Codec))
 	f.ctx = ctx
 	options := make([]grpc.DialOption, 0, 1)
-	if f.EnableTLS {
+	if f.TLS != nil && f.TLS.Enabled {
+		cfg, err := f.TLS.LoadTLSConfig()
+		if err != nil {
+			logger.Errorf(f.ctx.GetRuntimeContext(), util.AlarmGRPCFlusher, "error in creating TLS config,: %v", err)
+			return err
+		}
+		options = append(options, grpc.WithTransportCredentials(credentials.NewTLS(cfg)))
+	} else if f.EnableTLS {
 		cfg, err := util.GetTLSConfig(f.CertFile, f.KeyFile, f.CAFile, f.InsecureSkipVerify)
 		if err != nil {
-			logger.Errorf(f.ctx.GetRuntimeContext(), "GRPC_FLUSHER_ALARM", "error in creating TLS config,: %v", err)
+			logger.Errorf(f.ctx.GetRuntimeContext(), util.AlarmGRPCFlusher, "error in creating TLS config,: %v", err)
 			return err
 		}
 		options = append(options, grpc.WithTransportCredentials(credentials.NewTLS(cfg)))
@@ -62,7 +72,7 @@ func (f *Flusher) Init(ctx pipeline.Context) error {
 
 	c, err := grpc.Dial(f.Address, options...)
 	if err != nil {
-		logger.Errorf(f.ctx.GetRuntimeContext(), "GRPC_FLUSHER_ALARM", "error in dialing with gRPC server %s, would try again later", f.Address)
+		logger.Errorf(f.ctx.GetRuntimeContext(), util.AlarmGRPCFlusher, "error in dialing with gRPC server %s, would try again later", f.Address)
 	} else {
 		f.conn = c
 		f.client = protocol.NewLogReportServiceClient(c)
@@ -85,7 +95,7 @@ func (f *Flusher) IsReady(projectName string, logstoreName string, logstoreKey i
 	// force try to dial with server.
 	c, err := grpc.Dial(f.Address, f.dialOptions...)
 	if err != nil {
-		logger.Errorf(f.ctx.GetRuntimeContext(), "GRPC_FLUSHER_ALARM", "error in dialing with gRPC server %s, would try again later", f.Address)
+		logger.Errorf(f.ctx.GetRuntimeContext(), util.AlarmGRPCFlusher, "error in dialing with gRPC server %s, would try again later", f.Address)
 		return false
 	}
 	f.conn = c
@@ -97,7 +107,7 @@ func (f *Flusher) Flush(projectName string, logstoreName string, configName stri
 	stream, err := f.client.Collect(context.Background())
 	defer f.closeStream(stream)
 	if err != nil {
-		logger.Error(f.ctx.GetRuntimeContext(), "GRPC_FLUSH_ALARM", "err", err)
+		logger.Error(f.ctx.GetRuntimeContext(), util.AlarmGRPCFlush, "err", err)
 		return err
 	}
 	for _, group := range logGroupList {
@@ -105,7 +115,7 @@ func (f *Flusher) Flush(projectName string, logstoreName string, configName stri
 			continue
 		}
 		if err := stream.Send(group); err != nil {
-			logger.Error(f.ctx.GetRuntimeContext(), "GRPC_FLUSH_ALARM", "err", err)
+			logger.Error(f.ctx.GetRuntimeContext(), util.AlarmGRPCFlush, "err", err)
 			return err
 		}
 	}
@@ -116,6 +126,7 @@ func (f *Flusher) SetUrgent(flag bool) {
 }
 
 func (f *Flusher) Stop() error {
+	_ = f.TLS.Close()
 	return f.conn.Close()
 }
 
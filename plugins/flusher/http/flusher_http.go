@@ -17,8 +17,12 @@ package http
 import (
 	"bytes"
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math/big"
 	"net/http"
@@ -26,26 +30,42 @@ import (
 	"time"
 
 	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/helper/compress"
 	"github.com/alibaba/ilogtail/pkg/fmtstr"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/models"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/protocol"
 	converter "github.com/alibaba/ilogtail/pkg/protocol/converter"
+	"github.com/alibaba/ilogtail/pkg/tlscommon"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 const (
 	defaultTimeout = time.Minute
+	// the event id added by processor_event_id
+	defaultIdempotencyKey = "content.__event_id__"
+	// the hex characters of the sha256 sum of the body used as the idempotency key
+	bodyKeyLength = 32
 
 	contentTypeHeader  = "Content-Type"
 	defaultContentType = "application/octet-stream"
+	// the line protocol accepted by the /write api of influxdb
+	influxdbContentType = "text/plain; charset=utf-8"
+	// the text rendered by the template encoding
+	templateContentType = "text/plain; charset=utf-8"
 )
 
 var contentTypeMaps = map[string]string{
-	converter.EncodingJSON:     "application/json",
-	converter.EncodingProtobuf: defaultContentType,
-	converter.EncodingNone:     defaultContentType,
-	converter.EncodingCustom:   defaultContentType,
+	converter.EncodingJSON:        "application/json",
+	converter.EncodingJSONCompact: "application/json",
+	converter.EncodingMsgpack:     "application/msgpack",
+	converter.EncodingCBOR:        "application/cbor",
+	converter.EncodingProtobuf:    defaultContentType,
+	converter.EncodingRaw:         defaultContentType,
+	converter.EncodingNone:        defaultContentType,
+	converter.EncodingCustom:      defaultContentType,
+	converter.EncodingTemplate:    templateContentType,
 }
 
 type retryConfig struct {
@@ -63,12 +83,27 @@ type FlusherHTTP struct {
 	Retry       retryConfig          // Retry strategy, default is retry 3 times with delay time begin from 1second, max to 30 seconds
 	Convert     helper.ConvertConfig // Convert defines which protocol and format to convert to
 	Concurrency int                  // How many requests can be performed in concurrent
+	// Connection configures the connection pool of the flusher, the max idle connections per host is Concurrency+1 by default
+	Connection helper.HTTPConnectionConfig
+	// Compression compresses the request body, which could be gzip, snappy, zstd or lz4, no compression if empty
+	Compression        string
+	CompressionLevel   int    // The compression level of gzip or zstd, the default level if 0
+	ZstdDictionaryFile string // The zstd dictionary file trained by `zstd --train` to compress the small payloads
+	// TLS configures the client certificate and the CA for https, which supports mutual TLS, reloading and SPIFFE
+	TLS *tlscommon.TLSConfig
+	// IdempotencyHeader is the header carrying the idempotency key of the request, such as Idempotency-Key, none if empty
+	IdempotencyHeader string
+	// IdempotencyKey is the field of the idempotency key, "content.__event_id__" by default. The key of the request
+	// rendering multiple logs, or the log without the field, is the hash of the body.
+	IdempotencyKey string
 
 	varKeys []string
 
-	context   pipeline.Context
-	converter *converter.Converter
-	client    *http.Client
+	context    pipeline.Context
+	converter  *converter.Converter
+	rejectFile io.Closer
+	compressor *compress.Compressor
+	client     *http.Client
 
 	queue   chan interface{}
 	counter sync.WaitGroup
@@ -83,30 +118,50 @@ func (f *FlusherHTTP) Init(context pipeline.Context) error {
 	logger.Info(f.context.GetRuntimeContext(), "http flusher init", "initializing")
 	if f.RemoteURL == "" {
 		err := errors.New("remoteURL is empty")
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "http flusher init fail, error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "http flusher init fail, error", err)
 		return err
 	}
 
 	if f.Concurrency < 1 {
 		err := errors.New("concurrency must be greater than zero")
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "http flusher check concurrency fail, error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "http flusher check concurrency fail, error", err)
 		return err
 	}
 
 	converter, err := f.getConverter()
 	if err != nil {
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "http flusher init converter fail, error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "http flusher init converter fail, error", err)
 		return err
 	}
 	f.converter = converter
+	if err = f.Convert.InitFieldMapping(f.converter); err != nil {
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "http flusher init field mapping fail, error", err)
+		return err
+	}
+	if err = f.Convert.InitFieldSelection(f.converter); err != nil {
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "http flusher init field selection fail, error", err)
+		return err
+	}
+	if err = f.Convert.InitTemplate(f.converter); err != nil {
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "http flusher init template fail, error", err)
+		return err
+	}
+	if f.rejectFile, err = f.Convert.InitJSONSchema(f.context.GetRuntimeContext(), f.converter); err != nil {
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "http flusher init json schema fail, error", err)
+		return err
+	}
 
-	f.client = &http.Client{
-		Timeout: f.Timeout,
+	if f.compressor, err = compress.NewCompressor(f.Compression, f.CompressionLevel, f.ZstdDictionaryFile); err != nil {
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "http flusher init compressor fail, error", err)
+		return err
 	}
-	transport, ok := http.DefaultTransport.(*http.Transport)
-	if ok && f.Concurrency > transport.MaxIdleConnsPerHost {
-		transport.MaxIdleConnsPerHost = f.Concurrency + 1
-		f.client.Transport = transport
+	if f.compressor != nil {
+		f.compressor.RegisterMetrics(f.context)
+	}
+
+	if err = f.initClient(); err != nil {
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "http flusher init client fail, error", err)
+		return err
 	}
 
 	f.queue = make(chan interface{})
@@ -114,6 +169,9 @@ func (f *FlusherHTTP) Init(context pipeline.Context) error {
 		go f.runFlushTask()
 	}
 
+	if f.IdempotencyHeader != "" && f.IdempotencyKey == "" {
+		f.IdempotencyKey = defaultIdempotencyKey
+	}
 	f.buildVarKeys()
 	f.fillRequestContentType()
 
@@ -145,6 +203,31 @@ func (f *FlusherHTTP) IsReady(projectName string, logstoreName string, logstoreK
 func (f *FlusherHTTP) Stop() error {
 	f.counter.Wait()
 	close(f.queue)
+	if f.compressor != nil {
+		_ = f.compressor.Close()
+	}
+	if f.TLS != nil {
+		_ = f.TLS.Close()
+	}
+	if f.rejectFile != nil {
+		return f.rejectFile.Close()
+	}
+	return nil
+}
+
+// initClient uses a dedicated transport with the connection and tls config, so that the default transport shared by the other plugins is not affected.
+func (f *FlusherHTTP) initClient() error {
+	var tlsConfig *tls.Config
+	if f.TLS != nil {
+		var err error
+		if tlsConfig, err = f.TLS.LoadTLSConfig(); err != nil {
+			return err
+		}
+	}
+	f.client = &http.Client{
+		Timeout:   f.Timeout,
+		Transport: f.Connection.NewTransport(tlsConfig, f.Concurrency+1),
+	}
 	return nil
 }
 
@@ -165,7 +248,7 @@ func (f *FlusherHTTP) runFlushTask() {
 	for data := range f.queue {
 		err := f.convertAndFlush(data)
 		if err != nil {
-			logger.Error(f.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "http flusher failed convert or flush data, data dropped, error", err)
+			logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "http flusher failed convert or flush data, data dropped, error", err)
 		}
 	}
 }
@@ -185,7 +268,7 @@ func (f *FlusherHTTP) convertAndFlush(data interface{}) error {
 	}
 
 	if err != nil {
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "http flusher converter log fail, error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "http flusher converter log fail, error", err)
 		return err
 	}
 	switch rows := logs.(type) {
@@ -194,27 +277,42 @@ func (f *FlusherHTTP) convertAndFlush(data interface{}) error {
 			body, values := data, varValues[idx]
 			err = f.flushWithRetry(body, values)
 			if err != nil {
-				logger.Error(f.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "http flusher failed flush data after retry, data dropped, error", err)
+				logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "http flusher failed flush data after retry, data dropped, error", err)
 			}
 		}
 		return nil
 	case []byte:
 		err = f.flushWithRetry(rows, nil)
 		if err != nil {
-			logger.Error(f.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "http flusher failed flush data after retry, error", err)
+			logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "http flusher failed flush data after retry, error", err)
 		}
 		return err
 	default:
 		err = fmt.Errorf("not supported logs type [%T]", logs)
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "http flusher failed flush data, error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "http flusher failed flush data, error", err)
 		return err
 	}
 }
 
 func (f *FlusherHTTP) flushWithRetry(data []byte, varValues map[string]string) error {
 	var err error
+	body, headers := data, map[string]string(nil)
+	if f.compressor != nil {
+		if body, err = f.compressor.Compress(data); err != nil {
+			converter.PutPooledByteBuf(&data)
+			return err
+		}
+		headers = f.compressor.Headers(len(data))
+	}
+	if f.IdempotencyHeader != "" {
+		// the key is computed once, so that the retries of the request have the same key
+		if headers == nil {
+			headers = make(map[string]string, 1)
+		}
+		headers[f.IdempotencyHeader] = f.idempotencyKey(data, varValues)
+	}
 	for i := 0; i <= f.Retry.MaxRetryTimes; i++ {
-		ok, retryable, e := f.flush(data, varValues)
+		ok, retryable, e := f.flush(body, varValues, headers)
 		if ok || !retryable || !f.Retry.Enable {
 			break
 		}
@@ -225,6 +323,15 @@ func (f *FlusherHTTP) flushWithRetry(data []byte, varValues map[string]string) e
 	return err
 }
 
+// idempotencyKey returns the event id of the request, or the hash of the body if the id is not found.
+func (f *FlusherHTTP) idempotencyKey(data []byte, varValues map[string]string) string {
+	if key := varValues[f.IdempotencyKey]; key != "" {
+		return key
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:bodyKeyLength]
+}
+
 func (f *FlusherHTTP) getNextRetryDelay(retryTime int) time.Duration {
 	delay := f.Retry.InitialDelay * 1 << time.Duration(retryTime)
 	if delay > f.Retry.MaxDelay {
@@ -241,10 +348,11 @@ func (f *FlusherHTTP) getNextRetryDelay(retryTime int) time.Duration {
 	return time.Duration(harf + jitter.Int64())
 }
 
-func (f *FlusherHTTP) flush(data []byte, varValues map[string]string) (ok, retryable bool, err error) {
+// flush sends data with the extra headers, such as the headers describing the compressed data.
+func (f *FlusherHTTP) flush(data []byte, varValues, extraHeaders map[string]string) (ok, retryable bool, err error) {
 	req, err := http.NewRequest(http.MethodPost, f.RemoteURL, bytes.NewReader(data))
 	if err != nil {
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "http flusher create request fail, error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "http flusher create request fail, error", err)
 		return false, false, err
 	}
 
@@ -258,7 +366,7 @@ func (f *FlusherHTTP) flush(data []byte, varValues map[string]string) (ok, retry
 
 			fv, ferr := fmtstr.FormatTopic(varValues, v)
 			if ferr != nil {
-				logger.Error(f.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "http flusher format query fail, error", ferr)
+				logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "http flusher format query fail, error", ferr)
 			} else {
 				v = *fv
 			}
@@ -274,36 +382,39 @@ func (f *FlusherHTTP) flush(data []byte, varValues map[string]string) (ok, retry
 		}
 		fv, ferr := fmtstr.FormatTopic(varValues, v)
 		if ferr != nil {
-			logger.Error(f.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "http flusher format header fail, error", ferr)
+			logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "http flusher format header fail, error", ferr)
 		} else {
 			v = *fv
 		}
 		req.Header.Add(k, v)
 	}
+	for k, v := range extraHeaders {
+		req.Header.Set(k, v)
+	}
 	response, err := f.client.Do(req)
 	logger.Debugf(f.context.GetRuntimeContext(), "request [method]: %v; [header]: %v; [url]: %v; [body]: %v", req.Method, req.Header, req.URL, string(data))
 	if err != nil {
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALRAM", "http flusher send request fail, error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "http flusher send request fail, error", err)
 		return false, false, err
 	}
 	body, err := ioutil.ReadAll(response.Body)
 	if err != nil {
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALRAM", "http flusher read response fail, error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "http flusher read response fail, error", err)
 		return false, false, err
 	}
 	err = response.Body.Close()
 	if err != nil {
-		logger.Warning(f.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "http flusher close response body fail, error", err)
+		logger.Warning(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "http flusher close response body fail, error", err)
 		return false, false, err
 	}
 	switch response.StatusCode / 100 {
 	case 2:
 		return true, false, nil
 	case 5:
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "http flusher write data returned error, url", req.URL.String(), "status", response.Status, "body", string(body))
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "http flusher write data returned error, url", req.URL.String(), "status", response.Status, "body", string(body))
 		return false, true, fmt.Errorf("err status returned: %v", response.Status)
 	default:
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "http flusher write data returned error, url", req.URL.String(), "status", response.Status, "body", string(body))
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "http flusher write data returned error, url", req.URL.String(), "status", response.Status, "body", string(body))
 		return false, false, fmt.Errorf("unexpected status returned: %v", response.Status)
 	}
 }
@@ -311,12 +422,15 @@ func (f *FlusherHTTP) flush(data []byte, varValues map[string]string) (ok, retry
 func (f *FlusherHTTP) buildVarKeys() {
 	cache := map[string]struct{}{}
 	defines := []map[string]string{f.Query, f.Headers}
+	if f.IdempotencyKey != "" {
+		cache[f.IdempotencyKey] = struct{}{}
+	}
 
 	for _, define := range defines {
 		for _, v := range define {
 			keys, err := fmtstr.CompileKeys(v)
 			if err != nil {
-				logger.Warning(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "http flusher init varKeys fail, err", err)
+				logger.Warning(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "http flusher init varKeys fail, err", err)
 			}
 			for _, key := range keys {
 				cache[key] = struct{}{}
@@ -341,6 +455,11 @@ func (f *FlusherHTTP) fillRequestContentType() {
 		return
 	}
 
+	if f.Convert.Protocol == converter.ProtocolInfluxdb {
+		f.Headers[contentTypeHeader] = influxdbContentType
+		return
+	}
+
 	contentType, ok := contentTypeMaps[f.Convert.Encoding]
 	if !ok {
 		contentType = defaultContentType
@@ -16,8 +16,12 @@ package http
 
 import (
 	"context"
+	"encoding/pem"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"sort"
 	"testing"
 	"time"
@@ -27,11 +31,13 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/helper/decoder/common"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/models"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/protocol"
 	converter "github.com/alibaba/ilogtail/pkg/protocol/converter"
+	"github.com/alibaba/ilogtail/pkg/tlscommon"
 	"github.com/alibaba/ilogtail/plugins/test/mock"
 )
 
@@ -586,3 +592,179 @@ func (c mockContext) GetRuntimeContext() context.Context {
 func init() {
 	logger.InitTestLogger(logger.OptionOpenMemoryReceiver)
 }
+
+func TestHttpFlusherContentType(t *testing.T) {
+	cases := []struct {
+		protocol, encoding, want string
+	}{
+		{converter.ProtocolCustomSingle, converter.EncodingJSON, "application/json"},
+		{converter.ProtocolCustomSingle, converter.EncodingMsgpack, "application/msgpack"},
+		{converter.ProtocolInfluxdb, converter.EncodingCustom, influxdbContentType},
+		{converter.ProtocolRaw, converter.EncodingCustom, defaultContentType},
+	}
+	for _, c := range cases {
+		flusher := &FlusherHTTP{Convert: helper.ConvertConfig{Protocol: c.protocol, Encoding: c.encoding}}
+		flusher.fillRequestContentType()
+		assert.Equal(t, c.want, flusher.Headers[contentTypeHeader])
+	}
+}
+
+func TestHttpFlusherCompression(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var actualRequests []string
+	httpmock.RegisterResponder("POST", "http://test.com/write", func(req *http.Request) (*http.Response, error) {
+		// the compressed body should be accepted by the decoders of service_http_server
+		body, _, err := common.CollectBody(httptest.NewRecorder(), req, 1<<20)
+		if err != nil {
+			return httpmock.NewStringResponse(400, err.Error()), nil
+		}
+		actualRequests = append(actualRequests, string(body))
+		return httpmock.NewStringResponse(200, "ok"), nil
+	})
+
+	for _, compression := range []string{"gzip", "snappy", "zstd", "lz4"} {
+		actualRequests = nil
+		flusher := &FlusherHTTP{
+			RemoteURL: "http://test.com/write",
+			Convert: helper.ConvertConfig{
+				Protocol: converter.ProtocolCustomSingle,
+				Encoding: converter.EncodingJSON,
+			},
+			Timeout:     defaultTimeout,
+			Concurrency: 1,
+			Compression: compression,
+		}
+		assert.NoError(t, flusher.Init(mock.NewEmptyContext("p", "l", "c")))
+		err := flusher.Flush("", "", "", []*protocol.LogGroup{{Logs: []*protocol.Log{{Time: 1, Contents: []*protocol.Log_Content{{Key: "content", Value: "hello"}}}}}})
+		assert.NoError(t, err)
+		assert.NoError(t, flusher.Stop())
+		assert.Equal(t, []string{`{"contents":{"content":"hello"},"tags":{"host.ip":""},"time":1}`}, actualRequests, compression)
+	}
+
+	flusher := &FlusherHTTP{
+		RemoteURL:   "http://test.com/write",
+		Convert:     helper.ConvertConfig{Protocol: converter.ProtocolCustomSingle, Encoding: converter.EncodingJSON},
+		Concurrency: 1,
+		Compression: "brotli",
+	}
+	assert.Error(t, flusher.Init(mock.NewEmptyContext("p", "l", "c")))
+}
+
+func TestHttpFlusherIdempotencyHeader(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var keys []string
+	var bodies []string
+	httpmock.RegisterResponder("POST", "http://test.com/_bulk", func(req *http.Request) (*http.Response, error) {
+		body, _ := ioutil.ReadAll(req.Body)
+		keys = append(keys, req.Header.Get("Idempotency-Key"))
+		bodies = append(bodies, string(body))
+		// the first request is retried with the same key
+		if len(keys) == 1 {
+			return httpmock.NewStringResponse(503, "unavailable"), nil
+		}
+		return httpmock.NewStringResponse(200, "ok"), nil
+	})
+	logGroup := &protocol.LogGroup{Logs: []*protocol.Log{
+		{Time: 1, Contents: []*protocol.Log_Content{{Key: "content", Value: "a"}, {Key: "__event_id__", Value: "id-a"}}},
+		{Time: 2, Contents: []*protocol.Log_Content{{Key: "content", Value: "b"}, {Key: "__event_id__", Value: "id-b"}}},
+	}}
+
+	// each log is sent with its event id
+	flusher := &FlusherHTTP{
+		RemoteURL:         "http://test.com/_bulk",
+		Convert:           helper.ConvertConfig{Protocol: converter.ProtocolCustomSingle, Encoding: converter.EncodingJSON},
+		Timeout:           defaultTimeout,
+		Concurrency:       1,
+		Retry:             retryConfig{Enable: true, MaxRetryTimes: 1, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		IdempotencyHeader: "Idempotency-Key",
+	}
+	assert.NoError(t, flusher.Init(mock.NewEmptyContext("p", "l", "c")))
+	assert.NoError(t, flusher.Flush("", "", "", []*protocol.LogGroup{logGroup}))
+	assert.NoError(t, flusher.Stop())
+	assert.Equal(t, []string{"id-a", "id-a", "id-b"}, keys)
+
+	// the logs are rendered as the bulk request of elasticsearch with the event ids as the _id,
+	// and the request is sent with the hash of the body
+	keys, bodies = nil, nil
+	flusher = &FlusherHTTP{
+		RemoteURL: "http://test.com/_bulk",
+		Convert: helper.ConvertConfig{
+			Protocol: converter.ProtocolCustomSingle,
+			Encoding: converter.EncodingTemplate,
+			Template: `{{range .}}{"index":{"_id":{{index .Contents "__event_id__" | json}}}}` + "\n" +
+				`{"message":{{index .Contents "content" | json}}}` + "\n{{end}}",
+			TemplateBatch: true,
+		},
+		Timeout:           defaultTimeout,
+		Concurrency:       1,
+		Retry:             retryConfig{Enable: true, MaxRetryTimes: 1, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		IdempotencyHeader: "Idempotency-Key",
+	}
+	assert.NoError(t, flusher.Init(mock.NewEmptyContext("p", "l", "c")))
+	assert.NoError(t, flusher.Flush("", "", "", []*protocol.LogGroup{logGroup}))
+	assert.NoError(t, flusher.Stop())
+	expected := `{"index":{"_id":"id-a"}}` + "\n" + `{"message":"a"}` + "\n" + `{"index":{"_id":"id-b"}}` + "\n" + `{"message":"b"}` + "\n"
+	assert.Equal(t, []string{expected, expected}, bodies)
+	assert.Len(t, keys, 2)
+	assert.Len(t, keys[0], bodyKeyLength)
+	assert.Equal(t, keys[0], keys[1])
+}
+
+func TestHttpFlusherTLS(t *testing.T) {
+	bodies := make(chan string, 1)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies <- string(body)
+	}))
+	defer server.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	assert.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600))
+
+	flusher := &FlusherHTTP{
+		RemoteURL: server.URL,
+		Convert: helper.ConvertConfig{
+			Protocol: converter.ProtocolCustomSingle,
+			Encoding: converter.EncodingJSON,
+		},
+		Timeout:     defaultTimeout,
+		Concurrency: 1,
+		TLS:         &tlscommon.TLSConfig{Enabled: true, CAFile: caFile, ReloadIntervalSec: 60},
+	}
+	assert.NoError(t, flusher.Init(mock.NewEmptyContext("p", "l", "c")))
+	assert.NotEqual(t, http.DefaultTransport, flusher.client.Transport)
+	err := flusher.Flush("", "", "", []*protocol.LogGroup{{Logs: []*protocol.Log{{Time: 1, Contents: []*protocol.Log_Content{{Key: "content", Value: "hello"}}}}}})
+	assert.NoError(t, err)
+	assert.NoError(t, flusher.Stop())
+	assert.Equal(t, `{"contents":{"content":"hello"},"tags":{"host.ip":""},"time":1}`, <-bodies)
+
+	flusher.TLS = &tlscommon.TLSConfig{Enabled: true, CAFile: filepath.Join(t.TempDir(), "not_exist.pem")}
+	assert.Error(t, flusher.Init(mock.NewEmptyContext("p", "l", "c")))
+}
+
+func TestHttpFlusherConnection(t *testing.T) {
+	defaultTransport := http.DefaultTransport.(*http.Transport)
+	defaultIdleConnsPerHost := defaultTransport.MaxIdleConnsPerHost
+	flusher := &FlusherHTTP{
+		RemoteURL:   "http://test.com/write",
+		Convert:     helper.ConvertConfig{Protocol: converter.ProtocolCustomSingle, Encoding: converter.EncodingJSON},
+		Concurrency: 8,
+	}
+	assert.NoError(t, flusher.Init(mock.NewEmptyContext("p", "l", "c")))
+	transport := flusher.client.Transport.(*http.Transport)
+	assert.Equal(t, 9, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, defaultIdleConnsPerHost, defaultTransport.MaxIdleConnsPerHost, "the default transport should not be modified")
+	assert.NoError(t, flusher.Stop())
+
+	flusher.Connection = helper.HTTPConnectionConfig{MaxIdleConnsPerHost: 4, MaxConnsPerHost: 16, IdleConnTimeout: time.Second, DisableKeepAlives: true}
+	assert.NoError(t, flusher.Init(mock.NewEmptyContext("p", "l", "c")))
+	transport = flusher.client.Transport.(*http.Transport)
+	assert.Equal(t, 4, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 16, transport.MaxConnsPerHost)
+	assert.Equal(t, time.Second, transport.IdleConnTimeout)
+	assert.True(t, transport.DisableKeepAlives)
+	assert.NoError(t, flusher.Stop())
+}
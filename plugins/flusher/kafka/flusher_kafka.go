@@ -17,14 +17,23 @@ package kafka
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Shopify/sarama"
+	"github.com/rcrowley/go-metrics"
 
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/helper/credentials"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/protocol"
+	converter "github.com/alibaba/ilogtail/pkg/protocol/converter"
+	"github.com/alibaba/ilogtail/pkg/tlscommon"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 type FlusherKafka struct {
@@ -37,31 +46,113 @@ type FlusherKafka struct {
 	HashKeys        []string
 	HashOnce        bool
 	ClientID        string
+	// Convert serializes the logs with the shared converter when the encoding is set, otherwise the logs are marshaled as json directly.
+	Convert helper.ConvertConfig
+	// Compression compresses the message batches by the producer, which could be gzip, snappy, zstd or lz4,
+	// no compression if empty. The consumers decompress the messages transparently.
+	Compression      string
+	CompressionLevel int // The compression level of gzip or zstd, the default level if 0
+	// Credentials provides the SASL username and password instead of SASLUsername and SASLPassword, the producer
+	// is recreated when the provided ones are rotated.
+	Credentials *credentials.Config
+	// TLS connects the brokers with TLS, which supports mutual TLS, reloading the client certificate and SPIFFE
+	TLS *tlscommon.TLSConfig
 
 	isTerminal chan bool
 	producer   sarama.AsyncProducer
 	hashKeyMap map[string]struct{}
 	hashKey    sarama.StringEncoder
 	flusher    FlusherFunc
+	converter  *converter.Converter
+	rejectFile io.Closer
+	// producerLock protects the producer and isTerminal, which are replaced when the credentials are rotated
+	producerLock sync.RWMutex
+	config       *sarama.Config
+	provider     credentials.Provider
+	stopWatch    chan struct{}
+	watchWg      sync.WaitGroup
 }
 
+// credentialCheckInterval is the interval to check whether the provided credentials are rotated.
+var credentialCheckInterval = time.Minute
+
 type FlusherFunc func(projectName string, logstoreName string, configName string, logGroupList []*protocol.LogGroup) error
 
 func (k *FlusherKafka) Init(context pipeline.Context) error {
 	k.context = context
 	if k.Brokers == nil || len(k.Brokers) == 0 {
 		var err = errors.New("brokers ip is nil")
-		logger.Error(k.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init kafka flusher fail, error", err)
+		logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherInit, "init kafka flusher fail, error", err)
 		return err
 	}
+	if k.Convert.Encoding != "" {
+		if k.Convert.Protocol == "" {
+			k.Convert.Protocol = converter.ProtocolCustomSingle
+		}
+		// each log is sent as one message, so only the protocols converting log by log are supported
+		switch k.Convert.Protocol {
+		case converter.ProtocolCustomSingle, converter.ProtocolCEF, converter.ProtocolLEEF:
+		default:
+			err := fmt.Errorf("unsupported protocol %s, only %s, %s and %s are supported", k.Convert.Protocol,
+				converter.ProtocolCustomSingle, converter.ProtocolCEF, converter.ProtocolLEEF)
+			logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherInit, "init kafka flusher converter fail, error", err)
+			return err
+		}
+		var err error
+		if k.converter, err = converter.NewConverter(k.Convert.Protocol, k.Convert.Encoding, k.Convert.TagFieldsRename, k.Convert.ProtocolFieldsRename); err != nil {
+			logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherInit, "init kafka flusher converter fail, error", err)
+			return err
+		}
+		if err = k.Convert.InitFieldMapping(k.converter); err != nil {
+			logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherInit, "init kafka flusher field mapping fail, error", err)
+			return err
+		}
+		if err = k.Convert.InitFieldSelection(k.converter); err != nil {
+			logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherInit, "init kafka flusher field selection fail, error", err)
+			return err
+		}
+		if err = k.Convert.InitTemplate(k.converter); err != nil {
+			logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherInit, "init kafka flusher template fail, error", err)
+			return err
+		}
+		if k.rejectFile, err = k.Convert.InitJSONSchema(k.context.GetRuntimeContext(), k.converter); err != nil {
+			logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherInit, "init kafka flusher json schema fail, error", err)
+			return err
+		}
+	}
 	config := sarama.NewConfig()
+	if err := k.initCompression(config); err != nil {
+		logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherInit, "init kafka flusher compression fail, error", err)
+		return err
+	}
+	var cred *credentials.Credential
+	if k.Credentials != nil {
+		var err error
+		if k.provider, err = k.Credentials.NewProvider(); err == nil {
+			cred, err = k.provider.Retrieve()
+		}
+		if err != nil {
+			logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherInit, "retrieve kafka credentials fail, error", err)
+			return err
+		}
+		k.SASLUsername, k.SASLPassword = cred.Username, cred.Password
+	}
 	if len(k.SASLUsername) == 0 {
-		logger.Warning(k.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "SASL information is not set, access Kafka server without authentication")
+		logger.Warning(k.context.GetRuntimeContext(), util.AlarmFlusherInit, "SASL information is not set, access Kafka server without authentication")
 	} else {
 		config.Net.SASL.Enable = true
 		config.Net.SASL.User = k.SASLUsername
 		config.Net.SASL.Password = k.SASLPassword
 	}
+	if k.TLS != nil {
+		tlsConfig, err := k.TLS.LoadTLSConfig()
+		if err != nil {
+			logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherInit, "init kafka flusher tls fail, error", err)
+			return err
+		}
+		config.Net.TLS.Enable = tlsConfig != nil
+		config.Net.TLS.Config = tlsConfig
+	}
 	config.ClientID = k.ClientID
 	config.Producer.Return.Successes = true
 	// config.Producer.RequiredAcks = sarama.WaitForAll
@@ -80,15 +171,33 @@ func (k *FlusherKafka) Init(context pipeline.Context) error {
 	case "random":
 		partitioner = sarama.NewRandomPartitioner
 	default:
-		logger.Error(k.context.GetRuntimeContext(), "INVALID_KAFKA_PARTITIONER", "invalid PartitionerType, use RandomPartitioner instead, type", k.PartitionerType)
+		logger.Error(k.context.GetRuntimeContext(), util.AlarmInvalidKafkaPartitioner, "invalid PartitionerType, use RandomPartitioner instead, type", k.PartitionerType)
 	}
 	config.Producer.Partitioner = partitioner
 	config.Producer.Timeout = 5 * time.Second
-	producer, err := sarama.NewAsyncProducer(k.Brokers, config)
+	k.config = config
+	producer, SIGTERM, err := k.startProducer(config)
 	if err != nil {
-		logger.Error(k.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init kafka flusher fail, error", err)
+		logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherInit, "init kafka flusher fail, error", err)
 		return err
 	}
+	k.producer = producer
+	k.isTerminal = SIGTERM
+	if k.provider != nil {
+		k.stopWatch = make(chan struct{})
+		k.watchWg.Add(1)
+		go k.watchCredentials(cred)
+	}
+	return nil
+}
+
+// startProducer creates the producer and the goroutine consuming its errors and successes, which exits when
+// the returned channel is closed.
+func (k *FlusherKafka) startProducer(config *sarama.Config) (sarama.AsyncProducer, chan bool, error) {
+	producer, err := sarama.NewAsyncProducer(k.Brokers, config)
+	if err != nil {
+		return nil, nil, err
+	}
 	SIGTERM := make(chan bool)
 	go func(p sarama.AsyncProducer, SIGTERM chan bool) {
 		errors := p.Errors()
@@ -97,7 +206,7 @@ func (k *FlusherKafka) Init(context pipeline.Context) error {
 			select {
 			case err := <-errors:
 				if err != nil {
-					logger.Error(k.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "flush kafka write data fail, error", err)
+					logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherFlush, "flush kafka write data fail, error", err)
 				}
 			case <-success:
 				// Do Nothing
@@ -106,10 +215,36 @@ func (k *FlusherKafka) Init(context pipeline.Context) error {
 			}
 		}
 	}(producer, SIGTERM)
+	return producer, SIGTERM, nil
+}
 
-	k.producer = producer
-	k.isTerminal = SIGTERM
-	return nil
+// watchCredentials recreates the producer with the rotated SASL username and password, since sarama only reads
+// them when connecting the brokers. The messages buffered by the old producer are flushed when it is closed.
+func (k *FlusherKafka) watchCredentials(cred *credentials.Credential) {
+	defer k.watchWg.Done()
+	for {
+		if cred = credentials.WaitChange(k.provider, cred, credentialCheckInterval, k.stopWatch); cred == nil {
+			return
+		}
+		config := *k.config
+		config.Net.SASL.User, config.Net.SASL.Password = cred.Username, cred.Password
+		// the metrics of the brokers are unregistered when the old producer is closed
+		config.MetricRegistry = metrics.NewRegistry()
+		producer, SIGTERM, err := k.startProducer(&config)
+		if err != nil {
+			logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherFlush, "recreate kafka producer with the rotated credentials fail, error", err)
+			continue
+		}
+		k.producerLock.Lock()
+		oldProducer, oldSIGTERM := k.producer, k.isTerminal
+		k.producer, k.isTerminal = producer, SIGTERM
+		k.producerLock.Unlock()
+		if err = oldProducer.Close(); err != nil {
+			logger.Warning(k.context.GetRuntimeContext(), util.AlarmFlusherFlush, "close kafka producer error", err)
+		}
+		close(oldSIGTERM)
+		logger.Info(k.context.GetRuntimeContext(), "kafka producer is recreated with the rotated credentials")
+	}
 }
 
 func (k *FlusherKafka) Description() string {
@@ -117,6 +252,8 @@ func (k *FlusherKafka) Description() string {
 }
 
 func (k *FlusherKafka) Flush(projectName string, logstoreName string, configName string, logGroupList []*protocol.LogGroup) error {
+	k.producerLock.RLock()
+	defer k.producerLock.RUnlock()
 	return k.flusher(projectName, logstoreName, configName, logGroupList)
 }
 
@@ -124,14 +261,14 @@ func (k *FlusherKafka) NormalFlush(projectName string, logstoreName string, conf
 	for _, logGroup := range logGroupList {
 		logger.Debug(k.context.GetRuntimeContext(), "[LogGroup] topic", logGroup.Topic, "logstore", logGroup.Category, "logcount", len(logGroup.Logs), "tags", logGroup.LogTags)
 
-		for _, log := range logGroup.Logs {
-			buf, _ := json.Marshal(log)
+		serializedLogs, err := k.serialize(logGroup)
+		if err != nil {
+			logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherFlush, "convert logGroup fail, error", err)
+			continue
+		}
+		for _, buf := range serializedLogs {
 			logger.Debug(k.context.GetRuntimeContext(), string(buf))
-			m := &sarama.ProducerMessage{
-				Topic: k.Topic,
-				Value: sarama.ByteEncoder(buf),
-			}
-			k.producer.Input() <- m
+			k.producer.Input() <- k.newMessage(buf)
 		}
 	}
 	return nil
@@ -142,28 +279,87 @@ func (k *FlusherKafka) HashFlush(projectName string, logstoreName string, config
 		logger.Debug(k.context.GetRuntimeContext(), "[LogGroup] topic", logGroup.Topic, "logstore", logGroup.Category, "logcount", len(logGroup.Logs), "tags", logGroup.LogTags)
 
 		for _, log := range logGroup.Logs {
-			buf, _ := json.Marshal(log)
-			logger.Debug(k.context.GetRuntimeContext(), string(buf))
-			m := &sarama.ProducerMessage{
-				Topic: k.Topic,
-				Value: sarama.ByteEncoder(buf),
+			// serialize log by log to keep the key of each message, since the converter may reject a log
+			// or render the logs of a batch as one message
+			serializedLogs, err := k.serialize(&protocol.LogGroup{
+				Logs:     []*protocol.Log{log},
+				Category: logGroup.Category,
+				Topic:    logGroup.Topic,
+				Source:   logGroup.Source,
+				LogTags:  logGroup.LogTags,
+			})
+			if err != nil {
+				logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherFlush, "convert logGroup fail, error", err)
+				continue
 			}
-			// set key when partition type is hash
-			if k.HashOnce {
-				if len(k.hashKey) == 0 {
-					k.hashKey = k.hashPartitionKey(log, logstoreName)
+			for _, buf := range serializedLogs {
+				logger.Debug(k.context.GetRuntimeContext(), string(buf))
+				m := k.newMessage(buf)
+				// set key when partition type is hash
+				if k.HashOnce {
+					if len(k.hashKey) == 0 {
+						k.hashKey = k.hashPartitionKey(log, logstoreName)
+					}
+					m.Key = k.hashKey
+				} else {
+					m.Key = k.hashPartitionKey(log, logstoreName)
 				}
-				m.Key = k.hashKey
-			} else {
-				m.Key = k.hashPartitionKey(log, logstoreName)
+				k.producer.Input() <- m
 			}
-			k.producer.Input() <- m
 		}
 	}
 
 	return nil
 }
 
+func (k *FlusherKafka) newMessage(value []byte) *sarama.ProducerMessage {
+	return &sarama.ProducerMessage{Topic: k.Topic, Value: sarama.ByteEncoder(value)}
+}
+
+// initCompression sets the compression of the producer, and raises the protocol version required by the codec.
+func (k *FlusherKafka) initCompression(config *sarama.Config) error {
+	switch strings.ToLower(k.Compression) {
+	case "", "none":
+		return nil
+	case "gzip":
+		config.Producer.Compression = sarama.CompressionGZIP
+	case "snappy":
+		config.Producer.Compression = sarama.CompressionSnappy
+	case "lz4":
+		config.Producer.Compression = sarama.CompressionLZ4
+		if !config.Version.IsAtLeast(sarama.V0_10_0_0) {
+			config.Version = sarama.V0_10_0_0
+		}
+	case "zstd":
+		config.Producer.Compression = sarama.CompressionZSTD
+		if !config.Version.IsAtLeast(sarama.V2_1_0_0) {
+			config.Version = sarama.V2_1_0_0
+		}
+	default:
+		return fmt.Errorf("unsupported compression %s, must be one of gzip, snappy, zstd and lz4", k.Compression)
+	}
+	if k.CompressionLevel != 0 {
+		config.Producer.CompressionLevel = k.CompressionLevel
+	}
+	return config.Validate()
+}
+
+// serialize returns one message for each log in the logGroup.
+func (k *FlusherKafka) serialize(logGroup *protocol.LogGroup) ([][]byte, error) {
+	if k.converter != nil {
+		stream, err := k.converter.ToByteStream(logGroup)
+		if err != nil {
+			return nil, err
+		}
+		return stream.([][]byte), nil
+	}
+	serializedLogs := make([][]byte, len(logGroup.Logs))
+	for i, log := range logGroup.Logs {
+		serializedLogs[i], _ = json.Marshal(log)
+	}
+	return serializedLogs, nil
+}
+
 func (k *FlusherKafka) hashPartitionKey(log *protocol.Log, defaultKey string) sarama.StringEncoder {
 	var hashData []string
 	for _, content := range log.GetContents() {
@@ -188,8 +384,20 @@ func (k *FlusherKafka) IsReady(projectName string, logstoreName string, logstore
 
 // Stop ...
 func (k *FlusherKafka) Stop() error {
+	if k.stopWatch != nil {
+		close(k.stopWatch)
+		k.watchWg.Wait()
+	}
 	err := k.producer.Close()
 	close(k.isTerminal)
+	if k.TLS != nil {
+		_ = k.TLS.Close()
+	}
+	if k.rejectFile != nil {
+		if cerr := k.rejectFile.Close(); err == nil {
+			err = cerr
+		}
+	}
 	return err
 }
 
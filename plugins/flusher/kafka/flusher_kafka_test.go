@@ -15,14 +15,15 @@
 package kafka
 
 import (
-	"github.com/alibaba/ilogtail/pkg/protocol"
-	"github.com/alibaba/ilogtail/plugins/test"
-	"github.com/alibaba/ilogtail/plugins/test/mock"
-
 	"strconv"
 	"testing"
 
+	"github.com/Shopify/sarama"
 	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/test"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
 )
 
 // Invalid Test
@@ -70,3 +71,22 @@ func makeTestLogGroupList() *protocol.LogGroupList {
 	}
 	return lgl
 }
+
+func TestInitCompression(t *testing.T) {
+	config := sarama.NewConfig()
+	require.NoError(t, (&FlusherKafka{Compression: "zstd", CompressionLevel: 3}).initCompression(config))
+	require.Equal(t, sarama.CompressionZSTD, config.Producer.Compression)
+	require.Equal(t, 3, config.Producer.CompressionLevel)
+	require.True(t, config.Version.IsAtLeast(sarama.V2_1_0_0))
+
+	config = sarama.NewConfig()
+	require.NoError(t, (&FlusherKafka{Compression: "GZIP"}).initCompression(config))
+	require.Equal(t, sarama.CompressionGZIP, config.Producer.Compression)
+	require.Equal(t, sarama.CompressionLevelDefault, config.Producer.CompressionLevel)
+
+	require.Error(t, (&FlusherKafka{Compression: "gzip", CompressionLevel: 10}).initCompression(sarama.NewConfig()))
+	require.Error(t, (&FlusherKafka{Compression: "brotli"}).initCompression(sarama.NewConfig()))
+
+	m := (&FlusherKafka{Topic: "test"}).newMessage([]byte("hello"))
+	require.Empty(t, m.Headers)
+}
@@ -20,6 +20,7 @@ import (
 
 	"github.com/Shopify/sarama"
 
+	"github.com/alibaba/ilogtail/helper/credentials"
 	"github.com/alibaba/ilogtail/pkg/tlscommon"
 )
 
@@ -47,6 +48,11 @@ type SaslConfig struct {
 	Username string
 	// The password for connecting to Kafka.
 	Password string
+	// Credentials provides the username and password instead of the plaintext ones, the producer is recreated
+	// when the provided ones are rotated.
+	Credentials *credentials.Config
+
+	credentialSource
 }
 
 type PlainTextConfig struct {
@@ -54,6 +60,11 @@ type PlainTextConfig struct {
 	Username string
 	// The password for connecting to Kafka.
 	Password string
+	// Credentials provides the username and password instead of the plaintext ones, the producer is recreated
+	// when the provided ones are rotated.
+	Credentials *credentials.Config
+
+	credentialSource
 }
 
 // KerberosConfig defines kereros configuration.
@@ -95,6 +106,9 @@ func (config *Authentication) ConfigureAuthentication(saramaConfig *sarama.Confi
 }
 
 func (plainTextConfig *PlainTextConfig) ConfigurePlaintext(saramaConfig *sarama.Config) error {
+	if err := plainTextConfig.retrieve(plainTextConfig.Credentials, &plainTextConfig.Username, &plainTextConfig.Password); err != nil {
+		return err
+	}
 	// Validate Auth info
 	if plainTextConfig.Username != "" && plainTextConfig.Password == "" {
 		return fmt.Errorf("PlainTextConfig password must be set when username is configured")
@@ -109,6 +123,9 @@ func (plainTextConfig *PlainTextConfig) ConfigurePlaintext(saramaConfig *sarama.
 }
 
 func (saslConfig *SaslConfig) ConfigureSasl(saramaConfig *sarama.Config) error {
+	if err := saslConfig.retrieve(saslConfig.Credentials, &saslConfig.Username, &saslConfig.Password); err != nil {
+		return err
+	}
 	if saslConfig.Username == "" {
 		return fmt.Errorf("username have to be provided")
 	}
@@ -187,3 +204,44 @@ func (saslConfig *SaslConfig) Validate() error {
 	}
 	return nil
 }
+
+// credentialSource keeps the credential provider and the credential last retrieved, which is compared to find
+// the rotation.
+type credentialSource struct {
+	provider credentials.Provider
+	cred     *credentials.Credential
+}
+
+// retrieve fills the username and password from the credential provider if set.
+func (s *credentialSource) retrieve(config *credentials.Config, username, password *string) error {
+	if config == nil {
+		return nil
+	}
+	if s.provider == nil {
+		provider, err := config.NewProvider()
+		if err != nil {
+			return fmt.Errorf("create kafka credentials provider error: %w", err)
+		}
+		s.provider = provider
+	}
+	cred, err := s.provider.Retrieve()
+	if err != nil {
+		return fmt.Errorf("retrieve kafka credentials error: %w", err)
+	}
+	s.cred = cred
+	*username, *password = cred.Username, cred.Password
+	return nil
+}
+
+// providedCredential returns the source of the SASL username and password used by sarama, or nil if they are not
+// provided by a credential provider.
+func (config *Authentication) providedCredential() *credentialSource {
+	// SASL is configured after PlainText, so it takes precedence
+	if config.SASL != nil && config.SASL.provider != nil {
+		return &config.SASL.credentialSource
+	}
+	if config.PlainText != nil && config.PlainText.provider != nil {
+		return &config.PlainText.credentialSource
+	}
+	return nil
+}
@@ -21,12 +21,17 @@ import (
 	"math"
 	"math/big"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Shopify/sarama"
+	"github.com/rcrowley/go-metrics"
 
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/helper/credentials"
 	"github.com/alibaba/ilogtail/pkg/fmtstr"
 	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/models"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/protocol"
 	converter "github.com/alibaba/ilogtail/pkg/protocol/converter"
@@ -37,8 +42,14 @@ const (
 	PartitionerTypeRandom     = "random"
 	PartitionerTypeRoundRobin = "roundrobin"
 	PartitionerTypeRoundHash  = "hash"
+
+	topicCreateRetryInterval = time.Minute
+	defaultMaxTopics         = 100
 )
 
+// credentialCheckInterval is the interval to check whether the provided credentials are rotated.
+var credentialCheckInterval = time.Minute
+
 type FlusherKafka struct {
 	context   pipeline.Context
 	converter *converter.Converter
@@ -62,6 +73,9 @@ type FlusherKafka struct {
 	// The keep-alive period for an active network connection.
 	// If 0s, keep-alives are disabled. The default is 0 seconds.
 	KeepAlive time.Duration
+	// The maximum number of in-flight requests to each broker, 5 by default.
+	// Set it to 1 to keep the order of the messages when retrying.
+	MaxOpenRequests int
 	// The maximum number of messages the producer will send in a single
 	MaxMessageBytes *int
 	// RequiredAcks Number of acknowledgements required to assume that a message has been sent.
@@ -100,14 +114,29 @@ type FlusherKafka struct {
 	HashKeys []string
 	HashOnce bool
 	ClientID string
+	// The field used as the key of messages, such as content.__event_id__, which lets consumers
+	// dedup retried messages. It cannot be used with the hash partitioner, whose key decides the partition.
+	MessageKey string
+	// CreateTopic creates the topics resolved by Topic if they don't exist, so that the topics like one per
+	// namespace don't need to be created in advance.
+	CreateTopic *createTopicConfig
 
 	// obtain from Topic
-	topicKeys  []string
+	topicKeys []string
+	// topicKeys and MessageKey
+	selectKeys []string
 	isTerminal chan bool
 	producer   sarama.AsyncProducer
 	hashKeyMap map[string]interface{}
 	hashKey    sarama.StringEncoder
 	flusher    FlusherFunc
+	admin      sarama.ClusterAdmin
+	creator    *helper.DestinationCreator
+	// producerLock protects the producer, isTerminal and admin, which are replaced when the credentials are rotated
+	producerLock sync.RWMutex
+	saramaConfig *sarama.Config
+	stopWatch    chan struct{}
+	watchWg      sync.WaitGroup
 }
 
 type backoffConfig struct {
@@ -119,6 +148,20 @@ type backoffConfig struct {
 	Max time.Duration
 }
 
+type createTopicConfig struct {
+	// The number of partitions of the topics created, 1 by default.
+	NumPartitions int32
+	// The replication factor of the topics created, 1 by default.
+	ReplicationFactor int16
+	// The topic-level configs of the topics created, such as retention.ms.
+	ConfigEntries map[string]string
+	// The max count of the topics created, 100 by default, the messages of the other topics are dropped.
+	MaxCount int
+	// The regex the topics created must match, the messages of the other topics are dropped. All the topics
+	// are allowed if empty.
+	AllowPattern string
+}
+
 type header struct {
 	Key   string
 	Value string
@@ -153,6 +196,13 @@ type convertConfig struct {
 	// Convert encoding, default value:json
 	// The options are: 'json'
 	Encoding string
+	// The fields serialized, such as content.msg and tag.host.name, a trailing * matches the prefix, all fields if empty.
+	// The fields of the topic and the message key are not affected.
+	IncludeFields []string
+	// The fields not serialized, which takes precedence over IncludeFields
+	ExcludeFields []string
+	// Rename the serialized fields, such as {"content.msg": "message"}
+	FieldsRename map[string]string
 }
 
 type FlusherFunc func(projectName string, logstoreName string, configName string, logGroupList []*protocol.LogGroup) error
@@ -204,7 +254,7 @@ func (k *FlusherKafka) Init(context pipeline.Context) error {
 	k.context = context
 	if k.Brokers == nil || len(k.Brokers) == 0 {
 		var err = errors.New("brokers ip is nil")
-		logger.Error(k.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init kafka flusher fail, error", err)
+		logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherInit, "init kafka flusher fail, error", err)
 		return err
 	}
 
@@ -219,7 +269,7 @@ func (k *FlusherKafka) Init(context pipeline.Context) error {
 
 	convert, err := k.getConverter()
 	if err != nil {
-		logger.Error(k.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init kafka flusher converter fail, error", err)
+		logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherInit, "init kafka flusher converter fail, error", err)
 		return err
 	}
 	k.converter = convert
@@ -227,22 +277,52 @@ func (k *FlusherKafka) Init(context pipeline.Context) error {
 	// Obtain topic keys from dynamic topic expression
 	topicKeys, err := fmtstr.CompileKeys(k.Topic)
 	if err != nil {
-		logger.Error(k.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init kafka flusher fail, error", err)
+		logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherInit, "init kafka flusher fail, error", err)
 		return err
 	}
 	k.topicKeys = topicKeys
+	k.selectKeys = topicKeys
+	if k.MessageKey != "" {
+		k.selectKeys = util.UniqueStrings(topicKeys, []string{k.MessageKey})
+	}
 
 	saramaConfig, err := newSaramaConfig(k)
 	if err != nil {
-		logger.Error(k.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init kafka flusher fail, error", err)
+		logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherInit, "init kafka flusher fail, error", err)
 		return err
 	}
 
-	producer, err := sarama.NewAsyncProducer(k.Brokers, saramaConfig)
+	k.saramaConfig = saramaConfig
+	producer, SIGTERM, err := k.startProducer(saramaConfig)
 	if err != nil {
-		logger.Error(k.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init kafka flusher fail, error", err)
+		logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherInit, "init kafka flusher fail, error", err)
 		return err
 	}
+	if k.CreateTopic != nil {
+		if err = k.initTopicCreator(saramaConfig); err != nil {
+			_ = producer.Close()
+			close(SIGTERM)
+			logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherInit, "init kafka flusher topic creator fail, error", err)
+			return err
+		}
+	}
+	k.producer = producer
+	k.isTerminal = SIGTERM
+	if source := k.Authentication.providedCredential(); source != nil {
+		k.stopWatch = make(chan struct{})
+		k.watchWg.Add(1)
+		go k.watchCredentials(source)
+	}
+	return nil
+}
+
+// startProducer creates the producer and the goroutine consuming its errors and successes, which exits when
+// the returned channel is closed.
+func (k *FlusherKafka) startProducer(saramaConfig *sarama.Config) (sarama.AsyncProducer, chan bool, error) {
+	producer, err := sarama.NewAsyncProducer(k.Brokers, saramaConfig)
+	if err != nil {
+		return nil, nil, err
+	}
 	SIGTERM := make(chan bool)
 	go func(p sarama.AsyncProducer, SIGTERM chan bool) {
 		errors := p.Errors()
@@ -251,7 +331,7 @@ func (k *FlusherKafka) Init(context pipeline.Context) error {
 			select {
 			case err := <-errors:
 				if err != nil {
-					logger.Error(k.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "flush kafka write data fail, error", err)
+					logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherFlush, "flush kafka write data fail, error", err)
 				}
 			case <-success:
 				// Do Nothing
@@ -260,10 +340,50 @@ func (k *FlusherKafka) Init(context pipeline.Context) error {
 			}
 		}
 	}(producer, SIGTERM)
+	return producer, SIGTERM, nil
+}
 
-	k.producer = producer
-	k.isTerminal = SIGTERM
-	return nil
+// watchCredentials recreates the producer and the admin with the rotated SASL username and password, since
+// sarama only reads them when connecting the brokers. The messages buffered by the old producer are flushed
+// when it is closed.
+func (k *FlusherKafka) watchCredentials(source *credentialSource) {
+	defer k.watchWg.Done()
+	cred := source.cred
+	for {
+		if cred = credentials.WaitChange(source.provider, cred, credentialCheckInterval, k.stopWatch); cred == nil {
+			return
+		}
+		saramaConfig := *k.saramaConfig
+		saramaConfig.Net.SASL.User, saramaConfig.Net.SASL.Password = cred.Username, cred.Password
+		// the metrics of the brokers are unregistered when the old producer is closed
+		saramaConfig.MetricRegistry = metrics.NewRegistry()
+		producer, SIGTERM, err := k.startProducer(&saramaConfig)
+		if err != nil {
+			logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherFlush, "recreate kafka producer with the rotated credentials fail, error", err)
+			continue
+		}
+		var admin sarama.ClusterAdmin
+		if k.admin != nil {
+			if admin, err = sarama.NewClusterAdmin(k.Brokers, &saramaConfig); err != nil {
+				logger.Warning(k.context.GetRuntimeContext(), util.AlarmFlusherFlush, "recreate kafka admin with the rotated credentials fail, error", err)
+			}
+		}
+		k.producerLock.Lock()
+		oldProducer, oldSIGTERM, oldAdmin := k.producer, k.isTerminal, k.admin
+		k.producer, k.isTerminal = producer, SIGTERM
+		if admin != nil {
+			k.admin = admin
+		}
+		k.producerLock.Unlock()
+		if err = oldProducer.Close(); err != nil {
+			logger.Warning(k.context.GetRuntimeContext(), util.AlarmFlusherFlush, "close kafka producer error", err)
+		}
+		close(oldSIGTERM)
+		if admin != nil {
+			_ = oldAdmin.Close()
+		}
+		logger.Info(k.context.GetRuntimeContext(), "kafka producer is recreated with the rotated credentials")
+	}
 }
 
 func (k *FlusherKafka) Description() string {
@@ -271,26 +391,75 @@ func (k *FlusherKafka) Description() string {
 }
 
 func (k *FlusherKafka) Flush(projectName string, logstoreName string, configName string, logGroupList []*protocol.LogGroup) error {
+	k.producerLock.RLock()
+	defer k.producerLock.RUnlock()
 	return k.flusher(projectName, logstoreName, configName, logGroupList)
 }
 
 func (k *FlusherKafka) NormalFlush(projectName string, logstoreName string, configName string, logGroupList []*protocol.LogGroup) error {
 	for _, logGroup := range logGroupList {
 		logger.Debug(k.context.GetRuntimeContext(), "[LogGroup] topic", logGroup.Topic, "logstore", logGroup.Category, "logcount", len(logGroup.Logs), "tags", logGroup.LogTags)
-		logs, values, err := k.converter.ToByteStreamWithSelectedFields(logGroup, k.topicKeys)
+		logs, values, err := k.converter.ToByteStreamWithSelectedFields(logGroup, k.selectKeys)
 		if err != nil {
-			logger.Error(k.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "flush kafka convert log fail, error", err)
+			logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherFlush, "flush kafka convert log fail, error", err)
 		}
 		for index, log := range logs.([][]byte) {
 			valueMap := values[index]
 			topic, err := fmtstr.FormatTopic(valueMap, k.Topic)
 			if err != nil {
-				logger.Error(k.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "flush kafka format topic fail, error", err)
+				logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherFlush, "flush kafka format topic fail, error", err)
+			}
+			if !k.ensureTopic(*topic) {
+				continue
 			}
 			m := &sarama.ProducerMessage{
 				Topic: *topic,
 				Value: sarama.ByteEncoder(log),
 			}
+			if k.MessageKey != "" {
+				if key, ok := valueMap[k.MessageKey]; ok && key != "" {
+					m.Key = sarama.StringEncoder(key)
+				}
+			}
+			k.producer.Input() <- m
+		}
+	}
+	return nil
+}
+
+// Export sends the events of the v2 pipeline, which requires the raw protocol. The partition key of the group set
+// by the aggregators, e.g. aggregator_metadata_group, is used as the message key unless MessageKey is set, so that
+// the groups of the same key are sent to the same partition by the hash partitioner.
+func (k *FlusherKafka) Export(groupEventsArray []*models.PipelineGroupEvents, ctx pipeline.PipelineContext) error {
+	k.producerLock.RLock()
+	defer k.producerLock.RUnlock()
+	for _, groupEvents := range groupEventsArray {
+		logs, values, err := k.converter.ToByteStreamWithSelectedFieldsV2(groupEvents, k.selectKeys)
+		if err != nil {
+			logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherFlush, "flush kafka convert log fail, error", err)
+			continue
+		}
+		partitionKey := groupEvents.GetPartitionKey()
+		for index, log := range logs.([][]byte) {
+			valueMap := values[index]
+			topic, err := fmtstr.FormatTopic(valueMap, k.Topic)
+			if err != nil {
+				logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherFlush, "flush kafka format topic fail, error", err)
+			}
+			if !k.ensureTopic(*topic) {
+				continue
+			}
+			m := &sarama.ProducerMessage{
+				Topic: *topic,
+				Value: sarama.ByteEncoder(log),
+			}
+			key := partitionKey
+			if k.MessageKey != "" {
+				key = valueMap[k.MessageKey]
+			}
+			if key != "" {
+				m.Key = sarama.StringEncoder(key)
+			}
 			k.producer.Input() <- m
 		}
 	}
@@ -304,13 +473,16 @@ func (k *FlusherKafka) HashFlush(projectName string, logstoreName string, config
 		selectFields := util.UniqueStrings(k.topicKeys, k.HashKeys)
 		logs, values, err := k.converter.ToByteStreamWithSelectedFields(logGroup, selectFields)
 		if err != nil {
-			logger.Error(k.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "flush kafka convert log fail, error", err)
+			logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherFlush, "flush kafka convert log fail, error", err)
 		}
 		for index, log := range logs.([][]byte) {
 			selectedValueMap := values[index]
 			topic, err := fmtstr.FormatTopic(selectedValueMap, k.Topic)
 			if err != nil {
-				logger.Error(k.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "flush kafka format topic fail, error", err)
+				logger.Error(k.context.GetRuntimeContext(), util.AlarmFlusherFlush, "flush kafka format topic fail, error", err)
+			}
+			if !k.ensureTopic(*topic) {
+				continue
 			}
 			m := &sarama.ProducerMessage{
 				Topic: *topic,
@@ -355,11 +527,78 @@ func (k *FlusherKafka) IsReady(projectName string, logstoreName string, logstore
 
 // Stop ...
 func (k *FlusherKafka) Stop() error {
+	if k.stopWatch != nil {
+		close(k.stopWatch)
+		k.watchWg.Wait()
+	}
 	err := k.producer.Close()
 	close(k.isTerminal)
+	if k.admin != nil {
+		_ = k.admin.Close()
+	}
+	_ = k.Authentication.TLS.Close()
 	return err
 }
 
+// initTopicCreator creates the admin client of the topics with the same brokers and authentication as the producer.
+func (k *FlusherKafka) initTopicCreator(config *sarama.Config) error {
+	if k.CreateTopic.NumPartitions <= 0 {
+		k.CreateTopic.NumPartitions = 1
+	}
+	if k.CreateTopic.ReplicationFactor <= 0 {
+		k.CreateTopic.ReplicationFactor = 1
+	}
+	if k.CreateTopic.MaxCount <= 0 {
+		k.CreateTopic.MaxCount = defaultMaxTopics
+	}
+	limit := helper.DestinationLimit{MaxCount: k.CreateTopic.MaxCount, AllowPattern: k.CreateTopic.AllowPattern}
+	admin, err := sarama.NewClusterAdmin(k.Brokers, config)
+	if err != nil {
+		return err
+	}
+	entries := make(map[string]*string, len(k.CreateTopic.ConfigEntries))
+	for key, value := range k.CreateTopic.ConfigEntries {
+		value := value
+		entries[key] = &value
+	}
+	k.creator, err = helper.NewDestinationCreator(func(topic string) error {
+		// called by Flush, which holds the read lock of the admin
+		err := k.admin.CreateTopic(topic, &sarama.TopicDetail{
+			NumPartitions:     k.CreateTopic.NumPartitions,
+			ReplicationFactor: k.CreateTopic.ReplicationFactor,
+			ConfigEntries:     entries,
+		}, false)
+		var topicErr *sarama.TopicError
+		if errors.As(err, &topicErr) && topicErr.Err == sarama.ErrTopicAlreadyExists {
+			return nil
+		}
+		return err
+	}, topicCreateRetryInterval, limit)
+	if err != nil {
+		_ = admin.Close()
+		return err
+	}
+	k.admin = admin
+	return nil
+}
+
+// ensureTopic creates the topic before the first message is sent to it if CreateTopic is set, and returns false
+// if the topic is not allowed.
+func (k *FlusherKafka) ensureTopic(topic string) bool {
+	if k.creator == nil {
+		return true
+	}
+	err := k.creator.Ensure(topic)
+	if errors.Is(err, helper.ErrDestinationNotAllowed) {
+		logger.Warning(k.context.GetRuntimeContext(), util.AlarmFlusherFlush, "kafka topic is not allowed, drop the message", err)
+		return false
+	}
+	if err != nil {
+		logger.Warning(k.context.GetRuntimeContext(), util.AlarmFlusherFlush, "create kafka topic fail, topic", topic, "error", err)
+	}
+	return true
+}
+
 func newSaramaConfig(config *FlusherKafka) (*sarama.Config, error) {
 	partitioner, err := makePartitioner(config)
 	if err != nil {
@@ -374,6 +613,9 @@ func newSaramaConfig(config *FlusherKafka) (*sarama.Config, error) {
 	k.Net.ReadTimeout = timeout
 	k.Net.WriteTimeout = timeout
 	k.Net.KeepAlive = config.KeepAlive
+	if config.MaxOpenRequests > 0 {
+		k.Net.MaxOpenRequests = config.MaxOpenRequests
+	}
 	k.Producer.Timeout = config.BrokerTimeout
 	k.Producer.CompressionLevel = config.CompressionLevel
 
@@ -434,7 +676,7 @@ func newSaramaConfig(config *FlusherKafka) (*sarama.Config, error) {
 	k.Producer.Partitioner = partitioner
 
 	if err := k.Validate(); err != nil {
-		logger.Error(config.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "Invalid kafka configuration, error", err)
+		logger.Error(config.context.GetRuntimeContext(), util.AlarmFlusherInit, "Invalid kafka configuration, error", err)
 		return nil, err
 	}
 	return k, nil
@@ -476,6 +718,10 @@ func (k *FlusherKafka) Validate() error {
 		return err
 	}
 
+	if k.MessageKey != "" && k.PartitionerType == PartitionerTypeRoundHash {
+		return errors.New("MessageKey can't be used with the hash partitioner")
+	}
+
 	if k.Compression == "gzip" {
 		lvl := k.CompressionLevel
 		if lvl != sarama.CompressionLevelDefault && !(0 <= lvl && lvl <= 9) {
@@ -525,7 +771,20 @@ func saramaProducerCompressionCodec(compression string) (sarama.CompressionCodec
 func (k *FlusherKafka) getConverter() (*converter.Converter, error) {
 	logger.Debug(k.context.GetRuntimeContext(), "[ilogtail data convert config] Protocol", k.Convert.Protocol,
 		"Encoding", k.Convert.Encoding, "TagFieldsRename", k.Convert.TagFieldsRename, "ProtocolFieldsRename", k.Convert.ProtocolFieldsRename)
-	return converter.NewConverter(k.Convert.Protocol, k.Convert.Encoding, k.Convert.TagFieldsRename, k.Convert.ProtocolFieldsRename)
+	c, err := converter.NewConverter(k.Convert.Protocol, k.Convert.Encoding, k.Convert.TagFieldsRename, k.Convert.ProtocolFieldsRename)
+	if err != nil {
+		return nil, err
+	}
+	if len(k.Convert.IncludeFields) > 0 || len(k.Convert.ExcludeFields) > 0 || len(k.Convert.FieldsRename) > 0 {
+		if err = c.SetFieldSelection(&converter.FieldSelection{
+			Include: k.Convert.IncludeFields,
+			Exclude: k.Convert.ExcludeFields,
+			Rename:  k.Convert.FieldsRename,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
 }
 
 func init() {
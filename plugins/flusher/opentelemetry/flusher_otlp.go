@@ -35,6 +35,7 @@ import (
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/protocol"
 	converter "github.com/alibaba/ilogtail/pkg/protocol/converter"
+	"github.com/alibaba/ilogtail/pkg/util"
 
 	"github.com/alibaba/ilogtail/helper"
 )
@@ -66,7 +67,7 @@ func (f *FlusherOTLP) Init(ctx pipeline.Context) error {
 	logger.Info(f.context.GetRuntimeContext(), "otlp flusher init", "initializing")
 	convert, err := f.getConverter()
 	if err != nil {
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init otlp converter fail, error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "init otlp converter fail, error", err)
 		return err
 	}
 	f.converter = convert
@@ -74,7 +75,7 @@ func (f *FlusherOTLP) Init(ctx pipeline.Context) error {
 	if f.Logs != nil {
 		grpcConn, err := buildGrpcClientConn(f.Logs)
 		if err != nil {
-			logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init otlp logs gRPC conn fail, error", err)
+			logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "init otlp logs gRPC conn fail, error", err)
 		} else {
 			logger.Info(f.context.GetRuntimeContext(), "otlp logs flusher endpoint", f.Logs.Endpoint)
 			logMeta := metadata.New(f.Logs.Headers)
@@ -84,7 +85,7 @@ func (f *FlusherOTLP) Init(ctx pipeline.Context) error {
 	if f.Metrics != nil {
 		grpcConn, err := buildGrpcClientConn(f.Metrics)
 		if err != nil {
-			logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init otlp metrics gRPC conn fail, error", err)
+			logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "init otlp metrics gRPC conn fail, error", err)
 		} else {
 			logger.Info(f.context.GetRuntimeContext(), "otlp metrics flusher endpoint", f.Metrics.Endpoint)
 			metricMeta := metadata.New(f.Metrics.Headers)
@@ -95,7 +96,7 @@ func (f *FlusherOTLP) Init(ctx pipeline.Context) error {
 	if f.Traces != nil {
 		grpcConn, err := buildGrpcClientConn(f.Traces)
 		if err != nil {
-			logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init otlp traces gRPC conn fail, error", err)
+			logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "init otlp traces gRPC conn fail, error", err)
 		} else {
 			logger.Info(f.context.GetRuntimeContext(), "otlp traces flusher endpoint", f.Traces.Endpoint)
 			traceMeta := metadata.New(f.Traces.Headers)
@@ -105,7 +106,7 @@ func (f *FlusherOTLP) Init(ctx pipeline.Context) error {
 	}
 
 	if f.logClient == nil && f.metricClient == nil && f.traceClient == nil {
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init otlp flusher fail, error", "invalid gRPC configs")
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "init otlp flusher fail, error", "invalid gRPC configs")
 		return fmt.Errorf("invalid_grpc_configs")
 	}
 
@@ -152,7 +153,7 @@ func (f *FlusherOTLP) IsReady(projectName string, logstoreName string, logstoreK
 		(f.traceClient == nil || f.traceClient.isReady())
 
 	if !ready {
-		logger.Warning(f.context.GetRuntimeContext(), "FLUSHER_READY_ALARM", "otlp flusher is not ready, all gRPC conn is nil")
+		logger.Warning(f.context.GetRuntimeContext(), util.AlarmFlusherReady, "otlp flusher is not ready, all gRPC conn is nil")
 	}
 	return ready
 }
@@ -164,24 +165,29 @@ func (f *FlusherOTLP) Stop() error {
 	if f.logClient.grpcConn != nil {
 		err = f.logClient.grpcConn.Close()
 		if err != nil {
-			logger.Error(f.context.GetRuntimeContext(), "FLUSHER_STOP_ALARM", "stop otlp logs flusher fail, error", err)
+			logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherStop, "stop otlp logs flusher fail, error", err)
 		}
 	}
 
 	if f.metricClient.grpcConn != nil {
 		err = f.metricClient.grpcConn.Close()
 		if err != nil {
-			logger.Error(f.context.GetRuntimeContext(), "FLUSHER_STOP_ALARM", "stop otlp metrics flusher fail, error", err)
+			logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherStop, "stop otlp metrics flusher fail, error", err)
 		}
 	}
 
 	if f.traceClient.grpcConn != nil {
 		err = f.traceClient.grpcConn.Close()
 		if err != nil {
-			logger.Error(f.context.GetRuntimeContext(), "FLUSHER_STOP_ALARM", "stop otlp traces flusher fail, error", err)
+			logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherStop, "stop otlp traces flusher fail, error", err)
 		}
 	}
 
+	for _, config := range []*helper.GrpcClientConfig{f.Logs, f.Metrics, f.Traces} {
+		if config != nil {
+			_ = config.TLS.Close()
+		}
+	}
 	return err
 }
 
@@ -193,7 +199,7 @@ func (f *FlusherOTLP) convertPipelinesGroupeEventsToRequest(pipelinegroupeEventS
 	for _, ps := range pipelinegroupeEventSlice {
 		resourceLog, resourceMetric, resourceTrace, err := converter.ConvertPipelineEventToOtlpEvent(f.converter, ps)
 		if err != nil {
-			logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init gRPC client options fail, error", err)
+			logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "init gRPC client options fail, error", err)
 		}
 		if resourceLog.ScopeLogs().Len() > 0 {
 			newLog := logs.ResourceLogs().AppendEmpty()
@@ -247,7 +253,7 @@ func (f *FlusherOTLP) flushRequests(log plogotlp.ExportRequest, metric pmetricot
 			}
 
 			if err != nil {
-				logger.Error(f.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "send data to otlp server fail, error", err)
+				logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "send data to otlp server fail, error", err)
 			}
 			errChan <- err
 		}()
@@ -265,7 +271,7 @@ func (f *FlusherOTLP) flushRequests(log plogotlp.ExportRequest, metric pmetricot
 			}
 
 			if err != nil {
-				logger.Error(f.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "send metric data to otlp server fail, error", err)
+				logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "send metric data to otlp server fail, error", err)
 			}
 			errChan <- err
 		}()
@@ -283,7 +289,7 @@ func (f *FlusherOTLP) flushRequests(log plogotlp.ExportRequest, metric pmetricot
 			}
 
 			if err != nil {
-				logger.Error(f.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "send trace data to otlp server fail, error", err)
+				logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "send trace data to otlp server fail, error", err)
 			}
 			errChan <- err
 		}()
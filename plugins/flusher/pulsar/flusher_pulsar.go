@@ -48,6 +48,10 @@ type FlusherPulsar struct {
 	TLSTrustCertsFilePath string
 	// Authentication support tls
 	Authentication Authentication
+	// MaxConnectionsPerBroker is the max connections to each broker, 1 by default.
+	MaxConnectionsPerBroker int
+	// KeepAliveInterval is the interval of the pings to the brokers, 30s by default.
+	KeepAliveInterval time.Duration
 
 	DisableBlockIfQueueFull bool
 	// CompressionType  Codec used to produce messages,NONE,LZ4,ZLIB,ZSTD
@@ -89,7 +93,7 @@ func (f *FlusherPulsar) Init(context pipeline.Context) error {
 	f.context = context
 	// Validate config of flusher
 	if err := f.Validate(); err != nil {
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init pulsar flusher fail, error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "init pulsar flusher fail, error", err)
 		return err
 	}
 	// Set default value while not set
@@ -102,7 +106,7 @@ func (f *FlusherPulsar) Init(context pipeline.Context) error {
 	// Init converter
 	convert, err := f.getConverter()
 	if err != nil {
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init pulsar flusher converter fail, error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "init pulsar flusher converter fail, error", err)
 		return err
 	}
 	f.converter = convert
@@ -110,7 +114,7 @@ func (f *FlusherPulsar) Init(context pipeline.Context) error {
 	// Obtain topic keys from dynamic topic expression
 	topicKeys, err := fmtstr.CompileKeys(f.Topic)
 	if err != nil {
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init pulsar flusher fail, error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "init pulsar flusher fail, error", err)
 		return err
 	}
 	f.topicKeys = topicKeys
@@ -118,7 +122,7 @@ func (f *FlusherPulsar) Init(context pipeline.Context) error {
 	options := f.initClientOptions()
 	client, err := pulsar.NewClient(options)
 	if err != nil {
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init pulsar flusher fail, error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "init pulsar flusher fail, error", err)
 		return err
 	}
 	f.pulsarClient = client
@@ -129,7 +133,7 @@ func (f *FlusherPulsar) Init(context pipeline.Context) error {
 	// Init Producer options
 	producerOptions, err := f.initProducerOptions()
 	if err != nil {
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init pulsar flusher producer options fail, error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherInit, "init pulsar flusher producer options fail, error", err)
 		return err
 	}
 	f.producerOptions = producerOptions
@@ -178,17 +182,17 @@ func (f *FlusherPulsar) Flush(projectName string, logstoreName string, configNam
 		selectFields := util.UniqueStrings(f.topicKeys, f.PartitionKeys)
 		logs, values, err := f.converter.ToByteStreamWithSelectedFields(logGroup, selectFields)
 		if err != nil {
-			logger.Error(f.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "flush pulsar convert log fail, error", err)
+			logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "flush pulsar convert log fail, error", err)
 		}
 		for index, log := range logs.([][]byte) {
 			valueMap := values[index]
 			topic, err := fmtstr.FormatTopic(valueMap, f.Topic)
 			if err != nil {
-				logger.Error(f.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "flush pulsar format topic fail, error", err)
+				logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "flush pulsar format topic fail, error", err)
 			}
 			producer, err := f.producers.GetProducer(*topic, f.pulsarClient, f.producerOptions)
 			if err != nil {
-				logger.Error(f.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "load pulsar producer fail,topic", *topic, "err", err)
+				logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "load pulsar producer fail,topic", *topic, "err", err)
 				return err
 			}
 
@@ -200,7 +204,7 @@ func (f *FlusherPulsar) Flush(projectName string, logstoreName string, configNam
 			}
 			producer.SendAsync(f.context.GetRuntimeContext(), message, func(msgId pulsar.MessageID, prodMsg *pulsar.ProducerMessage, err error) {
 				if err != nil {
-					logger.Error(f.context.GetRuntimeContext(), "FLUSHER_FLUSH_ALARM", "send message to pulsar fail,error", err)
+					logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherFlush, "send message to pulsar fail,error", err)
 				} else {
 					logger.Debug(f.context.GetRuntimeContext(), "Pulsar success send events: messageID: %s ", msgId)
 				}
@@ -224,7 +228,7 @@ func (f *FlusherPulsar) IsReady(projectName string, logstoreName string, logstor
 func (f *FlusherPulsar) Stop() error {
 	err := f.producers.Close()
 	if err != nil {
-		logger.Error(f.context.GetRuntimeContext(), "FLUSHER_STOP_ALARM", "stop pulsar flusher fail, error", err)
+		logger.Error(f.context.GetRuntimeContext(), util.AlarmFlusherStop, "stop pulsar flusher fail, error", err)
 	}
 	f.pulsarClient.Close()
 	return err
@@ -241,6 +245,8 @@ func (f *FlusherPulsar) initClientOptions() pulsar.ClientOptions {
 		URL: f.URL,
 	}
 	options.TLSAllowInsecureConnection = f.EnableTLS
+	options.MaxConnectionsPerBroker = f.MaxConnectionsPerBroker
+	options.KeepAliveInterval = f.KeepAliveInterval
 	if len(f.TLSTrustCertsFilePath) > 0 {
 		options.TLSTrustCertsFilePath = f.TLSTrustCertsFilePath
 	}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 
 	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/logtail"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/protocol"
@@ -31,15 +32,26 @@ import (
 type SlsFlusher struct { // nolint:revive
 	EnableShardHash bool
 	KeepShardHash   bool
-
-	context    pipeline.Context
-	lenCounter pipeline.CounterMetric
+	// Logstore resolves the logstore of the log groups from their tags, such as %{tag._namespace_}, and the
+	// logstore of the config is used if any tag is missing. The logstores are in the project of the config.
+	Logstore string
+	// CreateLogstore creates the logstores resolved by Logstore if they don't exist.
+	CreateLogstore *CreateLogstoreConfig
+
+	context         pipeline.Context
+	lenCounter      pipeline.CounterMetric
+	logstoreKeys    []string
+	logstoreCreator *helper.DestinationCreator
 }
 
 // Init ...
 func (p *SlsFlusher) Init(context pipeline.Context) error {
 	p.context = context
 	p.lenCounter = helper.NewCounterMetric("flush_sls_size")
+	if err := p.initLogstore(); err != nil {
+		logger.Error(p.context.GetRuntimeContext(), util.AlarmFlusherInit, "init sls flusher logstore fail, error", err)
+		return err
+	}
 	return nil
 }
 
@@ -77,6 +89,13 @@ func (p *SlsFlusher) Flush(projectName string, logstoreName string, configName s
 				}
 			}
 		}
+		logstore := p.resolveLogstore(logGroup)
+		if logstore != logGroup.Category {
+			if !p.ensureLogstore(logstore) {
+				logstore = logGroup.Category
+			}
+			logGroup.Category = logstore
+		}
 		buf, err := logGroup.Marshal()
 		if err != nil {
 			return fmt.Errorf("loggroup marshal err %v", err)
@@ -0,0 +1,213 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux || windows
+// +build linux windows
+
+package sls
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/helper/credentials"
+	"github.com/alibaba/ilogtail/pkg/fmtstr"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	logstoreTagPrefix     = "tag."
+	logstoreTopicKey      = "tag.__topic__"
+	maxLogstoreNameLength = 63
+	createRetryInterval   = time.Minute
+	defaultMaxLogstores   = 100
+)
+
+// CreateLogstoreConfig creates the logstores resolved by the Logstore of the flusher when they don't exist, so that
+// the layouts like a logstore per namespace don't need the logstores to be created in advance. The logstores are
+// created in the project of the config.
+type CreateLogstoreConfig struct {
+	// Endpoint is the endpoint of the project of the config.
+	Endpoint string
+	// Credentials is the provider of the access key pair allowed to create the logstores of the project.
+	Credentials *credentials.Config
+	// MaxCount is the max count of the logstores resolved, 100 by default, the logs of the others are sent to
+	// the logstore of the config.
+	MaxCount int
+	// AllowPattern is the regex the logstores resolved must match, the logs of the others are sent to the
+	// logstore of the config. All the logstores are allowed if empty.
+	AllowPattern string
+	// TTL is the days the logs are kept, 30 by default.
+	TTL int
+	// ShardCount is the count of the shards, 2 by default.
+	ShardCount int
+	// AutoSplit splits the shards automatically up to MaxSplitShard, 64 by default.
+	AutoSplit     bool
+	MaxSplitShard int
+}
+
+func (c *CreateLogstoreConfig) init() error {
+	if c.Endpoint == "" || c.Credentials == nil {
+		return fmt.Errorf("Endpoint and Credentials of CreateLogstore must be set")
+	}
+	if c.MaxCount <= 0 {
+		c.MaxCount = defaultMaxLogstores
+	}
+	if c.TTL <= 0 {
+		c.TTL = 30
+	}
+	if c.ShardCount <= 0 {
+		c.ShardCount = 2
+	}
+	if c.MaxSplitShard <= 0 {
+		c.MaxSplitShard = 64
+	}
+	return nil
+}
+
+// newLogstoreCreator returns the creator of the logstores in the project through the open api of SLS. The
+// credential is retrieved for each creation, so the rotated or refreshed one takes effect.
+func (c *CreateLogstoreConfig) newLogstoreCreator(project string) (*helper.DestinationCreator, error) {
+	provider, err := c.Credentials.NewProvider()
+	if err != nil {
+		return nil, err
+	}
+	limit := helper.DestinationLimit{MaxCount: c.MaxCount, AllowPattern: c.AllowPattern}
+	return helper.NewDestinationCreator(func(logstore string) error {
+		cred, err := provider.Retrieve()
+		if err != nil {
+			return err
+		}
+		client := sls.CreateNormalInterface(c.Endpoint, cred.AccessKeyID, cred.AccessKeySecret, cred.SecurityToken)
+		defer client.Close() //nolint:errcheck
+		exist, err := client.CheckLogstoreExist(project, logstore)
+		if err != nil {
+			return err
+		}
+		if exist {
+			return nil
+		}
+		err = client.CreateLogStore(project, logstore, c.TTL, c.ShardCount, c.AutoSplit, c.MaxSplitShard)
+		var slsErr *sls.Error
+		if errors.As(err, &slsErr) && slsErr.Code == sls.LOGSTORE_ALREADY_EXIST {
+			return nil
+		}
+		return err
+	}, createRetryInterval, limit)
+}
+
+// initLogstore compiles the keys of the Logstore, which are the tags of the log groups.
+func (p *SlsFlusher) initLogstore() error {
+	if p.Logstore == "" {
+		return nil
+	}
+	keys, err := fmtstr.CompileKeys(p.Logstore)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, logstoreTagPrefix) {
+			return fmt.Errorf("key %s of Logstore must start with %s", key, logstoreTagPrefix)
+		}
+	}
+	p.logstoreKeys = keys
+	if p.CreateLogstore != nil {
+		if err = p.CreateLogstore.init(); err != nil {
+			return err
+		}
+		if p.logstoreCreator, err = p.CreateLogstore.newLogstoreCreator(p.context.GetProject()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureLogstore creates the logstore if CreateLogstore is set, and returns false if the logstore is not allowed.
+func (p *SlsFlusher) ensureLogstore(logstore string) bool {
+	if p.logstoreCreator == nil {
+		return true
+	}
+	err := p.logstoreCreator.Ensure(logstore)
+	if errors.Is(err, helper.ErrDestinationNotAllowed) {
+		logger.Warning(p.context.GetRuntimeContext(), util.AlarmFlusherFlush, "logstore is not allowed, use the logstore of the config", err)
+		return false
+	}
+	if err != nil {
+		logger.Warning(p.context.GetRuntimeContext(), util.AlarmFlusherFlush, "create logstore fail, logstore", logstore, "error", err)
+	}
+	return true
+}
+
+// resolveLogstore returns the logstore of the log group by the Logstore, or the logstore of the config if any tag
+// is missing or the name is invalid.
+func (p *SlsFlusher) resolveLogstore(logGroup *protocol.LogGroup) string {
+	if p.Logstore == "" {
+		return logGroup.Category
+	}
+	values := make(map[string]string, len(p.logstoreKeys))
+	for _, key := range p.logstoreKeys {
+		if key == logstoreTopicKey && logGroup.Topic != "" {
+			values[key] = logGroup.Topic
+			continue
+		}
+		tagKey := strings.TrimPrefix(key, logstoreTagPrefix)
+		for _, tag := range logGroup.LogTags {
+			if tag.Key == tagKey {
+				values[key] = tag.Value
+				break
+			}
+		}
+		if values[key] == "" {
+			return logGroup.Category
+		}
+	}
+	logstore, err := fmtstr.FormatTopic(values, p.Logstore)
+	if err != nil {
+		return logGroup.Category
+	}
+	if name := sanitizeLogstoreName(*logstore); name != "" {
+		return name
+	}
+	return logGroup.Category
+}
+
+// sanitizeLogstoreName converts the name to the rule of the logstores, which consists of the lowercase letters,
+// digits, hyphens and underscores, starts and ends with a letter or a digit, and is 2 to 63 characters long.
+// It returns empty if the name could not be converted.
+func sanitizeLogstoreName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return '-'
+	}, name)
+	if len(name) > maxLogstoreNameLength {
+		name = name[:maxLogstoreNameLength]
+	}
+	name = strings.Trim(name, "-_")
+	if len(name) < 2 {
+		return ""
+	}
+	return name
+}
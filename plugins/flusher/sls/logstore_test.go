@@ -0,0 +1,80 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux || windows
+// +build linux windows
+
+package sls
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/helper/credentials"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+func TestResolveLogstore(t *testing.T) {
+	p := &SlsFlusher{Logstore: "k8s-%{tag._namespace_}"}
+	require.NoError(t, p.initLogstore())
+
+	logGroup := &protocol.LogGroup{
+		Category: "default",
+		LogTags:  []*protocol.LogTag{{Key: "_namespace_", Value: "Kube_System"}},
+	}
+	assert.Equal(t, "k8s-kube_system", p.resolveLogstore(logGroup))
+	// the logstore of the config is used without the tag
+	assert.Equal(t, "default", p.resolveLogstore(&protocol.LogGroup{Category: "default"}))
+
+	p = &SlsFlusher{Logstore: "%{tag.__topic__}"}
+	require.NoError(t, p.initLogstore())
+	assert.Equal(t, "nginx-access", p.resolveLogstore(&protocol.LogGroup{Category: "default", Topic: "nginx.access"}))
+
+	assert.Error(t, (&SlsFlusher{Logstore: "%{content.ns}"}).initLogstore())
+	assert.Error(t, (&SlsFlusher{Logstore: "%{tag.ns}", CreateLogstore: &CreateLogstoreConfig{}}).initLogstore())
+}
+
+func TestEnsureLogstoreNotAllowed(t *testing.T) {
+	p := &SlsFlusher{
+		Logstore: "k8s-%{tag._namespace_}",
+		CreateLogstore: &CreateLogstoreConfig{
+			Endpoint:     "cn-hangzhou.log.aliyuncs.com",
+			Credentials:  &credentials.Config{Provider: credentials.ProviderStatic, AccessKeyID: "id", AccessKeySecret: "secret"},
+			AllowPattern: "^k8s-app-",
+		},
+		context: mock.NewEmptyContext("p", "l", "c"),
+	}
+	require.NoError(t, p.initLogstore())
+	assert.Equal(t, defaultMaxLogstores, p.CreateLogstore.MaxCount)
+	// the logstore not allowed is neither created nor used
+	assert.False(t, p.ensureLogstore("k8s-kube-system"))
+
+	p.CreateLogstore.AllowPattern = "("
+	assert.Error(t, p.initLogstore())
+}
+
+func TestSanitizeLogstoreName(t *testing.T) {
+	assert.Equal(t, "app-1", sanitizeLogstoreName("App.1"))
+	assert.Equal(t, "ns", sanitizeLogstoreName("_ns-"))
+	assert.Equal(t, "", sanitizeLogstoreName("中"))
+	assert.Len(t, sanitizeLogstoreName(string(make([]byte, 100))+"abc"), 0)
+	long := "a"
+	for len(long) < 100 {
+		long += "b"
+	}
+	assert.Len(t, sanitizeLogstoreName(long), maxLogstoreNameLength)
+}
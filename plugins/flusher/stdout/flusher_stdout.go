@@ -17,12 +17,16 @@ package stdout
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"strconv"
 
+	"github.com/alibaba/ilogtail/helper"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/models"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/protocol"
+	converter "github.com/alibaba/ilogtail/pkg/protocol/converter"
+	"github.com/alibaba/ilogtail/pkg/util"
 
 	"github.com/cihub/seelog"
 	jsoniter "github.com/json-iterator/go"
@@ -51,9 +55,13 @@ type FlusherStdout struct {
 	KeyValuePairs bool
 	Tags          bool
 	OnlyStdout    bool
+	// Convert serializes the logs with the shared converter when the encoding is set, which overrides KeyValuePairs.
+	Convert helper.ConvertConfig
 
-	context   pipeline.Context
-	outLogger seelog.LoggerInterface
+	context    pipeline.Context
+	outLogger  seelog.LoggerInterface
+	converter  *converter.Converter
+	rejectFile io.Closer
 }
 
 // Init method would be trigger before working. For the plugin, init method choose the log output
@@ -61,6 +69,33 @@ type FlusherStdout struct {
 func (p *FlusherStdout) Init(context pipeline.Context) error {
 	p.context = context
 
+	if p.Convert.Encoding != "" {
+		if p.Convert.Protocol == "" {
+			p.Convert.Protocol = converter.ProtocolCustomSingle
+		}
+		var err error
+		if p.converter, err = converter.NewConverter(p.Convert.Protocol, p.Convert.Encoding, p.Convert.TagFieldsRename, p.Convert.ProtocolFieldsRename); err != nil {
+			logger.Error(p.context.GetRuntimeContext(), util.AlarmFlusherInit, "init stdout flusher converter fail, error", err)
+			return err
+		}
+		if err = p.Convert.InitFieldMapping(p.converter); err != nil {
+			logger.Error(p.context.GetRuntimeContext(), util.AlarmFlusherInit, "init stdout flusher field mapping fail, error", err)
+			return err
+		}
+		if err = p.Convert.InitFieldSelection(p.converter); err != nil {
+			logger.Error(p.context.GetRuntimeContext(), util.AlarmFlusherInit, "init stdout flusher field selection fail, error", err)
+			return err
+		}
+		if err = p.Convert.InitTemplate(p.converter); err != nil {
+			logger.Error(p.context.GetRuntimeContext(), util.AlarmFlusherInit, "init stdout flusher template fail, error", err)
+			return err
+		}
+		if p.rejectFile, err = p.Convert.InitJSONSchema(p.context.GetRuntimeContext(), p.converter); err != nil {
+			logger.Error(p.context.GetRuntimeContext(), util.AlarmFlusherInit, "init stdout flusher json schema fail, error", err)
+			return err
+		}
+	}
+
 	pattern := ""
 	if p.OnlyStdout {
 		pattern = "<console/>"
@@ -79,7 +114,7 @@ func (p *FlusherStdout) Init(context pipeline.Context) error {
 		var err error
 		p.outLogger, err = seelog.LoggerFromConfigAsString(fmt.Sprintf(flushMsg, pattern))
 		if err != nil {
-			logger.Error(p.context.GetRuntimeContext(), "FLUSHER_INIT_ALARM", "init stdout flusher fail, error", err)
+			logger.Error(p.context.GetRuntimeContext(), util.AlarmFlusherInit, "init stdout flusher fail, error", err)
 			p.outLogger = seelog.Disabled
 		}
 	}
@@ -101,7 +136,20 @@ func (p *FlusherStdout) Flush(projectName string, logstoreName string, configNam
 			}
 		}
 
-		if p.KeyValuePairs {
+		if p.converter != nil {
+			stream, err := p.converter.ToByteStream(logGroup)
+			if err != nil {
+				logger.Error(p.context.GetRuntimeContext(), util.AlarmFlusherFlush, "convert logGroup fail, error", err)
+				continue
+			}
+			for _, buf := range stream.([][]byte) {
+				if p.outLogger != nil {
+					p.outLogger.Infof("%s", buf)
+				} else {
+					logger.Info(p.context.GetRuntimeContext(), string(buf))
+				}
+			}
+		} else if p.KeyValuePairs {
 			for _, log := range logGroup.Logs {
 				writer := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 128)
 				writer.WriteObjectStart()
@@ -192,7 +240,9 @@ func (p *FlusherStdout) Export(in []*models.PipelineGroupEvents, context pipelin
 			case models.EventTypeSpan:
 				p.writeSpan(writer, nil)
 			case models.EventTypeLogging:
-				p.writeLogBody(writer, nil)
+				if log, ok := event.(*models.Log); ok {
+					p.writeLogBody(writer, log)
+				}
 			case models.EventTypeByteArray:
 				p.writeByteArray(writer, event.(models.ByteArray))
 			}
@@ -255,8 +305,13 @@ func (p *FlusherStdout) writeSpan(writer *jsoniter.Stream, metric *models.Span)
 	// TODO
 }
 
-func (p *FlusherStdout) writeLogBody(writer *jsoniter.Stream, metric *models.Metric) {
-	// TODO
+func (p *FlusherStdout) writeLogBody(writer *jsoniter.Stream, log *models.Log) {
+	_, _ = writer.Write([]byte{','})
+	writer.WriteObjectField("severity")
+	writer.WriteString(log.GetSeverity().String())
+	_, _ = writer.Write([]byte{','})
+	writer.WriteObjectField("body")
+	writer.WriteString(string(log.GetBody()))
 }
 
 func (p FlusherStdout) writeByteArray(writer *jsoniter.Stream, metric models.ByteArray) {
@@ -280,6 +335,9 @@ func (p *FlusherStdout) Stop() error {
 	if p.outLogger != nil {
 		p.outLogger.Close()
 	}
+	if p.rejectFile != nil {
+		return p.rejectFile.Close()
+	}
 	return nil
 }
 
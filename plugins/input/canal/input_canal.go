@@ -54,9 +54,9 @@ func (p *LogCanal) Levels() []canalLog.Level {
 func (p *LogCanal) Fire(e *canalLog.Entry) error {
 	switch {
 	case e.Level == canalLog.WarnLevel:
-		logger.Warning(context.Background(), "INPUT_CANAL_ALARM", "canal log, level", e.Level.String(), "message", e.Message)
+		logger.Warning(context.Background(), util.AlarmInputCanal, "canal log, level", e.Level.String(), "message", e.Message)
 	case e.Level == canalLog.ErrorLevel || e.Level == canalLog.FatalLevel || e.Level == canalLog.PanicLevel:
-		logger.Error(context.Background(), "INPUT_CANAL_ALARM", "canal log, level", e.Level.String(), "message", e.Message)
+		logger.Error(context.Background(), util.AlarmInputCanal, "canal log, level", e.Level.String(), "message", e.Message)
 	default:
 		logger.Info(context.Background(), "canal log, level", e.Level.String(), "message", e.Message)
 	}
@@ -251,7 +251,7 @@ ValueLoop:
 			packedData[fieldName] = string(b)
 			return
 		}
-		logger.Warningf(sc.context.GetRuntimeContext(), "CANAL_RUNTIME_ALARM",
+		logger.Warningf(sc.context.GetRuntimeContext(), util.AlarmCanalRuntime,
 			"json.Marshal on %v failed: %v, %v", fieldName, value, err)
 		packedData[fieldName] = ""
 	}
@@ -366,7 +366,7 @@ func (sc *ServiceCanal) OnRow(e *canal.RowsEvent) error {
 			return nil
 		}
 		if len(e.Rows)%2 != 0 {
-			logger.Error(sc.context.GetRuntimeContext(), "CANAL_INVALID_ALARM", "invalid update value count", len(e.Rows))
+			logger.Error(sc.context.GetRuntimeContext(), util.AlarmCanalInvalid, "invalid update value count", len(e.Rows))
 			sc.syncCheckpointWithCanal()
 			return nil
 		}
@@ -380,7 +380,7 @@ func (sc *ServiceCanal) OnRow(e *canal.RowsEvent) error {
 					sc.canal.ClearTableCache([]byte(e.Table.Schema), []byte(e.Table.Name))
 					tableMeta, err := sc.canal.GetTable(e.Table.Schema, e.Table.Name)
 					if err != nil || tableMeta == nil {
-						logger.Error(sc.context.GetRuntimeContext(), "CANAL_INVALID_ALARM", "invalid row values", e.Table.Name,
+						logger.Error(sc.context.GetRuntimeContext(), util.AlarmCanalInvalid, "invalid row values", e.Table.Name,
 							"old columns", len(e.Rows[i]),
 							"new columns", len(e.Rows[i+1]),
 							"table meta columns", len(e.Table.Columns),
@@ -433,7 +433,7 @@ func (sc *ServiceCanal) OnRow(e *canal.RowsEvent) error {
 					sc.canal.ClearTableCache([]byte(e.Table.Schema), []byte(e.Table.Name))
 					tableMeta, err := sc.canal.GetTable(e.Table.Schema, e.Table.Name)
 					if err != nil || tableMeta == nil {
-						logger.Error(sc.context.GetRuntimeContext(), "CANAL_INVALID_ALARM", "invalid row values", e.Table.Name,
+						logger.Error(sc.context.GetRuntimeContext(), util.AlarmCanalInvalid, "invalid row values", e.Table.Name,
 							"columns", len(rowValues),
 							"table meta columns", len(e.Table.Columns),
 							"error", err)
@@ -567,7 +567,7 @@ func (sc *ServiceCanal) GetBinlogLatestPos() mysql.Position {
 				if errConv != nil {
 					latestPos.Name = valueStr
 				} else {
-					logger.Error(sc.context.GetRuntimeContext(), "CANAL_INVALID_ALARM", "show binary logs error")
+					logger.Error(sc.context.GetRuntimeContext(), util.AlarmCanalInvalid, "show binary logs error")
 				}
 				offset, conErr := strconv.Atoi(fmt.Sprint(value[1]))
 				if conErr == nil {
@@ -576,7 +576,7 @@ func (sc *ServiceCanal) GetBinlogLatestPos() mysql.Position {
 			}
 		}
 	} else {
-		logger.Error(sc.context.GetRuntimeContext(), "CANAL_INVALID_ALARM", "show binary logs error", err)
+		logger.Error(sc.context.GetRuntimeContext(), util.AlarmCanalInvalid, "show binary logs error", err)
 	}
 	logger.Info(sc.context.GetRuntimeContext(), "start from latest binlog position", latestPos)
 	return latestPos
@@ -656,7 +656,7 @@ func (sc *ServiceCanal) newCanal() (bool, error) {
 			return false, err
 		}
 
-		logger.Warning(sc.context.GetRuntimeContext(), "CANAL_START_ALARM", "newCanal failed, error", err, "retry it")
+		logger.Warning(sc.context.GetRuntimeContext(), util.AlarmCanalStart, "newCanal failed, error", err, "retry it")
 		if util.RandomSleep(time.Second*5, 0.1, sc.shutdown) {
 			return true, nil
 		}
@@ -674,7 +674,7 @@ func (sc *ServiceCanal) Start(c pipeline.Collector) error {
 	shouldShutdown, err := sc.newCanal()
 	if err != nil {
 		logger.Error(sc.context.GetRuntimeContext(),
-			"CANAL_START_ALARM", "service_canal plugin only supports ROW mode", err)
+			util.AlarmCanalStart, "service_canal plugin only supports ROW mode", err)
 		return err
 	}
 	if shouldShutdown {
@@ -693,7 +693,7 @@ func (sc *ServiceCanal) Start(c pipeline.Collector) error {
 			break
 		}
 		err = fmt.Errorf("Check GTID mode failed, error: %v", err)
-		logger.Warning(sc.context.GetRuntimeContext(), "CANAL_START_ALARM", err.Error())
+		logger.Warning(sc.context.GetRuntimeContext(), util.AlarmCanalStart, err.Error())
 		if shouldRetry {
 			if util.RandomSleep(time.Second*5, 0.1, sc.shutdown) {
 				sc.canal.Close()
@@ -726,7 +726,7 @@ func (sc *ServiceCanal) Start(c pipeline.Collector) error {
 	if sc.checkpoint.GTID != "" {
 		gtid, err = mysql.ParseGTIDSet(sc.Flavor, sc.checkpoint.GTID)
 		if err != nil {
-			logger.Error(sc.context.GetRuntimeContext(), "CANAL_START_ALARM", "Parse GTID error, clear it",
+			logger.Error(sc.context.GetRuntimeContext(), util.AlarmCanalStart, "Parse GTID error, clear it",
 				sc.checkpoint.GTID, err)
 			gtid = nil
 			sc.checkpoint.GTID = ""
@@ -740,7 +740,7 @@ func (sc *ServiceCanal) Start(c pipeline.Collector) error {
 	if nil == gtid && 0 == len(startPos.Name) && !sc.StartFromBegining {
 		gtid, err = sc.getLatestGTID()
 		if err != nil {
-			logger.Warning(sc.context.GetRuntimeContext(), "CANAL_START_ALARM", "Call getLatestGTID failed, error", err)
+			logger.Warning(sc.context.GetRuntimeContext(), util.AlarmCanalStart, "Call getLatestGTID failed, error", err)
 			startPos = sc.GetBinlogLatestPos()
 		}
 		logger.Infof(sc.context.GetRuntimeContext(), "Get latest checkpoint", gtid, startPos)
@@ -777,7 +777,7 @@ ForBlock:
 				break ForBlock
 			}
 			errStr := err.Error()
-			logger.Error(sc.context.GetRuntimeContext(), "CANAL_RUNTIME_ALARM", "Restart canal because of error", err)
+			logger.Error(sc.context.GetRuntimeContext(), util.AlarmCanalRuntime, "Restart canal because of error", err)
 
 			// Get latest position from server and restart.
 			//
@@ -799,7 +799,7 @@ ForBlock:
 				if sc.isGTIDEnabled {
 					gtid, err = sc.getLatestGTID()
 					if err != nil {
-						logger.Warning(sc.context.GetRuntimeContext(), "CANAL_RUNTIME_ALARM",
+						logger.Warning(sc.context.GetRuntimeContext(), util.AlarmCanalRuntime,
 							"getLatestGTID failed duration restarting", err)
 					}
 				}
@@ -0,0 +1,224 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudlogs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper/credentials"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	// cloudWatchTargetPrefix is the prefix of the X-Amz-Target header of the CloudWatch Logs API.
+	cloudWatchTargetPrefix = "Logs_20140328."
+	cloudWatchService      = "logs"
+	logGroupTag            = "__log_group__"
+	logStreamTag           = "__log_stream__"
+)
+
+// cloudWatchError is the error response of the CloudWatch Logs API, Type is the exception name such as
+// ThrottlingException.
+type cloudWatchError struct {
+	StatusCode int
+	Type       string `json:"__type"`
+	Message    string `json:"message"`
+}
+
+func (e *cloudWatchError) Error() string {
+	return fmt.Sprintf("%s (status code %d): %s", e.Type, e.StatusCode, e.Message)
+}
+
+type logEvent struct {
+	EventID       string `json:"eventId"`
+	LogStreamName string `json:"logStreamName"`
+	Timestamp     int64  `json:"timestamp"`
+	Message       string `json:"message"`
+	IngestionTime int64  `json:"ingestionTime"`
+}
+
+// groupCheckpoint is the read progress of a log group.
+type groupCheckpoint struct {
+	// StartTime is the start time in milliseconds of the next search of the events.
+	StartTime int64
+	// EventIDs are the timestamps of the events collected after StartTime by the event ids, the events are
+	// skipped when returned again.
+	EventIDs map[string]int64
+}
+
+// cloudWatchSource pulls the events of a log group of CloudWatch Logs. The events of all the streams are searched
+// by FilterLogEvents from the start time in the checkpoint every interval, and deduplicated by the event ids. The
+// start time is kept maxDelay before the latest event collected, so that the events searchable later than the
+// newer events of the other streams are still collected.
+type cloudWatchSource struct {
+	client   *cloudWatchClient
+	group    string
+	prefix   string
+	interval time.Duration
+	maxDelay time.Duration
+	// fromBeginning reads the log group from the beginning rather than the end at the first start.
+	fromBeginning bool
+	contentKey    string
+	context       pipeline.Context
+	checkpoint    *groupCheckpoint
+}
+
+func (s *cloudWatchSource) run(ctx context.Context, collector pipeline.Collector) {
+	if s.checkpoint.StartTime == 0 && len(s.checkpoint.EventIDs) == 0 && !s.fromBeginning {
+		s.checkpoint.StartTime = time.Now().UnixMilli()
+	}
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.poll(ctx, collector)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll collects the new events of the log group, and saves the checkpoint once after all the pages are read.
+func (s *cloudWatchSource) poll(ctx context.Context, collector pipeline.Collector) {
+	cp := s.checkpoint
+	if cp.EventIDs == nil {
+		cp.EventIDs = make(map[string]int64)
+	}
+	latest := cp.StartTime
+	token := ""
+	for {
+		events, next, err := s.client.filterLogEvents(ctx, s.group, s.prefix, cp.StartTime, token)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Warning(s.context.GetRuntimeContext(), util.AlarmCloudLogs, "filter the log events error", err, "log group", s.group)
+			}
+			break
+		}
+		for _, event := range events {
+			if _, ok := cp.EventIDs[event.EventID]; ok {
+				continue
+			}
+			tags := map[string]string{logGroupTag: s.group, logStreamTag: event.LogStreamName}
+			collector.AddData(tags, map[string]string{s.contentKey: event.Message}, time.UnixMilli(event.Timestamp))
+			cp.EventIDs[event.EventID] = event.Timestamp
+		}
+		if next == "" || ctx.Err() != nil {
+			break
+		}
+		token = next
+	}
+	for _, timestamp := range cp.EventIDs {
+		if timestamp > latest {
+			latest = timestamp
+		}
+	}
+	if startTime := latest - s.maxDelay.Milliseconds(); startTime > cp.StartTime {
+		cp.StartTime = startTime
+	}
+	// the events before the start time are not returned any more
+	for id, timestamp := range cp.EventIDs {
+		if timestamp < cp.StartTime {
+			delete(cp.EventIDs, id)
+		}
+	}
+	s.saveCheckpoint()
+}
+
+func (s *cloudWatchSource) saveCheckpoint() {
+	if err := s.context.SaveCheckPointObject(checkpointKey, s.checkpoint); err != nil {
+		logger.Warning(s.context.GetRuntimeContext(), util.AlarmCheckpointSave, "save the checkpoint of the log group error", err)
+	}
+}
+
+// cloudWatchClient calls the JSON API of CloudWatch Logs, the requests are signed by the AWS signature version 4.
+type cloudWatchClient struct {
+	client      *http.Client
+	endpoint    string
+	region      string
+	credentials credentials.Provider
+}
+
+// filterLogEvents returns a page of the events of the streams of @group with @prefix after @startTime in
+// milliseconds, and the token of the next page.
+func (c *cloudWatchClient) filterLogEvents(ctx context.Context, group, prefix string, startTime int64, token string) ([]logEvent, string, error) {
+	req := map[string]interface{}{"logGroupName": group}
+	if prefix != "" {
+		req["logStreamNamePrefix"] = prefix
+	}
+	if startTime > 0 {
+		req["startTime"] = startTime
+	}
+	if token != "" {
+		req["nextToken"] = token
+	}
+	var resp struct {
+		Events    []logEvent `json:"events"`
+		NextToken string     `json:"nextToken"`
+	}
+	if err := c.call(ctx, "FilterLogEvents", req, &resp); err != nil {
+		return nil, "", err
+	}
+	return resp.Events, resp.NextToken, nil
+}
+
+// call sends the signed request of @action and decodes the response into @resp.
+func (c *cloudWatchClient) call(ctx context.Context, action string, req, resp interface{}) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	httpReq.Header.Set("X-Amz-Target", cloudWatchTargetPrefix+action)
+	cred, err := c.credentials.Retrieve()
+	if err != nil {
+		return err
+	}
+	credentials.SignV4(httpReq, body, cred, c.region, cloudWatchService, time.Now())
+	httpResp, err := c.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close() //nolint:errcheck
+	if httpResp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(httpResp.Body, 1024))
+		e := &cloudWatchError{StatusCode: httpResp.StatusCode}
+		if json.Unmarshal(data, e) != nil || e.Type == "" {
+			e.Message = string(data)
+		}
+		// the type may be in the format of namespace#type
+		if i := strings.LastIndexByte(e.Type, '#'); i >= 0 {
+			e.Type = e.Type[i+1:]
+		}
+		return e
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
+		return fmt.Errorf("decode the response of %s error: %v", action, err)
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudlogs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	consumerLibrary "github.com/aliyun/aliyun-log-go-sdk/consumer"
+
+	"github.com/alibaba/ilogtail/helper/credentials"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/util"
+	"github.com/alibaba/ilogtail/pluginmanager"
+)
+
+const (
+	pluginName    = "service_cloud_logs"
+	checkpointKey = pluginName
+
+	sourceCloudWatch = "cloudwatch"
+	sourceSLS        = "sls"
+)
+
+// logSource pulls the logs until ctx is done.
+type logSource interface {
+	run(ctx context.Context, collector pipeline.Collector)
+}
+
+// InputCloudLogs pulls the logs of the cloud log services, so the logs of the cloud managed services can be
+// collected into the self-hosted backends. The events of a CloudWatch Logs log group are searched with the
+// checkpoint kept by the agent, and the shards of an SLS logstore are consumed by a consumer group, which keeps
+// the per-shard checkpoints on the server side.
+type InputCloudLogs struct {
+	Source          string              `comment:"the cloud log service, cloudwatch or sls"`
+	Endpoint        string              `comment:"the endpoint of the service, such as cn-hangzhou.log.aliyuncs.com of sls, https://logs.{Region}.amazonaws.com of cloudwatch by default"`
+	Region          string              `comment:"the region of cloudwatch to sign the requests"`
+	AccessKeyID     string              `comment:"the access key id"`
+	AccessKeySecret string              `comment:"the access key secret"`
+	SecurityToken   string              `comment:"the security token of the temporary credentials"`
+	Credentials     *credentials.Config `comment:"the provider of the access key pair, which takes precedence over the access key fields, the consumer of sls is restarted when the key pair changes"`
+	FromBeginning   bool                `comment:"read the existing logs at the first start rather than only the new ones"`
+
+	LogGroupName        string `comment:"the log group of cloudwatch"`
+	LogStreamNamePrefix string `comment:"the prefix of the log streams of cloudwatch to collect"`
+	IntervalSec         int    `comment:"the interval to search the events of cloudwatch, 10 by default"`
+	MaxEventDelaySec    int    `comment:"the max delay in seconds of the events of cloudwatch to be searchable after the newer events, the later ones are missed, 60 by default"`
+	ContentKey          string `comment:"the key of the message of cloudwatch in the log, content by default"`
+
+	Project       string `comment:"the project of sls"`
+	Logstore      string `comment:"the logstore of sls"`
+	ConsumerGroup string `comment:"the consumer group of sls, created if not exists"`
+	ConsumerName  string `comment:"the consumer name in the consumer group of sls, the host name by default"`
+
+	context pipeline.Context
+	source  logSource
+	runCtx  context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func (r *InputCloudLogs) Init(ctx pipeline.Context) (int, error) {
+	r.context = ctx
+	var err error
+	switch r.Source {
+	case sourceCloudWatch:
+		r.source, err = r.newCloudWatchSource()
+	case sourceSLS:
+		r.source, err = r.newSLSSource()
+	default:
+		err = fmt.Errorf("unknown source %q, must be %s or %s", r.Source, sourceCloudWatch, sourceSLS)
+	}
+	if err != nil {
+		return 0, err
+	}
+	r.runCtx, r.cancel = context.WithCancel(context.Background())
+	return 0, nil
+}
+
+func (r *InputCloudLogs) newCloudWatchSource() (*cloudWatchSource, error) {
+	if r.LogGroupName == "" || r.Region == "" {
+		return nil, fmt.Errorf("LogGroupName and Region must be set for %s", sourceCloudWatch)
+	}
+	endpoint := r.Endpoint
+	if endpoint == "" {
+		endpoint = "https://logs." + r.Region + ".amazonaws.com/"
+	}
+	provider, err := r.credentialProvider()
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil {
+		return nil, fmt.Errorf("the credentials must be set for %s", sourceCloudWatch)
+	}
+	if r.IntervalSec <= 0 {
+		r.IntervalSec = 10
+	}
+	if r.MaxEventDelaySec < 0 {
+		r.MaxEventDelaySec = 0
+	}
+	source := &cloudWatchSource{
+		client: &cloudWatchClient{
+			client:      &http.Client{Timeout: 30 * time.Second},
+			endpoint:    endpoint,
+			region:      r.Region,
+			credentials: provider,
+		},
+		group:         r.LogGroupName,
+		prefix:        r.LogStreamNamePrefix,
+		interval:      time.Duration(r.IntervalSec) * time.Second,
+		maxDelay:      time.Duration(r.MaxEventDelaySec) * time.Second,
+		fromBeginning: r.FromBeginning,
+		contentKey:    r.ContentKey,
+		context:       r.context,
+		checkpoint:    &groupCheckpoint{},
+	}
+	r.context.GetCheckPointObject(checkpointKey, source.checkpoint)
+	return source, nil
+}
+
+func (r *InputCloudLogs) newSLSSource() (*slsSource, error) {
+	if r.Endpoint == "" || r.Project == "" || r.Logstore == "" || r.ConsumerGroup == "" {
+		return nil, fmt.Errorf("Endpoint, Project, Logstore and ConsumerGroup must be set for %s", sourceSLS)
+	}
+	provider, err := r.credentialProvider()
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil {
+		return nil, fmt.Errorf("the credentials must be set for %s", sourceSLS)
+	}
+	consumerName := r.ConsumerName
+	if consumerName == "" {
+		consumerName = util.GetHostName()
+	}
+	cursor := consumerLibrary.END_CURSOR
+	if r.FromBeginning {
+		cursor = consumerLibrary.BEGIN_CURSOR
+	}
+	return &slsSource{context: r.context, credentials: provider, option: consumerLibrary.LogHubConfig{
+		Endpoint:          r.Endpoint,
+		Project:           r.Project,
+		Logstore:          r.Logstore,
+		ConsumerGroupName: r.ConsumerGroup,
+		ConsumerName:      consumerName,
+		CursorPosition:    cursor,
+		// the consumer library writes its own logs rather than the logs of the agent
+		AllowLogLevel: "warn",
+		LogFileName:   path.Join(pluginmanager.LogtailGlobalConfig.LogtailSysConfDir, "sls_consumer.log"),
+	}}, nil
+}
+
+// credentialProvider returns the provider of the access key pair, which is nil if not configured.
+func (r *InputCloudLogs) credentialProvider() (credentials.Provider, error) {
+	if r.Credentials != nil {
+		return r.Credentials.NewProvider()
+	}
+	if r.AccessKeyID == "" {
+		return nil, nil
+	}
+	return (&credentials.Config{
+		Provider:        credentials.ProviderStatic,
+		AccessKeyID:     r.AccessKeyID,
+		AccessKeySecret: r.AccessKeySecret,
+		SecurityToken:   r.SecurityToken,
+	}).NewProvider()
+}
+
+func (r *InputCloudLogs) Description() string {
+	return "pull the logs of the cloud log services, such as CloudWatch Logs and SLS, with the checkpoints"
+}
+
+func (r *InputCloudLogs) Collect(collector pipeline.Collector) error {
+	return nil
+}
+
+func (r *InputCloudLogs) Start(collector pipeline.Collector) error {
+	r.wg.Add(1)
+	defer r.wg.Done()
+	r.source.run(r.runCtx, collector)
+	return nil
+}
+
+func (r *InputCloudLogs) Stop() error {
+	r.cancel()
+	r.wg.Wait()
+	return nil
+}
+
+func init() {
+	pipeline.ServiceInputs[pluginName] = func() pipeline.ServiceInput {
+		return &InputCloudLogs{
+			IntervalSec:      10,
+			MaxEventDelaySec: 60,
+			ContentKey:       "content",
+		}
+	}
+}
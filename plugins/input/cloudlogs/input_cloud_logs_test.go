@@ -0,0 +1,251 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudlogs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/plugins/test"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+const eventsPageSize = 2
+
+// fakeCloudWatch serves FilterLogEvents of the events of the streams, the tokens are the indexes of the next
+// events.
+type fakeCloudWatch struct {
+	t      *testing.T
+	mu     sync.Mutex
+	events []logEvent
+}
+
+func (f *fakeCloudWatch) append(stream string, timestamp int64, messages ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, message := range messages {
+		f.events = append(f.events, logEvent{
+			EventID:       strconv.Itoa(len(f.events)),
+			LogStreamName: stream,
+			Timestamp:     timestamp,
+			Message:       message,
+		})
+	}
+}
+
+func (f *fakeCloudWatch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	assert.True(f.t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=id/"))
+	assert.Contains(f.t, r.Header.Get("Authorization"), "/us-west-2/logs/aws4_request")
+	var req map[string]interface{}
+	require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+	assert.Equal(f.t, "group", req["logGroupName"])
+	if r.Header.Get("X-Amz-Target") != "Logs_20140328.FilterLogEvents" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"__type":"com.amazonaws.logs#UnknownOperationException"}`))
+		return
+	}
+	prefix, _ := req["logStreamNamePrefix"].(string)
+	startTime, _ := req["startTime"].(float64)
+	var events []logEvent
+	for _, event := range f.events {
+		if strings.HasPrefix(event.LogStreamName, prefix) && event.Timestamp >= int64(startTime) {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Timestamp < events[j].Timestamp })
+	start := 0
+	if token, ok := req["nextToken"].(string); ok {
+		start, _ = strconv.Atoi(token)
+	}
+	resp := map[string]interface{}{}
+	end := start + eventsPageSize
+	if end < len(events) {
+		resp["nextToken"] = strconv.Itoa(end)
+	} else {
+		end = len(events)
+	}
+	resp["events"] = events[start:end]
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func newCloudWatchInput(t *testing.T, ctx pipeline.Context, endpoint string) *InputCloudLogs {
+	input := pipeline.ServiceInputs[pluginName]().(*InputCloudLogs)
+	input.Source = sourceCloudWatch
+	input.Endpoint = endpoint
+	input.Region = "us-west-2"
+	input.AccessKeyID, input.AccessKeySecret = "id", "secret"
+	input.LogGroupName = "group"
+	_, err := input.Init(ctx)
+	require.NoError(t, err)
+	return input
+}
+
+func messages(collector *test.MockMetricCollector) []string {
+	var lines []string
+	for _, log := range collector.Logs {
+		for _, content := range log.Contents {
+			if content.Key == "content" {
+				lines = append(lines, content.Value)
+			}
+		}
+	}
+	return lines
+}
+
+func TestPollCloudWatch(t *testing.T) {
+	fake := &fakeCloudWatch{t: t}
+	fake.append("a", 1000, "a1", "a2", "a3")
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	ctx := mock.NewEmptyContext("project", "store", "config")
+	input := newCloudWatchInput(t, ctx, server.URL)
+	source := input.source.(*cloudWatchSource)
+	collector := &test.MockMetricCollector{}
+
+	source.poll(context.Background(), collector)
+	assert.Equal(t, []string{"a1", "a2", "a3"}, messages(collector))
+	assert.Equal(t, map[string]string{
+		logGroupTag:  "group",
+		logStreamTag: "a",
+		"content":    "a1",
+	}, map[string]string{
+		collector.Logs[0].Contents[0].Key: collector.Logs[0].Contents[0].Value,
+		collector.Logs[0].Contents[1].Key: collector.Logs[0].Contents[1].Value,
+		collector.Logs[0].Contents[2].Key: collector.Logs[0].Contents[2].Value,
+	})
+	assert.Equal(t, uint32(1), collector.Logs[0].Time)
+
+	// restart from the checkpoint, the events returned again are skipped
+	fake.append("a", 1000, "a4")
+	fake.append("b", 2000, "b1")
+	input = newCloudWatchInput(t, ctx, server.URL)
+	source = input.source.(*cloudWatchSource)
+	collector = &test.MockMetricCollector{}
+	source.poll(context.Background(), collector)
+	assert.Equal(t, []string{"a4", "b1"}, messages(collector))
+
+	// the delayed event of the other stream is collected in the delay window
+	fake.append("c", 1500, "c1")
+	collector = &test.MockMetricCollector{}
+	source.poll(context.Background(), collector)
+	assert.Equal(t, []string{"c1"}, messages(collector))
+
+	// the events before the window are forgotten
+	fake.append("a", 200000, "a5")
+	source.poll(context.Background(), collector)
+	assert.Equal(t, []string{"c1", "a5"}, messages(collector))
+	assert.Equal(t, int64(140000), source.checkpoint.StartTime)
+	assert.Equal(t, map[string]int64{"6": 200000}, source.checkpoint.EventIDs)
+}
+
+func TestPollCloudWatchFromEnd(t *testing.T) {
+	fake := &fakeCloudWatch{t: t}
+	fake.append("a", time.Now().Add(-time.Minute).UnixMilli(), "old")
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	input := newCloudWatchInput(t, mock.NewEmptyContext("project", "store", "config"), server.URL)
+	source := input.source.(*cloudWatchSource)
+	collector := &test.MockMetricCollector{}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// the existing events are skipped at the first start
+	cancel()
+	source.run(ctx, collector)
+	assert.Empty(t, messages(collector))
+
+	fake.append("a", time.Now().Add(time.Second).UnixMilli(), "new")
+	source.poll(context.Background(), collector)
+	assert.Equal(t, []string{"new"}, messages(collector))
+}
+
+func TestCloudWatchError(t *testing.T) {
+	fake := &fakeCloudWatch{t: t}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	input := newCloudWatchInput(t, mock.NewEmptyContext("project", "store", "config"), server.URL)
+	client := input.source.(*cloudWatchSource).client
+	err := client.call(context.Background(), "Unknown", map[string]string{"logGroupName": "group"}, nil)
+	var cwErr *cloudWatchError
+	require.ErrorAs(t, err, &cwErr)
+	assert.Equal(t, "UnknownOperationException", cwErr.Type)
+	assert.Equal(t, http.StatusBadRequest, cwErr.StatusCode)
+}
+
+func TestInitErrors(t *testing.T) {
+	for _, input := range []*InputCloudLogs{
+		{Source: "unknown"},
+		{Source: sourceCloudWatch, Region: "us-west-2", AccessKeyID: "id"},
+		{Source: sourceCloudWatch, Region: "us-west-2", LogGroupName: "group"},
+		{Source: sourceSLS, Endpoint: "cn-hangzhou.log.aliyuncs.com", Project: "p", Logstore: "l", AccessKeyID: "id"},
+	} {
+		_, err := input.Init(mock.NewEmptyContext("project", "store", "config"))
+		assert.Error(t, err, input.Source)
+	}
+
+	input := &InputCloudLogs{Source: sourceSLS, Endpoint: "cn-hangzhou.log.aliyuncs.com", Project: "p", Logstore: "l",
+		ConsumerGroup: "g", AccessKeyID: "id", AccessKeySecret: "secret", FromBeginning: true}
+	_, err := input.Init(mock.NewEmptyContext("project", "store", "config"))
+	require.NoError(t, err)
+	option := input.source.(*slsSource).option
+	assert.Equal(t, "BEGIN_CURSOR", option.CursorPosition)
+	assert.NotEmpty(t, option.ConsumerName)
+}
+
+func TestAddLogGroups(t *testing.T) {
+	str := func(s string) *string { return &s }
+	logTime := uint32(1700000000)
+	logGroups := &sls.LogGroupList{LogGroups: []*sls.LogGroup{{
+		Topic:   str("topic"),
+		Source:  str("10.0.0.1"),
+		LogTags: []*sls.LogTag{{Key: str("host"), Value: str("h1")}},
+		Logs: []*sls.Log{{
+			Time: &logTime,
+			Contents: []*sls.LogContent{
+				{Key: str("k1"), Value: str("v1")},
+				{Key: str("k2"), Value: str("v2")},
+			},
+		}},
+	}}}
+	collector := &test.MockMetricCollector{}
+	addLogGroups(collector, logGroups)
+	require.Len(t, collector.Logs, 1)
+	assert.Equal(t, logTime, collector.Logs[0].Time)
+	fields := make(map[string]string)
+	for _, content := range collector.Logs[0].Contents {
+		fields[content.Key] = content.Value
+	}
+	assert.Equal(t, map[string]string{
+		"__topic__":    "topic",
+		"__source__":   "10.0.0.1",
+		"__tag__:host": "h1",
+		"k1":           "v1",
+		"k2":           "v2",
+	}, fields)
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudlogs
+
+import (
+	"context"
+	"time"
+
+	sls "github.com/aliyun/aliyun-log-go-sdk"
+	consumerLibrary "github.com/aliyun/aliyun-log-go-sdk/consumer"
+
+	"github.com/alibaba/ilogtail/helper/credentials"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	slsTopicTag     = "__topic__"
+	slsSourceTag    = "__source__"
+	slsLogTagPrefix = "__tag__:"
+)
+
+// credentialCheckInterval is the interval to check whether the key pair of the consumer is changed.
+var credentialCheckInterval = time.Minute
+
+// slsSource consumes a logstore of SLS by the consumer group, the shards are balanced among the consumers of the
+// group and the checkpoints of the shards are kept by the consumer group on the server side. The consumer library
+// cannot update the key pair, so the consumer is restarted with the new key pair when it is rotated or refreshed.
+type slsSource struct {
+	option      consumerLibrary.LogHubConfig
+	credentials credentials.Provider
+	context     pipeline.Context
+}
+
+func (s *slsSource) run(ctx context.Context, collector pipeline.Collector) {
+	for {
+		cred, err := s.credentials.Retrieve()
+		if err != nil {
+			logger.Warning(s.context.GetRuntimeContext(), util.AlarmCloudLogs, "retrieve the credentials of sls error", err)
+			if !util.Sleep(credentialCheckInterval, ctx.Done()) {
+				continue
+			}
+			return
+		}
+		option := s.option
+		option.AccessKeyID, option.AccessKeySecret, option.SecurityToken = cred.AccessKeyID, cred.AccessKeySecret, cred.SecurityToken
+		worker := consumerLibrary.InitConsumerWorker(option, func(shard int, logGroups *sls.LogGroupList) string {
+			addLogGroups(collector, logGroups)
+			// commit the fetched cursor as the checkpoint
+			return ""
+		})
+		worker.Start()
+		changed := credentials.WaitChange(s.credentials, cred, credentialCheckInterval, ctx.Done())
+		worker.StopAndWait()
+		if changed == nil {
+			return
+		}
+		logger.Info(s.context.GetRuntimeContext(), "restart the consumer of sls with the new credentials", option.ConsumerName)
+	}
+}
+
+func addLogGroups(collector pipeline.Collector, logGroups *sls.LogGroupList) {
+	for _, logGroup := range logGroups.GetLogGroups() {
+		tags := make(map[string]string, len(logGroup.GetLogTags())+2)
+		if topic := logGroup.GetTopic(); topic != "" {
+			tags[slsTopicTag] = topic
+		}
+		if source := logGroup.GetSource(); source != "" {
+			tags[slsSourceTag] = source
+		}
+		for _, tag := range logGroup.GetLogTags() {
+			tags[slsLogTagPrefix+tag.GetKey()] = tag.GetValue()
+		}
+		for _, log := range logGroup.GetLogs() {
+			keys := make([]string, 0, len(log.GetContents()))
+			values := make([]string, 0, len(log.GetContents()))
+			for _, content := range log.GetContents() {
+				keys = append(keys, content.GetKey())
+				values = append(values, content.GetValue())
+			}
+			collector.AddDataArray(tags, keys, values, time.Unix(int64(log.GetTime()), 0))
+		}
+	}
+}
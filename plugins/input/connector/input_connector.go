@@ -0,0 +1,139 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connector
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/alibaba/ilogtail/helper/connector"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	tagPrefix = "__tag__:"
+	topicKey  = "__log_topic__"
+)
+
+// skippedTags are not passed to the consuming pipeline, the hostname tag is added by the consuming pipeline again.
+var skippedTags = map[string]bool{
+	util.PackIDTagKey:     true,
+	"__user_defined_id__": true,
+	"__hostname__":        true,
+}
+
+// ServiceConnector consumes the LogGroups pushed by the flusher_connector of other pipelines with the same Name.
+// The topic of a LogGroup is kept in the __log_topic__ field, and the LogTags are kept as the fields prefixed
+// by __tag__:, just like the logs passed by the C++ core.
+type ServiceConnector struct {
+	// Name of the connector, which must be the same as the Name of flusher_connector.
+	Name string
+	// QueueSize is the capacity of the connector in LogGroups, it only takes effect on the side created first.
+	QueueSize int
+
+	context   pipeline.Context
+	connector *connector.Connector
+	waitGroup sync.WaitGroup
+
+	// the service is started and stopped repeatedly by the hot standby, so the shutdown channel is created
+	// by each Start, and a Stop before the Start stops the coming run.
+	lock        sync.Mutex
+	shutdown    chan struct{}
+	stopOnce    *sync.Once
+	pendingStop bool
+}
+
+func (s *ServiceConnector) Init(context pipeline.Context) (int, error) {
+	s.context = context
+	if s.Name == "" {
+		return 0, fmt.Errorf("must specify the Name of the connector")
+	}
+	s.connector = connector.Get(s.Name, s.QueueSize)
+	return 0, nil
+}
+
+func (s *ServiceConnector) Description() string {
+	return "connector input for consuming the logs of other pipelines in the same agent"
+}
+
+func (s *ServiceConnector) Collect(collector pipeline.Collector) error {
+	return nil
+}
+
+// Start consumes the connector until Stop is called. The LogGroups left in the connector are kept for the next
+// consumer, e.g. the same config after reloading.
+func (s *ServiceConnector) Start(collector pipeline.Collector) error {
+	s.lock.Lock()
+	if s.pendingStop {
+		s.pendingStop = false
+		s.lock.Unlock()
+		return nil
+	}
+	shutdown := make(chan struct{})
+	s.shutdown, s.stopOnce = shutdown, &sync.Once{}
+	s.waitGroup.Add(1)
+	s.lock.Unlock()
+	defer s.waitGroup.Done()
+	for {
+		select {
+		case <-shutdown:
+			return nil
+		case logGroup := <-s.connector.Queue():
+			s.collectLogGroup(collector, logGroup)
+		}
+	}
+}
+
+func (s *ServiceConnector) collectLogGroup(collector pipeline.Collector, logGroup *protocol.LogGroup) {
+	tags := make([]*protocol.Log_Content, 0, len(logGroup.LogTags))
+	for _, tag := range logGroup.LogTags {
+		if !skippedTags[tag.Key] {
+			tags = append(tags, &protocol.Log_Content{Key: tagPrefix + tag.Key, Value: tag.Value})
+		}
+	}
+	for _, log := range logGroup.Logs {
+		if logGroup.Topic != "" {
+			log.Contents = append(log.Contents, &protocol.Log_Content{Key: topicKey, Value: logGroup.Topic})
+		}
+		for _, tag := range tags {
+			log.Contents = append(log.Contents, &protocol.Log_Content{Key: tag.Key, Value: tag.Value})
+		}
+		collector.AddRawLogWithContext(log, map[string]interface{}{"source": logGroup.Source, "topic": logGroup.Topic})
+	}
+}
+
+func (s *ServiceConnector) Stop() error {
+	s.lock.Lock()
+	if s.shutdown != nil {
+		shutdown := s.shutdown
+		s.stopOnce.Do(func() { close(shutdown) })
+		s.shutdown = nil
+	} else {
+		s.pendingStop = true
+	}
+	s.lock.Unlock()
+	s.waitGroup.Wait()
+	return nil
+}
+
+func init() {
+	pipeline.ServiceInputs["service_connector"] = func() pipeline.ServiceInput {
+		return &ServiceConnector{
+			QueueSize: connector.DefaultQueueSize,
+		}
+	}
+}
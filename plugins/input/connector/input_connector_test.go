@@ -0,0 +1,119 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connector
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	flusherconnector "github.com/alibaba/ilogtail/plugins/flusher/connector"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+type mockCollector struct {
+	logs     []*protocol.Log
+	contexts []map[string]interface{}
+	lock     sync.Mutex
+}
+
+func (c *mockCollector) AddData(tags map[string]string, fields map[string]string, t ...time.Time) {
+}
+
+func (c *mockCollector) AddDataArray(tags map[string]string, columns []string, values []string, t ...time.Time) {
+}
+
+func (c *mockCollector) AddRawLog(log *protocol.Log) {
+	c.AddRawLogWithContext(log, nil)
+}
+
+func (c *mockCollector) AddDataWithContext(tags map[string]string, fields map[string]string, ctx map[string]interface{}, t ...time.Time) {
+}
+
+func (c *mockCollector) AddDataArrayWithContext(tags map[string]string, columns []string, values []string, ctx map[string]interface{}, t ...time.Time) {
+}
+
+func (c *mockCollector) AddRawLogWithContext(log *protocol.Log, ctx map[string]interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.logs = append(c.logs, log)
+	c.contexts = append(c.contexts, ctx)
+}
+
+func (c *mockCollector) count() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.logs)
+}
+
+func mockLogGroup(value string) *protocol.LogGroup {
+	return &protocol.LogGroup{
+		Topic:  "access",
+		Source: "172.10.0.56",
+		Logs: []*protocol.Log{
+			{Time: 1662434209, Contents: []*protocol.Log_Content{{Key: "content", Value: value}}},
+		},
+		LogTags: []*protocol.LogTag{{Key: "__hostname__", Value: "host-1"}, {Key: "env", Value: "prod"}},
+	}
+}
+
+func TestConnector(t *testing.T) {
+	flusher := &flusherconnector.FlusherConnector{Name: "test_connector", QueueSize: 2, PushTimeoutMs: 10}
+	require.NoError(t, flusher.Init(mock.NewEmptyContext("p", "l", "upstream")))
+	input := &ServiceConnector{Name: "test_connector", QueueSize: 100}
+	_, err := input.Init(mock.NewEmptyContext("p", "l", "downstream"))
+	require.NoError(t, err)
+
+	// the capacity of the connector is decided by the flusher created first
+	logGroups := []*protocol.LogGroup{mockLogGroup("a"), {}, mockLogGroup("b")}
+	assert.True(t, flusher.IsReady("p", "l", 0))
+	require.NoError(t, flusher.Flush("p", "l", "upstream", logGroups))
+	assert.False(t, flusher.IsReady("p", "l", 0))
+	assert.Error(t, flusher.Flush("p", "l", "upstream", []*protocol.LogGroup{mockLogGroup("c")}))
+
+	collector := &mockCollector{}
+	go func() {
+		_ = input.Start(collector)
+	}()
+	require.Eventually(t, func() bool { return collector.count() == 2 }, time.Second, 10*time.Millisecond)
+	require.NoError(t, input.Stop())
+	assert.True(t, flusher.IsReady("p", "l", 0))
+
+	assert.Equal(t, []*protocol.Log_Content{
+		{Key: "content", Value: "a"},
+		{Key: "__log_topic__", Value: "access"},
+		{Key: "__tag__:env", Value: "prod"},
+	}, collector.logs[0].Contents)
+	assert.Equal(t, map[string]interface{}{"source": "172.10.0.56", "topic": "access"}, collector.contexts[0])
+	assert.Equal(t, "b", collector.logs[1].Contents[0].Value)
+	// the flushed LogGroups are copied, which are shared with the other flushers
+	assert.Len(t, logGroups[0].Logs[0].Contents, 1)
+
+	// the input is restarted by the hot standby
+	go func() {
+		_ = input.Start(collector)
+	}()
+	require.NoError(t, flusher.Flush("p", "l", "upstream", []*protocol.LogGroup{mockLogGroup("c")}))
+	require.Eventually(t, func() bool { return collector.count() == 3 }, time.Second, 10*time.Millisecond)
+	require.NoError(t, input.Stop())
+
+	// the Stop before Start stops the coming run
+	require.NoError(t, input.Stop())
+	require.NoError(t, input.Start(collector))
+}
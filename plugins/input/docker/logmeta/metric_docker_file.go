@@ -150,11 +150,11 @@ func (idf *InputDockerFile) Init(context pipeline.Context) (int, error) {
 	var err error
 	idf.IncludeEnv, idf.IncludeEnvRegex, err = helper.SplitRegexFromMap(idf.IncludeEnv)
 	if err != nil {
-		logger.Warning(idf.context.GetRuntimeContext(), "INVALID_REGEX_ALARM", "init include env regex error", err)
+		logger.Warning(idf.context.GetRuntimeContext(), util.AlarmInvalidRegex, "init include env regex error", err)
 	}
 	idf.ExcludeEnv, idf.ExcludeEnvRegex, err = helper.SplitRegexFromMap(idf.ExcludeEnv)
 	if err != nil {
-		logger.Warning(idf.context.GetRuntimeContext(), "INVALID_REGEX_ALARM", "init exclude env regex error", err)
+		logger.Warning(idf.context.GetRuntimeContext(), util.AlarmInvalidRegex, "init exclude env regex error", err)
 	}
 	if idf.IncludeLabel != nil {
 		for k, v := range idf.IncludeContainerLabel {
@@ -172,11 +172,11 @@ func (idf *InputDockerFile) Init(context pipeline.Context) (int, error) {
 	}
 	idf.IncludeLabel, idf.IncludeLabelRegex, err = helper.SplitRegexFromMap(idf.IncludeLabel)
 	if err != nil {
-		logger.Warning(idf.context.GetRuntimeContext(), "INVALID_REGEX_ALARM", "init include label regex error", err)
+		logger.Warning(idf.context.GetRuntimeContext(), util.AlarmInvalidRegex, "init include label regex error", err)
 	}
 	idf.ExcludeLabel, idf.ExcludeLabelRegex, err = helper.SplitRegexFromMap(idf.ExcludeLabel)
 	if err != nil {
-		logger.Warning(idf.context.GetRuntimeContext(), "INVALID_REGEX_ALARM", "init exclude label regex error", err)
+		logger.Warning(idf.context.GetRuntimeContext(), util.AlarmInvalidRegex, "init exclude label regex error", err)
 	}
 	idf.K8sFilter, err = helper.CreateK8SFilter(idf.K8sNamespaceRegex, idf.K8sPodRegex, idf.K8sContainerRegex, idf.IncludeK8sLabel, idf.ExcludeK8sLabel)
 
@@ -206,7 +206,7 @@ func (idf *InputDockerFile) addMappingToLogtail(info *helper.DockerInfoDetail, d
 		return
 	}
 	if err := logtail.ExecuteCMD(configName, PluginDockerUpdateFile, cmdBuf); err != nil {
-		logger.Error(idf.context.GetRuntimeContext(), "DOCKER_FILE_MAPPING_ALARM", "cmdType", PluginDockerUpdateFile, "cmd", cmdBuf, "error", err)
+		logger.Error(idf.context.GetRuntimeContext(), util.AlarmDockerFileMapping, "cmdType", PluginDockerUpdateFile, "cmd", cmdBuf, "error", err)
 	}
 }
 
@@ -217,7 +217,7 @@ func (idf *InputDockerFile) deleteMappingFromLogtail(id string) {
 	cmdBuf, _ := json.Marshal(&cmd)
 	configName := idf.context.GetConfigName()
 	if err := logtail.ExecuteCMD(configName, PluginDockerDeleteFile, cmdBuf); err != nil {
-		logger.Error(idf.context.GetRuntimeContext(), "DOCKER_FILE_MAPPING_ALARM", "cmdType", PluginDockerDeleteFile, "cmd", cmdBuf, "error", err)
+		logger.Error(idf.context.GetRuntimeContext(), util.AlarmDockerFileMapping, "cmdType", PluginDockerDeleteFile, "cmd", cmdBuf, "error", err)
 	}
 }
 
@@ -228,7 +228,7 @@ func (idf *InputDockerFile) notifyStopToLogtail(id string) {
 	cmdBuf, _ := json.Marshal(&cmd)
 	configName := idf.context.GetConfigName()
 	if err := logtail.ExecuteCMD(configName, PluginDockerStopFile, cmdBuf); err != nil {
-		logger.Error(idf.context.GetRuntimeContext(), "DOCKER_FILE_MAPPING_ALARM", "cmdType", PluginDockerStopFile, "cmd", cmdBuf, "error", err)
+		logger.Error(idf.context.GetRuntimeContext(), util.AlarmDockerFileMapping, "cmdType", PluginDockerStopFile, "cmd", cmdBuf, "error", err)
 	}
 }
 
@@ -237,7 +237,7 @@ func (idf *InputDockerFile) updateAll(allCmd *DockerFileUpdateCmdAll) {
 	cmdBuf, _ := json.Marshal(allCmd)
 	configName := idf.context.GetConfigName()
 	if err := logtail.ExecuteCMD(configName, PluginDockerUpdateFileAll, cmdBuf); err != nil {
-		logger.Error(idf.context.GetRuntimeContext(), "DOCKER_FILE_MAPPING_ALARM", "cmdType", PluginDockerUpdateFileAll, "cmd", cmdBuf, "error", err)
+		logger.Error(idf.context.GetRuntimeContext(), util.AlarmDockerFileMapping, "cmdType", PluginDockerUpdateFileAll, "cmd", cmdBuf, "error", err)
 	}
 }
 
@@ -353,7 +353,7 @@ func (idf *InputDockerFile) Collect(collector pipeline.Collector) error {
 				idf.updateMapping(info, sourcePath, containerPath, allCmd)
 			}
 		} else {
-			logger.Warning(idf.context.GetRuntimeContext(), "DOCKER_FILE_MATCH_ALARM", "unknow error", "can't find path from this container", "path", idf.LogPath, "container", info.ContainerInfo.Name)
+			logger.Warning(idf.context.GetRuntimeContext(), util.AlarmDockerFileMatch, "unknow error", "can't find path from this container", "path", idf.LogPath, "container", info.ContainerInfo.Name)
 		}
 	}
 
@@ -67,7 +67,7 @@ func NewDockerFileSyner(sds *ServiceDockerStdout,
 	var err error
 	if len(sds.BeginLineRegex) > 0 {
 		if reg, err = regexp.Compile(sds.BeginLineRegex); err != nil {
-			logger.Warning(sds.context.GetRuntimeContext(), "DOCKER_REGEX_COMPILE_ALARM", "compile begin line regex error, regex", sds.BeginLineRegex, "error", err)
+			logger.Warning(sds.context.GetRuntimeContext(), util.AlarmDockerRegexCompile, "compile begin line regex error, regex", sds.BeginLineRegex, "error", err)
 		}
 	}
 
@@ -87,11 +87,11 @@ func NewDockerFileSyner(sds *ServiceDockerStdout,
 		// first watch this container
 		stat, err := os.Stat(checkpoint.Path)
 		if err != nil {
-			logger.Warning(sds.context.GetRuntimeContext(), "DOCKER_STDOUT_STAT_ALARM", "stat log file error, path", checkpoint.Path, "error", err.Error())
+			logger.Warning(sds.context.GetRuntimeContext(), util.AlarmDockerStdoutStat, "stat log file error, path", checkpoint.Path, "error", err.Error())
 		} else {
 			checkpoint.Offset = stat.Size()
 			if checkpoint.Offset > sds.StartLogMaxOffset {
-				logger.Warning(sds.context.GetRuntimeContext(), "DOCKER_STDOUT_START_ALARM", "log file too big, path", checkpoint.Path, "offset", checkpoint.Offset)
+				logger.Warning(sds.context.GetRuntimeContext(), util.AlarmDockerStdoutStart, "log file too big, path", checkpoint.Path, "offset", checkpoint.Offset)
 				checkpoint.Offset -= sds.StartLogMaxOffset
 			} else {
 				checkpoint.Offset = 0
@@ -208,11 +208,11 @@ func (sds *ServiceDockerStdout) Init(context pipeline.Context) (int, error) {
 	var err error
 	sds.IncludeEnv, sds.IncludeEnvRegex, err = helper.SplitRegexFromMap(sds.IncludeEnv)
 	if err != nil {
-		logger.Warning(sds.context.GetRuntimeContext(), "INVALID_REGEX_ALARM", "init include env regex error", err)
+		logger.Warning(sds.context.GetRuntimeContext(), util.AlarmInvalidRegex, "init include env regex error", err)
 	}
 	sds.ExcludeEnv, sds.ExcludeEnvRegex, err = helper.SplitRegexFromMap(sds.ExcludeEnv)
 	if err != nil {
-		logger.Warning(sds.context.GetRuntimeContext(), "INVALID_REGEX_ALARM", "init exclude env regex error", err)
+		logger.Warning(sds.context.GetRuntimeContext(), util.AlarmInvalidRegex, "init exclude env regex error", err)
 	}
 	if sds.IncludeLabel != nil {
 		for k, v := range sds.IncludeContainerLabel {
@@ -230,12 +230,12 @@ func (sds *ServiceDockerStdout) Init(context pipeline.Context) (int, error) {
 	}
 	sds.IncludeLabel, sds.IncludeLabelRegex, err = helper.SplitRegexFromMap(sds.IncludeLabel)
 	if err != nil {
-		logger.Warning(sds.context.GetRuntimeContext(), "INVALID_REGEX_ALARM", "init include label regex error", err)
+		logger.Warning(sds.context.GetRuntimeContext(), util.AlarmInvalidRegex, "init include label regex error", err)
 	}
 	sds.ExcludeLabel, sds.ExcludeLabelRegex, err = helper.SplitRegexFromMap(sds.ExcludeLabel)
 
 	if err != nil {
-		logger.Warning(sds.context.GetRuntimeContext(), "INVALID_REGEX_ALARM", "init exclude label regex error", err)
+		logger.Warning(sds.context.GetRuntimeContext(), util.AlarmInvalidRegex, "init exclude label regex error", err)
 	}
 	sds.K8sFilter, err = helper.CreateK8SFilter(sds.K8sNamespaceRegex, sds.K8sPodRegex, sds.K8sContainerRegex, sds.IncludeK8sLabel, sds.ExcludeK8sLabel)
 
@@ -0,0 +1,249 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package l7
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+)
+
+// otherPath is the path of the requests exceeding MaxFlows.
+const otherPath = "_other"
+
+// redKey is the operation of a service, which is a process.
+type redKey struct {
+	pid      uint32
+	comm     string
+	role     string
+	protocol string
+	method   string
+	path     string
+}
+
+type redStats struct {
+	requests    uint64
+	errors      uint64
+	durationSum time.Duration
+	durationMax time.Duration
+}
+
+// redAggregator aggregates the requests into the rate, errors and duration of each operation in the
+// interval. It's not thread safe.
+type redAggregator struct {
+	maxFlows    int
+	resolveComm func(pid uint32) string
+
+	comms map[uint32]string
+	flows map[redKey]*redStats
+}
+
+func newREDAggregator(maxFlows int) *redAggregator {
+	return &redAggregator{
+		maxFlows:    maxFlows,
+		resolveComm: func(uint32) string { return "" },
+		comms:       make(map[uint32]string),
+		flows:       make(map[redKey]*redStats),
+	}
+}
+
+func (a *redAggregator) comm(pid uint32) string {
+	comm, ok := a.comms[pid]
+	if !ok {
+		comm = a.resolveComm(pid)
+		a.comms[pid] = comm
+	}
+	return comm
+}
+
+func (a *redAggregator) add(req *request) {
+	key := redKey{
+		pid:      req.pid,
+		comm:     a.comm(req.pid),
+		role:     req.role,
+		protocol: req.protocol,
+		method:   req.method,
+		path:     req.path,
+	}
+	stats, ok := a.flows[key]
+	if !ok {
+		// The paths with ids make too many flows.
+		if len(a.flows) >= a.maxFlows {
+			key.path = otherPath
+			stats, ok = a.flows[key]
+		}
+		if !ok {
+			stats = &redStats{}
+			a.flows[key] = stats
+		}
+	}
+	stats.requests++
+	if req.error {
+		stats.errors++
+	}
+	stats.durationSum += req.duration
+	if req.duration > stats.durationMax {
+		stats.durationMax = req.duration
+	}
+}
+
+// collect emits the metrics of the operations requested in the interval and resets them.
+func (a *redAggregator) collect(collector pipeline.Collector, now time.Time) {
+	for key, stats := range a.flows {
+		var kv helper.KeyValues
+		kv.Append("pid", strconv.FormatUint(uint64(key.pid), 10))
+		kv.Append("comm", key.comm)
+		kv.Append("role", key.role)
+		kv.Append("protocol", key.protocol)
+		kv.Append("method", key.method)
+		kv.Append("path", key.path)
+		kv.Sort()
+		labels := kv.String()
+		addMetric(collector, "l7_requests", labels, float64(stats.requests), now)
+		addMetric(collector, "l7_request_errors", labels, float64(stats.errors), now)
+		addMetric(collector, "l7_request_duration_avg_ms", labels, durationMs(stats.durationSum)/float64(stats.requests), now)
+		addMetric(collector, "l7_request_duration_max_ms", labels, durationMs(stats.durationMax), now)
+	}
+	a.flows = make(map[redKey]*redStats)
+	a.comms = make(map[uint32]string)
+}
+
+func addMetric(collector pipeline.Collector, name, labels string, value float64, now time.Time) {
+	keys, vals := helper.MakeMetric(name, labels, now.UnixNano(), value)
+	collector.AddDataArray(nil, keys, vals, now)
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// requestLog returns the fields of the log of a request.
+func requestLog(req *request, comm string) map[string]string {
+	return map[string]string{
+		"pid":         strconv.FormatUint(uint64(req.pid), 10),
+		"comm":        comm,
+		"role":        req.role,
+		"protocol":    req.protocol,
+		"method":      req.method,
+		"path":        req.path,
+		"status":      req.status,
+		"error":       strconv.FormatBool(req.error),
+		"duration_ms": strconv.FormatFloat(durationMs(req.duration), 'f', 3, 64),
+	}
+}
+
+// InputL7 parses the HTTP/1, HTTP/2 and gRPC requests of the selected processes from the data of
+// their socket syscalls captured by eBPF, and emits the rate, errors and duration (RED) metrics of
+// each operation and the sampled request logs.
+type InputL7 struct {
+	ProcessNames    []string `comment:"the regexes of the names (comm) of the processes to trace"`
+	Ports           []int    `comment:"trace the processes listening on the ports"`
+	IntervalSec     int      `comment:"the interval to emit the metrics and refresh the processes, 15 by default"`
+	MaxCaptureBytes int      `comment:"the max bytes captured from each syscall, 4096 by default"`
+	MaxConnections  int      `comment:"the max count of the tracked connections, 16384 by default"`
+	MaxFlows        int      `comment:"the max count of the operations in an interval, the paths of the others are _other, 1000 by default"`
+	PerCPUBufferKB  int      `comment:"the perf buffer size of each cpu for the data events, 1024 by default"`
+	SampleRate      float64  `comment:"the ratio of the requests to emit logs, 0.01 by default"`
+	LogErrors       bool     `comment:"emit logs for all failed requests, true by default"`
+
+	context      pipeline.Context
+	processRegex []*regexp.Regexp
+	shutdown     chan struct{}
+	wg           sync.WaitGroup
+}
+
+func (r *InputL7) Init(context pipeline.Context) (int, error) {
+	r.context = context
+	r.shutdown = make(chan struct{})
+	if len(r.ProcessNames) == 0 && len(r.Ports) == 0 {
+		return 0, errors.New("either ProcessNames or Ports is required to select the processes to trace")
+	}
+	r.processRegex = r.processRegex[:0]
+	for _, name := range r.ProcessNames {
+		reg, err := regexp.Compile(name)
+		if err != nil {
+			return 0, fmt.Errorf("invalid process name regex %q: %v", name, err)
+		}
+		r.processRegex = append(r.processRegex, reg)
+	}
+	if r.IntervalSec <= 0 {
+		r.IntervalSec = 15
+	}
+	if r.MaxCaptureBytes <= 0 {
+		r.MaxCaptureBytes = 4096
+	} else if r.MaxCaptureBytes > 16384 {
+		r.MaxCaptureBytes = 16384
+	}
+	if r.MaxConnections <= 0 {
+		r.MaxConnections = 16384
+	}
+	if r.MaxFlows <= 0 {
+		r.MaxFlows = 1000
+	}
+	if r.PerCPUBufferKB <= 0 {
+		r.PerCPUBufferKB = 1024
+	}
+	return 0, nil
+}
+
+func (r *InputL7) Description() string {
+	return "parse the http and grpc requests of the processes by eBPF and collect the RED metrics (only linux 4.14+)"
+}
+
+func (r *InputL7) Collect(collector pipeline.Collector) error {
+	return nil
+}
+
+func (r *InputL7) Stop() error {
+	close(r.shutdown)
+	r.wg.Wait()
+	return nil
+}
+
+// matchProcess tells if the process of @comm is selected by name.
+func (r *InputL7) matchProcess(comm string) bool {
+	for _, reg := range r.processRegex {
+		if reg.MatchString(comm) {
+			return true
+		}
+	}
+	return false
+}
+
+// sampled tells if a log should be emitted for the request.
+func (r *InputL7) sampled(req *request) bool {
+	return (req.error && r.LogErrors) || (r.SampleRate > 0 && rand.Float64() < r.SampleRate) //nolint:gosec
+}
+
+func init() {
+	pipeline.ServiceInputs["service_ebpf_l7"] = func() pipeline.ServiceInput {
+		return &InputL7{
+			IntervalSec:     15,
+			MaxCaptureBytes: 4096,
+			MaxConnections:  16384,
+			MaxFlows:        1000,
+			PerCPUBufferKB:  1024,
+			SampleRate:      0.01,
+			LogErrors:       true,
+		}
+	}
+}
@@ -0,0 +1,444 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package l7
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	cilium "github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/asm"
+	"github.com/cilium/ebpf/perf"
+
+	"github.com/alibaba/ilogtail/helper/ebpf"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	// bpfCurrentCPU is BPF_F_CURRENT_CPU of bpf_perf_event_output.
+	bpfCurrentCPU  = 0xffffffff
+	maxTargets     = 4096
+	maxPendingRead = 10240
+	// idleTimeout is the time to forget the connections without data, which may be closed.
+	idleTimeout = 5 * time.Minute
+)
+
+// syscallProbe is a syscall reading or writing the data of a socket, and the fields of the tracepoint
+// at its entry.
+type syscallProbe struct {
+	name      string
+	fd        string
+	buf       string
+	size      string
+	direction int
+}
+
+var syscallProbes = []syscallProbe{
+	{name: "write", fd: "fd", buf: "buf", size: "count", direction: directionEgress},
+	{name: "sendto", fd: "fd", buf: "buff", size: "len", direction: directionEgress},
+	{name: "read", fd: "fd", buf: "buf", size: "count", direction: directionIngress},
+	{name: "recvfrom", fd: "fd", buf: "ubuf", size: "size", direction: directionIngress},
+}
+
+// programs are the maps shared by the programs of the syscalls.
+type programs struct {
+	module    *ebpf.Module
+	events    *cilium.Map
+	targets   *cilium.Map
+	reads     *cilium.Map
+	scratch   *cilium.Map
+	capture   int32
+	probeRead asm.BuiltinFunc
+}
+
+func (r *InputL7) Start(collector pipeline.Collector) error {
+	r.wg.Add(1)
+	defer r.wg.Done()
+	progs, err := r.load()
+	if err != nil {
+		logger.Error(r.context.GetRuntimeContext(), util.AlarmEBPF, "load ebpf programs error", err)
+		return err
+	}
+	defer progs.module.Close() //nolint:errcheck
+	reader, err := perf.NewReader(progs.events, r.PerCPUBufferKB*1024)
+	if err != nil {
+		logger.Error(r.context.GetRuntimeContext(), util.AlarmEBPF, "create perf reader error", err)
+		return err
+	}
+	defer reader.Close() //nolint:errcheck
+
+	records := make(chan perf.Record, 1024)
+	go func() {
+		defer close(records)
+		for {
+			record, err := reader.Read()
+			if err != nil {
+				if perf.IsClosed(err) {
+					return
+				}
+				logger.Warning(r.context.GetRuntimeContext(), util.AlarmEBPF, "read perf buffer error", err)
+				continue
+			}
+			select {
+			case records <- record:
+			case <-r.shutdown:
+				return
+			}
+		}
+	}()
+
+	aggregator := newREDAggregator(r.MaxFlows)
+	aggregator.resolveComm = readComm
+	tracker := newTracker(r.MaxConnections, func(req *request) {
+		aggregator.add(req)
+		if r.sampled(req) {
+			collector.AddData(nil, requestLog(req, aggregator.comm(req.pid)), time.Now())
+		}
+	})
+	tracker.isSocket = isSocket
+	targets := make(map[uint32]bool)
+	r.syncTargets(progs.targets, targets)
+	ticker := time.NewTicker(time.Duration(r.IntervalSec) * time.Second)
+	defer ticker.Stop()
+	var lost uint64
+	for {
+		select {
+		case <-r.shutdown:
+			return nil
+		case record, ok := <-records:
+			if !ok {
+				return nil
+			}
+			if record.LostSamples > 0 {
+				lost += record.LostSamples
+				continue
+			}
+			if ev, err := decodeDataEvent(record.RawSample); err == nil {
+				tracker.handle(ev)
+			}
+		case now := <-ticker.C:
+			if lost > 0 {
+				logger.Warning(r.context.GetRuntimeContext(), util.AlarmEBPF, "data events lost", lost, "increase", "PerCPUBufferKB")
+				lost = 0
+			}
+			aggregator.collect(collector, now)
+			tracker.expire(idleTimeout)
+			r.syncTargets(progs.targets, targets)
+		}
+	}
+}
+
+// load attaches the programs to the syscalls available, and fails only if none of them is attached.
+// The user memory is read by bpf_probe_read on the kernels before 5.5.
+func (r *InputL7) load() (progs *programs, err error) {
+	if err = ebpf.CheckSupport(4, 14); err != nil {
+		return nil, err
+	}
+	version, err := ebpf.CurrentKernelVersion()
+	if err != nil {
+		return nil, err
+	}
+	module, err := ebpf.NewModule()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = module.Close()
+		}
+	}()
+	progs = &programs{module: module, capture: int32(r.MaxCaptureBytes), probeRead: ebpf.ProbeReadUser(version)}
+	if progs.events, err = module.NewMap(&cilium.MapSpec{Name: "l7_events", Type: cilium.PerfEventArray}); err != nil {
+		return nil, err
+	}
+	if progs.targets, err = module.NewMap(&cilium.MapSpec{Name: "l7_targets", Type: cilium.Hash, KeySize: 4, ValueSize: 1, MaxEntries: maxTargets}); err != nil {
+		return nil, err
+	}
+	// The buffers and fds of the pending reads by the thread ids.
+	if progs.reads, err = module.NewMap(&cilium.MapSpec{Name: "l7_reads", Type: cilium.LRUHash, KeySize: 8, ValueSize: 16, MaxEntries: maxPendingRead}); err != nil {
+		return nil, err
+	}
+	// The event is too large for the stack.
+	if progs.scratch, err = module.NewMap(&cilium.MapSpec{
+		Name:       "l7_scratch",
+		Type:       cilium.PerCPUArray,
+		KeySize:    4,
+		ValueSize:  uint32(dataEventHeaderSize + r.MaxCaptureBytes),
+		MaxEntries: 1,
+	}); err != nil {
+		return nil, err
+	}
+	attached := 0
+	for _, probe := range syscallProbes {
+		if probeErr := progs.attach(probe); probeErr != nil {
+			logger.Warning(r.context.GetRuntimeContext(), util.AlarmEBPF, "syscall not traced", probe.name, "error", probeErr)
+			continue
+		}
+		attached++
+	}
+	if attached == 0 {
+		return nil, fmt.Errorf("%w: no syscall is traced", ebpf.ErrNotSupported)
+	}
+	logger.Info(r.context.GetRuntimeContext(), "ebpf l7 loaded, kernel", version, "probe read", progs.probeRead, "syscalls", attached)
+	return progs, nil
+}
+
+func (p *programs) attach(probe syscallProbe) error {
+	enter, err := ebpf.ReadTraceEventFormat("syscalls", "sys_enter_"+probe.name)
+	if err != nil {
+		return err
+	}
+	fields := make(map[string]ebpf.TraceEventField)
+	for _, name := range []string{probe.fd, probe.buf, probe.size} {
+		field, ok := enter.Field(name)
+		if !ok {
+			return fmt.Errorf("field %s not found in tracepoint sys_enter_%s", name, probe.name)
+		}
+		fields[name] = field
+	}
+	if probe.direction == directionEgress {
+		insns := p.targetCheck()
+		insns = append(insns, asm.LoadMem(asm.R7, asm.R6, fields[probe.size].Offset, asm.DWord))
+		insns = append(insns, p.emitData(probe.direction,
+			asm.LoadMem(asm.R1, asm.R6, fields[probe.fd].Offset, asm.DWord),
+			asm.LoadMem(asm.R3, asm.R6, fields[probe.buf].Offset, asm.DWord))...)
+		return p.module.AttachTracepoint("syscalls", "sys_enter_"+probe.name, insns)
+	}
+
+	// The data is read into the buffer when the syscall exits.
+	exit, err := ebpf.ReadTraceEventFormat("syscalls", "sys_exit_"+probe.name)
+	if err != nil {
+		return err
+	}
+	ret, ok := exit.Field("ret")
+	if !ok {
+		return fmt.Errorf("field ret not found in tracepoint sys_exit_%s", probe.name)
+	}
+	insns := p.targetCheck()
+	insns = append(insns,
+		asm.StoreMem(asm.RFP, -16, asm.R8, asm.DWord),
+		asm.LoadMem(asm.R1, asm.R6, fields[probe.buf].Offset, asm.DWord),
+		asm.StoreMem(asm.RFP, -32, asm.R1, asm.DWord),
+		asm.LoadMem(asm.R1, asm.R6, fields[probe.fd].Offset, asm.DWord),
+		asm.StoreMem(asm.RFP, -24, asm.R1, asm.DWord),
+		asm.LoadMapPtr(asm.R1, p.reads.FD()),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, -16),
+		asm.Mov.Reg(asm.R3, asm.RFP),
+		asm.Add.Imm(asm.R3, -32),
+		asm.Mov.Imm(asm.R4, 0),
+		asm.FnMapUpdateElem.Call(),
+	)
+	if err = p.module.AttachTracepoint("syscalls", "sys_enter_"+probe.name, insns); err != nil {
+		return err
+	}
+	insns = asm.Instructions{
+		asm.Mov.Reg(asm.R6, asm.R1),
+		asm.FnGetCurrentPidTgid.Call(),
+		asm.Mov.Reg(asm.R8, asm.R0),
+		asm.StoreMem(asm.RFP, -16, asm.R8, asm.DWord),
+		asm.LoadMapPtr(asm.R1, p.reads.FD()),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, -16),
+		asm.FnMapLookupElem.Call(),
+		asm.JEq.Imm(asm.R0, 0, ebpf.ExitLabel),
+		asm.LoadMem(asm.R1, asm.R0, 0, asm.DWord),
+		asm.StoreMem(asm.RFP, -32, asm.R1, asm.DWord),
+		asm.LoadMem(asm.R1, asm.R0, 8, asm.DWord),
+		asm.StoreMem(asm.RFP, -24, asm.R1, asm.DWord),
+		asm.LoadMapPtr(asm.R1, p.reads.FD()),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, -16),
+		asm.FnMapDeleteElem.Call(),
+		asm.LoadMem(asm.R7, asm.R6, ret.Offset, asm.DWord),
+	}
+	insns = append(insns, p.emitData(probe.direction,
+		asm.LoadMem(asm.R1, asm.RFP, -24, asm.DWord),
+		asm.LoadMem(asm.R3, asm.RFP, -32, asm.DWord))...)
+	return p.module.AttachTracepoint("syscalls", "sys_exit_"+probe.name, insns)
+}
+
+// targetCheck saves the context to R6 and the pid_tgid to R8, and exits if the process isn't traced.
+func (p *programs) targetCheck() asm.Instructions {
+	return asm.Instructions{
+		asm.Mov.Reg(asm.R6, asm.R1),
+		asm.FnGetCurrentPidTgid.Call(),
+		asm.Mov.Reg(asm.R8, asm.R0),
+		asm.Mov.Reg(asm.R1, asm.R8),
+		asm.RSh.Imm(asm.R1, 32),
+		asm.StoreMem(asm.RFP, -4, asm.R1, asm.Word),
+		asm.LoadMapPtr(asm.R1, p.targets.FD()),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, -4),
+		asm.FnMapLookupElem.Call(),
+		asm.JEq.Imm(asm.R0, 0, ebpf.ExitLabel),
+	}
+}
+
+// emitData outputs the data event of the layout decoded by decodeDataEvent, with the context in R6,
+// the pid_tgid in R8 and the size in R7. @loadFD loads the fd to R1 and @loadBuf loads the buffer to R3.
+func (p *programs) emitData(direction int, loadFD, loadBuf asm.Instruction) asm.Instructions {
+	insns := asm.Instructions{
+		asm.StoreImm(asm.RFP, -8, 0, asm.Word),
+		asm.LoadMapPtr(asm.R1, p.scratch.FD()),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, -8),
+		asm.FnMapLookupElem.Call(),
+		asm.JEq.Imm(asm.R0, 0, ebpf.ExitLabel),
+		asm.Mov.Reg(asm.R9, asm.R0),
+		asm.FnKtimeGetNs.Call(),
+		asm.StoreMem(asm.R9, 0, asm.R0, asm.DWord),
+		asm.StoreMem(asm.R9, 8, asm.R8, asm.DWord),
+		loadFD,
+		asm.StoreMem(asm.R9, 16, asm.R1, asm.Word),
+		asm.StoreImm(asm.R9, 20, int64(direction), asm.Word),
+		asm.StoreMem(asm.R9, 24, asm.R7, asm.Word),
+	}
+	insns = append(insns, ebpf.ClampSize(asm.R7, p.capture, "clamped")...)
+	return append(insns,
+		asm.StoreMem(asm.R9, 28, asm.R7, asm.Word),
+		asm.Mov.Reg(asm.R1, asm.R9),
+		asm.Add.Imm(asm.R1, dataEventHeaderSize),
+		asm.Mov.Reg(asm.R2, asm.R7),
+		loadBuf,
+		p.probeRead.Call(),
+		asm.Mov.Reg(asm.R1, asm.R6),
+		asm.LoadMapPtr(asm.R2, p.events.FD()),
+		asm.LoadImm(asm.R3, bpfCurrentCPU, asm.DWord),
+		asm.Mov.Reg(asm.R4, asm.R9),
+		asm.Mov.Reg(asm.R5, asm.R7),
+		asm.Add.Imm(asm.R5, dataEventHeaderSize),
+		asm.FnPerfEventOutput.Call(),
+	)
+}
+
+// syncTargets updates the traced processes in the kernel to the ones selected now.
+func (r *InputL7) syncTargets(bpfMap *cilium.Map, targets map[uint32]bool) {
+	current := r.discoverTargets()
+	for pid := range targets {
+		if !current[pid] {
+			_ = bpfMap.Delete(pid)
+			delete(targets, pid)
+		}
+	}
+	for pid := range current {
+		if targets[pid] {
+			continue
+		}
+		if len(targets) >= maxTargets {
+			logger.Warning(r.context.GetRuntimeContext(), util.AlarmEBPF, "too many processes to trace, max", maxTargets)
+			break
+		}
+		if err := bpfMap.Put(pid, uint8(1)); err != nil {
+			logger.Warning(r.context.GetRuntimeContext(), util.AlarmEBPF, "trace process error", err)
+			continue
+		}
+		targets[pid] = true
+	}
+}
+
+// discoverTargets selects the processes by name, or by the listening ports in their network namespaces.
+func (r *InputL7) discoverTargets() map[uint32]bool {
+	targets := make(map[uint32]bool)
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return targets
+	}
+	ports := make(map[int]bool, len(r.Ports))
+	for _, port := range r.Ports {
+		ports[port] = true
+	}
+	namespaceInodes := make(map[string]map[uint64]bool)
+	self := uint64(os.Getpid())
+	for _, entry := range entries {
+		pid, err := strconv.ParseUint(entry.Name(), 10, 32)
+		// The data of the agent itself is never traced, which generates more events.
+		if err != nil || pid == self {
+			continue
+		}
+		if r.matchProcess(readComm(uint32(pid))) {
+			targets[uint32(pid)] = true
+			continue
+		}
+		if len(ports) == 0 {
+			continue
+		}
+		procPath := filepath.Join("/proc", entry.Name())
+		netns, err := os.Readlink(filepath.Join(procPath, "ns", "net"))
+		if err != nil {
+			continue
+		}
+		inodes, ok := namespaceInodes[netns]
+		if !ok {
+			inodes = make(map[uint64]bool)
+			for _, file := range []string{"tcp", "tcp6"} {
+				if f, err := os.Open(filepath.Join(procPath, "net", file)); err == nil {
+					_ = parseListeningInodes(f, ports, inodes)
+					_ = f.Close()
+				}
+			}
+			namespaceInodes[netns] = inodes
+		}
+		if len(inodes) > 0 && ownsSocket(procPath, inodes) {
+			targets[uint32(pid)] = true
+		}
+	}
+	return targets
+}
+
+func ownsSocket(procPath string, inodes map[uint64]bool) bool {
+	fds, err := os.ReadDir(filepath.Join(procPath, "fd"))
+	if err != nil {
+		return false
+	}
+	for _, fd := range fds {
+		link, err := os.Readlink(filepath.Join(procPath, "fd", fd.Name()))
+		if err != nil {
+			continue
+		}
+		if inode, ok := parseSocketInode(link); ok && inodes[inode] {
+			return true
+		}
+	}
+	return false
+}
+
+// isSocket tells if the fd of the process is a socket. The fd may be closed before the data is
+// handled for the short connections, which is taken as a socket.
+func isSocket(pid, fd uint32) bool {
+	link, err := os.Readlink("/proc/" + strconv.FormatUint(uint64(pid), 10) + "/fd/" + strconv.FormatUint(uint64(fd), 10))
+	if err != nil {
+		return true
+	}
+	_, ok := parseSocketInode(link)
+	return ok
+}
+
+func readComm(pid uint32) string {
+	comm, err := os.ReadFile("/proc/" + strconv.FormatUint(uint64(pid), 10) + "/comm")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(comm))
+}
@@ -0,0 +1,30 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+// +build !linux
+
+package l7
+
+import (
+	"github.com/alibaba/ilogtail/helper/ebpf"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+func (r *InputL7) Start(collector pipeline.Collector) error {
+	logger.Error(r.context.GetRuntimeContext(), util.AlarmEBPF, "start l7 error", ebpf.ErrNotSupported)
+	return ebpf.ErrNotSupported
+}
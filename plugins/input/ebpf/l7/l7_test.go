@@ -0,0 +1,104 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package l7
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/plugins/test"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+func metricValues(c *test.MockMetricCollector) map[string]map[string]string {
+	values := make(map[string]map[string]string)
+	for _, log := range c.Logs {
+		var name, labels, value string
+		for _, content := range log.Contents {
+			switch content.Key {
+			case "__name__":
+				name = content.Value
+			case "__labels__":
+				labels = content.Value
+			case "__value__":
+				value = content.Value
+			}
+		}
+		if values[labels] == nil {
+			values[labels] = make(map[string]string)
+		}
+		values[labels][name] = value
+	}
+	return values
+}
+
+func TestREDAggregator(t *testing.T) {
+	aggregator := newREDAggregator(2)
+	aggregator.resolveComm = func(pid uint32) string { return "nginx" }
+	aggregator.add(&request{pid: 1, role: roleServer, protocol: protocolHTTP1, method: "GET", path: "/a", status: "200", duration: 10 * time.Millisecond})
+	aggregator.add(&request{pid: 1, role: roleServer, protocol: protocolHTTP1, method: "GET", path: "/a", status: "500", error: true, duration: 30 * time.Millisecond})
+	aggregator.add(&request{pid: 1, role: roleServer, protocol: protocolHTTP1, method: "GET", path: "/b", status: "200", duration: 5 * time.Millisecond})
+	// the flows exceeding the limit are merged into _other
+	aggregator.add(&request{pid: 1, role: roleServer, protocol: protocolHTTP1, method: "GET", path: "/c", status: "200", duration: 1 * time.Millisecond})
+	aggregator.add(&request{pid: 1, role: roleServer, protocol: protocolHTTP1, method: "GET", path: "/d", status: "200", duration: 3 * time.Millisecond})
+
+	collector := &test.MockMetricCollector{}
+	aggregator.collect(collector, time.Now())
+	values := metricValues(collector)
+	require.Len(t, values, 3)
+	assert.Equal(t, map[string]string{
+		"l7_requests":                "2",
+		"l7_request_errors":          "1",
+		"l7_request_duration_avg_ms": "20",
+		"l7_request_duration_max_ms": "30",
+	}, values["comm#$#nginx|method#$#GET|path#$#/a|pid#$#1|protocol#$#http1|role#$#server"])
+	assert.Equal(t, map[string]string{
+		"l7_requests":                "2",
+		"l7_request_errors":          "0",
+		"l7_request_duration_avg_ms": "2",
+		"l7_request_duration_max_ms": "3",
+	}, values["comm#$#nginx|method#$#GET|path#$#_other|pid#$#1|protocol#$#http1|role#$#server"])
+
+	collector = &test.MockMetricCollector{}
+	aggregator.collect(collector, time.Now())
+	assert.Empty(t, collector.Logs)
+}
+
+func TestInputL7Init(t *testing.T) {
+	input := &InputL7{}
+	_, err := input.Init(mock.NewEmptyContext("project", "store", "config"))
+	assert.Error(t, err)
+
+	input = &InputL7{ProcessNames: []string{"("}}
+	_, err = input.Init(mock.NewEmptyContext("project", "store", "config"))
+	assert.Error(t, err)
+
+	input = &InputL7{ProcessNames: []string{"^nginx$", "java"}, MaxCaptureBytes: 100000}
+	_, err = input.Init(mock.NewEmptyContext("project", "store", "config"))
+	require.NoError(t, err)
+	assert.Equal(t, 16384, input.MaxCaptureBytes)
+	assert.Equal(t, 15, input.IntervalSec)
+	assert.True(t, input.matchProcess("nginx"))
+	assert.True(t, input.matchProcess("java-app"))
+	assert.False(t, input.matchProcess("nginx-worker"))
+
+	input.LogErrors = true
+	input.SampleRate = 0
+	assert.True(t, input.sampled(&request{error: true}))
+	assert.False(t, input.sampled(&request{}))
+}
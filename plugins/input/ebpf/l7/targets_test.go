@@ -0,0 +1,49 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package l7
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseListeningInodes(t *testing.T) {
+	content := `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1001 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:0CEA 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1002 1 0000000000000000 100 0 0 10 0
+   2: 0100007F:1F90 0100007F:C350 01 00000000:00000000 00:00000000 00000000     0        0 1003 1 0000000000000000 20 4 30 10 -1
+`
+	content6 := `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:1F91 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 2001 1 0000000000000000 100 0 0 10 0
+`
+	inodes := make(map[uint64]bool)
+	ports := map[int]bool{8080: true, 8081: true}
+	require.NoError(t, parseListeningInodes(strings.NewReader(content), ports, inodes))
+	require.NoError(t, parseListeningInodes(strings.NewReader(content6), ports, inodes))
+	assert.Equal(t, map[uint64]bool{1001: true, 2001: true}, inodes)
+}
+
+func TestParseSocketInode(t *testing.T) {
+	inode, ok := parseSocketInode("socket:[12345]")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(12345), inode)
+	_, ok = parseSocketInode("pipe:[12345]")
+	assert.False(t, ok)
+	_, ok = parseSocketInode("/var/log/messages")
+	assert.False(t, ok)
+}
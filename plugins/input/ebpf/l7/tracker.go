@@ -0,0 +1,501 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package l7
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http2/hpack"
+)
+
+const (
+	directionEgress  = 0
+	directionIngress = 1
+
+	protocolHTTP1 = "http1"
+	protocolHTTP2 = "http2"
+	protocolGRPC  = "grpc"
+
+	roleClient = "client"
+	roleServer = "server"
+
+	// dataEventHeaderSize is the size of the header of the data event sent by the kernel, the layout is
+	// ts(8) pid_tgid(8) fd(4) direction(4) size(4) captured(4), followed by the captured data.
+	dataEventHeaderSize = 32
+
+	maxPendingRequests   = 16
+	maxStreams           = 1024
+	maxHeaderBlockSize   = 64 << 10
+	http2FrameHeaderSize = 9
+)
+
+// The HTTP/2 frame types and flags.
+const (
+	frameData         = 0x0
+	frameHeaders      = 0x1
+	frameRSTStream    = 0x3
+	frameContinuation = 0x9
+
+	flagEndStream  = 0x1
+	flagEndHeaders = 0x4
+	flagPadded     = 0x8
+	flagPriority   = 0x20
+)
+
+var http2Preface = []byte("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n")
+
+var http1Methods = []string{"GET", "POST", "PUT", "DELETE", "HEAD", "OPTIONS", "PATCH", "CONNECT", "TRACE"}
+
+// dataEvent is the data read from or written to a socket by a syscall, only the prefix of the data
+// is captured if it's larger than the capture size.
+type dataEvent struct {
+	ts        uint64
+	pid       uint32
+	fd        uint32
+	direction int
+	size      int
+	data      []byte
+}
+
+// decodeDataEvent decodes the event in the byte order of the supported architectures, which are all
+// little endian.
+func decodeDataEvent(raw []byte) (*dataEvent, error) {
+	if len(raw) < dataEventHeaderSize {
+		return nil, errors.New("data event too short")
+	}
+	le := binary.LittleEndian
+	captured := int(le.Uint32(raw[28:]))
+	if captured > len(raw)-dataEventHeaderSize {
+		return nil, errors.New("data event truncated")
+	}
+	return &dataEvent{
+		ts:        le.Uint64(raw[0:]),
+		pid:       uint32(le.Uint64(raw[8:]) >> 32),
+		fd:        le.Uint32(raw[16:]),
+		direction: int(le.Uint32(raw[20:])),
+		size:      int(le.Uint32(raw[24:])),
+		data:      raw[dataEventHeaderSize : dataEventHeaderSize+captured],
+	}, nil
+}
+
+// request is a request completed by its response.
+type request struct {
+	pid      uint32
+	role     string
+	protocol string
+	method   string
+	path     string
+	status   string
+	error    bool
+	duration time.Duration
+}
+
+type pendingRequest struct {
+	ts         uint64
+	direction  int
+	method     string
+	path       string
+	grpc       bool
+	status     string
+	grpcStatus string
+}
+
+func (p *pendingRequest) role() string {
+	if p.direction == directionIngress {
+		return roleServer
+	}
+	return roleClient
+}
+
+type connKey struct {
+	pid uint32
+	fd  uint32
+}
+
+type connection struct {
+	protocol   string
+	lastActive uint64
+
+	// HTTP/1, the requests waiting for the responses in order.
+	pending []*pendingRequest
+
+	// HTTP/2, the frames, the header compression contexts and the header blocks being continued of
+	// each direction.
+	readers      [2]frameReader
+	decoders     [2]*hpack.Decoder
+	headerBlocks [2][]byte
+	headerStream [2]uint32
+	headerFlags  [2]byte
+	streams      map[uint32]*pendingRequest
+}
+
+// tracker tracks the connections of the traced processes by their data, and parses the requests of
+// HTTP/1, HTTP/2 and gRPC. The connections are only tracked from the beginning of a request or an
+// HTTP/2 connection. It's not thread safe.
+type tracker struct {
+	maxConnections int
+	isSocket       func(pid, fd uint32) bool
+	onRequest      func(*request)
+
+	conns  map[connKey]*connection
+	lastTs uint64
+}
+
+func newTracker(maxConnections int, onRequest func(*request)) *tracker {
+	return &tracker{
+		maxConnections: maxConnections,
+		isSocket:       func(pid, fd uint32) bool { return true },
+		onRequest:      onRequest,
+		conns:          make(map[connKey]*connection),
+	}
+}
+
+func detectProtocol(data []byte) string {
+	if bytes.HasPrefix(data, http2Preface) {
+		return protocolHTTP2
+	}
+	if _, _, ok := parseHTTP1Request(data); ok {
+		return protocolHTTP1
+	}
+	return ""
+}
+
+func (t *tracker) handle(ev *dataEvent) {
+	if ev.direction != directionEgress && ev.direction != directionIngress {
+		return
+	}
+	t.lastTs = ev.ts
+	key := connKey{pid: ev.pid, fd: ev.fd}
+	c, ok := t.conns[key]
+	protocol := detectProtocol(ev.data)
+	switch {
+	case !ok && protocol == "":
+		return
+	case !ok:
+		if len(t.conns) >= t.maxConnections || !t.isSocket(ev.pid, ev.fd) {
+			return
+		}
+		c = &connection{protocol: protocol}
+		t.conns[key] = c
+	case protocol == protocolHTTP2:
+		// The fd is reused or upgraded to HTTP/2.
+		*c = connection{protocol: protocol}
+	}
+	c.lastActive = ev.ts
+	if c.protocol == protocolHTTP1 {
+		t.handleHTTP1(c, ev)
+	} else {
+		t.handleHTTP2(c, ev)
+	}
+}
+
+// expire removes the connections idle for @idle, including the closed ones.
+func (t *tracker) expire(idle time.Duration) {
+	for key, c := range t.conns {
+		if t.lastTs-c.lastActive > uint64(idle) {
+			delete(t.conns, key)
+		}
+	}
+}
+
+func (t *tracker) complete(ev *dataEvent, p *pendingRequest, protocol, status string, isError bool) {
+	var duration time.Duration
+	if ev.ts > p.ts {
+		duration = time.Duration(ev.ts - p.ts)
+	}
+	t.onRequest(&request{
+		pid:      ev.pid,
+		role:     p.role(),
+		protocol: protocol,
+		method:   p.method,
+		path:     p.path,
+		status:   status,
+		error:    isError,
+		duration: duration,
+	})
+}
+
+// parseHTTP1Request parses the request line like "GET /index.html?a=b HTTP/1.1", the query is removed
+// from the path.
+func parseHTTP1Request(data []byte) (method, path string, ok bool) {
+	line := data
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	} else {
+		return "", "", false
+	}
+	parts := strings.Split(strings.TrimSuffix(string(line), "\r"), " ")
+	if len(parts) != 3 || !strings.HasPrefix(parts[2], "HTTP/1.") {
+		return "", "", false
+	}
+	for _, m := range http1Methods {
+		if parts[0] == m {
+			path = parts[1]
+			if i := strings.IndexByte(path, '?'); i >= 0 {
+				path = path[:i]
+			}
+			return m, path, true
+		}
+	}
+	return "", "", false
+}
+
+// parseHTTP1Response parses the status of the status line like "HTTP/1.1 200 OK".
+func parseHTTP1Response(data []byte) (status string, ok bool) {
+	if len(data) < 12 || !bytes.HasPrefix(data, []byte("HTTP/1.")) || data[8] != ' ' {
+		return "", false
+	}
+	status = string(data[9:12])
+	if _, err := strconv.Atoi(status); err != nil {
+		return "", false
+	}
+	return status, true
+}
+
+func (t *tracker) handleHTTP1(c *connection, ev *dataEvent) {
+	if method, path, ok := parseHTTP1Request(ev.data); ok {
+		if len(c.pending) >= maxPendingRequests {
+			c.pending = c.pending[1:]
+		}
+		c.pending = append(c.pending, &pendingRequest{ts: ev.ts, direction: ev.direction, method: method, path: path})
+		return
+	}
+	status, ok := parseHTTP1Response(ev.data)
+	// The interim responses like 100 Continue are followed by the final one.
+	if !ok || status[0] == '1' || len(c.pending) == 0 || c.pending[0].direction == ev.direction {
+		return
+	}
+	p := c.pending[0]
+	c.pending = c.pending[1:]
+	t.complete(ev, p, protocolHTTP1, status, status[0] == '5')
+}
+
+func (t *tracker) handleHTTP2(c *connection, ev *dataEvent) {
+	data := ev.data
+	if bytes.HasPrefix(data, http2Preface) {
+		data = data[len(http2Preface):]
+	}
+	c.readers[ev.direction].feed(data, ev.size-len(ev.data), func(f *http2Frame) {
+		t.handleFrame(c, ev, f)
+	})
+}
+
+func (t *tracker) handleFrame(c *connection, ev *dataEvent, f *http2Frame) {
+	dir := ev.direction
+	switch f.typ {
+	case frameHeaders:
+		block := f.payload
+		if f.flags&flagPadded != 0 {
+			if len(block) == 0 || int(block[0]) >= len(block) {
+				return
+			}
+			block = block[1 : len(block)-int(block[0])]
+		}
+		if f.flags&flagPriority != 0 {
+			if len(block) < 5 {
+				return
+			}
+			block = block[5:]
+		}
+		if f.flags&flagEndHeaders == 0 {
+			c.headerBlocks[dir] = append([]byte(nil), block...)
+			c.headerStream[dir] = f.stream
+			c.headerFlags[dir] = f.flags
+			return
+		}
+		t.handleHeaders(c, ev, f.stream, block, f.flags&flagEndStream != 0)
+	case frameContinuation:
+		if c.headerBlocks[dir] == nil || c.headerStream[dir] != f.stream {
+			return
+		}
+		c.headerBlocks[dir] = append(c.headerBlocks[dir], f.payload...)
+		if len(c.headerBlocks[dir]) > maxHeaderBlockSize {
+			c.headerBlocks[dir] = nil
+			return
+		}
+		if f.flags&flagEndHeaders != 0 {
+			block := c.headerBlocks[dir]
+			c.headerBlocks[dir] = nil
+			t.handleHeaders(c, ev, f.stream, block, c.headerFlags[dir]&flagEndStream != 0)
+		}
+	case frameData:
+		if p := c.streams[f.stream]; p != nil && f.flags&flagEndStream != 0 && p.direction != dir && p.status != "" {
+			t.completeStream(c, ev, f.stream, p)
+		}
+	case frameRSTStream:
+		delete(c.streams, f.stream)
+	}
+}
+
+func (t *tracker) handleHeaders(c *connection, ev *dataEvent, stream uint32, block []byte, endStream bool) {
+	dir := ev.direction
+	if c.decoders[dir] == nil {
+		c.decoders[dir] = hpack.NewDecoder(4096, nil)
+	}
+	fields, err := c.decoders[dir].DecodeFull(block)
+	if err != nil {
+		// The compression context is lost with the data not captured, the following headers
+		// referring to it are likely broken too.
+		c.decoders[dir] = nil
+		return
+	}
+	var method, path, status, grpcStatus, contentType string
+	for _, field := range fields {
+		switch field.Name {
+		case ":method":
+			method = field.Value
+		case ":path":
+			path = field.Value
+		case ":status":
+			status = field.Value
+		case "grpc-status":
+			grpcStatus = field.Value
+		case "content-type":
+			contentType = field.Value
+		}
+	}
+	if method != "" {
+		if c.streams == nil {
+			c.streams = make(map[uint32]*pendingRequest)
+		}
+		if len(c.streams) >= maxStreams {
+			return
+		}
+		if i := strings.IndexByte(path, '?'); i >= 0 {
+			path = path[:i]
+		}
+		c.streams[stream] = &pendingRequest{
+			ts:        ev.ts,
+			direction: dir,
+			method:    method,
+			path:      path,
+			grpc:      strings.HasPrefix(contentType, "application/grpc"),
+		}
+		return
+	}
+	p := c.streams[stream]
+	if p == nil || p.direction == dir {
+		return
+	}
+	if status != "" {
+		p.status = status
+	}
+	if grpcStatus != "" {
+		p.grpcStatus = grpcStatus
+	}
+	if endStream {
+		t.completeStream(c, ev, stream, p)
+	}
+}
+
+// completeStream completes the HTTP/2 request, the error of gRPC is a non-OK grpc-status, which is
+// missing when the server fails before processing the call.
+func (t *tracker) completeStream(c *connection, ev *dataEvent, stream uint32, p *pendingRequest) {
+	delete(c.streams, stream)
+	if !p.grpc {
+		t.complete(ev, p, protocolHTTP2, p.status, strings.HasPrefix(p.status, "5"))
+		return
+	}
+	if p.grpcStatus == "" {
+		t.complete(ev, p, protocolGRPC, "http_"+p.status, true)
+		return
+	}
+	t.complete(ev, p, protocolGRPC, p.grpcStatus, p.grpcStatus != "0")
+}
+
+type http2Frame struct {
+	typ     byte
+	flags   byte
+	stream  uint32
+	payload []byte
+}
+
+// frameReader splits the data of a direction into HTTP/2 frames. Only the payloads of the header
+// frames are kept, and it's broken if a gap of the data not captured falls into a header frame or a
+// frame header, for the frame boundaries are lost.
+type frameReader struct {
+	buf    []byte
+	skip   int
+	broken bool
+}
+
+func (r *frameReader) feed(data []byte, gap int, onFrame func(*http2Frame)) {
+	for len(data) > 0 && !r.broken {
+		if r.skip > 0 {
+			n := minInt(r.skip, len(data))
+			r.skip -= n
+			data = data[n:]
+			continue
+		}
+		if len(r.buf) < http2FrameHeaderSize {
+			n := minInt(http2FrameHeaderSize-len(r.buf), len(data))
+			r.buf = append(r.buf, data[:n]...)
+			data = data[n:]
+			if len(r.buf) < http2FrameHeaderSize {
+				break
+			}
+			if typ := r.buf[3]; typ != frameHeaders && typ != frameContinuation {
+				onFrame(r.frame())
+				r.skip = r.length()
+				r.buf = r.buf[:0]
+				continue
+			}
+			if r.length() > maxHeaderBlockSize {
+				r.broken = true
+				break
+			}
+		}
+		n := minInt(http2FrameHeaderSize+r.length()-len(r.buf), len(data))
+		r.buf = append(r.buf, data[:n]...)
+		data = data[n:]
+		if len(r.buf) == http2FrameHeaderSize+r.length() {
+			f := r.frame()
+			f.payload = r.buf[http2FrameHeaderSize:]
+			onFrame(f)
+			r.buf = r.buf[:0]
+		}
+	}
+	if gap > 0 && !r.broken {
+		if len(r.buf) == 0 && r.skip >= gap {
+			r.skip -= gap
+		} else {
+			r.broken = true
+		}
+	}
+}
+
+func (r *frameReader) length() int {
+	return int(r.buf[0])<<16 | int(r.buf[1])<<8 | int(r.buf[2])
+}
+
+func (r *frameReader) frame() *http2Frame {
+	return &http2Frame{
+		typ:    r.buf[3],
+		flags:  r.buf[4],
+		stream: binary.BigEndian.Uint32(r.buf[5:9]) & 0x7fffffff,
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,224 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package l7
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+type requestRecorder struct {
+	requests []*request
+}
+
+func (r *requestRecorder) add(req *request) {
+	r.requests = append(r.requests, req)
+}
+
+func newDataEvent(ts time.Duration, fd uint32, direction int, data []byte) *dataEvent {
+	return &dataEvent{ts: uint64(ts), pid: 100, fd: fd, direction: direction, size: len(data), data: data}
+}
+
+func TestDecodeDataEvent(t *testing.T) {
+	raw := make([]byte, dataEventHeaderSize+8)
+	le := binary.LittleEndian
+	le.PutUint64(raw[0:], 1000)
+	le.PutUint64(raw[8:], 100<<32|101)
+	le.PutUint32(raw[16:], 7)
+	le.PutUint32(raw[20:], directionIngress)
+	le.PutUint32(raw[24:], 10000)
+	le.PutUint32(raw[28:], 4)
+	copy(raw[dataEventHeaderSize:], "GET /")
+
+	ev, err := decodeDataEvent(raw)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1000), ev.ts)
+	assert.Equal(t, uint32(100), ev.pid)
+	assert.Equal(t, uint32(7), ev.fd)
+	assert.Equal(t, directionIngress, ev.direction)
+	assert.Equal(t, 10000, ev.size)
+	assert.Equal(t, []byte("GET "), ev.data)
+
+	_, err = decodeDataEvent(raw[:dataEventHeaderSize-1])
+	assert.Error(t, err)
+	le.PutUint32(raw[28:], 9)
+	_, err = decodeDataEvent(raw)
+	assert.Error(t, err)
+}
+
+func TestParseHTTP1(t *testing.T) {
+	method, path, ok := parseHTTP1Request([]byte("POST /api/users?id=1 HTTP/1.1\r\nHost: a\r\n\r\n"))
+	assert.True(t, ok)
+	assert.Equal(t, "POST", method)
+	assert.Equal(t, "/api/users", path)
+	_, _, ok = parseHTTP1Request([]byte("FOO /api HTTP/1.1\r\n"))
+	assert.False(t, ok)
+	_, _, ok = parseHTTP1Request([]byte("GET /api HTTP/1.1"))
+	assert.False(t, ok)
+
+	status, ok := parseHTTP1Response([]byte("HTTP/1.1 404 Not Found\r\n"))
+	assert.True(t, ok)
+	assert.Equal(t, "404", status)
+	_, ok = parseHTTP1Response([]byte("HTTP/1.1 abc\r\n"))
+	assert.False(t, ok)
+}
+
+func TestTrackerHTTP1(t *testing.T) {
+	recorder := &requestRecorder{}
+	tr := newTracker(10, recorder.add)
+
+	// server, pipelined requests answered in order
+	tr.handle(newDataEvent(1*time.Millisecond, 3, directionIngress, []byte("GET /a HTTP/1.1\r\n\r\n")))
+	tr.handle(newDataEvent(2*time.Millisecond, 3, directionIngress, []byte("GET /b HTTP/1.1\r\n\r\n")))
+	tr.handle(newDataEvent(3*time.Millisecond, 3, directionEgress, []byte("HTTP/1.1 100 Continue\r\n\r\n")))
+	tr.handle(newDataEvent(4*time.Millisecond, 3, directionEgress, []byte("HTTP/1.1 200 OK\r\n\r\n")))
+	tr.handle(newDataEvent(6*time.Millisecond, 3, directionEgress, []byte("HTTP/1.1 503 Unavailable\r\n\r\n")))
+	// client
+	tr.handle(newDataEvent(10*time.Millisecond, 4, directionEgress, []byte("PUT /c HTTP/1.1\r\n\r\n")))
+	tr.handle(newDataEvent(12*time.Millisecond, 4, directionIngress, []byte("HTTP/1.1 201 Created\r\n\r\n")))
+	// the data of the unknown connections is ignored
+	tr.handle(newDataEvent(13*time.Millisecond, 5, directionIngress, []byte("HTTP/1.1 200 OK\r\n\r\n")))
+
+	require.Len(t, recorder.requests, 3)
+	assert.Equal(t, &request{pid: 100, role: roleServer, protocol: protocolHTTP1, method: "GET", path: "/a", status: "200", duration: 3 * time.Millisecond}, recorder.requests[0])
+	assert.Equal(t, &request{pid: 100, role: roleServer, protocol: protocolHTTP1, method: "GET", path: "/b", status: "503", error: true, duration: 4 * time.Millisecond}, recorder.requests[1])
+	assert.Equal(t, &request{pid: 100, role: roleClient, protocol: protocolHTTP1, method: "PUT", path: "/c", status: "201", duration: 2 * time.Millisecond}, recorder.requests[2])
+	assert.Len(t, tr.conns, 2)
+
+	tr.handle(newDataEvent(10*time.Minute, 6, directionIngress, []byte("GET /d HTTP/1.1\r\n\r\n")))
+	tr.expire(time.Minute)
+	assert.Len(t, tr.conns, 1)
+}
+
+func TestTrackerLimits(t *testing.T) {
+	recorder := &requestRecorder{}
+	tr := newTracker(1, recorder.add)
+	tr.isSocket = func(pid, fd uint32) bool { return fd != 3 }
+	tr.handle(newDataEvent(1, 3, directionIngress, []byte("GET /a HTTP/1.1\r\n\r\n")))
+	tr.handle(newDataEvent(1, 4, directionIngress, []byte("GET /a HTTP/1.1\r\n\r\n")))
+	tr.handle(newDataEvent(1, 5, directionIngress, []byte("GET /a HTTP/1.1\r\n\r\n")))
+	assert.Len(t, tr.conns, 1)
+	assert.Contains(t, tr.conns, connKey{pid: 100, fd: 4})
+}
+
+type http2Writer struct {
+	buf     bytes.Buffer
+	framer  *http2.Framer
+	headers bytes.Buffer
+	encoder *hpack.Encoder
+}
+
+func newHTTP2Writer() *http2Writer {
+	w := &http2Writer{}
+	w.framer = http2.NewFramer(&w.buf, nil)
+	w.encoder = hpack.NewEncoder(&w.headers)
+	return w
+}
+
+func (w *http2Writer) writeHeaders(t *testing.T, stream uint32, endStream bool, fields ...string) {
+	w.headers.Reset()
+	for i := 0; i < len(fields); i += 2 {
+		require.NoError(t, w.encoder.WriteField(hpack.HeaderField{Name: fields[i], Value: fields[i+1]}))
+	}
+	require.NoError(t, w.framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      stream,
+		BlockFragment: w.headers.Bytes(),
+		EndStream:     endStream,
+		EndHeaders:    true,
+	}))
+}
+
+func (w *http2Writer) flush() []byte {
+	data := append([]byte(nil), w.buf.Bytes()...)
+	w.buf.Reset()
+	return data
+}
+
+func TestTrackerGRPC(t *testing.T) {
+	recorder := &requestRecorder{}
+	tr := newTracker(10, recorder.add)
+	client, server := newHTTP2Writer(), newHTTP2Writer()
+
+	// the client of the server process
+	client.buf.Write(http2Preface)
+	require.NoError(t, client.framer.WriteSettings())
+	client.writeHeaders(t, 1, false, ":method", "POST", ":path", "/helloworld.Greeter/SayHello", "content-type", "application/grpc")
+	require.NoError(t, client.framer.WriteData(1, true, []byte("hello")))
+	client.writeHeaders(t, 3, false, ":method", "POST", ":path", "/helloworld.Greeter/SayHello", "content-type", "application/grpc")
+	require.NoError(t, client.framer.WriteData(3, true, []byte("hello")))
+	client.writeHeaders(t, 5, true, ":method", "GET", ":path", "/healthz?full=1")
+	tr.handle(newDataEvent(1*time.Millisecond, 3, directionIngress, client.flush()))
+
+	server.writeHeaders(t, 1, false, ":status", "200", "content-type", "application/grpc")
+	require.NoError(t, server.framer.WriteData(1, false, []byte("world")))
+	server.writeHeaders(t, 1, true, "grpc-status", "0")
+	server.writeHeaders(t, 3, true, ":status", "200", "content-type", "application/grpc", "grpc-status", "5")
+	tr.handle(newDataEvent(3*time.Millisecond, 3, directionEgress, server.flush()))
+	server.writeHeaders(t, 5, false, ":status", "500")
+	require.NoError(t, server.framer.WriteData(5, true, []byte("error")))
+	tr.handle(newDataEvent(4*time.Millisecond, 3, directionEgress, server.flush()))
+
+	require.Len(t, recorder.requests, 3)
+	assert.Equal(t, &request{pid: 100, role: roleServer, protocol: protocolGRPC, method: "POST", path: "/helloworld.Greeter/SayHello", status: "0", duration: 2 * time.Millisecond}, recorder.requests[0])
+	assert.Equal(t, &request{pid: 100, role: roleServer, protocol: protocolGRPC, method: "POST", path: "/helloworld.Greeter/SayHello", status: "5", error: true, duration: 2 * time.Millisecond}, recorder.requests[1])
+	assert.Equal(t, &request{pid: 100, role: roleServer, protocol: protocolHTTP2, method: "GET", path: "/healthz", status: "500", error: true, duration: 3 * time.Millisecond}, recorder.requests[2])
+}
+
+func TestTrackerHTTP2Split(t *testing.T) {
+	recorder := &requestRecorder{}
+	tr := newTracker(10, recorder.add)
+	client, server := newHTTP2Writer(), newHTTP2Writer()
+
+	client.buf.Write(http2Preface)
+	client.writeHeaders(t, 1, true, ":method", "GET", ":path", "/a")
+	data := client.flush()
+	// the frames are split across the syscalls
+	tr.handle(newDataEvent(1, 7, directionEgress, data[:len(http2Preface)+4]))
+	tr.handle(newDataEvent(2, 7, directionEgress, data[len(http2Preface)+4:]))
+
+	// the data of the response not captured is skipped by the frame lengths
+	server.writeHeaders(t, 1, false, ":status", "200")
+	require.NoError(t, server.framer.WriteData(1, false, make([]byte, 100)))
+	data = server.flush()
+	ev := newDataEvent(3, 7, directionIngress, data[:len(data)-90])
+	ev.size = len(data)
+	tr.handle(ev)
+	require.NoError(t, server.framer.WriteData(1, true, nil))
+	tr.handle(newDataEvent(5, 7, directionIngress, server.flush()))
+
+	require.Len(t, recorder.requests, 1)
+	assert.Equal(t, &request{pid: 100, role: roleClient, protocol: protocolHTTP2, method: "GET", path: "/a", status: "200", duration: 3}, recorder.requests[0])
+}
+
+func TestFrameReaderGap(t *testing.T) {
+	w := newHTTP2Writer()
+	w.writeHeaders(t, 1, true, ":method", "GET", ":path", "/a")
+	data := w.flush()
+
+	var frames []*http2Frame
+	var r frameReader
+	// the gap falls into a header frame
+	r.feed(data[:5], len(data)-5, func(f *http2Frame) { frames = append(frames, f) })
+	assert.True(t, r.broken)
+	r.feed(data, 0, func(f *http2Frame) { frames = append(frames, f) })
+	assert.Empty(t, frames)
+}
@@ -0,0 +1,414 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netflow
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+)
+
+// The TCP states of the kernel.
+const (
+	tcpEstablished = 1
+	tcpSynSent     = 2
+	tcpSynRecv     = 3
+	tcpFinWait1    = 4
+	tcpClose       = 7
+)
+
+const (
+	afInet6 = 10
+
+	roleClient  = "client"
+	roleServer  = "server"
+	roleUnknown = "unknown"
+)
+
+// connEventSize is the size of the connection event sent by the kernel, the layout is
+// ts(8) sk(8) pid_tgid(8) oldstate(4) newstate(4) sport(2) dport(2) family(2) pad(2)
+// saddr(4) daddr(4) saddr_v6(16) daddr_v6(16) comm(16).
+const connEventSize = 96
+
+// connEvent is a TCP state change of a socket.
+type connEvent struct {
+	ts         uint64
+	sk         uint64
+	pid        uint32
+	oldState   uint32
+	newState   uint32
+	localPort  uint16
+	remotePort uint16
+	localIP    net.IP
+	remoteIP   net.IP
+	comm       string
+}
+
+// decodeConnEvent decodes the event in the byte order of the supported architectures, which are all
+// little endian.
+func decodeConnEvent(data []byte) (*connEvent, error) {
+	if len(data) < connEventSize {
+		return nil, errors.New("connection event too short")
+	}
+	le := binary.LittleEndian
+	ev := &connEvent{
+		ts:         le.Uint64(data[0:]),
+		sk:         le.Uint64(data[8:]),
+		pid:        uint32(le.Uint64(data[16:]) >> 32),
+		oldState:   le.Uint32(data[24:]),
+		newState:   le.Uint32(data[28:]),
+		localPort:  le.Uint16(data[32:]),
+		remotePort: le.Uint16(data[34:]),
+	}
+	if le.Uint16(data[36:]) == afInet6 {
+		ev.localIP = net.IP(append([]byte(nil), data[48:64]...))
+		ev.remoteIP = net.IP(append([]byte(nil), data[64:80]...))
+	} else {
+		ev.localIP = net.IPv4(data[40], data[41], data[42], data[43])
+		ev.remoteIP = net.IPv4(data[44], data[45], data[46], data[47])
+	}
+	comm := data[80:96]
+	for i, c := range comm {
+		if c == 0 {
+			comm = comm[:i]
+			break
+		}
+	}
+	ev.comm = string(comm)
+	return ev, nil
+}
+
+// traffic is the value of a socket in the traffic map, accumulated by the kernel.
+type traffic struct {
+	BytesSent     uint64
+	BytesReceived uint64
+	Retransmits   uint64
+	Pid           uint32
+	Padding       uint32
+}
+
+// trafficTable is the traffic map keyed by the socket address.
+type trafficTable interface {
+	lookup(sk uint64) (traffic, bool)
+	delete(sk uint64)
+}
+
+type connection struct {
+	pid         uint32
+	comm        string
+	role        string
+	localIP     net.IP
+	remoteIP    net.IP
+	localPort   uint16
+	remotePort  uint16
+	synSent     uint64
+	established uint64
+	rtt         time.Duration
+	counted     bool
+	// last is the traffic attributed to the flows.
+	last traffic
+}
+
+// port is the remote port for the clients and the listening port for the servers.
+func (c *connection) key() flowKey {
+	port := c.remotePort
+	if c.role == roleServer {
+		port = c.localPort
+	}
+	return flowKey{pid: c.pid, comm: c.comm, role: c.role, remoteIP: c.remoteIP.String(), port: port}
+}
+
+// flowKey is a process talking with a destination.
+type flowKey struct {
+	pid      uint32
+	comm     string
+	role     string
+	remoteIP string
+	port     uint16
+}
+
+type flowStats struct {
+	connects      uint64
+	connectFails  uint64
+	closes        uint64
+	bytesSent     uint64
+	bytesReceived uint64
+	retransmits   uint64
+	rttSum        time.Duration
+	rttCount      int
+}
+
+// flowAggregator tracks the connections by the state changes and aggregates their traffic into the
+// flows of each interval. It's not thread safe.
+type flowAggregator struct {
+	table          trafficTable
+	resolveComm    func(pid uint32) string
+	maxConnections int
+	ignoreLoopback bool
+
+	conns map[uint64]*connection
+	flows map[flowKey]*flowStats
+}
+
+func newFlowAggregator(table trafficTable, maxConnections int, ignoreLoopback bool) *flowAggregator {
+	return &flowAggregator{
+		table:          table,
+		resolveComm:    func(uint32) string { return "" },
+		maxConnections: maxConnections,
+		ignoreLoopback: ignoreLoopback,
+		conns:          make(map[uint64]*connection),
+		flows:          make(map[flowKey]*flowStats),
+	}
+}
+
+func (a *flowAggregator) track(ev *connEvent) *connection {
+	if c, ok := a.conns[ev.sk]; ok {
+		return c
+	}
+	if len(a.conns) >= a.maxConnections {
+		return nil
+	}
+	c := newConnection(ev)
+	a.conns[ev.sk] = c
+	return c
+}
+
+func newConnection(ev *connEvent) *connection {
+	return &connection{
+		role:       roleUnknown,
+		localIP:    ev.localIP,
+		remoteIP:   ev.remoteIP,
+		localPort:  ev.localPort,
+		remotePort: ev.remotePort,
+	}
+}
+
+// handle updates the connections by the event, and returns the connection closed by it.
+func (a *flowAggregator) handle(ev *connEvent) *connection {
+	if a.ignoreLoopback && ev.remoteIP.IsLoopback() {
+		if ev.newState == tcpClose {
+			a.table.delete(ev.sk)
+		}
+		return nil
+	}
+	switch ev.newState {
+	case tcpSynSent:
+		// The connect is called in the process context, unlike the passive opens in softirq.
+		if c := a.track(ev); c != nil {
+			c.role = roleClient
+			c.pid = ev.pid
+			c.comm = ev.comm
+			c.synSent = ev.ts
+		}
+	case tcpEstablished:
+		c := a.track(ev)
+		if c == nil {
+			return nil
+		}
+		switch ev.oldState {
+		case tcpSynSent:
+			c.role = roleClient
+			if c.synSent > 0 {
+				c.rtt = time.Duration(ev.ts - c.synSent)
+			}
+		case tcpSynRecv:
+			c.role = roleServer
+		}
+		// The local address of the client is bound after SYN_SENT.
+		c.localIP, c.localPort = ev.localIP, ev.localPort
+		c.established = ev.ts
+	case tcpClose:
+		c, ok := a.conns[ev.sk]
+		if !ok {
+			// The connections established before starting.
+			c = newConnection(ev)
+		}
+		delete(a.conns, ev.sk)
+		stats := a.attribute(ev.sk, c, true)
+		stats.closes++
+		return c
+	}
+	return nil
+}
+
+// attribute adds the connection and its traffic since the last attribution to the flow stats. The
+// connections with the process unknown yet or nothing new are skipped unless @final.
+func (a *flowAggregator) attribute(sk uint64, c *connection, final bool) *flowStats {
+	t, ok := a.table.lookup(sk)
+	if final {
+		a.table.delete(sk)
+	}
+	if !ok {
+		// Nothing is sent or received yet, or the socket is evicted from the map.
+		t = c.last
+	}
+	if c.pid == 0 && t.Pid != 0 {
+		c.pid = t.Pid
+	}
+	if !final && (c.pid == 0 || (c.counted && t == c.last)) {
+		return nil
+	}
+	if c.comm == "" && c.pid != 0 {
+		c.comm = a.resolveComm(c.pid)
+	}
+	key := c.key()
+	stats, exist := a.flows[key]
+	if !exist {
+		stats = &flowStats{}
+		a.flows[key] = stats
+	}
+	if !c.counted {
+		c.counted = true
+		switch {
+		case c.established > 0:
+			stats.connects++
+			if c.rtt > 0 {
+				stats.rttSum += c.rtt
+				stats.rttCount++
+			}
+		case c.role == roleClient:
+			stats.connectFails++
+		}
+	}
+	stats.bytesSent += counterDelta(t.BytesSent, c.last.BytesSent)
+	stats.bytesReceived += counterDelta(t.BytesReceived, c.last.BytesReceived)
+	stats.retransmits += counterDelta(t.Retransmits, c.last.Retransmits)
+	c.last = t
+	return stats
+}
+
+// counterDelta tolerates the counters restarting from 0 after the socket is evicted from the map.
+func counterDelta(current, last uint64) uint64 {
+	if current < last {
+		return current
+	}
+	return current - last
+}
+
+// collect emits the metrics of the flows active in the interval and resets them.
+func (a *flowAggregator) collect(collector pipeline.Collector, now time.Time) {
+	for sk, c := range a.conns {
+		a.attribute(sk, c, false)
+	}
+	for key, stats := range a.flows {
+		var kv helper.KeyValues
+		kv.Append("pid", strconv.FormatUint(uint64(key.pid), 10))
+		kv.Append("comm", key.comm)
+		kv.Append("role", key.role)
+		kv.Append("remote_ip", key.remoteIP)
+		kv.Append("port", strconv.Itoa(int(key.port)))
+		kv.Sort()
+		labels := kv.String()
+		addMetric(collector, "net_flow_connects", labels, float64(stats.connects), now)
+		addMetric(collector, "net_flow_connect_fails", labels, float64(stats.connectFails), now)
+		addMetric(collector, "net_flow_closes", labels, float64(stats.closes), now)
+		addMetric(collector, "net_flow_bytes_sent", labels, float64(stats.bytesSent), now)
+		addMetric(collector, "net_flow_bytes_received", labels, float64(stats.bytesReceived), now)
+		addMetric(collector, "net_flow_retransmits", labels, float64(stats.retransmits), now)
+		if stats.rttCount > 0 {
+			addMetric(collector, "net_flow_rtt_ms", labels, float64(stats.rttSum.Microseconds())/1000/float64(stats.rttCount), now)
+		}
+	}
+	a.flows = make(map[flowKey]*flowStats)
+}
+
+func addMetric(collector pipeline.Collector, name, labels string, value float64, now time.Time) {
+	keys, vals := helper.MakeMetric(name, labels, now.UnixNano(), value)
+	collector.AddDataArray(nil, keys, vals, now)
+}
+
+// connectionLog returns the fields of the log of a closed connection.
+func connectionLog(c *connection, closed uint64) map[string]string {
+	fields := map[string]string{
+		"pid":            strconv.FormatUint(uint64(c.pid), 10),
+		"comm":           c.comm,
+		"role":           c.role,
+		"local_ip":       c.localIP.String(),
+		"local_port":     strconv.Itoa(int(c.localPort)),
+		"remote_ip":      c.remoteIP.String(),
+		"remote_port":    strconv.Itoa(int(c.remotePort)),
+		"bytes_sent":     strconv.FormatUint(c.last.BytesSent, 10),
+		"bytes_received": strconv.FormatUint(c.last.BytesReceived, 10),
+		"retransmits":    strconv.FormatUint(c.last.Retransmits, 10),
+	}
+	if c.rtt > 0 {
+		fields["rtt_ms"] = strconv.FormatFloat(float64(c.rtt.Microseconds())/1000, 'f', 3, 64)
+	}
+	if c.established > 0 && closed > c.established {
+		fields["duration_ms"] = strconv.FormatUint((closed-c.established)/uint64(time.Millisecond), 10)
+	}
+	return fields
+}
+
+// InputNetFlow collects the TCP flows of the host by eBPF. The connects, closes and retransmits are
+// traced by the tracepoints, and the bytes by the kprobes of tcp_sendmsg and tcp_cleanup_rbuf. The
+// flows are aggregated by process and destination, and the RTT is the latency of the handshake.
+type InputNetFlow struct {
+	IntervalSec    int  `comment:"the interval to emit the flow metrics, 15 by default"`
+	MaxConnections int  `comment:"the max count of the tracked connections, 65536 by default"`
+	PerCPUBufferKB int  `comment:"the perf buffer size of each cpu for the connection events, 64 by default"`
+	LogConnections bool `comment:"emit a log for each closed connection"`
+	IgnoreLoopback bool `comment:"ignore the connections to the loopback addresses, true by default"`
+
+	context  pipeline.Context
+	shutdown chan struct{}
+	wg       sync.WaitGroup
+}
+
+func (r *InputNetFlow) Init(context pipeline.Context) (int, error) {
+	r.context = context
+	r.shutdown = make(chan struct{})
+	if r.IntervalSec <= 0 {
+		r.IntervalSec = 15
+	}
+	if r.MaxConnections <= 0 {
+		r.MaxConnections = 65536
+	}
+	if r.PerCPUBufferKB <= 0 {
+		r.PerCPUBufferKB = 64
+	}
+	return 0, nil
+}
+
+func (r *InputNetFlow) Description() string {
+	return "collect the tcp flow metrics of the processes by eBPF (only linux 4.16+)"
+}
+
+func (r *InputNetFlow) Collect(collector pipeline.Collector) error {
+	return nil
+}
+
+func (r *InputNetFlow) Stop() error {
+	close(r.shutdown)
+	r.wg.Wait()
+	return nil
+}
+
+func init() {
+	pipeline.ServiceInputs["service_ebpf_netflow"] = func() pipeline.ServiceInput {
+		return &InputNetFlow{
+			IntervalSec:    15,
+			MaxConnections: 65536,
+			PerCPUBufferKB: 64,
+			IgnoreLoopback: true,
+		}
+	}
+}
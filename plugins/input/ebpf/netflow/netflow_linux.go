@@ -0,0 +1,308 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package netflow
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	cilium "github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/asm"
+	"github.com/cilium/ebpf/perf"
+
+	"github.com/alibaba/ilogtail/helper/ebpf"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	ipprotoTCP = 6
+	// bpfCurrentCPU is BPF_F_CURRENT_CPU of bpf_perf_event_output.
+	bpfCurrentCPU = 0xffffffff
+	trafficSize   = 32
+)
+
+type mapTrafficTable struct {
+	bpfMap *cilium.Map
+}
+
+func (t *mapTrafficTable) lookup(sk uint64) (traffic, bool) {
+	var value traffic
+	if err := t.bpfMap.Lookup(sk, &value); err != nil {
+		return value, false
+	}
+	return value, true
+}
+
+func (t *mapTrafficTable) delete(sk uint64) {
+	_ = t.bpfMap.Delete(sk)
+}
+
+func readComm(pid uint32) string {
+	comm, err := os.ReadFile("/proc/" + strconv.FormatUint(uint64(pid), 10) + "/comm")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(comm))
+}
+
+func (r *InputNetFlow) Start(collector pipeline.Collector) error {
+	r.wg.Add(1)
+	defer r.wg.Done()
+	module, events, table, err := r.load()
+	if err != nil {
+		logger.Error(r.context.GetRuntimeContext(), util.AlarmEBPF, "load ebpf programs error", err)
+		return err
+	}
+	defer module.Close() //nolint:errcheck
+	reader, err := perf.NewReader(events, r.PerCPUBufferKB*1024)
+	if err != nil {
+		logger.Error(r.context.GetRuntimeContext(), util.AlarmEBPF, "create perf reader error", err)
+		return err
+	}
+	defer reader.Close() //nolint:errcheck
+
+	records := make(chan perf.Record, 1024)
+	go func() {
+		defer close(records)
+		for {
+			record, err := reader.Read()
+			if err != nil {
+				if perf.IsClosed(err) {
+					return
+				}
+				logger.Warning(r.context.GetRuntimeContext(), util.AlarmEBPF, "read perf buffer error", err)
+				continue
+			}
+			select {
+			case records <- record:
+			case <-r.shutdown:
+				return
+			}
+		}
+	}()
+
+	aggregator := newFlowAggregator(table, r.MaxConnections, r.IgnoreLoopback)
+	aggregator.resolveComm = readComm
+	ticker := time.NewTicker(time.Duration(r.IntervalSec) * time.Second)
+	defer ticker.Stop()
+	var lost uint64
+	for {
+		select {
+		case <-r.shutdown:
+			return nil
+		case record, ok := <-records:
+			if !ok {
+				return nil
+			}
+			if record.LostSamples > 0 {
+				lost += record.LostSamples
+				continue
+			}
+			ev, err := decodeConnEvent(record.RawSample)
+			if err != nil {
+				continue
+			}
+			if c := aggregator.handle(ev); c != nil && r.LogConnections {
+				collector.AddData(nil, connectionLog(c, ev.ts), time.Now())
+			}
+		case now := <-ticker.C:
+			if lost > 0 {
+				logger.Warning(r.context.GetRuntimeContext(), util.AlarmEBPF, "connection events lost", lost, "increase", "PerCPUBufferKB")
+				lost = 0
+			}
+			aggregator.collect(collector, now)
+		}
+	}
+}
+
+// load attaches the programs, the bytes are not collected if the kprobes are unavailable, such as the
+// functions are inlined.
+func (r *InputNetFlow) load() (module *ebpf.Module, events *cilium.Map, table trafficTable, err error) {
+	if err = ebpf.CheckSupport(4, 16); err != nil {
+		return nil, nil, nil, err
+	}
+	stateFormat, err := ebpf.ReadTraceEventFormat("sock", "inet_sock_set_state")
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("read tracepoint format error: %v", err)
+	}
+	if module, err = ebpf.NewModule(); err != nil {
+		return nil, nil, nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = module.Close()
+		}
+	}()
+	if events, err = module.NewMap(&cilium.MapSpec{Name: "netflow_events", Type: cilium.PerfEventArray}); err != nil {
+		return
+	}
+	trafficMap, err := module.NewMap(&cilium.MapSpec{
+		Name:       "netflow_traffic",
+		Type:       cilium.LRUHash,
+		KeySize:    8,
+		ValueSize:  trafficSize,
+		MaxEntries: uint32(r.MaxConnections),
+	})
+	if err != nil {
+		return
+	}
+	table = &mapTrafficTable{bpfMap: trafficMap}
+
+	insns, err := stateProgram(stateFormat, events)
+	if err != nil {
+		return
+	}
+	if err = module.AttachTracepoint("sock", "inet_sock_set_state", insns); err != nil {
+		return
+	}
+	if retransmitFormat, retransmitErr := ebpf.ReadTraceEventFormat("tcp", "tcp_retransmit_skb"); retransmitErr == nil {
+		if insns, retransmitErr = retransmitProgram(retransmitFormat, trafficMap); retransmitErr == nil {
+			retransmitErr = module.AttachTracepoint("tcp", "tcp_retransmit_skb", insns)
+		}
+		if retransmitErr != nil {
+			logger.Warning(r.context.GetRuntimeContext(), util.AlarmEBPF, "retransmits are not collected", retransmitErr)
+		}
+	}
+	for _, probe := range []struct {
+		symbol string
+		arg    int
+		offset int16
+	}{{"tcp_sendmsg", 2, 0}, {"tcp_cleanup_rbuf", 1, 8}} {
+		probeInsns, probeErr := bytesProgram(trafficMap, probe.arg, probe.offset)
+		if probeErr == nil {
+			probeErr = module.AttachKprobe(probe.symbol, probeInsns)
+		}
+		if probeErr != nil {
+			logger.Warning(r.context.GetRuntimeContext(), util.AlarmEBPF, "bytes are not collected", probeErr)
+		}
+	}
+	return module, events, table, nil
+}
+
+// stateProgram outputs the connection event of the layout decoded by decodeConnEvent when the state
+// of a TCP socket changes.
+func stateProgram(format *ebpf.TraceEventFormat, events *cilium.Map) (asm.Instructions, error) {
+	const base = -connEventSize
+	fields := make(map[string]ebpf.TraceEventField)
+	for _, name := range []string{"skaddr", "oldstate", "newstate", "sport", "dport", "saddr", "daddr"} {
+		field, ok := format.Field(name)
+		if !ok {
+			return nil, fmt.Errorf("field %s not found in tracepoint inet_sock_set_state", name)
+		}
+		fields[name] = field
+	}
+	insns := asm.Instructions{asm.Mov.Reg(asm.R6, asm.R1)}
+	// The tracepoint covers SCTP and DCCP since 5.6.
+	if protocol, ok := format.Field("protocol"); ok && protocol.Size <= 2 {
+		size := asm.Byte
+		if protocol.Size == 2 {
+			size = asm.Half
+		}
+		insns = append(insns,
+			asm.LoadMem(asm.R1, asm.R6, protocol.Offset, size),
+			asm.JNE.Imm(asm.R1, ipprotoTCP, ebpf.ExitLabel),
+		)
+	}
+	insns = append(insns, ebpf.ZeroStack(base, connEventSize)...)
+	insns = append(insns,
+		asm.FnKtimeGetNs.Call(),
+		asm.StoreMem(asm.RFP, base, asm.R0, asm.DWord),
+		asm.FnGetCurrentPidTgid.Call(),
+		asm.StoreMem(asm.RFP, base+16, asm.R0, asm.DWord),
+	)
+	insns = append(insns, ebpf.CopyField(asm.R6, fields["skaddr"], base+8, 8)...)
+	insns = append(insns, ebpf.CopyField(asm.R6, fields["oldstate"], base+24, 4)...)
+	insns = append(insns, ebpf.CopyField(asm.R6, fields["newstate"], base+28, 4)...)
+	insns = append(insns, ebpf.CopyField(asm.R6, fields["sport"], base+32, 2)...)
+	insns = append(insns, ebpf.CopyField(asm.R6, fields["dport"], base+34, 2)...)
+	if family, ok := format.Field("family"); ok {
+		insns = append(insns, ebpf.CopyField(asm.R6, family, base+36, 2)...)
+	}
+	insns = append(insns, ebpf.CopyField(asm.R6, fields["saddr"], base+40, 4)...)
+	insns = append(insns, ebpf.CopyField(asm.R6, fields["daddr"], base+44, 4)...)
+	if saddr6, ok := format.Field("saddr_v6"); ok {
+		insns = append(insns, ebpf.CopyField(asm.R6, saddr6, base+48, 16)...)
+	}
+	if daddr6, ok := format.Field("daddr_v6"); ok {
+		insns = append(insns, ebpf.CopyField(asm.R6, daddr6, base+64, 16)...)
+	}
+	return append(insns,
+		asm.Mov.Reg(asm.R1, asm.RFP),
+		asm.Add.Imm(asm.R1, base+80),
+		asm.Mov.Imm(asm.R2, 16),
+		asm.FnGetCurrentComm.Call(),
+		asm.Mov.Reg(asm.R1, asm.R6),
+		asm.LoadMapPtr(asm.R2, events.FD()),
+		asm.LoadImm(asm.R3, bpfCurrentCPU, asm.DWord),
+		asm.Mov.Reg(asm.R4, asm.RFP),
+		asm.Add.Imm(asm.R4, base),
+		asm.Mov.Imm(asm.R5, connEventSize),
+		asm.FnPerfEventOutput.Call(),
+	), nil
+}
+
+// retransmitProgram counts the retransmits of the socket in the traffic map.
+func retransmitProgram(format *ebpf.TraceEventFormat, trafficMap *cilium.Map) (asm.Instructions, error) {
+	skaddr, ok := format.Field("skaddr")
+	if !ok {
+		return nil, fmt.Errorf("field skaddr not found in tracepoint tcp_retransmit_skb")
+	}
+	insns := asm.Instructions{asm.Mov.Reg(asm.R6, asm.R1)}
+	insns = append(insns, ebpf.CopyField(asm.R6, skaddr, -8, 8)...)
+	insns = append(insns, ebpf.LookupOrInit(trafficMap, -8, -8-trafficSize, trafficSize, "found")...)
+	add := asm.StoreXAdd(asm.R0, asm.R1, asm.DWord)
+	add.Offset = 16
+	return append(insns, asm.Mov.Imm(asm.R1, 1), add), nil
+}
+
+// bytesProgram adds the @arg-th argument of the probed function to the traffic of the socket in the
+// first argument at @offset, and records the process.
+func bytesProgram(trafficMap *cilium.Map, arg int, offset int16) (asm.Instructions, error) {
+	loadSock, err := ebpf.KprobeArg(asm.R7, asm.R1, 0)
+	if err != nil {
+		return nil, err
+	}
+	loadBytes, err := ebpf.KprobeArg(asm.R9, asm.R1, arg)
+	if err != nil {
+		return nil, err
+	}
+	insns := asm.Instructions{
+		loadSock,
+		loadBytes,
+		// The size is an int or size_t, sign-extend the low 32 bits and skip the failures.
+		asm.LSh.Imm(asm.R9, 32),
+		asm.ArSh.Imm(asm.R9, 32),
+		asm.JSLE.Imm(asm.R9, 0, ebpf.ExitLabel),
+		asm.StoreMem(asm.RFP, -8, asm.R7, asm.DWord),
+	}
+	insns = append(insns, ebpf.LookupOrInit(trafficMap, -8, -8-trafficSize, trafficSize, "found")...)
+	add := asm.StoreXAdd(asm.R8, asm.R9, asm.DWord)
+	add.Offset = offset
+	return append(insns,
+		asm.Mov.Reg(asm.R8, asm.R0),
+		add,
+		asm.FnGetCurrentPidTgid.Call(),
+		asm.RSh.Imm(asm.R0, 32),
+		asm.StoreMem(asm.R8, 24, asm.R0, asm.Word),
+	), nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netflow
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/plugins/test"
+)
+
+type fakeTrafficTable map[uint64]traffic
+
+func (t fakeTrafficTable) lookup(sk uint64) (traffic, bool) {
+	value, ok := t[sk]
+	return value, ok
+}
+
+func (t fakeTrafficTable) delete(sk uint64) {
+	delete(t, sk)
+}
+
+func newEvent(sk uint64, ts time.Duration, oldState, newState uint32, localPort, remotePort uint16) *connEvent {
+	return &connEvent{
+		ts:         uint64(ts),
+		sk:         sk,
+		oldState:   oldState,
+		newState:   newState,
+		localIP:    net.ParseIP("10.0.0.1"),
+		remoteIP:   net.ParseIP("10.0.0.2"),
+		localPort:  localPort,
+		remotePort: remotePort,
+	}
+}
+
+func metricValues(c *test.MockMetricCollector) map[string]map[string]string {
+	values := make(map[string]map[string]string)
+	for _, log := range c.Logs {
+		var name, labels, value string
+		for _, content := range log.Contents {
+			switch content.Key {
+			case "__name__":
+				name = content.Value
+			case "__labels__":
+				labels = content.Value
+			case "__value__":
+				value = content.Value
+			}
+		}
+		if values[labels] == nil {
+			values[labels] = make(map[string]string)
+		}
+		values[labels][name] = value
+	}
+	return values
+}
+
+func TestDecodeConnEvent(t *testing.T) {
+	data := make([]byte, connEventSize)
+	le := binary.LittleEndian
+	le.PutUint64(data[0:], 100)
+	le.PutUint64(data[8:], 0xffff0001)
+	le.PutUint64(data[16:], 1234<<32|1235)
+	le.PutUint32(data[24:], tcpSynSent)
+	le.PutUint32(data[28:], tcpEstablished)
+	le.PutUint16(data[32:], 40000)
+	le.PutUint16(data[34:], 443)
+	le.PutUint16(data[36:], 2)
+	copy(data[40:], []byte{192, 168, 0, 1})
+	copy(data[44:], []byte{192, 168, 0, 2})
+	copy(data[80:], "curl")
+
+	ev, err := decodeConnEvent(data)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0xffff0001), ev.sk)
+	assert.Equal(t, uint32(1234), ev.pid, "the pid should be the tgid")
+	assert.Equal(t, "192.168.0.1", ev.localIP.String())
+	assert.Equal(t, "192.168.0.2", ev.remoteIP.String())
+	assert.Equal(t, uint16(443), ev.remotePort)
+	assert.Equal(t, "curl", ev.comm)
+
+	le.PutUint16(data[36:], afInet6)
+	copy(data[64:], net.ParseIP("2001:db8::2"))
+	ev, err = decodeConnEvent(data)
+	require.NoError(t, err)
+	assert.Equal(t, "2001:db8::2", ev.remoteIP.String())
+
+	_, err = decodeConnEvent(data[:10])
+	assert.Error(t, err)
+}
+
+func TestFlowAggregator(t *testing.T) {
+	table := fakeTrafficTable{}
+	a := newFlowAggregator(table, 10, true)
+	a.resolveComm = func(pid uint32) string { return "nginx" }
+
+	// an outgoing connection of curl
+	syn := newEvent(1, time.Second, tcpClose, tcpSynSent, 0, 443)
+	syn.pid, syn.comm = 100, "curl"
+	assert.Nil(t, a.handle(syn))
+	assert.Nil(t, a.handle(newEvent(1, time.Second+2*time.Millisecond, tcpSynSent, tcpEstablished, 40000, 443)))
+	table[1] = traffic{BytesSent: 100, BytesReceived: 2000, Retransmits: 1, Pid: 100}
+	// an incoming connection of nginx, the process is known by the traffic
+	assert.Nil(t, a.handle(newEvent(2, time.Second, tcpSynRecv, tcpEstablished, 80, 50000)))
+	table[2] = traffic{BytesSent: 5000, BytesReceived: 300, Pid: 200}
+	// a failed connection
+	failed := newEvent(3, time.Second, tcpClose, tcpSynSent, 0, 8080)
+	failed.pid, failed.comm = 100, "curl"
+	a.handle(failed)
+	assert.NotNil(t, a.handle(newEvent(3, 2*time.Second, tcpSynSent, tcpClose, 40001, 8080)))
+
+	c := &test.MockMetricCollector{}
+	a.collect(c, time.Now())
+	values := metricValues(c)
+	client := values["comm#$#curl|pid#$#100|port#$#443|remote_ip#$#10.0.0.2|role#$#client"]
+	assert.Equal(t, "1", client["net_flow_connects"])
+	assert.Equal(t, "100", client["net_flow_bytes_sent"])
+	assert.Equal(t, "2000", client["net_flow_bytes_received"])
+	assert.Equal(t, "1", client["net_flow_retransmits"])
+	assert.Equal(t, "2", client["net_flow_rtt_ms"])
+	server := values["comm#$#nginx|pid#$#200|port#$#80|remote_ip#$#10.0.0.2|role#$#server"]
+	assert.Equal(t, "1", server["net_flow_connects"])
+	assert.Equal(t, "5000", server["net_flow_bytes_sent"])
+	failedFlow := values["comm#$#curl|pid#$#100|port#$#8080|remote_ip#$#10.0.0.2|role#$#client"]
+	assert.Equal(t, "1", failedFlow["net_flow_connect_fails"])
+	assert.Equal(t, "1", failedFlow["net_flow_closes"])
+
+	// only the delta is counted in the next interval
+	table[1] = traffic{BytesSent: 150, BytesReceived: 2000, Retransmits: 1, Pid: 100}
+	closed := a.handle(newEvent(1, 3*time.Second, tcpFinWait1, tcpClose, 40000, 443))
+	require.NotNil(t, closed)
+	assert.NotContains(t, table, uint64(1), "the traffic of the closed connection should be deleted")
+	log := connectionLog(closed, uint64(3*time.Second))
+	assert.Equal(t, "40000", log["local_port"], "the local port should be updated when established")
+	assert.Equal(t, "150", log["bytes_sent"])
+	assert.Equal(t, "1998", log["duration_ms"])
+
+	c = &test.MockMetricCollector{}
+	a.collect(c, time.Now())
+	values = metricValues(c)
+	client = values["comm#$#curl|pid#$#100|port#$#443|remote_ip#$#10.0.0.2|role#$#client"]
+	assert.Equal(t, "0", client["net_flow_connects"])
+	assert.Equal(t, "1", client["net_flow_closes"])
+	assert.Equal(t, "50", client["net_flow_bytes_sent"])
+	assert.NotContains(t, client, "net_flow_rtt_ms")
+	assert.Len(t, values, 1, "the idle flows should not be emitted")
+}
+
+func TestFlowAggregatorLimits(t *testing.T) {
+	table := fakeTrafficTable{}
+	a := newFlowAggregator(table, 1, true)
+	a.handle(newEvent(1, time.Second, tcpSynRecv, tcpEstablished, 80, 50000))
+	a.handle(newEvent(2, time.Second, tcpSynRecv, tcpEstablished, 80, 50001))
+	assert.Len(t, a.conns, 1, "the connections over the limit should not be tracked")
+
+	loopback := newEvent(3, time.Second, tcpSynRecv, tcpEstablished, 80, 50002)
+	loopback.remoteIP = net.ParseIP("127.0.0.1")
+	a.maxConnections = 10
+	a.handle(loopback)
+	assert.Len(t, a.conns, 1, "the loopback connections should be ignored")
+
+	// the process of the server is unknown without traffic
+	c := &test.MockMetricCollector{}
+	a.collect(c, time.Now())
+	assert.Empty(t, c.Logs)
+}
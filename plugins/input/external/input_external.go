@@ -0,0 +1,98 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper/externalplugin"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const tagPrefix = "__tag__:"
+
+// ServiceExternal collects the data from the input plugin implemented by a sidecar, see externalplugin.Options.
+type ServiceExternal struct {
+	externalplugin.Options
+
+	context   pipeline.Context
+	client    *externalplugin.Client
+	ctx       context.Context
+	cancel    context.CancelFunc
+	waitGroup sync.WaitGroup
+}
+
+func (p *ServiceExternal) Init(ctx pipeline.Context) (int, error) {
+	p.context = ctx
+	var err error
+	if p.client, err = externalplugin.NewClient(p.Options, externalplugin.CategoryInput, ctx.GetConfigName()); err != nil {
+		logger.Error(p.context.GetRuntimeContext(), util.AlarmExternalPlugin, "init external input error", err)
+		return 0, err
+	}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	return 0, nil
+}
+
+func (p *ServiceExternal) Description() string {
+	return "service input plugin to collect the data from the external plugin of the sidecar"
+}
+
+func (p *ServiceExternal) Collect(pipeline.Collector) error {
+	return nil
+}
+
+// Start receives the data of the sidecar, and reconnects after a second if the stream is broken.
+func (p *ServiceExternal) Start(c pipeline.Collector) error {
+	p.waitGroup.Add(1)
+	defer p.waitGroup.Done()
+	for {
+		err := p.client.Collect(p.ctx, func(logGroup *protocol.LogGroup) {
+			for _, log := range logGroup.Logs {
+				for _, tag := range logGroup.LogTags {
+					log.Contents = append(log.Contents, &protocol.Log_Content{Key: tagPrefix + tag.Key, Value: tag.Value})
+				}
+				c.AddRawLog(log)
+			}
+		})
+		if p.ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			logger.Warning(p.context.GetRuntimeContext(), util.AlarmExternalPlugin, "collect from external plugin error", err)
+		}
+		select {
+		case <-p.ctx.Done():
+			return nil
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (p *ServiceExternal) Stop() error {
+	p.cancel()
+	p.waitGroup.Wait()
+	return p.client.Close()
+}
+
+func init() {
+	pipeline.ServiceInputs["service_external"] = func() pipeline.ServiceInput {
+		return &ServiceExternal{}
+	}
+}
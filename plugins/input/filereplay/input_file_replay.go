@@ -0,0 +1,383 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filereplay
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/helper/decompress"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	pluginName    = "service_file_replay"
+	checkpointKey = pluginName
+	pathTag       = "__path__"
+
+	// checkpointRecords is the number of records between the checkpoints saved while reading a file.
+	checkpointRecords = 10000
+)
+
+// replayCheckpoint is the replay progress of a file.
+type replayCheckpoint struct {
+	Size    int64
+	ModTime int64
+	// Records is the number of the records read, which are skipped when the replay resumes. The
+	// records rather than the offset are counted because the compressed files cannot be seeked.
+	Records int64
+	Done    bool
+}
+
+// InputFileReplay replays the archived log files once, rather than tailing them like file_log. Each
+// file is read from the beginning to the end, and each record is collected with the timestamp
+// embedded in it. The records out of the time range are skipped, and the replay could be limited
+// by the rate not to overwhelm the backend. The files replayed are recorded in the checkpoint, and
+// are not replayed again after restart.
+type InputFileReplay struct {
+	FilePaths            []string `comment:"the glob patterns of the archived files, the files compressed by gzip or zstd are decompressed"`
+	ContentKey           string   `comment:"the key of the record in the log, content by default"`
+	TimeRegex            string   `comment:"the regex to extract the timestamp from the record, the first submatch is used if any, otherwise the whole match"`
+	TimeFormat           string   `comment:"the strptime format of the extracted timestamp, such as %Y-%m-%d %H:%M:%S, the records are collected with the current time when empty"`
+	AdjustUTCOffset      bool     `comment:"use UTCOffset rather than the local time zone for the timestamps without the time zone"`
+	UTCOffset            int      `comment:"the UTC offset in seconds of the timestamps without the time zone, such as 28800 for UTC+8"`
+	StartTime            string   `comment:"the RFC3339 start of the time range to replay, inclusive, such as 2023-01-01T00:00:00+08:00"`
+	EndTime              string   `comment:"the RFC3339 end of the time range to replay, exclusive"`
+	MaxEventsPerSecond   int      `comment:"the max records replayed per second, 0 means no limit"`
+	MaxBytesPerSecond    int      `comment:"the max bytes replayed per second, 0 means no limit"`
+	MaxRecordBytes       int      `comment:"the max length of a record, the replay of a file stops at a longer record, 8MB by default"`
+	MaxDecompressedBytes int64    `comment:"the max decompressed bytes of a compressed file, the rest is dropped, 0 means no limit"`
+	Framing              string   `comment:"the framing of the records, delimiter by default, octet_counting, uint32_length and varint_length are the length-prefixed framings"`
+	Delimiter            string   `comment:"the delimiter of the records, \\n by default"`
+	DelimiterRegex       string   `comment:"the regex separator of the records, takes precedence over Delimiter"`
+
+	context      pipeline.Context
+	splitter     *helper.RecordSplitter
+	timeRegex    *regexp.Regexp
+	location     *time.Location
+	start        time.Time
+	end          time.Time
+	eventLimiter *rate.Limiter
+	byteLimiter  *rate.Limiter
+	checkpoints  map[string]*replayCheckpoint
+	runCtx       context.Context
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+}
+
+// fileReplay is the state of replaying a file.
+type fileReplay struct {
+	tags map[string]string
+	// lastTime is the timestamp of the last record with a timestamp, which is inherited by the
+	// following records without timestamps, such as the lines of a stack trace.
+	lastTime time.Time
+	skipped  int64
+	noTime   int64
+}
+
+func (r *InputFileReplay) Init(ctx pipeline.Context) (int, error) {
+	r.context = ctx
+	if len(r.FilePaths) == 0 {
+		return 0, fmt.Errorf("FilePaths must be set")
+	}
+	for _, pattern := range r.FilePaths {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return 0, fmt.Errorf("invalid file path %q: %v", pattern, err)
+		}
+	}
+	if r.MaxRecordBytes <= 0 {
+		r.MaxRecordBytes = 8 * 1024 * 1024
+	}
+	splitter, err := helper.NewRecordSplitter(helper.RecordSplitterConfig{
+		Framing:        r.Framing,
+		Delimiter:      r.Delimiter,
+		DelimiterRegex: r.DelimiterRegex,
+		MaxFrameSize:   r.MaxRecordBytes,
+	})
+	if err != nil {
+		return 0, err
+	}
+	r.splitter = splitter
+	if r.TimeFormat != "" {
+		if r.TimeRegex == "" {
+			return 0, fmt.Errorf("TimeRegex must be set with TimeFormat")
+		}
+		if r.timeRegex, err = regexp.Compile(r.TimeRegex); err != nil {
+			return 0, fmt.Errorf("invalid TimeRegex %q: %v", r.TimeRegex, err)
+		}
+		r.location = time.Local
+		if r.AdjustUTCOffset {
+			if r.UTCOffset < -12*60*60 || r.UTCOffset > 14*60*60 {
+				return 0, fmt.Errorf("UTCOffset %v is out of range (from -12 to +14)", r.UTCOffset)
+			}
+			r.location = time.FixedZone("SpecifiedTimezone", r.UTCOffset)
+		}
+		if helper.StrptimeHasZone(r.TimeFormat) {
+			r.location = nil
+		}
+	}
+	if r.start, err = parseRangeTime("StartTime", r.StartTime); err != nil {
+		return 0, err
+	}
+	if r.end, err = parseRangeTime("EndTime", r.EndTime); err != nil {
+		return 0, err
+	}
+	if !r.start.IsZero() || !r.end.IsZero() {
+		if r.timeRegex == nil {
+			return 0, fmt.Errorf("TimeRegex and TimeFormat must be set with StartTime or EndTime")
+		}
+		if !r.end.IsZero() && !r.start.Before(r.end) {
+			return 0, fmt.Errorf("StartTime %s must be before EndTime %s", r.StartTime, r.EndTime)
+		}
+	}
+	if r.MaxEventsPerSecond > 0 {
+		r.eventLimiter = rate.NewLimiter(rate.Limit(r.MaxEventsPerSecond), r.MaxEventsPerSecond)
+	}
+	if r.MaxBytesPerSecond > 0 {
+		// the burst must hold the longest record, otherwise it could never be replayed.
+		burst := r.MaxBytesPerSecond
+		if burst < r.MaxRecordBytes {
+			burst = r.MaxRecordBytes
+		}
+		r.byteLimiter = rate.NewLimiter(rate.Limit(r.MaxBytesPerSecond), burst)
+	}
+	r.checkpoints = make(map[string]*replayCheckpoint)
+	r.context.GetCheckPointObject(checkpointKey, &r.checkpoints)
+	r.runCtx, r.cancel = context.WithCancel(context.Background())
+	// Start is called once after Init, adding it here makes sure that Stop waits for it even if
+	// Stop is called before the goroutine of Start runs.
+	r.wg.Add(1)
+	return 0, nil
+}
+
+func parseRangeTime(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q: %v", name, value, err)
+	}
+	return t, nil
+}
+
+func (r *InputFileReplay) Description() string {
+	return "replay the archived log files from the beginning once with the embedded timestamps"
+}
+
+func (r *InputFileReplay) Collect(collector pipeline.Collector) error {
+	return nil
+}
+
+// Start replays the matched files in the order of the paths, and returns when all of them are
+// replayed. The files created later are not replayed.
+func (r *InputFileReplay) Start(collector pipeline.Collector) error {
+	defer r.wg.Done()
+	files := r.matchFiles()
+	replayed := 0
+	for _, path := range files {
+		if r.runCtx.Err() != nil {
+			return nil
+		}
+		stat, err := os.Stat(path)
+		if err != nil {
+			logger.Warning(r.context.GetRuntimeContext(), util.AlarmFileReplay, "stat the file error", err, "path", path)
+			continue
+		}
+		cp, ok := r.checkpoints[path]
+		if !ok || cp.Size != stat.Size() || cp.ModTime != stat.ModTime().UnixNano() {
+			if ok {
+				logger.Info(r.context.GetRuntimeContext(), "the file is changed, replay it again, path", path)
+			}
+			cp = &replayCheckpoint{Size: stat.Size(), ModTime: stat.ModTime().UnixNano()}
+			r.checkpoints[path] = cp
+		}
+		if cp.Done {
+			continue
+		}
+		err = r.replayFile(collector, path, cp)
+		if r.runCtx.Err() != nil {
+			// keep the progress to resume the file
+			r.saveCheckpoints()
+			return nil
+		}
+		if errors.Is(err, decompress.ErrTooLarge) {
+			logger.Warning(r.context.GetRuntimeContext(), util.AlarmFileReplay, "the decompressed file exceeds the limit", r.MaxDecompressedBytes, "path", path)
+		} else if err != nil {
+			logger.Warning(r.context.GetRuntimeContext(), util.AlarmFileReplay, "replay the file error", err, "path", path, "records", cp.Records)
+		}
+		cp.Done = true
+		replayed++
+		r.saveCheckpoints()
+	}
+	logger.Info(r.context.GetRuntimeContext(), "replay done, files", len(files), "replayed", replayed)
+	return nil
+}
+
+func (r *InputFileReplay) Stop() error {
+	r.cancel()
+	r.wg.Wait()
+	return nil
+}
+
+// matchFiles returns the regular files matched by the patterns in the order of the paths, which
+// is the order of time for the rotated files named by the date.
+func (r *InputFileReplay) matchFiles() []string {
+	matched := make(map[string]bool)
+	for _, pattern := range r.FilePaths {
+		paths, _ := filepath.Glob(pattern)
+		for _, path := range paths {
+			if stat, err := os.Stat(path); err == nil && stat.Mode().IsRegular() {
+				matched[path] = true
+			}
+		}
+	}
+	files := make([]string, 0, len(matched))
+	for path := range matched {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	if len(files) == 0 {
+		logger.Warning(r.context.GetRuntimeContext(), util.AlarmFileReplay, "no file matched, patterns", strings.Join(r.FilePaths, ","))
+	}
+	return files
+}
+
+func (r *InputFileReplay) replayFile(collector pipeline.Collector, path string, cp *replayCheckpoint) error {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+	defer file.Close() //nolint:errcheck
+	reader, err := decompress.NewReader(file, decompress.Auto, r.MaxDecompressedBytes)
+	if err != nil {
+		return err
+	}
+	defer reader.Close() //nolint:errcheck
+	logger.Info(r.context.GetRuntimeContext(), "replay the file", path, "since record", cp.Records)
+	state := &fileReplay{tags: map[string]string{pathTag: path}}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), r.MaxRecordBytes)
+	scanner.Split(r.splitter.Split)
+	var records int64
+	for scanner.Scan() {
+		if r.runCtx.Err() != nil {
+			return nil
+		}
+		records++
+		record := scanner.Bytes()
+		if records <= cp.Records {
+			// the record is replayed before restart, only its timestamp is kept for the next records.
+			r.recordTime(state, record)
+			continue
+		}
+		if err = r.replayRecord(collector, state, record); err != nil {
+			return nil
+		}
+		cp.Records = records
+		if records%checkpointRecords == 0 {
+			r.saveCheckpoints()
+		}
+	}
+	if state.noTime > 0 || state.skipped > 0 {
+		logger.Info(r.context.GetRuntimeContext(), "replay the file done", path, "records", records, "out of range", state.skipped, "without timestamp", state.noTime)
+	}
+	return scanner.Err()
+}
+
+// replayRecord collects the record if it is in the time range, and returns error only when the
+// replay is stopped while waiting for the rate limit.
+func (r *InputFileReplay) replayRecord(collector pipeline.Collector, state *fileReplay, record []byte) error {
+	if len(record) == 0 {
+		return nil
+	}
+	t, ok := r.recordTime(state, record)
+	if r.timeRegex != nil && !ok {
+		state.noTime++
+		if !r.start.IsZero() || !r.end.IsZero() {
+			// the record before the first timestamp of the file cannot be checked by the range
+			state.skipped++
+			return nil
+		}
+	}
+	if ok && ((!r.start.IsZero() && t.Before(r.start)) || (!r.end.IsZero() && !t.Before(r.end))) {
+		state.skipped++
+		return nil
+	}
+	if r.eventLimiter != nil {
+		if err := r.eventLimiter.Wait(r.runCtx); err != nil {
+			return err
+		}
+	}
+	if r.byteLimiter != nil {
+		if err := r.byteLimiter.WaitN(r.runCtx, len(record)); err != nil {
+			return err
+		}
+	}
+	fields := map[string]string{r.ContentKey: string(record)}
+	if ok {
+		collector.AddData(state.tags, fields, t)
+	} else {
+		collector.AddData(state.tags, fields)
+	}
+	return nil
+}
+
+// recordTime returns the timestamp embedded in the record, or the timestamp of the last record if
+// there is none. The returned flag is false when no timestamp is known.
+func (r *InputFileReplay) recordTime(state *fileReplay, record []byte) (time.Time, bool) {
+	if r.timeRegex == nil {
+		return time.Time{}, false
+	}
+	if match := r.timeRegex.FindSubmatch(record); match != nil {
+		value := match[0]
+		if len(match) > 1 {
+			value = match[1]
+		}
+		if t, err := helper.Strptime(string(value), r.TimeFormat, r.location); err == nil {
+			state.lastTime = t
+		}
+	}
+	return state.lastTime, !state.lastTime.IsZero()
+}
+
+func (r *InputFileReplay) saveCheckpoints() {
+	if err := r.context.SaveCheckPointObject(checkpointKey, r.checkpoints); err != nil {
+		logger.Warning(r.context.GetRuntimeContext(), util.AlarmCheckpointSave, "save the checkpoints of the replayed files error", err)
+	}
+}
+
+func init() {
+	pipeline.ServiceInputs[pluginName] = func() pipeline.ServiceInput {
+		return &InputFileReplay{
+			ContentKey:     "content",
+			MaxRecordBytes: 8 * 1024 * 1024,
+		}
+	}
+}
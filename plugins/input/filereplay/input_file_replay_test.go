@@ -0,0 +1,183 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filereplay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/plugins/test"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+func newInput(t *testing.T, ctx pipeline.Context, configure func(*InputFileReplay)) *InputFileReplay {
+	input := pipeline.ServiceInputs[pluginName]().(*InputFileReplay)
+	input.TimeRegex = `^\[([^\]]+)\]`
+	input.TimeFormat = "%Y-%m-%d %H:%M:%S"
+	input.AdjustUTCOffset = true
+	configure(input)
+	_, err := input.Init(ctx)
+	require.NoError(t, err)
+	return input
+}
+
+func contents(collector *test.MockMetricCollector, key string) []string {
+	var values []string
+	for _, log := range collector.Logs {
+		for _, content := range log.Contents {
+			if content.Key == key {
+				values = append(values, content.Value)
+			}
+		}
+	}
+	return values
+}
+
+func TestReplayFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.log.1"), []byte(
+		"[2023-01-01 00:00:00] before\n"+
+			"[2023-01-01 01:00:00] error\n"+
+			"\tat main.go:10\n"+
+			"[2023-01-01 02:00:00] after\n"), 0o600))
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, _ = writer.Write([]byte("[2023-01-01 01:30:00] compressed"))
+	require.NoError(t, writer.Close())
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.log.2.gz"), buf.Bytes(), 0o600))
+
+	input := newInput(t, mock.NewEmptyContext("project", "store", "config"), func(input *InputFileReplay) {
+		input.FilePaths = []string{filepath.Join(dir, "app.log.*")}
+		input.StartTime = "2023-01-01T01:00:00Z"
+		input.EndTime = "2023-01-01T02:00:00Z"
+	})
+	collector := &test.MockMetricCollector{}
+	require.NoError(t, input.Start(collector))
+	require.NoError(t, input.Stop())
+
+	// the stack trace inherits the timestamp of the error, and the files are replayed once
+	assert.Equal(t, []string{"[2023-01-01 01:00:00] error", "\tat main.go:10", "[2023-01-01 01:30:00] compressed"}, contents(collector, "content"))
+	start := time.Date(2023, 1, 1, 1, 0, 0, 0, time.UTC)
+	assert.Equal(t, uint32(start.Unix()), collector.Logs[0].Time)
+	assert.Equal(t, uint32(start.Unix()), collector.Logs[1].Time)
+	assert.Equal(t, uint32(start.Add(30*time.Minute).Unix()), collector.Logs[2].Time)
+	assert.Equal(t, filepath.Join(dir, "app.log.2.gz"), contents(collector, pathTag)[2])
+	for _, cp := range input.checkpoints {
+		assert.True(t, cp.Done)
+	}
+}
+
+func TestReplayResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("[2023-01-01 00:00:00] 1\n\tcontinued\n[2023-01-01 00:00:01] 2\n"), 0o600))
+	stat, err := os.Stat(path)
+	require.NoError(t, err)
+	ctx := mock.NewEmptyContext("project", "store", "config")
+	require.NoError(t, ctx.SaveCheckPointObject(checkpointKey, map[string]*replayCheckpoint{
+		path: {Size: stat.Size(), ModTime: stat.ModTime().UnixNano(), Records: 1},
+	}))
+
+	input := newInput(t, ctx, func(input *InputFileReplay) {
+		input.FilePaths = []string{path}
+		input.StartTime = "2023-01-01T00:00:00Z"
+	})
+	collector := &test.MockMetricCollector{}
+	require.NoError(t, input.Start(collector))
+	// the timestamp of the replayed record is kept for the continued line
+	assert.Equal(t, []string{"\tcontinued", "[2023-01-01 00:00:01] 2"}, contents(collector, "content"))
+
+	// the replayed file is skipped after restart
+	input = newInput(t, ctx, func(input *InputFileReplay) {
+		input.FilePaths = []string{path}
+	})
+	collector = &test.MockMetricCollector{}
+	require.NoError(t, input.Start(collector))
+	assert.Empty(t, collector.Logs)
+}
+
+func TestReplayRateLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("1\n2\n3\n4\n"), 0o600))
+	input := newInput(t, mock.NewEmptyContext("project", "store", "config"), func(input *InputFileReplay) {
+		input.FilePaths = []string{path}
+		input.TimeRegex, input.TimeFormat = "", ""
+		input.MaxEventsPerSecond = 10
+	})
+	collector := &test.MockMetricCollector{}
+	begin := time.Now()
+	require.NoError(t, input.Start(collector))
+	// the burst of 10 events is not exhausted
+	assert.Less(t, time.Since(begin), time.Second)
+	assert.Equal(t, []string{"1", "2", "3", "4"}, contents(collector, "content"))
+
+	input = newInput(t, mock.NewEmptyContext("project", "store", "config"), func(input *InputFileReplay) {
+		input.FilePaths = []string{path}
+		input.TimeRegex, input.TimeFormat = "", ""
+		input.MaxBytesPerSecond = 1
+		input.MaxRecordBytes = 1
+	})
+	done := make(chan struct{})
+	go func() {
+		_ = input.Start(&test.MockMetricCollector{})
+		close(done)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	// the replay waiting for the rate limit could be stopped, and the file is resumed later
+	require.NoError(t, input.Stop())
+	<-done
+	assert.False(t, input.checkpoints[path].Done)
+}
+
+func TestReplayInvalidConfig(t *testing.T) {
+	ctx := mock.NewEmptyContext("project", "store", "config")
+	for _, input := range []*InputFileReplay{
+		{},
+		{FilePaths: []string{"/var/log/["}},
+		{FilePaths: []string{"/var/log/*.log"}, TimeFormat: "%Y"},
+		{FilePaths: []string{"/var/log/*.log"}, StartTime: "2023-01-01T00:00:00Z"},
+		{FilePaths: []string{"/var/log/*.log"}, TimeRegex: `\d+`, TimeFormat: "%s", StartTime: "2023-01-02T00:00:00Z", EndTime: "2023-01-01T00:00:00Z"},
+	} {
+		_, err := input.Init(ctx)
+		assert.Error(t, err)
+	}
+}
+
+func TestStopBeforeStart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("1\n"), 0o600))
+	input := newInput(t, mock.NewEmptyContext("project", "store", "config"), func(input *InputFileReplay) {
+		input.FilePaths = []string{path}
+	})
+	stopped := make(chan struct{})
+	go func() {
+		_ = input.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("Stop should wait for Start")
+	case <-time.After(100 * time.Millisecond):
+	}
+	require.NoError(t, input.Start(&test.MockMetricCollector{}))
+	<-stopped
+}
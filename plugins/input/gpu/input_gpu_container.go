@@ -0,0 +1,81 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gpu
+
+import (
+	"strings"
+
+	"github.com/alibaba/ilogtail/helper"
+)
+
+// visibleDevicesEnv is the env the nvidia device plugin sets to the containers for the allocated GPUs.
+const visibleDevicesEnv = "NVIDIA_VISIBLE_DEVICES"
+
+// parseVisibleDevices returns the GPU uuids or indexes in the value of NVIDIA_VISIBLE_DEVICES,
+// and nil for the special values not referring to the specific devices.
+func parseVisibleDevices(value string) []string {
+	switch value {
+	case "", "all", "none", "void":
+		return nil
+	}
+	var devices []string
+	for _, device := range strings.Split(value, ",") {
+		if device = strings.TrimSpace(device); device != "" {
+			devices = append(devices, device)
+		}
+	}
+	return devices
+}
+
+// allocatedContainers returns the metas of the containers keyed by the uuids or indexes of the GPUs
+// allocated to them.
+func allocatedContainers() map[string]*helper.ContainerMeta {
+	containerDevices := make(map[string][]string)
+	helper.ProcessContainerAllInfo(func(info *helper.DockerInfoDetail) {
+		if info.ContainerInfo.Config == nil {
+			return
+		}
+		for _, env := range info.ContainerInfo.Config.Env {
+			if value := strings.TrimPrefix(env, visibleDevicesEnv+"="); value != env {
+				if devices := parseVisibleDevices(value); len(devices) > 0 {
+					containerDevices[info.ContainerInfo.ID] = devices
+				}
+				return
+			}
+		}
+	})
+	containers := make(map[string]*helper.ContainerMeta)
+	for id, devices := range containerDevices {
+		meta := helper.GetContainerMeta(id)
+		if meta == nil {
+			continue
+		}
+		for _, device := range devices {
+			containers[device] = meta
+		}
+	}
+	return containers
+}
+
+func addContainerFields(fields map[string]string, meta *helper.ContainerMeta) {
+	if meta == nil {
+		return
+	}
+	fields["container_name"] = meta.ContainerName
+	if meta.PodName != "" {
+		fields["pod_name"] = meta.PodName
+		fields["namespace"] = meta.K8sNamespace
+	}
+}
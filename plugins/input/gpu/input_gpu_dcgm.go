@@ -0,0 +1,55 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gpu
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/alibaba/ilogtail/helper/decoder/prometheus"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+// CollectDcgmMetric scrapes the metrics of the dcgm-exporter, which are labeled by the GPU uuid and
+// the pod when the kubernetes mapping of the exporter is enabled.
+func (r *InputGpuMetric) CollectDcgmMetric() {
+	resp, err := r.client.Get(r.DcgmExporterURL)
+	if err != nil {
+		logger.Warning(r.context.GetRuntimeContext(), util.AlarmGPUDCGMCollect, "scrape the dcgm-exporter error", err)
+		return
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		logger.Warning(r.context.GetRuntimeContext(), util.AlarmGPUDCGMCollect, "scrape the dcgm-exporter error",
+			fmt.Sprintf("unexpected status code %d", resp.StatusCode))
+		return
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		logger.Warning(r.context.GetRuntimeContext(), util.AlarmGPUDCGMCollect, "read the dcgm-exporter response error", err)
+		return
+	}
+	// the response is always in the text format, the decoder only checks the headers of the remote write requests.
+	logs, err := (&prometheus.Decoder{}).Decode(data, &http.Request{Header: http.Header{}}, nil)
+	if err != nil {
+		logger.Warning(r.context.GetRuntimeContext(), util.AlarmGPUDCGMCollect, "decode the dcgm-exporter metrics error", err)
+		return
+	}
+	for _, log := range logs {
+		r.collector.AddRawLog(log)
+	}
+}
@@ -15,21 +15,41 @@
 package gpu
 
 import (
+	"fmt"
+	"net/http"
 	"strconv"
 	"sync"
 	"time"
 
+	"github.com/alibaba/ilogtail/helper"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/util"
 
 	"github.com/mindprince/gonvml"
 )
 
+const (
+	sourceNvml = "nvml"
+	sourceDcgm = "dcgm"
+)
+
 type InputGpuMetric struct {
 	CollectIntervalMs int
+	// Source is where the metrics come from, `nvml` reads the driver directly and `dcgm` scrapes the dcgm-exporter.
+	Source          string
+	DcgmExporterURL string // The metrics url of the dcgm-exporter.
+	// ProcessMetrics collects the GPU memory used by each process with nvidia-smi, because the per-process
+	// queries are not exported by the NVML binding.
+	ProcessMetrics bool
+	NvidiaSmiPath  string
+	// ContainerLabels attaches the container and pod the GPU is allocated to by the device plugin, or the
+	// process belongs to.
+	ContainerLabels bool
 
 	context   pipeline.Context
 	collector pipeline.Collector
+	client    *http.Client
 
 	waitGroup sync.WaitGroup
 
@@ -42,6 +62,13 @@ func (r *InputGpuMetric) Init(context pipeline.Context) (int, error) {
 	if r.CollectIntervalMs <= 0 {
 		r.CollectIntervalMs = 1000
 	}
+	switch r.Source {
+	case sourceNvml:
+	case sourceDcgm:
+		r.client = &http.Client{Timeout: time.Duration(r.CollectIntervalMs) * time.Millisecond}
+	default:
+		return 0, fmt.Errorf("unsupported gpu metric source: %s", r.Source)
+	}
 
 	return 0, nil
 }
@@ -55,12 +82,14 @@ func (r *InputGpuMetric) Collect(collector pipeline.Collector) error {
 }
 
 func (r *InputGpuMetric) Start(collector pipeline.Collector) error {
-	err := gonvml.Initialize()
-	if err != nil {
-		logger.Error(r.context.GetRuntimeContext(), "GPU_NVML_INIT_ALARM", "Couldn't initialize nvml, error", err)
-		return err
+	if r.Source == sourceNvml {
+		err := gonvml.Initialize()
+		if err != nil {
+			logger.Error(r.context.GetRuntimeContext(), util.AlarmGPUNVMLInit, "Couldn't initialize nvml, error", err)
+			return err
+		}
+		defer gonvml.Shutdown()
 	}
-	defer gonvml.Shutdown()
 
 	r.collector = collector
 	r.shutdown = make(chan struct{})
@@ -75,9 +104,10 @@ func (r *InputGpuMetric) Start(collector pipeline.Collector) error {
 		case <-r.shutdown:
 			return nil
 		case <-timer.C:
-			err := r.CollectGpuMetric()
-			if err != nil {
-				logger.Error(r.context.GetRuntimeContext(), "GPU_NVML_COLLECT_ALARM", "GPU collect metric error", err)
+			if r.Source == sourceDcgm {
+				r.CollectDcgmMetric()
+			} else if err := r.CollectGpuMetric(); err != nil {
+				logger.Error(r.context.GetRuntimeContext(), util.AlarmGPUNVMLCollect, "GPU collect metric error", err)
 				return nil
 			}
 			timer.Reset(time.Duration(r.CollectIntervalMs) * time.Millisecond)
@@ -89,10 +119,15 @@ func (r *InputGpuMetric) CollectGpuMetric() error {
 	t := time.Now()
 	numDevices, err := gonvml.DeviceCount()
 	if err != nil {
-		logger.Error(r.context.GetRuntimeContext(), "GPU_NVML_DEVICE_COUNT_ALARM", "GPU DeviceCount error", err)
+		logger.Error(r.context.GetRuntimeContext(), util.AlarmGPUNVMLDeviceCount, "GPU DeviceCount error", err)
 		return err
 	}
 
+	var containers map[string]*helper.ContainerMeta
+	if r.ContainerLabels {
+		containers = allocatedContainers()
+	}
+	uuidIndexes := make(map[string]string, numDevices)
 	for index := uint(0); index < numDevices; index++ {
 		fields := make(map[string]string)
 		fields["metric_type"] = "gpu"
@@ -100,9 +135,22 @@ func (r *InputGpuMetric) CollectGpuMetric() error {
 
 		device, err := gonvml.DeviceHandleByIndex(index)
 		if err != nil {
-			logger.Error(r.context.GetRuntimeContext(), "GPU_NVML_DEVICE_INDEX_ALARM", "GPU DeviceHandleByIndex", index, "error", err)
+			logger.Error(r.context.GetRuntimeContext(), util.AlarmGPUNVMLDeviceIndex, "GPU DeviceHandleByIndex", index, "error", err)
 			return err
 		}
+		uuid, _ := device.UUID()
+		fields["gpu_uuid"] = uuid
+		uuidIndexes[uuid] = fields["device"]
+		name, _ := device.Name()
+		fields["gpu_name"] = name
+		if r.ContainerLabels {
+			// the device plugin allocates the devices either by uuid or by index
+			if meta, ok := containers[uuid]; ok {
+				addContainerFields(fields, meta)
+			} else if meta, ok := containers[fields["device"]]; ok {
+				addContainerFields(fields, meta)
+			}
+		}
 		powerUsage, _ := device.PowerUsage()
 		fields["gpu_power_usage"] = strconv.FormatUint(uint64(powerUsage)/1000, 10)
 		temperature, _ := device.Temperature()
@@ -116,6 +164,9 @@ func (r *InputGpuMetric) CollectGpuMetric() error {
 		fields["gpu_free_memory"] = strconv.FormatUint((totalMemory-usedMemory)/1024/1024, 10)
 		r.collector.AddData(nil, fields, t)
 	}
+	if r.ProcessMetrics {
+		r.collectProcessMetric(t, uuidIndexes)
+	}
 	return nil
 }
 
@@ -129,6 +180,9 @@ func init() {
 	pipeline.ServiceInputs["service_gpu_metric"] = func() pipeline.ServiceInput {
 		return &InputGpuMetric{
 			CollectIntervalMs: 1000,
+			Source:            sourceNvml,
+			DcgmExporterURL:   "http://localhost:9400/metrics",
+			NvidiaSmiPath:     "nvidia-smi",
 		}
 	}
 }
@@ -0,0 +1,82 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gpu
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/plugins/test"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+func newInput(source string) *InputGpuMetric {
+	input := pipeline.ServiceInputs["service_gpu_metric"]().(*InputGpuMetric)
+	input.Source = source
+	return input
+}
+
+func TestInitWithUnsupportedSource(t *testing.T) {
+	_, err := newInput("unknown").Init(mock.NewEmptyContext("project", "store", "config"))
+	assert.Error(t, err)
+}
+
+func TestParseComputeApps(t *testing.T) {
+	output := []byte("GPU-2b3c, 1234, 512\nGPU-2b3c, 1235, [N/A]\nGPU-4d5e, 2345, 1024\n")
+	apps := parseComputeApps(output)
+	assert.Equal(t, []computeApp{
+		{gpuUUID: "GPU-2b3c", pid: 1234, usedMemoryMB: 512},
+		{gpuUUID: "GPU-4d5e", pid: 2345, usedMemoryMB: 1024},
+	}, apps)
+}
+
+func TestParseVisibleDevices(t *testing.T) {
+	assert.Equal(t, []string{"GPU-2b3c", "GPU-4d5e"}, parseVisibleDevices("GPU-2b3c, GPU-4d5e"))
+	assert.Equal(t, []string{"0"}, parseVisibleDevices("0"))
+	assert.Nil(t, parseVisibleDevices("all"))
+	assert.Nil(t, parseVisibleDevices("void"))
+}
+
+func TestCollectDcgmMetric(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`# HELP DCGM_FI_DEV_GPU_UTIL GPU utilization (in %).
+# TYPE DCGM_FI_DEV_GPU_UTIL gauge
+DCGM_FI_DEV_GPU_UTIL{gpu="0",UUID="GPU-2b3c",pod="trainer-0",namespace="default"} 87
+DCGM_FI_DEV_FB_USED{gpu="0",UUID="GPU-2b3c",pod="trainer-0",namespace="default"} 10240
+`))
+	}))
+	defer server.Close()
+
+	input := newInput(sourceDcgm)
+	input.DcgmExporterURL = server.URL
+	_, err := input.Init(mock.NewEmptyContext("project", "store", "config"))
+	require.NoError(t, err)
+	collector := &test.MockMetricCollector{}
+	input.collector = collector
+	input.CollectDcgmMetric()
+	require.Len(t, collector.Logs, 2)
+	for _, log := range collector.Logs {
+		for _, content := range log.Contents {
+			if content.Key == "__labels__" {
+				assert.Equal(t, "UUID#$#GPU-2b3c|gpu#$#0|namespace#$#default|pod#$#trainer-0", content.Value)
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gpu
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+// computeApp is a process running on a GPU reported by nvidia-smi.
+type computeApp struct {
+	gpuUUID      string
+	pid          int
+	usedMemoryMB uint64
+}
+
+// parseComputeApps parses the output of
+// `nvidia-smi --query-compute-apps=gpu_uuid,pid,used_memory --format=csv,noheader,nounits`.
+func parseComputeApps(output []byte) []computeApp {
+	var apps []computeApp
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		parts := strings.Split(scanner.Text(), ",")
+		if len(parts) != 3 {
+			continue
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil {
+			continue
+		}
+		// the used memory is [N/A] when the driver cannot account it, such as in the WDDM mode.
+		usedMemory, err := strconv.ParseUint(strings.TrimSpace(parts[2]), 10, 64)
+		if err != nil {
+			continue
+		}
+		apps = append(apps, computeApp{
+			gpuUUID:      strings.TrimSpace(parts[0]),
+			pid:          pid,
+			usedMemoryMB: usedMemory,
+		})
+	}
+	return apps
+}
+
+func (r *InputGpuMetric) collectProcessMetric(t time.Time, uuidIndexes map[string]string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(r.CollectIntervalMs)*time.Millisecond)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, r.NvidiaSmiPath, "--query-compute-apps=gpu_uuid,pid,used_memory",
+		"--format=csv,noheader,nounits").Output()
+	if err != nil {
+		logger.Warning(r.context.GetRuntimeContext(), util.AlarmGPUProcessCollect, "query the gpu processes by nvidia-smi error", err)
+		return
+	}
+	for _, app := range parseComputeApps(output) {
+		fields := make(map[string]string)
+		fields["metric_type"] = "gpu_process"
+		fields["device"] = uuidIndexes[app.gpuUUID]
+		fields["gpu_uuid"] = app.gpuUUID
+		fields["pid"] = strconv.Itoa(app.pid)
+		fields["gpu_process_used_memory"] = strconv.FormatUint(app.usedMemoryMB, 10)
+		if r.ContainerLabels {
+			if id := helper.ProcessContainerID(app.pid); id != "" {
+				addContainerFields(fields, helper.GetContainerMeta(id))
+			}
+		}
+		r.collector.AddData(nil, fields, t)
+	}
+}
@@ -0,0 +1,277 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphite
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/helper/decoder"
+	"github.com/alibaba/ilogtail/helper/decoder/common"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	pluginName          = "service_graphite"
+	defaultGraphitePort = "2003"
+)
+
+// ServiceGraphite receives the metrics in the graphite plaintext protocol over TCP or UDP, which could replace
+// the receiving side of carbon-relay. The lines in the tagged format like `cpu.load;host=a 1 1434055562` are supported.
+type ServiceGraphite struct {
+	Address        string // Address to receive the metrics, eg. tcp://0.0.0.0:2003 or udp://0.0.0.0:2003.
+	MaxConnections int    // Max connections, for TCP only, 0 means no limit.
+	TimeoutSeconds int    // The number of seconds of inactivity before a connection is closed, for TCP only, 0 means no timeout.
+	MaxBufferSize  int    // The max size of a UDP packet, or the max size of a line and of the lines decoded in one batch for TCP.
+
+	context     pipeline.Context
+	decoder     decoder.Decoder
+	collector   pipeline.Collector
+	collectorV2 pipeline.PipelineCollector
+	isStream    bool
+	scheme      string
+	host        string
+
+	listener    net.Listener
+	packetConn  net.PacketConn
+	connections map[net.Conn]struct{}
+	connMu      sync.Mutex
+	wg          sync.WaitGroup
+}
+
+func (g *ServiceGraphite) Init(context pipeline.Context) (int, error) {
+	g.context = context
+	var err error
+	if g.decoder, err = decoder.GetDecoder(common.ProtocolGraphite); err != nil {
+		return 0, err
+	}
+	if g.scheme, g.host, err = helper.SplitAddress(g.Address, defaultGraphitePort); err != nil {
+		return 0, err
+	}
+	switch g.scheme {
+	case "tcp", "tcp4", "tcp6":
+		g.isStream = true
+	case "udp", "udp4", "udp6":
+		g.isStream = false
+	default:
+		return 0, fmt.Errorf("unknown protocol '%s' in '%s'", g.scheme, g.Address)
+	}
+	if g.MaxBufferSize <= 0 {
+		g.MaxBufferSize = 65535
+	}
+	return 0, nil
+}
+
+func (g *ServiceGraphite) Description() string {
+	return "graphite plaintext protocol input plugin for logtail"
+}
+
+// Collect is not used by the service input.
+func (g *ServiceGraphite) Collect(pipeline.Collector) error {
+	return nil
+}
+
+// Start starts the service with the v1 collector.
+func (g *ServiceGraphite) Start(collector pipeline.Collector) error {
+	g.collector = collector
+	return g.start()
+}
+
+// StartService starts the service by plugin runner v2.
+func (g *ServiceGraphite) StartService(context pipeline.PipelineContext) error {
+	g.collectorV2 = context.Collector()
+	return g.start()
+}
+
+func (g *ServiceGraphite) start() error {
+	if g.isStream {
+		l, err := net.Listen(g.scheme, g.host)
+		if err != nil {
+			logger.Error(g.context.GetRuntimeContext(), util.AlarmServiceGraphiteInit, "net.Listen error", err, "Address", g.Address)
+			return err
+		}
+		g.listener = l
+		g.connections = make(map[net.Conn]struct{})
+		g.wg.Add(1)
+		go g.listenStream()
+	} else {
+		l, err := net.ListenPacket(g.scheme, g.host)
+		if err != nil {
+			logger.Error(g.context.GetRuntimeContext(), util.AlarmServiceGraphiteInit, "net.ListenPacket error", err, "Address", g.Address)
+			return err
+		}
+		g.packetConn = l
+		g.wg.Add(1)
+		go g.listenPacket()
+	}
+	logger.Info(g.context.GetRuntimeContext(), "graphite server start", g.Address)
+	return nil
+}
+
+// Stop closes the listener and all the connections.
+func (g *ServiceGraphite) Stop() error {
+	if g.listener != nil {
+		_ = g.listener.Close()
+	}
+	if g.packetConn != nil {
+		_ = g.packetConn.Close()
+	}
+	g.connMu.Lock()
+	for conn := range g.connections {
+		_ = conn.Close()
+	}
+	g.connMu.Unlock()
+	g.wg.Wait()
+	logger.Info(g.context.GetRuntimeContext(), "graphite server stop", g.Address)
+	return nil
+}
+
+func (g *ServiceGraphite) listenStream() {
+	defer g.wg.Done()
+	for {
+		conn, err := g.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			logger.Error(g.context.GetRuntimeContext(), util.AlarmServiceGraphiteStream, "accept error", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		g.connMu.Lock()
+		if g.MaxConnections > 0 && len(g.connections) >= g.MaxConnections {
+			g.connMu.Unlock()
+			logger.Warning(g.context.GetRuntimeContext(), util.AlarmServiceGraphiteStream, "too many connections, close", conn.RemoteAddr().String())
+			_ = conn.Close()
+			continue
+		}
+		g.connections[conn] = struct{}{}
+		g.connMu.Unlock()
+
+		g.wg.Add(1)
+		go g.handle(conn)
+	}
+}
+
+// handle decodes the complete lines received so far in one batch whenever the connection has no more buffered data.
+// The lines longer than MaxBufferSize are dropped, so that a client never sending '\n' could not exhaust the memory.
+func (g *ServiceGraphite) handle(conn net.Conn) {
+	defer func() {
+		g.connMu.Lock()
+		delete(g.connections, conn)
+		g.connMu.Unlock()
+		_ = conn.Close()
+		g.wg.Done()
+	}()
+
+	reader := bufio.NewReader(conn)
+	batch := make([]byte, 0, 4096)
+	// lineStart is the offset of the incomplete line in the batch.
+	lineStart := 0
+	dropping := false
+	for {
+		if g.TimeoutSeconds > 0 {
+			_ = conn.SetReadDeadline(time.Now().Add(time.Duration(g.TimeoutSeconds) * time.Second))
+		}
+		line, err := reader.ReadSlice('\n')
+		if dropping {
+			// skip the rest of the too long line
+			if len(line) > 0 && line[len(line)-1] == '\n' {
+				dropping = false
+			}
+		} else {
+			batch = append(batch, line...)
+		}
+		if err != nil && !errors.Is(err, bufio.ErrBufferFull) {
+			g.decode(batch)
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				logger.Warning(g.context.GetRuntimeContext(), util.AlarmServiceGraphiteStream, "read error", err, "remote", conn.RemoteAddr().String())
+			}
+			return
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			if !dropping && len(batch)-lineStart > g.MaxBufferSize {
+				logger.Warning(g.context.GetRuntimeContext(), util.AlarmServiceGraphiteStream, "drop the line longer than", g.MaxBufferSize, "remote", conn.RemoteAddr().String())
+				batch = batch[:lineStart]
+				dropping = true
+			}
+			continue
+		}
+		if !dropping {
+			lineStart = len(batch)
+		}
+		if lineStart > 0 && (reader.Buffered() == 0 || lineStart >= g.MaxBufferSize) {
+			g.decode(batch[:lineStart])
+			batch = batch[:0]
+			lineStart = 0
+		}
+	}
+}
+
+func (g *ServiceGraphite) listenPacket() {
+	defer g.wg.Done()
+	buf := make([]byte, g.MaxBufferSize)
+	for {
+		n, _, err := g.packetConn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			logger.Error(g.context.GetRuntimeContext(), util.AlarmServiceGraphitePacket, "read error", err)
+			continue
+		}
+		g.decode(buf[:n])
+	}
+}
+
+func (g *ServiceGraphite) decode(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	if g.collectorV2 != nil {
+		groups, err := g.decoder.DecodeV2(data, nil)
+		if err != nil {
+			logger.Warning(g.context.GetRuntimeContext(), util.AlarmServiceGraphiteDecode, "decode error", err)
+			return
+		}
+		g.collectorV2.CollectList(groups...)
+		return
+	}
+	logs, err := g.decoder.Decode(data, nil, nil)
+	if err != nil {
+		logger.Warning(g.context.GetRuntimeContext(), util.AlarmServiceGraphiteDecode, "decode error", err)
+		return
+	}
+	for _, log := range logs {
+		g.collector.AddRawLog(log)
+	}
+}
+
+func init() {
+	pipeline.ServiceInputs[pluginName] = func() pipeline.ServiceInput {
+		return &ServiceGraphite{
+			Address:       "tcp://0.0.0.0:" + defaultGraphitePort,
+			MaxBufferSize: 65535,
+		}
+	}
+}
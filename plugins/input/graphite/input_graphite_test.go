@@ -0,0 +1,120 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphite
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/plugins/test"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+func newInput(t *testing.T, address string) *ServiceGraphite {
+	g := pipeline.ServiceInputs[pluginName]().(*ServiceGraphite)
+	g.Address = address
+	_, err := g.Init(mock.NewEmptyContext("p", "l", "c"))
+	require.NoError(t, err)
+	return g
+}
+
+func localAddr(g *ServiceGraphite) string {
+	if g.listener != nil {
+		return g.listener.Addr().String()
+	}
+	return g.packetConn.LocalAddr().String()
+}
+
+func TestInvalidAddress(t *testing.T) {
+	g := pipeline.ServiceInputs[pluginName]().(*ServiceGraphite)
+	g.Address = "unix:///tmp/graphite.sock"
+	_, err := g.Init(mock.NewEmptyContext("p", "l", "c"))
+	assert.Error(t, err)
+}
+
+func TestServiceGraphite_TCP(t *testing.T) {
+	g := newInput(t, "tcp://127.0.0.1:0")
+	collector := &test.MockCollector{}
+	require.NoError(t, g.Start(collector))
+
+	conn, err := net.Dial("tcp", localAddr(g))
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("cpu.load;host=a 1.5 1434055562\nbad line\nmem.used 1024 "))
+	require.NoError(t, err)
+	time.Sleep(time.Millisecond * 100)
+	_, err = conn.Write([]byte("1434055562\n"))
+	require.NoError(t, err)
+	_ = conn.Close()
+
+	time.Sleep(time.Millisecond * 200)
+	require.NoError(t, g.Stop())
+	require.Len(t, collector.RawLogs, 2)
+	assert.Equal(t, "cpu_load", collector.RawLogs[0].Contents[0].Value)
+	assert.Equal(t, "host#$#a", collector.RawLogs[0].Contents[1].Value)
+	assert.Equal(t, "mem_used", collector.RawLogs[1].Contents[0].Value)
+	assert.Equal(t, "1024", collector.RawLogs[1].Contents[3].Value)
+}
+
+func TestServiceGraphite_TCPLongLine(t *testing.T) {
+	g := pipeline.ServiceInputs[pluginName]().(*ServiceGraphite)
+	g.Address = "tcp://127.0.0.1:0"
+	g.MaxBufferSize = 100
+	_, err := g.Init(mock.NewEmptyContext("p", "l", "c"))
+	require.NoError(t, err)
+	collector := &test.MockCollector{}
+	require.NoError(t, g.Start(collector))
+
+	conn, err := net.Dial("tcp", localAddr(g))
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("cpu.load 1.5 1434055562\n" + strings.Repeat("a", 10000) + " 1 1434055562\nmem.used 1024 1434055562\n"))
+	require.NoError(t, err)
+	_ = conn.Close()
+
+	time.Sleep(time.Millisecond * 200)
+	require.NoError(t, g.Stop())
+	require.Len(t, collector.RawLogs, 2)
+	assert.Equal(t, "cpu_load", collector.RawLogs[0].Contents[0].Value)
+	assert.Equal(t, "mem_used", collector.RawLogs[1].Contents[0].Value)
+}
+
+func TestServiceGraphite_UDPV2(t *testing.T) {
+	g := newInput(t, "udp://127.0.0.1:0")
+	ctx := pipeline.NewObservePipelineConext(10)
+	require.NoError(t, g.StartService(ctx))
+
+	conn, err := net.Dial("udp", localAddr(g))
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("cpu.load;host=a 1.5 1434055562\nmem.used 1024 1434055562\n"))
+	require.NoError(t, err)
+	_ = conn.Close()
+
+	time.Sleep(time.Millisecond * 200)
+	require.NoError(t, g.Stop())
+	groups := ctx.Collector().ToArray()
+	require.Len(t, groups, 1)
+	require.Len(t, groups[0].Events, 2)
+	metric := groups[0].Events[0].(*models.Metric)
+	assert.Equal(t, "cpu.load", metric.GetName())
+	assert.Equal(t, "a", metric.GetTags().Get("host"))
+	assert.Equal(t, 1.5, metric.GetValue().GetSingleValue())
+	assert.Equal(t, uint64(1434055562*1e9), metric.GetTimestamp())
+}
@@ -0,0 +1,193 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpserver
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+
+	"github.com/alibaba/ilogtail/helper/decoder/common"
+)
+
+const (
+	authAllApps         = "*"
+	defaultJWTAppsClaim = "apps"
+)
+
+var (
+	errUnauthorized = errors.New("unauthorized")
+	errForbidden    = errors.New("forbidden")
+)
+
+// AuthConfig authenticates the requests by the bearer token in the Authorization header, which is either
+// one of the static Tokens or a JWT verified by the JWT config. Each token is only allowed to push the data
+// of its applications, which are the app names of the pyroscope profiles, so that the teams sharing a
+// cluster cannot inject profiles into the applications of each other.
+type AuthConfig struct {
+	// The static tokens and the application patterns allowed for each of them, "*" allows all applications.
+	Tokens map[string][]string
+	// Verify the tokens as JWTs when they are not static tokens.
+	JWT *JWTConfig
+
+	tokens    map[[sha256.Size]byte][]string
+	jwtParser *jwt.Parser
+	jwtKey    interface{}
+}
+
+// JWTConfig verifies the signature, the time, the issuer and the audience of the JWTs, and reads the
+// applications allowed from the AppsClaim.
+type JWTConfig struct {
+	// The secret of the HMAC signed tokens.
+	Secret string
+	// The PEM file of the RSA or ECDSA public key, used when Secret is empty.
+	PublicKeyFile string
+	Issuer        string
+	Audience      string
+	// The claim of the application patterns allowed, a string or a list of strings, "apps" by default.
+	AppsClaim string
+}
+
+func (a *AuthConfig) init() error {
+	a.tokens = make(map[[sha256.Size]byte][]string, len(a.Tokens))
+	for token, apps := range a.Tokens {
+		if token == "" {
+			return errors.New("empty auth token")
+		}
+		a.tokens[sha256.Sum256([]byte(token))] = apps
+	}
+	if a.JWT == nil {
+		if len(a.tokens) == 0 {
+			return errors.New("must specify Tokens or JWT of auth")
+		}
+		return nil
+	}
+	if a.JWT.AppsClaim == "" {
+		a.JWT.AppsClaim = defaultJWTAppsClaim
+	}
+	switch {
+	case a.JWT.Secret != "":
+		a.jwtKey = []byte(a.JWT.Secret)
+		a.jwtParser = &jwt.Parser{ValidMethods: []string{"HS256", "HS384", "HS512"}}
+	case a.JWT.PublicKeyFile != "":
+		pem, err := os.ReadFile(a.JWT.PublicKeyFile)
+		if err != nil {
+			return fmt.Errorf("read jwt public key error: %w", err)
+		}
+		if a.jwtKey, err = jwt.ParseRSAPublicKeyFromPEM(pem); err == nil {
+			a.jwtParser = &jwt.Parser{ValidMethods: []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}}
+		} else if a.jwtKey, err = jwt.ParseECPublicKeyFromPEM(pem); err == nil {
+			a.jwtParser = &jwt.Parser{ValidMethods: []string{"ES256", "ES384", "ES512"}}
+		} else {
+			return fmt.Errorf("invalid jwt public key %v", a.JWT.PublicKeyFile)
+		}
+	default:
+		return errors.New("must specify Secret or PublicKeyFile of jwt")
+	}
+	return nil
+}
+
+// authorize returns errUnauthorized if the token of the request is invalid, and errForbidden if the token
+// is not allowed to push the data of the application.
+func (a *AuthConfig) authorize(r *http.Request, format string) error {
+	auth := r.Header.Get("Authorization")
+	if len(auth) <= len("Bearer ") || !strings.EqualFold(auth[:len("Bearer ")], "Bearer ") {
+		return errUnauthorized
+	}
+	token := auth[len("Bearer "):]
+	apps, ok := a.tokens[sha256.Sum256([]byte(token))]
+	if !ok {
+		if a.jwtParser == nil {
+			return errUnauthorized
+		}
+		var err error
+		if apps, err = a.parseJWT(token); err != nil {
+			return errUnauthorized
+		}
+	}
+	// only the pyroscope requests carry the application
+	if format != common.ProtocolPyroscope {
+		return nil
+	}
+	if !matchApp(apps, pyroscopeAppName(r)) {
+		return errForbidden
+	}
+	return nil
+}
+
+func (a *AuthConfig) parseJWT(token string) ([]string, error) {
+	claims := jwt.MapClaims{}
+	if _, err := a.jwtParser.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return a.jwtKey, nil
+	}); err != nil {
+		return nil, err
+	}
+	if a.JWT.Issuer != "" && !claims.VerifyIssuer(a.JWT.Issuer, true) {
+		return nil, errors.New("invalid issuer")
+	}
+	if a.JWT.Audience != "" && !claims.VerifyAudience(a.JWT.Audience, true) {
+		return nil, errors.New("invalid audience")
+	}
+	switch v := claims[a.JWT.AppsClaim].(type) {
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		apps := make([]string, 0, len(v))
+		for _, app := range v {
+			if s, ok := app.(string); ok {
+				apps = append(apps, s)
+			}
+		}
+		return apps, nil
+	}
+	return nil, nil
+}
+
+// pyroscopeAppName returns the app name in the name param, e.g. myapp of myapp.cpu{env=prod}.
+func pyroscopeAppName(r *http.Request) string {
+	name := r.URL.Query().Get("name")
+	if i := strings.IndexByte(name, '{'); i >= 0 {
+		name = name[:i]
+	}
+	return strings.TrimSpace(name)
+}
+
+// matchApp reports whether the app, with or without the profile type suffix such as .cpu, matches any of the patterns.
+func matchApp(patterns []string, app string) bool {
+	if app == "" {
+		return false
+	}
+	candidates := []string{app}
+	if i := strings.LastIndexByte(app, '.'); i > 0 {
+		candidates = append(candidates, app[:i])
+	}
+	for _, pattern := range patterns {
+		if pattern == authAllApps {
+			return true
+		}
+		for _, candidate := range candidates {
+			if ok, _ := path.Match(pattern, candidate); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpserver
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/require"
+	"gotest.tools/assert"
+)
+
+func TestMatchApp(t *testing.T) {
+	assert.Assert(t, matchApp([]string{"*"}, "any"))
+	assert.Assert(t, matchApp([]string{"team-a-*"}, "team-a-svc"))
+	assert.Assert(t, matchApp([]string{"svc"}, "svc.cpu"))
+	assert.Assert(t, !matchApp([]string{"svc"}, "svc2.cpu"))
+	assert.Assert(t, !matchApp([]string{"*"}, ""))
+}
+
+func TestInputAuth(t *testing.T) {
+	input, err := newInputWithOpts("pyroscope", func(input *ServiceHTTP) {
+		input.Auth = &AuthConfig{
+			Tokens: map[string][]string{"token-a": {"team-a-*"}},
+			JWT:    &JWTConfig{Secret: "secret", Issuer: "ilogtail"},
+		}
+	})
+	require.NoError(t, err)
+	input.collector = &mockCollector{}
+	input.version = v1
+
+	serve := func(app, token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/ingest?name="+app+".cpu%7Benv%3Dprod%7D&spyName=gospy", bytes.NewBufferString("foo;bar 100\n"))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		input.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+	sign := func(claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+		require.NoError(t, err)
+		return token
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve("team-a-svc", ""))
+	assert.Equal(t, http.StatusUnauthorized, serve("team-a-svc", "invalid"))
+	assert.Equal(t, http.StatusOK, serve("team-a-svc", "token-a"))
+	assert.Equal(t, http.StatusForbidden, serve("team-b-svc", "token-a"))
+
+	exp := time.Now().Add(time.Hour).Unix()
+	assert.Equal(t, http.StatusOK, serve("team-b-svc", sign(jwt.MapClaims{"iss": "ilogtail", "exp": exp, "apps": []string{"team-b-*"}})))
+	assert.Equal(t, http.StatusForbidden, serve("team-a-svc", sign(jwt.MapClaims{"iss": "ilogtail", "exp": exp, "apps": "team-b-svc"})))
+	assert.Equal(t, http.StatusUnauthorized, serve("team-b-svc", sign(jwt.MapClaims{"iss": "other", "exp": exp, "apps": "*"})))
+	assert.Equal(t, http.StatusUnauthorized, serve("team-b-svc", sign(jwt.MapClaims{"iss": "ilogtail", "exp": time.Now().Add(-time.Hour).Unix(), "apps": "*"})))
+
+	_, err = newInputWithOpts("pyroscope", func(input *ServiceHTTP) {
+		input.Auth = &AuthConfig{}
+	})
+	assert.ErrorContains(t, err, "must specify Tokens or JWT")
+}
@@ -16,20 +16,24 @@ package httpserver
 
 import (
 	"context"
+	"crypto/tls"
+	"io"
 	"net"
 	"net/http"
-	"net/url"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/alibaba/ilogtail/helper"
-	"github.com/alibaba/ilogtail/helper/decoder"
 	"github.com/alibaba/ilogtail/helper/decoder/common"
+	"github.com/alibaba/ilogtail/helper/decoder/pyroscope"
+	"github.com/alibaba/ilogtail/helper/k8smeta"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/models"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/tlscommon"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 const (
@@ -43,9 +47,10 @@ const name = "service_http_server"
 type ServiceHTTP struct {
 	context     pipeline.Context
 	collector   pipeline.Collector
-	decoder     decoder.Decoder
+	routes      []*Route
 	server      *http.Server
 	listener    net.Listener
+	tlsConfig   *tls.Config
 	wg          sync.WaitGroup
 	collectorV2 pipeline.PipelineCollector
 	version     int8
@@ -61,9 +66,42 @@ type ServiceHTTP struct {
 	ShutdownTimeoutSec int
 	MaxBodySize        int64
 	UnlinkUnixSock     bool
-	FieldsExtend       bool
-	DisableUncompress  bool
-	Tags               map[string]string // todo for v2
+	// UnixSocket sets the file permissions of the socket when Address is unix:///path/to/socket
+	UnixSocket        *helper.UnixSocketConfig
+	FieldsExtend      bool
+	DisableUncompress bool
+	FieldMapping      map[string]string // maps the CEF or LEEF keys to the log fields for the cef and leef formats
+	Tags              map[string]string // todo for v2
+	// TLS serves https with the server certificate, and verifies the client certificates if ClientAuth is set
+	TLS *tlscommon.TLSConfig
+	// Auth verifies the bearer tokens of the requests, and the applications allowed for the pyroscope format
+	Auth *AuthConfig
+	// ProfileTrimPathPrefixes strips the path prefixes from the file names of the pprof profiles, keyed by the
+	// application name or "*" for all the applications
+	ProfileTrimPathPrefixes map[string][]string
+	// ProfileDiff appends the "diff" column of the stack deltas between the consecutive uploads of the same profile
+	ProfileDiff bool
+	// ProfileHeapDumpTriggers emit the heap dump trigger events when the memory profiles of the applications exceed
+	// the thresholds, keyed by the application name or "*" for the other applications
+	ProfileHeapDumpTriggers map[string]*pyroscope.HeapDumpTrigger
+	// ProfileClockSkew corrects the time ranges of the profiles sent by the agents with the clocks ahead
+	ProfileClockSkew *pyroscope.ClockSkewConfig
+	// ProfileParseTimeoutSec aborts the profile uploads whose decompression and parsing exceed the seconds, and
+	// zero means no limit.
+	ProfileParseTimeoutSec int
+	// ProfileK8sMeta adds the namespace, pod, node and workload labels of the pods sending the profiles, which are
+	// resolved by the client ips from the shared kubernetes metadata cache.
+	ProfileK8sMeta *k8smeta.Options
+	// JSONSchemaFile is the JSON Schema or OpenAPI document validating the objects of the json format
+	JSONSchemaFile string
+	// JSONSchemaPointer is the JSON pointer of the schema in JSONSchemaFile, the whole document if empty
+	JSONSchemaPointer string
+	// CORS allows the browsers to send the data from the pages of the other origins
+	CORS *CORSConfig
+	// MaxRequestsPerSecond rejects the excess requests with 429, and zero means no limit
+	MaxRequestsPerSecond float64
+	// Routes serve the paths with their own formats on the same Address, which take precedence over Format and Path
+	Routes []*Route
 
 	// params below works only for version v2
 	QueryParams       []string
@@ -76,23 +114,22 @@ type ServiceHTTP struct {
 func (s *ServiceHTTP) Init(context pipeline.Context) (int, error) {
 	s.context = context
 	var err error
-	if s.decoder, err = decoder.GetDecoderWithOptions(s.Format, decoder.Option{FieldsExtend: s.FieldsExtend, DisableUncompress: s.DisableUncompress}); err != nil {
+	if err = s.initRoutes(); err != nil {
 		return 0, err
 	}
-
-	if s.Path == "" {
-		switch s.Format {
-		case common.ProtocolOTLPLogV1:
-			s.Path = "/v1/logs"
-		case common.ProtocolOTLPMetricV1:
-			s.Path = "/v1/metrics"
-		case common.ProtocolOTLPTraceV1:
-			s.Path = "/v1/traces"
-		case common.ProtocolPyroscope:
-			s.Path = "/ingest"
+	if err = s.UnixSocket.Init(); err != nil {
+		return 0, err
+	}
+	if s.TLS != nil {
+		if s.tlsConfig, err = s.TLS.LoadServerTLSConfig(); err != nil {
+			return 0, err
+		}
+	}
+	if s.Auth != nil {
+		if err = s.Auth.init(); err != nil {
+			return 0, err
 		}
 	}
-	s.Address += s.Path
 	logger.Infof(context.GetRuntimeContext(), "addr", s.Address, "format", s.Format)
 
 	s.paramCount = len(s.QueryParams) + len(s.HeaderParams)
@@ -116,11 +153,37 @@ func (s *ServiceHTTP) Collect(pipeline.Collector) error {
 }
 
 func (s *ServiceHTTP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if r.ContentLength > s.MaxBodySize {
+	route := s.matchRoute(r)
+	if route == nil {
+		NotFound(w)
+		return
+	}
+	if route.CORS.serveCORS(w, r) {
+		return
+	}
+	if s.Auth != nil {
+		if err := s.Auth.authorize(r, route.Format); err != nil {
+			logger.Warning(s.context.GetRuntimeContext(), util.AlarmHTTPAuth, "reject request", err, "request", r.URL.String(), "remote", r.RemoteAddr)
+			if err == errForbidden {
+				Forbidden(w)
+			} else {
+				Unauthorized(w)
+			}
+			return
+		}
+	}
+	if !route.allow() {
+		TooManyRequests(w)
+		return
+	}
+	if serveInfluxdbAPI(route.Format, w, r) {
+		return
+	}
+	if r.ContentLength > route.MaxBodySize {
 		TooLarge(w)
 		return
 	}
-	data, statusCode, err := s.decoder.ParseRequest(w, r, s.MaxBodySize)
+	data, statusCode, err := route.decoder.ParseRequest(w, r, route.MaxBodySize)
 	logger.Debugf(s.context.GetRuntimeContext(), "request [method] %v; [header] %v; [url] %v; [body len] %d", r.Method, r.Header, r.URL, len(data))
 	switch statusCode {
 	case http.StatusBadRequest:
@@ -133,7 +196,7 @@ func (s *ServiceHTTP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		MethodNotAllowed(w)
 	}
 	if err != nil {
-		logger.Warning(s.context.GetRuntimeContext(), "READ_BODY_FAIL_ALARM", "read body failed", err, "request", r.URL.String())
+		logger.Warning(s.context.GetRuntimeContext(), util.AlarmReadBodyFail, "read body failed", err, "request", r.URL.String())
 		return
 	}
 
@@ -148,9 +211,9 @@ func (s *ServiceHTTP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	switch s.version {
 	case v1:
-		logs, err := s.decoder.Decode(data, r, s.Tags)
+		logs, err := route.decoder.Decode(data, r, route.Tags)
 		if err != nil {
-			logger.Warning(s.context.GetRuntimeContext(), "DECODE_BODY_FAIL_ALARM", "decode body failed", err, "request", r.URL.String())
+			logger.Warning(s.context.GetRuntimeContext(), util.AlarmDecodeBodyFail, "decode body failed", err, "request", r.URL.String())
 			BadRequest(w)
 			return
 		}
@@ -158,9 +221,9 @@ func (s *ServiceHTTP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			s.collector.AddRawLog(log)
 		}
 	case v2:
-		groups, err := s.decoder.DecodeV2(data, r)
+		groups, err := route.decoder.DecodeV2(data, r)
 		if err != nil {
-			logger.Warning(s.context.GetRuntimeContext(), "DECODE_BODY_FAIL_ALARM", "decode body failed", err, "request", r.URL.String())
+			logger.Warning(s.context.GetRuntimeContext(), util.AlarmDecodeBodyFail, "decode body failed", err, "request", r.URL.String())
 			BadRequest(w)
 			return
 		}
@@ -172,7 +235,7 @@ func (s *ServiceHTTP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		s.collectorV2.CollectList(groups...)
 	}
 
-	switch s.Format {
+	switch route.Format {
 	case common.ProtocolSLS:
 		w.Header().Set("x-log-requestid", "1234567890abcde")
 		w.WriteHeader(http.StatusOK)
@@ -183,6 +246,25 @@ func (s *ServiceHTTP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// serveInfluxdbAPI answers the health check and the database creation requests sent by the influxdb clients such as Telegraf
+// before writing, which carry no data to decode.
+func serveInfluxdbAPI(format string, w http.ResponseWriter, r *http.Request) bool {
+	if format != common.ProtocolInflux && format != common.ProtocolInfluxdb {
+		return false
+	}
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/ping"):
+		w.WriteHeader(http.StatusNoContent)
+	case strings.HasSuffix(r.URL.Path, "/query"):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"results":[{"statement_id":0}]}`))
+	default:
+		return false
+	}
+	return true
+}
+
 func TooLarge(res http.ResponseWriter) {
 	res.Header().Set("Content-Type", "application/json")
 	res.WriteHeader(http.StatusRequestEntityTooLarge)
@@ -195,6 +277,19 @@ func MethodNotAllowed(res http.ResponseWriter) {
 	_, _ = res.Write([]byte(`{"error":"http: method not allowed"}`))
 }
 
+func Unauthorized(res http.ResponseWriter) {
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("WWW-Authenticate", "Bearer")
+	res.WriteHeader(http.StatusUnauthorized)
+	_, _ = res.Write([]byte(`{"error":"http: unauthorized"}`))
+}
+
+func Forbidden(res http.ResponseWriter) {
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusForbidden)
+	_, _ = res.Write([]byte(`{"error":"http: forbidden"}`))
+}
+
 func InternalServerError(res http.ResponseWriter) {
 	res.Header().Set("Content-Type", "application/json")
 	res.WriteHeader(http.StatusInternalServerError)
@@ -228,29 +323,16 @@ func (s *ServiceHTTP) start() error {
 		Handler:     s,
 		ReadTimeout: time.Duration(s.ReadTimeoutSec) * time.Second,
 	}
-	var listener net.Listener
-	var err error
-	switch {
-	case strings.HasPrefix(s.Address, "unix"):
-		sockPath := strings.Replace(s.Address, "unix://", "", 1)
-		if s.UnlinkUnixSock {
-			_ = syscall.Unlink(sockPath)
-		}
-		listener, err = net.Listen("unix", sockPath)
-	case strings.HasPrefix(s.Address, "http") ||
-		strings.HasPrefix(s.Address, "https") ||
-		strings.HasPrefix(s.Address, "tcp"):
-		configURL, errAddr := url.Parse(s.Address)
-		if errAddr != nil {
-			return errAddr
-		}
-		listener, err = net.Listen("tcp", configURL.Host)
-	default:
-		listener, err = net.Listen("tcp", s.Address)
+	if s.UnlinkUnixSock && strings.HasPrefix(s.Address, "unix") {
+		_ = syscall.Unlink(strings.Replace(s.Address, "unix://", "", 1))
 	}
+	listener, err := helper.Listen(s.Address, "tcp", s.UnixSocket)
 	if err != nil {
 		return err
 	}
+	if s.tlsConfig != nil {
+		listener = tls.NewListener(listener, s.tlsConfig)
+	}
 	s.listener = listener
 	s.server = server
 	go func() {
@@ -309,6 +391,13 @@ func (s *ServiceHTTP) Stop() error {
 	if s.dumper != nil {
 		s.dumper.Close()
 	}
+	// the decoders may hold shared resources, such as the kubernetes metadata cache of the profiles.
+	for _, route := range s.routes {
+		if closer, ok := route.decoder.(io.Closer); ok {
+			_ = closer.Close()
+		}
+	}
+	_ = s.TLS.Close()
 	return nil
 }
 
@@ -16,16 +16,25 @@ package httpserver
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"syscall"
 	"testing"
 	"time"
 
+	"github.com/alibaba/ilogtail/helper"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/tlscommon"
 	pluginmanager "github.com/alibaba/ilogtail/pluginmanager"
 
 	"github.com/stretchr/testify/require"
@@ -274,6 +283,42 @@ func TestInputInfluxDBWithFieldsExtend(t *testing.T) {
 	}
 }
 
+func TestInputTLS(t *testing.T) {
+	// reuses the certificate of the httptest server, which is valid for 127.0.0.1
+	certServer := httptest.NewTLSServer(http.NotFoundHandler())
+	certServer.Close()
+	cert := certServer.TLS.Certificates[0]
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0600))
+	keyDER, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600))
+
+	input, err := newInputWithOpts("influx", func(input *ServiceHTTP) {
+		input.Address = "127.0.0.1:0"
+		input.TLS = &tlscommon.TLSConfig{Enabled: true, CertFile: certFile, KeyFile: keyFile}
+	})
+	require.NoError(t, err)
+	collector := &mockCollector{}
+	require.NoError(t, input.Start(collector))
+	defer func() {
+		require.NoError(t, input.Stop())
+	}()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: certServer.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs}}}
+	resp, err := client.Post(fmt.Sprintf("https://%s/write", input.listener.Addr()), "text/plain", bytes.NewBufferString("cpu value=1i\n"))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, 1, len(collector.rawLogs))
+
+	_, err = newInputWithOpts("influx", func(input *ServiceHTTP) {
+		input.TLS = &tlscommon.TLSConfig{Enabled: true, CertFile: certFile}
+	})
+	require.Error(t, err)
+}
+
 func TestUnlinkUnixSock(t *testing.T) {
 	const sockPath = "test_service_http_server_unlink_unix_sock.run"
 	_ = syscall.Unlink(sockPath)
@@ -393,3 +438,56 @@ func TestInputWithRequestParamsWithoutPrefix(t *testing.T) {
 	}
 
 }
+
+func TestInputInfluxDBV2(t *testing.T) {
+	input, err := newInput("influxdb")
+	require.NoError(t, err)
+	inputCtx := pipeline.NewObservePipelineConext(10)
+	input.collectorV2 = inputCtx.Collector()
+	input.version = v2
+
+	recorder := httptest.NewRecorder()
+	input.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	input.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/query?q=CREATE+DATABASE+telegraf", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `{"results":[{"statement_id":0}]}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	input.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/write?db=telegraf", bytes.NewBufferString("cpu,host=server01 value=1 1434055562000000000\n")))
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+
+	res := inputCtx.Collector().ToArray()
+	require.Equal(t, 1, len(res))
+	assert.Equal(t, "telegraf", res[0].Group.Metadata.Get("db"))
+	require.Equal(t, 1, len(res[0].Events))
+	assert.Equal(t, "cpu", res[0].Events[0].GetName())
+}
+
+func TestInputUnixSocket(t *testing.T) {
+	sockPath := filepath.Join(t.TempDir(), "input.sock")
+	input, err := newInputWithOpts("influx", func(input *ServiceHTTP) {
+		input.Address = "unix://" + sockPath
+		input.UnixSocket = &helper.UnixSocketConfig{Mode: "0660"}
+	})
+	require.NoError(t, err)
+	collector := &mockCollector{}
+	require.NoError(t, input.Start(collector))
+	defer input.Stop() //nolint:errcheck
+
+	stat, err := os.Stat(sockPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o660), stat.Mode().Perm())
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", sockPath)
+		},
+	}}
+	resp, err := client.Post("http://localhost/write", "text/plain", bytes.NewBufferString("cpu,host=server01 value=1 1434055562000000000\n"))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, 1, len(collector.rawLogs))
+}
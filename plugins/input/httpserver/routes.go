@@ -0,0 +1,257 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpserver
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/time/rate"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/helper/decoder"
+	"github.com/alibaba/ilogtail/helper/decoder/common"
+	converter "github.com/alibaba/ilogtail/pkg/protocol/converter"
+)
+
+var defaultCORSHeaders = []string{"Content-Type", "Content-Encoding", "Authorization"}
+
+// Route serves the requests of a path with its own format, so that the data of several formats, such as
+// the OTLP logs sent by the applications and the profiles sent by the pyroscope agents, is received by one
+// listener rather than one port per format.
+type Route struct {
+	// Path matches the requests of the path and its sub paths, such as /influx/write for /influx. It's the
+	// default path of the format when empty, such as /v1/logs of otlp_logv1 and /ingest of pyroscope.
+	Path              string
+	Format            string
+	FieldsExtend      bool
+	DisableUncompress bool
+	FieldMapping      map[string]string
+	// Tags are added to the logs of the route, the Tags of the input are used when nil.
+	Tags map[string]string
+	// JSONSchemaFile and JSONSchemaPointer validate the objects of the json format, the ones of the input
+	// are used when JSONSchemaFile is empty.
+	JSONSchemaFile    string
+	JSONSchemaPointer string
+	// MaxBodySize overrides the MaxBodySize of the input when positive.
+	MaxBodySize int64
+	// MaxRequestsPerSecond rejects the excess requests of the route with 429, the MaxRequestsPerSecond of the
+	// input is used when 0, and each route has its own limit.
+	MaxRequestsPerSecond float64
+	// CORS overrides the CORS of the input.
+	CORS *CORSConfig
+
+	decoder decoder.Decoder
+	limiter *rate.Limiter
+}
+
+// CORSConfig allows the browsers to send the data from the pages of the other origins, such as the
+// frontend logs and the web vitals. The requests from the origins not allowed are rejected.
+type CORSConfig struct {
+	// AllowedOrigins are the origins allowed, such as https://www.example.com, "*" allows all origins.
+	AllowedOrigins []string
+	// AllowedHeaders are the request headers allowed, Content-Type, Content-Encoding and Authorization by default.
+	AllowedHeaders []string
+	// AllowCredentials allows the cookies and the authorization headers, the origin is echoed rather than "*".
+	AllowCredentials bool
+	// MaxAgeSec is the seconds to cache the preflight response, 0 means not cached.
+	MaxAgeSec int
+}
+
+// defaultPath returns the path used by the clients of the format by default.
+func defaultPath(format string) string {
+	switch format {
+	case common.ProtocolOTLPLogV1:
+		return "/v1/logs"
+	case common.ProtocolOTLPMetricV1:
+		return "/v1/metrics"
+	case common.ProtocolOTLPTraceV1:
+		return "/v1/traces"
+	case common.ProtocolPyroscope:
+		return "/ingest"
+	}
+	return ""
+}
+
+// initRoutes creates the decoders of the routes, and sorts the routes by the length of the paths so that
+// the longest path is matched first. The input is served as a single route matching all paths when no
+// route is configured.
+func (s *ServiceHTTP) initRoutes() error {
+	if len(s.Routes) == 0 {
+		if s.Path == "" {
+			s.Path = defaultPath(s.Format)
+		}
+		if !strings.HasPrefix(s.Address, "unix") {
+			// the path is for the http clients, the socket path is not changed
+			s.Address += s.Path
+		}
+		s.routes = []*Route{{
+			Format:               s.Format,
+			FieldsExtend:         s.FieldsExtend,
+			DisableUncompress:    s.DisableUncompress,
+			FieldMapping:         s.FieldMapping,
+			MaxRequestsPerSecond: s.MaxRequestsPerSecond,
+		}}
+	} else {
+		paths := make(map[string]bool, len(s.Routes))
+		for i, route := range s.Routes {
+			if route.Path == "" {
+				route.Path = defaultPath(route.Format)
+			}
+			if !strings.HasPrefix(route.Path, "/") {
+				return fmt.Errorf("the path %q of route %d must start with /", route.Path, i)
+			}
+			if paths[route.Path] {
+				return fmt.Errorf("duplicate path %s of route %d", route.Path, i)
+			}
+			paths[route.Path] = true
+		}
+		s.routes = append([]*Route(nil), s.Routes...)
+		sort.SliceStable(s.routes, func(i, j int) bool {
+			return len(s.routes[i].Path) > len(s.routes[j].Path)
+		})
+	}
+	for _, route := range s.routes {
+		if route.Tags == nil {
+			route.Tags = s.Tags
+		}
+		if route.JSONSchemaFile == "" {
+			route.JSONSchemaFile, route.JSONSchemaPointer = s.JSONSchemaFile, s.JSONSchemaPointer
+		}
+		if route.MaxBodySize <= 0 {
+			route.MaxBodySize = s.MaxBodySize
+		}
+		if route.MaxRequestsPerSecond <= 0 {
+			route.MaxRequestsPerSecond = s.MaxRequestsPerSecond
+		}
+		var schema *converter.JSONSchema
+		if route.JSONSchemaFile != "" {
+			var err error
+			if schema, err = helper.LoadJSONSchema(route.JSONSchemaFile, route.JSONSchemaPointer); err != nil {
+				return fmt.Errorf("json schema of path %q: %w", route.Path, err)
+			}
+		}
+		if route.CORS == nil {
+			route.CORS = s.CORS
+		}
+		var err error
+		if route.decoder, err = decoder.GetDecoderWithOptions(route.Format, decoder.Option{
+			FieldsExtend:            route.FieldsExtend,
+			DisableUncompress:       route.DisableUncompress,
+			FieldMapping:            route.FieldMapping,
+			JSONSchema:              schema,
+			ProfileTrimPathPrefixes: s.ProfileTrimPathPrefixes,
+			ProfileDiff:             s.ProfileDiff,
+			ProfileHeapDumpTriggers: s.ProfileHeapDumpTriggers,
+			ProfileClockSkew:        s.ProfileClockSkew,
+			ProfileParseTimeoutSec:  s.ProfileParseTimeoutSec,
+			ProfileK8sMeta:          s.ProfileK8sMeta,
+		}); err != nil {
+			return fmt.Errorf("format %q of path %q: %w", route.Format, route.Path, err)
+		}
+		if route.MaxRequestsPerSecond > 0 {
+			route.limiter = rate.NewLimiter(rate.Limit(route.MaxRequestsPerSecond), int(math.Ceil(route.MaxRequestsPerSecond)))
+		}
+	}
+	return nil
+}
+
+// matchRoute returns the route with the longest path matching the request, or nil if none.
+func (s *ServiceHTTP) matchRoute(r *http.Request) *Route {
+	for _, route := range s.routes {
+		prefix := strings.TrimSuffix(route.Path, "/")
+		if prefix == "" || r.URL.Path == prefix || strings.HasPrefix(r.URL.Path, prefix+"/") {
+			return route
+		}
+	}
+	return nil
+}
+
+// allow returns false if the request exceeds the rate limit of the route.
+func (route *Route) allow() bool {
+	return route.limiter == nil || route.limiter.Allow()
+}
+
+// serveCORS sets the CORS headers for the requests from the browsers, and returns true if the request is
+// handled, which is either a preflight request or a request from the origin not allowed.
+func (c *CORSConfig) serveCORS(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if c == nil || origin == "" {
+		return false
+	}
+	preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+	w.Header().Add("Vary", "Origin")
+	if !c.allowOrigin(origin) {
+		Forbidden(w)
+		return true
+	}
+	if c.AllowCredentials || !c.allowAllOrigins() {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	} else {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	}
+	if c.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+	if !preflight {
+		return false
+	}
+	headers := c.AllowedHeaders
+	if len(headers) == 0 {
+		headers = defaultCORSHeaders
+	}
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT")
+	w.Header().Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+	if c.MaxAgeSec > 0 {
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(c.MaxAgeSec))
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+func (c *CORSConfig) allowAllOrigins() bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CORSConfig) allowOrigin(origin string) bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func TooManyRequests(res http.ResponseWriter) {
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Retry-After", "1")
+	res.WriteHeader(http.StatusTooManyRequests)
+	_, _ = res.Write([]byte(`{"error":"http: too many requests"}`))
+}
+
+func NotFound(res http.ResponseWriter) {
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusNotFound)
+	_, _ = res.Write([]byte(`{"error":"http: not found"}`))
+}
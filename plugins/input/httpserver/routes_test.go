@@ -0,0 +1,144 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpserver
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInputRoutes(t *testing.T) {
+	schemaFile := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(schemaFile, []byte(`{"definitions":{"log":{"type":"object","required":["msg"]}}}`), 0600))
+	input, err := newInputWithOpts("", func(input *ServiceHTTP) {
+		input.Tags = map[string]string{"source": "input"}
+		input.Routes = []*Route{
+			{Path: "/influx", Format: "influx"},
+			{Format: "otlp_logv1"},
+			{Path: "/logs", Format: "json", JSONSchemaFile: schemaFile, JSONSchemaPointer: "/definitions/log"},
+			{Path: "/logs/raw", Format: "raw", MaxBodySize: 4},
+		}
+	})
+	require.NoError(t, err)
+	collector := &mockCollector{}
+	input.collector = collector
+	input.version = v1
+
+	serve := func(path, body string) int {
+		recorder := httptest.NewRecorder()
+		input.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body)))
+		return recorder.Code
+	}
+	assert.Equal(t, http.StatusNoContent, serve("/influx/ping", ""))
+	assert.Equal(t, http.StatusNoContent, serve("/influx/write", "cpu,host=server01 value=1 1434055562000000000\n"))
+	assert.Equal(t, http.StatusNoContent, serve("/logs", `{"msg":"hello"}`))
+	assert.Equal(t, http.StatusBadRequest, serve("/logs", `{"message":"hello"}`))
+	// the longest path is matched first
+	assert.Equal(t, http.StatusRequestEntityTooLarge, serve("/logs/raw", "hello"))
+	assert.Equal(t, http.StatusNotFound, serve("/logsx", `{"msg":"hello"}`))
+	assert.Equal(t, http.StatusNotFound, serve("/", ""))
+	assert.Equal(t, "/v1/logs", input.Routes[1].Path)
+
+	require.Len(t, collector.rawLogs, 2)
+	assert.Equal(t, "cpu", collector.rawLogs[0].Contents[0].Value)
+	var fields []string
+	for _, content := range collector.rawLogs[1].Contents {
+		fields = append(fields, content.Key+"="+content.Value)
+	}
+	assert.Equal(t, []string{"msg=hello", "source=input"}, fields)
+
+	for _, routes := range [][]*Route{
+		{{Path: "/a", Format: "json"}, {Path: "/a", Format: "raw"}},
+		{{Format: "json"}},
+		{{Path: "/a", Format: "unknown"}},
+		{{Path: "/a", Format: "json", JSONSchemaFile: schemaFile, JSONSchemaPointer: "/definitions/missing"}},
+	} {
+		_, err = newInputWithOpts("", func(input *ServiceHTTP) {
+			input.Routes = routes
+		})
+		assert.Error(t, err)
+	}
+}
+
+func TestInputRouteRateLimit(t *testing.T) {
+	input, err := newInputWithOpts("", func(input *ServiceHTTP) {
+		input.MaxRequestsPerSecond = 2
+		input.Routes = []*Route{
+			{Path: "/limited", Format: "raw", MaxRequestsPerSecond: 0.5},
+			{Path: "/inherited", Format: "raw"},
+		}
+	})
+	require.NoError(t, err)
+	input.collector = &mockCollector{}
+	input.version = v1
+
+	serve := func(path string) int {
+		recorder := httptest.NewRecorder()
+		input.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString("a")))
+		return recorder.Code
+	}
+	assert.Equal(t, http.StatusNoContent, serve("/limited"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("/limited"))
+	// the route without its own limit uses the limit of the input
+	assert.Equal(t, http.StatusNoContent, serve("/inherited"))
+	assert.Equal(t, http.StatusNoContent, serve("/inherited"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("/inherited"))
+}
+
+func TestInputCORS(t *testing.T) {
+	input, err := newInputWithOpts("raw", func(input *ServiceHTTP) {
+		input.CORS = &CORSConfig{AllowedOrigins: []string{"https://www.example.com"}, AllowCredentials: true, MaxAgeSec: 600}
+	})
+	require.NoError(t, err)
+	input.collector = &mockCollector{}
+	input.version = v1
+
+	serve := func(method, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/", bytes.NewBufferString("a"))
+		req.Header.Set("Origin", origin)
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		}
+		recorder := httptest.NewRecorder()
+		input.ServeHTTP(recorder, req)
+		return recorder
+	}
+	recorder := serve(http.MethodOptions, "https://www.example.com")
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, "https://www.example.com", recorder.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", recorder.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "Content-Type, Content-Encoding, Authorization", recorder.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "600", recorder.Header().Get("Access-Control-Max-Age"))
+
+	recorder = serve(http.MethodPost, "https://www.example.com")
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, "https://www.example.com", recorder.Header().Get("Access-Control-Allow-Origin"))
+
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodPost, "https://evil.example.com").Code)
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodOptions, "https://evil.example.com").Code)
+
+	// the requests without origin are not from the browsers
+	recorder = httptest.NewRecorder()
+	input.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString("a")))
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("Access-Control-Allow-Origin"))
+}
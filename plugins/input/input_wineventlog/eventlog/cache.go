@@ -27,6 +27,7 @@ import (
 	"context"
 	"expvar"
 	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
 	"github.com/alibaba/ilogtail/plugins/input/input_wineventlog/eventlog/common"
 	"time"
 
@@ -148,7 +149,7 @@ func (hc *messageFilesCache) freeHandles(mf sys.MessageFiles) {
 	for _, fh := range mf.Handles {
 		err := hc.freer(fh.Handle)
 		if err != nil {
-			logger.Warningf(context.Background(), "WINEVENTLOG_UTIL_ALARM",
+			logger.Warningf(context.Background(), util.AlarmWinEventLogUtil,
 				"messageFilesCache[%s] FreeLibrary error for handle %v",
 				hc.eventLogName, fh.Handle)
 		}
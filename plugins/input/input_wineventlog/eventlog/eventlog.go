@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
 	"reflect"
 	"strconv"
 	"time"
@@ -124,7 +125,7 @@ func addPairs(m map[string]string, key string, pairs []sys.KeyValue) {
 	}
 	val, err := json.Marshal(sub)
 	if err != nil {
-		logger.Warningf(context.Background(), "WINEVENTLOG_UTIL_ALARM",
+		logger.Warningf(context.Background(), util.AlarmWinEventLogUtil,
 			"Call json.Marshal for %v failed %v", sub, err)
 		return
 	}
@@ -20,6 +20,7 @@ package eventlog
 import (
 	"fmt"
 	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
 	"io"
 	"syscall"
 
@@ -66,7 +67,7 @@ func (w *winEventLog) Open(checkpoint Checkpoint) error {
 	// https://msdn.microsoft.com/en-us/library/windows/desktop/aa385771(v=vs.85).aspx#pull
 	signalEvent, err := windows.CreateEvent(nil, 0, 0, nil)
 	if err != nil {
-		logger.Warningf(w.config.Context.GetRuntimeContext(), "WINEVENTLOG_API_ALARM",
+		logger.Warningf(w.config.Context.GetRuntimeContext(), util.AlarmWinEventLogAPI,
 			"%s Create event error: %v", w.logPrefix, err)
 		return err
 	}
@@ -113,14 +114,14 @@ func (w *winEventLog) Read() ([]Record, error) {
 			err = w.render(h, w.outputBuf)
 		}
 		if err != nil && w.outputBuf.Len() == 0 {
-			logger.Errorf(w.config.Context.GetRuntimeContext(), "WINEVENTLOG_API_ALARM",
+			logger.Errorf(w.config.Context.GetRuntimeContext(), util.AlarmWinEventLogAPI,
 				"%s Dropping event with rendering error. %v", w.logPrefix, err)
 			continue
 		}
 
 		r, err := w.buildRecordFromXML(w.outputBuf.Bytes(), err)
 		if err != nil {
-			logger.Errorf(w.config.Context.GetRuntimeContext(), "WINEVENTLOG_API_ALARM",
+			logger.Errorf(w.config.Context.GetRuntimeContext(), util.AlarmWinEventLogAPI,
 				"%s Dropping event. %v", w.logPrefix, err)
 			continue
 		}
@@ -131,7 +132,7 @@ func (w *winEventLog) Read() ([]Record, error) {
 			Timestamp:    r.TimeCreated.SystemTime,
 		}
 		if r.Offset.Bookmark, err = w.createBookmarkFromEvent(h); err != nil {
-			logger.Warningf(w.config.Context.GetRuntimeContext(), "WINEVENTLOG_API_ALARM",
+			logger.Warningf(w.config.Context.GetRuntimeContext(), util.AlarmWinEventLogAPI,
 				"%s failed creating bookmark: %v", w.logPrefix, err)
 		}
 		records = append(records, r)
@@ -144,7 +145,7 @@ func (w *winEventLog) Read() ([]Record, error) {
 
 func (w *winEventLog) Close() error {
 	if err := windows.Close(w.signalEvent); err != nil {
-		logger.Warningf(w.config.Context.GetRuntimeContext(), "WINEVENTLOG_API_ALARM",
+		logger.Warningf(w.config.Context.GetRuntimeContext(), util.AlarmWinEventLogAPI,
 			"%s Close signal event error: %v", w.logPrefix, err)
 	}
 	w.signalEvent = 0
@@ -176,7 +177,7 @@ func (w *winEventLog) eventHandles(maxRead int) ([]win.EvtHandle, int, error) {
 		}
 		return w.eventHandles(maxRead / 2)
 	default:
-		logger.Warningf(w.config.Context.GetRuntimeContext(), "WINEVENTLOG_API_ALARM",
+		logger.Warningf(w.config.Context.GetRuntimeContext(), util.AlarmWinEventLogAPI,
 			"%s EventHandles returned error %v", w.logPrefix, err)
 		return nil, 0, err
 	}
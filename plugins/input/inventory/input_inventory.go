@@ -0,0 +1,162 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/shirou/gopsutil/cpu"
+	"github.com/shirou/gopsutil/host"
+	"github.com/shirou/gopsutil/mem"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const pluginName = "metric_host_inventory"
+
+// The values of the inventory_type field of the snapshots.
+const (
+	typeOS            = "os"
+	typeKernelParams  = "kernel_params"
+	typePackage       = "package"
+	typeListeningPort = "listening_port"
+)
+
+const inventoryTypeKey = "inventory_type"
+
+// InputInventory collects the snapshots of the host inventory periodically for the CMDB and the security posture,
+// including the OS and the hardware, the kernel params, the installed packages and the listening ports.
+// Each snapshot is a log with the inventory_type field, and the packages and the ports are a log per item.
+// When the agent runs in a container, the files of the host are read under the mount path, see helper.mount_others.go.
+type InputInventory struct {
+	OS bool
+	// The prefixes of the kernel params under /proc/sys, e.g. "net.ipv4.", all of them are collected into
+	// a log. An empty list disables collecting the kernel params.
+	KernelParams []string
+	// Collects the packages installed by dpkg, rpm and apk.
+	Packages bool
+	// Collects the listening TCP and UDP sockets with the processes.
+	Ports      bool
+	IntervalMs int
+
+	context pipeline.Context
+	root    string
+}
+
+func (in *InputInventory) Init(context pipeline.Context) (int, error) {
+	in.context = context
+	in.root = helper.DefaultLogtailMountPath
+	return in.IntervalMs, nil
+}
+
+func (in *InputInventory) Description() string {
+	return "Collect the snapshots of the host inventory"
+}
+
+func (in *InputInventory) Collect(collector pipeline.Collector) error {
+	now := time.Now()
+	if in.OS {
+		if fields, err := collectOS(); err != nil {
+			logger.Warning(in.context.GetRuntimeContext(), util.AlarmInventoryCollect, "collect os error", err)
+		} else {
+			collector.AddData(nil, fields, now)
+		}
+	}
+	if len(in.KernelParams) > 0 {
+		if fields, err := collectKernelParams(in.root, in.KernelParams); err != nil {
+			logger.Warning(in.context.GetRuntimeContext(), util.AlarmInventoryCollect, "collect kernel params error", err)
+		} else {
+			collector.AddData(nil, fields, now)
+		}
+	}
+	if in.Packages {
+		packages, err := collectPackages(in.root)
+		if err != nil {
+			logger.Warning(in.context.GetRuntimeContext(), util.AlarmInventoryCollect, "collect packages error", err)
+		}
+		for _, p := range packages {
+			collector.AddData(nil, map[string]string{
+				inventoryTypeKey: typePackage,
+				"name":           p.name,
+				"version":        p.version,
+				"arch":           p.arch,
+				"manager":        p.manager,
+			}, now)
+		}
+	}
+	if in.Ports {
+		ports, err := collectListeningPorts(in.root)
+		if err != nil {
+			logger.Warning(in.context.GetRuntimeContext(), util.AlarmInventoryCollect, "collect listening ports error", err)
+		}
+		for _, p := range ports {
+			collector.AddData(nil, map[string]string{
+				inventoryTypeKey: typeListeningPort,
+				"protocol":       p.protocol,
+				"address":        p.address,
+				"port":           strconv.Itoa(p.port),
+				"pid":            strconv.Itoa(p.pid),
+				"process":        p.process,
+			}, now)
+		}
+	}
+	return nil
+}
+
+// collectOS collects the OS, the kernel and the hardware of the host.
+func collectOS() (map[string]string, error) {
+	info, err := host.Info()
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]string{
+		inventoryTypeKey:        typeOS,
+		"hostname":              info.Hostname,
+		"host_id":               info.HostID,
+		"os":                    info.OS,
+		"platform":              info.Platform,
+		"platform_family":       info.PlatformFamily,
+		"platform_version":      info.PlatformVersion,
+		"kernel_version":        info.KernelVersion,
+		"kernel_arch":           info.KernelArch,
+		"virtualization_system": info.VirtualizationSystem,
+		"virtualization_role":   info.VirtualizationRole,
+		"boot_time":             strconv.FormatUint(info.BootTime, 10),
+	}
+	if cpus, err := cpu.Info(); err == nil && len(cpus) > 0 {
+		fields["cpu_model"] = cpus[0].ModelName
+		fields["cpu_count"] = strconv.Itoa(len(cpus))
+	}
+	if memory, err := mem.VirtualMemory(); err == nil {
+		fields["mem_total"] = strconv.FormatUint(memory.Total, 10)
+	}
+	return fields, nil
+}
+
+func init() {
+	pipeline.MetricInputs[pluginName] = func() pipeline.MetricInput {
+		return &InputInventory{
+			OS:           true,
+			KernelParams: []string{"kernel.", "vm.", "fs.", "net.core.", "net.ipv4."},
+			Packages:     true,
+			Ports:        true,
+			IntervalMs:   3600000,
+		}
+	}
+}
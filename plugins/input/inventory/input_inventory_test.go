@@ -0,0 +1,154 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/plugins/test"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+const dpkgStatus = `Package: bash
+Status: install ok installed
+Priority: required
+Architecture: amd64
+Version: 5.1-6ubuntu1
+Description: GNU Bourne Again SHell
+ Bash is an sh-compatible command language interpreter.
+
+Package: removed
+Status: deinstall ok config-files
+Architecture: amd64
+Version: 1.0
+
+Package: tzdata
+Status: install ok installed
+Architecture: all
+Version: 2023c-0ubuntu0.22.04.0
+`
+
+const apkInstalled = `C:Q1abc=
+P:musl
+V:1.2.3-r4
+A:x86_64
+T:the musl c library
+
+P:busybox
+V:1.35.0-r29
+A:x86_64
+`
+
+const procNetTCP = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0100007F:0CEA 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1001 1 0 100 0 0 10 0
+   1: 0100007F:0CEA 0100007F:D2F0 01 00000000:00000000 00:00000000 00000000     0        0 1002 1 0 20 4 30 10 -1
+`
+
+const procNetUDP6 = `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  1: 00000000000000000000000000000000:0035 00000000000000000000000000000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 2001 2 0 0
+`
+
+func writeFile(t *testing.T, path string, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+}
+
+func TestParsePackages(t *testing.T) {
+	packages, err := parseDpkgStatus(strings.NewReader(dpkgStatus))
+	require.NoError(t, err)
+	assert.Equal(t, []packageInfo{
+		{name: "bash", version: "5.1-6ubuntu1", arch: "amd64", manager: "dpkg"},
+		{name: "tzdata", version: "2023c-0ubuntu0.22.04.0", arch: "all", manager: "dpkg"},
+	}, packages)
+
+	packages, err = parseApkInstalled(strings.NewReader(apkInstalled))
+	require.NoError(t, err)
+	assert.Equal(t, []packageInfo{
+		{name: "musl", version: "1.2.3-r4", arch: "x86_64", manager: "apk"},
+		{name: "busybox", version: "1.35.0-r29", arch: "x86_64", manager: "apk"},
+	}, packages)
+
+	packages, err = parseRpmOutput(strings.NewReader("bash\t5.1.8-6.el9\tx86_64\ngpg-pubkey\t(none)\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []packageInfo{{name: "bash", version: "5.1.8-6.el9", arch: "x86_64", manager: "rpm"}}, packages)
+}
+
+func TestParseListeningSockets(t *testing.T) {
+	ports, err := parseListeningSockets(strings.NewReader(procNetTCP), "tcp")
+	require.NoError(t, err)
+	assert.Equal(t, []listeningPort{{protocol: "tcp", address: "127.0.0.1", port: 3306, inode: 1001}}, ports)
+
+	ports, err = parseListeningSockets(strings.NewReader(procNetUDP6), "udp6")
+	require.NoError(t, err)
+	assert.Equal(t, []listeningPort{{protocol: "udp6", address: "::", port: 53, inode: 2001}}, ports)
+
+	address, port, err := parseHexAddress("B80D01200000000067452301EFCDAB89:1F90")
+	require.NoError(t, err)
+	assert.Equal(t, "2001:db8::123:4567:89ab:cdef", address)
+	assert.Equal(t, 8080, port)
+}
+
+func TestCollect(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "proc/sys/net/ipv4/ip_forward"), "1\n")
+	writeFile(t, filepath.Join(root, "proc/sys/net/ipv4/tcp_rmem"), "4096\t131072\t6291456\n")
+	writeFile(t, filepath.Join(root, "proc/sys/net/ipv6/conf/all/forwarding"), "0\n")
+	writeFile(t, filepath.Join(root, "proc/sys/kernel/pid_max"), "4194304\n")
+	writeFile(t, filepath.Join(root, "var/lib/dpkg/status"), dpkgStatus)
+	writeFile(t, filepath.Join(root, "proc/1/net/tcp"), procNetTCP)
+	writeFile(t, filepath.Join(root, "proc/42/comm"), "mysqld\n")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "proc/42/fd"), 0750))
+	require.NoError(t, os.Symlink("socket:[1001]", filepath.Join(root, "proc/42/fd/3")))
+
+	in := &InputInventory{KernelParams: []string{"net.ipv4.", "kernel.pid_max"}, Packages: true, Ports: true}
+	_, err := in.Init(mock.NewEmptyContext("project", "store", "config"))
+	require.NoError(t, err)
+	in.root = root
+	collector := &test.MockMetricCollector{}
+	require.NoError(t, in.Collect(collector))
+
+	logs := make(map[string][]map[string]string)
+	for _, log := range collector.Logs {
+		fields := make(map[string]string)
+		for _, c := range log.Contents {
+			fields[c.Key] = c.Value
+		}
+		logs[fields[inventoryTypeKey]] = append(logs[fields[inventoryTypeKey]], fields)
+	}
+	require.Len(t, logs[typeKernelParams], 1)
+	assert.Equal(t, map[string]string{
+		inventoryTypeKey:      typeKernelParams,
+		"net.ipv4.ip_forward": "1",
+		"net.ipv4.tcp_rmem":   "4096 131072 6291456",
+		"kernel.pid_max":      "4194304",
+	}, logs[typeKernelParams][0])
+	assert.Len(t, logs[typePackage], 2)
+	require.Len(t, logs[typeListeningPort], 1)
+	assert.Equal(t, map[string]string{
+		inventoryTypeKey: typeListeningPort,
+		"protocol":       "tcp",
+		"address":        "127.0.0.1",
+		"port":           "3306",
+		"pid":            "42",
+		"process":        "mysqld",
+	}, logs[typeListeningPort][0])
+	assert.Empty(t, logs[typeOS])
+}
@@ -0,0 +1,382 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type packageInfo struct {
+	name    string
+	version string
+	arch    string
+	manager string
+}
+
+type listeningPort struct {
+	protocol string
+	address  string
+	port     int
+	inode    uint64
+	pid      int
+	process  string
+}
+
+// collectKernelParams reads the kernel params under /proc/sys matching @prefixes, the unreadable ones are skipped.
+// The names are in the format of sysctl, e.g. net.ipv4.ip_forward.
+func collectKernelParams(root string, prefixes []string) (map[string]string, error) {
+	sysDir := filepath.Join(root, "/proc/sys")
+	if _, err := os.Stat(sysDir); err != nil {
+		return nil, err
+	}
+	fields := map[string]string{inventoryTypeKey: typeKernelParams}
+	err := filepath.WalkDir(sysDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// the directories without the permission are skipped
+			if d != nil && d.IsDir() && path != sysDir {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		rel, _ := filepath.Rel(sysDir, path)
+		name := strings.ReplaceAll(rel, string(filepath.Separator), ".")
+		if d.IsDir() {
+			if path != sysDir && !matchPrefixes(name+".", prefixes) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !matchPrefixes(name, prefixes) {
+			return nil
+		}
+		content, err := os.ReadFile(path) //nolint:gosec
+		if err != nil {
+			return nil
+		}
+		fields[name] = strings.Join(strings.Fields(string(content)), " ")
+		return nil
+	})
+	return fields, err
+}
+
+// matchPrefixes returns whether @name matches any of @prefixes, or is the parent of any of them.
+func matchPrefixes(name string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, prefix) || strings.HasPrefix(prefix, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// collectPackages collects the packages installed by dpkg, apk and rpm, the package managers not installed are
+// skipped.
+func collectPackages(root string) ([]packageInfo, error) {
+	var packages []packageInfo
+	var errs []string
+	if f, err := os.Open(filepath.Join(root, "/var/lib/dpkg/status")); err == nil {
+		dpkg, err := parseDpkgStatus(f)
+		_ = f.Close()
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		packages = append(packages, dpkg...)
+	}
+	if f, err := os.Open(filepath.Join(root, "/lib/apk/db/installed")); err == nil {
+		apk, err := parseApkInstalled(f)
+		_ = f.Close()
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		packages = append(packages, apk...)
+	}
+	if _, err := os.Stat(filepath.Join(root, "/var/lib/rpm")); err == nil {
+		rpm, err := queryRpm(root)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		packages = append(packages, rpm...)
+	}
+	if len(errs) > 0 {
+		return packages, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return packages, nil
+}
+
+// parseDpkgStatus parses the stanzas of /var/lib/dpkg/status, only the installed packages are returned.
+func parseDpkgStatus(r io.Reader) ([]packageInfo, error) {
+	var packages []packageInfo
+	var p packageInfo
+	installed := false
+	flush := func() {
+		if p.name != "" && installed {
+			p.manager = "dpkg"
+			packages = append(packages, p)
+		}
+		p = packageInfo{}
+		installed = false
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			flush()
+			continue
+		}
+		// the continuation lines of the multi-line fields
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Package":
+			p.name = value
+		case "Version":
+			p.version = value
+		case "Architecture":
+			p.arch = value
+		case "Status":
+			installed = strings.HasSuffix(value, " installed")
+		}
+	}
+	flush()
+	return packages, scanner.Err()
+}
+
+// parseApkInstalled parses the records of /lib/apk/db/installed.
+func parseApkInstalled(r io.Reader) ([]packageInfo, error) {
+	var packages []packageInfo
+	var p packageInfo
+	flush := func() {
+		if p.name != "" {
+			p.manager = "apk"
+			packages = append(packages, p)
+		}
+		p = packageInfo{}
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			flush()
+			continue
+		}
+		if len(line) < 2 || line[1] != ':' {
+			continue
+		}
+		switch line[0] {
+		case 'P':
+			p.name = line[2:]
+		case 'V':
+			p.version = line[2:]
+		case 'A':
+			p.arch = line[2:]
+		}
+	}
+	flush()
+	return packages, scanner.Err()
+}
+
+// queryRpm queries the packages by the rpm command, because the database of rpm is in the binary format.
+func queryRpm(root string) ([]packageInfo, error) {
+	if _, err := exec.LookPath("rpm"); err != nil {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	args := []string{"-qa", "--queryformat", "%{NAME}\\t%{VERSION}-%{RELEASE}\\t%{ARCH}\\n"}
+	if root != "" {
+		args = append([]string{"--root", root}, args...)
+	}
+	output, err := exec.CommandContext(ctx, "rpm", args...).Output() //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("query rpm error: %v", err)
+	}
+	return parseRpmOutput(bytes.NewReader(output))
+}
+
+func parseRpmOutput(r io.Reader) ([]packageInfo, error) {
+	var packages []packageInfo
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 3 || fields[0] == "" {
+			continue
+		}
+		packages = append(packages, packageInfo{name: fields[0], version: fields[1], arch: fields[2], manager: "rpm"})
+	}
+	return packages, scanner.Err()
+}
+
+// The states of the listening sockets in /proc/net/tcp and /proc/net/udp.
+const (
+	tcpListen   = "0A"
+	udpUnconned = "07"
+)
+
+// collectListeningPorts collects the listening sockets in the network namespace of the init process, i.e. the host,
+// and finds the processes owning them.
+func collectListeningPorts(root string) ([]listeningPort, error) {
+	var ports []listeningPort
+	var errs []string
+	for _, protocol := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		f, err := os.Open(filepath.Join(root, "/proc/1/net", protocol))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				errs = append(errs, err.Error())
+			}
+			continue
+		}
+		result, err := parseListeningSockets(f, protocol)
+		_ = f.Close()
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		ports = append(ports, result...)
+	}
+	fillProcesses(root, ports)
+	if len(errs) > 0 {
+		return ports, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return ports, nil
+}
+
+// parseListeningSockets parses the listening TCP sockets or the unconnected UDP sockets in the content of
+// /proc/net/@protocol.
+func parseListeningSockets(r io.Reader, protocol string) ([]listeningPort, error) {
+	var ports []listeningPort
+	state := tcpListen
+	if strings.HasPrefix(protocol, "udp") {
+		state = udpUnconned
+	}
+	scanner := bufio.NewScanner(r)
+	// skip the header
+	scanner.Scan()
+	for scanner.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[3] != state {
+			continue
+		}
+		if state == udpUnconned && !strings.HasSuffix(fields[2], ":0000") {
+			continue
+		}
+		address, port, err := parseHexAddress(fields[1])
+		if err != nil {
+			continue
+		}
+		inode, _ := strconv.ParseUint(fields[9], 10, 64)
+		ports = append(ports, listeningPort{protocol: protocol, address: address, port: port, inode: inode})
+	}
+	return ports, scanner.Err()
+}
+
+// parseHexAddress parses the address like "0100007F:0050" in /proc/net/tcp, the IP is in the words of the host
+// byte order, i.e. little endian.
+func parseHexAddress(s string) (string, int, error) {
+	hexIP, hexPort, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("invalid address %s", s)
+	}
+	ip, err := hex.DecodeString(hexIP)
+	if err != nil || (len(ip) != net.IPv4len && len(ip) != net.IPv6len) {
+		return "", 0, fmt.Errorf("invalid address %s", s)
+	}
+	for i := 0; i < len(ip); i += 4 {
+		ip[i], ip[i+1], ip[i+2], ip[i+3] = ip[i+3], ip[i+2], ip[i+1], ip[i]
+	}
+	port, err := strconv.ParseUint(hexPort, 16, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid address %s", s)
+	}
+	return net.IP(ip).String(), int(port), nil
+}
+
+// fillProcesses finds the processes owning the sockets by the fds of the processes, the sockets of the processes
+// without the permission are left unknown.
+func fillProcesses(root string, ports []listeningPort) {
+	if len(ports) == 0 {
+		return
+	}
+	indexes := make(map[uint64][]int, len(ports))
+	for i := range ports {
+		if ports[i].inode != 0 {
+			indexes[ports[i].inode] = append(indexes[ports[i].inode], i)
+		}
+	}
+	procDir := filepath.Join(root, "/proc")
+	entries, err := os.ReadDir(procDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join(procDir, entry.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		process := ""
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil {
+				continue
+			}
+			inode, ok := parseSocketInode(link)
+			if !ok {
+				continue
+			}
+			for _, i := range indexes[inode] {
+				if process == "" {
+					comm, _ := os.ReadFile(filepath.Join(procDir, entry.Name(), "comm")) //nolint:gosec
+					process = strings.TrimSpace(string(comm))
+				}
+				ports[i].pid = pid
+				ports[i].process = process
+			}
+		}
+	}
+}
+
+// parseSocketInode parses the inode of the link of a socket fd like "socket:[12345]".
+func parseSocketInode(link string) (uint64, bool) {
+	if !strings.HasPrefix(link, "socket:[") || !strings.HasSuffix(link, "]") {
+		return 0, false
+	}
+	inode, err := strconv.ParseUint(link[len("socket:["):len(link)-1], 10, 64)
+	return inode, err == nil
+}
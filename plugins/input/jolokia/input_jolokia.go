@@ -0,0 +1,390 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jolokia
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/tlscommon"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const pluginName = "metric_jolokia"
+
+// Instance is a Jolokia agent, or a Jolokia proxy reading the remote JMX of Target.
+type Instance struct {
+	// The url of the Jolokia agent, e.g. http://localhost:8778/jolokia.
+	URL      string
+	Username string
+	Password string
+	// The JMX service url read by the Jolokia proxy, e.g. service:jmx:rmi:///jndi/rmi://kafka-1:9999/jmxrmi,
+	// which enables the proxy mode.
+	Target         string
+	TargetUsername string
+	TargetPassword string
+	// The labels added to the metrics of the instance.
+	Labels map[string]string
+}
+
+// Mapping maps the attributes of the MBeans matching the ObjectName pattern to the metrics.
+type Mapping struct {
+	// The ObjectName or the pattern of the MBeans, e.g. kafka.server:type=BrokerTopicMetrics,name=*,topic=*.
+	MBean string
+	// The attributes read, all of them if empty.
+	Attributes []string
+	// The prefix of the metric names, the domain and the type of the MBean by default. The metric name is the
+	// prefix and the snake case attribute, e.g. kafka_server_brokertopicmetrics_one_minute_rate, and the keys of
+	// the composite attributes are appended, e.g. java_lang_memory_heap_memory_usage_used.
+	Name string
+	// The metric names by the attributes or the keys of the composite attributes, e.g. {"HeapMemoryUsage.used": "jvm_heap_used"},
+	// which override the generated names.
+	Rename map[string]string
+	// The keys of the ObjectName added as the labels, all of the keys except type by default.
+	LabelKeys []string
+	// The labels added to the metrics of the mapping.
+	Labels map[string]string
+}
+
+// InputJolokia reads the MBeans by the bulk read requests of the Jolokia HTTP API, and converts the numeric and the boolean
+// attributes to the metrics by the mappings. The remote JMX, including JMXMP, is read by a Jolokia proxy with Target.
+type InputJolokia struct {
+	Instances []*Instance
+	Mappings  []*Mapping
+	TimeoutMs int
+	TLS       *tlscommon.TLSConfig
+
+	context pipeline.Context
+	client  *http.Client
+}
+
+func (in *InputJolokia) Init(context pipeline.Context) (int, error) {
+	in.context = context
+	if len(in.Instances) == 0 {
+		return 0, fmt.Errorf("no instances of %s", pluginName)
+	}
+	if len(in.Mappings) == 0 {
+		return 0, fmt.Errorf("no mappings of %s", pluginName)
+	}
+	for _, instance := range in.Instances {
+		if instance.URL == "" {
+			return 0, fmt.Errorf("no url of the instance of %s", pluginName)
+		}
+	}
+	for _, mapping := range in.Mappings {
+		domain, keys, err := parseObjectName(mapping.MBean)
+		if err != nil {
+			return 0, err
+		}
+		if mapping.Name == "" {
+			mapping.Name = domain
+			if t, ok := keys["type"]; ok && t != "*" {
+				mapping.Name += "_" + t
+			}
+		}
+		mapping.Name = sanitizeName(mapping.Name)
+		if mapping.LabelKeys == nil {
+			for k := range keys {
+				if k != "type" {
+					mapping.LabelKeys = append(mapping.LabelKeys, k)
+				}
+			}
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if in.TLS != nil {
+		tlsConfig, err := in.TLS.LoadTLSConfig()
+		if err != nil {
+			return 0, fmt.Errorf("load tls config error: %v", err)
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	in.client = &http.Client{Transport: transport, Timeout: time.Duration(in.TimeoutMs) * time.Millisecond}
+	return 0, nil
+}
+
+func (in *InputJolokia) Description() string {
+	return "Collect the JMX metrics by the Jolokia agents or proxies"
+}
+
+func (in *InputJolokia) Collect(collector pipeline.Collector) error {
+	var wg sync.WaitGroup
+	for _, instance := range in.Instances {
+		wg.Add(1)
+		go func(instance *Instance) {
+			defer wg.Done()
+			if err := in.gatherInstance(instance, collector); err != nil {
+				logger.Warning(in.context.GetRuntimeContext(), util.AlarmJolokiaCollect, "instance", instance.URL, "target", instance.Target, "error", err)
+			}
+		}(instance)
+	}
+	wg.Wait()
+	return nil
+}
+
+type readRequest struct {
+	Type      string        `json:"type"`
+	MBean     string        `json:"mbean"`
+	Attribute []string      `json:"attribute,omitempty"`
+	Target    *targetConfig `json:"target,omitempty"`
+}
+
+type targetConfig struct {
+	URL      string `json:"url"`
+	User     string `json:"user,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+type readResponse struct {
+	Value  json.RawMessage `json:"value"`
+	Status int             `json:"status"`
+	Error  string          `json:"error"`
+}
+
+// gatherInstance reads all the mappings of the instance in a bulk request, the failed reads of the mappings are
+// logged and skipped.
+func (in *InputJolokia) gatherInstance(instance *Instance, collector pipeline.Collector) error {
+	requests := make([]readRequest, len(in.Mappings))
+	for i, mapping := range in.Mappings {
+		requests[i] = readRequest{Type: "read", MBean: mapping.MBean, Attribute: mapping.Attributes}
+		if instance.Target != "" {
+			requests[i].Target = &targetConfig{URL: instance.Target, User: instance.TargetUsername, Password: instance.TargetPassword}
+		}
+	}
+	body, err := json.Marshal(requests)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, instance.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if instance.Username != "" {
+		req.SetBasicAuth(instance.Username, instance.Password)
+	}
+	resp, err := in.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	var responses []readResponse
+	if err = json.NewDecoder(resp.Body).Decode(&responses); err != nil {
+		return fmt.Errorf("decode response error: %v", err)
+	}
+	if len(responses) != len(in.Mappings) {
+		return fmt.Errorf("%d responses of %d requests", len(responses), len(in.Mappings))
+	}
+
+	now := time.Now()
+	instanceLabels := map[string]string{"instance": instance.URL}
+	if instance.Target != "" {
+		instanceLabels["instance"] = instance.Target
+	}
+	for k, v := range instance.Labels {
+		instanceLabels[k] = v
+	}
+	for i, mapping := range in.Mappings {
+		if responses[i].Status != http.StatusOK {
+			logger.Warning(in.context.GetRuntimeContext(), util.AlarmJolokiaRead, "read mbean error", mapping.MBean, "status", responses[i].Status, "error", responses[i].Error)
+			continue
+		}
+		for _, m := range mapping.convert(responses[i].Value, instanceLabels) {
+			helper.AddMetric(collector, m.name, now, m.labels, m.value)
+		}
+	}
+	return nil
+}
+
+type metric struct {
+	name   string
+	labels string
+	value  float64
+}
+
+// convert converts the value of the read response to the metrics. The value of a pattern is the attributes by
+// the ObjectNames of the MBeans matched, and the value of an ObjectName is the attributes, or the value of the
+// attribute if only one is read.
+func (m *Mapping) convert(raw json.RawMessage, instanceLabels map[string]string) []metric {
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil
+	}
+	beans := map[string]interface{}{m.MBean: value}
+	if strings.ContainsAny(m.MBean, "*?") {
+		if matched, ok := value.(map[string]interface{}); ok {
+			beans = matched
+		}
+	} else if len(m.Attributes) == 1 {
+		if attributes, ok := value.(map[string]interface{}); !ok || attributes[m.Attributes[0]] == nil {
+			beans[m.MBean] = map[string]interface{}{m.Attributes[0]: value}
+		}
+	}
+	var metrics []metric
+	for objectName, attributes := range beans {
+		attributeMap, ok := attributes.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		_, keys, err := parseObjectName(objectName)
+		if err != nil {
+			continue
+		}
+		var labels helper.KeyValues
+		labels.AppendMap(instanceLabels)
+		for _, k := range m.LabelKeys {
+			if v, ok := keys[k]; ok {
+				labels.Append(k, v)
+			}
+		}
+		labels.AppendMap(m.Labels)
+		labels.Sort()
+		labelStr := labels.String()
+		for attribute, v := range attributeMap {
+			m.flatten(attribute, v, labelStr, &metrics)
+		}
+	}
+	return metrics
+}
+
+// flatten appends the metrics of the attribute at @path, whose composite values are flattened by the keys.
+func (m *Mapping) flatten(path string, value interface{}, labels string, metrics *[]metric) {
+	var f float64
+	switch v := value.(type) {
+	case float64:
+		f = v
+	case bool:
+		if v {
+			f = 1
+		}
+	case map[string]interface{}:
+		for k, sub := range v {
+			m.flatten(path+"."+k, sub, labels, metrics)
+		}
+		return
+	default:
+		return
+	}
+	name, ok := m.Rename[path]
+	if !ok {
+		name = m.Name + "_" + sanitizeName(toSnakeCase(path))
+	}
+	*metrics = append(*metrics, metric{name: name, labels: labels, value: f})
+}
+
+// parseObjectName parses the domain and the key properties of an ObjectName, whose values might be quoted.
+func parseObjectName(name string) (string, map[string]string, error) {
+	i := strings.IndexByte(name, ':')
+	if i <= 0 {
+		return "", nil, fmt.Errorf("invalid mbean %s", name)
+	}
+	keys := make(map[string]string)
+	properties := name[i+1:]
+	for len(properties) > 0 {
+		eq := strings.IndexByte(properties, '=')
+		if eq <= 0 {
+			if properties == "*" {
+				break
+			}
+			return "", nil, fmt.Errorf("invalid mbean %s", name)
+		}
+		key := properties[:eq]
+		properties = properties[eq+1:]
+		var value string
+		if strings.HasPrefix(properties, "\"") {
+			end := 1
+			for end < len(properties) && properties[end] != '"' {
+				if properties[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(properties) {
+				return "", nil, fmt.Errorf("invalid mbean %s", name)
+			}
+			value = unquote(properties[1:end])
+			properties = properties[end+1:]
+		} else if comma := strings.IndexByte(properties, ','); comma >= 0 {
+			value = properties[:comma]
+			properties = properties[comma:]
+		} else {
+			value = properties
+			properties = ""
+		}
+		keys[key] = value
+		properties = strings.TrimPrefix(properties, ",")
+	}
+	return name[:i], keys, nil
+}
+
+// unquote unescapes the quoted value of an ObjectName without the quotes.
+func unquote(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if s[i] == 'n' {
+				sb.WriteByte('\n')
+				continue
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+// toSnakeCase converts the camel case names of the attributes, e.g. OneMinuteRate to one_minute_rate.
+func toSnakeCase(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' {
+			if i > 0 && s[i-1] != '.' && s[i-1] != '_' &&
+				((s[i-1] >= 'a' && s[i-1] <= 'z') || (s[i-1] >= '0' && s[i-1] <= '9') ||
+					(i+1 < len(s) && s[i+1] >= 'a' && s[i+1] <= 'z')) {
+				sb.WriteByte('_')
+			}
+			c += 'a' - 'A'
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// sanitizeName replaces the chars invalid in the metric names with _.
+func sanitizeName(s string) string {
+	s = strings.ToLower(s)
+	helper.ReplaceInvalidChars(&s)
+	return s
+}
+
+func init() {
+	pipeline.MetricInputs[pluginName] = func() pipeline.MetricInput {
+		return &InputJolokia{TimeoutMs: 5000}
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jolokia
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/plugins/test"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+func TestParseObjectName(t *testing.T) {
+	domain, keys, err := parseObjectName(`kafka.server:type=BrokerTopicMetrics,name=BytesInPerSec,topic=orders`)
+	require.NoError(t, err)
+	assert.Equal(t, "kafka.server", domain)
+	assert.Equal(t, map[string]string{"type": "BrokerTopicMetrics", "name": "BytesInPerSec", "topic": "orders"}, keys)
+
+	_, keys, err = parseObjectName(`Catalina:type=GlobalRequestProcessor,name="http-nio-8080",extra="a,\"b\""`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"type": "GlobalRequestProcessor", "name": "http-nio-8080", "extra": `a,"b"`}, keys)
+
+	_, keys, err = parseObjectName(`java.lang:type=GarbageCollector,*`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"type": "GarbageCollector"}, keys)
+
+	for _, name := range []string{"no-domain", ":type=a", "a:type", `a:name="unterminated`} {
+		_, _, err = parseObjectName(name)
+		assert.Error(t, err, name)
+	}
+	assert.Equal(t, "one_minute_rate", toSnakeCase("OneMinuteRate"))
+	assert.Equal(t, "heap_memory_usage.used", toSnakeCase("HeapMemoryUsage.used"))
+	assert.Equal(t, "jvm_uptime", toSnakeCase("JVMUptime"))
+}
+
+func TestCollect(t *testing.T) {
+	var requests []readRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if !ok || user != "jolokia" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		requests = nil
+		_ = json.NewDecoder(r.Body).Decode(&requests)
+		_, _ = w.Write([]byte(`[
+			{"status": 200, "value": {
+				"kafka.server:name=BytesInPerSec,topic=orders,type=BrokerTopicMetrics": {"OneMinuteRate": 1024.5, "Count": 10},
+				"kafka.server:name=BytesInPerSec,topic=users,type=BrokerTopicMetrics": {"OneMinuteRate": 2.5, "Count": 1}}},
+			{"status": 200, "value": {"HeapMemoryUsage": {"used": 100, "max": 200, "committed": 150, "init": 50}, "Verbose": true, "ObjectName": {"objectName": "java.lang:type=Memory"}}},
+			{"status": 200, "value": 3600000},
+			{"status": 404, "error": "javax.management.InstanceNotFoundException"}
+		]`))
+	}))
+	defer server.Close()
+
+	in := &InputJolokia{
+		Instances: []*Instance{{URL: server.URL, Username: "jolokia", Password: "secret",
+			Target: "service:jmx:rmi:///jndi/rmi://kafka-1:9999/jmxrmi", Labels: map[string]string{"cluster": "c1"}}},
+		Mappings: []*Mapping{
+			{MBean: "kafka.server:type=BrokerTopicMetrics,name=*,topic=*", Attributes: []string{"OneMinuteRate", "Count"}},
+			{MBean: "java.lang:type=Memory", Rename: map[string]string{"HeapMemoryUsage.used": "jvm_heap_used"}, Labels: map[string]string{"pool": "heap"}},
+			{MBean: "java.lang:type=Runtime", Attributes: []string{"Uptime"}, Name: "jvm"},
+			{MBean: "kafka.controller:type=KafkaController,name=ActiveControllerCount"},
+		},
+		TimeoutMs: 1000,
+	}
+	_, err := in.Init(mock.NewEmptyContext("project", "store", "config"))
+	require.NoError(t, err)
+	collector := &test.MockMetricCollector{}
+	require.NoError(t, in.Collect(collector))
+
+	require.Len(t, requests, 4)
+	assert.Equal(t, "read", requests[0].Type)
+	assert.Equal(t, []string{"OneMinuteRate", "Count"}, requests[0].Attribute)
+	assert.Equal(t, "service:jmx:rmi:///jndi/rmi://kafka-1:9999/jmxrmi", requests[0].Target.URL)
+
+	metrics := make(map[string]string)
+	for _, log := range collector.Logs {
+		fields := make(map[string]string)
+		for _, c := range log.Contents {
+			fields[c.Key] = c.Value
+		}
+		metrics[fields["__name__"]+"{"+fields["__labels__"]+"}"] = fields["__value__"]
+	}
+	instance := "cluster#$#c1|instance#$#service:jmx:rmi:///jndi/rmi://kafka-1:9999/jmxrmi"
+	assert.Equal(t, map[string]string{
+		"kafka_server_brokertopicmetrics_one_minute_rate{" + instance + "|name#$#BytesInPerSec|topic#$#orders}": "1024.5",
+		"kafka_server_brokertopicmetrics_count{" + instance + "|name#$#BytesInPerSec|topic#$#orders}":           "10",
+		"kafka_server_brokertopicmetrics_one_minute_rate{" + instance + "|name#$#BytesInPerSec|topic#$#users}":  "2.5",
+		"kafka_server_brokertopicmetrics_count{" + instance + "|name#$#BytesInPerSec|topic#$#users}":            "1",
+		"jvm_heap_used{" + instance + "|pool#$#heap}":                                                           "100",
+		"java_lang_memory_heap_memory_usage_max{" + instance + "|pool#$#heap}":                                  "200",
+		"java_lang_memory_heap_memory_usage_committed{" + instance + "|pool#$#heap}":                            "150",
+		"java_lang_memory_heap_memory_usage_init{" + instance + "|pool#$#heap}":                                 "50",
+		"java_lang_memory_verbose{" + instance + "|pool#$#heap}":                                                "1",
+		"jvm_uptime{" + instance + "}": "3.6e+06",
+	}, metrics)
+}
+
+func TestInitInvalid(t *testing.T) {
+	for _, in := range []*InputJolokia{
+		{Mappings: []*Mapping{{MBean: "java.lang:type=Memory"}}},
+		{Instances: []*Instance{{URL: "http://localhost:8778/jolokia"}}},
+		{Instances: []*Instance{{}}, Mappings: []*Mapping{{MBean: "java.lang:type=Memory"}}},
+		{Instances: []*Instance{{URL: "http://localhost:8778/jolokia"}}, Mappings: []*Mapping{{MBean: "invalid"}}},
+	} {
+		_, err := in.Init(mock.NewEmptyContext("project", "store", "config"))
+		assert.Error(t, err)
+	}
+}
@@ -74,9 +74,9 @@ func (sj *ServiceJournal) Follow(journal *sdjournal.Journal, stop <-chan struct{
 			entry, err := readEntry(journal)
 			if err != nil && err != io.EOF {
 				if cursor, cerr := journal.GetCursor(); cerr != nil {
-					logger.Warningf(sj.context.GetRuntimeContext(), "JOURNAL_READ_ALARM", "Received unknown error when reading a new entry: %v, cursor read error: %v", err, cerr)
+					logger.Warningf(sj.context.GetRuntimeContext(), util.AlarmJournalRead, "Received unknown error when reading a new entry: %v, cursor read error: %v", err, cerr)
 				} else {
-					logger.Warningf(sj.context.GetRuntimeContext(), "JOURNAL_READ_ALARM", "Received unknown error when reading a new entry: %v, cursor: %s", err, cursor)
+					logger.Warningf(sj.context.GetRuntimeContext(), util.AlarmJournalRead, "Received unknown error when reading a new entry: %v, cursor: %s", err, cursor)
 				}
 				util.RandomSleep(time.Second*5, 0.1, stop)
 				continue
@@ -120,7 +120,7 @@ func (sj *ServiceJournal) Follow(journal *sdjournal.Journal, stop <-chan struct{
 					case -9:
 						continue process
 					default:
-						logger.Warningf(sj.context.GetRuntimeContext(), "JOURNAL_READ_ALARM", "Received unknown event: %d", e)
+						logger.Warningf(sj.context.GetRuntimeContext(), util.AlarmJournalRead, "Received unknown event: %d", e)
 						util.RandomSleep(time.Second*5, 0.1, stop)
 					}
 				}
@@ -15,12 +15,15 @@
 package kafka
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
 
+	"github.com/alibaba/ilogtail/helper/decompress"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/util"
 
 	"github.com/Shopify/sarama"
 	cluster "github.com/bsm/sarama-cluster"
@@ -36,6 +39,7 @@ type InputKafka struct {
 	Offset        string
 	SASLUsername  string
 	SASLPassword  string
+	Decompression string // [none, auto, gzip, deflate, zstd, snappy], the decompressed value is limited by MaxMessageLen.
 
 	cluster  *cluster.Consumer
 	wg       *sync.WaitGroup
@@ -69,6 +73,11 @@ func (k *InputKafka) Init(context pipeline.Context) (int, error) {
 			maxInputKafkaLen, pluginName)
 	}
 
+	var err error
+	if k.Decompression, err = decompress.Normalize(k.Decompression); err != nil {
+		return 0, fmt.Errorf("invalid Decompression for plugin %v: %v", pluginName, err)
+	}
+
 	config := cluster.NewConfig()
 
 	if k.Version != "" {
@@ -95,7 +104,7 @@ func (k *InputKafka) Init(context pipeline.Context) (int, error) {
 	case "newest":
 		config.Consumer.Offsets.Initial = sarama.OffsetNewest
 	default:
-		logger.Warningf(k.context.GetRuntimeContext(), "INPUT_KAFKA_ALARM", "Kafka consumer invalid offset '%s', using 'oldest'",
+		logger.Warningf(k.context.GetRuntimeContext(), util.AlarmInputKafka, "Kafka consumer invalid offset '%s', using 'oldest'",
 			k.Offset)
 		config.Consumer.Offsets.Initial = sarama.OffsetOldest
 	}
@@ -110,7 +119,7 @@ func (k *InputKafka) Init(context pipeline.Context) (int, error) {
 	if clusterErr != nil {
 		err := fmt.Errorf("Error when creating Kafka consumer, brokers: %v, topics: %v, err: %v",
 			k.Brokers, k.Topics, clusterErr)
-		logger.Errorf(k.context.GetRuntimeContext(), "INPUT_KAFKA_ALARM", "%v", err)
+		logger.Errorf(k.context.GetRuntimeContext(), util.AlarmInputKafka, "%v", err)
 		return 0, err
 	}
 	return 0, nil
@@ -131,7 +140,7 @@ func (k *InputKafka) Start(collector pipeline.Collector) error {
 				logger.Infof(k.context.GetRuntimeContext(), "shutdown errors func")
 				return
 			default:
-				logger.Errorf(k.context.GetRuntimeContext(), "INPUT_KAFKA_ALARM", "Error: %s\n", err.Error())
+				logger.Errorf(k.context.GetRuntimeContext(), util.AlarmInputKafka, "Error: %s\n", err.Error())
 			}
 		}
 		logger.Infof(k.context.GetRuntimeContext(), "shutdown errors loop")
@@ -182,15 +191,22 @@ func (k *InputKafka) markOffset(msg *sarama.ConsumerMessage) {
 }
 
 func (k *InputKafka) onMessage(collector pipeline.Collector, msg *sarama.ConsumerMessage) {
-	if len(msg.Value) > k.MaxMessageLen {
-		logger.Errorf(k.context.GetRuntimeContext(), "INPUT_KAFKA_ALARM", "Message longer than max_message_len (%d > %d)",
-			len(msg.Value), k.MaxMessageLen)
-		return
-	}
-
 	if msg != nil {
+		// the length of the message is checked when decompressing, so that the compressed
+		// messages are limited by the decompressed length.
+		value, err := decompress.Decompress(msg.Value, k.Decompression, int64(k.MaxMessageLen))
+		if errors.Is(err, decompress.ErrTooLarge) {
+			logger.Errorf(k.context.GetRuntimeContext(), util.AlarmInputKafka, "Message longer than max_message_len (%d), received %d bytes",
+				k.MaxMessageLen, len(msg.Value))
+			return
+		}
+		if err != nil {
+			logger.Errorf(k.context.GetRuntimeContext(), util.AlarmInputKafka, "Decompress message error, topic: %v, partition: %v, offset: %v, err: %v",
+				msg.Topic, msg.Partition, msg.Offset, err)
+			return
+		}
 		fields := make(map[string]string)
-		fields[string(msg.Key)] = string(msg.Value)
+		fields[string(msg.Key)] = string(value)
 		collector.AddData(nil, fields)
 	}
 }
@@ -200,7 +216,7 @@ func (k *InputKafka) Stop() error {
 	k.wg.Wait()
 	if err := k.cluster.Close(); err != nil {
 		e := fmt.Errorf("[inputs.kafka_consumer] Error closing consumer: %v", err)
-		logger.Errorf(k.context.GetRuntimeContext(), "INPUT_KAFKA_ALARM", "%v", e)
+		logger.Errorf(k.context.GetRuntimeContext(), util.AlarmInputKafka, "%v", e)
 		return e
 	}
 	return nil
@@ -222,6 +238,7 @@ func init() {
 			Offset:        "oldest",
 			SASLUsername:  "",
 			SASLPassword:  "",
+			Decompression: decompress.None,
 		}
 	}
 }
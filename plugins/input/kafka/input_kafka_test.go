@@ -16,6 +16,7 @@ package kafka
 
 import (
 	"bytes"
+	"compress/gzip"
 	"fmt"
 	"os/exec"
 	"testing"
@@ -24,6 +25,7 @@ import (
 	"github.com/alibaba/ilogtail/pkg/protocol"
 	pluginmanager "github.com/alibaba/ilogtail/pluginmanager"
 
+	"github.com/Shopify/sarama"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -144,3 +146,28 @@ func closeKafka() {
 	// _, _ = execShell("kafka-server-stop")
 	// _, _ = execShell("zookeeper-server-stop")
 }
+
+func TestOnMessageDecompression(t *testing.T) {
+	ctx := &ContextTest{}
+	ctx.ContextImp.InitContext("a", "b", "c")
+	input := &InputKafka{MaxMessageLen: 10, Decompression: "auto", context: &ctx.ContextImp}
+	collector := &mockCollector{}
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, _ = writer.Write([]byte("0123456789"))
+	_ = writer.Close()
+	input.onMessage(collector, &sarama.ConsumerMessage{Key: []byte("key1"), Value: buf.Bytes()})
+	input.onMessage(collector, &sarama.ConsumerMessage{Key: []byte("key2"), Value: []byte("value2")})
+	require.Equal(t, 2, len(collector.logs))
+	assert.Equal(t, "0123456789", collector.logs[0].fields["key1"])
+	assert.Equal(t, "value2", collector.logs[1].fields["key2"])
+
+	// the decompressed value exceeds MaxMessageLen
+	buf.Reset()
+	writer = gzip.NewWriter(&buf)
+	_, _ = writer.Write([]byte("0123456789a"))
+	_ = writer.Close()
+	input.onMessage(collector, &sarama.ConsumerMessage{Key: []byte("key3"), Value: buf.Bytes()})
+	assert.Equal(t, 2, len(collector.logs))
+}
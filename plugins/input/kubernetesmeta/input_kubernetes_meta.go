@@ -29,6 +29,7 @@ import (
 	"github.com/alibaba/ilogtail/helper"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 const pluginName = "metric_meta_kubernetes"
@@ -75,7 +76,7 @@ func (in *InputKubernetesMeta) Init(context pipeline.Context) (int, error) {
 	in.informerStopChan = make(chan struct{})
 	in.context = context
 	if in.IntervalMs < 5000 {
-		logger.Warning(in.context.GetRuntimeContext(), "KUBERNETES_META_FETCH_INTERVAL_ALARM", "interval", "must over than 5000 ms")
+		logger.Warning(in.context.GetRuntimeContext(), util.AlarmKubernetesMetaFetchInterval, "interval", "must over than 5000 ms")
 		in.IntervalMs = defaultIntervalMs
 	}
 	// When kubeConfigPath is empty, cluster config would be read.
@@ -112,7 +113,7 @@ func (in *InputKubernetesMeta) Init(context pipeline.Context) (int, error) {
 	} else {
 		selector, err := labels.Parse(in.LabelSelectors)
 		if err != nil {
-			logger.Error(in.context.GetRuntimeContext(), "KUBERNETES_LABEL_SELECTOR_ERROR", "error", err)
+			logger.Error(in.context.GetRuntimeContext(), util.AlarmKubernetesLabelSelectorError, "error", err)
 			selector = labels.Everything()
 		}
 		in.selector = selector
@@ -196,7 +197,7 @@ func (in *InputKubernetesMeta) Collect(collector pipeline.Collector) error {
 	for _, c := range in.collectors {
 		nodes, err := c.collect(c.lister, in.selector)
 		if err != nil {
-			logger.Error(in.context.GetRuntimeContext(), "KUBERNETES_META_COLLECT_ERROR", "error", err)
+			logger.Error(in.context.GetRuntimeContext(), util.AlarmKubernetesMetaCollectError, "error", err)
 			continue
 		}
 		if len(nodes) == 0 {
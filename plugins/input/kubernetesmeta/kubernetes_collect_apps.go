@@ -23,6 +23,7 @@ import (
 
 	"github.com/alibaba/ilogtail/helper"
 	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 // collectDeployment list the kubernetes nodes by the label selector and collect the core metadata.
@@ -30,7 +31,7 @@ func (in *InputKubernetesMeta) collectDeployment(lister interface{}, selector la
 	deploymentLister := lister.(apps.DeploymentLister)
 	deployments, err := deploymentLister.List(selector)
 	if err != nil {
-		logger.Error(in.context.GetRuntimeContext(), "KUBERNETES_META_ALARM", "err", err)
+		logger.Error(in.context.GetRuntimeContext(), util.AlarmKubernetesMeta, "err", err)
 		return
 	}
 	if in.Deployment {
@@ -68,7 +69,7 @@ func (in *InputKubernetesMeta) collectDaemonSet(lister interface{}, selector lab
 	daemonSetLister := lister.(apps.DaemonSetLister)
 	daemonSets, err := daemonSetLister.List(selector)
 	if err != nil {
-		logger.Error(in.context.GetRuntimeContext(), "KUBERNETES_META_ALARM", "err", err)
+		logger.Error(in.context.GetRuntimeContext(), util.AlarmKubernetesMeta, "err", err)
 		return
 	}
 	if in.DaemonSet {
@@ -98,7 +99,7 @@ func (in *InputKubernetesMeta) collectDaemonSet(lister interface{}, selector lab
 func (in *InputKubernetesMeta) collectStatefulSet(lister interface{}, selector labels.Selector) (nodes []*helper.MetaNode, err error) {
 	statefulSets, err := lister.(apps.StatefulSetLister).List(selector)
 	if err != nil {
-		logger.Error(in.context.GetRuntimeContext(), "KUBERNETES_META_ALARM", "err", err)
+		logger.Error(in.context.GetRuntimeContext(), util.AlarmKubernetesMeta, "err", err)
 		return
 	}
 	if in.StatefulSet {
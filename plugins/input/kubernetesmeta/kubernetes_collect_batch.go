@@ -22,6 +22,7 @@ import (
 
 	"github.com/alibaba/ilogtail/helper"
 	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 // collectJobs collect the core metadata from the kubernetes jobs.
@@ -29,7 +30,7 @@ func (in *InputKubernetesMeta) collectJobs(lister interface{}, selector labels.S
 	jobLister := lister.(batch.JobLister)
 	jobs, err := jobLister.List(selector)
 	if err != nil {
-		logger.Error(in.context.GetRuntimeContext(), "KUBERNETES_META_ALARM", "err", err)
+		logger.Error(in.context.GetRuntimeContext(), util.AlarmKubernetesMeta, "err", err)
 		return
 	}
 	if in.Job {
@@ -64,7 +65,7 @@ func (in *InputKubernetesMeta) collectJobs(lister interface{}, selector labels.S
 func (in *InputKubernetesMeta) collectCronJobs(lister interface{}, selector labels.Selector) (nodes []*helper.MetaNode, err error) {
 	cronJobs, err := lister.(batchbeta.CronJobLister).List(selector)
 	if err != nil {
-		logger.Error(in.context.GetRuntimeContext(), "KUBERNETES_META_ALARM", "err", err)
+		logger.Error(in.context.GetRuntimeContext(), util.AlarmKubernetesMeta, "err", err)
 		return
 	}
 	if in.CronJob {
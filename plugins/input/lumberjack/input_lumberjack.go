@@ -115,7 +115,7 @@ func (p *ServiceLumber) Start(c pipeline.Collector) error {
 			var tlsConfig *tls.Config
 			tlsConfig, err = util.GetTLSConfig(p.SSLCert, p.SSLKey, p.SSLCA, p.InsecureSkipVerify)
 			if err != nil {
-				logger.Error(p.context.GetRuntimeContext(), "LUMBER_LISTEN_ALARM", "init tls error", err)
+				logger.Error(p.context.GetRuntimeContext(), util.AlarmLumberListen, "init tls error", err)
 			}
 			p.server, err = server.ListenAndServe(p.BindAddress,
 				server.V1(p.V1),
@@ -126,7 +126,7 @@ func (p *ServiceLumber) Start(c pipeline.Collector) error {
 		}
 
 		if err != nil {
-			logger.Error(p.context.GetRuntimeContext(), "LUMBER_LISTEN_ALARM", "listen init error", err, "sleep 10 seconds and retry")
+			logger.Error(p.context.GetRuntimeContext(), util.AlarmLumberListen, "listen init error", err, "sleep 10 seconds and retry")
 			if util.RandomSleep(time.Second*10, 0.1, p.shutdown) {
 				return nil
 			}
@@ -143,7 +143,7 @@ func (p *ServiceLumber) Start(c pipeline.Collector) error {
 			case batch := <-recvChan:
 				if batch == nil {
 					err = p.server.Close()
-					logger.Error(p.context.GetRuntimeContext(), "LUMBER_CONNECTION_ALARM", "lumber server error", "chan closed", "close server, err", err)
+					logger.Error(p.context.GetRuntimeContext(), util.AlarmLumberConnection, "lumber server error", "chan closed", "close server, err", err)
 					break ForBlock
 				}
 				for _, event := range batch.Events {
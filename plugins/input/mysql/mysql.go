@@ -27,6 +27,8 @@ import (
 	"github.com/go-sql-driver/mysql"
 
 	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/helper/credentials"
+	"github.com/alibaba/ilogtail/helper/scheduler"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/util"
@@ -70,6 +72,11 @@ type Mysql struct {
 	SSLCA                 string
 	SSLCert               string
 	SSLKey                string
+	// Schedule queries by the cron expression instead of every IntervalMs
+	Schedule *scheduler.Config
+	// Credentials provides the user and password instead of the plaintext ones, which are retrieved before each
+	// collection and the connection is reopened when they are rotated.
+	Credentials *credentials.Config
 
 	// inner params
 	checkpointColumnIndex int
@@ -82,10 +89,12 @@ type Mysql struct {
 	columnValuePointers   []interface{}
 	shutdown              chan struct{}
 	waitGroup             sync.WaitGroup
+	job                   *scheduler.Job
 	context               pipeline.Context
 	collectLatency        pipeline.LatencyMetric
 	collectTotal          pipeline.CounterMetric
 	checkpointMetric      pipeline.StringMetric
+	credentialTracker     *credentials.Tracker
 }
 
 func (m *Mysql) Init(context pipeline.Context) (int, error) {
@@ -95,7 +104,7 @@ func (m *Mysql) Init(context pipeline.Context) (int, error) {
 		if err != nil && len(data) > 0 {
 			m.StateMent = string(data)
 		} else {
-			logger.Warning(m.context.GetRuntimeContext(), "MYSQL_INIT_ALARM", "init sql statement error", err)
+			logger.Warning(m.context.GetRuntimeContext(), util.AlarmMySQLInit, "init sql statement error", err)
 		}
 	}
 	if len(m.StateMent) == 0 {
@@ -104,6 +113,22 @@ func (m *Mysql) Init(context pipeline.Context) (int, error) {
 	if m.Limit && m.PageSize > 0 {
 		m.StateMent += " limit ?, " + strconv.Itoa(m.PageSize)
 	}
+	if m.Credentials != nil {
+		provider, err := m.Credentials.NewProvider()
+		if err != nil {
+			return 0, err
+		}
+		m.credentialTracker = credentials.NewTracker(provider)
+	}
+	job, err := m.Schedule.NewJob(time.Duration(m.IntervalMs) * time.Millisecond)
+	if err != nil {
+		return 0, err
+	}
+	job.RunAtStart = true
+	job.OnSkip = func() {
+		logger.Warning(m.context.GetRuntimeContext(), util.AlarmMySQLTimeout, "sql collect cost very long time, skip the schedule", "intervalMs", m.IntervalMs)
+	}
+	m.job = job
 
 	m.collectLatency = helper.NewLatencyMetric("mysql_collect_avg_cost")
 	m.collectTotal = helper.NewCounterMetric("mysql_collect_total")
@@ -123,7 +148,7 @@ func (m *Mysql) Description() string {
 func (m *Mysql) initMysql() error {
 	tlsConfig, err := util.GetTLSConfig(m.SSLCert, m.SSLKey, m.SSLCA, false)
 	if err != nil {
-		logger.Error(m.context.GetRuntimeContext(), "MYSQL_INIT_ALARM", "MySQL Error registering TLS config", err)
+		logger.Error(m.context.GetRuntimeContext(), util.AlarmMySQLInit, "MySQL Error registering TLS config", err)
 	}
 
 	if tlsConfig != nil {
@@ -144,7 +169,7 @@ func (m *Mysql) initMysql() error {
 					logger.Debug(m.context.GetRuntimeContext(), "sql connect success, ping error", m.dbInstance.Ping())
 					break
 				} else {
-					logger.Warning(m.context.GetRuntimeContext(), "MYSQL_INIT_ALARM", "init db statement error, err", err)
+					logger.Warning(m.context.GetRuntimeContext(), util.AlarmMySQLInit, "init db statement error, err", err)
 					break
 				}
 			}
@@ -197,7 +222,7 @@ func (m *Mysql) dsnConfig() (string, error) {
 		if err != nil {
 			conf.Loc = loc
 		} else {
-			logger.Error(m.context.GetRuntimeContext(), "MYSQL_INIT_ALARM", "Set Mysql location error, loc", m.Location, "error", err)
+			logger.Error(m.context.GetRuntimeContext(), util.AlarmMySQLInit, "Set Mysql location error, loc", m.Location, "error", err)
 		}
 	}
 
@@ -230,10 +255,50 @@ func (m *Mysql) CheckPointToString() string {
 	return m.checkpointValue
 }
 
+// retrieveCredentials fills the user and password from the credential provider if set, and returns true if
+// they are changed.
+func (m *Mysql) retrieveCredentials() (bool, error) {
+	if m.credentialTracker == nil {
+		return false, nil
+	}
+	cred, changed, err := m.credentialTracker.Retrieve()
+	if err != nil {
+		return false, err
+	}
+	m.User, m.Password = cred.Username, cred.Password
+	return changed, nil
+}
+
+// reconnectIfRotated reopens the connection with the rotated user and password before the collection.
+func (m *Mysql) reconnectIfRotated() {
+	changed, err := m.retrieveCredentials()
+	if err != nil {
+		logger.Warning(m.context.GetRuntimeContext(), util.AlarmCredential, "retrieve the credentials error, use the last ones", err)
+		return
+	}
+	if !changed {
+		return
+	}
+	if m.dbStatment != nil {
+		_ = m.dbStatment.Close()
+		m.dbStatment = nil
+	}
+	if m.dbInstance != nil {
+		_ = m.dbInstance.Close()
+		m.dbInstance = nil
+	}
+	if err = m.initMysql(); err != nil {
+		logger.Warning(m.context.GetRuntimeContext(), util.AlarmMySQLInit, "reconnect with the rotated credentials error", err)
+	}
+}
+
 // Start starts the ServiceInput's service, whatever that may be
 func (m *Mysql) Start(collector pipeline.Collector) error {
 	m.waitGroup.Add(1)
 	defer m.waitGroup.Done()
+	if _, err := m.retrieveCredentials(); err != nil {
+		return err
+	}
 	// initialize additional query intervals
 	err := m.initMysql()
 	if err != nil {
@@ -249,11 +314,11 @@ func (m *Mysql) Start(collector pipeline.Collector) error {
 
 			switch {
 			case err != nil:
-				logger.Error(m.context.GetRuntimeContext(), "MYSQL_CHECKPOING_ALARM", "init checkpoint error, key", m.CheckPointColumn, "value", string(val), "error", err)
+				logger.Error(m.context.GetRuntimeContext(), util.AlarmMySQLCheckpoint, "init checkpoint error, key", m.CheckPointColumn, "value", string(val), "error", err)
 			case cp.CheckPointColumn == m.CheckPointColumn && m.CheckPointColumnType == cp.CheckPointColumnType:
 				m.checkpointValue = cp.Value
 			default:
-				logger.Warning(m.context.GetRuntimeContext(), "MYSQL_CHECKPOING_ALARM", "not matched checkpoint, may be config update, last column",
+				logger.Warning(m.context.GetRuntimeContext(), util.AlarmMySQLCheckpoint, "not matched checkpoint, may be config update, last column",
 					cp.CheckPointColumn, "now column", m.CheckPointColumn, "last type", cp.CheckPointColumnType, "now type", m.CheckPointColumnType)
 			}
 		}
@@ -267,32 +332,20 @@ func (m *Mysql) Start(collector pipeline.Collector) error {
 		return fmt.Errorf("no query statement")
 	}
 
-	// first collect after 10 ms
-	timer := time.NewTimer(10 * time.Millisecond)
-	for {
-		select {
-		case <-timer.C:
-			startTime := time.Now()
-			m.collectLatency.Begin()
-			err = m.Collect(collector)
-			if err != nil {
-				logger.Error(m.context.GetRuntimeContext(), "MYSQL_QUERY_ALARM", "sql query error", err)
-			}
-			m.collectLatency.End()
-			endTime := time.Now()
-			if endTime.Sub(startTime) > time.Duration(m.IntervalMs)*time.Millisecond/2 {
-				logger.Warning(m.context.GetRuntimeContext(), "MYSQL_TIMEOUT_ALARM", "sql collect cost very long time, start", startTime, "end", endTime, "intervalMs", m.IntervalMs)
-				timer.Reset(time.Duration(m.IntervalMs) * time.Millisecond)
-			} else {
-				timer.Reset(time.Duration(m.IntervalMs)*time.Millisecond - endTime.Sub(startTime))
-			}
-			logger.Debug(m.context.GetRuntimeContext(), "sql collect done, start", startTime, "end", endTime, "intervalMs", m.IntervalMs)
-		case <-m.shutdown:
-			m.SaveCheckPoint(collector)
-			logger.Debug(m.context.GetRuntimeContext(), "recv shutdown signal", "start to exit")
-			return nil
+	// collect at the start and then by the schedule, the running collection is waited at the shutdown
+	m.job.Run(func() {
+		m.reconnectIfRotated()
+		startTime := time.Now()
+		m.collectLatency.Begin()
+		if err := m.Collect(collector); err != nil {
+			logger.Error(m.context.GetRuntimeContext(), util.AlarmMySQLQuery, "sql query error", err)
 		}
-	}
+		m.collectLatency.End()
+		logger.Debug(m.context.GetRuntimeContext(), "sql collect done, start", startTime, "end", time.Now(), "intervalMs", m.IntervalMs)
+	}, m.shutdown)
+	m.SaveCheckPoint(collector)
+	logger.Debug(m.context.GetRuntimeContext(), "recv shutdown signal", "start to exit")
+	return nil
 }
 
 func (m *Mysql) Collect(collector pipeline.Collector) error {
@@ -361,7 +414,7 @@ func (m *Mysql) SaveCheckPoint(collector pipeline.Collector) {
 	cp := CheckPoint{CheckPointColumn: m.CheckPointColumn, CheckPointColumnType: m.CheckPointColumnType, Value: m.CheckPointToString(), LastUpdateTime: time.Now()}
 	buf, err := json.Marshal(&cp)
 	if err != nil {
-		logger.Warning(m.context.GetRuntimeContext(), "MYSQL_CHECKPOINT_ALARM", "save checkpoint marshal error, checkpoint", cp, "error", err)
+		logger.Warning(m.context.GetRuntimeContext(), util.AlarmMySQLCheckpoint, "save checkpoint marshal error, checkpoint", cp, "error", err)
 		return
 	}
 	err = m.context.SaveCheckPoint(m.CheckPointColumn, buf)
@@ -369,7 +422,7 @@ func (m *Mysql) SaveCheckPoint(collector pipeline.Collector) {
 		m.checkpointMetric.Set(m.CheckPointColumn)
 	}
 	if err != nil {
-		logger.Warning(m.context.GetRuntimeContext(), "MYSQL_CHECKPOINT_ALARM", "save checkpoint dump error, checkpoint", cp, "error", err)
+		logger.Warning(m.context.GetRuntimeContext(), util.AlarmMySQLCheckpoint, "save checkpoint dump error, checkpoint", cp, "error", err)
 	}
 }
 
@@ -381,7 +434,7 @@ func (m *Mysql) ParseRows(rows *sql.Rows, collector pipeline.Collector) int {
 	if m.columnsKeyBuffer == nil {
 		columns, err := rows.Columns()
 		if err != nil {
-			logger.Warning(m.context.GetRuntimeContext(), "MYSQL_PARSE_ALARM", "no columns info, use default columns info, error", err)
+			logger.Warning(m.context.GetRuntimeContext(), util.AlarmMySQLParse, "no columns info, use default columns info, error", err)
 		}
 		m.columnsKeyBuffer = make([]string, len(columns))
 		m.checkpointColumnIndex = 0
@@ -399,7 +452,7 @@ func (m *Mysql) ParseRows(rows *sql.Rows, collector pipeline.Collector) int {
 			}
 		}
 		if m.CheckPoint && len(m.CheckPointColumn) != 0 && !foundCheckpointColumn {
-			logger.Warning(m.context.GetRuntimeContext(), "MYSQL_PARSE_ALARM", "no checkpoint column", m.CheckPointColumn)
+			logger.Warning(m.context.GetRuntimeContext(), util.AlarmMySQLParse, "no checkpoint column", m.CheckPointColumn)
 		}
 
 		m.columnValues = make([]sql.NullString, len(m.columnsKeyBuffer))
@@ -416,7 +469,7 @@ func (m *Mysql) ParseRows(rows *sql.Rows, collector pipeline.Collector) int {
 	for rows.Next() {
 		err := rows.Scan(m.columnValuePointers...)
 		if err != nil {
-			logger.Warning(m.context.GetRuntimeContext(), "MYSQL_PARSE_ALARM", "scan error, row", rowCount, "error", err)
+			logger.Warning(m.context.GetRuntimeContext(), util.AlarmMySQLParse, "scan error, row", rowCount, "error", err)
 			return rowCount
 		}
 		for index, val := range m.columnValues {
@@ -391,7 +391,7 @@ func (m *NetPing) doICMPing(config *ICMPConfig) {
 
 	pinger, err := goping.NewPinger(m.getRealTarget(config.Target))
 	if err != nil {
-		logger.Error(m.context.GetRuntimeContext(), "FAIL_TO_INIT_PING", err.Error())
+		logger.Error(m.context.GetRuntimeContext(), util.AlarmFailToInitPing, err.Error())
 		label.Append("err", err.Error())
 		m.resultChannel <- &Result{
 			Valid:  true,
@@ -410,7 +410,7 @@ func (m *NetPing) doICMPing(config *ICMPConfig) {
 	pinger.Count = config.Count
 	err = pinger.Run() // Blocks until finished or timeout.
 	if err != nil {
-		logger.Error(m.context.GetRuntimeContext(), "FAIL_TO_RUN_PING", err.Error())
+		logger.Error(m.context.GetRuntimeContext(), util.AlarmFailToRunPing, err.Error())
 		label.Append("err", err.Error())
 		m.resultChannel <- &Result{
 			Valid:  true,
@@ -579,7 +579,7 @@ func (m *NetPing) doHTTPing(config *HTTPConfig) {
 	now := time.Now()
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		logger.Error(m.context.GetRuntimeContext(), "FAIL_TO_RUN_HTTPING", err.Error())
+		logger.Error(m.context.GetRuntimeContext(), util.AlarmFailToRunHTTPing, err.Error())
 		label.Append("err", err.Error())
 		m.resultChannel <- &Result{
 			Valid:   true,
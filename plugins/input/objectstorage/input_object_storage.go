@@ -0,0 +1,423 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objectstorage
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/helper/credentials"
+	"github.com/alibaba/ilogtail/helper/decompress"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	pluginName    = "service_object_storage"
+	checkpointKey = pluginName
+	// startAfterKey is the checkpoint of the last key skipped when listing the ordered keys.
+	startAfterKey = pluginName + "_start_after"
+	pathTag       = "__path__"
+)
+
+// objectCheckpoint is the read progress of an object.
+type objectCheckpoint struct {
+	// Offset is the end of the last complete line read.
+	Offset int64
+	// Size is the size of the object at the last reading, the incomplete last line is read when the
+	// object stops growing.
+	Size int64
+	ETag string
+	// Records is the count of the records collected from a compressed object, which are skipped when
+	// reading it again after an interruption.
+	Records int64 `json:",omitempty"`
+}
+
+// InputObjectStorage incrementally reads the append-only objects of a bucket or the files served by
+// http servers, and collects each line as a log. The objects are listed by the prefix every interval,
+// and the new content is read by the range requests from the offsets kept in the checkpoint.
+type InputObjectStorage struct {
+	Endpoint             string              `comment:"the endpoint of the S3 compatible service, such as https://s3.us-east-1.amazonaws.com or https://oss-cn-hangzhou.aliyuncs.com"`
+	Bucket               string              `comment:"the bucket to collect"`
+	Prefix               string              `comment:"the prefix of the objects to collect"`
+	Region               string              `comment:"the region to sign the requests, us-east-1 by default"`
+	PathStyle            bool                `comment:"access the bucket in the path style rather than the virtual hosted style"`
+	AccessKeyID          string              `comment:"the access key id, the bucket is accessed anonymously when empty"`
+	AccessKeySecret      string              `comment:"the access key secret"`
+	SecurityToken        string              `comment:"the security token of the temporary credentials"`
+	Credentials          *credentials.Config `comment:"the provider of the access key pair, such as env, file, vault, ecs_ram_role and rrsa, which takes precedence over the access key fields"`
+	URLs                 []string            `comment:"the urls of the files served by http servers, used when the bucket is empty"`
+	IntervalSec          int                 `comment:"the interval to list the objects, 60 by default"`
+	MaxReadBytes         int                 `comment:"the max bytes of a range request, and the max length of a line, 8MB by default"`
+	MaxObjectAgeHours    int                 `comment:"ignore the objects not modified in the hours, 0 means no limit"`
+	OrderedKeys          bool                `comment:"the new objects are created in the ascending order of the keys, such as the keys named by the time, so that the leading objects read completely or expired are not listed again"`
+	ContentKey           string              `comment:"the key of the line in the log, content by default"`
+	MaxDecompressedBytes int64               `comment:"the max decompressed bytes of a .gz or .zst object, the rest is dropped, 1GB by default and 0 means no limit"`
+	Framing              string              `comment:"the framing of the records, delimiter by default, octet_counting, uint32_length and varint_length are the length-prefixed framings"`
+	Delimiter            string              `comment:"the delimiter of the records, \\n by default"`
+	DelimiterRegex       string              `comment:"the regex separator of the records, takes precedence over Delimiter"`
+
+	context     pipeline.Context
+	source      objectSource
+	splitter    *helper.RecordSplitter
+	checkpoints map[string]*objectCheckpoint
+	startAfter  string
+	runCtx      context.Context
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+func (r *InputObjectStorage) Init(ctx pipeline.Context) (int, error) {
+	r.context = ctx
+	if r.IntervalSec <= 0 {
+		r.IntervalSec = 60
+	}
+	if r.MaxReadBytes <= 0 {
+		r.MaxReadBytes = 8 * 1024 * 1024
+	}
+	splitter, err := helper.NewRecordSplitter(helper.RecordSplitterConfig{
+		Framing:        r.Framing,
+		Delimiter:      r.Delimiter,
+		DelimiterRegex: r.DelimiterRegex,
+		MaxFrameSize:   r.MaxReadBytes,
+	})
+	if err != nil {
+		return 0, err
+	}
+	r.splitter = splitter
+	client := &http.Client{Timeout: time.Duration(r.IntervalSec) * time.Second}
+	switch {
+	case r.Bucket != "":
+		endpoint, err := url.Parse(r.Endpoint)
+		if err != nil || endpoint.Host == "" {
+			return 0, fmt.Errorf("invalid endpoint %q of bucket %s", r.Endpoint, r.Bucket)
+		}
+		provider, err := r.credentialProvider()
+		if err != nil {
+			return 0, err
+		}
+		r.source = &s3Source{
+			client:      client,
+			endpoint:    endpoint,
+			bucket:      r.Bucket,
+			prefix:      r.Prefix,
+			region:      r.Region,
+			pathStyle:   r.PathStyle,
+			credentials: provider,
+		}
+	case len(r.URLs) > 0:
+		r.source = &httpSource{client: client, urls: r.URLs}
+		// the urls are always requested
+		r.OrderedKeys = false
+	default:
+		return 0, fmt.Errorf("either Bucket or URLs must be set")
+	}
+	r.checkpoints = make(map[string]*objectCheckpoint)
+	r.context.GetCheckPointObject(checkpointKey, &r.checkpoints)
+	if r.OrderedKeys {
+		if value, ok := r.context.GetCheckPoint(startAfterKey); ok {
+			r.startAfter = string(value)
+		}
+	}
+	r.runCtx, r.cancel = context.WithCancel(context.Background())
+	// Start is called once after Init, adding it here makes sure that Stop waits for it even if
+	// Stop is called before the goroutine of Start runs.
+	r.wg.Add(1)
+	return 0, nil
+}
+
+// credentialProvider returns the provider of the access key pair, which is nil for the anonymous access.
+func (r *InputObjectStorage) credentialProvider() (credentials.Provider, error) {
+	if r.Credentials != nil {
+		return r.Credentials.NewProvider()
+	}
+	if r.AccessKeyID == "" {
+		return nil, nil
+	}
+	return (&credentials.Config{
+		Provider:        credentials.ProviderStatic,
+		AccessKeyID:     r.AccessKeyID,
+		AccessKeySecret: r.AccessKeySecret,
+		SecurityToken:   r.SecurityToken,
+	}).NewProvider()
+}
+
+func (r *InputObjectStorage) Description() string {
+	return "incrementally read the append-only objects of the S3 compatible services or the files of http servers"
+}
+
+func (r *InputObjectStorage) Collect(collector pipeline.Collector) error {
+	return nil
+}
+
+func (r *InputObjectStorage) Start(collector pipeline.Collector) error {
+	defer r.wg.Done()
+	ticker := time.NewTicker(time.Duration(r.IntervalSec) * time.Second)
+	defer ticker.Stop()
+	for {
+		r.poll(collector)
+		select {
+		case <-r.runCtx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *InputObjectStorage) Stop() error {
+	r.cancel()
+	r.wg.Wait()
+	return nil
+}
+
+// poll reads the new content of the listed objects, the objects modified earlier are read first. The
+// checkpoints are saved once after all the objects are read.
+func (r *InputObjectStorage) poll(collector pipeline.Collector) {
+	objects, err := r.source.list(r.runCtx, r.startAfter)
+	if err != nil {
+		if r.runCtx.Err() == nil {
+			logger.Warning(r.context.GetRuntimeContext(), util.AlarmObjectStorage, "list the objects error", err)
+		}
+		return
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].LastModified.Equal(objects[j].LastModified) {
+			return objects[i].Key < objects[j].Key
+		}
+		return objects[i].LastModified.Before(objects[j].LastModified)
+	})
+	var minModified time.Time
+	if r.MaxObjectAgeHours > 0 {
+		minModified = time.Now().Add(-time.Duration(r.MaxObjectAgeHours) * time.Hour)
+	}
+	listed := make(map[string]bool, len(objects))
+	for _, object := range objects {
+		if object.LastModified.Before(minModified) {
+			continue
+		}
+		listed[object.Key] = true
+		if object.Unavailable {
+			continue
+		}
+		cp, ok := r.checkpoints[object.Key]
+		if !ok {
+			cp = &objectCheckpoint{}
+			r.checkpoints[object.Key] = cp
+		}
+		if overwritten(object, cp) {
+			*cp = objectCheckpoint{}
+		}
+		cp.ETag = object.ETag
+		if r.runCtx.Err() != nil {
+			continue
+		}
+		if err := r.readObject(collector, object, cp); err != nil && r.runCtx.Err() == nil {
+			logger.Warning(r.context.GetRuntimeContext(), util.AlarmObjectStorage, "read the object error", err, "path", r.source.path(object.Key))
+		}
+	}
+	// drop the checkpoints of the deleted, expired or skipped objects
+	for key := range r.checkpoints {
+		if !listed[key] {
+			delete(r.checkpoints, key)
+		}
+	}
+	if r.OrderedKeys {
+		r.advanceStartAfter(objects, minModified)
+	}
+	r.saveCheckpoints()
+}
+
+// overwritten returns whether the object is truncated or overwritten since the last reading. The size
+// of an appended object grows, so the same size with a different ETag means overwritten, and the
+// compressed objects cannot be appended.
+func overwritten(object objectInfo, cp *objectCheckpoint) bool {
+	if object.Size < cp.Offset {
+		return true
+	}
+	if cp.ETag == "" || object.ETag == cp.ETag {
+		return false
+	}
+	return object.Size == cp.Size || compressionOf(object.Key) != decompress.None
+}
+
+// advanceStartAfter moves the start of listing over the leading objects in the order of keys, which are
+// expired or the compressed ones read completely, they are never read again.
+func (r *InputObjectStorage) advanceStartAfter(objects []objectInfo, minModified time.Time) {
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Key < objects[j].Key
+	})
+	for _, object := range objects {
+		if !object.LastModified.Before(minModified) {
+			cp, ok := r.checkpoints[object.Key]
+			if compressionOf(object.Key) == decompress.None || !ok || cp.Offset < object.Size {
+				break
+			}
+		}
+		r.startAfter = object.Key
+		delete(r.checkpoints, object.Key)
+	}
+}
+
+func (r *InputObjectStorage) readObject(collector pipeline.Collector, object objectInfo, cp *objectCheckpoint) error {
+	tags := map[string]string{pathTag: r.source.path(object.Key)}
+	if compression := compressionOf(object.Key); compression != decompress.None {
+		// the compressed objects are read only once as a whole, because they cannot be appended.
+		if cp.Offset > 0 || object.Size == 0 {
+			return nil
+		}
+		err := r.readCompressedObject(collector, object, compression, tags, cp)
+		if errors.Is(err, decompress.ErrTooLarge) {
+			// skip the rest of the object, reading it again gets the same error.
+			logger.Warning(r.context.GetRuntimeContext(), util.AlarmObjectStorage, "the decompressed object exceeds the limit", r.MaxDecompressedBytes, "path", tags[pathTag])
+		} else if err != nil {
+			return err
+		}
+		cp.Offset, cp.Size, cp.Records = object.Size, object.Size, 0
+		return nil
+	}
+	for cp.Offset < object.Size {
+		end := cp.Offset + int64(r.MaxReadBytes)
+		if end > object.Size {
+			end = object.Size
+		}
+		body, err := r.source.read(r.runCtx, object.Key, cp.Offset, end)
+		if err != nil {
+			return err
+		}
+		// the content is collected only when read completely, so that an interrupted reading is not
+		// collected twice.
+		data, err := io.ReadAll(body)
+		_ = body.Close()
+		if err != nil {
+			return err
+		}
+		consumed := r.addLines(collector, tags, data, false)
+		if consumed == 0 && len(data) == r.MaxReadBytes {
+			// the line is too long, collect the read part as a line.
+			r.addLine(collector, tags, data)
+			consumed = len(data)
+		}
+		if consumed < len(data) && end == object.Size && cp.Size == object.Size {
+			// the object stops growing since the last reading, collect the incomplete last line.
+			consumed += r.addLines(collector, tags, data[consumed:], true)
+		}
+		cp.Offset += int64(consumed)
+		if consumed < len(data) || consumed == 0 {
+			// wait for the rest of the incomplete last line
+			break
+		}
+	}
+	cp.Size = object.Size
+	return nil
+}
+
+// readCompressedObject collects the records of the compressed object, the records collected before an
+// interruption are skipped.
+func (r *InputObjectStorage) readCompressedObject(collector pipeline.Collector, object objectInfo, compression string, tags map[string]string, cp *objectCheckpoint) error {
+	body, err := r.source.read(r.runCtx, object.Key, 0, object.Size)
+	if err != nil {
+		return err
+	}
+	defer body.Close() //nolint:errcheck
+	reader, err := decompress.NewReader(body, compression, r.MaxDecompressedBytes)
+	if err != nil {
+		return err
+	}
+	defer reader.Close() //nolint:errcheck
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), r.MaxReadBytes)
+	scanner.Split(r.splitter.Split)
+	var records int64
+	for scanner.Scan() {
+		records++
+		if records <= cp.Records {
+			continue
+		}
+		r.addLine(collector, tags, scanner.Bytes())
+		cp.Records = records
+	}
+	return scanner.Err()
+}
+
+// addLines collects the complete lines of data, and returns the consumed size. The rest of data is
+// collected as the last line at EOF or when it cannot be split.
+func (r *InputObjectStorage) addLines(collector pipeline.Collector, tags map[string]string, data []byte, atEOF bool) int {
+	consumed := 0
+	for consumed < len(data) {
+		advance, line, err := r.splitter.Split(data[consumed:], atEOF)
+		if err != nil {
+			logger.Warning(r.context.GetRuntimeContext(), util.AlarmObjectStorage, "split the records error", err, "path", tags[pathTag], "offset", consumed)
+			advance, line = len(data)-consumed, data[consumed:]
+		}
+		if advance == 0 {
+			break
+		}
+		r.addLine(collector, tags, line)
+		consumed += advance
+	}
+	return consumed
+}
+
+func (r *InputObjectStorage) addLine(collector pipeline.Collector, tags map[string]string, line []byte) {
+	if len(line) == 0 {
+		return
+	}
+	collector.AddData(tags, map[string]string{r.ContentKey: string(line)})
+}
+
+// compressionOf returns the compression of the object by the suffix of the key.
+func compressionOf(key string) string {
+	switch {
+	case strings.HasSuffix(key, ".gz"):
+		return decompress.Gzip
+	case strings.HasSuffix(key, ".zst"):
+		return decompress.Zstd
+	}
+	return decompress.None
+}
+
+func (r *InputObjectStorage) saveCheckpoints() {
+	if err := r.context.SaveCheckPointObject(checkpointKey, r.checkpoints); err != nil {
+		logger.Warning(r.context.GetRuntimeContext(), util.AlarmCheckpointSave, "save the checkpoints of the objects error", err)
+	}
+	if r.OrderedKeys {
+		if err := r.context.SaveCheckPoint(startAfterKey, []byte(r.startAfter)); err != nil {
+			logger.Warning(r.context.GetRuntimeContext(), util.AlarmCheckpointSave, "save the start of listing error", err)
+		}
+	}
+}
+
+func init() {
+	pipeline.ServiceInputs[pluginName] = func() pipeline.ServiceInput {
+		return &InputObjectStorage{
+			Region:               "us-east-1",
+			IntervalSec:          60,
+			MaxReadBytes:         8 * 1024 * 1024,
+			ContentKey:           "content",
+			MaxDecompressedBytes: 1024 * 1024 * 1024,
+		}
+	}
+}
@@ -0,0 +1,285 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objectstorage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/md5" //nolint:gosec
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/plugins/test"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+// fakeBucket serves the ListObjectsV2 and the range GetObject requests in the path style.
+type fakeBucket struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	// lists is the start-after of the list requests
+	lists []string
+}
+
+func (b *fakeBucket) put(key string, content []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.objects[key] = content
+}
+
+func (b *fakeBucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+	if key == "" {
+		prefix := r.URL.Query().Get("prefix")
+		startAfter := r.URL.Query().Get("start-after")
+		b.lists = append(b.lists, startAfter)
+		var sb strings.Builder
+		sb.WriteString("<ListBucketResult><IsTruncated>false</IsTruncated>")
+		for k, v := range b.objects {
+			if strings.HasPrefix(k, prefix) && k > startAfter {
+				fmt.Fprintf(&sb, "<Contents><Key>%s</Key><LastModified>2023-01-01T00:00:00.000Z</LastModified><ETag>\"%x\"</ETag><Size>%d</Size></Contents>", k, md5.Sum(v), len(v))
+			}
+		}
+		sb.WriteString("</ListBucketResult>")
+		_, _ = w.Write([]byte(sb.String()))
+		return
+	}
+	content, ok := b.objects[key]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var start, end int
+	if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(end-start+1))
+	w.WriteHeader(http.StatusPartialContent)
+	_, _ = w.Write(content[start : end+1])
+}
+
+func newInput(t *testing.T, endpoint string) (*InputObjectStorage, *test.MockMetricCollector) {
+	input := pipeline.ServiceInputs[pluginName]().(*InputObjectStorage)
+	input.Endpoint = endpoint
+	input.Bucket = "bucket"
+	input.Prefix = "logs/"
+	input.PathStyle = true
+	_, err := input.Init(mock.NewEmptyContext("project", "store", "config"))
+	require.NoError(t, err)
+	return input, &test.MockMetricCollector{}
+}
+
+func contents(collector *test.MockMetricCollector) []string {
+	var lines []string
+	for _, log := range collector.Logs {
+		for _, content := range log.Contents {
+			if content.Key == "content" {
+				lines = append(lines, content.Value)
+			}
+		}
+	}
+	return lines
+}
+
+func TestPollAppendedObject(t *testing.T) {
+	bucket := &fakeBucket{objects: map[string][]byte{"logs/a.log": []byte("line1\nline2\nline3")}}
+	server := httptest.NewServer(bucket)
+	defer server.Close()
+	input, collector := newInput(t, server.URL)
+
+	input.poll(collector)
+	assert.Equal(t, []string{"line1", "line2"}, contents(collector))
+	assert.Equal(t, "s3://bucket/logs/a.log", collector.Logs[0].Contents[0].Value)
+
+	// the incomplete line is appended
+	bucket.put("logs/a.log", []byte("line1\nline2\nline3-appended\nline4"))
+	input.poll(collector)
+	assert.Equal(t, []string{"line1", "line2", "line3-appended"}, contents(collector))
+
+	// the object stops growing
+	input.poll(collector)
+	assert.Equal(t, []string{"line1", "line2", "line3-appended", "line4"}, contents(collector))
+	assert.Equal(t, int64(32), input.checkpoints["logs/a.log"].Offset)
+}
+
+func TestPollWithCheckpoint(t *testing.T) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, _ = writer.Write([]byte("gz1\ngz2\n"))
+	_ = writer.Close()
+	bucket := &fakeBucket{objects: map[string][]byte{
+		"logs/a.log":    []byte("line1\n"),
+		"logs/b.log.gz": buf.Bytes(),
+		"other/c.log":   []byte("ignored\n"),
+	}}
+	server := httptest.NewServer(bucket)
+	defer server.Close()
+	input, collector := newInput(t, server.URL)
+	input.MaxReadBytes = 4
+	input.poll(collector)
+	assert.ElementsMatch(t, []string{"line", "1", "gz1", "gz2"}, contents(collector))
+
+	// restart from the checkpoint
+	ctx := input.context
+	bucket.put("logs/a.log", []byte("line1\nline2\n"))
+	input = pipeline.ServiceInputs[pluginName]().(*InputObjectStorage)
+	input.Endpoint, input.Bucket, input.Prefix, input.PathStyle = server.URL, "bucket", "logs/", true
+	_, err := input.Init(ctx)
+	require.NoError(t, err)
+	collector = &test.MockMetricCollector{}
+	input.poll(collector)
+	assert.Equal(t, []string{"line2"}, contents(collector))
+
+	// the checkpoints of the deleted objects are dropped
+	bucket.mu.Lock()
+	delete(bucket.objects, "logs/b.log.gz")
+	bucket.mu.Unlock()
+	input.poll(collector)
+	assert.Len(t, input.checkpoints, 1)
+}
+
+func TestPollWithDelimiter(t *testing.T) {
+	bucket := &fakeBucket{objects: map[string][]byte{"logs/a.log": []byte("record1\nline2\x1e\nrecord2\x1e")}}
+	server := httptest.NewServer(bucket)
+	defer server.Close()
+	input, collector := newInput(t, server.URL)
+	input.Delimiter = "\x1e\n"
+	_, err := input.Init(input.context)
+	require.NoError(t, err)
+
+	input.poll(collector)
+	assert.Equal(t, []string{"record1\nline2"}, contents(collector))
+	input.poll(collector)
+	assert.Equal(t, []string{"record1\nline2", "record2\x1e"}, contents(collector))
+}
+
+func TestPollHTTPFiles(t *testing.T) {
+	content := "GET /index.html 200\nGET /favicon.ico 404\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "access.log", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+	input := pipeline.ServiceInputs[pluginName]().(*InputObjectStorage)
+	input.URLs = []string{server.URL + "/access.log"}
+	_, err := input.Init(mock.NewEmptyContext("project", "store", "config"))
+	require.NoError(t, err)
+	collector := &test.MockMetricCollector{}
+
+	input.poll(collector)
+	input.poll(collector)
+	assert.Equal(t, []string{"GET /index.html 200", "GET /favicon.ico 404"}, contents(collector))
+}
+
+func TestInitWithoutSource(t *testing.T) {
+	input := pipeline.ServiceInputs[pluginName]().(*InputObjectStorage)
+	_, err := input.Init(mock.NewEmptyContext("project", "store", "config"))
+	assert.Error(t, err)
+}
+
+func TestPollDecompressionLimit(t *testing.T) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, _ = writer.Write([]byte("gz1\ngz2\n"))
+	_ = writer.Close()
+	bucket := &fakeBucket{objects: map[string][]byte{"logs/a.log.gz": buf.Bytes()}}
+	server := httptest.NewServer(bucket)
+	defer server.Close()
+	input, collector := newInput(t, server.URL)
+	input.MaxDecompressedBytes = 4
+
+	input.poll(collector)
+	input.poll(collector)
+	assert.Equal(t, []string{"gz1"}, contents(collector))
+	assert.Equal(t, int64(buf.Len()), input.checkpoints["logs/a.log.gz"].Offset)
+}
+
+func TestPollOverwrittenObject(t *testing.T) {
+	bucket := &fakeBucket{objects: map[string][]byte{"logs/a.log": []byte("line1\n")}}
+	server := httptest.NewServer(bucket)
+	defer server.Close()
+	input, collector := newInput(t, server.URL)
+
+	input.poll(collector)
+	// overwritten in the same size
+	bucket.put("logs/a.log", []byte("line2\n"))
+	input.poll(collector)
+	input.poll(collector)
+	assert.Equal(t, []string{"line1", "line2"}, contents(collector))
+}
+
+func TestPollOrderedKeys(t *testing.T) {
+	gz := func(content string) []byte {
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		_, _ = writer.Write([]byte(content))
+		_ = writer.Close()
+		return buf.Bytes()
+	}
+	bucket := &fakeBucket{objects: map[string][]byte{
+		"logs/1.log.gz": gz("gz1\n"),
+		"logs/2.log.gz": gz("gz2\n"),
+		"logs/3.log":    []byte("line3\n"),
+		"logs/4.log.gz": gz("gz4\n"),
+	}}
+	server := httptest.NewServer(bucket)
+	defer server.Close()
+	input, collector := newInput(t, server.URL)
+	input.OrderedKeys = true
+
+	input.poll(collector)
+	assert.ElementsMatch(t, []string{"gz1", "gz2", "line3", "gz4"}, contents(collector))
+	assert.Equal(t, "logs/2.log.gz", input.startAfter)
+	assert.Len(t, input.checkpoints, 2)
+
+	// restart from the checkpoint
+	ctx := input.context
+	input = pipeline.ServiceInputs[pluginName]().(*InputObjectStorage)
+	input.Endpoint, input.Bucket, input.Prefix, input.PathStyle, input.OrderedKeys = server.URL, "bucket", "logs/", true, true
+	_, err := input.Init(ctx)
+	require.NoError(t, err)
+	input.poll(collector)
+	assert.Len(t, collector.Logs, 4)
+	assert.Equal(t, []string{"", "logs/2.log.gz"}, bucket.lists)
+}
+
+func TestReadCompressedObjectAfterInterruption(t *testing.T) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, _ = writer.Write([]byte("gz1\ngz2\ngz3\n"))
+	_ = writer.Close()
+	bucket := &fakeBucket{objects: map[string][]byte{"logs/a.log.gz": buf.Bytes()}}
+	server := httptest.NewServer(bucket)
+	defer server.Close()
+	input, collector := newInput(t, server.URL)
+	// two records are collected before the interruption
+	input.checkpoints["logs/a.log.gz"] = &objectCheckpoint{Records: 2}
+
+	input.poll(collector)
+	assert.Equal(t, []string{"gz3"}, contents(collector))
+	assert.Equal(t, int64(0), input.checkpoints["logs/a.log.gz"].Records)
+}
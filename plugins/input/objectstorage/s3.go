@@ -0,0 +1,135 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objectstorage
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper/credentials"
+)
+
+// s3Source reads the objects of a bucket by the S3 API, which is also supported by the S3 compatible
+// services, such as OSS, MinIO and COS. The requests are signed by the AWS signature version 4.
+type s3Source struct {
+	client    *http.Client
+	endpoint  *url.URL
+	bucket    string
+	prefix    string
+	region    string
+	pathStyle bool
+	// credentials provides the access key pair, the bucket is accessed anonymously if nil
+	credentials credentials.Provider
+}
+
+type listBucketResult struct {
+	IsTruncated           bool
+	NextContinuationToken string
+	Contents              []struct {
+		Key          string
+		LastModified time.Time
+		ETag         string
+		Size         int64
+	}
+}
+
+func (s *s3Source) list(ctx context.Context, startAfter string) ([]objectInfo, error) {
+	var objects []objectInfo
+	query := url.Values{}
+	query.Set("list-type", "2")
+	query.Set("prefix", s.prefix)
+	if startAfter != "" {
+		query.Set("start-after", startAfter)
+	}
+	for {
+		resp, err := s.do(ctx, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decode the objects of bucket %s error: %v", s.bucket, err)
+		}
+		for _, content := range result.Contents {
+			objects = append(objects, objectInfo{
+				Key:          content.Key,
+				Size:         content.Size,
+				ETag:         content.ETag,
+				LastModified: content.LastModified,
+			})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
+
+func (s *s3Source) read(ctx context.Context, key string, offset, end int64) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, key, nil, map[string]string{"Range": rangeHeader(offset, end)})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *s3Source) path(key string) string {
+	return "s3://" + s.bucket + "/" + key
+}
+
+// do sends the signed GET request of @key, the bucket itself when @key is empty.
+func (s *s3Source) do(ctx context.Context, key string, query url.Values, headers map[string]string) (*http.Response, error) {
+	u := *s.endpoint
+	if s.pathStyle {
+		u.Path = "/" + s.bucket + "/" + key
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = "/" + key
+	}
+	// send the path escaped in the same way as signed.
+	u.RawPath = credentials.CanonicalURI(u.Path)
+	u.RawQuery = credentials.CanonicalQuery(query)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if s.credentials != nil {
+		cred, err := s.credentials.Retrieve()
+		if err != nil {
+			return nil, err
+		}
+		credentials.SignV4(req, nil, cred, s.region, "s3", time.Now())
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("get %s: unexpected status code %d: %s", u.Path, resp.StatusCode, body)
+	}
+	return resp, nil
+}
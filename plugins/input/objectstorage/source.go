@@ -0,0 +1,127 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objectstorage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// objectInfo is the state of an object when listing.
+type objectInfo struct {
+	Key          string
+	Size         int64
+	ETag         string
+	LastModified time.Time
+	// Unavailable means the object cannot be accessed now, it is not read and its checkpoint is kept.
+	Unavailable bool
+}
+
+// objectSource lists the objects to collect and reads their contents.
+type objectSource interface {
+	// list returns the objects of the source, the keys not after @startAfter are skipped if supported.
+	list(ctx context.Context, startAfter string) ([]objectInfo, error)
+	// read returns the content of the object in [offset, end).
+	read(ctx context.Context, key string, offset, end int64) (io.ReadCloser, error)
+	// path returns the path of the object tagged to the logs.
+	path(key string) string
+}
+
+// httpSource reads the files served by http servers, the keys of the objects are the urls.
+type httpSource struct {
+	client *http.Client
+	urls   []string
+}
+
+func (s *httpSource) list(ctx context.Context, _ string) ([]objectInfo, error) {
+	objects := make([]objectInfo, 0, len(s.urls))
+	var lastErr error
+	available := 0
+	for _, url := range s.urls {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := s.client.Do(req)
+		if err == nil {
+			_ = resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				err = fmt.Errorf("head %s: unexpected status code %d", url, resp.StatusCode)
+			} else if resp.ContentLength < 0 {
+				err = fmt.Errorf("head %s: unknown content length", url)
+			}
+		}
+		if err != nil {
+			lastErr = err
+			objects = append(objects, objectInfo{Key: url, Unavailable: true})
+			continue
+		}
+		available++
+		lastModified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+		objects = append(objects, objectInfo{
+			Key:          url,
+			Size:         resp.ContentLength,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: lastModified,
+		})
+	}
+	if available == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return objects, nil
+}
+
+func (s *httpSource) read(ctx context.Context, key string, offset, end int64) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", rangeHeader(offset, end))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		return resp.Body, nil
+	case http.StatusOK:
+		// the server ignores the range, skip the read content.
+		if _, err = io.CopyN(io.Discard, resp.Body, offset); err != nil {
+			_ = resp.Body.Close()
+			return nil, err
+		}
+		return readCloser{Reader: io.LimitReader(resp.Body, end-offset), Closer: resp.Body}, nil
+	default:
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("get %s: unexpected status code %d", key, resp.StatusCode)
+	}
+}
+
+func (s *httpSource) path(key string) string {
+	return key
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func rangeHeader(offset, end int64) string {
+	return "bytes=" + strconv.FormatInt(offset, 10) + "-" + strconv.FormatInt(end-1, 10)
+}
@@ -16,12 +16,11 @@ package opentelemetry
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
-	"net/url"
-	"strings"
 	"sync"
 	"time"
 
@@ -29,12 +28,16 @@ import (
 	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
 	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
+	"github.com/alibaba/ilogtail/helper"
 	"github.com/alibaba/ilogtail/helper/decoder"
 	"github.com/alibaba/ilogtail/helper/decoder/common"
 	"github.com/alibaba/ilogtail/helper/decoder/opentelemetry"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/tlscommon"
+	"github.com/alibaba/ilogtail/pkg/util"
 	"github.com/alibaba/ilogtail/plugins/input/httpserver"
 )
 
@@ -62,6 +65,8 @@ type Server struct {
 	serverHTTP      *http.Server
 	grpcListener    net.Listener
 	httpListener    net.Listener
+	grpcTLSConfig   *tls.Config
+	httpTLSConfig   *tls.Config
 	logsReceiver    plogotlp.GRPCServer // currently logs are not supported
 	tracesReceiver  ptraceotlp.GRPCServer
 	metricsReceiver pmetricotlp.GRPCServer
@@ -79,7 +84,16 @@ func (s *Server) Init(context pipeline.Context) (int, error) {
 		if s.Protocals.GRPC.Endpoint == "" {
 			s.Protocals.GRPC.Endpoint = defaultGRPCEndpoint
 		}
-
+		if err := s.Protocals.GRPC.UnixSocket.Init(); err != nil {
+			return 0, err
+		}
+		if s.Protocals.GRPC.TLS != nil {
+			var err error
+			if s.grpcTLSConfig, err = s.Protocals.GRPC.TLS.LoadServerTLSConfig(); err != nil {
+				logger.Error(s.context.GetRuntimeContext(), util.AlarmServiceOTLPInit, "otlp grpc server init tls fail, error", err)
+				return 0, err
+			}
+		}
 	}
 
 	if s.Protocals.HTTP != nil {
@@ -97,6 +111,16 @@ func (s *Server) Init(context pipeline.Context) (int, error) {
 		if s.Protocals.HTTP.MaxRequestBodySizeMiB == 0 {
 			s.Protocals.HTTP.MaxRequestBodySizeMiB = 64
 		}
+		if err := s.Protocals.HTTP.UnixSocket.Init(); err != nil {
+			return 0, err
+		}
+		if s.Protocals.HTTP.TLS != nil {
+			var err error
+			if s.httpTLSConfig, err = s.Protocals.HTTP.TLS.LoadServerTLSConfig(); err != nil {
+				logger.Error(s.context.GetRuntimeContext(), util.AlarmServiceOTLPInit, "otlp http server init tls fail, error", err)
+				return 0, err
+			}
+		}
 
 	}
 
@@ -122,11 +146,13 @@ func (s *Server) StartService(ctx pipeline.PipelineContext) error {
 	s.logsReceiver = newLogsReceiver(ctx)
 
 	if s.Protocals.GRPC != nil {
-		grpcServer := grpc.NewServer(
-			serverGRPCOptions(s.Protocals.GRPC)...,
-		)
+		opts := serverGRPCOptions(s.Protocals.GRPC)
+		if s.grpcTLSConfig != nil {
+			opts = append(opts, grpc.Creds(credentials.NewTLS(s.grpcTLSConfig)))
+		}
+		grpcServer := grpc.NewServer(opts...)
 		s.serverGPRC = grpcServer
-		listener, err := getNetListener(s.Protocals.GRPC.Endpoint)
+		listener, err := helper.Listen(s.Protocals.GRPC.Endpoint, "tcp", s.Protocals.GRPC.UnixSocket)
 		if err != nil {
 			return err
 		}
@@ -163,10 +189,13 @@ func (s *Server) StartService(ctx pipeline.PipelineContext) error {
 		}
 
 		s.serverHTTP = httpServer
-		listener, err := getNetListener(s.Protocals.HTTP.Endpoint)
+		listener, err := helper.Listen(s.Protocals.HTTP.Endpoint, "tcp", s.Protocals.HTTP.UnixSocket)
 		if err != nil {
 			return err
 		}
+		if s.httpTLSConfig != nil {
+			listener = tls.NewListener(listener, s.httpTLSConfig)
+		}
 		s.httpListener = listener
 		logger.Info(s.context.GetRuntimeContext(), "otlp http server init", "initialized")
 
@@ -198,6 +227,13 @@ func (s *Server) Stop() error {
 		logger.Info(s.context.GetRuntimeContext(), "otlp http server stop", s.Protocals.HTTP.Endpoint)
 		s.wg.Wait()
 	}
+
+	if s.Protocals.GRPC != nil {
+		_ = s.Protocals.GRPC.TLS.Close()
+	}
+	if s.Protocals.HTTP != nil {
+		_ = s.Protocals.HTTP.TLS.Close()
+	}
 	return nil
 }
 
@@ -205,21 +241,21 @@ func (s *Server) registerHTTPLogsComsumer(serveMux *http.ServeMux, decoder decod
 	serveMux.HandleFunc(routing, func(w http.ResponseWriter, r *http.Request) {
 		data, err := handleInvalidRequest(w, r, maxBodySize, decoder)
 		if err != nil {
-			logger.Warning(s.context.GetRuntimeContext(), "READ_BODY_FAIL_ALARM", "read body failed", err, "request", r.URL.String())
+			logger.Warning(s.context.GetRuntimeContext(), util.AlarmReadBodyFail, "read body failed", err, "request", r.URL.String())
 			return
 		}
 
 		otlpLogReq := plogotlp.NewExportRequest()
 		otlpLogReq, err = opentelemetry.DecodeOtlpRequest(otlpLogReq, data, r)
 		if err != nil {
-			logger.Warning(s.context.GetRuntimeContext(), "DECODE_BODY_FAIL_ALARM", "decode body failed", err, "request", r.URL.String())
+			logger.Warning(s.context.GetRuntimeContext(), util.AlarmDecodeBodyFail, "decode body failed", err, "request", r.URL.String())
 			httpserver.BadRequest(w)
 			return
 		}
 
 		otlpResp, err := s.logsReceiver.Export(r.Context(), otlpLogReq)
 		if err != nil {
-			logger.Warning(s.context.GetRuntimeContext(), "EXPORT_REQ_FAIL_ALARM", "export logs failed", err, "request", r.URL.String())
+			logger.Warning(s.context.GetRuntimeContext(), util.AlarmExportReqFail, "export logs failed", err, "request", r.URL.String())
 			httpserver.BadRequest(w)
 			return
 		}
@@ -227,7 +263,7 @@ func (s *Server) registerHTTPLogsComsumer(serveMux *http.ServeMux, decoder decod
 		msg, contentType, err := marshalResp(otlpResp, r)
 
 		if err != nil {
-			logger.Warning(s.context.GetRuntimeContext(), "MARSHAL_RESP_FAIL_ALARM", "marshal resp failed", err, "request", r.URL.String())
+			logger.Warning(s.context.GetRuntimeContext(), util.AlarmMarshalRESPFail, "marshal resp failed", err, "request", r.URL.String())
 			httpserver.BadRequest(w)
 			return
 		}
@@ -240,28 +276,28 @@ func (s *Server) registerHTTPMetricsComsumer(serveMux *http.ServeMux, decoder de
 	serveMux.HandleFunc(routing, func(w http.ResponseWriter, r *http.Request) {
 		data, err := handleInvalidRequest(w, r, maxBodySize, decoder)
 		if err != nil {
-			logger.Warning(s.context.GetRuntimeContext(), "READ_BODY_FAIL_ALARM", "read body failed", err, "request", r.URL.String())
+			logger.Warning(s.context.GetRuntimeContext(), util.AlarmReadBodyFail, "read body failed", err, "request", r.URL.String())
 			return
 		}
 
 		otlpMetricReq := pmetricotlp.NewExportRequest()
 		otlpMetricReq, err = opentelemetry.DecodeOtlpRequest(otlpMetricReq, data, r)
 		if err != nil {
-			logger.Warning(s.context.GetRuntimeContext(), "DECODE_BODY_FAIL_ALARM", "decode body failed", err, "request", r.URL.String())
+			logger.Warning(s.context.GetRuntimeContext(), util.AlarmDecodeBodyFail, "decode body failed", err, "request", r.URL.String())
 			httpserver.BadRequest(w)
 			return
 		}
 
 		otlpResp, err := s.metricsReceiver.Export(r.Context(), otlpMetricReq)
 		if err != nil {
-			logger.Warning(s.context.GetRuntimeContext(), "EXPORT_REQ_FAIL_ALARM", "export metrics failed", err, "request", r.URL.String())
+			logger.Warning(s.context.GetRuntimeContext(), util.AlarmExportReqFail, "export metrics failed", err, "request", r.URL.String())
 			httpserver.BadRequest(w)
 			return
 		}
 
 		msg, contentType, err := marshalResp(otlpResp, r)
 		if err != nil {
-			logger.Warning(s.context.GetRuntimeContext(), "MARSHAL_RESP_FAIL_ALARM", "marshal resp failed", err, "request", r.URL.String())
+			logger.Warning(s.context.GetRuntimeContext(), util.AlarmMarshalRESPFail, "marshal resp failed", err, "request", r.URL.String())
 			httpserver.BadRequest(w)
 			return
 		}
@@ -274,21 +310,21 @@ func (s *Server) registerHTTPTracesComsumer(serveMux *http.ServeMux, decoder dec
 	serveMux.HandleFunc(routing, func(w http.ResponseWriter, r *http.Request) {
 		data, err := handleInvalidRequest(w, r, maxBodySize, decoder)
 		if err != nil {
-			logger.Warning(s.context.GetRuntimeContext(), "READ_BODY_FAIL_ALARM", "read body failed", err, "request", r.URL.String())
+			logger.Warning(s.context.GetRuntimeContext(), util.AlarmReadBodyFail, "read body failed", err, "request", r.URL.String())
 			return
 		}
 
 		otlpTraceReq := ptraceotlp.NewExportRequest()
 		otlpTraceReq, err = opentelemetry.DecodeOtlpRequest(otlpTraceReq, data, r)
 		if err != nil {
-			logger.Warning(s.context.GetRuntimeContext(), "DECODE_BODY_FAIL_ALARM", "decode body failed", err, "request", r.URL.String())
+			logger.Warning(s.context.GetRuntimeContext(), util.AlarmDecodeBodyFail, "decode body failed", err, "request", r.URL.String())
 			httpserver.BadRequest(w)
 			return
 		}
 
 		otlpResp, err := s.tracesReceiver.Export(r.Context(), otlpTraceReq)
 		if err != nil {
-			logger.Warning(s.context.GetRuntimeContext(), "EXPORT_REQ_FAIL_ALARM", "export traces failed", err, "request", r.URL.String())
+			logger.Warning(s.context.GetRuntimeContext(), util.AlarmExportReqFail, "export traces failed", err, "request", r.URL.String())
 			httpserver.BadRequest(w)
 			return
 		}
@@ -296,7 +332,7 @@ func (s *Server) registerHTTPTracesComsumer(serveMux *http.ServeMux, decoder dec
 		msg, contentType, err := marshalResp(otlpResp, r)
 
 		if err != nil {
-			logger.Warning(s.context.GetRuntimeContext(), "MARSHAL_RESP_FAIL_ALARM", "marshal resp failed", err, "request", r.URL.String())
+			logger.Warning(s.context.GetRuntimeContext(), util.AlarmMarshalRESPFail, "marshal resp failed", err, "request", r.URL.String())
 			httpserver.BadRequest(w)
 			return
 		}
@@ -327,24 +363,6 @@ func serverGRPCOptions(grpcConfig *GRPCServerSettings) []grpc.ServerOption {
 	return opts
 }
 
-func getNetListener(endpoint string) (net.Listener, error) {
-	var listener net.Listener
-	var err error
-	switch {
-	case strings.HasPrefix(endpoint, "http") ||
-		strings.HasPrefix(endpoint, "https") ||
-		strings.HasPrefix(endpoint, "tcp"):
-		configURL, errAddr := url.Parse(endpoint)
-		if errAddr != nil {
-			return nil, errAddr
-		}
-		listener, err = net.Listen("tcp", configURL.Host)
-	default:
-		listener, err = net.Listen("tcp", endpoint)
-	}
-	return listener, err
-}
-
 func marshalResp[
 	P interface {
 		MarshalProto() ([]byte, error)
@@ -415,6 +433,9 @@ type GRPCServerSettings struct {
 	MaxConcurrentStreams int
 	ReadBufferSize       int
 	WriteBufferSize      int
+	TLS                  *tlscommon.TLSConfig // serves with TLS, and verifies the client certificates if ClientAuth is set
+	// UnixSocket sets the file permissions of the socket when Endpoint is unix:///path/to/socket
+	UnixSocket *helper.UnixSocketConfig
 }
 
 type HTTPServerSettings struct {
@@ -422,6 +443,9 @@ type HTTPServerSettings struct {
 	MaxRequestBodySizeMiB int
 	ReadTimeoutSec        int
 	ShutdownTimeoutSec    int
+	TLS                   *tlscommon.TLSConfig // serves with TLS, and verifies the client certificates if ClientAuth is set
+	// UnixSocket sets the file permissions of the socket when Endpoint is unix:///path/to/socket
+	UnixSocket *helper.UnixSocketConfig
 }
 
 func init() {
@@ -19,6 +19,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -155,6 +156,27 @@ func TestOtlpGRPC_Metrics(t *testing.T) {
 	}
 }
 
+func TestOtlpGRPC_UnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "otlp.sock")
+	input, err := newInput(true, false, "unix://"+path, "")
+	require.NoError(t, err)
+
+	pipelineCxt := pipeline.NewObservePipelineConext(1)
+	require.NoError(t, input.StartService(pipelineCxt))
+	t.Cleanup(func() {
+		require.NoError(t, input.Stop())
+	})
+
+	cc, err := grpc.Dial("unix://"+path, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, cc.Close())
+	}()
+	require.NoError(t, exportTraces(cc, GenerateTraces(1)))
+	groupEvent := <-pipelineCxt.Collector().Observe()
+	assert.Equal(t, 1, len(groupEvent.Events))
+}
+
 func TestOtlpHTTP_Metrics(t *testing.T) {
 	endpointHTTP := test.GetAvailableLocalAddress(t)
 	input, err := newInput(false, true, "", endpointHTTP)
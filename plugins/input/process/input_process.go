@@ -17,6 +17,7 @@ package process
 import (
 	"github.com/alibaba/ilogtail/helper"
 	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/models"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/util"
 
@@ -28,6 +29,8 @@ import (
 const (
 	defaultMaxProcessCount     = 100
 	defaultMaxIdentifierLength = 100
+	// multiValuesMetricName is the name of the multi values metric emitted in the v2 pipeline.
+	multiValuesMetricName = "process"
 )
 
 // InputProcess plugin is modified with care, because two collect libs are used， which are procfs and gopsutil.
@@ -42,7 +45,10 @@ type InputProcess struct {
 	MinCPULimitPercent  float64           // The minimum CPU percentage for collecting.
 	MinMemoryLimitKB    int               // The minimum Memory usage for collecting.
 	ProcessNamesRegex   []string          // The regular expressions for matching processes.
+	ExcludeNamesRegex   []string          // The regular expressions for excluding processes, which takes precedence over ProcessNamesRegex.
 	Labels              map[string]string // The user custom labels.
+	// Attach the cgroup path and the container meta of the processes as labels, only available on Linux.
+	ContainerLabels bool
 	// The optional metric switches
 	OpenFD bool
 	Thread bool
@@ -52,6 +58,7 @@ type InputProcess struct {
 	context       pipeline.Context
 	lastProcesses map[int]processCache
 	regexpList    []*regexp.Regexp
+	excludeList   []*regexp.Regexp
 	commonLabels  helper.KeyValues
 	collectTime   time.Time
 }
@@ -64,13 +71,8 @@ func (ip *InputProcess) Init(context pipeline.Context) (int, error) {
 	if ip.MaxIdentifierLength <= 0 {
 		ip.MaxIdentifierLength = defaultMaxIdentifierLength
 	}
-	for _, regStr := range ip.ProcessNamesRegex {
-		if reg, err := regexp.Compile(regStr); err == nil {
-			ip.regexpList = append(ip.regexpList, reg)
-		} else {
-			logger.Error(ip.context.GetRuntimeContext(), "INVALID_REGEX_ALARM", "invalid regex", regStr, "error", err)
-		}
-	}
+	ip.regexpList = ip.compileRegexps(ip.ProcessNamesRegex)
+	ip.excludeList = ip.compileRegexps(ip.ExcludeNamesRegex)
 	ip.commonLabels.Append("hostname", util.GetHostName())
 	ip.commonLabels.Append("ip", util.GetIPAddress())
 	for key, val := range ip.Labels {
@@ -79,6 +81,17 @@ func (ip *InputProcess) Init(context pipeline.Context) (int, error) {
 	return 0, nil
 }
 
+func (ip *InputProcess) compileRegexps(regStrs []string) (regexpList []*regexp.Regexp) {
+	for _, regStr := range regStrs {
+		if reg, err := regexp.Compile(regStr); err == nil {
+			regexpList = append(regexpList, reg)
+		} else {
+			logger.Error(ip.context.GetRuntimeContext(), util.AlarmInvalidRegex, "invalid regex", regStr, "error", err)
+		}
+	}
+	return
+}
+
 func (ip *InputProcess) Description() string {
 	return "Support collect process metrics on the host machine or Linux virtual environments."
 }
@@ -91,31 +104,56 @@ func (ip *InputProcess) Collect(collector pipeline.Collector) error {
 	}
 	for _, pc := range matchedProcesses {
 		labels := pc.Labels(ip.commonLabels)
-		// add necessary metrics
-		ip.addCPUMetrics(pc, labels, collector)
-		ip.addMemMetrics(pc, labels, collector)
-		// add optional metrics
-		if ip.Thread {
-			ip.addThreadMetrics(pc, labels, collector)
-		}
-		if ip.OpenFD {
-			ip.addOpenFilesMetrics(pc, labels, collector)
-		}
-		if ip.NetIO {
-			ip.addNetIOMetrics(pc, labels, collector)
-		}
-		if ip.IO {
-			ip.addIOMetrics(pc, labels, collector)
-		}
+		ip.fetchMetrics(pc, func(name string, value float64) {
+			helper.AddMetric(collector, "process_"+name, ip.collectTime, labels, value)
+		})
 	}
 	return nil
 }
 
+// Read emits a multi values metric for each matched process in the v2 pipeline.
+func (ip *InputProcess) Read(context pipeline.PipelineContext) error {
+	ip.collectTime = time.Now()
+	matchedProcesses, err := ip.filterMatchedProcesses()
+	if err != nil {
+		return err
+	}
+	events := make([]models.PipelineEvent, 0, len(matchedProcesses))
+	for _, pc := range matchedProcesses {
+		values := models.NewMetricMultiValue()
+		ip.fetchMetrics(pc, values.Add)
+		events = append(events, models.NewMultiValuesMetric(multiValuesMetricName, models.MetricTypeGauge,
+			pc.Tags(ip.commonLabels), ip.collectTime.UnixNano(), values.GetMultiValues()))
+	}
+	context.Collector().Collect(models.NewGroup(models.NewMetadata(), models.NewTags()), events...)
+	return nil
+}
+
+// fetchMetrics fetches the necessary metrics and the enabled optional metrics of the process.
+func (ip *InputProcess) fetchMetrics(pc processCache, add func(name string, value float64)) {
+	// add necessary metrics
+	ip.addCPUMetrics(pc, add)
+	ip.addMemMetrics(pc, add)
+	// add optional metrics
+	if ip.Thread {
+		ip.addThreadMetrics(pc, add)
+	}
+	if ip.OpenFD {
+		ip.addOpenFilesMetrics(pc, add)
+	}
+	if ip.NetIO {
+		ip.addNetIOMetrics(pc, add)
+	}
+	if ip.IO {
+		ip.addIOMetrics(pc, add)
+	}
+}
+
 // filterMatchedProcesses select the matched processing in the whole processes.
 func (ip *InputProcess) filterMatchedProcesses() (matchedProcesses []processCache, err error) {
 	caches, err := findAllProcessCache(ip.MaxProcessCount)
 	if err != nil {
-		logger.Error(ip.context.GetRuntimeContext(), "PROCESS_LIST_ALARM", "error", err)
+		logger.Error(ip.context.GetRuntimeContext(), util.AlarmProcessList, "error", err)
 		return
 	}
 	matchedProcesses = ip.filterRegexMatchedProcess(caches)
@@ -128,8 +166,8 @@ func (ip *InputProcess) filterMatchedProcesses() (matchedProcesses []processCach
 func (ip *InputProcess) filterRegexMatchedProcess(caches []processCache) (matchedProcesses []processCache) {
 	newProcessesMap := make(map[int]processCache)
 	matchedProcesses = make([]processCache, 0, util.MinInt(ip.MaxProcessCount, len(caches)))
-	regexpChecker := func(name string) bool {
-		for _, r := range ip.regexpList {
+	regexpChecker := func(regexpList []*regexp.Regexp, name string) bool {
+		for _, r := range regexpList {
 			if r.MatchString(name) {
 				return true
 			}
@@ -138,14 +176,23 @@ func (ip *InputProcess) filterRegexMatchedProcess(caches []processCache) (matche
 	}
 	for _, pc := range caches {
 		// filter by history cache processes or regex conditions.
+		isNew := false
 		if lpc, ok := ip.lastProcesses[pc.GetPid()]; ok && pc.Same(lpc) {
 			pc = lpc
-		} else if len(ip.regexpList) > 0 && !regexpChecker(pc.GetExe()) && !regexpChecker(pc.GetCmdLine()) {
+		} else if len(ip.regexpList) > 0 && !regexpChecker(ip.regexpList, pc.GetExe()) && !regexpChecker(ip.regexpList, pc.GetCmdLine()) {
+			continue
+		} else if len(ip.excludeList) > 0 && (regexpChecker(ip.excludeList, pc.GetExe()) || regexpChecker(ip.excludeList, pc.GetCmdLine())) {
 			continue
+		} else {
+			isNew = true
 		}
 		if !pc.FetchCore() {
 			continue
 		}
+		if isNew && ip.ContainerLabels {
+			// the labels are built only once and cached in the process cache.
+			pc.Labels(ip.containerLabels(pc))
+		}
 		if pc.FetchCoreCount() > 1 {
 			matchedProcesses = append(matchedProcesses, pc)
 		}
@@ -218,52 +265,74 @@ func (ip *InputProcess) filterTopAndThresholdMatchedProcesses(processList []proc
 	return
 }
 
-func (ip *InputProcess) addCPUMetrics(pc processCache, labels string, collector pipeline.Collector) {
+// containerLabels appends the cgroup path and the meta of the container the process belongs to
+// to the common labels.
+func (ip *InputProcess) containerLabels(pc processCache) helper.KeyValues {
+	cgroup := pc.GetCgroup()
+	if cgroup == "" {
+		return ip.commonLabels
+	}
+	labels := ip.commonLabels.Clone()
+	labels.Append("cgroup", cgroup)
+	if id := helper.ContainerIDFromCgroup(cgroup); id != "" {
+		labels.Append("container_id", id)
+		if meta := helper.GetContainerMeta(id); meta != nil {
+			labels.Append("container_name", meta.ContainerName)
+			if meta.PodName != "" {
+				labels.Append("pod_name", meta.PodName)
+				labels.Append("namespace", meta.K8sNamespace)
+			}
+		}
+	}
+	return labels
+}
+
+func (ip *InputProcess) addCPUMetrics(pc processCache, add func(name string, value float64)) {
 	if percentage := pc.GetProcessStatus().CPUPercentage; percentage != nil {
-		helper.AddMetric(collector, "process_cpu_percent", ip.collectTime, labels, percentage.TotalPercentage)
-		helper.AddMetric(collector, "process_cpu_stime_percent", ip.collectTime, labels, percentage.STimePercentage)
-		helper.AddMetric(collector, "process_cpu_utime_percent", ip.collectTime, labels, percentage.UTimePercentage)
+		add("cpu_percent", percentage.TotalPercentage)
+		add("cpu_stime_percent", percentage.STimePercentage)
+		add("cpu_utime_percent", percentage.UTimePercentage)
 	}
 }
 
-func (ip *InputProcess) addMemMetrics(pc processCache, labels string, collector pipeline.Collector) {
+func (ip *InputProcess) addMemMetrics(pc processCache, add func(name string, value float64)) {
 	if mem := pc.GetProcessStatus().Memory; mem != nil {
-		helper.AddMetric(collector, "process_mem_rss", ip.collectTime, labels, float64(mem.Rss))
-		helper.AddMetric(collector, "process_mem_swap", ip.collectTime, labels, float64(mem.Swap))
-		helper.AddMetric(collector, "process_mem_vsz", ip.collectTime, labels, float64(mem.Vsz))
-		helper.AddMetric(collector, "process_mem_data", ip.collectTime, labels, float64(mem.Data))
+		add("mem_rss", float64(mem.Rss))
+		add("mem_swap", float64(mem.Swap))
+		add("mem_vsz", float64(mem.Vsz))
+		add("mem_data", float64(mem.Data))
 	}
 }
 
-func (ip *InputProcess) addThreadMetrics(pc processCache, labels string, collector pipeline.Collector) {
+func (ip *InputProcess) addThreadMetrics(pc processCache, add func(name string, value float64)) {
 	if pc.FetchThreads() {
-		helper.AddMetric(collector, "process_threads", ip.collectTime, labels, float64(pc.GetProcessStatus().ThreadsNum))
+		add("threads", float64(pc.GetProcessStatus().ThreadsNum))
 	}
 }
 
-func (ip *InputProcess) addOpenFilesMetrics(pc processCache, labels string, collector pipeline.Collector) {
+func (ip *InputProcess) addOpenFilesMetrics(pc processCache, add func(name string, value float64)) {
 	if pc.FetchFds() {
-		helper.AddMetric(collector, "process_fds", ip.collectTime, labels, float64(pc.GetProcessStatus().FdsNum))
+		add("fds", float64(pc.GetProcessStatus().FdsNum))
 	}
 }
 
-func (ip *InputProcess) addNetIOMetrics(pc processCache, labels string, collector pipeline.Collector) {
+func (ip *InputProcess) addNetIOMetrics(pc processCache, add func(name string, value float64)) {
 	if pc.FetchNetIO() {
 		net := pc.GetProcessStatus().NetIO
-		helper.AddMetric(collector, "process_net_in_bytes", ip.collectTime, labels, float64(net.InBytes))
-		helper.AddMetric(collector, "process_net_in_packet", ip.collectTime, labels, float64(net.InPacket))
-		helper.AddMetric(collector, "process_net_out_bytes", ip.collectTime, labels, float64(net.OutBytes))
-		helper.AddMetric(collector, "process_net_out_packet", ip.collectTime, labels, float64(net.OutPacket))
+		add("net_in_bytes", float64(net.InBytes))
+		add("net_in_packet", float64(net.InPacket))
+		add("net_out_bytes", float64(net.OutBytes))
+		add("net_out_packet", float64(net.OutPacket))
 	}
 }
 
-func (ip *InputProcess) addIOMetrics(pc processCache, labels string, collector pipeline.Collector) {
+func (ip *InputProcess) addIOMetrics(pc processCache, add func(name string, value float64)) {
 	if pc.FetchIO() {
 		io := pc.GetProcessStatus().IO
-		helper.AddMetric(collector, "process_read_bytes", ip.collectTime, labels, float64(io.ReadeBytes))
-		helper.AddMetric(collector, "process_write_bytes", ip.collectTime, labels, float64(io.WriteBytes))
-		helper.AddMetric(collector, "process_read_count", ip.collectTime, labels, float64(io.ReadCount))
-		helper.AddMetric(collector, "process_write_count", ip.collectTime, labels, float64(io.WriteCount))
+		add("read_bytes", float64(io.ReadeBytes))
+		add("write_bytes", float64(io.WriteBytes))
+		add("read_count", float64(io.ReadCount))
+		add("write_count", float64(io.WriteCount))
 	}
 }
 
@@ -16,6 +16,7 @@ package process
 
 import (
 	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/models"
 
 	"time"
 )
@@ -26,8 +27,11 @@ type processCache interface {
 	Same(cache processCache) bool
 	GetExe() string
 	GetCmdLine() string
+	// GetCgroup returns the cgroup path of the process, which is empty when unsupported.
+	GetCgroup() string
 	FetchCoreCount() int64
 	Labels(values helper.KeyValues) string
+	Tags(values helper.KeyValues) models.Tags
 	GetProcessStatus() *processStatus
 	// FetchCore fetch the core exported status, such as CPU and Memory.
 	FetchCore() bool
@@ -41,12 +45,14 @@ type (
 	// processMeta contains the stable process meta data.
 	processMeta struct {
 		maxLabelLength int
-		labels         string    // The custom labels
-		lastFetchTime  time.Time // The last fetch stat time
-		nowFetchTime   time.Time // The fetch stat time
-		fetchCoreCount int64     // Auto increment, the max value supports running 1462356043387 years when the fetching frequency is 5s
-		cmdline        string    // The command line
-		exe            string    // The absolute path of the executable command
+		labels         string           // The custom labels
+		labelValues    helper.KeyValues // The custom labels in key values
+		lastFetchTime  time.Time        // The last fetch stat time
+		nowFetchTime   time.Time        // The fetch stat time
+		fetchCoreCount int64            // Auto increment, the max value supports running 1462356043387 years when the fetching frequency is 5s
+		cmdline        string           // The command line
+		exe            string           // The absolute path of the executable command
+		cgroup         string           // The cgroup path
 	}
 
 	// processStatus contains the dynamic process status.
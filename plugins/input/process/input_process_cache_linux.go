@@ -26,6 +26,8 @@ import (
 
 	"github.com/alibaba/ilogtail/helper"
 	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 const userHZ = 100
@@ -44,12 +46,12 @@ type processCacheLinux struct {
 func findAllProcessCache(maxLabelLength int) ([]processCache, error) {
 	fs, err := procfs.NewFS(helper.GetMountedFilePath(procfs.DefaultMountPoint))
 	if err != nil {
-		logger.Error(context.Background(), "OPEN_PROCFS_ALARM", "error", err)
+		logger.Error(context.Background(), util.AlarmOpenProcFS, "error", err)
 		return nil, err
 	}
 	procs, err := fs.AllProcs()
 	if err != nil {
-		logger.Error(context.Background(), "PROCESS_LIST_ALARM", "error", err)
+		logger.Error(context.Background(), util.AlarmProcessList, "error", err)
 		return nil, err
 	}
 	if len(procs) == 0 {
@@ -141,6 +143,36 @@ func (pc *processCacheLinux) GetCmdLine() string {
 	return pc.meta.cmdline
 }
 
+func (pc *processCacheLinux) GetCgroup() string {
+	if pc.meta.cgroup == "" {
+		cgroups, err := pc.proc.Cgroups()
+		if err != nil {
+			logger.Debugf(context.Background(), "error when getting cgroup in proc: %v", err)
+			return ""
+		}
+		pc.meta.cgroup = selectCgroupPath(cgroups)
+	}
+	return pc.meta.cgroup
+}
+
+// selectCgroupPath prefers the path of the memory controller in cgroup v1, and falls back to
+// the unified hierarchy of cgroup v2.
+func selectCgroupPath(cgroups []procfs.Cgroup) string {
+	var unified string
+	for _, cgroup := range cgroups {
+		if cgroup.HierarchyID == 0 {
+			unified = cgroup.Path
+			continue
+		}
+		for _, controller := range cgroup.Controllers {
+			if controller == "memory" {
+				return cgroup.Path
+			}
+		}
+	}
+	return unified
+}
+
 func (pc *processCacheLinux) Labels(customLabels helper.KeyValues) string {
 	if len(pc.meta.labels) == 0 {
 		if pc.stat == nil && !pc.fetchStat() {
@@ -150,17 +182,25 @@ func (pc *processCacheLinux) Labels(customLabels helper.KeyValues) string {
 		processLabels.Append("pid", strconv.Itoa(pc.GetPid()))
 		comm := pc.stat.Comm
 		if pc.meta.maxLabelLength < len(comm) {
-			logger.Warningf(context.Background(), "PROCESS_LABEL_TOO_LONG_ALARM", "the stat comm label is over %d chars: %s", pc.meta.maxLabelLength, comm)
+			logger.Warningf(context.Background(), util.AlarmProcessLabelTooLong, "the stat comm label is over %d chars: %s", pc.meta.maxLabelLength, comm)
 			processLabels.Append("comm", comm[:pc.meta.maxLabelLength])
 		} else {
 			processLabels.Append("comm", comm)
 		}
 		processLabels.Sort()
+		pc.meta.labelValues = processLabels
 		pc.meta.labels = processLabels.String()
 	}
 	return pc.meta.labels
 }
 
+func (pc *processCacheLinux) Tags(customLabels helper.KeyValues) models.Tags {
+	if pc.Labels(customLabels) == "" {
+		return models.NewTags()
+	}
+	return models.NewTagsWithMap(pc.meta.labelValues.ToMap())
+}
+
 func (pc *processCacheLinux) FetchFds() bool {
 	//  fetch file descriptors from /proc/pid/fd
 	fds, err := pc.proc.FileDescriptorsLen()
@@ -27,6 +27,8 @@ import (
 
 	"github.com/alibaba/ilogtail/helper"
 	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 type processCacheOther struct {
@@ -45,7 +47,7 @@ type processCacheOther struct {
 func findAllProcessCache(maxLabelLength int) ([]processCache, error) {
 	processes, err := process.Processes()
 	if err != nil {
-		logger.Errorf(context.Background(), "PROCESS_LIST_ALARM", "error: %v", err)
+		logger.Errorf(context.Background(), util.AlarmProcessList, "error: %v", err)
 		return nil, err
 	}
 	if len(processes) == 0 {
@@ -107,6 +109,11 @@ func (pc *processCacheOther) GetCmdLine() string {
 	return pc.meta.cmdline
 }
 
+// GetCgroup returns empty because cgroups only exist on Linux.
+func (pc *processCacheOther) GetCgroup() string {
+	return ""
+}
+
 func (pc *processCacheOther) getCommName() string {
 	if pc.isRunning && pc.commName == "" {
 		name, err := pc.proc.Name()
@@ -130,18 +137,26 @@ func (pc *processCacheOther) Labels(customLabels helper.KeyValues) string {
 			processLabels := customLabels.Clone()
 			processLabels.Append("pid", strconv.Itoa(pc.GetPid()))
 			if pc.meta.maxLabelLength < len(name) {
-				logger.Warningf(context.Background(), "PROCESS_LABEL_TOO_LONG_ALARM", "the stat cmdline label is over %d chars: %s", pc.meta.maxLabelLength, name)
+				logger.Warningf(context.Background(), util.AlarmProcessLabelTooLong, "the stat cmdline label is over %d chars: %s", pc.meta.maxLabelLength, name)
 				processLabels.Append("comm", name[:pc.meta.maxLabelLength])
 			} else {
 				processLabels.Append("comm", name)
 			}
 			processLabels.Sort()
+			pc.meta.labelValues = processLabels
 			pc.meta.labels = processLabels.String()
 		}
 	}
 	return pc.meta.labels
 }
 
+func (pc *processCacheOther) Tags(customLabels helper.KeyValues) models.Tags {
+	if pc.Labels(customLabels) == "" {
+		return models.NewTags()
+	}
+	return models.NewTagsWithMap(pc.meta.labelValues.ToMap())
+}
+
 func (pc *processCacheOther) FetchCore() bool {
 	if !pc.isRunning {
 		return false
@@ -27,6 +27,7 @@ import (
 	"testing"
 
 	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/models"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/plugins/test"
 	"github.com/alibaba/ilogtail/plugins/test/mock"
@@ -47,13 +48,17 @@ func (t *TestProcessCache) Same(cache processCache) bool {
 }
 
 func (t *TestProcessCache) GetExe() string {
-	return ""
+	return t.exe
 }
 
 func (t *TestProcessCache) GetCmdLine() string {
 	return ""
 }
 
+func (t *TestProcessCache) GetCgroup() string {
+	return t.cgroup
+}
+
 func (t *TestProcessCache) FetchCoreCount() int64 {
 	return t.fetchCoreCount
 }
@@ -62,6 +67,10 @@ func (t *TestProcessCache) Labels(values helper.KeyValues) string {
 	return ""
 }
 
+func (t *TestProcessCache) Tags(values helper.KeyValues) models.Tags {
+	return models.NewTagsWithMap(values.ToMap())
+}
+
 func (t *TestProcessCache) GetProcessStatus() *processStatus { // nolint:revive
 	return t.processStatus
 }
@@ -263,3 +272,59 @@ func TestInputProcess_filterTopAndThresholdMatchedProcesses(t *testing.T) {
 		})
 	}
 }
+
+func TestInputProcess_filterRegexMatchedProcessWithExclude(t *testing.T) {
+	creator := func(pid int, exe string) *TestProcessCache {
+		pc := &TestProcessCache{
+			pid:           pid,
+			processMeta:   newProcessCacheMeta(100),
+			processStatus: newProcessCacheStatus(),
+		}
+		pc.exe = exe
+		return pc
+	}
+	ip := &InputProcess{
+		MaxProcessCount:   defaultMaxProcessCount,
+		ProcessNamesRegex: []string{"^/usr/bin/"},
+		ExcludeNamesRegex: []string{"sshd$"},
+		lastProcesses:     make(map[int]processCache),
+	}
+	if _, err := ip.Init(mock.NewEmptyContext("project", "store", "config")); err != nil {
+		t.Errorf("cannot init the process plugin: %v", err)
+		return
+	}
+	caches := []processCache{creator(1, "/usr/bin/java"), creator(2, "/usr/bin/sshd"), creator(3, "/bin/bash")}
+	ip.filterRegexMatchedProcess(caches)
+	if len(ip.lastProcesses) != 1 || ip.lastProcesses[1] == nil {
+		t.Errorf("only the process 1 should be matched, but got %v", ip.lastProcesses)
+	}
+}
+
+func TestInputProcess_Read(t *testing.T) {
+	cxt := mock.NewEmptyContext("project", "store", "config")
+	p := pipeline.MetricInputs["metric_process_v2"]().(*InputProcess)
+	p.TopNCPU = 1
+	p.Thread = true
+	if _, err := p.Init(cxt); err != nil {
+		t.Errorf("cannot init the mock process plugin: %v", err)
+		return
+	}
+	pipelineCxt := pipeline.NewObservePipelineConext(10)
+	_ = p.Read(pipelineCxt)
+	_ = p.Read(pipelineCxt)
+	res := pipelineCxt.Collector().ToArray()
+	if len(res) != 1 || len(res[0].Events) != 1 {
+		t.Errorf("only the second reading should emit one process metric, but got %v", res)
+		return
+	}
+	metric := res[0].Events[0].(*models.Metric)
+	if metric.GetName() != multiValuesMetricName || !metric.GetValue().IsMultiValues() {
+		t.Errorf("the process metric should be a multi values metric named %s, but got %v", multiValuesMetricName, metric)
+		return
+	}
+	for _, name := range []string{"cpu_percent", "mem_rss", "threads"} {
+		if !metric.GetValue().GetMultiValues().Contains(name) {
+			t.Errorf("the process metric should contain the value %s", name)
+		}
+	}
+}
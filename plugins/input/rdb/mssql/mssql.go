@@ -97,8 +97,7 @@ func (m *Mssql) dsnConfig() string {
 
 // Start starts the ServiceInput's service, whatever that may be
 func (m *Mssql) Start(collector pipeline.Collector) error {
-	connStr := m.dsnConfig()
-	return m.Rdb.Start(collector, connStr, func() error {
+	return m.Rdb.Start(collector, m.dsnConfig, func() error {
 		return nil
 	}, msColumnResolverFuncMap)
 }
@@ -90,8 +90,7 @@ func (m *Pgsql) dsnConfig() string {
 
 // Start starts the ServiceInput's service, whatever that may be
 func (m *Pgsql) Start(collector pipeline.Collector) error {
-	connStr := m.dsnConfig()
-	return m.Rdb.Start(collector, connStr, func() error {
+	return m.Rdb.Start(collector, m.dsnConfig, func() error {
 		return nil
 	}, nil)
 }
@@ -24,8 +24,11 @@ import (
 	"time"
 
 	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/helper/credentials"
+	"github.com/alibaba/ilogtail/helper/scheduler"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 type RdbFunc func() error //nolint:revive
@@ -64,6 +67,11 @@ type Rdb struct {
 	CheckPointStart       string
 	CheckPointSavePerPage bool
 	IntervalMs            int
+	// Schedule queries by the cron expression instead of every IntervalMs
+	Schedule *scheduler.Config
+	// Credentials provides the user and password instead of the plaintext ones, which are retrieved before each
+	// collection and the connection is reopened when they are rotated.
+	Credentials *credentials.Config
 
 	// inner params
 	checkpointColumnIndex int
@@ -77,10 +85,13 @@ type Rdb struct {
 	columnValuePointers   []interface{}
 	Shutdown              chan struct{}
 	waitGroup             sync.WaitGroup
+	job                   *scheduler.Job
 	Context               pipeline.Context
 	collectLatency        pipeline.LatencyMetric
 	collectTotal          pipeline.CounterMetric
 	checkpointMetric      pipeline.StringMetric
+	dsn                   func() string
+	credentialTracker     *credentials.Tracker
 }
 
 func (m *Rdb) Init(context pipeline.Context, rdbFunc RdbFunc) (int, error) {
@@ -97,10 +108,27 @@ func (m *Rdb) Init(context pipeline.Context, rdbFunc RdbFunc) (int, error) {
 	if len(m.StateMent) == 0 {
 		return 0, fmt.Errorf("no sql statement")
 	}
+	if m.Credentials != nil {
+		provider, err := m.Credentials.NewProvider()
+		if err != nil {
+			return 0, err
+		}
+		m.credentialTracker = credentials.NewTracker(provider)
+	}
 	err := rdbFunc()
 	if err != nil {
 		logger.Warning(m.Context.GetRuntimeContext(), initAlarmName, "init rdbFunc error", err)
 	}
+	job, err := m.Schedule.NewJob(time.Duration(m.IntervalMs) * time.Millisecond)
+	if err != nil {
+		return 0, err
+	}
+	job.RunAtStart = true
+	timeoutAlarmName := fmt.Sprintf("%s_TIMEOUT_ALARM", strings.ToUpper(m.Driver))
+	job.OnSkip = func() {
+		logger.Warning(m.Context.GetRuntimeContext(), timeoutAlarmName, "sql collect cost very long time, skip the schedule", "intervalMs", m.IntervalMs)
+	}
+	m.job = job
 
 	m.collectLatency = helper.NewLatencyMetric(fmt.Sprintf("%s_collect_avg_cost", m.Driver))
 	m.collectTotal = helper.NewCounterMetric(fmt.Sprintf("%s_collect_total", m.Driver))
@@ -148,15 +176,57 @@ func (m *Rdb) CheckPointToString() string {
 	return m.checkpointValue
 }
 
-// Start starts the ServiceInput's service, whatever that may be
-func (m *Rdb) Start(collector pipeline.Collector, connStr string, rdbFunc RdbFunc, columnResolverFuncMap map[string]ColumnResolverFunc) error {
+// retrieveCredentials fills the user and password from the credential provider if set, and returns true if
+// they are changed.
+func (m *Rdb) retrieveCredentials() (bool, error) {
+	if m.credentialTracker == nil {
+		return false, nil
+	}
+	cred, changed, err := m.credentialTracker.Retrieve()
+	if err != nil {
+		return false, err
+	}
+	m.User, m.Password = cred.Username, cred.Password
+	return changed, nil
+}
+
+// reconnectIfRotated reopens the connection with the rotated user and password before the collection.
+func (m *Rdb) reconnectIfRotated() {
+	changed, err := m.retrieveCredentials()
+	if err != nil {
+		logger.Warning(m.Context.GetRuntimeContext(), util.AlarmCredential, "retrieve the credentials error, use the last ones", err)
+		return
+	}
+	if !changed {
+		return
+	}
+	if m.dbStatment != nil {
+		_ = m.dbStatment.Close()
+		m.dbStatment = nil
+	}
+	if m.dbInstance != nil {
+		_ = m.dbInstance.Close()
+		m.dbInstance = nil
+	}
+	if err = m.initRdbsql(m.dsn(), func() error { return nil }); err != nil {
+		logger.Warning(m.Context.GetRuntimeContext(), fmt.Sprintf("%s_INIT_ALARM", strings.ToUpper(m.Driver)),
+			"reconnect with the rotated credentials error", err)
+	}
+}
+
+// Start starts the ServiceInput's service, whatever that may be. The dsn is built again when the credentials
+// are rotated.
+func (m *Rdb) Start(collector pipeline.Collector, dsn func() string, rdbFunc RdbFunc, columnResolverFuncMap map[string]ColumnResolverFunc) error {
 	checkpointAlarmName := fmt.Sprintf("%s_CHECKPOINT_ALARM", strings.ToUpper(m.Driver))
-	timeoutAlarmName := fmt.Sprintf("%s_TIMEOUT_ALARM", strings.ToUpper(m.Driver))
 	queryAlarmName := fmt.Sprintf("%s_QUERY_ALARM", strings.ToUpper(m.Driver))
 	m.waitGroup.Add(1)
 	defer m.waitGroup.Done()
+	m.dsn = dsn
+	if _, err := m.retrieveCredentials(); err != nil {
+		return err
+	}
 	// initialize additional query intervals
-	err := m.initRdbsql(connStr, rdbFunc)
+	err := m.initRdbsql(dsn(), rdbFunc)
 	if err != nil {
 		return err
 	}
@@ -188,33 +258,20 @@ func (m *Rdb) Start(collector pipeline.Collector, connStr string, rdbFunc RdbFun
 		return fmt.Errorf("no query statement")
 	}
 
-	// first collect after 10 ms
-	timer := time.NewTimer(10 * time.Millisecond)
-
-	for {
-		select {
-		case <-timer.C:
-			startTime := time.Now()
-			m.collectLatency.Begin()
-			err = m.Collect(collector, columnResolverFuncMap)
-			if err != nil {
-				logger.Error(m.Context.GetRuntimeContext(), queryAlarmName, "collect err", err)
-			}
-			m.collectLatency.End()
-			endTime := time.Now()
-			if endTime.Sub(startTime) > time.Duration(m.IntervalMs)*time.Millisecond/2 {
-				logger.Warning(m.Context.GetRuntimeContext(), timeoutAlarmName, "sql collect cost very long time, start", startTime, "end", endTime, "intervalMs", m.IntervalMs)
-				timer.Reset(time.Duration(m.IntervalMs) * time.Millisecond)
-			} else {
-				timer.Reset(time.Duration(m.IntervalMs)*time.Millisecond - endTime.Sub(startTime))
-			}
-			logger.Debug(m.Context.GetRuntimeContext(), "sql collect done, start", startTime, "end", endTime, "intervalMs", m.IntervalMs)
-		case <-m.Shutdown:
-			m.SaveCheckPoint(collector)
-			logger.Info(m.Context.GetRuntimeContext(), "recv shutdown signal", "start to exit")
-			return nil
+	// collect at the start and then by the schedule, the running collection is waited at the shutdown
+	m.job.Run(func() {
+		m.reconnectIfRotated()
+		startTime := time.Now()
+		m.collectLatency.Begin()
+		if err := m.Collect(collector, columnResolverFuncMap); err != nil {
+			logger.Error(m.Context.GetRuntimeContext(), queryAlarmName, "collect err", err)
 		}
-	}
+		m.collectLatency.End()
+		logger.Debug(m.Context.GetRuntimeContext(), "sql collect done, start", startTime, "end", time.Now(), "intervalMs", m.IntervalMs)
+	}, m.Shutdown)
+	m.SaveCheckPoint(collector)
+	logger.Info(m.Context.GetRuntimeContext(), "recv shutdown signal", "start to exit")
+	return nil
 }
 
 func (m *Rdb) Collect(collector pipeline.Collector, columnResolverFuncMap map[string]ColumnResolverFunc) error {
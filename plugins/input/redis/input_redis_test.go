@@ -0,0 +1,241 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/helper/credentials"
+	"github.com/alibaba/ilogtail/pkg/tlscommon"
+	"github.com/alibaba/ilogtail/plugins/test"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+const info = "# Server\r\nredis_version:7.0.11\r\nuptime_in_seconds:100\r\nos:Linux\r\n\r\n" +
+	"# Clients\r\nconnected_clients:3\r\n\r\n# Errorstats\r\nerrorstat_ERR:count=2\r\n\r\n" +
+	"# Keyspace\r\ndb0:keys=10,expires=2,avg_ttl=100\r\ndb1:keys=5,expires=0,avg_ttl=0\r\n"
+
+// fakeRedis serves AUTH, INFO and SLOWLOG GET with the slow log entries in the reply.
+type fakeRedis struct {
+	listener net.Listener
+	password string
+	mu       sync.Mutex
+	slowLog  string
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	return serveFakeRedis(t, listener, password)
+}
+
+func newFakeRedisTLS(t *testing.T, password string) *fakeRedis {
+	// borrow the self-signed certificate of httptest
+	ts := httptest.NewUnstartedServer(nil)
+	ts.StartTLS()
+	cert := ts.TLS.Certificates[0]
+	ts.Close()
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12})
+	require.NoError(t, err)
+	return serveFakeRedis(t, listener, password)
+}
+
+func serveFakeRedis(t *testing.T, listener net.Listener, password string) *fakeRedis {
+	s := &fakeRedis{listener: listener, password: password, slowLog: "*0\r\n"}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			c, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(c)
+		}
+	}()
+	return s
+}
+
+func (s *fakeRedis) setSlowLog(reply string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.slowLog = reply
+}
+
+func (s *fakeRedis) serve(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	authed := s.password == ""
+	for {
+		reply, err := helper.ReadRedisReply(r)
+		if err != nil {
+			return
+		}
+		var args []string
+		for _, arg := range reply.([]interface{}) {
+			args = append(args, arg.(string))
+		}
+		var resp string
+		switch {
+		case args[0] == "AUTH":
+			if args[len(args)-1] == s.password {
+				authed = true
+				resp = "+OK\r\n"
+			} else {
+				resp = "-WRONGPASS invalid username-password pair\r\n"
+			}
+		case !authed:
+			resp = "-NOAUTH Authentication required.\r\n"
+		case args[0] == "INFO":
+			resp = fmt.Sprintf("$%d\r\n%s\r\n", len(info), info)
+		case args[0] == "SLOWLOG":
+			s.mu.Lock()
+			resp = s.slowLog
+			s.mu.Unlock()
+		default:
+			resp = "-ERR unknown command\r\n"
+		}
+		_, _ = c.Write([]byte(resp))
+	}
+}
+
+func newSlowLogEntry(id, timestamp, duration int, args ...string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*6\r\n:%d\r\n:%d\r\n:%d\r\n*%d\r\n", id, timestamp, duration, len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&sb, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	sb.WriteString("$15\r\n127.0.0.1:50000\r\n$0\r\n\r\n")
+	return sb.String()
+}
+
+func collectFields(collector *test.MockMetricCollector) []map[string]string {
+	var logs []map[string]string
+	for _, log := range collector.Logs {
+		fields := make(map[string]string)
+		for _, c := range log.Contents {
+			fields[c.Key] = c.Value
+		}
+		logs = append(logs, fields)
+	}
+	return logs
+}
+
+func TestCollectInfoAndSlowLog(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+	server.setSlowLog("*2\r\n" + newSlowLogEntry(2, 1688090511, 20000, "KEYS", "*") +
+		newSlowLogEntry(1, 1688090510, 15000, "HGETALL", "big"))
+	r := &InputRedis{ServerUrls: []string{server.listener.Addr().String()}, Username: "monitor", Password: "secret", SlowLog: true}
+	_, err := r.Init(mock.NewEmptyContext("project", "store", "config"))
+	require.NoError(t, err)
+
+	collector := &test.MockMetricCollector{}
+	require.NoError(t, r.Collect(collector))
+	logs := collectFields(collector)
+	require.Len(t, logs, 3)
+	assert.Equal(t, "7.0.11", logs[0]["redis_version"])
+	assert.Equal(t, "3", logs[0]["clients"])
+	assert.Equal(t, "15", logs[0]["total_db_keys"])
+	assert.Equal(t, "2", logs[0]["total_db_count"])
+	assert.Equal(t, "127.0.0.1", logs[0]["server"])
+	assert.Equal(t, "slowlog", logs[1]["type"])
+	assert.Equal(t, "1", logs[1]["id"])
+	assert.Equal(t, "HGETALL big", logs[1]["command"])
+	assert.Equal(t, "15000", logs[1]["duration_us"])
+	assert.Equal(t, "127.0.0.1:50000", logs[1]["client_addr"])
+	assert.Equal(t, uint32(1688090510), collector.Logs[1].Time)
+	assert.Equal(t, "KEYS *", logs[2]["command"])
+
+	// only the new entries are collected
+	server.setSlowLog("*3\r\n" + newSlowLogEntry(3, 1688090512, 30000, "SMEMBERS", "s") +
+		newSlowLogEntry(2, 1688090511, 20000, "KEYS", "*") + newSlowLogEntry(1, 1688090510, 15000, "HGETALL", "big"))
+	collector = &test.MockMetricCollector{}
+	require.NoError(t, r.Collect(collector))
+	logs = collectFields(collector)
+	require.Len(t, logs, 2)
+	assert.Equal(t, "SMEMBERS s", logs[1]["command"])
+
+	// the entries after the slow log is reset are collected again
+	server.setSlowLog("*0\r\n")
+	collector = &test.MockMetricCollector{}
+	require.NoError(t, r.Collect(collector))
+	require.Len(t, collector.Logs, 1)
+	server.setSlowLog("*1\r\n" + newSlowLogEntry(0, 1688090513, 40000, "FLUSHALL"))
+	collector = &test.MockMetricCollector{}
+	require.NoError(t, r.Collect(collector))
+	logs = collectFields(collector)
+	require.Len(t, logs, 2)
+	assert.Equal(t, "FLUSHALL", logs[1]["command"])
+}
+
+func TestAuthFailure(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+	r := &InputRedis{Password: "wrong"}
+	_, err := r.Init(mock.NewEmptyContext("project", "store", "config"))
+	require.NoError(t, err)
+	addr := server.listener.Addr().String()
+	collector := &test.MockMetricCollector{}
+	err = r.gatherServer(mustParseURL(t, "tcp://"+addr), collector)
+	assert.ErrorContains(t, err, "WRONGPASS")
+
+	// the password in the url takes precedence
+	require.NoError(t, r.gatherServer(mustParseURL(t, "tcp://:secret@"+addr), collector))
+	assert.Len(t, collector.Logs, 1)
+}
+
+func TestAuthByCredentials(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+	r := &InputRedis{ServerUrls: []string{server.listener.Addr().String()}, Password: "wrong",
+		Credentials: &credentials.Config{Provider: credentials.ProviderStatic, Password: "secret"}}
+	_, err := r.Init(mock.NewEmptyContext("project", "store", "config"))
+	require.NoError(t, err)
+	collector := &test.MockMetricCollector{}
+	require.NoError(t, r.Collect(collector))
+	assert.Len(t, collector.Logs, 1)
+}
+
+func TestCollectTLS(t *testing.T) {
+	server := newFakeRedisTLS(t, "secret")
+	addr := server.listener.Addr().String()
+	r := &InputRedis{ServerUrls: []string{"tls://:secret@" + addr}}
+	_, err := r.Init(mock.NewEmptyContext("project", "store", "config"))
+	require.NoError(t, err)
+	collector := &test.MockMetricCollector{}
+	// the certificate isn't trusted by the default tls config
+	assert.Error(t, r.gatherServer(mustParseURL(t, "tls://:secret@"+addr), collector))
+
+	r = &InputRedis{ServerUrls: []string{addr}, Password: "secret", TLS: &tlscommon.TLSConfig{Enabled: true, InsecureSkipVerify: true}}
+	_, err = r.Init(mock.NewEmptyContext("project", "store", "config"))
+	require.NoError(t, err)
+	require.NoError(t, r.Collect(collector))
+	require.Len(t, collector.Logs, 1)
+}
+
+func mustParseURL(t *testing.T, s string) *url.URL {
+	u, err := url.Parse(s)
+	require.NoError(t, err)
+	return u
+}
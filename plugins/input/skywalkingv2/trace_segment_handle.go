@@ -24,6 +24,7 @@ import (
 
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/util"
 	"github.com/alibaba/ilogtail/plugins/input/skywalkingv2/skywalking/apm/network/language/agent"
 	"github.com/alibaba/ilogtail/plugins/input/skywalkingv3"
 )
@@ -80,7 +81,7 @@ func (t *TraceSegmentHandle) collectSegment(upstream *agent.UpstreamSegment) err
 		if otTrace := t.parseSpan(span, applicationInstance, traceID, convertUniIDToString(segment.TraceSegmentId)); otTrace != nil {
 			log, err := otTrace.ToLog()
 			if err != nil {
-				logger.Error(t.context.GetRuntimeContext(), "SKYWALKING_TO_OT_TRACE_ERR", "err", err)
+				logger.Error(t.context.GetRuntimeContext(), util.AlarmSkyWalkingToOTTraceErr, "err", err)
 				return err
 			}
 			t.collector.AddRawLog(log)
@@ -30,6 +30,7 @@ import (
 
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/util"
 	"github.com/alibaba/ilogtail/plugins/input/skywalkingv2/skywalking/apm/network/common"
 	"github.com/alibaba/ilogtail/plugins/input/skywalkingv2/skywalking/apm/network/language/agent"
 	v2 "github.com/alibaba/ilogtail/plugins/input/skywalkingv2/skywalking/apm/network/language/agent/v2"
@@ -49,7 +50,7 @@ func panicRecover() {
 	if err := recover(); err != nil {
 		trace := make([]byte, 2048)
 		runtime.Stack(trace, true)
-		logger.Error(context.Background(), "PLUGIN_RUNTIME_ALARM", "skywalking v2 runtime panic error", err, "stack", string(trace))
+		logger.Error(context.Background(), util.AlarmPluginRuntime, "skywalking v2 runtime panic error", err, "stack", string(trace))
 	}
 }
 
@@ -123,7 +124,7 @@ func (t *TraceSegmentReportHandle) collectSegment(server v2.TraceSegmentReportSe
 		} else {
 			log, err := otTrace.ToLog()
 			if err != nil {
-				logger.Error(t.context.GetRuntimeContext(), "SKYWALKING_TO_OT_TRACE_ERR", "err", err)
+				logger.Error(t.context.GetRuntimeContext(), util.AlarmSkyWalkingToOTTraceErr, "err", err)
 				return err
 			}
 			t.collector.AddRawLog(log)
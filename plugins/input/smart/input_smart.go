@@ -0,0 +1,267 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package smart
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const pluginName = "metric_disk_smart"
+
+// The raw values of the ATA attributes counting the bad sectors and the uncorrectable errors, which predict the
+// failures of the disks when they grow above zero.
+var ataCriticalAttributes = map[int]bool{
+	5:   true, // Reallocated_Sector_Ct
+	187: true, // Reported_Uncorrect
+	197: true, // Current_Pending_Sector
+	198: true, // Offline_Uncorrectable
+}
+
+// InputSmart collects the SMART attributes of the ATA disks and the health information logs of the NVMe disks by
+// smartctl of smartmontools 7 or later, which supports the JSON output. The warning logs are emitted when the
+// checks of the disks start failing or the failing values change, such as the reallocated sectors growing.
+type InputSmart struct {
+	// The path of smartctl, which is looked up in PATH by default.
+	SmartctlPath string
+	// The devices, e.g. /dev/sda, or with the device type of smartctl, e.g. /dev/sda:sat. All the devices
+	// found by smartctl --scan by default.
+	Devices        []string
+	ExcludeDevices []string
+	// Skips the ATA disks in the standby mode instead of spinning them up.
+	SkipStandby bool
+	TimeoutMs   int
+	// The warning thresholds of the temperature in celsius and the percentage used of the NVMe disks, zero
+	// disables the check.
+	TemperatureThreshold    float64
+	PercentageUsedThreshold float64
+	Labels                  map[string]string
+
+	context  pipeline.Context
+	smartctl *smartctl
+	exclude  map[string]bool
+	// the failing checks by the device and the check, with the value warned last time.
+	warned map[string]string
+}
+
+func (in *InputSmart) Init(context pipeline.Context) (int, error) {
+	in.context = context
+	in.smartctl = &smartctl{
+		path:        in.SmartctlPath,
+		timeout:     time.Duration(in.TimeoutMs) * time.Millisecond,
+		skipStandby: in.SkipStandby,
+		run:         runCommand,
+	}
+	in.exclude = make(map[string]bool, len(in.ExcludeDevices))
+	for _, device := range in.ExcludeDevices {
+		in.exclude[device] = true
+	}
+	in.warned = make(map[string]string)
+	return 0, nil
+}
+
+func (in *InputSmart) Description() string {
+	return "Collect the SMART attributes and the NVMe health logs of the disks by smartctl"
+}
+
+func (in *InputSmart) Collect(collector pipeline.Collector) error {
+	devices, err := in.devices()
+	if err != nil {
+		return err
+	}
+	failing := make(map[string]bool)
+	for _, device := range devices {
+		if in.exclude[device.Name] {
+			continue
+		}
+		output, err := in.smartctl.read(device)
+		if err != nil {
+			logger.Warning(in.context.GetRuntimeContext(), util.AlarmSmartCollect, "device", device.Name, "error", err)
+			continue
+		}
+		if output == nil {
+			logger.Debug(in.context.GetRuntimeContext(), "skip the device in standby", device.Name)
+			continue
+		}
+		now := time.Now()
+		in.addMetrics(collector, device.Name, output, now)
+		for _, w := range in.check(output) {
+			key := device.Name + "/" + w.check
+			failing[key] = true
+			if last, ok := in.warned[key]; ok && last == w.value {
+				continue
+			}
+			in.warned[key] = w.value
+			collector.AddData(nil, map[string]string{
+				"type":      "smart_warning",
+				"device":    device.Name,
+				"model":     output.ModelName,
+				"serial":    output.SerialNumber,
+				"check":     w.check,
+				"value":     w.value,
+				"threshold": w.threshold,
+				"message":   w.message,
+			}, now)
+		}
+	}
+	// the checks recovered warn again when they fail next time
+	for key := range in.warned {
+		if !failing[key] {
+			delete(in.warned, key)
+		}
+	}
+	return nil
+}
+
+// devices returns the configured devices, or the devices found by smartctl.
+func (in *InputSmart) devices() ([]scanDevice, error) {
+	if len(in.Devices) == 0 {
+		return in.smartctl.scan()
+	}
+	devices := make([]scanDevice, 0, len(in.Devices))
+	for _, device := range in.Devices {
+		name, deviceType, _ := strings.Cut(device, ":")
+		devices = append(devices, scanDevice{Name: name, Type: deviceType})
+	}
+	return devices, nil
+}
+
+func (in *InputSmart) addMetrics(collector pipeline.Collector, device string, output *deviceOutput, now time.Time) {
+	var labels helper.KeyValues
+	labels.Append("device", device)
+	labels.Append("model", output.ModelName)
+	labels.Append("serial", output.SerialNumber)
+	labels.Append("protocol", output.Device.Protocol)
+	labels.AppendMap(in.Labels)
+	labels.Sort()
+	deviceLabels := labels.String()
+
+	if output.SmartStatus != nil {
+		healthy := 0.0
+		if output.SmartStatus.Passed {
+			healthy = 1
+		}
+		helper.AddMetric(collector, "smart_device_healthy", now, deviceLabels, healthy)
+	}
+	if output.Temperature != nil {
+		helper.AddMetric(collector, "smart_device_temperature_celsius", now, deviceLabels, output.Temperature.Current)
+	}
+	if output.PowerOnTime != nil {
+		helper.AddMetric(collector, "smart_device_power_on_hours", now, deviceLabels, output.PowerOnTime.Hours)
+	}
+	if output.PowerCycleCount != nil {
+		helper.AddMetric(collector, "smart_device_power_cycle_count", now, deviceLabels, *output.PowerCycleCount)
+	}
+	if output.AtaSmartAttributes != nil {
+		for _, attribute := range output.AtaSmartAttributes.Table {
+			attributeLabels := labels.Clone()
+			attributeLabels.Append("attribute_id", strconv.Itoa(attribute.ID))
+			attributeLabels.Append("attribute_name", attribute.Name)
+			attributeLabels.Sort()
+			labelStr := attributeLabels.String()
+			helper.AddMetric(collector, "smart_attribute_value", now, labelStr, float64(attribute.Value))
+			helper.AddMetric(collector, "smart_attribute_worst", now, labelStr, float64(attribute.Worst))
+			helper.AddMetric(collector, "smart_attribute_threshold", now, labelStr, float64(attribute.Thresh))
+			helper.AddMetric(collector, "smart_attribute_raw_value", now, labelStr, attribute.Raw.Value)
+		}
+	}
+	keys := make([]string, 0, len(output.NvmeSmartHealthInformationLog))
+	for key := range output.NvmeSmartHealthInformationLog {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		// the arrays such as temperature_sensors are skipped
+		if value, ok := output.NvmeSmartHealthInformationLog[key].(float64); ok {
+			helper.AddMetric(collector, "smart_nvme_"+key, now, deviceLabels, value)
+		}
+	}
+}
+
+type warning struct {
+	check     string
+	value     string
+	threshold string
+	message   string
+}
+
+// check returns the failing checks of the device.
+func (in *InputSmart) check(output *deviceOutput) []warning {
+	var warnings []warning
+	if output.SmartStatus != nil && !output.SmartStatus.Passed {
+		warnings = append(warnings, warning{check: "smart_status", value: "failed",
+			message: "the SMART overall-health self-assessment test failed"})
+	}
+	if output.Temperature != nil && in.TemperatureThreshold > 0 && output.Temperature.Current >= in.TemperatureThreshold {
+		warnings = append(warnings, warning{check: "temperature", value: formatFloat(output.Temperature.Current),
+			threshold: formatFloat(in.TemperatureThreshold), message: "the temperature is too high"})
+	}
+	if output.AtaSmartAttributes != nil {
+		for _, a := range output.AtaSmartAttributes.Table {
+			if a.WhenFailed == "now" || (a.Thresh > 0 && a.Value <= a.Thresh) {
+				warnings = append(warnings, warning{check: "attribute_" + a.Name, value: strconv.Itoa(a.Value),
+					threshold: strconv.Itoa(a.Thresh), message: fmt.Sprintf("the normalized value of attribute %d %s reaches the threshold", a.ID, a.Name)})
+			} else if ataCriticalAttributes[a.ID] && a.Raw.Value > 0 {
+				warnings = append(warnings, warning{check: "attribute_" + a.Name, value: formatFloat(a.Raw.Value),
+					threshold: "0", message: fmt.Sprintf("the raw value of attribute %d %s is above zero", a.ID, a.Name)})
+			}
+		}
+	}
+	if nvme := output.NvmeSmartHealthInformationLog; nvme != nil {
+		if v, _ := nvme["critical_warning"].(float64); v != 0 {
+			warnings = append(warnings, warning{check: "nvme_critical_warning", value: formatFloat(v), threshold: "0",
+				message: "the NVMe critical warning is set"})
+		}
+		spare, ok1 := nvme["available_spare"].(float64)
+		spareThreshold, ok2 := nvme["available_spare_threshold"].(float64)
+		if ok1 && ok2 && spare < spareThreshold {
+			warnings = append(warnings, warning{check: "nvme_available_spare", value: formatFloat(spare),
+				threshold: formatFloat(spareThreshold), message: "the available spare is below the threshold"})
+		}
+		if used, ok := nvme["percentage_used"].(float64); ok && in.PercentageUsedThreshold > 0 && used >= in.PercentageUsedThreshold {
+			warnings = append(warnings, warning{check: "nvme_percentage_used", value: formatFloat(used),
+				threshold: formatFloat(in.PercentageUsedThreshold), message: "the estimated life used reaches the threshold"})
+		}
+		if v, _ := nvme["media_errors"].(float64); v > 0 {
+			warnings = append(warnings, warning{check: "nvme_media_errors", value: formatFloat(v), threshold: "0",
+				message: "the NVMe media errors are above zero"})
+		}
+	}
+	return warnings
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func init() {
+	pipeline.MetricInputs[pluginName] = func() pipeline.MetricInput {
+		return &InputSmart{
+			SmartctlPath:            "smartctl",
+			SkipStandby:             true,
+			TimeoutMs:               30000,
+			TemperatureThreshold:    60,
+			PercentageUsedThreshold: 90,
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package smart
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/plugins/test"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+const scanJSON = `{"devices": [
+	{"name": "/dev/sda", "info_name": "/dev/sda [SAT]", "type": "sat", "protocol": "ATA"},
+	{"name": "/dev/nvme0", "info_name": "/dev/nvme0", "type": "nvme", "protocol": "NVMe"},
+	{"name": "/dev/sdb", "info_name": "/dev/sdb [SAT]", "type": "sat", "protocol": "ATA"}
+]}`
+
+const ataJSON = `{
+	"smartctl": {"exit_status": 4},
+	"device": {"name": "/dev/sda", "type": "sat", "protocol": "ATA"},
+	"model_name": "WDC WD40EFRX", "serial_number": "WD-1",
+	"smart_status": {"passed": true},
+	"temperature": {"current": 38},
+	"power_on_time": {"hours": 20000},
+	"power_cycle_count": 120,
+	"ata_smart_attributes": {"table": [
+		{"id": 5, "name": "Reallocated_Sector_Ct", "value": 200, "worst": 200, "thresh": 140, "when_failed": "", "raw": {"value": %d}},
+		{"id": 9, "name": "Power_On_Hours", "value": 73, "worst": 73, "thresh": 0, "when_failed": "", "raw": {"value": 20000}},
+		{"id": 3, "name": "Spin_Up_Time", "value": 20, "worst": 20, "thresh": 21, "when_failed": "now", "raw": {"value": 9000}}
+	]}
+}`
+
+const nvmeJSON = `{
+	"smartctl": {"exit_status": 0},
+	"device": {"name": "/dev/nvme0", "type": "nvme", "protocol": "NVMe"},
+	"model_name": "Samsung SSD 980", "serial_number": "S1",
+	"smart_status": {"passed": false},
+	"temperature": {"current": 65},
+	"nvme_smart_health_information_log": {"critical_warning": 4, "temperature": 65, "available_spare": 5,
+		"available_spare_threshold": 10, "percentage_used": 95, "media_errors": 0, "temperature_sensors": [65, 70]}
+}`
+
+const standbyJSON = `{
+	"smartctl": {"exit_status": 2, "messages": [{"string": "Device is in STANDBY mode, exit(2)", "severity": "information"}]},
+	"device": {"name": "/dev/sdb", "type": "sat", "protocol": "ATA"}
+}`
+
+type fakeSmartctl struct {
+	reallocated int
+	calls       [][]string
+}
+
+func (f *fakeSmartctl) run(ctx context.Context, path string, args ...string) ([]byte, error) {
+	f.calls = append(f.calls, args)
+	exitErr := errors.New("exit status 4")
+	switch args[len(args)-1] {
+	case "--scan":
+		return []byte(scanJSON), nil
+	case "/dev/sda":
+		return []byte(strings.Replace(ataJSON, "%d", string(rune('0'+f.reallocated)), 1)), exitErr
+	case "/dev/nvme0":
+		return []byte(nvmeJSON), nil
+	case "/dev/sdb":
+		return []byte(standbyJSON), errors.New("exit status 2")
+	}
+	return nil, errors.New("no such device")
+}
+
+func collect(t *testing.T, in *InputSmart) (map[string]string, []map[string]string) {
+	collector := &test.MockMetricCollector{}
+	require.NoError(t, in.Collect(collector))
+	metrics := make(map[string]string)
+	var warnings []map[string]string
+	for _, log := range collector.Logs {
+		fields := make(map[string]string)
+		for _, c := range log.Contents {
+			fields[c.Key] = c.Value
+		}
+		if fields["type"] == "smart_warning" {
+			warnings = append(warnings, fields)
+		} else {
+			metrics[fields["__name__"]+"{"+fields["__labels__"]+"}"] = fields["__value__"]
+		}
+	}
+	return metrics, warnings
+}
+
+func checks(warnings []map[string]string) []string {
+	var result []string
+	for _, w := range warnings {
+		result = append(result, w["device"]+"/"+w["check"]+"="+w["value"])
+	}
+	return result
+}
+
+func TestCollect(t *testing.T) {
+	in := pipeline.MetricInputs[pluginName]().(*InputSmart)
+	_, err := in.Init(mock.NewEmptyContext("project", "store", "config"))
+	require.NoError(t, err)
+	fake := &fakeSmartctl{}
+	in.smartctl.run = fake.run
+
+	metrics, warnings := collect(t, in)
+	assert.Equal(t, []string{"--json", "--scan"}, fake.calls[0])
+	assert.Equal(t, []string{"--json", "--all", "--device", "sat", "--nocheck", "standby", "/dev/sda"}, fake.calls[1])
+	sda := "device#$#/dev/sda|model#$#WDC WD40EFRX|protocol#$#ATA|serial#$#WD-1"
+	assert.Equal(t, "1", metrics["smart_device_healthy{"+sda+"}"])
+	assert.Equal(t, "38", metrics["smart_device_temperature_celsius{"+sda+"}"])
+	assert.Equal(t, "20000", metrics["smart_device_power_on_hours{"+sda+"}"])
+	assert.Equal(t, "120", metrics["smart_device_power_cycle_count{"+sda+"}"])
+	assert.Equal(t, "140", metrics["smart_attribute_threshold{attribute_id#$#5|attribute_name#$#Reallocated_Sector_Ct|"+sda+"}"])
+	nvme := "device#$#/dev/nvme0|model#$#Samsung SSD 980|protocol#$#NVMe|serial#$#S1"
+	assert.Equal(t, "0", metrics["smart_device_healthy{"+nvme+"}"])
+	assert.Equal(t, "95", metrics["smart_nvme_percentage_used{"+nvme+"}"])
+	assert.NotContains(t, metrics, "smart_nvme_temperature_sensors{"+nvme+"}")
+	for name := range metrics {
+		assert.NotContains(t, name, "/dev/sdb", "the device in standby should be skipped")
+	}
+	assert.ElementsMatch(t, []string{
+		"/dev/sda/attribute_Spin_Up_Time=20",
+		"/dev/nvme0/smart_status=failed",
+		"/dev/nvme0/temperature=65",
+		"/dev/nvme0/nvme_critical_warning=4",
+		"/dev/nvme0/nvme_available_spare=5",
+		"/dev/nvme0/nvme_percentage_used=95",
+	}, checks(warnings))
+
+	// the warnings are only emitted again when the values change
+	fake.reallocated = 8
+	_, warnings = collect(t, in)
+	assert.Equal(t, []string{"/dev/sda/attribute_Reallocated_Sector_Ct=8"}, checks(warnings))
+	_, warnings = collect(t, in)
+	assert.Empty(t, warnings)
+	fake.reallocated = 0
+	_, warnings = collect(t, in)
+	assert.Empty(t, warnings)
+	fake.reallocated = 1
+	_, warnings = collect(t, in)
+	assert.Equal(t, []string{"/dev/sda/attribute_Reallocated_Sector_Ct=1"}, checks(warnings))
+}
+
+func TestConfiguredDevices(t *testing.T) {
+	in := pipeline.MetricInputs[pluginName]().(*InputSmart)
+	in.Devices = []string{"/dev/nvme0", "/dev/sda:sat", "/dev/sdc"}
+	in.ExcludeDevices = []string{"/dev/nvme0"}
+	in.SkipStandby = false
+	_, err := in.Init(mock.NewEmptyContext("project", "store", "config"))
+	require.NoError(t, err)
+	fake := &fakeSmartctl{}
+	in.smartctl.run = fake.run
+	metrics, _ := collect(t, in)
+	require.Len(t, fake.calls, 2)
+	assert.Equal(t, []string{"--json", "--all", "--device", "sat", "/dev/sda"}, fake.calls[0])
+	assert.Equal(t, []string{"--json", "--all", "/dev/sdc"}, fake.calls[1])
+	assert.NotEmpty(t, metrics)
+}
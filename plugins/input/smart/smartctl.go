@@ -0,0 +1,160 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package smart
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// The exit status bits of smartctl, the output is incomplete if any of the lower two bits is set.
+const (
+	exitCommandLineError = 1 << 0
+	exitDeviceOpenError  = 1 << 1
+)
+
+type scanOutput struct {
+	Devices []scanDevice `json:"devices"`
+}
+
+type scanDevice struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// deviceOutput is the output of smartctl --json --all, see the JSON schema of smartmontools 7.
+type deviceOutput struct {
+	Smartctl struct {
+		ExitStatus int `json:"exit_status"`
+		Messages   []struct {
+			String   string `json:"string"`
+			Severity string `json:"severity"`
+		} `json:"messages"`
+	} `json:"smartctl"`
+	Device struct {
+		Name     string `json:"name"`
+		Type     string `json:"type"`
+		Protocol string `json:"protocol"`
+	} `json:"device"`
+	ModelName    string `json:"model_name"`
+	SerialNumber string `json:"serial_number"`
+	SmartStatus  *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	Temperature *struct {
+		Current float64 `json:"current"`
+	} `json:"temperature"`
+	PowerOnTime *struct {
+		Hours float64 `json:"hours"`
+	} `json:"power_on_time"`
+	PowerCycleCount    *float64 `json:"power_cycle_count"`
+	AtaSmartAttributes *struct {
+		Table []ataAttribute `json:"table"`
+	} `json:"ata_smart_attributes"`
+	NvmeSmartHealthInformationLog map[string]interface{} `json:"nvme_smart_health_information_log"`
+}
+
+type ataAttribute struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Value      int    `json:"value"`
+	Worst      int    `json:"worst"`
+	Thresh     int    `json:"thresh"`
+	WhenFailed string `json:"when_failed"`
+	Raw        struct {
+		Value float64 `json:"value"`
+	} `json:"raw"`
+}
+
+// commandRunner runs smartctl with the args and returns the stdout, the exit error is returned with the output
+// because smartctl reports the disk problems by the exit status.
+type commandRunner func(ctx context.Context, path string, args ...string) ([]byte, error)
+
+func runCommand(ctx context.Context, path string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, path, args...).Output() //nolint:gosec
+}
+
+// smartctl runs smartctl and decodes the JSON output.
+type smartctl struct {
+	path        string
+	timeout     time.Duration
+	skipStandby bool
+	run         commandRunner
+}
+
+func (s *smartctl) exec(v interface{}, args ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	output, runErr := s.run(ctx, s.path, append([]string{"--json"}, args...)...)
+	if len(output) == 0 {
+		if runErr == nil {
+			runErr = fmt.Errorf("no output")
+		}
+		return fmt.Errorf("run smartctl %s error: %v", strings.Join(args, " "), runErr)
+	}
+	if err := json.Unmarshal(output, v); err != nil {
+		return fmt.Errorf("decode the output of smartctl %s error: %v", strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// scan returns the devices found by smartctl.
+func (s *smartctl) scan() ([]scanDevice, error) {
+	var output scanOutput
+	if err := s.exec(&output, "--scan"); err != nil {
+		return nil, err
+	}
+	return output.Devices, nil
+}
+
+// read returns the SMART information of the device, or nil if the device is skipped in the standby mode.
+func (s *smartctl) read(device scanDevice) (*deviceOutput, error) {
+	args := []string{"--all"}
+	if device.Type != "" {
+		args = append(args, "--device", device.Type)
+	}
+	if s.skipStandby {
+		args = append(args, "--nocheck", "standby")
+	}
+	args = append(args, device.Name)
+	var output deviceOutput
+	if err := s.exec(&output, args...); err != nil {
+		return nil, err
+	}
+	if output.Smartctl.ExitStatus&(exitCommandLineError|exitDeviceOpenError) != 0 {
+		if s.skipStandby && output.SmartStatus == nil && isStandby(&output) {
+			return nil, nil
+		}
+		var messages []string
+		for _, m := range output.Smartctl.Messages {
+			messages = append(messages, m.String)
+		}
+		return nil, fmt.Errorf("read device %s error, exit status %d: %s", device.Name, output.Smartctl.ExitStatus, strings.Join(messages, "; "))
+	}
+	return &output, nil
+}
+
+func isStandby(output *deviceOutput) bool {
+	for _, m := range output.Smartctl.Messages {
+		if strings.Contains(strings.ToUpper(m.String), "STANDBY") {
+			return true
+		}
+	}
+	return false
+}
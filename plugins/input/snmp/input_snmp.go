@@ -26,10 +26,13 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/alibaba/ilogtail/helper/scheduler"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/util"
 
 	// third-party
 	g "github.com/gosnmp/gosnmp"
@@ -82,9 +85,16 @@ type Agent struct {
 	PrivacyProtocol          string
 	PrivacyPassphrase        string
 
+	// Schedule queries the targets periodically by the cron expression, e.g. {"Cron": "*/5 * * * *"},
+	// the targets are queried only once at the start when it's not set
+	Schedule *scheduler.Config
+
 	gs            []*g.GoSNMP
 	fieldContents []Field
 	context       pipeline.Context
+	job           *scheduler.Job
+	shutdown      chan struct{}
+	wg            sync.WaitGroup
 }
 
 // Field holds the configuration for a Field to look up.
@@ -166,6 +176,18 @@ func (s *Agent) Init(context pipeline.Context) (int, error) {
 		}
 	}
 
+	if s.Schedule != nil {
+		job, err := s.Schedule.NewJob(0)
+		if err != nil {
+			return 1, err
+		}
+		job.RunAtStart = true
+		job.OnSkip = func() {
+			logger.Warning(s.context.GetRuntimeContext(), util.AlarmInputSNMPTimeout, "the last query is still running, skip the schedule")
+		}
+		s.job = job
+	}
+	s.shutdown = make(chan struct{})
 	return 0, nil
 }
 
@@ -463,121 +485,141 @@ func (s *Agent) GetTranslated() error {
 
 func (s *Agent) Start(collector pipeline.Collector) error {
 	runtime.GOMAXPROCS(len(s.gs))
-	for index, GsAgent := range s.gs {
-		// use anonymous function to avoid resource leak
-		thisGsAgent := GsAgent
-		thisIndex := index
-		go func() {
-			err := func() error {
-				err := thisGsAgent.Connect()
-				if err != nil {
-					logger.Errorf(context.Background(), "INPUT_SNMP_CONNECTION_ERROR", "%v", err)
-					return err
-				}
+	if s.job == nil {
+		go s.queryAll(collector)
+		return nil
+	}
+	s.wg.Add(1)
+	defer s.wg.Done()
+	s.job.Run(func() {
+		s.queryAll(collector)
+	}, s.shutdown)
+	return nil
+}
 
-				var sent time.Time
-				thisGsAgent.OnSent = func(x *g.GoSNMP) {
-					sent = time.Now()
-				}
-				thisGsAgent.OnRecv = func(x *g.GoSNMP) {
-					logger.Debugf(context.Background(), "Query latency in seconds:", time.Since(sent).Seconds())
-				}
+// queryAll queries all the targets concurrently, and waits for the results.
+func (s *Agent) queryAll(collector pipeline.Collector) {
+	var wg sync.WaitGroup
+	for index := range s.gs {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			if err := s.query(collector, index); err != nil {
+				logger.Errorf(context.Background(), util.AlarmInputSNMPConnectionError, fmt.Sprintf("%v", err))
+			}
+		}(index)
+	}
+	wg.Wait()
+}
 
-				var oids []string
-				for _, fieldContents := range s.fieldContents {
-					oids = append(oids, fieldContents.Oid)
-				}
+// query gets the fields of the target at @thisIndex.
+func (s *Agent) query(collector pipeline.Collector, thisIndex int) error {
+	thisGsAgent := s.gs[thisIndex]
+	if thisGsAgent.Conn == nil {
+		// connect once at the first query, and the connection is reused by the scheduled queries
+		if err := thisGsAgent.Connect(); err != nil {
+			return err
+		}
+	}
 
-				result, err := thisGsAgent.Get(oids)
-
-				switch {
-				case errors.Is(err, g.ErrUnknownSecurityLevel):
-					return fmt.Errorf("unknown security level")
-				case errors.Is(err, g.ErrUnknownUsername):
-					return fmt.Errorf("unknown username")
-				case errors.Is(err, g.ErrWrongDigest):
-					return fmt.Errorf("wrong digest")
-				case errors.Is(err, g.ErrDecryption):
-					return fmt.Errorf("decryption error")
-				case err != nil:
-					return err
-				}
+	var sent time.Time
+	thisGsAgent.OnSent = func(x *g.GoSNMP) {
+		sent = time.Now()
+	}
+	thisGsAgent.OnRecv = func(x *g.GoSNMP) {
+		logger.Debugf(context.Background(), "Query latency in seconds:", time.Since(sent).Seconds())
+	}
 
-				for i, variable := range result.Variables {
-					if s.fieldContents[i].Conversion == "hwaddr" {
-						switch vt := variable.Value.(type) {
-						case string:
-							variable.Value = net.HardwareAddr(vt).String()
-						case []byte:
-							variable.Value = net.HardwareAddr(vt).String()
-						default:
-							return fmt.Errorf("invalid type (%T) for hwaddr conversion", variable.Value)
-						}
-
-					}
-
-					if s.fieldContents[i].Conversion == "ipaddr" {
-						var ipbs []byte
-
-						switch vt := variable.Value.(type) {
-						case string:
-							ipbs = []byte(vt)
-						case []byte:
-							ipbs = vt
-						default:
-							return fmt.Errorf("invalid type (%T) for ipaddr conversion", variable.Value)
-						}
-
-						switch len(ipbs) {
-						case 4, 16:
-							variable.Value = net.IP(ipbs).String()
-						default:
-							return fmt.Errorf("invalid length (%d) for ipaddr conversion", len(ipbs))
-						}
-					}
-
-					var res string
-					switch variable.Type {
-					case g.OctetString:
-						res = string(variable.Value.([]byte))
-					default:
-						res = g.ToBigInt(variable.Value).String()
-					}
-
-					translatedType := Asn1BER2String(variable.Type)
-
-					if translatedType == "Null" || translatedType == "" {
-						logger.Warning(context.Background(), "result of %v is probably empty", s.fieldContents[i].Name)
-						if s.Version == 1 {
-							logger.Warning(context.Background(), "snmp v1 will not return any `GET` result while one of them is empty, please check `Oids`, `Fields`, `Tables` or change `Version` into `2`")
-						}
-					}
-
-					collector.AddData(nil, map[string]string{
-						"_targetindex_": strconv.Itoa(thisIndex),
-						"_target_":      thisGsAgent.Target,
-						"_field_":       s.fieldContents[i].Name,
-						"_oid_":         variable.Name,
-						"_conversion_":  s.fieldContents[i].Conversion,
-						"_type_":        translatedType,
-						"_content_":     res})
-				}
-				return nil
-			}()
-			if err != nil {
-				logger.Errorf(context.Background(), "INPUT_SNMP_CONNECTION_ERROR", fmt.Sprintf("%v", err))
+	var oids []string
+	for _, fieldContents := range s.fieldContents {
+		oids = append(oids, fieldContents.Oid)
+	}
+
+	result, err := thisGsAgent.Get(oids)
+
+	switch {
+	case errors.Is(err, g.ErrUnknownSecurityLevel):
+		return fmt.Errorf("unknown security level")
+	case errors.Is(err, g.ErrUnknownUsername):
+		return fmt.Errorf("unknown username")
+	case errors.Is(err, g.ErrWrongDigest):
+		return fmt.Errorf("wrong digest")
+	case errors.Is(err, g.ErrDecryption):
+		return fmt.Errorf("decryption error")
+	case err != nil:
+		return err
+	}
+
+	for i, variable := range result.Variables {
+		if s.fieldContents[i].Conversion == "hwaddr" {
+			switch vt := variable.Value.(type) {
+			case string:
+				variable.Value = net.HardwareAddr(vt).String()
+			case []byte:
+				variable.Value = net.HardwareAddr(vt).String()
+			default:
+				return fmt.Errorf("invalid type (%T) for hwaddr conversion", variable.Value)
 			}
-		}()
+
+		}
+
+		if s.fieldContents[i].Conversion == "ipaddr" {
+			var ipbs []byte
+
+			switch vt := variable.Value.(type) {
+			case string:
+				ipbs = []byte(vt)
+			case []byte:
+				ipbs = vt
+			default:
+				return fmt.Errorf("invalid type (%T) for ipaddr conversion", variable.Value)
+			}
+
+			switch len(ipbs) {
+			case 4, 16:
+				variable.Value = net.IP(ipbs).String()
+			default:
+				return fmt.Errorf("invalid length (%d) for ipaddr conversion", len(ipbs))
+			}
+		}
+
+		var res string
+		switch variable.Type {
+		case g.OctetString:
+			res = string(variable.Value.([]byte))
+		default:
+			res = g.ToBigInt(variable.Value).String()
+		}
+
+		translatedType := Asn1BER2String(variable.Type)
+
+		if translatedType == "Null" || translatedType == "" {
+			logger.Warning(context.Background(), "result of %v is probably empty", s.fieldContents[i].Name)
+			if s.Version == 1 {
+				logger.Warning(context.Background(), "snmp v1 will not return any `GET` result while one of them is empty, please check `Oids`, `Fields`, `Tables` or change `Version` into `2`")
+			}
+		}
+
+		collector.AddData(nil, map[string]string{
+			"_targetindex_": strconv.Itoa(thisIndex),
+			"_target_":      thisGsAgent.Target,
+			"_field_":       s.fieldContents[i].Name,
+			"_oid_":         variable.Name,
+			"_conversion_":  s.fieldContents[i].Conversion,
+			"_type_":        translatedType,
+			"_content_":     res})
 	}
 	return nil
 }
 
 func (s *Agent) Stop() error {
+	close(s.shutdown)
 	for _, GsAgent := range s.gs {
-		func() {
-			defer GsAgent.Conn.Close()
-		}()
+		if GsAgent.Conn != nil {
+			_ = GsAgent.Conn.Close()
+		}
 	}
+	s.wg.Wait()
 	return nil
 }
 
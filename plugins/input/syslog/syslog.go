@@ -22,20 +22,21 @@ import (
 	"fmt"
 	"io"
 	"net"
-	"net/url"
 	"os"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/alibaba/ilogtail/helper"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 const (
-	maxMessageSize = 512 * 1024
+	maxMessageSize    = 512 * 1024
+	defaultSyslogPort = "6514"
 )
 
 // Syslog is a service input plugin to collect syslog.
@@ -44,7 +45,7 @@ const (
 // It allows users to offer ParseFailField, if a failure happened in parsing pharse,
 // it can stop parse and copy whole data to specify field, and returns to caller.
 type Syslog struct {
-	Address            string // Address to receive logs from agents, eg. [tcp/udp]://[host]:[port].
+	Address            string // Address to receive logs from agents, eg. [tcp/udp]://[host]:[port], or [unix/unixgram]:///path/to/socket.
 	MaxConnections     int    // Max connections, for TCP only.
 	TimeoutSeconds     int    // The number of seconds of inactivity before a remote connection is closed.
 	MaxMessageSize     int    // Maximum size of message in bytes received over transport protocol.
@@ -52,6 +53,11 @@ type Syslog struct {
 	ParseProtocol      string // ["", rfc3164, rfc5424, auto], empty means no parser.
 	IgnoreParseFailure bool   // When parse failure happened, ignore error and set content field if it is set.
 	AddHostname        bool   // When listen unixgram from /dev/log, the hostname field is not included in the log, so use rfc3164 will cause parse error, so AddHostname give parser it's own hostname, then parser can parse tag, program, content field currently.
+	Framing            string // ["", delimiter, octet_counting, uint32_length, varint_length], the framing of the messages over stream connections, empty means delimiter.
+	Delimiter          string // The delimiter of the messages over stream connections, "\n" by default.
+	DelimiterRegex     string // The regex separator of the messages over stream connections, takes precedence over Delimiter.
+	// UnixSocket sets the file permissions of the socket when Address is [unix/unixgram]:///path/to/socket.
+	UnixSocket *helper.UnixSocketConfig
 
 	done chan struct{}
 	mu   sync.Mutex
@@ -60,13 +66,14 @@ type Syslog struct {
 
 	context       pipeline.Context
 	isStream      bool
-	isUnix        bool // If scheme is "unixgram", need to flag it and delete file when closed.
-	connections   map[string]net.Conn
+	isUnix        bool // If scheme is "unix" or "unixgram", need to flag it and delete file when closed.
+	connections   map[net.Conn]struct{}
 	connectionsMu sync.Mutex
 	connectionsWg sync.WaitGroup
 	tcpListener   net.Listener
 	udpListener   net.PacketConn
 	parser        parser
+	splitter      *helper.RecordSplitter
 }
 
 // Init ...
@@ -88,6 +95,20 @@ func (s *Syslog) Init(context pipeline.Context) (int, error) {
 		addHostname:        s.AddHostname,
 	})
 
+	splitter, err := helper.NewRecordSplitter(helper.RecordSplitterConfig{
+		Framing:        strings.ToLower(strings.TrimSpace(s.Framing)),
+		Delimiter:      s.Delimiter,
+		DelimiterRegex: s.DelimiterRegex,
+		MaxFrameSize:   s.MaxMessageSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+	s.splitter = splitter
+	if err = s.UnixSocket.Init(); err != nil {
+		return 0, err
+	}
+
 	s.context = context
 	logger.Debug(s.context.GetRuntimeContext(), "syslog load config", s.context.GetConfigName())
 	return 0, nil
@@ -106,7 +127,7 @@ func (s *Syslog) Collect(collector pipeline.Collector) error {
 // Start ...
 func (s *Syslog) Start(collector pipeline.Collector) error {
 	s.done = make(chan struct{}, 1)
-	scheme, host, err := getAddressParts(s.Address)
+	scheme, host, err := helper.SplitAddress(s.Address, defaultSyslogPort)
 	if err != nil {
 		return err
 	}
@@ -116,6 +137,9 @@ func (s *Syslog) Start(collector pipeline.Collector) error {
 		s.isStream = true
 	case "udp", "udp4", "udp6":
 		s.isStream = false
+	case "unix":
+		s.isStream = true
+		s.isUnix = true
 	case "unixgram":
 		s.isStream = false
 		s.isUnix = true
@@ -124,9 +148,9 @@ func (s *Syslog) Start(collector pipeline.Collector) error {
 	}
 
 	if s.isStream {
-		l, err := net.Listen(scheme, host)
+		l, err := helper.Listen(host, scheme, s.UnixSocket)
 		if err != nil {
-			logger.Error(s.context.GetRuntimeContext(), "SERVICE_SYSLOG_INIT_ALARM", "net.Listen error", err,
+			logger.Error(s.context.GetRuntimeContext(), util.AlarmServiceSyslogInit, "net.Listen error", err,
 				"Address", s.Address, "scheme", scheme, "host", host)
 			return err
 		}
@@ -136,9 +160,9 @@ func (s *Syslog) Start(collector pipeline.Collector) error {
 		s.wg.Add(1)
 		go s.listenStream(collector)
 	} else {
-		l, err := net.ListenPacket(scheme, host)
+		l, err := helper.ListenPacket(host, scheme, s.UnixSocket)
 		if err != nil {
-			logger.Error(s.context.GetRuntimeContext(), "SERVICE_SYSLOG_INIT_ALARM", "net.ListenPacket error", err,
+			logger.Error(s.context.GetRuntimeContext(), util.AlarmServiceSyslogInit, "net.ListenPacket error", err,
 				"Address", s.Address, "scheme", scheme, "host", host)
 			return err
 		}
@@ -160,55 +184,29 @@ func (s *Syslog) Stop() error {
 
 	if s.Closer != nil {
 		if err := s.Close(); err != nil {
-			logger.Error(s.context.GetRuntimeContext(), "SERVICE_SYSLOG_CLOSE_ALARM", "Syslog.Close error", err,
+			logger.Error(s.context.GetRuntimeContext(), util.AlarmServiceSyslogClose, "Syslog.Close error", err,
 				"Address", s.Address)
 		}
 	}
 	s.wg.Wait()
 	s.connectionsWg.Wait()
 
-	// If scheme type is "unixgram", remove unix socket file after close.
+	// If scheme type is "unix" or "unixgram", remove unix socket file after close.
 	if s.isUnix {
-		_, host, err := getAddressParts(s.Address)
+		_, host, err := helper.SplitAddress(s.Address, defaultSyslogPort)
 		if err != nil {
-			logger.Error(s.context.GetRuntimeContext(), "SERVICE_SYSLOG_CLOSE_ALARM", "getAddressParts error", err,
+			logger.Error(s.context.GetRuntimeContext(), util.AlarmServiceSyslogClose, "split address error", err,
 				"Address", s.Address)
 		}
 		err = os.Remove(host)
-		if err != nil {
-			logger.Error(s.context.GetRuntimeContext(), "SERVICE_SYSLOG_CLOSE_ALARM", "os.Remove error", err,
+		if err != nil && !os.IsNotExist(err) {
+			logger.Error(s.context.GetRuntimeContext(), util.AlarmServiceSyslogClose, "os.Remove error", err,
 				"Host", host)
 		}
 	}
 	return nil
 }
 
-func getAddressParts(a string) (string, string, error) {
-	parts := strings.SplitN(a, "://", 2)
-	if len(parts) != 2 {
-		return "", "", fmt.Errorf("missing protocol within address '%s'", a)
-	}
-
-	u, _ := url.Parse(a)
-	switch u.Scheme {
-	case "unix", "unixpacket", "unixgram":
-		return parts[0], parts[1], nil
-	}
-
-	var host string
-	if u.Hostname() != "" {
-		host = u.Hostname()
-	}
-	host += ":"
-	if u.Port() == "" {
-		host += "6514"
-	} else {
-		host += u.Port()
-	}
-
-	return u.Scheme, host, nil
-}
-
 func (s *Syslog) resetTimeout(c net.Conn) {
 	if s.TimeoutSeconds > 0 {
 		_ = c.SetReadDeadline(time.Now().Add(time.Duration(s.TimeoutSeconds) * time.Second))
@@ -243,7 +241,7 @@ func (s *Syslog) sleepWithChan(duration time.Duration) bool {
 func (s *Syslog) listenStream(collector pipeline.Collector) {
 	defer s.wg.Done()
 
-	s.connections = map[string]net.Conn{}
+	s.connections = map[net.Conn]struct{}{}
 	backoff := newSimpleBackoff()
 Loop:
 	for {
@@ -257,7 +255,7 @@ Loop:
 			}
 
 			// Alarm and sleep (with backoff).
-			logger.Error(s.context.GetRuntimeContext(), "SERVICE_SYSLOG_STREAM_ALARM", "accept error", err)
+			logger.Error(s.context.GetRuntimeContext(), util.AlarmServiceSyslogStream, "accept error", err)
 			if s.sleepWithChan(backoff.Next()) {
 				break
 			}
@@ -273,11 +271,14 @@ Loop:
 			_ = conn.Close()
 			continue
 		}
-		s.connections[conn.RemoteAddr().String()] = conn
+		// the remote addresses of the unix socket connections are the same, so the connections are the keys
+		s.connections[conn] = struct{}{}
 		s.connectionsMu.Unlock()
 
-		if err := s.setKeepAlive(tcpConn); err != nil {
-			logger.Error(s.context.GetRuntimeContext(), "SERVICE_SYSLOG_STREAM_ALARM", "setKeepAlive error", err)
+		if tcpConn != nil {
+			if err := s.setKeepAlive(tcpConn); err != nil {
+				logger.Error(s.context.GetRuntimeContext(), util.AlarmServiceSyslogStream, "setKeepAlive error", err)
+			}
 		}
 
 		s.connectionsWg.Add(1)
@@ -285,7 +286,7 @@ Loop:
 	}
 
 	s.connectionsMu.Lock()
-	for _, c := range s.connections {
+	for c := range s.connections {
 		_ = c.Close()
 	}
 	s.connections = nil
@@ -304,7 +305,13 @@ func (s *Syslog) handle(conn net.Conn, collector pipeline.Collector) {
 	scanner := bufio.NewScanner(buf)
 	byteBuf := make([]byte, s.MaxMessageSize)
 	scanner.Buffer(byteBuf, s.MaxMessageSize)
+	scanner.Split(s.splitter.Split)
 	s.resetTimeout(conn)
+	clientIP := conn.RemoteAddr().String()
+	if s.isUnix {
+		// the local applications have no ip
+		clientIP = ""
+	}
 	backoff := newSimpleBackoff()
 	// TODO: Scan panics if the split function returns too many empty tokens without advancing the input.
 	// This is a common error mode for scanners.
@@ -318,12 +325,12 @@ func (s *Syslog) handle(conn net.Conn, collector pipeline.Collector) {
 
 			// I/O timeout.
 			if strings.HasSuffix(err.Error(), ": i/o timeout") {
-				logger.Warning(s.context.GetRuntimeContext(), "SERVICE_SYSLOG_STREAM_ALARM", "connection i/o timeout")
+				logger.Warning(s.context.GetRuntimeContext(), util.AlarmServiceSyslogStream, "connection i/o timeout")
 				s.resetTimeout(conn)
 				continue
 			}
 
-			logger.Error(s.context.GetRuntimeContext(), "SERVICE_SYSLOG_STREAM_ALARM", "scan error", err)
+			logger.Error(s.context.GetRuntimeContext(), util.AlarmServiceSyslogStream, "scan error", err)
 			if s.sleepWithChan(backoff.Next()) || backoff.CanQuit() {
 				break
 			}
@@ -334,12 +341,12 @@ func (s *Syslog) handle(conn net.Conn, collector pipeline.Collector) {
 
 		data := scanner.Bytes()
 		if len(data) > 0 {
-			s.parse(data, conn.RemoteAddr().String(), collector)
+			s.parseLine(data, clientIP, collector)
 		}
 		s.resetTimeout(conn)
 	}
 	if err := scanner.Err(); err != nil {
-		logger.Error(s.context.GetRuntimeContext(), "SERVICE_SYSLOG_STREAM_ALARM",
+		logger.Error(s.context.GetRuntimeContext(), util.AlarmServiceSyslogStream,
 			"quit stream connection because of scan error out of loop", err)
 	}
 	logger.Info(s.context.GetRuntimeContext(), "handle for connection", conn.RemoteAddr().String(), "quit")
@@ -347,7 +354,7 @@ func (s *Syslog) handle(conn net.Conn, collector pipeline.Collector) {
 
 func (s *Syslog) removeConnection(c net.Conn) {
 	s.connectionsMu.Lock()
-	delete(s.connections, c.RemoteAddr().String())
+	delete(s.connections, c)
 	s.connectionsMu.Unlock()
 }
 
@@ -371,11 +378,11 @@ func (s *Syslog) listenPacket(collector pipeline.Collector) {
 
 			// I/O timeout, warn and retry.
 			if strings.HasSuffix(err.Error(), ": i/o timeout") {
-				logger.Warning(s.context.GetRuntimeContext(), "SERVICE_SYSLOG_PACKET_ALARM", "connection i/o timeout")
+				logger.Warning(s.context.GetRuntimeContext(), util.AlarmServiceSyslogPacket, "connection i/o timeout")
 				continue
 			}
 
-			logger.Error(s.context.GetRuntimeContext(), "SERVICE_SYSLOG_PACKET_ALARM", "read from error", err)
+			logger.Error(s.context.GetRuntimeContext(), util.AlarmServiceSyslogPacket, "read from error", err)
 			if s.sleepWithChan(backoff.Next()) {
 				break
 			}
@@ -397,52 +404,57 @@ func (s *Syslog) parse(b []byte, clientIP string, collector pipeline.Collector)
 		lines = lines[:len(lines)-1]
 	}
 
-	// Parse lines one by one, fill some fields of result if they are empty.
+	// Parse lines one by one.
 	for _, line := range lines {
-		rst, err := s.parser.Parse(line)
-		if err != nil {
-			logger.Warning(s.context.GetRuntimeContext(), "SERVICE_SYSLOG_PARSE_ALARM",
-				"Parse failed with protocol '", s.ParseProtocol,
-				"error", err,
-				"', drop line:", string(line))
-			continue
-		}
+		s.parseLine(line, clientIP, collector)
+	}
+}
 
-		fields := map[string]string{}
-		fields["_program_"] = rst.program
-		fields["_priority_"] = strconv.Itoa(rst.priority)
-		fields["_facility_"] = strconv.Itoa(rst.facility)
-		fields["_severity_"] = strconv.Itoa(rst.severity)
-		// use nano timestamp because RFC5424's timestamp is [RFC3339]
-		// eg: 2003-08-24T05:14:15.000003-07:00, 2003-10-11T22:14:15.003Z
-		fields["_unixtimestamp_"] = strconv.FormatInt(rst.time.UnixNano(), 10)
-		if rst.hostname == "" {
-			fields["_hostname_"] = util.GetHostName()
-		} else {
-			fields["_hostname_"] = rst.hostname
-		}
-		if len(clientIP) > 0 {
-			fields["_client_ip_"] = strings.Split(clientIP, ":")[0]
-		} else {
-			fields["_client_ip_"] = ""
-		}
+// parseLine parses a message, and fills some fields of result if they are empty.
+func (s *Syslog) parseLine(line []byte, clientIP string, collector pipeline.Collector) {
+	rst, err := s.parser.Parse(line)
+	if err != nil {
+		logger.Warning(s.context.GetRuntimeContext(), util.AlarmServiceSyslogParse,
+			"Parse failed with protocol '", s.ParseProtocol,
+			"error", err,
+			"', drop line:", string(line))
+		return
+	}
 
-		fields["_ip_"] = util.GetIPAddress()
-		fields["_content_"] = rst.content
+	fields := map[string]string{}
+	fields["_program_"] = rst.program
+	fields["_priority_"] = strconv.Itoa(rst.priority)
+	fields["_facility_"] = strconv.Itoa(rst.facility)
+	fields["_severity_"] = strconv.Itoa(rst.severity)
+	// use nano timestamp because RFC5424's timestamp is [RFC3339]
+	// eg: 2003-08-24T05:14:15.000003-07:00, 2003-10-11T22:14:15.003Z
+	fields["_unixtimestamp_"] = strconv.FormatInt(rst.time.UnixNano(), 10)
+	if rst.hostname == "" {
+		fields["_hostname_"] = util.GetHostName()
+	} else {
+		fields["_hostname_"] = rst.hostname
+	}
+	if len(clientIP) > 0 {
+		fields["_client_ip_"] = strings.Split(clientIP, ":")[0]
+	} else {
+		fields["_client_ip_"] = ""
+	}
 
-		if rst.structuredData != nil {
-			structuredData, _ := json.Marshal(*rst.structuredData)
-			fields["_structured_data_"] = string(structuredData)
-		}
-		if rst.msgID != nil {
-			fields["_message_id_"] = *rst.msgID
-		}
-		if rst.procID != nil {
-			fields["_process_id_"] = *rst.procID
-		}
+	fields["_ip_"] = util.GetIPAddress()
+	fields["_content_"] = rst.content
 
-		collector.AddData(nil, fields, rst.time)
+	if rst.structuredData != nil {
+		structuredData, _ := json.Marshal(*rst.structuredData)
+		fields["_structured_data_"] = string(structuredData)
+	}
+	if rst.msgID != nil {
+		fields["_message_id_"] = *rst.msgID
 	}
+	if rst.procID != nil {
+		fields["_process_id_"] = *rst.procID
+	}
+
+	collector.AddData(nil, fields, rst.time)
 }
 
 func newSyslog() *Syslog {
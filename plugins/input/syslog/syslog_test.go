@@ -15,6 +15,7 @@
 package inputsyslog
 
 import (
+	"github.com/alibaba/ilogtail/helper"
 	_ "github.com/alibaba/ilogtail/pkg/logger/test"
 	"github.com/alibaba/ilogtail/pkg/protocol"
 	"github.com/alibaba/ilogtail/pluginmanager"
@@ -23,6 +24,7 @@ import (
 	"math/rand"
 	"net"
 	"os"
+	"path/filepath"
 	"strconv"
 	"sync"
 	"testing"
@@ -66,12 +68,12 @@ func TestStartAndStop(t *testing.T) {
 }
 
 func connect(t *testing.T, syslog *Syslog) net.Conn {
-	scheme, path, err := getAddressParts(syslog.Address)
+	scheme, path, err := helper.SplitAddress(syslog.Address, defaultSyslogPort)
 	require.NoError(t, err)
 
 	var host string
 	switch {
-	case scheme == "unixgram":
+	case syslog.isUnix:
 		host = path
 	case syslog.tcpListener != nil:
 		host = fmt.Sprintf("127.0.0.1:%d", syslog.tcpListener.Addr().(*net.TCPAddr).Port)
@@ -191,7 +193,7 @@ func mockRun(t *testing.T, syslog *Syslog, collector *mockCollector) {
 		priority, _ := strconv.Atoi(slog.fields["_priority_"])
 		log := getLog(priority,
 			slog.fields["_hostname_"], slog.fields["_program_"], slog.fields["_content_"], &slog.t)
-		if t.Name() != "TestMockUnixGram" {
+		if !syslog.isUnix {
 			require.Equal(t, "127.0.0.1", slog.fields["_client_ip_"])
 		}
 		require.Equal(t, rawLog, log, "log index: %v, slog: %v, raw log: %v", idx, slog, rawLog)
@@ -259,3 +261,95 @@ func TestMockUnixGram(t *testing.T) {
 
 	mockRun(t, syslog, collector)
 }
+
+func TestMockUnixStream(t *testing.T) {
+	unixFilePath := filepath.Join(t.TempDir(), "syslog.sock")
+	ctx := &pluginmanager.ContextImp{}
+	ctx.InitContext("test_project", "test_logstore", "test_configname")
+	collector := &mockCollector{}
+
+	syslog := newSyslog()
+	syslog.ParseProtocol = "rfc3164"
+	syslog.Address = "unix://" + unixFilePath
+	syslog.UnixSocket = &helper.UnixSocketConfig{Mode: "0660"}
+	_, err := syslog.Init(ctx)
+	require.NoError(t, err)
+	require.NoError(t, syslog.Start(collector))
+	stat, err := os.Stat(unixFilePath)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o660), stat.Mode().Perm())
+
+	mockRun(t, syslog, collector)
+	_, err = os.Stat(unixFilePath)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestTcpFraming(t *testing.T) {
+	ctx := &pluginmanager.ContextImp{}
+	ctx.InitContext("test_project", "test_logstore", "test_configname")
+	collector := &mockCollector{}
+
+	syslog := newSyslog()
+	syslog.Framing = "octet_counting"
+	_, err := syslog.Init(ctx)
+	require.NoError(t, err)
+	go func() {
+		err := syslog.Start(collector)
+		require.NoError(t, err)
+	}()
+	time.Sleep(time.Duration(1) * time.Second)
+
+	conn := connect(t, syslog)
+	messages := []string{"<13>first line\nsecond line", "<14>message"}
+	for _, message := range messages {
+		_, err = fmt.Fprintf(conn, "%d %s", len(message), message)
+		require.NoError(t, err)
+	}
+	time.Sleep(time.Millisecond * 100)
+	assert.NoError(t, syslog.Stop())
+	assert.NoError(t, conn.Close())
+
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	require.Equal(t, len(messages), len(collector.logs))
+	for i, message := range messages {
+		assert.Equal(t, message, collector.logs[i].fields["_content_"])
+	}
+
+	syslog = newSyslog()
+	syslog.Framing = "unknown"
+	_, err = syslog.Init(ctx)
+	assert.Error(t, err)
+}
+
+func TestTcpMaxFrameSize(t *testing.T) {
+	ctx := &pluginmanager.ContextImp{}
+	ctx.InitContext("test_project", "test_logstore", "test_configname")
+	collector := &mockCollector{}
+
+	syslog := newSyslog()
+	syslog.Framing = "octet_counting"
+	syslog.MaxMessageSize = 16
+	_, err := syslog.Init(ctx)
+	require.NoError(t, err)
+	go func() {
+		err := syslog.Start(collector)
+		require.NoError(t, err)
+	}()
+	time.Sleep(time.Duration(1) * time.Second)
+
+	conn := connect(t, syslog)
+	// the connection is closed by the header of the frame larger than MaxMessageSize
+	_, err = fmt.Fprintf(conn, "11 <13>message%d <13>", 1<<30)
+	require.NoError(t, err)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+	_, err = conn.Read(make([]byte, 1))
+	assert.Error(t, err)
+	assert.NoError(t, syslog.Stop())
+	assert.NoError(t, conn.Close())
+
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	require.Len(t, collector.logs, 1)
+	assert.Equal(t, "<13>message", collector.logs[0].fields["_content_"])
+}
@@ -0,0 +1,56 @@
+// Copyright 2021 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build darwin
+// +build darwin
+
+package systemv2
+
+import (
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/util"
+
+	"github.com/shirou/gopsutil/net"
+	"golang.org/x/sys/unix"
+)
+
+func (r *InputSystem) Init(context pipeline.Context) (int, error) {
+	return r.CommonInit(context)
+}
+
+// CollectTCPStats is never called because the protocol counters are not supported on macOS,
+// the connections are counted by CollectTCPConnections instead.
+func (r *InputSystem) CollectTCPStats(collector pipeline.Collector, stat *net.ProtoCountersStat) {
+}
+
+// CollectOpenFD collects the open files of the system by sysctl.
+func (r *InputSystem) CollectOpenFD(collector pipeline.Collector) {
+	allocated, err := unix.SysctlUint32("kern.num_files")
+	if err != nil {
+		logger.Error(r.context.GetRuntimeContext(), util.AlarmReadFileNr, "err", err)
+		return
+	}
+	maximum, err := unix.SysctlUint32("kern.maxfiles")
+	if err != nil {
+		logger.Error(r.context.GetRuntimeContext(), util.AlarmReadFileNr, "err", err)
+		return
+	}
+	r.addMetric(collector, "fd_allocated", r.commonLabelsStr, float64(allocated))
+	r.addMetric(collector, "fd_max", r.commonLabelsStr, float64(maximum))
+}
+
+func (r *InputSystem) CollectDiskUsage(collector pipeline.Collector) {
+	r.collectPartitionsUsage(collector, true)
+}
@@ -12,16 +12,14 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-//go:build !linux
-// +build !linux
+//go:build !linux && !windows && !darwin
+// +build !linux,!windows,!darwin
 
 package systemv2
 
 import (
-	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 
-	"github.com/shirou/gopsutil/disk"
 	"github.com/shirou/gopsutil/net"
 )
 
@@ -38,32 +36,5 @@ func (r *InputSystem) CollectOpenFD(collector pipeline.Collector) {
 }
 
 func (r *InputSystem) CollectDiskUsage(collector pipeline.Collector) {
-	if allParts, err := disk.Partitions(false); err == nil {
-		for _, part := range allParts {
-			if r.excludeDiskFsTypeRegex != nil && r.excludeDiskFsTypeRegex.MatchString(part.Fstype) {
-				logger.Debug(r.context.GetRuntimeContext(), "ignore disk path", part.Mountpoint)
-				continue
-			}
-			if r.excludeDiskPathRegex != nil && r.excludeDiskPathRegex.MatchString(part.Mountpoint) {
-				logger.Debug(r.context.GetRuntimeContext(), "ignore disk path", part.Mountpoint)
-				continue
-			}
-			newLabels := r.commonLabels.Clone()
-			newLabels.Append("path", part.Mountpoint)
-			newLabels.Append("device", part.Device)
-			newLabels.Append("fs_type", part.Fstype)
-			newLabels.Sort()
-			labels := newLabels.String()
-
-			usage, err := disk.Usage(part.Mountpoint)
-			if err == nil {
-				r.addMetric(collector, "disk_space_usage", labels, usage.UsedPercent)
-				r.addMetric(collector, "disk_inode_usage", labels, usage.InodesUsedPercent)
-				r.addMetric(collector, "disk_space_used", labels, float64(usage.Used))
-				r.addMetric(collector, "disk_space_total", labels, float64(usage.Total))
-				r.addMetric(collector, "disk_inode_total", labels, float64(usage.InodesTotal))
-				r.addMetric(collector, "disk_inode_used", labels, float64(usage.InodesUsed))
-			}
-		}
-	}
+	r.collectPartitionsUsage(collector, true)
 }
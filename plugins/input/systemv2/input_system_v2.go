@@ -77,14 +77,14 @@ type InputSystem struct {
 }
 
 func (r *InputSystem) Description() string {
-	return "Support collect system metrics on the host machine or Linux virtual environments."
+	return "Support collect system metrics on the host machine (Linux, Windows and macOS) or Linux virtual environments."
 }
 
 func (r *InputSystem) CommonInit(context pipeline.Context) (int, error) {
 	if r.ExcludeDiskFsType != "" {
 		reg, err := regexp.Compile(r.ExcludeDiskFsType)
 		if err != nil {
-			logger.Error(r.context.GetRuntimeContext(), "COMPILE_REGEXP_ALARM", "err", err)
+			logger.Error(r.context.GetRuntimeContext(), util.AlarmCompileRegexp, "err", err)
 			return 0, err
 		}
 		r.excludeDiskFsTypeRegex = reg
@@ -92,7 +92,7 @@ func (r *InputSystem) CommonInit(context pipeline.Context) (int, error) {
 	if r.ExcludeDiskPath != "" {
 		reg, err := regexp.Compile(r.ExcludeDiskPath)
 		if err != nil {
-			logger.Error(r.context.GetRuntimeContext(), "COMPILE_REGEXP_ALARM", "err", err)
+			logger.Error(r.context.GetRuntimeContext(), util.AlarmCompileRegexp, "err", err)
 			return 0, err
 		}
 		r.excludeDiskPathRegex = reg
@@ -153,7 +153,7 @@ func (r *InputSystem) CollectCPU(collector pipeline.Collector) {
 		if len(cpushareEnv) > 0 {
 			cpuRequest, err := strconv.Atoi(cpushareEnv)
 			if err != nil || cpuRequest <= 0 || ncpus == 0 {
-				logger.Error(r.context.GetRuntimeContext(), "GET_SIGMA_ENV_ERROR", "get sigma env failed",
+				logger.Error(r.context.GetRuntimeContext(), util.AlarmGetSigmaEnvError, "get sigma env failed",
 					"error", err,
 					"ncpus", ncpus,
 					"SIGMA_CPU_REQUEST", cpushareEnv)
@@ -339,9 +339,59 @@ func (r *InputSystem) CollectNet(collector pipeline.Collector) {
 	}
 }
 
+// tcpConnectionStates normalizes the TCP states of connections on different platforms to the metric names
+// of Linux, such as SYN_RECEIVED of Windows and macOS to syn_recv.
+var tcpConnectionStates = map[string]string{
+	"ESTABLISHED":  "established",
+	"SYN_SENT":     "syn_sent",
+	"SYN_RECV":     "syn_recv",
+	"SYN_RECEIVED": "syn_recv",
+	"FIN_WAIT1":    "fin_wait1",
+	"FIN_WAIT_1":   "fin_wait1",
+	"FIN_WAIT2":    "fin_wait2",
+	"FIN_WAIT_2":   "fin_wait2",
+	"TIME_WAIT":    "time_wait",
+	"CLOSE":        "close",
+	"CLOSED":       "close",
+	"CLOSE_WAIT":   "close_wait",
+	"LAST_ACK":     "last_ack",
+	"LISTEN":       "listen",
+	"CLOSING":      "closing",
+}
+
+// CollectTCPConnections counts the TCP connections by state, which is used when the protocol counters are
+// not supported by the platform, such as Windows and macOS.
+func (r *InputSystem) CollectTCPConnections(collector pipeline.Collector) {
+	conns, err := net.Connections("tcp")
+	if err != nil {
+		logger.Debug(r.context.GetRuntimeContext(), "list tcp connections error", err)
+		return
+	}
+	for state, num := range countTCPStates(conns) {
+		r.addMetric(collector, "protocol_tcp_"+state, r.commonLabelsStr, float64(num))
+	}
+}
+
+func countTCPStates(conns []net.ConnectionStat) map[string]int {
+	counts := make(map[string]int)
+	for _, state := range tcpConnectionStates {
+		counts[state] = 0
+	}
+	for i := range conns {
+		if state, ok := tcpConnectionStates[conns[i].Status]; ok {
+			counts[state]++
+		}
+	}
+	return counts
+}
+
 func (r *InputSystem) CollectProtocol(collector pipeline.Collector) {
 	protoCounterStats, err := net.ProtoCounters([]string{})
-	if err == nil && len(protoCounterStats) > 0 {
+	if err != nil {
+		r.CollectTCPConnections(collector)
+		return
+	}
+	if len(protoCounterStats) > 0 {
 
 		nowTime := time.Now()
 		retransSegField := "RetransSegs"
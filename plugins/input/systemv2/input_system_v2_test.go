@@ -0,0 +1,40 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package systemv2
+
+import (
+	"testing"
+
+	"github.com/shirou/gopsutil/net"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountTCPStates(t *testing.T) {
+	counts := countTCPStates([]net.ConnectionStat{
+		{Status: "ESTABLISHED"},
+		{Status: "ESTABLISHED"},
+		{Status: "SYN_RECEIVED"},
+		{Status: "SYN_RECV"},
+		{Status: "FIN_WAIT_1"},
+		{Status: "CLOSED"},
+		{Status: "NONE"},
+	})
+	assert.Equal(t, 2, counts["established"])
+	assert.Equal(t, 2, counts["syn_recv"], "the states of Windows and macOS should be normalized")
+	assert.Equal(t, 1, counts["fin_wait1"])
+	assert.Equal(t, 1, counts["close"])
+	assert.Equal(t, 0, counts["listen"], "the absent states should be reported as 0")
+	assert.Len(t, counts, 11)
+}
@@ -0,0 +1,43 @@
+// Copyright 2021 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package systemv2
+
+import (
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+
+	"github.com/shirou/gopsutil/net"
+)
+
+func (r *InputSystem) Init(context pipeline.Context) (int, error) {
+	return r.CommonInit(context)
+}
+
+// CollectTCPStats is never called because the protocol counters are not supported on Windows,
+// the connections are counted by CollectTCPConnections instead.
+func (r *InputSystem) CollectTCPStats(collector pipeline.Collector, stat *net.ProtoCountersStat) {
+}
+
+// CollectOpenFD collects nothing because Windows has handles rather than file descriptors, and
+// there is no cheap way to count the handles of the whole system.
+func (r *InputSystem) CollectOpenFD(collector pipeline.Collector) {
+}
+
+// CollectDiskUsage collects the usage of the drives, NTFS and FAT have no inodes.
+func (r *InputSystem) CollectDiskUsage(collector pipeline.Collector) {
+	r.collectPartitionsUsage(collector, false)
+}
@@ -111,7 +111,7 @@ func shouldCreatePath(p string) bool {
 	if err == nil {
 		return !ret
 	}
-	logger.Warningf(telegrafManager.GetContext(), "SERVICE_TELEGRAF_RUNTIME_ALARM",
+	logger.Warningf(telegrafManager.GetContext(), util.AlarmServiceTelegrafRuntime,
 		"stat path %v err: %v", p, err)
 	return false
 }
@@ -137,7 +137,7 @@ const defaultConfig = `
 func (tm *Manager) initAgentDir() {
 	if newDir, err := makeSureDirectoryExist(tm.telegrafConfPath); newDir {
 		if err != nil {
-			logger.Warningf(telegrafManager.GetContext(), "SERVICE_TELEGRAF_RUNTIME_ALARM",
+			logger.Warningf(telegrafManager.GetContext(), util.AlarmServiceTelegrafRuntime,
 				"create conf dir error, path %v, err: %v", tm.telegrafConfPath, err)
 		}
 	} else {
@@ -153,13 +153,13 @@ func (tm *Manager) initAgentDir() {
 				}
 			}
 		} else {
-			logger.Warningf(telegrafManager.GetContext(), "SERVICE_TELEGRAF_RUNTIME_ALARM",
+			logger.Warningf(telegrafManager.GetContext(), util.AlarmServiceTelegrafRuntime,
 				"clean conf dir error, path %v, err: %v", tm.telegrafConfPath, err)
 		}
 	}
 	defaultConfigPath := path.Join(tm.telegrafPath, defaultConfFileName)
 	if err := ioutil.WriteFile(defaultConfigPath, []byte(fmt.Sprintf(defaultConfig, logger.DebugFlag())), 0600); err != nil {
-		logger.Warningf(telegrafManager.GetContext(), "SERVICE_TELEGRAF_RUNTIME_ALARM",
+		logger.Warningf(telegrafManager.GetContext(), util.AlarmServiceTelegrafRuntime,
 			"write default config error, path: %v, err: %v", defaultConfigPath, err)
 	}
 }
@@ -258,12 +258,12 @@ func (tm *Manager) concatConfFilePath(name string) string {
 func (tm *Manager) overwriteConfigFile(cfg *Config) bool {
 	filePath := tm.concatConfFilePath(cfg.Name)
 	if _, err := makeSureDirectoryExist(tm.telegrafConfPath); err != nil {
-		logger.Warningf(telegrafManager.GetContext(), "SERVICE_TELEGRAF_OVERWRITE_CONFIG_ALARM",
+		logger.Warningf(telegrafManager.GetContext(), util.AlarmServiceTelegrafOverwriteConfig,
 			"overwrite local config file error, path: %v err: %v", filePath, err)
 		return false
 	}
 	if err := ioutil.WriteFile(filePath, []byte(cfg.Detail), 0600); err != nil {
-		logger.Warningf(telegrafManager.GetContext(), "SERVICE_TELEGRAF_OVERWRITE_CONFIG_ALARM",
+		logger.Warningf(telegrafManager.GetContext(), util.AlarmServiceTelegrafOverwriteConfig,
 			"overwrite local config file error, path: %v err: %v", filePath, err)
 		return false
 	}
@@ -275,7 +275,7 @@ func (tm *Manager) overwriteConfigFile(cfg *Config) bool {
 func (tm *Manager) removeConfigFile(name string) {
 	filePath := path.Join(tm.telegrafConfPath, fmt.Sprintf("%v.conf", name))
 	if err := os.Remove(filePath); err != nil {
-		logger.Warningf(telegrafManager.GetContext(), "SERVICE_TELEGRAF_REMOVE_CONFIG_ALARM",
+		logger.Warningf(telegrafManager.GetContext(), util.AlarmServiceTelegrafRemoveConfig,
 			"remove local config file error, path: %v, err: %v", filePath, err)
 		return
 	}
@@ -295,7 +295,7 @@ func (tm *Manager) runTelegrafd(command string, needOutput bool) (output []byte,
 	// Workaround: exec.Command throws wait:no child process error always under c-shared buildmode.
 	// TODO: try cgo, implement exec with C and popen.
 	if err != nil && !strings.Contains(err.Error(), "no child process") {
-		logger.Warningf(telegrafManager.GetContext(), "SERVICE_TELEGRAF_RUNTIME_ALARM",
+		logger.Warningf(telegrafManager.GetContext(), util.AlarmServiceTelegrafRuntime,
 			"%v error, output: %v, error: %v", command, string(output), err)
 	}
 	return
@@ -304,7 +304,7 @@ func (tm *Manager) runTelegrafd(command string, needOutput bool) (output []byte,
 // install returns true if agent has been installed.
 func (tm *Manager) install() bool {
 	if exist, err := isPathExist(tm.telegrafdPath); err != nil {
-		logger.Warningf(telegrafManager.GetContext(), "SERVICE_TELEGRAF_RUNTIME_ALARM",
+		logger.Warningf(telegrafManager.GetContext(), util.AlarmServiceTelegrafRuntime,
 			"stat path %v err when install: %v", tm.telegrafdPath, err)
 		return false
 	} else if exist {
@@ -313,7 +313,7 @@ func (tm *Manager) install() bool {
 
 	scriptPath := path.Join(tm.telegrafPath, "install.sh")
 	if exist, err := isPathExist(scriptPath); err != nil || !exist {
-		logger.Warningf(telegrafManager.GetContext(), "SERVICE_TELEGRAF_RUNTIME_ALARM",
+		logger.Warningf(telegrafManager.GetContext(), util.AlarmServiceTelegrafRuntime,
 			"can not find install script %v, maybe stat error: %v", scriptPath, err)
 		return false
 	}
@@ -322,7 +322,7 @@ func (tm *Manager) install() bool {
 	cmd := exec.Command(scriptPath)
 	output, err := cmd.CombinedOutput()
 	if err != nil && !strings.Contains(err.Error(), "no child process") {
-		logger.Warningf(telegrafManager.GetContext(), "SERVICE_TELEGRAF_RUNTIME_ALARM",
+		logger.Warningf(telegrafManager.GetContext(), util.AlarmServiceTelegrafRuntime,
 			"install agent error, output: %v, error: %v", string(output), err)
 		return false
 	}
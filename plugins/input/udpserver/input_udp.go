@@ -16,25 +16,31 @@ package udpserver
 
 import (
 	"net"
+	"os"
 	"strconv"
 	"strings"
 
+	"github.com/alibaba/ilogtail/helper"
 	"github.com/alibaba/ilogtail/helper/decoder"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 type UDPServer struct {
 	Format        string
-	Address       string
+	Address       string // host:port, or unixgram:///path/to/socket to receive the datagrams of the local applications
 	MaxBufferSize int
-
-	context   pipeline.Context
-	decoder   decoder.Decoder
-	addr      *net.UDPAddr
-	conn      *net.UDPConn
-	collector pipeline.Collector
+	// UnixSocket sets the file permissions of the socket when Address is unixgram:///path/to/socket
+	UnixSocket *helper.UnixSocketConfig
+
+	context    pipeline.Context
+	decoder    decoder.Decoder
+	addr       *net.UDPAddr
+	socketPath string
+	conn       net.PacketConn
+	collector  pipeline.Collector
 }
 
 func (u *UDPServer) Init(context pipeline.Context) (int, error) {
@@ -44,9 +50,13 @@ func (u *UDPServer) Init(context pipeline.Context) (int, error) {
 		return 0, err
 	}
 
+	if network, path, err := helper.ParseListenAddress(u.Address, "udp"); err == nil && network == "unixgram" {
+		u.socketPath = path
+		return 0, u.UnixSocket.Init()
+	}
 	host, portStr, err := net.SplitHostPort(u.Address)
 	if err != nil {
-		logger.Error(u.context.GetRuntimeContext(), "UDP_SERVER_ALARM", "illegal udp listening addr", u.Address, "err", err)
+		logger.Error(u.context.GetRuntimeContext(), util.AlarmUDPServer, "illegal udp listening addr", u.Address, "err", err)
 		return 0, err
 	}
 	if host == "" {
@@ -55,13 +65,13 @@ func (u *UDPServer) Init(context pipeline.Context) (int, error) {
 
 	ip, err := net.ResolveIPAddr("ip", host)
 	if err != nil {
-		logger.Error(u.context.GetRuntimeContext(), "UDP_SERVER_ALARM", "unable resolve addr", u.Address, "err", err)
+		logger.Error(u.context.GetRuntimeContext(), util.AlarmUDPServer, "unable resolve addr", u.Address, "err", err)
 		return 0, err
 	}
 
 	port, err := strconv.Atoi(portStr)
 	if err != nil || port < 0 || port > 65535 {
-		logger.Error(u.context.GetRuntimeContext(), "UDP_SERVER_ALARM", "illegal port", portStr, "err", err)
+		logger.Error(u.context.GetRuntimeContext(), util.AlarmUDPServer, "illegal port", portStr, "err", err)
 	}
 
 	u.addr = &net.UDPAddr{
@@ -85,30 +95,35 @@ func (u *UDPServer) Start(collector pipeline.Collector) error {
 
 func (u *UDPServer) doStart(dispatchFunc func(logs []*protocol.Log)) error {
 	var err error
-	u.conn, err = net.ListenUDP("udp", u.addr)
+	if u.socketPath != "" {
+		u.conn, err = helper.ListenPacket(u.Address, "udp", u.UnixSocket)
+	} else {
+		u.conn, err = net.ListenUDP("udp", u.addr)
+	}
 	if err != nil {
-		logger.Error(u.context.GetRuntimeContext(), "UDP_SERVER_ALARM", "start udp server err", err)
+		logger.Error(u.context.GetRuntimeContext(), util.AlarmUDPServer, "start udp server err", err)
 		return err
 	}
+	conn := u.conn
 	go func() {
 		buf := make([]byte, u.MaxBufferSize)
 		defer func() {
 			logger.Debug(u.context.GetRuntimeContext(), "release udp read goroutine")
 		}()
 		for {
-			n, _, err := u.conn.ReadFromUDP(buf)
+			n, _, err := conn.ReadFrom(buf)
 			if err != nil {
 				// https://github.com/golang/go/issues/4373
 				// ignore net: errClosing error as it will occur during shutdown
 				if strings.HasSuffix(err.Error(), "use of closed network connection") {
 					return
 				}
-				logger.Error(u.context.GetRuntimeContext(), "UDP_SERVER_ALARM", "read record err", err)
+				logger.Error(u.context.GetRuntimeContext(), util.AlarmUDPServer, "read record err", err)
 				return
 			}
 			logs, err := u.decoder.Decode(buf[:n], nil, nil)
 			if err != nil {
-				logger.Error(u.context.GetRuntimeContext(), "UDP_SERVER_ALARM", "decode record err,some logs would be dropped", err)
+				logger.Error(u.context.GetRuntimeContext(), util.AlarmUDPServer, "decode record err,some logs would be dropped", err)
 			} else {
 				dispatchFunc(logs)
 			}
@@ -120,6 +135,9 @@ func (u *UDPServer) doStart(dispatchFunc func(logs []*protocol.Log)) error {
 func (u *UDPServer) Stop() error {
 	_ = u.conn.Close()
 	u.conn = nil
+	if u.socketPath != "" {
+		_ = os.Remove(u.socketPath)
+	}
 	logger.Infof(u.context.GetRuntimeContext(), "stop udp server, success")
 	return nil
 }
@@ -0,0 +1,65 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package udpserver
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+func TestUDPServerUnixgram(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "statsd.sock")
+	server := &UDPServer{
+		Format:        "statsd",
+		Address:       "unixgram://" + path,
+		MaxBufferSize: 65535,
+		UnixSocket:    &helper.UnixSocketConfig{Mode: "0620"},
+	}
+	_, err := server.Init(mock.NewEmptyContext("p", "l", "c"))
+	require.NoError(t, err)
+
+	received := make(chan []*protocol.Log, 1)
+	require.NoError(t, server.doStart(func(logs []*protocol.Log) {
+		received <- logs
+	}))
+	stat, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o620), stat.Mode().Perm())
+
+	conn, err := net.Dial("unixgram", path)
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("temperature:21.5|g"))
+	require.NoError(t, err)
+	_ = conn.Close()
+	logs := <-received
+	require.Equal(t, 1, len(logs))
+	assert.NotEmpty(t, logs[0].Contents)
+
+	require.NoError(t, server.Stop())
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,441 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accesslog
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const pluginName = "processor_access_log"
+
+const (
+	formatW3C      = "w3c"
+	formatCommon   = "common"
+	formatCombined = "combined"
+)
+
+// maxSources limits the sources whose #Fields directives are remembered.
+const maxSources = 10000
+
+const clfTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+var errNoFields = errors.New("no #Fields directive before the record")
+
+// ProcessorAccessLog parses the access logs of the web servers and the CDNs.
+//   - w3c: the W3C extended log format (ELF) used by IIS and the CDNs. The fields are declared by the
+//     #Fields directive, which is remembered per source identified by SourceIDKey, so that the files with
+//     different fields can be collected by the same config. The directive lines are dropped.
+//   - common: the Common Log Format of Apache and Nginx, `%h %l %u %t "%r" %>s %b`.
+//   - combined: the Combined Log Format, which appends `"%{Referer}i" "%{User-Agent}i"` to the common one.
+//
+// The common and combined formats are parsed without regex, and the keys are named after the Nginx
+// variables, such as remote_addr, time_local and body_bytes_sent.
+type ProcessorAccessLog struct {
+	SourceKey string
+	Format    string // [w3c, common, combined]
+	// The default W3C fields of the records before any #Fields directive, such as [date, time, c-ip].
+	Fields []string
+	// The key whose value identifies the source of the #Fields directives, __tag__:__path__ by default.
+	SourceIDKey string
+	// Convert the W3C field names to the snake case, such as cs(User-Agent) to cs_user_agent.
+	NormalizeKeys bool
+	// Set the log time by the date and time fields of W3C or the time_local of the common and combined formats.
+	ParseTime bool
+	// Keep the fields whose values are "-", which means empty in the access logs.
+	KeepDash               bool
+	NoKeyError             bool
+	KeepSource             bool
+	KeepSourceIfParseError bool
+
+	context pipeline.Context
+	fields  []field
+	sources map[string][]field
+}
+
+// field is a W3C field with the key to emit and the type to normalize the values.
+type field struct {
+	name string
+	key  string
+	typ  string
+}
+
+// Init called for init some system resources, like socket, mutex...
+func (p *ProcessorAccessLog) Init(context pipeline.Context) error {
+	p.context = context
+	switch p.Format {
+	case formatW3C, formatCommon, formatCombined:
+	default:
+		return fmt.Errorf("invalid format %q, you can only use \"w3c\", \"common\" or \"combined\" as Format for plugin %v", p.Format, pluginName)
+	}
+	if p.SourceIDKey == "" {
+		p.SourceIDKey = "__tag__:__path__"
+	}
+	p.fields = p.newFields(p.Fields)
+	p.sources = make(map[string][]field)
+	return nil
+}
+
+func (*ProcessorAccessLog) Description() string {
+	return "w3c extended, common and combined access log processor for logtail"
+}
+
+func (p *ProcessorAccessLog) ProcessLogs(logArray []*protocol.Log) []*protocol.Log {
+	result := logArray[:0]
+	for _, log := range logArray {
+		if p.processLog(log) {
+			result = append(result, log)
+		}
+	}
+	return result
+}
+
+// processLog returns false if the log is a W3C directive to be dropped.
+func (p *ProcessorAccessLog) processLog(log *protocol.Log) bool {
+	for idx, content := range log.Contents {
+		if content.Key != p.SourceKey {
+			continue
+		}
+		var err error
+		if p.Format == formatW3C {
+			line := strings.TrimRight(content.Value, "\r\n")
+			if strings.HasPrefix(line, "#") {
+				p.parseDirective(log, line)
+				return false
+			}
+			err = p.parseW3C(log, line)
+		} else {
+			err = p.parseCLF(log, content.Value)
+		}
+		if err != nil {
+			logger.Warningf(p.context.GetRuntimeContext(), util.AlarmProcessorAccessLog, "parse %v log error %v, log %v", p.Format, err, util.CutString(content.Value, 1024))
+		}
+		if !(p.KeepSource || (p.KeepSourceIfParseError && err != nil)) {
+			log.Contents = append(log.Contents[:idx], log.Contents[idx+1:]...)
+		}
+		return true
+	}
+	if p.NoKeyError {
+		logger.Warningf(p.context.GetRuntimeContext(), util.AlarmProcessorAccessLogFind, "cannot find key %v", p.SourceKey)
+	}
+	return true
+}
+
+func (p *ProcessorAccessLog) parseDirective(log *protocol.Log, line string) {
+	name, value, _ := strings.Cut(line[1:], ":")
+	if strings.TrimSpace(name) != "Fields" {
+		return
+	}
+	source := sourceOf(log, p.SourceIDKey)
+	if _, ok := p.sources[source]; !ok && len(p.sources) >= maxSources {
+		p.sources = make(map[string][]field)
+	}
+	p.sources[source] = p.newFields(strings.Fields(value))
+}
+
+func (p *ProcessorAccessLog) parseW3C(log *protocol.Log, line string) error {
+	fields, ok := p.sources[sourceOf(log, p.SourceIDKey)]
+	if !ok {
+		fields = p.fields
+	}
+	if len(fields) == 0 {
+		return errNoFields
+	}
+	values, err := splitW3C(line)
+	if err != nil {
+		return err
+	}
+	if len(values) != len(fields) {
+		return fmt.Errorf("the record has %d values but %d fields are declared", len(values), len(fields))
+	}
+	var date, clock string
+	for i, value := range values {
+		switch fields[i].name {
+		case "date":
+			date = value
+		case "time":
+			clock = value
+		}
+		if value == "-" && !p.KeepDash {
+			continue
+		}
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: fields[i].key, Value: normalize(fields[i].typ, value)})
+	}
+	if p.ParseTime && date != "" && clock != "" {
+		// the times of W3C are in UTC.
+		t, err := time.Parse("2006-01-02 15:04:05", date+" "+clock)
+		if err != nil {
+			return fmt.Errorf("invalid date %v or time %v", date, clock)
+		}
+		log.Time = uint32(t.Unix())
+	}
+	return nil
+}
+
+func (p *ProcessorAccessLog) parseCLF(log *protocol.Log, line string) error {
+	s := &scanner{line: strings.TrimRight(line, "\r\n")}
+	remoteAddr, ident, remoteUser := s.token(), s.token(), s.token()
+	timeLocal, err := s.bracketed()
+	if err != nil {
+		return err
+	}
+	request, err := s.quoted()
+	if err != nil {
+		return err
+	}
+	status, bodyBytesSent := s.token(), s.token()
+	if bodyBytesSent == "" {
+		return errors.New("missing status or body_bytes_sent")
+	}
+	var referer, userAgent string
+	if p.Format == formatCombined {
+		if referer, err = s.quoted(); err != nil {
+			return err
+		}
+		if userAgent, err = s.quoted(); err != nil {
+			return err
+		}
+	}
+	var logTime time.Time
+	if p.ParseTime {
+		if logTime, err = time.Parse(clfTimeLayout, timeLocal); err != nil {
+			return fmt.Errorf("invalid time_local %v", timeLocal)
+		}
+	}
+
+	p.add(log, "remote_addr", remoteAddr)
+	p.add(log, "ident", ident)
+	p.add(log, "remote_user", remoteUser)
+	p.add(log, "time_local", timeLocal)
+	p.add(log, "request", request)
+	if parts := strings.Split(request, " "); len(parts) == 3 {
+		p.add(log, "request_method", parts[0])
+		p.add(log, "request_uri", parts[1])
+		p.add(log, "server_protocol", parts[2])
+	}
+	p.add(log, "status", status)
+	p.add(log, "body_bytes_sent", bodyBytesSent)
+	if p.Format == formatCombined {
+		p.add(log, "http_referer", referer)
+		p.add(log, "http_user_agent", userAgent)
+		// the customized formats usually append more fields to the combined one.
+		if extra := strings.TrimSpace(s.line[s.pos:]); extra != "" {
+			p.add(log, "extra", extra)
+		}
+	}
+	if p.ParseTime {
+		log.Time = uint32(logTime.Unix())
+	}
+	return nil
+}
+
+func (p *ProcessorAccessLog) add(log *protocol.Log, key, value string) {
+	if value == "-" && !p.KeepDash {
+		return
+	}
+	log.Contents = append(log.Contents, &protocol.Log_Content{Key: key, Value: value})
+}
+
+func (p *ProcessorAccessLog) newFields(names []string) []field {
+	fields := make([]field, 0, len(names))
+	for _, name := range names {
+		f := field{name: name, key: name, typ: w3cTypes[strings.ToLower(name)]}
+		if p.NormalizeKeys {
+			f.key = normalizeKey(name)
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+const (
+	typeInt   = "int"
+	typeFloat = "float"
+)
+
+// w3cTypes are the types of the numeric W3C fields of IIS and the CDNs.
+var w3cTypes = map[string]string{
+	"sc-status":          typeInt,
+	"sc-substatus":       typeInt,
+	"sc-win32-status":    typeInt,
+	"sc-bytes":           typeInt,
+	"cs-bytes":           typeInt,
+	"s-port":             typeInt,
+	"c-port":             typeInt,
+	"sc-content-len":     typeInt,
+	"sc-range-start":     typeInt,
+	"sc-range-end":       typeInt,
+	"time-taken":         typeFloat,
+	"time-to-first-byte": typeFloat,
+}
+
+// normalize converts the numeric values to the canonical form, such as 0200 to 200 and 1.500 to 1.5,
+// the values are kept as they are if they are not numbers.
+func normalize(typ, value string) string {
+	switch typ {
+	case typeInt:
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return strconv.FormatInt(i, 10)
+		}
+	case typeFloat:
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return strconv.FormatFloat(f, 'f', -1, 64)
+		}
+	}
+	return value
+}
+
+// normalizeKey converts the field name to the snake case, such as cs(User-Agent) to cs_user_agent.
+func normalizeKey(name string) string {
+	var b strings.Builder
+	underscore := false
+	for _, c := range strings.ToLower(name) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			if underscore && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			underscore = false
+			b.WriteRune(c)
+		} else {
+			underscore = true
+		}
+	}
+	return b.String()
+}
+
+// splitW3C splits the W3C record by the spaces or tabs. The quoted values may contain the separators,
+// and the quotes inside are doubled.
+func splitW3C(line string) ([]string, error) {
+	var values []string
+	for i := 0; i < len(line); {
+		switch line[i] {
+		case ' ', '\t':
+			i++
+		case '"':
+			var b strings.Builder
+			i++
+			for {
+				end := strings.IndexByte(line[i:], '"')
+				if end < 0 {
+					return nil, errors.New("unterminated quoted value")
+				}
+				b.WriteString(line[i : i+end])
+				i += end + 1
+				if i < len(line) && line[i] == '"' {
+					b.WriteByte('"')
+					i++
+					continue
+				}
+				break
+			}
+			values = append(values, b.String())
+		default:
+			end := strings.IndexAny(line[i:], " \t")
+			if end < 0 {
+				end = len(line) - i
+			}
+			values = append(values, line[i:i+end])
+			i += end
+		}
+	}
+	return values, nil
+}
+
+// scanner reads the tokens of the common and combined log formats.
+type scanner struct {
+	line string
+	pos  int
+}
+
+func (s *scanner) skipSpaces() {
+	for s.pos < len(s.line) && s.line[s.pos] == ' ' {
+		s.pos++
+	}
+}
+
+func (s *scanner) token() string {
+	s.skipSpaces()
+	start := s.pos
+	for s.pos < len(s.line) && s.line[s.pos] != ' ' {
+		s.pos++
+	}
+	return s.line[start:s.pos]
+}
+
+func (s *scanner) bracketed() (string, error) {
+	s.skipSpaces()
+	if s.pos >= len(s.line) || s.line[s.pos] != '[' {
+		return "", fmt.Errorf("expect [ at %d", s.pos)
+	}
+	end := strings.IndexByte(s.line[s.pos:], ']')
+	if end < 0 {
+		return "", errors.New("unterminated [")
+	}
+	value := s.line[s.pos+1 : s.pos+end]
+	s.pos += end + 1
+	return value, nil
+}
+
+// quoted reads a quoted string, in which \" and \\ are unescaped as Apache escapes them.
+func (s *scanner) quoted() (string, error) {
+	s.skipSpaces()
+	if s.pos >= len(s.line) || s.line[s.pos] != '"' {
+		return "", fmt.Errorf("expect \" at %d", s.pos)
+	}
+	s.pos++
+	var b strings.Builder
+	for s.pos < len(s.line) {
+		c := s.line[s.pos]
+		switch {
+		case c == '\\' && s.pos+1 < len(s.line) && (s.line[s.pos+1] == '"' || s.line[s.pos+1] == '\\'):
+			b.WriteByte(s.line[s.pos+1])
+			s.pos += 2
+		case c == '"':
+			s.pos++
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+			s.pos++
+		}
+	}
+	return "", errors.New("unterminated \"")
+}
+
+func sourceOf(log *protocol.Log, key string) string {
+	for _, content := range log.Contents {
+		if content.Key == key {
+			return content.Value
+		}
+	}
+	return ""
+}
+
+func init() {
+	pipeline.Processors[pluginName] = func() pipeline.Processor {
+		return &ProcessorAccessLog{
+			SourceKey:              "content",
+			Format:                 formatW3C,
+			KeepSourceIfParseError: true,
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accesslog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+func newProcessor(t *testing.T, format string) *ProcessorAccessLog {
+	processor := pipeline.Processors[pluginName]().(*ProcessorAccessLog)
+	processor.Format = format
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+	return processor
+}
+
+func newLog(path, content string) *protocol.Log {
+	return &protocol.Log{Contents: []*protocol.Log_Content{
+		{Key: "__tag__:__path__", Value: path},
+		{Key: "content", Value: content},
+	}}
+}
+
+func contents(log *protocol.Log) map[string]string {
+	result := make(map[string]string)
+	for _, content := range log.Contents {
+		result[content.Key] = content.Value
+	}
+	return result
+}
+
+func TestW3C(t *testing.T) {
+	processor := newProcessor(t, formatW3C)
+	processor.NormalizeKeys = true
+	processor.ParseTime = true
+
+	logs := processor.ProcessLogs([]*protocol.Log{
+		newLog("iis.log", "#Software: Microsoft Internet Information Services 10.0"),
+		newLog("iis.log", "#Fields: date time c-ip cs-method cs-uri-stem sc-status time-taken cs(User-Agent)"),
+		newLog("cdn.log", "#Fields: date time x-edge-location sc-bytes cs-uri-query"),
+		newLog("iis.log", "2023-05-01 08:00:01 10.0.0.1 GET /index.html 0200 15 Mozilla/5.0+(Windows)"),
+		newLog("cdn.log", "2023-05-01\t08:00:02\tSFO5\t1024\t-"),
+		newLog("iis.log", "2023-05-01 08:00:03 10.0.0.1 GET"),
+		newLog("other.log", "2023-05-01 08:00:04"),
+	})
+	require.Len(t, logs, 4)
+	assert.Equal(t, map[string]string{
+		"__tag__:__path__": "iis.log",
+		"date":             "2023-05-01",
+		"time":             "08:00:01",
+		"c_ip":             "10.0.0.1",
+		"cs_method":        "GET",
+		"cs_uri_stem":      "/index.html",
+		"sc_status":        "200",
+		"time_taken":       "15",
+		"cs_user_agent":    "Mozilla/5.0+(Windows)",
+	}, contents(logs[0]))
+	assert.Equal(t, uint32(time.Date(2023, 5, 1, 8, 0, 1, 0, time.UTC).Unix()), logs[0].Time)
+	assert.Equal(t, map[string]string{
+		"__tag__:__path__": "cdn.log",
+		"date":             "2023-05-01",
+		"time":             "08:00:02",
+		"x_edge_location":  "SFO5",
+		"sc_bytes":         "1024",
+	}, contents(logs[1]))
+	// the invalid records and the records without #Fields keep the source.
+	assert.Equal(t, "2023-05-01 08:00:03 10.0.0.1 GET", contents(logs[2])["content"])
+	assert.Equal(t, "2023-05-01 08:00:04", contents(logs[3])["content"])
+}
+
+func TestW3CDefaultFields(t *testing.T) {
+	processor := newProcessor(t, formatW3C)
+	processor.Fields = []string{"c-ip", "cs(Referer)"}
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+
+	logs := processor.ProcessLogs([]*protocol.Log{newLog("a.log", `10.0.0.1 "http://a.com/?q=""x y"""`)})
+	assert.Equal(t, map[string]string{
+		"__tag__:__path__": "a.log",
+		"c-ip":             "10.0.0.1",
+		"cs(Referer)":      `http://a.com/?q="x y"`,
+	}, contents(logs[0]))
+}
+
+func TestCombined(t *testing.T) {
+	processor := newProcessor(t, formatCombined)
+	processor.ParseTime = true
+
+	logs := processor.ProcessLogs([]*protocol.Log{
+		newLog("access.log", `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08 \"compatible\"" "1.2.3.4"`),
+		newLog("access.log", `127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /a HTTP/1.0" 200 -`),
+	})
+	assert.Equal(t, map[string]string{
+		"__tag__:__path__": "access.log",
+		"remote_addr":      "127.0.0.1",
+		"remote_user":      "frank",
+		"time_local":       "10/Oct/2000:13:55:36 -0700",
+		"request":          "GET /apache_pb.gif HTTP/1.0",
+		"request_method":   "GET",
+		"request_uri":      "/apache_pb.gif",
+		"server_protocol":  "HTTP/1.0",
+		"status":           "200",
+		"body_bytes_sent":  "2326",
+		"http_referer":     "http://www.example.com/start.html",
+		"http_user_agent":  `Mozilla/4.08 "compatible"`,
+		"extra":            `"1.2.3.4"`,
+	}, contents(logs[0]))
+	assert.Equal(t, uint32(971211336), logs[0].Time)
+	// the common records miss the referer and the user agent of the combined format.
+	assert.Equal(t, []*protocol.Log_Content{
+		{Key: "__tag__:__path__", Value: "access.log"},
+		{Key: "content", Value: `127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /a HTTP/1.0" 200 -`},
+	}, logs[1].Contents)
+
+	processor = newProcessor(t, formatCommon)
+	processor.KeepDash = true
+	logs = processor.ProcessLogs([]*protocol.Log{
+		newLog("access.log", `127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /a HTTP/1.0" 200 -`),
+	})
+	result := contents(logs[0])
+	assert.Equal(t, "-", result["remote_user"])
+	assert.Equal(t, "-", result["body_bytes_sent"])
+	assert.NotContains(t, result, "content")
+}
+
+func TestInvalidFormat(t *testing.T) {
+	processor := pipeline.Processors[pluginName]().(*ProcessorAccessLog)
+	processor.Format = "elf"
+	assert.Error(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+}
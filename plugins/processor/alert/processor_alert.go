@@ -0,0 +1,253 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alert
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const pluginName = "processor_alert"
+
+const defaultSeverity = "warning"
+
+// Rule fires an alert when more than Threshold logs matching all Filters arrive within the sliding window of
+// WindowSec seconds. The logs are counted per the values of GroupKeys, such as one alert per app.
+type Rule struct {
+	Name      string
+	Filters   map[string]string
+	GroupKeys []string
+	WindowSec int
+	Threshold int
+	// The alert of a group is not fired again within CooldownSec seconds, WindowSec by default.
+	CooldownSec int
+	Severity    string
+	Message     string
+
+	filters map[string]*regexp.Regexp
+	groups  map[string]*group
+}
+
+// group counts the logs of a rule per second in the window.
+type group struct {
+	values    []string
+	seconds   []int64
+	counts    []int
+	total     int
+	lastFired time.Time
+}
+
+// ProcessorAlert evaluates the alert rules on the logs at the edge, and appends the alert logs to the pipeline
+// when the rules fire, so that the alerts are sent by flusher_alert even if the backend is unreachable.
+// The windows slide by the arrival time of the logs, and the raw logs are kept as they are.
+type ProcessorAlert struct {
+	Rules []*Rule
+	// Max group count of each rule, the logs of new groups are ignored when exceeded.
+	MaxGroups int
+
+	context   pipeline.Context
+	nowFunc   func() time.Time
+	overLimit bool
+}
+
+// Init called for init some system resources, like socket, mutex...
+func (p *ProcessorAlert) Init(context pipeline.Context) error {
+	p.context = context
+	if len(p.Rules) == 0 {
+		return fmt.Errorf("must specify Rules for plugin %v", pluginName)
+	}
+	for _, r := range p.Rules {
+		if r.Name == "" {
+			return fmt.Errorf("must specify Name of rules for plugin %v", pluginName)
+		}
+		if r.WindowSec <= 0 {
+			return fmt.Errorf("invalid WindowSec %v of rule %v for plugin %v", r.WindowSec, r.Name, pluginName)
+		}
+		if r.Threshold < 0 {
+			return fmt.Errorf("invalid Threshold %v of rule %v for plugin %v", r.Threshold, r.Name, pluginName)
+		}
+		if r.CooldownSec <= 0 {
+			r.CooldownSec = r.WindowSec
+		}
+		if r.Severity == "" {
+			r.Severity = defaultSeverity
+		}
+		r.filters = make(map[string]*regexp.Regexp, len(r.Filters))
+		for key, pattern := range r.Filters {
+			reg, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid filter %v of rule %v: %v", pattern, r.Name, err)
+			}
+			r.filters[key] = reg
+		}
+		r.groups = make(map[string]*group)
+	}
+	if p.nowFunc == nil {
+		p.nowFunc = time.Now
+	}
+	return nil
+}
+
+func (*ProcessorAlert) Description() string {
+	return "alert processor for logtail, which fires alerts when the logs matching the rules exceed the thresholds"
+}
+
+func (p *ProcessorAlert) ProcessLogs(logArray []*protocol.Log) []*protocol.Log {
+	now := p.nowFunc()
+	var alerts []*protocol.Log
+	for _, r := range p.Rules {
+		fired := make(map[*group]bool)
+		for _, log := range logArray {
+			if g := p.count(r, log, now); g != nil {
+				fired[g] = true
+			}
+		}
+		// the groups fired are evaluated in the order of the values for the stable output
+		groups := make([]*group, 0, len(fired))
+		for g := range fired {
+			groups = append(groups, g)
+		}
+		sort.Slice(groups, func(i, j int) bool {
+			return strings.Join(groups[i].values, "\x00") < strings.Join(groups[j].values, "\x00")
+		})
+		for _, g := range groups {
+			if g.total > r.Threshold && now.Sub(g.lastFired) >= time.Duration(r.CooldownSec)*time.Second {
+				g.lastFired = now
+				alerts = append(alerts, newAlertLog(r, g, now))
+			}
+		}
+	}
+	return append(logArray, alerts...)
+}
+
+// count adds the log to its group if it matches the rule, and returns the group.
+func (p *ProcessorAlert) count(r *Rule, log *protocol.Log, now time.Time) *group {
+	if isAlertLog(log) {
+		return nil
+	}
+	matchedFilters := 0
+	values := make([]string, len(r.GroupKeys))
+	for _, cont := range log.Contents {
+		if reg, ok := r.filters[cont.Key]; ok {
+			if !reg.MatchString(cont.Value) {
+				return nil
+			}
+			matchedFilters++
+		}
+		for i, key := range r.GroupKeys {
+			if cont.Key == key {
+				values[i] = cont.Value
+			}
+		}
+	}
+	if matchedFilters != len(r.filters) {
+		return nil
+	}
+	key := strings.Join(values, "\x00")
+	g, ok := r.groups[key]
+	if !ok {
+		if p.MaxGroups > 0 && len(r.groups) >= p.MaxGroups {
+			r.removeIdleGroups(now)
+		}
+		if p.MaxGroups > 0 && len(r.groups) >= p.MaxGroups {
+			if !p.overLimit {
+				p.overLimit = true
+				logger.Warning(p.context.GetRuntimeContext(), util.AlarmAlertProcessor, "group count exceeds", p.MaxGroups, "rule", r.Name)
+			}
+			return nil
+		}
+		g = &group{values: values}
+		r.groups[key] = g
+	}
+	g.add(now.Unix(), int64(r.WindowSec))
+	return g
+}
+
+// add counts a log at the second and slides the window.
+func (g *group) add(second, window int64) {
+	g.slide(second, window)
+	if n := len(g.seconds); n > 0 && g.seconds[n-1] == second {
+		g.counts[n-1]++
+	} else {
+		g.seconds = append(g.seconds, second)
+		g.counts = append(g.counts, 1)
+	}
+	g.total++
+}
+
+// slide removes the counts out of the window ending at the second.
+func (g *group) slide(second, window int64) {
+	expired := 0
+	for expired < len(g.seconds) && g.seconds[expired] <= second-window {
+		g.total -= g.counts[expired]
+		expired++
+	}
+	g.seconds = g.seconds[expired:]
+	g.counts = g.counts[expired:]
+}
+
+// removeIdleGroups removes the groups without any log in the window and out of the cooldown.
+func (r *Rule) removeIdleGroups(now time.Time) {
+	for key, g := range r.groups {
+		g.slide(now.Unix(), int64(r.WindowSec))
+		if g.total == 0 && now.Sub(g.lastFired) >= time.Duration(r.CooldownSec)*time.Second {
+			delete(r.groups, key)
+		}
+	}
+}
+
+func newAlertLog(r *Rule, g *group, now time.Time) *protocol.Log {
+	log := &protocol.Log{Time: uint32(now.Unix())}
+	log.Contents = append(log.Contents,
+		&protocol.Log_Content{Key: util.AlertNameKey, Value: r.Name},
+		&protocol.Log_Content{Key: util.AlertSeverityKey, Value: r.Severity},
+		&protocol.Log_Content{Key: util.AlertCountKey, Value: strconv.Itoa(g.total)},
+		&protocol.Log_Content{Key: util.AlertThresholdKey, Value: strconv.Itoa(r.Threshold)},
+		&protocol.Log_Content{Key: util.AlertWindowKey, Value: strconv.Itoa(r.WindowSec)},
+	)
+	if r.Message != "" {
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: util.AlertMessageKey, Value: r.Message})
+	}
+	for i, key := range r.GroupKeys {
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: key, Value: g.values[i]})
+	}
+	return log
+}
+
+func isAlertLog(log *protocol.Log) bool {
+	for _, cont := range log.Contents {
+		if cont.Key == util.AlertNameKey {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	pipeline.Processors[pluginName] = func() pipeline.Processor {
+		return &ProcessorAlert{
+			MaxGroups: 10000,
+		}
+	}
+}
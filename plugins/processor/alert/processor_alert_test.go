@@ -0,0 +1,117 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+func newLog(app, level string) *protocol.Log {
+	return &protocol.Log{Contents: []*protocol.Log_Content{
+		{Key: "app", Value: app},
+		{Key: "level", Value: level},
+	}}
+}
+
+func getContent(log *protocol.Log, key string) string {
+	for _, cont := range log.Contents {
+		if cont.Key == key {
+			return cont.Value
+		}
+	}
+	return ""
+}
+
+func TestProcessorAlert(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	p := &ProcessorAlert{
+		Rules: []*Rule{{
+			Name:      "too_many_errors",
+			Filters:   map[string]string{"level": "^ERROR$"},
+			GroupKeys: []string{"app"},
+			WindowSec: 60,
+			Threshold: 2,
+			Message:   "more than 2 errors per minute",
+		}},
+		MaxGroups: 10000,
+		nowFunc:   func() time.Time { return now },
+	}
+	require.NoError(t, p.Init(mock.NewEmptyContext("p", "l", "c")))
+
+	logs := p.ProcessLogs([]*protocol.Log{newLog("x", "ERROR"), newLog("x", "INFO"), newLog("y", "ERROR")})
+	assert.Len(t, logs, 3)
+
+	now = now.Add(30 * time.Second)
+	logs = p.ProcessLogs([]*protocol.Log{newLog("x", "ERROR"), newLog("x", "ERROR")})
+	require.Len(t, logs, 3)
+	alert := logs[2]
+	assert.Equal(t, "too_many_errors", getContent(alert, util.AlertNameKey))
+	assert.Equal(t, "warning", getContent(alert, util.AlertSeverityKey))
+	assert.Equal(t, "3", getContent(alert, util.AlertCountKey))
+	assert.Equal(t, "2", getContent(alert, util.AlertThresholdKey))
+	assert.Equal(t, "x", getContent(alert, "app"))
+	assert.Equal(t, uint32(now.Unix()), alert.Time)
+
+	// the alert logs are not counted, and the alert is not fired again in the cooldown
+	now = now.Add(10 * time.Second)
+	logs = p.ProcessLogs([]*protocol.Log{newLog("x", "ERROR"), alert})
+	assert.Len(t, logs, 2)
+
+	// fired again after the cooldown, the errors out of the window are not counted
+	now = now.Add(55 * time.Second)
+	logs = p.ProcessLogs([]*protocol.Log{newLog("x", "ERROR"), newLog("x", "ERROR")})
+	require.Len(t, logs, 3)
+	assert.Equal(t, "3", getContent(logs[2], util.AlertCountKey))
+
+	now = now.Add(120 * time.Second)
+	logs = p.ProcessLogs([]*protocol.Log{newLog("x", "ERROR")})
+	assert.Len(t, logs, 1)
+}
+
+func TestProcessorAlertMaxGroups(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	p := &ProcessorAlert{
+		Rules:     []*Rule{{Name: "errors", GroupKeys: []string{"app"}, WindowSec: 10, Threshold: 0}},
+		MaxGroups: 1,
+		nowFunc:   func() time.Time { return now },
+	}
+	require.NoError(t, p.Init(mock.NewEmptyContext("p", "l", "c")))
+	assert.Len(t, p.ProcessLogs([]*protocol.Log{newLog("x", "ERROR"), newLog("y", "ERROR")}), 3)
+
+	// the idle group is removed for the new group
+	now = now.Add(20 * time.Second)
+	logs := p.ProcessLogs([]*protocol.Log{newLog("y", "ERROR")})
+	require.Len(t, logs, 2)
+	assert.Equal(t, "y", getContent(logs[1], "app"))
+}
+
+func TestProcessorAlertInit(t *testing.T) {
+	ctx := mock.NewEmptyContext("p", "l", "c")
+	assert.Error(t, (&ProcessorAlert{}).Init(ctx))
+	assert.Error(t, (&ProcessorAlert{Rules: []*Rule{{Name: "r"}}}).Init(ctx))
+	assert.Error(t, (&ProcessorAlert{Rules: []*Rule{{Name: "r", WindowSec: 60, Filters: map[string]string{"a": "("}}}}).Init(ctx))
+	p := &ProcessorAlert{Rules: []*Rule{{Name: "r", WindowSec: 60}}}
+	require.NoError(t, p.Init(ctx))
+	assert.Equal(t, 60, p.Rules[0].CooldownSec)
+	assert.Equal(t, "warning", p.Rules[0].Severity)
+}
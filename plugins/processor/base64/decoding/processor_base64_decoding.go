@@ -20,6 +20,7 @@ import (
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 type ProcessorBase64Decoding struct {
@@ -54,14 +55,14 @@ func (p *ProcessorBase64Decoding) ProcessLogs(logArray []*protocol.Log) []*proto
 					}
 					log.Contents = append(log.Contents, newContent)
 				} else if p.DecodeError {
-					logger.Warning(p.context.GetRuntimeContext(), "BASE64_D_ALARM", "decode base64 error", err)
+					logger.Warning(p.context.GetRuntimeContext(), util.AlarmBase64D, "decode base64 error", err)
 				}
 				found = true
 				break
 			}
 		}
 		if !found && p.NoKeyError {
-			logger.Warning(p.context.GetRuntimeContext(), "BASE64_D_FIND_ALARM", "cannot find key", p.SourceKey)
+			logger.Warning(p.context.GetRuntimeContext(), util.AlarmBase64DFind, "cannot find key", p.SourceKey)
 		}
 	}
 	return logArray
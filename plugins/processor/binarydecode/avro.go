@@ -0,0 +1,347 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package binarydecode
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+// confluentMagicByte is the first byte of the payloads serialized with the Confluent Schema Registry,
+// which is followed by the 4 bytes big-endian schema id.
+const confluentMagicByte = 0
+
+// avroDecoder decodes the Avro payloads by the inline schema or the schemas in the schema registry.
+type avroDecoder struct {
+	schema   *avroSchema
+	registry *schemaRegistry
+}
+
+func newAvroDecoder(schema string, registry *schemaRegistry) (*avroDecoder, error) {
+	if schema == "" && registry.url == "" {
+		return nil, fmt.Errorf("must specify AvroSchema or SchemaRegistryURL")
+	}
+	d := &avroDecoder{registry: registry}
+	if schema != "" {
+		var err error
+		if d.schema, err = newAvroSchema(schema); err != nil {
+			return nil, err
+		}
+	}
+	registry.url = strings.TrimSuffix(registry.url, "/")
+	registry.schemas = make(map[uint32]*avroSchema)
+	registry.failures = make(map[uint32]*schemaFailure)
+	registry.fetching = make(map[uint32]*schemaFetch)
+	registry.now = time.Now
+	registry.client = &http.Client{Timeout: registry.timeout}
+	return d, nil
+}
+
+func (d *avroDecoder) decode(data []byte) (interface{}, error) {
+	if d.registry.url != "" && len(data) >= 5 && data[0] == confluentMagicByte {
+		schema, err := d.registry.schema(binary.BigEndian.Uint32(data[1:5]))
+		if err != nil {
+			return nil, err
+		}
+		return schema.decode(data[5:])
+	}
+	if d.schema == nil {
+		return nil, errors.New("the payload does not start with the schema registry header")
+	}
+	return d.schema.decode(data)
+}
+
+// avroSchema decodes the payloads with the codec, and simplifies the decoded values by the schema.
+type avroSchema struct {
+	codec *goavro.Codec
+	// names are the named types indexed by both the full names and the short names.
+	names map[string]map[string]interface{}
+	root  interface{}
+}
+
+func newAvroSchema(schema string) (*avroSchema, error) {
+	codec, err := goavro.NewCodec(schema)
+	if err != nil {
+		return nil, err
+	}
+	s := &avroSchema{codec: codec, names: make(map[string]map[string]interface{})}
+	if err = json.Unmarshal([]byte(schema), &s.root); err != nil {
+		// the schema of a primitive type could be a bare name.
+		s.root = strings.Trim(schema, "\" ")
+	}
+	s.register(s.root, "")
+	return s, nil
+}
+
+func (s *avroSchema) decode(data []byte) (interface{}, error) {
+	native, rest, err := s.codec.NativeFromBinary(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("%d bytes remain after decoding", len(rest))
+	}
+	return s.simplify(s.root, native), nil
+}
+
+// register indexes the named types in the schema.
+func (s *avroSchema) register(node interface{}, namespace string) {
+	switch n := node.(type) {
+	case []interface{}:
+		for _, item := range n {
+			s.register(item, namespace)
+		}
+	case map[string]interface{}:
+		if ns, ok := n["namespace"].(string); ok {
+			namespace = ns
+		}
+		typ := n["type"]
+		switch typ {
+		case "record", "error", "enum", "fixed":
+			if name, ok := n["name"].(string); ok {
+				fullName := name
+				if i := strings.LastIndexByte(name, '.'); i >= 0 {
+					namespace, name = name[:i], name[i+1:]
+				} else if namespace != "" {
+					fullName = namespace + "." + name
+				}
+				n["fullname"] = fullName
+				s.names[fullName] = n
+				s.names[name] = n
+			}
+		}
+		switch typ {
+		case "record", "error":
+			fields, _ := n["fields"].([]interface{})
+			for _, field := range fields {
+				if f, ok := field.(map[string]interface{}); ok {
+					s.register(f["type"], namespace)
+				}
+			}
+		case "array":
+			s.register(n["items"], namespace)
+		case "map":
+			s.register(n["values"], namespace)
+		default:
+			s.register(typ, namespace)
+		}
+	}
+}
+
+// simplify unwraps the union values, which are decoded as the single key maps by goavro, and formats
+// the decimals by the scales.
+func (s *avroSchema) simplify(node interface{}, value interface{}) interface{} {
+	switch n := node.(type) {
+	case string:
+		if named, ok := s.names[n]; ok {
+			return s.simplify(named, value)
+		}
+	case []interface{}:
+		union, ok := value.(map[string]interface{})
+		if !ok || len(union) != 1 {
+			return value
+		}
+		for branch, v := range union {
+			return s.simplify(s.unionMember(n, branch), v)
+		}
+	case map[string]interface{}:
+		switch typ := n["type"].(type) {
+		case string:
+			switch typ {
+			case "record", "error":
+				record, ok := value.(map[string]interface{})
+				if !ok {
+					return value
+				}
+				fields, _ := n["fields"].([]interface{})
+				for _, field := range fields {
+					if f, ok := field.(map[string]interface{}); ok {
+						name, _ := f["name"].(string)
+						if v, exists := record[name]; exists {
+							record[name] = s.simplify(f["type"], v)
+						}
+					}
+				}
+				return record
+			case "array":
+				if array, ok := value.([]interface{}); ok {
+					for i := range array {
+						array[i] = s.simplify(n["items"], array[i])
+					}
+				}
+				return value
+			case "map":
+				if m, ok := value.(map[string]interface{}); ok {
+					for key, v := range m {
+						m[key] = s.simplify(n["values"], v)
+					}
+				}
+				return value
+			}
+			if rat, ok := value.(*big.Rat); ok {
+				scale, _ := n["scale"].(float64)
+				return json.Number(rat.FloatString(int(scale)))
+			}
+			if n["logicalType"] == "date" {
+				if t, ok := value.(time.Time); ok {
+					return t.Format("2006-01-02")
+				}
+			}
+			if named, ok := s.names[typ]; ok {
+				return s.simplify(named, value)
+			}
+		default:
+			return s.simplify(typ, value)
+		}
+	}
+	return value
+}
+
+// unionMember finds the member of the union by the branch name of goavro, such as "string", "array",
+// the full name of a named type, or "long.timestamp-millis" for the logical types.
+func (s *avroSchema) unionMember(members []interface{}, branch string) interface{} {
+	for _, member := range members {
+		switch m := member.(type) {
+		case string:
+			if named, ok := s.names[m]; ok && named["fullname"] == branch {
+				return named
+			}
+		case map[string]interface{}:
+			typ, _ := m["type"].(string)
+			if logicalType, ok := m["logicalType"].(string); ok && typ+"."+logicalType == branch {
+				return m
+			}
+			if m["fullname"] == branch || ((typ == "array" || typ == "map") && typ == branch) {
+				return m
+			}
+		}
+	}
+	return branch
+}
+
+// schemaRegistry fetches the Avro schemas from the Confluent Schema Registry and caches them by ids.
+// The schemas are fetched out of the lock, and the concurrent lookups of the same id share one request.
+// The failures are cached for errorTTL, so that the payloads of a missing schema don't flood the registry.
+type schemaRegistry struct {
+	url      string
+	username string
+	password string
+	timeout  time.Duration
+	errorTTL time.Duration
+
+	client   *http.Client
+	mu       sync.Mutex
+	schemas  map[uint32]*avroSchema
+	failures map[uint32]*schemaFailure
+	fetching map[uint32]*schemaFetch
+	now      func() time.Time
+}
+
+// schemaFailure is the cached error of fetching a schema.
+type schemaFailure struct {
+	err     error
+	expires time.Time
+}
+
+// schemaFetch is the request of a schema in flight, and done is closed when it finishes.
+type schemaFetch struct {
+	done   chan struct{}
+	schema *avroSchema
+	err    error
+}
+
+func (r *schemaRegistry) schema(id uint32) (*avroSchema, error) {
+	r.mu.Lock()
+	if schema, ok := r.schemas[id]; ok {
+		r.mu.Unlock()
+		return schema, nil
+	}
+	if failure, ok := r.failures[id]; ok {
+		if r.now().Before(failure.expires) {
+			r.mu.Unlock()
+			return nil, failure.err
+		}
+		delete(r.failures, id)
+	}
+	if fetch, ok := r.fetching[id]; ok {
+		r.mu.Unlock()
+		<-fetch.done
+		return fetch.schema, fetch.err
+	}
+	fetch := &schemaFetch{done: make(chan struct{})}
+	r.fetching[id] = fetch
+	r.mu.Unlock()
+
+	fetch.schema, fetch.err = r.fetch(id)
+
+	r.mu.Lock()
+	delete(r.fetching, id)
+	if fetch.err != nil {
+		r.failures[id] = &schemaFailure{err: fetch.err, expires: r.now().Add(r.errorTTL)}
+	} else {
+		r.schemas[id] = fetch.schema
+	}
+	r.mu.Unlock()
+	close(fetch.done)
+	return fetch.schema, fetch.err
+}
+
+func (r *schemaRegistry) fetch(id uint32) (*avroSchema, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/schemas/ids/%d", r.url, id), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch schema %d error, status: %v, body: %s", id, resp.Status, body)
+	}
+	var result struct {
+		Schema     string `json:"schema"`
+		SchemaType string `json:"schemaType"`
+	}
+	if err = json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("invalid response of schema %d: %v", id, err)
+	}
+	if result.SchemaType != "" && result.SchemaType != "AVRO" {
+		return nil, fmt.Errorf("schema %d is %v rather than AVRO", id, result.SchemaType)
+	}
+	schema, err := newAvroSchema(result.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema %d: %v", id, err)
+	}
+	return schema, nil
+}
@@ -0,0 +1,237 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package binarydecode
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const pluginName = "processor_binary_decode"
+
+const (
+	formatProtobuf = "protobuf"
+	formatAvro     = "avro"
+)
+
+// decoder decodes a binary payload into the structured value, which consists of map[string]interface{},
+// []interface{} and the scalar values.
+type decoder interface {
+	decode(data []byte) (interface{}, error)
+}
+
+// ProcessorBinaryDecode decodes the protobuf or Avro payloads, such as the messages of the binary Kafka
+// topics, into the structured contents.
+// The protobuf messages are decoded by the descriptors in DescriptorSetFile, which is generated by
+// `protoc --include_imports --descriptor_set_out`.
+// The Avro payloads are decoded by AvroSchema, or by the schemas in the Confluent Schema Registry when
+// the payloads start with the magic byte 0 and the 4 bytes schema id.
+// In the v1 pipeline, the value of SourceKey is decoded and expanded into the contents like processor_json.
+// In the v2 pipeline, the ByteArray events are decoded and replaced by the JSON encoded ones.
+type ProcessorBinaryDecode struct {
+	SourceKey              string
+	Format                 string // [protobuf, avro]
+	DescriptorSetFile      string // The FileDescriptorSet file of the protobuf messages.
+	MessageType            string // The full name of the protobuf message, such as foo.bar.Message.
+	AvroSchema             string // The Avro schema of the payloads without the schema registry header.
+	SchemaRegistryURL      string // The url of the Confluent Schema Registry, such as http://localhost:8081.
+	SchemaRegistryUsername string
+	SchemaRegistryPassword string
+	SchemaRegistryTimeout  int // The timeout in seconds to fetch a schema, 5 by default.
+	// The time in seconds the failure of fetching a schema is cached before retrying, 30 by default.
+	SchemaRegistryErrorTTL int
+	NoKeyError             bool
+	ExpandDepth            int    // 0 means no limit, 1 means the first level.
+	ExpandConnector        string // The connector of the expanded keys, "_" by default.
+	Prefix                 string // The prefix of the expanded keys.
+	KeepSource             bool
+	KeepSourceIfParseError bool
+
+	context pipeline.Context
+	decoder decoder
+}
+
+// Init called for init some system resources, like socket, mutex...
+func (p *ProcessorBinaryDecode) Init(context pipeline.Context) error {
+	p.context = context
+	var err error
+	switch p.Format {
+	case formatProtobuf:
+		p.decoder, err = newProtobufDecoder(p.DescriptorSetFile, p.MessageType)
+	case formatAvro:
+		p.decoder, err = newAvroDecoder(p.AvroSchema, &schemaRegistry{
+			url:      p.SchemaRegistryURL,
+			username: p.SchemaRegistryUsername,
+			password: p.SchemaRegistryPassword,
+			timeout:  time.Duration(p.SchemaRegistryTimeout) * time.Second,
+			errorTTL: time.Duration(p.SchemaRegistryErrorTTL) * time.Second,
+		})
+	default:
+		return fmt.Errorf("invalid format %q, you can only use \"protobuf\" or \"avro\" as Format for plugin %v", p.Format, pluginName)
+	}
+	if err != nil {
+		return fmt.Errorf("init %v decoder error for plugin %v: %v", p.Format, pluginName, err)
+	}
+	return nil
+}
+
+func (*ProcessorBinaryDecode) Description() string {
+	return "protobuf and avro decode processor for logtail"
+}
+
+func (p *ProcessorBinaryDecode) ProcessLogs(logArray []*protocol.Log) []*protocol.Log {
+	for _, log := range logArray {
+		p.processLog(log)
+	}
+	return logArray
+}
+
+func (p *ProcessorBinaryDecode) processLog(log *protocol.Log) {
+	for idx, content := range log.Contents {
+		if content.Key != p.SourceKey {
+			continue
+		}
+		value, err := p.decoder.decode([]byte(content.Value))
+		if err != nil {
+			logger.Warningf(p.context.GetRuntimeContext(), util.AlarmProcessorBinaryDecode, "decode %v error %v", p.Format, err)
+		} else {
+			p.expand(log, p.Prefix, 1, value)
+		}
+		if !(p.KeepSource || (p.KeepSourceIfParseError && err != nil)) {
+			log.Contents = append(log.Contents[:idx], log.Contents[idx+1:]...)
+		}
+		return
+	}
+	if p.NoKeyError {
+		logger.Warningf(p.context.GetRuntimeContext(), util.AlarmProcessorBinaryDecodeFind, "cannot find key %v", p.SourceKey)
+	}
+}
+
+// expand appends the fields of the object to the contents, the nested objects are expanded until ExpandDepth.
+func (p *ProcessorBinaryDecode) expand(log *protocol.Log, prefix string, depth int, value interface{}) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: prefix + p.SourceKey, Value: formatValue(value)})
+		return
+	}
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if nested, ok := object[key].(map[string]interface{}); ok && (p.ExpandDepth <= 0 || depth < p.ExpandDepth) {
+			p.expand(log, prefix+key+p.ExpandConnector, depth+1, nested)
+			continue
+		}
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: prefix + key, Value: formatValue(object[key])})
+	}
+}
+
+func (p *ProcessorBinaryDecode) Process(in *models.PipelineGroupEvents, context pipeline.PipelineContext) {
+	events := in.Events[:0]
+	for _, event := range in.Events {
+		data, ok := event.(models.ByteArray)
+		if !ok {
+			events = append(events, event)
+			continue
+		}
+		value, err := p.decoder.decode(data)
+		if err == nil {
+			var encoded []byte
+			if encoded, err = json.Marshal(jsonValue(value)); err == nil {
+				events = append(events, models.ByteArray(encoded))
+				continue
+			}
+		}
+		logger.Warningf(p.context.GetRuntimeContext(), util.AlarmProcessorBinaryDecode, "decode %v error %v", p.Format, err)
+		if p.KeepSourceIfParseError {
+			events = append(events, event)
+		}
+	}
+	in.Events = events
+	context.Collector().Collect(in.Group, in.Events...)
+}
+
+// formatValue formats the scalar values as they are, and the others in JSON.
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case json.Number:
+		return v.String()
+	case bool, int, int32, int64, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	}
+	if encoded, err := json.Marshal(jsonValue(value)); err == nil {
+		return string(encoded)
+	}
+	return fmt.Sprint(value)
+}
+
+// jsonValue converts the values not supported by JSON, such as NaN and the big numbers, into strings.
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[key] = jsonValue(item)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			converted[i] = jsonValue(item)
+		}
+		return converted
+	case nil, string, []byte, bool, json.Number, time.Time, int, int32, int64, uint32, uint64:
+		return v
+	case float32, float64:
+		if _, err := json.Marshal(v); err != nil {
+			return fmt.Sprint(v)
+		}
+		return v
+	}
+	return fmt.Sprint(value)
+}
+
+func init() {
+	pipeline.Processors[pluginName] = func() pipeline.Processor {
+		return &ProcessorBinaryDecode{
+			NoKeyError:             true,
+			ExpandDepth:            0,
+			ExpandConnector:        "_",
+			KeepSource:             false,
+			KeepSourceIfParseError: true,
+			SchemaRegistryTimeout:  5,
+			SchemaRegistryErrorTTL: 30,
+		}
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package binarydecode
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+var testFile = &descriptorpb.FileDescriptorProto{
+	Name:    proto.String("test.proto"),
+	Package: proto.String("test"),
+	Syntax:  proto.String("proto3"),
+	MessageType: []*descriptorpb.DescriptorProto{
+		{
+			Name: proto.String("Request"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("method"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()},
+				{Name: proto.String("latency_ms"), Number: proto.Int32(2), Type: descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()},
+				{Name: proto.String("peer"), Number: proto.Int32(3), Type: descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), TypeName: proto.String(".test.Peer")},
+				{Name: proto.String("tags"), Number: proto.Int32(4), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()},
+			},
+		},
+		{
+			Name: proto.String("Peer"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("ip"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()},
+			},
+		},
+	},
+}
+
+func newProtobufMessage(t *testing.T) []byte {
+	file, err := protodesc.NewFile(testFile, nil)
+	require.NoError(t, err)
+	request := dynamicpb.NewMessage(file.Messages().ByName("Request"))
+	fields := request.Descriptor().Fields()
+	request.Set(fields.ByName("method"), protoreflect.ValueOfString("GET"))
+	request.Set(fields.ByName("latency_ms"), protoreflect.ValueOfInt64(12))
+	peer := dynamicpb.NewMessage(file.Messages().ByName("Peer"))
+	peer.Set(peer.Descriptor().Fields().ByName("ip"), protoreflect.ValueOfString("10.0.0.1"))
+	request.Set(fields.ByName("peer"), protoreflect.ValueOfMessage(peer))
+	tags := request.Mutable(fields.ByName("tags")).List()
+	tags.Append(protoreflect.ValueOfString("a"))
+	tags.Append(protoreflect.ValueOfString("b"))
+	data, err := proto.Marshal(request)
+	require.NoError(t, err)
+	return data
+}
+
+func contents(log *protocol.Log) map[string]string {
+	result := make(map[string]string)
+	for _, content := range log.Contents {
+		result[content.Key] = content.Value
+	}
+	return result
+}
+
+func TestProtobuf(t *testing.T) {
+	descriptorSet, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{testFile}})
+	require.NoError(t, err)
+	descriptorSetFile := filepath.Join(t.TempDir(), "test.desc")
+	require.NoError(t, os.WriteFile(descriptorSetFile, descriptorSet, 0600))
+
+	processor := pipeline.Processors[pluginName]().(*ProcessorBinaryDecode)
+	processor.SourceKey = "value"
+	processor.Format = formatProtobuf
+	processor.DescriptorSetFile = descriptorSetFile
+	processor.MessageType = "test.Request"
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+
+	logs := processor.ProcessLogs([]*protocol.Log{
+		{Contents: []*protocol.Log_Content{{Key: "value", Value: string(newProtobufMessage(t))}}},
+		{Contents: []*protocol.Log_Content{{Key: "value", Value: "\xff\xff"}}},
+	})
+	assert.Equal(t, map[string]string{
+		"method":     "GET",
+		"latency_ms": "12",
+		"peer_ip":    "10.0.0.1",
+		"tags":       `["a","b"]`,
+	}, contents(logs[0]))
+	assert.Equal(t, map[string]string{"value": "\xff\xff"}, contents(logs[1]))
+
+	processor.MessageType = "test.Unknown"
+	assert.Error(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+}
+
+const testAvroSchema = `{
+	"type": "record",
+	"name": "Request",
+	"namespace": "test",
+	"fields": [
+		{"name": "method", "type": "string"},
+		{"name": "user", "type": ["null", "string"], "default": null},
+		{"name": "peer", "type": ["null", {"type": "record", "name": "Peer", "fields": [{"name": "ip", "type": "string"}]}]},
+		{"name": "price", "type": {"type": "bytes", "logicalType": "decimal", "precision": 6, "scale": 2}}
+	]
+}`
+
+func newAvroPayload(t *testing.T, schemaID int) []byte {
+	codec, err := goavro.NewCodec(testAvroSchema)
+	require.NoError(t, err)
+	payload, err := codec.BinaryFromNative(nil, map[string]interface{}{
+		"method": "GET",
+		"user":   goavro.Union("string", "alice"),
+		"peer":   goavro.Union("test.Peer", map[string]interface{}{"ip": "10.0.0.1"}),
+		"price":  big.NewRat(1234, 100),
+	})
+	require.NoError(t, err)
+	if schemaID < 0 {
+		return payload
+	}
+	header := make([]byte, 5)
+	binary.BigEndian.PutUint32(header[1:], uint32(schemaID))
+	return append(header, payload...)
+}
+
+func TestAvroWithSchemaRegistry(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path != "/schemas/ids/7" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"schema": %s}`, strconv.Quote(testAvroSchema))
+	}))
+	defer server.Close()
+
+	processor := pipeline.Processors[pluginName]().(*ProcessorBinaryDecode)
+	processor.SourceKey = "value"
+	processor.Format = formatAvro
+	processor.SchemaRegistryURL = server.URL + "/"
+	processor.Prefix = "avro_"
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+
+	logs := processor.ProcessLogs([]*protocol.Log{
+		{Contents: []*protocol.Log_Content{{Key: "value", Value: string(newAvroPayload(t, 7))}}},
+		{Contents: []*protocol.Log_Content{{Key: "value", Value: string(newAvroPayload(t, 7))}}},
+		{Contents: []*protocol.Log_Content{{Key: "value", Value: string(newAvroPayload(t, 8))}}},
+	})
+	expected := map[string]string{
+		"avro_method":  "GET",
+		"avro_user":    "alice",
+		"avro_peer_ip": "10.0.0.1",
+		"avro_price":   "12.34",
+	}
+	assert.Equal(t, expected, contents(logs[0]))
+	assert.Equal(t, expected, contents(logs[1]))
+	assert.Equal(t, []string{"value"}, keys(logs[2]))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestSchemaRegistryCache(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		if r.URL.Path != "/schemas/ids/7" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"schema": %s}`, strconv.Quote(testAvroSchema))
+	}))
+	defer server.Close()
+	registry := &schemaRegistry{url: server.URL, timeout: 5 * time.Second, errorTTL: 30 * time.Second}
+	_, err := newAvroDecoder("", registry)
+	require.NoError(t, err)
+	now := time.Now()
+	registry.now = func() time.Time { return now }
+
+	// the concurrent lookups of the same id share one request
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			schema, err := registry.schema(7)
+			assert.NoError(t, err)
+			assert.NotNil(t, schema)
+		}()
+	}
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&requests) == 1 }, time.Second, 10*time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// the failure is cached until the ttl expires
+	_, err = registry.schema(8)
+	assert.Error(t, err)
+	_, err = registry.schema(8)
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	now = now.Add(30 * time.Second)
+	_, err = registry.schema(8)
+	assert.Error(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+func keys(log *protocol.Log) []string {
+	var result []string
+	for _, content := range log.Contents {
+		result = append(result, content.Key)
+	}
+	return result
+}
+
+func TestAvroV2(t *testing.T) {
+	processor := pipeline.Processors[pluginName]().(*ProcessorBinaryDecode)
+	processor.Format = formatAvro
+	processor.AvroSchema = testAvroSchema
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+
+	context := pipeline.NewObservePipelineConext(10)
+	processor.Process(&models.PipelineGroupEvents{
+		Group:  models.NewGroup(models.NewMetadata(), models.NewTags()),
+		Events: []models.PipelineEvent{models.ByteArray(newAvroPayload(t, -1)), models.ByteArray("invalid")},
+	}, context)
+	groups := context.Collector().ToArray()
+	require.Len(t, groups, 1)
+	require.Len(t, groups[0].Events, 2)
+	assert.JSONEq(t, `{"method":"GET","user":"alice","peer":{"ip":"10.0.0.1"},"price":12.34}`, string(groups[0].Events[0].(models.ByteArray)))
+	assert.Equal(t, "invalid", string(groups[0].Events[1].(models.ByteArray)))
+}
@@ -0,0 +1,95 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package binarydecode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// protobufDecoder decodes the protobuf messages by the descriptors loaded from a FileDescriptorSet file.
+type protobufDecoder struct {
+	message   protoreflect.MessageDescriptor
+	marshaler protojson.MarshalOptions
+}
+
+func newProtobufDecoder(descriptorSetFile, messageType string) (*protobufDecoder, error) {
+	if descriptorSetFile == "" || messageType == "" {
+		return nil, fmt.Errorf("must specify DescriptorSetFile and MessageType")
+	}
+	content, err := os.ReadFile(descriptorSetFile) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err = proto.Unmarshal(content, &set); err != nil {
+		return nil, fmt.Errorf("invalid descriptor set file %v: %v", descriptorSetFile, err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor set file %v: %v", descriptorSetFile, err)
+	}
+	descriptor, err := files.FindDescriptorByName(protoreflect.FullName(messageType))
+	if err != nil {
+		return nil, fmt.Errorf("cannot find message %v: %v", messageType, err)
+	}
+	message, ok := descriptor.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%v is not a message", messageType)
+	}
+	types := new(protoregistry.Types)
+	files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		registerTypes(types, file.Messages())
+		return true
+	})
+	return &protobufDecoder{
+		message: message,
+		// the types resolve the google.protobuf.Any fields.
+		marshaler: protojson.MarshalOptions{UseProtoNames: true, Resolver: types},
+	}, nil
+}
+
+func registerTypes(types *protoregistry.Types, messages protoreflect.MessageDescriptors) {
+	for i := 0; i < messages.Len(); i++ {
+		_ = types.RegisterMessage(dynamicpb.NewMessageType(messages.Get(i)))
+		registerTypes(types, messages.Get(i).Messages())
+	}
+}
+
+func (d *protobufDecoder) decode(data []byte) (interface{}, error) {
+	message := dynamicpb.NewMessage(d.message)
+	if err := proto.Unmarshal(data, message); err != nil {
+		return nil, err
+	}
+	encoded, err := d.marshaler.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var value interface{}
+	err = decoder.Decode(&value)
+	return value, err
+}
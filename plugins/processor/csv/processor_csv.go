@@ -18,8 +18,10 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io"
+	"math"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
@@ -27,27 +29,100 @@ import (
 	"github.com/alibaba/ilogtail/pkg/util"
 )
 
+const (
+	typeString = "string"
+	typeInt    = "int"
+	typeFloat  = "float"
+	typeBool   = "bool"
+)
+
+// The policies of the ragged rows, whose field count differs from the keys.
+const (
+	raggedKeep   = "keep"
+	raggedPad    = "pad"
+	raggedDrop   = "drop"
+	raggedSource = "source"
+)
+
+const (
+	decodeSuccess = iota
+	decodeFailure
+	decodeDrop
+)
+
+// maxHeaderSources limits the sources whose headers are remembered.
+const maxHeaderSources = 10000
+
 type ProcessorCSVDecoder struct {
-	SourceKey        string   `comment:"The source key containing the CSV record"`
-	NoKeyError       bool     `comment:"Optional. Whether to report error if no key in the log mathes the SourceKey, default to false"`
-	SplitKeys        []string `comment:"The keys matching the decoded CSV fields"`
-	SplitSep         string   `comment:"Optional. The Separator, default to ,"`
-	TrimLeadingSpace bool     `comment:"Optional. Whether to ignore the leading space in each CSV field, default to false"`
-	PreserveOthers   bool     `comment:"Optional. Whether to preserve the remaining record if #splitKeys < #CSV fields, default to false"`
-	ExpandOthers     bool     `comment:"Optional. Whether to decode the remaining record if #splitKeys < #CSV fields, default to false"`
-	ExpandKeyPrefix  string   `comment:"Required when ExpandOthers=true. The prefix of the keys for storing the remaining record fields"`
-	KeepSource       bool     `comment:"Optional. Whether to keep the source log content given successful decoding, default to false"`
+	SourceKey        string            `comment:"The source key containing the CSV record"`
+	NoKeyError       bool              `comment:"Optional. Whether to report error if no key in the log mathes the SourceKey, default to false"`
+	SplitKeys        []string          `comment:"The keys matching the decoded CSV fields"`
+	SplitSep         string            `comment:"Optional. The Separator, default to ,. Use \\t for TSV"`
+	Quote            string            `comment:"Optional. The quote character, default to \""`
+	DisableQuote     bool              `comment:"Optional. Whether to treat the quote character as a normal one, default to false"`
+	Escape           string            `comment:"Optional. The escape character, default to empty which means the quote is escaped by doubling it"`
+	TrimLeadingSpace bool              `comment:"Optional. Whether to ignore the leading space in each CSV field, default to false"`
+	PreserveOthers   bool              `comment:"Optional. Whether to preserve the remaining record if #splitKeys < #CSV fields, default to false"`
+	ExpandOthers     bool              `comment:"Optional. Whether to decode the remaining record if #splitKeys < #CSV fields, default to false"`
+	ExpandKeyPrefix  string            `comment:"Required when ExpandOthers=true. The prefix of the keys for storing the remaining record fields"`
+	KeepSource       bool              `comment:"Optional. Whether to keep the source log content given successful decoding, default to false"`
+	HeaderRow        bool              `comment:"Optional. Whether the records contain the header rows, which are dropped. The first record of each source is the header and used as the keys when SplitKeys is empty, otherwise the records same as SplitKeys are the headers, default to false"`
+	HeaderSourceKey  string            `comment:"Optional. The key whose value identifies the source of the records for HeaderRow, default to __tag__:__path__"`
+	FieldTypes       map[string]string `comment:"Optional. The type hints of the keys, int, float, bool or string, the values are coerced to the canonical form of the types"`
+	RaggedRowPolicy  string            `comment:"Optional. The policy of the records whose field count differs from the keys, keep (decode the present fields), pad (fill the missing fields with empty), drop (drop the log) or source (keep the source only), default to keep"`
 
 	sep     rune
+	quote   rune
+	escape  rune
+	headers map[string][]string
 	context pipeline.Context
 }
 
 func (p *ProcessorCSVDecoder) Init(context pipeline.Context) error {
 	sepRunes := []rune(p.SplitSep)
+	if p.SplitSep == "\\t" {
+		sepRunes = []rune{'\t'}
+	}
 	if len(sepRunes) != 1 {
 		return fmt.Errorf("invalid separator: %s", p.SplitSep)
 	}
 	p.sep = sepRunes[0]
+	p.quote = '"'
+	if p.Quote != "" {
+		quoteRunes := []rune(p.Quote)
+		if len(quoteRunes) != 1 || quoteRunes[0] == p.sep {
+			return fmt.Errorf("invalid quote: %s", p.Quote)
+		}
+		p.quote = quoteRunes[0]
+	}
+	if p.DisableQuote {
+		p.quote = 0
+	}
+	if p.Escape != "" {
+		escapeRunes := []rune(p.Escape)
+		if len(escapeRunes) != 1 || escapeRunes[0] == p.sep {
+			return fmt.Errorf("invalid escape: %s", p.Escape)
+		}
+		p.escape = escapeRunes[0]
+	}
+	for key, typ := range p.FieldTypes {
+		switch typ {
+		case typeString, typeInt, typeFloat, typeBool:
+		default:
+			return fmt.Errorf("invalid type %v of key %v", typ, key)
+		}
+	}
+	switch p.RaggedRowPolicy {
+	case "":
+		p.RaggedRowPolicy = raggedKeep
+	case raggedKeep, raggedPad, raggedDrop, raggedSource:
+	default:
+		return fmt.Errorf("invalid ragged row policy: %s", p.RaggedRowPolicy)
+	}
+	if p.HeaderSourceKey == "" {
+		p.HeaderSourceKey = "__tag__:__path__"
+	}
+	p.headers = make(map[string][]string)
 	p.context = context
 	return nil
 }
@@ -57,39 +132,65 @@ func (*ProcessorCSVDecoder) Description() string {
 }
 
 func (p *ProcessorCSVDecoder) decodeCSV(log *protocol.Log, value string) bool {
-	if len(p.SplitKeys) == 0 {
+	return p.decode(log, value) == decodeSuccess
+}
+
+func (p *ProcessorCSVDecoder) decode(log *protocol.Log, value string) int {
+	if len(p.SplitKeys) == 0 && !p.HeaderRow {
 		if p.PreserveOthers {
 			log.Contents = append(log.Contents, &protocol.Log_Content{Key: "_decode_preserve_", Value: value})
 		}
-		return true
+		return decodeSuccess
 	}
 
-	r := csv.NewReader(strings.NewReader(value))
-	r.Comma = p.sep
-	r.TrimLeadingSpace = p.TrimLeadingSpace
+	record, err := p.parseRecord(value)
+	if err != nil {
+		logger.Warning(p.context.GetRuntimeContext(), util.AlarmDecodeLog, "cannot decode log", err, "log", util.CutString(value, 1024))
+		return decodeFailure
+	}
 
-	var record []string
-	record, err := r.Read()
-	if err != nil && err != io.EOF {
-		logger.Warning(p.context.GetRuntimeContext(), "DECODE_LOG_ALARM", "cannot decode log", err, "log", util.CutString(value, 1024))
-		return false
+	keys := p.SplitKeys
+	if p.HeaderRow {
+		if len(keys) == 0 {
+			source := sourceOf(log, p.HeaderSourceKey)
+			header, ok := p.headers[source]
+			if !ok || equalRecords(header, record) {
+				if !ok && len(p.headers) >= maxHeaderSources {
+					p.headers = make(map[string][]string)
+				}
+				p.headers[source] = record
+				return decodeDrop
+			}
+			keys = header
+		} else if equalRecords(keys, record) {
+			return decodeDrop
+		}
 	}
-	// Empty value should also be considered as a valid field.
-	// (To be compatible with the fact that value with only blank chars
-	// and TrimLeadingSpace=true is considered valid by encoding/csv pkg)
-	if err == io.EOF {
-		record = append(record, "")
+
+	if len(keys) != len(record) {
+		logger.Warning(p.context.GetRuntimeContext(), util.AlarmDecodeLog, "decode keys not match, split len", len(record), "log", util.CutString(value, 1024))
+		switch p.RaggedRowPolicy {
+		case raggedDrop:
+			return decodeDrop
+		case raggedSource:
+			return decodeFailure
+		}
 	}
 
 	var keyIndex int
-	for keyIndex = 0; keyIndex < len(p.SplitKeys) && keyIndex < len(record); keyIndex++ {
-		log.Contents = append(log.Contents, &protocol.Log_Content{Key: p.SplitKeys[keyIndex], Value: record[keyIndex]})
+	for keyIndex = 0; keyIndex < len(keys) && keyIndex < len(record); keyIndex++ {
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: keys[keyIndex], Value: p.coerce(keys[keyIndex], record[keyIndex])})
+	}
+	if p.RaggedRowPolicy == raggedPad {
+		for ; keyIndex < len(keys); keyIndex++ {
+			log.Contents = append(log.Contents, &protocol.Log_Content{Key: keys[keyIndex], Value: ""})
+		}
 	}
 
 	if keyIndex < len(record) && p.PreserveOthers {
 		if p.ExpandOthers {
 			for ; keyIndex < len(record); keyIndex++ {
-				log.Contents = append(log.Contents, &protocol.Log_Content{Key: p.ExpandKeyPrefix + strconv.Itoa(keyIndex+1-len(p.SplitKeys)), Value: record[keyIndex]})
+				log.Contents = append(log.Contents, &protocol.Log_Content{Key: p.ExpandKeyPrefix + strconv.Itoa(keyIndex+1-len(keys)), Value: record[keyIndex]})
 			}
 		} else {
 			var b strings.Builder
@@ -101,31 +202,147 @@ func (p *ProcessorCSVDecoder) decodeCSV(log *protocol.Log, value string) bool {
 			log.Contents = append(log.Contents, &protocol.Log_Content{Key: "_decode_preserve_", Value: remained[:len(remained)-1]})
 		}
 	}
+	return decodeSuccess
+}
+
+// parseRecord splits the value into fields, encoding/csv is used for the RFC 4180 quote and escape.
+func (p *ProcessorCSVDecoder) parseRecord(value string) ([]string, error) {
+	if p.quote != '"' || p.escape != 0 {
+		return p.splitRecord(value)
+	}
+	r := csv.NewReader(strings.NewReader(value))
+	r.Comma = p.sep
+	r.TrimLeadingSpace = p.TrimLeadingSpace
+
+	var record []string
+	record, err := r.Read()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	// Empty value should also be considered as a valid field.
+	// (To be compatible with the fact that value with only blank chars
+	// and TrimLeadingSpace=true is considered valid by encoding/csv pkg)
+	if err == io.EOF {
+		record = append(record, "")
+	}
+	return record, nil
+}
+
+// splitRecord splits the value with the configured quote and escape. The escape character makes the
+// next character literal both inside and outside the quotes, and the quote is escaped by doubling it
+// when there is no escape character.
+func (p *ProcessorCSVDecoder) splitRecord(value string) ([]string, error) {
+	var record []string
+	var field strings.Builder
+	inQuotes, quoted := false, false
+	runes := []rune(value)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case p.escape != 0 && c == p.escape && i+1 < len(runes):
+			i++
+			field.WriteRune(runes[i])
+		case inQuotes && c == p.quote:
+			if p.escape == 0 && i+1 < len(runes) && runes[i+1] == p.quote {
+				i++
+				field.WriteRune(c)
+			} else {
+				inQuotes = false
+			}
+		case inQuotes:
+			field.WriteRune(c)
+		case c == p.sep:
+			record = append(record, field.String())
+			field.Reset()
+			quoted = false
+		case p.quote != 0 && c == p.quote && field.Len() == 0 && !quoted:
+			inQuotes, quoted = true, true
+		case p.TrimLeadingSpace && field.Len() == 0 && !quoted && unicode.IsSpace(c):
+		default:
+			field.WriteRune(c)
+		}
+	}
+	if inQuotes {
+		return nil, fmt.Errorf("extraneous or missing %q in quoted-field", p.quote)
+	}
+	return append(record, field.String()), nil
+}
+
+// coerce converts the value to the canonical form of the type hint of the key, the value is kept
+// as it is if it cannot be converted.
+func (p *ProcessorCSVDecoder) coerce(key, value string) string {
+	typ, ok := p.FieldTypes[key]
+	if !ok || typ == typeString || value == "" {
+		return value
+	}
+	trimmed := strings.TrimSpace(value)
+	switch typ {
+	case typeInt:
+		if i, err := strconv.ParseInt(trimmed, 10, 64); err == nil {
+			return strconv.FormatInt(i, 10)
+		}
+		if f, err := strconv.ParseFloat(trimmed, 64); err == nil && f == math.Trunc(f) && math.Abs(f) < 1<<63 {
+			return strconv.FormatInt(int64(f), 10)
+		}
+	case typeFloat:
+		if f, err := strconv.ParseFloat(trimmed, 64); err == nil {
+			return strconv.FormatFloat(f, 'f', -1, 64)
+		}
+	case typeBool:
+		if b, err := strconv.ParseBool(trimmed); err == nil {
+			return strconv.FormatBool(b)
+		}
+	}
+	logger.Warning(p.context.GetRuntimeContext(), util.AlarmDecodeLog, "cannot convert value to type", typ, "key", key, "value", util.CutString(value, 1024))
+	return value
+}
+
+func sourceOf(log *protocol.Log, key string) string {
+	for _, cont := range log.Contents {
+		if cont.Key == key {
+			return cont.Value
+		}
+	}
+	return ""
+}
 
-	if len(p.SplitKeys) != len(record) {
-		logger.Warning(p.context.GetRuntimeContext(), "DECODE_LOG_ALARM", "decode keys not match, split len", len(record), "log", util.CutString(value, 1024))
+func equalRecords(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
 	}
 	return true
 }
 
 func (p *ProcessorCSVDecoder) ProcessLogs(logArray []*protocol.Log) []*protocol.Log {
+	result := logArray[:0]
 	for _, log := range logArray {
 		findKey := false
+		dropped := false
 		for i, cont := range log.Contents {
 			if len(p.SourceKey) == 0 || p.SourceKey == cont.Key {
 				findKey = true
-				res := p.decodeCSV(log, cont.Value)
-				if !p.shouldKeepSrc(res) {
+				res := p.decode(log, cont.Value)
+				if res == decodeDrop {
+					dropped = true
+				} else if !p.shouldKeepSrc(res == decodeSuccess) {
 					log.Contents = append(log.Contents[:i], log.Contents[i+1:]...)
 				}
 				break
 			}
 		}
 		if !findKey && p.NoKeyError {
-			logger.Warning(p.context.GetRuntimeContext(), "DECODE_FIND_ALARM", "cannot find key", p.SourceKey)
+			logger.Warning(p.context.GetRuntimeContext(), util.AlarmDecodeFind, "cannot find key", p.SourceKey)
+		}
+		if !dropped {
+			result = append(result, log)
 		}
 	}
-	return logArray
+	return result
 }
 
 func (p *ProcessorCSVDecoder) shouldKeepSrc(res bool) bool {
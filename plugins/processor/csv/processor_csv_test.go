@@ -359,3 +359,122 @@ func TestProcessorCSVDecoder(t *testing.T) {
 		})
 	})
 }
+
+func TestProcessorCSVDecoderOptions(t *testing.T) {
+	Convey("Given a tsv decoder with the single quote and the backslash escape", t, func() {
+		processor := &ProcessorCSVDecoder{
+			SplitSep:  "\\t",
+			Quote:     "'",
+			Escape:    "\\",
+			SplitKeys: []string{"f1", "f2", "f3"},
+		}
+		So(processor.Init(mock.NewEmptyContext("p", "l", "c")), ShouldBeNil)
+
+		Convey("When the record contains the quoted and escaped fields", func() {
+			record := "'a\tb'\t'it\\'s'\tc\\\td"
+			log := &protocol.Log{Time: 0}
+			res := processor.decodeCSV(log, record)
+
+			Convey("Then the fields are unquoted and unescaped", func() {
+				So(res, ShouldBeTrue)
+				So(len(log.Contents), ShouldEqual, 3)
+				So(log.Contents[0].Value, ShouldEqual, "a\tb")
+				So(log.Contents[1].Value, ShouldEqual, "it's")
+				So(log.Contents[2].Value, ShouldEqual, "c\td")
+			})
+		})
+
+		Convey("When the quote is not terminated", func() {
+			log := &protocol.Log{Time: 0}
+			res := processor.decodeCSV(log, "'a\tb")
+
+			Convey("Then the decoding is invalid", func() {
+				So(res, ShouldBeFalse)
+				So(len(log.Contents), ShouldEqual, 0)
+			})
+		})
+	})
+
+	Convey("Given a csv decoder with the header row and the type hints", t, func() {
+		processor := &ProcessorCSVDecoder{
+			SourceKey:  "content",
+			SplitSep:   ",",
+			HeaderRow:  true,
+			FieldTypes: map[string]string{"status": "int", "latency": "float", "ok": "bool"},
+		}
+		So(processor.Init(mock.NewEmptyContext("p", "l", "c")), ShouldBeNil)
+
+		newLog := func(path, record string) *protocol.Log {
+			return &protocol.Log{Contents: []*protocol.Log_Content{
+				{Key: "__tag__:__path__", Value: path},
+				{Key: "content", Value: record},
+			}}
+		}
+
+		Convey("When the records of two files are processed", func() {
+			logs := processor.ProcessLogs([]*protocol.Log{
+				newLog("a.csv", "status,latency,ok"),
+				newLog("a.csv", "200,1.50,TRUE"),
+				newLog("b.csv", "ok,status"),
+				newLog("a.csv", "status,latency,ok"),
+				newLog("b.csv", "f, 404.0"),
+				newLog("a.csv", "abc,0.1,1"),
+			})
+
+			Convey("Then the headers are dropped and used as the keys", func() {
+				So(len(logs), ShouldEqual, 3)
+				So(logs[0].Contents[1:], ShouldResemble, []*protocol.Log_Content{
+					{Key: "status", Value: "200"}, {Key: "latency", Value: "1.5"}, {Key: "ok", Value: "true"},
+				})
+				So(logs[1].Contents[1:], ShouldResemble, []*protocol.Log_Content{
+					{Key: "ok", Value: "false"}, {Key: "status", Value: "404"},
+				})
+				So(logs[2].Contents[1:], ShouldResemble, []*protocol.Log_Content{
+					{Key: "status", Value: "abc"}, {Key: "latency", Value: "0.1"}, {Key: "ok", Value: "true"},
+				})
+			})
+		})
+	})
+
+	Convey("Given csv decoders with the ragged row policies", t, func() {
+		newPolicyProcessor := func(policy string) *ProcessorCSVDecoder {
+			processor := &ProcessorCSVDecoder{
+				SourceKey:       "content",
+				SplitSep:        ",",
+				SplitKeys:       []string{"f1", "f2", "f3"},
+				RaggedRowPolicy: policy,
+			}
+			So(processor.Init(mock.NewEmptyContext("p", "l", "c")), ShouldBeNil)
+			return processor
+		}
+		newLogs := func() []*protocol.Log {
+			return []*protocol.Log{
+				{Contents: []*protocol.Log_Content{{Key: "content", Value: "1,2"}}},
+				{Contents: []*protocol.Log_Content{{Key: "content", Value: "1,2,3"}}},
+			}
+		}
+
+		Convey("Then the short records are handled by the policies", func() {
+			logs := newPolicyProcessor("").ProcessLogs(newLogs())
+			So(logs[0].Contents, ShouldResemble, []*protocol.Log_Content{{Key: "f1", Value: "1"}, {Key: "f2", Value: "2"}})
+
+			logs = newPolicyProcessor("pad").ProcessLogs(newLogs())
+			So(logs[0].Contents, ShouldResemble, []*protocol.Log_Content{{Key: "f1", Value: "1"}, {Key: "f2", Value: "2"}, {Key: "f3", Value: ""}})
+
+			logs = newPolicyProcessor("drop").ProcessLogs(newLogs())
+			So(len(logs), ShouldEqual, 1)
+			So(len(logs[0].Contents), ShouldEqual, 3)
+
+			logs = newPolicyProcessor("source").ProcessLogs(newLogs())
+			So(logs[0].Contents, ShouldResemble, []*protocol.Log_Content{{Key: "content", Value: "1,2"}})
+			So(len(logs[1].Contents), ShouldEqual, 3)
+		})
+
+		Convey("Then the invalid options are rejected", func() {
+			processor := &ProcessorCSVDecoder{SplitSep: ",", RaggedRowPolicy: "unknown"}
+			So(processor.Init(mock.NewEmptyContext("p", "l", "c")), ShouldNotBeNil)
+			processor = &ProcessorCSVDecoder{SplitSep: ",", FieldTypes: map[string]string{"a": "date"}}
+			So(processor.Init(mock.NewEmptyContext("p", "l", "c")), ShouldNotBeNil)
+		})
+	})
+}
@@ -31,11 +31,12 @@ import (
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 const (
 	pluginName       = "processor_encrypt"
-	defaultAlarmType = "PROCESSOR_ENCRYPT_ALARM"
+	defaultAlarmType = util.AlarmProcessorEncrypt
 	encryptErrorText = "ENCRYPT_ERROR"
 )
 
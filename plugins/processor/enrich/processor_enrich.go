@@ -0,0 +1,206 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enrich
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const pluginName = "processor_enrich"
+
+const (
+	sourceFile  = "file"
+	sourceHTTP  = "http"
+	sourceRedis = "redis"
+)
+
+// table is an external lookup table, the returned columns of a missing key should be nil.
+type table interface {
+	Lookup(key string) (map[string]string, error)
+}
+
+// ProcessorEnrich joins the value of SourceKey against an external table and adds the mapped columns to the log.
+// Three kinds of tables are supported:
+//   - file: a CSV file whose first row is the header, the rows are indexed by KeyColumn and reloaded when the file is modified.
+//   - http: a HTTP endpoint returning a JSON object of columns, %{key} in the URL is replaced by the value of SourceKey,
+//     responses are cached for CacheTTLSec seconds.
+//   - redis: a redis hash named RedisKeyPrefix + value of SourceKey, fetched by HGETALL and cached as http.
+type ProcessorEnrich struct {
+	SourceKey string
+	Source    string
+	// Columns to add, all columns are added when empty.
+	Columns []string
+	// Prefix of the added keys.
+	KeyPrefix string
+	// Overwrite the existing contents with the same key or not.
+	Overwrite    bool
+	NoKeyError   bool
+	NoMatchError bool
+
+	FilePath          string
+	KeyColumn         string
+	ReloadIntervalSec int
+
+	URL            string
+	Headers        map[string]string
+	TimeoutSeconds int
+
+	RedisAddress   string
+	RedisPassword  string
+	RedisDB        int
+	RedisKeyPrefix string
+
+	CacheTTLSec int
+	CacheSize   int
+
+	context pipeline.Context
+	table   table
+	columns map[string]struct{}
+}
+
+// Init called for init some system resources, like socket, mutex...
+func (p *ProcessorEnrich) Init(context pipeline.Context) error {
+	p.context = context
+	if p.SourceKey == "" {
+		return fmt.Errorf("must specify SourceKey for plugin %v", pluginName)
+	}
+	if len(p.Columns) > 0 {
+		p.columns = make(map[string]struct{}, len(p.Columns))
+		for _, c := range p.Columns {
+			p.columns[c] = struct{}{}
+		}
+	}
+	var err error
+	switch p.Source {
+	case sourceFile:
+		if p.FilePath == "" {
+			return fmt.Errorf("must specify FilePath for plugin %v", pluginName)
+		}
+		p.table, err = newFileTable(p.FilePath, p.KeyColumn, time.Duration(p.ReloadIntervalSec)*time.Second)
+	case sourceHTTP:
+		if p.URL == "" {
+			return fmt.Errorf("must specify URL for plugin %v", pluginName)
+		}
+		p.table, err = newCachedTable(newHTTPTable(p.URL, p.Headers, time.Duration(p.TimeoutSeconds)*time.Second),
+			p.CacheSize, time.Duration(p.CacheTTLSec)*time.Second)
+	case sourceRedis:
+		if p.RedisAddress == "" {
+			return fmt.Errorf("must specify RedisAddress for plugin %v", pluginName)
+		}
+		p.table, err = newCachedTable(newRedisTable(p.RedisAddress, p.RedisPassword, p.RedisDB, p.RedisKeyPrefix, time.Duration(p.TimeoutSeconds)*time.Second),
+			p.CacheSize, time.Duration(p.CacheTTLSec)*time.Second)
+	default:
+		return fmt.Errorf("invalid source %v, you can only use \"file\", \"http\" or \"redis\" as Source", p.Source)
+	}
+	return err
+}
+
+func (*ProcessorEnrich) Description() string {
+	return "enrich processor for logtail, which adds columns from external tables"
+}
+
+func (p *ProcessorEnrich) ProcessLogs(logArray []*protocol.Log) []*protocol.Log {
+	for _, log := range logArray {
+		p.processLog(log)
+	}
+	return logArray
+}
+
+func (p *ProcessorEnrich) processLog(log *protocol.Log) {
+	var value string
+	found := false
+	for _, cont := range log.Contents {
+		if cont.Key == p.SourceKey {
+			value = cont.Value
+			found = true
+			break
+		}
+	}
+	if !found {
+		if p.NoKeyError {
+			logger.Warning(p.context.GetRuntimeContext(), util.AlarmEnrich, "cannot find key", p.SourceKey)
+		}
+		return
+	}
+	columns, err := p.table.Lookup(value)
+	if errors.Is(err, errBackoff) {
+		return
+	}
+	if err != nil {
+		logger.Warning(p.context.GetRuntimeContext(), util.AlarmEnrich, "lookup value", value, "error", err)
+		return
+	}
+	if columns == nil {
+		if p.NoMatchError {
+			logger.Warning(p.context.GetRuntimeContext(), util.AlarmEnrich, "cannot find value", value)
+		}
+		return
+	}
+	names := make([]string, 0, len(columns))
+	for column := range columns {
+		if p.columns != nil {
+			if _, ok := p.columns[column]; !ok {
+				continue
+			}
+		}
+		names = append(names, column)
+	}
+	// keep the order of added contents stable
+	sort.Strings(names)
+	for _, column := range names {
+		p.setContent(log, p.KeyPrefix+column, columns[column])
+	}
+}
+
+// Stop closes the connection of the remote table.
+func (p *ProcessorEnrich) Stop() error {
+	if closer, ok := p.table.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (p *ProcessorEnrich) setContent(log *protocol.Log, key, value string) {
+	for _, cont := range log.Contents {
+		if cont.Key == key {
+			if p.Overwrite {
+				cont.Value = value
+			}
+			return
+		}
+	}
+	log.Contents = append(log.Contents, &protocol.Log_Content{Key: key, Value: value})
+}
+
+func init() {
+	pipeline.Processors[pluginName] = func() pipeline.Processor {
+		return &ProcessorEnrich{
+			Source:            sourceFile,
+			ReloadIntervalSec: 60,
+			TimeoutSeconds:    5,
+			CacheTTLSec:       300,
+			CacheSize:         10000,
+		}
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enrich
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+func newLog(kvs ...string) *protocol.Log {
+	log := &protocol.Log{}
+	for i := 0; i+1 < len(kvs); i += 2 {
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: kvs[i], Value: kvs[i+1]})
+	}
+	return log
+}
+
+func getValue(log *protocol.Log, key string) (string, bool) {
+	for _, cont := range log.Contents {
+		if cont.Key == key {
+			return cont.Value, true
+		}
+	}
+	return "", false
+}
+
+func contents(log *protocol.Log) string {
+	var sb strings.Builder
+	for _, cont := range log.Contents {
+		sb.WriteString(cont.Key + ":" + cont.Value + " ")
+	}
+	return sb.String()
+}
+
+func TestInvalidConfig(t *testing.T) {
+	ctx := mock.NewEmptyContext("p", "l", "c")
+	require.Error(t, (&ProcessorEnrich{Source: sourceFile, FilePath: "a.csv"}).Init(ctx))
+	require.Error(t, (&ProcessorEnrich{SourceKey: "id", Source: "mysql"}).Init(ctx))
+	require.Error(t, (&ProcessorEnrich{SourceKey: "id", Source: sourceHTTP}).Init(ctx))
+	require.Error(t, (&ProcessorEnrich{SourceKey: "id", Source: sourceFile, FilePath: "not_exist.csv"}).Init(ctx))
+}
+
+func TestFileTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "services.csv")
+	require.NoError(t, os.WriteFile(path, []byte("team,service_id,owner\nlogging,svc-1,alice\nmetric,svc-2,bob\n"), 0600))
+	processor := &ProcessorEnrich{
+		SourceKey:         "service",
+		Source:            sourceFile,
+		FilePath:          path,
+		KeyColumn:         "service_id",
+		KeyPrefix:         "service_",
+		ReloadIntervalSec: 1,
+	}
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+
+	logs := processor.ProcessLogs([]*protocol.Log{newLog("service", "svc-1"), newLog("service", "svc-3"), newLog("other", "svc-1")})
+	assert.Equal(t, "service:svc-1 service_owner:alice service_team:logging ", contents(logs[0]))
+	assert.Len(t, logs[1].Contents, 1)
+	assert.Len(t, logs[2].Contents, 1)
+
+	// reload when modified
+	require.NoError(t, os.WriteFile(path, []byte("team,service_id,owner\nlogging,svc-1,carol\n"), 0600))
+	modTime := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+	processor.table.(*fileTable).lastCheck = time.Now().Add(-time.Minute)
+	logs = processor.ProcessLogs([]*protocol.Log{newLog("service", "svc-1", "service_owner", "unknown")})
+	value, _ := getValue(logs[0], "service_owner")
+	assert.Equal(t, "unknown", value)
+	assert.Equal(t, "carol", processor.table.(*fileTable).rows["svc-1"]["owner"])
+
+	processor.Overwrite = true
+	processor.Columns = []string{"owner"}
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+	logs = processor.ProcessLogs([]*protocol.Log{newLog("service", "svc-1", "service_owner", "unknown")})
+	assert.Equal(t, "service:svc-1 service_owner:carol ", contents(logs[0]))
+}
+
+func TestHTTPTable(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		assert.Equal(t, "token", r.Header.Get("Authorization"))
+		switch r.URL.Query().Get("id") {
+		case "svc 1":
+			_, _ = w.Write([]byte(`{"owner":"alice","replicas":3,"labels":{"env":"prod"}}`))
+		case "svc-2":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	processor := &ProcessorEnrich{
+		SourceKey:   "service",
+		Source:      sourceHTTP,
+		URL:         server.URL + "/services?id=%{key}",
+		Headers:     map[string]string{"Authorization": "token"},
+		CacheTTLSec: 300,
+		CacheSize:   10,
+	}
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+	logs := processor.ProcessLogs([]*protocol.Log{newLog("service", "svc 1"), newLog("service", "svc 1"), newLog("service", "svc-2"), newLog("service", "svc-3")})
+	assert.Equal(t, `service:svc 1 labels:{"env":"prod"} owner:alice replicas:3 `, contents(logs[0]))
+	assert.Equal(t, contents(logs[0]), contents(logs[1]))
+	assert.Len(t, logs[2].Contents, 1)
+	assert.Len(t, logs[3].Contents, 1)
+	// the second svc 1 hits the cache
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+
+	// no request within the backoff after the failure, and the backoff doubles on the next failure
+	cached := processor.table.(*cachedTable)
+	now := time.Now()
+	cached.nowFunc = func() time.Time { return now }
+	_, err := cached.Lookup("svc-4")
+	assert.ErrorIs(t, err, errBackoff)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	now = now.Add(minFailureBackoff)
+	_, err = cached.Lookup("svc-4")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, errBackoff)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&requests))
+	assert.Equal(t, now.Add(2*minFailureBackoff), cached.retryAt)
+
+	// the keys cached before are still available during the backoff
+	logs = processor.ProcessLogs([]*protocol.Log{newLog("service", "svc 1")})
+	assert.Equal(t, `service:svc 1 labels:{"env":"prod"} owner:alice replicas:3 `, contents(logs[0]))
+}
+
+func TestRedisTable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close() //nolint:gosec
+	var conns int32
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&conns, 1)
+			go serveFakeRedis(conn, map[string][]string{"service:svc-1": {"owner", "alice", "team", "logging"}})
+		}
+	}()
+
+	processor := &ProcessorEnrich{
+		SourceKey:      "service",
+		Source:         sourceRedis,
+		RedisAddress:   listener.Addr().String(),
+		RedisPassword:  "pwd",
+		RedisDB:        1,
+		RedisKeyPrefix: "service:",
+		TimeoutSeconds: 5,
+		CacheTTLSec:    300,
+		CacheSize:      10,
+	}
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+	logs := processor.ProcessLogs([]*protocol.Log{newLog("service", "svc-1"), newLog("service", "svc-2")})
+	assert.Equal(t, "service:svc-1 owner:alice team:logging ", contents(logs[0]))
+	assert.Len(t, logs[1].Contents, 1)
+	// the connection is reused by the lookups
+	assert.Equal(t, int32(1), atomic.LoadInt32(&conns))
+	require.NoError(t, processor.Stop())
+
+	processor.RedisPassword = "wrong"
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+	_, err = processor.table.Lookup("svc-1")
+	require.Error(t, err)
+}
+
+// serveFakeRedis serves AUTH, SELECT and HGETALL commands.
+func serveFakeRedis(conn net.Conn, hashes map[string][]string) {
+	defer conn.Close() //nolint:gosec
+	rdr := bufio.NewReader(conn)
+	for {
+		var n int
+		if _, err := fmt.Fscanf(rdr, "*%d\r\n", &n); err != nil {
+			return
+		}
+		args := make([]string, n)
+		for i := range args {
+			var size int
+			if _, err := fmt.Fscanf(rdr, "$%d\r\n", &size); err != nil {
+				return
+			}
+			buf := make([]byte, size+2)
+			if _, err := io.ReadFull(rdr, buf); err != nil {
+				return
+			}
+			args[i] = string(buf[:size])
+		}
+		switch args[0] {
+		case "AUTH":
+			if args[1] != "pwd" {
+				_, _ = conn.Write([]byte("-ERR invalid password\r\n"))
+				continue
+			}
+			_, _ = conn.Write([]byte("+OK\r\n"))
+		case "SELECT":
+			_, _ = conn.Write([]byte("+OK\r\n"))
+		case "HGETALL":
+			fields := hashes[args[1]]
+			reply := fmt.Sprintf("*%d\r\n", len(fields))
+			for _, f := range fields {
+				reply += fmt.Sprintf("$%d\r\n%s\r\n", len(f), f)
+			}
+			_, _ = conn.Write([]byte(reply))
+		}
+	}
+}
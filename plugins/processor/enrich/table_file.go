@@ -0,0 +1,119 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enrich
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// fileTable loads the whole CSV file into memory, and reloads it when the file is modified.
+type fileTable struct {
+	path           string
+	keyColumn      string
+	reloadInterval time.Duration
+
+	rows      map[string]map[string]string
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+func newFileTable(path, keyColumn string, reloadInterval time.Duration) (*fileTable, error) {
+	t := &fileTable{
+		path:           path,
+		keyColumn:      keyColumn,
+		reloadInterval: reloadInterval,
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err = t.load(); err != nil {
+		return nil, err
+	}
+	t.modTime = info.ModTime()
+	t.lastCheck = time.Now()
+	return t, nil
+}
+
+func (t *fileTable) Lookup(key string) (map[string]string, error) {
+	var err error
+	if t.reloadInterval > 0 && time.Since(t.lastCheck) >= t.reloadInterval {
+		err = t.reloadIfChanged()
+	}
+	// the old rows are still available when the reloading fails
+	return t.rows[key], err
+}
+
+func (t *fileTable) reloadIfChanged() error {
+	t.lastCheck = time.Now()
+	info, err := os.Stat(t.path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(t.modTime) {
+		return nil
+	}
+	t.modTime = info.ModTime()
+	return t.load()
+}
+
+func (t *fileTable) load() error {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return fmt.Errorf("can not read table file, err: %+v", err)
+	}
+	defer f.Close() //nolint:gosec
+	r := csv.NewReader(f)
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("can not read header of table file %v, err: %+v", t.path, err)
+	}
+	keyIdx := 0
+	if t.keyColumn != "" {
+		keyIdx = -1
+		for i, name := range header {
+			if name == t.keyColumn {
+				keyIdx = i
+				break
+			}
+		}
+		if keyIdx < 0 {
+			return fmt.Errorf("cannot find key column %v in table file %v", t.keyColumn, t.path)
+		}
+	}
+	rows := make(map[string]map[string]string)
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("can not read full table file, err: %+v", err)
+		}
+		columns := make(map[string]string, len(header)-1)
+		for i, name := range header {
+			if i != keyIdx {
+				columns[name] = record[i]
+			}
+		}
+		rows[record[keyIdx]] = columns
+	}
+	t.rows = rows
+	return nil
+}
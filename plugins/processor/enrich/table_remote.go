@@ -0,0 +1,255 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enrich
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/golang-lru/simplelru"
+
+	"github.com/alibaba/ilogtail/helper"
+)
+
+const keyPlaceholder = "%{key}"
+
+const (
+	minFailureBackoff = time.Second
+	maxFailureBackoff = time.Minute
+)
+
+// errBackoff is returned without looking up the remote table during the backoff after a failure.
+var errBackoff = errors.New("remote table is backing off after the failure")
+
+type cacheEntry struct {
+	columns  map[string]string
+	expireAt time.Time
+}
+
+// cachedTable caches the results of a remote table, including the missing keys, to avoid a request per log.
+// The failures are cached too, the lookups within the backoff after a failure return errBackoff at once rather
+// than waiting for the unavailable table, and the backoff doubles on each consecutive failure.
+type cachedTable struct {
+	table table
+	ttl   time.Duration
+	cache *simplelru.LRU
+
+	failures int
+	retryAt  time.Time
+	nowFunc  func() time.Time
+}
+
+func newCachedTable(t table, size int, ttl time.Duration) (*cachedTable, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid cache size %v for plugin %v", size, pluginName)
+	}
+	cache, err := simplelru.NewLRU(size, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &cachedTable{table: t, ttl: ttl, cache: cache, nowFunc: time.Now}, nil
+}
+
+func (c *cachedTable) Lookup(key string) (map[string]string, error) {
+	now := c.nowFunc()
+	if v, ok := c.cache.Get(key); ok {
+		entry := v.(*cacheEntry)
+		if now.Before(entry.expireAt) {
+			return entry.columns, nil
+		}
+	}
+	if now.Before(c.retryAt) {
+		return nil, errBackoff
+	}
+	columns, err := c.table.Lookup(key)
+	if err != nil {
+		backoff := maxFailureBackoff
+		if c.failures < 6 {
+			backoff = helper.Min(minFailureBackoff<<c.failures, maxFailureBackoff)
+		}
+		c.failures++
+		c.retryAt = now.Add(backoff)
+		return nil, err
+	}
+	c.failures = 0
+	c.cache.Add(key, &cacheEntry{columns: columns, expireAt: now.Add(c.ttl)})
+	return columns, nil
+}
+
+// Close closes the remote table if it holds the connections.
+func (c *cachedTable) Close() error {
+	if closer, ok := c.table.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// httpTable requests the URL with the key, and decodes the JSON object in the response as columns.
+// 404 is treated as a missing key.
+type httpTable struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func newHTTPTable(url string, headers map[string]string, timeout time.Duration) *httpTable {
+	return &httpTable{
+		url:     url,
+		headers: headers,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+func (t *httpTable) Lookup(key string) (map[string]string, error) {
+	req, err := http.NewRequest(http.MethodGet, strings.ReplaceAll(t.url, keyPlaceholder, url.QueryEscape(key)), nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:gosec
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %v, response: %v", resp.StatusCode, string(body))
+	}
+	var object map[string]interface{}
+	if err = json.Unmarshal(body, &object); err != nil {
+		return nil, err
+	}
+	if object == nil {
+		return nil, nil
+	}
+	columns := make(map[string]string, len(object))
+	for k, v := range object {
+		switch val := v.(type) {
+		case string:
+			columns[k] = val
+		case nil:
+			columns[k] = ""
+		default:
+			bytes, _ := json.Marshal(val)
+			columns[k] = string(bytes)
+		}
+	}
+	return columns, nil
+}
+
+// redisTable reads the hash of the key by HGETALL, an empty hash is treated as a missing key.
+// The connection is reused by the lookups, and dialed again after it's broken.
+type redisTable struct {
+	address   string
+	password  string
+	db        int
+	keyPrefix string
+	timeout   time.Duration
+
+	conn net.Conn
+	rw   *bufio.ReadWriter
+}
+
+func newRedisTable(address, password string, db int, keyPrefix string, timeout time.Duration) *redisTable {
+	return &redisTable{
+		address:   address,
+		password:  password,
+		db:        db,
+		keyPrefix: keyPrefix,
+		timeout:   timeout,
+	}
+}
+
+func (t *redisTable) connect() error {
+	conn, err := net.DialTimeout("tcp", t.address, t.timeout)
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Now().Add(t.timeout))
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	if t.password != "" {
+		if _, err = helper.RedisDo(rw, "AUTH", t.password); err != nil {
+			_ = conn.Close()
+			return err
+		}
+	}
+	if t.db != 0 {
+		if _, err = helper.RedisDo(rw, "SELECT", strconv.Itoa(t.db)); err != nil {
+			_ = conn.Close()
+			return err
+		}
+	}
+	t.conn, t.rw = conn, rw
+	return nil
+}
+
+func (t *redisTable) Lookup(key string) (map[string]string, error) {
+	if t.conn == nil {
+		if err := t.connect(); err != nil {
+			return nil, err
+		}
+	}
+	_ = t.conn.SetDeadline(time.Now().Add(t.timeout))
+	reply, err := helper.RedisDo(t.rw, "HGETALL", t.keyPrefix+key)
+	if err != nil {
+		// the connection is still usable after an error reply of the server
+		var redisErr helper.RedisError
+		if !errors.As(err, &redisErr) {
+			_ = t.Close()
+		}
+		return nil, err
+	}
+	fields, ok := reply.([]interface{})
+	if !ok {
+		return nil, helper.ErrRedisProtocol
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	columns := make(map[string]string, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		name, _ := fields[i].(string)
+		value, _ := fields[i+1].(string)
+		columns[name] = value
+	}
+	return columns, nil
+}
+
+// Close closes the reused connection.
+func (t *redisTable) Close() error {
+	if t.conn == nil {
+		return nil
+	}
+	err := t.conn.Close()
+	t.conn, t.rw = nil, nil
+	return err
+}
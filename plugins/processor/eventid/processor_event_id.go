@@ -0,0 +1,150 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventid
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"strings"
+
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+const (
+	pluginName = "processor_event_id"
+
+	defaultTargetKey = "__event_id__"
+	defaultOffsetKey = "__tag__:__file_offset__"
+	tagPrefix        = "__tag__:"
+	// idBytes is the bytes of the sha256 sum kept in the id, which is 32 characters in hex.
+	idBytes = 16
+)
+
+var defaultSourceKeys = []string{"__tag__:__path__"}
+
+// ProcessorEventID adds the stable id of the event, which is the hash of the source, the offset and the contents,
+// so that the same event collected again, e.g. retried after the agent restarts, has the same id. The flushers
+// could use the id as the key of the kafka message, or the idempotency header of the http request, etc., so that
+// the downstream could remove the duplicates.
+type ProcessorEventID struct {
+	// The key of the id added to the event, "__event_id__" by default.
+	TargetKey string
+	// The keys identifying the source of the event, ["__tag__:__path__"] by default.
+	SourceKeys []string
+	// The key of the offset of the event in the source, "__tag__:__file_offset__" by default, empty means none.
+	OffsetKey *string
+	// Hash the contents of the event except the tags, true by default.
+	IncludeContents *bool
+	// Replace the id that already exists, e.g. added by the upstream agent, false by default.
+	Overwrite bool
+
+	context pipeline.Context
+	sources map[string]int
+}
+
+// Init called for init some system resources, like socket, mutex...
+func (p *ProcessorEventID) Init(context pipeline.Context) error {
+	p.context = context
+	if p.TargetKey == "" {
+		p.TargetKey = defaultTargetKey
+	}
+	if p.SourceKeys == nil {
+		p.SourceKeys = defaultSourceKeys
+	}
+	if p.OffsetKey == nil {
+		offsetKey := defaultOffsetKey
+		p.OffsetKey = &offsetKey
+	}
+	if p.IncludeContents == nil {
+		includeContents := true
+		p.IncludeContents = &includeContents
+	}
+	p.sources = make(map[string]int, len(p.SourceKeys)+1)
+	for i, key := range p.SourceKeys {
+		p.sources[key] = i
+	}
+	if *p.OffsetKey != "" {
+		p.sources[*p.OffsetKey] = len(p.SourceKeys)
+	}
+	return nil
+}
+
+func (*ProcessorEventID) Description() string {
+	return "event id processor to add the stable id of the event to remove the duplicates downstream"
+}
+
+func (p *ProcessorEventID) ProcessLogs(logArray []*protocol.Log) []*protocol.Log {
+	// the hasher is not shared, so that the processor is concurrency-safe
+	hasher := sha256.New()
+	var sum []byte
+	for _, log := range logArray {
+		sum = p.processLog(log, hasher, sum)
+	}
+	return logArray
+}
+
+func (p *ProcessorEventID) processLog(log *protocol.Log, hasher hash.Hash, sum []byte) []byte {
+	var existing *protocol.Log_Content
+	sources := make([]string, len(p.sources))
+	for _, content := range log.Contents {
+		if content.Key == p.TargetKey {
+			existing = content
+		} else if i, ok := p.sources[content.Key]; ok {
+			sources[i] = content.Value
+		}
+	}
+	if existing != nil && !p.Overwrite {
+		return sum
+	}
+
+	hasher.Reset()
+	for _, source := range sources {
+		writeValue(hasher, source)
+	}
+	if *p.IncludeContents {
+		for _, content := range log.Contents {
+			if content.Key == p.TargetKey || strings.HasPrefix(content.Key, tagPrefix) {
+				continue
+			}
+			writeValue(hasher, content.Key)
+			writeValue(hasher, content.Value)
+		}
+	}
+	sum = hasher.Sum(sum[:0])
+	id := hex.EncodeToString(sum[:idBytes])
+	if existing != nil {
+		existing.Value = id
+	} else {
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: p.TargetKey, Value: id})
+	}
+	return sum
+}
+
+// writeValue writes the length before the value, so that the different values are not hashed to the same bytes.
+func writeValue(hasher hash.Hash, value string) {
+	var length [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(length[:], uint64(len(value)))
+	_, _ = hasher.Write(length[:n])
+	_, _ = hasher.Write([]byte(value))
+}
+
+func init() {
+	pipeline.Processors[pluginName] = func() pipeline.Processor {
+		return &ProcessorEventID{}
+	}
+}
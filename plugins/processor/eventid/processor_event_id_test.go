@@ -0,0 +1,97 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventid
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+func init() {
+	logger.InitTestLogger(logger.OptionOpenMemoryReceiver)
+}
+
+func newLog(kv ...string) *protocol.Log {
+	log := &protocol.Log{Time: 1680000000}
+	for i := 0; i < len(kv); i += 2 {
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: kv[i], Value: kv[i+1]})
+	}
+	return log
+}
+
+func getID(log *protocol.Log) string {
+	for _, content := range log.Contents {
+		if content.Key == defaultTargetKey {
+			return content.Value
+		}
+	}
+	return ""
+}
+
+func TestProcessorEventID(t *testing.T) {
+	processor := &ProcessorEventID{}
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+
+	logs := processor.ProcessLogs([]*protocol.Log{
+		newLog("content", "a", "__tag__:__path__", "/var/log/a.log", "__tag__:__file_offset__", "0"),
+		newLog("content", "a", "__tag__:__path__", "/var/log/a.log", "__tag__:__file_offset__", "2"),
+		newLog("content", "a", "__tag__:__path__", "/var/log/b.log", "__tag__:__file_offset__", "0"),
+		// the tags out of the sources don't affect the id
+		newLog("content", "a", "__tag__:__path__", "/var/log/a.log", "__tag__:__file_offset__", "0", "__tag__:__hostname__", "h"),
+	})
+	ids := []string{getID(logs[0]), getID(logs[1]), getID(logs[2]), getID(logs[3])}
+	assert.Len(t, ids[0], 32)
+	assert.NotEqual(t, ids[0], ids[1])
+	assert.NotEqual(t, ids[0], ids[2])
+	assert.Equal(t, ids[0], ids[3])
+
+	// the same event collected again has the same id
+	again := processor.ProcessLogs([]*protocol.Log{newLog("content", "a", "__tag__:__path__", "/var/log/a.log", "__tag__:__file_offset__", "0")})
+	assert.Equal(t, ids[0], getID(again[0]))
+
+	// the values are framed by the length
+	logs = processor.ProcessLogs([]*protocol.Log{newLog("ab", "c"), newLog("a", "bc")})
+	assert.NotEqual(t, getID(logs[0]), getID(logs[1]))
+}
+
+func TestProcessorEventIDExisting(t *testing.T) {
+	processor := &ProcessorEventID{}
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+	logs := processor.ProcessLogs([]*protocol.Log{newLog("content", "a", defaultTargetKey, "upstream")})
+	assert.Equal(t, newLog("content", "a", defaultTargetKey, "upstream"), logs[0])
+
+	processor.Overwrite = true
+	logs = processor.ProcessLogs([]*protocol.Log{newLog("content", "a", defaultTargetKey, "upstream")})
+	assert.Len(t, logs[0].Contents, 2)
+	assert.Equal(t, getID(processor.ProcessLogs([]*protocol.Log{newLog("content", "a")})[0]), getID(logs[0]))
+}
+
+func TestProcessorEventIDWithoutContents(t *testing.T) {
+	offsetKey, includeContents := "offset", false
+	processor := &ProcessorEventID{TargetKey: "id", SourceKeys: []string{"file"}, OffsetKey: &offsetKey, IncludeContents: &includeContents}
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+	logs := processor.ProcessLogs([]*protocol.Log{
+		newLog("file", "a", "offset", "1", "content", "x"),
+		newLog("file", "a", "offset", "1", "content", "y"),
+	})
+	assert.Equal(t, "id", logs[0].Contents[3].Key)
+	assert.Equal(t, logs[0].Contents[3].Value, logs[1].Contents[3].Value)
+}
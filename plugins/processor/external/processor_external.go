@@ -0,0 +1,73 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"github.com/alibaba/ilogtail/helper/externalplugin"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+// ProcessorExternal processes the logs by the processor plugin implemented by a sidecar, see externalplugin.Options.
+type ProcessorExternal struct {
+	externalplugin.Options
+	// Drop the logs if the sidecar fails to process them, otherwise the logs are passed through.
+	DropOnError bool
+
+	context pipeline.Context
+	client  *externalplugin.Client
+}
+
+func (p *ProcessorExternal) Init(context pipeline.Context) error {
+	p.context = context
+	var err error
+	if p.client, err = externalplugin.NewClient(p.Options, externalplugin.CategoryProcessor, context.GetConfigName()); err != nil {
+		logger.Error(p.context.GetRuntimeContext(), util.AlarmExternalPlugin, "init external processor error", err)
+		return err
+	}
+	return nil
+}
+
+func (*ProcessorExternal) Description() string {
+	return "processor plugin to process the logs by the external plugin of the sidecar"
+}
+
+func (p *ProcessorExternal) ProcessLogs(logArray []*protocol.Log) []*protocol.Log {
+	if len(logArray) == 0 {
+		return logArray
+	}
+	logGroup, err := p.client.Process(&protocol.LogGroup{Logs: logArray})
+	if err != nil {
+		logger.Warning(p.context.GetRuntimeContext(), util.AlarmExternalPlugin, "process by external plugin error", err, "drop", p.DropOnError)
+		if p.DropOnError {
+			return logArray[:0]
+		}
+		return logArray
+	}
+	return logGroup.Logs
+}
+
+// Stop releases the instance of the sidecar.
+func (p *ProcessorExternal) Stop() error {
+	return p.client.Close()
+}
+
+func init() {
+	pipeline.Processors["processor_external"] = func() pipeline.Processor {
+		return &ProcessorExternal{}
+	}
+}
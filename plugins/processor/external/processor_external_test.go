@@ -0,0 +1,77 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/alibaba/ilogtail/helper/externalplugin"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+type dropProcessor struct{}
+
+func (dropProcessor) Stop() error {
+	return nil
+}
+
+// Process keeps the logs with the content "keep", and fails if there is no log to keep.
+func (dropProcessor) Process(logGroup *protocol.LogGroup) (*protocol.LogGroup, error) {
+	kept := &protocol.LogGroup{}
+	for _, log := range logGroup.Logs {
+		if log.Contents[0].Key == "keep" {
+			kept.Logs = append(kept.Logs, log)
+		}
+	}
+	if len(kept.Logs) == 0 {
+		return nil, errors.New("nothing to keep")
+	}
+	return kept, nil
+}
+
+func TestProcessorExternal(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer(externalplugin.ServerOption())
+	externalplugin.NewServer(map[string]externalplugin.Factory{
+		"drop": func(string, string) (externalplugin.Instance, error) { return dropProcessor{}, nil },
+	}).Register(server)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	processor := &ProcessorExternal{Options: externalplugin.Options{Address: listener.Addr().String(), Plugin: "drop"}}
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+	defer processor.Stop() //nolint:errcheck
+	logs := processor.ProcessLogs([]*protocol.Log{
+		{Contents: []*protocol.Log_Content{{Key: "keep", Value: "1"}}},
+		{Contents: []*protocol.Log_Content{{Key: "drop", Value: "2"}}},
+	})
+	require.Len(t, logs, 1)
+	assert.Equal(t, "1", logs[0].Contents[0].Value)
+
+	logs = []*protocol.Log{{Contents: []*protocol.Log_Content{{Key: "drop", Value: "2"}}}}
+	assert.Len(t, processor.ProcessLogs(logs), 1, "the logs should be passed through on error")
+	processor.DropOnError = true
+	assert.Len(t, processor.ProcessLogs(logs), 0)
+}
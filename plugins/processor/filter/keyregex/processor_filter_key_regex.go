@@ -22,6 +22,7 @@ import (
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 const pluginName = "processor_filter_key_regex"
@@ -45,7 +46,7 @@ func (p *ProcessorKeyFilter) Init(context pipeline.Context) error {
 		for key, val := range p.Include {
 			reg, err := regexp.Compile(val)
 			if err != nil {
-				logger.Warning(p.context.GetRuntimeContext(), "FILTER_INIT_ALARM", "init include filter error, key", key, "regex", val, "error", err)
+				logger.Warning(p.context.GetRuntimeContext(), util.AlarmFilterInit, "init include filter error, key", key, "regex", val, "error", err)
 				return err
 			}
 			p.includeRegex[key] = reg
@@ -56,7 +57,7 @@ func (p *ProcessorKeyFilter) Init(context pipeline.Context) error {
 		for key, val := range p.Exclude {
 			reg, err := regexp.Compile(val)
 			if err != nil {
-				logger.Warning(p.context.GetRuntimeContext(), "FILTER_INIT_ALARM", "init exclude filter error, key", key, "regex", val, "error", err)
+				logger.Warning(p.context.GetRuntimeContext(), util.AlarmFilterInit, "init exclude filter error, key", key, "regex", val, "error", err)
 				return err
 			}
 			p.excludeRegex[key] = reg
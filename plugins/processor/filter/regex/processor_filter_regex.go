@@ -22,6 +22,7 @@ import (
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 const pluginName = "processor_filter_regex"
@@ -50,7 +51,7 @@ func (p *ProcessorRegexFilter) Init(context pipeline.Context) error {
 		for key, val := range p.Include {
 			reg, err := regexp.Compile(val)
 			if err != nil {
-				logger.Warning(p.context.GetRuntimeContext(), "FILTER_INIT_ALARM", "init include filter error, key", key, "regex", val, "error", err)
+				logger.Warning(p.context.GetRuntimeContext(), util.AlarmFilterInit, "init include filter error, key", key, "regex", val, "error", err)
 				return err
 			}
 			p.includeRegex[key] = reg
@@ -61,7 +62,7 @@ func (p *ProcessorRegexFilter) Init(context pipeline.Context) error {
 		for key, val := range p.Exclude {
 			reg, err := regexp.Compile(val)
 			if err != nil {
-				logger.Warning(p.context.GetRuntimeContext(), "FILTER_INIT_ALARM", "init exclude filter error, key", key, "regex", val, "error", err)
+				logger.Warning(p.context.GetRuntimeContext(), util.AlarmFilterInit, "init exclude filter error, key", key, "regex", val, "error", err)
 				return err
 			}
 			p.excludeRegex[key] = reg
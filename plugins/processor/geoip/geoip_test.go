@@ -13,3 +13,58 @@
 // limitations under the License.
 
 package geoip
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+func TestInitWithInvalidDB(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "invalid.mmdb")
+	require.NoError(t, os.WriteFile(path, []byte("invalid"), 0600))
+
+	p := &ProcessorGeoIP{DBPath: filepath.Join(dir, "not_exist.mmdb")}
+	require.Error(t, p.Init(mock.NewEmptyContext("p", "l", "c")))
+	p = &ProcessorGeoIP{DBPath: path}
+	require.Error(t, p.Init(mock.NewEmptyContext("p", "l", "c")))
+}
+
+func TestReloadIfChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "city.mmdb")
+	require.NoError(t, os.WriteFile(path, []byte("invalid"), 0600))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	db := &geoDB{path: path, modTime: info.ModTime(), lastCheck: time.Now()}
+
+	// not reach the interval
+	reloaded, err := db.reloadIfChanged(time.Now(), time.Minute)
+	require.NoError(t, err)
+	require.False(t, reloaded)
+	// disabled
+	reloaded, err = db.reloadIfChanged(time.Now().Add(time.Hour), 0)
+	require.NoError(t, err)
+	require.False(t, reloaded)
+	// file not modified
+	reloaded, err = db.reloadIfChanged(time.Now().Add(time.Hour), time.Minute)
+	require.NoError(t, err)
+	require.False(t, reloaded)
+
+	// modified to a broken file, the modification time is recorded to avoid reopening
+	modTime := info.ModTime().Add(time.Second)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+	reloaded, err = db.reloadIfChanged(time.Now().Add(2*time.Hour), time.Minute)
+	require.Error(t, err)
+	require.False(t, reloaded)
+	require.Nil(t, db.reader)
+	require.True(t, db.modTime.Equal(modTime))
+	reloaded, err = db.reloadIfChanged(time.Now().Add(3*time.Hour), time.Minute)
+	require.NoError(t, err)
+	require.False(t, reloaded)
+}
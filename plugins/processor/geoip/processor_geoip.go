@@ -21,33 +21,93 @@ import (
 	"github.com/alibaba/ilogtail/pkg/util"
 
 	"net"
+	"os"
 	"strconv"
+	"time"
 
 	"github.com/oschwald/geoip2-golang"
 )
 
+// geoDB holds a mmdb reader and reopens it when the modification time of the file changes,
+// so that databases updated by tools such as geoipupdate take effect without restarting.
+type geoDB struct {
+	path      string
+	reader    *geoip2.Reader
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+func openGeoDB(path string) (*geoDB, error) {
+	db := &geoDB{path: path}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if db.reader, err = geoip2.Open(path); err != nil {
+		return nil, err
+	}
+	db.modTime = info.ModTime()
+	db.lastCheck = time.Now()
+	return db, nil
+}
+
+// reloadIfChanged checks the database file at most once per interval, and swaps the reader
+// if the file has been modified. The old reader is kept when the new file cannot be opened.
+func (db *geoDB) reloadIfChanged(now time.Time, interval time.Duration) (bool, error) {
+	if interval <= 0 || now.Sub(db.lastCheck) < interval {
+		return false, nil
+	}
+	db.lastCheck = now
+	info, err := os.Stat(db.path)
+	if err != nil {
+		return false, err
+	}
+	if info.ModTime().Equal(db.modTime) {
+		return false, nil
+	}
+	// record the modification time anyway to avoid reopening a broken file repeatedly
+	db.modTime = info.ModTime()
+	reader, err := geoip2.Open(db.path)
+	if err != nil {
+		return false, err
+	}
+	if db.reader != nil {
+		_ = db.reader.Close()
+	}
+	db.reader = reader
+	return true, nil
+}
+
 // ProcessorGeoIP is a processor plugin to insert geographical information into log according
 // to IP address specified by SourceKey.
 // DBPath and related Language must be set because plugin does not contain any GeoIP database,
 // the type of database should be mmdb.
 // NoProvince/City/... are used to control the information granularity.
 // The keys of geographical information will be prefixed with SourceKey, such as SourceKey_city_.
+// ASNDBPath is optional, when set the autonomous system number and organization are also added
+// with the keys SourceKey_asn_ and SourceKey_asn_org_.
+// The databases are reopened when the files are modified, which is checked every ReloadIntervalSec seconds,
+// and a non-positive value disables the reloading.
 type ProcessorGeoIP struct {
-	NoProvince    bool
-	NoCity        bool
-	NoCountry     bool
-	NoCountryCode bool
-	NoCoordinate  bool
-	IPValueFlag   bool
-	NoKeyError    bool
-	NoMatchError  bool
-	KeepSource    bool
-	DBPath        string
-	SourceKey     string
-	Language      string
+	NoProvince        bool
+	NoCity            bool
+	NoCountry         bool
+	NoCountryCode     bool
+	NoCoordinate      bool
+	NoASN             bool
+	IPValueFlag       bool
+	NoKeyError        bool
+	NoMatchError      bool
+	KeepSource        bool
+	DBPath            string
+	ASNDBPath         string
+	SourceKey         string
+	Language          string
+	ReloadIntervalSec int
 
 	context       pipeline.Context
-	db            *geoip2.Reader
+	db            *geoDB
+	asnDB         *geoDB
 	sourceIP      bool
 	sourceIPConts []*protocol.Log_Content
 }
@@ -56,9 +116,14 @@ type ProcessorGeoIP struct {
 func (p *ProcessorGeoIP) Init(context pipeline.Context) error {
 	p.context = context
 	var err error
-	if p.db, err = geoip2.Open(p.DBPath); err != nil {
+	if p.db, err = openGeoDB(p.DBPath); err != nil {
 		return err
 	}
+	if p.ASNDBPath != "" && !p.NoASN {
+		if p.asnDB, err = openGeoDB(p.ASNDBPath); err != nil {
+			return err
+		}
+	}
 	if p.SourceKey == "__source__" {
 		p.sourceIP = true
 	}
@@ -73,12 +138,33 @@ func (p *ProcessorGeoIP) ProcessLogs(logArray []*protocol.Log) []*protocol.Log {
 	if p.db == nil {
 		return logArray
 	}
+	p.reloadDBs()
 	for _, log := range logArray {
 		p.ProcessLog(log)
 	}
 	return logArray
 }
 
+func (p *ProcessorGeoIP) reloadDBs() {
+	now := time.Now()
+	interval := time.Duration(p.ReloadIntervalSec) * time.Second
+	for _, db := range []*geoDB{p.db, p.asnDB} {
+		if db == nil {
+			continue
+		}
+		reloaded, err := db.reloadIfChanged(now, interval)
+		if err != nil {
+			logger.Warning(p.context.GetRuntimeContext(), util.AlarmGeoIP, "reload database", db.path, "error", err)
+			continue
+		}
+		if reloaded {
+			// the cached geographical information of local ip may be outdated
+			p.sourceIPConts = nil
+			logger.Info(p.context.GetRuntimeContext(), "reload geoip database", db.path)
+		}
+	}
+}
+
 func (p *ProcessorGeoIP) ProcessLog(log *protocol.Log) {
 	if p.sourceIP {
 		if len(p.sourceIPConts) == 0 {
@@ -104,7 +190,7 @@ func (p *ProcessorGeoIP) ProcessLog(log *protocol.Log) {
 		}
 	}
 	if !findKey && p.NoKeyError {
-		logger.Warning(p.context.GetRuntimeContext(), "GEOIP_ALARM", "cannot find key", p.SourceKey)
+		logger.Warning(p.context.GetRuntimeContext(), util.AlarmGeoIP, "cannot find key", p.SourceKey)
 	}
 }
 
@@ -127,13 +213,16 @@ func (p *ProcessorGeoIP) ProcessGeoIP(log *protocol.Log, val *string) {
 	}
 	if ip == nil {
 		if p.NoMatchError {
-			logger.Warning(p.context.GetRuntimeContext(), "GEOIP_ALARM", "invalid ip", *val)
+			logger.Warning(p.context.GetRuntimeContext(), util.AlarmGeoIP, "invalid ip", *val)
 		}
 		return
 	}
-	record, err := p.db.City(ip)
-	if err != nil && p.NoMatchError {
-		logger.Warning(p.context.GetRuntimeContext(), "GEOIP_ALARM", "parse ip", ip, "error", err)
+	p.processASN(log, ip)
+	record, err := p.db.reader.City(ip)
+	if err != nil {
+		if p.NoMatchError {
+			logger.Warning(p.context.GetRuntimeContext(), util.AlarmGeoIP, "parse ip", ip, "error", err)
+		}
 		return
 	}
 
@@ -161,14 +250,32 @@ func (p *ProcessorGeoIP) ProcessGeoIP(log *protocol.Log, val *string) {
 		log.Contents = append(log.Contents, &protocol.Log_Content{Key: p.SourceKey + "_longitude_", Value: strconv.FormatFloat(record.Location.Longitude, 'f', 8, 64)})
 		log.Contents = append(log.Contents, &protocol.Log_Content{Key: p.SourceKey + "_latitude_", Value: strconv.FormatFloat(record.Location.Latitude, 'f', 8, 64)})
 	}
+}
 
+func (p *ProcessorGeoIP) processASN(log *protocol.Log, ip net.IP) {
+	if p.asnDB == nil {
+		return
+	}
+	record, err := p.asnDB.reader.ASN(ip)
+	if err != nil {
+		if p.NoMatchError {
+			logger.Warning(p.context.GetRuntimeContext(), util.AlarmGeoIP, "parse asn of ip", ip, "error", err)
+		}
+		return
+	}
+	if record.AutonomousSystemNumber == 0 {
+		return
+	}
+	log.Contents = append(log.Contents, &protocol.Log_Content{Key: p.SourceKey + "_asn_", Value: strconv.FormatUint(uint64(record.AutonomousSystemNumber), 10)})
+	log.Contents = append(log.Contents, &protocol.Log_Content{Key: p.SourceKey + "_asn_org_", Value: record.AutonomousSystemOrganization})
 }
 
 func init() {
 	pipeline.Processors["processor_geoip"] = func() pipeline.Processor {
 		return &ProcessorGeoIP{
-			KeepSource: true,
-			Language:   "zh-CN",
+			KeepSource:        true,
+			Language:          "zh-CN",
+			ReloadIntervalSec: 60,
 		}
 	}
 }
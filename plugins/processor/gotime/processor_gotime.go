@@ -22,6 +22,7 @@ import (
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 const (
@@ -114,7 +115,7 @@ func (p *ProcessorGotime) processLog(log *protocol.Log) {
 			if p.timestampFormat {
 				i, err := strconv.ParseInt(content.Value, 10, 64)
 				if err != nil && p.AlarmIfFail {
-					logger.Warningf(p.context.GetRuntimeContext(), "GOTIME_PARSE_ALARM", "ParseInt(%v, %v) failed: %v",
+					logger.Warningf(p.context.GetRuntimeContext(), util.AlarmGoTimeParse, "ParseInt(%v, %v) failed: %v",
 						p.SourceFormat, content.Value, err)
 					return
 				}
@@ -122,7 +123,7 @@ func (p *ProcessorGotime) processLog(log *protocol.Log) {
 			} else {
 				parsedStringTime, err := time.ParseInLocation(p.SourceFormat, content.Value, p.sourceLocation)
 				if err != nil && p.AlarmIfFail {
-					logger.Warningf(p.context.GetRuntimeContext(), "GOTIME_PARSE_ALARM", "ParseInLocation(%v, %v, %v) failed: %v",
+					logger.Warningf(p.context.GetRuntimeContext(), util.AlarmGoTimeParse, "ParseInLocation(%v, %v, %v) failed: %v",
 						p.SourceFormat, content.Value, p.sourceLocation, err)
 					return
 				}
@@ -148,7 +149,7 @@ func (p *ProcessorGotime) processLog(log *protocol.Log) {
 		}
 	}
 	if !found && p.NoKeyError {
-		logger.Warningf(p.context.GetRuntimeContext(), "GOTIME_FIND_ALARM", "cannot find key %v", p.SourceKey)
+		logger.Warningf(p.context.GetRuntimeContext(), util.AlarmGoTimeFind, "cannot find key %v", p.SourceKey)
 	}
 }
 
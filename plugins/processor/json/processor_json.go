@@ -22,6 +22,7 @@ import (
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 type ProcessorJSON struct {
@@ -78,7 +79,7 @@ func (p *ProcessorJSON) processLog(log *protocol.Log) {
 			}
 			err := jsonparser.ObjectEach([]byte(objectVal), param.ExpandJSONCallBack)
 			if err != nil {
-				logger.Errorf(p.context.GetRuntimeContext(), "PROCESSOR_JSON_PARSER_ALARM", "parser json error %v", err)
+				logger.Errorf(p.context.GetRuntimeContext(), util.AlarmProcessorJSONParser, "parser json error %v", err)
 			}
 			if !p.shouldKeepSource(err) {
 				log.Contents = append(log.Contents[:idx], log.Contents[idx+1:]...)
@@ -88,7 +89,7 @@ func (p *ProcessorJSON) processLog(log *protocol.Log) {
 		}
 	}
 	if !findKey && p.NoKeyError {
-		logger.Warningf(p.context.GetRuntimeContext(), "PROCESSOR_JSON_FIND_ALARM", "cannot find key %v", p.SourceKey)
+		logger.Warningf(p.context.GetRuntimeContext(), util.AlarmProcessorJSONFind, "cannot find key %v", p.SourceKey)
 	}
 }
 
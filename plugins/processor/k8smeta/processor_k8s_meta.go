@@ -0,0 +1,181 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8smeta
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/alibaba/ilogtail/helper/k8smeta"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const pluginName = "processor_k8s_meta"
+
+const allLabels = "*"
+
+var fieldGetters = map[string]func(*k8smeta.PodMeta) string{
+	"namespace":     func(m *k8smeta.PodMeta) string { return m.Namespace },
+	"pod":           func(m *k8smeta.PodMeta) string { return m.Name },
+	"pod_uid":       func(m *k8smeta.PodMeta) string { return m.UID },
+	"pod_ip":        func(m *k8smeta.PodMeta) string { return m.IP },
+	"node":          func(m *k8smeta.PodMeta) string { return m.NodeName },
+	"workload_kind": func(m *k8smeta.PodMeta) string { return m.WorkloadKind },
+	"workload_name": func(m *k8smeta.PodMeta) string { return m.WorkloadName },
+	"services":      func(m *k8smeta.PodMeta) string { return strings.Join(m.Services, ",") },
+}
+
+// podLookup is implemented by k8smeta.MetaManager.
+type podLookup interface {
+	PodByIP(ip string) *k8smeta.PodMeta
+	PodByContainerID(containerID string) *k8smeta.PodMeta
+	PodByName(namespace, name string) *k8smeta.PodMeta
+}
+
+// ProcessorK8sMeta attaches the metadata of the pod which a log belongs to, the pod is looked up by
+// the value of PodIPKey, ContainerIDKey, or NamespaceKey and PodNameKey, in order.
+// The metadata is read from the kubernetes metadata cache shared by all the plugins with the same
+// KubeConfigPath and LabelSelector.
+type ProcessorK8sMeta struct {
+	PodIPKey       string
+	ContainerIDKey string
+	NamespaceKey   string
+	PodNameKey     string
+	// Fields could be namespace, pod, pod_uid, pod_ip, node, workload_kind, workload_name and services.
+	Fields []string
+	// The label keys to attach, * means all labels.
+	PodLabels       []string
+	NodeLabels      []string
+	NamespaceLabels []string
+	Prefix          string
+	KubeConfigPath  string
+	LabelSelector   string
+	NoMatchError    bool
+
+	context pipeline.Context
+	manager podLookup
+	// the shared manager acquired by Init, which is released when the processor is stopped.
+	shared *k8smeta.MetaManager
+}
+
+// Init called for init some system resources, like socket, mutex...
+func (p *ProcessorK8sMeta) Init(context pipeline.Context) error {
+	p.context = context
+	if p.PodIPKey == "" && p.ContainerIDKey == "" && (p.NamespaceKey == "" || p.PodNameKey == "") {
+		return fmt.Errorf("must specify PodIPKey, ContainerIDKey, or both NamespaceKey and PodNameKey for plugin %v", pluginName)
+	}
+	for _, field := range p.Fields {
+		if _, ok := fieldGetters[field]; !ok {
+			return fmt.Errorf("invalid field %v for plugin %v", field, pluginName)
+		}
+	}
+	if p.manager == nil {
+		manager, err := k8smeta.GetMetaManager(k8smeta.Options{KubeConfigPath: p.KubeConfigPath, LabelSelector: p.LabelSelector})
+		if err != nil {
+			return err
+		}
+		p.manager = manager
+		p.shared = manager
+	}
+	return nil
+}
+
+// Stop releases the shared metadata cache, whose informers are stopped when no plugin uses it.
+func (p *ProcessorK8sMeta) Stop() error {
+	if p.shared != nil {
+		k8smeta.ReleaseMetaManager(p.shared)
+		p.shared = nil
+	}
+	return nil
+}
+
+func (*ProcessorK8sMeta) Description() string {
+	return "k8s meta processor for logtail, which attaches the metadata of pods to logs"
+}
+
+func (p *ProcessorK8sMeta) ProcessLogs(logArray []*protocol.Log) []*protocol.Log {
+	for _, log := range logArray {
+		p.processLog(log)
+	}
+	return logArray
+}
+
+func (p *ProcessorK8sMeta) processLog(log *protocol.Log) {
+	var podIP, containerID, namespace, podName string
+	for _, cont := range log.Contents {
+		switch cont.Key {
+		case p.PodIPKey:
+			podIP = cont.Value
+		case p.ContainerIDKey:
+			containerID = cont.Value
+		case p.NamespaceKey:
+			namespace = cont.Value
+		case p.PodNameKey:
+			podName = cont.Value
+		}
+	}
+	var meta *k8smeta.PodMeta
+	if p.PodIPKey != "" {
+		meta = p.manager.PodByIP(podIP)
+	}
+	if meta == nil && p.ContainerIDKey != "" {
+		meta = p.manager.PodByContainerID(containerID)
+	}
+	if meta == nil && namespace != "" && podName != "" {
+		meta = p.manager.PodByName(namespace, podName)
+	}
+	if meta == nil {
+		if p.NoMatchError {
+			logger.Warning(p.context.GetRuntimeContext(), util.AlarmK8SMeta, "cannot find pod of ip", podIP, "container id", containerID,
+				"namespace", namespace, "pod", podName)
+		}
+		return
+	}
+	for _, field := range p.Fields {
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: p.Prefix + field, Value: fieldGetters[field](meta)})
+	}
+	log.Contents = appendLabels(log.Contents, p.Prefix+"pod_label_", p.PodLabels, meta.Labels)
+	log.Contents = appendLabels(log.Contents, p.Prefix+"node_label_", p.NodeLabels, meta.NodeLabels)
+	log.Contents = appendLabels(log.Contents, p.Prefix+"namespace_label_", p.NamespaceLabels, meta.NamespaceLabels)
+}
+
+func appendLabels(contents []*protocol.Log_Content, prefix string, keys []string, labels map[string]string) []*protocol.Log_Content {
+	if len(keys) == 1 && keys[0] == allLabels {
+		keys = make([]string, 0, len(labels))
+		for key := range labels {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+	}
+	for _, key := range keys {
+		if value, ok := labels[key]; ok {
+			contents = append(contents, &protocol.Log_Content{Key: prefix + key, Value: value})
+		}
+	}
+	return contents
+}
+
+func init() {
+	pipeline.Processors[pluginName] = func() pipeline.Processor {
+		return &ProcessorK8sMeta{
+			Fields: []string{"namespace", "pod", "node", "workload_kind", "workload_name"},
+			Prefix: "_k8s_",
+		}
+	}
+}
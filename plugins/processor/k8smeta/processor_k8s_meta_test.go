@@ -0,0 +1,125 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8smeta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/helper/k8smeta"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+var nginxPod = &k8smeta.PodMeta{
+	Namespace:       "default",
+	Name:            "nginx-6799fc88d8-abcde",
+	IP:              "10.0.0.1",
+	NodeName:        "node-1",
+	Labels:          map[string]string{"app": "nginx", "version": "v1"},
+	WorkloadKind:    "Deployment",
+	WorkloadName:    "nginx",
+	Services:        []string{"nginx-svc", "nginx-headless"},
+	NodeLabels:      map[string]string{"zone": "a"},
+	NamespaceLabels: map[string]string{"env": "prod"},
+}
+
+type fakeLookup struct{}
+
+func (fakeLookup) PodByIP(ip string) *k8smeta.PodMeta {
+	if ip == nginxPod.IP {
+		return nginxPod
+	}
+	return nil
+}
+
+func (fakeLookup) PodByContainerID(containerID string) *k8smeta.PodMeta {
+	if containerID == "containerd://abc123" {
+		return nginxPod
+	}
+	return nil
+}
+
+func (fakeLookup) PodByName(namespace, name string) *k8smeta.PodMeta {
+	if namespace == nginxPod.Namespace && name == nginxPod.Name {
+		return nginxPod
+	}
+	return nil
+}
+
+func newLog(kvs ...string) *protocol.Log {
+	log := &protocol.Log{}
+	for i := 0; i+1 < len(kvs); i += 2 {
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: kvs[i], Value: kvs[i+1]})
+	}
+	return log
+}
+
+func contents(log *protocol.Log) map[string]string {
+	m := make(map[string]string, len(log.Contents))
+	for _, cont := range log.Contents {
+		m[cont.Key] = cont.Value
+	}
+	return m
+}
+
+func TestInvalidConfig(t *testing.T) {
+	ctx := mock.NewEmptyContext("p", "l", "c")
+	assert.Error(t, (&ProcessorK8sMeta{NamespaceKey: "ns", manager: fakeLookup{}}).Init(ctx))
+	assert.Error(t, (&ProcessorK8sMeta{PodIPKey: "ip", Fields: []string{"cluster"}, manager: fakeLookup{}}).Init(ctx))
+}
+
+func TestProcessLogs(t *testing.T) {
+	processor := &ProcessorK8sMeta{
+		PodIPKey:        "remote_addr",
+		ContainerIDKey:  "container_id",
+		NamespaceKey:    "ns",
+		PodNameKey:      "pod",
+		Fields:          []string{"namespace", "pod", "node", "workload_kind", "workload_name", "services"},
+		PodLabels:       []string{"app", "missing"},
+		NodeLabels:      []string{"*"},
+		NamespaceLabels: []string{"env"},
+		Prefix:          "_k8s_",
+		manager:         fakeLookup{},
+	}
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+	logs := processor.ProcessLogs([]*protocol.Log{
+		newLog("remote_addr", "10.0.0.1"),
+		newLog("remote_addr", "10.0.0.2", "container_id", "containerd://abc123"),
+		newLog("ns", "default", "pod", "nginx-6799fc88d8-abcde"),
+		newLog("remote_addr", "10.0.0.2"),
+	})
+	require.Len(t, logs, 4)
+	expected := map[string]string{
+		"_k8s_namespace":           "default",
+		"_k8s_pod":                 "nginx-6799fc88d8-abcde",
+		"_k8s_node":                "node-1",
+		"_k8s_workload_kind":       "Deployment",
+		"_k8s_workload_name":       "nginx",
+		"_k8s_services":            "nginx-svc,nginx-headless",
+		"_k8s_pod_label_app":       "nginx",
+		"_k8s_node_label_zone":     "a",
+		"_k8s_namespace_label_env": "prod",
+	}
+	for i, log := range logs[:3] {
+		for key, value := range expected {
+			assert.Equal(t, value, contents(log)[key], "log %v key %v", i, key)
+		}
+	}
+	assert.Len(t, logs[0].Contents, 1+len(expected))
+	assert.Equal(t, map[string]string{"remote_addr": "10.0.0.2"}, contents(logs[3]))
+}
@@ -0,0 +1,366 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logtometric
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const pluginName = "processor_log_to_metric"
+
+const (
+	metricTypeCounter   = "counter"
+	metricTypeHistogram = "histogram"
+)
+
+// defaultBuckets is the same as the default buckets of prometheus client.
+var defaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// MetricConfig defines a metric extracted from logs.
+// The metric is updated by the logs matching all Filters, and the values of LabelKeys are used as labels.
+// A counter is increased by 1 for each log, or by the value of ValueKey if set.
+// A histogram observes the value of ValueKey, which is required.
+type MetricConfig struct {
+	Name      string
+	Type      string
+	Filters   map[string]string
+	ValueKey  string
+	LabelKeys []string
+	Buckets   []float64
+
+	filters map[string]*regexp.Regexp
+	series  map[string]*series
+	// the series updated in the open windows in EventTime mode, indexed by the start of windows
+	windows map[int64]map[string]*series
+}
+
+type series struct {
+	labels    util.Labels
+	value     float64
+	histogram *util.HistogramData
+}
+
+// ProcessorLogToMetric extracts counters and histograms from logs, and emits them as metric logs
+// every IntervalSec seconds, so that SLOs could be computed without indexing raw logs.
+// The values are cumulative, like the counters and histograms of prometheus.
+// The metrics are emitted by Tick once the interval passes, even if no logs arrive, or appended to the first batch
+// after the interval.
+// In EventTime mode, logs update the windows of IntervalSec seconds by their time, and the metrics are emitted
+// with the time of the end of each window once the watermark passes it, so that delayed or replayed logs are
+// counted in the windows they belong to.
+type ProcessorLogToMetric struct {
+	Metrics []*MetricConfig
+	// Constant labels added to all metrics.
+	Labels      map[string]string
+	IntervalSec int
+	// Drop the raw logs after extracting metrics.
+	DropSource bool
+	// Max series count of each metric, the logs of new series are ignored when exceeded.
+	MaxSeries int
+	// Aggregate logs by the windows of their time instead of the arrival time.
+	EventTime bool
+	// How late in seconds the logs could be in EventTime mode, the later logs are ignored.
+	AllowedLatenessSec int
+
+	context   pipeline.Context
+	labels    util.Labels
+	lastEmit  time.Time
+	interval  time.Duration
+	nowFunc   func() time.Time
+	overLimit bool
+	windows   *helper.EventTimeWindows
+	lateLogs  int
+}
+
+// Init called for init some system resources, like socket, mutex...
+func (p *ProcessorLogToMetric) Init(context pipeline.Context) error {
+	p.context = context
+	if len(p.Metrics) == 0 {
+		return fmt.Errorf("must specify Metrics for plugin %v", pluginName)
+	}
+	for _, m := range p.Metrics {
+		if m.Name == "" {
+			return fmt.Errorf("must specify Name of metrics for plugin %v", pluginName)
+		}
+		switch m.Type {
+		case "", metricTypeCounter:
+			m.Type = metricTypeCounter
+		case metricTypeHistogram:
+			if m.ValueKey == "" {
+				return fmt.Errorf("must specify ValueKey of histogram %v for plugin %v", m.Name, pluginName)
+			}
+			if len(m.Buckets) == 0 {
+				m.Buckets = defaultBuckets
+			}
+			sort.Float64s(m.Buckets)
+		default:
+			return fmt.Errorf("invalid type %v of metric %v, you can only use \"counter\" or \"histogram\"", m.Type, m.Name)
+		}
+		m.filters = make(map[string]*regexp.Regexp, len(m.Filters))
+		for key, pattern := range m.Filters {
+			reg, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid filter %v of metric %v: %v", pattern, m.Name, err)
+			}
+			m.filters[key] = reg
+		}
+		m.series = make(map[string]*series)
+		m.windows = make(map[int64]map[string]*series)
+	}
+	p.labels = p.labels[:0]
+	for k, v := range p.Labels {
+		p.labels = append(p.labels, util.Label{Name: k, Value: v})
+	}
+	if p.IntervalSec <= 0 {
+		return fmt.Errorf("invalid IntervalSec %v for plugin %v", p.IntervalSec, pluginName)
+	}
+	p.interval = time.Duration(p.IntervalSec) * time.Second
+	if p.nowFunc == nil {
+		p.nowFunc = time.Now
+	}
+	p.lastEmit = p.nowFunc()
+	if p.EventTime {
+		if p.AllowedLatenessSec < 0 {
+			return fmt.Errorf("invalid AllowedLatenessSec %v for plugin %v", p.AllowedLatenessSec, pluginName)
+		}
+		p.windows = helper.NewEventTimeWindows(p.IntervalSec, p.AllowedLatenessSec, p.nowFunc)
+	}
+	return nil
+}
+
+func (*ProcessorLogToMetric) Description() string {
+	return "log to metric processor for logtail, which extracts counters and histograms from logs"
+}
+
+func (p *ProcessorLogToMetric) ProcessLogs(logArray []*protocol.Log) []*protocol.Log {
+	result := logArray[:0]
+	for _, log := range logArray {
+		matched := false
+		start, inWindow := int64(0), true
+		if p.windows != nil {
+			if start, inWindow = p.windows.Assign(int64(log.Time)); !inWindow {
+				p.lateLogs++
+			}
+		}
+		for _, m := range p.Metrics {
+			target := m.series
+			if p.windows != nil && inWindow {
+				if target = m.windows[start]; target == nil {
+					target = make(map[string]*series)
+					m.windows[start] = target
+				}
+			}
+			if p.processLog(m, log, target, inWindow) {
+				matched = true
+			}
+		}
+		if !matched || !p.DropSource {
+			result = append(result, log)
+		}
+	}
+	return append(result, p.Tick()...)
+}
+
+// Tick emits the metrics if the interval passes, or the closed windows in EventTime mode.
+func (p *ProcessorLogToMetric) Tick() []*protocol.Log {
+	if p.windows != nil {
+		return p.emitClosedWindows()
+	}
+	now := p.nowFunc()
+	if now.Sub(p.lastEmit) < p.interval {
+		return nil
+	}
+	p.lastEmit = now
+	return p.emit(now)
+}
+
+// processLog updates the series in target with the log if it matches the metric, and only checks the match
+// if update is false.
+func (p *ProcessorLogToMetric) processLog(m *MetricConfig, log *protocol.Log, target map[string]*series, update bool) bool {
+	matchedFilters := 0
+	valueStr, hasValue := "", false
+	labelValues := make([]string, len(m.LabelKeys))
+	for _, cont := range log.Contents {
+		if reg, ok := m.filters[cont.Key]; ok {
+			if !reg.MatchString(cont.Value) {
+				return false
+			}
+			matchedFilters++
+		}
+		if cont.Key == m.ValueKey {
+			valueStr, hasValue = cont.Value, true
+		}
+		for i, key := range m.LabelKeys {
+			if cont.Key == key {
+				labelValues[i] = cont.Value
+			}
+		}
+	}
+	if matchedFilters != len(m.filters) {
+		return false
+	}
+	value := 1.0
+	if m.ValueKey != "" {
+		if !hasValue {
+			return false
+		}
+		var err error
+		if value, err = strconv.ParseFloat(valueStr, 64); err != nil {
+			logger.Debug(p.context.GetRuntimeContext(), "invalid value", valueStr, "of metric", m.Name)
+			return false
+		}
+	}
+	if !update {
+		return true
+	}
+	seriesKey := strings.Join(labelValues, "\x00")
+	s, ok := target[seriesKey]
+	if !ok {
+		if p.MaxSeries > 0 && len(target) >= p.MaxSeries {
+			if !p.overLimit {
+				p.overLimit = true
+				logger.Warning(p.context.GetRuntimeContext(), util.AlarmLogToMetric, "series count exceeds", p.MaxSeries, "metric", m.Name)
+			}
+			return true
+		}
+		labels := make(util.Labels, 0, len(p.labels)+len(m.LabelKeys))
+		labels = append(labels, p.labels...)
+		for i, key := range m.LabelKeys {
+			labels = append(labels, util.Label{Name: key, Value: labelValues[i]})
+		}
+		sort.Sort(labels)
+		s = newSeries(m, labels)
+		target[seriesKey] = s
+	}
+	if s.histogram == nil {
+		s.value += value
+		return true
+	}
+	// buckets are cumulative as prometheus
+	for i := range s.histogram.Buckets {
+		if value <= s.histogram.Buckets[i].Le {
+			s.histogram.Buckets[i].Count++
+		}
+	}
+	s.histogram.Count++
+	s.histogram.Sum += value
+	return true
+}
+
+func newSeries(m *MetricConfig, labels util.Labels) *series {
+	s := &series{labels: labels}
+	if m.Type == metricTypeHistogram {
+		s.histogram = &util.HistogramData{Buckets: make([]util.DefBucket, len(m.Buckets))}
+		for i, le := range m.Buckets {
+			s.histogram.Buckets[i].Le = le
+		}
+	}
+	return s
+}
+
+// merge adds the values of the series in a window to the cumulative series.
+func (s *series) merge(w *series) {
+	if s.histogram == nil {
+		s.value += w.value
+		return
+	}
+	for i := range s.histogram.Buckets {
+		s.histogram.Buckets[i].Count += w.histogram.Buckets[i].Count
+	}
+	s.histogram.Count += w.histogram.Count
+	s.histogram.Sum += w.histogram.Sum
+}
+
+// emitClosedWindows merges the closed windows into the cumulative series in the order of time,
+// and emits the metrics at the end of each window.
+func (p *ProcessorLogToMetric) emitClosedWindows() []*protocol.Log {
+	var starts []int64
+	for _, m := range p.Metrics {
+		for start := range m.windows {
+			if p.windows.Closed(start) {
+				starts = append(starts, start)
+			}
+		}
+	}
+	if p.lateLogs > 0 && len(starts) > 0 {
+		logger.Warning(p.context.GetRuntimeContext(), util.AlarmLogToMetric, "ignore logs later than the watermark, count", p.lateLogs)
+		p.lateLogs = 0
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+	var metrics []*protocol.Log
+	for i, start := range starts {
+		if i > 0 && start == starts[i-1] {
+			continue
+		}
+		for _, m := range p.Metrics {
+			for key, w := range m.windows[start] {
+				s, ok := m.series[key]
+				if !ok {
+					if p.MaxSeries > 0 && len(m.series) >= p.MaxSeries {
+						continue
+					}
+					s = newSeries(m, w.labels)
+					m.series[key] = s
+				}
+				s.merge(w)
+			}
+			delete(m.windows, start)
+		}
+		metrics = append(metrics, p.emit(time.Unix(start+p.windows.Size(), 0))...)
+	}
+	return metrics
+}
+
+func (p *ProcessorLogToMetric) emit(now time.Time) []*protocol.Log {
+	var metrics []*protocol.Log
+	timeMs := now.UnixNano() / int64(time.Millisecond)
+	for _, m := range p.Metrics {
+		keys := make([]string, 0, len(m.series))
+		for k := range m.series {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			s := m.series[k]
+			if s.histogram != nil {
+				metrics = append(metrics, s.histogram.ToMetricLogs(m.Name, timeMs, s.labels)...)
+			} else {
+				metrics = append(metrics, util.NewMetricLog(m.Name, timeMs, strconv.FormatFloat(s.value, 'g', -1, 64), s.labels))
+			}
+		}
+	}
+	p.overLimit = false
+	return metrics
+}
+
+func init() {
+	pipeline.Processors[pluginName] = func() pipeline.Processor {
+		return &ProcessorLogToMetric{
+			IntervalSec: 60,
+			MaxSeries:   10000,
+		}
+	}
+}
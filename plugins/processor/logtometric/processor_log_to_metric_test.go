@@ -0,0 +1,185 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logtometric
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+func newLog(kvs ...string) *protocol.Log {
+	log := &protocol.Log{}
+	for i := 0; i+1 < len(kvs); i += 2 {
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: kvs[i], Value: kvs[i+1]})
+	}
+	return log
+}
+
+func metricValues(logs []*protocol.Log) map[string]string {
+	values := make(map[string]string)
+	for _, log := range logs {
+		var name, labels, value string
+		for _, cont := range log.Contents {
+			switch cont.Key {
+			case "__name__":
+				name = cont.Value
+			case "__labels__":
+				labels = cont.Value
+			case "__value__":
+				value = cont.Value
+			}
+		}
+		values[name+"{"+labels+"}"] = value
+	}
+	return values
+}
+
+func TestInvalidConfig(t *testing.T) {
+	ctx := mock.NewEmptyContext("p", "l", "c")
+	require.Error(t, (&ProcessorLogToMetric{IntervalSec: 60}).Init(ctx))
+	require.Error(t, (&ProcessorLogToMetric{IntervalSec: 60, Metrics: []*MetricConfig{{Name: "latency", Type: metricTypeHistogram}}}).Init(ctx))
+	require.Error(t, (&ProcessorLogToMetric{IntervalSec: 60, Metrics: []*MetricConfig{{Name: "requests", Type: "gauge"}}}).Init(ctx))
+	require.Error(t, (&ProcessorLogToMetric{IntervalSec: 60, Metrics: []*MetricConfig{{Name: "requests", Filters: map[string]string{"status": "("}}}}).Init(ctx))
+}
+
+func TestLogToMetric(t *testing.T) {
+	now := time.Unix(1660000000, 0)
+	processor := &ProcessorLogToMetric{
+		Metrics: []*MetricConfig{
+			{
+				Name:      "http_requests_total",
+				Filters:   map[string]string{"status": "."},
+				LabelKeys: []string{"method", "status"},
+			},
+			{
+				Name:      "http_errors_total",
+				Filters:   map[string]string{"status": "^5\\d\\d$"},
+				LabelKeys: []string{"method"},
+			},
+			{
+				Name:     "http_request_duration_seconds",
+				Type:     metricTypeHistogram,
+				ValueKey: "latency",
+				Buckets:  []float64{1, 0.1},
+			},
+		},
+		Labels:      map[string]string{"cluster": "c1"},
+		IntervalSec: 60,
+		DropSource:  true,
+		nowFunc:     func() time.Time { return now },
+	}
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+
+	logs := processor.ProcessLogs([]*protocol.Log{
+		newLog("method", "GET", "status", "200", "latency", "0.05"),
+		newLog("method", "GET", "status", "500", "latency", "0.5"),
+		newLog("method", "POST", "status", "200", "latency", "2"),
+		newLog("method", "GET", "status", "200", "latency", "invalid"),
+		newLog("message", "not matched"),
+	})
+	// raw logs are dropped except the unmatched one, and metrics are not emitted before the interval
+	require.Len(t, logs, 1)
+
+	// the metrics are emitted by the timer without logs
+	assert.Empty(t, processor.Tick())
+	now = now.Add(time.Minute)
+	logs = processor.Tick()
+	assert.Equal(t, map[string]string{
+		"http_requests_total{cluster#$#c1|method#$#GET|status#$#200}":  "2",
+		"http_requests_total{cluster#$#c1|method#$#GET|status#$#500}":  "1",
+		"http_requests_total{cluster#$#c1|method#$#POST|status#$#200}": "1",
+		"http_errors_total{cluster#$#c1|method#$#GET}":                 "1",
+		"http_request_duration_seconds_bucket{cluster#$#c1|le#$#0.1}":  "1",
+		"http_request_duration_seconds_bucket{cluster#$#c1|le#$#1}":    "2",
+		"http_request_duration_seconds_count{cluster#$#c1}":            "3",
+		"http_request_duration_seconds_sum{cluster#$#c1}":              "2.55",
+	}, metricValues(logs))
+
+	// values are cumulative
+	now = now.Add(time.Minute)
+	logs = processor.ProcessLogs([]*protocol.Log{newLog("method", "GET", "status", "500", "latency", "0.01")})
+	values := metricValues(logs)
+	assert.Equal(t, "2", values["http_errors_total{cluster#$#c1|method#$#GET}"])
+	assert.Equal(t, "4", values["http_request_duration_seconds_count{cluster#$#c1}"])
+}
+
+func TestMaxSeries(t *testing.T) {
+	now := time.Unix(1660000000, 0)
+	processor := &ProcessorLogToMetric{
+		Metrics:     []*MetricConfig{{Name: "requests", LabelKeys: []string{"path"}}},
+		IntervalSec: 60,
+		MaxSeries:   1,
+		nowFunc:     func() time.Time { return now },
+	}
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+	logs := processor.ProcessLogs([]*protocol.Log{newLog("path", "/a"), newLog("path", "/b"), newLog("path", "/a")})
+	require.Len(t, logs, 3)
+	now = now.Add(time.Minute)
+	logs = processor.ProcessLogs([]*protocol.Log{})
+	assert.Equal(t, map[string]string{"requests{path#$#/a}": "2"}, metricValues(logs))
+}
+
+func TestEventTime(t *testing.T) {
+	now := time.Unix(1660000100, 0)
+	processor := &ProcessorLogToMetric{
+		Metrics: []*MetricConfig{
+			{
+				Name:      "http_requests_total",
+				LabelKeys: []string{"status"},
+			},
+		},
+		IntervalSec:        60,
+		DropSource:         true,
+		EventTime:          true,
+		AllowedLatenessSec: 30,
+		nowFunc:            func() time.Time { return now },
+	}
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+
+	// the start of a window
+	const base = 1660000020
+	newTimedLog := func(status string, offset uint32) *protocol.Log {
+		log := newLog("status", status)
+		log.Time = base + offset
+		return log
+	}
+	// replayed logs of two windows arrive at once
+	assert.Empty(t, processor.ProcessLogs([]*protocol.Log{newTimedLog("200", 0), newTimedLog("200", 10), newTimedLog("500", 70)}))
+
+	// the watermark passes the end of the first window
+	logs := processor.ProcessLogs([]*protocol.Log{newTimedLog("200", 100)})
+	require.Len(t, logs, 1)
+	assert.Equal(t, uint32(base+60), logs[0].Time)
+	assert.Equal(t, map[string]string{"http_requests_total{status#$#200}": "2"}, metricValues(logs))
+
+	// a log later than the watermark is ignored, and a delayed log within the lateness is counted
+	assert.Empty(t, processor.ProcessLogs([]*protocol.Log{newTimedLog("200", 50), newTimedLog("500", 75)}))
+
+	// the watermark moves on while idle
+	now = now.Add(time.Minute)
+	logs = processor.ProcessLogs(nil)
+	require.Len(t, logs, 2)
+	assert.Equal(t, uint32(base+120), logs[0].Time)
+	assert.Equal(t, map[string]string{
+		"http_requests_total{status#$#200}": "3",
+		"http_requests_total{status#$#500}": "2",
+	}, metricValues(logs))
+}
@@ -0,0 +1,291 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logtospan
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const pluginName = "processor_log_to_span"
+
+const (
+	timeFormatUnix   = "unix"
+	timeFormatUnixMs = "unix_ms"
+	timeFormatUnixUs = "unix_us"
+	timeFormatUnixNs = "unix_ns"
+)
+
+var durationUnits = map[string]float64{
+	"s":  1e6,
+	"ms": 1e3,
+	"us": 1,
+	"ns": 1e-3,
+}
+
+// ProcessorLogToSpan constructs spans from access logs of nginx, envoy and so on, so that they could be
+// analyzed as traces. The spans are in the same format as the spans received by the trace inputs,
+// i.e. the times are in microseconds, and the attributes and resources are JSON objects.
+//
+// The start time is read from StartTimeKey, or computed by EndTimeKey and DurationKey when StartTimeKey is not set.
+// The format of time could be unix, unix_ms, unix_us, unix_ns or a go time layout, and unix allows fractions
+// such as 1660000000.123 of nginx $msec.
+// The trace ids and span ids are generated when they are not present in logs.
+type ProcessorLogToSpan struct {
+	StartTimeKey    string
+	EndTimeKey      string
+	TimeFormat      string
+	DurationKey     string
+	DurationUnit    string
+	TraceIDKey      string
+	SpanIDKey       string
+	ParentSpanIDKey string
+	// The values of NameKeys are joined by space as span name, e.g. method and path.
+	NameKeys []string
+	// Use the value of ServiceKey as service, and Service if not found.
+	ServiceKey string
+	Service    string
+	Kind       string
+	// The status code is ERROR when the value of StatusKey is a number not less than ErrorStatusFrom.
+	StatusKey       string
+	ErrorStatusFrom int
+	// Contents copied as attributes, all the other contents are copied when empty.
+	AttributeKeys []string
+	KeepSource    bool
+
+	context       pipeline.Context
+	attributeKeys map[string]struct{}
+	usedKeys      map[string]struct{}
+	durationUnit  float64
+	random        *rand.Rand
+}
+
+// Init called for init some system resources, like socket, mutex...
+func (p *ProcessorLogToSpan) Init(context pipeline.Context) error {
+	p.context = context
+	if p.StartTimeKey == "" && (p.EndTimeKey == "" || p.DurationKey == "") {
+		return fmt.Errorf("must specify StartTimeKey, or both EndTimeKey and DurationKey for plugin %v", pluginName)
+	}
+	var ok bool
+	if p.durationUnit, ok = durationUnits[p.DurationUnit]; !ok {
+		return fmt.Errorf("invalid duration unit %v, you can only use \"s\", \"ms\", \"us\" or \"ns\"", p.DurationUnit)
+	}
+	if _, ok = models.SpanKindValues[models.SpanKindText(p.Kind)]; !ok {
+		return fmt.Errorf("invalid span kind %v for plugin %v", p.Kind, pluginName)
+	}
+	if len(p.AttributeKeys) > 0 {
+		p.attributeKeys = make(map[string]struct{}, len(p.AttributeKeys))
+		for _, key := range p.AttributeKeys {
+			p.attributeKeys[key] = struct{}{}
+		}
+	}
+	p.usedKeys = make(map[string]struct{})
+	for _, key := range append([]string{p.StartTimeKey, p.EndTimeKey, p.DurationKey, p.TraceIDKey, p.SpanIDKey, p.ParentSpanIDKey, p.ServiceKey}, p.NameKeys...) {
+		if key != "" {
+			p.usedKeys[key] = struct{}{}
+		}
+	}
+	p.random = rand.New(rand.NewSource(time.Now().UnixNano())) //nolint:gosec
+	return nil
+}
+
+func (*ProcessorLogToSpan) Description() string {
+	return "log to span processor for logtail, which constructs spans from access logs"
+}
+
+func (p *ProcessorLogToSpan) ProcessLogs(logArray []*protocol.Log) []*protocol.Log {
+	result := make([]*protocol.Log, 0, len(logArray))
+	for _, log := range logArray {
+		span, err := p.toSpan(log)
+		if err != nil {
+			logger.Warning(p.context.GetRuntimeContext(), util.AlarmLogToSpan, "convert log to span error", err)
+			result = append(result, log)
+			continue
+		}
+		if p.KeepSource {
+			result = append(result, log)
+		}
+		result = append(result, span)
+	}
+	return result
+}
+
+func (p *ProcessorLogToSpan) toSpan(log *protocol.Log) (*protocol.Log, error) {
+	values := make(map[string]string, len(log.Contents))
+	attributes := make(map[string]string)
+	for _, cont := range log.Contents {
+		values[cont.Key] = cont.Value
+		if p.attributeKeys != nil {
+			if _, ok := p.attributeKeys[cont.Key]; ok {
+				attributes[cont.Key] = cont.Value
+			}
+		} else if _, ok := p.usedKeys[cont.Key]; !ok {
+			attributes[cont.Key] = cont.Value
+		}
+	}
+
+	var start, end, duration int64
+	var err error
+	if p.DurationKey != "" {
+		value, ok := values[p.DurationKey]
+		if !ok {
+			return nil, fmt.Errorf("cannot find duration key %v", p.DurationKey)
+		}
+		var d float64
+		if d, err = strconv.ParseFloat(value, 64); err != nil {
+			return nil, fmt.Errorf("invalid duration %v: %v", value, err)
+		}
+		duration = int64(math.Round(d * p.durationUnit))
+	}
+	if p.StartTimeKey != "" {
+		if start, err = p.parseTime(values, p.StartTimeKey); err != nil {
+			return nil, err
+		}
+	}
+	if p.EndTimeKey != "" {
+		if end, err = p.parseTime(values, p.EndTimeKey); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case p.StartTimeKey == "":
+		start = end - duration
+	case p.EndTimeKey == "":
+		end = start + duration
+	case p.DurationKey == "":
+		duration = end - start
+	}
+
+	names := make([]string, 0, len(p.NameKeys))
+	for _, key := range p.NameKeys {
+		if v, ok := values[key]; ok {
+			names = append(names, v)
+		}
+	}
+	service := p.Service
+	if v, ok := values[p.ServiceKey]; ok && p.ServiceKey != "" {
+		service = v
+	}
+	traceID := values[p.TraceIDKey]
+	if traceID == "" {
+		traceID = p.newID(16)
+	}
+	spanID := values[p.SpanIDKey]
+	if spanID == "" {
+		spanID = p.newID(8)
+	}
+	statusCode := "OK"
+	if p.StatusKey != "" {
+		if status, err := strconv.Atoi(values[p.StatusKey]); err == nil && status >= p.ErrorStatusFrom {
+			statusCode = "ERROR"
+		}
+	}
+	attributeJSON, err := json.Marshal(attributes)
+	if err != nil {
+		return nil, err
+	}
+	resourceJSON, err := json.Marshal(map[string]string{"service.name": service})
+	if err != nil {
+		return nil, err
+	}
+
+	span := &protocol.Log{Time: uint32(end / 1e6)}
+	if end == 0 {
+		span.Time = log.Time
+	}
+	span.Contents = []*protocol.Log_Content{
+		{Key: "host", Value: util.GetHostName()},
+		{Key: "service", Value: service},
+		{Key: "resource", Value: string(resourceJSON)},
+		{Key: "name", Value: strings.Join(names, " ")},
+		{Key: "kind", Value: p.Kind},
+		{Key: "traceID", Value: traceID},
+		{Key: "spanID", Value: spanID},
+		{Key: "parentSpanID", Value: values[p.ParentSpanIDKey]},
+		{Key: "links", Value: "[]"},
+		{Key: "logs", Value: "[]"},
+		{Key: "traceState", Value: ""},
+		{Key: "start", Value: strconv.FormatInt(start, 10)},
+		{Key: "end", Value: strconv.FormatInt(end, 10)},
+		{Key: "duration", Value: strconv.FormatInt(duration, 10)},
+		{Key: "attribute", Value: string(attributeJSON)},
+		{Key: "statusCode", Value: statusCode},
+		{Key: "statusMessage", Value: ""},
+	}
+	return span, nil
+}
+
+// parseTime returns the time of key in microseconds.
+func (p *ProcessorLogToSpan) parseTime(values map[string]string, key string) (int64, error) {
+	value, ok := values[key]
+	if !ok {
+		return 0, fmt.Errorf("cannot find time key %v", key)
+	}
+	switch p.TimeFormat {
+	case timeFormatUnix:
+		t, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid time %v: %v", value, err)
+		}
+		return int64(math.Round(t * 1e6)), nil
+	case timeFormatUnixMs, timeFormatUnixUs, timeFormatUnixNs:
+		t, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid time %v: %v", value, err)
+		}
+		switch p.TimeFormat {
+		case timeFormatUnixMs:
+			return t * 1e3, nil
+		case timeFormatUnixNs:
+			return t / 1e3, nil
+		}
+		return t, nil
+	default:
+		t, err := time.Parse(p.TimeFormat, value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid time %v: %v", value, err)
+		}
+		return t.UnixNano() / 1e3, nil
+	}
+}
+
+func (p *ProcessorLogToSpan) newID(size int) string {
+	id := make([]byte, size)
+	_, _ = p.random.Read(id)
+	return hex.EncodeToString(id)
+}
+
+func init() {
+	pipeline.Processors[pluginName] = func() pipeline.Processor {
+		return &ProcessorLogToSpan{
+			TimeFormat:      timeFormatUnix,
+			DurationUnit:    "s",
+			Kind:            models.SpanKindTextServer,
+			ErrorStatusFrom: 500,
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logtospan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+func newLog(kvs ...string) *protocol.Log {
+	log := &protocol.Log{Time: 1660000000}
+	for i := 0; i+1 < len(kvs); i += 2 {
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: kvs[i], Value: kvs[i+1]})
+	}
+	return log
+}
+
+func contents(log *protocol.Log) map[string]string {
+	m := make(map[string]string, len(log.Contents))
+	for _, cont := range log.Contents {
+		m[cont.Key] = cont.Value
+	}
+	return m
+}
+
+func newProcessor(modify func(p *ProcessorLogToSpan)) (*ProcessorLogToSpan, error) {
+	p := pluginCreator()
+	modify(p)
+	return p, p.Init(mock.NewEmptyContext("p", "l", "c"))
+}
+
+func pluginCreator() *ProcessorLogToSpan {
+	return &ProcessorLogToSpan{
+		TimeFormat:      timeFormatUnix,
+		DurationUnit:    "s",
+		Kind:            "server",
+		ErrorStatusFrom: 500,
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	_, err := newProcessor(func(p *ProcessorLogToSpan) { p.EndTimeKey = "msec" })
+	require.Error(t, err)
+	_, err = newProcessor(func(p *ProcessorLogToSpan) { p.StartTimeKey = "start"; p.DurationUnit = "m" })
+	require.Error(t, err)
+	_, err = newProcessor(func(p *ProcessorLogToSpan) { p.StartTimeKey = "start"; p.Kind = "unknown" })
+	require.Error(t, err)
+}
+
+func TestNginxAccessLog(t *testing.T) {
+	processor, err := newProcessor(func(p *ProcessorLogToSpan) {
+		p.EndTimeKey = "msec"
+		p.DurationKey = "request_time"
+		p.NameKeys = []string{"method", "uri"}
+		p.Service = "nginx"
+		p.StatusKey = "status"
+	})
+	require.NoError(t, err)
+	logs := processor.ProcessLogs([]*protocol.Log{
+		newLog("msec", "1660000001.500", "request_time", "0.250", "method", "GET", "uri", "/api", "status", "502"),
+		newLog("msec", "invalid", "request_time", "0.250"),
+	})
+	require.Len(t, logs, 2)
+
+	span := contents(logs[0])
+	assert.Equal(t, uint32(1660000001), logs[0].Time)
+	assert.Equal(t, "1660000001250000", span["start"])
+	assert.Equal(t, "1660000001500000", span["end"])
+	assert.Equal(t, "250000", span["duration"])
+	assert.Equal(t, "GET /api", span["name"])
+	assert.Equal(t, "nginx", span["service"])
+	assert.Equal(t, "server", span["kind"])
+	assert.Equal(t, "ERROR", span["statusCode"])
+	assert.Equal(t, `{"status":"502"}`, span["attribute"])
+	assert.Len(t, span["traceID"], 32)
+	assert.Len(t, span["spanID"], 16)
+	assert.Equal(t, "", span["parentSpanID"])
+
+	// the log failed to convert is kept
+	assert.Equal(t, "invalid", contents(logs[1])["msec"])
+}
+
+func TestEnvoyAccessLog(t *testing.T) {
+	processor, err := newProcessor(func(p *ProcessorLogToSpan) {
+		p.StartTimeKey = "start_time"
+		p.TimeFormat = "2006-01-02T15:04:05.000Z07:00"
+		p.DurationKey = "duration"
+		p.DurationUnit = "ms"
+		p.TraceIDKey = "trace_id"
+		p.SpanIDKey = "span_id"
+		p.ParentSpanIDKey = "parent_span_id"
+		p.ServiceKey = "upstream_cluster"
+		p.Kind = "client"
+		p.StatusKey = "response_code"
+		p.AttributeKeys = []string{"response_code", "path"}
+		p.KeepSource = true
+	})
+	require.NoError(t, err)
+	logs := processor.ProcessLogs([]*protocol.Log{
+		newLog("start_time", "2022-08-09T00:00:00.100Z", "duration", "12", "trace_id", "4bf92f3577b34da6a3ce929d0e0e4736",
+			"span_id", "00f067aa0ba902b7", "parent_span_id", "a3ce929d0e0e4736", "upstream_cluster", "backend",
+			"response_code", "200", "path", "/users", "user_agent", "curl"),
+	})
+	require.Len(t, logs, 2)
+	assert.Equal(t, "curl", contents(logs[0])["user_agent"])
+
+	span := contents(logs[1])
+	assert.Equal(t, "1660003200100000", span["start"])
+	assert.Equal(t, "1660003200112000", span["end"])
+	assert.Equal(t, "12000", span["duration"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span["traceID"])
+	assert.Equal(t, "00f067aa0ba902b7", span["spanID"])
+	assert.Equal(t, "a3ce929d0e0e4736", span["parentSpanID"])
+	assert.Equal(t, "backend", span["service"])
+	assert.Equal(t, `{"service.name":"backend"}`, span["resource"])
+	assert.Equal(t, "client", span["kind"])
+	assert.Equal(t, "OK", span["statusCode"])
+	assert.Equal(t, `{"path":"/users","response_code":"200"}`, span["attribute"])
+}
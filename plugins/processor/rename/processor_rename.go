@@ -21,6 +21,7 @@ import (
 	"github.com/alibaba/ilogtail/pkg/models"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 type ProcessorRename struct {
@@ -94,7 +95,7 @@ func (p *ProcessorRename) processLog(log *protocol.Log) {
 	}
 	if p.NoKeyError {
 		if errorArray := p.checkNoKeyError(); errorArray != nil {
-			logger.Warningf(p.context.GetRuntimeContext(), "RENAME_FIND_ALARM", "cannot find key %v", errorArray)
+			logger.Warningf(p.context.GetRuntimeContext(), util.AlarmRenameFind, "cannot find key %v", errorArray)
 		}
 	}
 }
@@ -120,7 +121,7 @@ func (p *ProcessorRename) Process(in *models.PipelineGroupEvents, context pipeli
 	context.Collector().Collect(in.Group, in.Events...)
 	if p.NoKeyError {
 		if errorArray := p.checkNoKeyError(); errorArray != nil {
-			logger.Warningf(p.context.GetRuntimeContext(), "RENAME_FIND_ALARM", "cannot find key %v", errorArray)
+			logger.Warningf(p.context.GetRuntimeContext(), util.AlarmRenameFind, "cannot find key %v", errorArray)
 		}
 	}
 }
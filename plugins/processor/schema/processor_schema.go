@@ -0,0 +1,234 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const pluginName = "processor_schema"
+
+const (
+	typeString    = "string"
+	typeInt       = "int"
+	typeFloat     = "float"
+	typeBool      = "bool"
+	typeTimestamp = "timestamp"
+)
+
+const (
+	actionTag  = "tag"
+	actionDrop = "drop"
+	actionKeep = "keep"
+)
+
+// Field declares the schema of a content.
+// The value of a timestamp field must be in the format of TimeFormat, and is coerced to unix seconds.
+type Field struct {
+	Key        string
+	Type       string
+	Required   bool
+	Enum       []string
+	Default    string
+	TimeFormat string
+
+	enum map[string]struct{}
+}
+
+// ProcessorSchema validates logs against the declared fields, to prevent bad data from poisoning
+// typed columns of the backends such as ClickHouse.
+// When Coerce is true, the convertible values are rewritten to the canonical form of the type, e.g. " 12.0 " to 12 for int.
+// The invalid logs are handled according to InvalidAction:
+//   - tag: add InvalidKey with the reasons, so that they could be routed by the key, e.g. with aggregator_logstore_router.
+//   - drop: drop the logs.
+//   - keep: keep the logs as they are.
+type ProcessorSchema struct {
+	Fields []*Field
+	Coerce bool
+	// Remove the contents not declared in Fields.
+	DropUnknownKeys bool
+	InvalidAction   string
+	InvalidKey      string
+	NoMatchError    bool
+
+	context pipeline.Context
+	fields  map[string]*Field
+}
+
+// Init called for init some system resources, like socket, mutex...
+func (p *ProcessorSchema) Init(context pipeline.Context) error {
+	p.context = context
+	if len(p.Fields) == 0 {
+		return fmt.Errorf("must specify Fields for plugin %v", pluginName)
+	}
+	switch p.InvalidAction {
+	case actionTag:
+		if p.InvalidKey == "" {
+			return fmt.Errorf("must specify InvalidKey for plugin %v", pluginName)
+		}
+	case actionDrop, actionKeep:
+	default:
+		return fmt.Errorf("invalid action %v, you can only use \"tag\", \"drop\" or \"keep\" as InvalidAction", p.InvalidAction)
+	}
+	p.fields = make(map[string]*Field, len(p.Fields))
+	for _, f := range p.Fields {
+		if f.Key == "" {
+			return fmt.Errorf("must specify Key of fields for plugin %v", pluginName)
+		}
+		switch f.Type {
+		case "":
+			f.Type = typeString
+		case typeString, typeInt, typeFloat, typeBool:
+		case typeTimestamp:
+			if f.TimeFormat == "" {
+				f.TimeFormat = time.RFC3339
+			}
+		default:
+			return fmt.Errorf("invalid type %v of field %v for plugin %v", f.Type, f.Key, pluginName)
+		}
+		if len(f.Enum) > 0 {
+			f.enum = make(map[string]struct{}, len(f.Enum))
+			for _, e := range f.Enum {
+				f.enum[e] = struct{}{}
+			}
+		}
+		p.fields[f.Key] = f
+	}
+	return nil
+}
+
+func (*ProcessorSchema) Description() string {
+	return "schema processor for logtail, which validates and coerces the contents of logs"
+}
+
+func (p *ProcessorSchema) ProcessLogs(logArray []*protocol.Log) []*protocol.Log {
+	result := logArray[:0]
+	for _, log := range logArray {
+		reasons := p.processLog(log)
+		if len(reasons) == 0 {
+			result = append(result, log)
+			continue
+		}
+		if p.NoMatchError {
+			logger.Warning(p.context.GetRuntimeContext(), util.AlarmSchema, "invalid log", strings.Join(reasons, "; "))
+		}
+		switch p.InvalidAction {
+		case actionTag:
+			log.Contents = append(log.Contents, &protocol.Log_Content{Key: p.InvalidKey, Value: strings.Join(reasons, "; ")})
+			result = append(result, log)
+		case actionKeep:
+			result = append(result, log)
+		}
+	}
+	return result
+}
+
+// processLog validates and coerces the contents, and returns the reasons of invalidation.
+func (p *ProcessorSchema) processLog(log *protocol.Log) []string {
+	var reasons []string
+	found := make(map[string]struct{}, len(p.fields))
+	contents := log.Contents[:0]
+	for _, cont := range log.Contents {
+		f, ok := p.fields[cont.Key]
+		if !ok {
+			if !p.DropUnknownKeys {
+				contents = append(contents, cont)
+			}
+			continue
+		}
+		found[cont.Key] = struct{}{}
+		contents = append(contents, cont)
+		value, err := f.convert(cont.Value)
+		if err != nil {
+			reasons = append(reasons, err.Error())
+			continue
+		}
+		if p.Coerce {
+			cont.Value = value
+		}
+		if f.enum != nil {
+			if _, ok := f.enum[value]; !ok {
+				reasons = append(reasons, fmt.Sprintf("%v: %v is not in enum", f.Key, cont.Value))
+			}
+		}
+	}
+	log.Contents = contents
+	for _, f := range p.Fields {
+		if _, ok := found[f.Key]; ok {
+			continue
+		}
+		if f.Default != "" {
+			log.Contents = append(log.Contents, &protocol.Log_Content{Key: f.Key, Value: f.Default})
+		} else if f.Required {
+			reasons = append(reasons, fmt.Sprintf("%v: required", f.Key))
+		}
+	}
+	return reasons
+}
+
+// convert returns the canonical form of value, or an error if value is not convertible to the type.
+func (f *Field) convert(value string) (string, error) {
+	trimmed := strings.TrimSpace(value)
+	switch f.Type {
+	case typeInt:
+		if i, err := strconv.ParseInt(trimmed, 10, 64); err == nil {
+			return strconv.FormatInt(i, 10), nil
+		}
+		// integral float such as 12.0 or 1e3
+		if v, err := strconv.ParseFloat(trimmed, 64); err == nil && v == math.Trunc(v) && math.Abs(v) < math.MaxInt64 {
+			return strconv.FormatInt(int64(v), 10), nil
+		}
+	case typeFloat:
+		if v, err := strconv.ParseFloat(trimmed, 64); err == nil && !math.IsNaN(v) && !math.IsInf(v, 0) {
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		}
+	case typeBool:
+		switch strings.ToLower(trimmed) {
+		case "true", "1", "yes", "on":
+			return "true", nil
+		case "false", "0", "no", "off":
+			return "false", nil
+		}
+	case typeTimestamp:
+		if t, err := time.Parse(f.TimeFormat, trimmed); err == nil {
+			return strconv.FormatInt(t.Unix(), 10), nil
+		}
+		if _, err := strconv.ParseInt(trimmed, 10, 64); err == nil {
+			return trimmed, nil
+		}
+	default:
+		return value, nil
+	}
+	return value, fmt.Errorf("%v: %v is not %v", f.Key, value, f.Type)
+}
+
+func init() {
+	pipeline.Processors[pluginName] = func() pipeline.Processor {
+		return &ProcessorSchema{
+			Coerce:        true,
+			InvalidAction: actionTag,
+			InvalidKey:    "__schema_error__",
+		}
+	}
+}
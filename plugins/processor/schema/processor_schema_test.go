@@ -0,0 +1,117 @@
+// Copyright 2022 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+func newLog(kvs ...string) *protocol.Log {
+	log := &protocol.Log{}
+	for i := 0; i+1 < len(kvs); i += 2 {
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: kvs[i], Value: kvs[i+1]})
+	}
+	return log
+}
+
+func contents(log *protocol.Log) map[string]string {
+	m := make(map[string]string, len(log.Contents))
+	for _, cont := range log.Contents {
+		m[cont.Key] = cont.Value
+	}
+	return m
+}
+
+func newProcessor(action string) (*ProcessorSchema, error) {
+	processor := &ProcessorSchema{
+		Fields: []*Field{
+			{Key: "status", Type: typeInt, Required: true},
+			{Key: "latency", Type: typeFloat},
+			{Key: "cached", Type: typeBool, Default: "false"},
+			{Key: "level", Enum: []string{"info", "warn", "error"}},
+			{Key: "time", Type: typeTimestamp},
+		},
+		Coerce:        true,
+		InvalidAction: action,
+		InvalidKey:    "__schema_error__",
+	}
+	return processor, processor.Init(mock.NewEmptyContext("p", "l", "c"))
+}
+
+func TestInvalidConfig(t *testing.T) {
+	ctx := mock.NewEmptyContext("p", "l", "c")
+	require.Error(t, (&ProcessorSchema{InvalidAction: actionTag, InvalidKey: "err"}).Init(ctx))
+	require.Error(t, (&ProcessorSchema{Fields: []*Field{{Key: "a"}}, InvalidAction: "route"}).Init(ctx))
+	require.Error(t, (&ProcessorSchema{Fields: []*Field{{Key: "a"}}, InvalidAction: actionTag}).Init(ctx))
+	require.Error(t, (&ProcessorSchema{Fields: []*Field{{Key: "a", Type: "uint"}}, InvalidAction: actionDrop}).Init(ctx))
+}
+
+func TestCoerce(t *testing.T) {
+	processor, err := newProcessor(actionTag)
+	require.NoError(t, err)
+	logs := processor.ProcessLogs([]*protocol.Log{
+		newLog("status", " 200.0 ", "latency", "1.50", "cached", "TRUE", "level", "info", "time", "2022-08-09T00:00:00Z", "other", "x"),
+	})
+	require.Len(t, logs, 1)
+	assert.Equal(t, map[string]string{
+		"status":  "200",
+		"latency": "1.5",
+		"cached":  "true",
+		"level":   "info",
+		"time":    "1660003200",
+		"other":   "x",
+	}, contents(logs[0]))
+
+	// defaults are filled, and unknown keys are dropped
+	processor.DropUnknownKeys = true
+	logs = processor.ProcessLogs([]*protocol.Log{newLog("status", "404", "other", "x")})
+	assert.Equal(t, map[string]string{"status": "404", "cached": "false"}, contents(logs[0]))
+}
+
+func TestInvalidActions(t *testing.T) {
+	newLogs := func() []*protocol.Log {
+		return []*protocol.Log{
+			newLog("status", "200"),
+			newLog("status", "OK", "level", "debug"),
+			newLog("latency", "1"),
+		}
+	}
+
+	processor, err := newProcessor(actionTag)
+	require.NoError(t, err)
+	logs := processor.ProcessLogs(newLogs())
+	require.Len(t, logs, 3)
+	assert.NotContains(t, contents(logs[0]), "__schema_error__")
+	assert.Equal(t, "status: OK is not int; level: debug is not in enum", contents(logs[1])["__schema_error__"])
+	assert.Equal(t, "status: required", contents(logs[2])["__schema_error__"])
+
+	processor, err = newProcessor(actionDrop)
+	require.NoError(t, err)
+	logs = processor.ProcessLogs(newLogs())
+	require.Len(t, logs, 1)
+	assert.Equal(t, "200", contents(logs[0])["status"])
+
+	processor, err = newProcessor(actionKeep)
+	require.NoError(t, err)
+	logs = processor.ProcessLogs(newLogs())
+	require.Len(t, logs, 3)
+	assert.Equal(t, "OK", contents(logs[1])["status"])
+}
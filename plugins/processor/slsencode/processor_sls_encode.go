@@ -0,0 +1,81 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slsencode
+
+import (
+	"strconv"
+
+	"github.com/alibaba/ilogtail/helper/encoder/sls"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const pluginName = "processor_sls_encode"
+
+// ProcessorSLSEncode packs the events of each group into one SLS LogGroup in protobuf, which replaces the events as
+// a single ByteArray event. So the raw forward pipelines, such as flusher_http with the raw protocol, could relay the
+// data to another iLogtail or to the SLS compatible endpoints without converting it again.
+// The compress type and the raw size of the body are added to the group metadata with the names of the SLS headers,
+// which could be referenced by the flusher headers like %{metadata.x-log-bodyrawsize}.
+// It works only in the v2 pipeline.
+type ProcessorSLSEncode struct {
+	Compress bool // Compress the LogGroup in lz4, true by default.
+
+	context pipeline.Context
+}
+
+// Init called for init some system resources, like socket, mutex...
+func (p *ProcessorSLSEncode) Init(context pipeline.Context) error {
+	p.context = context
+	return nil
+}
+
+func (*ProcessorSLSEncode) Description() string {
+	return "sls log group encode processor for logtail"
+}
+
+func (p *ProcessorSLSEncode) Process(in *models.PipelineGroupEvents, context pipeline.PipelineContext) {
+	if len(in.Events) == 0 {
+		return
+	}
+	encoded, err := sls.Encode(in, p.Compress)
+	if err != nil {
+		logger.Warningf(p.context.GetRuntimeContext(), util.AlarmProcessorSLSEncode, "encode sls log group error %v", err)
+		return
+	}
+	if in.Group == nil {
+		in.Group = models.NewGroup(models.NewMetadata(), models.NewTags())
+	} else if in.Group.Metadata == nil {
+		in.Group.Metadata = models.NewMetadata()
+	}
+	compressType := ""
+	if encoded.Compressed {
+		compressType = sls.CompressTypeLZ4
+	}
+	in.Group.Metadata.Add(sls.HeaderCompressType, compressType)
+	in.Group.Metadata.Add(sls.HeaderBodyRawSize, strconv.Itoa(encoded.RawSize))
+	in.Events = []models.PipelineEvent{models.ByteArray(encoded.Data)}
+	context.Collector().Collect(in.Group, in.Events...)
+}
+
+func init() {
+	pipeline.Processors[pluginName] = func() pipeline.Processor {
+		return &ProcessorSLSEncode{
+			Compress: true,
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slsencode
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/pierrec/lz4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/helper/encoder/sls"
+	"github.com/alibaba/ilogtail/pkg/models"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+func TestProcess(t *testing.T) {
+	processor := pipeline.Processors[pluginName]().(*ProcessorSLSEncode)
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+
+	line := strings.Repeat("GET /index.html 200 ", 50)
+	context := pipeline.NewObservePipelineConext(10)
+	processor.Process(&models.PipelineGroupEvents{
+		Group:  models.NewGroup(nil, models.NewTagsWithKeyValues("host", "a")),
+		Events: []models.PipelineEvent{models.ByteArray(line), models.ByteArray(line)},
+	}, context)
+	processor.Process(&models.PipelineGroupEvents{
+		Group:  models.NewGroup(models.NewMetadata(), models.NewTags()),
+		Events: []models.PipelineEvent{&models.Span{}},
+	}, context)
+
+	groups := context.Collector().ToArray()
+	require.Len(t, groups, 1)
+	require.Len(t, groups[0].Events, 1)
+	metadata := groups[0].Group.GetMetadata()
+	assert.Equal(t, sls.CompressTypeLZ4, metadata.Get(sls.HeaderCompressType))
+	rawSize, err := strconv.Atoi(metadata.Get(sls.HeaderBodyRawSize))
+	require.NoError(t, err)
+
+	data := make([]byte, rawSize)
+	_, err = lz4.UncompressBlock(groups[0].Events[0].(models.ByteArray), data)
+	require.NoError(t, err)
+	logGroup := &protocol.LogGroup{}
+	require.NoError(t, logGroup.Unmarshal(data))
+	require.Len(t, logGroup.Logs, 2)
+	assert.Equal(t, line, logGroup.Logs[0].Contents[0].Value)
+	assert.Equal(t, []*protocol.LogTag{{Key: "host", Value: "a"}}, logGroup.LogTags)
+}
+
+func TestProcessWithoutCompress(t *testing.T) {
+	processor := pipeline.Processors[pluginName]().(*ProcessorSLSEncode)
+	processor.Compress = false
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+
+	context := pipeline.NewObservePipelineConext(10)
+	processor.Process(&models.PipelineGroupEvents{
+		Group:  models.NewGroup(models.NewMetadata(), models.NewTags()),
+		Events: []models.PipelineEvent{models.ByteArray("hello")},
+	}, context)
+	groups := context.Collector().ToArray()
+	require.Len(t, groups, 1)
+	assert.Equal(t, "", groups[0].Group.GetMetadata().Get(sls.HeaderCompressType))
+	logGroup := &protocol.LogGroup{}
+	require.NoError(t, logGroup.Unmarshal(groups[0].Events[0].(models.ByteArray)))
+	assert.Equal(t, "hello", logGroup.Logs[0].Contents[0].Value)
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
 
 	"strings"
 	"time"
@@ -90,7 +91,7 @@ func (p *ProcessorSplit) ProcessLogs(logArray []*protocol.Log) []*protocol.Log {
 			}
 		} else {
 			if p.NoKeyError {
-				logger.Warning(p.context.GetRuntimeContext(), "PROCESSOR_SPLIT_LOG_STRING_FIND_ALARM", "can't find split key", p.SplitKey)
+				logger.Warning(p.context.GetRuntimeContext(), util.AlarmProcessorSplitLogStringFind, "can't find split key", p.SplitKey)
 			}
 			if p.PreserveOthers {
 				destArray = append(destArray, newLog)
@@ -0,0 +1,171 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package starlark
+
+import (
+	"fmt"
+	"strconv"
+
+	starlarkjson "go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+// maxSizeDepth limits the depth to estimate the size of the nested values, which may be recursive.
+const maxSizeDepth = 32
+
+// fromLog converts the log to the dict of the contents, and the time is set as __time__.
+func fromLog(log *protocol.Log) *starlark.Dict {
+	dict := starlark.NewDict(len(log.Contents) + 1)
+	for _, content := range log.Contents {
+		_ = dict.SetKey(starlark.String(content.Key), starlark.String(content.Value))
+	}
+	_ = dict.SetKey(starlark.String(timeKey), starlark.MakeUint64(uint64(log.Time)))
+	return dict
+}
+
+// toLog converts the dict returned by the script to the log, the values of None are omitted and the
+// values not in string are encoded in json.
+func toLog(dict *starlark.Dict, time uint32) (*protocol.Log, error) {
+	log := &protocol.Log{Time: time, Contents: make([]*protocol.Log_Content, 0, dict.Len())}
+	for _, item := range dict.Items() {
+		key, ok := starlark.AsString(item[0])
+		if !ok {
+			return nil, fmt.Errorf("the key of the log should be string instead of %s", item[0].Type())
+		}
+		if key == timeKey {
+			t, ok := item[1].(starlark.Int)
+			if !ok {
+				return nil, fmt.Errorf("the %s of the log should be int instead of %s", timeKey, item[1].Type())
+			}
+			u, ok := t.Uint64()
+			if !ok || u > uint64(^uint32(0)) {
+				return nil, fmt.Errorf("the %s of the log is out of range: %s", timeKey, t)
+			}
+			log.Time = uint32(u)
+			continue
+		}
+		value, err := toString(item[1])
+		if err != nil {
+			return nil, err
+		}
+		if item[1] != starlark.None {
+			log.Contents = append(log.Contents, &protocol.Log_Content{Key: key, Value: value})
+		}
+	}
+	return log, nil
+}
+
+func toString(v starlark.Value) (string, error) {
+	switch v := v.(type) {
+	case starlark.String:
+		return string(v), nil
+	case starlark.NoneType:
+		return "", nil
+	case starlark.Bool:
+		return strconv.FormatBool(bool(v)), nil
+	case starlark.Int, starlark.Float:
+		return v.String(), nil
+	}
+	encoded, err := starlark.Call(&starlark.Thread{Name: pluginName}, starlarkjson.Module.Members["encode"], starlark.Tuple{v}, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded.(starlark.String)), nil
+}
+
+// toStarlark converts the value decoded from json config to the starlark value.
+func toStarlark(v interface{}) (starlark.Value, error) {
+	switch v := v.(type) {
+	case nil:
+		return starlark.None, nil
+	case string:
+		return starlark.String(v), nil
+	case bool:
+		return starlark.Bool(v), nil
+	case int:
+		return starlark.MakeInt(v), nil
+	case float64:
+		if v == float64(int64(v)) {
+			return starlark.MakeInt64(int64(v)), nil
+		}
+		return starlark.Float(v), nil
+	case []interface{}:
+		elems := make([]starlark.Value, 0, len(v))
+		for _, e := range v {
+			value, err := toStarlark(e)
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, value)
+		}
+		return starlark.NewList(elems), nil
+	case map[string]interface{}:
+		dict := starlark.NewDict(len(v))
+		for k, e := range v {
+			value, err := toStarlark(e)
+			if err != nil {
+				return nil, err
+			}
+			_ = dict.SetKey(starlark.String(k), value)
+		}
+		return dict, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %T", v)
+	}
+}
+
+// sizeOf estimates the bytes of the value, which is used to limit the memory of the state.
+func sizeOf(v starlark.Value, depth int) int {
+	if depth > maxSizeDepth {
+		return 0
+	}
+	switch v := v.(type) {
+	case starlark.String:
+		return 16 + len(v)
+	case starlark.Bytes:
+		return 16 + len(v)
+	case *starlark.Dict:
+		size := 64
+		for _, item := range v.Items() {
+			size += sizeOf(item[0], depth+1) + sizeOf(item[1], depth+1)
+		}
+		return size
+	case *starlark.Set:
+		size := 64
+		iter := v.Iterate()
+		defer iter.Done()
+		var e starlark.Value
+		for iter.Next(&e) {
+			size += sizeOf(e, depth+1)
+		}
+		return size
+	case *starlark.List:
+		size := 32
+		for i := 0; i < v.Len(); i++ {
+			size += sizeOf(v.Index(i), depth+1)
+		}
+		return size
+	case starlark.Tuple:
+		size := 32
+		for _, e := range v {
+			size += sizeOf(e, depth+1)
+		}
+		return size
+	default:
+		return 16
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package starlark
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	starlarkjson "go.starlark.net/lib/json"
+	starlarkmath "go.starlark.net/lib/math"
+	starlarktime "go.starlark.net/lib/time"
+	"go.starlark.net/starlark"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	pluginName = "processor_starlark"
+	// applyFunction is the function defined by the script to process a log.
+	applyFunction = "apply"
+	// timeKey is the key of the log time in the dict passed to the script.
+	timeKey = "__time__"
+
+	defaultMaxSteps     = 100000
+	defaultMaxStateSize = 16 * 1024 * 1024
+)
+
+// ProcessorStarlark processes the logs by the apply function of a starlark script. Each log is passed
+// to the function as a dict, and the function returns the dict, a list of dicts or None to drop the log.
+// The dict named state is shared by all the calls, which could be used to keep the counters or caches.
+type ProcessorStarlark struct {
+	// The source of the script.
+	Source string
+	// The path of the script file, which is used when Source is empty.
+	Script string
+	// The constants declared as the global variables of the script.
+	Constants map[string]interface{}
+	// The max computation steps to process a log, 100000 by default.
+	MaxSteps int
+	// The max bytes estimated of the state, the state is cleared when exceeded, 16MiB by default.
+	MaxStateSize int
+	// Drop the log if the script fails to process it, otherwise the log is passed through.
+	DropOnError bool
+
+	context     pipeline.Context
+	state       *starlark.Dict
+	predeclared starlark.StringDict
+	apply       *starlark.Function
+}
+
+func (p *ProcessorStarlark) Init(context pipeline.Context) error {
+	p.context = context
+	if p.MaxSteps <= 0 {
+		p.MaxSteps = defaultMaxSteps
+	}
+	if p.MaxStateSize <= 0 {
+		p.MaxStateSize = defaultMaxStateSize
+	}
+	filename, source := p.Script, p.Source
+	if source == "" {
+		if filename == "" {
+			return fmt.Errorf("the Source or Script of %s is required", pluginName)
+		}
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		source = string(content)
+	} else {
+		filename = pluginName
+	}
+
+	p.state = starlark.NewDict(0)
+	p.predeclared = starlark.StringDict{
+		"state": p.state,
+		"json":  starlarkjson.Module,
+		"math":  starlarkmath.Module,
+		"time":  starlarktime.Module,
+	}
+	for k, v := range p.Constants {
+		value, err := toStarlark(v)
+		if err != nil {
+			return fmt.Errorf("invalid constant %s: %v", k, err)
+		}
+		p.predeclared[k] = value
+	}
+	globals, err := starlark.ExecFile(p.newThread(), filename, source, p.predeclared)
+	if err != nil {
+		logger.Error(context.GetRuntimeContext(), util.AlarmProcessorInit, "init starlark script error", errorDetail(err))
+		return err
+	}
+	apply, ok := globals[applyFunction].(*starlark.Function)
+	if !ok || apply.NumParams() != 1 {
+		return fmt.Errorf("the script should define the function %s(log)", applyFunction)
+	}
+	p.apply = apply
+	return nil
+}
+
+func (*ProcessorStarlark) Description() string {
+	return "starlark processor to process the logs by the stateful starlark script"
+}
+
+func (p *ProcessorStarlark) ProcessLogs(logArray []*protocol.Log) []*protocol.Log {
+	result := make([]*protocol.Log, 0, len(logArray))
+	for _, log := range logArray {
+		logs, err := p.processLog(log)
+		if err != nil {
+			logger.Warning(p.context.GetRuntimeContext(), util.AlarmStarlarkProcess, "process log by starlark script error", errorDetail(err), "drop", p.DropOnError)
+			if !p.DropOnError {
+				result = append(result, log)
+			}
+			continue
+		}
+		result = append(result, logs...)
+	}
+	if size := sizeOf(p.state, 0); size > p.MaxStateSize {
+		logger.Warning(p.context.GetRuntimeContext(), util.AlarmStarlarkProcess, "the state exceeds the max size and is cleared", size, "max", p.MaxStateSize)
+		if err := p.state.Clear(); err != nil {
+			// the state is frozen or being iterated, which is replaced by a new one,
+			// and the predeclared names are looked up when called so the script sees the new state
+			p.state = starlark.NewDict(0)
+			p.predeclared["state"] = p.state
+		}
+	}
+	return result
+}
+
+func (p *ProcessorStarlark) processLog(log *protocol.Log) ([]*protocol.Log, error) {
+	value, err := starlark.Call(p.newThread(), p.apply, starlark.Tuple{fromLog(log)}, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case *starlark.Dict:
+		out, err := toLog(v, log.Time)
+		if err != nil {
+			return nil, err
+		}
+		return []*protocol.Log{out}, nil
+	case *starlark.List:
+		logs := make([]*protocol.Log, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			dict, ok := v.Index(i).(*starlark.Dict)
+			if !ok {
+				return nil, fmt.Errorf("%s returns a list of %s instead of dict", applyFunction, v.Index(i).Type())
+			}
+			out, err := toLog(dict, log.Time)
+			if err != nil {
+				return nil, err
+			}
+			logs = append(logs, out)
+		}
+		return logs, nil
+	default:
+		return nil, fmt.Errorf("%s returns %s instead of dict, list or None", applyFunction, value.Type())
+	}
+}
+
+func (p *ProcessorStarlark) newThread() *starlark.Thread {
+	thread := &starlark.Thread{
+		Name: pluginName,
+		Print: func(_ *starlark.Thread, msg string) {
+			logger.Info(p.context.GetRuntimeContext(), "starlark", msg)
+		},
+	}
+	thread.SetMaxExecutionSteps(uint64(p.MaxSteps))
+	return thread
+}
+
+// errorDetail returns the error with the backtrace of the script.
+func errorDetail(err error) string {
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		return evalErr.Backtrace()
+	}
+	return err.Error()
+}
+
+func init() {
+	pipeline.Processors[pluginName] = func() pipeline.Processor {
+		return &ProcessorStarlark{}
+	}
+}
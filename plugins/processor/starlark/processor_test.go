@@ -0,0 +1,134 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package starlark
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+func init() {
+	logger.InitTestLogger(logger.OptionOpenMemoryReceiver)
+}
+
+func newLog(kv ...string) *protocol.Log {
+	log := &protocol.Log{Time: 1680000000}
+	for i := 0; i < len(kv); i += 2 {
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: kv[i], Value: kv[i+1]})
+	}
+	return log
+}
+
+func TestProcessorStarlarkState(t *testing.T) {
+	processor := &ProcessorStarlark{Script: "testdata/dedup.star"}
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+
+	logs := processor.ProcessLogs([]*protocol.Log{
+		newLog("level", "INFO", "msg", "a"),
+		newLog("level", "INFO", "msg", "a"),
+	})
+	require.Len(t, logs, 1)
+	assert.Equal(t, newLog("level", "INFO", "msg", "a", "count", "1"), logs[0])
+
+	// the state is kept across the batches
+	logs = processor.ProcessLogs([]*protocol.Log{newLog("level", "INFO", "msg", "b")})
+	require.Len(t, logs, 1)
+	assert.Equal(t, newLog("level", "INFO", "msg", "b", "count", "3"), logs[0])
+}
+
+func TestProcessorStarlarkSplit(t *testing.T) {
+	processor := &ProcessorStarlark{
+		Source: `
+def apply(log):
+    items = json.decode(log["items"])
+    return [{"item": item, "source": source, "__time__": log["__time__"] + 1, "extra": None, "detail": {"ok": True}} for item in items]
+`,
+		Constants: map[string]interface{}{"source": "mock"},
+	}
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+
+	logs := processor.ProcessLogs([]*protocol.Log{newLog("items", `["a", 1]`)})
+	require.Len(t, logs, 2)
+	expected := newLog("item", "a", "source", "mock", "detail", `{"ok":true}`)
+	expected.Time++
+	assert.Equal(t, expected, logs[0])
+	assert.Equal(t, "1", logs[1].Contents[0].Value)
+}
+
+func TestProcessorStarlarkError(t *testing.T) {
+	processor := &ProcessorStarlark{Source: `
+def apply(log):
+    if log["msg"] == "loop":
+        for i in range(1000000):
+            pass
+    return log["msg"] + 1
+`, MaxSteps: 1000}
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+
+	logs := processor.ProcessLogs([]*protocol.Log{newLog("msg", "a"), newLog("msg", "loop")})
+	assert.Equal(t, []*protocol.Log{newLog("msg", "a"), newLog("msg", "loop")}, logs, "the logs should be passed through on error")
+	processor.DropOnError = true
+	assert.Len(t, processor.ProcessLogs([]*protocol.Log{newLog("msg", "a"), newLog("msg", "loop")}), 0)
+}
+
+func TestProcessorStarlarkMaxStateSize(t *testing.T) {
+	processor := &ProcessorStarlark{Source: `
+def apply(log):
+    state[log["msg"]] = log["msg"]
+    return log
+`, MaxStateSize: 1024}
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+
+	logs := make([]*protocol.Log, 0, 100)
+	for i := 0; i < 100; i++ {
+		logs = append(logs, newLog("msg", string(rune('a'+i%26))+string(rune('0'+i/26))))
+	}
+	assert.Len(t, processor.ProcessLogs(logs), 100)
+	assert.Equal(t, 0, processor.state.Len(), "the state should be cleared when exceeding the max size")
+	processor.ProcessLogs([]*protocol.Log{newLog("msg", "a")})
+	assert.Equal(t, 1, processor.state.Len())
+}
+
+func TestProcessorStarlarkReplaceFrozenState(t *testing.T) {
+	processor := &ProcessorStarlark{Source: `
+def apply(log):
+    state[log["msg"]] = True
+    log["count"] = str(len(state))
+    return log
+`, MaxStateSize: 1}
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+
+	// the frozen state could not be cleared, so that it is replaced by a new one
+	processor.state.Freeze()
+	logs := processor.ProcessLogs([]*protocol.Log{newLog("msg", "a")})
+	assert.Equal(t, []*protocol.Log{newLog("msg", "a")}, logs, "the log should be passed through when the state is frozen")
+	logs = processor.ProcessLogs([]*protocol.Log{newLog("msg", "b")})
+	assert.Equal(t, []*protocol.Log{newLog("msg", "b", "count", "1")}, logs, "the script should write to the new state")
+}
+
+func TestProcessorStarlarkInitError(t *testing.T) {
+	ctx := mock.NewEmptyContext("p", "l", "c")
+	assert.Error(t, (&ProcessorStarlark{}).Init(ctx))
+	assert.Error(t, (&ProcessorStarlark{Script: "testdata/not_exist.star"}).Init(ctx))
+	assert.Error(t, (&ProcessorStarlark{Source: "def apply(log) return log"}).Init(ctx))
+	assert.ErrorContains(t, (&ProcessorStarlark{Source: "def process(log):\n    return log"}).Init(ctx), "apply(log)")
+	assert.Error(t, (&ProcessorStarlark{Source: "x = 1", Constants: map[string]interface{}{"c": struct{}{}}}).Init(ctx))
+}
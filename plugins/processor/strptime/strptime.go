@@ -20,11 +20,11 @@ import (
 	"strings"
 	"time"
 
-	"github.com/knz/strtime"
-
+	"github.com/alibaba/ilogtail/helper"
 	"github.com/alibaba/ilogtail/pkg/logger"
 	"github.com/alibaba/ilogtail/pkg/pipeline"
 	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
 )
 
 const (
@@ -126,25 +126,15 @@ func (s *Strptime) processLog(log *protocol.Log) {
 			continue
 		}
 
-		value := content.Value
-		// Truncate if format is Unix timestamp.
-		if s.Format == "%s" {
-			value = value[0:10]
-		}
-
-		logTime, err = strtime.Strptime(value, s.Format)
-		if err != nil || (logTime == time.Time{}) {
+		logTime, err = helper.Strptime(content.Value, s.Format, s.location)
+		if err != nil {
 			if s.AlarmIfFail {
-				logger.Warningf(s.context.GetRuntimeContext(), "STRPTIME_PARSE_ALARM", "strptime(%v, %v) failed: %v, %v",
-					content.Value, s.Format, logTime, err)
+				logger.Warningf(s.context.GetRuntimeContext(), util.AlarmStrptimeParse, "strptime(%v, %v) failed: %v",
+					content.Value, s.Format, err)
 			}
 			break
 		}
 
-		if s.location != nil {
-			logTime = logTime.In(s.location).Add(time.Second * time.Duration(-s.UTCOffset))
-		}
-
 		log.Time = uint32(logTime.Unix())
 		if !s.KeepSource {
 			log.Contents = append(log.Contents[:idx], log.Contents[idx+1:]...)
@@ -0,0 +1,74 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package strptimev2
+
+// localeNames maps the lower case month and weekday names and abbreviations of the locales to the English ones.
+var localeNames = map[string]map[string]string{
+	"de": names(
+		[][]string{{"januar", "jan", "jänner"}, {"februar", "feb"}, {"märz", "mär", "mrz"}, {"april", "apr"}, {"mai"}, {"juni", "jun"},
+			{"juli", "jul"}, {"august", "aug"}, {"september", "sep", "sept"}, {"oktober", "okt"}, {"november", "nov"}, {"dezember", "dez"}},
+		[][]string{{"montag"}, {"dienstag"}, {"mittwoch"}, {"donnerstag"}, {"freitag"}, {"samstag", "sonnabend"}, {"sonntag"}},
+	),
+	"es": names(
+		[][]string{{"enero", "ene"}, {"febrero", "feb"}, {"marzo", "mar"}, {"abril", "abr"}, {"mayo", "may"}, {"junio", "jun"},
+			{"julio", "jul"}, {"agosto", "ago"}, {"septiembre", "setiembre", "sep", "sept", "set"}, {"octubre", "oct"}, {"noviembre", "nov"}, {"diciembre", "dic"}},
+		[][]string{{"lunes", "lun"}, {"martes"}, {"miércoles", "mié"}, {"jueves", "jue"}, {"viernes", "vie"}, {"sábado", "sáb"}, {"domingo", "dom"}},
+	),
+	"fr": names(
+		[][]string{{"janvier", "janv"}, {"février", "févr", "fév"}, {"mars"}, {"avril", "avr"}, {"mai"}, {"juin"},
+			{"juillet", "juil"}, {"août"}, {"septembre", "sept"}, {"octobre", "oct"}, {"novembre", "nov"}, {"décembre", "déc"}},
+		[][]string{{"lundi", "lun"}, {"mardi"}, {"mercredi", "mer"}, {"jeudi", "jeu"}, {"vendredi", "ven"}, {"samedi", "sam"}, {"dimanche", "dim"}},
+	),
+	"it": names(
+		[][]string{{"gennaio", "gen"}, {"febbraio", "feb"}, {"marzo", "mar"}, {"aprile", "apr"}, {"maggio", "mag"}, {"giugno", "giu"},
+			{"luglio", "lug"}, {"agosto", "ago"}, {"settembre", "set"}, {"ottobre", "ott"}, {"novembre", "nov"}, {"dicembre", "dic"}},
+		[][]string{{"lunedì", "lun"}, {"martedì"}, {"mercoledì", "mer"}, {"giovedì", "gio"}, {"venerdì", "ven"}, {"sabato", "sab"}, {"domenica", "dom"}},
+	),
+	"nl": names(
+		[][]string{{"januari", "jan"}, {"februari", "feb"}, {"maart", "mrt"}, {"april", "apr"}, {"mei"}, {"juni", "jun"},
+			{"juli", "jul"}, {"augustus", "aug"}, {"september", "sep", "sept"}, {"oktober", "okt"}, {"november", "nov"}, {"december", "dec"}},
+		[][]string{{"maandag"}, {"dinsdag"}, {"woensdag"}, {"donderdag"}, {"vrijdag"}, {"zaterdag"}, {"zondag"}},
+	),
+	"pt": names(
+		[][]string{{"janeiro", "jan"}, {"fevereiro", "fev"}, {"março", "mar"}, {"abril", "abr"}, {"maio", "mai"}, {"junho", "jun"},
+			{"julho", "jul"}, {"agosto", "ago"}, {"setembro", "set"}, {"outubro", "out"}, {"novembro", "nov"}, {"dezembro", "dez"}},
+		[][]string{{"segunda"}, {"terça"}, {"quarta"}, {"quinta"}, {"sexta"}, {"sábado", "sáb"}, {"domingo", "dom"}},
+	),
+	"ru": names(
+		[][]string{{"январь", "января", "янв"}, {"февраль", "февраля", "фев"}, {"март", "марта", "мар"}, {"апрель", "апреля", "апр"},
+			{"май", "мая"}, {"июнь", "июня", "июн"}, {"июль", "июля", "июл"}, {"август", "августа", "авг"},
+			{"сентябрь", "сентября", "сен"}, {"октябрь", "октября", "окт"}, {"ноябрь", "ноября", "ноя"}, {"декабрь", "декабря", "дек"}},
+		[][]string{{"понедельник", "пн"}, {"вторник", "вт"}, {"среда", "ср"}, {"четверг", "чт"}, {"пятница", "пт"}, {"суббота", "сб"}, {"воскресенье", "вс"}},
+	),
+}
+
+var englishMonths = []string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+
+var englishWeekdays = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+
+func names(months, weekdays [][]string) map[string]string {
+	result := make(map[string]string)
+	for i, aliases := range months {
+		for _, alias := range aliases {
+			result[alias] = englishMonths[i]
+		}
+	}
+	for i, aliases := range weekdays {
+		for _, alias := range aliases {
+			result[alias] = englishWeekdays[i]
+		}
+	}
+	return result
+}
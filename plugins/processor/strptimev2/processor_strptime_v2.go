@@ -0,0 +1,348 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package strptimev2
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	// embed the IANA timezone database for the hosts and containers without it.
+	_ "time/tzdata"
+
+	"github.com/alibaba/ilogtail/helper"
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	pluginName       = "processor_strptime_v2"
+	defaultSourceKey = "time"
+
+	defaultPreciseTimestampKey = "precise_timestamp"
+	timeStampMilliSecond       = "ms"
+	timeStampMicroSecond       = "us"
+	timeStampNanoSecond        = "ns"
+)
+
+// The special formats of the epoch timestamps, epoch detects the unit by the number of the integer digits.
+const (
+	formatEpoch            = "epoch"
+	formatEpochSecond      = "epoch_second"
+	formatEpochMillisecond = "epoch_millisecond"
+	formatEpochMicrosecond = "epoch_microsecond"
+	formatEpochNanosecond  = "epoch_nanosecond"
+)
+
+const (
+	outlierReject = "reject"
+	outlierClamp  = "clamp"
+)
+
+var errOutlier = errors.New("time is out of the allowed range")
+
+// StrptimeV2 parses the time of SourceKey by the first matched format of Formats, and overwrites the
+// time of log if parsing is successful.
+//
+// Formats comment
+// The formats follow the rules of C strptime, such as %Y-%m-%d %H:%M:%S, or one of the epoch formats:
+// epoch_second, epoch_millisecond, epoch_microsecond, epoch_nanosecond, and epoch which detects the
+// unit by the number of digits. The formats are tried in order.
+//
+// Timezone comment
+// The times without offsets (%z) are in Timezone, which is an IANA name such as America/New_York, so
+// that the DST transitions are handled. The local timezone is used by default.
+//
+// Locales comment
+// The month and weekday names of the locales, such as de and fr, are recognized by %b and %a besides
+// the English ones.
+//
+// MaxFutureSeconds and MaxPastSeconds comment
+// The times later than now+MaxFutureSeconds or earlier than now-MaxPastSeconds are outliers, which
+// are rejected (the log time is kept) or clamped to the bounds according to OutlierAction.
+type StrptimeV2 struct {
+	SourceKey              string   `comment:"The source key prepared to be parsed."`
+	Formats                []string `comment:"The ordered formats of strptime or epoch_second, epoch_millisecond, epoch_microsecond, epoch_nanosecond, epoch."`
+	Timezone               string   `comment:"Optional. The IANA timezone of the times without offsets, such as Asia/Shanghai, the local timezone by default."`
+	Locales                []string `comment:"Optional. The locales of the month and weekday names, such as de, es, fr, it, nl, pt, ru."`
+	KeepSource             bool     `comment:"Optional. Specifies whether to keep the source key in the log content after the processing."`
+	AlarmIfFail            bool     `comment:"Optional. Specifies whether to trigger an alert if the time information fails to be extracted."`
+	MaxFutureSeconds       int      `comment:"Optional. The times later than now plus the seconds are outliers, 0 means no limit."`
+	MaxPastSeconds         int      `comment:"Optional. The times earlier than now minus the seconds are outliers, 0 means no limit."`
+	OutlierAction          string   `comment:"Optional. reject to keep the log time, or clamp to use the bound, reject by default."`
+	EnablePreciseTimestamp bool     `comment:"Optional. Specifies whether to enable precise timestamp."`
+	PreciseTimestampKey    string   `comment:"Optional. The generated precise timestamp key."`
+	PreciseTimestampUnit   string   `comment:"Optional. The generated precise timestamp unit."`
+
+	context  pipeline.Context
+	location *time.Location
+	names    map[string]string
+	// hasOffset marks the formats containing the offsets of the times.
+	hasOffset []bool
+}
+
+// Init ...
+func (s *StrptimeV2) Init(context pipeline.Context) error {
+	s.context = context
+	if len(s.Formats) == 0 {
+		return fmt.Errorf("formats can not be empty for plugin %v", pluginName)
+	}
+	s.hasOffset = make([]bool, len(s.Formats))
+	for i, format := range s.Formats {
+		if format == "" {
+			return fmt.Errorf("format can not be empty for plugin %v", pluginName)
+		}
+		s.hasOffset[i] = helper.StrptimeHasZone(format)
+	}
+	s.location = time.Local
+	if s.Timezone != "" {
+		var err error
+		if s.location, err = time.LoadLocation(s.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone %v for plugin %v: %v", s.Timezone, pluginName, err)
+		}
+	}
+	s.names = make(map[string]string)
+	// the names of the former locales take precedence.
+	for i := len(s.Locales) - 1; i >= 0; i-- {
+		names, ok := localeNames[s.Locales[i]]
+		if !ok {
+			return fmt.Errorf("unsupported locale %v for plugin %v", s.Locales[i], pluginName)
+		}
+		for name, english := range names {
+			s.names[name] = english
+		}
+	}
+	switch s.OutlierAction {
+	case "":
+		s.OutlierAction = outlierReject
+	case outlierReject, outlierClamp:
+	default:
+		return fmt.Errorf("invalid outlier action %v for plugin %v", s.OutlierAction, pluginName)
+	}
+	if len(s.SourceKey) == 0 {
+		s.SourceKey = defaultSourceKey
+	}
+	if len(s.PreciseTimestampKey) == 0 {
+		s.PreciseTimestampKey = defaultPreciseTimestampKey
+	}
+	return nil
+}
+
+// Description ...
+func (s *StrptimeV2) Description() string {
+	return "Processor to extract time from log by the first matched format"
+}
+
+// ProcessLogs ...
+func (s *StrptimeV2) ProcessLogs(logs []*protocol.Log) []*protocol.Log {
+	now := time.Now()
+	for _, log := range logs {
+		s.processLog(log, now)
+	}
+	return logs
+}
+
+func (s *StrptimeV2) processLog(log *protocol.Log, now time.Time) {
+	for idx, content := range log.Contents {
+		if content.Key != s.SourceKey {
+			continue
+		}
+		logTime, err := s.parse(content.Value)
+		if err == nil {
+			logTime, err = s.checkRange(logTime, now)
+		}
+		if err != nil {
+			if s.AlarmIfFail {
+				logger.Warningf(s.context.GetRuntimeContext(), util.AlarmStrptimeParse, "strptime(%v, %v) failed: %v",
+					content.Value, s.Formats, err)
+			}
+			return
+		}
+		log.Time = uint32(logTime.Unix())
+		if !s.KeepSource {
+			log.Contents = append(log.Contents[:idx], log.Contents[idx+1:]...)
+		}
+		if s.EnablePreciseTimestamp {
+			log.Contents = append(log.Contents, &protocol.Log_Content{
+				Key:   s.PreciseTimestampKey,
+				Value: s.preciseTimestamp(logTime),
+			})
+		}
+		return
+	}
+}
+
+// parse tries the formats in order and returns the time of the first matched one.
+func (s *StrptimeV2) parse(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	localized := s.localize(value)
+	var lastErr error
+	for i, format := range s.Formats {
+		if strings.HasPrefix(format, formatEpoch) {
+			t, err := parseEpoch(value, format)
+			if err == nil {
+				return t, nil
+			}
+			lastErr = err
+			continue
+		}
+		// the wall clock is in the timezone, whose offset depends on the DST.
+		var location *time.Location
+		if !s.hasOffset[i] {
+			location = s.location
+		}
+		t, err := helper.Strptime(localized, format, location)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return t, nil
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no format matched")
+	}
+	return time.Time{}, lastErr
+}
+
+// localize replaces the month and weekday names of the locales with the English ones.
+func (s *StrptimeV2) localize(value string) string {
+	if len(s.names) == 0 {
+		return value
+	}
+	var b strings.Builder
+	runes := []rune(value)
+	for i := 0; i < len(runes); {
+		if !isLetter(runes[i]) {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && isLetter(runes[j]) {
+			j++
+		}
+		word := string(runes[i:j])
+		if english, ok := s.names[strings.ToLower(word)]; ok {
+			b.WriteString(english)
+			// the abbreviations may end with a dot, such as févr.
+			if j < len(runes) && runes[j] == '.' {
+				j++
+			}
+		} else {
+			b.WriteString(word)
+		}
+		i = j
+	}
+	return b.String()
+}
+
+func isLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r > 0x7f && r != 0xa0
+}
+
+func (s *StrptimeV2) checkRange(t, now time.Time) (time.Time, error) {
+	if s.MaxFutureSeconds > 0 {
+		if bound := now.Add(time.Duration(s.MaxFutureSeconds) * time.Second); t.After(bound) {
+			if s.OutlierAction == outlierClamp {
+				return bound, nil
+			}
+			return t, fmt.Errorf("%w: %v is later than %v", errOutlier, t, bound)
+		}
+	}
+	if s.MaxPastSeconds > 0 {
+		if bound := now.Add(-time.Duration(s.MaxPastSeconds) * time.Second); t.Before(bound) {
+			if s.OutlierAction == outlierClamp {
+				return bound, nil
+			}
+			return t, fmt.Errorf("%w: %v is earlier than %v", errOutlier, t, bound)
+		}
+	}
+	return t, nil
+}
+
+func (s *StrptimeV2) preciseTimestamp(t time.Time) string {
+	switch s.PreciseTimestampUnit {
+	case timeStampMicroSecond:
+		return strconv.FormatInt(t.UnixNano()/1e3, 10)
+	case timeStampNanoSecond:
+		return strconv.FormatInt(t.UnixNano(), 10)
+	default:
+		return strconv.FormatInt(t.UnixNano()/1e6, 10)
+	}
+}
+
+// parseEpoch parses the epoch timestamp, which may have the fraction, such as 1690000000.123 in seconds.
+func parseEpoch(value, format string) (time.Time, error) {
+	integer, fraction, _ := strings.Cut(value, ".")
+	if integer == "" || strings.Trim(integer, "0123456789") != "" || strings.Trim(fraction, "0123456789") != "" {
+		return time.Time{}, fmt.Errorf("invalid epoch timestamp %v", value)
+	}
+	if format == formatEpoch {
+		switch digits := len(integer); {
+		case digits <= 10:
+			format = formatEpochSecond
+		case digits <= 13:
+			format = formatEpochMillisecond
+		case digits <= 16:
+			format = formatEpochMicrosecond
+		default:
+			format = formatEpochNanosecond
+		}
+	}
+	var scale float64
+	switch format {
+	case formatEpochSecond:
+		scale = 1e9
+	case formatEpochMillisecond:
+		scale = 1e6
+	case formatEpochMicrosecond:
+		scale = 1e3
+	case formatEpochNanosecond:
+		scale = 1
+	default:
+		return time.Time{}, fmt.Errorf("unsupported format %v", format)
+	}
+	i, err := strconv.ParseInt(integer, 10, 64)
+	if err != nil || float64(i) > math.MaxInt64/scale {
+		return time.Time{}, fmt.Errorf("invalid epoch timestamp %v", value)
+	}
+	nanos := i * int64(scale)
+	if fraction != "" {
+		f, _ := strconv.ParseFloat("0."+fraction, 64)
+		nanos += int64(math.Round(f * scale))
+	}
+	return time.Unix(0, nanos), nil
+}
+
+func newStrptimeV2() *StrptimeV2 {
+	return &StrptimeV2{
+		SourceKey:            defaultSourceKey,
+		KeepSource:           true,
+		AlarmIfFail:          true,
+		OutlierAction:        outlierReject,
+		PreciseTimestampKey:  defaultPreciseTimestampKey,
+		PreciseTimestampUnit: timeStampMilliSecond,
+	}
+}
+
+func init() {
+	pipeline.Processors[pluginName] = func() pipeline.Processor {
+		return newStrptimeV2()
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package strptimev2
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+func newProcessor(t *testing.T, configure func(s *StrptimeV2)) *StrptimeV2 {
+	s := newStrptimeV2()
+	configure(s)
+	require.NoError(t, s.Init(mock.NewEmptyContext("p", "l", "c")))
+	return s
+}
+
+func process(s *StrptimeV2, value string) *protocol.Log {
+	log := &protocol.Log{Contents: []*protocol.Log_Content{{Key: "time", Value: value}}}
+	s.ProcessLogs([]*protocol.Log{log})
+	return log
+}
+
+func unix(value string) uint32 {
+	t, _ := time.Parse(time.RFC3339, value)
+	return uint32(t.Unix())
+}
+
+func TestFormatsFallback(t *testing.T) {
+	s := newProcessor(t, func(s *StrptimeV2) {
+		s.Formats = []string{"%Y-%m-%dT%H:%M:%S%z", "%d/%b/%Y:%H:%M:%S", formatEpoch}
+		s.Timezone = "America/New_York"
+	})
+	assert.Equal(t, unix("2023-03-12T01:00:00Z"), process(s, "2023-03-12T09:00:00+0800").Time)
+	// the offset of New York changes from -0500 to -0400 at 2023-03-12 02:00.
+	assert.Equal(t, unix("2023-03-12T06:30:00Z"), process(s, "12/Mar/2023:01:30:00").Time)
+	assert.Equal(t, unix("2023-03-12T07:30:00Z"), process(s, "12/Mar/2023:03:30:00").Time)
+	assert.Equal(t, unix("2023-07-14T22:13:20Z"), process(s, "1689372800").Time)
+	assert.Equal(t, unix("2023-07-14T22:13:20Z"), process(s, "1689372800123").Time)
+
+	log := process(s, "not a time")
+	assert.Equal(t, uint32(0), log.Time)
+	assert.Equal(t, "not a time", log.Contents[0].Value)
+}
+
+func TestLocales(t *testing.T) {
+	s := newProcessor(t, func(s *StrptimeV2) {
+		s.Formats = []string{"%a %d %b %Y %H:%M:%S"}
+		s.Timezone = "UTC"
+		s.Locales = []string{"fr", "de"}
+		s.KeepSource = false
+	})
+	expected := unix("2023-02-03T10:00:00Z")
+	for _, value := range []string{"ven. 03 févr. 2023 10:00:00", "Freitag 03 Februar 2023 10:00:00", "Fri 03 Feb 2023 10:00:00"} {
+		log := process(s, value)
+		assert.Equal(t, expected, log.Time, value)
+		assert.Empty(t, log.Contents, value)
+	}
+
+	assert.Error(t, newStrptimeV2().Init(mock.NewEmptyContext("p", "l", "c")))
+	invalid := newStrptimeV2()
+	invalid.Formats = []string{formatEpoch}
+	invalid.Locales = []string{"xx"}
+	assert.Error(t, invalid.Init(mock.NewEmptyContext("p", "l", "c")))
+	invalid.Locales = nil
+	invalid.Timezone = "Mars/Olympus"
+	assert.Error(t, invalid.Init(mock.NewEmptyContext("p", "l", "c")))
+}
+
+func TestEpoch(t *testing.T) {
+	for _, c := range []struct {
+		value  string
+		format string
+		nanos  int64
+	}{
+		{"1689372800", formatEpoch, 1689372800000000000},
+		{"1689372800.25", formatEpochSecond, 1689372800250000000},
+		{"1689372800123", formatEpoch, 1689372800123000000},
+		{"1689372800123456", formatEpoch, 1689372800123456000},
+		{"1689372800123456789", formatEpoch, 1689372800123456789},
+		{"1689372800123", formatEpochMillisecond, 1689372800123000000},
+		{"1689372800", formatEpochMillisecond, 1689372800000000},
+	} {
+		parsed, err := parseEpoch(c.value, c.format)
+		require.NoError(t, err, c.value)
+		assert.Equal(t, c.nanos, parsed.UnixNano(), c.value)
+	}
+	for _, value := range []string{"", "-1", "12a", "1.2.3", "99999999999999999999"} {
+		_, err := parseEpoch(value, formatEpochSecond)
+		assert.Error(t, err, value)
+	}
+
+	s := newProcessor(t, func(s *StrptimeV2) {
+		s.Formats = []string{formatEpochNanosecond}
+		s.EnablePreciseTimestamp = true
+		s.PreciseTimestampUnit = timeStampMicroSecond
+	})
+	log := process(s, "1689372800123456789")
+	assert.Equal(t, uint32(1689372800), log.Time)
+	assert.Equal(t, &protocol.Log_Content{Key: defaultPreciseTimestampKey, Value: "1689372800123456"}, log.Contents[1])
+}
+
+func TestOutliers(t *testing.T) {
+	now := time.Now()
+	future := strconv.FormatInt(now.Add(2*time.Hour).Unix(), 10)
+	past := strconv.FormatInt(now.Add(-48*time.Hour).Unix(), 10)
+	recent := strconv.FormatInt(now.Add(-time.Hour).Unix(), 10)
+
+	s := newProcessor(t, func(s *StrptimeV2) {
+		s.Formats = []string{formatEpochSecond}
+		s.MaxFutureSeconds = 3600
+		s.MaxPastSeconds = 86400
+	})
+	assert.Equal(t, uint32(0), process(s, future).Time)
+	assert.Equal(t, uint32(0), process(s, past).Time)
+	assert.Equal(t, recent, strconv.FormatInt(int64(process(s, recent).Time), 10))
+
+	s = newProcessor(t, func(s *StrptimeV2) {
+		s.Formats = []string{formatEpochSecond}
+		s.MaxFutureSeconds = 3600
+		s.MaxPastSeconds = 86400
+		s.OutlierAction = outlierClamp
+	})
+	assert.InDelta(t, now.Add(time.Hour).Unix(), int64(process(s, future).Time), 5)
+	assert.InDelta(t, now.Add(-24*time.Hour).Unix(), int64(process(s, past).Time), 5)
+}
@@ -0,0 +1,391 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracecontext
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+const pluginName = "processor_trace_context"
+
+// The names of the extracted fields, which are also the names of the groups of Patterns.
+const (
+	fieldTraceID      = "trace_id"
+	fieldSpanID       = "span_id"
+	fieldParentSpanID = "parent_span_id"
+	fieldSampled      = "sampled"
+	fieldTraceState   = "trace_state"
+)
+
+// The normalized header names, see normalizeKey.
+const (
+	headerTraceparent  = "traceparent"
+	headerTracestate   = "tracestate"
+	headerB3           = "b3"
+	headerB3TraceID    = "x-b3-traceid"
+	headerB3SpanID     = "x-b3-spanid"
+	headerB3ParentSpan = "x-b3-parentspanid"
+	headerB3Sampled    = "x-b3-sampled"
+	headerB3Flags      = "x-b3-flags"
+	headerJaeger       = "uber-trace-id"
+)
+
+// defaultPatterns find the trace context in the messages of the common logging frameworks, such as
+// trace_id=xxx of the OpenTelemetry log appenders and "traceId":"xxx" of the JSON logs.
+var defaultPatterns = []string{
+	`\b00-(?P<trace_id>[0-9a-f]{32})-(?P<span_id>[0-9a-f]{16})-(?P<sampled>[0-9a-f]{2})\b`,
+	`(?i)\btrace[_.-]?id["']?\s*[=:]\s*["']?(?P<trace_id>[0-9a-f]{16,32})\b`,
+	`(?i)\bspan[_.-]?id["']?\s*[=:]\s*["']?(?P<span_id>[0-9a-f]{16})\b`,
+}
+
+// traceContext is the canonical trace context, the ids are in lower case hex of the W3C lengths.
+type traceContext struct {
+	traceID      string
+	spanID       string
+	parentSpanID string
+	sampled      string
+	traceState   string
+}
+
+// ProcessorTraceContext extracts the trace context from the propagation headers recorded in the logs,
+// such as the $http_traceparent of nginx, or from the log messages by Patterns, into the canonical
+// trace_id, span_id, parent_span_id, sampled and trace_state fields prefixed by Prefix, so that the
+// logs of different frameworks could be correlated with the traces uniformly.
+//
+// The headers are matched case-insensitively, and the underscores and the http_ prefix are ignored,
+// e.g. HTTP_X_B3_TRACEID matches X-B3-TraceId. The formats are tried in the order of:
+//   - W3C Trace Context: traceparent and tracestate.
+//   - B3 single header: b3.
+//   - B3 multiple headers: X-B3-TraceId, X-B3-SpanId, X-B3-ParentSpanId, X-B3-Sampled and X-B3-Flags.
+//   - Jaeger: uber-trace-id.
+//   - Patterns on the values of PatternKeys.
+//
+// The 64 bits trace ids of B3 and Jaeger are padded to 128 bits with zeros as OpenTelemetry does.
+type ProcessorTraceContext struct {
+	// The contents in which Patterns are searched, such as content.
+	PatternKeys []string
+	// The regex patterns with the named groups trace_id, span_id, parent_span_id, sampled and trace_state.
+	// The former patterns take precedence, and the default patterns are used when empty.
+	Patterns []string
+	// The prefix of the extracted fields, __tag__: by default.
+	Prefix string
+	// Overwrite the extracted fields if they already exist.
+	Overwrite bool
+	// Keep the header contents after extraction.
+	KeepSource bool
+
+	context  pipeline.Context
+	patterns []*regexp.Regexp
+}
+
+// Init called for init some system resources, like socket, mutex...
+func (p *ProcessorTraceContext) Init(context pipeline.Context) error {
+	p.context = context
+	patterns := p.Patterns
+	if len(patterns) == 0 {
+		patterns = defaultPatterns
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %v for plugin %v: %v", pattern, pluginName, err)
+		}
+		valid := false
+		for _, name := range re.SubexpNames() {
+			switch name {
+			case fieldTraceID, fieldSpanID, fieldParentSpanID, fieldSampled, fieldTraceState:
+				valid = true
+			}
+		}
+		if !valid {
+			return fmt.Errorf("pattern %v has no named group of trace_id, span_id, parent_span_id, sampled or trace_state for plugin %v", pattern, pluginName)
+		}
+		p.patterns = append(p.patterns, re)
+	}
+	return nil
+}
+
+func (*ProcessorTraceContext) Description() string {
+	return "trace context processor for logtail, which extracts the trace ids from the headers and the messages"
+}
+
+func (p *ProcessorTraceContext) ProcessLogs(logArray []*protocol.Log) []*protocol.Log {
+	for _, log := range logArray {
+		p.processLog(log)
+	}
+	return logArray
+}
+
+func (p *ProcessorTraceContext) processLog(log *protocol.Log) {
+	if !p.Overwrite {
+		for _, content := range log.Contents {
+			if content.Key == p.Prefix+fieldTraceID {
+				return
+			}
+		}
+	}
+	headers := make(map[string]int)
+	for i, content := range log.Contents {
+		if key := normalizeKey(content.Key); isHeader(key) {
+			headers[key] = i
+		}
+	}
+	header := func(name string) string {
+		if i, ok := headers[name]; ok {
+			return strings.TrimSpace(log.Contents[i].Value)
+		}
+		return ""
+	}
+
+	tc, ok := p.extract(log, header)
+	if !ok {
+		return
+	}
+
+	if p.Overwrite {
+		p.removeFields(log)
+	}
+	if !p.KeepSource && len(headers) > 0 {
+		contents := log.Contents[:0]
+		for _, content := range log.Contents {
+			if !isHeader(normalizeKey(content.Key)) {
+				contents = append(contents, content)
+			}
+		}
+		log.Contents = contents
+	}
+	p.add(log, fieldTraceID, tc.traceID)
+	p.add(log, fieldSpanID, tc.spanID)
+	p.add(log, fieldParentSpanID, tc.parentSpanID)
+	p.add(log, fieldSampled, tc.sampled)
+	p.add(log, fieldTraceState, tc.traceState)
+}
+
+// extract returns the trace context of the first matched format.
+func (p *ProcessorTraceContext) extract(log *protocol.Log, header func(string) string) (traceContext, bool) {
+	if tc, ok := parseTraceparent(header(headerTraceparent)); ok {
+		tc.traceState = header(headerTracestate)
+		return tc, true
+	}
+	if tc, ok := parseB3(header(headerB3)); ok {
+		return tc, true
+	}
+	if tc, ok := parseB3Headers(header); ok {
+		return tc, true
+	}
+	if tc, ok := parseJaeger(header(headerJaeger)); ok {
+		return tc, true
+	}
+	return p.parsePatterns(log)
+}
+
+func (p *ProcessorTraceContext) add(log *protocol.Log, field, value string) {
+	if value != "" {
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: p.Prefix + field, Value: value})
+	}
+}
+
+func (p *ProcessorTraceContext) removeFields(log *protocol.Log) {
+	contents := log.Contents[:0]
+	for _, content := range log.Contents {
+		switch strings.TrimPrefix(content.Key, p.Prefix) {
+		case fieldTraceID, fieldSpanID, fieldParentSpanID, fieldSampled, fieldTraceState:
+			if strings.HasPrefix(content.Key, p.Prefix) {
+				continue
+			}
+		}
+		contents = append(contents, content)
+	}
+	log.Contents = contents
+}
+
+func (p *ProcessorTraceContext) parsePatterns(log *protocol.Log) (traceContext, bool) {
+	var tc traceContext
+	for _, key := range p.PatternKeys {
+		for _, content := range log.Contents {
+			if content.Key != key {
+				continue
+			}
+			for _, re := range p.patterns {
+				match := re.FindStringSubmatch(content.Value)
+				if match == nil {
+					continue
+				}
+				for i, name := range re.SubexpNames() {
+					if match[i] == "" {
+						continue
+					}
+					switch name {
+					case fieldTraceID:
+						if tc.traceID == "" {
+							tc.traceID = normalizeID(match[i], 32)
+						}
+					case fieldSpanID:
+						if tc.spanID == "" {
+							tc.spanID = normalizeID(match[i], 16)
+						}
+					case fieldParentSpanID:
+						if tc.parentSpanID == "" {
+							tc.parentSpanID = normalizeID(match[i], 16)
+						}
+					case fieldSampled:
+						if tc.sampled == "" {
+							tc.sampled = parseSampled(match[i])
+						}
+					case fieldTraceState:
+						if tc.traceState == "" {
+							tc.traceState = match[i]
+						}
+					}
+				}
+			}
+		}
+	}
+	return tc, tc.traceID != ""
+}
+
+// parseTraceparent parses the W3C traceparent: {version}-{trace-id}-{parent-id}-{trace-flags}.
+func parseTraceparent(value string) (traceContext, bool) {
+	parts := strings.Split(value, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) ||
+		len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 || !isHex(parts[0]) || !isHex(parts[3]) {
+		return traceContext{}, false
+	}
+	tc := traceContext{traceID: normalizeID(parts[1], 32), spanID: normalizeID(parts[2], 16), sampled: parseSampled(parts[3])}
+	return tc, tc.traceID != "" && tc.spanID != ""
+}
+
+// parseB3 parses the B3 single header: {TraceId}-{SpanId}-{SamplingState}-{ParentSpanId}, in which
+// the sampling state and the parent span id are optional, or only the sampling state.
+func parseB3(value string) (traceContext, bool) {
+	parts := strings.Split(value, "-")
+	if len(parts) < 2 || len(parts) > 4 {
+		return traceContext{}, false
+	}
+	tc := traceContext{traceID: normalizeID(parts[0], 32), spanID: normalizeID(parts[1], 16)}
+	if len(parts) > 2 {
+		tc.sampled = parseSampled(parts[2])
+	}
+	if len(parts) > 3 {
+		tc.parentSpanID = normalizeID(parts[3], 16)
+	}
+	return tc, tc.traceID != "" && tc.spanID != ""
+}
+
+func parseB3Headers(header func(string) string) (traceContext, bool) {
+	tc := traceContext{
+		traceID:      normalizeID(header(headerB3TraceID), 32),
+		spanID:       normalizeID(header(headerB3SpanID), 16),
+		parentSpanID: normalizeID(header(headerB3ParentSpan), 16),
+		sampled:      parseSampled(header(headerB3Sampled)),
+	}
+	// the debug flag implies sampled.
+	if header(headerB3Flags) == "1" {
+		tc.sampled = "1"
+	}
+	return tc, tc.traceID != "" && tc.spanID != ""
+}
+
+// parseJaeger parses the Jaeger uber-trace-id: {trace-id}:{span-id}:{parent-span-id}:{flags}, which
+// may be url encoded.
+func parseJaeger(value string) (traceContext, bool) {
+	if strings.Contains(value, "%") {
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+	}
+	parts := strings.Split(value, ":")
+	if len(parts) != 4 {
+		return traceContext{}, false
+	}
+	tc := traceContext{traceID: normalizeID(parts[0], 32), spanID: normalizeID(parts[1], 16), parentSpanID: normalizeID(parts[2], 16)}
+	if flags := parts[3]; isHex(flags) && flags != "" {
+		if flags[len(flags)-1]&1 == 1 {
+			tc.sampled = "1"
+		} else {
+			tc.sampled = "0"
+		}
+	}
+	return tc, tc.traceID != "" && tc.spanID != ""
+}
+
+// parseSampled returns 1 or 0 for the sampling states or the trace flags, and empty for the unknown ones.
+func parseSampled(value string) string {
+	switch strings.ToLower(value) {
+	case "1", "d", "true":
+		return "1"
+	case "0", "false":
+		return "0"
+	}
+	// the sampled flag is the lowest bit of the W3C trace flags.
+	if len(value) == 2 && isHex(value) {
+		if strings.ContainsRune("13579bdfBDF", rune(value[1])) {
+			return "1"
+		}
+		return "0"
+	}
+	return ""
+}
+
+// normalizeID returns the id in lower case hex padded with zeros to the length, or empty if the id is
+// invalid, too long or all zeros.
+func normalizeID(id string, length int) string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if id == "" || len(id) > length || !isHex(id) || strings.Trim(id, "0") == "" {
+		return ""
+	}
+	return strings.Repeat("0", length-len(id)) + id
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeKey converts the keys of the headers to the lower case with dashes, and removes the http-
+// prefix of the nginx variables, e.g. http_x_b3_traceid to x-b3-traceid.
+func normalizeKey(key string) string {
+	key = strings.ReplaceAll(strings.ToLower(key), "_", "-")
+	return strings.TrimPrefix(key, "http-")
+}
+
+func isHeader(key string) bool {
+	switch key {
+	case headerTraceparent, headerTracestate, headerB3, headerB3TraceID, headerB3SpanID, headerB3ParentSpan,
+		headerB3Sampled, headerB3Flags, headerJaeger:
+		return true
+	}
+	return false
+}
+
+func init() {
+	pipeline.Processors[pluginName] = func() pipeline.Processor {
+		return &ProcessorTraceContext{
+			Prefix:     "__tag__:",
+			KeepSource: true,
+		}
+	}
+}
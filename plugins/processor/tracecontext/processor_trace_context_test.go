@@ -0,0 +1,132 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracecontext
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+func newProcessor(t *testing.T, configure func(p *ProcessorTraceContext)) *ProcessorTraceContext {
+	p := pipeline.Processors[pluginName]().(*ProcessorTraceContext)
+	configure(p)
+	require.NoError(t, p.Init(mock.NewEmptyContext("p", "l", "c")))
+	return p
+}
+
+func newLog(kvs ...string) *protocol.Log {
+	log := &protocol.Log{}
+	for i := 0; i < len(kvs); i += 2 {
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: kvs[i], Value: kvs[i+1]})
+	}
+	return log
+}
+
+func extracted(log *protocol.Log) map[string]string {
+	result := make(map[string]string)
+	for _, content := range log.Contents {
+		if strings.HasPrefix(content.Key, "__tag__:") {
+			result[strings.TrimPrefix(content.Key, "__tag__:")] = content.Value
+		}
+	}
+	return result
+}
+
+func TestHeaders(t *testing.T) {
+	p := newProcessor(t, func(p *ProcessorTraceContext) {})
+	logs := p.ProcessLogs([]*protocol.Log{
+		newLog("http_traceparent", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", "http_tracestate", "congo=t61rcWkgMzE",
+			"X-B3-TraceId", "80f198ee56343ba864fe8b2a57d3eff7"),
+		newLog("b3", "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-d-05e3ac9a4f6e3b90"),
+		newLog("X-B3-TraceId", "a3ce929d0e0e4736", "X-B3-SpanId", "00f067aa0ba902b7", "X-B3-Sampled", "0"),
+		newLog("uber-trace-id", "a3ce929d0e0e4736%3A00f067aa0ba902b7%3A0%3A1"),
+		newLog("traceparent", "00-00000000000000000000000000000000-00f067aa0ba902b7-01"),
+	})
+	assert.Equal(t, map[string]string{
+		"trace_id":    "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":     "00f067aa0ba902b7",
+		"sampled":     "1",
+		"trace_state": "congo=t61rcWkgMzE",
+	}, extracted(logs[0]))
+	assert.Len(t, logs[0].Contents, 7)
+	assert.Equal(t, map[string]string{
+		"trace_id":       "80f198ee56343ba864fe8b2a57d3eff7",
+		"span_id":        "e457b5a2e4d86bd1",
+		"parent_span_id": "05e3ac9a4f6e3b90",
+		"sampled":        "1",
+	}, extracted(logs[1]))
+	assert.Equal(t, map[string]string{
+		"trace_id": "0000000000000000a3ce929d0e0e4736",
+		"span_id":  "00f067aa0ba902b7",
+		"sampled":  "0",
+	}, extracted(logs[2]))
+	assert.Equal(t, map[string]string{
+		"trace_id": "0000000000000000a3ce929d0e0e4736",
+		"span_id":  "00f067aa0ba902b7",
+		"sampled":  "1",
+	}, extracted(logs[3]))
+	// the invalid trace id is ignored.
+	assert.Empty(t, extracted(logs[4]))
+}
+
+func TestPatterns(t *testing.T) {
+	p := newProcessor(t, func(p *ProcessorTraceContext) {
+		p.PatternKeys = []string{"content"}
+		p.Prefix = ""
+		p.KeepSource = false
+	})
+	logs := p.ProcessLogs([]*protocol.Log{
+		newLog("content", `2023-05-01 INFO [main] trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 started`),
+		newLog("content", `{"msg":"ok","traceId":"A3CE929D0E0E4736","spanId":"00f067aa0ba902b7"}`),
+		newLog("content", `forwarded traceparent: 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00`),
+		newLog("content", `no trace`, "traceparent", "invalid"),
+	})
+	assert.Equal(t, []*protocol.Log_Content{
+		{Key: "content", Value: `2023-05-01 INFO [main] trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 started`},
+		{Key: "trace_id", Value: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{Key: "span_id", Value: "00f067aa0ba902b7"},
+	}, logs[0].Contents)
+	assert.Equal(t, "0000000000000000a3ce929d0e0e4736", logs[1].Contents[1].Value)
+	assert.Equal(t, []*protocol.Log_Content{
+		{Key: "content", Value: `forwarded traceparent: 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00`},
+		{Key: "trace_id", Value: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{Key: "span_id", Value: "00f067aa0ba902b7"},
+		{Key: "sampled", Value: "0"},
+	}, logs[2].Contents)
+	// the headers are kept when nothing is extracted.
+	assert.Len(t, logs[3].Contents, 2)
+}
+
+func TestOverwrite(t *testing.T) {
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	p := newProcessor(t, func(p *ProcessorTraceContext) {})
+	log := p.ProcessLogs([]*protocol.Log{newLog("traceparent", traceparent, "__tag__:trace_id", "old")})[0]
+	assert.Equal(t, map[string]string{"trace_id": "old"}, extracted(log))
+
+	p = newProcessor(t, func(p *ProcessorTraceContext) { p.Overwrite = true })
+	log = p.ProcessLogs([]*protocol.Log{newLog("traceparent", traceparent, "__tag__:trace_id", "old", "__tag__:sampled", "0")})[0]
+	assert.Equal(t, map[string]string{"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736", "span_id": "00f067aa0ba902b7", "sampled": "1"}, extracted(log))
+
+	invalid := pipeline.Processors[pluginName]().(*ProcessorTraceContext)
+	invalid.Patterns = []string{`trace=(\w+)`}
+	assert.Error(t, invalid.Init(mock.NewEmptyContext("p", "l", "c")))
+}
@@ -0,0 +1,109 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+)
+
+// hostModule returns the host functions exported to the wasm modules. The strings are passed by the pointers
+// and the lengths in the memory of the module.
+//
+//	get_field(key_ptr, key_len, buf_ptr, buf_cap) -> value_len: copies the value of the field to the buffer if
+//	    it fits, returns -1 if the field doesn't exist.
+//	set_field(key_ptr, key_len, value_ptr, value_len): adds or replaces the field.
+//	delete_field(key_ptr, key_len): deletes the field.
+//	add_tag(key_ptr, key_len, value_ptr, value_len): adds or replaces the tag.
+//	drop(): drops the log.
+//	get_config(buf_ptr, buf_cap) -> config_len: copies the json of Config to the buffer if it fits.
+//	log(msg_ptr, msg_len): writes the message to the log of ilogtail.
+func (p *ProcessorWasm) hostModule() wazero.HostModuleBuilder {
+	return p.runtime.NewHostModuleBuilder(hostModuleName).
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module, keyPtr, keyLen, bufPtr, bufCap uint32) int32 {
+		key := readString(m, keyPtr, keyLen)
+		for _, content := range currentEvent(ctx).log.Contents {
+			if content.Key == key {
+				return writeString(m, bufPtr, bufCap, content.Value)
+			}
+		}
+		return -1
+	}).Export("get_field").
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module, keyPtr, keyLen, valuePtr, valueLen uint32) {
+		setContent(currentEvent(ctx).log, readString(m, keyPtr, keyLen), readString(m, valuePtr, valueLen))
+	}).Export("set_field").
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module, keyPtr, keyLen uint32) {
+		log, key := currentEvent(ctx).log, readString(m, keyPtr, keyLen)
+		for i, content := range log.Contents {
+			if content.Key == key {
+				log.Contents = append(log.Contents[:i], log.Contents[i+1:]...)
+				return
+			}
+		}
+	}).Export("delete_field").
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module, keyPtr, keyLen, valuePtr, valueLen uint32) {
+		setContent(currentEvent(ctx).log, tagPrefix+readString(m, keyPtr, keyLen), readString(m, valuePtr, valueLen))
+	}).Export("add_tag").
+		NewFunctionBuilder().WithFunc(func(ctx context.Context) {
+		currentEvent(ctx).dropped = true
+	}).Export("drop").
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module, bufPtr, bufCap uint32) int32 {
+		return writeString(m, bufPtr, bufCap, string(p.config))
+	}).Export("get_config").
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module, msgPtr, msgLen uint32) {
+		logger.Info(p.context.GetRuntimeContext(), "wasm module", p.ModulePath, "message", readString(m, msgPtr, msgLen))
+	}).Export("log")
+}
+
+func currentEvent(ctx context.Context) *wasmEvent {
+	if event, ok := ctx.Value(eventKey{}).(*wasmEvent); ok {
+		return event
+	}
+	// called when the module is initialized
+	return &wasmEvent{log: &protocol.Log{}}
+}
+
+// readString reads the string from the memory of the module, the panic traps the module.
+func readString(m api.Module, ptr, length uint32) string {
+	b, ok := m.Memory().Read(ptr, length)
+	if !ok {
+		panic(fmt.Errorf("out of memory range, offset %d, length %d", ptr, length))
+	}
+	return string(b)
+}
+
+// writeString writes @s to the buffer if it fits, and returns the length of @s.
+func writeString(m api.Module, ptr, capacity uint32, s string) int32 {
+	if uint32(len(s)) <= capacity && !m.Memory().WriteString(ptr, s) {
+		panic(fmt.Errorf("out of memory range, offset %d, length %d", ptr, len(s)))
+	}
+	return int32(len(s))
+}
+
+func setContent(log *protocol.Log, key, value string) {
+	for _, content := range log.Contents {
+		if content.Key == key {
+			content.Value = value
+			return
+		}
+	}
+	log.Contents = append(log.Contents, &protocol.Log_Content{Key: key, Value: value})
+}
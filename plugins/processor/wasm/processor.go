@@ -0,0 +1,202 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/pipeline"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/pkg/util"
+)
+
+const (
+	pluginName = "processor_wasm"
+	// hostModuleName is the module name of the host functions imported by the wasm modules.
+	hostModuleName = "ilogtail"
+	tagPrefix      = "__tag__:"
+
+	defaultMaxMemoryPages = 256
+	defaultTimeoutMs      = 100
+)
+
+// ProcessorWasm processes the logs by the exported function of a user-supplied wasm module, which reads
+// and modifies the log by the host functions of the "ilogtail" module, see the docs for the ABI.
+// The module runs in the sandbox of wazero without the access to the file system and network.
+type ProcessorWasm struct {
+	// The path of the wasm module.
+	ModulePath string
+	// The exported function called for each log, "process" by default.
+	Function string
+	// The config passed to the module, which could be read in json by get_config.
+	Config map[string]interface{}
+	// The max memory of the module in 64KiB pages, 256 (16MiB) by default.
+	MaxMemoryPages int
+	// The max time to process a log, 100 by default.
+	TimeoutMs int
+	// Drop the log if the module fails to process it, otherwise the log is passed through.
+	DropOnError bool
+
+	context  pipeline.Context
+	timeout  time.Duration
+	config   []byte
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	// the module is called by one log at a time.
+	lock    sync.Mutex
+	module  api.Module
+	process api.Function
+}
+
+// wasmEvent is the log being processed, which is passed to the host functions by the context.
+type wasmEvent struct {
+	log     *protocol.Log
+	dropped bool
+}
+
+type eventKey struct{}
+
+func (p *ProcessorWasm) Init(context pipeline.Context) error {
+	p.context = context
+	if p.ModulePath == "" {
+		return fmt.Errorf("the ModulePath of %s is required", pluginName)
+	}
+	if p.Function == "" {
+		p.Function = "process"
+	}
+	if p.MaxMemoryPages <= 0 {
+		p.MaxMemoryPages = defaultMaxMemoryPages
+	}
+	if p.TimeoutMs <= 0 {
+		p.TimeoutMs = defaultTimeoutMs
+	}
+	p.timeout = time.Duration(p.TimeoutMs) * time.Millisecond
+	var err error
+	if p.config, err = json.Marshal(p.Config); err != nil {
+		return err
+	}
+	binary, err := os.ReadFile(p.ModulePath)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.GetRuntimeContext()
+	p.runtime = wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(uint32(p.MaxMemoryPages)).
+		WithCloseOnContextDone(true))
+	if err = p.init(ctx, binary); err != nil {
+		_ = p.runtime.Close(ctx)
+		logger.Error(ctx, util.AlarmProcessorInit, "init wasm module error", err, "module", p.ModulePath)
+		return err
+	}
+	return nil
+}
+
+func (p *ProcessorWasm) init(ctx context.Context, binary []byte) error {
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, p.runtime); err != nil {
+		return err
+	}
+	if _, err := p.hostModule().Instantiate(ctx); err != nil {
+		return err
+	}
+	var err error
+	if p.compiled, err = p.runtime.CompileModule(ctx, binary); err != nil {
+		return err
+	}
+	return p.instantiate(ctx)
+}
+
+// instantiate creates a new instance of the module, the reactor modules are initialized by "_initialize".
+func (p *ProcessorWasm) instantiate(ctx context.Context) error {
+	module, err := p.runtime.InstantiateModule(ctx, p.compiled, wazero.NewModuleConfig().WithName("").WithStartFunctions("_initialize"))
+	if err != nil {
+		return err
+	}
+	process := module.ExportedFunction(p.Function)
+	if process == nil {
+		_ = module.Close(ctx)
+		return fmt.Errorf("the module doesn't export the function %s", p.Function)
+	}
+	p.module, p.process = module, process
+	return nil
+}
+
+func (*ProcessorWasm) Description() string {
+	return "wasm processor to process the logs by the user-supplied wasm module"
+}
+
+func (p *ProcessorWasm) ProcessLogs(logArray []*protocol.Log) []*protocol.Log {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	result := logArray[:0]
+	for _, log := range logArray {
+		if p.module == nil {
+			// the module failed to be instantiated again
+			if !p.DropOnError {
+				result = append(result, log)
+			}
+			continue
+		}
+		event := &wasmEvent{log: log}
+		ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), eventKey{}, event), p.timeout)
+		_, err := p.process.Call(ctx)
+		cancel()
+		if err != nil {
+			logger.Warning(p.context.GetRuntimeContext(), util.AlarmWasmProcess, "process log by wasm module error", err, "drop", p.DropOnError)
+			// the module may be closed by the timeout or broken by the trap, so a new instance is used
+			p.reinstantiate()
+			if p.DropOnError {
+				continue
+			}
+		} else if event.dropped {
+			continue
+		}
+		result = append(result, log)
+	}
+	return result
+}
+
+func (p *ProcessorWasm) reinstantiate() {
+	ctx := p.context.GetRuntimeContext()
+	_ = p.module.Close(ctx)
+	p.module, p.process = nil, nil
+	if err := p.instantiate(ctx); err != nil {
+		logger.Error(ctx, util.AlarmWasmProcess, "instantiate wasm module error", err)
+	}
+}
+
+// Stop releases the runtime of the module.
+func (p *ProcessorWasm) Stop() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.module, p.process = nil, nil
+	return p.runtime.Close(p.context.GetRuntimeContext())
+}
+
+func init() {
+	pipeline.Processors[pluginName] = func() pipeline.Processor {
+		return &ProcessorWasm{}
+	}
+}
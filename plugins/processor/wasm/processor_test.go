@@ -0,0 +1,77 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alibaba/ilogtail/pkg/logger"
+	"github.com/alibaba/ilogtail/pkg/protocol"
+	"github.com/alibaba/ilogtail/plugins/test/mock"
+)
+
+func init() {
+	logger.InitTestLogger(logger.OptionOpenMemoryReceiver)
+}
+
+func newLog(kv ...string) *protocol.Log {
+	log := &protocol.Log{}
+	for i := 0; i < len(kv); i += 2 {
+		log.Contents = append(log.Contents, &protocol.Log_Content{Key: kv[i], Value: kv[i+1]})
+	}
+	return log
+}
+
+func TestProcessorWasm(t *testing.T) {
+	processor := &ProcessorWasm{ModulePath: "testdata/filter.wasm"}
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+	defer processor.Stop() //nolint:errcheck
+
+	logs := processor.ProcessLogs([]*protocol.Log{
+		newLog("level", "DEBUG", "msg", "a"),
+		newLog("level", "ERROR", "msg", "b"),
+		newLog("msg", "c"),
+	})
+	require.Len(t, logs, 2)
+	assert.Equal(t, newLog("level", "ERROR", "msg", "b", "checked", "true", "__tag__:env", "prod"), logs[0])
+	assert.Equal(t, newLog("msg", "c", "checked", "true", "__tag__:env", "prod"), logs[1])
+
+	// the field is replaced instead of added
+	logs = processor.ProcessLogs([]*protocol.Log{newLog("checked", "false")})
+	assert.Equal(t, newLog("checked", "true", "__tag__:env", "prod"), logs[0])
+}
+
+func TestProcessorWasmTimeout(t *testing.T) {
+	processor := &ProcessorWasm{ModulePath: "testdata/loop.wasm", TimeoutMs: 10}
+	require.NoError(t, processor.Init(mock.NewEmptyContext("p", "l", "c")))
+	defer processor.Stop() //nolint:errcheck
+
+	logs := processor.ProcessLogs([]*protocol.Log{newLog("msg", "a")})
+	assert.Len(t, logs, 1, "the log should be passed through on error")
+	require.NotNil(t, processor.module, "the module should be instantiated again")
+	processor.DropOnError = true
+	assert.Len(t, processor.ProcessLogs([]*protocol.Log{newLog("msg", "a")}), 0)
+}
+
+func TestProcessorWasmInitError(t *testing.T) {
+	ctx := mock.NewEmptyContext("p", "l", "c")
+	assert.Error(t, (&ProcessorWasm{}).Init(ctx))
+	assert.Error(t, (&ProcessorWasm{ModulePath: "testdata/not_exist.wasm"}).Init(ctx))
+	assert.ErrorContains(t, (&ProcessorWasm{ModulePath: "testdata/filter.wasm", Function: "other"}).Init(ctx), "doesn't export the function other")
+	assert.Error(t, (&ProcessorWasm{ModulePath: "testdata/filter.wat"}).Init(ctx))
+}
@@ -0,0 +1,20 @@
+;; Drops the logs whose level starts with "DEBU", and adds the field checked=true and the tag env=prod to the others.
+(module
+  (import "ilogtail" "get_field" (func $get_field (param i32 i32 i32 i32) (result i32)))
+  (import "ilogtail" "set_field" (func $set_field (param i32 i32 i32 i32)))
+  (import "ilogtail" "add_tag" (func $add_tag (param i32 i32 i32 i32)))
+  (import "ilogtail" "drop" (func $drop))
+  (memory (export "memory") 1)
+  (data (i32.const 0) "level")
+  (data (i32.const 8) "DEBUG")
+  (data (i32.const 16) "env")
+  (data (i32.const 24) "prod")
+  (data (i32.const 32) "checked")
+  (data (i32.const 40) "true")
+  (func (export "process")
+    (if (i32.eq (call $get_field (i32.const 0) (i32.const 5) (i32.const 64) (i32.const 16)) (i32.const 5))
+      (then
+        (if (i32.eq (i32.load (i32.const 64)) (i32.load (i32.const 8)))
+          (then (call $drop) (return)))))
+    (call $set_field (i32.const 32) (i32.const 7) (i32.const 40) (i32.const 4))
+    (call $add_tag (i32.const 16) (i32.const 3) (i32.const 24) (i32.const 4))))
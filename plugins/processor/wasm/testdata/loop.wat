@@ -0,0 +1,5 @@
+;; Never returns, which is stopped by the timeout.
+(module
+  (memory (export "memory") 1)
+  (func (export "process")
+    (loop $forever (br $forever))))
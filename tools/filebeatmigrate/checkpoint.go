@@ -0,0 +1,175 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/spaolacci/murmur3"
+)
+
+const (
+	// the version of the checkpoint file written by ilogtail, see check_point_version of the C++ part.
+	checkpointVersion = 200
+	// the signature of a file is the hash of the first bytes of it, see CheckFileSignatureAndOffset of the C++ part.
+	signatureMaxSize = 1024
+	signatureSeed    = 0xdeadbeaf
+)
+
+// fileCheckpoint is a file checkpoint in the checkpoint file of ilogtail, see CheckPointManager of the C++ part.
+type fileCheckpoint struct {
+	FileName     string `json:"file_name"`
+	RealFileName string `json:"real_file_name"`
+	// Offset is a string of the decimal number
+	Offset     string `json:"offset"`
+	SigSize    uint32 `json:"sig_size"`
+	SigHash    uint64 `json:"sig_hash"`
+	UpdateTime int32  `json:"update_time"`
+	Inode      uint64 `json:"inode"`
+	Dev        uint64 `json:"dev"`
+	FileOpen   int    `json:"file_open"`
+	// ConfigName is omitted to bind the checkpoint to all the configs matching the file when ilogtail loads it.
+	ConfigName string `json:"config_name,omitempty"`
+}
+
+// key returns the key of the checkpoint in the checkpoint file.
+func (c *fileCheckpoint) key() string {
+	return c.FileName + "*" + strconv.FormatUint(c.Dev, 10) + "*" + strconv.FormatUint(c.Inode, 10) + "*" + c.ConfigName
+}
+
+// newCheckpoint converts the state to the checkpoint of the file. The file is looked up by the device and the inode
+// in the directory of the source if it is rotated, and an error is returned if the file is deleted.
+func newCheckpoint(state fileState, configName string, now time.Time) (*fileCheckpoint, error) {
+	realPath := state.Source
+	dev, inode, err := devInode(realPath)
+	if state.HasDevInode && (err != nil || dev != state.Device || inode != state.Inode) {
+		realPath, err = findRotatedFile(filepath.Dir(state.Source), state.Device, state.Inode)
+		if err != nil {
+			return nil, err
+		}
+		dev, inode = state.Device, state.Inode
+	} else if err != nil {
+		return nil, err
+	}
+	sigSize, sigHash, err := fileSignature(realPath)
+	if err != nil {
+		return nil, err
+	}
+	cp := &fileCheckpoint{
+		FileName:   state.Source,
+		Offset:     strconv.FormatInt(state.Offset, 10),
+		SigSize:    sigSize,
+		SigHash:    sigHash,
+		UpdateTime: int32(now.Unix()),
+		Inode:      inode,
+		Dev:        dev,
+		ConfigName: configName,
+	}
+	if realPath != state.Source {
+		cp.RealFileName = realPath
+	}
+	return cp, nil
+}
+
+// findRotatedFile finds the file with the device and the inode in @dir.
+func findRotatedFile(dir string, dev, inode uint64) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if d, i, err := devInode(path); err == nil && d == dev && i == inode {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("the file with device %d and inode %d is not found in %s", dev, inode, dir)
+}
+
+// fileSignature returns the size and the hash of the signature of the file, which is the first 1024 bytes before
+// the first NUL byte.
+func fileSignature(path string) (uint32, uint64, error) {
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close() //nolint:errcheck
+	buf := make([]byte, signatureMaxSize)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return 0, 0, err
+	}
+	sig := buf[:n]
+	if i := bytes.IndexByte(sig, 0); i >= 0 {
+		sig = sig[:i]
+	}
+	h1, _ := murmur3.Sum128WithSeed(sig, signatureSeed)
+	return uint32(len(sig)), h1, nil
+}
+
+// mergeCheckpoints adds the checkpoints to the checkpoint file of ilogtail, the other content of the file is kept.
+// The file is replaced by renaming to avoid leaving a broken file.
+func mergeCheckpoints(path string, checkpoints []*fileCheckpoint) error {
+	root := make(map[string]json.RawMessage)
+	if data, err := os.ReadFile(path); err == nil { //nolint:gosec
+		if err = json.Unmarshal(data, &root); err != nil {
+			return fmt.Errorf("decode the checkpoint file %s error: %v", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	files := make(map[string]json.RawMessage)
+	if raw, ok := root["check_point"]; ok {
+		if err := json.Unmarshal(raw, &files); err != nil {
+			return fmt.Errorf("decode the file checkpoints of %s error: %v", path, err)
+		}
+	}
+	for _, cp := range checkpoints {
+		raw, err := json.Marshal(cp)
+		if err != nil {
+			return err
+		}
+		files[cp.key()] = raw
+	}
+	var err error
+	if root["check_point"], err = json.Marshal(files); err != nil {
+		return err
+	}
+	if _, ok := root["version"]; !ok {
+		root["version"] = json.RawMessage(strconv.Itoa(checkpointVersion))
+	}
+	data, err := json.MarshalIndent(root, "", "   ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	tmp := path + ".migrate"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
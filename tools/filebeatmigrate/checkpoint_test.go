@@ -0,0 +1,106 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSignature(t *testing.T) {
+	dir := t.TempDir()
+	long := filepath.Join(dir, "long.log")
+	require.NoError(t, os.WriteFile(long, []byte(strings.Repeat("a", 2000)), 0o600))
+	prefix := filepath.Join(dir, "prefix.log")
+	require.NoError(t, os.WriteFile(prefix, []byte(strings.Repeat("a", 1024)), 0o600))
+	nul := filepath.Join(dir, "nul.log")
+	require.NoError(t, os.WriteFile(nul, []byte("abc\x00def"), 0o600))
+
+	size, hash, err := fileSignature(long)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1024), size)
+	_, prefixHash, err := fileSignature(prefix)
+	require.NoError(t, err)
+	assert.Equal(t, prefixHash, hash)
+
+	size, _, err = fileSignature(nul)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(3), size)
+}
+
+func TestNewCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	rotated := filepath.Join(dir, "app.log.1")
+	require.NoError(t, os.WriteFile(rotated, []byte("old logs\n"), 0o600))
+	dev, inode, err := devInode(rotated)
+	require.NoError(t, err)
+	current := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(current, []byte("new logs\n"), 0o600))
+	now := time.Unix(1672531200, 0)
+
+	cp, err := newCheckpoint(fileState{Source: current, Offset: 4, HasDevInode: true, Device: dev, Inode: inode}, "", now)
+	require.NoError(t, err)
+	assert.Equal(t, current, cp.FileName)
+	assert.Equal(t, rotated, cp.RealFileName)
+	assert.Equal(t, "4", cp.Offset)
+	assert.Equal(t, inode, cp.Inode)
+	assert.Equal(t, int32(1672531200), cp.UpdateTime)
+	assert.Equal(t, current+"*"+strconv.FormatUint(dev, 10)+"*"+strconv.FormatUint(inode, 10)+"*", cp.key())
+
+	// the states identified by the path use the current file
+	cp, err = newCheckpoint(fileState{Source: current, Offset: 8}, "config#/etc/ilogtail/config/app.yaml", now)
+	require.NoError(t, err)
+	assert.Empty(t, cp.RealFileName)
+	assert.Equal(t, "config#/etc/ilogtail/config/app.yaml", cp.ConfigName)
+
+	require.NoError(t, os.Remove(rotated))
+	_, err = newCheckpoint(fileState{Source: current, Offset: 4, HasDevInode: true, Device: dev, Inode: inode}, "", now)
+	assert.Error(t, err)
+}
+
+func TestMergeCheckpoints(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logtail_check_point")
+	require.NoError(t, os.WriteFile(path, []byte(`{"check_point":{"/var/log/old.log*1*1*":{"file_name":"/var/log/old.log"}},"dir_check_point":{"/var/log":{}},"version":200}`), 0o600))
+
+	cp := &fileCheckpoint{FileName: "/var/log/a.log", Offset: "10", Dev: 2049, Inode: 1}
+	require.NoError(t, mergeCheckpoints(path, []*fileCheckpoint{cp}))
+
+	var root struct {
+		CheckPoint    map[string]map[string]interface{} `json:"check_point"`
+		DirCheckPoint map[string]interface{}            `json:"dir_check_point"`
+		Version       int                               `json:"version"`
+	}
+	require.NoError(t, readJSONFile(path, &root))
+	assert.Len(t, root.CheckPoint, 2)
+	assert.Equal(t, "10", root.CheckPoint["/var/log/a.log*2049*1*"]["offset"])
+	assert.NotContains(t, root.CheckPoint["/var/log/a.log*2049*1*"], "config_name")
+	assert.Contains(t, root.DirCheckPoint, "/var/log")
+	assert.Equal(t, checkpointVersion, root.Version)
+
+	newPath := filepath.Join(t.TempDir(), "new", "logtail_check_point")
+	require.NoError(t, mergeCheckpoints(newPath, []*fileCheckpoint{cp}))
+	require.NoError(t, readJSONFile(newPath, &root))
+	assert.Equal(t, checkpointVersion, root.Version)
+}
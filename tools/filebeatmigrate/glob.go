@@ -0,0 +1,185 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// recursiveGlobDepth is the max depth of the directories matched by ** in filebeat.
+const recursiveGlobDepth = 8
+
+const configHeader = `# Converted from the filebeat input %s by filebeatmigrate.
+# Add the processors and the flushers, then set enable to true.
+`
+
+// filebeatConfig is the part of filebeat.yml about the paths of the inputs. The inputs can be written either in the
+// dotted style or in the nested style, and prospectors are the inputs of filebeat 6.x.
+type filebeatConfig struct {
+	Inputs      []filebeatInput `yaml:"filebeat.inputs"`
+	Prospectors []filebeatInput `yaml:"filebeat.prospectors"`
+	Filebeat    struct {
+		Inputs      []filebeatInput `yaml:"inputs"`
+		Prospectors []filebeatInput `yaml:"prospectors"`
+	} `yaml:"filebeat"`
+}
+
+type filebeatInput struct {
+	Type         string   `yaml:"type"`
+	ID           string   `yaml:"id"`
+	Enabled      *bool    `yaml:"enabled"`
+	Paths        []string `yaml:"paths"`
+	ExcludeFiles []string `yaml:"exclude_files"`
+}
+
+// fileLogInput is the file_log input of ilogtail.
+type fileLogInput struct {
+	Type        string `yaml:"Type"`
+	LogPath     string `yaml:"LogPath"`
+	FilePattern string `yaml:"FilePattern"`
+	MaxDepth    int    `yaml:"MaxDepth,omitempty"`
+}
+
+type pipelineConfig struct {
+	Enable bool           `yaml:"enable"`
+	Inputs []fileLogInput `yaml:"inputs"`
+}
+
+// convertedConfig is a pipeline config converted from a path of a filebeat input.
+type convertedConfig struct {
+	Name     string
+	Input    string
+	Config   pipelineConfig
+	Warnings []string
+}
+
+var invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// loadFilebeatInputs loads the enabled inputs of the files from filebeat.yml.
+func loadFilebeatInputs(path string) ([]filebeatInput, error) {
+	data, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	var conf filebeatConfig
+	if err = yaml.Unmarshal(data, &conf); err != nil {
+		return nil, fmt.Errorf("decode %s error: %v", path, err)
+	}
+	var inputs []filebeatInput
+	for _, list := range [][]filebeatInput{conf.Inputs, conf.Prospectors, conf.Filebeat.Inputs, conf.Filebeat.Prospectors} {
+		inputs = append(inputs, list...)
+	}
+	return inputs, nil
+}
+
+// convertInputs converts each path of the inputs to a pipeline config of the file_log input. The inputs other than
+// log, filestream and container are skipped with warnings.
+func convertInputs(inputs []filebeatInput) ([]convertedConfig, []string) {
+	var configs []convertedConfig
+	var warnings []string
+	for i, input := range inputs {
+		name := input.ID
+		if name == "" {
+			name = "filebeat_input_" + strconv.Itoa(i)
+		}
+		name = invalidNameChars.ReplaceAllString(name, "_")
+		if input.Enabled != nil && !*input.Enabled {
+			continue
+		}
+		switch input.Type {
+		case "", "log", "filestream", "container":
+		default:
+			warnings = append(warnings, fmt.Sprintf("input %s: the input type %s is skipped", name, input.Type))
+			continue
+		}
+		for j, path := range input.Paths {
+			c := convertedConfig{Name: name, Input: name, Config: pipelineConfig{}}
+			if len(input.Paths) > 1 {
+				c.Name += "_" + strconv.Itoa(j)
+			}
+			fileLog, exact, err := globToFileLog(path)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("input %s: %v", name, err))
+				continue
+			}
+			if !exact {
+				c.Warnings = append(c.Warnings, fmt.Sprintf("the directories after ** of %s are not restricted, more files may be collected", path))
+			}
+			if len(input.ExcludeFiles) > 0 {
+				c.Warnings = append(c.Warnings, fmt.Sprintf("exclude_files %v is not converted", input.ExcludeFiles))
+			}
+			c.Config.Inputs = []fileLogInput{fileLog}
+			configs = append(configs, c)
+		}
+	}
+	return configs, warnings
+}
+
+// globToFileLog converts the glob of filebeat to the file_log input. The ** of filebeat matches at most 8 levels of
+// directories, which is the same as MaxDepth 8 of the directory before it. The directories after ** can't be
+// expressed, so the input is broader than the glob and exact is false.
+func globToFileLog(pattern string) (input fileLogInput, exact bool, err error) {
+	if !filepath.IsAbs(pattern) {
+		return input, false, fmt.Errorf("the path %s is not absolute", pattern)
+	}
+	pattern = filepath.Clean(pattern)
+	dir, base := filepath.Split(pattern)
+	dir = filepath.Clean(dir)
+	if base == "**" {
+		dir, base = filepath.Join(dir, "**"), "*"
+	}
+	input = fileLogInput{Type: "file_log", LogPath: dir, FilePattern: base}
+	parts := strings.Split(dir, string(filepath.Separator))
+	for i, part := range parts {
+		if part != "**" {
+			continue
+		}
+		input.LogPath = strings.Join(parts[:i], string(filepath.Separator))
+		if input.LogPath == "" {
+			input.LogPath = string(filepath.Separator)
+		}
+		input.MaxDepth = recursiveGlobDepth + len(parts) - i - 1
+		return input, i == len(parts)-1, nil
+	}
+	return input, true, nil
+}
+
+// writeConfigs writes the configs to @dir, the name of each file is the name of the config.
+func writeConfigs(dir string, configs []convertedConfig) error {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+	for _, c := range configs {
+		data, err := yaml.Marshal(c.Config)
+		if err != nil {
+			return err
+		}
+		header := fmt.Sprintf(configHeader, c.Input)
+		for _, warning := range c.Warnings {
+			header += "# WARNING: " + warning + "\n"
+		}
+		if err = os.WriteFile(filepath.Join(dir, c.Name+".yaml"), append([]byte(header), data...), 0o600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
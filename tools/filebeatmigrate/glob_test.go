@@ -0,0 +1,97 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobToFileLog(t *testing.T) {
+	cases := []struct {
+		pattern string
+		want    fileLogInput
+		exact   bool
+	}{
+		{"/var/log/app.log", fileLogInput{Type: "file_log", LogPath: "/var/log", FilePattern: "app.log"}, true},
+		{"/var/log/*/app-*.log", fileLogInput{Type: "file_log", LogPath: "/var/log/*", FilePattern: "app-*.log"}, true},
+		{"/var/log/**/*.log", fileLogInput{Type: "file_log", LogPath: "/var/log", FilePattern: "*.log", MaxDepth: 8}, true},
+		{"/var/log/**", fileLogInput{Type: "file_log", LogPath: "/var/log", FilePattern: "*", MaxDepth: 8}, true},
+		{"/**/*.log", fileLogInput{Type: "file_log", LogPath: "/", FilePattern: "*.log", MaxDepth: 8}, true},
+		{"/var/log/**/app/*.log", fileLogInput{Type: "file_log", LogPath: "/var/log", FilePattern: "*.log", MaxDepth: 9}, false},
+	}
+	for _, c := range cases {
+		got, exact, err := globToFileLog(c.pattern)
+		require.NoError(t, err, c.pattern)
+		assert.Equal(t, c.want, got, c.pattern)
+		assert.Equal(t, c.exact, exact, c.pattern)
+	}
+
+	_, _, err := globToFileLog("logs/*.log")
+	assert.Error(t, err)
+}
+
+func TestConvertFilebeatConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "filebeat.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`filebeat.inputs:
+- type: filestream
+  id: nginx
+  paths:
+    - /var/log/nginx/access.log
+    - /var/log/nginx/**/error.log
+- type: log
+  enabled: false
+  paths:
+    - /var/log/disabled.log
+- type: log
+  paths:
+    - /var/log/app/*.log
+  exclude_files: ['\.gz$']
+- type: tcp
+  host: localhost:9000
+output.console:
+  pretty: true
+`), 0o600))
+
+	inputs, err := loadFilebeatInputs(path)
+	require.NoError(t, err)
+	configs, warnings := convertInputs(inputs)
+	assert.Len(t, warnings, 1)
+	require.Len(t, configs, 3)
+	assert.Equal(t, "nginx_0", configs[0].Name)
+	assert.Equal(t, "nginx_1", configs[1].Name)
+	assert.Equal(t, 8, configs[1].Config.Inputs[0].MaxDepth)
+	assert.Equal(t, "filebeat_input_2", configs[2].Name)
+	assert.Len(t, configs[2].Warnings, 1)
+
+	output := filepath.Join(dir, "configs")
+	require.NoError(t, writeConfigs(output, configs))
+	data, err := os.ReadFile(filepath.Join(output, "filebeat_input_2.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, `# Converted from the filebeat input filebeat_input_2 by filebeatmigrate.
+# Add the processors and the flushers, then set enable to true.
+# WARNING: exclude_files [\.gz$] is not converted
+enable: false
+inputs:
+    - Type: file_log
+      LogPath: /var/log/app
+      FilePattern: '*.log'
+`, string(data))
+}
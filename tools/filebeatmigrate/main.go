@@ -0,0 +1,108 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// this tool is for migrating from filebeat, it imports the read progress in the registry of filebeat to the
+// checkpoint file of ilogtail, and converts the paths of the filebeat inputs to the file_log configs.
+// ilogtail must be stopped when importing, otherwise the checkpoint file is overwritten when ilogtail exits.
+// usage: filebeatmigrate -registry <registry-path> [-checkpoint <checkpoint-file>] [-filebeat-config <filebeat.yml> -output-dir <config-dir>]
+
+var registryPath = flag.String("registry", "", "the registry of filebeat, the directory data/registry/filebeat of 7.x and later, or the file data/registry of 6.x")
+var checkpointFile = flag.String("checkpoint", "/tmp/logtail_check_point", "the checkpoint file of ilogtail, which is check_point_filename in ilogtail_config.json")
+var configName = flag.String("config-name", "", "bind the checkpoints to the config, the checkpoints are bound to all the configs matching the files if empty")
+var filebeatConfigFile = flag.String("filebeat-config", "", "filebeat.yml whose inputs are converted to the file_log configs")
+var outputDir = flag.String("output-dir", "./filebeat_configs", "the directory of the converted configs")
+var dryRun = flag.Bool("dry-run", false, "print the result without writing any file")
+
+func main() {
+	flag.Parse()
+	if *registryPath == "" && *filebeatConfigFile == "" {
+		fmt.Println("neither registry nor filebeat-config is specified")
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *registryPath != "" {
+		if err := importRegistry(); err != nil {
+			fmt.Println("failed to import the registry, err:", err)
+			os.Exit(1)
+		}
+	}
+	if *filebeatConfigFile != "" {
+		if err := convertConfig(); err != nil {
+			fmt.Println("failed to convert the filebeat config, err:", err)
+			os.Exit(1)
+		}
+	}
+}
+
+func importRegistry() error {
+	states, err := loadRegistry(*registryPath)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	checkpoints := make([]*fileCheckpoint, 0, len(states))
+	for _, state := range states {
+		cp, err := newCheckpoint(state, *configName, now)
+		if err != nil {
+			fmt.Println("skip:", state.Source, "offset:", state.Offset, "error:", err)
+			continue
+		}
+		fmt.Println("import:", cp.FileName, "real file:", cp.RealFileName, "offset:", cp.Offset, "dev:", cp.Dev, "inode:", cp.Inode)
+		checkpoints = append(checkpoints, cp)
+	}
+	fmt.Printf("%d of %d file states are imported\n", len(checkpoints), len(states))
+	if *dryRun || len(checkpoints) == 0 {
+		return nil
+	}
+	if err = mergeCheckpoints(*checkpointFile, checkpoints); err != nil {
+		return err
+	}
+	fmt.Println("checkpoint file is written:", *checkpointFile)
+	return nil
+}
+
+func convertConfig() error {
+	inputs, err := loadFilebeatInputs(*filebeatConfigFile)
+	if err != nil {
+		return err
+	}
+	configs, warnings := convertInputs(inputs)
+	for _, warning := range warnings {
+		fmt.Println("warning:", warning)
+	}
+	for _, c := range configs {
+		input := c.Config.Inputs[0]
+		fmt.Println("config:", c.Name, "LogPath:", input.LogPath, "FilePattern:", input.FilePattern, "MaxDepth:", input.MaxDepth)
+		for _, warning := range c.Warnings {
+			fmt.Println("  warning:", warning)
+		}
+	}
+	if *dryRun || len(configs) == 0 {
+		return nil
+	}
+	if err = writeConfigs(*outputDir, configs); err != nil {
+		return err
+	}
+	fmt.Println("configs are written:", *outputDir)
+	return nil
+}
@@ -0,0 +1,255 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// fileState is the read progress of a file in the registry of filebeat.
+type fileState struct {
+	Key    string
+	Source string
+	Offset int64
+	// HasDevInode is false for the states identified by the path or the fingerprint, whose device and inode are
+	// got from the file of Source.
+	HasDevInode bool
+	Device      uint64
+	Inode       uint64
+}
+
+// registryValue is the value of a state, which is the state of the log input, or the cursor of the filestream input.
+type registryValue struct {
+	Source      string `json:"source"`
+	Offset      int64  `json:"offset"`
+	TTL         *int64 `json:"ttl"`
+	FileStateOS *struct {
+		Inode  uint64 `json:"inode"`
+		Device uint64 `json:"device"`
+	} `json:"FileStateOS"`
+	Cursor *struct {
+		Offset int64 `json:"offset"`
+	} `json:"cursor"`
+	Meta *struct {
+		Source string `json:"source"`
+	} `json:"meta"`
+}
+
+// snapshotEntry is an entry of the checkpoint file of the registry, i.e. the value with the key.
+type snapshotEntry struct {
+	Key string `json:"_key"`
+	registryValue
+}
+
+// logOp is the operation line of log.json.
+type logOp struct {
+	Op string `json:"op"`
+	ID uint64 `json:"id"`
+}
+
+type logEntry struct {
+	Key   string          `json:"k"`
+	Value json.RawMessage `json:"v"`
+}
+
+// loadRegistry loads the file states from @path, which is the registry directory of filebeat 7.x and later, such as
+// data/registry/filebeat, or the registry file of filebeat 6.x, such as data/registry. The states are sorted by the
+// sources.
+func loadRegistry(path string) ([]fileState, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]registryValue)
+	if !info.IsDir() {
+		// the legacy registry file is an array of the states without the keys
+		var entries []snapshotEntry
+		if err = readJSONFile(path, &entries); err != nil {
+			return nil, err
+		}
+		addEntries(values, entries)
+	} else {
+		if err = loadSnapshot(path, values); err != nil {
+			return nil, err
+		}
+		if err = replayLog(filepath.Join(path, "log.json"), values); err != nil {
+			return nil, err
+		}
+	}
+
+	states := make([]fileState, 0, len(values))
+	for key, value := range values {
+		if state, ok := toFileState(key, value); ok {
+			states = append(states, state)
+		}
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].Source == states[j].Source {
+			return states[i].Key < states[j].Key
+		}
+		return states[i].Source < states[j].Source
+	})
+	return states, nil
+}
+
+// loadSnapshot loads the checkpoint file pointed by active.dat, or data.json of filebeat 7.0 to 7.8. The registry
+// without any checkpoint has only log.json.
+func loadSnapshot(dir string, values map[string]registryValue) error {
+	snapshot := filepath.Join(dir, "data.json")
+	active, err := os.ReadFile(filepath.Join(dir, "active.dat")) //nolint:gosec
+	switch {
+	case err == nil:
+		snapshot = strings.TrimSpace(string(active))
+		// active.dat keeps the absolute path, which changes when the registry is copied to another place
+		if _, err = os.Stat(snapshot); err != nil {
+			snapshot = filepath.Join(dir, filepath.Base(snapshot))
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+	if _, err = os.Stat(snapshot); os.IsNotExist(err) {
+		return nil
+	}
+	var entries []snapshotEntry
+	if err = readJSONFile(snapshot, &entries); err != nil {
+		return err
+	}
+	addEntries(values, entries)
+	return nil
+}
+
+// addEntries adds the entries of a checkpoint file, the entries of the legacy registry without the keys are keyed
+// by the indexes.
+func addEntries(values map[string]registryValue, entries []snapshotEntry) {
+	for i, entry := range entries {
+		if entry.Key == "" {
+			entry.Key = strconv.Itoa(i)
+		}
+		values[entry.Key] = entry.registryValue
+	}
+}
+
+// replayLog applies the operations in log.json written after the checkpoint file. Each operation is a line of the
+// op followed by a line of the key and the value.
+func replayLog(path string, values map[string]registryValue) error {
+	f, err := os.Open(path) //nolint:gosec
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint:errcheck
+	var lines [][]byte
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			lines = append(lines, append([]byte(nil), line...))
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(lines); i += 2 {
+		var op logOp
+		var entry logEntry
+		if err = json.Unmarshal(lines[i], &op); err == nil {
+			err = json.Unmarshal(lines[i+1], &entry)
+		}
+		if err != nil {
+			// the last operation is truncated when filebeat is killed while writing
+			if i+2 >= len(lines) {
+				return nil
+			}
+			return fmt.Errorf("invalid operation %d of %s: %v", i/2+1, path, err)
+		}
+		switch op.Op {
+		case "set":
+			var value registryValue
+			if err = json.Unmarshal(entry.Value, &value); err != nil {
+				return fmt.Errorf("invalid value of %s in %s: %v", entry.Key, path, err)
+			}
+			values[entry.Key] = value
+		case "remove":
+			delete(values, entry.Key)
+		}
+	}
+	return nil
+}
+
+// toFileState converts the value of the log input or the filestream input, the removed states are skipped.
+func toFileState(key string, value registryValue) (fileState, bool) {
+	// the states with ttl 0 are waiting to be removed
+	if value.TTL != nil && *value.TTL == 0 {
+		return fileState{}, false
+	}
+	state := fileState{Key: key, Source: value.Source, Offset: value.Offset}
+	if value.Cursor != nil {
+		state.Offset = value.Cursor.Offset
+	}
+	if state.Source == "" && value.Meta != nil {
+		state.Source = value.Meta.Source
+	}
+	if state.Source == "" {
+		return fileState{}, false
+	}
+	if value.FileStateOS != nil && value.FileStateOS.Inode != 0 {
+		state.HasDevInode = true
+		state.Inode, state.Device = value.FileStateOS.Inode, value.FileStateOS.Device
+	} else if inode, device, ok := parseNativeKey(key); ok {
+		state.HasDevInode = true
+		state.Inode, state.Device = inode, device
+	}
+	return state, true
+}
+
+// parseNativeKey parses the inode and the device of the key identified natively, such as
+// filestream::my-input::native::1234-2049.
+func parseNativeKey(key string) (uint64, uint64, bool) {
+	i := strings.LastIndex(key, "::native::")
+	if i < 0 {
+		return 0, 0, false
+	}
+	inodeStr, deviceStr, ok := strings.Cut(key[i+len("::native::"):], "-")
+	if !ok {
+		return 0, 0, false
+	}
+	inode, err1 := strconv.ParseUint(inodeStr, 10, 64)
+	device, err2 := strconv.ParseUint(deviceStr, 10, 64)
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	return inode, device, true
+}
+
+func readJSONFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("decode %s error: %v", path, err)
+	}
+	return nil
+}
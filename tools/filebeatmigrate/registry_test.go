@@ -0,0 +1,77 @@
+// Copyright 2023 iLogtail Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadLegacyRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+{"source":"/var/log/b.log","offset":20,"FileStateOS":{"inode":2,"device":2049},"ttl":-1},
+{"source":"/var/log/a.log","offset":10,"FileStateOS":{"inode":1,"device":2049},"ttl":-1},
+{"source":"/var/log/c.log","offset":30,"FileStateOS":{"inode":3,"device":2049},"ttl":0}
+]`), 0o600))
+
+	states, err := loadRegistry(path)
+	require.NoError(t, err)
+	assert.Equal(t, []fileState{
+		{Key: "1", Source: "/var/log/a.log", Offset: 10, HasDevInode: true, Device: 2049, Inode: 1},
+		{Key: "0", Source: "/var/log/b.log", Offset: 20, HasDevInode: true, Device: 2049, Inode: 2},
+	}, states)
+}
+
+func TestLoadRegistry(t *testing.T) {
+	dir := t.TempDir()
+	// active.dat points to the snapshot in the original place of the registry
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "active.dat"), []byte("/usr/share/filebeat/data/registry/filebeat/5.json"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "5.json"), []byte(`[
+{"_key":"filebeat::logs::native::1-2049","source":"/var/log/a.log","offset":10,"FileStateOS":{"inode":1,"device":2049},"ttl":-1},
+{"_key":"filebeat::logs::native::2-2049","source":"/var/log/b.log","offset":20,"FileStateOS":{"inode":2,"device":2049},"ttl":-1}
+]`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "log.json"), []byte(`{"op":"set","id":6}
+{"k":"filebeat::logs::native::1-2049","v":{"source":"/var/log/a.log","offset":15,"FileStateOS":{"inode":1,"device":2049},"ttl":-1}}
+{"op":"remove","id":7}
+{"k":"filebeat::logs::native::2-2049"}
+{"op":"set","id":8}
+{"k":"filestream::my-input::native::3-2049","v":{"cursor":{"offset":30},"meta":{"source":"/var/log/c.log","identifier_name":"native"},"ttl":1800000000000}}
+{"op":"set","id":9}
+{"k":"filestream::my-input::native::4-20
+`), 0o600))
+
+	states, err := loadRegistry(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []fileState{
+		{Key: "filebeat::logs::native::1-2049", Source: "/var/log/a.log", Offset: 15, HasDevInode: true, Device: 2049, Inode: 1},
+		{Key: "filestream::my-input::native::3-2049", Source: "/var/log/c.log", Offset: 30, HasDevInode: true, Device: 2049, Inode: 3},
+	}, states)
+}
+
+func TestLoadRegistryWithoutSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "log.json"), []byte(`{"op":"set","id":1}
+{"k":"filestream::my-input::path::/var/log/a.log","v":{"cursor":{"offset":5},"meta":{"source":"/var/log/a.log","identifier_name":"path"},"ttl":-1}}
+`), 0o600))
+
+	states, err := loadRegistry(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []fileState{{Key: "filestream::my-input::path::/var/log/a.log", Source: "/var/log/a.log", Offset: 5}}, states)
+}